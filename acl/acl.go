@@ -107,6 +107,20 @@ type Authorizer interface {
 	// functions can be used.
 	OperatorWrite() bool
 
+	// OperatorRaftRead determines if the Raft configuration can be read.
+	OperatorRaftRead() bool
+
+	// OperatorRaftWrite determines if the Raft peer set can be modified.
+	OperatorRaftWrite() bool
+
+	// OperatorAutopilotRead determines if the Autopilot configuration and
+	// server health can be read.
+	OperatorAutopilotRead() bool
+
+	// OperatorAutopilotWrite determines if the Autopilot configuration can
+	// be modified.
+	OperatorAutopilotWrite() bool
+
 	// PreparedQueryRead determines if a specific prepared query can be read
 	// to show its contents (this is not used for execution).
 	PreparedQueryRead(string) bool
@@ -217,6 +231,22 @@ func (s *StaticAuthorizer) OperatorWrite() bool {
 	return s.defaultAllow
 }
 
+func (s *StaticAuthorizer) OperatorRaftRead() bool {
+	return s.defaultAllow
+}
+
+func (s *StaticAuthorizer) OperatorRaftWrite() bool {
+	return s.defaultAllow
+}
+
+func (s *StaticAuthorizer) OperatorAutopilotRead() bool {
+	return s.defaultAllow
+}
+
+func (s *StaticAuthorizer) OperatorAutopilotWrite() bool {
+	return s.defaultAllow
+}
+
 func (s *StaticAuthorizer) PreparedQueryRead(string) bool {
 	return s.defaultAllow
 }
@@ -330,6 +360,12 @@ type PolicyAuthorizer struct {
 
 	// operatorRule contains the operator policies.
 	operatorRule string
+
+	// operatorRaftRule and operatorAutopilotRule contain the policies for
+	// the operator sub-resources. They are combined with operatorRule, see
+	// enforceSubResource.
+	operatorRaftRule      string
+	operatorAutopilotRule string
 }
 
 // policyAuthorizerRadixLeaf is used as the main
@@ -406,6 +442,25 @@ func enforce(rule string, requiredPermission string) (allow, recurse bool) {
 	}
 }
 
+// enforceSubResource is like enforce for a sub-resource rule whose parent
+// resource rule applies as well. The more permissive of the two rules is
+// used, but an explicit denial in either of them always denies.
+func enforceSubResource(rule, parentRule string, requiredPermission string) (allow, recurse bool) {
+	if rule == PolicyDeny || parentRule == PolicyDeny {
+		return false, false
+	}
+	allow, recurse = enforce(rule, requiredPermission)
+	if allow {
+		return true, false
+	}
+	parentAllow, parentRecurse := enforce(parentRule, requiredPermission)
+	if parentAllow {
+		return true, false
+	}
+	// only recurse if neither rule was set
+	return false, recurse && parentRecurse
+}
+
 // NewPolicyAuthorizer is used to construct a policy based ACL from a set of policies
 // and a parent policy to resolve missing cases.
 func NewPolicyAuthorizer(parent Authorizer, policies []*Policy, sentinel sentinel.Evaluator) (*PolicyAuthorizer, error) {
@@ -556,8 +611,10 @@ func NewPolicyAuthorizer(parent Authorizer, policies []*Policy, sentinel sentine
 	// Load the keyring policy
 	p.keyringRule = policy.Keyring
 
-	// Load the operator policy
+	// Load the operator policies
 	p.operatorRule = policy.Operator
+	p.operatorRaftRule = policy.OperatorRaft
+	p.operatorAutopilotRule = policy.OperatorAutopilot
 
 	return p, nil
 }
@@ -830,6 +887,47 @@ func (p *PolicyAuthorizer) OperatorWrite() bool {
 	return p.parent.OperatorWrite()
 }
 
+// OperatorRaftRead determines if the Raft configuration can be read, by
+// either the operator_raft or the operator policy.
+func (p *PolicyAuthorizer) OperatorRaftRead() bool {
+	if allow, recurse := enforceSubResource(p.operatorRaftRule, p.operatorRule, PolicyRead); !recurse {
+		return allow
+	}
+
+	return p.parent.OperatorRaftRead()
+}
+
+// OperatorRaftWrite determines if the Raft peer set can be modified, by
+// either the operator_raft or the operator policy.
+func (p *PolicyAuthorizer) OperatorRaftWrite() bool {
+	if allow, recurse := enforceSubResource(p.operatorRaftRule, p.operatorRule, PolicyWrite); !recurse {
+		return allow
+	}
+
+	return p.parent.OperatorRaftWrite()
+}
+
+// OperatorAutopilotRead determines if the Autopilot configuration and server
+// health can be read, by either the operator_autopilot or the operator
+// policy.
+func (p *PolicyAuthorizer) OperatorAutopilotRead() bool {
+	if allow, recurse := enforceSubResource(p.operatorAutopilotRule, p.operatorRule, PolicyRead); !recurse {
+		return allow
+	}
+
+	return p.parent.OperatorAutopilotRead()
+}
+
+// OperatorAutopilotWrite determines if the Autopilot configuration can be
+// modified, by either the operator_autopilot or the operator policy.
+func (p *PolicyAuthorizer) OperatorAutopilotWrite() bool {
+	if allow, recurse := enforceSubResource(p.operatorAutopilotRule, p.operatorRule, PolicyWrite); !recurse {
+		return allow
+	}
+
+	return p.parent.OperatorAutopilotWrite()
+}
+
 // NodeRead checks if reading (discovery) of a node is allowed
 func (p *PolicyAuthorizer) NodeRead(name string) bool {
 	// Check for an exact rule or catch-all
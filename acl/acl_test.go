@@ -110,6 +110,22 @@ func checkAllowOperatorWrite(t *testing.T, authz Authorizer, prefix string) {
 	require.True(t, authz.OperatorWrite())
 }
 
+func checkAllowOperatorRaftRead(t *testing.T, authz Authorizer, prefix string) {
+	require.True(t, authz.OperatorRaftRead())
+}
+
+func checkAllowOperatorRaftWrite(t *testing.T, authz Authorizer, prefix string) {
+	require.True(t, authz.OperatorRaftWrite())
+}
+
+func checkAllowOperatorAutopilotRead(t *testing.T, authz Authorizer, prefix string) {
+	require.True(t, authz.OperatorAutopilotRead())
+}
+
+func checkAllowOperatorAutopilotWrite(t *testing.T, authz Authorizer, prefix string) {
+	require.True(t, authz.OperatorAutopilotWrite())
+}
+
 func checkAllowPreparedQueryRead(t *testing.T, authz Authorizer, prefix string) {
 	require.True(t, authz.PreparedQueryRead(prefix))
 }
@@ -214,6 +230,22 @@ func checkDenyOperatorWrite(t *testing.T, authz Authorizer, prefix string) {
 	require.False(t, authz.OperatorWrite())
 }
 
+func checkDenyOperatorRaftRead(t *testing.T, authz Authorizer, prefix string) {
+	require.False(t, authz.OperatorRaftRead())
+}
+
+func checkDenyOperatorRaftWrite(t *testing.T, authz Authorizer, prefix string) {
+	require.False(t, authz.OperatorRaftWrite())
+}
+
+func checkDenyOperatorAutopilotRead(t *testing.T, authz Authorizer, prefix string) {
+	require.False(t, authz.OperatorAutopilotRead())
+}
+
+func checkDenyOperatorAutopilotWrite(t *testing.T, authz Authorizer, prefix string) {
+	require.False(t, authz.OperatorAutopilotWrite())
+}
+
 func checkDenyPreparedQueryRead(t *testing.T, authz Authorizer, prefix string) {
 	require.False(t, authz.PreparedQueryRead(prefix))
 }
@@ -278,6 +310,10 @@ func TestACL(t *testing.T) {
 				{name: "DenyNodeWrite", check: checkDenyNodeWrite},
 				{name: "DenyOperatorRead", check: checkDenyOperatorRead},
 				{name: "DenyOperatorWrite", check: checkDenyOperatorWrite},
+				{name: "DenyOperatorRaftRead", check: checkDenyOperatorRaftRead},
+				{name: "DenyOperatorRaftWrite", check: checkDenyOperatorRaftWrite},
+				{name: "DenyOperatorAutopilotRead", check: checkDenyOperatorAutopilotRead},
+				{name: "DenyOperatorAutopilotWrite", check: checkDenyOperatorAutopilotWrite},
 				{name: "DenyPreparedQueryRead", check: checkDenyPreparedQueryRead},
 				{name: "DenyPreparedQueryWrite", check: checkDenyPreparedQueryWrite},
 				{name: "DenyServiceRead", check: checkDenyServiceRead},
@@ -308,6 +344,10 @@ func TestACL(t *testing.T) {
 				{name: "AllowNodeWrite", check: checkAllowNodeWrite},
 				{name: "AllowOperatorRead", check: checkAllowOperatorRead},
 				{name: "AllowOperatorWrite", check: checkAllowOperatorWrite},
+				{name: "AllowOperatorRaftRead", check: checkAllowOperatorRaftRead},
+				{name: "AllowOperatorRaftWrite", check: checkAllowOperatorRaftWrite},
+				{name: "AllowOperatorAutopilotRead", check: checkAllowOperatorAutopilotRead},
+				{name: "AllowOperatorAutopilotWrite", check: checkAllowOperatorAutopilotWrite},
 				{name: "AllowPreparedQueryRead", check: checkAllowPreparedQueryRead},
 				{name: "AllowPreparedQueryWrite", check: checkAllowPreparedQueryWrite},
 				{name: "AllowServiceRead", check: checkAllowServiceRead},
@@ -338,6 +378,10 @@ func TestACL(t *testing.T) {
 				{name: "AllowNodeWrite", check: checkAllowNodeWrite},
 				{name: "AllowOperatorRead", check: checkAllowOperatorRead},
 				{name: "AllowOperatorWrite", check: checkAllowOperatorWrite},
+				{name: "AllowOperatorRaftRead", check: checkAllowOperatorRaftRead},
+				{name: "AllowOperatorRaftWrite", check: checkAllowOperatorRaftWrite},
+				{name: "AllowOperatorAutopilotRead", check: checkAllowOperatorAutopilotRead},
+				{name: "AllowOperatorAutopilotWrite", check: checkAllowOperatorAutopilotWrite},
 				{name: "AllowPreparedQueryRead", check: checkAllowPreparedQueryRead},
 				{name: "AllowPreparedQueryWrite", check: checkAllowPreparedQueryWrite},
 				{name: "AllowServiceRead", check: checkAllowServiceRead},
@@ -801,6 +845,71 @@ func TestACL(t *testing.T) {
 				{name: "WriteDenied", check: checkDenyOperatorWrite},
 			},
 		},
+		{
+			name:          "OperatorRaftDefaultDenyPolicyRead",
+			defaultPolicy: DenyAll(),
+			policyStack: []*Policy{
+				&Policy{
+					OperatorRaft: PolicyRead,
+				},
+			},
+			checks: []aclCheck{
+				{name: "OperatorReadDenied", check: checkDenyOperatorRead},
+				{name: "RaftReadAllowed", check: checkAllowOperatorRaftRead},
+				{name: "RaftWriteDenied", check: checkDenyOperatorRaftWrite},
+				{name: "AutopilotReadDenied", check: checkDenyOperatorAutopilotRead},
+				{name: "KeyringReadDenied", check: checkDenyKeyringRead},
+				{name: "KeyringWriteDenied", check: checkDenyKeyringWrite},
+			},
+		},
+		{
+			name:          "OperatorSubResourcesDefaultDenyOperatorRead",
+			defaultPolicy: DenyAll(),
+			policyStack: []*Policy{
+				&Policy{
+					Operator: PolicyRead,
+				},
+			},
+			checks: []aclCheck{
+				{name: "RaftReadAllowed", check: checkAllowOperatorRaftRead},
+				{name: "RaftWriteDenied", check: checkDenyOperatorRaftWrite},
+				{name: "AutopilotReadAllowed", check: checkAllowOperatorAutopilotRead},
+				{name: "AutopilotWriteDenied", check: checkDenyOperatorAutopilotWrite},
+			},
+		},
+		{
+			name:          "OperatorSubResourcesCombineWithOperator",
+			defaultPolicy: AllowAll(),
+			policyStack: []*Policy{
+				&Policy{
+					Operator:          PolicyWrite,
+					OperatorRaft:      PolicyDeny,
+					OperatorAutopilot: PolicyRead,
+				},
+			},
+			checks: []aclCheck{
+				{name: "OperatorWriteAllowed", check: checkAllowOperatorWrite},
+				{name: "RaftReadDenied", check: checkDenyOperatorRaftRead},
+				{name: "RaftWriteDenied", check: checkDenyOperatorRaftWrite},
+				{name: "AutopilotReadAllowed", check: checkAllowOperatorAutopilotRead},
+				{name: "AutopilotWriteAllowed", check: checkAllowOperatorAutopilotWrite},
+			},
+		},
+		{
+			name:          "OperatorDenyOverridesSubResources",
+			defaultPolicy: AllowAll(),
+			policyStack: []*Policy{
+				&Policy{
+					Operator:     PolicyDeny,
+					OperatorRaft: PolicyWrite,
+				},
+			},
+			checks: []aclCheck{
+				{name: "RaftReadDenied", check: checkDenyOperatorRaftRead},
+				{name: "RaftWriteDenied", check: checkDenyOperatorRaftWrite},
+				{name: "AutopilotReadDenied", check: checkDenyOperatorAutopilotRead},
+			},
+		},
 		{
 			name:          "NodeDefaultDeny",
 			defaultPolicy: DenyAll(),
@@ -1728,6 +1837,35 @@ func TestACL(t *testing.T) {
 	}
 }
 
+func TestACL_MergedOperatorSubResources(t *testing.T) {
+	// A token with one policy granting operator write and another granting
+	// only operator_raft read keeps the write access to Raft.
+	authz, err := NewPolicyAuthorizer(DenyAll(), []*Policy{
+		&Policy{Operator: PolicyWrite},
+		&Policy{OperatorRaft: PolicyRead},
+		&Policy{OperatorAutopilot: PolicyDeny},
+	}, nil)
+	require.NoError(t, err)
+
+	require.True(t, authz.OperatorWrite())
+	require.True(t, authz.OperatorRaftRead())
+	require.True(t, authz.OperatorRaftWrite())
+	require.False(t, authz.OperatorAutopilotRead())
+	require.False(t, authz.OperatorAutopilotWrite())
+
+	// A read-only operator policy still grants read to every sub-resource.
+	authz, err = NewPolicyAuthorizer(DenyAll(), []*Policy{
+		&Policy{Operator: PolicyRead},
+		&Policy{OperatorRaft: PolicyWrite},
+	}, nil)
+	require.NoError(t, err)
+
+	require.False(t, authz.OperatorWrite())
+	require.True(t, authz.OperatorRaftWrite())
+	require.True(t, authz.OperatorAutopilotRead())
+	require.False(t, authz.OperatorAutopilotWrite())
+}
+
 func TestRootAuthorizer(t *testing.T) {
 	require.Equal(t, AllowAll(), RootAuthorizer("allow"))
 	require.Equal(t, DenyAll(), RootAuthorizer("deny"))
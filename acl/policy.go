@@ -48,6 +48,8 @@ type Policy struct {
 	PreparedQueryPrefixes []*PreparedQueryPolicy `hcl:"query_prefix,expand"`
 	Keyring               string                 `hcl:"keyring"`
 	Operator              string                 `hcl:"operator"`
+	OperatorRaft          string                 `hcl:"operator_raft"`
+	OperatorAutopilot     string                 `hcl:"operator_autopilot"`
 }
 
 // Sentinel defines a snippet of Sentinel code that can be attached to a policy.
@@ -306,6 +308,15 @@ func parseCurrent(rules string, sentinel sentinel.Evaluator) (*Policy, error) {
 		return nil, fmt.Errorf("Invalid operator policy: %#v", p.Operator)
 	}
 
+	// Validate the operator sub-resource policies - these are allowed to be
+	// empty, in which case the operator policy applies
+	if p.OperatorRaft != "" && !isPolicyValid(p.OperatorRaft) {
+		return nil, fmt.Errorf("Invalid operator_raft policy: %#v", p.OperatorRaft)
+	}
+	if p.OperatorAutopilot != "" && !isPolicyValid(p.OperatorAutopilot) {
+		return nil, fmt.Errorf("Invalid operator_autopilot policy: %#v", p.OperatorAutopilot)
+	}
+
 	return p, nil
 }
 
@@ -451,11 +462,13 @@ func NewPolicyFromSource(id string, revision uint64, rules string, syntax Syntax
 
 func (policy *Policy) ConvertToLegacy() *Policy {
 	converted := &Policy{
-		ID:       policy.ID,
-		Revision: policy.Revision,
-		ACL:      policy.ACL,
-		Keyring:  policy.Keyring,
-		Operator: policy.Operator,
+		ID:                policy.ID,
+		Revision:          policy.Revision,
+		ACL:               policy.ACL,
+		Keyring:           policy.Keyring,
+		Operator:          policy.Operator,
+		OperatorRaft:      policy.OperatorRaft,
+		OperatorAutopilot: policy.OperatorAutopilot,
 	}
 
 	converted.Agents = append(converted.Agents, policy.Agents...)
@@ -488,7 +501,63 @@ func (policy *Policy) ConvertFromLegacy() *Policy {
 		PreparedQueryPrefixes: policy.PreparedQueries,
 		Keyring:               policy.Keyring,
 		Operator:              policy.Operator,
+		OperatorRaft:          policy.OperatorRaft,
+		OperatorAutopilot:     policy.OperatorAutopilot,
+	}
+}
+
+// GrantsWrite returns true if any rule of the policy grants write access.
+func (policy *Policy) GrantsWrite() bool {
+	rules := []string{policy.ACL, policy.Keyring, policy.Operator, policy.OperatorRaft, policy.OperatorAutopilot}
+	for _, ap := range policy.Agents {
+		rules = append(rules, ap.Policy)
+	}
+	for _, ap := range policy.AgentPrefixes {
+		rules = append(rules, ap.Policy)
+	}
+	for _, kp := range policy.Keys {
+		rules = append(rules, kp.Policy)
+	}
+	for _, kp := range policy.KeyPrefixes {
+		rules = append(rules, kp.Policy)
+	}
+	for _, np := range policy.Nodes {
+		rules = append(rules, np.Policy)
+	}
+	for _, np := range policy.NodePrefixes {
+		rules = append(rules, np.Policy)
+	}
+	for _, sp := range policy.Services {
+		rules = append(rules, sp.Policy, sp.Intentions)
+	}
+	for _, sp := range policy.ServicePrefixes {
+		rules = append(rules, sp.Policy, sp.Intentions)
+	}
+	for _, sp := range policy.Sessions {
+		rules = append(rules, sp.Policy)
 	}
+	for _, sp := range policy.SessionPrefixes {
+		rules = append(rules, sp.Policy)
+	}
+	for _, ep := range policy.Events {
+		rules = append(rules, ep.Policy)
+	}
+	for _, ep := range policy.EventPrefixes {
+		rules = append(rules, ep.Policy)
+	}
+	for _, qp := range policy.PreparedQueries {
+		rules = append(rules, qp.Policy)
+	}
+	for _, qp := range policy.PreparedQueryPrefixes {
+		rules = append(rules, qp.Policy)
+	}
+
+	for _, rule := range rules {
+		if rule == PolicyWrite {
+			return true
+		}
+	}
+	return false
 }
 
 // takesPrecedenceOver returns true when permission a
@@ -553,6 +622,8 @@ func MergePolicies(policies []*Policy) *Policy {
 	nodePolicies := make(map[string]*NodePolicy)
 	nodePrefixPolicies := make(map[string]*NodePolicy)
 	operatorPolicy := ""
+	operatorRaftPolicy := ""
+	operatorAutopilotPolicy := ""
 	preparedQueryPolicies := make(map[string]*PreparedQueryPolicy)
 	preparedQueryPrefixPolicies := make(map[string]*PreparedQueryPolicy)
 	servicePolicies := make(map[string]*ServicePolicy)
@@ -663,6 +734,14 @@ func MergePolicies(policies []*Policy) *Policy {
 			operatorPolicy = policy.Operator
 		}
 
+		if takesPrecedenceOver(policy.OperatorRaft, operatorRaftPolicy) {
+			operatorRaftPolicy = policy.OperatorRaft
+		}
+
+		if takesPrecedenceOver(policy.OperatorAutopilot, operatorAutopilotPolicy) {
+			operatorAutopilotPolicy = policy.OperatorAutopilot
+		}
+
 		for _, qp := range policy.PreparedQueries {
 			update := true
 			if permission, found := preparedQueryPolicies[qp.Prefix]; found {
@@ -744,7 +823,13 @@ func MergePolicies(policies []*Policy) *Policy {
 		}
 	}
 
-	merged := &Policy{ACL: aclPolicy, Keyring: keyringPolicy, Operator: operatorPolicy}
+	merged := &Policy{
+		ACL:               aclPolicy,
+		Keyring:           keyringPolicy,
+		Operator:          operatorPolicy,
+		OperatorRaft:      operatorRaftPolicy,
+		OperatorAutopilot: operatorAutopilotPolicy,
+	}
 
 	// All the for loop appends are ugly but Go doesn't have a way to get
 	// a slice of all values within a map so this is necessary
@@ -539,6 +539,27 @@ func TestPolicySourceParse(t *testing.T) {
 			&Policy{Operator: ""},
 			"",
 		},
+		{
+			"Operator Sub-Resources",
+			SyntaxCurrent,
+			`operator = "deny" operator_raft = "read" operator_autopilot = "write"`,
+			&Policy{Operator: PolicyDeny, OperatorRaft: PolicyRead, OperatorAutopilot: PolicyWrite},
+			"",
+		},
+		{
+			"Bad Policy - Operator Raft",
+			SyntaxCurrent,
+			`operator_raft = "nope"`,
+			nil,
+			"Invalid operator_raft policy",
+		},
+		{
+			"Bad Policy - Operator Autopilot",
+			SyntaxCurrent,
+			`operator_autopilot = "nope"`,
+			nil,
+			"Invalid operator_autopilot policy",
+		},
 	}
 
 	for _, tc := range cases {
@@ -1235,20 +1256,23 @@ func TestMergePolicies(t *testing.T) {
 			name: "Deny Precedence",
 			input: []*Policy{
 				&Policy{
-					ACL:      PolicyWrite,
-					Keyring:  PolicyWrite,
-					Operator: PolicyWrite,
+					ACL:          PolicyWrite,
+					Keyring:      PolicyWrite,
+					Operator:     PolicyWrite,
+					OperatorRaft: PolicyRead,
 				},
 				&Policy{
-					ACL:      PolicyDeny,
-					Keyring:  PolicyDeny,
-					Operator: PolicyDeny,
+					ACL:          PolicyDeny,
+					Keyring:      PolicyDeny,
+					Operator:     PolicyDeny,
+					OperatorRaft: PolicyDeny,
 				},
 			},
 			expected: &Policy{
-				ACL:      PolicyDeny,
-				Keyring:  PolicyDeny,
-				Operator: PolicyDeny,
+				ACL:          PolicyDeny,
+				Keyring:      PolicyDeny,
+				Operator:     PolicyDeny,
+				OperatorRaft: PolicyDeny,
 			},
 		},
 		{
@@ -1278,6 +1302,8 @@ func TestMergePolicies(t *testing.T) {
 			req.Equal(exp.ACL, act.ACL)
 			req.Equal(exp.Keyring, act.Keyring)
 			req.Equal(exp.Operator, act.Operator)
+			req.Equal(exp.OperatorRaft, act.OperatorRaft)
+			req.Equal(exp.OperatorAutopilot, act.OperatorAutopilot)
 			req.ElementsMatch(exp.Agents, act.Agents)
 			req.ElementsMatch(exp.AgentPrefixes, act.AgentPrefixes)
 			req.ElementsMatch(exp.Events, act.Events)
@@ -1544,3 +1570,29 @@ func TestPrecedence(t *testing.T) {
 		})
 	}
 }
+
+func TestPolicyGrantsWrite(t *testing.T) {
+	cases := []struct {
+		name     string
+		rules    string
+		expected bool
+	}{
+		{"Empty", ``, false},
+		{"Read Only", `node_prefix "" { policy = "read" } service_prefix "" { policy = "read" } operator = "read"`, false},
+		{"List", `key_prefix "" { policy = "list" }`, false},
+		{"Node Write", `node "foo" { policy = "write" }`, true},
+		{"Service Prefix Write", `service_prefix "" { policy = "write" }`, true},
+		{"Intentions Write", `service "foo" { policy = "read" intentions = "write" }`, true},
+		{"ACL Write", `acl = "write"`, true},
+		{"Operator Raft Write", `operator_raft = "write"`, true},
+		{"Query Write", `query_prefix "" { policy = "write" }`, true},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.name, func(t *testing.T) {
+			policy, err := NewPolicyFromSource("", 0, tcase.rules, SyntaxCurrent, nil)
+			require.NoError(t, err)
+			require.Equal(t, tcase.expected, policy.GrantsWrite())
+		})
+	}
+}
@@ -300,6 +300,8 @@ func (s *HTTPServer) ACLPolicyWrite(resp http.ResponseWriter, req *http.Request,
 		Datacenter: s.agent.config.Datacenter,
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	if err := decodeBody(req, &args.Policy, fixCreateTimeAndHash); err != nil {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Policy decoding failed: %v", err)}
@@ -327,6 +329,8 @@ func (s *HTTPServer) ACLPolicyDelete(resp http.ResponseWriter, req *http.Request
 		PolicyID:   policyID,
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	var ignored string
 	if err := s.agent.RPC("ACL.PolicyDelete", args, &ignored); err != nil {
@@ -397,6 +401,47 @@ func (s *HTTPServer) ACLTokenCRUD(resp http.ResponseWriter, req *http.Request) (
 	return fn(resp, req, tokenID)
 }
 
+func (s *HTTPServer) ACLAnonymous(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	switch req.Method {
+	case "GET":
+		return s.ACLTokenGet(resp, req, structs.ACLTokenAnonymousID)
+
+	case "PUT":
+		return s.ACLAnonymousPoliciesSet(resp, req)
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT"}}
+	}
+}
+
+func (s *HTTPServer) ACLAnonymousPoliciesSet(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ACLAnonymousPoliciesSetRequest{
+		Datacenter: s.agent.config.Datacenter,
+	}
+	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
+
+	var body struct {
+		Policies []structs.ACLTokenPolicyLink
+	}
+	if err := decodeBody(req, &body, nil); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Policies decoding failed: %v", err)}
+	}
+	args.Policies = body.Policies
+
+	var out structs.ACLToken
+	if err := s.agent.RPC("ACL.AnonymousPoliciesSet", args, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
 func (s *HTTPServer) ACLTokenSelf(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.checkACLDisabled(resp, req) {
 		return nil, nil
@@ -471,6 +516,8 @@ func (s *HTTPServer) ACLTokenSet(resp http.ResponseWriter, req *http.Request, to
 		Datacenter: s.agent.config.Datacenter,
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	if err := decodeBody(req, &args.ACLToken, fixCreateTimeAndHash); err != nil {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Token decoding failed: %v", err)}
@@ -496,6 +543,8 @@ func (s *HTTPServer) ACLTokenDelete(resp http.ResponseWriter, req *http.Request,
 		TokenID:    tokenID,
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	var ignored string
 	if err := s.agent.RPC("ACL.TokenDelete", args, &ignored); err != nil {
@@ -517,6 +566,8 @@ func (s *HTTPServer) ACLTokenClone(resp http.ResponseWriter, req *http.Request,
 		return nil, BadRequestError{Reason: fmt.Sprintf("Token decoding failed: %v", err)}
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	// Set this for the ID to clone
 	args.ACLToken.AccessorID = tokenID
@@ -23,6 +23,8 @@ func (s *HTTPServer) ACLDestroy(resp http.ResponseWriter, req *http.Request) (in
 		Op:         structs.ACLDelete,
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	// Pull out the acl id
 	args.ACL.ID = strings.TrimPrefix(req.URL.Path, "/v1/acl/destroy/")
@@ -62,6 +64,8 @@ func (s *HTTPServer) aclSet(resp http.ResponseWriter, req *http.Request, update
 		},
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	// Handle optional request body
 	if req.ContentLength > 0 {
@@ -28,6 +28,7 @@ func TestACL_Disabled_Response(t *testing.T) {
 	}
 
 	tests := []testCase{
+		{"ACLAnonymous", a.srv.ACLAnonymous},
 		{"ACLBootstrap", a.srv.ACLBootstrap},
 		{"ACLReplicationStatus", a.srv.ACLReplicationStatus},
 		{"AgentToken", a.srv.AgentToken}, // See TestAgent_Token
@@ -593,4 +594,54 @@ func TestACL_HTTP(t *testing.T) {
 			require.Equal(t, structs.ACLPolicyGlobalManagementID, token.Policies[0].ID)
 		})
 	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		policyInput := &structs.ACLPolicy{
+			Name:  "anonymous-read",
+			Rules: `node_prefix "" { policy = "read" }`,
+		}
+		req, _ := http.NewRequest("PUT", "/v1/acl/policy?token=root", jsonBody(policyInput))
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.ACLPolicyCreate(resp, req)
+		require.NoError(t, err)
+		policy := obj.(*structs.ACLPolicy)
+
+		t.Run("Set Policies", func(t *testing.T) {
+			body := map[string]interface{}{
+				"Policies": []map[string]string{{"Name": "anonymous-read"}},
+			}
+			req, _ := http.NewRequest("PUT", "/v1/acl/anonymous?token=root", jsonBody(body))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLAnonymous(resp, req)
+			require.NoError(t, err)
+			token, ok := obj.(*structs.ACLToken)
+			require.True(t, ok)
+			require.Equal(t, structs.ACLTokenAnonymousID, token.AccessorID)
+			require.Len(t, token.Policies, 1)
+			require.Equal(t, policy.ID, token.Policies[0].ID)
+		})
+
+		t.Run("Reject Write Policies", func(t *testing.T) {
+			body := map[string]interface{}{
+				"Policies": []map[string]string{{"ID": structs.ACLPolicyGlobalManagementID}},
+			}
+			req, _ := http.NewRequest("PUT", "/v1/acl/anonymous?token=root", jsonBody(body))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLAnonymous(resp, req)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "grants write access")
+		})
+
+		t.Run("Read", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/anonymous?token=root", nil)
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLAnonymous(resp, req)
+			require.NoError(t, err)
+			token, ok := obj.(*structs.ACLToken)
+			require.True(t, ok)
+			require.Equal(t, structs.ACLTokenAnonymousID, token.AccessorID)
+			require.Len(t, token.Policies, 1)
+			require.Equal(t, policy.ID, token.Policies[0].ID)
+		})
+	})
 }
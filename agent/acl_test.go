@@ -131,6 +131,10 @@ func (a *TestACLAgent) RemoveFailedNode(node string) error {
 	return fmt.Errorf("Unimplemented")
 }
 
+func (a *TestACLAgent) ResolveTokenToAccessorID(secretID string) (string, error) {
+	return "", fmt.Errorf("Unimplemented")
+}
+
 func (a *TestACLAgent) RPC(method string, args interface{}, reply interface{}) error {
 	return fmt.Errorf("Unimplemented")
 }
@@ -79,6 +79,10 @@ type StateSyncer struct {
 	pauseLock sync.Mutex
 	paused    int
 
+	// synced stores whether a full sync run has completed successfully.
+	syncedLock sync.Mutex
+	synced     bool
+
 	// serverUpInterval is the max time after which a full sync is
 	// performed when a server has been added to the cluster.
 	serverUpInterval time.Duration
@@ -169,6 +173,9 @@ func (s *StateSyncer) nextFSMState(fs fsmState) fsmState {
 			s.Logger.Printf("[ERR] agent: failed to sync remote state: %v", err)
 			return retryFullSyncState
 		}
+		s.syncedLock.Lock()
+		s.synced = true
+		s.syncedLock.Unlock()
 
 		return partialSyncState
 
@@ -306,6 +313,14 @@ func (s *StateSyncer) Paused() bool {
 	return s.paused != 0
 }
 
+// Synced returns whether a full sync run has completed successfully since
+// the syncer was started.
+func (s *StateSyncer) Synced() bool {
+	s.syncedLock.Lock()
+	defer s.syncedLock.Unlock()
+	return s.synced
+}
+
 // Resume re-enables sync runs. It returns true if it was the last pause/resume
 // pair on the stack and so actually caused the state syncer to resume.
 func (s *StateSyncer) Resume() bool {
@@ -164,6 +164,9 @@ func TestAE_FSM(t *testing.T) {
 			if got, want := fs, retryFullSyncState; got != want {
 				t.Fatalf("got state %v want %v", got, want)
 			}
+			if l.Synced() {
+				t.Fatal("should not be synced")
+			}
 		})
 		t.Run("SyncFull() OK -> partialSyncState", func(t *testing.T) {
 			l := testSyncer()
@@ -172,6 +175,9 @@ func TestAE_FSM(t *testing.T) {
 			if got, want := fs, partialSyncState; got != want {
 				t.Fatalf("got state %v want %v", got, want)
 			}
+			if !l.Synced() {
+				t.Fatal("should be synced")
+			}
 		})
 	})
 
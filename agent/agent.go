@@ -13,19 +13,25 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/ae"
+	"github.com/hashicorp/consul/agent/agentpb"
 	"github.com/hashicorp/consul/agent/cache"
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/checks"
+	checkplugin "github.com/hashicorp/consul/agent/checks/plugin"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/local"
@@ -34,10 +40,12 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/systemd"
 	"github.com/hashicorp/consul/agent/token"
+	"github.com/hashicorp/consul/agent/tracing"
 	"github.com/hashicorp/consul/agent/xds"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/ipaddr"
 	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/lib/connlimit"
 	"github.com/hashicorp/consul/lib/file"
 	"github.com/hashicorp/consul/logger"
 	"github.com/hashicorp/consul/tlsutil"
@@ -45,6 +53,7 @@ import (
 	"github.com/hashicorp/consul/watch"
 	multierror "github.com/hashicorp/go-multierror"
 	uuid "github.com/hashicorp/go-uuid"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/memberlist"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
@@ -71,6 +80,11 @@ const (
 		"but no reason was provided. This is a default message."
 	defaultServiceMaintReason = "Maintenance mode is enabled for this " +
 		"service, but no reason was provided. This is a default message."
+
+	// leaveServiceMaintReason is the reason used for the maintenance checks
+	// registered while services are drained before a leave.
+	leaveServiceMaintReason = "The agent is leaving the cluster and is " +
+		"draining this service before deregistering it."
 )
 
 type configSource int
@@ -93,6 +107,7 @@ type delegate interface {
 	JoinLAN(addrs []string) (n int, err error)
 	RemoveFailedNode(node string) error
 	ResolveToken(secretID string) (acl.Authorizer, error)
+	ResolveTokenToAccessorID(secretID string) (string, error)
 	RPC(method string, args interface{}, reply interface{}) error
 	ACLsEnabled() bool
 	UseLegacyACLs() bool
@@ -103,7 +118,7 @@ type delegate interface {
 	enterpriseDelegate
 }
 
-// notifier is called after a successful JoinLAN.
+// notifier is used to tell systemd about the state of the agent.
 type notifier interface {
 	Notify(string) error
 }
@@ -130,6 +145,10 @@ type Agent struct {
 	// In-memory sink used for collecting metrics
 	MemSink *metrics.InmemSink
 
+	// tracer pushes the spans of API requests to an OpenTelemetry
+	// collector if telemetry.otlp_traces_endpoint is set.
+	tracer *tracing.Tracer
+
 	// delegate is either a *consul.Server or *consul.Client
 	// depending on the configuration
 	delegate delegate
@@ -178,11 +197,22 @@ type Agent struct {
 	// checkAliases maps the check ID to an associated Alias checks
 	checkAliases map[types.CheckID]*checks.CheckAlias
 
+	// checkPlugins maps the check ID to an associated check implemented by
+	// a plugin
+	checkPlugins map[types.CheckID]*checks.CheckPlugin
+
+	// maintTimers maps the check ID of an expiring node or service
+	// maintenance check to the timer that clears it
+	maintTimers map[types.CheckID]*time.Timer
+
 	// stateLock protects the agent state
 	stateLock sync.Mutex
 
 	// dockerClient is the client for performing docker health checks.
-	dockerClient *checks.DockerClient
+	dockerClient checks.ContainerClient
+
+	// checkPluginManager launches the plugins of plugin checks.
+	checkPluginManager *checkplugin.Manager
 
 	// eventCh is used to receive user events
 	eventCh chan serf.UserEvent
@@ -198,12 +228,31 @@ type Agent struct {
 
 	reloadCh chan chan error
 
+	// reloadConfig is the configuration of the last successful reload and
+	// reloadReport lists the fields it changed. Both are guarded by
+	// stateLock.
+	reloadConfig *config.RuntimeConfig
+	reloadReport *ReloadReport
+
+	// configOrigins maps the config keys to the source which set them for
+	// reloadConfig, if known. It is guarded by stateLock.
+	configOrigins map[string]string
+
+	// enableDebug is 1 if the pprof endpoints are available when ACLs are
+	// disabled. It can be changed on reload and is accessed atomically.
+	enableDebug int32
+
 	shutdown     bool
 	shutdownCh   chan struct{}
 	shutdownLock sync.Mutex
 
-	// joinLANNotifier is called after a successful JoinLAN.
-	joinLANNotifier notifier
+	// sockets are the sockets passed by systemd socket activation that
+	// haven't been used by any listener yet.
+	sockets *systemd.Sockets
+
+	// systemdNotifier tells systemd when the agent is ready, reloading or
+	// stopping and pings its watchdog.
+	systemdNotifier notifier
 
 	// retryJoinCh transports errors from the retry join
 	// attempts.
@@ -218,6 +267,10 @@ type Agent struct {
 	// dnsServer provides the DNS API
 	dnsServers []*DNSServer
 
+	// dnsTruncated holds the questions of recent truncated UDP DNS
+	// responses to count the TCP retries of them.
+	dnsTruncated *lru.Cache
+
 	// httpServers provides the HTTP API on various endpoints
 	httpServers []*HTTPServer
 
@@ -233,6 +286,10 @@ type Agent struct {
 	// the configuration directly.
 	tokens *token.Store
 
+	// tokenRequestMetrics counts the HTTP API requests of each token. It is
+	// nil unless telemetry.token_request_metrics_limit is set.
+	tokenRequestMetrics *tokenRequestMetrics
+
 	// proxyManager is the proxy process manager for managed Connect proxies.
 	proxyManager *proxyprocess.Manager
 
@@ -258,6 +315,10 @@ type Agent struct {
 	// store within the data directory. This will prevent loading while writing as
 	// well as multiple concurrent writes.
 	persistedTokensLock sync.RWMutex
+
+	// dataDirCipher encrypts the sensitive state persisted in the data dir.
+	// It is nil if the encryption isn't enabled.
+	dataDirCipher *dataDirCipher
 }
 
 func New(c *config.RuntimeConfig) (*Agent, error) {
@@ -278,26 +339,40 @@ func New(c *config.RuntimeConfig) (*Agent, error) {
 		checkGRPCs:      make(map[types.CheckID]*checks.CheckGRPC),
 		checkDockers:    make(map[types.CheckID]*checks.CheckDocker),
 		checkAliases:    make(map[types.CheckID]*checks.CheckAlias),
+		checkPlugins:    make(map[types.CheckID]*checks.CheckPlugin),
+		maintTimers:     make(map[types.CheckID]*time.Timer),
 		eventCh:         make(chan serf.UserEvent, 1024),
 		eventBuf:        make([]*UserEvent, 256),
-		joinLANNotifier: &systemd.Notifier{},
+		systemdNotifier: &systemd.Notifier{},
 		reloadCh:        make(chan chan error),
+		reloadConfig:    c,
 		retryJoinCh:     make(chan error),
 		shutdownCh:      make(chan struct{}),
 		endpoints:       make(map[string]string),
 		tokens:          new(token.Store),
 	}
+	a.setEnableDebug(c.EnableDebug)
+
+	var err error
+	if a.dnsTruncated, err = lru.New(dnsTruncatedCacheSize); err != nil {
+		return nil, err
+	}
 
 	if err := a.initializeACLs(); err != nil {
 		return nil, err
 	}
 
+	if a.dataDirCipher, err = newDataDirCipher(c.DataDirEncryptionKeyFile, c.DataDir); err != nil {
+		return nil, err
+	}
+
 	return a, nil
 }
 
 func LocalConfig(cfg *config.RuntimeConfig) local.Config {
 	lc := local.Config{
 		AdvertiseAddr:       cfg.AdvertiseAddrLAN.String(),
+		CheckFlapHoldDown:   cfg.CheckFlapHoldDown,
 		CheckUpdateInterval: cfg.CheckUpdateInterval,
 		Datacenter:          cfg.Datacenter,
 		DiscardCheckOutput:  cfg.DiscardCheckOutput,
@@ -368,10 +443,38 @@ func (a *Agent) Start() error {
 			"1 and 63 bytes.", a.config.NodeName)
 	}
 
+	// Encrypt the state persisted before the data dir encryption was
+	// enabled, before any of it is loaded.
+	if err := a.dataDirCipher.migrate(c.DataDir, a.logger); err != nil {
+		return fmt.Errorf("Failed to encrypt the data dir: %v", err)
+	}
+
 	// load the tokens - this requires the logger to be setup
 	// which is why we can't do this in New
 	a.loadTokens(a.config)
 
+	// Start tracing API requests before they can be made.
+	if endpoint := c.Telemetry.OTLPTracesEndpoint; endpoint != "" {
+		serviceName := c.Telemetry.MetricsPrefix
+		if serviceName == "" {
+			serviceName = "consul"
+		}
+		tracer, err := tracing.NewTracer(tracing.Config{
+			Endpoint:    endpoint,
+			Headers:     c.Telemetry.OTLPHeaders,
+			CAFile:      c.Telemetry.OTLPCAFile,
+			CertFile:    c.Telemetry.OTLPCertFile,
+			KeyFile:     c.Telemetry.OTLPKeyFile,
+			ServiceName: serviceName,
+			HostName:    c.NodeName,
+		})
+		if err != nil {
+			return err
+		}
+		a.tracer = tracer
+		tracing.SetGlobal(tracer)
+	}
+
 	// create the local state
 	a.State = local.NewState(LocalConfig(c), a.logger, a.tokens)
 
@@ -394,6 +497,7 @@ func (a *Agent) Start() error {
 	consulCfg.ServerUp = a.sync.SyncFull.Trigger
 
 	a.tlsConfigurator = tlsutil.NewConfigurator(c.ToTLSUtilConfig())
+	a.tlsConfigurator.SetLogger(a.logger)
 
 	// Setup either the client or the server.
 	if c.ServerMode {
@@ -459,6 +563,7 @@ func (a *Agent) Start() error {
 			Datacenter: a.config.Datacenter,
 			Segment:    a.config.SegmentName,
 		},
+		TLSConfigurator: a.tlsConfigurator,
 	})
 	if err != nil {
 		return err
@@ -473,19 +578,51 @@ func (a *Agent) Start() error {
 	// checks.
 	go a.reapServices()
 
+	// Start counting the HTTP API requests of each token.
+	if limit := c.Telemetry.TokenRequestMetricsLimit; limit > 0 {
+		a.tokenRequestMetrics = newTokenRequestMetrics(limit)
+		go a.tokenRequestMetrics.run(a.shutdownCh)
+	}
+
 	// Start handling events.
 	go a.handleEvents()
 
+	// Log the certificates reloaded from disk.
+	if c.TLSWatchFiles {
+		go a.logTLSReloads()
+	}
+
 	// Start sending network coordinate to the server.
 	if !c.DisableCoordinates {
 		go a.sendCoordinate()
 	}
 
+	// Start pinging the systemd watchdog if it's enabled. Pinging at half
+	// the watchdog interval leaves room for a missed ping.
+	if intv, ok := systemd.WatchdogInterval(); ok {
+		go a.systemdWatchdog(intv / 2)
+	}
+
+	// Start acknowledging CA roots to the leader so it can complete staged
+	// root rotations.
+	if c.ConnectEnabled && !c.ServerMode {
+		go a.ackCARoots()
+	}
+
 	// Write out the PID file if necessary.
 	if err := a.storePid(); err != nil {
 		return err
 	}
 
+	// Use the sockets systemd passed to the agent, if any, instead of
+	// binding them. This allows using privileged ports without running the
+	// agent as root.
+	sockets, err := systemd.InheritedSockets()
+	if err != nil {
+		return err
+	}
+	a.sockets = sockets
+
 	// start DNS servers
 	if err := a.listenAndServeDNS(); err != nil {
 		return err
@@ -511,6 +648,11 @@ func (a *Agent) Start() error {
 		return err
 	}
 
+	for _, addr := range a.sockets.Close() {
+		a.logger.Printf("[WARN] agent: Closed socket %s (%s) passed by systemd since it doesn't match any configured address",
+			addr.String(), addr.Network())
+	}
+
 	// register watches
 	if err := a.reloadWatches(a.config); err != nil {
 		return err
@@ -536,18 +678,34 @@ func (a *Agent) listenAndServeGRPC() error {
 	}
 	a.xdsServer.Initialize()
 
+	var cert *tls.Certificate
 	var err error
 	if a.config.HTTPSPort > 0 {
 		// gRPC uses the same TLS settings as the HTTPS API. If HTTPS is
 		// enabled then gRPC will require HTTPS as well.
-		a.grpcServer, err = a.xdsServer.GRPCServer(a.config.CertFile, a.config.KeyFile)
-	} else {
-		a.grpcServer, err = a.xdsServer.GRPCServer("", "")
+		if cert, err = a.config.ToTLSUtilConfig().KeyPair(); err != nil {
+			return err
+		}
 	}
+	a.grpcServer, err = a.xdsServer.GRPCServer(cert)
 	if err != nil {
 		return err
 	}
 
+	// Serve the standard gRPC health service so probes can check the
+	// readiness and liveness of the agent over gRPC too.
+	grpcHealth := health.NewServer()
+	healthpb.RegisterHealthServer(a.grpcServer, grpcHealth)
+	go a.updateGRPCHealth(grpcHealth)
+
+	// Serve session keepalives as an alternative to renewing sessions over
+	// HTTP.
+	agentpb.RegisterSessionServer(a.grpcServer, &grpcSession{agent: a})
+
+	// Stream the health of services as an alternative to blocking queries
+	// on the health endpoints.
+	agentpb.RegisterServiceHealthServer(a.grpcServer, &grpcServiceHealth{agent: a})
+
 	ln, err := a.startListeners(a.config.GRPCAddrs)
 	if err != nil {
 		return err
@@ -577,11 +735,17 @@ func (a *Agent) listenAndServeDNS() error {
 		}
 		a.dnsServers = append(a.dnsServers, s)
 
-		// start server
+		// start server, on the socket passed by systemd if there is one
+		l, pc := a.sockets.Listener(addr), a.sockets.PacketConn(addr)
 		a.wgServers.Add(1)
 		go func(addr net.Addr) {
 			defer a.wgServers.Done()
-			err := s.ListenAndServe(addr.Network(), addr.String(), func() { notif <- addr })
+			var err error
+			if l != nil || pc != nil {
+				err = s.ActivateAndServe(addr.Network(), addr.String(), l, pc, func() { notif <- addr })
+			} else {
+				err = s.ListenAndServe(addr.Network(), addr.String(), func() { notif <- addr })
+			}
 			if err != nil && !strings.Contains(err.Error(), "accept") {
 				errCh <- err
 			}
@@ -609,6 +773,16 @@ func (a *Agent) listenAndServeDNS() error {
 func (a *Agent) startListeners(addrs []net.Addr) ([]net.Listener, error) {
 	var ln []net.Listener
 	for _, addr := range addrs {
+		// Use the socket passed by systemd if there is one.
+		if l := a.sockets.Listener(addr); l != nil {
+			a.logger.Printf("[INFO] agent: Using socket %s (%s) passed by systemd", addr.String(), addr.Network())
+			if tl, ok := l.(*net.TCPListener); ok {
+				l = &tcpKeepAliveListener{tl}
+			}
+			ln = append(ln, l)
+			continue
+		}
+
 		var l net.Listener
 		var err error
 
@@ -658,27 +832,40 @@ func (a *Agent) listenHTTP() ([]*HTTPServer, error) {
 			return err
 		}
 
-		for _, l := range listeners {
+		for i, l := range listeners {
 			var tlscfg *tls.Config
+			var limiter *connlimit.Limiter
 			_, isTCP := l.(*tcpKeepAliveListener)
 			if isTCP && proto == "https" {
-				tlscfg, err = a.tlsConfigurator.IncomingHTTPSConfig()
+				// Listeners can have their own certificate and client
+				// verification setting.
+				if c, ok := a.config.HTTPSAddrTLS[addrs[i].String()]; ok {
+					tlscfg, err = a.tlsConfigurator.IncomingHTTPSListenerConfig(c.CertFile, c.KeyFile, c.VerifyIncoming)
+				} else {
+					tlscfg, err = a.tlsConfigurator.IncomingHTTPSConfig()
+				}
 				if err != nil {
 					return err
 				}
-				l = tls.NewListener(l, tlscfg)
+				limiter = connlimit.New(connlimit.Config{
+					AcceptRate:              a.config.HTTPSAcceptRate,
+					MaxConcurrentHandshakes: a.config.HTTPSMaxConcurrentHandshakes,
+				}, []string{"http"}, "agent.http", a.logger)
+				l = connlimit.NewTLSListener(l, tlscfg, limiter)
 			}
 			srv := &HTTPServer{
 				Server: &http.Server{
-					Addr:      l.Addr().String(),
-					TLSConfig: tlscfg,
+					Addr:           l.Addr().String(),
+					TLSConfig:      tlscfg,
+					MaxHeaderBytes: a.config.HTTPMaxHeaderBytes,
 				},
 				ln:        l,
 				agent:     a,
 				blacklist: NewBlacklist(a.config.HTTPBlockEndpoints),
+				limiter:   limiter,
 				proto:     proto,
 			}
-			srv.Server.Handler = srv.handler(a.config.EnableDebug)
+			srv.Server.Handler = srv.handler()
 
 			// This will enable upgrading connections to HTTP/2 as
 			// part of TLS negotiation.
@@ -935,6 +1122,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 		base.SerfWANConfig.MemberlistConfig.BindPort = a.config.SerfBindAddrWAN.Port
 		base.SerfWANConfig.MemberlistConfig.AdvertiseAddr = a.config.SerfAdvertiseAddrWAN.IP.String()
 		base.SerfWANConfig.MemberlistConfig.AdvertisePort = a.config.SerfAdvertiseAddrWAN.Port
+		base.AdvertiseAddrWANMap = a.config.AdvertiseAddrWANMap
 		base.SerfWANConfig.MemberlistConfig.GossipVerifyIncoming = a.config.EncryptVerifyIncoming
 		base.SerfWANConfig.MemberlistConfig.GossipVerifyOutgoing = a.config.EncryptVerifyOutgoing
 		base.SerfWANConfig.MemberlistConfig.GossipInterval = a.config.GossipWANGossipInterval
@@ -971,6 +1159,8 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.BootstrapExpect != 0 {
 		base.BootstrapExpect = a.config.BootstrapExpect
 	}
+	base.BootstrapClusterID = a.config.BootstrapClusterID
+	base.SkipClusterIDCheck = a.config.SkipClusterIDCheck
 	if a.config.RPCProtocol > 0 {
 		base.ProtocolVersion = uint8(a.config.RPCProtocol)
 	}
@@ -1007,6 +1197,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.ACLEnableKeyListPolicy {
 		base.ACLEnableKeyListPolicy = a.config.ACLEnableKeyListPolicy
 	}
+	base.ACLAllowAnonymousWrite = a.config.ACLAllowAnonymousWrite
 	if a.config.SessionTTLMin != 0 {
 		base.SessionTTLMin = a.config.SessionTTLMin
 	}
@@ -1017,6 +1208,8 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	// These are fully specified in the agent defaults, so we can simply
 	// copy them over.
 	base.AutopilotConfig.CleanupDeadServers = a.config.AutopilotCleanupDeadServers
+	base.AutopilotConfig.DeadServerQuarantineTime = a.config.AutopilotDeadServerQuarantineTime
+	base.AutopilotConfig.MaxDeadServerRemovals = uint64(a.config.AutopilotMaxDeadServerRemovals)
 	base.AutopilotConfig.LastContactThreshold = a.config.AutopilotLastContactThreshold
 	base.AutopilotConfig.MaxTrailingLogs = uint64(a.config.AutopilotMaxTrailingLogs)
 	base.AutopilotConfig.ServerStabilizationTime = a.config.AutopilotServerStabilizationTime
@@ -1037,6 +1230,19 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 		base.RPCMaxBurst = a.config.RPCMaxBurst
 	}
 
+	// Connection limits for incoming RPC.
+	base.RPCMaxConns = a.config.RPCMaxConns
+	base.RPCMaxStreamsPerConn = a.config.RPCMaxStreamsPerConn
+	base.RPCAcceptBackpressure = a.config.RPCAcceptBackpressure
+	base.RPCAcceptRate = a.config.RPCAcceptRate
+	base.RPCMaxConcurrentHandshakes = a.config.RPCMaxConcurrentHandshakes
+
+	// Concurrency limits for expensive queries.
+	base.RPCQueryLimits = a.config.RPCQueryLimits
+	if a.config.RPCQueryQueueTimeout > 0 {
+		base.RPCQueryQueueTimeout = a.config.RPCQueryQueueTimeout
+	}
+
 	// RPC-related performance configs.
 	if a.config.RPCHoldTimeout > 0 {
 		base.RPCHoldTimeout = a.config.RPCHoldTimeout
@@ -1044,6 +1250,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.LeaveDrainTime > 0 {
 		base.LeaveDrainTime = a.config.LeaveDrainTime
 	}
+	base.FollowerReads = a.config.FollowerReads
 
 	// set the src address for outgoing rpc connections
 	// Use port 0 so that outgoing connections use a random port.
@@ -1067,11 +1274,18 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	base.VerifyServerHostname = a.config.VerifyServerHostname
 	base.CAFile = a.config.CAFile
 	base.CAPath = a.config.CAPath
+	base.CRLFile = a.config.CRLFile
+	base.CRLPath = a.config.CRLPath
 	base.CertFile = a.config.CertFile
 	base.KeyFile = a.config.KeyFile
+	base.CertBundleFile = a.config.CertBundleFile
+	base.KeyPassphrase = a.config.KeyPassphrase
+	base.KeyPassphraseFile = a.config.KeyPassphraseFile
 	base.ServerName = a.config.ServerName
+	base.DatacenterServerNames = a.config.TLSDatacenterServerNames
 	base.Domain = a.config.DNSDomain
 	base.TLSMinVersion = a.config.TLSMinVersion
+	base.TLSMaxVersion = a.config.TLSMaxVersion
 	base.TLSCipherSuites = a.config.TLSCipherSuites
 	base.TLSPreferServerCipherSuites = a.config.TLSPreferServerCipherSuites
 
@@ -1420,11 +1634,69 @@ func (a *Agent) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io.
 	return a.delegate.SnapshotRPC(args, in, out, replyFn)
 }
 
-// Leave is used to prepare the agent for a graceful shutdown
+// Leave is used to prepare the agent for a graceful shutdown. If a
+// service drain time is configured the local services are drained and
+// deregistered before the agent leaves gossip.
 func (a *Agent) Leave() error {
+	if a.config.ServiceDrainTime > 0 {
+		a.drainServices(a.config.ServiceDrainTime)
+	}
 	return a.delegate.Leave()
 }
 
+// drainServices marks all local services as critical, waits for the given
+// duration so that DNS and API consumers stop routing to them and then
+// deregisters them from the catalog. Neither the maintenance checks nor the
+// removals are persisted so the services come back on the next start.
+func (a *Agent) drainServices(drain time.Duration) {
+	services := a.State.Services()
+	if len(services) == 0 {
+		return
+	}
+
+	a.logger.Printf("[INFO] agent: Draining %d services for %s before leaving", len(services), drain)
+	for id, service := range services {
+		check := &structs.HealthCheck{
+			Node:        a.config.NodeName,
+			CheckID:     serviceMaintCheckID(id),
+			Name:        "Service Maintenance Mode",
+			Notes:       leaveServiceMaintReason,
+			ServiceID:   service.ID,
+			ServiceName: service.Service,
+			Status:      api.HealthCritical,
+			Maintenance: &structs.CheckMaintenance{Reason: leaveServiceMaintReason},
+		}
+		if err := a.AddCheck(check, nil, false, "", ConfigSourceLocal); err != nil {
+			a.logger.Printf("[WARN] agent: Failed to drain service %q: %s", id, err)
+		}
+	}
+	if err := a.State.SyncChanges(); err != nil {
+		a.logger.Printf("[WARN] agent: Failed to sync drained services: %s", err)
+	}
+
+	select {
+	case <-time.After(drain):
+	case <-a.shutdownCh:
+		return
+	}
+
+	checks := a.State.Checks()
+	for id := range services {
+		var checkIDs []types.CheckID
+		for checkID, check := range checks {
+			if check.ServiceID == id {
+				checkIDs = append(checkIDs, checkID)
+			}
+		}
+		if err := a.State.RemoveServiceWithChecks(id, checkIDs); err != nil {
+			a.logger.Printf("[WARN] agent: Failed to deregister drained service %q: %s", id, err)
+		}
+	}
+	if err := a.State.SyncChanges(); err != nil {
+		a.logger.Printf("[WARN] agent: Failed to sync deregistered services: %s", err)
+	}
+}
+
 // ShutdownAgent is used to hard stop the agent. Should be preceded by
 // Leave to do it gracefully. Should be followed by ShutdownEndpoints to
 // terminate the HTTP and DNS servers as well.
@@ -1436,6 +1708,7 @@ func (a *Agent) ShutdownAgent() error {
 		return nil
 	}
 	a.logger.Println("[INFO] agent: Requesting shutdown")
+	a.notifySystemd(systemd.Stopping)
 
 	// Stop all the checks
 	a.stateLock.Lock()
@@ -1461,6 +1734,15 @@ func (a *Agent) ShutdownAgent() error {
 	for _, chk := range a.checkAliases {
 		chk.Stop()
 	}
+	for _, chk := range a.checkPlugins {
+		chk.Stop()
+	}
+	if a.checkPluginManager != nil {
+		a.checkPluginManager.Close()
+	}
+	for _, timer := range a.maintTimers {
+		timer.Stop()
+	}
 
 	// Stop gRPC
 	if a.grpcServer != nil {
@@ -1505,17 +1787,43 @@ func (a *Agent) ShutdownAgent() error {
 		}
 	}
 
+	// Push the remaining spans
+	if a.tracer != nil {
+		if tracing.Global() == a.tracer {
+			tracing.SetGlobal(nil)
+		}
+		a.tracer.Stop()
+	}
+
 	pidErr := a.deletePid()
 	if pidErr != nil {
 		a.logger.Println("[WARN] agent: could not delete pid file ", pidErr)
 	}
 
+	if a.tlsConfigurator != nil {
+		a.tlsConfigurator.Stop()
+	}
+
 	a.logger.Println("[INFO] agent: shutdown complete")
 	a.shutdown = true
 	close(a.shutdownCh)
 	return err
 }
 
+// logTLSReloads logs every time the TLS configurator reloaded the
+// certificate files until the agent is shut down.
+func (a *Agent) logTLSReloads() {
+	for {
+		select {
+		case <-a.shutdownCh:
+			return
+		case <-a.tlsConfigurator.Notify():
+			a.logger.Printf("[INFO] agent: Reloaded TLS certificate files (version %d)",
+				a.tlsConfigurator.Version())
+		}
+	}
+}
+
 // ShutdownEndpoints terminates the HTTP and DNS servers. Should be
 // preceded by ShutdownAgent.
 func (a *Agent) ShutdownEndpoints() {
@@ -1571,10 +1879,8 @@ func (a *Agent) JoinLAN(addrs []string) (n int, err error) {
 	a.logger.Printf("[INFO] agent: (LAN) joining: %v", addrs)
 	n, err = a.delegate.JoinLAN(addrs)
 	a.logger.Printf("[INFO] agent: (LAN) joined: %d Err: %v", n, err)
-	if err == nil && a.joinLANNotifier != nil {
-		if notifErr := a.joinLANNotifier.Notify(systemd.Ready); notifErr != nil {
-			a.logger.Printf("[DEBUG] agent: systemd notify failed: %v", notifErr)
-		}
+	if err == nil {
+		a.notifySystemd(systemd.Ready)
 	}
 	return
 }
@@ -1624,6 +1930,42 @@ func (a *Agent) WANMembers() []serf.Member {
 func (a *Agent) StartSync() {
 	go a.sync.Run()
 	a.logger.Printf("[INFO] agent: started state syncer")
+
+	// Startup is complete at this point unless the agent still needs to
+	// join the cluster, in which case JoinLAN tells systemd.
+	if len(a.config.StartJoinAddrsLAN) == 0 && len(a.config.RetryJoinLAN) == 0 {
+		a.notifySystemd(systemd.Ready)
+	}
+}
+
+// notifySystemd sends the given state to systemd. This is a no-op unless the
+// agent runs as a systemd service with Type=notify.
+func (a *Agent) notifySystemd(state string) {
+	if a.systemdNotifier == nil {
+		return
+	}
+	if err := a.systemdNotifier.Notify(state); err != nil {
+		a.logger.Printf("[DEBUG] agent: systemd notify failed: %v", err)
+	}
+}
+
+// systemdWatchdog is a long-running loop that pings the systemd watchdog so it
+// doesn't restart the agent. It only does so once the agent state can be
+// locked, so an agent that is stuck gets restarted. Closing the agent's
+// shutdownChannel will cause this to exit.
+func (a *Agent) systemdWatchdog(intv time.Duration) {
+	for {
+		select {
+		case <-time.After(intv):
+			a.stateLock.Lock()
+			a.stateLock.Unlock()
+			a.State.Checks()
+
+			a.notifySystemd(systemd.Watchdog)
+		case <-a.shutdownCh:
+			return
+		}
+	}
 }
 
 // PauseSync is used to pause anti-entropy while bulk changes are made. It also
@@ -1681,6 +2023,56 @@ func (a *Agent) GetLANCoordinate() (lib.CoordinateSet, error) {
 	return a.delegate.GetLANCoordinate()
 }
 
+// ackCARoots is a long-running loop that watches the CA roots and tells the
+// leader which roots this agent has received, so it can activate a staged root
+// once every agent trusts it. Closing the agent's shutdownChannel will cause
+// this to exit.
+func (a *Agent) ackCARoots() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan cache.UpdateEvent, 1)
+	err := a.cache.Notify(ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+		Datacenter: a.config.Datacenter,
+	}, "roots", ch)
+	if err != nil {
+		a.logger.Printf("[ERR] agent: Failed to watch CA roots: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case u := <-ch:
+			if u.Err != nil {
+				continue
+			}
+			roots, ok := u.Result.(*structs.IndexedCARoots)
+			if !ok {
+				continue
+			}
+
+			req := structs.CARootsAckRequest{
+				Datacenter:   a.config.Datacenter,
+				Node:         a.config.NodeName,
+				WriteRequest: structs.WriteRequest{Token: a.tokens.AgentToken()},
+			}
+			for _, r := range roots.Roots {
+				req.RootIDs = append(req.RootIDs, r.ID)
+			}
+			var reply struct{}
+			if err := a.RPC("ConnectCA.RootsAck", &req, &reply); err != nil {
+				if acl.IsErrPermissionDenied(err) {
+					a.logger.Printf("[WARN] agent: CA roots acknowledgement blocked by ACLs")
+				} else {
+					a.logger.Printf("[ERR] agent: CA roots acknowledgement error: %v", err)
+				}
+			}
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
 // sendCoordinate is a long-running loop that periodically sends our coordinate
 // to the server. Closing the agent's shutdownChannel will cause this to exit.
 func (a *Agent) sendCoordinate() {
@@ -1806,6 +2198,9 @@ func (a *Agent) persistService(service *structs.NodeService) error {
 	if err != nil {
 		return err
 	}
+	if encoded, err = a.dataDirCipher.seal(svcPath, encoded); err != nil {
+		return err
+	}
 
 	return file.WriteAtomic(svcPath, encoded)
 }
@@ -1844,6 +2239,9 @@ func (a *Agent) persistProxy(proxy *local.ManagedProxy, FromFile bool) error {
 	if err != nil {
 		return err
 	}
+	if encoded, err = a.dataDirCipher.seal(proxyPath, encoded); err != nil {
+		return err
+	}
 
 	return file.WriteAtomic(proxyPath, encoded)
 }
@@ -1872,6 +2270,9 @@ func (a *Agent) persistCheck(check *structs.HealthCheck, chkType *structs.CheckT
 	if err != nil {
 		return err
 	}
+	if encoded, err = a.dataDirCipher.seal(checkPath, encoded); err != nil {
+		return err
+	}
 
 	return file.WriteAtomic(checkPath, encoded)
 }
@@ -2161,6 +2562,9 @@ func (a *Agent) addCheckLocked(check *structs.HealthCheck, chkType *structs.Chec
 		return err
 	}
 
+	// Schedule clearing the maintenance mode if it expires
+	a.scheduleMaintenanceExpiry(check)
+
 	// Persist the check
 	if persist && a.config.DataDir != "" {
 		return a.persistCheck(check, chkType)
@@ -2188,6 +2592,12 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				return fmt.Errorf("Scripts are disabled on this agent from remote calls; to enable, configure 'enable_script_checks' to true")
 			}
 		}
+
+		if chkType.Plugin != "" {
+			if _, ok := a.config.CheckPlugins[chkType.Plugin]; !ok {
+				return fmt.Errorf("Check plugin %q is not configured on this agent; configure it in 'check_plugins'", chkType.Plugin)
+			}
+		}
 	}
 
 	if check.ServiceID != "" {
@@ -2248,6 +2658,7 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				Timeout:         chkType.Timeout,
 				Logger:          a.logger,
 				TLSClientConfig: tlsClientConfig,
+				OutputLimit:     a.checkOutputLimit(),
 			}
 			http.Start()
 			a.checkHTTPs[check.CheckID] = http
@@ -2319,12 +2730,12 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			if a.dockerClient == nil {
-				dc, err := checks.NewDockerClient(os.Getenv("DOCKER_HOST"), checks.BufSize)
+				dc, err := a.newContainerClient()
 				if err != nil {
-					a.logger.Printf("[ERR] agent: error creating docker client: %s", err)
+					a.logger.Printf("[ERR] agent: error creating %s client: %s", a.config.DockerCheckRuntime, err)
 					return err
 				}
-				a.logger.Printf("[DEBUG] agent: created docker client for %s", dc.Host())
+				a.logger.Printf("[DEBUG] agent: created %s client for %s", a.config.DockerCheckRuntime, dc.Host())
 				a.dockerClient = dc
 			}
 
@@ -2356,12 +2767,19 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			monitor := &checks.CheckMonitor{
-				Notify:     a.State,
-				CheckID:    check.CheckID,
-				ScriptArgs: chkType.ScriptArgs,
-				Interval:   chkType.Interval,
-				Timeout:    chkType.Timeout,
-				Logger:     a.logger,
+				Notify:      a.State,
+				CheckID:     check.CheckID,
+				ScriptArgs:  chkType.ScriptArgs,
+				Interval:    chkType.Interval,
+				Timeout:     chkType.Timeout,
+				Logger:      a.logger,
+				OutputLimit: a.checkOutputLimit(),
+				Sandbox: checks.ScriptSandbox{
+					User:         a.config.ScriptCheckUser,
+					EnvWhitelist: a.config.ScriptCheckEnvWhitelist,
+					Dir:          a.config.ScriptCheckDir,
+					KillTimeout:  a.config.ScriptCheckKillTimeout,
+				},
 			}
 			monitor.Start()
 			a.checkMonitors[check.CheckID] = monitor
@@ -2395,6 +2813,35 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			chkImpl.Start()
 			a.checkAliases[check.CheckID] = chkImpl
 
+		case chkType.IsPlugin():
+			if existing, ok := a.checkPlugins[check.CheckID]; ok {
+				existing.Stop()
+				delete(a.checkPlugins, check.CheckID)
+			}
+			if chkType.Interval < checks.MinInterval {
+				a.logger.Printf("[WARN] agent: check '%s' has interval below minimum of %v",
+					check.CheckID, checks.MinInterval)
+				chkType.Interval = checks.MinInterval
+			}
+
+			if a.checkPluginManager == nil {
+				a.checkPluginManager = checkplugin.NewManager(a.config.CheckPlugins, a.LogOutput)
+			}
+
+			pluginCheck := &checks.CheckPlugin{
+				Notify:      a.State,
+				CheckID:     check.CheckID,
+				Plugin:      chkType.Plugin,
+				Args:        chkType.PluginArgs,
+				Interval:    chkType.Interval,
+				Timeout:     chkType.Timeout,
+				Logger:      a.logger,
+				Plugins:     a.checkPluginManager,
+				OutputLimit: a.checkOutputLimit(),
+			}
+			pluginCheck.Start()
+			a.checkPlugins[check.CheckID] = pluginCheck
+
 		default:
 			return fmt.Errorf("Check type is not valid")
 		}
@@ -2449,7 +2896,7 @@ func (a *Agent) removeCheckLocked(checkID types.CheckID, persist bool) error {
 
 // addProxyLocked adds a new local Connect Proxy instance to be managed by the agent.
 //
-// This assumes that the agent's proxyLock is already held
+// # This assumes that the agent's proxyLock is already held
 //
 // It REQUIRES that the service that is being proxied is already present in the
 // local state. Note that this is only used for agent-managed proxies so we can
@@ -2820,6 +3267,14 @@ func (a *Agent) cancelCheckMonitors(checkID types.CheckID) {
 		check.Stop()
 		delete(a.checkDockers, checkID)
 	}
+	if check, ok := a.checkPlugins[checkID]; ok {
+		check.Stop()
+		delete(a.checkPlugins, checkID)
+	}
+	if timer, ok := a.maintTimers[checkID]; ok {
+		timer.Stop()
+		delete(a.maintTimers, checkID)
+	}
 }
 
 // updateTTLCheck is used to update the status of a TTL check via the Agent API.
@@ -2833,6 +3288,10 @@ func (a *Agent) updateTTLCheck(checkID types.CheckID, status, output string) err
 		return fmt.Errorf("CheckID %q does not have associated TTL", checkID)
 	}
 
+	// Notes can be arbitrarily large, limit them like the output of other
+	// checks.
+	output = a.checkOutputLimit().Truncate(output)
+
 	// Set the status through CheckTTL to reset the TTL.
 	check.SetStatus(status, output)
 
@@ -2850,6 +3309,33 @@ func (a *Agent) updateTTLCheck(checkID types.CheckID, status, output string) err
 	return nil
 }
 
+// checkOutputLimit returns the limit of the output of the agent's checks.
+func (a *Agent) checkOutputLimit() checks.OutputLimit {
+	return checks.OutputLimit{
+		MaxSize:    a.config.CheckOutputMaxSize,
+		Truncation: a.config.CheckOutputTruncation,
+	}
+}
+
+// newContainerClient returns the client for the container runtime of Docker
+// checks.
+func (a *Agent) newContainerClient() (checks.ContainerClient, error) {
+	maxbuf := int64(a.checkOutputLimit().Size())
+	endpoint := a.config.DockerCheckRuntimeEndpoint
+	switch a.config.DockerCheckRuntime {
+	case "containerd", "cri":
+		if endpoint == "" {
+			endpoint = checks.DefaultContainerdEndpoint
+		}
+		return checks.NewCRIClient(endpoint, maxbuf)
+	default:
+		if endpoint == "" {
+			endpoint = os.Getenv("DOCKER_HOST")
+		}
+		return checks.NewDockerClient(endpoint, maxbuf)
+	}
+}
+
 // persistCheckState is used to record the check status into the data dir.
 // This allows the state to be restored on a later agent start. Currently
 // only useful for TTL based checks.
@@ -2863,19 +3349,22 @@ func (a *Agent) persistCheckState(check *checks.CheckTTL, status, output string)
 	}
 
 	// Encode the state
+	dir := filepath.Join(a.config.DataDir, checkStateDir)
+	file := filepath.Join(dir, checkIDHash(check.CheckID))
 	buf, err := json.Marshal(state)
 	if err != nil {
 		return err
 	}
+	if buf, err = a.dataDirCipher.seal(file, buf); err != nil {
+		return err
+	}
 
 	// Create the state dir if it doesn't exist
-	dir := filepath.Join(a.config.DataDir, checkStateDir)
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		return fmt.Errorf("failed creating check state dir %q: %s", dir, err)
 	}
 
 	// Write the state to the file
-	file := filepath.Join(dir, checkIDHash(check.CheckID))
 
 	// Create temp file in same dir, to make more likely atomic
 	tempFile := file + ".tmp"
@@ -2902,6 +3391,9 @@ func (a *Agent) loadCheckState(check *structs.HealthCheck) error {
 		}
 		return fmt.Errorf("failed reading file %q: %s", file, err)
 	}
+	if buf, err = a.dataDirCipher.open(file, buf); err != nil {
+		return fmt.Errorf("failed reading file %q: %s", file, err)
+	}
 
 	// Decode the state data
 	var p persistedCheckState
@@ -3074,6 +3566,9 @@ func (a *Agent) loadServices(conf *config.RuntimeConfig) error {
 		if err != nil {
 			return fmt.Errorf("failed reading service file %q: %s", file, err)
 		}
+		if buf, err = a.dataDirCipher.open(file, buf); err != nil {
+			return fmt.Errorf("failed reading service file %q: %s", file, err)
+		}
 
 		// Try decoding the service definition
 		var p persistedService
@@ -3156,6 +3651,9 @@ func (a *Agent) loadChecks(conf *config.RuntimeConfig) error {
 		if err != nil {
 			return fmt.Errorf("failed reading check file %q: %s", file, err)
 		}
+		if buf, err = a.dataDirCipher.open(file, buf); err != nil {
+			return fmt.Errorf("failed reading check file %q: %s", file, err)
+		}
 
 		// Decode the check
 		var p persistedCheck
@@ -3243,6 +3741,9 @@ func (a *Agent) loadPersistedProxies() (map[string]persistedProxy, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed reading proxy file %q: %s", file, err)
 		}
+		if buf, err = a.dataDirCipher.open(file, buf); err != nil {
+			return nil, fmt.Errorf("failed reading proxy file %q: %s", file, err)
+		}
 
 		// Try decoding the proxy definition
 		var p persistedProxy
@@ -3310,6 +3811,7 @@ type persistedTokens struct {
 	AgentMaster string `json:"agent_master,omitempty"`
 	Default     string `json:"default,omitempty"`
 	Agent       string `json:"agent,omitempty"`
+	DNS         string `json:"dns,omitempty"`
 }
 
 func (a *Agent) getPersistedTokens() (*persistedTokens, error) {
@@ -3331,6 +3833,9 @@ func (a *Agent) getPersistedTokens() (*persistedTokens, error) {
 		}
 		return persistedTokens, fmt.Errorf("failed reading tokens file %q: %s", tokensFullPath, err)
 	}
+	if buf, err = a.dataDirCipher.open(tokensFullPath, buf); err != nil {
+		return persistedTokens, fmt.Errorf("failed reading tokens file %q: %s", tokensFullPath, err)
+	}
 
 	if err := json.Unmarshal(buf, persistedTokens); err != nil {
 		return persistedTokens, fmt.Errorf("failed to decode tokens file %q: %s", tokensFullPath, err)
@@ -3386,6 +3891,16 @@ func (a *Agent) loadTokens(conf *config.RuntimeConfig) error {
 		a.tokens.UpdateReplicationToken(conf.ACLReplicationToken, token.TokenSourceConfig)
 	}
 
+	if persistedTokens.DNS != "" {
+		a.tokens.UpdateDNSToken(persistedTokens.DNS, token.TokenSourceAPI)
+
+		if conf.ACLDNSToken != "" {
+			a.logger.Printf("[WARN] \"dns\" token present in both the configuration and persisted token store, using the persisted token")
+		}
+	} else {
+		a.tokens.UpdateDNSToken(conf.ACLDNSToken, token.TokenSourceConfig)
+	}
+
 	return persistenceErr
 }
 
@@ -3439,6 +3954,13 @@ func serviceMaintCheckID(serviceID string) types.CheckID {
 // EnableServiceMaintenance will register a false health check against the given
 // service ID with critical status. This will exclude the service from queries.
 func (a *Agent) EnableServiceMaintenance(serviceID, reason, token string) error {
+	return a.EnableServiceMaintenanceWithMeta(serviceID, &structs.CheckMaintenance{Reason: reason}, token)
+}
+
+// EnableServiceMaintenanceWithMeta is like EnableServiceMaintenance but
+// records the given maintenance metadata with the check. If the metadata has
+// an expiry the maintenance mode is cleared automatically once it is reached.
+func (a *Agent) EnableServiceMaintenanceWithMeta(serviceID string, maint *structs.CheckMaintenance, token string) error {
 	service, ok := a.State.Services()[serviceID]
 	if !ok {
 		return fmt.Errorf("No service registered with ID %q", serviceID)
@@ -3451,8 +3973,9 @@ func (a *Agent) EnableServiceMaintenance(serviceID, reason, token string) error
 	}
 
 	// Use default notes if no reason provided
-	if reason == "" {
-		reason = defaultServiceMaintReason
+	maint = maint.Clone()
+	if maint.Reason == "" {
+		maint.Reason = defaultServiceMaintReason
 	}
 
 	// Create and register the critical health check
@@ -3460,10 +3983,11 @@ func (a *Agent) EnableServiceMaintenance(serviceID, reason, token string) error
 		Node:        a.config.NodeName,
 		CheckID:     checkID,
 		Name:        "Service Maintenance Mode",
-		Notes:       reason,
+		Notes:       maint.Reason,
 		ServiceID:   service.ID,
 		ServiceName: service.Service,
 		Status:      api.HealthCritical,
+		Maintenance: maint,
 	}
 	a.AddCheck(check, nil, true, token, ConfigSourceLocal)
 	a.logger.Printf("[INFO] agent: Service %q entered maintenance mode", serviceID)
@@ -3493,23 +4017,32 @@ func (a *Agent) DisableServiceMaintenance(serviceID string) error {
 
 // EnableNodeMaintenance places a node into maintenance mode.
 func (a *Agent) EnableNodeMaintenance(reason, token string) {
+	a.EnableNodeMaintenanceWithMeta(&structs.CheckMaintenance{Reason: reason}, token)
+}
+
+// EnableNodeMaintenanceWithMeta is like EnableNodeMaintenance but records
+// the given maintenance metadata with the check. If the metadata has an
+// expiry the maintenance mode is cleared automatically once it is reached.
+func (a *Agent) EnableNodeMaintenanceWithMeta(maint *structs.CheckMaintenance, token string) {
 	// Ensure node maintenance is not already enabled
 	if _, ok := a.State.Checks()[structs.NodeMaint]; ok {
 		return
 	}
 
 	// Use a default notes value
-	if reason == "" {
-		reason = defaultNodeMaintReason
+	maint = maint.Clone()
+	if maint.Reason == "" {
+		maint.Reason = defaultNodeMaintReason
 	}
 
 	// Create and register the node maintenance check
 	check := &structs.HealthCheck{
-		Node:    a.config.NodeName,
-		CheckID: structs.NodeMaint,
-		Name:    "Node Maintenance Mode",
-		Notes:   reason,
-		Status:  api.HealthCritical,
+		Node:        a.config.NodeName,
+		CheckID:     structs.NodeMaint,
+		Name:        "Node Maintenance Mode",
+		Notes:       maint.Reason,
+		Status:      api.HealthCritical,
+		Maintenance: maint,
 	}
 	a.AddCheck(check, nil, true, token, ConfigSourceLocal)
 	a.logger.Printf("[INFO] agent: Node entered maintenance mode")
@@ -3524,12 +4057,62 @@ func (a *Agent) DisableNodeMaintenance() {
 	a.logger.Printf("[INFO] agent: Node left maintenance mode")
 }
 
+// scheduleMaintenanceExpiry arms a timer which clears the maintenance mode
+// of the given check once its expiry is reached. This must be called with
+// the stateLock held.
+func (a *Agent) scheduleMaintenanceExpiry(check *structs.HealthCheck) {
+	if timer, ok := a.maintTimers[check.CheckID]; ok {
+		timer.Stop()
+		delete(a.maintTimers, check.CheckID)
+	}
+	if check.Maintenance == nil || check.Maintenance.Expires == nil {
+		return
+	}
+
+	serviceID := check.ServiceID
+	a.maintTimers[check.CheckID] = time.AfterFunc(time.Until(*check.Maintenance.Expires), func() {
+		if serviceID != "" {
+			if err := a.DisableServiceMaintenance(serviceID); err != nil {
+				a.logger.Printf("[WARN] agent: Failed to clear expired maintenance mode for service %q: %s", serviceID, err)
+				return
+			}
+			a.logger.Printf("[INFO] agent: Maintenance mode for service %q expired", serviceID)
+		} else {
+			a.DisableNodeMaintenance()
+			a.logger.Printf("[INFO] agent: Node maintenance mode expired")
+		}
+		a.sync.SyncChanges.Trigger()
+	})
+}
+
 func (a *Agent) loadLimits(conf *config.RuntimeConfig) {
 	a.config.RPCRateLimit = conf.RPCRateLimit
 	a.config.RPCMaxBurst = conf.RPCMaxBurst
+	a.config.RPCMaxConns = conf.RPCMaxConns
+	a.config.RPCMaxStreamsPerConn = conf.RPCMaxStreamsPerConn
+	a.config.RPCAcceptBackpressure = conf.RPCAcceptBackpressure
+	a.config.RPCAcceptRate = conf.RPCAcceptRate
+	a.config.RPCMaxConcurrentHandshakes = conf.RPCMaxConcurrentHandshakes
+	a.config.RPCQueryLimits = conf.RPCQueryLimits
+	a.config.RPCQueryQueueTimeout = conf.RPCQueryQueueTimeout
+	a.config.HTTPSAcceptRate = conf.HTTPSAcceptRate
+	a.config.HTTPSMaxConcurrentHandshakes = conf.HTTPSMaxConcurrentHandshakes
+
+	for _, srv := range a.httpServers {
+		if srv.limiter != nil {
+			srv.limiter.SetConfig(connlimit.Config{
+				AcceptRate:              conf.HTTPSAcceptRate,
+				MaxConcurrentHandshakes: conf.HTTPSMaxConcurrentHandshakes,
+			})
+		}
+	}
 }
 
 func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
+	// Tell systemd so it doesn't consider a slow reload a hung agent.
+	a.notifySystemd(systemd.Reloading)
+	defer a.notifySystemd(systemd.Ready)
+
 	// Bulk update the services and checks
 	a.PauseSync()
 	defer a.ResumeSync()
@@ -3541,6 +4124,18 @@ func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
 	snap := a.snapshotCheckState()
 	defer a.restoreCheckState(snap)
 
+	// Compare the configs before the reload updates the limits in a.config,
+	// which reloadConfig still points to until the first reload.
+	report := newReloadReport(a.config, a.reloadConfig, newCfg)
+
+	// Swap the DNS config first since it is the only part which can fail
+	// validation, before anything has been unloaded.
+	for _, srv := range a.dnsServers {
+		if err := srv.ReloadConfig(newCfg); err != nil {
+			return fmt.Errorf("Failed reloading dns config: %v", err)
+		}
+	}
+
 	// First unload all checks, services, and metadata. This lets us begin the reload
 	// with a clean slate.
 	if err := a.unloadProxies(); err != nil {
@@ -3589,15 +4184,66 @@ func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
 		return err
 	}
 
-	// Update filtered metrics
-	metrics.UpdateFilter(newCfg.Telemetry.AllowedPrefixes,
-		newCfg.Telemetry.BlockedPrefixes)
+	// Rebuild the metric sinks if any of them changed, otherwise only
+	// update the filtered metrics.
+	if a.MemSink != nil && !reflect.DeepEqual(a.reloadConfig.Telemetry, newCfg.Telemetry) {
+		if err := lib.ReloadTelemetry(a.MemSink, newCfg.Telemetry); err != nil {
+			return fmt.Errorf("Failed reloading telemetry: %v", err)
+		}
+	} else {
+		metrics.UpdateFilter(newCfg.Telemetry.AllowedPrefixes,
+			newCfg.Telemetry.BlockedPrefixes)
+	}
 
 	a.State.SetDiscardCheckOutput(newCfg.DiscardCheckOutput)
+	a.setEnableDebug(newCfg.EnableDebug)
+
+	a.reloadReport = report
+	a.reloadConfig = newCfg
 
 	return nil
 }
 
+// debugEnabled returns whether the pprof endpoints are available when ACLs
+// are disabled.
+func (a *Agent) debugEnabled() bool {
+	return atomic.LoadInt32(&a.enableDebug) == 1
+}
+
+func (a *Agent) setEnableDebug(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&a.enableDebug, v)
+}
+
+// ReloadReport returns the fields changed by the last successful reload, or
+// nil if the agent has not been reloaded yet.
+func (a *Agent) ReloadReport() *ReloadReport {
+	a.stateLock.Lock()
+	defer a.stateLock.Unlock()
+	return a.reloadReport
+}
+
+// SetConfigOrigins records which config source set each config key of the
+// configuration the agent was last started or reloaded with, as reported by
+// the config.Builder which built it.
+func (a *Agent) SetConfigOrigins(origins map[string]string) {
+	a.stateLock.Lock()
+	defer a.stateLock.Unlock()
+	a.configOrigins = origins
+}
+
+// EffectiveConfig returns the configuration of the last successful reload,
+// or the one the agent was started with, together with the sources of its
+// config keys.
+func (a *Agent) EffectiveConfig() (*config.RuntimeConfig, map[string]string) {
+	a.stateLock.Lock()
+	defer a.stateLock.Unlock()
+	return a.reloadConfig, a.configOrigins
+}
+
 // registerCache configures the cache and registers all the supported
 // types onto the cache. This is NOT safe to call multiple times so
 // care should be taken to call this exactly once after the cache
@@ -3670,6 +4316,15 @@ func (a *Agent) registerCache() {
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
 	})
+
+	a.cache.RegisterType(cachetype.ConfigEntryName, &cachetype.ConfigEntry{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
 }
 
 // defaultProxyCommand returns the default Connect managed proxy command.
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -19,7 +20,6 @@ import (
 
 	"github.com/hashicorp/consul/acl"
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
-	"github.com/hashicorp/consul/agent/checks"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/debug"
 	"github.com/hashicorp/consul/agent/local"
@@ -45,6 +45,10 @@ type Self struct {
 	Member      serf.Member
 	Stats       map[string]map[string]string
 	Meta        map[string]string
+
+	// ConfigOrigins maps the config keys to the config file, flag or
+	// default which set them. It is only returned with ?full.
+	ConfigOrigins map[string]string `json:",omitempty"`
 }
 
 func (s *HTTPServer) AgentSelf(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -82,14 +86,26 @@ func (s *HTTPServer) AgentSelf(resp http.ResponseWriter, req *http.Request) (int
 		Server:     s.agent.config.ServerMode,
 		Version:    s.agent.config.Version,
 	}
-	return Self{
+	self := Self{
 		Config:      config,
 		DebugConfig: s.agent.config.Sanitized(),
 		Coord:       cs[s.agent.config.SegmentName],
 		Member:      s.agent.LocalMember(),
 		Stats:       s.agent.Stats(),
 		Meta:        s.agent.State.Metadata(),
-	}, nil
+	}
+
+	// The full view is the configuration in effect after the last reload,
+	// with the sources its keys came from.
+	if _, ok := req.URL.Query()["full"]; ok {
+		effective, origins := s.agent.EffectiveConfig()
+		self.DebugConfig = effective.Sanitized()
+		self.ConfigOrigins = origins
+		if self.ConfigOrigins == nil {
+			self.ConfigOrigins = make(map[string]string)
+		}
+	}
+	return self, nil
 }
 
 // enablePrometheusOutput will look for Prometheus mime-type or format Query parameter the same way as Nomad
@@ -154,7 +170,10 @@ func (s *HTTPServer) AgentReload(resp http.ResponseWriter, req *http.Request) (i
 	case <-s.agent.shutdownCh:
 		return nil, fmt.Errorf("Agent was shutdown before reload could be completed")
 	case err := <-errCh:
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+		return s.agent.ReloadReport(), nil
 	}
 }
 
@@ -174,6 +193,7 @@ func buildAgentService(s *structs.NodeService, proxies map[string]*local.Managed
 		Meta:              s.Meta,
 		Port:              s.Port,
 		Address:           s.Address,
+		TaggedAddresses:   taggedAddressesToAPI(s.TaggedAddresses),
 		EnableTagOverride: s.EnableTagOverride,
 		CreateIndex:       s.CreateIndex,
 		ModifyIndex:       s.ModifyIndex,
@@ -186,9 +206,12 @@ func buildAgentService(s *structs.NodeService, proxies map[string]*local.Managed
 	if as.Meta == nil {
 		as.Meta = map[string]string{}
 	}
-	// Attach Unmanaged Proxy config if exists
-	if s.Kind == structs.ServiceKindConnectProxy {
+	// Attach Unmanaged Proxy config if exists. Gateways are configured
+	// through it as well.
+	if s.Kind != structs.ServiceKindTypical {
 		as.Proxy = s.Proxy.ToAPI()
+	}
+	if s.Kind == structs.ServiceKindConnectProxy {
 		// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
 		// Also set the deprecated ProxyDestination
 		as.ProxyDestination = as.Proxy.DestinationServiceName
@@ -214,6 +237,18 @@ func buildAgentService(s *structs.NodeService, proxies map[string]*local.Managed
 	return as
 }
 
+// taggedAddressesToAPI converts the tagged addresses of a service.
+func taggedAddressesToAPI(addrs map[string]structs.ServiceAddress) map[string]api.ServiceAddress {
+	if len(addrs) == 0 {
+		return nil
+	}
+	out := make(map[string]api.ServiceAddress, len(addrs))
+	for tag, addr := range addrs {
+		out[tag] = api.ServiceAddress{Address: addr.Address, Port: addr.Port}
+	}
+	return out
+}
+
 func (s *HTTPServer) AgentServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any.
 	var token string
@@ -359,7 +394,7 @@ func (s *HTTPServer) AgentService(resp http.ResponseWriter, req *http.Request) (
 				}
 			}
 
-			if svc.Kind == structs.ServiceKindConnectProxy {
+			if svc.Kind != structs.ServiceKindTypical {
 				proxy = svc.Proxy.ToAPI()
 			}
 
@@ -380,6 +415,7 @@ func (s *HTTPServer) AgentService(resp http.ResponseWriter, req *http.Request) (
 				Meta:              svc.Meta,
 				Port:              svc.Port,
 				Address:           svc.Address,
+				TaggedAddresses:   taggedAddressesToAPI(svc.TaggedAddresses),
 				EnableTagOverride: svc.EnableTagOverride,
 				Weights:           weights,
 				Proxy:             proxy,
@@ -398,6 +434,25 @@ func (s *HTTPServer) AgentService(resp http.ResponseWriter, req *http.Request) (
 		})
 }
 
+// agentCheck is a check of the local agent along with the tracking of its
+// status transitions, which isn't synced to the servers.
+type agentCheck struct {
+	*structs.HealthCheck
+
+	// StatusChangeTime is the last time the status reported by the check
+	// changed.
+	StatusChangeTime time.Time
+
+	// FlapScore counts the recent status transitions of the check, and
+	// Flapping is set when there were enough of them.
+	FlapScore float64
+	Flapping  bool
+
+	// PendingStatus is the latest status of a flapping check while it is
+	// held down and not synced yet.
+	PendingStatus string `json:",omitempty"`
+}
+
 func (s *HTTPServer) AgentChecks(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any.
 	var token string
@@ -408,16 +463,28 @@ func (s *HTTPServer) AgentChecks(resp http.ResponseWriter, req *http.Request) (i
 		return nil, err
 	}
 
-	// Use empty list instead of nil
+	states := s.agent.State.CheckStates()
+	out := make(map[types.CheckID]*agentCheck, len(checks))
 	for id, c := range checks {
+		// Use empty list instead of nil
 		if c.ServiceTags == nil {
 			clone := *c
 			clone.ServiceTags = make([]string, 0)
-			checks[id] = &clone
+			c = &clone
 		}
+		check := &agentCheck{HealthCheck: c}
+		if state, ok := states[id]; ok {
+			check.StatusChangeTime = state.StatusChangeTime
+			check.FlapScore = state.FlapScore()
+			check.Flapping = state.Flapping()
+			if state.HoldDown != nil {
+				check.PendingStatus = state.PendingStatus
+			}
+		}
+		out[id] = check
 	}
 
-	return checks, nil
+	return out, nil
 }
 
 func (s *HTTPServer) AgentMembers(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -692,12 +759,6 @@ func (s *HTTPServer) AgentCheckUpdate(resp http.ResponseWriter, req *http.Reques
 		return nil, nil
 	}
 
-	total := len(update.Output)
-	if total > checks.BufSize {
-		update.Output = fmt.Sprintf("%s ... (captured %d of %d bytes)",
-			update.Output[:checks.BufSize], checks.BufSize, total)
-	}
-
 	checkID := types.CheckID(strings.TrimPrefix(req.URL.Path, "/v1/agent/check/update/"))
 
 	// Get the provided token, if any, and vet against any ACL policies.
@@ -845,6 +906,7 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 		// and why we should get rid of it.
 		config.TranslateKeys(rawMap, map[string]string{
 			"enable_tag_override": "EnableTagOverride",
+			"tagged_addresses":    "TaggedAddresses",
 			// Managed Proxy Config
 			"exec_mode": "ExecMode",
 			// Proxy Upstreams
@@ -858,8 +920,17 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 			"destination_service_id":   "DestinationServiceID",
 			"local_service_port":       "LocalServicePort",
 			"local_service_address":    "LocalServiceAddress",
+			// Transparent Proxy Config
+			"transparent_proxy":      "TransparentProxy",
+			"outbound_listener_port": "OutboundListenerPort",
+			"exclude_inbound_ports":  "ExcludeInboundPorts",
+			"exclude_outbound_ports": "ExcludeOutboundPorts",
+			"exclude_outbound_cidrs": "ExcludeOutboundCIDRs",
+			"exclude_uids":           "ExcludeUIDs",
 			// SidecarService
 			"sidecar_service": "SidecarService",
+			"inherit_tags":    "InheritTags",
+			"inherit_meta":    "InheritMeta",
 
 			// DON'T Recurse into these opaque config maps or we might mangle user's
 			// keys. Note empty canonical is a special sentinel to prevent recursion.
@@ -878,6 +949,10 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 			"Connect.SidecarService.Meta":                   "",
 			"Connect.SidecarService.Proxy.Config":           "",
 			"Connect.SidecarService.Proxy.Upstreams.config": "",
+
+			// The arguments of check plugins are opaque too.
+			"Check.PluginArgs":  "",
+			"Checks.PluginArgs": "",
 		})
 
 		for k, v := range rawMap {
@@ -1096,8 +1171,11 @@ func (s *HTTPServer) AgentServiceMaintenance(resp http.ResponseWriter, req *http
 	}
 
 	if enable {
-		reason := params.Get("reason")
-		if err = s.agent.EnableServiceMaintenance(serviceID, reason, token); err != nil {
+		maint, ok := parseMaintenance(resp, params)
+		if !ok {
+			return nil, nil
+		}
+		if err = s.agent.EnableServiceMaintenanceWithMeta(serviceID, maint, token); err != nil {
 			resp.WriteHeader(http.StatusNotFound)
 			fmt.Fprint(resp, err.Error())
 			return nil, nil
@@ -1142,7 +1220,11 @@ func (s *HTTPServer) AgentNodeMaintenance(resp http.ResponseWriter, req *http.Re
 	}
 
 	if enable {
-		s.agent.EnableNodeMaintenance(params.Get("reason"), token)
+		maint, ok := parseMaintenance(resp, params)
+		if !ok {
+			return nil, nil
+		}
+		s.agent.EnableNodeMaintenanceWithMeta(maint, token)
 	} else {
 		s.agent.DisableNodeMaintenance()
 	}
@@ -1150,6 +1232,27 @@ func (s *HTTPServer) AgentNodeMaintenance(resp http.ResponseWriter, req *http.Re
 	return nil, nil
 }
 
+// parseMaintenance builds the maintenance metadata from the reason, actor
+// and duration query parameters. It returns false and writes a response if
+// the parameters are invalid.
+func parseMaintenance(resp http.ResponseWriter, params url.Values) (*structs.CheckMaintenance, bool) {
+	maint := &structs.CheckMaintenance{
+		Reason: params.Get("reason"),
+		Actor:  params.Get("actor"),
+	}
+	if raw := params.Get("duration"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid value for duration: %q", raw)
+			return nil, false
+		}
+		expires := time.Now().Add(d).UTC()
+		maint.Expires = &expires
+	}
+	return maint, true
+}
+
 func (s *HTTPServer) AgentMonitor(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any, and enforce agent policy.
 	var token string
@@ -1293,6 +1396,9 @@ func (s *HTTPServer) AgentToken(resp http.ResponseWriter, req *http.Request) (in
 	case "acl_replication_token", "replication":
 		s.agent.tokens.UpdateReplicationToken(args.Token, token_store.TokenSourceAPI)
 
+	case "dns":
+		s.agent.tokens.UpdateDNSToken(args.Token, token_store.TokenSourceAPI)
+
 	default:
 		resp.WriteHeader(http.StatusNotFound)
 		fmt.Fprintf(resp, "Token %q is unknown", target)
@@ -1318,13 +1424,22 @@ func (s *HTTPServer) AgentToken(resp http.ResponseWriter, req *http.Request) (in
 			tokens.Replication = tok
 		}
 
+		if tok, source := s.agent.tokens.DNSTokenAndSource(); tok != "" && source == token_store.TokenSourceAPI {
+			tokens.DNS = tok
+		}
+
 		data, err := json.Marshal(tokens)
 		if err != nil {
 			s.agent.logger.Printf("[WARN] agent: failed to persist tokens - %v", err)
 			return nil, fmt.Errorf("Failed to marshal tokens for persistence: %v", err)
 		}
+		tokensFullPath := filepath.Join(s.agent.config.DataDir, tokensPath)
+		if data, err = s.agent.dataDirCipher.seal(tokensFullPath, data); err != nil {
+			s.agent.logger.Printf("[WARN] agent: failed to persist tokens - %v", err)
+			return nil, fmt.Errorf("Failed to encrypt tokens for persistence: %v", err)
+		}
 
-		if err := file.WriteAtomicWithPerms(filepath.Join(s.agent.config.DataDir, tokensPath), data, 0600); err != nil {
+		if err := file.WriteAtomicWithPerms(tokensFullPath, data, 0600); err != nil {
 			s.agent.logger.Printf("[WARN] agent: failed to persist tokens - %v", err)
 			return nil, fmt.Errorf("Failed to persist tokens - %v", err)
 		}
@@ -1536,6 +1651,178 @@ func (s *HTTPServer) AgentConnectProxyConfig(resp http.ResponseWriter, req *http
 		})
 }
 
+// connectProxySnapshot is the debug view of the config state the agent has
+// computed for a proxy. Certificates and keys are left out.
+type connectProxySnapshot struct {
+	ProxyID           string
+	Address           string
+	Port              int
+	Proxy             structs.ConnectProxyConfig
+	Valid             bool
+	Roots             *connectProxySnapshotRoots
+	Leaf              *connectProxySnapshotLeaf
+	Intentions        structs.Intentions
+	UpstreamEndpoints map[string]structs.CheckServiceNodes
+}
+
+type connectProxySnapshotRoots struct {
+	ActiveRootID string
+	TrustDomain  string
+	Roots        []connectProxySnapshotRoot
+}
+
+type connectProxySnapshotRoot struct {
+	ID        string
+	Name      string
+	Active    bool
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+type connectProxySnapshotLeaf struct {
+	SerialNumber string
+	Service      string
+	ServiceURI   string
+	ValidAfter   time.Time
+	ValidBefore  time.Time
+	ExpiresIn    string
+}
+
+// GET /v1/agent/connect/proxy-config/:proxy_service_id
+//
+// Returns the config state the agent has computed for the identified proxy,
+// including incomplete state, to debug why a proxy didn't receive its
+// config. Only available if enable_debug is set.
+func (s *HTTPServer) AgentConnectProxySnapshot(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if !s.agent.debugEnabled() {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(resp, "Proxy config debugging requires enable_debug")
+		return nil, nil
+	}
+
+	// Fetch the ACL token, if any, and enforce operator policy like the other
+	// debug endpoints.
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil && !rule.OperatorRead() {
+		return nil, acl.ErrPermissionDenied
+	}
+
+	id := strings.TrimPrefix(req.URL.Path, "/v1/agent/connect/proxy-config/")
+	snap := s.agent.proxyConfig.DebugSnapshot(id)
+	if snap == nil {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(resp, "unknown proxy service ID: %s", id)
+		return nil, nil
+	}
+
+	out := &connectProxySnapshot{
+		ProxyID:           snap.ProxyID,
+		Address:           snap.Address,
+		Port:              snap.Port,
+		Proxy:             snap.Proxy,
+		Valid:             snap.Valid(),
+		Intentions:        snap.Intentions,
+		UpstreamEndpoints: snap.UpstreamEndpoints,
+	}
+	if snap.Roots != nil {
+		out.Roots = &connectProxySnapshotRoots{
+			ActiveRootID: snap.Roots.ActiveRootID,
+			TrustDomain:  snap.Roots.TrustDomain,
+		}
+		for _, r := range snap.Roots.Roots {
+			out.Roots.Roots = append(out.Roots.Roots, connectProxySnapshotRoot{
+				ID:        r.ID,
+				Name:      r.Name,
+				Active:    r.Active,
+				NotBefore: r.NotBefore,
+				NotAfter:  r.NotAfter,
+			})
+		}
+	}
+	if leaf := snap.Leaf; leaf != nil {
+		out.Leaf = &connectProxySnapshotLeaf{
+			SerialNumber: leaf.SerialNumber,
+			Service:      leaf.Service,
+			ServiceURI:   leaf.ServiceURI,
+			ValidAfter:   leaf.ValidAfter,
+			ValidBefore:  leaf.ValidBefore,
+			ExpiresIn:    time.Until(leaf.ValidBefore).Round(time.Second).String(),
+		}
+	}
+	return out, nil
+}
+
+// GET /v1/agent/connect/redirect-traffic/:proxy_service_id
+//
+// Returns the ports, exclusions and upstream virtual IPs needed to redirect
+// the traffic of a service to the identified local proxy.
+func (s *HTTPServer) AgentConnectRedirectTraffic(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var token string
+	s.parseToken(req, &token)
+
+	id := strings.TrimPrefix(req.URL.Path, "/v1/agent/connect/redirect-traffic/")
+	proxy := s.agent.State.Service(id)
+	if proxy == nil || proxy.Kind != structs.ServiceKindConnectProxy {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(resp, "unknown proxy service ID: %s", id)
+		return nil, nil
+	}
+
+	if err := s.agent.vetServiceUpdate(token, id); err != nil {
+		return nil, err
+	}
+
+	out := &api.AgentTrafficRedirect{
+		ProxyID:      id,
+		InboundPort:  proxy.Port,
+		OutboundPort: structs.TransparentProxyOutboundListenerPortDefault,
+	}
+	if tp := proxy.Proxy.TransparentProxy; tp != nil {
+		if tp.OutboundListenerPort != 0 {
+			out.OutboundPort = tp.OutboundListenerPort
+		}
+		out.ExcludeInboundPorts = tp.ExcludeInboundPorts
+		out.ExcludeOutboundPorts = tp.ExcludeOutboundPorts
+		out.ExcludeOutboundCIDRs = tp.ExcludeOutboundCIDRs
+		out.ExcludeUIDs = tp.ExcludeUIDs
+	}
+
+	// Look up the virtual IPs of the upstream services.
+	for _, u := range proxy.Proxy.Upstreams {
+		if u.DestinationType != "" && u.DestinationType != structs.UpstreamDestTypeService {
+			continue
+		}
+
+		args := structs.ServiceSpecificRequest{
+			Datacenter:   u.Datacenter,
+			ServiceName:  u.DestinationName,
+			QueryOptions: structs.QueryOptions{Token: token},
+		}
+		if args.Datacenter == "" {
+			args.Datacenter = s.agent.config.Datacenter
+		}
+
+		var reply structs.IndexedServiceVirtualIP
+		if err := s.agent.RPC("Catalog.VirtualIPForService", &args, &reply); err != nil {
+			return nil, err
+		}
+		if reply.VirtualIP == nil {
+			continue
+		}
+		if out.VirtualIPs == nil {
+			out.VirtualIPs = make(map[string]string)
+		}
+		out.VirtualIPs[u.DestinationName] = reply.VirtualIP.IP
+	}
+
+	return out, nil
+}
+
 type agentLocalBlockingFunc func(ws memdb.WatchSet) (string, interface{}, error)
 
 // agentLocalBlockingQuery performs a blocking query in a generic way against
@@ -1639,8 +1926,8 @@ type connectAuthorizeResp struct {
 // GET /v1/agent/host
 //
 // Retrieves information about resources available and in-use for the
-// host the agent is running on such as CPU, memory, and disk usage. Requires
-// a operator:read ACL token.
+// host the agent is running on such as CPU, memory, and the disk usage of
+// the data directory. Requires a operator:read ACL token.
 func (s *HTTPServer) AgentHost(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any, and enforce agent policy.
 	var token string
@@ -1654,5 +1941,5 @@ func (s *HTTPServer) AgentHost(resp http.ResponseWriter, req *http.Request) (int
 		return nil, acl.ErrPermissionDenied
 	}
 
-	return debug.CollectHostInfo(), nil
+	return debug.CollectHostInfo(s.agent.config.DataDir), nil
 }
@@ -13,6 +13,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -35,6 +36,7 @@ import (
 	"github.com/mitchellh/copystructure"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 func makeReadOnlyAgentACL(t *testing.T, srv *HTTPServer) string {
@@ -275,7 +277,7 @@ func TestAgent_Service(t *testing.T) {
 		Service:     "web-sidecar-proxy",
 		Port:        8000,
 		Proxy:       expectProxy.ToAPI(),
-		ContentHash: "3442362e971c43d1",
+		ContentHash: "ead3b039c6ce9d28",
 		Weights: api.AgentWeights{
 			Passing: 1,
 			Warning: 1,
@@ -285,14 +287,14 @@ func TestAgent_Service(t *testing.T) {
 	// Copy and modify
 	updatedResponse := *expectedResponse
 	updatedResponse.Port = 9999
-	updatedResponse.ContentHash = "90b5c19bf0f5073"
+	updatedResponse.ContentHash = "d79ada0eeedd8e8a"
 
 	// Simple response for non-proxy service registered in TestAgent config
 	expectWebResponse := &api.AgentService{
 		ID:          "web",
 		Service:     "web",
 		Port:        8181,
-		ContentHash: "69351c1ac865b034",
+		ContentHash: "f6e4f875dd7c0de8",
 		Weights: api.AgentWeights{
 			Passing: 1,
 			Warning: 1,
@@ -620,13 +622,16 @@ func TestAgent_Checks(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Err: %v", err)
 	}
-	val := obj.(map[types.CheckID]*structs.HealthCheck)
+	val := obj.(map[types.CheckID]*agentCheck)
 	if len(val) != 1 {
 		t.Fatalf("bad checks: %v", obj)
 	}
 	if val["mysql"].Status != api.HealthPassing {
 		t.Fatalf("bad check: %v", obj)
 	}
+	if val["mysql"].StatusChangeTime.IsZero() || val["mysql"].Flapping {
+		t.Fatalf("bad check: %v", obj)
+	}
 }
 
 func TestAgent_HealthServiceByID(t *testing.T) {
@@ -1087,7 +1092,7 @@ func TestAgent_Checks_ACLFilter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Err: %v", err)
 		}
-		val := obj.(map[types.CheckID]*structs.HealthCheck)
+		val := obj.(map[types.CheckID]*agentCheck)
 		if len(val) != 0 {
 			t.Fatalf("bad checks: %v", obj)
 		}
@@ -1099,7 +1104,7 @@ func TestAgent_Checks_ACLFilter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Err: %v", err)
 		}
-		val := obj.(map[types.CheckID]*structs.HealthCheck)
+		val := obj.(map[types.CheckID]*agentCheck)
 		if len(val) != 1 {
 			t.Fatalf("bad checks: %v", obj)
 		}
@@ -1144,6 +1149,53 @@ func TestAgent_Self(t *testing.T) {
 	}
 }
 
+func TestAgent_Self_Full(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		limits = {
+			rpc_rate = 1
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	a.SetConfigOrigins(map[string]string{"limits.rpc_rate": "a.hcl"})
+
+	// The origins are only returned with ?full.
+	req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+	obj, err := a.srv.AgentSelf(nil, req)
+	require.NoError(t, err)
+	require.Nil(t, obj.(Self).ConfigOrigins)
+
+	req, _ = http.NewRequest("GET", "/v1/agent/self?full", nil)
+	obj, err = a.srv.AgentSelf(nil, req)
+	require.NoError(t, err)
+	val := obj.(Self)
+	require.Equal(t, map[string]string{"limits.rpc_rate": "a.hcl"}, val.ConfigOrigins)
+	require.Equal(t, rate.Limit(1), val.DebugConfig["RPCRateLimit"])
+
+	// The full view reflects the last reload.
+	cfg2 := TestConfig(config.Source{
+		Name:   "reload",
+		Format: "hcl",
+		Data: `
+			data_dir = "` + a.Config.DataDir + `"
+			node_id = "` + string(a.Config.NodeID) + `"
+			node_name = "` + a.Config.NodeName + `"
+			limits = {
+				rpc_rate = 2
+			}
+		`,
+	})
+	require.NoError(t, a.ReloadConfig(cfg2))
+	a.SetConfigOrigins(map[string]string{"limits.rpc_rate": "b.hcl"})
+
+	obj, err = a.srv.AgentSelf(nil, req)
+	require.NoError(t, err)
+	val = obj.(Self)
+	require.Equal(t, map[string]string{"limits.rpc_rate": "b.hcl"}, val.ConfigOrigins)
+	require.Equal(t, rate.Limit(2), val.DebugConfig["RPCRateLimit"])
+}
+
 func TestAgent_Self_ACLDeny(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), TestACLConfig())
@@ -1275,6 +1327,65 @@ func TestAgent_Reload(t *testing.T) {
 	}
 }
 
+func TestAgent_Reload_Report(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			only_passing = false
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	require.Nil(t, a.ReloadReport())
+	require.NotEmpty(t, a.dnsServers)
+
+	cfg2 := TestConfig(config.Source{
+		Name:   "reload",
+		Format: "hcl",
+		Data: `
+			data_dir = "` + a.Config.DataDir + `"
+			node_id = "` + string(a.Config.NodeID) + `"
+			node_name = "` + a.Config.NodeName + `"
+
+			leave_on_terminate = true
+			dns_config {
+				only_passing = true
+				service_ttl {
+					"web" = "10s"
+				}
+			}
+			limits {
+				rpc_max_conns = 100
+			}
+		`,
+	})
+	require.NoError(t, a.ReloadConfig(cfg2))
+	require.Equal(t, 100, a.config.RPCMaxConns)
+
+	for _, srv := range a.dnsServers {
+		require.True(t, srv.currentConfig().OnlyPassing)
+		ttl, ok := srv.GetTTLForService("web")
+		require.True(t, ok)
+		require.Equal(t, 10*time.Second, ttl)
+	}
+
+	r := a.ReloadReport()
+	require.NotNil(t, r)
+	require.Contains(t, r.Applied, "DNSOnlyPassing")
+	require.Contains(t, r.Applied, "DNSServiceTTL")
+	require.Contains(t, r.Applied, "RPCMaxConns")
+	require.Contains(t, r.RequiresRestart, "LeaveOnTerm")
+	require.NotContains(t, r.RequiresRestart, "DNSOnlyPassing")
+
+	// Reloading the same config again applies nothing new but the restart
+	// is still pending.
+	require.NoError(t, a.ReloadConfig(cfg2))
+	r = a.ReloadReport()
+	require.Empty(t, r.Applied)
+	require.Contains(t, r.RequiresRestart, "LeaveOnTerm")
+}
+
 func TestAgent_Reload_ACLDeny(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), TestACLConfig())
@@ -1470,13 +1581,26 @@ func TestAgent_Join_ACLDeny(t *testing.T) {
 	})
 }
 
-type mockNotifier struct{ s string }
+type mockNotifier struct {
+	sync.Mutex
+	s      string
+	states []string
+}
 
 func (n *mockNotifier) Notify(state string) error {
+	n.Lock()
+	defer n.Unlock()
 	n.s = state
+	n.states = append(n.states, state)
 	return nil
 }
 
+func (n *mockNotifier) States() []string {
+	n.Lock()
+	defer n.Unlock()
+	return append([]string(nil), n.states...)
+}
+
 func TestAgent_JoinLANNotify(t *testing.T) {
 	t.Parallel()
 	a1 := NewTestAgent(t, t.Name(), "")
@@ -1490,7 +1614,7 @@ func TestAgent_JoinLANNotify(t *testing.T) {
 	defer a2.Shutdown()
 
 	notif := &mockNotifier{}
-	a1.joinLANNotifier = notif
+	a1.systemdNotifier = notif
 
 	addr := fmt.Sprintf("127.0.0.1:%d", a2.Config.SerfPortLAN)
 	_, err := a1.JoinLAN([]string{addr})
@@ -1503,6 +1627,32 @@ func TestAgent_JoinLANNotify(t *testing.T) {
 	}
 }
 
+func TestAgent_SystemdNotify(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	notif := &mockNotifier{}
+	a.systemdNotifier = notif
+
+	// Reloads are reported.
+	require.NoError(t, a.ReloadConfig(a.Config))
+	require.Equal(t, []string{"RELOADING=1", "READY=1"}, notif.States())
+
+	// The watchdog keeps being pinged.
+	go a.systemdWatchdog(10 * time.Millisecond)
+	retry.Run(t, func(r *retry.R) {
+		states := notif.States()
+		if got := states[len(states)-1]; got != "WATCHDOG=1" {
+			r.Fatalf("got %q want WATCHDOG=1", got)
+		}
+	})
+
+	a.ShutdownAgent()
+	require.Contains(t, notif.States(), "STOPPING=1")
+}
+
 func TestAgent_Leave(t *testing.T) {
 	t.Parallel()
 	a1 := NewTestAgent(t, t.Name(), "")
@@ -2574,6 +2724,59 @@ func TestAgent_RegisterService_InvalidAddress(t *testing.T) {
 	}
 }
 
+func TestAgent_RegisterService_TaggedAddresses(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	json := `
+	{
+		"name": "web",
+		"port": 8000,
+		"tagged_addresses": {
+			"lan": { "address": "10.0.0.1", "port": 8000 },
+			"wan": { "address": "198.18.0.1", "port": 80 }
+		}
+	}`
+	req, _ := http.NewRequest("PUT", "/v1/agent/service/register", strings.NewReader(json))
+	rr := httptest.NewRecorder()
+	_, err := a.srv.AgentRegisterService(rr, req)
+	require.NoError(t, err)
+	require.Equal(t, 200, rr.Code, "body: %s", rr.Body)
+
+	expect := map[string]structs.ServiceAddress{
+		"lan": {Address: "10.0.0.1", Port: 8000},
+		"wan": {Address: "198.18.0.1", Port: 80},
+	}
+	require.Equal(t, expect, a.State.Service("web").TaggedAddresses)
+
+	req, _ = http.NewRequest("GET", "/v1/agent/service/web", nil)
+	obj, err := a.srv.AgentService(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]api.ServiceAddress{
+		"lan": {Address: "10.0.0.1", Port: 8000},
+		"wan": {Address: "198.18.0.1", Port: 80},
+	}, obj.(*api.AgentService).TaggedAddresses)
+
+	t.Run("invalid", func(t *testing.T) {
+		args := &structs.ServiceDefinition{
+			Name: "db",
+			Port: 5432,
+			TaggedAddresses: map[string]structs.ServiceAddress{
+				"wan": {Address: "0.0.0.0", Port: 5432},
+			},
+		}
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/register", jsonReader(args))
+		rr := httptest.NewRecorder()
+		_, err := a.srv.AgentRegisterService(rr, req)
+		require.NoError(t, err)
+		require.Equal(t, 400, rr.Code)
+		require.Contains(t, rr.Body.String(), `Invalid address "0.0.0.0" of tagged address "wan"`)
+		require.Nil(t, a.State.Service("db"))
+	})
+}
+
 // This tests local agent service registration with a managed proxy.
 func TestAgent_RegisterService_ManagedConnectProxy(t *testing.T) {
 	t.Parallel()
@@ -3777,6 +3980,60 @@ func TestAgent_ServiceMaintenance_Enable(t *testing.T) {
 	}
 }
 
+func TestAgent_ServiceMaintenance_EnableWithExpiry(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Register the service
+	service := &structs.NodeService{
+		ID:      "test",
+		Service: "test",
+	}
+	if err := a.AddService(service, nil, false, "", ConfigSourceLocal); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An invalid duration is rejected
+	req, _ := http.NewRequest("PUT", "/v1/agent/service/maintenance/test?enable=true&duration=nope", nil)
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.AgentServiceMaintenance(resp, req); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp.Code != 400 {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+
+	// Force the service into maintenance mode with an expiry
+	req, _ = http.NewRequest("PUT", "/v1/agent/service/maintenance/test?enable=true&reason=broken&actor=ops&duration=200ms", nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.AgentServiceMaintenance(resp, req); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+
+	// Ensure the metadata was recorded
+	checkID := serviceMaintCheckID("test")
+	check, ok := a.State.Checks()[checkID]
+	if !ok {
+		t.Fatalf("should have registered maintenance check")
+	}
+	require.NotNil(t, check.Maintenance)
+	require.Equal(t, "broken", check.Maintenance.Reason)
+	require.Equal(t, "ops", check.Maintenance.Actor)
+	require.NotNil(t, check.Maintenance.Expires)
+
+	// Ensure the maintenance mode is cleared once it expires
+	retry.Run(t, func(r *retry.R) {
+		if _, ok := a.State.Checks()[checkID]; ok {
+			r.Fatal("maintenance check should have expired")
+		}
+	})
+}
+
 func TestAgent_ServiceMaintenance_Disable(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -4085,6 +4342,7 @@ func TestAgent_Token(t *testing.T) {
 				agent = ""
 				agent_master = ""
 				replication = ""
+				dns = ""
 			}
 		}
 	`)
@@ -4100,6 +4358,8 @@ func TestAgent_Token(t *testing.T) {
 		masterSource tokenStore.TokenSource
 		repl         string
 		replSource   tokenStore.TokenSource
+		dns          string
+		dnsSource    tokenStore.TokenSource
 	}
 
 	resetTokens := func(init tokens) {
@@ -4107,6 +4367,7 @@ func TestAgent_Token(t *testing.T) {
 		a.tokens.UpdateAgentToken(init.agent, init.agentSource)
 		a.tokens.UpdateAgentMasterToken(init.master, init.masterSource)
 		a.tokens.UpdateReplicationToken(init.repl, init.replSource)
+		a.tokens.UpdateDNSToken(init.dns, init.dnsSource)
 	}
 
 	body := func(token string) io.Reader {
@@ -4147,7 +4408,7 @@ func TestAgent_Token(t *testing.T) {
 			body:      body("U"),
 			code:      http.StatusOK,
 			raw:       tokens{user: "U", userSource: tokenStore.TokenSourceAPI},
-			effective: tokens{user: "U", agent: "U"},
+			effective: tokens{user: "U", agent: "U", dns: "U"},
 		},
 		{
 			name:      "set default",
@@ -4156,7 +4417,7 @@ func TestAgent_Token(t *testing.T) {
 			body:      body("U"),
 			code:      http.StatusOK,
 			raw:       tokens{user: "U", userSource: tokenStore.TokenSourceAPI},
-			effective: tokens{user: "U", agent: "U"},
+			effective: tokens{user: "U", agent: "U", dns: "U"},
 		},
 		{
 			name:      "set agent legacy",
@@ -4166,7 +4427,7 @@ func TestAgent_Token(t *testing.T) {
 			code:      http.StatusOK,
 			init:      tokens{user: "U", agent: "U"},
 			raw:       tokens{user: "U", agent: "A", agentSource: tokenStore.TokenSourceAPI},
-			effective: tokens{user: "U", agent: "A"},
+			effective: tokens{user: "U", agent: "A", dns: "U"},
 		},
 		{
 			name:      "set agent",
@@ -4176,7 +4437,7 @@ func TestAgent_Token(t *testing.T) {
 			code:      http.StatusOK,
 			init:      tokens{user: "U", agent: "U"},
 			raw:       tokens{user: "U", agent: "A", agentSource: tokenStore.TokenSourceAPI},
-			effective: tokens{user: "U", agent: "A"},
+			effective: tokens{user: "U", agent: "A", dns: "U"},
 		},
 		{
 			name:      "set master legacy",
@@ -4214,6 +4475,15 @@ func TestAgent_Token(t *testing.T) {
 			raw:       tokens{repl: "R", replSource: tokenStore.TokenSourceAPI},
 			effective: tokens{repl: "R"},
 		},
+		{
+			name:      "set dns",
+			method:    "PUT",
+			url:       "dns?token=root",
+			body:      body("D"),
+			code:      http.StatusOK,
+			raw:       tokens{dns: "D", dnsSource: tokenStore.TokenSourceAPI},
+			effective: tokens{dns: "D"},
+		},
 		{
 			name:   "clear user legacy",
 			method: "PUT",
@@ -4286,6 +4556,15 @@ func TestAgent_Token(t *testing.T) {
 			init:   tokens{repl: "R"},
 			raw:    tokens{replSource: tokenStore.TokenSourceAPI},
 		},
+		{
+			name:   "clear dns",
+			method: "PUT",
+			url:    "dns?token=root",
+			body:   body(""),
+			code:   http.StatusOK,
+			init:   tokens{dns: "D"},
+			raw:    tokens{dnsSource: tokenStore.TokenSourceAPI},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -4300,6 +4579,7 @@ func TestAgent_Token(t *testing.T) {
 			require.Equal(t, tt.effective.agent, a.tokens.AgentToken())
 			require.Equal(t, tt.effective.master, a.tokens.AgentMasterToken())
 			require.Equal(t, tt.effective.repl, a.tokens.ReplicationToken())
+			require.Equal(t, tt.effective.dns, a.tokens.DNSToken())
 
 			tok, src := a.tokens.UserTokenAndSource()
 			require.Equal(t, tt.raw.user, tok)
@@ -4316,6 +4596,10 @@ func TestAgent_Token(t *testing.T) {
 			tok, src = a.tokens.ReplicationTokenAndSource()
 			require.Equal(t, tt.raw.repl, tok)
 			require.Equal(t, tt.raw.replSource, src)
+
+			tok, src = a.tokens.DNSTokenAndSource()
+			require.Equal(t, tt.raw.dns, tok)
+			require.Equal(t, tt.raw.dnsSource, src)
 		})
 	}
 
@@ -5330,6 +5614,7 @@ func makeTelemetryDefaults(targetID string) lib.TelemetryConfig {
 	return lib.TelemetryConfig{
 		FilterDefault: true,
 		MetricsPrefix: "consul.proxy." + targetID,
+		OTLPInterval:  10 * time.Second,
 	}
 }
 
@@ -5488,6 +5773,7 @@ func TestAgentConnectProxyConfig_ConfigHandling(t *testing.T) {
 					FilterDefault: true,
 					MetricsPrefix: "consul.proxy." + reg.ID,
 					StatsiteAddr:  "localhost:8989",
+					OTLPInterval:  10 * time.Second,
 				},
 			},
 		},
@@ -5542,6 +5828,7 @@ func TestAgentConnectProxyConfig_ConfigHandling(t *testing.T) {
 					FilterDefault: true,
 					MetricsPrefix: "foo",
 					StatsiteAddr:  "stats.it:10101",
+					OTLPInterval:  10 * time.Second,
 				},
 			},
 		},
@@ -5768,6 +6055,129 @@ func TestAgentConnectAuthorize_idNotService(t *testing.T) {
 	assert.Contains(err.Error(), "ClientCertURI not a valid Service identifier")
 }
 
+// Test workloads of a federated trust domain
+func TestAgentConnectAuthorize_federated(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	target := "db"
+
+	// Trust the bundle of another trust domain
+	federatedCA := connect.TestCA(t, nil)
+	{
+		req := structs.CARequest{
+			Datacenter: "dc1",
+			Config: &structs.CAConfiguration{
+				Provider: "consul",
+				Config: map[string]interface{}{
+					"PrivateKey":     "",
+					"RootCert":       "",
+					"RotationPeriod": 90 * 24 * time.Hour,
+					"FederatedTrustBundles": []map[string]interface{}{
+						{
+							"TrustDomain": "spire.example.org",
+							"RootCerts":   []string{federatedCA.RootCert},
+						},
+					},
+				},
+			},
+		}
+		var reply interface{}
+		require.NoError(a.RPC("ConnectCA.ConfigurationSet", &req, &reply))
+	}
+
+	// Deny a federated workload and allow the Consul service "web"
+	for _, source := range []struct {
+		Type   structs.IntentionSourceType
+		Name   string
+		Action structs.IntentionAction
+	}{
+		{structs.IntentionSourceSPIFFE, "spiffe://spire.example.org/ns/prod/sa/api", structs.IntentionActionDeny},
+		{structs.IntentionSourceConsul, "web", structs.IntentionActionAllow},
+	} {
+		req := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpCreate,
+			Intention:  structs.TestIntention(t),
+		}
+		req.Intention.SourceNS = structs.IntentionDefaultNamespace
+		req.Intention.SourceType = source.Type
+		req.Intention.SourceName = source.Name
+		req.Intention.DestinationNS = structs.IntentionDefaultNamespace
+		req.Intention.DestinationName = target
+		req.Intention.Action = source.Action
+
+		var ixnID string
+		require.NoError(a.RPC("Intention.Apply", &req, &ixnID))
+	}
+
+	authorize := func(clientCertURI, clientCertPEM string) *connectAuthorizeResp {
+		args := &structs.ConnectAuthorizeRequest{
+			Target:        target,
+			ClientCertURI: clientCertURI,
+			ClientCertPEM: clientCertPEM,
+		}
+		req, _ := http.NewRequest("POST", "/v1/agent/connect/authorize", jsonReader(args))
+		resp := httptest.NewRecorder()
+		respRaw, err := a.srv.AgentConnectAuthorize(resp, req)
+		require.NoError(err)
+		return respRaw.(*connectAuthorizeResp)
+	}
+
+	// The federated workload matches its intention.
+	apiID := &connect.SpiffeIDExternal{TrustDomain: "spire.example.org", Path: "/ns/prod/sa/api"}
+	apiCert, _ := connect.TestLeafWithURI(t, apiID, federatedCA)
+	obj := authorize(apiID.URI().String(), apiCert)
+	require.False(obj.Authorized)
+	require.Contains(obj.Reason, "Matched")
+
+	// Its URI alone isn't enough.
+	obj = authorize(apiID.URI().String(), "")
+	require.False(obj.Authorized)
+	require.Contains(obj.Reason, "must present their client certificate")
+
+	// A federated workload can't pass as the Consul service "web" by its
+	// SPIFFE ID path.
+	fakeID := &connect.SpiffeIDExternal{TrustDomain: "spire.example.org", Path: "/ns/default/dc/dc1/svc/web"}
+	fakeCert, _ := connect.TestLeafWithURI(t, fakeID, federatedCA)
+	obj = authorize(fakeID.URI().String(), fakeCert)
+	require.True(obj.Authorized)
+	require.Contains(obj.Reason, "ACLs disabled")
+
+	// The federated CA can't issue certificates for Consul services.
+	var roots structs.IndexedCARoots
+	require.NoError(a.RPC("ConnectCA.Roots", &structs.DCSpecificRequest{Datacenter: "dc1"}, &roots))
+	webID := &connect.SpiffeIDService{
+		Host:       roots.TrustDomain,
+		Namespace:  "default",
+		Datacenter: "dc1",
+		Service:    "web",
+	}
+	forgedCert, _ := connect.TestLeafWithURI(t, webID, federatedCA)
+	obj = authorize(webID.URI().String(), forgedCert)
+	require.False(obj.Authorized)
+	require.Contains(obj.Reason, "not signed by the roots of the cluster")
+
+	// A certificate for a federated trust domain must be signed by its roots.
+	otherCA := connect.TestCA(t, nil)
+	forgedCert, _ = connect.TestLeafWithURI(t, apiID, otherCA)
+	obj = authorize(apiID.URI().String(), forgedCert)
+	require.False(obj.Authorized)
+	require.Contains(obj.Reason, `not signed by the roots of trust domain "spire.example.org"`)
+
+	// Certificates issued by the cluster are accepted.
+	csr, _ := connect.TestCSR(t, webID)
+	var issued structs.IssuedCert
+	require.NoError(a.RPC("ConnectCA.Sign", &structs.CASignRequest{Datacenter: "dc1", CSR: csr}, &issued))
+	obj = authorize(webID.URI().String(), issued.CertPEM)
+	require.True(obj.Authorized)
+	require.Contains(obj.Reason, "Matched")
+}
+
 // Test when there is an intention allowing the connection
 func TestAgentConnectAuthorize_allow(t *testing.T) {
 	t.Parallel()
@@ -6175,6 +6585,7 @@ func TestAgent_Host(t *testing.T) {
 	obj := respRaw.(*debug.HostInfo)
 	assert.NotNil(obj.CollectionTime)
 	assert.Empty(obj.Errors)
+	assert.Equal(a.Config.DataDir, obj.Disk.Path)
 }
 
 func TestAgent_HostBadACL(t *testing.T) {
@@ -6200,3 +6611,153 @@ func TestAgent_HostBadACL(t *testing.T) {
 	assert.Equal(http.StatusOK, resp.Code)
 	assert.Nil(respRaw)
 }
+
+func TestAgentConnectProxySnapshot(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "enable_debug = true")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	proxy := structs.TestNodeServiceProxy(t)
+	proxy.ID = "web-proxy"
+	require.NoError(t, a.AddService(proxy, nil, false, "", ConfigSourceLocal))
+
+	t.Run("unknown proxy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/connect/proxy-config/nope", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.AgentConnectProxySnapshot(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	retry.Run(t, func(r *retry.R) {
+		req, _ := http.NewRequest("GET", "/v1/agent/connect/proxy-config/web-proxy", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.AgentConnectProxySnapshot(resp, req)
+		if err != nil {
+			r.Fatal(err)
+		}
+		snap, ok := obj.(*connectProxySnapshot)
+		if !ok {
+			r.Fatalf("bad response: %v", obj)
+		}
+		if !snap.Valid {
+			r.Fatal("snapshot not complete yet")
+		}
+		if snap.Leaf == nil || snap.Leaf.Service != "web" {
+			r.Fatalf("bad leaf: %#v", snap.Leaf)
+		}
+		if snap.Roots == nil || len(snap.Roots.Roots) != 1 || !snap.Roots.Roots[0].Active {
+			r.Fatalf("bad roots: %#v", snap.Roots)
+		}
+		if snap.Proxy.DestinationServiceName != "web" {
+			r.Fatalf("bad proxy config: %#v", snap.Proxy)
+		}
+	})
+}
+
+func TestAgentConnectProxySnapshot_DebugDisabled(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "enable_debug = false")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/agent/connect/proxy-config/web-proxy", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.AgentConnectProxySnapshot(resp, req)
+	require.NoError(t, err)
+	require.Nil(t, obj)
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	require.Contains(t, resp.Body.String(), "enable_debug")
+}
+
+func TestAgentConnectRedirectTraffic(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Give the "db" upstream a virtual IP
+	{
+		args := structs.TestRegisterRequestProxy(t)
+		args.Service.Proxy.DestinationServiceName = "db"
+		var out struct{}
+		require.NoError(a.RPC("Catalog.Register", args, &out))
+	}
+
+	// Register the proxy with snake case keys like a service definition
+	{
+		body := bytes.NewBufferString(`{
+			"kind": "connect-proxy",
+			"id": "web-proxy",
+			"name": "web-proxy",
+			"port": 21000,
+			"proxy": {
+				"destination_service_name": "web",
+				"upstreams": [
+					{"destination_name": "db", "local_bind_port": 9191},
+					{"destination_name": "cache", "local_bind_port": 9192}
+				],
+				"transparent_proxy": {
+					"exclude_inbound_ports": [8080],
+					"exclude_outbound_cidrs": ["10.0.0.0/8"],
+					"exclude_uids": ["5678"]
+				}
+			}
+		}`)
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/register", body)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.AgentRegisterService(resp, req)
+		require.NoError(err)
+		require.Equal(http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	t.Run("unknown proxy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/connect/redirect-traffic/nope", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.AgentConnectRedirectTraffic(resp, req)
+		require.NoError(err)
+		require.Equal(http.StatusNotFound, resp.Code)
+	})
+
+	req, _ := http.NewRequest("GET", "/v1/agent/connect/redirect-traffic/web-proxy", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.AgentConnectRedirectTraffic(resp, req)
+	require.NoError(err)
+	require.Equal(&api.AgentTrafficRedirect{
+		ProxyID:              "web-proxy",
+		InboundPort:          21000,
+		OutboundPort:         structs.TransparentProxyOutboundListenerPortDefault,
+		ExcludeInboundPorts:  []int{8080},
+		ExcludeOutboundCIDRs: []string{"10.0.0.0/8"},
+		ExcludeUIDs:          []string{"5678"},
+		VirtualIPs:           map[string]string{"db": "240.0.0.1"},
+	}, obj)
+}
+
+func TestAgentConnectRedirectTraffic_ACLDeny(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	proxy := structs.TestNodeServiceProxy(t)
+	proxy.ID = "web-proxy"
+	require.NoError(a.AddService(proxy, nil, false, "", ConfigSourceLocal))
+
+	req, _ := http.NewRequest("GET", "/v1/agent/connect/redirect-traffic/web-proxy", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.AgentConnectRedirectTraffic(resp, req)
+	require.True(acl.IsErrPermissionDenied(err))
+
+	req, _ = http.NewRequest("GET", "/v1/agent/connect/redirect-traffic/web-proxy?token=root", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.AgentConnectRedirectTraffic(resp, req)
+	require.NoError(err)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -21,6 +22,7 @@ import (
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/systemd"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
 	"github.com/hashicorp/consul/testutil/retry"
@@ -109,6 +111,60 @@ func TestAgent_ConnectClusterIDConfig(t *testing.T) {
 	}
 }
 
+func TestAgent_startListeners_systemdSockets(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	a := &Agent{
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+		sockets: &systemd.Sockets{Listeners: []net.Listener{l}},
+	}
+	ln, err := a.startListeners([]net.Addr{l.Addr()})
+	require.NoError(t, err)
+	require.Len(t, ln, 1)
+
+	// The TCP listener passed by systemd is used with keep-alives enabled.
+	tl, ok := ln[0].(*tcpKeepAliveListener)
+	require.True(t, ok)
+	require.Equal(t, l, tl.TCPListener)
+	require.Empty(t, a.sockets.Listeners)
+}
+
+func TestAgent_listenHTTP_addrTLS(t *testing.T) {
+	t.Parallel()
+
+	// Require client certificates by default but not on the listener.
+	a := &TestAgent{
+		Name:   t.Name(),
+		UseTLS: true,
+		HCL: `
+			key_file = "../test/client_certs/server.key"
+			cert_file = "../test/client_certs/server.crt"
+			ca_file = "../test/client_certs/rootca.crt"
+			verify_incoming_https = true
+			addresses {
+				https = [{ address = "127.0.0.1" verify_incoming = false }]
+			}
+		`,
+	}
+	a.Start(t)
+	defer a.Shutdown()
+
+	// Connect without a client certificate.
+	tlscfg, err := api.SetupTLSConfig(&api.TLSConfig{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	transport := api.DefaultConfig().Transport
+	transport.TLSClientConfig = tlscfg
+	hc := &http.Client{Transport: transport}
+
+	resp, err := hc.Get(fmt.Sprintf("https://%s/v1/agent/self", a.srv.ln.Addr().String()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
 func TestAgent_StartStop(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -1051,6 +1107,42 @@ func TestAgent_AddCheck_ExecRemoteDisable(t *testing.T) {
 	}
 }
 
+func TestAgent_AddCheck_Plugin(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), `
+		check_plugins {
+			ldap = "/usr/local/bin/consul-check-ldap"
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "ldap",
+		Name:    "ldap bind",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		Plugin:     "snmp",
+		PluginArgs: map[string]string{"host": "switch1"},
+		Interval:   15 * time.Second,
+	}
+	err := a.AddCheck(health, chk, false, "", ConfigSourceLocal)
+	if err == nil || !strings.Contains(err.Error(), `Check plugin "snmp" is not configured on this agent`) {
+		t.Fatalf("err: %v", err)
+	}
+
+	chk.Plugin = "ldap"
+	if err := a.AddCheck(health, chk, false, "", ConfigSourceLocal); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := a.checkPlugins["ldap"]; !ok {
+		t.Fatalf("missing ldap plugin check")
+	}
+}
+
 func TestAgent_AddCheck_GRPC(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -1398,6 +1490,31 @@ func TestAgent_updateTTLCheck(t *testing.T) {
 	}
 }
 
+func TestAgent_updateTTLCheck_OutputLimit(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		check_output_max_size = 5
+		check_output_truncation = "head"
+	`)
+	defer a.Shutdown()
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "mem",
+		Name:    "memory util",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		TTL: 15 * time.Second,
+	}
+	require.NoError(t, a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+	require.NoError(t, a.updateTTLCheck("mem", api.HealthPassing, "0123456789"))
+
+	status := a.State.Checks()["mem"]
+	require.Equal(t, api.HealthPassing, status.Status)
+	require.Equal(t, "01234\n...\nCaptured 5 of 10 bytes", status.Output)
+}
+
 func TestAgent_PersistService(t *testing.T) {
 	t.Parallel()
 	dataDir := testutil.TempDir(t, "agent") // we manage the data dir
@@ -2437,6 +2554,41 @@ func TestAgent_Service_MaintenanceMode(t *testing.T) {
 	}
 }
 
+func TestAgent_Leave_DrainServices(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		service_drain_time = "50ms"
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	svc := &structs.NodeService{
+		ID:      "redis",
+		Service: "redis",
+		Port:    8000,
+	}
+	require.NoError(t, a.AddService(svc, nil, false, "", ConfigSourceLocal))
+	require.NoError(t, a.State.SyncFull())
+
+	a.drainServices(a.config.ServiceDrainTime)
+
+	// The service and its maintenance check must be gone locally.
+	require.Nil(t, a.State.Service("redis"))
+	_, ok := a.State.Checks()[serviceMaintCheckID("redis")]
+	require.False(t, ok)
+
+	// And the service must have been deregistered from the catalog.
+	req := structs.NodeSpecificRequest{
+		Datacenter: "dc1",
+		Node:       a.config.NodeName,
+	}
+	var out structs.IndexedNodeServices
+	require.NoError(t, a.RPC("Catalog.NodeServices", &req, &out))
+	require.NotNil(t, out.NodeServices)
+	_, ok = out.NodeServices.Services["redis"]
+	require.False(t, ok)
+}
+
 func TestAgent_Service_Reap(t *testing.T) {
 	// t.Parallel() // timing test. no parallel
 	a := NewTestAgent(t, t.Name(), `
@@ -3397,6 +3549,7 @@ func TestAgent_loadTokens(t *testing.T) {
 				agent_master = "bravo",
 				default = "charlie"
 				replication = "delta"
+				dns = "dns"
 			}
 		}
 
@@ -3411,6 +3564,7 @@ func TestAgent_loadTokens(t *testing.T) {
 		require.Equal("bravo", a.tokens.AgentMasterToken())
 		require.Equal("charlie", a.tokens.UserToken())
 		require.Equal("delta", a.tokens.ReplicationToken())
+		require.Equal("dns", a.tokens.DNSToken())
 	})
 
 	t.Run("updated-configuration", func(t *testing.T) {
@@ -3466,7 +3620,8 @@ func TestAgent_loadTokens(t *testing.T) {
 			"agent" : "mike",
 			"agent_master" : "november",
 			"default": "oscar",
-			"replication" : "papa"
+			"replication" : "papa",
+			"dns" : "dns-persisted"
 		}`
 
 		cfg := &config.RuntimeConfig{
@@ -3483,6 +3638,7 @@ func TestAgent_loadTokens(t *testing.T) {
 		require.Equal("november", a.tokens.AgentMasterToken())
 		require.Equal("oscar", a.tokens.UserToken())
 		require.Equal("papa", a.tokens.ReplicationToken())
+		require.Equal("dns-persisted", a.tokens.DNSToken())
 	})
 
 	t.Run("partial-persisted", func(t *testing.T) {
@@ -0,0 +1,45 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const ConfigEntryName = "config-entry"
+
+// ConfigEntry supports fetching a single config entry by kind and name.
+type ConfigEntry struct {
+	RPC RPC
+}
+
+func (c *ConfigEntry) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a ConfigEntryQuery.
+	reqReal, ok := req.(*structs.ConfigEntryQuery)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Set the minimum query index to our current index so we block
+	reqReal.MinQueryIndex = opts.MinIndex
+	reqReal.MaxQueryTime = opts.Timeout
+
+	// Fetch
+	var reply structs.ConfigEntryResponse
+	if err := c.RPC.RPC("ConfigEntry.Get", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.Index
+	return result, nil
+}
+
+func (c *ConfigEntry) SupportsBlocking() bool {
+	return true
+}
@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/lib"
 
 	"github.com/hashicorp/consul/agent/cache"
@@ -45,6 +46,11 @@ const ConnectCALeafName = "connect-ca-leaf"
 // more detail.
 const caChangeJitterWindow = 30 * time.Second
 
+// leafExpiryMetricInterval is how often the expiry of a cached cert is emitted
+// while a Fetch is blocking. Gauges are only reported when they are set so
+// this keeps the value fresh in sinks that expire old values.
+const leafExpiryMetricInterval = 10 * time.Second
+
 // ConnectCALeaf supports fetching and generating Connect leaf
 // certificates.
 type ConnectCALeaf struct {
@@ -363,6 +369,8 @@ func (c *ConnectCALeaf) Fetch(opts cache.FetchOptions, req cache.Request) (cache
 		return c.generateNewLeaf(reqReal, lastResultWithNewState())
 	}
 
+	emitLeafExpiry(reqReal.Service, existing)
+
 	// We are about to block and wait for a change or timeout.
 
 	// Make a chan we can be notified of changes to CA roots on. It must be
@@ -392,9 +400,15 @@ func (c *ConnectCALeaf) Fetch(opts cache.FetchOptions, req cache.Request) (cache
 	// loop below.
 	expiresCh := time.After(expiresAt.Sub(now))
 
+	metricsTicker := time.NewTicker(leafExpiryMetricInterval)
+	defer metricsTicker.Stop()
+
 	// Current cert is valid so just wait until it expires or we time out.
 	for {
 		select {
+		case <-metricsTicker.C:
+			emitLeafExpiry(reqReal.Service, existing)
+
 		case <-timeoutCh:
 			// We timed out the request with same cert.
 			return lastResultWithNewState(), nil
@@ -447,6 +461,14 @@ func (c *ConnectCALeaf) Fetch(opts cache.FetchOptions, req cache.Request) (cache
 	}
 }
 
+// emitLeafExpiry sets the gauge with the seconds until the given leaf cert of
+// the service expires.
+func emitLeafExpiry(service string, cert *structs.IssuedCert) {
+	metrics.SetGaugeWithLabels([]string{"connect", "leaf", "expiry"},
+		float32(time.Until(cert.ValidBefore).Seconds()),
+		[]metrics.Label{{Name: "service", Value: service}})
+}
+
 func activeRootHasKey(roots *structs.IndexedCARoots, currentSigningKeyID string) bool {
 	for _, ca := range roots.Roots {
 		if ca.Active {
@@ -586,6 +608,8 @@ func (c *ConnectCALeaf) generateNewLeaf(req *ConnectCALeafRequest,
 	// Set the CA key ID so we can easily tell when a active root has changed.
 	state.authorityKeyID = connect.HexString(cert.AuthorityKeyId)
 
+	emitLeafExpiry(req.Service, &reply)
+
 	result.Value = &reply
 	// Store value not pointer so we don't accidentally mutate the cache entry
 	// state in Fetch.
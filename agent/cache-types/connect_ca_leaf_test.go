@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/testutil/retry"
 
 	"github.com/hashicorp/consul/agent/cache"
@@ -964,6 +965,27 @@ func TestConnectCALeaf_expiringLeaf(t *testing.T) {
 	}
 }
 
+// This test replaces the global metrics sink so it can't run in parallel.
+func TestConnectCALeaf_expiryMetric(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("consul")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+	metrics.NewGlobal(cfg, sink)
+	defer metrics.NewGlobal(metrics.DefaultConfig(""), &metrics.BlackholeSink{})
+
+	emitLeafExpiry("web", &structs.IssuedCert{
+		ValidAfter:  time.Now().Add(-time.Hour),
+		ValidBefore: time.Now().Add(time.Hour),
+	})
+
+	data := sink.Data()
+	require.NotEmpty(t, data)
+	gauge, ok := data[len(data)-1].Gauges["consul.connect.leaf.expiry;service=web"]
+	require.True(t, ok)
+	require.InDelta(t, time.Hour.Seconds(), gauge.Value, 60)
+}
+
 // testCALeafType returns a *ConnectCALeaf that is pre-configured to
 // use the given RPC implementation for "ConnectCA.Sign" operations.
 func testCALeafType(t *testing.T, rpc RPC) (*ConnectCALeaf, chan structs.IndexedCARoots) {
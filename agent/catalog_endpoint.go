@@ -28,6 +28,8 @@ func (s *HTTPServer) CatalogRegister(resp http.ResponseWriter, req *http.Request
 		args.Datacenter = s.agent.config.Datacenter
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	// Forward to the servers
 	var out struct{}
@@ -41,6 +43,55 @@ func (s *HTTPServer) CatalogRegister(resp http.ResponseWriter, req *http.Request
 	return true, nil
 }
 
+// CatalogBulkRegister registers the array of registrations in the request
+// body, which have the same format as the body of /v1/catalog/register. The
+// registrations which fail are reported by their position in the array, and
+// don't prevent the others from being applied.
+func (s *HTTPServer) CatalogBulkRegister(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_bulk_register"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+
+	var args structs.BulkRegisterRequest
+	fixup := func(raw interface{}) error {
+		regs, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array of registrations")
+		}
+		for _, reg := range regs {
+			if err := durations.FixupDurations(reg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := decodeBody(req, &args.Registrations, fixup); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "Request decode failed: %v", err)
+		return nil, nil
+	}
+
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
+
+	// Forward to the servers
+	var out structs.BulkRegisterResponse
+	if err := s.agent.RPC("Catalog.BulkRegister", &args, &out); err != nil {
+		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_bulk_register"}, 1,
+			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+		return nil, err
+	}
+	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_bulk_register"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+
+	// Always return an array of errors for the caller to check.
+	if out.Errors == nil {
+		out.Errors = make([]structs.BulkRegisterError, 0)
+	}
+	return out, nil
+}
+
 func (s *HTTPServer) CatalogDeregister(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_deregister"}, 1,
 		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
@@ -57,6 +108,8 @@ func (s *HTTPServer) CatalogDeregister(resp http.ResponseWriter, req *http.Reque
 		args.Datacenter = s.agent.config.Datacenter
 	}
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	// Forward to the servers
 	var out struct{}
@@ -103,6 +156,9 @@ func (s *HTTPServer) CatalogNodes(resp http.ResponseWriter, req *http.Request) (
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
 	if err := s.agent.RPC("Catalog.ListNodes", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
 		return nil, err
 	}
 	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
@@ -138,6 +194,9 @@ func (s *HTTPServer) CatalogServices(resp http.ResponseWriter, req *http.Request
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
 	if err := s.agent.RPC("Catalog.ListServices", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
 		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_services"}, 1,
 			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
 		return nil, err
@@ -221,6 +280,9 @@ func (s *HTTPServer) catalogServiceNodes(resp http.ResponseWriter, req *http.Req
 	} else {
 	RETRY_ONCE:
 		if err := s.agent.RPC("Catalog.ServiceNodes", &args, &out); err != nil {
+			if staleIfError(&args.QueryOptions, err) {
+				goto RETRY_ONCE
+			}
 			metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_service_nodes"}, 1,
 				[]metrics.Label{{Name: "node", Value: s.nodeName()}})
 			return nil, err
@@ -274,6 +336,9 @@ func (s *HTTPServer) CatalogNodeServices(resp http.ResponseWriter, req *http.Req
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
 	if err := s.agent.RPC("Catalog.NodeServices", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
 		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_node_services"}, 1,
 			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
 		return nil, err
@@ -309,3 +374,85 @@ RETRY_ONCE:
 		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
 	return out.NodeServices, nil
 }
+
+// CatalogWeightOverride sets or deletes the weight override of a service
+// instance, given as /v1/catalog/weight-override/<node>/<service-id>. A PUT
+// takes the weights in the body.
+func (s *HTTPServer) CatalogWeightOverride(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_weight_override"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+
+	args := structs.ServiceWeightOverrideRequest{}
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
+
+	// Pull out the node name and service ID
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/catalog/weight-override/"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing node name or service ID")
+		return nil, nil
+	}
+	args.Override.Node, args.Override.ServiceID = parts[0], parts[1]
+
+	switch req.Method {
+	case "PUT":
+		args.Op = structs.ServiceWeightOverrideSet
+		if err := decodeBody(req, &args.Override.Weights, nil); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Request decode failed: %v", err)
+			return nil, nil
+		}
+	case "DELETE":
+		args.Op = structs.ServiceWeightOverrideDelete
+	}
+
+	// Forward to the servers
+	var out struct{}
+	if err := s.agent.RPC("Catalog.WeightOverride", &args, &out); err != nil {
+		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_weight_override"}, 1,
+			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+		return nil, err
+	}
+	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_weight_override"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+	return true, nil
+}
+
+func (s *HTTPServer) CatalogWeightOverrides(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_weight_overrides"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+
+	args := structs.DCSpecificRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var out structs.IndexedServiceWeightOverrides
+	defer setMeta(resp, &out.QueryMeta)
+RETRY_ONCE:
+	if err := s.agent.RPC("Catalog.WeightOverrides", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
+		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_weight_overrides"}, 1,
+			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+		return nil, err
+	}
+	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
+		args.AllowStale = false
+		args.MaxStaleDuration = 0
+		goto RETRY_ONCE
+	}
+	out.ConsistencyLevel = args.QueryOptions.ConsistencyLevel()
+
+	// Use empty list instead of nil
+	if out.Overrides == nil {
+		out.Overrides = make(structs.ServiceWeightOverrides, 0)
+	}
+	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_weight_overrides"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+	return out.Overrides, nil
+}
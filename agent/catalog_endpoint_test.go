@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -40,6 +41,55 @@ func TestCatalogRegister_Service_InvalidAddress(t *testing.T) {
 	}
 }
 
+func TestCatalogBulkRegister(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	body := bytes.NewBufferString(`[
+		{
+			"Node": "foo",
+			"Address": "127.0.0.1",
+			"Service": {"Service": "web", "Port": 8080},
+			"Checks": [{
+				"CheckID": "web-http",
+				"ServiceID": "web",
+				"Definition": {"HTTP": "http://127.0.0.1:8080", "Interval": "10s"}
+			}]
+		},
+		{
+			"Node": "bar"
+		},
+		{
+			"Node": "baz",
+			"Address": "127.0.0.2"
+		}
+	]`)
+	req, _ := http.NewRequest("PUT", "/v1/catalog/bulk-register", body)
+	obj, err := a.srv.CatalogBulkRegister(nil, req)
+	require.NoError(t, err)
+	out := obj.(structs.BulkRegisterResponse)
+	require.Equal(t, 2, out.Registered)
+	require.Len(t, out.Errors, 1)
+	require.Equal(t, 1, out.Errors[0].Index)
+	require.Equal(t, "bar", out.Errors[0].Node)
+
+	// The durations of check definitions are decoded.
+	args := structs.NodeSpecificRequest{Datacenter: "dc1", Node: "foo"}
+	var indexed structs.IndexedHealthChecks
+	require.NoError(t, a.RPC("Health.NodeChecks", &args, &indexed))
+	require.Len(t, indexed.HealthChecks, 1)
+	require.Equal(t, 10*time.Second, indexed.HealthChecks[0].Definition.Interval)
+
+	// The body must be an array.
+	req, _ = http.NewRequest("PUT", "/v1/catalog/bulk-register", bytes.NewBufferString(`{"Node": "foo"}`))
+	resp := httptest.NewRecorder()
+	_, err = a.srv.CatalogBulkRegister(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
 func TestCatalogDeregister(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -1041,3 +1091,60 @@ func TestCatalogNodeServices_WanTranslation(t *testing.T) {
 		t.Fatalf("bad: %v", service2)
 	}
 }
+
+func TestCatalogWeightOverride(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			ID:      "web1",
+			Service: "web",
+			Weights: &structs.Weights{Passing: 3, Warning: 1},
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	// Drain the instance.
+	body := bytes.NewBufferString(`{"Passing": 0, "Warning": 0}`)
+	req, _ := http.NewRequest("PUT", "/v1/catalog/weight-override/foo/web1", body)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CatalogWeightOverride(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, true, obj)
+
+	req, _ = http.NewRequest("GET", "/v1/catalog/weight-overrides", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CatalogWeightOverrides(resp, req)
+	require.NoError(t, err)
+	assertIndex(t, resp)
+	overrides := obj.(structs.ServiceWeightOverrides)
+	require.Len(t, overrides, 1)
+	require.Equal(t, "web", overrides[0].Service)
+	require.Equal(t, structs.Weights{Passing: 0, Warning: 0}, overrides[0].Weights)
+
+	// A missing service ID is rejected.
+	req, _ = http.NewRequest("DELETE", "/v1/catalog/weight-override/foo", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.CatalogWeightOverride(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// Delete the override.
+	req, _ = http.NewRequest("DELETE", "/v1/catalog/weight-override/foo/web1", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.CatalogWeightOverride(resp, req)
+	require.NoError(t, err)
+
+	req, _ = http.NewRequest("GET", "/v1/catalog/weight-overrides", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CatalogWeightOverrides(resp, req)
+	require.NoError(t, err)
+	require.Len(t, obj.(structs.ServiceWeightOverrides), 0)
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/armon/circbuf"
+	"github.com/hashicorp/consul/agent/checks/plugin"
 	"github.com/hashicorp/consul/agent/exec"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
@@ -28,7 +29,7 @@ const (
 	// Otherwise we risk fork bombing a system.
 	MinInterval = time.Second
 
-	// BufSize is the maximum size of the captured
+	// BufSize is the default maximum size of the captured
 	// check output. Prevents an enormous buffer
 	// from being captured
 	BufSize = 4 * 1024 // 4KB
@@ -64,11 +65,39 @@ type CheckMonitor struct {
 	Timeout    time.Duration
 	Logger     *log.Logger
 
+	// OutputLimit limits the size of the captured output.
+	OutputLimit OutputLimit
+
+	// Sandbox restricts the environment the script runs in.
+	Sandbox ScriptSandbox
+
 	stop     bool
 	stopCh   chan struct{}
 	stopLock sync.Mutex
 }
 
+// ScriptSandbox restricts the environment of script checks so that a
+// runaway check can't orphan processes or read secrets from the agent's
+// environment. The zero value runs scripts like the agent itself.
+type ScriptSandbox struct {
+	// User is the name of the user the script runs as. The agent must have
+	// the privileges to switch users. Empty means the agent's user.
+	User string
+
+	// EnvWhitelist is the list of environment variables of the agent passed
+	// to the script. A name ending in "*" matches all variables with that
+	// prefix. Empty passes the whole environment.
+	EnvWhitelist []string
+
+	// Dir is the working directory of the script. Empty means the agent's
+	// working directory.
+	Dir string
+
+	// KillTimeout is the time a timed out script has to exit after it was
+	// asked to terminate before it's killed. Zero kills it right away.
+	KillTimeout time.Duration
+}
+
 // Start is used to start a check monitor.
 // Monitor runs until stop is called
 func (c *CheckMonitor) Start() {
@@ -121,28 +150,53 @@ func (c *CheckMonitor) check() {
 		return
 	}
 
-	// Collect the output
-	output, _ := circbuf.NewBuffer(BufSize)
-	cmd.Stdout = output
-	cmd.Stderr = output
 	exec.SetSysProcAttr(cmd)
+	if c.Sandbox.User != "" {
+		if err := exec.SetUser(cmd, c.Sandbox.User); err != nil {
+			c.Logger.Printf("[ERR] agent: Check %q failed to setup: %s", c.CheckID, err)
+			c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+			return
+		}
+	}
+	if len(c.Sandbox.EnvWhitelist) > 0 {
+		cmd.Env = exec.FilterEnv(os.Environ(), c.Sandbox.EnvWhitelist)
+	}
+	cmd.Dir = c.Sandbox.Dir
+
+	// Collect the output through a pipe rather than letting the command
+	// copy it, since the copy only ends once all processes holding the
+	// pipe exited and children left behind by the script would block the
+	// check until the timeout.
+	output := c.OutputLimit.newBuffer()
+	r, w, err := os.Pipe()
+	if err != nil {
+		c.Logger.Printf("[ERR] agent: Check %q failed to setup: %s", c.CheckID, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+	defer r.Close()
+	cmd.Stdout = w
+	cmd.Stderr = w
 
 	truncateAndLogOutput := func() string {
-		outputStr := string(output.Bytes())
-		if output.TotalWritten() > output.Size() {
-			outputStr = fmt.Sprintf("Captured %d of %d bytes\n...\n%s",
-				output.Size(), output.TotalWritten(), outputStr)
-		}
+		outputStr := output.String()
 		c.Logger.Printf("[TRACE] agent: Check %q output: %s", c.CheckID, outputStr)
 		return outputStr
 	}
 
 	// Start the check
-	if err := cmd.Start(); err != nil {
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
 		c.Logger.Printf("[ERR] agent: Check %q failed to invoke: %s", c.CheckID, err)
 		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
 		return
 	}
+	copyDoneCh := make(chan struct{})
+	go func() {
+		io.Copy(output, r)
+		close(copyDoneCh)
+	}()
 
 	// Wait for the check to complete
 	waitCh := make(chan error, 1)
@@ -154,12 +208,23 @@ func (c *CheckMonitor) check() {
 	if c.Timeout > 0 {
 		timeout = c.Timeout
 	}
+	timedOut := false
 	select {
 	case <-time.After(timeout):
-		if err := exec.KillCommandSubtree(cmd); err != nil {
-			c.Logger.Printf("[WARN] agent: Check %q failed to kill after timeout: %s", c.CheckID, err)
-		}
+		timedOut = true
+		c.stopTimedOut(cmd, waitCh)
+
+	case err = <-waitCh:
+		// The process returned before the timeout, proceed normally
+	}
 
+	// Kill whatever the script left running in its process group, which
+	// also closes the pipe, so no instance of the check is ever running
+	// concurrently. The group is usually gone by now.
+	exec.KillCommandSubtree(cmd)
+	<-copyDoneCh
+
+	if timedOut {
 		msg := fmt.Sprintf("Timed out (%s) running check", timeout.String())
 		c.Logger.Printf("[WARN] agent: Check %q: %s", c.CheckID, msg)
 
@@ -168,14 +233,7 @@ func (c *CheckMonitor) check() {
 			msg += "\n\n" + outputStr
 		}
 		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, msg)
-
-		// Now wait for the process to exit so we never start another
-		// instance concurrently.
-		<-waitCh
 		return
-
-	case err = <-waitCh:
-		// The process returned before the timeout, proceed normally
 	}
 
 	// Check if the check passed
@@ -204,6 +262,26 @@ func (c *CheckMonitor) check() {
 	c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, outputStr)
 }
 
+// stopTimedOut stops a timed out script and waits for it to exit. The
+// script is asked to terminate and killed if it's still running after the
+// kill timeout.
+func (c *CheckMonitor) stopTimedOut(cmd *osexec.Cmd, waitCh <-chan error) {
+	if c.Sandbox.KillTimeout > 0 {
+		if err := exec.TerminateCommandSubtree(cmd); err != nil {
+			c.Logger.Printf("[WARN] agent: Check %q failed to terminate after timeout: %s", c.CheckID, err)
+		}
+		select {
+		case <-waitCh:
+			return
+		case <-time.After(c.Sandbox.KillTimeout):
+		}
+	}
+	if err := exec.KillCommandSubtree(cmd); err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q failed to kill after timeout: %s", c.CheckID, err)
+	}
+	<-waitCh
+}
+
 // CheckTTL is used to apply a TTL to check status,
 // and enables clients to set the status of a check
 // but upon the TTL expiring, the check status is
@@ -304,6 +382,9 @@ type CheckHTTP struct {
 	Logger          *log.Logger
 	TLSClientConfig *tls.Config
 
+	// OutputLimit limits the size of the captured response body.
+	OutputLimit OutputLimit
+
 	httpClient *http.Client
 	stop       bool
 	stopCh     chan struct{}
@@ -412,8 +493,8 @@ func (c *CheckHTTP) check() {
 	}
 	defer resp.Body.Close()
 
-	// Read the response into a circular buffer to limit the size
-	output, _ := circbuf.NewBuffer(BufSize)
+	// Read the response into a buffer to limit the size
+	output := c.OutputLimit.newBuffer()
 	if _, err := io.Copy(output, resp.Body); err != nil {
 		c.Logger.Printf("[WARN] agent: Check %q error while reading body: %s", c.CheckID, err)
 	}
@@ -526,6 +607,8 @@ func (c *CheckTCP) check() {
 // determine the health of an application running inside a
 // Docker Container. We assume that the script is compatible
 // with nagios plugins and expects the output in the same format.
+// Despite its name the container can be managed by any runtime
+// the Client supports.
 type CheckDocker struct {
 	Notify            CheckNotifier
 	CheckID           types.CheckID
@@ -535,7 +618,7 @@ type CheckDocker struct {
 	Shell             string
 	Interval          time.Duration
 	Logger            *log.Logger
-	Client            *DockerClient
+	Client            ContainerClient
 
 	stop chan struct{}
 }
@@ -588,8 +671,8 @@ func (c *CheckDocker) check() {
 		c.Logger.Printf("[DEBUG] agent: Check %q: %s", c.CheckID, err)
 		out = err.Error()
 	} else {
-		// out is already limited to CheckBufSize since we're getting a
-		// limited buffer. So we don't need to truncate it just report
+		// out is already limited to the size of the client's buffer since
+		// we're getting a limited buffer. So we don't need to truncate it just report
 		// that it was truncated.
 		out = string(b.Bytes())
 		if int(b.TotalWritten()) > len(out) {
@@ -613,17 +696,7 @@ func (c *CheckDocker) doCheck() (string, *circbuf.Buffer, error) {
 		cmd = []string{c.Shell, "-c", c.Script}
 	}
 
-	execID, err := c.Client.CreateExec(c.DockerContainerID, cmd)
-	if err != nil {
-		return api.HealthCritical, nil, err
-	}
-
-	buf, err := c.Client.StartExec(c.DockerContainerID, execID)
-	if err != nil {
-		return api.HealthCritical, nil, err
-	}
-
-	exitCode, err := c.Client.InspectExec(c.DockerContainerID, execID)
+	exitCode, buf, err := c.Client.Exec(c.DockerContainerID, cmd)
 	if err != nil {
 		return api.HealthCritical, nil, err
 	}
@@ -707,3 +780,123 @@ func (c *CheckGRPC) Stop() {
 		close(c.stopCh)
 	}
 }
+
+// PluginCheckers returns the custom check types implemented by plugins.
+// It is implemented by plugin.Manager.
+type PluginCheckers interface {
+	Checker(name string) (plugin.Checker, error)
+}
+
+// CheckPlugin is used to periodically run a custom check type that is
+// implemented by a plugin. The plugin reports the status of the check.
+type CheckPlugin struct {
+	Notify   CheckNotifier
+	CheckID  types.CheckID
+	Plugin   string
+	Args     map[string]string
+	Interval time.Duration
+	Timeout  time.Duration
+	Logger   *log.Logger
+	Plugins  PluginCheckers
+
+	// OutputLimit limits the size of the output reported by the plugin.
+	OutputLimit OutputLimit
+
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+}
+
+func (c *CheckPlugin) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	go c.run()
+}
+
+func (c *CheckPlugin) run() {
+	// Get the randomized initial pause time
+	initialPauseTime := lib.RandomStagger(c.Interval)
+	next := time.After(initialPauseTime)
+	for {
+		select {
+		case <-next:
+			c.check()
+			next = time.After(c.Interval)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+func (c *CheckPlugin) check() {
+	checker, err := c.Plugins.Checker(c.Plugin)
+	if err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q failed: %s", c.CheckID, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+
+	timeout := 10 * time.Second
+	if c.Timeout > 0 {
+		timeout = c.Timeout
+	}
+	type result struct {
+		resp *plugin.CheckResponse
+		err  error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		resp, err := checker.Check(&plugin.CheckRequest{
+			CheckID: string(c.CheckID),
+			Args:    c.Args,
+			Timeout: timeout,
+		})
+		resultCh <- result{resp, err}
+	}()
+
+	var r result
+	select {
+	case <-time.After(timeout):
+		msg := fmt.Sprintf("Timed out (%s) running check plugin %q", timeout, c.Plugin)
+		c.Logger.Printf("[WARN] agent: Check %q: %s", c.CheckID, msg)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, msg)
+		return
+	case r = <-resultCh:
+	}
+
+	if r.err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q failed: %s", c.CheckID, r.err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, r.err.Error())
+		return
+	}
+	resp := r.resp
+	if resp == nil {
+		resp = &plugin.CheckResponse{}
+	}
+
+	switch resp.Status {
+	case api.HealthPassing:
+		c.Logger.Printf("[DEBUG] agent: Check %q is passing", c.CheckID)
+	case api.HealthWarning:
+		c.Logger.Printf("[WARN] agent: Check %q is now warning", c.CheckID)
+	case api.HealthCritical:
+		c.Logger.Printf("[WARN] agent: Check %q is now critical", c.CheckID)
+	default:
+		msg := fmt.Sprintf("Check plugin %q returned invalid status %q", c.Plugin, resp.Status)
+		c.Logger.Printf("[WARN] agent: Check %q: %s", c.CheckID, msg)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, msg)
+		return
+	}
+	c.Notify.UpdateCheck(c.CheckID, resp.Status, c.OutputLimit.Truncate(resp.Output))
+}
+
+func (c *CheckPlugin) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+}
@@ -9,12 +9,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/agent/checks/plugin"
 	"github.com/hashicorp/consul/agent/mock"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil/retry"
@@ -170,6 +172,99 @@ func TestCheckMonitor_LimitOutput(t *testing.T) {
 	}
 }
 
+func TestCheckMonitor_Sandbox(t *testing.T) {
+	t.Parallel()
+	os.Setenv("CHECK_SANDBOX_SECRET", "hunter2")
+	os.Setenv("CHECK_SANDBOX_PUBLIC", "public")
+	defer os.Unsetenv("CHECK_SANDBOX_SECRET")
+	defer os.Unsetenv("CHECK_SANDBOX_PUBLIC")
+
+	dir, err := ioutil.TempDir("", "check-sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	notif := mock.NewNotify()
+	check := &CheckMonitor{
+		Notify:     notif,
+		CheckID:    types.CheckID("foo"),
+		ScriptArgs: []string{"/bin/sh", "-c", "pwd; echo $CHECK_SANDBOX_PUBLIC $CHECK_SANDBOX_SECRET"},
+		Interval:   25 * time.Millisecond,
+		Logger:     log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+		Sandbox: ScriptSandbox{
+			EnvWhitelist: []string{"PATH", "CHECK_SANDBOX_PUB*"},
+			Dir:          dir,
+		},
+	}
+	check.Start()
+	defer check.Stop()
+
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notif.Output("foo"), realDir+"\npublic\n"; got != want {
+			r.Fatalf("got output %q want %q", got, want)
+		}
+	})
+}
+
+func TestCheckMonitor_Orphans(t *testing.T) {
+	t.Parallel()
+	notif := mock.NewNotify()
+	check := &CheckMonitor{
+		Notify:     notif,
+		CheckID:    types.CheckID("foo"),
+		ScriptArgs: []string{"sh", "-c", "sleep 10 & echo done"},
+		Interval:   10 * time.Second,
+		Timeout:    5 * time.Second,
+		Logger:     log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+	}
+
+	// The check completes when the script exits rather than when the child
+	// it left behind does, or when the check times out.
+	start := time.Now()
+	check.check()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("check took %s", d)
+	}
+	if got, want := notif.State("foo"), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if got, want := notif.Output("foo"), "done\n"; got != want {
+		t.Fatalf("got output %q want %q", got, want)
+	}
+}
+
+func TestCheckMonitor_KillTimeout(t *testing.T) {
+	t.Parallel()
+	notif := mock.NewNotify()
+	check := &CheckMonitor{
+		Notify:     notif,
+		CheckID:    types.CheckID("foo"),
+		ScriptArgs: []string{"sh", "-c", "trap 'echo terminated; exit 0' TERM; sleep 10 & wait"},
+		Interval:   10 * time.Second,
+		Timeout:    250 * time.Millisecond,
+		Logger:     log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+		Sandbox:    ScriptSandbox{KillTimeout: 5 * time.Second},
+	}
+
+	// The script is asked to terminate and gets to report its output.
+	start := time.Now()
+	check.check()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("check took %s", d)
+	}
+	if got, want := notif.State("foo"), api.HealthCritical; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if got, want := notif.Output("foo"), "Timed out (250ms) running check\n\nterminated\n"; got != want {
+		t.Fatalf("got output %q want %q", got, want)
+	}
+}
+
 func TestCheckTTL(t *testing.T) {
 	// t.Parallel() // timing test. no parallel
 	notif := mock.NewNotify()
@@ -807,3 +902,86 @@ func TestCheck_Docker(t *testing.T) {
 		})
 	}
 }
+
+// testCheckers implements PluginCheckers with checker functions.
+type testCheckers map[string]testChecker
+
+func (c testCheckers) Checker(name string) (plugin.Checker, error) {
+	checker, ok := c[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown check plugin %q", name)
+	}
+	return checker, nil
+}
+
+type testChecker func(req *plugin.CheckRequest) (*plugin.CheckResponse, error)
+
+func (f testChecker) Check(req *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+	return f(req)
+}
+
+func TestCheckPlugin(t *testing.T) {
+	t.Parallel()
+
+	respond := func(status, output string) testChecker {
+		return func(req *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+			return &plugin.CheckResponse{Status: status, Output: output}, nil
+		}
+	}
+	plugins := testCheckers{
+		"passing":  respond(api.HealthPassing, "bound"),
+		"warning":  respond(api.HealthWarning, "slow"),
+		"critical": respond(api.HealthCritical, "down"),
+		"invalid":  respond("maintenance", ""),
+		"long":     respond(api.HealthPassing, "0123456789abcdefghij"),
+		"args": func(req *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+			out := fmt.Sprintf("%s %s %s", req.CheckID, req.Args["host"], req.Timeout)
+			return &plugin.CheckResponse{Status: api.HealthPassing, Output: out}, nil
+		},
+		"error": func(req *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+			return nil, fmt.Errorf("no route to host")
+		},
+		"hang": func(req *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+			time.Sleep(time.Second)
+			return &plugin.CheckResponse{Status: api.HealthPassing}, nil
+		},
+	}
+
+	tests := []struct {
+		plugin string
+		state  string
+		output string
+	}{
+		{"passing", api.HealthPassing, "bound"},
+		{"warning", api.HealthWarning, "slow"},
+		{"critical", api.HealthCritical, "down"},
+		{"invalid", api.HealthCritical, `Check plugin "invalid" returned invalid status "maintenance"`},
+		{"long", api.HealthPassing, "Captured 16 of 20 bytes\n...\n456789abcdefghij"},
+		{"args", api.HealthPassing, "foo ldap 50ms"},
+		{"error", api.HealthCritical, "no route to host"},
+		{"hang", api.HealthCritical, `Timed out (50ms) running check plugin "hang"`},
+		{"missing", api.HealthCritical, `unknown check plugin "missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.plugin, func(t *testing.T) {
+			notif := mock.NewNotify()
+			check := &CheckPlugin{
+				Notify:      notif,
+				CheckID:     types.CheckID("foo"),
+				Plugin:      tt.plugin,
+				Args:        map[string]string{"host": "ldap"},
+				Timeout:     50 * time.Millisecond,
+				Logger:      log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+				Plugins:     plugins,
+				OutputLimit: OutputLimit{MaxSize: 16},
+			}
+			check.check()
+			if got, want := notif.State("foo"), tt.state; got != want {
+				t.Fatalf("got state %q want %q", got, want)
+			}
+			if got, want := notif.Output("foo"), tt.output; got != want {
+				t.Fatalf("got output %q want %q", got, want)
+			}
+		})
+	}
+}
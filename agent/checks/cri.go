@@ -0,0 +1,160 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/armon/circbuf"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultContainerdEndpoint is the endpoint of the CRI plugin of containerd.
+const DefaultContainerdEndpoint = "unix:///run/containerd/containerd.sock"
+
+// criExecSyncMethods are the ExecSync methods of the versions of the CRI API
+// the client supports, in order of preference. The messages of the method
+// are the same in both versions.
+var criExecSyncMethods = []string{
+	"/runtime.v1.RuntimeService/ExecSync",
+	"/runtime.v1alpha2.RuntimeService/ExecSync",
+}
+
+// CRIClient is a client for the Container Runtime Interface API of
+// Kubernetes, which is served by containerd, CRI-O and other runtimes, to
+// execute the health checks without depending on dockerd. Like the
+// DockerClient it only implements the call needed by the checks and limits
+// the output to a ring buffer.
+type CRIClient struct {
+	endpoint string
+	proto    string
+	addr     string
+	maxbuf   int64
+
+	// l guards the connection and the selected API version, which are
+	// established by the first call.
+	l      sync.Mutex
+	conn   *grpc.ClientConn
+	method string
+}
+
+// NewCRIClient returns a client for the CRI API served at the given
+// endpoint, such as "unix:///run/containerd/containerd.sock".
+func NewCRIClient(endpoint string, maxbuf int64) (*CRIClient, error) {
+	network, addr, _, err := ParseHost(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if network != "unix" && network != "tcp" {
+		return nil, fmt.Errorf("unsupported protocol %q of CRI endpoint %s", network, endpoint)
+	}
+	return &CRIClient{
+		endpoint: endpoint,
+		proto:    network,
+		addr:     addr,
+		maxbuf:   maxbuf,
+	}, nil
+}
+
+// Close closes the connection to the runtime. The next call opens a new
+// one.
+func (c *CRIClient) Close() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *CRIClient) Host() string {
+	return c.endpoint
+}
+
+// Exec runs the command in the container and returns its exit code and its
+// output. The stderr output follows the stdout output.
+func (c *CRIClient) Exec(containerID string, cmd []string) (int, *circbuf.Buffer, error) {
+	conn, method, err := c.connect()
+	if err != nil {
+		return 0, nil, fmt.Errorf("exec failed for container %s: %v", containerID, err)
+	}
+
+	req := &criExecSyncRequest{ContainerId: containerID, Cmd: cmd}
+	var resp criExecSyncResponse
+	err = conn.Invoke(context.Background(), method, req, &resp)
+	if status.Code(err) == codes.Unimplemented && method == criExecSyncMethods[0] {
+		// The runtime only serves the older version of the API.
+		method = criExecSyncMethods[1]
+		err = conn.Invoke(context.Background(), method, req, &resp)
+		if err == nil {
+			c.l.Lock()
+			c.method = method
+			c.l.Unlock()
+		}
+	}
+	switch {
+	case status.Code(err) == codes.NotFound:
+		return 0, nil, fmt.Errorf("exec failed for unknown container %s", containerID)
+	case err != nil:
+		return 0, nil, fmt.Errorf("exec failed for container %s: %v", containerID, err)
+	}
+
+	b, err := circbuf.NewBuffer(c.maxbuf)
+	if err != nil {
+		return 0, nil, err
+	}
+	b.Write(resp.Stdout)
+	b.Write(resp.Stderr)
+	return int(resp.ExitCode), b, nil
+}
+
+// connect returns the connection to the runtime and the ExecSync method to
+// call, connecting first if needed.
+func (c *CRIClient) connect() (*grpc.ClientConn, string, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.conn == nil {
+		conn, err := grpc.Dial(c.addr,
+			grpc.WithInsecure(),
+			grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+				return net.DialTimeout(c.proto, addr, timeout)
+			}))
+		if err != nil {
+			return nil, "", err
+		}
+		c.conn = conn
+	}
+	if c.method == "" {
+		c.method = criExecSyncMethods[0]
+	}
+	return c.conn, c.method, nil
+}
+
+// criExecSyncRequest is the ExecSyncRequest message of the CRI API. Only
+// the fields used by the checks are declared.
+type criExecSyncRequest struct {
+	ContainerId string   `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3"`
+	Cmd         []string `protobuf:"bytes,2,rep,name=cmd,proto3"`
+}
+
+func (m *criExecSyncRequest) Reset()         { *m = criExecSyncRequest{} }
+func (m *criExecSyncRequest) String() string { return proto.CompactTextString(m) }
+func (*criExecSyncRequest) ProtoMessage()    {}
+
+// criExecSyncResponse is the ExecSyncResponse message of the CRI API.
+type criExecSyncResponse struct {
+	Stdout   []byte `protobuf:"bytes,1,opt,name=stdout,proto3"`
+	Stderr   []byte `protobuf:"bytes,2,opt,name=stderr,proto3"`
+	ExitCode int32  `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3"`
+}
+
+func (m *criExecSyncResponse) Reset()         { *m = criExecSyncResponse{} }
+func (m *criExecSyncResponse) String() string { return proto.CompactTextString(m) }
+func (*criExecSyncResponse) ProtoMessage()    {}
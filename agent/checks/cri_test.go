@@ -0,0 +1,123 @@
+package checks
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/mock"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeCRIServer serves the ExecSync method of the given version of the CRI
+// API, running commands with exec.
+func fakeCRIServer(t *testing.T, version string, exec func(*criExecSyncRequest) (*criExecSyncResponse, error)) (string, func()) {
+	dir, err := ioutil.TempDir("", "cri")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "cri.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "runtime." + version + ".RuntimeService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "ExecSync",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				var req criExecSyncRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				return exec(&req)
+			},
+		}},
+	}, struct{}{})
+	go srv.Serve(l)
+
+	return "unix://" + path, func() {
+		srv.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCRIClient_Exec(t *testing.T) {
+	t.Parallel()
+
+	for _, version := range []string{"v1", "v1alpha2"} {
+		t.Run(version, func(t *testing.T) {
+			endpoint, stop := fakeCRIServer(t, version, func(req *criExecSyncRequest) (*criExecSyncResponse, error) {
+				if req.ContainerId != "123" {
+					return nil, status.Errorf(codes.NotFound, "container %q not found", req.ContainerId)
+				}
+				return &criExecSyncResponse{
+					Stdout:   []byte(strings.Join(req.Cmd, " ") + "\n"),
+					Stderr:   []byte("warning\n"),
+					ExitCode: 1,
+				}, nil
+			})
+			defer stop()
+
+			c, err := NewCRIClient(endpoint, 20)
+			require.NoError(t, err)
+			defer c.Close()
+
+			code, buf, err := c.Exec("123", []string{"/bin/sh", "-c", "exit 1"})
+			require.NoError(t, err)
+			require.Equal(t, 1, code)
+			require.Equal(t, "h -c exit 1\nwarning\n", string(buf.Bytes()))
+			require.Equal(t, int64(26), buf.TotalWritten())
+
+			_, _, err = c.Exec("456", []string{"true"})
+			require.EqualError(t, err, "exec failed for unknown container 456")
+
+			// The client reconnects after it was closed.
+			require.NoError(t, c.Close())
+			code, _, err = c.Exec("123", []string{"true"})
+			require.NoError(t, err)
+			require.Equal(t, 1, code)
+		})
+	}
+}
+
+func TestCRIClient_InvalidEndpoint(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewCRIClient("npipe:////./pipe/containerd-containerd", 10)
+	require.EqualError(t, err, `unsupported protocol "npipe" of CRI endpoint npipe:////./pipe/containerd-containerd`)
+}
+
+func TestCheck_DockerCRI(t *testing.T) {
+	t.Parallel()
+
+	endpoint, stop := fakeCRIServer(t, "v1", func(req *criExecSyncRequest) (*criExecSyncResponse, error) {
+		return &criExecSyncResponse{Stdout: []byte("OK")}, nil
+	})
+	defer stop()
+
+	c, err := NewCRIClient(endpoint, 1024)
+	require.NoError(t, err)
+
+	notif := mock.NewNotify()
+	check := &CheckDocker{
+		Notify:            notif,
+		CheckID:           types.CheckID("foo"),
+		ScriptArgs:        []string{"/health.sh"},
+		DockerContainerID: "123",
+		Logger:            log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+		Client:            c,
+	}
+	check.check()
+	require.Equal(t, api.HealthPassing, notif.State("foo"))
+	require.Equal(t, "OK", notif.Output("foo"))
+}
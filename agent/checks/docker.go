@@ -14,6 +14,21 @@ import (
 	"github.com/docker/go-connections/sockets"
 )
 
+// ContainerClient executes the commands of Docker checks in containers.
+// It is implemented by the DockerClient and the CRIClient.
+type ContainerClient interface {
+	// Exec runs the command in the container and returns its exit code and
+	// its output, limited to the size of the client's buffer.
+	Exec(containerID string, cmd []string) (int, *circbuf.Buffer, error)
+
+	// Host returns the address of the container runtime.
+	Host() string
+
+	// Close releases the connections to the runtime. The client remains
+	// usable.
+	Close() error
+}
+
 // DockerClient is a simplified client for the Docker Engine API
 // to execute the health checks and avoid significant dependencies.
 // It also consumes all data returned from the Docker API through
@@ -124,6 +139,26 @@ func (c *DockerClient) call(method, uri string, v interface{}) (*circbuf.Buffer,
 	return b, resp.StatusCode, err
 }
 
+// Exec runs the command in the container and returns its exit code and its
+// output.
+func (c *DockerClient) Exec(containerID string, cmd []string) (int, *circbuf.Buffer, error) {
+	execID, err := c.CreateExec(containerID, cmd)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	buf, err := c.StartExec(containerID, execID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	exitCode, err := c.InspectExec(containerID, execID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return exitCode, buf, nil
+}
+
 func (c *DockerClient) CreateExec(containerID string, cmd []string) (string, error) {
 	data := struct {
 		AttachStdin  bool
@@ -0,0 +1,107 @@
+package checks
+
+import (
+	"fmt"
+
+	"github.com/armon/circbuf"
+	metrics "github.com/armon/go-metrics"
+)
+
+const (
+	// TruncateHead keeps the beginning of check output that exceeds the
+	// maximum size.
+	TruncateHead = "head"
+
+	// TruncateTail keeps the end of check output that exceeds the maximum
+	// size. This is the default since scripts usually report the cause of
+	// a failure last.
+	TruncateTail = "tail"
+)
+
+// OutputLimit limits the size of the output of checks so large outputs
+// don't bloat the agent's memory, anti-entropy syncs and Raft entries.
+// Truncated outputs are counted in the "agent.check.output_truncated"
+// metric.
+type OutputLimit struct {
+	// MaxSize is the maximum size of the output in bytes. Zero means
+	// BufSize.
+	MaxSize int
+
+	// Truncation is either TruncateHead or TruncateTail. Empty means
+	// TruncateTail.
+	Truncation string
+}
+
+// Size returns the maximum size of the output in bytes.
+func (l OutputLimit) Size() int {
+	if l.MaxSize <= 0 {
+		return BufSize
+	}
+	return l.MaxSize
+}
+
+// Truncate returns the output truncated to the limit.
+func (l OutputLimit) Truncate(output string) string {
+	if len(output) <= l.Size() {
+		return output
+	}
+	b := l.newBuffer()
+	b.Write([]byte(output))
+	return b.String()
+}
+
+// newBuffer returns a buffer capturing output within the limit.
+func (l OutputLimit) newBuffer() *outputBuffer {
+	b := &outputBuffer{limit: l}
+	if l.Truncation != TruncateHead {
+		// This only fails for a size below one.
+		b.tail, _ = circbuf.NewBuffer(int64(l.Size()))
+	}
+	return b
+}
+
+// outputBuffer is a writer capturing the head or the tail of the written
+// data.
+type outputBuffer struct {
+	limit OutputLimit
+	head  []byte
+	tail  *circbuf.Buffer
+	total int
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	size := b.limit.Size()
+	if b.total <= size && b.total+len(p) > size {
+		metrics.IncrCounter([]string{"agent", "check", "output_truncated"}, 1)
+	}
+	b.total += len(p)
+
+	if b.tail != nil {
+		return b.tail.Write(p)
+	}
+	if n := size - len(b.head); n > 0 {
+		if n > len(p) {
+			n = len(p)
+		}
+		b.head = append(b.head, p[:n]...)
+	}
+	return len(p), nil
+}
+
+// String returns the captured output along with a note about its size if
+// it was truncated.
+func (b *outputBuffer) String() string {
+	if b.tail != nil {
+		out := string(b.tail.Bytes())
+		if b.total > len(out) {
+			return fmt.Sprintf("Captured %d of %d bytes\n...\n%s", len(out), b.total, out)
+		}
+		return out
+	}
+
+	out := string(b.head)
+	if b.total > len(out) {
+		return fmt.Sprintf("%s\n...\nCaptured %d of %d bytes", out, len(out), b.total)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package checks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputLimit_Truncate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		limit  OutputLimit
+		output string
+		want   string
+	}{
+		{"short", OutputLimit{MaxSize: 10}, "0123456789", "0123456789"},
+		{"tail", OutputLimit{MaxSize: 4}, "0123456789", "Captured 4 of 10 bytes\n...\n6789"},
+		{"explicit tail", OutputLimit{MaxSize: 4, Truncation: TruncateTail}, "0123456789", "Captured 4 of 10 bytes\n...\n6789"},
+		{"head", OutputLimit{MaxSize: 4, Truncation: TruncateHead}, "0123456789", "0123\n...\nCaptured 4 of 10 bytes"},
+		{"default size", OutputLimit{}, strings.Repeat("a", BufSize), strings.Repeat("a", BufSize)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.limit.Truncate(tc.output))
+		})
+	}
+}
+
+func TestOutputLimit_Buffer(t *testing.T) {
+	t.Parallel()
+
+	// Output written in several chunks keeps the head across writes.
+	b := OutputLimit{MaxSize: 5, Truncation: TruncateHead}.newBuffer()
+	for _, chunk := range []string{"012", "345", "678"} {
+		n, err := b.Write([]byte(chunk))
+		require.NoError(t, err)
+		require.Equal(t, len(chunk), n)
+	}
+	require.Equal(t, "01234\n...\nCaptured 5 of 9 bytes", b.String())
+
+	b = OutputLimit{MaxSize: 5}.newBuffer()
+	for _, chunk := range []string{"012", "345", "678"} {
+		b.Write([]byte(chunk))
+	}
+	require.Equal(t, "Captured 5 of 9 bytes\n...\n45678", b.String())
+}
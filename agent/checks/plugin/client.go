@@ -0,0 +1,17 @@
+package plugin
+
+import (
+	"github.com/hashicorp/go-plugin"
+)
+
+// ClientConfig returns a base *plugin.ClientConfig that is configured to
+// be able to dispense check plugins. The returned value should be modified
+// with additional options prior to execution (such as Cmd, Logger, etc.)
+func ClientConfig() *plugin.ClientConfig {
+	return &plugin.ClientConfig{
+		HandshakeConfig: handshakeConfig,
+		Plugins: map[string]plugin.Plugin{
+			Name: &CheckerPlugin{},
+		},
+	}
+}
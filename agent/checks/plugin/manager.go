@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+// Manager launches the check plugins of the agent when they are first used
+// and relaunches them if they exit.
+type Manager struct {
+	paths  map[string]string
+	logger hclog.Logger
+
+	// l guards the running plugins.
+	l       sync.Mutex
+	plugins map[string]*runningPlugin
+	closed  bool
+}
+
+// runningPlugin is a launched plugin.
+type runningPlugin struct {
+	client  *plugin.Client
+	checker Checker
+}
+
+// NewManager returns a manager for the plugins at the given paths, keyed
+// by the names check definitions refer to them with. The log lines of the
+// plugins are written to logOutput.
+func NewManager(paths map[string]string, logOutput io.Writer) *Manager {
+	return &Manager{
+		paths: paths,
+		logger: hclog.New(&hclog.LoggerOptions{
+			Name:   "agent.check_plugin",
+			Level:  hclog.Info,
+			Output: logOutput,
+		}),
+		plugins: make(map[string]*runningPlugin),
+	}
+}
+
+// Has returns whether a plugin with the given name is configured.
+func (m *Manager) Has(name string) bool {
+	_, ok := m.paths[name]
+	return ok
+}
+
+// Checker returns the check type implemented by the named plugin,
+// launching the plugin if it isn't running. The plugin is launched without
+// holding the lock, so checks of other plugins aren't held up by it.
+func (m *Manager) Checker(name string) (Checker, error) {
+	path, ok := m.paths[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown check plugin %q", name)
+	}
+	if checker, err := m.running(name); checker != nil || err != nil {
+		return checker, err
+	}
+
+	p, err := m.launch(name, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another call may have launched the plugin in the meantime, or the
+	// manager may have been closed.
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.closed {
+		p.client.Kill()
+		return nil, fmt.Errorf("check plugins are stopped")
+	}
+	if running, ok := m.plugins[name]; ok && !running.client.Exited() {
+		p.client.Kill()
+		return running.checker, nil
+	}
+	m.plugins[name] = p
+	return p.checker, nil
+}
+
+// running returns the checker of the named plugin if it's running. A plugin
+// that exited is removed so that it's relaunched.
+func (m *Manager) running(name string) (Checker, error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.closed {
+		return nil, fmt.Errorf("check plugins are stopped")
+	}
+	p, ok := m.plugins[name]
+	if !ok {
+		return nil, nil
+	}
+	if !p.client.Exited() {
+		return p.checker, nil
+	}
+	m.logger.Warn("relaunching check plugin that exited", "plugin", name)
+	p.client.Kill()
+	delete(m.plugins, name)
+	return nil, nil
+}
+
+// launch starts the plugin at path and waits for its handshake.
+func (m *Manager) launch(name, path string) (*runningPlugin, error) {
+	config := ClientConfig()
+	config.Cmd = exec.Command(path)
+	config.Logger = m.logger.Named(name)
+	client := plugin.NewClient(config)
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to launch check plugin %q: %v", name, err)
+	}
+	raw, err := rpcClient.Dispense(Name)
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to dispense check plugin %q: %v", name, err)
+	}
+	checker, ok := raw.(Checker)
+	if !ok {
+		client.Kill()
+		return nil, fmt.Errorf("check plugin %q is not a Checker", name)
+	}
+	return &runningPlugin{client: client, checker: checker}, nil
+}
+
+// Close stops the running plugins. Checker fails once the manager is
+// closed.
+func (m *Manager) Close() {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	m.closed = true
+	for name, p := range m.plugins {
+		p.client.Kill()
+		delete(m.plugins, name)
+	}
+}
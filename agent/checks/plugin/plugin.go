@@ -0,0 +1,61 @@
+// Package plugin implements custom check types as plugins. A plugin is a
+// separate binary that the agent launches and calls over net/rpc using
+// go-plugin. The main function of the binary calls Serve with the
+// implementation of the check type.
+package plugin
+
+import (
+	"net/rpc"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// Checker is a custom check type implemented by a plugin.
+type Checker interface {
+	// Check runs a check and returns its result. An error makes the check
+	// critical with the error as its output.
+	Check(req *CheckRequest) (*CheckResponse, error)
+}
+
+// CheckRequest is the definition of a check to run.
+type CheckRequest struct {
+	// CheckID is the ID of the check.
+	CheckID string
+
+	// Args are the arguments of the check definition, such as the address
+	// of the device to query.
+	Args map[string]string
+
+	// Timeout is the time the check has to complete. The agent reports the
+	// check as critical once it elapsed.
+	Timeout time.Duration
+}
+
+// CheckResponse is the result of a check.
+type CheckResponse struct {
+	// Status is the health of the check, one of "passing", "warning" and
+	// "critical".
+	Status string
+
+	// Output is the output of the check. It is truncated like the output of
+	// the built-in check types.
+	Output string
+}
+
+// CheckerPlugin implements plugin.Plugin for initializing a plugin server
+// and client for net/rpc.
+type CheckerPlugin struct {
+	Impl Checker
+}
+
+func (p CheckerPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &checkerPluginRPCServer{impl: p.Impl}, nil
+}
+
+func (CheckerPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &checkerPluginRPCClient{client: c}, nil
+}
+
+// Verification
+var _ plugin.Plugin = CheckerPlugin{}
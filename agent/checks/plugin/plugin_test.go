@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/require"
+)
+
+// testHelperEnv makes the test binary serve testChecker as a plugin so the
+// Manager tests can launch it.
+const testHelperEnv = "CONSUL_TEST_CHECK_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testHelperEnv) != "" {
+		Serve(testChecker{})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testChecker reports the check as passing with the "output" argument as
+// its output and fails if the "error" argument is set.
+type testChecker struct{}
+
+func (testChecker) Check(req *CheckRequest) (*CheckResponse, error) {
+	if msg := req.Args["error"]; msg != "" {
+		return nil, errors.New(msg)
+	}
+	return &CheckResponse{
+		Status: "passing",
+		Output: fmt.Sprintf("%s %s %s", req.CheckID, req.Args["output"], req.Timeout),
+	}, nil
+}
+
+func TestChecker_Check(t *testing.T) {
+	t.Parallel()
+
+	client, _ := plugin.TestPluginRPCConn(t, map[string]plugin.Plugin{
+		Name: &CheckerPlugin{Impl: testChecker{}},
+	}, nil)
+	defer client.Close()
+
+	raw, err := client.Dispense(Name)
+	require.NoError(t, err)
+	checker := raw.(Checker)
+
+	resp, err := checker.Check(&CheckRequest{
+		CheckID: "ldap",
+		Args:    map[string]string{"output": "bound"},
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &CheckResponse{Status: "passing", Output: "ldap bound 1s"}, resp)
+
+	_, err = checker.Check(&CheckRequest{Args: map[string]string{"error": "no route to host"}})
+	require.EqualError(t, err, "no route to host")
+}
+
+func TestManager(t *testing.T) {
+	os.Setenv(testHelperEnv, "1")
+	defer os.Unsetenv(testHelperEnv)
+
+	m := NewManager(map[string]string{"test": os.Args[0]}, ioutil.Discard)
+	defer m.Close()
+
+	require.True(t, m.Has("test"))
+	require.False(t, m.Has("other"))
+	_, err := m.Checker("other")
+	require.EqualError(t, err, `unknown check plugin "other"`)
+
+	checker, err := m.Checker("test")
+	require.NoError(t, err)
+	resp, err := checker.Check(&CheckRequest{CheckID: "a", Args: map[string]string{"output": "ok"}})
+	require.NoError(t, err)
+	require.Equal(t, "a ok 0s", resp.Output)
+
+	// The running plugin is reused.
+	again, err := m.Checker("test")
+	require.NoError(t, err)
+	require.True(t, checker == again)
+
+	// A plugin that exited is relaunched.
+	m.l.Lock()
+	m.plugins["test"].client.Kill()
+	m.l.Unlock()
+	checker, err = m.Checker("test")
+	require.NoError(t, err)
+	resp, err = checker.Check(&CheckRequest{CheckID: "b"})
+	require.NoError(t, err)
+	require.Equal(t, "passing", resp.Status)
+
+	m.Close()
+	_, err = m.Checker("test")
+	require.EqualError(t, err, "check plugins are stopped")
+}
+
+func TestManager_Concurrent(t *testing.T) {
+	os.Setenv(testHelperEnv, "1")
+	defer os.Unsetenv(testHelperEnv)
+
+	m := NewManager(map[string]string{"test": os.Args[0]}, ioutil.Discard)
+	defer m.Close()
+
+	// Concurrent calls may each launch the plugin, but all of them end up
+	// with the one that's kept running.
+	type result struct {
+		checker Checker
+		err     error
+	}
+	results := make(chan result, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			checker, err := m.Checker("test")
+			results <- result{checker, err}
+		}()
+	}
+
+	var checkers []Checker
+	for i := 0; i < 4; i++ {
+		r := <-results
+		require.NoError(t, r.err)
+		checkers = append(checkers, r.checker)
+	}
+	m.l.Lock()
+	running := m.plugins["test"].checker
+	m.l.Unlock()
+	for _, checker := range checkers {
+		require.True(t, checker == running)
+	}
+	resp, err := running.Check(&CheckRequest{CheckID: "a"})
+	require.NoError(t, err)
+	require.Equal(t, "passing", resp.Status)
+}
@@ -0,0 +1,33 @@
+package plugin
+
+import (
+	"github.com/hashicorp/go-plugin"
+)
+
+// Name is the name of the plugin that users of the package should use
+// with *plugin.Client.Dispense to get the proper plugin instance.
+const Name = "consul-check"
+
+// handshakeConfig is the HandshakeConfig used to configure clients and servers.
+var handshakeConfig = plugin.HandshakeConfig{
+	// The ProtocolVersion is the version that must match between Consul
+	// and check plugins. This should be bumped whenever a change happens in
+	// one or the other that makes it so that they can't safely communicate.
+	ProtocolVersion: 1,
+
+	// The magic cookie values should NEVER be changed. They are shared with
+	// the other plugins of Consul.
+	MagicCookieKey:   "CONSUL_PLUGIN_MAGIC_COOKIE",
+	MagicCookieValue: "f31f63b28fa82a3cdb30a6284cb1e50e3a13b7e60ba105a2c91219da319d216c",
+}
+
+// Serve serves a check plugin. This function never returns and should be
+// the final function called in the main function of the plugin.
+func Serve(c Checker) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: handshakeConfig,
+		Plugins: map[string]plugin.Plugin{
+			Name: &CheckerPlugin{Impl: c},
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"net/rpc"
+)
+
+// checkerPluginRPCServer implements a net/rpc backed transport for an
+// underlying implementation of a Checker. The server side is the plugin
+// binary itself.
+type checkerPluginRPCServer struct {
+	impl Checker
+}
+
+func (p *checkerPluginRPCServer) Check(args *CheckRequest, resp *CheckResponse) error {
+	r, err := p.impl.Check(args)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		*resp = *r
+	}
+	return nil
+}
+
+// checkerPluginRPCClient implements a net/rpc backed transport for an
+// underlying implementation of a Checker. The client side is the agent
+// calling into the plugin binary over rpc.
+//
+// This implements Checker.
+type checkerPluginRPCClient struct {
+	client *rpc.Client
+}
+
+func (p *checkerPluginRPCClient) Check(req *CheckRequest) (*CheckResponse, error) {
+	var resp CheckResponse
+	if err := p.client.Call("Plugin.Check", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Verification
+var _ Checker = &checkerPluginRPCClient{}
@@ -26,8 +26,12 @@ func FixupCheckType(raw interface{}) error {
 		"script_args":                       "ScriptArgs",
 		"deregister_critical_service_after": "DeregisterCriticalServiceAfter",
 		"docker_container_id":               "DockerContainerID",
+		"plugin_args":                       "PluginArgs",
 		"tls_skip_verify":                   "TLSSkipVerify",
 		"service_id":                        "ServiceID",
+
+		// Don't recurse into the opaque arguments of check plugins.
+		"PluginArgs": "",
 	})
 
 	parseDuration := func(v interface{}) (time.Duration, error) {
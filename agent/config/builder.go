@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -26,6 +27,11 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// maxServiceDrainTime is the longest service_drain_time allowed. A graceful
+// leave, including the /v1/agent/leave endpoint, blocks while the services
+// drain.
+const maxServiceDrainTime = 5 * time.Minute
+
 // Builder constructs a valid runtime configuration from multiple
 // configuration sources.
 //
@@ -36,9 +42,9 @@ import (
 //
 // The sources are merged in the following order:
 //
-//  * default configuration
-//  * config files in alphabetical order
-//  * command line arguments
+//   - default configuration
+//   - config files in alphabetical order
+//   - command line arguments
 //
 // The config sources are merged sequentially and later values
 // overwrite previously set values. Slice values are merged by
@@ -65,6 +71,12 @@ type Builder struct {
 	// parsing the configuration.
 	Warnings []string
 
+	// Origins maps the config keys set by the config sources, e.g.
+	// "ports.http", to the name of the last source which set them. Keys
+	// which are lists name the last source which added elements to them.
+	// It is populated by Build.
+	Origins map[string]string
+
 	// Hostname returns the hostname of the machine. If nil, os.Hostname
 	// is called.
 	Hostname func() (string, error)
@@ -101,9 +113,40 @@ func NewBuilder(flags Flags) (*Builder, error) {
 		Head:  []Source{DefaultSource()},
 	}
 
-	if b.boolVal(b.Flags.DevMode) {
+	devMode := b.boolVal(b.Flags.DevMode)
+	if !devMode && (b.boolVal(b.Flags.DevACLs) || b.boolVal(b.Flags.DevTLS)) {
+		return nil, fmt.Errorf("config: -dev-acls and -dev-tls require -dev")
+	}
+	if devMode {
 		b.Head = append(b.Head, DevSource())
 	}
+	if devMode && b.boolVal(b.Flags.DevACLs) {
+		token, err := devMasterToken(b.Flags.DevMasterToken)
+		if err != nil {
+			return nil, err
+		}
+		b.Flags.DevMasterToken = token
+		b.Head = append(b.Head, DevACLSource(token))
+	}
+	if devMode && b.boolVal(b.Flags.DevTLS) {
+		caFile, certFile, keyFile, err := devTLSFiles(b.Flags.DevTLSDir)
+		if err != nil {
+			return nil, err
+		}
+		b.Flags.DevTLSDir = filepath.Dir(caFile)
+		b.Head = append(b.Head, DevTLSSource(caFile, certFile, keyFile))
+	}
+
+	// Remote config layers are fetched whenever a builder is created so
+	// that a reload picks up centrally rolled out changes. They are merged
+	// before the local config files which take precedence.
+	for _, loc := range b.Flags.ConfigRemote {
+		sources, err := ReadRemote(loc)
+		if err != nil {
+			return nil, err
+		}
+		b.Head = append(b.Head, sources...)
+	}
 
 	// Since the merge logic is to overwrite all fields with later
 	// values except slices which are merged by appending later values
@@ -230,6 +273,7 @@ func (b *Builder) BuildAndValidate() (RuntimeConfig, error) {
 func (b *Builder) Build() (rt RuntimeConfig, err error) {
 	b.err = nil
 	b.Warnings = nil
+	b.Origins = make(map[string]string)
 
 	// ----------------------------------------------------------------
 	// merge config sources as follows
@@ -276,6 +320,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		if err != nil {
 			return RuntimeConfig{}, fmt.Errorf("Error parsing %s: %s", s.Name, err)
 		}
+		b.recordOrigins(s.Name, c2)
 
 		// if we have a single 'check' or 'service' we need to add them to the
 		// list of checks and services first since we cannot merge them
@@ -371,6 +416,11 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		return RuntimeConfig{}, fmt.Errorf(
 			"sidecar_min_port must be less than sidecar_max_port. To disable, set both to zero.")
 	}
+	if proxyMinPort > 0 && sidecarMinPort > 0 &&
+		proxyMinPort <= sidecarMaxPort && sidecarMinPort <= proxyMaxPort {
+		return RuntimeConfig{}, fmt.Errorf(
+			"proxy_min_port to proxy_max_port must not overlap with sidecar_min_port to sidecar_max_port.")
+	}
 
 	// determine the default bind and advertise address
 	//
@@ -457,12 +507,34 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		serfAdvertiseAddrWAN = &net.TCPAddr{IP: advertiseAddrWAN.IP, Port: serfPortWAN}
 	}
 
+	// expand the per-datacenter WAN advertise addresses
+	var advertiseAddrWANMap map[string]string
+	for dc, addr := range c.AdvertiseAddrWANMap {
+		addr := addr
+		ip := b.expandFirstIP(fmt.Sprintf("advertise_addr_wan_map[%s]", dc), &addr)
+		if ip == nil {
+			continue
+		}
+		if advertiseAddrWANMap == nil {
+			advertiseAddrWANMap = make(map[string]string)
+		}
+		advertiseAddrWANMap[dc] = ip.IP.String()
+	}
+
 	// determine client addresses
 	clientAddrs := b.expandIPs("client_addr", c.ClientAddr)
-	dnsAddrs := b.makeAddrs(b.expandAddrs("addresses.dns", c.Addresses.DNS), clientAddrs, dnsPort)
-	httpAddrs := b.makeAddrs(b.expandAddrs("addresses.http", c.Addresses.HTTP), clientAddrs, httpPort)
-	httpsAddrs := b.makeAddrs(b.expandAddrs("addresses.https", c.Addresses.HTTPS), clientAddrs, httpsPort)
-	grpcAddrs := b.makeAddrs(b.expandAddrs("addresses.grpc", c.Addresses.GRPC), clientAddrs, grpcPort)
+	dnsAddrs, _ := b.expandAddrConfigs("addresses.dns", c.Addresses.DNS, dnsPort, nil)
+	dnsAddrs = b.makeAddrs(dnsAddrs, clientAddrs, dnsPort)
+	httpAddrs, _ := b.expandAddrConfigs("addresses.http", c.Addresses.HTTP, httpPort, nil)
+	httpAddrs = b.makeAddrs(httpAddrs, clientAddrs, httpPort)
+	httpsAddrs, httpsAddrTLS := b.expandAddrConfigs("addresses.https", c.Addresses.HTTPS, httpsPort, &AddrTLSConfig{
+		CertFile:       b.stringVal(c.CertFile),
+		KeyFile:        b.stringVal(c.KeyFile),
+		VerifyIncoming: b.boolVal(c.VerifyIncoming) || b.boolVal(c.VerifyIncomingHTTPS),
+	})
+	httpsAddrs = b.makeAddrs(httpsAddrs, clientAddrs, httpsPort)
+	grpcAddrs, _ := b.expandAddrConfigs("addresses.grpc", c.Addresses.GRPC, grpcPort, nil)
+	grpcAddrs = b.makeAddrs(grpcAddrs, clientAddrs, grpcPort)
 
 	for _, a := range dnsAddrs {
 		if x, ok := a.(*net.TCPAddr); ok {
@@ -518,12 +590,34 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 			port,
 		)
 
-		segments = append(segments, structs.NetworkSegment{
+		segment := structs.NetworkSegment{
 			Name:        name,
 			Bind:        bind,
 			Advertise:   advertise,
 			RPCListener: b.boolVal(s.RPCListener),
-		})
+			CAFile:      b.stringVal(s.CAFile),
+			CertFile:    b.stringVal(s.CertFile),
+			KeyFile:     b.stringVal(s.KeyFile),
+		}
+		if (segment.CertFile == "") != (segment.KeyFile == "") {
+			return RuntimeConfig{}, fmt.Errorf("cert_file and key_file for segment %q must be set together", name)
+		}
+		if !segment.RPCListener && (segment.CAFile != "" || segment.CertFile != "") {
+			return RuntimeConfig{}, fmt.Errorf("TLS files for segment %q require rpc_listener to be enabled", name)
+		}
+		segments = append(segments, segment)
+	}
+
+	var sniCertificates []tlsutil.SNICertificate
+	for i, s := range c.SNICertificates {
+		cert := tlsutil.SNICertificate{
+			CertFile: b.stringVal(s.CertFile),
+			KeyFile:  b.stringVal(s.KeyFile),
+		}
+		if cert.CertFile == "" || cert.KeyFile == "" {
+			return RuntimeConfig{}, fmt.Errorf("sni_certificates[%d]: cert_file and key_file must be set", i)
+		}
+		sniCertificates = append(sniCertificates, cert)
 	}
 
 	// Parse the metric filters
@@ -548,9 +642,73 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 	if performanceRaftMultiplier < 1 || uint(performanceRaftMultiplier) > consul.MaxRaftMultiplier {
 		return RuntimeConfig{}, fmt.Errorf("performance.raft_multiplier cannot be %d. Must be between 1 and %d", performanceRaftMultiplier, consul.MaxRaftMultiplier)
 	}
-	consulRaftElectionTimeout := b.durationVal("consul.raft.election_timeout", c.Consul.Raft.ElectionTimeout) * time.Duration(performanceRaftMultiplier)
-	consulRaftHeartbeatTimeout := b.durationVal("consul.raft.heartbeat_timeout", c.Consul.Raft.HeartbeatTimeout) * time.Duration(performanceRaftMultiplier)
-	consulRaftLeaderLeaseTimeout := b.durationVal("consul.raft.leader_lease_timeout", c.Consul.Raft.LeaderLeaseTimeout) * time.Duration(performanceRaftMultiplier)
+
+	// The timeouts can be scaled individually, for example to relax the
+	// election timeout on flappy networks while keeping failure detection
+	// fast. Each multiplier defaults to raft_multiplier.
+	raftMultiplier := func(name string, v *int) (time.Duration, error) {
+		if v == nil {
+			return time.Duration(performanceRaftMultiplier), nil
+		}
+		if *v < 1 || uint(*v) > consul.MaxRaftMultiplier {
+			return 0, fmt.Errorf("performance.%s cannot be %d. Must be between 1 and %d", name, *v, consul.MaxRaftMultiplier)
+		}
+		return time.Duration(*v), nil
+	}
+	electionMultiplier, err := raftMultiplier("raft_election_multiplier", c.Performance.RaftElectionMultiplier)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	heartbeatMultiplier, err := raftMultiplier("raft_heartbeat_multiplier", c.Performance.RaftHeartbeatMultiplier)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	leaderLeaseMultiplier, err := raftMultiplier("raft_leader_lease_multiplier", c.Performance.RaftLeaderLeaseMultiplier)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	consulRaftElectionTimeout := b.durationVal("consul.raft.election_timeout", c.Consul.Raft.ElectionTimeout) * electionMultiplier
+	consulRaftHeartbeatTimeout := b.durationVal("consul.raft.heartbeat_timeout", c.Consul.Raft.HeartbeatTimeout) * heartbeatMultiplier
+	consulRaftLeaderLeaseTimeout := b.durationVal("consul.raft.leader_lease_timeout", c.Consul.Raft.LeaderLeaseTimeout) * leaderLeaseMultiplier
+	if consulRaftElectionTimeout < consulRaftHeartbeatTimeout {
+		return RuntimeConfig{}, fmt.Errorf("Raft election timeout %s cannot be less than the heartbeat timeout %s. Check performance.raft_election_multiplier and performance.raft_heartbeat_multiplier", consulRaftElectionTimeout, consulRaftHeartbeatTimeout)
+	}
+	if consulRaftLeaderLeaseTimeout > consulRaftHeartbeatTimeout {
+		return RuntimeConfig{}, fmt.Errorf("Raft leader lease timeout %s cannot be greater than the heartbeat timeout %s. Check performance.raft_leader_lease_multiplier and performance.raft_heartbeat_multiplier", consulRaftLeaderLeaseTimeout, consulRaftHeartbeatTimeout)
+	}
+
+	// HTTP request body limits
+	httpMaxRequestBodyBytes := map[string]int{
+		"default": b.intVal(c.HTTPConfig.MaxRequestBodyBytes.Default),
+		"kv":      b.intVal(c.HTTPConfig.MaxRequestBodyBytes.KV),
+		"txn":     b.intVal(c.HTTPConfig.MaxRequestBodyBytes.Txn),
+	}
+
+	// Merging config files always yields a map, so drop an empty one to
+	// keep it out of the telemetry config inherited by managed proxies.
+	otlpHeaders := c.Telemetry.OTLPHeaders
+	if len(otlpHeaders) == 0 {
+		otlpHeaders = nil
+	}
+
+	// UI metrics proxy, which by default only allows the Prometheus
+	// query API
+	uiMetricsProxyPathAllowlist := c.UIConfig.MetricsProxy.PathAllowlist
+	if len(uiMetricsProxyPathAllowlist) == 0 {
+		uiMetricsProxyPathAllowlist = []string{"/api/v1/query", "/api/v1/query_range"}
+	}
+
+	// query concurrency limits
+	rpcQueryLimits := make(map[string]int)
+	for class, limit := range map[string]*int{
+		"health": c.Limits.RPCQueryLimits.Health,
+		"kv":     c.Limits.RPCQueryLimits.KV,
+		"txn":    c.Limits.RPCQueryLimits.Txn,
+	} {
+		if limit != nil {
+			rpcQueryLimits[class] = *limit
+		}
+	}
 
 	// Connect proxy defaults.
 	connectEnabled := b.boolVal(c.Connect.Enabled)
@@ -576,9 +734,16 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 			"tls_skip_verify":       "TLSSkipVerify",
 
 			// Common CA config
-			"leaf_cert_ttl":      "LeafCertTTL",
-			"csr_max_per_second": "CSRMaxPerSecond",
-			"csr_max_concurrent": "CSRMaxConcurrent",
+			"leaf_cert_ttl":              "LeafCertTTL",
+			"csr_max_per_second":         "CSRMaxPerSecond",
+			"csr_max_concurrent":         "CSRMaxConcurrent",
+			"root_rollout_timeout":       "RootRolloutTimeout",
+			"root_rotation_min_interval": "RootRotationMinInterval",
+
+			// Federated trust bundles
+			"federated_trust_bundles": "FederatedTrustBundles",
+			"trust_domain":            "TrustDomain",
+			"root_certs":              "RootCerts",
 		})
 	}
 
@@ -676,9 +841,11 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		// ACL
 		ACLEnforceVersion8:        b.boolValWithDefault(c.ACLEnforceVersion8, true),
 		ACLsEnabled:               aclsEnabled,
+		ACLAllowAnonymousWrite:    b.boolVal(c.ACL.AllowAnonymousWrite),
 		ACLAgentMasterToken:       b.stringValWithDefault(c.ACL.Tokens.AgentMaster, b.stringVal(c.ACLAgentMasterToken)),
 		ACLAgentToken:             b.stringValWithDefault(c.ACL.Tokens.Agent, b.stringVal(c.ACLAgentToken)),
 		ACLDatacenter:             aclDC,
+		ACLDNSToken:               b.stringVal(c.ACL.Tokens.DNS),
 		ACLDefaultPolicy:          b.stringValWithDefault(c.ACL.DefaultPolicy, b.stringVal(c.ACLDefaultPolicy)),
 		ACLDownPolicy:             b.stringValWithDefault(c.ACL.DownPolicy, b.stringVal(c.ACLDownPolicy)),
 		ACLEnableKeyListPolicy:    b.boolValWithDefault(c.ACL.EnableKeyListPolicy, b.boolVal(c.ACLEnableKeyListPolicy)),
@@ -691,22 +858,26 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		ACLEnableTokenPersistence: b.boolValWithDefault(c.ACL.EnableTokenPersistence, false),
 
 		// Autopilot
-		AutopilotCleanupDeadServers:      b.boolVal(c.Autopilot.CleanupDeadServers),
-		AutopilotDisableUpgradeMigration: b.boolVal(c.Autopilot.DisableUpgradeMigration),
-		AutopilotLastContactThreshold:    b.durationVal("autopilot.last_contact_threshold", c.Autopilot.LastContactThreshold),
-		AutopilotMaxTrailingLogs:         b.intVal(c.Autopilot.MaxTrailingLogs),
-		AutopilotRedundancyZoneTag:       b.stringVal(c.Autopilot.RedundancyZoneTag),
-		AutopilotServerStabilizationTime: b.durationVal("autopilot.server_stabilization_time", c.Autopilot.ServerStabilizationTime),
-		AutopilotUpgradeVersionTag:       b.stringVal(c.Autopilot.UpgradeVersionTag),
+		AutopilotCleanupDeadServers:       b.boolVal(c.Autopilot.CleanupDeadServers),
+		AutopilotDeadServerQuarantineTime: b.durationVal("autopilot.dead_server_quarantine_time", c.Autopilot.DeadServerQuarantineTime),
+		AutopilotDisableUpgradeMigration:  b.boolVal(c.Autopilot.DisableUpgradeMigration),
+		AutopilotLastContactThreshold:     b.durationVal("autopilot.last_contact_threshold", c.Autopilot.LastContactThreshold),
+		AutopilotMaxDeadServerRemovals:    b.intVal(c.Autopilot.MaxDeadServerRemovals),
+		AutopilotMaxTrailingLogs:          b.intVal(c.Autopilot.MaxTrailingLogs),
+		AutopilotRedundancyZoneTag:        b.stringVal(c.Autopilot.RedundancyZoneTag),
+		AutopilotServerStabilizationTime:  b.durationVal("autopilot.server_stabilization_time", c.Autopilot.ServerStabilizationTime),
+		AutopilotUpgradeVersionTag:        b.stringVal(c.Autopilot.UpgradeVersionTag),
 
 		// DNS
 		DNSAddrs:              dnsAddrs,
 		DNSAllowStale:         b.boolVal(c.DNS.AllowStale),
 		DNSARecordLimit:       b.intVal(c.DNS.ARecordLimit),
+		DNSAnswerOrder:        b.stringVal(c.DNS.AnswerOrder),
 		DNSDisableCompression: b.boolVal(c.DNS.DisableCompression),
 		DNSDomain:             b.stringVal(c.DNSDomain),
 		DNSEnableTruncate:     b.boolVal(c.DNS.EnableTruncate),
 		DNSMaxStale:           b.durationVal("dns_config.max_stale", c.DNS.MaxStale),
+		DNSNegativeTTL:        b.durationVal("dns_config.negative_ttl", c.DNS.NegativeTTL),
 		DNSNodeTTL:            b.durationVal("dns_config.node_ttl", c.DNS.NodeTTL),
 		DNSOnlyPassing:        b.boolVal(c.DNS.OnlyPassing),
 		DNSPort:               dnsPort,
@@ -720,13 +891,18 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		DNSCacheMaxAge:        b.durationVal("dns_config.cache_max_age", c.DNS.CacheMaxAge),
 
 		// HTTP
-		HTTPPort:            httpPort,
-		HTTPSPort:           httpsPort,
-		HTTPAddrs:           httpAddrs,
-		HTTPSAddrs:          httpsAddrs,
-		HTTPBlockEndpoints:  c.HTTPConfig.BlockEndpoints,
-		HTTPResponseHeaders: c.HTTPConfig.ResponseHeaders,
-		AllowWriteHTTPFrom:  b.cidrsVal("allow_write_http_from", c.HTTPConfig.AllowWriteHTTPFrom),
+		HTTPPort:                     httpPort,
+		HTTPSPort:                    httpsPort,
+		HTTPAddrs:                    httpAddrs,
+		HTTPSAddrs:                   httpsAddrs,
+		HTTPSAddrTLS:                 httpsAddrTLS,
+		HTTPSAcceptRate:              b.float64Val(c.Limits.HTTPSAcceptRate),
+		HTTPSMaxConcurrentHandshakes: b.intVal(c.Limits.HTTPSMaxConcurrentHandshakes),
+		HTTPBlockEndpoints:           c.HTTPConfig.BlockEndpoints,
+		HTTPResponseHeaders:          c.HTTPConfig.ResponseHeaders,
+		HTTPMaxHeaderBytes:           b.intVal(c.HTTPConfig.MaxHeaderBytes),
+		HTTPMaxRequestBodyBytes:      httpMaxRequestBodyBytes,
+		AllowWriteHTTPFrom:           b.cidrsVal("allow_write_http_from", c.HTTPConfig.AllowWriteHTTPFrom),
 
 		// Telemetry
 		Telemetry: lib.TelemetryConfig{
@@ -747,23 +923,42 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 			DogstatsdAddr:                      b.stringVal(c.Telemetry.DogstatsdAddr),
 			DogstatsdTags:                      c.Telemetry.DogstatsdTags,
 			PrometheusRetentionTime:            b.durationVal("prometheus_retention_time", c.Telemetry.PrometheusRetentionTime),
+			PrometheusHistogramBuckets:         c.Telemetry.PrometheusHistogramBuckets,
+			PrometheusHistogramPrefixes:        c.Telemetry.PrometheusHistogramPrefixes,
 			FilterDefault:                      b.boolVal(c.Telemetry.FilterDefault),
 			AllowedPrefixes:                    telemetryAllowedPrefixes,
 			BlockedPrefixes:                    telemetryBlockedPrefixes,
 			MetricsPrefix:                      b.stringVal(c.Telemetry.MetricsPrefix),
+			OTLPEndpoint:                       b.stringVal(c.Telemetry.OTLPEndpoint),
+			OTLPHeaders:                        otlpHeaders,
+			OTLPInterval:                       b.durationVal("telemetry.otlp_interval", c.Telemetry.OTLPInterval),
+			OTLPTracesEndpoint:                 b.stringVal(c.Telemetry.OTLPTracesEndpoint),
+			OTLPCAFile:                         b.stringVal(c.Telemetry.OTLPCAFile),
+			OTLPCertFile:                       b.stringVal(c.Telemetry.OTLPCertFile),
+			OTLPKeyFile:                        b.stringVal(c.Telemetry.OTLPKeyFile),
 			StatsdAddr:                         b.stringVal(c.Telemetry.StatsdAddr),
 			StatsiteAddr:                       b.stringVal(c.Telemetry.StatsiteAddr),
+			TokenRequestMetricsLimit:           b.intVal(c.Telemetry.TokenRequestMetricsLimit),
 		},
 
 		// Agent
 		AdvertiseAddrLAN:                        advertiseAddrLAN,
 		AdvertiseAddrWAN:                        advertiseAddrWAN,
+		AdvertiseAddrWANMap:                     advertiseAddrWANMap,
 		BindAddr:                                bindAddr,
 		Bootstrap:                               b.boolVal(c.Bootstrap),
+		BootstrapClusterID:                      b.stringVal(c.BootstrapClusterID),
 		BootstrapExpect:                         b.intVal(c.BootstrapExpect),
 		CAFile:                                  b.stringVal(c.CAFile),
 		CAPath:                                  b.stringVal(c.CAPath),
+		CRLFile:                                 b.stringVal(c.CRLFile),
+		CRLPath:                                 b.stringVal(c.CRLPath),
+		CertBundleFile:                          b.stringVal(c.CertBundleFile),
 		CertFile:                                b.stringVal(c.CertFile),
+		CheckFlapHoldDown:                       b.durationVal("check_flap_hold_down", c.CheckFlapHoldDown),
+		CheckOutputMaxSize:                      b.intVal(c.CheckOutputMaxSize),
+		CheckOutputTruncation:                   b.stringVal(c.CheckOutputTruncation),
+		CheckPlugins:                            c.CheckPlugins,
 		CheckUpdateInterval:                     b.durationVal("check_update_interval", c.CheckUpdateInterval),
 		Checks:                                  checks,
 		ClientAddrs:                             clientAddrs,
@@ -781,6 +976,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		ConnectProxyDefaultScriptCommand:        proxyDefaultScriptCommand,
 		ConnectProxyDefaultConfig:               proxyDefaultConfig,
 		DataDir:                                 b.stringVal(c.DataDir),
+		DataDirEncryptionKeyFile:                b.stringVal(c.DataDirEncryptionKeyFile),
 		Datacenter:                              datacenter,
 		DevMode:                                 b.boolVal(b.Flags.DevMode),
 		DisableAnonymousSignature:               b.boolVal(c.DisableAnonymousSignature),
@@ -792,18 +988,24 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		DisableUpdateCheck:                      b.boolVal(c.DisableUpdateCheck),
 		DiscardCheckOutput:                      b.boolVal(c.DiscardCheckOutput),
 		DiscoveryMaxStale:                       b.durationVal("discovery_max_stale", c.DiscoveryMaxStale),
+		DockerCheckRuntime:                      b.stringVal(c.DockerCheckRuntime),
+		DockerCheckRuntimeEndpoint:              b.stringVal(c.DockerCheckRuntimeEndpoint),
 		EnableAgentTLSForChecks:                 b.boolVal(c.EnableAgentTLSForChecks),
 		EnableDebug:                             b.boolVal(c.EnableDebug),
 		EnableRemoteScriptChecks:                enableRemoteScriptChecks,
 		EnableLocalScriptChecks:                 enableLocalScriptChecks,
+		EnableEventLog:                          b.boolVal(c.EnableEventLog),
 		EnableSyslog:                            b.boolVal(c.EnableSyslog),
 		EnableUI:                                b.boolVal(c.UI),
 		EncryptKey:                              b.stringVal(c.EncryptKey),
 		EncryptVerifyIncoming:                   b.boolVal(c.EncryptVerifyIncoming),
 		EncryptVerifyOutgoing:                   b.boolVal(c.EncryptVerifyOutgoing),
+		FollowerReads:                           c.Performance.FollowerReads,
 		GRPCPort:                                grpcPort,
 		GRPCAddrs:                               grpcAddrs,
 		KeyFile:                                 b.stringVal(c.KeyFile),
+		KeyPassphrase:                           b.stringVal(c.KeyPassphrase),
+		KeyPassphraseFile:                       b.stringVal(c.KeyPassphraseFile),
 		LeaveDrainTime:                          b.durationVal("performance.leave_drain_time", c.Performance.LeaveDrainTime),
 		LeaveOnTerm:                             leaveOnTerm,
 		LogLevel:                                b.stringVal(c.LogLevel),
@@ -820,6 +1022,13 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		RPCBindAddr:                             rpcBindAddr,
 		RPCHoldTimeout:                          b.durationVal("performance.rpc_hold_timeout", c.Performance.RPCHoldTimeout),
 		RPCMaxBurst:                             b.intVal(c.Limits.RPCMaxBurst),
+		RPCMaxConns:                             b.intVal(c.Limits.RPCMaxConns),
+		RPCMaxStreamsPerConn:                    b.intVal(c.Limits.RPCMaxStreamsPerConn),
+		RPCAcceptBackpressure:                   b.boolVal(c.Limits.RPCAcceptBackpressure),
+		RPCAcceptRate:                           b.float64Val(c.Limits.RPCAcceptRate),
+		RPCMaxConcurrentHandshakes:              b.intVal(c.Limits.RPCMaxConcurrentHandshakes),
+		RPCQueryLimits:                          rpcQueryLimits,
+		RPCQueryQueueTimeout:                    b.durationVal("limits.rpc_query_queue_timeout", c.Limits.RPCQueryQueueTimeout),
 		RPCProtocol:                             b.intVal(c.RPCProtocol),
 		RPCRateLimit:                            rate.Limit(b.float64Val(c.Limits.RPCRate)),
 		RaftProtocol:                            b.intVal(c.RaftProtocol),
@@ -833,7 +1042,15 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		RetryJoinLAN:                            b.expandAllOptionalAddrs("retry_join", c.RetryJoinLAN),
 		RetryJoinMaxAttemptsLAN:                 b.intVal(c.RetryJoinMaxAttemptsLAN),
 		RetryJoinMaxAttemptsWAN:                 b.intVal(c.RetryJoinMaxAttemptsWAN),
+		RetryJoinMaxIntervalLAN:                 b.durationVal("retry_max_interval", c.RetryJoinMaxIntervalLAN),
+		RetryJoinMaxIntervalWAN:                 b.durationVal("retry_max_interval_wan", c.RetryJoinMaxIntervalWAN),
+		RetryJoinSplayLAN:                       b.durationVal("retry_splay", c.RetryJoinSplayLAN),
+		RetryJoinSplayWAN:                       b.durationVal("retry_splay_wan", c.RetryJoinSplayWAN),
 		RetryJoinWAN:                            b.expandAllOptionalAddrs("retry_join_wan", c.RetryJoinWAN),
+		ScriptCheckDir:                          b.stringVal(c.ScriptCheckDir),
+		ScriptCheckEnvWhitelist:                 c.ScriptCheckEnvWhitelist,
+		ScriptCheckKillTimeout:                  b.durationVal("script_check_kill_timeout", c.ScriptCheckKillTimeout),
+		ScriptCheckUser:                         b.stringVal(c.ScriptCheckUser),
 		SegmentName:                             b.stringVal(c.SegmentName),
 		Segments:                                segments,
 		SerfAdvertiseAddrLAN:                    serfAdvertiseAddrLAN,
@@ -845,18 +1062,30 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		ServerMode:                              b.boolVal(c.ServerMode),
 		ServerName:                              b.stringVal(c.ServerName),
 		ServerPort:                              serverPort,
+		ServiceDrainTime:                        b.durationVal("service_drain_time", c.ServiceDrainTime),
 		Services:                                services,
 		SessionTTLMin:                           b.durationVal("session_ttl_min", c.SessionTTLMin),
+		SkipClusterIDCheck:                      b.boolVal(c.SkipClusterIDCheck),
 		SkipLeaveOnInt:                          skipLeaveOnInt,
+		SNICertificates:                         sniCertificates,
 		StartJoinAddrsLAN:                       b.expandAllOptionalAddrs("start_join", c.StartJoinAddrsLAN),
 		StartJoinAddrsWAN:                       b.expandAllOptionalAddrs("start_join_wan", c.StartJoinAddrsWAN),
 		SyslogFacility:                          b.stringVal(c.SyslogFacility),
 		TLSCipherSuites:                         b.tlsCipherSuites("tls_cipher_suites", c.TLSCipherSuites),
+		TLSDatacenterServerNames:                c.TLSDatacenterServerNames,
+		TLSMaxVersion:                           b.stringVal(c.TLSMaxVersion),
 		TLSMinVersion:                           b.stringVal(c.TLSMinVersion),
+		TLSOCSPRefreshInterval:                  b.durationVal("tls_ocsp_refresh_interval", c.TLSOCSPRefreshInterval),
+		TLSOCSPResponderURL:                     b.stringVal(c.TLSOCSPResponderURL),
+		TLSOCSPStapling:                         b.boolVal(c.TLSOCSPStapling),
 		TLSPreferServerCipherSuites:             b.boolVal(c.TLSPreferServerCipherSuites),
+		TLSWatchFiles:                           b.boolVal(c.TLSWatchFiles),
 		TaggedAddresses:                         c.TaggedAddresses,
 		TranslateWANAddrs:                       b.boolVal(c.TranslateWANAddrs),
 		UIDir:                                   b.stringVal(c.UIDir),
+		UIMetricsProxyBaseURL:                   b.stringVal(c.UIConfig.MetricsProxy.BaseURL),
+		UIMetricsProxyAddHeaders:                c.UIConfig.MetricsProxy.AddHeaders,
+		UIMetricsProxyPathAllowlist:             uiMetricsProxyPathAllowlist,
 		UnixSocketGroup:                         b.stringVal(c.UnixSocket.Group),
 		UnixSocketMode:                          b.stringVal(c.UnixSocket.Mode),
 		UnixSocketUser:                          b.stringVal(c.UnixSocket.User),
@@ -913,6 +1142,20 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if ipaddr.IsAny(rt.AdvertiseAddrWAN.IP) {
 		return fmt.Errorf("Advertise WAN address cannot be 0.0.0.0, :: or [::]")
 	}
+	for dc, addr := range rt.AdvertiseAddrWANMap {
+		if dc == "" {
+			return fmt.Errorf("advertise_addr_wan_map cannot have an empty datacenter")
+		}
+		if dc == rt.Datacenter {
+			return fmt.Errorf("advertise_addr_wan_map cannot have an address for the local datacenter %q", dc)
+		}
+		if ipaddr.IsAny(addr) {
+			return fmt.Errorf("Advertise WAN address for datacenter %q cannot be 0.0.0.0, :: or [::]", dc)
+		}
+	}
+	if len(rt.AdvertiseAddrWANMap) > 0 && !rt.ServerMode {
+		b.warn("advertise_addr_wan_map is only used by servers")
+	}
 	if err := b.validateSegments(rt); err != nil {
 		return err
 	}
@@ -926,6 +1169,13 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 			return fmt.Errorf("DNS recursor address cannot be 0.0.0.0, :: or [::]")
 		}
 	}
+	for _, class := range rt.FollowerReads {
+		switch class {
+		case "catalog", "health", "kv":
+		default:
+			return fmt.Errorf("performance.follower_reads: invalid endpoint class %q. Must be one of catalog, health or kv", class)
+		}
+	}
 	if rt.Bootstrap && !rt.ServerMode {
 		return fmt.Errorf("'bootstrap = true' requires 'server = true'")
 	}
@@ -941,9 +1191,61 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if rt.BootstrapExpect > 0 && rt.Bootstrap {
 		return fmt.Errorf("'bootstrap_expect > 0' and 'bootstrap = true' are mutually exclusive")
 	}
+	if rt.BootstrapClusterID != "" && !rt.ServerMode {
+		return fmt.Errorf("'bootstrap_cluster_id' requires 'server = true'")
+	}
 	if rt.AEInterval <= 0 {
 		return fmt.Errorf("ae_interval cannot be %s. Must be positive", rt.AEInterval)
 	}
+	if rt.CheckFlapHoldDown < 0 {
+		return fmt.Errorf("check_flap_hold_down cannot be negative, got %s", rt.CheckFlapHoldDown)
+	}
+	if rt.CheckOutputMaxSize < 1 {
+		return fmt.Errorf("check_output_max_size cannot be %d. Must be greater than zero", rt.CheckOutputMaxSize)
+	}
+	switch rt.CheckOutputTruncation {
+	case "head", "tail":
+	default:
+		return fmt.Errorf("check_output_truncation must be \"head\" or \"tail\", got %q", rt.CheckOutputTruncation)
+	}
+	for name, path := range rt.CheckPlugins {
+		if path == "" {
+			return fmt.Errorf("check_plugins[%s] must be the path of the plugin", name)
+		}
+	}
+	switch rt.DockerCheckRuntime {
+	case "docker", "containerd":
+	case "cri":
+		if rt.DockerCheckRuntimeEndpoint == "" {
+			return fmt.Errorf("docker_check_runtime_endpoint must be set for docker_check_runtime \"cri\"")
+		}
+	default:
+		return fmt.Errorf("docker_check_runtime must be \"docker\", \"containerd\" or \"cri\", got %q", rt.DockerCheckRuntime)
+	}
+	if rt.ServiceDrainTime < 0 || rt.ServiceDrainTime > maxServiceDrainTime {
+		return fmt.Errorf("service_drain_time cannot be %s. Must be between 0s and %s", rt.ServiceDrainTime, maxServiceDrainTime)
+	}
+	if rt.ScriptCheckKillTimeout < 0 {
+		return fmt.Errorf("script_check_kill_timeout cannot be %s. Must be greater than or equal to zero", rt.ScriptCheckKillTimeout)
+	}
+	if rt.RetryJoinMaxIntervalLAN != 0 && rt.RetryJoinMaxIntervalLAN < rt.RetryJoinIntervalLAN {
+		return fmt.Errorf("retry_max_interval cannot be %s. Must be zero or greater than or equal to retry_interval %s", rt.RetryJoinMaxIntervalLAN, rt.RetryJoinIntervalLAN)
+	}
+	if rt.RetryJoinMaxIntervalWAN != 0 && rt.RetryJoinMaxIntervalWAN < rt.RetryJoinIntervalWAN {
+		return fmt.Errorf("retry_max_interval_wan cannot be %s. Must be zero or greater than or equal to retry_interval_wan %s", rt.RetryJoinMaxIntervalWAN, rt.RetryJoinIntervalWAN)
+	}
+	if rt.RetryJoinSplayLAN < 0 {
+		return fmt.Errorf("retry_splay cannot be %s. Must be greater than or equal to zero", rt.RetryJoinSplayLAN)
+	}
+	if rt.RetryJoinSplayWAN < 0 {
+		return fmt.Errorf("retry_splay_wan cannot be %s. Must be greater than or equal to zero", rt.RetryJoinSplayWAN)
+	}
+	if rt.AutopilotDeadServerQuarantineTime < 0 {
+		return fmt.Errorf("autopilot.dead_server_quarantine_time cannot be %s. Must be greater than or equal to zero", rt.AutopilotDeadServerQuarantineTime)
+	}
+	if rt.AutopilotMaxDeadServerRemovals < 0 {
+		return fmt.Errorf("autopilot.max_dead_server_removals cannot be %d. Must be greater than or equal to zero", rt.AutopilotMaxDeadServerRemovals)
+	}
 	if rt.AutopilotMaxTrailingLogs < 0 {
 		return fmt.Errorf("autopilot.max_trailing_logs cannot be %d. Must be greater than or equal to zero", rt.AutopilotMaxTrailingLogs)
 	}
@@ -956,12 +1258,102 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 				"If trying to use your own web UI resources, use the ui-dir flag.\n" +
 				"If using Consul version 0.7.0 or later, the web UI is included in the binary so use ui to enable it")
 	}
+	if rt.UIMetricsProxyBaseURL != "" && !isHTTPURL(rt.UIMetricsProxyBaseURL) {
+		return fmt.Errorf("ui_config.metrics_proxy.base_url must be an http or https URL")
+	}
+	for _, p := range rt.UIMetricsProxyPathAllowlist {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("ui_config.metrics_proxy.path_allowlist entry %q must be an absolute path", p)
+		}
+	}
 	if rt.DNSUDPAnswerLimit < 0 {
 		return fmt.Errorf("dns_config.udp_answer_limit cannot be %d. Must be greater than or equal to zero", rt.DNSUDPAnswerLimit)
 	}
+	if rt.RPCMaxConns < 0 {
+		return fmt.Errorf("limits.rpc_max_conns cannot be %d. Must be greater than or equal to zero", rt.RPCMaxConns)
+	}
+	if rt.RPCMaxStreamsPerConn < 0 {
+		return fmt.Errorf("limits.rpc_max_streams_per_conn cannot be %d. Must be greater than or equal to zero", rt.RPCMaxStreamsPerConn)
+	}
+	if rt.RPCAcceptRate < 0 {
+		return fmt.Errorf("limits.rpc_accept_rate cannot be %v. Must be greater than or equal to zero", rt.RPCAcceptRate)
+	}
+	if rt.RPCMaxConcurrentHandshakes < 0 {
+		return fmt.Errorf("limits.rpc_max_concurrent_handshakes cannot be %d. Must be greater than or equal to zero", rt.RPCMaxConcurrentHandshakes)
+	}
+	if rt.HTTPSAcceptRate < 0 {
+		return fmt.Errorf("limits.https_accept_rate cannot be %v. Must be greater than or equal to zero", rt.HTTPSAcceptRate)
+	}
+	if rt.HTTPSMaxConcurrentHandshakes < 0 {
+		return fmt.Errorf("limits.https_max_concurrent_handshakes cannot be %d. Must be greater than or equal to zero", rt.HTTPSMaxConcurrentHandshakes)
+	}
+	if rt.HTTPMaxHeaderBytes < 0 {
+		return fmt.Errorf("http_config.max_header_bytes cannot be %d. Must be greater than or equal to zero", rt.HTTPMaxHeaderBytes)
+	}
+	for _, class := range []string{"default", "kv", "txn"} {
+		if limit := rt.HTTPMaxRequestBodyBytes[class]; limit < 0 {
+			return fmt.Errorf("http_config.max_request_body_bytes.%s cannot be %d. Must be greater than or equal to zero", class, limit)
+		}
+	}
+	for _, class := range []string{"health", "kv", "txn"} {
+		if limit := rt.RPCQueryLimits[class]; limit < 0 {
+			return fmt.Errorf("limits.rpc_query_limits.%s cannot be %d. Must be greater than or equal to zero", class, limit)
+		}
+	}
+	if rt.Telemetry.OTLPEndpoint != "" {
+		if !isHTTPURL(rt.Telemetry.OTLPEndpoint) {
+			return fmt.Errorf("telemetry.otlp_endpoint must be an http or https URL")
+		}
+		if rt.Telemetry.OTLPInterval <= 0 {
+			return fmt.Errorf("telemetry.otlp_interval must be positive")
+		}
+	}
+	if rt.Telemetry.OTLPTracesEndpoint != "" && !isHTTPURL(rt.Telemetry.OTLPTracesEndpoint) {
+		return fmt.Errorf("telemetry.otlp_traces_endpoint must be an http or https URL")
+	}
+	for i, bound := range rt.Telemetry.PrometheusHistogramBuckets {
+		if i > 0 && bound <= rt.Telemetry.PrometheusHistogramBuckets[i-1] {
+			return fmt.Errorf("telemetry.prometheus_histogram_buckets must be in increasing order")
+		}
+	}
+	if rt.Telemetry.TokenRequestMetricsLimit < 0 {
+		return fmt.Errorf("telemetry.token_request_metrics_limit cannot be %d. Must be greater than or equal to zero", rt.Telemetry.TokenRequestMetricsLimit)
+	}
+	switch rt.DNSAnswerOrder {
+	case DNSAnswerOrderRandom, DNSAnswerOrderRoundRobin, DNSAnswerOrderWeighted:
+	default:
+		return fmt.Errorf("dns_config.answer_order must be one of %q, %q or %q, got %q",
+			DNSAnswerOrderRandom, DNSAnswerOrderRoundRobin, DNSAnswerOrderWeighted, rt.DNSAnswerOrder)
+	}
 	if rt.DNSARecordLimit < 0 {
 		return fmt.Errorf("dns_config.a_record_limit cannot be %d. Must be greater than or equal to zero", rt.DNSARecordLimit)
 	}
+	if rt.TLSMaxVersion != "" {
+		max, ok := tlsutil.TLSLookup[rt.TLSMaxVersion]
+		if !ok {
+			return fmt.Errorf("tls_max_version must be one of \"tls10\", \"tls11\", \"tls12\" or \"tls13\", got %q", rt.TLSMaxVersion)
+		}
+		if min, ok := tlsutil.TLSLookup[rt.TLSMinVersion]; ok && min > max {
+			return fmt.Errorf("tls_min_version %q cannot be greater than tls_max_version %q", rt.TLSMinVersion, rt.TLSMaxVersion)
+		}
+	}
+	for dc, name := range rt.TLSDatacenterServerNames {
+		if name == "" {
+			return fmt.Errorf("tls_datacenter_server_names: server name for datacenter %q cannot be empty", dc)
+		}
+	}
+	if rt.TLSOCSPStapling && rt.CertBundleFile == "" && (rt.CertFile == "" || rt.KeyFile == "") {
+		return fmt.Errorf("tls_ocsp_stapling requires cert_file and key_file or cert_bundle_file")
+	}
+	if rt.TLSOCSPResponderURL != "" {
+		u, err := url.Parse(rt.TLSOCSPResponderURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("tls_ocsp_responder_url must be an http or https URL, got %q", rt.TLSOCSPResponderURL)
+		}
+	}
+	if rt.TLSOCSPRefreshInterval != 0 && rt.TLSOCSPRefreshInterval < time.Minute {
+		return fmt.Errorf("tls_ocsp_refresh_interval cannot be shorter than 1m, got %s", rt.TLSOCSPRefreshInterval)
+	}
 	if err := structs.ValidateMetadata(rt.NodeMeta, false); err != nil {
 		return fmt.Errorf("node_meta invalid: %v", err)
 	}
@@ -1133,7 +1525,7 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		Name:                           b.stringVal(v.Name),
 		Notes:                          b.stringVal(v.Notes),
 		ServiceID:                      b.stringVal(v.ServiceID),
-		Token:                          b.stringVal(v.Token),
+		Token:                          b.tokenVal(fmt.Sprintf("check[%s]", id), v.Token, v.TokenFile, v.TokenEnv),
 		Status:                         b.stringVal(v.Status),
 		ScriptArgs:                     v.ScriptArgs,
 		HTTP:                           b.stringVal(v.HTTP),
@@ -1145,6 +1537,8 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		Shell:                          b.stringVal(v.Shell),
 		GRPC:                           b.stringVal(v.GRPC),
 		GRPCUseTLS:                     b.boolVal(v.GRPCUseTLS),
+		Plugin:                         b.stringVal(v.Plugin),
+		PluginArgs:                     v.PluginArgs,
 		TLSSkipVerify:                  b.boolVal(v.TLSSkipVerify),
 		AliasNode:                      b.stringVal(v.AliasNode),
 		AliasService:                   b.stringVal(v.AliasService),
@@ -1154,6 +1548,43 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 	}
 }
 
+// tokenVal returns the ACL token of a service or check definition, which is
+// either given inline or read from a file or an environment variable when the
+// configuration is loaded.
+func (b *Builder) tokenVal(name string, token, file, env *string) string {
+	set := 0
+	for _, v := range []*string{token, file, env} {
+		if v != nil {
+			set++
+		}
+	}
+	if set > 1 {
+		b.err = multierror.Append(b.err, fmt.Errorf("%s: only one of token, token_file and token_env can be set", name))
+		return ""
+	}
+
+	switch {
+	case file != nil:
+		data, err := ioutil.ReadFile(*file)
+		if err != nil {
+			b.err = multierror.Append(b.err, fmt.Errorf("%s: failed to read token_file: %s", name, err))
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+
+	case env != nil:
+		v, ok := os.LookupEnv(*env)
+		if !ok {
+			b.err = multierror.Append(b.err, fmt.Errorf("%s: token_env %q is not set", name, *env))
+			return ""
+		}
+		return v
+
+	default:
+		return b.stringVal(token)
+	}
+}
+
 func (b *Builder) serviceVal(v *ServiceDefinition) *structs.ServiceDefinition {
 	if v == nil {
 		return nil
@@ -1186,16 +1617,31 @@ func (b *Builder) serviceVal(v *ServiceDefinition) *structs.ServiceDefinition {
 	if err := structs.ValidateWeights(serviceWeights); err != nil {
 		b.err = multierror.Append(fmt.Errorf("Invalid weight definition for service %s: %s", b.stringVal(v.Name), err))
 	}
+
+	var taggedAddrs map[string]structs.ServiceAddress
+	if len(v.TaggedAddresses) > 0 {
+		taggedAddrs = make(map[string]structs.ServiceAddress)
+		for tag, addr := range v.TaggedAddresses {
+			taggedAddrs[tag] = structs.ServiceAddress{
+				Address: b.stringVal(addr.Address),
+				Port:    b.intVal(addr.Port),
+			}
+		}
+	}
+
 	return &structs.ServiceDefinition{
 		Kind:              b.serviceKindVal(v.Kind),
 		ID:                b.stringVal(v.ID),
 		Name:              b.stringVal(v.Name),
 		Tags:              v.Tags,
 		Address:           b.stringVal(v.Address),
+		TaggedAddresses:   taggedAddrs,
 		Meta:              meta,
 		Port:              b.intVal(v.Port),
-		Token:             b.stringVal(v.Token),
+		Token:             b.tokenVal(fmt.Sprintf("service[%s]", b.stringVal(v.Name)), v.Token, v.TokenFile, v.TokenEnv),
 		EnableTagOverride: b.boolVal(v.EnableTagOverride),
+		InheritTags:       b.boolVal(v.InheritTags),
+		InheritMeta:       b.boolVal(v.InheritMeta),
 		Weights:           serviceWeights,
 		Checks:            checks,
 		// DEPRECATED (ProxyDestination) - don't populate deprecated field, just use
@@ -1212,6 +1658,10 @@ func (b *Builder) serviceKindVal(v *string) structs.ServiceKind {
 	switch *v {
 	case string(structs.ServiceKindConnectProxy):
 		return structs.ServiceKindConnectProxy
+	case string(structs.ServiceKindTerminatingGateway):
+		return structs.ServiceKindTerminatingGateway
+	case string(structs.ServiceKindIngressGateway):
+		return structs.ServiceKindIngressGateway
 	default:
 		return structs.ServiceKindTypical
 	}
@@ -1234,6 +1684,21 @@ func (b *Builder) serviceProxyVal(v *ServiceProxy, deprecatedDest *string) *stru
 		LocalServicePort:       b.intVal(v.LocalServicePort),
 		Config:                 v.Config,
 		Upstreams:              b.upstreamsVal(v.Upstreams),
+		TransparentProxy:       b.transparentProxyVal(v.TransparentProxy),
+	}
+}
+
+func (b *Builder) transparentProxyVal(v *TransparentProxy) *structs.TransparentProxyConfig {
+	if v == nil {
+		return nil
+	}
+
+	return &structs.TransparentProxyConfig{
+		OutboundListenerPort: b.intVal(v.OutboundListenerPort),
+		ExcludeInboundPorts:  v.ExcludeInboundPorts,
+		ExcludeOutboundPorts: v.ExcludeOutboundPorts,
+		ExcludeOutboundCIDRs: v.ExcludeOutboundCIDRs,
+		ExcludeUIDs:          v.ExcludeUIDs,
 	}
 }
 
@@ -1441,6 +1906,60 @@ func (b *Builder) expandAddrs(name string, s *string) []net.Addr {
 	return addrs
 }
 
+// expandAddrConfigs expands the addresses of all entries in an address list
+// and returns the result as a list of *net.IPAddr and *net.UnixAddr. If def
+// is not nil, entries can have TLS settings which override the ones in def.
+// They are returned keyed by the listener addresses the entries expand to
+// with the given port. Entries without TLS settings of their own are not
+// included.
+func (b *Builder) expandAddrConfigs(name string, cs *[]AddressConfig, port int, def *AddrTLSConfig) ([]net.Addr, map[string]AddrTLSConfig) {
+	if cs == nil {
+		return nil, nil
+	}
+
+	var addrs []net.Addr
+	var tlsConfs map[string]AddrTLSConfig
+	for _, c := range *cs {
+		x := b.expandAddrs(name, c.Address)
+		addrs = append(addrs, x...)
+		if c.CertFile == nil && c.KeyFile == nil && c.VerifyIncoming == nil {
+			continue
+		}
+
+		if def == nil {
+			b.err = multierror.Append(b.err, fmt.Errorf("%s: cert_file, key_file and verify_incoming are only supported for HTTPS addresses", name))
+			return nil, nil
+		}
+		if (c.CertFile == nil) != (c.KeyFile == nil) {
+			b.err = multierror.Append(b.err, fmt.Errorf("%s: cert_file and key_file must be set together", name))
+			return nil, nil
+		}
+		tlsConf := AddrTLSConfig{
+			CertFile:       b.stringValWithDefault(c.CertFile, def.CertFile),
+			KeyFile:        b.stringValWithDefault(c.KeyFile, def.KeyFile),
+			VerifyIncoming: b.boolValWithDefault(c.VerifyIncoming, def.VerifyIncoming),
+		}
+		if tlsConf.CertFile == "" || tlsConf.KeyFile == "" {
+			b.err = multierror.Append(b.err, fmt.Errorf("%s: TLS settings require a cert_file and key_file", name))
+			return nil, nil
+		}
+		if port <= 0 {
+			continue
+		}
+		for _, a := range x {
+			if isUnixAddr(a) {
+				b.err = multierror.Append(b.err, fmt.Errorf("%s: TLS settings are not supported for unix sockets", name))
+				return nil, nil
+			}
+			if tlsConfs == nil {
+				tlsConfs = make(map[string]AddrTLSConfig)
+			}
+			tlsConfs[b.makeAddr(a, nil, port).String()] = tlsConf
+		}
+	}
+	return addrs, tlsConfs
+}
+
 // expandOptionalAddrs expands the go-sockaddr template in s and returns the
 // result as a list of strings. If s does not contain a go-sockaddr template,
 // the result list will contain the input string as a single element with no
@@ -1639,3 +2158,9 @@ func isUnixAddr(a net.Addr) bool {
 	_, ok := a.(*net.UnixAddr)
 	return ok
 }
+
+// isHTTPURL returns true if the given string is an absolute http or https URL.
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
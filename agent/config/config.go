@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 
 	multierror "github.com/hashicorp/go-multierror"
@@ -47,6 +48,12 @@ func Parse(data string, format string) (c Config, err error) {
 		return Config{}, err
 	}
 
+	// Replace ${env.NAME} and ${file("path")} in all string values before
+	// the values are decoded so that they can be used for any field.
+	if _, err := interpolate(raw); err != nil {
+		return Config{}, err
+	}
+
 	// We want to be able to report fields which we cannot map as an
 	// error so that users find typos in their configuration quickly. To
 	// achieve this we use the mapstructure library which maps a a raw
@@ -80,6 +87,7 @@ func Parse(data string, format string) (c Config, err error) {
 	m := patchSliceOfMaps(raw, []string{
 		"checks",
 		"segments",
+		"sni_certificates",
 		"service.checks",
 		"services",
 		"services.checks",
@@ -97,6 +105,12 @@ func Parse(data string, format string) (c Config, err error) {
 		"services.connect.sidecar_service.checks",
 		"service.connect.sidecar_service.proxy.upstreams",
 		"services.connect.sidecar_service.proxy.upstreams",
+
+		// Addresses can be lists mixing strings and objects.
+		"addresses.dns",
+		"addresses.http",
+		"addresses.https",
+		"addresses.grpc",
 	})
 
 	// There is a difference of representation of some fields depending on
@@ -115,15 +129,25 @@ func Parse(data string, format string) (c Config, err error) {
 	TranslateKeys(m, map[string]string{
 		"deregistercriticalserviceafter": "deregister_critical_service_after",
 		"dockercontainerid":              "docker_container_id",
+		"pluginargs":                     "plugin_args",
 		"scriptargs":                     "args",
 		"serviceid":                      "service_id",
 		"tlsskipverify":                  "tls_skip_verify",
+
+		// Don't recurse into the opaque arguments of check plugins.
+		"check.plugin_args":           "",
+		"checks.plugin_args":          "",
+		"service.check.plugin_args":   "",
+		"service.checks.plugin_args":  "",
+		"services.check.plugin_args":  "",
+		"services.checks.plugin_args": "",
 	})
 
 	var md mapstructure.Metadata
 	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		Metadata: &md,
-		Result:   &c,
+		DecodeHook: addressDecodeHook,
+		Metadata:   &md,
+		Result:     &c,
 	})
 	if err != nil {
 		return Config{}, err
@@ -173,14 +197,23 @@ type Config struct {
 	Addresses                        Addresses                `json:"addresses,omitempty" hcl:"addresses" mapstructure:"addresses"`
 	AdvertiseAddrLAN                 *string                  `json:"advertise_addr,omitempty" hcl:"advertise_addr" mapstructure:"advertise_addr"`
 	AdvertiseAddrWAN                 *string                  `json:"advertise_addr_wan,omitempty" hcl:"advertise_addr_wan" mapstructure:"advertise_addr_wan"`
+	AdvertiseAddrWANMap              map[string]string        `json:"advertise_addr_wan_map,omitempty" hcl:"advertise_addr_wan_map" mapstructure:"advertise_addr_wan_map"`
 	Autopilot                        Autopilot                `json:"autopilot,omitempty" hcl:"autopilot" mapstructure:"autopilot"`
 	BindAddr                         *string                  `json:"bind_addr,omitempty" hcl:"bind_addr" mapstructure:"bind_addr"`
 	Bootstrap                        *bool                    `json:"bootstrap,omitempty" hcl:"bootstrap" mapstructure:"bootstrap"`
+	BootstrapClusterID               *string                  `json:"bootstrap_cluster_id,omitempty" hcl:"bootstrap_cluster_id" mapstructure:"bootstrap_cluster_id"`
 	BootstrapExpect                  *int                     `json:"bootstrap_expect,omitempty" hcl:"bootstrap_expect" mapstructure:"bootstrap_expect"`
 	CAFile                           *string                  `json:"ca_file,omitempty" hcl:"ca_file" mapstructure:"ca_file"`
 	CAPath                           *string                  `json:"ca_path,omitempty" hcl:"ca_path" mapstructure:"ca_path"`
+	CRLFile                          *string                  `json:"crl_file,omitempty" hcl:"crl_file" mapstructure:"crl_file"`
+	CRLPath                          *string                  `json:"crl_path,omitempty" hcl:"crl_path" mapstructure:"crl_path"`
+	CertBundleFile                   *string                  `json:"cert_bundle_file,omitempty" hcl:"cert_bundle_file" mapstructure:"cert_bundle_file"`
 	CertFile                         *string                  `json:"cert_file,omitempty" hcl:"cert_file" mapstructure:"cert_file"`
 	Check                            *CheckDefinition         `json:"check,omitempty" hcl:"check" mapstructure:"check"` // needs to be a pointer to avoid partial merges
+	CheckFlapHoldDown                *string                  `json:"check_flap_hold_down,omitempty" hcl:"check_flap_hold_down" mapstructure:"check_flap_hold_down"`
+	CheckOutputMaxSize               *int                     `json:"check_output_max_size,omitempty" hcl:"check_output_max_size" mapstructure:"check_output_max_size"`
+	CheckOutputTruncation            *string                  `json:"check_output_truncation,omitempty" hcl:"check_output_truncation" mapstructure:"check_output_truncation"`
+	CheckPlugins                     map[string]string        `json:"check_plugins,omitempty" hcl:"check_plugins" mapstructure:"check_plugins"`
 	CheckUpdateInterval              *string                  `json:"check_update_interval,omitempty" hcl:"check_update_interval" mapstructure:"check_update_interval"`
 	Checks                           []CheckDefinition        `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
 	ClientAddr                       *string                  `json:"client_addr,omitempty" hcl:"client_addr" mapstructure:"client_addr"`
@@ -189,6 +222,7 @@ type Config struct {
 	DNSDomain                        *string                  `json:"domain,omitempty" hcl:"domain" mapstructure:"domain"`
 	DNSRecursors                     []string                 `json:"recursors,omitempty" hcl:"recursors" mapstructure:"recursors"`
 	DataDir                          *string                  `json:"data_dir,omitempty" hcl:"data_dir" mapstructure:"data_dir"`
+	DataDirEncryptionKeyFile         *string                  `json:"data_dir_encryption_key_file,omitempty" hcl:"data_dir_encryption_key_file" mapstructure:"data_dir_encryption_key_file"`
 	Datacenter                       *string                  `json:"datacenter,omitempty" hcl:"datacenter" mapstructure:"datacenter"`
 	DisableAnonymousSignature        *bool                    `json:"disable_anonymous_signature,omitempty" hcl:"disable_anonymous_signature" mapstructure:"disable_anonymous_signature"`
 	DisableCoordinates               *bool                    `json:"disable_coordinates,omitempty" hcl:"disable_coordinates" mapstructure:"disable_coordinates"`
@@ -199,11 +233,14 @@ type Config struct {
 	DisableUpdateCheck               *bool                    `json:"disable_update_check,omitempty" hcl:"disable_update_check" mapstructure:"disable_update_check"`
 	DiscardCheckOutput               *bool                    `json:"discard_check_output" hcl:"discard_check_output" mapstructure:"discard_check_output"`
 	DiscoveryMaxStale                *string                  `json:"discovery_max_stale" hcl:"discovery_max_stale" mapstructure:"discovery_max_stale"`
+	DockerCheckRuntime               *string                  `json:"docker_check_runtime,omitempty" hcl:"docker_check_runtime" mapstructure:"docker_check_runtime"`
+	DockerCheckRuntimeEndpoint       *string                  `json:"docker_check_runtime_endpoint,omitempty" hcl:"docker_check_runtime_endpoint" mapstructure:"docker_check_runtime_endpoint"`
 	EnableACLReplication             *bool                    `json:"enable_acl_replication,omitempty" hcl:"enable_acl_replication" mapstructure:"enable_acl_replication"`
 	EnableAgentTLSForChecks          *bool                    `json:"enable_agent_tls_for_checks,omitempty" hcl:"enable_agent_tls_for_checks" mapstructure:"enable_agent_tls_for_checks"`
 	EnableDebug                      *bool                    `json:"enable_debug,omitempty" hcl:"enable_debug" mapstructure:"enable_debug"`
 	EnableScriptChecks               *bool                    `json:"enable_script_checks,omitempty" hcl:"enable_script_checks" mapstructure:"enable_script_checks"`
 	EnableLocalScriptChecks          *bool                    `json:"enable_local_script_checks,omitempty" hcl:"enable_local_script_checks" mapstructure:"enable_local_script_checks"`
+	EnableEventLog                   *bool                    `json:"enable_eventlog,omitempty" hcl:"enable_eventlog" mapstructure:"enable_eventlog"`
 	EnableSyslog                     *bool                    `json:"enable_syslog,omitempty" hcl:"enable_syslog" mapstructure:"enable_syslog"`
 	EncryptKey                       *string                  `json:"encrypt,omitempty" hcl:"encrypt" mapstructure:"encrypt"`
 	EncryptVerifyIncoming            *bool                    `json:"encrypt_verify_incoming,omitempty" hcl:"encrypt_verify_incoming" mapstructure:"encrypt_verify_incoming"`
@@ -212,6 +249,8 @@ type Config struct {
 	GossipWAN                        GossipWANConfig          `json:"gossip_wan,omitempty" hcl:"gossip_wan" mapstructure:"gossip_wan"`
 	HTTPConfig                       HTTPConfig               `json:"http_config,omitempty" hcl:"http_config" mapstructure:"http_config"`
 	KeyFile                          *string                  `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
+	KeyPassphrase                    *string                  `json:"key_passphrase,omitempty" hcl:"key_passphrase" mapstructure:"key_passphrase"`
+	KeyPassphraseFile                *string                  `json:"key_passphrase_file,omitempty" hcl:"key_passphrase_file" mapstructure:"key_passphrase_file"`
 	LeaveOnTerm                      *bool                    `json:"leave_on_terminate,omitempty" hcl:"leave_on_terminate" mapstructure:"leave_on_terminate"`
 	Limits                           Limits                   `json:"limits,omitempty" hcl:"limits" mapstructure:"limits"`
 	LogLevel                         *string                  `json:"log_level,omitempty" hcl:"log_level" mapstructure:"log_level"`
@@ -238,28 +277,46 @@ type Config struct {
 	RetryJoinLAN                     []string                 `json:"retry_join,omitempty" hcl:"retry_join" mapstructure:"retry_join"`
 	RetryJoinMaxAttemptsLAN          *int                     `json:"retry_max,omitempty" hcl:"retry_max" mapstructure:"retry_max"`
 	RetryJoinMaxAttemptsWAN          *int                     `json:"retry_max_wan,omitempty" hcl:"retry_max_wan" mapstructure:"retry_max_wan"`
+	RetryJoinMaxIntervalLAN          *string                  `json:"retry_max_interval,omitempty" hcl:"retry_max_interval" mapstructure:"retry_max_interval"`
+	RetryJoinMaxIntervalWAN          *string                  `json:"retry_max_interval_wan,omitempty" hcl:"retry_max_interval_wan" mapstructure:"retry_max_interval_wan"`
+	RetryJoinSplayLAN                *string                  `json:"retry_splay,omitempty" hcl:"retry_splay" mapstructure:"retry_splay"`
+	RetryJoinSplayWAN                *string                  `json:"retry_splay_wan,omitempty" hcl:"retry_splay_wan" mapstructure:"retry_splay_wan"`
 	RetryJoinWAN                     []string                 `json:"retry_join_wan,omitempty" hcl:"retry_join_wan" mapstructure:"retry_join_wan"`
+	ScriptCheckDir                   *string                  `json:"script_check_dir,omitempty" hcl:"script_check_dir" mapstructure:"script_check_dir"`
+	ScriptCheckEnvWhitelist          []string                 `json:"script_check_env_whitelist,omitempty" hcl:"script_check_env_whitelist" mapstructure:"script_check_env_whitelist"`
+	ScriptCheckKillTimeout           *string                  `json:"script_check_kill_timeout,omitempty" hcl:"script_check_kill_timeout" mapstructure:"script_check_kill_timeout"`
+	ScriptCheckUser                  *string                  `json:"script_check_user,omitempty" hcl:"script_check_user" mapstructure:"script_check_user"`
 	SegmentName                      *string                  `json:"segment,omitempty" hcl:"segment" mapstructure:"segment"`
 	Segments                         []Segment                `json:"segments,omitempty" hcl:"segments" mapstructure:"segments"`
 	SerfBindAddrLAN                  *string                  `json:"serf_lan,omitempty" hcl:"serf_lan" mapstructure:"serf_lan"`
 	SerfBindAddrWAN                  *string                  `json:"serf_wan,omitempty" hcl:"serf_wan" mapstructure:"serf_wan"`
 	ServerMode                       *bool                    `json:"server,omitempty" hcl:"server" mapstructure:"server"`
 	ServerName                       *string                  `json:"server_name,omitempty" hcl:"server_name" mapstructure:"server_name"`
+	ServiceDrainTime                 *string                  `json:"service_drain_time,omitempty" hcl:"service_drain_time" mapstructure:"service_drain_time"`
 	Service                          *ServiceDefinition       `json:"service,omitempty" hcl:"service" mapstructure:"service"`
 	Services                         []ServiceDefinition      `json:"services,omitempty" hcl:"services" mapstructure:"services"`
 	SessionTTLMin                    *string                  `json:"session_ttl_min,omitempty" hcl:"session_ttl_min" mapstructure:"session_ttl_min"`
+	SkipClusterIDCheck               *bool                    `json:"skip_cluster_id_check,omitempty" hcl:"skip_cluster_id_check" mapstructure:"skip_cluster_id_check"`
 	SkipLeaveOnInt                   *bool                    `json:"skip_leave_on_interrupt,omitempty" hcl:"skip_leave_on_interrupt" mapstructure:"skip_leave_on_interrupt"`
+	SNICertificates                  []SNICertificate         `json:"sni_certificates,omitempty" hcl:"sni_certificates" mapstructure:"sni_certificates"`
 	StartJoinAddrsLAN                []string                 `json:"start_join,omitempty" hcl:"start_join" mapstructure:"start_join"`
 	StartJoinAddrsWAN                []string                 `json:"start_join_wan,omitempty" hcl:"start_join_wan" mapstructure:"start_join_wan"`
 	SyslogFacility                   *string                  `json:"syslog_facility,omitempty" hcl:"syslog_facility" mapstructure:"syslog_facility"`
 	TLSCipherSuites                  *string                  `json:"tls_cipher_suites,omitempty" hcl:"tls_cipher_suites" mapstructure:"tls_cipher_suites"`
+	TLSDatacenterServerNames         map[string]string        `json:"tls_datacenter_server_names,omitempty" hcl:"tls_datacenter_server_names" mapstructure:"tls_datacenter_server_names"`
+	TLSMaxVersion                    *string                  `json:"tls_max_version,omitempty" hcl:"tls_max_version" mapstructure:"tls_max_version"`
 	TLSMinVersion                    *string                  `json:"tls_min_version,omitempty" hcl:"tls_min_version" mapstructure:"tls_min_version"`
+	TLSOCSPRefreshInterval           *string                  `json:"tls_ocsp_refresh_interval,omitempty" hcl:"tls_ocsp_refresh_interval" mapstructure:"tls_ocsp_refresh_interval"`
+	TLSOCSPResponderURL              *string                  `json:"tls_ocsp_responder_url,omitempty" hcl:"tls_ocsp_responder_url" mapstructure:"tls_ocsp_responder_url"`
+	TLSOCSPStapling                  *bool                    `json:"tls_ocsp_stapling,omitempty" hcl:"tls_ocsp_stapling" mapstructure:"tls_ocsp_stapling"`
 	TLSPreferServerCipherSuites      *bool                    `json:"tls_prefer_server_cipher_suites,omitempty" hcl:"tls_prefer_server_cipher_suites" mapstructure:"tls_prefer_server_cipher_suites"`
+	TLSWatchFiles                    *bool                    `json:"tls_watch_files,omitempty" hcl:"tls_watch_files" mapstructure:"tls_watch_files"`
 	TaggedAddresses                  map[string]string        `json:"tagged_addresses,omitempty" hcl:"tagged_addresses" mapstructure:"tagged_addresses"`
 	Telemetry                        Telemetry                `json:"telemetry,omitempty" hcl:"telemetry" mapstructure:"telemetry"`
 	TranslateWANAddrs                *bool                    `json:"translate_wan_addrs,omitempty" hcl:"translate_wan_addrs" mapstructure:"translate_wan_addrs"`
 	UI                               *bool                    `json:"ui,omitempty" hcl:"ui" mapstructure:"ui"`
 	UIDir                            *string                  `json:"ui_dir,omitempty" hcl:"ui_dir" mapstructure:"ui_dir"`
+	UIConfig                         UIConfig                 `json:"ui_config,omitempty" hcl:"ui_config" mapstructure:"ui_config"`
 	UnixSocket                       UnixSocket               `json:"unix_sockets,omitempty" hcl:"unix_sockets" mapstructure:"unix_sockets"`
 	VerifyIncoming                   *bool                    `json:"verify_incoming,omitempty" hcl:"verify_incoming" mapstructure:"verify_incoming"`
 	VerifyIncomingHTTPS              *bool                    `json:"verify_incoming_https,omitempty" hcl:"verify_incoming_https" mapstructure:"verify_incoming_https"`
@@ -326,11 +383,42 @@ type Consul struct {
 	} `json:"server,omitempty" hcl:"server" mapstructure:"server"`
 }
 
+// Addresses contains the addresses the client endpoints bind to. Each of
+// them is either a single address string or a list of address strings and
+// AddressConfig objects. A plain string is decoded as an AddressConfig with
+// only the address set, see addressDecodeHook.
 type Addresses struct {
-	DNS   *string `json:"dns,omitempty" hcl:"dns" mapstructure:"dns"`
-	HTTP  *string `json:"http,omitempty" hcl:"http" mapstructure:"http"`
-	HTTPS *string `json:"https,omitempty" hcl:"https" mapstructure:"https"`
-	GRPC  *string `json:"grpc,omitempty" hcl:"grpc" mapstructure:"grpc"`
+	DNS   *[]AddressConfig `json:"dns,omitempty" hcl:"dns" mapstructure:"dns"`
+	HTTP  *[]AddressConfig `json:"http,omitempty" hcl:"http" mapstructure:"http"`
+	HTTPS *[]AddressConfig `json:"https,omitempty" hcl:"https" mapstructure:"https"`
+	GRPC  *[]AddressConfig `json:"grpc,omitempty" hcl:"grpc" mapstructure:"grpc"`
+}
+
+// AddressConfig is a single entry of an address list. Address can be an IP
+// address, a UNIX socket path or a go-sockaddr template expanding to several
+// of them. The TLS settings override the agent wide ones for the HTTPS
+// listeners of this entry.
+type AddressConfig struct {
+	Address        *string `json:"address,omitempty" hcl:"address" mapstructure:"address"`
+	CertFile       *string `json:"cert_file,omitempty" hcl:"cert_file" mapstructure:"cert_file"`
+	KeyFile        *string `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
+	VerifyIncoming *bool   `json:"verify_incoming,omitempty" hcl:"verify_incoming" mapstructure:"verify_incoming"`
+}
+
+// addressDecodeHook allows the entries in the addresses stanza to be given as
+// a single string or a list which mixes strings and objects by turning the
+// strings into the shape of an AddressConfig list.
+func addressDecodeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String {
+		return data, nil
+	}
+	switch to {
+	case reflect.TypeOf([]AddressConfig{}):
+		return []interface{}{data}, nil
+	case reflect.TypeOf(AddressConfig{}):
+		return map[string]interface{}{"address": data}, nil
+	}
+	return data, nil
 }
 
 type AdvertiseAddrsConfig struct {
@@ -340,13 +428,15 @@ type AdvertiseAddrsConfig struct {
 }
 
 type Autopilot struct {
-	CleanupDeadServers      *bool   `json:"cleanup_dead_servers,omitempty" hcl:"cleanup_dead_servers" mapstructure:"cleanup_dead_servers"`
-	DisableUpgradeMigration *bool   `json:"disable_upgrade_migration,omitempty" hcl:"disable_upgrade_migration" mapstructure:"disable_upgrade_migration"`
-	LastContactThreshold    *string `json:"last_contact_threshold,omitempty" hcl:"last_contact_threshold" mapstructure:"last_contact_threshold"`
-	MaxTrailingLogs         *int    `json:"max_trailing_logs,omitempty" hcl:"max_trailing_logs" mapstructure:"max_trailing_logs"`
-	RedundancyZoneTag       *string `json:"redundancy_zone_tag,omitempty" hcl:"redundancy_zone_tag" mapstructure:"redundancy_zone_tag"`
-	ServerStabilizationTime *string `json:"server_stabilization_time,omitempty" hcl:"server_stabilization_time" mapstructure:"server_stabilization_time"`
-	UpgradeVersionTag       *string `json:"upgrade_version_tag,omitempty" hcl:"upgrade_version_tag" mapstructure:"upgrade_version_tag"`
+	CleanupDeadServers       *bool   `json:"cleanup_dead_servers,omitempty" hcl:"cleanup_dead_servers" mapstructure:"cleanup_dead_servers"`
+	DeadServerQuarantineTime *string `json:"dead_server_quarantine_time,omitempty" hcl:"dead_server_quarantine_time" mapstructure:"dead_server_quarantine_time"`
+	DisableUpgradeMigration  *bool   `json:"disable_upgrade_migration,omitempty" hcl:"disable_upgrade_migration" mapstructure:"disable_upgrade_migration"`
+	LastContactThreshold     *string `json:"last_contact_threshold,omitempty" hcl:"last_contact_threshold" mapstructure:"last_contact_threshold"`
+	MaxDeadServerRemovals    *int    `json:"max_dead_server_removals,omitempty" hcl:"max_dead_server_removals" mapstructure:"max_dead_server_removals"`
+	MaxTrailingLogs          *int    `json:"max_trailing_logs,omitempty" hcl:"max_trailing_logs" mapstructure:"max_trailing_logs"`
+	RedundancyZoneTag        *string `json:"redundancy_zone_tag,omitempty" hcl:"redundancy_zone_tag" mapstructure:"redundancy_zone_tag"`
+	ServerStabilizationTime  *string `json:"server_stabilization_time,omitempty" hcl:"server_stabilization_time" mapstructure:"server_stabilization_time"`
+	UpgradeVersionTag        *string `json:"upgrade_version_tag,omitempty" hcl:"upgrade_version_tag" mapstructure:"upgrade_version_tag"`
 }
 
 // ServiceWeights defines the registration of weights used in DNS for a Service
@@ -355,19 +445,29 @@ type ServiceWeights struct {
 	Warning *int `json:"warning,omitempty" hcl:"warning" mapstructure:"warning"`
 }
 
+type ServiceAddress struct {
+	Address *string `json:"address,omitempty" hcl:"address" mapstructure:"address"`
+	Port    *int    `json:"port,omitempty" hcl:"port" mapstructure:"port"`
+}
+
 type ServiceDefinition struct {
-	Kind              *string           `json:"kind,omitempty" hcl:"kind" mapstructure:"kind"`
-	ID                *string           `json:"id,omitempty" hcl:"id" mapstructure:"id"`
-	Name              *string           `json:"name,omitempty" hcl:"name" mapstructure:"name"`
-	Tags              []string          `json:"tags,omitempty" hcl:"tags" mapstructure:"tags"`
-	Address           *string           `json:"address,omitempty" hcl:"address" mapstructure:"address"`
-	Meta              map[string]string `json:"meta,omitempty" hcl:"meta" mapstructure:"meta"`
-	Port              *int              `json:"port,omitempty" hcl:"port" mapstructure:"port"`
-	Check             *CheckDefinition  `json:"check,omitempty" hcl:"check" mapstructure:"check"`
-	Checks            []CheckDefinition `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
-	Token             *string           `json:"token,omitempty" hcl:"token" mapstructure:"token"`
-	Weights           *ServiceWeights   `json:"weights,omitempty" hcl:"weights" mapstructure:"weights"`
-	EnableTagOverride *bool             `json:"enable_tag_override,omitempty" hcl:"enable_tag_override" mapstructure:"enable_tag_override"`
+	Kind              *string                   `json:"kind,omitempty" hcl:"kind" mapstructure:"kind"`
+	ID                *string                   `json:"id,omitempty" hcl:"id" mapstructure:"id"`
+	Name              *string                   `json:"name,omitempty" hcl:"name" mapstructure:"name"`
+	Tags              []string                  `json:"tags,omitempty" hcl:"tags" mapstructure:"tags"`
+	Address           *string                   `json:"address,omitempty" hcl:"address" mapstructure:"address"`
+	TaggedAddresses   map[string]ServiceAddress `json:"tagged_addresses,omitempty" hcl:"tagged_addresses" mapstructure:"tagged_addresses"`
+	Meta              map[string]string         `json:"meta,omitempty" hcl:"meta" mapstructure:"meta"`
+	Port              *int                      `json:"port,omitempty" hcl:"port" mapstructure:"port"`
+	Check             *CheckDefinition          `json:"check,omitempty" hcl:"check" mapstructure:"check"`
+	Checks            []CheckDefinition         `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
+	Token             *string                   `json:"token,omitempty" hcl:"token" mapstructure:"token"`
+	TokenFile         *string                   `json:"token_file,omitempty" hcl:"token_file" mapstructure:"token_file"`
+	TokenEnv          *string                   `json:"token_env,omitempty" hcl:"token_env" mapstructure:"token_env"`
+	Weights           *ServiceWeights           `json:"weights,omitempty" hcl:"weights" mapstructure:"weights"`
+	EnableTagOverride *bool                     `json:"enable_tag_override,omitempty" hcl:"enable_tag_override" mapstructure:"enable_tag_override"`
+	InheritTags       *bool                     `json:"inherit_tags,omitempty" hcl:"inherit_tags" mapstructure:"inherit_tags"`
+	InheritMeta       *bool                     `json:"inherit_meta,omitempty" hcl:"inherit_meta" mapstructure:"inherit_meta"`
 	// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
 	ProxyDestination *string         `json:"proxy_destination,omitempty" hcl:"proxy_destination" mapstructure:"proxy_destination"`
 	Proxy            *ServiceProxy   `json:"proxy,omitempty" hcl:"proxy" mapstructure:"proxy"`
@@ -380,6 +480,8 @@ type CheckDefinition struct {
 	Notes                          *string             `json:"notes,omitempty" hcl:"notes" mapstructure:"notes"`
 	ServiceID                      *string             `json:"service_id,omitempty" hcl:"service_id" mapstructure:"service_id"`
 	Token                          *string             `json:"token,omitempty" hcl:"token" mapstructure:"token"`
+	TokenFile                      *string             `json:"token_file,omitempty" hcl:"token_file" mapstructure:"token_file"`
+	TokenEnv                       *string             `json:"token_env,omitempty" hcl:"token_env" mapstructure:"token_env"`
 	Status                         *string             `json:"status,omitempty" hcl:"status" mapstructure:"status"`
 	ScriptArgs                     []string            `json:"args,omitempty" hcl:"args" mapstructure:"args"`
 	HTTP                           *string             `json:"http,omitempty" hcl:"http" mapstructure:"http"`
@@ -391,6 +493,8 @@ type CheckDefinition struct {
 	Shell                          *string             `json:"shell,omitempty" hcl:"shell" mapstructure:"shell"`
 	GRPC                           *string             `json:"grpc,omitempty" hcl:"grpc" mapstructure:"grpc"`
 	GRPCUseTLS                     *bool               `json:"grpc_use_tls,omitempty" hcl:"grpc_use_tls" mapstructure:"grpc_use_tls"`
+	Plugin                         *string             `json:"plugin,omitempty" hcl:"plugin" mapstructure:"plugin"`
+	PluginArgs                     map[string]string   `json:"plugin_args,omitempty" hcl:"plugin_args" mapstructure:"plugin_args"`
 	TLSSkipVerify                  *bool               `json:"tls_skip_verify,omitempty" hcl:"tls_skip_verify" mapstructure:"tls_skip_verify"`
 	AliasNode                      *string             `json:"alias_node,omitempty" hcl:"alias_node" mapstructure:"alias_node"`
 	AliasService                   *string             `json:"alias_service,omitempty" hcl:"alias_service" mapstructure:"alias_service"`
@@ -458,6 +562,20 @@ type ServiceProxy struct {
 	// Upstreams describes any upstream dependencies the proxy instance should
 	// setup.
 	Upstreams []Upstream `json:"upstreams,omitempty" hcl:"upstreams" mapstructure:"upstreams"`
+
+	// TransparentProxy configures how the traffic of the local service is
+	// redirected to the proxy.
+	TransparentProxy *TransparentProxy `json:"transparent_proxy,omitempty" hcl:"transparent_proxy" mapstructure:"transparent_proxy"`
+}
+
+// TransparentProxy describes which traffic of a service is redirected to its
+// proxy.
+type TransparentProxy struct {
+	OutboundListenerPort *int     `json:"outbound_listener_port,omitempty" hcl:"outbound_listener_port" mapstructure:"outbound_listener_port"`
+	ExcludeInboundPorts  []int    `json:"exclude_inbound_ports,omitempty" hcl:"exclude_inbound_ports" mapstructure:"exclude_inbound_ports"`
+	ExcludeOutboundPorts []int    `json:"exclude_outbound_ports,omitempty" hcl:"exclude_outbound_ports" mapstructure:"exclude_outbound_ports"`
+	ExcludeOutboundCIDRs []string `json:"exclude_outbound_cidrs,omitempty" hcl:"exclude_outbound_cidrs" mapstructure:"exclude_outbound_cidrs"`
+	ExcludeUIDs          []string `json:"exclude_uids,omitempty" hcl:"exclude_uids" mapstructure:"exclude_uids"`
 }
 
 // Upstream represents a single upstream dependency for a service or proxy. It
@@ -545,9 +663,11 @@ type SOA struct {
 type DNS struct {
 	AllowStale         *bool             `json:"allow_stale,omitempty" hcl:"allow_stale" mapstructure:"allow_stale"`
 	ARecordLimit       *int              `json:"a_record_limit,omitempty" hcl:"a_record_limit" mapstructure:"a_record_limit"`
+	AnswerOrder        *string           `json:"answer_order,omitempty" hcl:"answer_order" mapstructure:"answer_order"`
 	DisableCompression *bool             `json:"disable_compression,omitempty" hcl:"disable_compression" mapstructure:"disable_compression"`
 	EnableTruncate     *bool             `json:"enable_truncate,omitempty" hcl:"enable_truncate" mapstructure:"enable_truncate"`
 	MaxStale           *string           `json:"max_stale,omitempty" hcl:"max_stale" mapstructure:"max_stale"`
+	NegativeTTL        *string           `json:"negative_ttl,omitempty" hcl:"negative_ttl" mapstructure:"negative_ttl"`
 	NodeTTL            *string           `json:"node_ttl,omitempty" hcl:"node_ttl" mapstructure:"node_ttl"`
 	OnlyPassing        *bool             `json:"only_passing,omitempty" hcl:"only_passing" mapstructure:"only_passing"`
 	RecursorTimeout    *string           `json:"recursor_timeout,omitempty" hcl:"recursor_timeout" mapstructure:"recursor_timeout"`
@@ -560,40 +680,72 @@ type DNS struct {
 }
 
 type HTTPConfig struct {
-	BlockEndpoints     []string          `json:"block_endpoints,omitempty" hcl:"block_endpoints" mapstructure:"block_endpoints"`
-	AllowWriteHTTPFrom []string          `json:"allow_write_http_from,omitempty" hcl:"allow_write_http_from" mapstructure:"allow_write_http_from"`
-	ResponseHeaders    map[string]string `json:"response_headers,omitempty" hcl:"response_headers" mapstructure:"response_headers"`
+	BlockEndpoints      []string          `json:"block_endpoints,omitempty" hcl:"block_endpoints" mapstructure:"block_endpoints"`
+	AllowWriteHTTPFrom  []string          `json:"allow_write_http_from,omitempty" hcl:"allow_write_http_from" mapstructure:"allow_write_http_from"`
+	ResponseHeaders     map[string]string `json:"response_headers,omitempty" hcl:"response_headers" mapstructure:"response_headers"`
+	MaxHeaderBytes      *int              `json:"max_header_bytes,omitempty" hcl:"max_header_bytes" mapstructure:"max_header_bytes"`
+	MaxRequestBodyBytes HTTPBodyLimits    `json:"max_request_body_bytes,omitempty" hcl:"max_request_body_bytes" mapstructure:"max_request_body_bytes"`
+}
+
+type HTTPBodyLimits struct {
+	Default *int `json:"default,omitempty" hcl:"default" mapstructure:"default"`
+	KV      *int `json:"kv,omitempty" hcl:"kv" mapstructure:"kv"`
+	Txn     *int `json:"txn,omitempty" hcl:"txn" mapstructure:"txn"`
+}
+
+type UIConfig struct {
+	MetricsProxy UIMetricsProxy `json:"metrics_proxy,omitempty" hcl:"metrics_proxy" mapstructure:"metrics_proxy"`
+}
+
+type UIMetricsProxy struct {
+	BaseURL       *string           `json:"base_url,omitempty" hcl:"base_url" mapstructure:"base_url"`
+	AddHeaders    map[string]string `json:"add_headers,omitempty" hcl:"add_headers" mapstructure:"add_headers"`
+	PathAllowlist []string          `json:"path_allowlist,omitempty" hcl:"path_allowlist" mapstructure:"path_allowlist"`
 }
 
 type Performance struct {
-	LeaveDrainTime *string `json:"leave_drain_time,omitempty" hcl:"leave_drain_time" mapstructure:"leave_drain_time"`
-	RaftMultiplier *int    `json:"raft_multiplier,omitempty" hcl:"raft_multiplier" mapstructure:"raft_multiplier"` // todo(fs): validate as uint
-	RPCHoldTimeout *string `json:"rpc_hold_timeout" hcl:"rpc_hold_timeout" mapstructure:"rpc_hold_timeout"`
+	FollowerReads             []string `json:"follower_reads,omitempty" hcl:"follower_reads" mapstructure:"follower_reads"`
+	LeaveDrainTime            *string  `json:"leave_drain_time,omitempty" hcl:"leave_drain_time" mapstructure:"leave_drain_time"`
+	RaftMultiplier            *int     `json:"raft_multiplier,omitempty" hcl:"raft_multiplier" mapstructure:"raft_multiplier"` // todo(fs): validate as uint
+	RaftElectionMultiplier    *int     `json:"raft_election_multiplier,omitempty" hcl:"raft_election_multiplier" mapstructure:"raft_election_multiplier"`
+	RaftHeartbeatMultiplier   *int     `json:"raft_heartbeat_multiplier,omitempty" hcl:"raft_heartbeat_multiplier" mapstructure:"raft_heartbeat_multiplier"`
+	RaftLeaderLeaseMultiplier *int     `json:"raft_leader_lease_multiplier,omitempty" hcl:"raft_leader_lease_multiplier" mapstructure:"raft_leader_lease_multiplier"`
+	RPCHoldTimeout            *string  `json:"rpc_hold_timeout" hcl:"rpc_hold_timeout" mapstructure:"rpc_hold_timeout"`
 }
 
 type Telemetry struct {
-	CirconusAPIApp                     *string  `json:"circonus_api_app,omitempty" hcl:"circonus_api_app" mapstructure:"circonus_api_app"`
-	CirconusAPIToken                   *string  `json:"circonus_api_token,omitempty" json:"-" hcl:"circonus_api_token" mapstructure:"circonus_api_token" json:"-"`
-	CirconusAPIURL                     *string  `json:"circonus_api_url,omitempty" hcl:"circonus_api_url" mapstructure:"circonus_api_url"`
-	CirconusBrokerID                   *string  `json:"circonus_broker_id,omitempty" hcl:"circonus_broker_id" mapstructure:"circonus_broker_id"`
-	CirconusBrokerSelectTag            *string  `json:"circonus_broker_select_tag,omitempty" hcl:"circonus_broker_select_tag" mapstructure:"circonus_broker_select_tag"`
-	CirconusCheckDisplayName           *string  `json:"circonus_check_display_name,omitempty" hcl:"circonus_check_display_name" mapstructure:"circonus_check_display_name"`
-	CirconusCheckForceMetricActivation *string  `json:"circonus_check_force_metric_activation,omitempty" hcl:"circonus_check_force_metric_activation" mapstructure:"circonus_check_force_metric_activation"`
-	CirconusCheckID                    *string  `json:"circonus_check_id,omitempty" hcl:"circonus_check_id" mapstructure:"circonus_check_id"`
-	CirconusCheckInstanceID            *string  `json:"circonus_check_instance_id,omitempty" hcl:"circonus_check_instance_id" mapstructure:"circonus_check_instance_id"`
-	CirconusCheckSearchTag             *string  `json:"circonus_check_search_tag,omitempty" hcl:"circonus_check_search_tag" mapstructure:"circonus_check_search_tag"`
-	CirconusCheckTags                  *string  `json:"circonus_check_tags,omitempty" hcl:"circonus_check_tags" mapstructure:"circonus_check_tags"`
-	CirconusSubmissionInterval         *string  `json:"circonus_submission_interval,omitempty" hcl:"circonus_submission_interval" mapstructure:"circonus_submission_interval"`
-	CirconusSubmissionURL              *string  `json:"circonus_submission_url,omitempty" hcl:"circonus_submission_url" mapstructure:"circonus_submission_url"`
-	DisableHostname                    *bool    `json:"disable_hostname,omitempty" hcl:"disable_hostname" mapstructure:"disable_hostname"`
-	DogstatsdAddr                      *string  `json:"dogstatsd_addr,omitempty" hcl:"dogstatsd_addr" mapstructure:"dogstatsd_addr"`
-	DogstatsdTags                      []string `json:"dogstatsd_tags,omitempty" hcl:"dogstatsd_tags" mapstructure:"dogstatsd_tags"`
-	FilterDefault                      *bool    `json:"filter_default,omitempty" hcl:"filter_default" mapstructure:"filter_default"`
-	PrefixFilter                       []string `json:"prefix_filter,omitempty" hcl:"prefix_filter" mapstructure:"prefix_filter"`
-	MetricsPrefix                      *string  `json:"metrics_prefix,omitempty" hcl:"metrics_prefix" mapstructure:"metrics_prefix"`
-	PrometheusRetentionTime            *string  `json:"prometheus_retention_time,omitempty" hcl:"prometheus_retention_time" mapstructure:"prometheus_retention_time"`
-	StatsdAddr                         *string  `json:"statsd_address,omitempty" hcl:"statsd_address" mapstructure:"statsd_address"`
-	StatsiteAddr                       *string  `json:"statsite_address,omitempty" hcl:"statsite_address" mapstructure:"statsite_address"`
+	CirconusAPIApp                     *string           `json:"circonus_api_app,omitempty" hcl:"circonus_api_app" mapstructure:"circonus_api_app"`
+	CirconusAPIToken                   *string           `json:"circonus_api_token,omitempty" json:"-" hcl:"circonus_api_token" mapstructure:"circonus_api_token" json:"-"`
+	CirconusAPIURL                     *string           `json:"circonus_api_url,omitempty" hcl:"circonus_api_url" mapstructure:"circonus_api_url"`
+	CirconusBrokerID                   *string           `json:"circonus_broker_id,omitempty" hcl:"circonus_broker_id" mapstructure:"circonus_broker_id"`
+	CirconusBrokerSelectTag            *string           `json:"circonus_broker_select_tag,omitempty" hcl:"circonus_broker_select_tag" mapstructure:"circonus_broker_select_tag"`
+	CirconusCheckDisplayName           *string           `json:"circonus_check_display_name,omitempty" hcl:"circonus_check_display_name" mapstructure:"circonus_check_display_name"`
+	CirconusCheckForceMetricActivation *string           `json:"circonus_check_force_metric_activation,omitempty" hcl:"circonus_check_force_metric_activation" mapstructure:"circonus_check_force_metric_activation"`
+	CirconusCheckID                    *string           `json:"circonus_check_id,omitempty" hcl:"circonus_check_id" mapstructure:"circonus_check_id"`
+	CirconusCheckInstanceID            *string           `json:"circonus_check_instance_id,omitempty" hcl:"circonus_check_instance_id" mapstructure:"circonus_check_instance_id"`
+	CirconusCheckSearchTag             *string           `json:"circonus_check_search_tag,omitempty" hcl:"circonus_check_search_tag" mapstructure:"circonus_check_search_tag"`
+	CirconusCheckTags                  *string           `json:"circonus_check_tags,omitempty" hcl:"circonus_check_tags" mapstructure:"circonus_check_tags"`
+	CirconusSubmissionInterval         *string           `json:"circonus_submission_interval,omitempty" hcl:"circonus_submission_interval" mapstructure:"circonus_submission_interval"`
+	CirconusSubmissionURL              *string           `json:"circonus_submission_url,omitempty" hcl:"circonus_submission_url" mapstructure:"circonus_submission_url"`
+	DisableHostname                    *bool             `json:"disable_hostname,omitempty" hcl:"disable_hostname" mapstructure:"disable_hostname"`
+	DogstatsdAddr                      *string           `json:"dogstatsd_addr,omitempty" hcl:"dogstatsd_addr" mapstructure:"dogstatsd_addr"`
+	DogstatsdTags                      []string          `json:"dogstatsd_tags,omitempty" hcl:"dogstatsd_tags" mapstructure:"dogstatsd_tags"`
+	FilterDefault                      *bool             `json:"filter_default,omitempty" hcl:"filter_default" mapstructure:"filter_default"`
+	PrefixFilter                       []string          `json:"prefix_filter,omitempty" hcl:"prefix_filter" mapstructure:"prefix_filter"`
+	MetricsPrefix                      *string           `json:"metrics_prefix,omitempty" hcl:"metrics_prefix" mapstructure:"metrics_prefix"`
+	OTLPEndpoint                       *string           `json:"otlp_endpoint,omitempty" hcl:"otlp_endpoint" mapstructure:"otlp_endpoint"`
+	OTLPHeaders                        map[string]string `json:"otlp_headers,omitempty" hcl:"otlp_headers" mapstructure:"otlp_headers"`
+	OTLPInterval                       *string           `json:"otlp_interval,omitempty" hcl:"otlp_interval" mapstructure:"otlp_interval"`
+	OTLPTracesEndpoint                 *string           `json:"otlp_traces_endpoint,omitempty" hcl:"otlp_traces_endpoint" mapstructure:"otlp_traces_endpoint"`
+	OTLPCAFile                         *string           `json:"otlp_ca_file,omitempty" hcl:"otlp_ca_file" mapstructure:"otlp_ca_file"`
+	OTLPCertFile                       *string           `json:"otlp_cert_file,omitempty" hcl:"otlp_cert_file" mapstructure:"otlp_cert_file"`
+	OTLPKeyFile                        *string           `json:"otlp_key_file,omitempty" hcl:"otlp_key_file" mapstructure:"otlp_key_file"`
+	PrometheusRetentionTime            *string           `json:"prometheus_retention_time,omitempty" hcl:"prometheus_retention_time" mapstructure:"prometheus_retention_time"`
+	PrometheusHistogramBuckets         []float64         `json:"prometheus_histogram_buckets,omitempty" hcl:"prometheus_histogram_buckets" mapstructure:"prometheus_histogram_buckets"`
+	PrometheusHistogramPrefixes        []string          `json:"prometheus_histogram_prefixes,omitempty" hcl:"prometheus_histogram_prefixes" mapstructure:"prometheus_histogram_prefixes"`
+	StatsdAddr                         *string           `json:"statsd_address,omitempty" hcl:"statsd_address" mapstructure:"statsd_address"`
+	StatsiteAddr                       *string           `json:"statsite_address,omitempty" hcl:"statsite_address" mapstructure:"statsite_address"`
+	TokenRequestMetricsLimit           *int              `json:"token_request_metrics_limit,omitempty" hcl:"token_request_metrics_limit" mapstructure:"token_request_metrics_limit"`
 }
 
 type Ports struct {
@@ -617,18 +769,43 @@ type UnixSocket struct {
 }
 
 type Limits struct {
-	RPCMaxBurst *int     `json:"rpc_max_burst,omitempty" hcl:"rpc_max_burst" mapstructure:"rpc_max_burst"`
-	RPCRate     *float64 `json:"rpc_rate,omitempty" hcl:"rpc_rate" mapstructure:"rpc_rate"`
+	HTTPSAcceptRate              *float64    `json:"https_accept_rate,omitempty" hcl:"https_accept_rate" mapstructure:"https_accept_rate"`
+	HTTPSMaxConcurrentHandshakes *int        `json:"https_max_concurrent_handshakes,omitempty" hcl:"https_max_concurrent_handshakes" mapstructure:"https_max_concurrent_handshakes"`
+	RPCAcceptRate                *float64    `json:"rpc_accept_rate,omitempty" hcl:"rpc_accept_rate" mapstructure:"rpc_accept_rate"`
+	RPCMaxConcurrentHandshakes   *int        `json:"rpc_max_concurrent_handshakes,omitempty" hcl:"rpc_max_concurrent_handshakes" mapstructure:"rpc_max_concurrent_handshakes"`
+	RPCMaxBurst                  *int        `json:"rpc_max_burst,omitempty" hcl:"rpc_max_burst" mapstructure:"rpc_max_burst"`
+	RPCRate                      *float64    `json:"rpc_rate,omitempty" hcl:"rpc_rate" mapstructure:"rpc_rate"`
+	RPCMaxConns                  *int        `json:"rpc_max_conns,omitempty" hcl:"rpc_max_conns" mapstructure:"rpc_max_conns"`
+	RPCMaxStreamsPerConn         *int        `json:"rpc_max_streams_per_conn,omitempty" hcl:"rpc_max_streams_per_conn" mapstructure:"rpc_max_streams_per_conn"`
+	RPCAcceptBackpressure        *bool       `json:"rpc_accept_backpressure,omitempty" hcl:"rpc_accept_backpressure" mapstructure:"rpc_accept_backpressure"`
+	RPCQueryLimits               QueryLimits `json:"rpc_query_limits,omitempty" hcl:"rpc_query_limits" mapstructure:"rpc_query_limits"`
+	RPCQueryQueueTimeout         *string     `json:"rpc_query_queue_timeout,omitempty" hcl:"rpc_query_queue_timeout" mapstructure:"rpc_query_queue_timeout"`
+}
+
+type QueryLimits struct {
+	Health *int `json:"health,omitempty" hcl:"health" mapstructure:"health"`
+	KV     *int `json:"kv,omitempty" hcl:"kv" mapstructure:"kv"`
+	Txn    *int `json:"txn,omitempty" hcl:"txn" mapstructure:"txn"`
 }
 
 type Segment struct {
 	Advertise   *string `json:"advertise,omitempty" hcl:"advertise" mapstructure:"advertise"`
 	Bind        *string `json:"bind,omitempty" hcl:"bind" mapstructure:"bind"`
+	CAFile      *string `json:"ca_file,omitempty" hcl:"ca_file" mapstructure:"ca_file"`
+	CertFile    *string `json:"cert_file,omitempty" hcl:"cert_file" mapstructure:"cert_file"`
+	KeyFile     *string `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
 	Name        *string `json:"name,omitempty" hcl:"name" mapstructure:"name"`
 	Port        *int    `json:"port,omitempty" hcl:"port" mapstructure:"port"`
 	RPCListener *bool   `json:"rpc_listener,omitempty" hcl:"rpc_listener" mapstructure:"rpc_listener"`
 }
 
+// SNICertificate is an additional certificate the HTTPS listeners serve to
+// clients asking for one of its names.
+type SNICertificate struct {
+	CertFile *string `json:"cert_file,omitempty" hcl:"cert_file" mapstructure:"cert_file"`
+	KeyFile  *string `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
+}
+
 type ACL struct {
 	Enabled                *bool   `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
 	TokenReplication       *bool   `json:"enable_token_replication,omitempty" hcl:"enable_token_replication" mapstructure:"enable_token_replication"`
@@ -637,6 +814,7 @@ type ACL struct {
 	DownPolicy             *string `json:"down_policy,omitempty" hcl:"down_policy" mapstructure:"down_policy"`
 	DefaultPolicy          *string `json:"default_policy,omitempty" hcl:"default_policy" mapstructure:"default_policy"`
 	EnableKeyListPolicy    *bool   `json:"enable_key_list_policy,omitempty" hcl:"enable_key_list_policy" mapstructure:"enable_key_list_policy"`
+	AllowAnonymousWrite    *bool   `json:"allow_anonymous_write,omitempty" hcl:"allow_anonymous_write" mapstructure:"allow_anonymous_write"`
 	Tokens                 Tokens  `json:"tokens,omitempty" hcl:"tokens" mapstructure:"tokens"`
 	DisabledTTL            *string `json:"disabled_ttl,omitempty" hcl:"disabled_ttl" mapstructure:"disabled_ttl"`
 	EnableTokenPersistence *bool   `json:"enable_token_persistence" hcl:"enable_token_persistence" mapstructure:"enable_token_persistence"`
@@ -648,4 +826,5 @@ type Tokens struct {
 	AgentMaster *string `json:"agent_master,omitempty" hcl:"agent_master" mapstructure:"agent_master"`
 	Default     *string `json:"default,omitempty" hcl:"default" mapstructure:"default"`
 	Agent       *string `json:"agent,omitempty" hcl:"agent" mapstructure:"agent"`
+	DNS         *string `json:"dns,omitempty" hcl:"dns" mapstructure:"dns"`
 }
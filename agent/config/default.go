@@ -49,12 +49,15 @@ func DefaultSource() Source {
 		bind_addr = "0.0.0.0"
 		bootstrap = false
 		bootstrap_expect = 0
+		check_output_max_size = 4096
+		check_output_truncation = "tail"
 		check_update_interval = "5m"
 		client_addr = "127.0.0.1"
 		datacenter = "` + consul.DefaultDC + `"
 		disable_coordinates = false
 		disable_host_node_id = true
 		disable_remote_exec = true
+		docker_check_runtime = "docker"
 		domain = "consul."
 		encrypt_verify_incoming = true
 		encrypt_verify_outgoing = true
@@ -93,13 +96,22 @@ func DefaultSource() Source {
 		dns_config = {
 			allow_stale = true
 			a_record_limit = 0
+			answer_order = "random"
 			udp_answer_limit = 3
 			max_stale = "87600h"
 			recursor_timeout = "2s"
 		}
+		http_config = {
+			max_request_body_bytes = {
+				default = 10485760
+				kv = 524288
+				txn = 2097152
+			}
+		}
 		limits = {
 			rpc_rate = -1
 			rpc_max_burst = 1000
+			rpc_query_queue_timeout = "5s"
 		}
 		performance = {
 			leave_drain_time = "5s"
@@ -122,6 +134,7 @@ func DefaultSource() Source {
 		telemetry = {
 			metrics_prefix = "consul"
 			filter_default = true
+			otlp_interval = "10s"
 		}
 
 	`,
@@ -168,6 +181,46 @@ func DevSource() Source {
 	}
 }
 
+// DevACLSource is the additional configuration for dev mode with ACLs
+// enabled. The master token is also used as the agent token so that the
+// agent can register itself with a deny-by-default policy.
+// This should be merged in the head after the dev configuration.
+func DevACLSource(masterToken string) Source {
+	return Source{
+		Name:   "dev-acls",
+		Format: "hcl",
+		Data: `
+		acl = {
+			enabled = true
+			default_policy = "deny"
+			down_policy = "extend-cache"
+			tokens = {
+				master = "` + masterToken + `"
+				agent = "` + masterToken + `"
+			}
+		}
+	`,
+	}
+}
+
+// DevTLSSource is the additional configuration for dev mode with TLS
+// enabled using the generated CA and agent certificate.
+// This should be merged in the head after the dev configuration.
+func DevTLSSource(caFile, certFile, keyFile string) Source {
+	return Source{
+		Name:   "dev-tls",
+		Format: "hcl",
+		Data: `
+		ca_file = "` + caFile + `"
+		cert_file = "` + certFile + `"
+		key_file = "` + keyFile + `"
+		ports = {
+			https = 8501
+		}
+	`,
+	}
+}
+
 // NonUserSource contains the values the user cannot configure.
 // This needs to be merged in the tail.
 func NonUserSource() Source {
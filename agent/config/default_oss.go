@@ -1,3 +1,4 @@
+//go:build !ent
 // +build !ent
 
 package config
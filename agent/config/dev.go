@@ -0,0 +1,102 @@
+package config
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/consul/tlsutil"
+	uuid "github.com/hashicorp/go-uuid"
+)
+
+const (
+	devCAFile   = "consul-agent-ca.pem"
+	devCertFile = "dc1-server-consul-0.pem"
+	devKeyFile  = "dc1-server-consul-0-key.pem"
+
+	// devCertDays is the validity of the generated dev certificates. The
+	// material is thrown away with the dev agent so this only needs to
+	// outlive a single session.
+	devCertDays = 7
+)
+
+// devMasterToken returns the master token for -dev-acls, generating a
+// random one if none was provided.
+func devMasterToken(token string) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+	token, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", fmt.Errorf("config: Failed to generate dev master token: %s", err)
+	}
+	return token, nil
+}
+
+// devTLSFiles returns the CA, certificate and key file for -dev-tls. If
+// dir is empty a temporary directory is created and a throwaway CA and
+// agent certificate are generated into it.
+func devTLSFiles(dir string) (string, string, string, error) {
+	if dir == "" {
+		var err error
+		dir, err = ioutil.TempDir("", "consul-dev-tls")
+		if err != nil {
+			return "", "", "", fmt.Errorf("config: Failed to create dev TLS dir: %s", err)
+		}
+	}
+
+	caFile := filepath.Join(dir, devCAFile)
+	certFile := filepath.Join(dir, devCertFile)
+	keyFile := filepath.Join(dir, devKeyFile)
+
+	// Reuse existing material so that reloads and restarts with the same
+	// directory keep the same CA.
+	if _, err := os.Stat(caFile); err == nil {
+		return caFile, certFile, keyFile, nil
+	}
+
+	ca, cert, key, err := generateDevTLS()
+	if err != nil {
+		return "", "", "", fmt.Errorf("config: Failed to generate dev TLS material: %s", err)
+	}
+	files := map[string]string{caFile: ca, certFile: cert, keyFile: key}
+	for path, data := range files {
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			return "", "", "", fmt.Errorf("config: Failed to write %s: %s", path, err)
+		}
+	}
+	return caFile, certFile, keyFile, nil
+}
+
+// generateDevTLS generates a CA and an agent certificate signed by it that
+// is valid for the loopback interface and the default server name.
+func generateDevTLS() (string, string, string, error) {
+	sn, err := tlsutil.GenerateSerialNumber()
+	if err != nil {
+		return "", "", "", err
+	}
+	signer, _, err := tlsutil.GeneratePrivateKey()
+	if err != nil {
+		return "", "", "", err
+	}
+	ca, err := tlsutil.GenerateCA(signer, sn, devCertDays, nil)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	sn, err = tlsutil.GenerateSerialNumber()
+	if err != nil {
+		return "", "", "", err
+	}
+	dnsNames := []string{"localhost", "server.dc1.consul"}
+	ips := []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}
+	extKeyUsage := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	cert, key, err := tlsutil.GenerateCert(signer, ca, sn, "server.dc1.consul", devCertDays, dnsNames, ips, extKeyUsage)
+	if err != nil {
+		return "", "", "", err
+	}
+	return ca, cert, key, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul/tlsutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_DevACLs(t *testing.T) {
+	t.Parallel()
+
+	b, err := NewBuilder(Flags{DevMode: pBool(true), DevACLs: pBool(true)})
+	require.NoError(t, err)
+	require.NotEmpty(t, b.Flags.DevMasterToken)
+
+	rt, err := b.BuildAndValidate()
+	require.NoError(t, err)
+	require.True(t, rt.ACLsEnabled)
+	require.Equal(t, "deny", rt.ACLDefaultPolicy)
+	require.Equal(t, b.Flags.DevMasterToken, rt.ACLMasterToken)
+	require.Equal(t, b.Flags.DevMasterToken, rt.ACLAgentToken)
+
+	// A second builder with the same flags must keep the token.
+	b2, err := NewBuilder(b.Flags)
+	require.NoError(t, err)
+	require.Equal(t, b.Flags.DevMasterToken, b2.Flags.DevMasterToken)
+}
+
+func TestBuilder_DevTLS(t *testing.T) {
+	t.Parallel()
+
+	b, err := NewBuilder(Flags{DevMode: pBool(true), DevTLS: pBool(true)})
+	require.NoError(t, err)
+	require.NotEmpty(t, b.Flags.DevTLSDir)
+	defer os.RemoveAll(b.Flags.DevTLSDir)
+
+	rt, err := b.BuildAndValidate()
+	require.NoError(t, err)
+	require.Equal(t, 8501, rt.HTTPSPort)
+	require.Equal(t, filepath.Join(b.Flags.DevTLSDir, devCAFile), rt.CAFile)
+	require.Equal(t, filepath.Join(b.Flags.DevTLSDir, devCertFile), rt.CertFile)
+	require.Equal(t, filepath.Join(b.Flags.DevTLSDir, devKeyFile), rt.KeyFile)
+
+	// The generated material must be usable by the configurator.
+	c := tlsutil.NewConfigurator(rt.ToTLSUtilConfig())
+	tlsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	require.Len(t, tlsConf.Certificates, 1)
+}
+
+func TestBuilder_DevFlagsRequireDev(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewBuilder(Flags{DevACLs: pBool(true)})
+	require.Error(t, err)
+	_, err = NewBuilder(Flags{DevTLS: pBool(true)})
+	require.Error(t, err)
+}
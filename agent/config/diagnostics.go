@@ -0,0 +1,300 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/hcl/parser"
+)
+
+// The severities of a Diagnostic. Only errors make a configuration invalid.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic describes a single problem found in the configuration. File,
+// Line and Column are set when the problem can be attributed to a position
+// in a config file.
+type Diagnostic struct {
+	File       string `json:",omitempty"`
+	Line       int    `json:",omitempty"`
+	Column     int    `json:",omitempty"`
+	Severity   string
+	Message    string
+	Suggestion string `json:",omitempty"`
+}
+
+// checkSuggestions maps the validation errors of check definitions to a
+// suggested fix.
+var checkSuggestions = map[string]string{
+	"Interval and TTL cannot both be specified":              `Remove either "interval" or "ttl".`,
+	"Interval must be > 0 for Script, HTTP, or TCP checks":   `Add an "interval", e.g. "10s".`,
+	"Interval cannot be set for Alias checks":                `Remove "interval" from the alias check.`,
+	"TTL must be not be set for Alias checks":                `Remove "ttl" from the alias check.`,
+	"TTL must be > 0 for TTL checks":                         `Add a "ttl", or one of "args", "http", "tcp" or "grpc" with an "interval".`,
+	"Services with a Connect managed proxy must have a port": `Add a "port" to the service.`,
+}
+
+// Diagnose checks every config file on its own and reports all problems
+// found instead of stopping at the first one. Beyond the syntax and the
+// config keys it validates the service and check definitions of each file.
+// If no file has errors the merged configuration is validated as well and
+// its warnings are included.
+func (b *Builder) Diagnose() []Diagnostic {
+	var diags []Diagnostic
+	configFormat := b.stringVal(b.Flags.ConfigFormat)
+	for _, src := range b.Sources {
+		format := FormatFrom(src.Name)
+		if configFormat != "" {
+			format = configFormat
+		}
+		if format == "" {
+			continue
+		}
+		diags = append(diags, b.diagnoseSource(src, format)...)
+	}
+
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return diags
+		}
+	}
+
+	if _, err := b.BuildAndValidate(); err != nil {
+		diags = append(diags, Diagnostic{Severity: SeverityError, Message: err.Error()})
+	}
+	for _, w := range b.Warnings {
+		diags = append(diags, Diagnostic{Severity: SeverityWarning, Message: w})
+	}
+	return diags
+}
+
+func (b *Builder) diagnoseSource(src Source, format string) []Diagnostic {
+	c, err := Parse(src.Data, format)
+	if err != nil {
+		return parseDiagnostics(src, err)
+	}
+
+	var diags []Diagnostic
+	add := func(anchor, msg string) {
+		d := Diagnostic{File: src.Name, Severity: SeverityError, Message: msg}
+		d.Line, d.Column = findText(src.Data, anchor)
+		for prefix, s := range checkSuggestions {
+			if strings.Contains(msg, prefix) {
+				d.Suggestion = s
+			}
+		}
+		diags = append(diags, d)
+	}
+
+	var services []*ServiceDefinition
+	if c.Service != nil {
+		services = append(services, c.Service)
+	}
+	for i := range c.Services {
+		services = append(services, &c.Services[i])
+	}
+	for _, sd := range services {
+		b.err = nil
+		s := b.serviceVal(sd)
+		name := fmt.Sprintf("service %q", s.Name)
+		if b.err != nil {
+			add(s.Name, fmt.Sprintf("%s: %s", name, b.err))
+		}
+		for _, err := range flattenErrors(s.Validate()) {
+			add(s.Name, fmt.Sprintf("%s: %s", name, err))
+		}
+		if _, err := s.CheckTypes(); err != nil {
+			add(s.Name, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	var checks []*CheckDefinition
+	if c.Check != nil {
+		checks = append(checks, c.Check)
+	}
+	for i := range c.Checks {
+		checks = append(checks, &c.Checks[i])
+	}
+	for _, cd := range checks {
+		b.err = nil
+		chk := b.checkVal(cd)
+		anchor := chk.Name
+		if chk.ID != "" {
+			anchor = string(chk.ID)
+		}
+		name := fmt.Sprintf("check %q", anchor)
+		if b.err != nil {
+			add(anchor, fmt.Sprintf("%s: %s", name, b.err))
+		}
+		if err := chk.CheckType().Validate(); err != nil {
+			add(anchor, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	b.err = nil
+	return diags
+}
+
+// parseDiagnostics converts the error returned by Parse into diagnostics
+// with the position of the problem where it is known.
+func parseDiagnostics(src Source, err error) []Diagnostic {
+	var diags []Diagnostic
+	for _, err := range flattenErrors(err) {
+		d := Diagnostic{File: src.Name, Severity: SeverityError, Message: err.Error()}
+		switch e := err.(type) {
+		case *parser.PosError:
+			d.Line, d.Column = e.Pos.Line, e.Pos.Column
+			d.Message = e.Err.Error()
+		case *json.SyntaxError:
+			// the offset is after the offending character
+			d.Line, d.Column = offsetPos(src.Data, e.Offset-1)
+		case *json.UnmarshalTypeError:
+			d.Line, d.Column = offsetPos(src.Data, e.Offset)
+		default:
+			if key := strings.TrimPrefix(d.Message, "invalid config key "); key != d.Message {
+				parts := strings.Split(key, ".")
+				d.Line, d.Column = findText(src.Data, stripIndex(parts[len(parts)-1]))
+				d.Suggestion = suggestKey(parts)
+			}
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if merr, ok := err.(*multierror.Error); ok {
+		return merr.Errors
+	}
+	return []error{err}
+}
+
+// offsetPos returns the line and column of the byte offset in data.
+func offsetPos(data string, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := int(offset) - strings.LastIndex(before, "\n")
+	return line, col
+}
+
+// findText returns the position of the first occurrence of s as a key or
+// quoted value in data. It returns zero values if s cannot be found.
+func findText(data, s string) (int, int) {
+	if s == "" {
+		return 0, 0
+	}
+	re := regexp.MustCompile(`(^|[^A-Za-z0-9_-])"?` + regexp.QuoteMeta(s) + `"?([^A-Za-z0-9_-]|$)`)
+	loc := re.FindStringIndex(data)
+	if loc == nil {
+		return 0, 0
+	}
+	start := loc[0] + strings.Index(data[loc[0]:loc[1]], s)
+	if start > 0 && data[start-1] == '"' {
+		start--
+	}
+	return offsetPos(data, int64(start))
+}
+
+var indexRe = regexp.MustCompile(`\[\d+\]`)
+
+func stripIndex(s string) string {
+	return indexRe.ReplaceAllString(s, "")
+}
+
+// suggestKey returns a suggestion for an unknown config key by looking for
+// a known key with a similar name in the same object.
+func suggestKey(path []string) string {
+	t := reflect.TypeOf(Config{})
+	for _, p := range path[:len(path)-1] {
+		f, ok := fieldByKey(t, stripIndex(p))
+		if !ok {
+			return ""
+		}
+		t = f.Type
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return ""
+		}
+	}
+
+	key := stripIndex(path[len(path)-1])
+	best, bestDist := "", len(key)/2+1
+	for i := 0; i < t.NumField(); i++ {
+		name := keyName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		if d := editDistance(key, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		return fmt.Sprintf("Remove %q or check the documentation for the supported keys.", key)
+	}
+	return fmt.Sprintf("Did you mean %q?", best)
+}
+
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if keyName(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func keyName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_Diagnose(t *testing.T) {
+	t.Parallel()
+	dir := testutil.TempDir(t, "diagnose")
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(data), 0600))
+		return path
+	}
+
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		diags []Diagnostic
+	}{
+		{
+			name: "valid",
+			file: "valid.json",
+			data: `{"bind_addr": "10.0.0.1", "data_dir": "` + dir + `"}`,
+		},
+		{
+			name: "hcl syntax",
+			file: "syntax.hcl",
+			data: "service {\n  name = \"web\"\n",
+			diags: []Diagnostic{
+				{Line: 3, Column: 2, Severity: SeverityError, Message: "object expected closing RBRACE got: EOF"},
+			},
+		},
+		{
+			name: "json syntax",
+			file: "syntax.json",
+			data: "{\n  \"datacenter\": ,\n}",
+			diags: []Diagnostic{
+				{Line: 2, Column: 17, Severity: SeverityError, Message: "invalid character ',' looking for beginning of value"},
+			},
+		},
+		{
+			name: "unknown key",
+			file: "typo.hcl",
+			data: "datacenter = \"dc1\"\ndatacentre = \"dc2\"\n",
+			diags: []Diagnostic{
+				{Line: 2, Column: 1, Severity: SeverityError, Message: "invalid config key datacentre", Suggestion: `Did you mean "datacenter"?`},
+			},
+		},
+		{
+			name: "service check",
+			file: "service.json",
+			data: "{\n  \"service\": {\n    \"name\": \"web\",\n    \"check\": {\"http\": \"http://localhost\"}\n  }\n}",
+			diags: []Diagnostic{
+				{Line: 3, Column: 13, Severity: SeverityError, Message: `service "web": Interval must be > 0 for Script, HTTP, or TCP checks`, Suggestion: `Add an "interval", e.g. "10s".`},
+			},
+		},
+		{
+			name: "check",
+			file: "check.hcl",
+			data: "check {\n  id = \"mem\"\n  ttl = \"10s\"\n  interval = \"10s\"\n  args = [\"true\"]\n}\n",
+			diags: []Diagnostic{
+				{Line: 2, Column: 8, Severity: SeverityError, Message: `check "mem": Interval and TTL cannot both be specified`, Suggestion: `Remove either "interval" or "ttl".`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := write(tt.file, tt.data)
+			b, err := NewBuilder(Flags{ConfigFiles: []string{path}})
+			require.NoError(t, err)
+
+			for i := range tt.diags {
+				tt.diags[i].File = path
+			}
+			require.Equal(t, tt.diags, b.Diagnose())
+		})
+	}
+}
@@ -20,11 +20,34 @@ type Flags struct {
 	// format independent of their extension.
 	ConfigFormat *string
 
+	// ConfigRemote contains the list of remote locations config layers
+	// are fetched from. They are merged in order before the config files
+	// so that local files take precedence.
+	ConfigRemote []string
+
 	// HCL contains an arbitrary config in hcl format.
 	// DevMode indicates whether the agent should be started in development
 	// mode. This cannot be configured in a config file.
 	DevMode *bool
 
+	// DevACLs enables ACLs in development mode and bootstraps them with
+	// DevMasterToken. This cannot be configured in a config file.
+	DevACLs *bool
+
+	// DevMasterToken is the ACL master token used with DevACLs. If it is
+	// empty when the builder is created a random token is generated.
+	DevMasterToken string
+
+	// DevTLS enables TLS for the HTTPS and gRPC listeners in development
+	// mode using a throwaway CA and agent certificate generated into
+	// DevTLSDir. This cannot be configured in a config file.
+	DevTLS *bool
+
+	// DevTLSDir is the directory holding the generated CA and agent
+	// certificate for DevTLS. If it is empty when the builder is created
+	// a temporary directory is created and populated.
+	DevTLSDir string
+
 	HCL []string
 
 	// Args contains the remaining unparsed flags.
@@ -63,9 +86,12 @@ func AddFlags(fs *flag.FlagSet, f *Flags) {
 	add(&f.ConfigFiles, "config-dir", "Path to a directory to read configuration files from. This will read every file ending in '.json' as configuration in this directory in alphabetical order. Can be specified multiple times.")
 	add(&f.ConfigFiles, "config-file", "Path to a JSON file to read configuration from. Can be specified multiple times.")
 	add(&f.ConfigFormat, "config-format", "Config files are in this format irrespective of their extension. Must be 'hcl' or 'json'")
+	add(&f.ConfigRemote, "config-remote", "URL to fetch a base configuration layer from which is merged before the local config files. Either an http(s):// URL or consul://host:port/prefix to read all keys below a KV prefix. Can be specified multiple times.")
 	add(&f.Config.DataDir, "data-dir", "Path to a data directory to store agent state.")
 	add(&f.Config.Datacenter, "datacenter", "Datacenter of the agent.")
 	add(&f.DevMode, "dev", "Starts the agent in development mode.")
+	add(&f.DevACLs, "dev-acls", "Enables ACLs in development mode with a generated master token. Requires -dev.")
+	add(&f.DevTLS, "dev-tls", "Enables TLS in development mode with a generated throwaway CA and certificate. Requires -dev.")
 	add(&f.Config.DisableHostNodeID, "disable-host-node-id", "Setting this to true will prevent Consul from using information from the host to generate a node ID, and will cause Consul to generate a random node ID instead.")
 	add(&f.Config.DisableKeyringFile, "disable-keyring-file", "Disables the backing up of the keyring to a file.")
 	add(&f.Config.Ports.DNS, "dns-port", "DNS port to use.")
@@ -73,6 +99,7 @@ func AddFlags(fs *flag.FlagSet, f *Flags) {
 	add(&f.Config.EnableScriptChecks, "enable-script-checks", "Enables health check scripts.")
 	add(&f.Config.EnableLocalScriptChecks, "enable-local-script-checks", "Enables health check scripts from configuration file.")
 	add(&f.Config.HTTPConfig.AllowWriteHTTPFrom, "allow-write-http-from", "Only allow write endpoint calls from given network. CIDR format, can be specified multiple times.")
+	add(&f.Config.EnableEventLog, "eventlog", "Enables logging to the Windows event log.")
 	add(&f.Config.EncryptKey, "encrypt", "Provides the gossip encryption key.")
 	add(&f.Config.Ports.GRPC, "grpc-port", "Sets the gRPC API port to listen on (currently needed for Envoy xDS only).")
 	add(&f.Config.Ports.HTTP, "http-port", "Sets the HTTP API port to listen on.")
@@ -97,6 +124,10 @@ func AddFlags(fs *flag.FlagSet, f *Flags) {
 	add(&f.Config.RetryJoinWAN, "retry-join-wan", "Address of an agent to join -wan at start time with retries enabled. Can be specified multiple times.")
 	add(&f.Config.RetryJoinMaxAttemptsLAN, "retry-max", "Maximum number of join attempts. Defaults to 0, which will retry indefinitely.")
 	add(&f.Config.RetryJoinMaxAttemptsWAN, "retry-max-wan", "Maximum number of join -wan attempts. Defaults to 0, which will retry indefinitely.")
+	add(&f.Config.RetryJoinMaxIntervalLAN, "retry-max-interval", "Maximum time to wait between join attempts. The wait doubles after every failed attempt up to this value.")
+	add(&f.Config.RetryJoinMaxIntervalWAN, "retry-max-interval-wan", "Maximum time to wait between join -wan attempts. The wait doubles after every failed attempt up to this value.")
+	add(&f.Config.RetryJoinSplayLAN, "retry-splay", "Maximum random delay added before every join attempt.")
+	add(&f.Config.RetryJoinSplayWAN, "retry-splay-wan", "Maximum random delay added before every join -wan attempt.")
 	add(&f.Config.SerfBindAddrLAN, "serf-lan-bind", "Address to bind Serf LAN listeners to.")
 	add(&f.Config.Ports.SerfLAN, "serf-lan-port", "Sets the Serf LAN port to listen on.")
 	add(&f.Config.SegmentName, "segment", "(Enterprise-only) Sets the network segment to join.")
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// interpolateRe matches the supported interpolations ${env.NAME} and
+// ${file("path")}. A leading "$$" escapes the interpolation and yields the
+// literal text with a single "$". Any other ${...} expression is left as is
+// since check and watch handler scripts commonly contain shell variables.
+var interpolateRe = regexp.MustCompile(`\$?\$\{\s*(?:env\.([A-Za-z_][A-Za-z0-9_]*)|file\(\s*"([^"]*)"\s*\))\s*\}`)
+
+// interpolate replaces the interpolations in all string values of the raw
+// configuration. It is evaluated every time a configuration is parsed so
+// that values read from the environment or from files are picked up on
+// reload.
+func interpolate(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case string:
+		return interpolateString(x)
+
+	case map[string]interface{}:
+		for k, y := range x {
+			val, err := interpolate(y)
+			if err != nil {
+				return nil, err
+			}
+			x[k] = val
+		}
+		return x, nil
+
+	case []map[string]interface{}:
+		for _, y := range x {
+			if _, err := interpolate(y); err != nil {
+				return nil, err
+			}
+		}
+		return x, nil
+
+	case []interface{}:
+		for i, y := range x {
+			val, err := interpolate(y)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = val
+		}
+		return x, nil
+
+	default:
+		return v, nil
+	}
+}
+
+func interpolateString(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var err error
+	out := interpolateRe.ReplaceAllStringFunc(s, func(m string) string {
+		if err != nil {
+			return m
+		}
+		if strings.HasPrefix(m, "$$") {
+			return m[1:]
+		}
+
+		sub := interpolateRe.FindStringSubmatch(m)
+		switch {
+		case sub[1] != "":
+			val, ok := os.LookupEnv(sub[1])
+			if !ok {
+				err = fmt.Errorf("environment variable %q is not set", sub[1])
+			}
+			return val
+
+		default:
+			b, rerr := ioutil.ReadFile(sub[2])
+			if rerr != nil {
+				err = fmt.Errorf("cannot read file %q: %s", sub[2], rerr)
+				return ""
+			}
+			return strings.TrimSpace(string(b))
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateString(t *testing.T) {
+	dir := testutil.TempDir(t, "interpolate")
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("c2VjcmV0\n"), 0600))
+
+	os.Setenv("CONSUL_INTERPOLATE_TOKEN", "abc")
+	defer os.Unsetenv("CONSUL_INTERPOLATE_TOKEN")
+
+	tests := []struct {
+		in, out string
+		err     string
+	}{
+		{in: "plain", out: "plain"},
+		{in: "${env.CONSUL_INTERPOLATE_TOKEN}", out: "abc"},
+		{in: "x-${ env.CONSUL_INTERPOLATE_TOKEN }-y", out: "x-abc-y"},
+		{in: `${file("` + keyFile + `")}`, out: "c2VjcmV0"},
+		{in: "$${env.CONSUL_INTERPOLATE_TOKEN}", out: "${env.CONSUL_INTERPOLATE_TOKEN}"},
+		{in: "echo ${HOME}", out: "echo ${HOME}"},
+		{in: "${env.CONSUL_INTERPOLATE_MISSING}", err: `environment variable "CONSUL_INTERPOLATE_MISSING" is not set`},
+		{in: `${file("` + filepath.Join(dir, "missing") + `")}`, err: "cannot read file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := interpolateString(tt.in)
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.out, out)
+		})
+	}
+}
+
+func TestParse_Interpolate(t *testing.T) {
+	os.Setenv("CONSUL_INTERPOLATE_TOKEN", "abc")
+	defer os.Unsetenv("CONSUL_INTERPOLATE_TOKEN")
+
+	for format, data := range map[string]string{
+		"json": `{"acl_agent_token": "${env.CONSUL_INTERPOLATE_TOKEN}", "services": [{"name": "web", "tags": ["${env.CONSUL_INTERPOLATE_TOKEN}"]}]}`,
+		"hcl":  `acl_agent_token = "${env.CONSUL_INTERPOLATE_TOKEN}" services = [{ name = "web" tags = ["${env.CONSUL_INTERPOLATE_TOKEN}"] }]`,
+	} {
+		t.Run(format, func(t *testing.T) {
+			c, err := Parse(data, format)
+			require.NoError(t, err)
+			require.Equal(t, "abc", *c.ACLAgentToken)
+			require.Len(t, c.Services, 1)
+			require.Equal(t, []string{"abc"}, c.Services[0].Tags)
+		})
+	}
+}
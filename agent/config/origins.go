@@ -0,0 +1,37 @@
+package config
+
+import (
+	"encoding/json"
+)
+
+// recordOrigins marks the keys set in the config parsed from the named
+// source as originating from it.
+func (b *Builder) recordOrigins(name string, c Config) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return
+	}
+	flattenKeys("", m, func(key string) {
+		b.Origins[key] = name
+	})
+}
+
+// flattenKeys calls fn with the dotted path of every value in m which isn't
+// an object. Lists are treated as values.
+func flattenKeys(prefix string, m map[string]interface{}, fn func(key string)) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if child, ok := v.(map[string]interface{}); ok {
+			flattenKeys(key, child, fn)
+			continue
+		}
+		fn(key)
+	}
+}
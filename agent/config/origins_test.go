@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_Origins(t *testing.T) {
+	t.Parallel()
+
+	logLevel := "WARN"
+	b, err := NewBuilder(Flags{
+		Config: Config{LogLevel: &logLevel},
+	})
+	require.NoError(t, err)
+	b.Sources = append(b.Sources,
+		Source{Name: "a.hcl", Format: "hcl", Data: `
+			data_dir = "/tmp/a"
+			log_level = "DEBUG"
+			ports { http = 8000 }
+			node_meta { rack = "r1" }
+			retry_join = ["a"]
+		`},
+		Source{Name: "b.json", Format: "json", Data: `{
+			"ports": { "dns": 8600 },
+			"retry_join": ["b"]
+		}`},
+	)
+
+	rt, err := b.BuildAndValidate()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, rt.RetryJoinLAN)
+
+	require.Equal(t, "a.hcl", b.Origins["data_dir"])
+	require.Equal(t, "a.hcl", b.Origins["ports.http"])
+	require.Equal(t, "a.hcl", b.Origins["node_meta.rack"])
+	require.Equal(t, "b.json", b.Origins["ports.dns"])
+	require.Equal(t, "b.json", b.Origins["retry_join"])
+
+	// Flags take precedence over the config files.
+	require.Equal(t, "flags.values", b.Origins["log_level"])
+
+	// Keys set by no one else come from the defaults.
+	require.Equal(t, "default", b.Origins["ports.server"])
+	require.NotContains(t, b.Origins, "ports")
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// remoteTimeout is the time allowed for fetching a single remote config
+// layer.
+const remoteTimeout = 10 * time.Second
+
+// ReadRemote fetches the config layers from a remote location. The location
+// is either an http:// or https:// URL returning a single config fragment,
+// or a consul:// URL of the form
+//
+//	consul://host:port/prefix?dc=dc1&token=secret&scheme=https
+//
+// which reads every key below the prefix of the KV store of that cluster as
+// a separate config fragment in key order. The format of a fragment is
+// derived from the ".json" or ".hcl" suffix of the URL path or key and
+// defaults to JSON.
+func ReadRemote(loc string) ([]Source, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		// The error of url.Parse includes the location
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("config: Invalid remote config location: %s", err)
+	}
+	name := redactURL(u)
+
+	switch u.Scheme {
+	case "http", "https":
+		src, err := readRemoteHTTP(u)
+		if err != nil {
+			return nil, fmt.Errorf("config: Failed to read remote config from %s: %s", name, err)
+		}
+		return []Source{src}, nil
+
+	case "consul":
+		srcs, err := readRemoteKV(u)
+		if err != nil {
+			return nil, fmt.Errorf("config: Failed to read remote config from %s: %s", name, err)
+		}
+		return srcs, nil
+
+	default:
+		return nil, fmt.Errorf("config: Unsupported remote config location %q. Must be http://, https:// or consul://", name)
+	}
+}
+
+func readRemoteHTTP(u *url.URL) (Source, error) {
+	client := &http.Client{Timeout: remoteTimeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		// The error of the client includes the URL
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return Source{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Source{}, fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Source{}, err
+	}
+	return Source{Name: redactURL(u), Format: remoteFormat(u.Path), Data: string(data)}, nil
+}
+
+func readRemoteKV(u *url.URL) ([]Source, error) {
+	q := u.Query()
+	conf := api.DefaultConfig()
+	conf.Address = u.Host
+	if s := q.Get("scheme"); s != "" {
+		conf.Scheme = s
+	}
+	if t := q.Get("token"); t != "" {
+		conf.Token = t
+	}
+
+	client, err := api.NewClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	conf.HttpClient.Timeout = remoteTimeout
+
+	prefix := strings.TrimPrefix(u.Path, "/")
+	pairs, _, err := client.KV().List(prefix, &api.QueryOptions{Datacenter: q.Get("dc")})
+	if err != nil {
+		return nil, err
+	}
+
+	var srcs []Source
+	for _, p := range pairs {
+		// skip folders and empty keys
+		if strings.HasSuffix(p.Key, "/") || len(p.Value) == 0 {
+			continue
+		}
+		srcs = append(srcs, Source{
+			Name:   "consul://" + u.Host + "/" + p.Key,
+			Format: remoteFormat(p.Key),
+			Data:   string(p.Value),
+		})
+	}
+	return srcs, nil
+}
+
+// redactURL returns the URL with the userinfo and the query values replaced,
+// so that credentials don't end up in logs, errors or source names.
+func redactURL(u *url.URL) string {
+	r := *u
+	if r.User != nil {
+		r.User = url.User("redacted")
+	}
+	if r.RawQuery != "" {
+		q := r.Query()
+		for k := range q {
+			q[k] = []string{"redacted"}
+		}
+		r.RawQuery = q.Encode()
+	}
+	return r.String()
+}
+
+func remoteFormat(name string) string {
+	if f := FormatFrom(name); f != "" {
+		return f
+	}
+	return "json"
+}
@@ -15,6 +15,20 @@ import (
 	"golang.org/x/time/rate"
 )
 
+const (
+	// DNSAnswerOrderRandom shuffles the instances of every answer.
+	DNSAnswerOrderRandom = "random"
+
+	// DNSAnswerOrderRoundRobin rotates the instances by one position with
+	// every query, so resolvers that always pick the first record still
+	// spread the load.
+	DNSAnswerOrderRoundRobin = "round-robin"
+
+	// DNSAnswerOrderWeighted shuffles the instances so that the chance of an
+	// instance to come first is proportional to its weight.
+	DNSAnswerOrderWeighted = "weighted"
+)
+
 type RuntimeSOAConfig struct {
 	Refresh uint32 // 3600 by default
 	Retry   uint32 // 600
@@ -22,6 +36,19 @@ type RuntimeSOAConfig struct {
 	Minttl  uint32 // 0,
 }
 
+// AddrTLSConfig contains the TLS settings of a single HTTPS listener.
+type AddrTLSConfig struct {
+	// CertFile and KeyFile are the certificate and key the listener
+	// serves. They default to the agent wide cert_file and key_file.
+	CertFile string
+	KeyFile  string
+
+	// VerifyIncoming requires clients of the listener to present a
+	// certificate signed by the CA. It defaults to the agent wide
+	// verify_incoming or verify_incoming_https setting.
+	VerifyIncoming bool
+}
+
 // RuntimeConfig specifies the configuration the consul agent actually
 // uses. Is is derived from one or more Config structures which can come
 // from files, flags and/or environment variables.
@@ -60,6 +87,14 @@ type RuntimeConfig struct {
 	// hcl: acl.enabled = boolean
 	ACLsEnabled bool
 
+	// ACLAllowAnonymousWrite allows linking policies that grant write
+	// access to the anonymous token. Without it a misconfigured anonymous
+	// token could silently open up the cluster to every request without a
+	// token.
+	//
+	// hcl: acl.allow_anonymous_write = (true|false)
+	ACLAllowAnonymousWrite bool
+
 	// ACLAgentMasterToken is a special token that has full read and write
 	// privileges for this agent, and can be used to call agent endpoints
 	// when no servers are available.
@@ -155,6 +190,12 @@ type RuntimeConfig struct {
 	// hcl: acl.token_ttl = "duration"
 	ACLPolicyTTL time.Duration
 
+	// ACLDNSToken is the token used to resolve DNS queries. If not configured
+	// the ACLToken is used.
+	//
+	// hcl: acl.tokens.dns = string
+	ACLDNSToken string
+
 	// ACLToken is the default token used to make requests if a per-request
 	// token is not provided. If not configured the 'anonymous' token is used.
 	//
@@ -171,6 +212,13 @@ type RuntimeConfig struct {
 	// hcl: autopilot { cleanup_dead_servers = (true|false) }
 	AutopilotCleanupDeadServers bool
 
+	// AutopilotDeadServerQuarantineTime is the minimum amount of time a
+	// server must be dead before it's cleaned up. Zero cleans up dead
+	// servers right away.
+	//
+	// hcl: autopilot { dead_server_quarantine_time = "duration" }
+	AutopilotDeadServerQuarantineTime time.Duration
+
 	// AutopilotDisableUpgradeMigration will disable Autopilot's upgrade migration
 	// strategy of waiting until enough newer-versioned servers have been added to the
 	// cluster before promoting them to voters. (Enterprise-only)
@@ -184,6 +232,12 @@ type RuntimeConfig struct {
 	// hcl: autopilot { last_contact_threshold = "duration" }
 	AutopilotLastContactThreshold time.Duration
 
+	// AutopilotMaxDeadServerRemovals is the maximum number of dead servers
+	// cleaned up at a time. Zero means no limit.
+	//
+	// hcl: autopilot { max_dead_server_removals = int }
+	AutopilotMaxDeadServerRemovals int
+
 	// AutopilotMaxTrailingLogs is the amount of entries in the Raft Log that a server can
 	// be behind before being considered unhealthy. The value must be positive.
 	//
@@ -239,6 +293,13 @@ type RuntimeConfig struct {
 	// hcl: dns_config { a_record_limit = int }
 	DNSARecordLimit int
 
+	// DNSAnswerOrder is the order of the instances in the answers of service
+	// lookups: DNSAnswerOrderRandom, DNSAnswerOrderRoundRobin or
+	// DNSAnswerOrderWeighted.
+	//
+	// hcl: dns_config { answer_order = ("random"|"round-robin"|"weighted") }
+	DNSAnswerOrder string
+
 	// DNSDisableCompression is used to control whether DNS responses are
 	// compressed. In Consul 0.7 this was turned on by default and this
 	// config was added as an opt-out.
@@ -271,6 +332,14 @@ type RuntimeConfig struct {
 	// hcl: dns_config { max_stale = "duration" }
 	DNSMaxStale time.Duration
 
+	// DNSNegativeTTL is how long resolvers cache negative answers, which are
+	// NXDOMAIN responses and responses without answers. It sets the TTL and
+	// the minimum of the SOA record in the authority section of negative
+	// answers. If zero, the minimum TTL of the SOA record is used.
+	//
+	// hcl: dns_config { negative_ttl = "duration" }
+	DNSNegativeTTL time.Duration
+
 	// DNSNodeTTL provides the TTL value for a node query.
 	//
 	// hcl: dns_config { node_ttl = "duration" }
@@ -348,6 +417,21 @@ type RuntimeConfig struct {
 	// hcl: http_config { response_headers = map[string]string }
 	HTTPResponseHeaders map[string]string
 
+	// HTTPMaxHeaderBytes is the maximum size of the request headers of
+	// the HTTP API. Zero means the default of the Go HTTP server, which is
+	// 1MB.
+	//
+	// hcl: http_config { max_header_bytes = int }
+	HTTPMaxHeaderBytes int
+
+	// HTTPMaxRequestBodyBytes is the maximum size of request bodies of the
+	// HTTP API for each endpoint class: "kv", "txn", and "default" for all
+	// other endpoints except snapshots. Larger requests get a 413
+	// response. Zero means no limit.
+	//
+	// hcl: http_config { max_request_body_bytes { default = int kv = int txn = int } }
+	HTTPMaxRequestBodyBytes map[string]int
+
 	// Embed Telemetry Config
 	Telemetry lib.TelemetryConfig
 
@@ -395,6 +479,18 @@ type RuntimeConfig struct {
 	// hcl: advertise_addr_wan = string
 	AdvertiseAddrWAN *net.IPAddr
 
+	// AdvertiseAddrWANMap maps the names of remote datacenters to the
+	// address that the servers of that datacenter should use to reach this
+	// server over the WAN, for both Serf and Consul RPC. This lets
+	// datacenters behind NAT advertise their public address to some
+	// datacenters and their private one to others. Datacenters not in the
+	// map use AdvertiseAddrWAN. The addresses can be specified as ip
+	// addresses or as go-sockaddr templates which resolve to a single ip
+	// address.
+	//
+	// hcl: advertise_addr_wan_map = map[string]string
+	AdvertiseAddrWANMap map[string]string
+
 	// BindAddr is used to control the address we bind to.
 	// If not specified, the first private IP we find is used.
 	// This controls the address we use for cluster facing
@@ -414,6 +510,17 @@ type RuntimeConfig struct {
 	// flag: -bootstrap
 	Bootstrap bool
 
+	// BootstrapClusterID identifies the cluster the server belongs to. The
+	// servers advertise it to each other, and a server with BootstrapExpect
+	// set refuses to bootstrap a new cluster if it sees a server with a
+	// different cluster ID, or if it can't confirm that one of the servers
+	// it sees has no Raft peers yet. This prevents a server whose
+	// configuration was copied from another cluster from bootstrapping a
+	// second cluster next to it.
+	//
+	// hcl: bootstrap_cluster_id = string
+	BootstrapClusterID string
+
 	// BootstrapExpect tries to automatically bootstrap the Consul cluster, by
 	// having servers wait to bootstrap until enough servers join, and then
 	// performing the bootstrap process automatically. They will disable their
@@ -436,12 +543,58 @@ type RuntimeConfig struct {
 	// hcl: ca_path = string
 	CAPath string
 
+	// CRLFile is a path to a PEM or DER encoded certificate revocation list.
+	// Incoming connections presenting a client certificate revoked by it are
+	// rejected.
+	//
+	// hcl: crl_file = string
+	CRLFile string
+
+	// CRLPath is a path to a directory of certificate revocation list files,
+	// which are used like CRLFile.
+	//
+	// hcl: crl_path = string
+	CRLPath string
+
+	// CertBundleFile is a PKCS#12 bundle with the TLS certificate, its key
+	// and intermediates. It is used instead of CertFile and KeyFile when set,
+	// and is decrypted with the KeyPassphrase.
+	//
+	// hcl: cert_bundle_file = string
+	CertBundleFile string
+
 	// CertFile is used to provide a TLS certificate that is used for serving
 	// TLS connections. Must be provided to serve TLS connections.
 	//
 	// hcl: cert_file = string
 	CertFile string
 
+	// CheckFlapHoldDown is how long the status of a flapping health check
+	// has to be stable before it is synced to the servers. Zero disables
+	// holding down flapping checks.
+	//
+	// hcl: check_flap_hold_down = "duration"
+	CheckFlapHoldDown time.Duration
+
+	// CheckOutputMaxSize is the maximum size in bytes of the output of the
+	// agent's health checks. Larger outputs are truncated according to
+	// CheckOutputTruncation.
+	//
+	// hcl: check_output_max_size = int
+	CheckOutputMaxSize int
+
+	// CheckOutputTruncation is the part of check outputs exceeding
+	// CheckOutputMaxSize that is kept, either "head" or "tail".
+	//
+	// hcl: check_output_truncation = ("head"|"tail")
+	CheckOutputTruncation string
+
+	// CheckPlugins maps the names of custom check types to the paths of the
+	// plugin binaries implementing them. Checks refer to a plugin by its name.
+	//
+	// hcl: check_plugins { name = "path" }
+	CheckPlugins map[string]string
+
 	// CheckUpdateInterval controls the interval on which the output of a health check
 	// is updated if there is no change to the state. For example, a check in a steady
 	// state may run every 5 second generating a unique output (timestamp, etc), forcing
@@ -573,14 +726,14 @@ type RuntimeConfig struct {
 	// empty.
 	//
 	// The ip addresses are taken from 'addresses.dns' which should contain a
-	// space separated list of ip addresses and/or go-sockaddr templates.
+	// list or a space separated string of ip addresses and/or go-sockaddr templates.
 	//
 	// If 'addresses.dns' was not provided the 'client_addr' addresses are
 	// used.
 	//
 	// The DNS server cannot be bound to UNIX sockets.
 	//
-	// hcl: client_addr = string addresses { dns = string } ports { dns = int }
+	// hcl: client_addr = string addresses { dns = (string|[string]) } ports { dns = int }
 	DNSAddrs []net.Addr
 
 	// DNSPort is the port the DNS server listens on. The default is 8600.
@@ -600,6 +753,14 @@ type RuntimeConfig struct {
 	// flag: -data-dir string
 	DataDir string
 
+	// DataDirEncryptionKeyFile is the path to a file with a base64 encoded
+	// AES key. If it is set the sensitive state persisted in the data dir,
+	// like the ACL tokens and the definitions of services, checks and
+	// proxies, is encrypted with it.
+	//
+	// hcl: data_dir_encryption_key_file = string
+	DataDirEncryptionKeyFile string
+
 	// DevMode enables a fast-path mode of operation to bring up an in-memory
 	// server with minimal configuration. Useful for developing Consul.
 	//
@@ -662,6 +823,23 @@ type RuntimeConfig struct {
 	// hcl: discard_check_output = (true|false)
 	DiscardCheckOutput bool
 
+	// DockerCheckRuntime is the container runtime Docker checks execute
+	// their commands with. "docker" uses the Docker Engine API, "containerd"
+	// and "cri" use the CRI API of containerd or any other runtime serving
+	// it.
+	//
+	// hcl: docker_check_runtime = ("docker"|"containerd"|"cri")
+	DockerCheckRuntime string
+
+	// DockerCheckRuntimeEndpoint is the endpoint of the runtime of Docker
+	// checks, such as "unix:///run/containerd/containerd.sock". It defaults
+	// to the DOCKER_HOST environment variable or the default socket for
+	// "docker" and the socket of containerd for "containerd", and must be
+	// set for "cri".
+	//
+	// hcl: docker_check_runtime_endpoint = string
+	DockerCheckRuntimeEndpoint string
+
 	// EnableAgentTLSForChecks is used to apply the agent's TLS settings in
 	// order to configure the HTTP client used for health checks. Enabling
 	// this allows HTTP checks to present a client certificate and verify
@@ -690,6 +868,14 @@ type RuntimeConfig struct {
 	// flag: -enable-script-checks
 	EnableRemoteScriptChecks bool
 
+	// EnableEventLog is used to also tee all the logs over to the Windows
+	// event log. Only supported on Windows. Other platforms will generate an
+	// error.
+	//
+	// hcl: enable_eventlog = (true|false)
+	// flag: -eventlog
+	EnableEventLog bool
+
 	// EnableSyslog is used to also tee all the logs over to syslog. Only supported
 	// on linux and OSX. Other platforms will generate an error.
 	//
@@ -722,8 +908,18 @@ type RuntimeConfig struct {
 	// hcl: encrypt_verify_outgoing = (true|false)
 	EncryptVerifyOutgoing bool
 
-	// GRPCPort is the port the gRPC server listens on. Currently this only
-	// exposes the xDS and ext_authz APIs for Envoy and it is disabled by default.
+	// FollowerReads lists the endpoint classes for which servers that are
+	// followers answer reads with the default consistency themselves while
+	// they hold a leader lease and once they applied the commit index,
+	// instead of forwarding them to the leader.
+	// Valid classes are "catalog", "health" and "kv".
+	//
+	// hcl: performance { follower_reads = []string }
+	FollowerReads []string
+
+	// GRPCPort is the port the gRPC server listens on. It exposes the xDS
+	// and ext_authz APIs for Envoy, the gRPC health service and session
+	// keepalives, and it is disabled by default.
 	//
 	// hcl: ports { grpc = int }
 	// flags: -grpc-port int
@@ -734,14 +930,14 @@ type RuntimeConfig struct {
 	// the list is empty.
 	//
 	// The addresses are taken from 'addresses.grpc' which should contain a
-	// space separated list of ip addresses, UNIX socket paths and/or
+	// list or a space separated string of ip addresses, UNIX socket paths and/or
 	// go-sockaddr templates. UNIX socket paths must be written as
 	// 'unix://<full path>', e.g. 'unix:///var/run/consul-grpc.sock'.
 	//
 	// If 'addresses.grpc' was not provided the 'client_addr' addresses are
 	// used.
 	//
-	// hcl: client_addr = string addresses { grpc = string } ports { grpc = int }
+	// hcl: client_addr = string addresses { grpc = (string|[string]) } ports { grpc = int }
 	GRPCAddrs []net.Addr
 
 	// HTTPAddrs contains the list of TCP addresses and UNIX sockets the HTTP
@@ -749,14 +945,14 @@ type RuntimeConfig struct {
 	// the list is empty.
 	//
 	// The addresses are taken from 'addresses.http' which should contain a
-	// space separated list of ip addresses, UNIX socket paths and/or
+	// list or a space separated string of ip addresses, UNIX socket paths and/or
 	// go-sockaddr templates. UNIX socket paths must be written as
 	// 'unix://<full path>', e.g. 'unix:///var/run/consul-http.sock'.
 	//
 	// If 'addresses.http' was not provided the 'client_addr' addresses are
 	// used.
 	//
-	// hcl: client_addr = string addresses { http = string } ports { http = int }
+	// hcl: client_addr = string addresses { http = (string|[string]) } ports { http = int }
 	HTTPAddrs []net.Addr
 
 	// HTTPPort is the port the HTTP server listens on. The default is 8500.
@@ -771,16 +967,37 @@ type RuntimeConfig struct {
 	// 0) the list is empty.
 	//
 	// The addresses are taken from 'addresses.https' which should contain a
-	// space separated list of ip addresses, UNIX socket paths and/or
+	// list or a space separated string of ip addresses, UNIX socket paths and/or
 	// go-sockaddr templates. UNIX socket paths must be written as
 	// 'unix://<full path>', e.g. 'unix:///var/run/consul-https.sock'.
 	//
 	// If 'addresses.https' was not provided the 'client_addr' addresses are
 	// used.
 	//
-	// hcl: client_addr = string addresses { https = string } ports { https = int }
+	// hcl: client_addr = string addresses { https = (string|[string]) } ports { https = int }
 	HTTPSAddrs []net.Addr
 
+	// HTTPSAddrTLS contains the TLS settings of the HTTPS addresses which
+	// were configured with their own certificate or client verification
+	// setting, keyed by the address as returned by net.Addr.String(). All
+	// other HTTPS addresses use the agent wide TLS settings.
+	//
+	// hcl: addresses { https = [{ address = string cert_file = string key_file = string verify_incoming = (true|false) }] }
+	HTTPSAddrTLS map[string]AddrTLSConfig
+
+	// HTTPSAcceptRate is the number of connections per second the HTTPS
+	// listeners accept. Connections above the rate are closed right away.
+	// Zero means no limit.
+	//
+	// hcl: limits { https_accept_rate = float64 }
+	HTTPSAcceptRate float64
+
+	// HTTPSMaxConcurrentHandshakes is the maximum number of TLS handshakes
+	// each HTTPS listener performs concurrently. Zero means no limit.
+	//
+	// hcl: limits { https_max_concurrent_handshakes = int }
+	HTTPSMaxConcurrentHandshakes int
+
 	// HTTPSPort is the port the HTTP server listens on. The default is -1.
 	// Setting this to a value <= 0 disables the endpoint.
 	//
@@ -793,6 +1010,18 @@ type RuntimeConfig struct {
 	// hcl: key_file = string
 	KeyFile string
 
+	// KeyPassphrase is used to decrypt KeyFile if it is an encrypted PKCS#8
+	// or PKCS#1 key, and CertBundleFile.
+	//
+	// hcl: key_passphrase = string
+	KeyPassphrase string
+
+	// KeyPassphraseFile is a file containing the KeyPassphrase. It is used
+	// when KeyPassphrase is not set.
+	//
+	// hcl: key_passphrase_file = string
+	KeyPassphraseFile string
+
 	// LeaveDrainTime is used to wait after a server has left the LAN Serf
 	// pool for RPCs to drain and new requests to be sent to other servers.
 	//
@@ -900,6 +1129,54 @@ type RuntimeConfig struct {
 	RPCRateLimit rate.Limit
 	RPCMaxBurst  int
 
+	// RPCMaxConns is the maximum number of RPC connections a server handles
+	// concurrently. Raft connections and connections from other Consul
+	// servers don't count against it. Zero means no limit.
+	//
+	// hcl: limits { rpc_max_conns = int }
+	RPCMaxConns int
+
+	// RPCMaxStreamsPerConn is the maximum number of concurrent streams a
+	// server handles on a single multiplexed RPC connection. Zero means no
+	// limit.
+	//
+	// hcl: limits { rpc_max_streams_per_conn = int }
+	RPCMaxStreamsPerConn int
+
+	// RPCAcceptRate is the number of connections per second the RPC
+	// listener accepts. Connections above the rate are closed right away.
+	// Zero means no limit.
+	//
+	// hcl: limits { rpc_accept_rate = float64 }
+	RPCAcceptRate float64
+
+	// RPCMaxConcurrentHandshakes is the maximum number of TLS handshakes
+	// the RPC listener performs concurrently. Zero means no limit.
+	//
+	// hcl: limits { rpc_max_concurrent_handshakes = int }
+	RPCMaxConcurrentHandshakes int
+
+	// RPCAcceptBackpressure makes a server wait for a free slot when
+	// RPCMaxConns or RPCMaxStreamsPerConn is reached instead of rejecting
+	// new connections and streams.
+	//
+	// hcl: limits { rpc_accept_backpressure = (true|false) }
+	RPCAcceptBackpressure bool
+
+	// RPCQueryLimits limits the number of concurrent queries a server runs
+	// for each of the expensive query classes "health", "kv" and "txn", so
+	// a burst of them can't starve other RPCs. Classes without a limit are
+	// not limited.
+	//
+	// hcl: limits { rpc_query_limits { health = int kv = int txn = int } }
+	RPCQueryLimits map[string]int
+
+	// RPCQueryQueueTimeout is how long a query waits for a free slot when
+	// the limit of its class is reached before it is rejected.
+	//
+	// hcl: limits { rpc_query_queue_timeout = "duration" }
+	RPCQueryQueueTimeout time.Duration
+
 	// RPCProtocol is the Consul protocol version to use.
 	//
 	// hcl: protocol = int
@@ -984,6 +1261,37 @@ type RuntimeConfig struct {
 	// flag: -retry-max-wan int
 	RetryJoinMaxAttemptsWAN int
 
+	// RetryJoinMaxIntervalLAN is the maximum time to wait in between join
+	// attempts. If it is greater than RetryJoinIntervalLAN the wait doubles
+	// after every failed attempt up to this value. The default of zero
+	// waits RetryJoinIntervalLAN between all attempts.
+	//
+	// hcl: retry_max_interval = "duration"
+	// flag: -retry-max-interval duration
+	RetryJoinMaxIntervalLAN time.Duration
+
+	// RetryJoinMaxIntervalWAN is the maximum time to wait in between join
+	// -wan attempts, like RetryJoinMaxIntervalLAN.
+	//
+	// hcl: retry_max_interval_wan = "duration"
+	// flag: -retry-max-interval-wan duration
+	RetryJoinMaxIntervalWAN time.Duration
+
+	// RetryJoinSplayLAN is the maximum random delay added before every join
+	// attempt, including the first one, so that agents which start at the
+	// same time don't all join at once.
+	//
+	// hcl: retry_splay = "duration"
+	// flag: -retry-splay duration
+	RetryJoinSplayLAN time.Duration
+
+	// RetryJoinSplayWAN is the maximum random delay added before every join
+	// -wan attempt, like RetryJoinSplayLAN.
+	//
+	// hcl: retry_splay_wan = "duration"
+	// flag: -retry-splay-wan duration
+	RetryJoinSplayWAN time.Duration
+
 	// RetryJoinWAN is a list of addresses and/or go-discover expressions to
 	// join -wan with retry enabled. See
 	// https://www.consul.io/docs/agent/options.html#cloud-auto-joining for
@@ -993,6 +1301,33 @@ type RuntimeConfig struct {
 	// flag: -retry-join-wan string -retry-join-wan string
 	RetryJoinWAN []string
 
+	// ScriptCheckDir is the working directory of script checks. Empty means
+	// the working directory of the agent.
+	//
+	// hcl: script_check_dir = string
+	ScriptCheckDir string
+
+	// ScriptCheckEnvWhitelist is the list of environment variables of the
+	// agent that are passed to script checks. A name ending in "*" matches
+	// all variables with that prefix. Empty passes the whole environment.
+	//
+	// hcl: script_check_env_whitelist = []string
+	ScriptCheckEnvWhitelist []string
+
+	// ScriptCheckKillTimeout is the time a timed out script check has to
+	// exit after its process group was asked to terminate before it's
+	// killed. Zero kills it right away.
+	//
+	// hcl: script_check_kill_timeout = "duration"
+	ScriptCheckKillTimeout time.Duration
+
+	// ScriptCheckUser is the name of the user script checks run as. The
+	// agent must have the privileges to switch users. Empty means the
+	// agent's user.
+	//
+	// hcl: script_check_user = string
+	ScriptCheckUser string
+
 	// SegmentName is the network segment for this client to join.
 	// (Enterprise-only)
 	//
@@ -1020,6 +1355,13 @@ type RuntimeConfig struct {
 	//     # rpc_listener controls whether or not to bind a separate
 	//     # RPC listener to the bind address.
 	//     rpc_listener = (true|false)
+	//
+	//     # ca_file, cert_file and key_file override the TLS configuration
+	//     # of the segment's RPC listener. cert_file and key_file must be
+	//     # set together and all require rpc_listener.
+	//     ca_file = string
+	//     cert_file = string
+	//     key_file = string
 	//   },
 	//   ...
 	// ]
@@ -1245,6 +1587,16 @@ type RuntimeConfig struct {
 	// hcl: ports { server = int }
 	ServerPort int
 
+	// ServiceDrainTime is the time the agent waits between marking its
+	// local services as critical and deregistering them when it leaves
+	// the cluster. This gives DNS and API consumers time to stop sending
+	// requests to the node. A value of zero disables draining. Since a
+	// graceful leave blocks while the services drain, it can be at most
+	// maxServiceDrainTime.
+	//
+	// hcl: service_drain_time = "duration"
+	ServiceDrainTime time.Duration
+
 	// Services contains the provided service definitions:
 	//
 	// hcl: services = [
@@ -1267,6 +1619,14 @@ type RuntimeConfig struct {
 	// hcl: session_ttl_min = "duration"
 	SessionTTLMin time.Duration
 
+	// SkipClusterIDCheck allows a server to merge with servers of its
+	// datacenter that advertise a different cluster ID. By default such
+	// LAN and WAN joins are rejected since the servers belong to different
+	// clusters.
+	//
+	// hcl: skip_cluster_id_check = (true|false)
+	SkipClusterIDCheck bool
+
 	// SkipLeaveOnInt controls if Serf skips a graceful leave when
 	// receiving the INT signal. Defaults false on clients, true on
 	// servers. (reloadable)
@@ -1274,6 +1634,14 @@ type RuntimeConfig struct {
 	// hcl: skip_leave_on_interrupt = (true|false)
 	SkipLeaveOnInt bool
 
+	// SNICertificates are additional certificates the HTTPS listeners serve
+	// to clients which ask for one of their names with SNI, so that the API
+	// can be served on several host names with distinct certificates. All
+	// other clients are served the CertFile.
+	//
+	// hcl: sni_certificates = [{ cert_file = string key_file = string }]
+	SNICertificates []tlsutil.SNICertificate
+
 	// StartJoinLAN is a list of addresses to attempt to join -wan when the
 	// agent starts. If Serf is unable to communicate with any of these
 	// addresses, then the agent will error and exit.
@@ -1330,18 +1698,57 @@ type RuntimeConfig struct {
 	// hcl: tls_cipher_suites = []string
 	TLSCipherSuites []uint16
 
+	// TLSDatacenterServerNames maps datacenters to the name the certificates
+	// of their servers are verified against with VerifyServerHostname,
+	// instead of "server.<datacenter>.<domain>".
+	//
+	// hcl: tls_datacenter_server_names = map[string]string
+	TLSDatacenterServerNames map[string]string
+
 	// TLSMinVersion is used to set the minimum TLS version used for TLS
-	// connections. Should be either "tls10", "tls11", or "tls12".
+	// connections. Should be either "tls10", "tls11", "tls12" or "tls13".
 	//
 	// hcl: tls_min_version = string
 	TLSMinVersion string
 
+	// TLSMaxVersion is used to set the maximum TLS version used for TLS
+	// connections. Should be either "tls10", "tls11", "tls12" or "tls13".
+	// It defaults to the latest supported version.
+	//
+	// hcl: tls_max_version = string
+	TLSMaxVersion string
+
+	// TLSOCSPStapling makes the HTTPS API staple OCSP responses for the
+	// agent's certificate, which are fetched and refreshed in the background.
+	//
+	// hcl: tls_ocsp_stapling = (true|false)
+	TLSOCSPStapling bool
+
+	// TLSOCSPResponderURL overrides the OCSP responder named by the agent's
+	// certificate.
+	//
+	// hcl: tls_ocsp_responder_url = string
+	TLSOCSPResponderURL string
+
+	// TLSOCSPRefreshInterval is how often the stapled OCSP response is
+	// refreshed. It defaults to an hour.
+	//
+	// hcl: tls_ocsp_refresh_interval = "duration"
+	TLSOCSPRefreshInterval time.Duration
+
 	// TLSPreferServerCipherSuites specifies whether to prefer the server's
 	// cipher suite over the client cipher suites.
 	//
 	// hcl: tls_prefer_server_cipher_suites = (true|false)
 	TLSPreferServerCipherSuites bool
 
+	// TLSWatchFiles enables reloading the certificate, key and CA files
+	// when they change on disk, so that certificates can be rotated without
+	// reloading the agent.
+	//
+	// hcl: tls_watch_files = (true|false)
+	TLSWatchFiles bool
+
 	// TaggedAddresses are used to publish a set of addresses for
 	// for a node, which can be used by the remote agent. We currently
 	// populate only the "wan" tag based on the SerfWan advertise address,
@@ -1366,6 +1773,26 @@ type RuntimeConfig struct {
 	// flag: -ui-dir string
 	UIDir string
 
+	// UIMetricsProxyBaseURL is the URL of the metrics provider that the
+	// /v1/internal/ui/metrics-proxy/ endpoint forwards requests to, such as
+	// a Prometheus server. The proxy is disabled if it is empty.
+	//
+	// hcl: ui_config { metrics_proxy { base_url = string } }
+	UIMetricsProxyBaseURL string
+
+	// UIMetricsProxyAddHeaders are the HTTP headers added to the requests
+	// forwarded to the metrics provider, for example to authenticate them.
+	//
+	// hcl: ui_config { metrics_proxy { add_headers = map[string]string } }
+	UIMetricsProxyAddHeaders map[string]string
+
+	// UIMetricsProxyPathAllowlist are the paths of the metrics provider API
+	// that the UI is allowed to request. Defaults to the Prometheus query
+	// API.
+	//
+	// hcl: ui_config { metrics_proxy { path_allowlist = []string } }
+	UIMetricsProxyPathAllowlist []string
+
 	// UnixSocketGroup contains the group of the file permissions when
 	// Consul binds to UNIX sockets.
 	//
@@ -1588,14 +2015,26 @@ func (c *RuntimeConfig) ToTLSUtilConfig() *tlsutil.Config {
 		VerifyOutgoing:           c.VerifyOutgoing,
 		CAFile:                   c.CAFile,
 		CAPath:                   c.CAPath,
+		CRLFile:                  c.CRLFile,
+		CRLPath:                  c.CRLPath,
 		CertFile:                 c.CertFile,
 		KeyFile:                  c.KeyFile,
+		CertBundleFile:           c.CertBundleFile,
+		KeyPassphrase:            c.KeyPassphrase,
+		KeyPassphraseFile:        c.KeyPassphraseFile,
+		SNICertificates:          c.SNICertificates,
 		NodeName:                 c.NodeName,
 		ServerName:               c.ServerName,
+		DatacenterServerNames:    c.TLSDatacenterServerNames,
 		TLSMinVersion:            c.TLSMinVersion,
+		TLSMaxVersion:            c.TLSMaxVersion,
 		CipherSuites:             c.TLSCipherSuites,
 		PreferServerCipherSuites: c.TLSPreferServerCipherSuites,
 		EnableAgentTLSForChecks:  c.EnableAgentTLSForChecks,
+		WatchFiles:               c.TLSWatchFiles,
+		OCSPStapling:             c.TLSOCSPStapling,
+		OCSPResponderURL:         c.TLSOCSPResponderURL,
+		OCSPRefreshInterval:      c.TLSOCSPRefreshInterval,
 	}
 }
 
@@ -1686,6 +2125,11 @@ func sanitize(name string, v reflect.Value) reflect.Value {
 		m := map[string]interface{}{}
 		for _, k := range v.MapKeys() {
 			key := k.String()
+			// headers sent to metrics collectors usually carry credentials
+			if name == "OTLPHeaders" || name == "UIMetricsProxyAddHeaders" {
+				m[key] = "hidden"
+				continue
+			}
 			m[key] = sanitize(key, v.MapIndex(k)).Interface()
 		}
 		return reflect.ValueOf(m)
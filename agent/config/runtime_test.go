@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/testutil"
+	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/consul/types"
 	"github.com/pascaldekloe/goe/verify"
 	"github.com/stretchr/testify/require"
@@ -589,6 +590,50 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "-retry-max-interval and -retry-splay",
+			args: []string{
+				`-retry-max-interval=5m`,
+				`-retry-splay=10s`,
+				`-data-dir=` + dataDir,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.RetryJoinMaxIntervalLAN = 5 * time.Minute
+				rt.RetryJoinSplayLAN = 10 * time.Second
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "-retry-max-interval-wan and -retry-splay-wan",
+			args: []string{
+				`-retry-max-interval-wan=5m`,
+				`-retry-splay-wan=10s`,
+				`-data-dir=` + dataDir,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.RetryJoinMaxIntervalWAN = 5 * time.Minute
+				rt.RetryJoinSplayWAN = 10 * time.Second
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "retry_max_interval less than retry_interval",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "retry_interval": "30s", "retry_max_interval": "10s" }`},
+			hcl:  []string{`retry_interval = "30s" retry_max_interval = "10s"`},
+			err:  "retry_max_interval cannot be 10s. Must be zero or greater than or equal to retry_interval 30s",
+		},
+		{
+			desc: "retry_splay_wan negative",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "retry_splay_wan": "-1s" }`},
+			hcl:  []string{`retry_splay_wan = "-1s"`},
+			err:  "retry_splay_wan cannot be -1s. Must be greater than or equal to zero",
+		},
 		{
 			desc: "-retry-join",
 			args: []string{
@@ -709,6 +754,17 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "-eventlog",
+			args: []string{
+				`-eventlog`,
+				`-data-dir=` + dataDir,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.EnableEventLog = true
+				rt.DataDir = dataDir
+			},
+		},
 		{
 			desc: "-syslog",
 			args: []string{
@@ -1016,6 +1072,78 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "address lists",
+			args: []string{`-data-dir=` + dataDir},
+			json: []string{`{
+					"addresses": {
+						"dns": ["1.1.1.1", "2001:db8::10"],
+						"http": ["2.2.2.2", "{{ printf \"unix://http 2001:db8::20\" }}"],
+						"https": [
+							"3.3.3.3",
+							{ "address": "2001:db8::30", "cert_file": "a.crt", "key_file": "a.key" },
+							{ "address": "3.3.3.4", "verify_incoming": true }
+						],
+						"grpc": ["4.4.4.4", { "address": "unix://grpc" }]
+					},
+					"cert_file": "b.crt",
+					"key_file": "b.key",
+					"ports":{ "dns":1, "http":2, "https":3, "grpc":4 }
+				}`},
+			hcl: []string{`
+					addresses = {
+						dns = ["1.1.1.1", "2001:db8::10"]
+						http = ["2.2.2.2", "{{ printf \"unix://http 2001:db8::20\" }}"]
+						https = [
+							"3.3.3.3",
+							{ address = "2001:db8::30" cert_file = "a.crt" key_file = "a.key" },
+							{ address = "3.3.3.4" verify_incoming = true }
+						]
+						grpc = ["4.4.4.4", { address = "unix://grpc" }]
+					}
+					cert_file = "b.crt"
+					key_file = "b.key"
+					ports { dns = 1 http = 2 https = 3 grpc = 4 }
+				`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DNSPort = 1
+				rt.DNSAddrs = []net.Addr{tcpAddr("1.1.1.1:1"), tcpAddr("[2001:db8::10]:1"), udpAddr("1.1.1.1:1"), udpAddr("[2001:db8::10]:1")}
+				rt.HTTPPort = 2
+				rt.HTTPAddrs = []net.Addr{tcpAddr("2.2.2.2:2"), unixAddr("unix://http"), tcpAddr("[2001:db8::20]:2")}
+				rt.HTTPSPort = 3
+				rt.HTTPSAddrs = []net.Addr{tcpAddr("3.3.3.3:3"), tcpAddr("[2001:db8::30]:3"), tcpAddr("3.3.3.4:3")}
+				rt.HTTPSAddrTLS = map[string]AddrTLSConfig{
+					"[2001:db8::30]:3": {CertFile: "a.crt", KeyFile: "a.key"},
+					"3.3.3.4:3":        {CertFile: "b.crt", KeyFile: "b.key", VerifyIncoming: true},
+				}
+				rt.GRPCPort = 4
+				rt.GRPCAddrs = []net.Addr{tcpAddr("4.4.4.4:4"), unixAddr("unix://grpc")}
+				rt.CertFile = "b.crt"
+				rt.KeyFile = "b.key"
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "address TLS settings only for https",
+			args: []string{`-data-dir=` + dataDir},
+			json: []string{`{ "addresses": { "http": [{ "address": "1.2.3.4", "verify_incoming": true }] } }`},
+			hcl:  []string{`addresses = { http = [{ address = "1.2.3.4" verify_incoming = true }] }`},
+			err:  "addresses.http: cert_file, key_file and verify_incoming are only supported for HTTPS addresses",
+		},
+		{
+			desc: "address TLS settings require cert and key",
+			args: []string{`-data-dir=` + dataDir},
+			json: []string{`{ "addresses": { "https": [{ "address": "1.2.3.4", "cert_file": "a.crt" }] }, "ports": { "https": 8501 } }`},
+			hcl:  []string{`addresses = { https = [{ address = "1.2.3.4" cert_file = "a.crt" }] } ports { https = 8501 }`},
+			err:  "addresses.https: cert_file and key_file must be set together",
+		},
+		{
+			desc: "address TLS settings not for unix sockets",
+			args: []string{`-data-dir=` + dataDir},
+			json: []string{`{ "addresses": { "https": [{ "address": "unix:///foo", "cert_file": "a.crt", "key_file": "a.key" }] }, "ports": { "https": 8501 } }`},
+			hcl:  []string{`addresses = { https = [{ address = "unix:///foo" cert_file = "a.crt" key_file = "a.key" }] } ports { https = 8501 }`},
+			err:  "addresses.https: TLS settings are not supported for unix sockets",
+		},
 		{
 			desc: "advertise address lan template",
 			args: []string{`-data-dir=` + dataDir},
@@ -1350,6 +1478,18 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "raft performance scaling per timeout",
+			args: []string{`-data-dir=` + dataDir},
+			json: []string{`{ "performance": { "raft_multiplier": 2, "raft_election_multiplier": 8, "raft_leader_lease_multiplier": 1 } }`},
+			hcl:  []string{`performance = { raft_multiplier = 2 raft_election_multiplier = 8 raft_leader_lease_multiplier = 1 }`},
+			patch: func(rt *RuntimeConfig) {
+				rt.ConsulRaftElectionTimeout = 8 * 1000 * time.Millisecond
+				rt.ConsulRaftHeartbeatTimeout = 2 * 1000 * time.Millisecond
+				rt.ConsulRaftLeaderLeaseTimeout = 1 * 500 * time.Millisecond
+				rt.DataDir = dataDir
+			},
+		},
 
 		// ------------------------------------------------------------
 		// validations
@@ -1491,6 +1631,199 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`autopilot = { max_trailing_logs = -1 }`},
 			err:  "autopilot.max_trailing_logs cannot be -1. Must be greater than or equal to zero",
 		},
+		{
+			desc: "check_flap_hold_down negative",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "check_flap_hold_down": "-1s" }`},
+			hcl:  []string{`check_flap_hold_down = "-1s"`},
+			err:  "check_flap_hold_down cannot be negative, got -1s",
+		},
+		{
+			desc: "check_output_max_size invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "check_output_max_size": 0 }`},
+			hcl:  []string{`check_output_max_size = 0`},
+			err:  "check_output_max_size cannot be 0. Must be greater than zero",
+		},
+		{
+			desc: "check_output_truncation invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "check_output_truncation": "middle" }`},
+			hcl:  []string{`check_output_truncation = "middle"`},
+			err:  `check_output_truncation must be "head" or "tail", got "middle"`,
+		},
+		{
+			desc: "tls_max_version invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_max_version": "tls14" }`},
+			hcl:  []string{`tls_max_version = "tls14"`},
+			err:  `tls_max_version must be one of "tls10", "tls11", "tls12" or "tls13", got "tls14"`,
+		},
+		{
+			desc: "tls_max_version lower than tls_min_version",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_min_version": "tls12", "tls_max_version": "tls11" }`},
+			hcl:  []string{`tls_min_version = "tls12" tls_max_version = "tls11"`},
+			err:  `tls_min_version "tls12" cannot be greater than tls_max_version "tls11"`,
+		},
+		{
+			desc: "tls_max_version",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_min_version": "tls13", "tls_max_version": "tls13" }`},
+			hcl:  []string{`tls_min_version = "tls13" tls_max_version = "tls13"`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.TLSMinVersion = "tls13"
+				rt.TLSMaxVersion = "tls13"
+			},
+		},
+		{
+			desc: "tls_datacenter_server_names empty name",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_datacenter_server_names": { "dc2": "" } }`},
+			hcl:  []string{`tls_datacenter_server_names = { dc2 = "" }`},
+			err:  `tls_datacenter_server_names: server name for datacenter "dc2" cannot be empty`,
+		},
+		{
+			desc: "tls_ocsp_stapling without cert",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_ocsp_stapling": true }`},
+			hcl:  []string{`tls_ocsp_stapling = true`},
+			err:  `tls_ocsp_stapling requires cert_file and key_file or cert_bundle_file`,
+		},
+		{
+			desc: "sni_certificates without key",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "sni_certificates": [{ "cert_file": "a.crt" }] }`},
+			hcl:  []string{`sni_certificates = [{ cert_file = "a.crt" }]`},
+			err:  `sni_certificates[0]: cert_file and key_file must be set`,
+		},
+		{
+			desc: "tls_ocsp_responder_url invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_ocsp_responder_url": "ocsp.example.com" }`},
+			hcl:  []string{`tls_ocsp_responder_url = "ocsp.example.com"`},
+			err:  `tls_ocsp_responder_url must be an http or https URL, got "ocsp.example.com"`,
+		},
+		{
+			desc: "tls_ocsp_refresh_interval too short",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_ocsp_refresh_interval": "30s" }`},
+			hcl:  []string{`tls_ocsp_refresh_interval = "30s"`},
+			err:  `tls_ocsp_refresh_interval cannot be shorter than 1m, got 30s`,
+		},
+		{
+			desc: "tls_ocsp_stapling",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "cert_file": "a.pem", "key_file": "b.pem", "tls_ocsp_stapling": true, "tls_ocsp_refresh_interval": "10m" }`},
+			hcl:  []string{`cert_file = "a.pem" key_file = "b.pem" tls_ocsp_stapling = true tls_ocsp_refresh_interval = "10m"`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.CertFile = "a.pem"
+				rt.KeyFile = "b.pem"
+				rt.TLSOCSPStapling = true
+				rt.TLSOCSPRefreshInterval = 10 * time.Minute
+			},
+		},
+		{
+			desc: "check_plugins without path",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "check_plugins": { "ldap": "" } }`},
+			hcl:  []string{`check_plugins { ldap = "" }`},
+			err:  "check_plugins[ldap] must be the path of the plugin",
+		},
+		{
+			desc: "docker_check_runtime invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "docker_check_runtime": "rkt" }`},
+			hcl:  []string{`docker_check_runtime = "rkt"`},
+			err:  `docker_check_runtime must be "docker", "containerd" or "cri", got "rkt"`,
+		},
+		{
+			desc: "docker_check_runtime cri without endpoint",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "docker_check_runtime": "cri" }`},
+			hcl:  []string{`docker_check_runtime = "cri"`},
+			err:  `docker_check_runtime_endpoint must be set for docker_check_runtime "cri"`,
+		},
+		{
+			desc: "docker_check_runtime containerd",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "docker_check_runtime": "containerd" }`},
+			hcl:  []string{`docker_check_runtime = "containerd"`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.DockerCheckRuntime = "containerd"
+			},
+		},
+		{
+			desc: "service_drain_time too long",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "service_drain_time": "6m" }`},
+			hcl:  []string{`service_drain_time = "6m"`},
+			err:  "service_drain_time cannot be 6m0s. Must be between 0s and 5m0s",
+		},
+		{
+			desc: "script_check_kill_timeout invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "script_check_kill_timeout": "-1s" }`},
+			hcl:  []string{`script_check_kill_timeout = "-1s"`},
+			err:  "script_check_kill_timeout cannot be -1s. Must be greater than or equal to zero",
+		},
+		{
+			desc: "autopilot.max_dead_server_removals invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "autopilot": { "max_dead_server_removals": -1 } }`},
+			hcl:  []string{`autopilot = { max_dead_server_removals = -1 }`},
+			err:  "autopilot.max_dead_server_removals cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "autopilot.dead_server_quarantine_time invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "autopilot": { "dead_server_quarantine_time": "-1s" } }`},
+			hcl:  []string{`autopilot = { dead_server_quarantine_time = "-1s" }`},
+			err:  "autopilot.dead_server_quarantine_time cannot be -1s. Must be greater than or equal to zero",
+		},
 		{
 			desc: "bind_addr cannot be empty",
 			args: []string{`-data-dir=` + dataDir},
@@ -1532,6 +1865,16 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`bootstrap_expect = 3`},
 			err:  "'bootstrap_expect > 0' requires 'server = true'",
 		},
+		{
+			desc: "bootstrap_cluster_id without server",
+			args: []string{
+				`-datacenter=a`,
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "bootstrap_cluster_id": "prod" }`},
+			hcl:  []string{`bootstrap_cluster_id = "prod"`},
+			err:  "'bootstrap_cluster_id' requires 'server = true'",
+		},
 		{
 			desc: "bootstrap-expect invalid",
 			args: []string{
@@ -1692,6 +2035,39 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`advertise_addr_wan = "::"`},
 			err:  "Advertise WAN address cannot be 0.0.0.0, :: or [::]",
 		},
+		{
+			desc: "advertise_addr_wan_map local datacenter",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "datacenter": "a", "advertise_addr_wan_map": { "a": "1.2.3.4" } }`},
+			hcl:  []string{`datacenter = "a" advertise_addr_wan_map = { a = "1.2.3.4" }`},
+			err:  `advertise_addr_wan_map cannot have an address for the local datacenter "a"`,
+		},
+		{
+			desc: "advertise_addr_wan_map any",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "advertise_addr_wan_map": { "b": "0.0.0.0" } }`},
+			hcl:  []string{`advertise_addr_wan_map = { b = "0.0.0.0" }`},
+			err:  `Advertise WAN address for datacenter "b" cannot be 0.0.0.0, :: or [::]`,
+		},
+		{
+			desc: "advertise_addr_wan_map template",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "server": true, "advertise_addr_wan_map": { "b": "{{ printf \"1.2.3.4\" }}" } }`},
+			hcl:  []string{`server = true advertise_addr_wan_map = { b = "{{ printf \"1.2.3.4\" }}" }`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.ServerMode = true
+				rt.LeaveOnTerm = false
+				rt.SkipLeaveOnInt = true
+				rt.AdvertiseAddrWANMap = map[string]string{"b": "1.2.3.4"}
+			},
+		},
 		{
 			desc: "recursors any",
 			args: []string{
@@ -1719,6 +2095,123 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`dns_config = { a_record_limit = -1 }`},
 			err:  "dns_config.a_record_limit cannot be -1. Must be greater than or equal to zero",
 		},
+		{
+			desc: "dns_config.answer_order invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "dns_config": { "answer_order": "alphabetical" } }`},
+			hcl:  []string{`dns_config = { answer_order = "alphabetical" }`},
+			err:  `dns_config.answer_order must be one of "random", "round-robin" or "weighted", got "alphabetical"`,
+		},
+		{
+			desc: "limits.rpc_max_conns invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "limits": { "rpc_max_conns": -1 } }`},
+			hcl:  []string{`limits = { rpc_max_conns = -1 }`},
+			err:  "limits.rpc_max_conns cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "limits.rpc_max_streams_per_conn invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "limits": { "rpc_max_streams_per_conn": -1 } }`},
+			hcl:  []string{`limits = { rpc_max_streams_per_conn = -1 }`},
+			err:  "limits.rpc_max_streams_per_conn cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "limits.rpc_accept_rate invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "limits": { "rpc_accept_rate": -1 } }`},
+			hcl:  []string{`limits = { rpc_accept_rate = -1 }`},
+			err:  "limits.rpc_accept_rate cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "limits.https_max_concurrent_handshakes invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "limits": { "https_max_concurrent_handshakes": -1 } }`},
+			hcl:  []string{`limits = { https_max_concurrent_handshakes = -1 }`},
+			err:  "limits.https_max_concurrent_handshakes cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "http_config.max_request_body_bytes.txn < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "max_request_body_bytes": { "txn": -1 } } }`},
+			hcl:  []string{`http_config = { max_request_body_bytes = { txn = -1 } }`},
+			err:  "http_config.max_request_body_bytes.txn cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "telemetry.otlp_endpoint invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "telemetry": { "otlp_endpoint": "collector:4318" } }`},
+			hcl:  []string{`telemetry = { otlp_endpoint = "collector:4318" }`},
+			err:  "telemetry.otlp_endpoint must be an http or https URL",
+		},
+		{
+			desc: "telemetry.otlp_traces_endpoint invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "telemetry": { "otlp_traces_endpoint": "/v1/traces" } }`},
+			hcl:  []string{`telemetry = { otlp_traces_endpoint = "/v1/traces" }`},
+			err:  "telemetry.otlp_traces_endpoint must be an http or https URL",
+		},
+		{
+			desc: "telemetry.prometheus_histogram_buckets not increasing",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "telemetry": { "prometheus_histogram_buckets": [10, 5] } }`},
+			hcl:  []string{`telemetry = { prometheus_histogram_buckets = [10, 5] }`},
+			err:  "telemetry.prometheus_histogram_buckets must be in increasing order",
+		},
+		{
+			desc: "telemetry.token_request_metrics_limit < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "telemetry": { "token_request_metrics_limit": -1 } }`},
+			hcl:  []string{`telemetry = { token_request_metrics_limit = -1 }`},
+			err:  "telemetry.token_request_metrics_limit cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "ui_config.metrics_proxy.base_url invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "ui_config": { "metrics_proxy": { "base_url": "prometheus:9090" } } }`},
+			hcl:  []string{`ui_config { metrics_proxy { base_url = "prometheus:9090" } }`},
+			err:  "ui_config.metrics_proxy.base_url must be an http or https URL",
+		},
+		{
+			desc: "ui_config.metrics_proxy.path_allowlist relative",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "ui_config": { "metrics_proxy": { "path_allowlist": ["api/v1/query"] } } }`},
+			hcl:  []string{`ui_config { metrics_proxy { path_allowlist = ["api/v1/query"] } }`},
+			err:  `ui_config.metrics_proxy.path_allowlist entry "api/v1/query" must be an absolute path`,
+		},
+		{
+			desc: "limits.rpc_query_limits.kv < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "limits": { "rpc_query_limits": { "kv": -1 } } }`},
+			hcl:  []string{`limits = { rpc_query_limits = { kv = -1 } }`},
+			err:  "limits.rpc_query_limits.kv cannot be -1. Must be greater than or equal to zero",
+		},
 		{
 			desc: "performance.raft_multiplier < 0",
 			args: []string{
@@ -1746,6 +2239,51 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`performance = { raft_multiplier = 20 }`},
 			err:  `performance.raft_multiplier cannot be 20. Must be between 1 and 10`,
 		},
+		{
+			desc: "performance.raft_election_multiplier > 10",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_election_multiplier": 11 } }`},
+			hcl:  []string{`performance = { raft_election_multiplier = 11 }`},
+			err:  `performance.raft_election_multiplier cannot be 11. Must be between 1 and 10`,
+		},
+		{
+			desc: "performance.raft_heartbeat_multiplier == 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_heartbeat_multiplier": 0 } }`},
+			hcl:  []string{`performance = { raft_heartbeat_multiplier = 0 }`},
+			err:  `performance.raft_heartbeat_multiplier cannot be 0. Must be between 1 and 10`,
+		},
+		{
+			desc: "raft election timeout less than heartbeat timeout",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_election_multiplier": 1, "raft_heartbeat_multiplier": 2 } }`},
+			hcl:  []string{`performance = { raft_election_multiplier = 1 raft_heartbeat_multiplier = 2 }`},
+			err:  `Raft election timeout 1s cannot be less than the heartbeat timeout 2s`,
+		},
+		{
+			desc: "raft leader lease timeout greater than heartbeat timeout",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_multiplier": 1, "raft_leader_lease_multiplier": 3 } }`},
+			hcl:  []string{`performance = { raft_multiplier = 1 raft_leader_lease_multiplier = 3 }`},
+			err:  `Raft leader lease timeout 1.5s cannot be greater than the heartbeat timeout 1s`,
+		},
+		{
+			desc: "performance.follower_reads invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "follower_reads": ["catalog", "acl"] } }`},
+			hcl:  []string{`performance = { follower_reads = ["catalog", "acl"] }`},
+			err:  `performance.follower_reads: invalid endpoint class "acl". Must be one of catalog, health or kv`,
+		},
 		{
 			desc: "node_name invalid",
 			args: []string{
@@ -1949,6 +2487,29 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			`},
 			err: "sidecar_service can't have a nested sidecar_service",
 		},
+		{
+			desc: "proxy and sidecar port ranges can't overlap",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{
+					"ports": {
+						"proxy_min_port": 21000,
+						"proxy_max_port": 21100,
+						"sidecar_min_port": 21100,
+						"sidecar_max_port": 21200
+					}
+				}`},
+			hcl: []string{`
+				ports {
+					proxy_min_port = 21000
+					proxy_max_port = 21100
+					sidecar_min_port = 21100
+					sidecar_max_port = 21200
+				}
+			`},
+			err: "proxy_min_port to proxy_max_port must not overlap with sidecar_min_port to sidecar_max_port.",
+		},
 		{
 			desc: "sidecar_service can't have managed proxy",
 			args: []string{
@@ -2100,6 +2661,32 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "plugin check",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "check_plugins": { "ldap": "/usr/local/bin/consul-check-ldap" },
+				   "check": { "name": "a", "plugin": "ldap", "plugin_args": { "base_dn": "dc=example", "ServiceID": "x" }, "interval": "10s" } }`,
+			},
+			hcl: []string{
+				`check_plugins { ldap = "/usr/local/bin/consul-check-ldap" }
+				 check = { name = "a" plugin = "ldap" plugin_args = { base_dn = "dc=example" ServiceID = "x" } interval = "10s" }`,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.CheckPlugins = map[string]string{"ldap": "/usr/local/bin/consul-check-ldap"}
+				rt.Checks = []*structs.CheckDefinition{
+					&structs.CheckDefinition{
+						Name:       "a",
+						Plugin:     "ldap",
+						PluginArgs: map[string]string{"base_dn": "dc=example", "ServiceID": "x"},
+						Interval:   10 * time.Second,
+					},
+				}
+				rt.DataDir = dataDir
+			},
+		},
 		{
 			desc: "alias check with no node",
 			args: []string{
@@ -2145,6 +2732,129 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "service with tagged addresses",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "port": 80, "tagged_addresses": { "lan": { "address": "10.0.0.1", "port": 8080 }, "wan": { "address": "198.18.0.1" } } } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" port = 80 tagged_addresses = { lan = { address = "10.0.0.1" port = 8080 } wan = { address = "198.18.0.1" } } }`,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.Services = []*structs.ServiceDefinition{
+					&structs.ServiceDefinition{
+						Name: "a",
+						Port: 80,
+						TaggedAddresses: map[string]structs.ServiceAddress{
+							"lan": {Address: "10.0.0.1", Port: 8080},
+							"wan": {Address: "198.18.0.1"},
+						},
+						Weights: &structs.Weights{
+							Passing: 1,
+							Warning: 1,
+						},
+					},
+				}
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "service with invalid tagged address",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "port": 80, "tagged_addresses": { "wan": { "address": "0.0.0.0", "port": 80 } } } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" port = 80 tagged_addresses = { wan = { address = "0.0.0.0" port = 80 } } }`,
+			},
+			err: `service "a": 1 error(s) occurred:
+
+* Invalid address "0.0.0.0" of tagged address "wan"`,
+		},
+		{
+			desc: "service and check token from file",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "port": 80, "token_file": "` + filepath.Join(dataDir, "token") + `" } }`,
+				`{ "check": { "name": "b", "ttl": "10s", "token_file": "` + filepath.Join(dataDir, "token") + `" } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" port = 80 token_file = "` + filepath.Join(dataDir, "token") + `" }`,
+				`check = { name = "b" ttl = "10s" token_file = "` + filepath.Join(dataDir, "token") + `" }`,
+			},
+			pre: func() {
+				writeFile(filepath.Join(dataDir, "token"), []byte("b6d2a6f0-b2c1-4d0e-a5a0-2a0c38f2c5a3\n"))
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.Services = []*structs.ServiceDefinition{
+					&structs.ServiceDefinition{Name: "a", Port: 80, Token: "b6d2a6f0-b2c1-4d0e-a5a0-2a0c38f2c5a3", Weights: &structs.Weights{
+						Passing: 1,
+						Warning: 1,
+					}},
+				}
+				rt.Checks = []*structs.CheckDefinition{
+					&structs.CheckDefinition{Name: "b", TTL: 10 * time.Second, Token: "b6d2a6f0-b2c1-4d0e-a5a0-2a0c38f2c5a3"},
+				}
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "service token from env",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "service": { "name": "a", "port": 80, "token_env": "CONSUL_TEST_SERVICE_TOKEN" } }`},
+			hcl:  []string{`service = { name = "a" port = 80 token_env = "CONSUL_TEST_SERVICE_TOKEN" }`},
+			pre: func() {
+				os.Setenv("CONSUL_TEST_SERVICE_TOKEN", "4a3d2d5e-0a0c-4d2e-9b1f-7c0d1b8d9e6f")
+			},
+			post: func() {
+				os.Unsetenv("CONSUL_TEST_SERVICE_TOKEN")
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.Services = []*structs.ServiceDefinition{
+					&structs.ServiceDefinition{Name: "a", Port: 80, Token: "4a3d2d5e-0a0c-4d2e-9b1f-7c0d1b8d9e6f", Weights: &structs.Weights{
+						Passing: 1,
+						Warning: 1,
+					}},
+				}
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "service token env not set",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "service": { "name": "a", "port": 80, "token_env": "CONSUL_TEST_MISSING_TOKEN" } }`},
+			hcl:  []string{`service = { name = "a" port = 80 token_env = "CONSUL_TEST_MISSING_TOKEN" }`},
+			err:  `service[a]: token_env "CONSUL_TEST_MISSING_TOKEN" is not set`,
+		},
+		{
+			desc: "service token file missing",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "service": { "name": "a", "port": 80, "token_file": "` + filepath.Join(dataDir, "missing") + `" } }`},
+			hcl:  []string{`service = { name = "a" port = 80 token_file = "` + filepath.Join(dataDir, "missing") + `" }`},
+			err:  `service[a]: failed to read token_file`,
+		},
+		{
+			desc: "check with token and token_file",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "check": { "id": "b", "name": "b", "ttl": "10s", "token": "foo", "token_file": "/tmp/token" } }`},
+			hcl:  []string{`check = { id = "b" name = "b" ttl = "10s" token = "foo" token_file = "/tmp/token" }`},
+			err:  `check[b]: only one of token, token_file and token_env can be set`,
+		},
 		{
 			desc: "service with wrong meta: too long key",
 			args: []string{
@@ -2863,14 +3573,13 @@ func testConfig(t *testing.T, tests []configTest, dataDir string) {
 // To aid populating the fields the following bash functions can be used
 // to generate random strings and ints:
 //
-//   random-int() { echo $RANDOM }
-//   random-string() { base64 /dev/urandom | tr -d '/+' | fold -w ${1:-32} | head -n 1 }
+//	random-int() { echo $RANDOM }
+//	random-string() { base64 /dev/urandom | tr -d '/+' | fold -w ${1:-32} | head -n 1 }
 //
 // To generate a random string of length 8 run the following command in
 // a terminal:
 //
-//   random-string 8
-//
+//	random-string 8
 func TestFullConfig(t *testing.T) {
 	dataDir := testutil.TempDir(t, "consul")
 	defer os.RemoveAll(dataDir)
@@ -2899,6 +3608,7 @@ func TestFullConfig(t *testing.T) {
 				"down_policy" : "03eb2aee",
 				"default_policy" : "72c2e7a0",
 				"enable_key_list_policy": false,
+				"allow_anonymous_write": true,
 				"enable_token_persistence": true,
 				"policy_ttl": "1123s",
 				"token_ttl": "3321s",
@@ -2908,7 +3618,8 @@ func TestFullConfig(t *testing.T) {
 					"agent_master" : "64fd0e08",
 					"replication" : "5795983a",
 					"agent" : "bed2377c",
-					"default" : "418fdff1"
+					"default" : "418fdff1",
+					"dns" : "1d4b5f8c"
 				}
 			},
 			"addresses": {
@@ -2919,10 +3630,13 @@ func TestFullConfig(t *testing.T) {
 			},
 			"advertise_addr": "17.99.29.16",
 			"advertise_addr_wan": "78.63.37.19",
+			"advertise_addr_wan_map": { "dc2": "48.39.57.11" },
 			"autopilot": {
 				"cleanup_dead_servers": true,
+				"dead_server_quarantine_time": "6215s",
 				"disable_upgrade_migration": true,
 				"last_contact_threshold": "12705s",
+				"max_dead_server_removals": 3,
 				"max_trailing_logs": 17849,
 				"redundancy_zone_tag": "3IsufDJf",
 				"server_stabilization_time": "23057s",
@@ -2930,10 +3644,14 @@ func TestFullConfig(t *testing.T) {
 			},
 			"bind_addr": "16.99.34.17",
 			"bootstrap": true,
+			"bootstrap_cluster_id": "ThsqbcQn",
 			"bootstrap_expect": 53,
 			"ca_file": "erA7T0PM",
 			"ca_path": "mQEN1Mfp",
+			"cert_bundle_file": "Rn5qWt3E",
 			"cert_file": "7s4QAzDk",
+			"crl_file": "Wv8RcT2k",
+			"crl_path": "jD3fQx7N",
 			"check": {
 				"id": "fZaCAXww",
 				"name": "OOM2eo0f",
@@ -3005,6 +3723,12 @@ func TestFullConfig(t *testing.T) {
 					"deregister_critical_service_after": "2366s"
 				}
 			],
+			"check_flap_hold_down": "8431s",
+			"check_output_max_size": 12983,
+			"check_output_truncation": "head",
+			"check_plugins": {
+				"ldap": "/usr/local/bin/consul-check-ldap"
+			},
 			"check_update_interval": "16507s",
 			"client_addr": "93.83.18.19",
 			"connect": {
@@ -3013,7 +3737,9 @@ func TestFullConfig(t *testing.T) {
 					"rotation_period": "90h",
 					"leaf_cert_ttl": "1h",
 					"csr_max_per_second": 100,
-					"csr_max_concurrent": 2
+					"csr_max_concurrent": 2,
+					"root_rollout_timeout": "1m",
+					"root_rotation_min_interval": "24h"
 				},
 				"enabled": true,
 				"proxy_defaults": {
@@ -3044,6 +3770,7 @@ func TestFullConfig(t *testing.T) {
 				"probe_timeout"  : "104ms"
 			},
 			"data_dir": "` + dataDir + `",
+			"data_dir_encryption_key_file": "hB4bJvVc",
 			"datacenter": "rzo029wg",
 			"disable_anonymous_signature": true,
 			"disable_coordinates": true,
@@ -3054,13 +3781,17 @@ func TestFullConfig(t *testing.T) {
 			"disable_update_check": true,
 			"discard_check_output": true,
 			"discovery_max_stale": "5s",
+			"docker_check_runtime": "cri",
+			"docker_check_runtime_endpoint": "unix:///var/run/Wq7GhZ3b.sock",
 			"domain": "7W1xXSqd",
 			"dns_config": {
 				"allow_stale": true,
 				"a_record_limit": 29907,
+				"answer_order": "round-robin",
 				"disable_compression": true,
 				"enable_truncate": true,
 				"max_stale": "29685s",
+				"negative_ttl": "31s",
 				"node_ttl": "7084s",
 				"only_passing": true,
 				"recursor_timeout": "4427s",
@@ -3076,6 +3807,7 @@ func TestFullConfig(t *testing.T) {
 			"enable_debug": true,
 			"enable_script_checks": true,
 			"enable_local_script_checks": true,
+			"enable_eventlog": true,
 			"enable_syslog": true,
 			"encrypt": "A4wELWqH",
 			"encrypt_verify_incoming": true,
@@ -3086,13 +3818,32 @@ func TestFullConfig(t *testing.T) {
 				"response_headers": {
 					"M6TKa9NP": "xjuxjOzQ",
 					"JRCrHZed": "rl0mTx81"
+				},
+				"max_header_bytes": 7166,
+				"max_request_body_bytes": {
+					"default": 24512,
+					"txn": 6331
 				}
 			},
 			"key_file": "IEkkwgIA",
+			"key_passphrase": "p2HvWz8R",
+			"key_passphrase_file": "Xk4dLq7N",
 			"leave_on_terminate": true,
 			"limits": {
 				"rpc_rate": 12029.43,
-				"rpc_max_burst": 44848
+				"rpc_max_burst": 44848,
+				"rpc_max_conns": 3012,
+				"rpc_max_streams_per_conn": 431,
+				"rpc_accept_backpressure": true,
+				"rpc_accept_rate": 713.5,
+				"rpc_max_concurrent_handshakes": 58,
+				"https_accept_rate": 311.25,
+				"https_max_concurrent_handshakes": 92,
+				"rpc_query_limits": {
+					"health": 193,
+					"txn": 27
+				},
+				"rpc_query_queue_timeout": "2431s"
 			},
 			"log_level": "k1zo9Spt",
 			"node_id": "AsUIlw99",
@@ -3103,8 +3854,12 @@ func TestFullConfig(t *testing.T) {
 			"node_name": "otlLxGaI",
 			"non_voting_server": true,
 			"performance": {
+				"follower_reads": ["catalog", "kv"],
 				"leave_drain_time": "8265s",
 				"raft_multiplier": 5,
+				"raft_election_multiplier": 7,
+				"raft_heartbeat_multiplier": 6,
+				"raft_leader_lease_multiplier": 4,
 				"rpc_hold_timeout": "15707s"
 			},
 			"pid_file": "43xN80Km",
@@ -3134,6 +3889,14 @@ func TestFullConfig(t *testing.T) {
 			"retry_join_wan": [ "PFsR02Ye", "rJdQIhER" ],
 			"retry_max": 913,
 			"retry_max_wan": 23160,
+			"retry_max_interval": "9521s",
+			"retry_max_interval_wan": "31202s",
+			"retry_splay": "417s",
+			"retry_splay_wan": "2384s",
+			"script_check_dir": "/var/lib/m2xWsy5N",
+			"script_check_env_whitelist": [ "PATH", "yQ5BvK6c_*" ],
+			"script_check_kill_timeout": "3526s",
+			"script_check_user": "cW3kUvGB",
 			"segment": "BC2NhTDi",
 			"segments": [
 				{
@@ -3148,13 +3911,17 @@ func TestFullConfig(t *testing.T) {
 					"bind": "37.58.38.19",
 					"port": 39292,
 					"rpc_listener": true,
-					"advertise": "83.58.26.27"
+					"advertise": "83.58.26.27",
+					"ca_file": "6ZmUsfeH",
+					"cert_file": "w3Z8nC4q",
+					"key_file": "R8dG2TtH"
 				}
 			],
 			"serf_lan": "99.43.63.15",
 			"serf_wan": "67.88.33.19",
 			"server": true,
 			"server_name": "Oerr9n1G",
+			"service_drain_time": "263s",
 			"service": {
 				"id": "dLOXpSCI",
 				"name": "o1ynPkp0",
@@ -3367,12 +4134,21 @@ func TestFullConfig(t *testing.T) {
 								"local_bind_address": "127.24.88.0",
 								"local_bind_port": 11884
 							}
-						]
+						],
+						"transparent_proxy": {
+							"outbound_listener_port": 15991,
+							"exclude_inbound_ports": [ 8954 ],
+							"exclude_outbound_ports": [ 53, 3346 ],
+							"exclude_outbound_cidrs": [ "10.219.0.0/16" ],
+							"exclude_uids": [ "4092" ]
+						}
 					}
 				}
 			],
 			"session_ttl_min": "26627s",
+			"skip_cluster_id_check": true,
 			"skip_leave_on_interrupt": true,
+			"sni_certificates": [{ "cert_file": "Vp3tNm8Q", "key_file": "hT5wLk2R" }],
 			"start_join": [ "LR3hGDoG", "MwVpZ4Up" ],
 			"start_join_wan": [ "EbFSc3nA", "kwXTh623" ],
 			"syslog_facility": "hHv79Uia",
@@ -3400,16 +4176,39 @@ func TestFullConfig(t *testing.T) {
 				"filter_default": true,
 				"prefix_filter": [ "+oJotS8XJ","-cazlEhGn" ],
 				"metrics_prefix": "ftO6DySn",
+				"otlp_endpoint": "https://ZOfDXRz3:4318/v1/metrics",
+				"otlp_headers": { "Authorization": "Bearer Pd3QV9Ap" },
+				"otlp_interval": "23s",
+				"otlp_traces_endpoint": "https://ZOfDXRz3:4318/v1/traces",
+				"otlp_ca_file": "Jn8JU1Rk",
+				"otlp_cert_file": "hBsqVt7G",
+				"otlp_key_file": "yhTq3xLE",
 				"prometheus_retention_time": "15s",
+				"prometheus_histogram_buckets": [1.5, 25, 400],
+				"prometheus_histogram_prefixes": ["consul.rpc", "consul.kvs"],
 				"statsd_address": "drce87cy",
-				"statsite_address": "HpFwKB8R"
+				"statsite_address": "HpFwKB8R",
+				"token_request_metrics_limit": 17
 			},
 			"tls_cipher_suites": "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"tls_datacenter_server_names": { "dc9": "consul.dc9.example.com" },
+			"tls_max_version": "tls13",
 			"tls_min_version": "pAOWafkR",
+			"tls_ocsp_refresh_interval": "47m",
+			"tls_ocsp_responder_url": "http://ocsp.example.com/h8KzRf2c",
+			"tls_ocsp_stapling": true,
 			"tls_prefer_server_cipher_suites": true,
+			"tls_watch_files": true,
 			"translate_wan_addrs": true,
 			"ui": true,
 			"ui_dir": "11IFzAUn",
+			"ui_config": {
+				"metrics_proxy": {
+					"base_url": "http://T5QKx6Rg:9090",
+					"add_headers": { "Authorization": "Bearer x2Wq0JkO" },
+					"path_allowlist": ["/api/v1/query", "/api/v1/series"]
+				}
+			},
 			"unix_sockets": {
 				"group": "8pFodrV8",
 				"mode": "E8sAwOv4",
@@ -3451,6 +4250,7 @@ func TestFullConfig(t *testing.T) {
 				down_policy = "03eb2aee"
 				default_policy = "72c2e7a0"
 				enable_key_list_policy = false
+				allow_anonymous_write = true
 				enable_token_persistence = true
 				policy_ttl = "1123s"
 				token_ttl = "3321s"
@@ -3460,7 +4260,8 @@ func TestFullConfig(t *testing.T) {
 					agent_master = "64fd0e08",
 					replication = "5795983a",
 					agent = "bed2377c",
-					default = "418fdff1"
+					default = "418fdff1",
+					dns = "1d4b5f8c"
 				}
 			}
 			addresses = {
@@ -3471,10 +4272,13 @@ func TestFullConfig(t *testing.T) {
 			}
 			advertise_addr = "17.99.29.16"
 			advertise_addr_wan = "78.63.37.19"
+			advertise_addr_wan_map = { dc2 = "48.39.57.11" }
 			autopilot = {
 				cleanup_dead_servers = true
+				dead_server_quarantine_time = "6215s"
 				disable_upgrade_migration = true
 				last_contact_threshold = "12705s"
+				max_dead_server_removals = 3
 				max_trailing_logs = 17849
 				redundancy_zone_tag = "3IsufDJf"
 				server_stabilization_time = "23057s"
@@ -3482,10 +4286,14 @@ func TestFullConfig(t *testing.T) {
 			}
 			bind_addr = "16.99.34.17"
 			bootstrap = true
+			bootstrap_cluster_id = "ThsqbcQn"
 			bootstrap_expect = 53
 			ca_file = "erA7T0PM"
 			ca_path = "mQEN1Mfp"
+			cert_bundle_file = "Rn5qWt3E"
 			cert_file = "7s4QAzDk"
+			crl_file = "Wv8RcT2k"
+			crl_path = "jD3fQx7N"
 			check = {
 				id = "fZaCAXww"
 				name = "OOM2eo0f"
@@ -3557,6 +4365,12 @@ func TestFullConfig(t *testing.T) {
 					deregister_critical_service_after = "2366s"
 				}
 			]
+			check_flap_hold_down = "8431s"
+			check_output_max_size = 12983
+			check_output_truncation = "head"
+			check_plugins {
+				ldap = "/usr/local/bin/consul-check-ldap"
+			}
 			check_update_interval = "16507s"
 			client_addr = "93.83.18.19"
 			connect {
@@ -3568,6 +4382,8 @@ func TestFullConfig(t *testing.T) {
 					# assert against the same thing
 					csr_max_per_second = 100.0
 					csr_max_concurrent = 2.0
+					root_rollout_timeout = "1m"
+					root_rotation_min_interval = "24h"
 				}
 				enabled = true
 				proxy_defaults {
@@ -3600,6 +4416,7 @@ func TestFullConfig(t *testing.T) {
 				probe_timeout   = "104ms"
 			}
 			data_dir = "` + dataDir + `"
+			data_dir_encryption_key_file = "hB4bJvVc"
 			datacenter = "rzo029wg"
 			disable_anonymous_signature = true
 			disable_coordinates = true
@@ -3610,13 +4427,17 @@ func TestFullConfig(t *testing.T) {
 			disable_update_check = true
 			discard_check_output = true
 			discovery_max_stale = "5s"
+			docker_check_runtime = "cri"
+			docker_check_runtime_endpoint = "unix:///var/run/Wq7GhZ3b.sock"
 			domain = "7W1xXSqd"
 			dns_config {
 				allow_stale = true
 				a_record_limit = 29907
+				answer_order = "round-robin"
 				disable_compression = true
 				enable_truncate = true
 				max_stale = "29685s"
+				negative_ttl = "31s"
 				node_ttl = "7084s"
 				only_passing = true
 				recursor_timeout = "4427s"
@@ -3632,6 +4453,7 @@ func TestFullConfig(t *testing.T) {
 			enable_debug = true
 			enable_script_checks = true
 			enable_local_script_checks = true
+			enable_eventlog = true
 			enable_syslog = true
 			encrypt = "A4wELWqH"
 			encrypt_verify_incoming = true
@@ -3643,12 +4465,31 @@ func TestFullConfig(t *testing.T) {
 					"M6TKa9NP" = "xjuxjOzQ"
 					"JRCrHZed" = "rl0mTx81"
 				}
+				max_header_bytes = 7166
+				max_request_body_bytes = {
+					default = 24512
+					txn = 6331
+				}
 			}
 			key_file = "IEkkwgIA"
+			key_passphrase = "p2HvWz8R"
+			key_passphrase_file = "Xk4dLq7N"
 			leave_on_terminate = true
 			limits {
 				rpc_rate = 12029.43
 				rpc_max_burst = 44848
+				rpc_max_conns = 3012
+				rpc_max_streams_per_conn = 431
+				rpc_accept_backpressure = true
+				rpc_accept_rate = 713.5
+				rpc_max_concurrent_handshakes = 58
+				https_accept_rate = 311.25
+				https_max_concurrent_handshakes = 92
+				rpc_query_limits {
+					health = 193
+					txn = 27
+				}
+				rpc_query_queue_timeout = "2431s"
 			}
 			log_level = "k1zo9Spt"
 			node_id = "AsUIlw99"
@@ -3659,8 +4500,12 @@ func TestFullConfig(t *testing.T) {
 			node_name = "otlLxGaI"
 			non_voting_server = true
 			performance {
+				follower_reads = ["catalog", "kv"]
 				leave_drain_time = "8265s"
 				raft_multiplier = 5
+				raft_election_multiplier = 7
+				raft_heartbeat_multiplier = 6
+				raft_leader_lease_multiplier = 4
 				rpc_hold_timeout = "15707s"
 			}
 			pid_file = "43xN80Km"
@@ -3690,6 +4535,14 @@ func TestFullConfig(t *testing.T) {
 			retry_join_wan = [ "PFsR02Ye", "rJdQIhER" ]
 			retry_max = 913
 			retry_max_wan = 23160
+			retry_max_interval = "9521s"
+			retry_max_interval_wan = "31202s"
+			retry_splay = "417s"
+			retry_splay_wan = "2384s"
+			script_check_dir = "/var/lib/m2xWsy5N"
+			script_check_env_whitelist = [ "PATH", "yQ5BvK6c_*" ]
+			script_check_kill_timeout = "3526s"
+			script_check_user = "cW3kUvGB"
 			segment = "BC2NhTDi"
 			segments = [
 				{
@@ -3705,12 +4558,16 @@ func TestFullConfig(t *testing.T) {
 					port = 39292
 					rpc_listener = true
 					advertise = "83.58.26.27"
+					ca_file = "6ZmUsfeH"
+					cert_file = "w3Z8nC4q"
+					key_file = "R8dG2TtH"
 				}
 			]
 			serf_lan = "99.43.63.15"
 			serf_wan = "67.88.33.19"
 			server = true
 			server_name = "Oerr9n1G"
+			service_drain_time = "263s"
 			service = {
 				id = "dLOXpSCI"
 				name = "o1ynPkp0"
@@ -3924,11 +4781,20 @@ func TestFullConfig(t *testing.T) {
 								local_bind_address = "127.24.88.0"
 							},
 						]
+						transparent_proxy {
+							outbound_listener_port = 15991
+							exclude_inbound_ports = [ 8954 ]
+							exclude_outbound_ports = [ 53, 3346 ]
+							exclude_outbound_cidrs = [ "10.219.0.0/16" ]
+							exclude_uids = [ "4092" ]
+						}
 					}
 				}
 			]
 			session_ttl_min = "26627s"
+			skip_cluster_id_check = true
 			skip_leave_on_interrupt = true
+			sni_certificates = [{ cert_file = "Vp3tNm8Q" key_file = "hT5wLk2R" }]
 			start_join = [ "LR3hGDoG", "MwVpZ4Up" ]
 			start_join_wan = [ "EbFSc3nA", "kwXTh623" ]
 			syslog_facility = "hHv79Uia"
@@ -3956,16 +4822,39 @@ func TestFullConfig(t *testing.T) {
 				filter_default = true
 				prefix_filter = [ "+oJotS8XJ","-cazlEhGn" ]
 				metrics_prefix = "ftO6DySn"
+				otlp_endpoint = "https://ZOfDXRz3:4318/v1/metrics"
+				otlp_headers = { Authorization = "Bearer Pd3QV9Ap" }
+				otlp_interval = "23s"
+				otlp_traces_endpoint = "https://ZOfDXRz3:4318/v1/traces"
+				otlp_ca_file = "Jn8JU1Rk"
+				otlp_cert_file = "hBsqVt7G"
+				otlp_key_file = "yhTq3xLE"
 				prometheus_retention_time = "15s"
+				prometheus_histogram_buckets = [1.5, 25, 400]
+				prometheus_histogram_prefixes = ["consul.rpc", "consul.kvs"]
 				statsd_address = "drce87cy"
 				statsite_address = "HpFwKB8R"
+				token_request_metrics_limit = 17
 			}
 			tls_cipher_suites = "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"
+			tls_datacenter_server_names = { dc9 = "consul.dc9.example.com" }
+			tls_max_version = "tls13"
 			tls_min_version = "pAOWafkR"
+			tls_ocsp_refresh_interval = "47m"
+			tls_ocsp_responder_url = "http://ocsp.example.com/h8KzRf2c"
+			tls_ocsp_stapling = true
 			tls_prefer_server_cipher_suites = true
+			tls_watch_files = true
 			translate_wan_addrs = true
 			ui = true
 			ui_dir = "11IFzAUn"
+			ui_config {
+				metrics_proxy {
+					base_url = "http://T5QKx6Rg:9090"
+					add_headers = { Authorization = "Bearer x2Wq0JkO" }
+					path_allowlist = ["/api/v1/query", "/api/v1/series"]
+				}
+			}
 			unix_sockets = {
 				group = "8pFodrV8"
 				mode = "E8sAwOv4"
@@ -4095,9 +4984,9 @@ func TestFullConfig(t *testing.T) {
 		ConsulCoordinateUpdateBatchSize:  9244,
 		ConsulCoordinateUpdateMaxBatches: 15164,
 		ConsulCoordinateUpdatePeriod:     25093 * time.Second,
-		ConsulRaftElectionTimeout:        5 * 31947 * time.Second,
-		ConsulRaftHeartbeatTimeout:       5 * 25699 * time.Second,
-		ConsulRaftLeaderLeaseTimeout:     5 * 15351 * time.Second,
+		ConsulRaftElectionTimeout:        7 * 31947 * time.Second,
+		ConsulRaftHeartbeatTimeout:       6 * 25699 * time.Second,
+		ConsulRaftLeaderLeaseTimeout:     4 * 15351 * time.Second,
 		GossipLANGossipInterval:          25252 * time.Second,
 		GossipLANGossipNodes:             6,
 		GossipLANProbeInterval:           101 * time.Millisecond,
@@ -4114,36 +5003,45 @@ func TestFullConfig(t *testing.T) {
 
 		// user configurable values
 
-		ACLAgentMasterToken:              "64fd0e08",
-		ACLAgentToken:                    "bed2377c",
-		ACLsEnabled:                      true,
-		ACLDatacenter:                    "ejtmd43d",
-		ACLDefaultPolicy:                 "72c2e7a0",
-		ACLDownPolicy:                    "03eb2aee",
-		ACLEnforceVersion8:               true,
-		ACLEnableKeyListPolicy:           false,
-		ACLEnableTokenPersistence:        true,
-		ACLMasterToken:                   "8a19ac27",
-		ACLReplicationToken:              "5795983a",
-		ACLTokenTTL:                      3321 * time.Second,
-		ACLPolicyTTL:                     1123 * time.Second,
-		ACLToken:                         "418fdff1",
-		ACLTokenReplication:              true,
-		AdvertiseAddrLAN:                 ipAddr("17.99.29.16"),
-		AdvertiseAddrWAN:                 ipAddr("78.63.37.19"),
-		AutopilotCleanupDeadServers:      true,
-		AutopilotDisableUpgradeMigration: true,
-		AutopilotLastContactThreshold:    12705 * time.Second,
-		AutopilotMaxTrailingLogs:         17849,
-		AutopilotRedundancyZoneTag:       "3IsufDJf",
-		AutopilotServerStabilizationTime: 23057 * time.Second,
-		AutopilotUpgradeVersionTag:       "W9pDwFAL",
-		BindAddr:                         ipAddr("16.99.34.17"),
-		Bootstrap:                        true,
-		BootstrapExpect:                  53,
-		CAFile:                           "erA7T0PM",
-		CAPath:                           "mQEN1Mfp",
-		CertFile:                         "7s4QAzDk",
+		ACLAllowAnonymousWrite:            true,
+		ACLAgentMasterToken:               "64fd0e08",
+		ACLAgentToken:                     "bed2377c",
+		ACLsEnabled:                       true,
+		ACLDatacenter:                     "ejtmd43d",
+		ACLDNSToken:                       "1d4b5f8c",
+		ACLDefaultPolicy:                  "72c2e7a0",
+		ACLDownPolicy:                     "03eb2aee",
+		ACLEnforceVersion8:                true,
+		ACLEnableKeyListPolicy:            false,
+		ACLEnableTokenPersistence:         true,
+		ACLMasterToken:                    "8a19ac27",
+		ACLReplicationToken:               "5795983a",
+		ACLTokenTTL:                       3321 * time.Second,
+		ACLPolicyTTL:                      1123 * time.Second,
+		ACLToken:                          "418fdff1",
+		ACLTokenReplication:               true,
+		AdvertiseAddrLAN:                  ipAddr("17.99.29.16"),
+		AdvertiseAddrWAN:                  ipAddr("78.63.37.19"),
+		AdvertiseAddrWANMap:               map[string]string{"dc2": "48.39.57.11"},
+		AutopilotCleanupDeadServers:       true,
+		AutopilotDeadServerQuarantineTime: 6215 * time.Second,
+		AutopilotDisableUpgradeMigration:  true,
+		AutopilotLastContactThreshold:     12705 * time.Second,
+		AutopilotMaxDeadServerRemovals:    3,
+		AutopilotMaxTrailingLogs:          17849,
+		AutopilotRedundancyZoneTag:        "3IsufDJf",
+		AutopilotServerStabilizationTime:  23057 * time.Second,
+		AutopilotUpgradeVersionTag:        "W9pDwFAL",
+		BindAddr:                          ipAddr("16.99.34.17"),
+		Bootstrap:                         true,
+		BootstrapClusterID:                "ThsqbcQn",
+		BootstrapExpect:                   53,
+		CAFile:                            "erA7T0PM",
+		CAPath:                            "mQEN1Mfp",
+		CRLFile:                           "Wv8RcT2k",
+		CRLPath:                           "jD3fQx7N",
+		CertBundleFile:                    "Rn5qWt3E",
+		CertFile:                          "7s4QAzDk",
 		Checks: []*structs.CheckDefinition{
 			&structs.CheckDefinition{
 				ID:         "uAjE6m9Z",
@@ -4215,6 +5113,10 @@ func TestFullConfig(t *testing.T) {
 				DeregisterCriticalServiceAfter: 13209 * time.Second,
 			},
 		},
+		CheckFlapHoldDown:       8431 * time.Second,
+		CheckOutputMaxSize:      12983,
+		CheckOutputTruncation:   "head",
+		CheckPlugins:            map[string]string{"ldap": "/usr/local/bin/consul-check-ldap"},
 		CheckUpdateInterval:     16507 * time.Second,
 		ClientAddrs:             []*net.IPAddr{ipAddr("93.83.18.19")},
 		ConnectEnabled:          true,
@@ -4224,10 +5126,12 @@ func TestFullConfig(t *testing.T) {
 		ConnectSidecarMaxPort:   9999,
 		ConnectCAProvider:       "consul",
 		ConnectCAConfig: map[string]interface{}{
-			"RotationPeriod":   "90h",
-			"LeafCertTTL":      "1h",
-			"CSRMaxPerSecond":  float64(100),
-			"CSRMaxConcurrent": float64(2),
+			"RotationPeriod":          "90h",
+			"LeafCertTTL":             "1h",
+			"CSRMaxPerSecond":         float64(100),
+			"CSRMaxConcurrent":        float64(2),
+			"RootRolloutTimeout":      "1m",
+			"RootRotationMinInterval": "24h",
 		},
 		ConnectProxyAllowManagedRoot:            false,
 		ConnectProxyAllowManagedAPIRegistration: false,
@@ -4242,10 +5146,12 @@ func TestFullConfig(t *testing.T) {
 		DNSAddrs:                         []net.Addr{tcpAddr("93.95.95.81:7001"), udpAddr("93.95.95.81:7001")},
 		DNSARecordLimit:                  29907,
 		DNSAllowStale:                    true,
+		DNSAnswerOrder:                   "round-robin",
 		DNSDisableCompression:            true,
 		DNSDomain:                        "7W1xXSqd",
 		DNSEnableTruncate:                true,
 		DNSMaxStale:                      29685 * time.Second,
+		DNSNegativeTTL:                   31 * time.Second,
 		DNSNodeTTL:                       7084 * time.Second,
 		DNSOnlyPassing:                   true,
 		DNSPort:                          7001,
@@ -4258,6 +5164,7 @@ func TestFullConfig(t *testing.T) {
 		DNSUseCache:                      true,
 		DNSCacheMaxAge:                   5 * time.Minute,
 		DataDir:                          dataDir,
+		DataDirEncryptionKeyFile:         "hB4bJvVc",
 		Datacenter:                       "rzo029wg",
 		DevMode:                          true,
 		DisableAnonymousSignature:        true,
@@ -4269,15 +5176,19 @@ func TestFullConfig(t *testing.T) {
 		DisableUpdateCheck:               true,
 		DiscardCheckOutput:               true,
 		DiscoveryMaxStale:                5 * time.Second,
+		DockerCheckRuntime:               "cri",
+		DockerCheckRuntimeEndpoint:       "unix:///var/run/Wq7GhZ3b.sock",
 		EnableAgentTLSForChecks:          true,
 		EnableDebug:                      true,
 		EnableRemoteScriptChecks:         true,
 		EnableLocalScriptChecks:          true,
+		EnableEventLog:                   true,
 		EnableSyslog:                     true,
 		EnableUI:                         true,
 		EncryptKey:                       "A4wELWqH",
 		EncryptVerifyIncoming:            true,
 		EncryptVerifyOutgoing:            true,
+		FollowerReads:                    []string{"catalog", "kv"},
 		GRPCPort:                         4881,
 		GRPCAddrs:                        []net.Addr{tcpAddr("32.31.61.91:4881")},
 		HTTPAddrs:                        []net.Addr{tcpAddr("83.39.91.39:7999")},
@@ -4285,9 +5196,13 @@ func TestFullConfig(t *testing.T) {
 		AllowWriteHTTPFrom:               []*net.IPNet{cidr("127.0.0.0/8"), cidr("22.33.44.55/32"), cidr("0.0.0.0/0")},
 		HTTPPort:                         7999,
 		HTTPResponseHeaders:              map[string]string{"M6TKa9NP": "xjuxjOzQ", "JRCrHZed": "rl0mTx81"},
+		HTTPMaxHeaderBytes:               7166,
+		HTTPMaxRequestBodyBytes:          map[string]int{"default": 24512, "kv": 524288, "txn": 6331},
 		HTTPSAddrs:                       []net.Addr{tcpAddr("95.17.17.19:15127")},
 		HTTPSPort:                        15127,
 		KeyFile:                          "IEkkwgIA",
+		KeyPassphrase:                    "p2HvWz8R",
+		KeyPassphraseFile:                "Xk4dLq7N",
 		LeaveDrainTime:                   8265 * time.Second,
 		LeaveOnTerm:                      true,
 		LogLevel:                         "k1zo9Spt",
@@ -4303,6 +5218,15 @@ func TestFullConfig(t *testing.T) {
 		RPCProtocol:                      30793,
 		RPCRateLimit:                     12029.43,
 		RPCMaxBurst:                      44848,
+		RPCMaxConns:                      3012,
+		RPCMaxStreamsPerConn:             431,
+		RPCAcceptBackpressure:            true,
+		RPCAcceptRate:                    713.5,
+		RPCMaxConcurrentHandshakes:       58,
+		HTTPSAcceptRate:                  311.25,
+		HTTPSMaxConcurrentHandshakes:     92,
+		RPCQueryLimits:                   map[string]int{"health": 193, "txn": 27},
+		RPCQueryQueueTimeout:             2431 * time.Second,
 		RaftProtocol:                     19016,
 		RaftSnapshotThreshold:            16384,
 		RaftSnapshotInterval:             30 * time.Second,
@@ -4314,7 +5238,15 @@ func TestFullConfig(t *testing.T) {
 		RetryJoinLAN:                     []string{"pbsSFY7U", "l0qLtWij"},
 		RetryJoinMaxAttemptsLAN:          913,
 		RetryJoinMaxAttemptsWAN:          23160,
+		RetryJoinMaxIntervalLAN:          9521 * time.Second,
+		RetryJoinMaxIntervalWAN:          31202 * time.Second,
+		RetryJoinSplayLAN:                417 * time.Second,
+		RetryJoinSplayWAN:                2384 * time.Second,
 		RetryJoinWAN:                     []string{"PFsR02Ye", "rJdQIhER"},
+		ScriptCheckDir:                   "/var/lib/m2xWsy5N",
+		ScriptCheckEnvWhitelist:          []string{"PATH", "yQ5BvK6c_*"},
+		ScriptCheckKillTimeout:           3526 * time.Second,
+		ScriptCheckUser:                  "cW3kUvGB",
 		SegmentName:                      "BC2NhTDi",
 		Segments: []structs.NetworkSegment{
 			{
@@ -4328,13 +5260,17 @@ func TestFullConfig(t *testing.T) {
 				Bind:        tcpAddr("37.58.38.19:39292"),
 				Advertise:   tcpAddr("83.58.26.27:39292"),
 				RPCListener: true,
+				CAFile:      "6ZmUsfeH",
+				CertFile:    "w3Z8nC4q",
+				KeyFile:     "R8dG2TtH",
 			},
 		},
-		SerfPortLAN: 8301,
-		SerfPortWAN: 8302,
-		ServerMode:  true,
-		ServerName:  "Oerr9n1G",
-		ServerPort:  3757,
+		SerfPortLAN:      8301,
+		SerfPortWAN:      8302,
+		ServerMode:       true,
+		ServerName:       "Oerr9n1G",
+		ServerPort:       3757,
+		ServiceDrainTime: 263 * time.Second,
 		Services: []*structs.ServiceDefinition{
 			{
 				ID:      "wI1dzxS4",
@@ -4480,6 +5416,13 @@ func TestFullConfig(t *testing.T) {
 							LocalBindAddress:     "127.24.88.0",
 						},
 					},
+					TransparentProxy: &structs.TransparentProxyConfig{
+						OutboundListenerPort: 15991,
+						ExcludeInboundPorts:  []int{8954},
+						ExcludeOutboundPorts: []int{53, 3346},
+						ExcludeOutboundCIDRs: []string{"10.219.0.0/16"},
+						ExcludeUIDs:          []string{"4092"},
+					},
 				},
 				Weights: &structs.Weights{
 					Passing: 1,
@@ -4574,7 +5517,9 @@ func TestFullConfig(t *testing.T) {
 		SerfBindAddrLAN:      tcpAddr("99.43.63.15:8301"),
 		SerfBindAddrWAN:      tcpAddr("67.88.33.19:8302"),
 		SessionTTLMin:        26627 * time.Second,
+		SkipClusterIDCheck:   true,
 		SkipLeaveOnInt:       true,
+		SNICertificates:      []tlsutil.SNICertificate{{CertFile: "Vp3tNm8Q", KeyFile: "hT5wLk2R"}},
 		StartJoinAddrsLAN:    []string{"LR3hGDoG", "MwVpZ4Up"},
 		StartJoinAddrsWAN:    []string{"EbFSc3nA", "kwXTh623"},
 		SyslogFacility:       "hHv79Uia",
@@ -4599,29 +5544,48 @@ func TestFullConfig(t *testing.T) {
 			AllowedPrefixes:                    []string{"oJotS8XJ"},
 			BlockedPrefixes:                    []string{"cazlEhGn"},
 			MetricsPrefix:                      "ftO6DySn",
+			OTLPEndpoint:                       "https://ZOfDXRz3:4318/v1/metrics",
+			OTLPHeaders:                        map[string]string{"Authorization": "Bearer Pd3QV9Ap"},
+			OTLPInterval:                       23 * time.Second,
+			OTLPTracesEndpoint:                 "https://ZOfDXRz3:4318/v1/traces",
+			OTLPCAFile:                         "Jn8JU1Rk",
+			OTLPCertFile:                       "hBsqVt7G",
+			OTLPKeyFile:                        "yhTq3xLE",
 			PrometheusRetentionTime:            15 * time.Second,
+			PrometheusHistogramBuckets:         []float64{1.5, 25, 400},
+			PrometheusHistogramPrefixes:        []string{"consul.rpc", "consul.kvs"},
 			StatsdAddr:                         "drce87cy",
 			StatsiteAddr:                       "HpFwKB8R",
+			TokenRequestMetricsLimit:           17,
 		},
 		TLSCipherSuites:             []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+		TLSDatacenterServerNames:    map[string]string{"dc9": "consul.dc9.example.com"},
+		TLSMaxVersion:               "tls13",
 		TLSMinVersion:               "pAOWafkR",
+		TLSOCSPRefreshInterval:      47 * time.Minute,
+		TLSOCSPResponderURL:         "http://ocsp.example.com/h8KzRf2c",
+		TLSOCSPStapling:             true,
 		TLSPreferServerCipherSuites: true,
+		TLSWatchFiles:               true,
 		TaggedAddresses: map[string]string{
 			"7MYgHrYH": "dALJAhLD",
 			"h6DdBy6K": "ebrr9zZ8",
 			"lan":      "17.99.29.16",
 			"wan":      "78.63.37.19",
 		},
-		TranslateWANAddrs:    true,
-		UIDir:                "11IFzAUn",
-		UnixSocketUser:       "E0nB1DwA",
-		UnixSocketGroup:      "8pFodrV8",
-		UnixSocketMode:       "E8sAwOv4",
-		VerifyIncoming:       true,
-		VerifyIncomingHTTPS:  true,
-		VerifyIncomingRPC:    true,
-		VerifyOutgoing:       true,
-		VerifyServerHostname: true,
+		TranslateWANAddrs:           true,
+		UIDir:                       "11IFzAUn",
+		UIMetricsProxyBaseURL:       "http://T5QKx6Rg:9090",
+		UIMetricsProxyAddHeaders:    map[string]string{"Authorization": "Bearer x2Wq0JkO"},
+		UIMetricsProxyPathAllowlist: []string{"/api/v1/query", "/api/v1/series"},
+		UnixSocketUser:              "E0nB1DwA",
+		UnixSocketGroup:             "8pFodrV8",
+		UnixSocketMode:              "E8sAwOv4",
+		VerifyIncoming:              true,
+		VerifyIncomingHTTPS:         true,
+		VerifyIncomingRPC:           true,
+		VerifyOutgoing:              true,
+		VerifyServerHostname:        true,
 		Watches: []map[string]interface{}{
 			map[string]interface{}{
 				"type":       "key",
@@ -4930,11 +5894,17 @@ func TestSanitize(t *testing.T) {
 				Token: "zope",
 			},
 		},
+		Telemetry: lib.TelemetryConfig{
+			OTLPHeaders: map[string]string{"Authorization": "Bearer secret"},
+		},
+		UIMetricsProxyAddHeaders: map[string]string{"Authorization": "Bearer secret"},
 	}
 
 	rtJSON := `{
 		"ACLAgentMasterToken": "hidden",
 		"ACLAgentToken": "hidden",
+		"ACLAllowAnonymousWrite": false,
+		"ACLDNSToken": "hidden",
 		"ACLDatacenter": "",
 		"ACLDefaultPolicy": "",
 		"ACLDisabledTTL": "0s",
@@ -4952,20 +5922,31 @@ func TestSanitize(t *testing.T) {
 		"AEInterval": "0s",
 		"AdvertiseAddrLAN": "",
 		"AdvertiseAddrWAN": "",
+		"AdvertiseAddrWANMap": {},
 		"AutopilotCleanupDeadServers": false,
+		"AutopilotDeadServerQuarantineTime": "0s",
 		"AutopilotDisableUpgradeMigration": false,
 		"AutopilotLastContactThreshold": "0s",
+		"AutopilotMaxDeadServerRemovals": 0,
 		"AutopilotMaxTrailingLogs": 0,
 		"AutopilotRedundancyZoneTag": "",
 		"AutopilotServerStabilizationTime": "0s",
 		"AutopilotUpgradeVersionTag": "",
 		"BindAddr": "127.0.0.1",
 		"Bootstrap": false,
+		"BootstrapClusterID": "",
 		"BootstrapExpect": 0,
 		"CAFile": "",
 		"CAPath": "",
+		"CRLFile": "",
+		"CRLPath": "",
+		"CertBundleFile": "",
 		"CertFile": "",
 		"CheckDeregisterIntervalMin": "0s",
+		"CheckFlapHoldDown": "0s",
+		"CheckOutputMaxSize": 0,
+		"CheckOutputTruncation": "",
+		"CheckPlugins": {},
 		"CheckReapInterval": "0s",
 		"CheckUpdateInterval": "0s",
 		"Checks": [{
@@ -4982,6 +5963,8 @@ func TestSanitize(t *testing.T) {
 			"Method": "",
 			"Name": "zoo",
 			"Notes": "",
+			"Plugin": "",
+			"PluginArgs": {},
 			"ScriptArgs": [],
 			"ServiceID": "",
 			"Shell": "",
@@ -5028,6 +6011,7 @@ func TestSanitize(t *testing.T) {
 		"GossipWANSuspicionMult": 0,
 		"ConsulServerHealthInterval": "0s",
 		"DNSARecordLimit": 0,
+		"DNSAnswerOrder": "",
 		"DNSAddrs": [
 			"tcp://1.2.3.4:5678",
 			"udp://1.2.3.4:5678"
@@ -5037,6 +6021,7 @@ func TestSanitize(t *testing.T) {
 		"DNSDomain": "",
 		"DNSEnableTruncate": false,
 		"DNSMaxStale": "0s",
+		"DNSNegativeTTL": "0s",
 		"DNSNodeMetaTXT": false,
 		"DNSNodeTTL": "0s",
 		"DNSOnlyPassing": false,
@@ -5054,6 +6039,7 @@ func TestSanitize(t *testing.T) {
 		"DNSUseCache": false,
 		"DNSCacheMaxAge": "0s",
 		"DataDir": "",
+		"DataDirEncryptionKeyFile": "hidden",
 		"Datacenter": "",
 		"DevMode": false,
 		"DisableAnonymousSignature": false,
@@ -5065,8 +6051,11 @@ func TestSanitize(t *testing.T) {
 		"DisableUpdateCheck": false,
 		"DiscardCheckOutput": false,
 		"DiscoveryMaxStale": "0s",
+		"DockerCheckRuntime": "",
+		"DockerCheckRuntimeEndpoint": "",
 		"EnableAgentTLSForChecks": false,
 		"EnableDebug": false,
+		"EnableEventLog": false,
 		"EnableLocalScriptChecks": false,
 		"EnableRemoteScriptChecks": false,
 		"EnableSyslog": false,
@@ -5074,6 +6063,7 @@ func TestSanitize(t *testing.T) {
 		"EncryptKey": "hidden",
 		"EncryptVerifyIncoming": false,
 		"EncryptVerifyOutgoing": false,
+		"FollowerReads": [],
 		"GRPCAddrs": [],
 		"GRPCPort": 0,
 		"HTTPAddrs": [
@@ -5083,9 +6073,16 @@ func TestSanitize(t *testing.T) {
 		"HTTPBlockEndpoints": [],
 		"HTTPPort": 0,
 		"HTTPResponseHeaders": {},
+		"HTTPMaxHeaderBytes": 0,
+		"HTTPMaxRequestBodyBytes": {},
+		"HTTPSAcceptRate": 0,
+		"HTTPSAddrTLS": {},
 		"HTTPSAddrs": [],
+		"HTTPSMaxConcurrentHandshakes": 0,
 		"HTTPSPort": 0,
 		"KeyFile": "hidden",
+		"KeyPassphrase": "hidden",
+		"KeyPassphraseFile": "hidden",
 		"LeaveDrainTime": "0s",
 		"LeaveOnTerm": false,
 		"LogLevel": "",
@@ -5098,10 +6095,17 @@ func TestSanitize(t *testing.T) {
 		"NonVotingServer": false,
 		"PidFile": "",
 		"PrimaryDatacenter": "",
+		"RPCAcceptBackpressure": false,
+		"RPCAcceptRate": 0,
 		"RPCAdvertiseAddr": "",
 		"RPCBindAddr": "",
 		"RPCHoldTimeout": "0s",
 		"RPCMaxBurst": 0,
+		"RPCMaxConcurrentHandshakes": 0,
+		"RPCMaxConns": 0,
+		"RPCMaxStreamsPerConn": 0,
+		"RPCQueryLimits": {},
+		"RPCQueryQueueTimeout": "0s",
 		"RPCProtocol": 0,
 		"RPCRateLimit": 0,
 		"RaftProtocol": 0,
@@ -5117,10 +6121,18 @@ func TestSanitize(t *testing.T) {
 		],
 		"RetryJoinMaxAttemptsLAN": 0,
 		"RetryJoinMaxAttemptsWAN": 0,
+		"RetryJoinMaxIntervalLAN": "0s",
+		"RetryJoinMaxIntervalWAN": "0s",
+		"RetryJoinSplayLAN": "0s",
+		"RetryJoinSplayWAN": "0s",
 		"RetryJoinWAN": [
 			"wan_foo=bar wan_key=hidden wan_secret=hidden wan_bang=bar"
 		],
 		"Revision": "",
+		"ScriptCheckDir": "",
+		"ScriptCheckEnvWhitelist": [],
+		"ScriptCheckKillTimeout": "0s",
+		"ScriptCheckUser": "",
 		"SegmentLimit": 0,
 		"SegmentName": "",
 		"SegmentNameLimit": 0,
@@ -5134,6 +6146,7 @@ func TestSanitize(t *testing.T) {
 		"ServerMode": false,
 		"ServerName": "",
 		"ServerPort": 0,
+		"ServiceDrainTime": "0s",
 		"Services": [{
 			"Address": "",
 			"Check": {
@@ -5150,6 +6163,8 @@ func TestSanitize(t *testing.T) {
 				"Method": "",
 				"Name": "blurb",
 				"Notes": "",
+				"Plugin": "",
+				"PluginArgs": {},
 				"ScriptArgs": [],
 				"Shell": "",
 				"Status": "",
@@ -5162,12 +6177,15 @@ func TestSanitize(t *testing.T) {
 			"Connect": null,
 			"EnableTagOverride": false,
 			"ID": "",
+			"InheritMeta": false,
+			"InheritTags": false,
 			"Kind": "",
 			"Meta": {},
 			"Name": "foo",
 			"Port": 0,
 			"Proxy": null,
 			"ProxyDestination": "",
+			"TaggedAddresses": {},
 			"Tags": [],
 			"Token": "hidden",
 			"Weights": {
@@ -5176,15 +6194,23 @@ func TestSanitize(t *testing.T) {
 			}
 		}],
 		"SessionTTLMin": "0s",
+		"SkipClusterIDCheck": false,
 		"SkipLeaveOnInt": false,
+		"SNICertificates": [],
 		"StartJoinAddrsLAN": [],
 		"StartJoinAddrsWAN": [],
 		"SyncCoordinateIntervalMin": "0s",
 		"SyncCoordinateRateTarget": 0,
 		"SyslogFacility": "",
 		"TLSCipherSuites": [],
+		"TLSDatacenterServerNames": {},
+		"TLSMaxVersion": "",
 		"TLSMinVersion": "",
+		"TLSOCSPRefreshInterval": "0s",
+		"TLSOCSPResponderURL": "",
+		"TLSOCSPStapling": false,
 		"TLSPreferServerCipherSuites": false,
+		"TLSWatchFiles": false,
 		"TaggedAddresses": {},
 		"Telemetry": {
 			"AllowedPrefixes": [],
@@ -5207,12 +6233,29 @@ func TestSanitize(t *testing.T) {
 			"DogstatsdTags": [],
 			"FilterDefault": false,
 			"MetricsPrefix": "",
+			"OTLPCAFile": "",
+			"OTLPCertFile": "",
+			"OTLPEndpoint": "",
+			"OTLPHeaders": {
+				"Authorization": "hidden"
+			},
+			"OTLPInterval": "0s",
+			"OTLPKeyFile": "hidden",
+			"OTLPTracesEndpoint": "",
 			"PrometheusRetentionTime": "0s",
+			"PrometheusHistogramBuckets": [],
+			"PrometheusHistogramPrefixes": [],
 			"StatsdAddr": "",
-			"StatsiteAddr": ""
+			"StatsiteAddr": "",
+			"TokenRequestMetricsLimit": 0
 		},
 		"TranslateWANAddrs": false,
 		"UIDir": "",
+		"UIMetricsProxyAddHeaders": {
+			"Authorization": "hidden"
+		},
+		"UIMetricsProxyBaseURL": "",
+		"UIMetricsProxyPathAllowlist": [],
 		"UnixSocketGroup": "",
 		"UnixSocketMode": "",
 		"UnixSocketUser": "",
@@ -5436,14 +6479,26 @@ func TestRuntime_ToTLSUtilConfig(t *testing.T) {
 		VerifyOutgoing:              true,
 		CAFile:                      "a",
 		CAPath:                      "b",
+		CRLFile:                     "g",
+		CRLPath:                     "h",
 		CertFile:                    "c",
 		KeyFile:                     "d",
+		CertBundleFile:              "l",
+		KeyPassphrase:               "j",
+		KeyPassphraseFile:           "k",
+		SNICertificates:             []tlsutil.SNICertificate{{CertFile: "m", KeyFile: "n"}},
 		NodeName:                    "e",
 		ServerName:                  "f",
+		TLSDatacenterServerNames:    map[string]string{"dc2": "i"},
 		TLSMinVersion:               "tls12",
+		TLSMaxVersion:               "tls13",
 		TLSCipherSuites:             []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305},
 		TLSPreferServerCipherSuites: true,
 		EnableAgentTLSForChecks:     true,
+		TLSWatchFiles:               true,
+		TLSOCSPStapling:             true,
+		TLSOCSPResponderURL:         "http://ocsp.example.com",
+		TLSOCSPRefreshInterval:      10 * time.Minute,
 	}
 	r := c.ToTLSUtilConfig()
 	require.Equal(t, c.VerifyIncoming, r.VerifyIncoming)
@@ -5452,14 +6507,26 @@ func TestRuntime_ToTLSUtilConfig(t *testing.T) {
 	require.Equal(t, c.VerifyOutgoing, r.VerifyOutgoing)
 	require.Equal(t, c.CAFile, r.CAFile)
 	require.Equal(t, c.CAPath, r.CAPath)
+	require.Equal(t, c.CRLFile, r.CRLFile)
+	require.Equal(t, c.CRLPath, r.CRLPath)
 	require.Equal(t, c.CertFile, r.CertFile)
 	require.Equal(t, c.KeyFile, r.KeyFile)
+	require.Equal(t, c.CertBundleFile, r.CertBundleFile)
+	require.Equal(t, c.KeyPassphrase, r.KeyPassphrase)
+	require.Equal(t, c.KeyPassphraseFile, r.KeyPassphraseFile)
+	require.Equal(t, c.SNICertificates, r.SNICertificates)
 	require.Equal(t, c.NodeName, r.NodeName)
 	require.Equal(t, c.ServerName, r.ServerName)
+	require.Equal(t, c.TLSDatacenterServerNames, r.DatacenterServerNames)
 	require.Equal(t, c.TLSMinVersion, r.TLSMinVersion)
+	require.Equal(t, c.TLSMaxVersion, r.TLSMaxVersion)
 	require.Equal(t, c.TLSCipherSuites, r.CipherSuites)
 	require.Equal(t, c.TLSPreferServerCipherSuites, r.PreferServerCipherSuites)
 	require.Equal(t, c.EnableAgentTLSForChecks, r.EnableAgentTLSForChecks)
+	require.Equal(t, c.TLSWatchFiles, r.WatchFiles)
+	require.Equal(t, c.TLSOCSPStapling, r.OCSPStapling)
+	require.Equal(t, c.TLSOCSPResponderURL, r.OCSPResponderURL)
+	require.Equal(t, c.TLSOCSPRefreshInterval, r.OCSPRefreshInterval)
 }
 
 func splitIPPort(hostport string) (net.IP, int) {
@@ -1,3 +1,4 @@
+//go:build !ent
 // +build !ent
 
 package config
@@ -32,6 +33,24 @@ func TestSegments(t *testing.T) {
 			hcl:  []string{`segments = [{ name = "x" }]`},
 			err:  `Port for segment "x" cannot be <= 0`,
 		},
+		{
+			desc: "segment cert and key must be set together",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "segments":[{ "name":"x", "port": 123, "rpc_listener": true, "cert_file": "a" }] }`},
+			hcl:  []string{`segments = [{ name = "x" port = 123 rpc_listener = true cert_file = "a" }]`},
+			err:  `cert_file and key_file for segment "x" must be set together`,
+		},
+		{
+			desc: "segment TLS requires an RPC listener",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "segments":[{ "name":"x", "port": 123, "cert_file": "a", "key_file": "b" }] }`},
+			hcl:  []string{`segments = [{ name = "x" port = 123 cert_file = "a" key_file = "b" }]`},
+			err:  `TLS files for segment "x" require rpc_listener to be enabled`,
+		},
 		{
 			desc: "segments not in OSS",
 			args: []string{
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Apply(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Create some config entries.
+	body := bytes.NewBuffer([]byte(`
+	{
+		"Kind": "terminating-gateway",
+		"Name": "gateway",
+		"Services": [
+			{
+				"Name": "db",
+				"CAFile": "/etc/ssl/db-ca.pem",
+				"SNI": "db.example.com"
+			}
+		]
+	}`))
+
+	req, _ := http.NewRequest("PUT", "/v1/config", body)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.ConfigApply(resp, req)
+	require.NoError(err)
+	require.Equal(200, resp.Code, resp.Body.String())
+
+	// Get it back.
+	req, _ = http.NewRequest("GET", "/v1/config/terminating-gateway/gateway", nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.Config(resp, req)
+	require.NoError(err)
+
+	entry := obj.(*structs.TerminatingGatewayConfigEntry)
+	require.Equal("gateway", entry.Name)
+	require.Equal([]structs.LinkedService{
+		{Name: "db", CAFile: "/etc/ssl/db-ca.pem", SNI: "db.example.com"},
+	}, entry.Services)
+
+	// List the kind.
+	req, _ = http.NewRequest("GET", "/v1/config/terminating-gateway", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.Config(resp, req)
+	require.NoError(err)
+	require.Len(obj.([]structs.ConfigEntry), 1)
+
+	// Unknown keys are rejected.
+	body = bytes.NewBuffer([]byte(`{"Kind": "terminating-gateway", "Name": "gateway", "Servicez": []}`))
+	req, _ = http.NewRequest("PUT", "/v1/config", body)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.ConfigApply(resp, req)
+	require.Error(err)
+	require.Contains(err.Error(), "Servicez")
+}
+
+func TestConfig_Delete(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.TerminatingGatewayConfigEntry{
+			Name: "gateway",
+		},
+	}
+	var out struct{}
+	require.NoError(a.RPC("ConfigEntry.Apply", &req, &out))
+
+	// Delete it.
+	httpReq, _ := http.NewRequest("DELETE", "/v1/config/terminating-gateway/gateway", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.Config(resp, httpReq)
+	require.NoError(err)
+
+	// Make sure it's gone.
+	httpReq, _ = http.NewRequest("GET", "/v1/config/terminating-gateway/gateway", nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.Config(resp, httpReq)
+	require.NoError(err)
+	require.Nil(obj)
+	require.Equal(http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,15 @@
+package connect
+
+import (
+	"fmt"
+)
+
+// ServiceSNI returns the TLS server name Connect clients send when dialing the
+// given service. Terminating gateways use it to pick the certificate and the
+// destination for a connection since a gateway represents many services.
+func ServiceSNI(service, namespace, datacenter, trustDomain string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%s.%s.%s.internal.%s", service, namespace, datacenter, trustDomain)
+}
@@ -0,0 +1,16 @@
+package connect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceSNI(t *testing.T) {
+	const trustDomain = "11111111-2222-3333-4444-555555555555.consul"
+
+	require.Equal(t, "db.default.dc1.internal."+trustDomain,
+		ServiceSNI("db", "", "dc1", trustDomain))
+	require.Equal(t, "db.other.dc2.internal."+trustDomain,
+		ServiceSNI("db", "other", "dc2", trustDomain))
+}
@@ -130,6 +130,24 @@ func TestCA(t testing.T, xc *structs.CARoot) *structs.CARoot {
 // TestLeaf returns a valid leaf certificate and it's private key for the named
 // service with the given CA Root.
 func TestLeaf(t testing.T, service string, root *structs.CARoot) (string, string) {
+	// Build the SPIFFE ID
+	spiffeId := &SpiffeIDService{
+		Host:       fmt.Sprintf("%s.consul", TestClusterID),
+		Namespace:  "default",
+		Datacenter: "dc1",
+		Service:    service,
+	}
+	return testLeaf(t, service, spiffeId, root)
+}
+
+// TestLeafWithURI returns a valid leaf certificate and it's private key for
+// the given SPIFFE ID with the given CA Root. It allows issuing certificates
+// of other trust domains, e.g. with the root of a federated trust domain.
+func TestLeafWithURI(t testing.T, uri CertURI, root *structs.CARoot) (string, string) {
+	return testLeaf(t, "test", uri, root)
+}
+
+func testLeaf(t testing.T, commonName string, uri CertURI, root *structs.CARoot) (string, string) {
 	// Parse the CA cert and signing key from the root
 	cert := root.SigningCert
 	if cert == "" {
@@ -144,14 +162,6 @@ func TestLeaf(t testing.T, service string, root *structs.CARoot) (string, string
 		t.Fatalf("error parsing signing key: %s", err)
 	}
 
-	// Build the SPIFFE ID
-	spiffeId := &SpiffeIDService{
-		Host:       fmt.Sprintf("%s.consul", TestClusterID),
-		Namespace:  "default",
-		Datacenter: "dc1",
-		Service:    service,
-	}
-
 	// The serial number for the cert
 	sn, err := testSerialNumber()
 	if err != nil {
@@ -167,8 +177,8 @@ func TestLeaf(t testing.T, service string, root *structs.CARoot) (string, string
 	// Cert template for generation
 	template := x509.Certificate{
 		SerialNumber:          sn,
-		Subject:               pkix.Name{CommonName: service},
-		URIs:                  []*url.URL{spiffeId.URI()},
+		Subject:               pkix.Name{CommonName: commonName},
+		URIs:                  []*url.URL{uri.URI()},
 		SignatureAlgorithm:    x509.ECDSAWithSHA256,
 		BasicConstraintsValid: true,
 		KeyUsage: x509.KeyUsageDataEncipherment |
@@ -0,0 +1,59 @@
+package connect
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// SpiffeIDExternal is the SPIFFE ID of a workload in a federated trust domain
+// that is not managed by Consul, e.g. a mesh managed by SPIRE. The path of
+// the ID is opaque to Consul.
+type SpiffeIDExternal struct {
+	TrustDomain string
+	Path        string
+}
+
+// ParseFederatedCertURI parses the URI of a certificate of a workload in one
+// of the given federated trust domains. It returns an error if the URI
+// belongs to any other trust domain.
+func ParseFederatedCertURI(input *url.URL, trustDomains []string) (*SpiffeIDExternal, error) {
+	if input.Scheme != "spiffe" {
+		return nil, fmt.Errorf("SPIFFE ID must have 'spiffe' scheme")
+	}
+
+	for _, domain := range trustDomains {
+		if input.Host == domain {
+			return &SpiffeIDExternal{
+				TrustDomain: input.Host,
+				Path:        input.EscapedPath(),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("SPIFFE ID is not in a federated trust domain")
+}
+
+// URI returns the *url.URL for this SPIFFE ID.
+func (id *SpiffeIDExternal) URI() *url.URL {
+	result, err := url.Parse("spiffe://" + id.TrustDomain + id.Path)
+	if err != nil {
+		// The path was escaped when parsing the ID so this can't happen.
+		return &url.URL{Scheme: "spiffe", Host: id.TrustDomain}
+	}
+	return result
+}
+
+// CertURI impl. External workloads only match intentions for their exact
+// SPIFFE ID, wildcard intentions only apply to Consul services.
+func (id *SpiffeIDExternal) Authorize(ixn *structs.Intention) (bool, bool) {
+	if ixn.SourceType != structs.IntentionSourceSPIFFE {
+		return false, false
+	}
+
+	if ixn.SourceName != id.URI().String() {
+		return false, false
+	}
+
+	return ixn.Action == structs.IntentionActionAllow, true
+}
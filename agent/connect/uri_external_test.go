@@ -0,0 +1,96 @@
+package connect
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFederatedCertURI(t *testing.T) {
+	domains := []string{"spire.example.org"}
+
+	u, err := url.Parse("spiffe://spire.example.org/ns/prod/sa/web")
+	require.NoError(t, err)
+	id, err := ParseFederatedCertURI(u, domains)
+	require.NoError(t, err)
+	require.Equal(t, &SpiffeIDExternal{
+		TrustDomain: "spire.example.org",
+		Path:        "/ns/prod/sa/web",
+	}, id)
+	require.Equal(t, u.String(), id.URI().String())
+
+	// Other trust domains are rejected, even if the path looks like a Consul
+	// service.
+	u, err = url.Parse("spiffe://1234.consul/ns/default/dc/dc1/svc/web")
+	require.NoError(t, err)
+	_, err = ParseFederatedCertURI(u, domains)
+	require.Error(t, err)
+
+	u, err = url.Parse("https://spire.example.org/web")
+	require.NoError(t, err)
+	_, err = ParseFederatedCertURI(u, domains)
+	require.Error(t, err)
+}
+
+func TestSpiffeIDExternalAuthorize(t *testing.T) {
+	id := &SpiffeIDExternal{TrustDomain: "spire.example.org", Path: "/ns/prod/sa/web"}
+
+	cases := []struct {
+		Name  string
+		Ixn   *structs.Intention
+		Auth  bool
+		Match bool
+	}{
+		{
+			"exact source, allow",
+			&structs.Intention{
+				SourceType: structs.IntentionSourceSPIFFE,
+				SourceName: "spiffe://spire.example.org/ns/prod/sa/web",
+				Action:     structs.IntentionActionAllow,
+			},
+			true,
+			true,
+		},
+		{
+			"exact source, deny",
+			&structs.Intention{
+				SourceType: structs.IntentionSourceSPIFFE,
+				SourceName: "spiffe://spire.example.org/ns/prod/sa/web",
+				Action:     structs.IntentionActionDeny,
+			},
+			false,
+			true,
+		},
+		{
+			"different workload",
+			&structs.Intention{
+				SourceType: structs.IntentionSourceSPIFFE,
+				SourceName: "spiffe://spire.example.org/ns/prod/sa/db",
+				Action:     structs.IntentionActionAllow,
+			},
+			false,
+			false,
+		},
+		{
+			"consul wildcard doesn't match",
+			&structs.Intention{
+				SourceType: structs.IntentionSourceConsul,
+				SourceNS:   structs.IntentionWildcard,
+				SourceName: structs.IntentionWildcard,
+				Action:     structs.IntentionActionAllow,
+			},
+			false,
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			auth, match := id.Authorize(tc.Ixn)
+			require.Equal(t, tc.Auth, auth)
+			require.Equal(t, tc.Match, match)
+		})
+	}
+}
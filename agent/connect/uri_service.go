@@ -27,6 +27,11 @@ func (id *SpiffeIDService) URI() *url.URL {
 
 // CertURI impl.
 func (id *SpiffeIDService) Authorize(ixn *structs.Intention) (bool, bool) {
+	if ixn.SourceType == structs.IntentionSourceSPIFFE {
+		// Only matches workloads of federated trust domains
+		return false, false
+	}
+
 	if ixn.SourceNS != structs.IntentionWildcard && ixn.SourceNS != id.Namespace {
 		// Non-matching namespace
 		return false, false
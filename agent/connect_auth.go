@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net/url"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/cache"
@@ -40,14 +43,33 @@ func (a *Agent) ConnectAuthorize(token string,
 	}
 
 	// Parse the certificate URI from the client ID
-	uri, err := connect.ParseCertURIFromString(req.ClientCertURI)
+	uriRaw, err := url.Parse(req.ClientCertURI)
 	if err != nil {
 		return returnErr(BadRequestError{"ClientCertURI not a valid Connect identifier"})
 	}
 
-	uriService, ok := uri.(*connect.SpiffeIDService)
-	if !ok {
-		return returnErr(BadRequestError{"ClientCertURI not a valid Service identifier"})
+	roots, err := a.connectCARoots()
+	if err != nil {
+		return returnErr(err)
+	}
+
+	// Workloads of federated trust domains are checked first so they can't
+	// pass as a Consul service by using a matching SPIFFE ID path.
+	var clientID connect.CertURI
+	federatedID, err := connect.ParseFederatedCertURI(uriRaw, roots.FederatedTrustDomains)
+	if err == nil {
+		clientID = federatedID
+	} else {
+		uri, err := connect.ParseCertURI(uriRaw)
+		if err != nil {
+			return returnErr(BadRequestError{"ClientCertURI not a valid Connect identifier"})
+		}
+
+		uriService, ok := uri.(*connect.SpiffeIDService)
+		if !ok {
+			return returnErr(BadRequestError{"ClientCertURI not a valid Service identifier"})
+		}
+		clientID = uriService
 	}
 
 	// We need to verify service:write permissions for the given token.
@@ -61,6 +83,19 @@ func (a *Agent) ConnectAuthorize(token string,
 		return returnErr(acl.ErrPermissionDenied)
 	}
 
+	// Proxies only trust the roots of the cluster, so the URI of a federated
+	// workload proves nothing without its certificate, which must chain to the
+	// roots of its own trust domain. Certificates of Consul services are
+	// checked too if they are given, so a federated root can't vouch for one.
+	if federatedID != nil && req.ClientCertPEM == "" {
+		return false, "Workloads of federated trust domains must present their client certificate", nil, nil
+	}
+	if req.ClientCertPEM != "" {
+		if err := verifyClientCert(roots, uriRaw, req.ClientCertPEM); err != nil {
+			return false, fmt.Sprintf("Invalid client certificate: %v", err), nil, nil
+		}
+	}
+
 	// Note that we DON'T explicitly validate the trust-domain matches ours. See
 	// the PR for this change for details.
 
@@ -96,7 +131,7 @@ func (a *Agent) ConnectAuthorize(token string,
 
 	// Test the authorization for each match
 	for _, ixn := range reply.Matches[0] {
-		if auth, ok := uriService.Authorize(ixn); ok {
+		if auth, ok := clientID.Authorize(ixn); ok {
 			reason = fmt.Sprintf("Matched intention: %s", ixn.String())
 			return auth, reason, &meta, nil
 		}
@@ -117,3 +152,79 @@ func (a *Agent) ConnectAuthorize(token string,
 	reason = "Default behavior configured by ACLs"
 	return rule.IntentionDefaultAllow(), reason, &meta, nil
 }
+
+// connectCARoots returns the roots of the cluster and of the federated trust
+// domains.
+func (a *Agent) connectCARoots() (*structs.IndexedCARoots, error) {
+	raw, _, err := a.cache.Get(cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+		Datacenter: a.config.Datacenter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	roots, ok := raw.(*structs.IndexedCARoots)
+	if !ok {
+		return nil, fmt.Errorf("internal error: roots response type not correct")
+	}
+	return roots, nil
+}
+
+// verifyClientCert verifies that the PEM-encoded client certificate and
+// intermediates chain to the roots trusted for the trust domain of the URI
+// and that the certificate has this URI. Only the roots of a federated trust
+// domain are trusted for its workloads and only the roots of the cluster for
+// any other URI.
+func verifyClientCert(roots *structs.IndexedCARoots, uri *url.URL, certPEM string) error {
+	var certs []*x509.Certificate
+	rest := []byte(certPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate found")
+	}
+	leaf := certs[0]
+	if len(leaf.URIs) < 1 || leaf.URIs[0].String() != uri.String() {
+		return fmt.Errorf("certificate doesn't match ClientCertURI")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	federated := false
+	for _, r := range roots.FederatedRoots {
+		if r.ExternalTrustDomain == uri.Host {
+			federated = true
+			opts.Roots.AppendCertsFromPEM([]byte(r.RootCert))
+		}
+	}
+	if !federated {
+		for _, r := range roots.Roots {
+			opts.Roots.AppendCertsFromPEM([]byte(r.RootCert))
+			for _, intermediate := range r.IntermediateCerts {
+				opts.Intermediates.AppendCertsFromPEM([]byte(intermediate))
+			}
+		}
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		if federated {
+			return fmt.Errorf("not signed by the roots of trust domain %q: %v", uri.Host, err)
+		}
+		return fmt.Errorf("not signed by the roots of the cluster: %v", err)
+	}
+	return nil
+}
@@ -62,15 +62,25 @@ func (s *HTTPServer) ConnectCAConfigurationSet(resp http.ResponseWriter, req *ht
 	var args structs.CARequest
 	s.parseDC(req, &args.Datacenter)
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 	if err := decodeBody(req, &args.Config, nil); err != nil {
 		resp.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(resp, "Request decode failed: %v", err)
 		return nil, nil
 	}
 
-	var reply interface{}
-	err := s.agent.RPC("ConnectCA.ConfigurationSet", &args, &reply)
-	return nil, err
+	var reply string
+	if err := s.agent.RPC("ConnectCA.ConfigurationSet", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	// A staged root is activated in the background, so report its ID for the
+	// rollout to be followed on the roots endpoint.
+	if reply != "" {
+		return struct{ StagedRootID string }{reply}, nil
+	}
+	return nil, nil
 }
 
 // A hack to fix up the config types inside of the map[string]interface{}
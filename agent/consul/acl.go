@@ -823,6 +823,29 @@ func (r *ACLResolver) ResolveToken(token string) (acl.Authorizer, error) {
 
 }
 
+// ResolveTokenToAccessorID returns the accessor ID of a token, for example to
+// attribute requests to the token that made them. It returns an empty string
+// if ACLs are disabled or the legacy ACL system is in use.
+func (r *ACLResolver) ResolveTokenToAccessorID(token string) (string, error) {
+	if !r.ACLsEnabled() || r.delegate.UseLegacyACLs() {
+		return "", nil
+	}
+
+	// handle the anonymous token
+	if token == "" {
+		token = anonymousToken
+	}
+
+	identity, err := r.resolveIdentityFromToken(token)
+	if err != nil {
+		return "", err
+	}
+	if identity == nil {
+		return "", acl.ErrNotFound
+	}
+	return identity.ID(), nil
+}
+
 func (r *ACLResolver) ACLsEnabled() bool {
 	// Whether we desire ACLs to be enabled according to configuration
 	if !r.delegate.ACLsEnabled() {
@@ -978,6 +1001,39 @@ func (f *aclFilter) filterCheckServiceNodes(nodes *structs.CheckServiceNodes) {
 	*nodes = csn
 }
 
+// filterGatewayServices is used to filter the services bound to a gateway and
+// their instances based on ACL rules.
+func (f *aclFilter) filterGatewayServices(services *structs.GatewayServices) {
+	gs := *services
+	for i := 0; i < len(gs); i++ {
+		svc := gs[i]
+		if f.allowService(svc.Service) {
+			f.filterCheckServiceNodes(&svc.Instances)
+			continue
+		}
+		f.logger.Printf("[DEBUG] consul: dropping service %q from result due to ACLs", svc.Service)
+		gs = append(gs[:i], gs[i+1:]...)
+		i--
+	}
+	*services = gs
+}
+
+// filterServiceWeightOverrides is used to filter the weight overrides of
+// service instances based on ACL rules.
+func (f *aclFilter) filterServiceWeightOverrides(overrides *structs.ServiceWeightOverrides) {
+	o := *overrides
+	for i := 0; i < len(o); i++ {
+		override := o[i]
+		if f.allowService(override.Service) {
+			continue
+		}
+		f.logger.Printf("[DEBUG] consul: dropping weight override of service %q from result due to ACLs", override.ServiceID)
+		o = append(o[:i], o[i+1:]...)
+		i--
+	}
+	*overrides = o
+}
+
 // filterSessions is used to filter a set of sessions based on ACLs.
 func (f *aclFilter) filterSessions(sessions *structs.Sessions) {
 	s := *sessions
@@ -1186,6 +1242,9 @@ func (r *ACLResolver) filterACLWithAuthorizer(authorizer acl.Authorizer, subj in
 	case *structs.IndexedCoordinates:
 		filt.filterCoordinates(&v.Coordinates)
 
+	case *structs.IndexedGatewayServices:
+		filt.filterGatewayServices(&v.Services)
+
 	case *structs.IndexedHealthChecks:
 		filt.filterHealthChecks(&v.HealthChecks)
 
@@ -1207,6 +1266,9 @@ func (r *ACLResolver) filterACLWithAuthorizer(authorizer acl.Authorizer, subj in
 	case *structs.IndexedServices:
 		filt.filterServices(v.Services)
 
+	case *structs.IndexedServiceWeightOverrides:
+		filt.filterServiceWeightOverrides(&v.Overrides)
+
 	case *structs.IndexedSessions:
 		filt.filterSessions(&v.Sessions)
 
@@ -99,3 +99,7 @@ func (c *Client) ResolvePolicyFromID(policyID string) (bool, *structs.ACLPolicy,
 func (c *Client) ResolveToken(token string) (acl.Authorizer, error) {
 	return c.acls.ResolveToken(token)
 }
+
+func (c *Client) ResolveTokenToAccessorID(token string) (string, error) {
+	return c.acls.ResolveTokenToAccessorID(token)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/consul/agent/consul/state"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/sentinel"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-uuid"
 )
@@ -413,6 +414,12 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 	}
 	token.Policies = policies
 
+	if token.AccessorID == structs.ACLTokenAnonymousID {
+		if err := a.checkAnonymousPolicies(token.Policies); err != nil {
+			return err
+		}
+	}
+
 	if token.Rules != "" {
 		return fmt.Errorf("Rules cannot be specified for this token")
 	}
@@ -449,6 +456,86 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 	return nil
 }
 
+// AnonymousPoliciesSet replaces the policies linked to the anonymous token,
+// which apply to every request made without a token.
+func (a *ACL) AnonymousPoliciesSet(args *structs.ACLAnonymousPoliciesSetRequest, reply *structs.ACLToken) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	// The anonymous token is global so it's always modified in the ACL DC
+	args.Datacenter = a.srv.config.ACLDatacenter
+
+	if done, err := a.srv.forward("ACL.AnonymousPoliciesSet", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "anonymous", "upsert"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	_, token, err := a.srv.fsm.State().ACLTokenGetByAccessor(nil, structs.ACLTokenAnonymousID)
+	if err != nil {
+		return err
+	} else if token == nil {
+		return acl.ErrNotFound
+	}
+
+	setReq := structs.ACLTokenSetRequest{
+		Datacenter: args.Datacenter,
+		ACLToken: structs.ACLToken{
+			AccessorID:  token.AccessorID,
+			SecretID:    token.SecretID,
+			Description: token.Description,
+			Policies:    args.Policies,
+			Local:       token.Local,
+		},
+		WriteRequest: args.WriteRequest,
+	}
+	return a.tokenSetInternal(&setReq, reply, false)
+}
+
+// checkAnonymousPolicies returns an error if any of the given policies grants
+// write access, unless that is explicitly allowed for the anonymous token.
+func (a *ACL) checkAnonymousPolicies(links []structs.ACLTokenPolicyLink) error {
+	if a.srv.config.ACLAllowAnonymousWrite {
+		return nil
+	}
+
+	state := a.srv.fsm.State()
+	for _, link := range links {
+		_, policy, err := state.ACLPolicyGetByID(nil, link.ID)
+		if err != nil {
+			return fmt.Errorf("acl policy lookup failed: %v", err)
+		}
+		if policy == nil {
+			return fmt.Errorf("No such ACL policy with ID %q", link.ID)
+		}
+		if err := checkAnonymousPolicyRules(policy, a.srv.sentinel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAnonymousPolicyRules returns an error if the policy grants write
+// access.
+func checkAnonymousPolicyRules(policy *structs.ACLPolicy, sentinel sentinel.Evaluator) error {
+	parsed, err := acl.NewPolicyFromSource(policy.ID, policy.ModifyIndex, policy.Rules, policy.Syntax, sentinel)
+	if err != nil {
+		return err
+	}
+	if parsed.GrantsWrite() {
+		return fmt.Errorf("Policy %q grants write access, which is not permitted for the anonymous token unless acl.allow_anonymous_write is set", policy.Name)
+	}
+	return nil
+}
+
 func (a *ACL) TokenDelete(args *structs.ACLTokenDeleteRequest, reply *string) error {
 	if err := a.aclPreCheck(); err != nil {
 		return err
@@ -743,6 +830,24 @@ func (a *ACL) PolicySet(args *structs.ACLPolicySetRequest, reply *structs.ACLPol
 		return err
 	}
 
+	// don't let an update grant write access to the anonymous token
+	if !a.srv.config.ACLAllowAnonymousWrite {
+		_, anonymous, err := state.ACLTokenGetByAccessor(nil, structs.ACLTokenAnonymousID)
+		if err != nil {
+			return fmt.Errorf("acl token lookup failed: %v", err)
+		}
+		if anonymous != nil {
+			for _, id := range anonymous.PolicyIDs() {
+				if id != policy.ID {
+					continue
+				}
+				if err := checkAnonymousPolicyRules(policy, a.srv.sentinel); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	// calculate the hash for this policy
 	policy.SetHash(true)
 
@@ -850,6 +850,133 @@ func TestACLEndpoint_TokenSet_anon(t *testing.T) {
 	require.Equal(t, tokenResp.Token.Policies[0].ID, policy.ID)
 }
 
+func TestACLEndpoint_TokenSet_anonWrite(t *testing.T) {
+	t.Parallel()
+
+	for _, allow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allow=%v", allow), func(t *testing.T) {
+			dir1, s1 := testServerWithConfig(t, func(c *Config) {
+				c.ACLDatacenter = "dc1"
+				c.ACLsEnabled = true
+				c.ACLMasterToken = "root"
+				c.ACLAllowAnonymousWrite = allow
+			})
+			defer os.RemoveAll(dir1)
+			defer s1.Shutdown()
+			codec := rpcClient(t, s1)
+			defer codec.Close()
+
+			testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+			acl := ACL{srv: s1}
+
+			// Linking the global-management policy to the anonymous token
+			// would open up the cluster.
+			req := structs.ACLTokenSetRequest{
+				Datacenter: "dc1",
+				ACLToken: structs.ACLToken{
+					AccessorID: structs.ACLTokenAnonymousID,
+					Policies: []structs.ACLTokenPolicyLink{
+						structs.ACLTokenPolicyLink{
+							ID: structs.ACLPolicyGlobalManagementID,
+						},
+					},
+				},
+				WriteRequest: structs.WriteRequest{Token: "root"},
+			}
+			token := structs.ACLToken{}
+			err := acl.TokenSet(&req, &token)
+			if allow {
+				require.NoError(t, err)
+				require.Len(t, token.Policies, 1)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "grants write access")
+			}
+		})
+	}
+}
+
+func TestACLEndpoint_AnonymousPoliciesSet(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+
+	policyReq := structs.ACLPolicySetRequest{
+		Datacenter: "dc1",
+		Policy: structs.ACLPolicy{
+			Name:  "dns-read",
+			Rules: `node_prefix "" { policy = "read" } service_prefix "" { policy = "read" }`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	policy := structs.ACLPolicy{}
+	require.NoError(t, endpoint.PolicySet(&policyReq, &policy))
+
+	t.Run("Requires acl:write", func(t *testing.T) {
+		req := structs.ACLAnonymousPoliciesSetRequest{
+			Datacenter: "dc1",
+			Policies:   []structs.ACLTokenPolicyLink{{Name: "dns-read"}},
+		}
+		token := structs.ACLToken{}
+		err := endpoint.AnonymousPoliciesSet(&req, &token)
+		require.True(t, acl.IsErrPermissionDenied(err), err)
+	})
+
+	t.Run("Read policies", func(t *testing.T) {
+		req := structs.ACLAnonymousPoliciesSetRequest{
+			Datacenter:   "dc1",
+			Policies:     []structs.ACLTokenPolicyLink{{Name: "dns-read"}},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		token := structs.ACLToken{}
+		require.NoError(t, endpoint.AnonymousPoliciesSet(&req, &token))
+		require.Equal(t, structs.ACLTokenAnonymousID, token.AccessorID)
+		require.Equal(t, "Anonymous Token", token.Description)
+		require.Equal(t, []structs.ACLTokenPolicyLink{{ID: policy.ID, Name: "dns-read"}}, token.Policies)
+	})
+
+	t.Run("Write policies", func(t *testing.T) {
+		req := structs.ACLAnonymousPoliciesSetRequest{
+			Datacenter:   "dc1",
+			Policies:     []structs.ACLTokenPolicyLink{{Name: "global-management"}},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		token := structs.ACLToken{}
+		err := endpoint.AnonymousPoliciesSet(&req, &token)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "grants write access")
+	})
+
+	t.Run("Policy update granting write", func(t *testing.T) {
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				ID:    policy.ID,
+				Name:  "dns-read",
+				Rules: `node_prefix "" { policy = "write" }`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLPolicy{}
+		err := endpoint.PolicySet(&req, &resp)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "grants write access")
+	})
+}
+
 func TestACLEndpoint_TokenDelete(t *testing.T) {
 	t.Parallel()
 
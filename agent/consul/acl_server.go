@@ -170,6 +170,10 @@ func (s *Server) ResolveToken(token string) (acl.Authorizer, error) {
 	return s.acls.ResolveToken(token)
 }
 
+func (s *Server) ResolveTokenToAccessorID(token string) (string, error) {
+	return s.acls.ResolveTokenToAccessorID(token)
+}
+
 func (s *Server) filterACL(token string, subj interface{}) error {
 	return s.acls.filterACL(token, subj)
 }
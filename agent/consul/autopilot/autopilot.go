@@ -14,6 +14,15 @@ import (
 	"github.com/hashicorp/serf/serf"
 )
 
+const (
+	// maxRemovals is the number of dead server removals kept for the
+	// removals endpoint.
+	maxRemovals = 100
+
+	removalReasonFailed = "failed"
+	removalReasonStale  = "stale"
+)
+
 // Delegate is the interface for the Autopilot mechanism
 type Delegate interface {
 	AutopilotConfig() *Config
@@ -38,6 +47,13 @@ type Autopilot struct {
 	clusterHealth     OperatorHealthReply
 	clusterHealthLock sync.RWMutex
 
+	// deadSince holds the time each dead server was first noticed, keyed by
+	// address. It's only used by the run loop.
+	deadSince map[string]time.Time
+
+	removals     []ServerRemoval
+	removalsLock sync.RWMutex
+
 	enabled      bool
 	removeDeadCh chan struct{}
 	shutdownCh   chan struct{}
@@ -75,6 +91,10 @@ func (a *Autopilot) Start() {
 	a.shutdownCh = make(chan struct{})
 	a.waitGroup = sync.WaitGroup{}
 	a.clusterHealth = OperatorHealthReply{}
+	a.deadSince = make(map[string]time.Time)
+	a.removalsLock.Lock()
+	a.removals = nil
+	a.removalsLock.Unlock()
 
 	a.waitGroup.Add(2)
 	go a.run()
@@ -173,7 +193,9 @@ func (a *Autopilot) RemoveDeadServers() {
 	}
 }
 
-// pruneDeadServers removes up to numPeers/2 failed servers
+// pruneDeadServers removes up to numPeers/2 failed servers. Servers are only
+// removed once they have been dead for the quarantine time, and at most
+// MaxDeadServerRemovals of them are removed at a time.
 func (a *Autopilot) pruneDeadServers() error {
 	conf := a.delegate.AutopilotConfig()
 	if conf == nil || !conf.CleanupDeadServers {
@@ -182,7 +204,7 @@ func (a *Autopilot) pruneDeadServers() error {
 
 	// Failed servers are known to Serf and marked failed, and stale servers
 	// are known to Raft but not Serf.
-	var failed []string
+	var failed, failedNonvoters []ServerRemoval
 	staleRaftServers := make(map[string]raft.Server)
 	raftNode := a.delegate.Raft()
 	future := raftNode.GetConfiguration()
@@ -195,6 +217,20 @@ func (a *Autopilot) pruneDeadServers() error {
 		staleRaftServers[string(server.Address)] = server
 	}
 
+	// Track when each server was first seen dead. Servers that are alive
+	// again are forgotten so their quarantine restarts the next time.
+	now := time.Now()
+	deadSince := make(map[string]time.Time)
+	defer func() { a.deadSince = deadSince }()
+	quarantined := func(addr string) (time.Time, bool) {
+		since, ok := a.deadSince[addr]
+		if !ok {
+			since = now
+		}
+		deadSince[addr] = since
+		return since, now.Sub(since) < conf.DeadServerQuarantineTime
+	}
+
 	serfLAN := a.delegate.Serf()
 	for _, member := range serfLAN.Members() {
 		server, err := a.delegate.IsServer(member)
@@ -210,36 +246,94 @@ func (a *Autopilot) pruneDeadServers() error {
 			}
 
 			if member.Status == serf.StatusFailed {
+				since, wait := quarantined(server.Addr.String())
+				if wait {
+					continue
+				}
+				removal := ServerRemoval{
+					ID:        server.ID,
+					Name:      member.Name,
+					Address:   server.Addr.String(),
+					Reason:    removalReasonFailed,
+					DeadSince: since,
+				}
+
 				// If the node is a nonvoter, we can remove it immediately.
 				if found && s.Suffrage == raft.Nonvoter {
-					a.logger.Printf("[INFO] autopilot: Attempting removal of failed server node %q", member.Name)
-					go serfLAN.RemoveFailedNode(member.Name)
+					failedNonvoters = append(failedNonvoters, removal)
 				} else {
-					failed = append(failed, member.Name)
+					failed = append(failed, removal)
 				}
 			}
 		}
 	}
 
+	var stale []ServerRemoval
+	for addr, raftServer := range staleRaftServers {
+		since, wait := quarantined(addr)
+		if wait {
+			continue
+		}
+		stale = append(stale, ServerRemoval{
+			ID:        string(raftServer.ID),
+			Address:   addr,
+			Reason:    removalReasonStale,
+			DeadSince: since,
+		})
+	}
+
+	// Nonvoters are removed first since they don't affect the quorum.
+	var removed, deferred uint64
+	take := func() bool {
+		if conf.MaxDeadServerRemovals > 0 && removed >= conf.MaxDeadServerRemovals {
+			deferred++
+			return false
+		}
+		removed++
+		return true
+	}
+	defer func() {
+		if deferred > 0 {
+			a.logger.Printf("[DEBUG] autopilot: Deferring removal of %d dead servers, limit of %d removals reached", deferred, conf.MaxDeadServerRemovals)
+		}
+	}()
+	for _, removal := range failedNonvoters {
+		if !take() {
+			continue
+		}
+		a.logger.Printf("[INFO] autopilot: Attempting removal of failed server node %q", removal.Name)
+		go serfLAN.RemoveFailedNode(removal.Name)
+		a.recordRemoval(removal)
+	}
+
 	// We can bail early if there's nothing to do.
-	removalCount := len(failed) + len(staleRaftServers)
+	removalCount := len(failed) + len(stale)
 	if removalCount == 0 {
 		return nil
 	}
 
-	// Only do removals if a minority of servers will be affected.
+	// Only do removals if a minority of servers will be affected. This
+	// counts all dead servers even if the limit removes fewer of them.
 	peers := NumPeers(raftConfig)
 	if removalCount < peers/2 {
-		for _, node := range failed {
-			a.logger.Printf("[INFO] autopilot: Attempting removal of failed server node %q", node)
-			go serfLAN.RemoveFailedNode(node)
+		for _, removal := range failed {
+			if !take() {
+				continue
+			}
+			a.logger.Printf("[INFO] autopilot: Attempting removal of failed server node %q", removal.Name)
+			go serfLAN.RemoveFailedNode(removal.Name)
+			a.recordRemoval(removal)
 		}
 
 		minRaftProtocol, err := a.MinRaftProtocol()
 		if err != nil {
 			return err
 		}
-		for _, raftServer := range staleRaftServers {
+		for _, removal := range stale {
+			if !take() {
+				continue
+			}
+			raftServer := raft.Server{ID: raft.ServerID(removal.ID), Address: raft.ServerAddress(removal.Address)}
 			a.logger.Printf("[INFO] autopilot: Attempting removal of stale %s", fmtServer(raftServer))
 			var future raft.Future
 			if minRaftProtocol >= 2 {
@@ -250,6 +344,7 @@ func (a *Autopilot) pruneDeadServers() error {
 			if err := future.Error(); err != nil {
 				return err
 			}
+			a.recordRemoval(removal)
 		}
 	} else {
 		a.logger.Printf("[DEBUG] autopilot: Failed to remove dead servers: too many dead servers: %d/%d", removalCount, peers)
@@ -258,6 +353,28 @@ func (a *Autopilot) pruneDeadServers() error {
 	return nil
 }
 
+// recordRemoval adds a removal to the list returned by GetRemovals.
+func (a *Autopilot) recordRemoval(removal ServerRemoval) {
+	removal.RemovedAt = time.Now()
+
+	a.removalsLock.Lock()
+	defer a.removalsLock.Unlock()
+	a.removals = append(a.removals, removal)
+	if len(a.removals) > maxRemovals {
+		a.removals = a.removals[len(a.removals)-maxRemovals:]
+	}
+}
+
+// GetRemovals returns the dead servers removed since this server became the
+// leader, oldest first. Only the most recent removals are kept.
+func (a *Autopilot) GetRemovals() OperatorRemovalsReply {
+	a.removalsLock.RLock()
+	defer a.removalsLock.RUnlock()
+	removals := make([]ServerRemoval, len(a.removals))
+	copy(removals, a.removals)
+	return OperatorRemovalsReply{Removals: removals}
+}
+
 // MinRaftProtocol returns the lowest supported Raft protocol among alive servers
 func (a *Autopilot) MinRaftProtocol() (int, error) {
 	return minRaftProtocol(a.delegate.Serf().Members(), a.delegate.IsServer)
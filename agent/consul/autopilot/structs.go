@@ -12,6 +12,15 @@ type Config struct {
 	// server is added to the Raft peers.
 	CleanupDeadServers bool
 
+	// DeadServerQuarantineTime is the minimum amount of time a server must
+	// be dead before it's removed, so servers that are only restarted aren't
+	// removed. Zero removes dead servers as soon as they are noticed.
+	DeadServerQuarantineTime time.Duration
+
+	// MaxDeadServerRemovals is the maximum number of dead servers removed
+	// each time Autopilot checks for dead servers. Zero means no limit.
+	MaxDeadServerRemovals uint64
+
 	// LastContactThreshold is the limit on the amount of time a server can go
 	// without leader contact before being considered unhealthy.
 	LastContactThreshold time.Duration
@@ -123,6 +132,35 @@ func (h *ServerHealth) IsStable(now time.Time, conf *Config) bool {
 	return true
 }
 
+// ServerRemoval records the removal of a dead server by Autopilot.
+type ServerRemoval struct {
+	// ID is the raft ID of the server.
+	ID string
+
+	// Name is the node name of the server. It's empty for servers that were
+	// only known to Raft.
+	Name string
+
+	// Address is the address of the server.
+	Address string
+
+	// Reason is "failed" for servers that Serf reported as failed and
+	// "stale" for servers known to Raft but not to Serf.
+	Reason string
+
+	// DeadSince is the time Autopilot first noticed the server was dead.
+	DeadSince time.Time
+
+	// RemovedAt is the time the server was removed.
+	RemovedAt time.Time
+}
+
+// OperatorRemovalsReply is the list of the servers the current leader
+// removed, oldest first.
+type OperatorRemovalsReply struct {
+	Removals []ServerRemoval
+}
+
 // ServerStats holds miscellaneous Raft metrics for a server
 type ServerStats struct {
 	// LastContact is the time since this node's last contact with the leader.
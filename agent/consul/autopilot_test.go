@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/hashicorp/raft"
@@ -301,6 +302,69 @@ func TestAutopilot_CleanupStaleRaftServer(t *testing.T) {
 	}
 }
 
+func TestAutopilot_DeadServerQuarantine(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.AutopilotConfig.DeadServerQuarantineTime = time.Hour
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	dir2, s2 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	dir3, s3 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir3)
+	defer s3.Shutdown()
+
+	dir4, s4 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir4)
+	defer s4.Shutdown()
+
+	servers := []*Server{s1, s2, s3}
+	for _, s := range servers[1:] {
+		joinLAN(t, s, s1)
+	}
+	for _, s := range servers {
+		retry.Run(t, func(r *retry.R) { r.Check(wantPeers(s, 3)) })
+	}
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Add s4 to peers directly, it stays while it's quarantined.
+	s1.raft.AddVoter(raft.ServerID(s4.config.NodeID), raft.ServerAddress(joinAddrLAN(s4)), 0, 0)
+	time.Sleep(500 * time.Millisecond)
+	if err := wantPeers(s1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if removals := s1.autopilot.GetRemovals().Removals; len(removals) != 0 {
+		t.Fatalf("bad: %v", removals)
+	}
+
+	// Once the quarantine is lifted s4 is removed and the removal recorded.
+	conf := *s1.getOrCreateAutopilotConfig()
+	conf.DeadServerQuarantineTime = 0
+	arg := structs.AutopilotSetConfigRequest{Datacenter: "dc1", Config: conf}
+	var reply bool
+	if err := s1.RPC("Operator.AutopilotSetConfiguration", &arg, &reply); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range servers {
+		retry.Run(t, func(r *retry.R) { r.Check(wantPeers(s, 3)) })
+	}
+
+	removals := s1.autopilot.GetRemovals().Removals
+	if len(removals) != 1 {
+		t.Fatalf("bad: %v", removals)
+	}
+	if removals[0].ID != string(s4.config.NodeID) ||
+		removals[0].Address != joinAddrLAN(s4) ||
+		removals[0].Reason != "stale" ||
+		removals[0].DeadSince.After(removals[0].RemovedAt) {
+		t.Fatalf("bad: %v", removals[0])
+	}
+}
+
 func TestAutopilot_PromoteNonVoter(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServerWithConfig(t, func(c *Config) {
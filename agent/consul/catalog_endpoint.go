@@ -86,6 +86,10 @@ func checkPreApply(check *structs.HealthCheck) {
 	}
 }
 
+// bulkRegisterBatchSize is the maximum number of registrations of a bulk
+// request applied with a single Raft log entry.
+const bulkRegisterBatchSize = 64
+
 // Register is used register that a node is providing a given service.
 func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error {
 	if done, err := c.srv.forward("Catalog.Register", args, args, reply); done {
@@ -99,6 +103,23 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 		return err
 	}
 
+	if err := c.registerPreApply(args, rule); err != nil {
+		return err
+	}
+
+	resp, err := c.srv.raftApply(structs.RegisterRequestType, args)
+	if err != nil {
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+	return nil
+}
+
+// registerPreApply does the verification of a register request before it is
+// applied to Raft, including the ACL checks.
+func (c *Catalog) registerPreApply(args *structs.RegisterRequest, rule acl.Authorizer) error {
 	// Verify the args.
 	if err := nodePreApply(args.Node, string(args.ID)); err != nil {
 		return err
@@ -137,14 +158,88 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 			return err
 		}
 	}
+	return nil
+}
+
+// BulkRegister registers many nodes, services and checks at once. Each
+// registration is verified like with Register, and the valid ones are
+// applied to Raft in batches. A registration which fails doesn't affect the
+// others; the failures are reported in the reply along with the number of
+// registrations which were applied.
+func (c *Catalog) BulkRegister(args *structs.BulkRegisterRequest, reply *structs.BulkRegisterResponse) error {
+	if done, err := c.srv.forward("Catalog.BulkRegister", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"catalog", "bulk_register"}, time.Now())
 
-	resp, err := c.srv.raftApply(structs.RegisterRequestType, args)
+	// Fetch the ACL token, if any.
+	rule, err := c.srv.ResolveToken(args.Token)
 	if err != nil {
 		return err
 	}
-	if respErr, ok := resp.(error); ok {
-		return respErr
+
+	reply.Registered = 0
+	reply.Errors = nil
+	fail := func(i int, err error) {
+		e := structs.BulkRegisterError{Index: i, Error: err.Error()}
+		if reg := args.Registrations[i]; reg != nil {
+			e.Node = reg.Node
+		}
+		reply.Errors = append(reply.Errors, e)
+	}
+
+	// Verify the registrations, keeping track of the position of the valid
+	// ones in the request.
+	var valid []int
+	for i, reg := range args.Registrations {
+		if reg == nil {
+			fail(i, fmt.Errorf("Missing registration"))
+			continue
+		}
+		reg.Datacenter = args.Datacenter
+		if err := c.registerPreApply(reg, rule); err != nil {
+			fail(i, err)
+			continue
+		}
+		valid = append(valid, i)
 	}
+
+	// Apply the valid registrations in batches.
+	for start := 0; start < len(valid); start += bulkRegisterBatchSize {
+		end := start + bulkRegisterBatchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+		batch := valid[start:end]
+
+		req := structs.BulkRegisterRequest{
+			Datacenter:    args.Datacenter,
+			Registrations: make([]*structs.RegisterRequest, len(batch)),
+		}
+		for j, i := range batch {
+			req.Registrations[j] = args.Registrations[i]
+		}
+
+		resp, err := c.srv.raftApply(structs.BulkRegisterRequestType, &req)
+		if err != nil {
+			for _, i := range batch {
+				fail(i, err)
+			}
+			continue
+		}
+		errs, _ := resp.([]error)
+		for j, i := range batch {
+			if j < len(errs) && errs[j] != nil {
+				fail(i, errs[j])
+				continue
+			}
+			reply.Registered++
+		}
+	}
+
+	sort.Slice(reply.Errors, func(i, j int) bool {
+		return reply.Errors[i].Index < reply.Errors[j].Index
+	})
 	return nil
 }
 
@@ -266,6 +361,9 @@ func (c *Catalog) ListServices(args *structs.DCSpecificRequest, reply *structs.I
 			}
 
 			reply.Index, reply.Services = index, services
+			if err := c.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
 			return c.srv.filterACL(args.Token, reply)
 		})
 }
@@ -346,6 +444,9 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 				}
 				reply.ServiceNodes = filtered
 			}
+			if err := c.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
 			if err := c.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
@@ -410,6 +511,121 @@ func (c *Catalog) NodeServices(args *structs.NodeSpecificRequest, reply *structs
 			}
 
 			reply.Index, reply.NodeServices = index, services
+			if err := c.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
+			return c.srv.filterACL(args.Token, reply)
+		})
+}
+
+// VirtualIPForService returns the virtual IP assigned to a service that
+// supports Connect.
+func (c *Catalog) VirtualIPForService(args *structs.ServiceSpecificRequest, reply *structs.IndexedServiceVirtualIP) error {
+	if done, err := c.srv.forward("Catalog.VirtualIPForService", args, args, reply); done {
+		return err
+	}
+
+	// Verify the arguments
+	if args.ServiceName == "" {
+		return fmt.Errorf("Must provide service name")
+	}
+
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.ServiceRead(args.ServiceName) {
+		return acl.ErrPermissionDenied
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, vip, err := state.VirtualIPForService(ws, args.ServiceName)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.VirtualIP = index, vip
+			return nil
+		})
+}
+
+// WeightOverride sets or deletes the weight override of a service instance.
+// The override replaces the registered weights of the instance in health
+// and DNS results until it is deleted or the instance is deregistered, and a
+// zero passing weight drains the instance from DNS and Connect traffic.
+func (c *Catalog) WeightOverride(args *structs.ServiceWeightOverrideRequest, reply *struct{}) error {
+	if done, err := c.srv.forward("Catalog.WeightOverride", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"catalog", "weight_override"}, time.Now())
+
+	// Verify the args
+	override := &args.Override
+	if override.Node == "" || override.ServiceID == "" {
+		return fmt.Errorf("Must provide node and service ID")
+	}
+	switch args.Op {
+	case structs.ServiceWeightOverrideSet:
+		if err := structs.ValidateWeightOverride(&override.Weights); err != nil {
+			return fmt.Errorf("Invalid weights: %v", err)
+		}
+	case structs.ServiceWeightOverrideDelete:
+	default:
+		return fmt.Errorf("Invalid weight override operation %q", args.Op)
+	}
+
+	_, ns, err := c.srv.fsm.State().NodeService(override.Node, override.ServiceID)
+	if err != nil {
+		return fmt.Errorf("Service lookup failed: %v", err)
+	}
+	if ns == nil {
+		// Overrides are removed along with the instance so there is nothing
+		// left to delete.
+		if args.Op == structs.ServiceWeightOverrideDelete {
+			return nil
+		}
+		return fmt.Errorf("Unknown service %q on node %q", override.ServiceID, override.Node)
+	}
+
+	// Overriding the weights of an instance requires the same permission as
+	// registering it.
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.ServiceWrite(ns.Service, nil) {
+		return acl.ErrPermissionDenied
+	}
+
+	resp, err := c.srv.raftApply(structs.ServiceWeightOverrideRequestType, args)
+	if err != nil {
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+	return nil
+}
+
+// WeightOverrides lists the weight overrides of service instances.
+func (c *Catalog) WeightOverrides(args *structs.DCSpecificRequest, reply *structs.IndexedServiceWeightOverrides) error {
+	if done, err := c.srv.forward("Catalog.WeightOverrides", args, args, reply); done {
+		return err
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, overrides, err := state.ServiceWeightOverrides(ws)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Overrides = index, overrides
 			return c.srv.filterACL(args.Token, reply)
 		})
 }
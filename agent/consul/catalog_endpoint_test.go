@@ -50,6 +50,118 @@ func TestCatalog_Register(t *testing.T) {
 	}
 }
 
+func TestCatalog_BulkRegister(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Use enough registrations to need several batches.
+	args := structs.BulkRegisterRequest{Datacenter: "dc1"}
+	for i := 0; i < 2*bulkRegisterBatchSize+1; i++ {
+		args.Registrations = append(args.Registrations, &structs.RegisterRequest{
+			Node:    fmt.Sprintf("node%d", i),
+			Address: "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: "db",
+				ID:      fmt.Sprintf("db%d", i),
+				Port:    8000,
+			},
+			Check: &structs.HealthCheck{
+				CheckID:   types.CheckID(fmt.Sprintf("db%d-check", i)),
+				ServiceID: fmt.Sprintf("db%d", i),
+			},
+		})
+	}
+
+	// One registration fails verification, and another one fails when it's
+	// applied, since its check belongs to an unknown service.
+	args.Registrations[3].Address = ""
+	args.Registrations[bulkRegisterBatchSize+2].Checks = structs.HealthChecks{
+		&structs.HealthCheck{CheckID: "bad", ServiceID: "nope"},
+	}
+
+	var out structs.BulkRegisterResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.BulkRegister", &args, &out))
+	require.Equal(t, 2*bulkRegisterBatchSize-1, out.Registered)
+	require.Len(t, out.Errors, 2)
+	require.Equal(t, 3, out.Errors[0].Index)
+	require.Equal(t, "node3", out.Errors[0].Node)
+	require.Contains(t, out.Errors[0].Error, "Must provide address")
+	require.Equal(t, bulkRegisterBatchSize+2, out.Errors[1].Index)
+	require.Contains(t, out.Errors[1].Error, "Missing service registration")
+
+	state := s1.fsm.State()
+	_, nodes, err := state.ServiceNodes(nil, "db")
+	require.NoError(t, err)
+	require.Len(t, nodes, 2*bulkRegisterBatchSize-1)
+	_, checks, err := state.NodeChecks(nil, "node0")
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	require.Equal(t, "db", checks[0].ServiceName)
+}
+
+func TestCatalog_BulkRegister_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+		c.ACLEnforceVersion8 = false
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	// Create the ACL.
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name: "User token",
+			Type: structs.ACLTokenTypeClient,
+			Rules: `
+service "foo" {
+	policy = "write"
+}
+`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &id))
+
+	// Only the registration of the "foo" service is allowed.
+	args := structs.BulkRegisterRequest{
+		Datacenter: "dc1",
+		Registrations: []*structs.RegisterRequest{
+			{
+				Node:    "foo",
+				Address: "127.0.0.1",
+				Service: &structs.NodeService{Service: "db", Port: 8000},
+			},
+			{
+				Node:    "foo",
+				Address: "127.0.0.1",
+				Service: &structs.NodeService{Service: "foo", Port: 8000},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: id},
+	}
+	var out structs.BulkRegisterResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.BulkRegister", &args, &out))
+	require.Equal(t, 1, out.Registered)
+	require.Len(t, out.Errors, 1)
+	require.Equal(t, 0, out.Errors[0].Index)
+	require.Equal(t, acl.ErrPermissionDenied.Error(), out.Errors[0].Error)
+}
+
 func TestCatalog_RegisterService_InvalidAddress(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -2570,3 +2682,168 @@ func TestCatalog_NodeServices_FilterACL(t *testing.T) {
 		t.Fatalf("bad: %#v", reply.NodeServices)
 	}
 }
+
+func TestCatalog_VirtualIPForService(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web",
+	}
+	var out structs.IndexedServiceVirtualIP
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.VirtualIPForService", &args, &out))
+	require.Nil(out.VirtualIP)
+
+	// Register a proxy for the service
+	reg := structs.TestRegisterRequestProxy(t)
+	reg.Service.Proxy.DestinationServiceName = "web"
+	var regOut struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.Register", reg, &regOut))
+
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.VirtualIPForService", &args, &out))
+	require.NotNil(out.VirtualIP)
+	require.Equal("web", out.VirtualIP.Service)
+	require.Equal("240.0.0.1", out.VirtualIP.IP)
+}
+
+func TestCatalog_VirtualIPForService_ACLDeny(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir, token, srv, codec := testACLFilterServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+	defer codec.Close()
+
+	args := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "bar",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var out structs.IndexedServiceVirtualIP
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.VirtualIPForService", &args, &out)
+	require.True(acl.IsErrPermissionDenied(err))
+
+	args.ServiceName = "foo"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.VirtualIPForService", &args, &out))
+}
+
+func TestCatalog_WeightOverride(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	reg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			ID:      "web1",
+			Service: "web",
+			Port:    80,
+		},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.Register", &reg, &out))
+
+	// Drain the instance.
+	args := structs.ServiceWeightOverrideRequest{
+		Datacenter: "dc1",
+		Op:         structs.ServiceWeightOverrideSet,
+		Override: structs.ServiceWeightOverride{
+			Node:      "foo",
+			ServiceID: "web1",
+		},
+	}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out))
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web",
+	}
+	var nodes structs.IndexedCheckServiceNodes
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &nodes))
+	require.Len(nodes.Nodes, 1)
+	require.True(nodes.Nodes[0].Service.Drained())
+
+	list := structs.DCSpecificRequest{Datacenter: "dc1"}
+	var overrides structs.IndexedServiceWeightOverrides
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverrides", &list, &overrides))
+	require.Len(overrides.Overrides, 1)
+	require.Equal("web", overrides.Overrides[0].Service)
+
+	// Invalid weights and unknown instances are rejected.
+	args.Override.Weights.Passing = -1
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out)
+	require.Error(err)
+	require.Contains(err.Error(), "Invalid weights")
+
+	args.Override.Weights.Passing = 1
+	args.Override.ServiceID = "nope"
+	err = msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out)
+	require.Error(err)
+	require.Contains(err.Error(), "Unknown service")
+
+	// Deleting the override restores the registered weights.
+	args.Op = structs.ServiceWeightOverrideDelete
+	args.Override.ServiceID = "web1"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out))
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &nodes))
+	require.False(nodes.Nodes[0].Service.Drained())
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverrides", &list, &overrides))
+	require.Len(overrides.Overrides, 0)
+}
+
+func TestCatalog_WeightOverride_ACLDeny(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir, token, srv, codec := testACLFilterServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+	defer codec.Close()
+
+	args := structs.ServiceWeightOverrideRequest{
+		Datacenter: "dc1",
+		Op:         structs.ServiceWeightOverrideSet,
+		Override: structs.ServiceWeightOverride{
+			Node:      srv.config.NodeName,
+			ServiceID: "bar",
+		},
+		WriteRequest: structs.WriteRequest{Token: token},
+	}
+	var out struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out)
+	require.True(acl.IsErrPermissionDenied(err))
+
+	args.Override.ServiceID = "foo"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out))
+
+	args.Override.ServiceID = "bar"
+	args.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverride", &args, &out))
+
+	// Only the overrides of readable services are listed.
+	list := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var overrides structs.IndexedServiceWeightOverrides
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.WeightOverrides", &list, &overrides))
+	require.Len(overrides.Overrides, 1)
+	require.Equal("foo", overrides.Overrides[0].ServiceID)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/router"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/tracing"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/serf/serf"
@@ -119,18 +120,25 @@ func NewClientLogger(config *Config, logger *log.Logger, tlsConfigurator *tlsuti
 		return nil, err
 	}
 
+	// Create the TLS wrapper for outgoing gRPC connections.
+	alpnWrap, err := tlsConfigurator.OutgoingALPNRPCWrapper()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a logger
 	if logger == nil {
 		logger = log.New(config.LogOutput, "", log.LstdFlags)
 	}
 
 	connPool := &pool.ConnPool{
-		SrcAddr:    config.RPCSrcAddr,
-		LogOutput:  config.LogOutput,
-		MaxTime:    clientRPCConnMaxIdle,
-		MaxStreams: clientMaxStreams,
-		TLSWrapper: tlsWrap,
-		ForceTLS:   config.VerifyOutgoing,
+		SrcAddr:     config.RPCSrcAddr,
+		LogOutput:   config.LogOutput,
+		MaxTime:     clientRPCConnMaxIdle,
+		MaxStreams:  clientMaxStreams,
+		TLSWrapper:  tlsWrap,
+		ALPNWrapper: alpnWrap,
+		ForceTLS:    config.VerifyOutgoing,
 	}
 
 	// Create client
@@ -275,6 +283,19 @@ func (c *Client) Encrypted() bool {
 
 // RPC is used to forward an RPC call to a consul server, or fail if no servers
 func (c *Client) RPC(method string, args interface{}, reply interface{}) error {
+	// Only servers forwarding a request to another datacenter may set the
+	// datacenter it comes from.
+	structs.SetSourceDatacenter(args, "")
+
+	span, endSpan := startRPCSpan("rpc "+method, args)
+	err := c.rpc(method, args, reply, span)
+	endSpan(err)
+	return err
+}
+
+// rpc makes an RPC call to a consul server, retrying with another server
+// while it's safe to. The server tried last is recorded on the given span.
+func (c *Client) rpc(method string, args interface{}, reply interface{}, span *tracing.Span) error {
 	// This is subtle but we start measuring the time on the client side
 	// right at the time of the first request, vs. on the first retry as
 	// is done on the server side inside forward(). This is because the
@@ -297,13 +318,14 @@ TRY:
 	}
 
 	// Make the request.
+	span.SetAttribute("consul.server", server.Addr.String())
 	rpcErr := c.connPool.RPC(c.config.Datacenter, server.Addr, server.Version, method, server.UseTLS, args, reply)
 	if rpcErr == nil {
 		return nil
 	}
 
 	// Move off to another server, and see if we can retry.
-	c.logger.Printf("[ERR] consul: %q RPC failed to server %s: %v", method, server.Addr, rpcErr)
+	c.logger.Printf("[ERR] consul: %q RPC failed to server %s: %v request_id=%s", method, server.Addr, rpcErr, structs.RequestID(args))
 	metrics.IncrCounterWithLabels([]string{"client", "rpc", "failed"}, 1, []metrics.Label{{Name: "server", Value: server.Name}})
 	c.routers.NotifyFailedServer(server)
 	if retry := canRetry(args, rpcErr); !retry {
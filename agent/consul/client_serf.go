@@ -75,7 +75,8 @@ func (c *Client) lanEventHandler() {
 			case serf.EventUser:
 				c.localEvent(e.(serf.UserEvent))
 			case serf.EventMemberUpdate: // Ignore
-			case serf.EventQuery: // Ignore
+			case serf.EventQuery:
+				handleKeyringStatusQuery(c.logger, e.(*serf.Query), c.config.SerfLANConfig.MemberlistConfig.Keyring)
 			default:
 				c.logger.Printf("[WARN] consul: unhandled LAN Serf Event: %#v", e)
 			}
@@ -384,7 +384,7 @@ func TestClient_RPC_ConsulServerPing(t *testing.T) {
 	for range servers {
 		time.Sleep(200 * time.Millisecond)
 		s := c.routers.FindServer()
-		ok, err := c.connPool.Ping(s.Datacenter, s.Addr, s.Version, s.UseTLS)
+		ok, err := c.connPool.Ping(s.Datacenter, s.Addr, s.Version, s.UseTLS, s.UseGRPC)
 		if !ok {
 			t.Errorf("Unable to ping server %v: %s", s.String(), err)
 		}
@@ -0,0 +1,82 @@
+package consul
+
+import (
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-uuid"
+)
+
+// initializeClusterIdentity persists the identity of the cluster if it
+// isn't set yet. The ID is the bootstrap cluster ID if one is configured,
+// otherwise a new UUID.
+func (s *Server) initializeClusterIdentity() error {
+	identity, err := s.fsm.State().ClusterIdentity(nil)
+	if err != nil {
+		return err
+	}
+	if identity != nil {
+		if s.config.BootstrapClusterID != "" && identity.ID != s.config.BootstrapClusterID {
+			s.logger.Printf("[WARN] consul: The cluster ID is %q, ignoring the configured bootstrap_cluster_id %q",
+				identity.ID, s.config.BootstrapClusterID)
+		}
+		return nil
+	}
+
+	id := s.config.BootstrapClusterID
+	if id == "" {
+		if id, err = uuid.GenerateUUID(); err != nil {
+			return err
+		}
+	}
+
+	// Older servers ignore the identity, they don't check it anyway.
+	req := structs.ClusterIdentity{ID: id}
+	if _, err := s.raftApply(structs.ClusterIdentityRequestType|structs.IgnoreUnknownTypeFlag, &req); err != nil {
+		return err
+	}
+	s.logger.Printf("[INFO] consul: Initialized the cluster ID %q", id)
+	return nil
+}
+
+// clusterID returns the ID of the cluster the server belongs to. Until the
+// identity is persisted it is the bootstrap cluster ID, which may be empty.
+func (s *Server) clusterID() string {
+	identity, err := s.fsm.State().ClusterIdentity(nil)
+	if err != nil {
+		s.logger.Printf("[ERR] consul: Failed to read the cluster ID: %v", err)
+	} else if identity != nil {
+		return identity.ID
+	}
+	return s.config.BootstrapClusterID
+}
+
+// advertiseClusterID keeps the cluster ID in the serf tags of the server up
+// to date until the server shuts down.
+func (s *Server) advertiseClusterID() {
+	advertised := s.serfLAN.LocalMember().Tags["cluster_id"]
+	for {
+		state := s.fsm.State()
+		ws := memdb.NewWatchSet()
+		ws.Add(state.AbandonCh())
+		ws.Add(s.shutdownCh)
+
+		identity, err := state.ClusterIdentity(ws)
+		if err != nil {
+			s.logger.Printf("[ERR] consul: Failed to read the cluster ID: %v", err)
+		} else if identity != nil && identity.ID != advertised {
+			lib.UpdateSerfTag(s.serfLAN, "cluster_id", identity.ID)
+			if s.serfWAN != nil {
+				lib.UpdateSerfTag(s.serfWAN, "cluster_id", identity.ID)
+			}
+			advertised = identity.ID
+		}
+
+		ws.Watch(nil)
+		select {
+		case <-s.shutdownCh:
+			return
+		default:
+		}
+	}
+}
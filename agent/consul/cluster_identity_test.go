@@ -0,0 +1,95 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ClusterIdentity(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The leader generates the cluster ID and the server advertises it.
+	var id string
+	retry.Run(t, func(r *retry.R) {
+		if err := msgpackrpc.CallWithCodec(codec, "Status.ClusterID", struct{}{}, &id); err != nil {
+			r.Fatal(err)
+		}
+		if id == "" {
+			r.Fatal("no cluster ID")
+		}
+	})
+	retry.Run(t, func(r *retry.R) {
+		if got := s1.serfLAN.LocalMember().Tags["cluster_id"]; got != id {
+			r.Fatalf("got LAN cluster ID %q, want %q", got, id)
+		}
+		if got := s1.serfWAN.LocalMember().Tags["cluster_id"]; got != id {
+			r.Fatalf("got WAN cluster ID %q, want %q", got, id)
+		}
+	})
+
+	// The ID doesn't change with a new leadership.
+	require.NoError(t, s1.revokeLeadership())
+	require.NoError(t, s1.establishLeadership())
+	var after string
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Status.ClusterID", struct{}{}, &after))
+	require.Equal(t, id, after)
+}
+
+func TestServer_ClusterIdentity_Configured(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.BootstrapClusterID = "prod"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	// The bootstrap cluster ID is advertised right away.
+	require.Equal(t, "prod", s1.serfLAN.LocalMember().Tags["cluster_id"])
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	retry.Run(t, func(r *retry.R) {
+		if id := s1.clusterID(); id != "prod" {
+			r.Fatalf("got cluster ID %q", id)
+		}
+	})
+}
+
+func TestServer_ClusterIdentity_RejectJoin(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	// A second cluster bootstrapped on its own.
+	dir2, s2 := testServer(t)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s2.RPC, "dc1")
+	retry.Run(t, func(r *retry.R) {
+		if s1.serfLAN.LocalMember().Tags["cluster_id"] == "" || s2.serfLAN.LocalMember().Tags["cluster_id"] == "" {
+			r.Fatal("cluster IDs not advertised")
+		}
+	})
+
+	_, err := s2.JoinLAN([]string{joinAddrLAN(s1)})
+	require.Error(t, err)
+	require.Len(t, s1.LANMembers(), 1)
+
+	_, err = s2.JoinWAN([]string{joinAddrWAN(s1)})
+	require.Error(t, err)
+	require.Len(t, s1.WANMembers(), 1)
+}
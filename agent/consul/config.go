@@ -75,6 +75,15 @@ type Config struct {
 	// of nodes.
 	BootstrapExpect int
 
+	// BootstrapClusterID is advertised to the other servers. If it is set
+	// the server refuses to bootstrap with servers advertising a different
+	// cluster ID or whose Raft peers can't be confirmed.
+	BootstrapClusterID string
+
+	// SkipClusterIDCheck allows the server to merge with the servers of
+	// its datacenter that advertise a different cluster ID.
+	SkipClusterIDCheck bool
+
 	// Datacenter is the datacenter this Consul server represents.
 	Datacenter string
 
@@ -136,6 +145,11 @@ type Config struct {
 	// SerfWANConfig is the configuration for the cross-dc serf
 	SerfWANConfig *serf.Config
 
+	// AdvertiseAddrWANMap maps the names of remote datacenters to the IP
+	// address their servers should use to reach this server over the WAN,
+	// instead of the advertise address of SerfWANConfig.
+	AdvertiseAddrWANMap map[string]string
+
 	// SerfFloodInterval controls how often we attempt to flood local Serf
 	// Consul servers into the global areas (WAN and user-defined areas in
 	// Consul Enterprise).
@@ -185,6 +199,12 @@ type Config struct {
 	// VerifyIncoming or VerifyOutgoing to verify the TLS connection.
 	CAPath string
 
+	// CRLFile and CRLPath are paths to a certificate revocation list file and
+	// to a directory of them. Incoming connections presenting a revoked client
+	// certificate are rejected.
+	CRLFile string
+	CRLPath string
+
 	// CertFile is used to provide a TLS certificate that is used for serving TLS connections.
 	// Must be provided to serve TLS connections.
 	CertFile string
@@ -193,13 +213,29 @@ type Config struct {
 	// Must be provided to serve TLS connections.
 	KeyFile string
 
+	// CertBundleFile is a PKCS#12 bundle which is used instead of CertFile
+	// and KeyFile when set.
+	CertBundleFile string
+
+	// KeyPassphrase and KeyPassphraseFile are used to decrypt an encrypted
+	// KeyFile or CertBundleFile.
+	KeyPassphrase     string
+	KeyPassphraseFile string
+
 	// ServerName is used with the TLS certificate to ensure the name we
 	// provide matches the certificate
 	ServerName string
 
+	// DatacenterServerNames overrides the name the certificates of the
+	// servers in a datacenter are verified against.
+	DatacenterServerNames map[string]string
+
 	// TLSMinVersion is used to set the minimum TLS version used for TLS connections.
 	TLSMinVersion string
 
+	// TLSMaxVersion is used to set the maximum TLS version used for TLS connections.
+	TLSMaxVersion string
+
 	// TLSCipherSuites is used to specify the list of supported ciphersuites.
 	TLSCipherSuites []uint16
 
@@ -282,6 +318,10 @@ type Config struct {
 	// used to limit the amount of Raft bandwidth used for replication.
 	ACLReplicationApplyLimit int
 
+	// ACLAllowAnonymousWrite allows linking policies that grant write access
+	// to the anonymous token.
+	ACLAllowAnonymousWrite bool
+
 	// ACLEnableKeyListPolicy is used to gate enforcement of the new "list" policy that
 	// protects listing keys by prefix. This behavior is opt-in
 	// by default in Consul 1.0 and later.
@@ -354,10 +394,49 @@ type Config struct {
 	RPCRate     rate.Limit
 	RPCMaxBurst int
 
+	// RPCMaxConns is the maximum number of RPC connections the server
+	// handles concurrently. Raft connections and connections from other
+	// Consul servers, recognized by their TLS certificate when
+	// VerifyServerHostname is set or else by their address, don't count
+	// against it. Zero means no limit.
+	RPCMaxConns int
+
+	// RPCMaxStreamsPerConn is the maximum number of concurrent streams on a
+	// single multiplexed RPC connection. Zero means no limit.
+	RPCMaxStreamsPerConn int
+
+	// RPCAcceptRate is the number of connections per second the RPC
+	// listener accepts. Zero means no limit.
+	RPCAcceptRate float64
+
+	// RPCMaxConcurrentHandshakes is the maximum number of TLS handshakes
+	// the RPC listener performs concurrently. Zero means no limit.
+	RPCMaxConcurrentHandshakes int
+
+	// RPCAcceptBackpressure makes the server wait for a free slot when
+	// RPCMaxConns or RPCMaxStreamsPerConn is reached instead of rejecting
+	// the connection or stream.
+	RPCAcceptBackpressure bool
+
+	// RPCQueryLimits limits the number of concurrent queries of each
+	// expensive query class ("health", "kv" and "txn"). Classes without a
+	// limit are not limited.
+	RPCQueryLimits map[string]int
+
+	// RPCQueryQueueTimeout is how long a query waits for a free slot when
+	// the limit of its class is reached before it is rejected.
+	RPCQueryQueueTimeout time.Duration
+
 	// LeaveDrainTime is used to wait after a server has left the LAN Serf
 	// pool for RPCs to drain and new requests to be sent to other servers.
 	LeaveDrainTime time.Duration
 
+	// FollowerReads lists the endpoint classes ("catalog", "health", "kv")
+	// for which followers serve reads with the default consistency locally
+	// while they hold a leader lease and once they applied the commit index,
+	// instead of forwarding them to the leader.
+	FollowerReads []string
+
 	// AutopilotConfig is used to apply the initial autopilot config when
 	// bootstrapping.
 	AutopilotConfig *autopilot.Config
@@ -385,11 +464,18 @@ func (c *Config) ToTLSUtilConfig() *tlsutil.Config {
 		VerifyOutgoing:           c.VerifyOutgoing,
 		CAFile:                   c.CAFile,
 		CAPath:                   c.CAPath,
+		CRLFile:                  c.CRLFile,
+		CRLPath:                  c.CRLPath,
 		CertFile:                 c.CertFile,
 		KeyFile:                  c.KeyFile,
+		CertBundleFile:           c.CertBundleFile,
+		KeyPassphrase:            c.KeyPassphrase,
+		KeyPassphraseFile:        c.KeyPassphraseFile,
 		NodeName:                 c.NodeName,
 		ServerName:               c.ServerName,
+		DatacenterServerNames:    c.DatacenterServerNames,
 		TLSMinVersion:            c.TLSMinVersion,
+		TLSMaxVersion:            c.TLSMaxVersion,
 		CipherSuites:             c.TLSCipherSuites,
 		PreferServerCipherSuites: c.TLSPreferServerCipherSuites,
 	}
@@ -463,6 +549,8 @@ func DefaultConfig() *Config {
 		RPCRate:     rate.Inf,
 		RPCMaxBurst: 1000,
 
+		RPCQueryQueueTimeout: 5 * time.Second,
+
 		TLSMinVersion: "tls10",
 
 		// TODO (slackpad) - Until #3744 is done, we need to keep these
@@ -0,0 +1,177 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	memdb "github.com/hashicorp/go-memdb"
+)
+
+// The ConfigEntry endpoint is used to query centralized config information
+type ConfigEntry struct {
+	srv *Server
+}
+
+// Apply does an upsert of the given config entry.
+func (c *ConfigEntry) Apply(args *structs.ConfigEntryRequest, reply *struct{}) error {
+	if done, err := c.srv.forward("ConfigEntry.Apply", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "apply"}, time.Now())
+
+	if args.Entry == nil {
+		return fmt.Errorf("Config entry is required")
+	}
+
+	// Normalize and validate the incoming config entry.
+	if err := args.Entry.Normalize(); err != nil {
+		return err
+	}
+	if err := args.Entry.Validate(); err != nil {
+		return err
+	}
+
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !args.Entry.CanWrite(rule) {
+		return acl.ErrPermissionDenied
+	}
+
+	args.Op = structs.ConfigEntryUpsert
+	args.Audit = c.srv.auditInfo(args.Token)
+	resp, err := c.srv.raftApply(structs.ConfigEntryRequestType, args)
+	if err != nil {
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	return nil
+}
+
+// Get returns a single config entry by Kind/Name.
+func (c *ConfigEntry) Get(args *structs.ConfigEntryQuery, reply *structs.ConfigEntryResponse) error {
+	if done, err := c.srv.forward("ConfigEntry.Get", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "get"}, time.Now())
+
+	// Fetch the ACL token, if any.
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	// Create a dummy config entry to check the ACL permissions.
+	lookupEntry, err := structs.MakeConfigEntry(args.Kind, args.Name)
+	if err != nil {
+		return err
+	}
+
+	if rule != nil && !lookupEntry.CanRead(rule) {
+		return acl.ErrPermissionDenied
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, entry, err := state.ConfigEntry(ws, args.Kind, args.Name)
+			if err != nil {
+				return err
+			}
+
+			reply.Index = index
+			reply.Entry = entry
+			return nil
+		})
+}
+
+// List returns all the config entries of the given kind. If Kind is blank,
+// all existing config entries will be returned.
+func (c *ConfigEntry) List(args *structs.ConfigEntryQuery, reply *structs.IndexedConfigEntries) error {
+	if done, err := c.srv.forward("ConfigEntry.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "list"}, time.Now())
+
+	if args.Kind != "" {
+		if _, err := structs.MakeConfigEntry(args.Kind, ""); err != nil {
+			return err
+		}
+	}
+
+	// Fetch the ACL token, if any.
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, entries, err := state.ConfigEntriesByKind(ws, args.Kind)
+			if err != nil {
+				return err
+			}
+
+			// Filter the entries returned by ACL permissions.
+			filteredEntries := make([]structs.ConfigEntry, 0, len(entries))
+			for _, entry := range entries {
+				if rule != nil && !entry.CanRead(rule) {
+					continue
+				}
+				filteredEntries = append(filteredEntries, entry)
+			}
+
+			reply.Kind = args.Kind
+			reply.Index = index
+			reply.Entries = filteredEntries
+			return nil
+		})
+}
+
+// Delete deletes a config entry.
+func (c *ConfigEntry) Delete(args *structs.ConfigEntryRequest, reply *struct{}) error {
+	if done, err := c.srv.forward("ConfigEntry.Delete", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "delete"}, time.Now())
+
+	if args.Entry == nil {
+		return fmt.Errorf("Config entry is required")
+	}
+
+	// Normalize the incoming entry.
+	if err := args.Entry.Normalize(); err != nil {
+		return err
+	}
+
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !args.Entry.CanWrite(rule) {
+		return acl.ErrPermissionDenied
+	}
+
+	args.Op = structs.ConfigEntryDelete
+	args.Audit = c.srv.auditInfo(args.Token)
+	resp, err := c.srv.raftApply(structs.ConfigEntryRequestType, args)
+	if err != nil {
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigEntry_Apply(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.TerminatingGatewayConfigEntry{
+			Name: "gateway",
+			Services: []structs.LinkedService{
+				{Name: "db", CAFile: "/etc/ssl/ca.pem"},
+			},
+		},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+
+	// Read it back over RPC so the response decoding is exercised too.
+	getArgs := structs.ConfigEntryQuery{
+		Kind:       structs.TerminatingGateway,
+		Name:       "gateway",
+		Datacenter: "dc1",
+	}
+	var resp structs.ConfigEntryResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &getArgs, &resp))
+
+	entry, ok := resp.Entry.(*structs.TerminatingGatewayConfigEntry)
+	require.True(ok)
+	require.Equal(structs.TerminatingGateway, entry.Kind)
+	require.Equal("gateway", entry.Name)
+	require.Equal([]structs.LinkedService{{Name: "db", CAFile: "/etc/ssl/ca.pem"}}, entry.Services)
+	require.Equal(resp.Index, entry.ModifyIndex)
+
+	// Invalid entries are rejected.
+	args.Entry = &structs.TerminatingGatewayConfigEntry{
+		Name: "gateway",
+		Services: []structs.LinkedService{
+			{Name: "db", CertFile: "/etc/ssl/client.pem"},
+		},
+	}
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out)
+	require.Error(err)
+	require.Contains(err.Error(), "KeyFile")
+}
+
+func TestConfigEntry_ListDelete(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	require.NoError(state.EnsureConfigEntry(1, &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gw1",
+	}))
+	require.NoError(state.EnsureConfigEntry(2, &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gw2",
+	}))
+
+	args := structs.ConfigEntryQuery{
+		Kind:       structs.TerminatingGateway,
+		Datacenter: "dc1",
+	}
+	var out structs.IndexedConfigEntries
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.List", &args, &out))
+	require.Len(out.Entries, 2)
+	require.Equal("gw1", out.Entries[0].GetName())
+	require.Equal("gw2", out.Entries[1].GetName())
+
+	delArgs := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.TerminatingGatewayConfigEntry{
+			Name: "gw1",
+		},
+	}
+	var empty struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Delete", &delArgs, &empty))
+
+	_, entry, err := state.ConfigEntry(nil, structs.TerminatingGateway, "gw1")
+	require.NoError(err)
+	require.Nil(entry)
+}
+
+func TestConfigEntry_ACLs(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create a token that can read the gateway but not write config.
+	var token string
+	{
+		req := structs.ACLRequest{
+			Datacenter: "dc1",
+			Op:         structs.ACLSet,
+			ACL: structs.ACL{
+				Name:  "User token",
+				Type:  structs.ACLTokenTypeClient,
+				Rules: `service "gw1" { policy = "read" }`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.Apply", &req, &token))
+	}
+
+	args := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.TerminatingGatewayConfigEntry{
+			Name: "gw1",
+		},
+		WriteRequest: structs.WriteRequest{Token: token},
+	}
+	var out struct{}
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out)
+	require.True(acl.IsErrPermissionDenied(err))
+
+	args.Entry = &structs.TerminatingGatewayConfigEntry{Name: "gw1"}
+	args.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+	args.Entry = &structs.TerminatingGatewayConfigEntry{Name: "gw2"}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+
+	// The token can only see the gateway it has read access to.
+	listArgs := structs.ConfigEntryQuery{
+		Kind:         structs.TerminatingGateway,
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var list structs.IndexedConfigEntries
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.List", &listArgs, &list))
+	require.Len(list.Entries, 1)
+	require.Equal("gw1", list.Entries[0].GetName())
+
+	getArgs := structs.ConfigEntryQuery{
+		Kind:         structs.TerminatingGateway,
+		Name:         "gw2",
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var resp structs.ConfigEntryResponse
+	err = msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &getArgs, &resp)
+	require.True(acl.IsErrPermissionDenied(err))
+}
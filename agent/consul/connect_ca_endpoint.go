@@ -9,12 +9,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/lib/semaphore"
 
 	"golang.org/x/time/rate"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/connect"
+	ca "github.com/hashicorp/consul/agent/connect/ca"
 	"github.com/hashicorp/consul/agent/consul/state"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/go-memdb"
@@ -60,8 +62,9 @@ type ConnectCA struct {
 	// Sign RPC concurrency if configured. The zero value is usable as soon as
 	// SetSize is called which we do dynamically in the RPC handler to avoid
 	// having to hook elaborate synchronization mechanisms through the CA config
-	// endpoint and config reload etc.
-	csrConcurrencyLimiter semaphore.Dynamic
+	// endpoint and config reload etc. Waiting requests are queued per service
+	// so a mass restart of one service can't starve all the others.
+	csrConcurrencyLimiter semaphore.Fair
 }
 
 // getCSRRateLimiterWithLimit returns a rate.Limiter with the desired limit set.
@@ -123,7 +126,7 @@ func (s *ConnectCA) ConfigurationGet(
 	}
 
 	state := s.srv.fsm.State()
-	_, config, err := state.CAConfig()
+	_, config, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
@@ -132,10 +135,11 @@ func (s *ConnectCA) ConfigurationGet(
 	return nil
 }
 
-// ConfigurationSet updates the configuration for the CA.
+// ConfigurationSet updates the configuration for the CA. If the new root is
+// staged, reply is set to its ID.
 func (s *ConnectCA) ConfigurationSet(
 	args *structs.CARequest,
-	reply *interface{}) error {
+	reply *string) error {
 	// Exit early if Connect hasn't been enabled.
 	if !s.srv.config.ConnectEnabled {
 		return ErrConnectNotEnabled
@@ -156,7 +160,7 @@ func (s *ConnectCA) ConfigurationSet(
 
 	// Exit early if it's a no-op change
 	state := s.srv.fsm.State()
-	confIdx, config, err := state.CAConfig()
+	confIdx, config, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
@@ -167,6 +171,12 @@ func (s *ConnectCA) ConfigurationSet(
 		return nil
 	}
 
+	// Don't allow changes while a staged root rotation is in progress since
+	// its activation would overwrite them.
+	if s.srv.caRootRolloutInProgress("") {
+		return fmt.Errorf("a CA root rotation is already in progress")
+	}
+
 	// Create a new instance of the provider described by the config
 	// and get the current active root CA. This acts as a good validation
 	// of the config and makes sure the provider is functioning correctly
@@ -225,6 +235,28 @@ func (s *ConnectCA) ConfigurationSet(
 	// either by swapping the provider type or changing the provider's config
 	// to use a different root certificate.
 
+	// Enforce the minimum interval between rotations set in the current
+	// config.
+	common, err := config.GetCommonConfig()
+	if err != nil {
+		return err
+	}
+	idx, roots, err := state.CARoots(nil)
+	if err != nil {
+		return err
+	}
+	if min := common.RootRotationMinInterval; min > 0 {
+		last := lastCARootRotation(roots)
+		if since := time.Since(last); !last.IsZero() && since < min {
+			return fmt.Errorf("CA root was rotated %s ago, roots can only be rotated every %s",
+				since.Round(time.Second), min)
+		}
+	}
+	newCommon, err := args.Config.GetCommonConfig()
+	if err != nil {
+		return err
+	}
+
 	// If it's a config change that would trigger a rotation (different provider/root):
 	// 1. Get the root from the new provider.
 	// 2. Call CrossSignCA on the old provider to sign the new root with the old one to
@@ -256,14 +288,89 @@ func (s *ConnectCA) ConfigurationSet(
 		newActiveRoot.IntermediateCerts = append(newActiveRoot.IntermediateCerts, intermediate)
 	}
 
-	// Update the roots and CA config in the state store at the same time
-	idx, roots, err := state.CARoots(nil)
+	// If a rollout timeout is configured and some agents need the new root,
+	// stage it first so they start trusting it before any certificates are
+	// signed by it. The leader activates it in the background once the agents
+	// have acknowledged it, and the progress is reported by the roots endpoint.
+	if newCommon.RootRolloutTimeout > 0 {
+		rollout, err := s.srv.startCARootRollout(newActiveRoot.ID, newCommon.RootRolloutTimeout)
+		if err != nil {
+			return err
+		}
+		pending, err := s.srv.pendingCARootAcks(rollout)
+		if err != nil {
+			s.srv.finishCARootRollout(rollout)
+			return err
+		}
+		if len(pending) > 0 {
+			if err := s.srv.stageCARoot(idx, roots, newActiveRoot); err != nil {
+				s.srv.finishCARootRollout(rollout)
+				return err
+			}
+
+			s.srv.logger.Printf("[INFO] connect: staged new root CA (ID: %s), waiting for %d agent(s) to acknowledge it",
+				newActiveRoot.ID, len(pending))
+			go s.srv.runCARootRollout(rollout, newProvider, func() error {
+				return s.srv.activateCARoot(args.Config, confIdx, config.Provider, oldProvider, newProvider, newActiveRoot)
+			})
+
+			// Return the ID of the staged root so its rollout can be followed
+			// on the roots endpoint.
+			*reply = newActiveRoot.ID
+			return nil
+		}
+		s.srv.finishCARootRollout(rollout)
+	}
+
+	return s.srv.activateCARoot(args.Config, confIdx, config.Provider, oldProvider, newProvider, newActiveRoot)
+}
+
+// stageCARoot adds a staged copy of the given root to the trusted roots. It
+// isn't used for signing until it's activated.
+func (s *Server) stageCARoot(idx uint64, roots structs.CARoots, root *structs.CARoot) error {
+	stagedRoot := *root
+	stagedRoot.Active = false
+	stagedRoot.Staged = true
+	stagedRoots := append(roots[:len(roots):len(roots)], &stagedRoot)
+
+	resp, err := s.raftApply(structs.ConnectCARequestType, &structs.CARequest{
+		Op:    structs.CAOpSetRoots,
+		Index: idx,
+		Roots: stagedRoots,
+	})
+	if err != nil {
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+	if respOk, ok := resp.(bool); ok && !respOk {
+		return fmt.Errorf("could not atomically update roots")
+	}
+	return nil
+}
+
+// activateCARoot makes the given root the active one along with the config of
+// its provider, and switches the leader over to the new provider.
+func (s *Server) activateCARoot(
+	config *structs.CAConfiguration,
+	confIdx uint64,
+	oldProviderName string,
+	oldProvider, newProvider ca.Provider,
+	newActiveRoot *structs.CARoot) error {
+	idx, roots, err := s.fsm.State().CARoots(nil)
 	if err != nil {
 		return err
 	}
 
+	// Update the roots and CA config in the state store at the same time
 	var newRoots structs.CARoots
 	for _, r := range roots {
+		if r.ID == newActiveRoot.ID {
+			// This is the staged copy of the new root, which is replaced
+			// by the active one below.
+			continue
+		}
 		newRoot := *r
 		if newRoot.Active {
 			newRoot.Active = false
@@ -273,11 +380,13 @@ func (s *ConnectCA) ConfigurationSet(
 	}
 	newRoots = append(newRoots, newActiveRoot)
 
-	args.Op = structs.CAOpSetRootsAndConfig
-	args.Index = idx
-	args.Config.ModifyIndex = confIdx
-	args.Roots = newRoots
-	resp, err := s.srv.raftApply(structs.ConnectCARequestType, args)
+	config.ModifyIndex = confIdx
+	resp, err := s.raftApply(structs.ConnectCARequestType, &structs.CARequest{
+		Op:     structs.CAOpSetRootsAndConfig,
+		Index:  idx,
+		Config: config,
+		Roots:  newRoots,
+	})
 	if err != nil {
 		return err
 	}
@@ -290,14 +399,42 @@ func (s *ConnectCA) ConfigurationSet(
 
 	// If the config has been committed, update the local provider instance
 	// and call teardown on the old provider
-	s.srv.setCAProvider(newProvider, newActiveRoot)
+	s.setCAProvider(newProvider, newActiveRoot)
 
 	if err := oldProvider.Cleanup(); err != nil {
-		s.srv.logger.Printf("[WARN] connect: failed to clean up old provider %q", config.Provider)
+		s.logger.Printf("[WARN] connect: failed to clean up old provider %q", oldProviderName)
+	}
+
+	s.logger.Printf("[INFO] connect: CA rotated to new root under provider %q", config.Provider)
+
+	return nil
+}
+
+// RootsAck records that an agent has received the given CA roots. During a
+// staged root rotation the leader waits for these acknowledgements before
+// activating the new root.
+func (s *ConnectCA) RootsAck(
+	args *structs.CARootsAckRequest,
+	reply *struct{}) error {
+	// Exit early if Connect hasn't been enabled.
+	if !s.srv.config.ConnectEnabled {
+		return ErrConnectNotEnabled
 	}
 
-	s.srv.logger.Printf("[INFO] connect: CA rotated to new root under provider %q", args.Config.Provider)
+	if done, err := s.srv.forward("ConnectCA.RootsAck", args, args, reply); done {
+		return err
+	}
 
+	// This action requires node write access.
+	rule, err := s.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.NodeWrite(args.Node, nil) {
+		return acl.ErrPermissionDenied
+	}
+
+	s.srv.ackCARoots(args.Node, args.RootIDs)
 	return nil
 }
 
@@ -324,7 +461,7 @@ func (s *ConnectCA) Roots(
 	// of config during the blocking query below.
 	{
 		state := s.srv.fsm.State()
-		_, config, err := state.CAConfig()
+		_, config, err := state.CAConfig(nil)
 		if err != nil {
 			return err
 		}
@@ -374,6 +511,7 @@ func (s *ConnectCA) Roots(
 					IntermediateCerts:   r.IntermediateCerts,
 					RaftIndex:           r.RaftIndex,
 					Active:              r.Active,
+					Staged:              r.Staged,
 				}
 
 				if r.Active {
@@ -381,15 +519,74 @@ func (s *ConnectCA) Roots(
 				}
 			}
 
+			// Report the progress of a staged rotation, which only the leader
+			// tracks. Acknowledgements don't wake up blocking queries.
+			reply.Rollout, err = s.srv.caRootRolloutStatus()
+			if err != nil {
+				return err
+			}
+
+			// Add the roots of the federated trust domains. We watch the config
+			// too so changes to the bundles wake up blocking queries.
+			_, config, err := state.CAConfig(ws)
+			if err != nil {
+				return err
+			}
+			if config == nil {
+				return nil
+			}
+			common, err := config.GetCommonConfig()
+			if err != nil {
+				return err
+			}
+			reply.FederatedTrustDomains = nil
+			reply.FederatedRoots = nil
+			for _, bundle := range common.FederatedTrustBundles {
+				roots, err := federatedCARoots(bundle)
+				if err != nil {
+					s.srv.logger.Printf("[WARN] connect: ignoring trust bundle of %q: %v", bundle.TrustDomain, err)
+					continue
+				}
+				reply.FederatedTrustDomains = append(reply.FederatedTrustDomains, bundle.TrustDomain)
+				reply.FederatedRoots = append(reply.FederatedRoots, roots...)
+			}
+
 			return nil
 		},
 	)
 }
 
+// federatedCARoots returns the roots of a federated trust bundle. They are
+// never active since they can't be used for signing.
+func federatedCARoots(bundle structs.FederatedTrustBundle) (structs.CARoots, error) {
+	var roots structs.CARoots
+	for _, pem := range bundle.RootCerts {
+		id, err := connect.CalculateCertFingerprint(pem)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing root fingerprint: %v", err)
+		}
+		cert, err := connect.ParseCert(pem)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing root cert: %v", err)
+		}
+		roots = append(roots, &structs.CARoot{
+			ID:                  id,
+			Name:                fmt.Sprintf("Federated %s Root Cert", bundle.TrustDomain),
+			SerialNumber:        cert.SerialNumber.Uint64(),
+			SigningKeyID:        connect.HexString(cert.SubjectKeyId),
+			ExternalTrustDomain: bundle.TrustDomain,
+			NotBefore:           cert.NotBefore,
+			NotAfter:            cert.NotAfter,
+			RootCert:            pem,
+		})
+	}
+	return roots, nil
+}
+
 // Sign signs a certificate for a service.
 func (s *ConnectCA) Sign(
 	args *structs.CASignRequest,
-	reply *structs.IssuedCert) error {
+	reply *structs.IssuedCert) (err error) {
 	// Exit early if Connect hasn't been enabled.
 	if !s.srv.config.ConnectEnabled {
 		return ErrConnectNotEnabled
@@ -399,6 +596,15 @@ func (s *ConnectCA) Sign(
 		return err
 	}
 
+	// Count the requests handled by this server and the ones that failed,
+	// including the ones rejected by the rate limit.
+	metrics.IncrCounter([]string{"connect", "ca", "sign", "request"}, 1)
+	defer func() {
+		if err != nil {
+			metrics.IncrCounter([]string{"connect", "ca", "sign", "failure"}, 1)
+		}
+	}()
+
 	// Parse the CSR
 	csr, err := connect.ParseCSR(args.CSR)
 	if err != nil {
@@ -422,7 +628,7 @@ func (s *ConnectCA) Sign(
 
 	// Verify that the CSR entity is in the cluster's trust domain
 	state := s.srv.fsm.State()
-	_, config, err := state.CAConfig()
+	_, config, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
@@ -457,17 +663,23 @@ func (s *ConnectCA) Sign(
 		// Wait up to the small threshold we allow for a token.
 		ctx, cancel := context.WithTimeout(context.Background(), csrLimitWait)
 		defer cancel()
+		start := time.Now()
 		if lim.Wait(ctx) != nil {
+			metrics.IncrCounter([]string{"connect", "ca", "sign", "rate_limited"}, 1)
 			return ErrRateLimited
 		}
+		metrics.MeasureSince([]string{"connect", "ca", "sign", "wait"}, start)
 	} else if commonCfg.CSRMaxConcurrent > 0 {
 		s.csrConcurrencyLimiter.SetSize(int64(commonCfg.CSRMaxConcurrent))
 		ctx, cancel := context.WithTimeout(context.Background(), csrLimitWait)
 		defer cancel()
-		if err := s.csrConcurrencyLimiter.Acquire(ctx); err != nil {
+		start := time.Now()
+		if err := s.csrConcurrencyLimiter.Acquire(ctx, serviceID.Service); err != nil {
+			metrics.IncrCounter([]string{"connect", "ca", "sign", "rate_limited"}, 1)
 			return ErrRateLimited
 		}
 		defer s.csrConcurrencyLimiter.Release()
+		metrics.MeasureSince([]string{"connect", "ca", "sign", "wait"}, start)
 	}
 
 	// All seems to be in order, actually sign it.
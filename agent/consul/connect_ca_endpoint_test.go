@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul/agent/connect"
@@ -52,7 +53,7 @@ func TestConnectCARoots(t *testing.T) {
 	ok, err := state.CARootSetCAS(idx, idx, []*structs.CARoot{ca1, ca2})
 	assert.True(ok)
 	require.NoError(err)
-	_, caCfg, err := state.CAConfig()
+	_, caCfg, err := state.CAConfig(nil)
 	require.NoError(err)
 
 	// Request
@@ -73,6 +74,64 @@ func TestConnectCARoots(t *testing.T) {
 	assert.Equal(fmt.Sprintf("%s.consul", caCfg.ClusterID), reply.TrustDomain)
 }
 
+func TestConnectCARoots_federatedTrustBundles(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Add the bundle of another trust domain to the config.
+	federated := connect.TestCA(t, nil)
+	{
+		args := &structs.CARequest{
+			Datacenter: "dc1",
+			Config: &structs.CAConfiguration{
+				Provider: "consul",
+				Config: map[string]interface{}{
+					"PrivateKey":     "",
+					"RootCert":       "",
+					"RotationPeriod": 90 * 24 * time.Hour,
+					"FederatedTrustBundles": []map[string]interface{}{
+						{
+							"TrustDomain": "spire.example.org",
+							"RootCerts":   []string{federated.RootCert},
+						},
+					},
+				},
+			},
+		}
+		var reply interface{}
+		retry.Run(t, func(r *retry.R) {
+			r.Check(msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationSet", args, &reply))
+		})
+	}
+
+	args := &structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	var reply structs.IndexedCARoots
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", args, &reply))
+
+	require.Equal([]string{"spire.example.org"}, reply.FederatedTrustDomains)
+
+	// The federated root is kept apart from the roots of the cluster so it
+	// can't vouch for Consul services.
+	require.Len(reply.Roots, 1)
+	require.NotEqual(federated.RootCert, reply.Roots[0].RootCert)
+	require.Len(reply.FederatedRoots, 1)
+	r := reply.FederatedRoots[0]
+	require.Equal(federated.RootCert, r.RootCert)
+	require.False(r.Active)
+	require.NotEqual(reply.ActiveRootID, r.ID)
+	require.Equal("spire.example.org", r.ExternalTrustDomain)
+}
+
 func TestConnectCAConfig_GetSet(t *testing.T) {
 	t.Parallel()
 
@@ -294,6 +353,214 @@ func TestConnectCAConfig_TriggerRotation(t *testing.T) {
 }
 
 // Test CA signing
+func TestConnectCAConfig_TriggerRotation_staged(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	dir2, c1 := testClient(t)
+	defer os.RemoveAll(dir2)
+	defer c1.Shutdown()
+	joinLAN(t, c1, s1)
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Register a proxy on the client so the leader waits for it.
+	{
+		args := structs.TestRegisterRequestProxy(t)
+		args.Node = c1.config.NodeName
+		var out struct{}
+		require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.Register", args, &out))
+	}
+
+	rootReq := &structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	var rootList structs.IndexedCARoots
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", rootReq, &rootList))
+	require.Len(rootList.Roots, 1)
+	oldRoot := rootList.Roots[0]
+
+	// Rotate the root. This returns the ID of the new root once it's staged,
+	// without waiting for the client to acknowledge it.
+	var stagedID string
+	_, newKey, err := connect.GeneratePrivateKey()
+	require.NoError(err)
+	{
+		args := &structs.CARequest{
+			Datacenter: "dc1",
+			Config: &structs.CAConfiguration{
+				Provider: "consul",
+				Config: map[string]interface{}{
+					"PrivateKey":         newKey,
+					"RootCert":           "",
+					"RotationPeriod":     90 * 24 * time.Hour,
+					"RootRolloutTimeout": "30s",
+				},
+			},
+		}
+		require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationSet", args, &stagedID))
+	}
+
+	// The new root should be staged but not yet active, and the roots
+	// endpoint reports the client as pending.
+	var staged *structs.CARoot
+	{
+		var reply structs.IndexedCARoots
+		require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", rootReq, &reply))
+		require.Len(reply.Roots, 2)
+		for _, root := range reply.Roots {
+			if root.ID != oldRoot.ID {
+				staged = root
+			}
+		}
+		require.Equal(staged.ID, stagedID)
+		require.True(staged.Staged)
+		require.False(staged.Active)
+		require.Equal(oldRoot.ID, reply.ActiveRootID)
+
+		require.NotNil(reply.Rollout)
+		require.Equal(staged.ID, reply.Rollout.RootID)
+		require.Equal(0, reply.Rollout.Acknowledged)
+		require.Equal([]string{c1.config.NodeName}, reply.Rollout.PendingAgents)
+		require.Equal(30*time.Second, reply.Rollout.Deadline.Sub(reply.Rollout.StartedAt))
+	}
+
+	// Config changes are rejected while the rotation is in progress.
+	{
+		args := &structs.CARequest{
+			Datacenter: "dc1",
+			Config: &structs.CAConfiguration{
+				Provider: "consul",
+				Config: map[string]interface{}{
+					"PrivateKey":     newKey,
+					"RootCert":       "",
+					"RotationPeriod": 180 * 24 * time.Hour,
+				},
+			},
+		}
+		var reply interface{}
+		err := msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationSet", args, &reply)
+		require.Error(err)
+		require.Contains(err.Error(), "already in progress")
+	}
+
+	// Acknowledge the new root from the client.
+	{
+		args := &structs.CARootsAckRequest{
+			Datacenter: "dc1",
+			Node:       c1.config.NodeName,
+			RootIDs:    []string{oldRoot.ID, staged.ID},
+		}
+		var reply struct{}
+		require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.RootsAck", args, &reply))
+	}
+
+	// The new root is activated in the background and is no longer staged.
+	retry.Run(t, func(r *retry.R) {
+		var reply structs.IndexedCARoots
+		if err := s1.RPC("ConnectCA.Roots", rootReq, &reply); err != nil {
+			r.Fatal(err)
+		}
+		if reply.ActiveRootID != staged.ID {
+			r.Fatalf("new root not active yet: %v", reply.ActiveRootID)
+		}
+		if reply.Rollout != nil {
+			r.Fatalf("rollout still in progress: %v", reply.Rollout)
+		}
+		if len(reply.Roots) != 2 {
+			r.Fatalf("bad: %v", reply.Roots)
+		}
+		for _, root := range reply.Roots {
+			if root.Staged || root.Active != (root.ID == staged.ID) {
+				r.Fatalf("bad: %v", root)
+			}
+		}
+	})
+}
+
+func TestConnectCAConfig_TriggerRotation_stagedLeadershipLost(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// A rollout that was stopped because leadership was lost never activates
+	// its root, and cleans up its provider.
+	r, err := s1.startCARootRollout("staged", time.Minute)
+	require.NoError(err)
+	s1.stopCARootRollout()
+
+	provider := &ca.MockProvider{}
+	provider.On("Cleanup").Return(nil).Once()
+	activated := false
+	s1.runCARootRollout(r, provider, func() error {
+		activated = true
+		return nil
+	})
+	require.False(activated)
+	require.False(s1.caRootRolloutInProgress(""))
+	provider.AssertExpectations(t)
+
+	// So does a rollout whose root fails to activate.
+	r, err = s1.startCARootRollout("staged", 0)
+	require.NoError(err)
+	provider = &ca.MockProvider{}
+	provider.On("Cleanup").Return(nil).Once()
+	s1.runCARootRollout(r, provider, func() error {
+		return fmt.Errorf("failed")
+	})
+	require.False(s1.caRootRolloutInProgress(""))
+	provider.AssertExpectations(t)
+}
+
+func TestConnectCAConfig_RotationMinInterval(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	rotate := func() error {
+		_, newKey, err := connect.GeneratePrivateKey()
+		require.NoError(err)
+		args := &structs.CARequest{
+			Datacenter: "dc1",
+			Config: &structs.CAConfiguration{
+				Provider: "consul",
+				Config: map[string]interface{}{
+					"PrivateKey":              newKey,
+					"RootCert":                "",
+					"RotationPeriod":          90 * 24 * time.Hour,
+					"RootRotationMinInterval": "1h",
+				},
+			},
+		}
+		var reply interface{}
+		return msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationSet", args, &reply)
+	}
+
+	// The first rotation is allowed, the second one comes too soon.
+	require.NoError(rotate())
+	err := rotate()
+	require.Error(err)
+	require.Contains(err.Error(), "roots can only be rotated every 1h0m0s")
+}
+
 func TestConnectCASign(t *testing.T) {
 	t.Parallel()
 
@@ -349,6 +616,60 @@ func TestConnectCASign(t *testing.T) {
 	assert.Equal(spiffeId.URI().String(), reply.ServiceURI)
 }
 
+// This test replaces the global metrics sink so it can't run in parallel.
+func TestConnectCA_metrics(t *testing.T) {
+	require := require.New(t)
+
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("consul")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+	metrics.NewGlobal(cfg, sink)
+	defer metrics.NewGlobal(metrics.DefaultConfig(""), &metrics.BlackholeSink{})
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Sign a valid CSR and one from another trust domain.
+	csr, _ := connect.TestCSR(t, connect.TestSpiffeIDService(t, "web"))
+	args := &structs.CASignRequest{Datacenter: "dc1", CSR: csr}
+	var reply structs.IssuedCert
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.Sign", args, &reply))
+
+	csr, _ = connect.TestCSR(t, &connect.SpiffeIDService{
+		Host:       "55555555-4444-3333-2222-111111111111.consul",
+		Namespace:  "default",
+		Datacenter: "dc1",
+		Service:    "web",
+	})
+	args = &structs.CASignRequest{Datacenter: "dc1", CSR: csr}
+	require.Error(msgpackrpc.CallWithCodec(codec, "ConnectCA.Sign", args, &reply))
+
+	require.NoError(s1.emitCARootExpiry())
+
+	_, root, err := s1.fsm.State().CARootActive(nil)
+	require.NoError(err)
+
+	// The test might span more than one interval of the sink.
+	var requests, failures int
+	var expiry float32
+	for _, intv := range sink.Data() {
+		requests += intv.Counters["consul.connect.ca.sign.request"].Count
+		failures += intv.Counters["consul.connect.ca.sign.failure"].Count
+		if gauge, ok := intv.Gauges["consul.connect.ca.root.expiry"]; ok {
+			expiry = gauge.Value
+		}
+	}
+	require.Equal(2, requests)
+	require.Equal(1, failures)
+	require.InDelta(time.Until(root.NotAfter).Seconds(), expiry, 60)
+}
+
 // Bench how long Signing RPC takes. This was used to ballpark reasonable
 // default rate limit to protect servers from thundering herds of signing
 // requests on root rotation.
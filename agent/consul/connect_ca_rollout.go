@@ -0,0 +1,254 @@
+package consul
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/connect/ca"
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/serf/serf"
+)
+
+const (
+	// caRolloutCheckInterval is how often a staged root rotation re-checks
+	// the set of agents that need to acknowledge the new root, so that
+	// agents joining or leaving during the rollout are accounted for.
+	caRolloutCheckInterval = time.Second
+)
+
+// caRootRollout tracks the agents that have acknowledged a staged root.
+type caRootRollout struct {
+	rootID   string
+	started  time.Time
+	deadline time.Time
+	acked    map[string]struct{}
+
+	// ackCh is signaled whenever a new acknowledgement is recorded.
+	ackCh chan struct{}
+
+	// stopCh is closed when leadership is lost, which aborts the rollout.
+	stopCh chan struct{}
+}
+
+// startCARootRollout registers a staged rotation to the given root that is
+// activated after the given timeout at the latest. Only one rotation can be in
+// progress at a time.
+func (s *Server) startCARootRollout(rootID string, timeout time.Duration) (*caRootRollout, error) {
+	s.caRolloutLock.Lock()
+	defer s.caRolloutLock.Unlock()
+
+	if s.caRollout != nil {
+		return nil, fmt.Errorf("a CA root rotation is already in progress")
+	}
+	now := time.Now()
+	s.caRollout = &caRootRollout{
+		rootID:   rootID,
+		started:  now,
+		deadline: now.Add(timeout),
+		acked:    make(map[string]struct{}),
+		ackCh:    make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+	}
+	return s.caRollout, nil
+}
+
+// finishCARootRollout clears the given rotation if it's still the one in
+// progress.
+func (s *Server) finishCARootRollout(r *caRootRollout) {
+	s.caRolloutLock.Lock()
+	if s.caRollout == r {
+		s.caRollout = nil
+	}
+	s.caRolloutLock.Unlock()
+}
+
+// stopCARootRollout aborts the staged rotation in progress, if any. The staged
+// root is left in the state store to be pruned by the next leader.
+func (s *Server) stopCARootRollout() {
+	s.caRolloutLock.Lock()
+	if s.caRollout != nil {
+		close(s.caRollout.stopCh)
+		s.caRollout = nil
+	}
+	s.caRolloutLock.Unlock()
+}
+
+// caRootRolloutInProgress returns true if a staged rotation is in progress.
+// If rootID is given, the rotation must also be to that root.
+func (s *Server) caRootRolloutInProgress(rootID string) bool {
+	s.caRolloutLock.Lock()
+	defer s.caRolloutLock.Unlock()
+
+	return s.caRollout != nil && (rootID == "" || s.caRollout.rootID == rootID)
+}
+
+// caRootRolloutStatus returns the progress of the staged rotation in
+// progress, or nil if there is none.
+func (s *Server) caRootRolloutStatus() (*structs.CARootRollout, error) {
+	s.caRolloutLock.Lock()
+	r := s.caRollout
+	var acked int
+	if r != nil {
+		acked = len(r.acked)
+	}
+	s.caRolloutLock.Unlock()
+
+	if r == nil {
+		return nil, nil
+	}
+	pending, err := s.pendingCARootAcks(r)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.CARootRollout{
+		RootID:        r.rootID,
+		StartedAt:     r.started,
+		Deadline:      r.deadline,
+		Acknowledged:  acked,
+		PendingAgents: pending,
+	}, nil
+}
+
+// ackCARoots records that the given node has received the given roots.
+// Acknowledgements that don't include the staged root are ignored.
+func (s *Server) ackCARoots(node string, rootIDs []string) {
+	s.caRolloutLock.Lock()
+	defer s.caRolloutLock.Unlock()
+
+	r := s.caRollout
+	if r == nil {
+		return
+	}
+	for _, id := range rootIDs {
+		if id != r.rootID {
+			continue
+		}
+		r.acked[node] = struct{}{}
+		select {
+		case r.ackCh <- struct{}{}:
+		default:
+		}
+		return
+	}
+}
+
+// pendingCARootAcks returns the names of the alive client agents that run
+// Connect proxies or Connect-native services and have not yet acknowledged
+// the staged root.
+func (s *Server) pendingCARootAcks(r *caRootRollout) ([]string, error) {
+	state := s.fsm.State()
+
+	var pending []string
+	for _, m := range s.LANMembers() {
+		if m.Status != serf.StatusAlive {
+			continue
+		}
+		if ok, _ := metadata.IsConsulServer(m); ok {
+			continue
+		}
+
+		_, services, err := state.NodeServices(nil, m.Name)
+		if err != nil {
+			return nil, err
+		}
+		if services == nil || !hasConnectServices(services) {
+			continue
+		}
+
+		s.caRolloutLock.Lock()
+		_, ok := r.acked[m.Name]
+		s.caRolloutLock.Unlock()
+		if !ok {
+			pending = append(pending, m.Name)
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+// runCARootRollout waits for the agents to acknowledge the staged root and
+// then activates it. It's run in the background by the leader so the
+// configuration change that staged the root doesn't block on the rollout. If
+// leadership is lost the root isn't activated, and the next leader prunes it.
+// The provider of the staged root is cleaned up unless it's activated.
+func (s *Server) runCARootRollout(r *caRootRollout, newProvider ca.Provider, activate func() error) {
+	defer s.finishCARootRollout(r)
+
+	if err := s.waitForCARootRollout(r); err != nil {
+		s.logger.Printf("[WARN] connect: aborted rotation to staged root CA (ID: %s): %v", r.rootID, err)
+	} else if err := activate(); err != nil {
+		s.logger.Printf("[ERR] connect: failed to activate staged root CA (ID: %s): %v", r.rootID, err)
+	} else {
+		return
+	}
+
+	if err := newProvider.Cleanup(); err != nil {
+		s.logger.Printf("[WARN] connect: failed to clean up provider of staged root CA (ID: %s): %v", r.rootID, err)
+	}
+}
+
+// waitForCARootRollout blocks until all the agents that need the staged root
+// have acknowledged it. If that takes until the rollout's deadline, the
+// rollout is considered complete anyway so a partitioned or misbehaving agent
+// can't block the rotation forever.
+func (s *Server) waitForCARootRollout(r *caRootRollout) error {
+	defer metrics.MeasureSince([]string{"leader", "ca", "root_rollout"}, time.Now())
+
+	deadline := time.After(time.Until(r.deadline))
+	for {
+		select {
+		case <-r.stopCh:
+			return fmt.Errorf("leadership lost")
+		default:
+		}
+
+		pending, err := s.pendingCARootAcks(r)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-r.ackCh:
+		case <-time.After(caRolloutCheckInterval):
+		case <-deadline:
+			metrics.IncrCounter([]string{"leader", "ca", "root_rollout_timeout"}, 1)
+			s.logger.Printf("[WARN] connect: activating new root CA after %s without acknowledgement from %d agent(s): %s",
+				r.deadline.Sub(r.started), len(pending), strings.Join(pending, ", "))
+			return nil
+		case <-r.stopCh:
+			return fmt.Errorf("leadership lost")
+		case <-s.shutdownCh:
+			return fmt.Errorf("server is shutting down")
+		}
+	}
+}
+
+// hasConnectServices returns true if any of the node's services is a Connect
+// proxy or a Connect-native service.
+func hasConnectServices(services *structs.NodeServices) bool {
+	for _, svc := range services.Services {
+		if svc.Kind == structs.ServiceKindConnectProxy || svc.Connect.Native {
+			return true
+		}
+	}
+	return false
+}
+
+// lastCARootRotation returns the last time a root was rotated out, or the
+// zero time if there was never a rotation.
+func lastCARootRotation(roots structs.CARoots) time.Time {
+	var last time.Time
+	for _, r := range roots {
+		if r.RotatedOutAt.After(last) {
+			last = r.RotatedOutAt
+		}
+	}
+	return last
+}
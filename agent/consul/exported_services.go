@@ -0,0 +1,148 @@
+package consul
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// exportFilter removes the services that aren't exported to the datacenter
+// a query was forwarded from. Without an entry all services are exported.
+type exportFilter struct {
+	entry    *structs.ExportedServicesConfigEntry
+	sourceDC string
+}
+
+// allowService returns whether the service is exported to the source
+// datacenter. Connect proxies are exported along with the service they
+// represent.
+func (f *exportFilter) allowService(kind structs.ServiceKind, service, destination string) bool {
+	if f.entry == nil {
+		return true
+	}
+	if kind == structs.ServiceKindConnectProxy && destination != "" {
+		service = destination
+	}
+	return f.entry.Exports(service, f.sourceDC)
+}
+
+func (f *exportFilter) filterServices(services structs.Services) {
+	for svc := range services {
+		if !f.allowService(structs.ServiceKindTypical, svc, "") {
+			delete(services, svc)
+		}
+	}
+}
+
+func (f *exportFilter) filterServiceNodes(nodes *structs.ServiceNodes) {
+	sn := *nodes
+	for i := 0; i < len(sn); i++ {
+		node := sn[i]
+		if f.allowService(node.ServiceKind, node.ServiceName, node.ServiceProxy.DestinationServiceName) {
+			continue
+		}
+		sn = append(sn[:i], sn[i+1:]...)
+		i--
+	}
+	*nodes = sn
+}
+
+func (f *exportFilter) filterNodeServices(services *structs.NodeServices) {
+	if services == nil {
+		return
+	}
+	for id, svc := range services.Services {
+		if !f.allowService(svc.Kind, svc.Service, svc.Proxy.DestinationServiceName) {
+			delete(services.Services, id)
+		}
+	}
+}
+
+func (f *exportFilter) filterCheckServiceNodes(nodes *structs.CheckServiceNodes) {
+	csn := *nodes
+	for i := 0; i < len(csn); i++ {
+		svc := csn[i].Service
+		if f.allowService(svc.Kind, svc.Service, svc.Proxy.DestinationServiceName) {
+			continue
+		}
+		csn = append(csn[:i], csn[i+1:]...)
+		i--
+	}
+	*nodes = csn
+}
+
+// filterHealthChecks keeps the node checks and the checks of exported
+// services.
+func (f *exportFilter) filterHealthChecks(checks *structs.HealthChecks) {
+	hc := *checks
+	for i := 0; i < len(hc); i++ {
+		check := hc[i]
+		if check.ServiceName == "" || f.allowService(structs.ServiceKindTypical, check.ServiceName, "") {
+			continue
+		}
+		hc = append(hc[:i], hc[i+1:]...)
+		i--
+	}
+	*checks = hc
+}
+
+// filterExported removes the services that aren't exported to the given
+// datacenter from the results of a query. Nothing is filtered for local
+// queries or if the datacenter has no exported-services config entry. The
+// entry is looked up with the watch set and the index of the results is
+// raised to the index of the config entries, so blocking queries return
+// when the exported services change.
+func (s *Server) filterExported(ws memdb.WatchSet, state *state.Store, sourceDC string, subj interface{}) error {
+	if sourceDC == "" || sourceDC == s.config.Datacenter {
+		return nil
+	}
+
+	idx, entry, err := state.ConfigEntry(ws, structs.ExportedServices, structs.ExportedServicesName)
+	if err != nil {
+		return err
+	}
+	filt := &exportFilter{sourceDC: sourceDC}
+	if entry != nil {
+		exported, ok := entry.(*structs.ExportedServicesConfigEntry)
+		if !ok {
+			return fmt.Errorf("invalid exported-services config entry type %T", entry)
+		}
+		filt.entry = exported
+	}
+
+	var meta *structs.QueryMeta
+	switch v := subj.(type) {
+	case *structs.CheckServiceNodes:
+		filt.filterCheckServiceNodes(v)
+
+	case *structs.IndexedCheckServiceNodes:
+		meta = &v.QueryMeta
+		filt.filterCheckServiceNodes(&v.Nodes)
+
+	case *structs.IndexedHealthChecks:
+		meta = &v.QueryMeta
+		filt.filterHealthChecks(&v.HealthChecks)
+
+	case *structs.IndexedNodeServices:
+		meta = &v.QueryMeta
+		filt.filterNodeServices(v.NodeServices)
+
+	case *structs.IndexedServiceNodes:
+		meta = &v.QueryMeta
+		filt.filterServiceNodes(&v.ServiceNodes)
+
+	case *structs.IndexedServices:
+		meta = &v.QueryMeta
+		filt.filterServices(v.Services)
+
+	default:
+		panic(fmt.Errorf("Unhandled type passed to export filter: %#v", subj))
+	}
+
+	if meta != nil && idx > meta.Index {
+		meta.Index = idx
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package consul
+
+import (
+	"net/rpc"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ExportedServices(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	dir2, s2 := testServerDC(t, "dc2")
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	codec2 := rpcClient(t, s2)
+	defer codec2.Close()
+
+	joinWAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s1.RPC, "dc2")
+
+	for _, svc := range []string{"web", "db"} {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc2",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service:    &structs.NodeService{Service: svc, Port: 8000},
+			Check:      &structs.HealthCheck{Name: svc, ServiceID: svc},
+		}
+		var out struct{}
+		require.NoError(msgpackrpc.CallWithCodec(codec2, "Catalog.Register", &arg, &out))
+	}
+
+	serviceNodes := func(codec rpc.ClientCodec, service string) structs.CheckServiceNodes {
+		args := structs.ServiceSpecificRequest{Datacenter: "dc2", ServiceName: service}
+		var out structs.IndexedCheckServiceNodes
+		require.NoError(msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &args, &out))
+		return out.Nodes
+	}
+
+	// Without an exported-services entry everything is visible.
+	require.Len(serviceNodes(codec, "db"), 1)
+
+	arg := structs.ConfigEntryRequest{
+		Datacenter: "dc2",
+		Entry: &structs.ExportedServicesConfigEntry{
+			Name:     "default",
+			Services: []structs.ExportedService{{Name: "web", Datacenters: []string{"dc1"}}},
+		},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec2, "ConfigEntry.Apply", &arg, &out))
+
+	// Only the exported service is visible from dc1.
+	require.Len(serviceNodes(codec, "web"), 1)
+	require.Len(serviceNodes(codec, "db"), 0)
+
+	var services structs.IndexedServices
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.ListServices",
+		&structs.DCSpecificRequest{Datacenter: "dc2"}, &services))
+	require.Equal(structs.Services{"web": []string{}}, services.Services)
+
+	var nodeServices structs.IndexedNodeServices
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.NodeServices",
+		&structs.NodeSpecificRequest{Datacenter: "dc2", Node: "foo"}, &nodeServices))
+	require.Len(nodeServices.NodeServices.Services, 1)
+	require.Contains(nodeServices.NodeServices.Services, "web")
+
+	var checks structs.IndexedHealthChecks
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Health.NodeChecks",
+		&structs.NodeSpecificRequest{Datacenter: "dc2", Node: "foo"}, &checks))
+	require.Len(checks.HealthChecks, 1)
+	require.Equal("web", checks.HealthChecks[0].ServiceName)
+
+	// A source datacenter supplied by the caller is replaced, both when
+	// forwarding the request and at the local entry point.
+	args := structs.ServiceSpecificRequest{
+		Datacenter:   "dc2",
+		ServiceName:  "db",
+		QueryOptions: structs.QueryOptions{SourceDatacenter: "dc2"},
+	}
+	var nodes structs.IndexedCheckServiceNodes
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &args, &nodes))
+	require.Len(nodes.Nodes, 0)
+	args.SourceDatacenter = "dc2"
+	require.NoError(s1.RPC("Health.ServiceNodes", &args, &nodes))
+	require.Len(nodes.Nodes, 0)
+
+	// The local datacenter sees all services.
+	require.Len(serviceNodes(codec2, "db"), 1)
+}
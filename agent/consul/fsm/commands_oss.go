@@ -1,6 +1,7 @@
 package fsm
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -29,6 +30,10 @@ func init() {
 	registerCommand(structs.ACLPolicySetRequestType, (*FSM).applyACLPolicySetOperation)
 	registerCommand(structs.ACLPolicyDeleteRequestType, (*FSM).applyACLPolicyDeleteOperation)
 	registerCommand(structs.ConnectCALeafRequestType, (*FSM).applyConnectCALeafOperation)
+	registerCommand(structs.ConfigEntryRequestType, (*FSM).applyConfigEntryOperation)
+	registerCommand(structs.ServiceWeightOverrideRequestType, (*FSM).applyServiceWeightOverrideOperation)
+	registerCommand(structs.ClusterIdentityRequestType, (*FSM).applyClusterIdentity)
+	registerCommand(structs.BulkRegisterRequestType, (*FSM).applyBulkRegister)
 }
 
 func (c *FSM) applyRegister(buf []byte, index uint64) interface{} {
@@ -46,6 +51,26 @@ func (c *FSM) applyRegister(buf []byte, index uint64) interface{} {
 	return nil
 }
 
+// applyBulkRegister applies each registration of a bulk request in its own
+// transaction. It returns the error of each registration, which is nil for
+// the ones that were applied.
+func (c *FSM) applyBulkRegister(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"fsm", "bulk_register"}, time.Now())
+	var req structs.BulkRegisterRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	errs := make([]error, len(req.Registrations))
+	for i, reg := range req.Registrations {
+		if err := c.state.EnsureRegistration(index, reg); err != nil {
+			c.logger.Printf("[WARN] consul.fsm: EnsureRegistration failed: %v", err)
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
 func (c *FSM) applyDeregister(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"fsm", "deregister"}, time.Now())
 	var req structs.DeregisterRequest
@@ -246,7 +271,25 @@ func (c *FSM) applyTxn(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 	defer metrics.MeasureSince([]string{"fsm", "txn"}, time.Now())
+
+	// Record the changes of intentions in the audit trail if the whole
+	// transaction succeeded. Changes of the same intention are merged.
+	var audits []*structs.AuditEntry
+	seen := make(map[string]bool)
+	for _, op := range req.Ops {
+		if op.Intention == nil || op.Intention.Intention == nil || seen[op.Intention.Intention.ID] {
+			continue
+		}
+		seen[op.Intention.Intention.ID] = true
+		audits = append(audits, c.intentionAuditEntry((*structs.IntentionRequest)(op.Intention)))
+	}
 	results, errors := c.state.TxnRW(index, req.Ops)
+	if len(errors) == 0 {
+		for _, audit := range audits {
+			audit.After = c.intentionJSON(audit.Name)
+			c.appendAuditEntry(index, audit)
+		}
+	}
 	return structs.TxnResponse{
 		Results: results,
 		Errors:  errors,
@@ -281,15 +324,80 @@ func (c *FSM) applyIntentionOperation(buf []byte, index uint64) interface{} {
 		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
 	defer metrics.MeasureSinceWithLabels([]string{"fsm", "intention"}, time.Now(),
 		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
+	audit := c.intentionAuditEntry(&req)
+	var err error
 	switch req.Op {
 	case structs.IntentionOpCreate, structs.IntentionOpUpdate:
-		return c.state.IntentionSet(index, req.Intention)
+		err = c.state.IntentionSet(index, req.Intention)
 	case structs.IntentionOpDelete:
-		return c.state.IntentionDelete(index, req.Intention.ID)
+		err = c.state.IntentionDelete(index, req.Intention.ID)
 	default:
 		c.logger.Printf("[WARN] consul.fsm: Invalid Intention operation '%s'", req.Op)
 		return fmt.Errorf("Invalid Intention operation '%s'", req.Op)
 	}
+	if err != nil {
+		return err
+	}
+	audit.After = c.intentionJSON(audit.Name)
+	c.appendAuditEntry(index, audit)
+	return nil
+}
+
+// intentionAuditEntry returns the audit entry of the intention request with
+// the intention as it is before the change.
+func (c *FSM) intentionAuditEntry(req *structs.IntentionRequest) *structs.AuditEntry {
+	return &structs.AuditEntry{
+		AuditInfo: req.Audit,
+		Resource:  structs.AuditResourceIntention,
+		Name:      req.Intention.ID,
+		Op:        string(req.Op),
+		Before:    c.intentionJSON(req.Intention.ID),
+	}
+}
+
+// intentionJSON returns the JSON encoded intention for the audit trail, or
+// nil if it doesn't exist.
+func (c *FSM) intentionJSON(id string) json.RawMessage {
+	_, ixn, err := c.state.IntentionGet(nil, id)
+	if err != nil {
+		c.logger.Printf("[WARN] consul.fsm: Failed to look up intention for audit trail: %v", err)
+		return nil
+	}
+	if ixn == nil {
+		return nil
+	}
+	return c.auditJSON(ixn)
+}
+
+// configEntryJSON returns the JSON encoded config entry for the audit trail,
+// or nil if it doesn't exist.
+func (c *FSM) configEntryJSON(kind, name string) json.RawMessage {
+	_, entry, err := c.state.ConfigEntry(nil, kind, name)
+	if err != nil {
+		c.logger.Printf("[WARN] consul.fsm: Failed to look up config entry for audit trail: %v", err)
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+	return c.auditJSON(entry)
+}
+
+func (c *FSM) auditJSON(v interface{}) json.RawMessage {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		c.logger.Printf("[WARN] consul.fsm: Failed to encode value for audit trail: %v", err)
+		return nil
+	}
+	return buf
+}
+
+// appendAuditEntry adds the entry to the audit trail. Failures are logged
+// rather than returned since the change itself was applied.
+func (c *FSM) appendAuditEntry(index uint64, entry *structs.AuditEntry) {
+	if err := c.state.AuditEntryAppend(index, entry); err != nil {
+		c.logger.Printf("[WARN] consul.fsm: Failed to record audit entry: %v", err)
+	}
 }
 
 // applyConnectCAOperation applies the given CA operation to the state store.
@@ -428,3 +536,72 @@ func (c *FSM) applyACLPolicyDeleteOperation(buf []byte, index uint64) interface{
 
 	return c.state.ACLPolicyBatchDelete(index, req.PolicyIDs)
 }
+
+func (c *FSM) applyConfigEntryOperation(buf []byte, index uint64) interface{} {
+	var req structs.ConfigEntryRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+	defer metrics.MeasureSinceWithLabels([]string{"fsm", "config_entry", req.Entry.GetKind()}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
+
+	kind, name := req.Entry.GetKind(), req.Entry.GetName()
+	audit := &structs.AuditEntry{
+		AuditInfo: req.Audit,
+		Resource:  structs.AuditResourceConfigEntry,
+		Name:      kind + "/" + name,
+		Op:        string(req.Op),
+		Before:    c.configEntryJSON(kind, name),
+	}
+	var resp interface{}
+	switch req.Op {
+	case structs.ConfigEntryUpsert:
+		if err := c.state.EnsureConfigEntry(index, req.Entry); err != nil {
+			return err
+		}
+		resp = true
+	case structs.ConfigEntryDelete:
+		if err := c.state.DeleteConfigEntry(index, kind, name); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid config entry operation type: %v", req.Op)
+	}
+	audit.After = c.configEntryJSON(kind, name)
+	c.appendAuditEntry(index, audit)
+	return resp
+}
+
+func (c *FSM) applyServiceWeightOverrideOperation(buf []byte, index uint64) interface{} {
+	var req structs.ServiceWeightOverrideRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+	defer metrics.MeasureSinceWithLabels([]string{"fsm", "weight_override"}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
+
+	switch req.Op {
+	case structs.ServiceWeightOverrideSet:
+		return c.state.ServiceWeightOverrideSet(index, &req.Override)
+	case structs.ServiceWeightOverrideDelete:
+		return c.state.ServiceWeightOverrideDelete(index, req.Override.Node, req.Override.ServiceID)
+	default:
+		return fmt.Errorf("invalid weight override operation type: %v", req.Op)
+	}
+}
+
+// applyClusterIdentity sets the identity of the cluster unless it is
+// already set. It returns whether the identity was set.
+func (c *FSM) applyClusterIdentity(buf []byte, index uint64) interface{} {
+	var req structs.ClusterIdentity
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+	defer metrics.MeasureSince([]string{"fsm", "cluster_identity"}, time.Now())
+
+	set, err := c.state.ClusterIdentitySet(index, &req)
+	if err != nil {
+		return err
+	}
+	return set
+}
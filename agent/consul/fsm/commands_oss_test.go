@@ -18,6 +18,7 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/pascaldekloe/goe/verify"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func generateUUID() (ret string) {
@@ -83,6 +84,60 @@ func TestFSM_RegisterNode(t *testing.T) {
 	}
 }
 
+func TestFSM_BulkRegister(t *testing.T) {
+	t.Parallel()
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(t, err)
+
+	req := structs.BulkRegisterRequest{
+		Datacenter: "dc1",
+		Registrations: []*structs.RegisterRequest{
+			{
+				Datacenter: "dc1",
+				Node:       "foo",
+				Address:    "127.0.0.1",
+				Service:    &structs.NodeService{ID: "db", Service: "db"},
+			},
+			{
+				Datacenter: "dc1",
+				Node:       "bar",
+				Address:    "127.0.0.2",
+				Checks: structs.HealthChecks{
+					&structs.HealthCheck{Node: "bar", CheckID: "db", ServiceID: "db"},
+				},
+			},
+			{
+				Datacenter: "dc1",
+				Node:       "baz",
+				Address:    "127.0.0.3",
+			},
+		},
+	}
+	buf, err := structs.Encode(structs.BulkRegisterRequestType, req)
+	require.NoError(t, err)
+
+	// The failure of a registration doesn't affect the others.
+	resp := fsm.Apply(makeLog(buf))
+	errs, ok := resp.([]error)
+	require.True(t, ok, "resp: %v", resp)
+	require.Len(t, errs, 3)
+	require.NoError(t, errs[0])
+	require.Error(t, errs[1])
+	require.NoError(t, errs[2])
+
+	_, services, err := fsm.state.NodeServices(nil, "foo")
+	require.NoError(t, err)
+	require.Contains(t, services.Services, "db")
+	_, node, err := fsm.state.GetNode("baz")
+	require.NoError(t, err)
+	require.NotNil(t, node)
+
+	// The failed registration was applied as a whole or not at all.
+	_, node, err = fsm.state.GetNode("bar")
+	require.NoError(t, err)
+	require.Nil(t, node)
+}
+
 func TestFSM_RegisterNode_Service(t *testing.T) {
 	t.Parallel()
 	fsm, err := New(nil, os.Stderr)
@@ -1219,6 +1274,200 @@ func TestFSM_Intention_CRUD(t *testing.T) {
 	}
 }
 
+func TestFSM_ConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(err)
+
+	// Create a simple config entry
+	entry := &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gateway",
+		Services: []structs.LinkedService{
+			{Name: "db", CAFile: "/etc/ssl/db.pem", SNI: "db.example.com"},
+		},
+	}
+
+	// Create a new request.
+	req := &structs.ConfigEntryRequest{
+		Op:    structs.ConfigEntryUpsert,
+		Entry: entry,
+	}
+
+	{
+		buf, err := structs.Encode(structs.ConfigEntryRequestType, req)
+		require.NoError(err)
+		resp := fsm.Apply(makeLog(buf))
+		if _, ok := resp.(error); ok {
+			t.Fatalf("bad: %v", resp)
+		}
+	}
+
+	// Verify it's in the state store.
+	{
+		_, config, err := fsm.state.ConfigEntry(nil, structs.TerminatingGateway, "gateway")
+		require.NoError(err)
+		entry.RaftIndex.CreateIndex = 1
+		entry.RaftIndex.ModifyIndex = 1
+		require.Equal(entry, config)
+	}
+
+	// Delete it.
+	req.Op = structs.ConfigEntryDelete
+	{
+		buf, err := structs.Encode(structs.ConfigEntryRequestType, req)
+		require.NoError(err)
+		resp := fsm.Apply(makeLog(buf))
+		if _, ok := resp.(error); ok {
+			t.Fatalf("bad: %v", resp)
+		}
+	}
+
+	// Make sure it's gone.
+	{
+		_, config, err := fsm.state.ConfigEntry(nil, structs.TerminatingGateway, "gateway")
+		require.NoError(err)
+		require.Nil(config)
+	}
+}
+
+func TestFSM_AuditTrail(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(err)
+
+	var index uint64
+	apply := func(t structs.MessageType, req interface{}) interface{} {
+		buf, err := structs.Encode(t, req)
+		require.NoError(err)
+		log := makeLog(buf)
+		index++
+		log.Index = index
+		return fsm.Apply(log)
+	}
+	info := structs.AuditInfo{
+		AccessorID: "e2d9b4a1-1c4a-4b8e-9f0c-5d2b3a1e7f60",
+		Time:       time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	// Create, update and delete an intention.
+	ixn := structs.IntentionRequest{
+		Datacenter: "dc1",
+		Op:         structs.IntentionOpCreate,
+		Intention:  structs.TestIntention(t),
+		Audit:      info,
+	}
+	ixn.Intention.ID = generateUUID()
+	ixn.Intention.UpdatePrecedence()
+	require.Nil(apply(structs.IntentionRequestType, ixn))
+	ixn.Op = structs.IntentionOpUpdate
+	ixn.Intention.Action = structs.IntentionActionDeny
+	require.Nil(apply(structs.IntentionRequestType, ixn))
+	ixn.Op = structs.IntentionOpDelete
+	require.Nil(apply(structs.IntentionRequestType, ixn))
+
+	// Upsert a config entry and change it in a failed transaction, which
+	// isn't recorded.
+	entry := &structs.TerminatingGatewayConfigEntry{
+		Kind:     structs.TerminatingGateway,
+		Name:     "gateway",
+		Services: []structs.LinkedService{{Name: "db"}},
+	}
+	require.Equal(true, apply(structs.ConfigEntryRequestType, &structs.ConfigEntryRequest{
+		Op:    structs.ConfigEntryUpsert,
+		Entry: entry,
+		Audit: info,
+	}))
+	resp := apply(structs.TxnRequestType, structs.TxnRequest{
+		Ops: structs.TxnOps{
+			{Intention: &structs.TxnIntentionOp{
+				Op:        structs.IntentionOpUpdate,
+				Intention: &structs.Intention{ID: generateUUID()},
+				Audit:     info,
+			}},
+			{KV: &structs.TxnKVOp{
+				Verb:   api.KVCheckIndex,
+				DirEnt: structs.DirEntry{Key: "missing", RaftIndex: structs.RaftIndex{ModifyIndex: 99}},
+			}},
+		},
+	})
+	require.NotEmpty(resp.(structs.TxnResponse).Errors)
+
+	idx, trail, err := fsm.state.AuditTrail(nil, "")
+	require.NoError(err)
+	require.Equal(uint64(4), idx)
+	require.Len(trail, 4)
+	for i, op := range []string{"create", "update", "delete", "upsert"} {
+		require.Equal(uint64(i+1), trail[i].Index)
+		require.Equal(op, trail[i].Op)
+		require.Equal(info, trail[i].AuditInfo)
+	}
+
+	require.Equal(structs.AuditResourceIntention, trail[0].Resource)
+	require.Equal(ixn.Intention.ID, trail[0].Name)
+	require.Nil(trail[0].Before)
+	require.Contains(string(trail[0].After), `"Action":"allow"`)
+	require.Equal(trail[0].After, trail[1].Before)
+	require.Contains(string(trail[1].After), `"Action":"deny"`)
+	require.Equal(trail[1].After, trail[2].Before)
+	require.Nil(trail[2].After)
+
+	require.Equal(structs.AuditResourceConfigEntry, trail[3].Resource)
+	require.Equal("terminating-gateway/gateway", trail[3].Name)
+	require.Nil(trail[3].Before)
+	require.Contains(string(trail[3].After), `"Name":"db"`)
+}
+
+func TestFSM_ServiceWeightOverride(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(err)
+
+	require.NoError(fsm.state.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(fsm.state.EnsureService(2, "foo", &structs.NodeService{ID: "web1", Service: "web", Port: 80}))
+
+	req := &structs.ServiceWeightOverrideRequest{
+		Op: structs.ServiceWeightOverrideSet,
+		Override: structs.ServiceWeightOverride{
+			Node:      "foo",
+			ServiceID: "web1",
+			Weights:   structs.Weights{Passing: 0, Warning: 0},
+		},
+	}
+	buf, err := structs.Encode(structs.ServiceWeightOverrideRequestType, req)
+	require.NoError(err)
+	require.Nil(fsm.Apply(makeLog(buf)))
+
+	_, nodes, err := fsm.state.CheckServiceNodes(nil, "web")
+	require.NoError(err)
+	require.Len(nodes, 1)
+	require.True(nodes[0].Service.Drained())
+
+	// Setting an override on an unknown instance fails.
+	req.Override.ServiceID = "web2"
+	buf, err = structs.Encode(structs.ServiceWeightOverrideRequestType, req)
+	require.NoError(err)
+	_, ok := fsm.Apply(makeLog(buf)).(error)
+	require.True(ok)
+
+	// Deleting it restores the registered weights.
+	req.Op = structs.ServiceWeightOverrideDelete
+	req.Override.ServiceID = "web1"
+	buf, err = structs.Encode(structs.ServiceWeightOverrideRequestType, req)
+	require.NoError(err)
+	require.Nil(fsm.Apply(makeLog(buf)))
+
+	_, nodes, err = fsm.state.CheckServiceNodes(nil, "web")
+	require.NoError(err)
+	require.False(nodes[0].Service.Drained())
+}
+
 func TestFSM_CAConfig(t *testing.T) {
 	t.Parallel()
 
@@ -1246,7 +1495,7 @@ func TestFSM_CAConfig(t *testing.T) {
 	}
 
 	// Verify key is set directly in the state store.
-	_, config, err := fsm.state.CAConfig()
+	_, config, err := fsm.state.CAConfig(nil)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -1279,7 +1528,7 @@ func TestFSM_CAConfig(t *testing.T) {
 		t.Fatalf("bad: %v", resp)
 	}
 
-	_, config, err = fsm.state.CAConfig()
+	_, config, err = fsm.state.CAConfig(nil)
 	assert.Nil(err)
 	if config.Provider != "static" {
 		t.Fatalf("bad: %v", config.Provider)
@@ -141,52 +141,63 @@ func (c *FSM) Restore(old io.ReadCloser) error {
 		return err
 	}
 
+	if _, err := restoreSnapshot(old, stateNew); err != nil {
+		return err
+	}
+
+	// External code might be calling State(), so we need to synchronize
+	// here to make sure we swap in the new state store atomically.
+	c.stateLock.Lock()
+	stateOld := c.state
+	c.state = stateNew
+	c.stateLock.Unlock()
+
+	// Signal that the old state store has been abandoned. This is required
+	// because we don't operate on it any more, we just throw it away, so
+	// blocking queries won't see any changes and need to be woken up.
+	stateOld.Abandon()
+	return nil
+}
+
+// restoreSnapshot decodes the snapshot from the reader into the given state
+// store and returns the number of records of each type it contained.
+func restoreSnapshot(in io.Reader, store *state.Store) (map[structs.MessageType]int, error) {
 	// Set up a new restore transaction
-	restore := stateNew.Restore()
+	restore := store.Restore()
 	defer restore.Abort()
 
 	// Create a decoder
-	dec := codec.NewDecoder(old, msgpackHandle)
+	dec := codec.NewDecoder(in, msgpackHandle)
 
 	// Read in the header
 	var header snapshotHeader
 	if err := dec.Decode(&header); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Populate the new state
+	counts := make(map[structs.MessageType]int)
 	msgType := make([]byte, 1)
 	for {
 		// Read the message type
-		_, err := old.Read(msgType)
+		_, err := in.Read(msgType)
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Decode
 		msg := structs.MessageType(msgType[0])
 		if fn := restorers[msg]; fn != nil {
 			if err := fn(&header, restore, dec); err != nil {
-				return err
+				return nil, err
 			}
 		} else {
-			return fmt.Errorf("Unrecognized msg type %d", msg)
+			return nil, fmt.Errorf("Unrecognized msg type %d", msg)
 		}
+		counts[msg]++
 	}
 	restore.Commit()
-
-	// External code might be calling State(), so we need to synchronize
-	// here to make sure we swap in the new state store atomically.
-	c.stateLock.Lock()
-	stateOld := c.state
-	c.state = stateNew
-	c.stateLock.Unlock()
-
-	// Signal that the old state store has been abandoned. This is required
-	// because we don't operate on it any more, we just throw it away, so
-	// blocking queries won't see any changes and need to be woken up.
-	stateOld.Abandon()
-	return nil
+	return counts, nil
 }
@@ -2,6 +2,7 @@ package fsm
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -82,3 +83,66 @@ func (s *snapshot) Persist(sink raft.SnapshotSink) error {
 func (s *snapshot) Release() {
 	s.state.Close()
 }
+
+// snapshotRecordNames are the names of the types of records in a snapshot,
+// which are reported by VerifySnapshot.
+var snapshotRecordNames = map[structs.MessageType]string{
+	structs.RegisterRequestType:              "Registration",
+	structs.KVSRequestType:                   "KV",
+	structs.TombstoneRequestType:             "Tombstone",
+	structs.SessionRequestType:               "Session",
+	structs.ACLRequestType:                   "LegacyACL",
+	structs.ACLBootstrapRequestType:          "ACLBootstrap",
+	structs.CoordinateBatchUpdateType:        "Coordinate",
+	structs.PreparedQueryRequestType:         "PreparedQuery",
+	structs.AutopilotRequestType:             "AutopilotConfig",
+	structs.IntentionRequestType:             "Intention",
+	structs.ConnectCARequestType:             "CARoot",
+	structs.ConnectCAProviderStateType:       "CAProviderState",
+	structs.ConnectCAConfigType:              "CAConfig",
+	structs.IndexRequestType:                 "TableIndex",
+	structs.ACLTokenSetRequestType:           "ACLToken",
+	structs.ACLPolicySetRequestType:          "ACLPolicy",
+	structs.ConfigEntryRequestType:           "ConfigEntry",
+	structs.ServiceVirtualIPRequestType:      "ServiceVirtualIP",
+	structs.FreeVirtualIPRequestType:         "FreeVirtualIP",
+	structs.ServiceWeightOverrideRequestType: "ServiceWeightOverride",
+	structs.AuditEntryRequestType:            "AuditEntry",
+	structs.ClusterIdentityRequestType:       "ClusterIdentity",
+}
+
+// VerifySnapshot decodes the FSM snapshot from the reader into a scratch
+// state store, so that a corrupted snapshot or one with records this version
+// doesn't know fails before it replaces the state of the servers. It returns
+// a summary of the contents of the snapshot.
+func VerifySnapshot(in io.Reader) (*structs.SnapshotSummary, error) {
+	store, err := state.NewStateStore(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := restoreSnapshot(in, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot state: %v", err)
+	}
+
+	summary := &structs.SnapshotSummary{
+		Records: make(map[string]int),
+	}
+	for msg, count := range counts {
+		name, ok := snapshotRecordNames[msg]
+		if !ok {
+			name = fmt.Sprintf("Type%d", msg)
+		}
+		summary.Records[name] += count
+	}
+
+	identity, err := store.ClusterIdentity(nil)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		summary.ClusterID = identity.ID
+	}
+	return summary, nil
+}
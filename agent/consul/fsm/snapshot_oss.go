@@ -27,9 +27,19 @@ func init() {
 	registerRestorer(structs.IndexRequestType, restoreIndex)
 	registerRestorer(structs.ACLTokenSetRequestType, restoreToken)
 	registerRestorer(structs.ACLPolicySetRequestType, restorePolicy)
+	registerRestorer(structs.ConfigEntryRequestType, restoreConfigEntry)
+	registerRestorer(structs.ServiceVirtualIPRequestType, restoreServiceVirtualIP)
+	registerRestorer(structs.FreeVirtualIPRequestType, restoreFreeVirtualIP)
+	registerRestorer(structs.ServiceWeightOverrideRequestType, restoreServiceWeightOverride)
+	registerRestorer(structs.AuditEntryRequestType, restoreAuditEntry)
+	registerRestorer(structs.ClusterIdentityRequestType, restoreClusterIdentity)
 }
 
 func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) error {
+	// Virtual IPs go first so restoring the services doesn't assign new ones.
+	if err := s.persistVirtualIPs(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistNodes(sink, encoder); err != nil {
 		return err
 	}
@@ -63,6 +73,18 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistConnectCAConfig(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistConfigEntries(sink, encoder); err != nil {
+		return err
+	}
+	if err := s.persistServiceWeightOverrides(sink, encoder); err != nil {
+		return err
+	}
+	if err := s.persistAuditEntries(sink, encoder); err != nil {
+		return err
+	}
+	if err := s.persistClusterIdentity(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistIndex(sink, encoder); err != nil {
 		return err
 	}
@@ -360,6 +382,113 @@ func (s *snapshot) persistIntentions(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *snapshot) persistVirtualIPs(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	vips, err := s.state.ServiceVirtualIPs()
+	if err != nil {
+		return err
+	}
+
+	for _, vip := range vips {
+		if _, err := sink.Write([]byte{byte(structs.ServiceVirtualIPRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(vip); err != nil {
+			return err
+		}
+	}
+
+	free, err := s.state.FreeVirtualIPs()
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range free {
+		if _, err := sink.Write([]byte{byte(structs.FreeVirtualIPRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) persistServiceWeightOverrides(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	overrides, err := s.state.ServiceWeightOverrides()
+	if err != nil {
+		return err
+	}
+
+	for _, override := range overrides {
+		if _, err := sink.Write([]byte{byte(structs.ServiceWeightOverrideRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(override); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) persistClusterIdentity(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	identity, err := s.state.ClusterIdentity()
+	if err != nil {
+		return err
+	}
+	if identity == nil {
+		return nil
+	}
+
+	if _, err := sink.Write([]byte{byte(structs.ClusterIdentityRequestType)}); err != nil {
+		return err
+	}
+	return encoder.Encode(identity)
+}
+
+func (s *snapshot) persistAuditEntries(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	entries, err := s.state.AuditEntries()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := sink.Write([]byte{byte(structs.AuditEntryRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) persistConfigEntries(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	entries, err := s.state.ConfigEntries()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := sink.Write([]byte{byte(structs.ConfigEntryRequestType)}); err != nil {
+			return err
+		}
+		// Encode the kind first so the entry can be decoded into the
+		// right type on restore.
+		if err := encoder.Encode(entry.GetKind()); err != nil {
+			return err
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *snapshot) persistIndex(sink raft.SnapshotSink, encoder *codec.Encoder) error {
 	// Get all the indexes
 	iter, err := s.state.Indexes()
@@ -533,6 +662,61 @@ func restoreConnectCAConfig(header *snapshotHeader, restore *state.Restore, deco
 	return nil
 }
 
+func restoreServiceVirtualIP(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ServiceVirtualIP
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.ServiceVirtualIP(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreServiceWeightOverride(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ServiceWeightOverride
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.ServiceWeightOverride(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreClusterIdentity(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ClusterIdentity
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.ClusterIdentity(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreAuditEntry(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.AuditEntry
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.AuditEntry(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreFreeVirtualIP(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req state.FreeVirtualIP
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.FreeVirtualIP(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
 func restoreIndex(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req state.IndexEntry
 	if err := decoder.Decode(&req); err != nil {
@@ -565,3 +749,18 @@ func restorePolicy(header *snapshotHeader, restore *state.Restore, decoder *code
 	}
 	return restore.ACLPolicy(&req)
 }
+
+func restoreConfigEntry(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var kind string
+	if err := decoder.Decode(&kind); err != nil {
+		return err
+	}
+	entry, err := structs.MakeConfigEntry(kind, "")
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(entry); err != nil {
+		return err
+	}
+	return restore.ConfigEntry(entry)
+}
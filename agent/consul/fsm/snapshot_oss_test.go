@@ -170,6 +170,43 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	}
 	assert.Nil(fsm.state.IntentionSet(14, ixn))
 
+	// Config entries
+	gateway := &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gateway",
+		Services: []structs.LinkedService{
+			{Name: "db"},
+		},
+	}
+	assert.Nil(fsm.state.EnsureConfigEntry(18, gateway))
+
+	// Virtual IPs, "web" keeps its IP and the one of "cache" is freed.
+	assert.Nil(fsm.state.EnsureService(19, "baz", &structs.NodeService{ID: "cache", Service: "cache", Connect: structs.ServiceConnect{Native: true}}))
+	assert.Nil(fsm.state.DeleteService(20, "baz", "cache"))
+
+	// Weight overrides
+	assert.Nil(fsm.state.ServiceWeightOverrideSet(21, &structs.ServiceWeightOverride{
+		Node:      "baz",
+		ServiceID: "web",
+	}))
+
+	// Audit trail
+	audit := &structs.AuditEntry{
+		AuditInfo: structs.AuditInfo{
+			AccessorID: "b6c3ce5b-6d71-4b8a-93ff-8e5e0b1a5c04",
+			Time:       time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Resource: structs.AuditResourceConfigEntry,
+		Name:     "terminating-gateway/my-gateway",
+		Op:       "upsert",
+		After:    []byte(`{"Kind":"terminating-gateway"}`),
+	}
+	assert.Nil(fsm.state.AuditEntryAppend(23, audit))
+
+	// Cluster identity
+	_, err = fsm.state.ClusterIdentitySet(24, &structs.ClusterIdentity{ID: "d2d2ec25-fc52-4d0e-9c8a-0c3b43f7a2a5"})
+	assert.Nil(err)
+
 	// CA Roots
 	roots := []*structs.CARoot{
 		connect.TestCA(t, nil),
@@ -372,6 +409,57 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	assert.Len(ixns, 1)
 	assert.Equal(ixn, ixns[0])
 
+	// Verify config entries are restored.
+	_, entries, err := fsm2.state.ConfigEntries(nil)
+	assert.Nil(err)
+	assert.Equal([]structs.ConfigEntry{gateway}, entries)
+
+	// Verify virtual IPs are restored.
+	_, vip, err := fsm2.state.VirtualIPForService(nil, "web")
+	assert.Nil(err)
+	assert.Equal("240.0.0.1", vip.IP)
+	_, vip, err = fsm2.state.VirtualIPForService(nil, "cache")
+	assert.Nil(err)
+	assert.Nil(vip)
+
+	// Verify freed virtual IPs are restored without the services assigning
+	// new ones.
+	stateSnap := fsm2.state.Snapshot()
+	free, err := stateSnap.FreeVirtualIPs()
+	stateSnap.Close()
+	assert.Nil(err)
+	assert.Equal([]*state.FreeVirtualIP{
+		{IP: "240.0.0.2"},
+		{IP: "240.0.0.2", IsCounter: true},
+	}, free)
+	assert.Nil(fsm2.state.EnsureService(22, "baz", &structs.NodeService{ID: "api", Service: "api", Connect: structs.ServiceConnect{Native: true}}))
+	_, vip, err = fsm2.state.VirtualIPForService(nil, "api")
+	assert.Nil(err)
+	assert.Equal("240.0.0.2", vip.IP)
+
+	// Verify weight overrides are restored.
+	_, overrides, err := fsm2.state.ServiceWeightOverrides(nil)
+	assert.Nil(err)
+	assert.Equal(structs.ServiceWeightOverrides{{
+		Node:      "baz",
+		ServiceID: "web",
+		Service:   "web",
+		RaftIndex: structs.RaftIndex{CreateIndex: 21, ModifyIndex: 21},
+	}}, overrides)
+
+	// Verify the audit trail is restored.
+	_, trail, err := fsm2.state.AuditTrail(nil, "")
+	assert.Nil(err)
+	assert.Equal(structs.AuditEntries{audit}, trail)
+
+	// Verify the cluster identity is restored.
+	identity, err := fsm2.state.ClusterIdentity(nil)
+	assert.Nil(err)
+	assert.Equal(&structs.ClusterIdentity{
+		ID:        "d2d2ec25-fc52-4d0e-9c8a-0c3b43f7a2a5",
+		RaftIndex: structs.RaftIndex{CreateIndex: 24, ModifyIndex: 24},
+	}, identity)
+
 	// Verify CA roots are restored.
 	_, roots, err = fsm2.state.CARoots(nil)
 	assert.Nil(err)
@@ -384,7 +472,7 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	assert.Equal("bar", state.RootCert)
 
 	// Verify CA configuration is restored.
-	_, caConf, err := fsm2.state.CAConfig()
+	_, caConf, err := fsm2.state.CAConfig(nil)
 	assert.Nil(err)
 	assert.Equal(caConfig, caConf)
 
@@ -492,7 +580,7 @@ func TestFSM_BadSnapshot_NilCAConfig(t *testing.T) {
 
 	// Make sure there's no entry in the CA config table.
 	state := fsm2.State()
-	idx, config, err := state.CAConfig()
+	idx, config, err := state.CAConfig(nil)
 	require.NoError(err)
 	require.Equal(uint64(0), idx)
 	if config != nil {
@@ -0,0 +1,59 @@
+package fsm
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySnapshot(t *testing.T) {
+	t.Parallel()
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(t, err)
+
+	require.NoError(t, fsm.state.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(t, fsm.state.KVSSet(2, &structs.DirEntry{Key: "a", Value: []byte("1")}))
+	require.NoError(t, fsm.state.KVSSet(3, &structs.DirEntry{Key: "b", Value: []byte("2")}))
+	_, err = fsm.state.ClusterIdentitySet(4, &structs.ClusterIdentity{ID: "c0ffee"})
+	require.NoError(t, err)
+
+	snap, err := fsm.Snapshot()
+	require.NoError(t, err)
+	defer snap.Release()
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, snap.Persist(&MockSink{buf, false}))
+
+	summary, err := VerifySnapshot(buf)
+	require.NoError(t, err)
+	require.Equal(t, "c0ffee", summary.ClusterID)
+	require.Equal(t, 2, summary.Records["KV"])
+	require.Equal(t, 1, summary.Records["Registration"])
+	require.Equal(t, 1, summary.Records["ClusterIdentity"])
+
+	// The state of the FSM isn't touched.
+	_, nodes, err := fsm.state.Nodes(nil)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+}
+
+func TestVerifySnapshot_UnknownRecord(t *testing.T) {
+	t.Parallel()
+
+	// A snapshot of a newer version may have records this one doesn't
+	// know.
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, codec.NewEncoder(buf, msgpackHandle).Encode(&snapshotHeader{LastIndex: 1}))
+	buf.WriteByte(byte(structs.IgnoreUnknownTypeFlag - 1))
+
+	_, err := VerifySnapshot(buf)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Unrecognized msg type")
+
+	_, err = VerifySnapshot(bytes.NewBufferString("bad snapshot"))
+	require.Error(t, err)
+}
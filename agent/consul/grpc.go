@@ -0,0 +1,120 @@
+package consul
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/consul/grpcpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// grpcListener implements net.Listener for the gRPC server, which is handed
+// the connections that selected the gRPC transport on the RPC port.
+type grpcListener struct {
+	// addr is the listener address to return.
+	addr net.Addr
+
+	// connCh is used to accept connections.
+	connCh chan net.Conn
+
+	// Tracks if we are closed
+	closed    bool
+	closeCh   chan struct{}
+	closeLock sync.Mutex
+}
+
+func newGRPCListener(addr net.Addr) *grpcListener {
+	return &grpcListener{
+		addr:    addr,
+		connCh:  make(chan net.Conn),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Handoff is used to hand off a connection to the gRPC server.
+func (l *grpcListener) Handoff(c net.Conn) error {
+	select {
+	case l.connCh <- c:
+		return nil
+	case <-l.closeCh:
+		return fmt.Errorf("gRPC listener closed")
+	}
+}
+
+// Accept is used to return the connections handed off to the listener.
+func (l *grpcListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.connCh:
+		return conn, nil
+	case <-l.closeCh:
+		return nil, fmt.Errorf("gRPC listener closed")
+	}
+}
+
+// Close is used to stop accepting connections.
+func (l *grpcListener) Close() error {
+	l.closeLock.Lock()
+	defer l.closeLock.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.closeCh)
+	}
+	return nil
+}
+
+// Addr is used to return the address of the listener
+func (l *grpcListener) Addr() net.Addr {
+	return l.addr
+}
+
+// setupGRPC creates the gRPC server and registers its services. It serves
+// the connections handed off by the RPC listener.
+func (s *Server) setupGRPC() {
+	s.grpcListener = newGRPCListener(s.Listener.Addr())
+	s.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(grpcMetricsInterceptor))
+	grpcpb.RegisterStatusServer(s.grpcServer, &GRPCStatus{srv: s})
+	go s.grpcServer.Serve(s.grpcListener)
+}
+
+// handleGRPCConn hands a connection that selected the gRPC transport to the
+// gRPC server.
+func (s *Server) handleGRPCConn(conn net.Conn) {
+	metrics.IncrCounter([]string{"rpc", "grpc_handoff"}, 1)
+	if err := s.grpcListener.Handoff(conn); err != nil {
+		s.logger.Printf("[ERR] consul.rpc: failed to hand off gRPC conn: %v %s", err, logConn(conn))
+		conn.Close()
+	}
+}
+
+// grpcMetricsInterceptor measures the time each gRPC request takes and counts
+// the failed ones, labeled with the method.
+func grpcMetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	labels := []metrics.Label{{Name: "method", Value: info.FullMethod}}
+	defer metrics.MeasureSinceWithLabels([]string{"grpc", "server", "request"}, time.Now(), labels)
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		metrics.IncrCounterWithLabels([]string{"grpc", "server", "request", "error"}, 1, labels)
+	}
+	return resp, err
+}
+
+// GRPCStatus implements the Status gRPC service.
+type GRPCStatus struct {
+	srv *Server
+}
+
+// Ping is used to check the connection to a server.
+func (s *GRPCStatus) Ping(ctx context.Context, req *grpcpb.PingRequest) (*grpcpb.PingResponse, error) {
+	return &grpcpb.PingResponse{}, nil
+}
+
+// Leader returns the RPC address of the current Raft leader.
+func (s *GRPCStatus) Leader(ctx context.Context, req *grpcpb.LeaderRequest) (*grpcpb.LeaderResponse, error) {
+	return &grpcpb.LeaderResponse{Address: string(s.srv.raft.Leader())}, nil
+}
@@ -0,0 +1,163 @@
+package consul
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/consul/grpcpb"
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/tlsutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/connectivity"
+)
+
+// testGRPCTLS returns a config callback that sets up TLS for the server with
+// a freshly generated CA and certificate.
+func testGRPCTLS(t *testing.T, verifyOutgoing bool) func(c *Config) {
+	dir, err := ioutil.TempDir("", "consul-grpc-tls")
+	require.NoError(t, err)
+
+	signer, _, err := tlsutil.GeneratePrivateKey()
+	require.NoError(t, err)
+	sn, err := tlsutil.GenerateSerialNumber()
+	require.NoError(t, err)
+	ca, err := tlsutil.GenerateCA(signer, sn, 1, nil)
+	require.NoError(t, err)
+	sn, err = tlsutil.GenerateSerialNumber()
+	require.NoError(t, err)
+	cert, key, err := tlsutil.GenerateCert(signer, ca, sn, "server.dc1.consul", 1,
+		[]string{"server.dc1.consul", "localhost"}, []net.IP{net.ParseIP("127.0.0.1")},
+		[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
+	require.NoError(t, err)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "server.pem")
+	keyFile := filepath.Join(dir, "server-key.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, []byte(ca), 0600))
+	require.NoError(t, ioutil.WriteFile(certFile, []byte(cert), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(key), 0600))
+
+	return func(c *Config) {
+		c.CAFile = caFile
+		c.CertFile = certFile
+		c.KeyFile = keyFile
+		c.VerifyIncoming = true
+		c.VerifyOutgoing = verifyOutgoing
+	}
+}
+
+func TestGRPC_Status(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		useTLS bool
+		cb     func(c *Config)
+	}{
+		"plain": {
+			false,
+			nil,
+		},
+		"TLS": {
+			true,
+			testGRPCTLS(t, true),
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require := require.New(t)
+
+			dir1, s1 := testServerWithConfig(t, tc.cb)
+			defer os.RemoveAll(dir1)
+			defer s1.Shutdown()
+			testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+			conn, err := s1.connPool.GRPCConn("dc1", s1.config.RPCAddr, tc.useTLS)
+			require.NoError(err)
+			client := grpcpb.NewStatusClient(conn)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = client.Ping(ctx, &grpcpb.PingRequest{})
+			require.NoError(err)
+
+			resp, err := client.Leader(ctx, &grpcpb.LeaderRequest{})
+			require.NoError(err)
+			require.Equal(s1.config.RPCAdvertise.String(), resp.Address)
+
+			// The connection is shared.
+			conn2, err := s1.connPool.GRPCConn("dc1", s1.config.RPCAddr, tc.useTLS)
+			require.NoError(err)
+			require.True(conn == conn2)
+		})
+	}
+}
+
+func TestGRPC_VerifyIncoming(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, testGRPCTLS(t, false))
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Plain gRPC connections are rejected.
+	conn, err := s1.connPool.GRPCConn("dc1", s1.config.RPCAddr, false)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = grpcpb.NewStatusClient(conn).Ping(ctx, &grpcpb.PingRequest{})
+	require.Error(t, err)
+}
+
+func TestGRPC_Ping(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]func(c *Config){
+		"plain": nil,
+		"TLS":   testGRPCTLS(t, true),
+	}
+
+	for name, cb := range cases {
+		cb := cb
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require := require.New(t)
+
+			dir1, s1 := testServerWithConfig(t, cb)
+			defer os.RemoveAll(dir1)
+			defer s1.Shutdown()
+			testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+			// The server advertises its gRPC transport, which is then used
+			// to ping it.
+			var server *metadata.Server
+			for _, m := range s1.LANMembers() {
+				if ok, parts := metadata.IsConsulServer(m); ok {
+					server = parts
+				}
+			}
+			require.NotNil(server)
+			require.True(server.UseGRPC)
+
+			ok, err := s1.connPool.Ping(server.Datacenter, server.Addr, server.Version, server.UseTLS, server.UseGRPC)
+			require.NoError(err)
+			require.True(ok)
+
+			// The ping went over the shared gRPC connection.
+			conn, err := s1.connPool.GRPCConn(server.Datacenter, server.Addr, server.UseTLS)
+			require.NoError(err)
+			require.Equal(connectivity.Ready, conn.GetState())
+		})
+	}
+}
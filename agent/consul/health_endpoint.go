@@ -25,7 +25,7 @@ func (h *Health) ChecksInState(args *structs.ChecksInStateRequest,
 	return h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
-		func(ws memdb.WatchSet, state *state.Store) error {
+		h.srv.limitQuery(queryClassHealth, func(ws memdb.WatchSet, state *state.Store) error {
 			var index uint64
 			var checks structs.HealthChecks
 			var err error
@@ -38,11 +38,14 @@ func (h *Health) ChecksInState(args *structs.ChecksInStateRequest,
 				return err
 			}
 			reply.Index, reply.HealthChecks = index, checks
+			if err := h.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
 			if err := h.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.HealthChecks)
-		})
+		}))
 }
 
 // NodeChecks is used to get all the checks for a node
@@ -61,6 +64,9 @@ func (h *Health) NodeChecks(args *structs.NodeSpecificRequest,
 				return err
 			}
 			reply.Index, reply.HealthChecks = index, checks
+			if err := h.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
 			return h.srv.filterACL(args.Token, reply)
 		})
 }
@@ -94,6 +100,9 @@ func (h *Health) ServiceChecks(args *structs.ServiceSpecificRequest,
 				return err
 			}
 			reply.Index, reply.HealthChecks = index, checks
+			if err := h.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
 			if err := h.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
@@ -141,7 +150,7 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 	err := h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
-		func(ws memdb.WatchSet, state *state.Store) error {
+		h.srv.limitQuery(queryClassHealth, func(ws memdb.WatchSet, state *state.Store) error {
 			index, nodes, err := f(ws, state, args)
 			if err != nil {
 				return err
@@ -151,11 +160,14 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 			if len(args.NodeMetaFilters) > 0 {
 				reply.Nodes = nodeMetaFilter(args.NodeMetaFilters, reply.Nodes)
 			}
+			if err := h.srv.filterExported(ws, state, args.SourceDatacenter, reply); err != nil {
+				return err
+			}
 			if err := h.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
-		})
+		}))
 
 	// Provide some metrics
 	if err == nil {
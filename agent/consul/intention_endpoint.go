@@ -41,6 +41,35 @@ func (s *Intention) Apply(
 	defer metrics.MeasureSince([]string{"consul", "intention", "apply"}, time.Now())
 	defer metrics.MeasureSince([]string{"intention", "apply"}, time.Now())
 
+	// Get the ACL token for the request for the checks below.
+	rule, err := s.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := intentionPreApply(s.srv, rule, args); err != nil {
+		return err
+	}
+	*reply = args.Intention.ID
+	args.Audit = s.srv.auditInfo(args.Token)
+
+	// Commit
+	resp, err := s.srv.raftApply(structs.IntentionRequestType, args)
+	if err != nil {
+		s.srv.logger.Printf("[ERR] consul.intention: Apply failed %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	return nil
+}
+
+// intentionPreApply checks the ACLs for an intention operation, validates it
+// and sets the fields that must be fixed before it is appended to the Raft
+// log. It is shared by Intention.Apply and transactions.
+func intentionPreApply(srv *Server, rule acl.Authorizer, args *structs.IntentionRequest) error {
 	// Always set a non-nil intention to avoid nil-access below
 	if args.Intention == nil {
 		args.Intention = &structs.Intention{}
@@ -55,18 +84,18 @@ func (s *Intention) Apply(
 			return fmt.Errorf("ID must be empty when creating a new intention")
 		}
 
-		state := s.srv.fsm.State()
+		state := srv.fsm.State()
 		for {
 			var err error
 			args.Intention.ID, err = uuid.GenerateUUID()
 			if err != nil {
-				s.srv.logger.Printf("[ERR] consul.intention: UUID generation failed: %v", err)
+				srv.logger.Printf("[ERR] consul.intention: UUID generation failed: %v", err)
 				return err
 			}
 
 			_, ixn, err := state.IntentionGet(nil, args.Intention.ID)
 			if err != nil {
-				s.srv.logger.Printf("[ERR] consul.intention: intention lookup failed: %v", err)
+				srv.logger.Printf("[ERR] consul.intention: intention lookup failed: %v", err)
 				return err
 			}
 			if ixn == nil {
@@ -77,25 +106,18 @@ func (s *Intention) Apply(
 		// Set the created at
 		args.Intention.CreatedAt = time.Now().UTC()
 	}
-	*reply = args.Intention.ID
-
-	// Get the ACL token for the request for the checks below.
-	rule, err := s.srv.ResolveToken(args.Token)
-	if err != nil {
-		return err
-	}
 
 	// Perform the ACL check
 	if prefix, ok := args.Intention.GetACLPrefix(); ok {
 		if rule != nil && !rule.IntentionWrite(prefix) {
-			s.srv.logger.Printf("[WARN] consul.intention: Operation on intention '%s' denied due to ACLs", args.Intention.ID)
+			srv.logger.Printf("[WARN] consul.intention: Operation on intention '%s' denied due to ACLs", args.Intention.ID)
 			return acl.ErrPermissionDenied
 		}
 	}
 
 	// If this is not a create, then we have to verify the ID.
 	if args.Op != structs.IntentionOpCreate {
-		state := s.srv.fsm.State()
+		state := srv.fsm.State()
 		_, ixn, err := state.IntentionGet(nil, args.Intention.ID)
 		if err != nil {
 			return fmt.Errorf("Intention lookup failed: %v", err)
@@ -108,7 +130,7 @@ func (s *Intention) Apply(
 		// which must be true to perform any rename.
 		if prefix, ok := ixn.GetACLPrefix(); ok {
 			if rule != nil && !rule.IntentionWrite(prefix) {
-				s.srv.logger.Printf("[WARN] consul.intention: Operation on intention '%s' denied due to ACLs", args.Intention.ID)
+				srv.logger.Printf("[WARN] consul.intention: Operation on intention '%s' denied due to ACLs", args.Intention.ID)
 				return acl.ErrPermissionDenied
 			}
 		}
@@ -141,16 +163,6 @@ func (s *Intention) Apply(
 		}
 	}
 
-	// Commit
-	resp, err := s.srv.raftApply(structs.IntentionRequestType, args)
-	if err != nil {
-		s.srv.logger.Printf("[ERR] consul.intention: Apply failed %v", err)
-		return err
-	}
-	if respErr, ok := resp.(error); ok {
-		return respErr
-	}
-
 	return nil
 }
 
@@ -60,6 +60,42 @@ func (m *Internal) NodeDump(args *structs.DCSpecificRequest,
 		})
 }
 
+// GatewayServiceDump returns the services bound to an ingress or terminating
+// gateway along with their instances.
+func (m *Internal) GatewayServiceDump(args *structs.ServiceSpecificRequest,
+	reply *structs.IndexedGatewayServices) error {
+	if done, err := m.srv.forward("Internal.GatewayServiceDump", args, args, reply); done {
+		return err
+	}
+
+	if args.ServiceName == "" {
+		return fmt.Errorf("Must provide gateway name")
+	}
+
+	// The services bound to a gateway are part of its configuration so they
+	// can only be listed with read access to the gateway.
+	rule, err := m.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.ServiceRead(args.ServiceName) {
+		return acl.ErrPermissionDenied
+	}
+
+	return m.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, services, err := state.GatewayServices(ws, args.ServiceName)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Services = index, services
+			return m.srv.filterACL(args.Token, reply)
+		})
+}
+
 // EventFire is a bit of an odd endpoint, but it allows for a cross-DC RPC
 // call to fire an event. The primary use case is to enable user events being
 // triggered in a remote DC.
@@ -148,22 +184,20 @@ func (m *Internal) executeKeyringOp(
 	wan bool) {
 
 	if wan {
-		mgr := m.srv.KeyManagerWAN()
-		m.executeKeyringOpMgr(mgr, args, reply, wan, "")
+		m.executeKeyringOpMgr(m.srv.serfWAN, args, reply, wan, "")
 	} else {
 		segments := m.srv.LANSegments()
 		for name, segment := range segments {
-			mgr := segment.KeyManager()
-			m.executeKeyringOpMgr(mgr, args, reply, wan, name)
+			m.executeKeyringOpMgr(segment, args, reply, wan, name)
 		}
 	}
 }
 
 // executeKeyringOpMgr executes the appropriate keyring-related function based on
-// the type of keyring operation in the request. It takes the KeyManager as an
+// the type of keyring operation in the request. It takes the Serf pool as an
 // argument, so it can handle any operation for either LAN or WAN pools.
 func (m *Internal) executeKeyringOpMgr(
-	mgr *serf.KeyManager,
+	pool *serf.Serf,
 	args *structs.KeyringRequest,
 	reply *structs.KeyringResponses,
 	wan bool,
@@ -171,6 +205,7 @@ func (m *Internal) executeKeyringOpMgr(
 	var serfResp *serf.KeyResponse
 	var err error
 
+	mgr := pool.KeyManager()
 	opts := &serf.KeyRequestOptions{RelayFactor: args.RelayFactor}
 	switch args.Operation {
 	case structs.KeyringList:
@@ -188,7 +223,7 @@ func (m *Internal) executeKeyringOpMgr(
 		errStr = err.Error()
 	}
 
-	reply.Responses = append(reply.Responses, &structs.KeyringResponse{
+	resp := &structs.KeyringResponse{
 		WAN:        wan,
 		Datacenter: m.srv.config.Datacenter,
 		Segment:    segment,
@@ -196,5 +231,18 @@ func (m *Internal) executeKeyringOpMgr(
 		Keys:       serfResp.Keys,
 		NumNodes:   serfResp.NumNodes,
 		Error:      errStr,
-	})
+	}
+
+	// Ask each node for its keyring as well, since the key manager only
+	// reports how many nodes have each key installed.
+	if args.Operation == structs.KeyringList && args.Detailed && err == nil {
+		primaryKeys, nodes, err := queryKeyringStatus(pool, args.RelayFactor)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.PrimaryKeys, resp.Nodes = primaryKeys, nodes
+		}
+	}
+
+	reply.Responses = append(reply.Responses, resp)
 }
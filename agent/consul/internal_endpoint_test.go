@@ -10,7 +10,9 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/memberlist"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInternal_NodeInfo(t *testing.T) {
@@ -242,6 +244,68 @@ func TestInternal_KeyringOperation(t *testing.T) {
 	}
 }
 
+func TestInternal_KeyringOperation_Detailed(t *testing.T) {
+	t.Parallel()
+	key1 := "H1dfkSZOVnP/JUnaBfTzXg=="
+	key2 := "z90lFx3sZZLtTOkutXcwYg=="
+	keyBytes1, err := base64.StdEncoding.DecodeString(key1)
+	require.NoError(t, err)
+	keyBytes2, err := base64.StdEncoding.DecodeString(key2)
+	require.NoError(t, err)
+
+	// Both servers have both keys installed, but use different primaries.
+	keyring := func(primary []byte) *memberlist.Keyring {
+		k, err := memberlist.NewKeyring([][]byte{keyBytes1, keyBytes2}, primary)
+		require.NoError(t, err)
+		return k
+	}
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.SerfLANConfig.MemberlistConfig.Keyring = keyring(keyBytes1)
+		c.SerfWANConfig.MemberlistConfig.Keyring = keyring(keyBytes1)
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Bootstrap = false
+		c.SerfLANConfig.MemberlistConfig.Keyring = keyring(keyBytes2)
+		c.SerfWANConfig.MemberlistConfig.Keyring = keyring(keyBytes2)
+	})
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	joinLAN(t, s2, s1)
+	joinWAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	var out structs.KeyringResponses
+	req := structs.KeyringRequest{
+		Operation:  structs.KeyringList,
+		Datacenter: "dc1",
+		Detailed:   true,
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Internal.KeyringOperation", &req, &out))
+	require.Len(t, out.Responses, 2)
+	for _, resp := range out.Responses {
+		require.Empty(t, resp.Error)
+		require.Equal(t, map[string]int{key1: 1, key2: 1}, resp.PrimaryKeys)
+		require.Len(t, resp.Nodes, 2)
+
+		// The nodes are sorted by name.
+		require.True(t, resp.Nodes[0].Node < resp.Nodes[1].Node)
+		for _, node := range resp.Nodes {
+			require.Empty(t, node.Error)
+			require.ElementsMatch(t, []string{key1, key2}, node.Keys)
+			primary := key1
+			if node.Node == s2.config.NodeName || node.Node == s2.config.NodeName+".dc1" {
+				primary = key2
+			}
+			require.Equal(t, primary, node.PrimaryKey)
+		}
+	}
+}
+
 func TestInternal_NodeInfo_FilterACL(t *testing.T) {
 	t.Parallel()
 	dir, token, srv, codec := testACLFilterServer(t)
@@ -378,3 +442,100 @@ func TestInternal_EventFire_Token(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 }
+
+func TestInternal_GatewayServiceDump(t *testing.T) {
+	t.Parallel()
+	dir, srv := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+		c.ACLEnforceVersion8 = false
+	})
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+
+	codec := rpcClient(t, srv)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, srv.RPC, "dc1")
+
+	// Register web and db and expose both on an ingress gateway.
+	for _, name := range []string{"web", "db"} {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      name,
+				Service: name,
+				Port:    8080,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, nil))
+	}
+	entry := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.IngressGatewayConfigEntry{
+			Name: "ingress",
+			Listeners: []structs.IngressListener{
+				{Port: 8443, TLS: true, Services: []structs.IngressService{
+					{Name: "web", Hosts: []string{"web.example.com"}},
+					{Name: "db"},
+				}},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &entry, &out))
+
+	// The token can read the gateway and web but not db.
+	var token string
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name: "User token",
+			Type: structs.ACLTokenTypeClient,
+			Rules: `
+service "ingress" {
+	policy = "read"
+}
+service "web" {
+	policy = "read"
+}
+`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &token))
+
+	args := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "ingress",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	var reply structs.IndexedGatewayServices
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Internal.GatewayServiceDump", &args, &reply))
+	require.Len(t, reply.Services, 2)
+	require.Equal(t, "web", reply.Services[0].Service)
+	require.Equal(t, structs.ServiceKindIngressGateway, reply.Services[0].GatewayKind)
+	require.Equal(t, []string{"web.example.com"}, reply.Services[0].Hosts)
+	require.Len(t, reply.Services[0].Instances, 1)
+	require.Equal(t, "db", reply.Services[1].Service)
+
+	// Services the token can't read are dropped.
+	args.Token = token
+	reply = structs.IndexedGatewayServices{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Internal.GatewayServiceDump", &args, &reply))
+	require.Len(t, reply.Services, 1)
+	require.Equal(t, "web", reply.Services[0].Service)
+	require.Len(t, reply.Services[0].Instances, 1)
+
+	// Reading the services of a gateway requires read access to it.
+	args.Token = ""
+	err := msgpackrpc.CallWithCodec(codec, "Internal.GatewayServiceDump", &args, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "bad: %v", err)
+}
@@ -0,0 +1,110 @@
+package consul
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/hashicorp/memberlist"
+	"github.com/hashicorp/serf/serf"
+)
+
+// keyringStatusQuery is the name of the Serf query used to ask each node of
+// a gossip pool which keys it has installed.
+const keyringStatusQuery = "consul:keyring-status"
+
+// keyringStatus is the reply of a node to a keyring status query.
+type keyringStatus struct {
+	// Keys are the base64 encoded keys installed on the node.
+	Keys []string
+
+	// PrimaryKey is the base64 encoded key the node encrypts messages with.
+	PrimaryKey string
+}
+
+// handleKeyringStatusQuery replies to a keyring status query with the keys of
+// the given keyring, which is nil if gossip encryption is disabled. Other
+// queries are ignored.
+func handleKeyringStatusQuery(logger *log.Logger, q *serf.Query, keyring *memberlist.Keyring) {
+	if q.Name != keyringStatusQuery {
+		return
+	}
+
+	var status keyringStatus
+	if keyring != nil {
+		for _, key := range keyring.GetKeys() {
+			status.Keys = append(status.Keys, base64.StdEncoding.EncodeToString(key))
+		}
+		status.PrimaryKey = base64.StdEncoding.EncodeToString(keyring.GetPrimaryKey())
+	}
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, &codec.MsgpackHandle{}).Encode(&status); err != nil {
+		logger.Printf("[ERR] consul: failed to encode keyring status: %v", err)
+		return
+	}
+	if err := q.Respond(buf.Bytes()); err != nil {
+		logger.Printf("[ERR] consul: failed to respond to keyring status query: %v", err)
+	}
+}
+
+// queryKeyringStatus asks the nodes of a gossip pool which keys they have
+// installed and returns the status of each node, along with the number of
+// nodes using each key as their primary key. Alive nodes that don't reply
+// before the query times out, such as nodes running older versions, are
+// reported with an error.
+func queryKeyringStatus(pool *serf.Serf, relayFactor uint8) (map[string]int, []*structs.KeyringNodeStatus, error) {
+	params := pool.DefaultQueryParams()
+	params.RelayFactor = relayFactor
+	resp, err := pool.Query(keyringStatusQuery, nil, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Close()
+
+	alive := make(map[string]bool)
+	for _, member := range pool.Members() {
+		if member.Status == serf.StatusAlive {
+			alive[member.Name] = true
+		}
+	}
+
+	statuses := make(map[string]*structs.KeyringNodeStatus)
+	for r := range resp.ResponseCh() {
+		status := &structs.KeyringNodeStatus{Node: r.From}
+		var reply keyringStatus
+		if err := codec.NewDecoder(bytes.NewReader(r.Payload), &codec.MsgpackHandle{}).Decode(&reply); err != nil {
+			status.Error = fmt.Sprintf("Failed to decode keyring status: %v", err)
+		} else {
+			status.Keys, status.PrimaryKey = reply.Keys, reply.PrimaryKey
+		}
+		statuses[r.From] = status
+
+		// Return early if all nodes have responded.
+		if len(statuses) >= len(alive) {
+			break
+		}
+	}
+	for name := range alive {
+		if _, ok := statuses[name]; !ok {
+			statuses[name] = &structs.KeyringNodeStatus{Node: name, Error: "No response"}
+		}
+	}
+
+	primaryKeys := make(map[string]int)
+	nodes := make([]*structs.KeyringNodeStatus, 0, len(statuses))
+	for _, status := range statuses {
+		if status.PrimaryKey != "" {
+			primaryKeys[status.PrimaryKey]++
+		}
+		nodes = append(nodes, status)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+	return primaryKeys, nodes, nil
+}
@@ -171,7 +171,7 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 	return k.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
-		func(ws memdb.WatchSet, state *state.Store) error {
+		k.srv.limitQuery(queryClassKV, func(ws memdb.WatchSet, state *state.Store) error {
 			index, ent, err := state.KVSList(ws, args.Key)
 			if err != nil {
 				return err
@@ -194,7 +194,55 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 				reply.Entries = ent
 			}
 			return nil
-		})
+		}))
+}
+
+// ListPrefixes is used to list the keys of several prefixes from a single
+// snapshot of the KV store, so that their entries are consistent with each
+// other.
+func (k *KVS) ListPrefixes(args *structs.KeyPrefixesRequest, reply *structs.IndexedDirEntries) error {
+	if done, err := k.srv.forward("KVS.ListPrefixes", args, args, reply); done {
+		return err
+	}
+
+	aclToken, err := k.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	if aclToken != nil && k.srv.config.ACLEnableKeyListPolicy {
+		prefixes := args.Prefixes
+		if len(prefixes) == 0 {
+			prefixes = []string{""}
+		}
+		for _, prefix := range prefixes {
+			if !aclToken.KeyList(prefix) {
+				return acl.ErrPermissionDenied
+			}
+		}
+	}
+
+	return k.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		k.srv.limitQuery(queryClassKV, func(ws memdb.WatchSet, state *state.Store) error {
+			index, ent, err := state.KVSListPrefixes(ws, args.Prefixes)
+			if err != nil {
+				return err
+			}
+			if aclToken != nil {
+				ent = FilterDirEnt(aclToken, ent)
+			}
+
+			// Must provide non-zero index to prevent blocking
+			// Index 1 is impossible anyways (due to Raft internals)
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+			reply.Entries = ent
+			return nil
+		}))
 }
 
 // ListKeys is used to list all keys with a given prefix to a separator.
@@ -215,7 +263,7 @@ func (k *KVS) ListKeys(args *structs.KeyListRequest, reply *structs.IndexedKeyLi
 	return k.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
-		func(ws memdb.WatchSet, state *state.Store) error {
+		k.srv.limitQuery(queryClassKV, func(ws memdb.WatchSet, state *state.Store) error {
 			index, keys, err := state.KVSListKeys(ws, args.Prefix, args.Seperator)
 			if err != nil {
 				return err
@@ -234,5 +282,5 @@ func (k *KVS) ListKeys(args *structs.KeyListRequest, reply *structs.IndexedKeyLi
 			}
 			reply.Keys = keys
 			return nil
-		})
+		}))
 }
@@ -1,6 +1,7 @@
 package consul
 
 import (
+	"net/rpc"
 	"os"
 	"testing"
 	"time"
@@ -606,6 +607,144 @@ key "zip" {
 
 }
 
+func TestKVSEndpoint_ListPrefixes(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	apply := func(codec rpc.ClientCodec, key string) {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   key,
+				Flags: 1,
+			},
+		}
+		var out bool
+		if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	for _, key := range []string{"web/port", "db/port", "web/tls/cert", "other"} {
+		apply(codec, key)
+	}
+
+	getR := structs.KeyPrefixesRequest{
+		Datacenter: "dc1",
+		Prefixes:   []string{"web/", "db/"},
+	}
+	var dirent structs.IndexedDirEntries
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.ListPrefixes", &getR, &dirent); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var keys []string
+	for _, d := range dirent.Entries {
+		keys = append(keys, d.Key)
+		if d.Flags != 1 || d.CreateIndex == 0 || d.ModifyIndex == 0 {
+			t.Fatalf("bad: %v", d)
+		}
+	}
+	verify.Values(t, "", keys, []string{"db/port", "web/port", "web/tls/cert"})
+
+	// Setup a blocking query that is woken up by a write to one of the
+	// prefixes.
+	getR.MinQueryIndex = dirent.Index
+	getR.MaxQueryTime = time.Second
+	start := time.Now()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		codec := rpcClient(t, s1)
+		defer codec.Close()
+		apply(codec, "db/user")
+	}()
+
+	dirent = structs.IndexedDirEntries{}
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.ListPrefixes", &getR, &dirent); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if time.Since(start) < 100*time.Millisecond {
+		t.Fatalf("too fast")
+	}
+	if dirent.Index <= getR.MinQueryIndex || len(dirent.Entries) != 4 {
+		t.Fatalf("bad: %v", dirent)
+	}
+}
+
+func TestKVSEndpoint_ListPrefixes_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for _, key := range []string{"abe", "foo", "foo/bar", "test", "zip"} {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key: key,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "User token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: testListRules,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Keys the token can't read are filtered out.
+	getR := structs.KeyPrefixesRequest{
+		Datacenter:   "dc1",
+		Prefixes:     []string{"a", "foo", "test"},
+		QueryOptions: structs.QueryOptions{Token: id},
+	}
+	var dirent structs.IndexedDirEntries
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.ListPrefixes", &getR, &dirent); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var keys []string
+	for _, d := range dirent.Entries {
+		keys = append(keys, d.Key)
+	}
+	verify.Values(t, "", keys, []string{"foo", "foo/bar", "test"})
+
+	// With list policies enforced, every prefix must be listable.
+	s1.config.ACLEnableKeyListPolicy = true
+	err := msgpackrpc.CallWithCodec(codec, "KVS.ListPrefixes", &getR, &dirent)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestKVSEndpoint_ListKeys(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -37,6 +37,10 @@ var (
 	// caRootPruneInterval is how often we check for stale CARoots to remove.
 	caRootPruneInterval = time.Hour
 
+	// caMetricsInterval is how often the leader emits the expiry of the
+	// active CA root.
+	caMetricsInterval = 10 * time.Second
+
 	// minAutopilotVersion is the minimum Consul version in which Autopilot features
 	// are supported.
 	minAutopilotVersion = version.Must(version.NewVersion("0.8.0"))
@@ -261,6 +265,10 @@ func (s *Server) establishLeadership() error {
 		return err
 	}
 
+	if err := s.initializeClusterIdentity(); err != nil {
+		return err
+	}
+
 	s.getOrCreateAutopilotConfig()
 	s.autopilot.Start()
 
@@ -273,6 +281,8 @@ func (s *Server) establishLeadership() error {
 
 	s.startCARootPruning()
 
+	s.startCAMetrics()
+
 	s.setConsistentReadReady()
 	return nil
 }
@@ -293,6 +303,10 @@ func (s *Server) revokeLeadership() error {
 
 	s.stopCARootPruning()
 
+	s.stopCARootRollout()
+
+	s.stopCAMetrics()
+
 	s.setCAProvider(nil, nil)
 
 	s.stopACLUpgrade()
@@ -879,7 +893,7 @@ func (s *Server) getOrCreateAutopilotConfig() *autopilot.Config {
 // when setting up the CA during establishLeadership
 func (s *Server) initializeCAConfig() (*structs.CAConfiguration, error) {
 	state := s.fsm.State()
-	_, config, err := state.CAConfig()
+	_, config, err := state.CAConfig(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1087,7 +1101,7 @@ func (s *Server) pruneCARoots() error {
 		return err
 	}
 
-	_, caConf, err := state.CAConfig()
+	_, caConf, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
@@ -1103,6 +1117,12 @@ func (s *Server) pruneCARoots() error {
 			s.logger.Printf("[INFO] connect: pruning old unused root CA (ID: %s)", r.ID)
 			continue
 		}
+		// A staged root without a rotation in progress was left behind by a
+		// leader that lost leadership before activating it.
+		if r.Staged && !s.caRootRolloutInProgress(r.ID) {
+			s.logger.Printf("[INFO] connect: pruning staged root CA of an interrupted rotation (ID: %s)", r.ID)
+			continue
+		}
 		newRoot := *r
 		newRoots = append(newRoots, &newRoot)
 	}
@@ -1141,6 +1161,71 @@ func (s *Server) stopCARootPruning() {
 	s.caPruningEnabled = false
 }
 
+// startCAMetrics starts a goroutine that periodically emits the time until the
+// active CA root expires.
+func (s *Server) startCAMetrics() {
+	s.caMetricsLock.Lock()
+	defer s.caMetricsLock.Unlock()
+
+	if s.caMetricsEnabled {
+		return
+	}
+
+	s.caMetricsCh = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(caMetricsInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.emitCARootExpiry(); err != nil {
+				s.logger.Printf("[ERR] connect: error emitting CA root metrics: %v", err)
+			}
+
+			select {
+			case <-s.caMetricsCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	s.caMetricsEnabled = true
+}
+
+// emitCARootExpiry sets the gauge with the seconds until the active CA root
+// expires. Nothing is emitted until there is an active root.
+func (s *Server) emitCARootExpiry() error {
+	if !s.config.ConnectEnabled {
+		return nil
+	}
+
+	_, root, err := s.fsm.State().CARootActive(nil)
+	if err != nil {
+		return err
+	}
+	if root == nil || root.NotAfter.IsZero() {
+		return nil
+	}
+
+	metrics.SetGauge([]string{"connect", "ca", "root", "expiry"},
+		float32(time.Until(root.NotAfter).Seconds()))
+	return nil
+}
+
+// stopCAMetrics stops the goroutine emitting CA metrics.
+func (s *Server) stopCAMetrics() {
+	s.caMetricsLock.Lock()
+	defer s.caMetricsLock.Unlock()
+
+	if !s.caMetricsEnabled {
+		return
+	}
+
+	close(s.caMetricsCh)
+	s.caMetricsEnabled = false
+}
+
 // reconcileReaped is used to reconcile nodes that have failed and been reaped
 // from Serf but remain in the catalog. This is done by looking for unknown nodes with serfHealth checks registered.
 // We generate a "reap" event to cause the node to be cleaned up.
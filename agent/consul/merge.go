@@ -17,6 +17,10 @@ type lanMergeDelegate struct {
 	nodeID   types.NodeID
 	nodeName string
 	segment  string
+
+	// clusterID returns the ID of the cluster of the server. It is nil on
+	// clients and when the check of the cluster ID is skipped.
+	clusterID func() string
 }
 
 // uniqueIDMinVersion is the lowest version where we insist that nodes
@@ -66,6 +70,9 @@ func (md *lanMergeDelegate) NotifyMerge(members []*serf.Member) error {
 				return fmt.Errorf("Member '%s' part of wrong datacenter '%s'",
 					m.Name, parts.Datacenter)
 			}
+			if err := checkClusterID(md.clusterID, parts); err != nil {
+				return err
+			}
 		}
 
 		if segment := m.Tags["segment"]; segment != md.segment {
@@ -78,16 +85,49 @@ func (md *lanMergeDelegate) NotifyMerge(members []*serf.Member) error {
 
 // wanMergeDelegate is used to handle a cluster merge on the WAN gossip
 // ring. We check that the peers are server nodes and abort the merge
-// otherwise.
+// otherwise. The WAN addresses the peers advertise for our datacenter are
+// passed on to the transport.
 type wanMergeDelegate struct {
+	dc string
+
+	// clusterID returns the ID of the cluster of the server. It is nil
+	// when the check of the cluster ID is skipped.
+	clusterID func() string
+
+	transport *wanTransport
 }
 
 func (md *wanMergeDelegate) NotifyMerge(members []*serf.Member) error {
 	for _, m := range members {
-		ok, _ := metadata.IsConsulServer(*m)
+		ok, parts := metadata.IsConsulServer(*m)
 		if !ok {
 			return fmt.Errorf("Member '%s' is not a server", m.Name)
 		}
+		if parts.Datacenter == md.dc {
+			if err := checkClusterID(md.clusterID, parts); err != nil {
+				return err
+			}
+		}
+	}
+	if md.transport != nil {
+		for _, m := range members {
+			md.transport.update(m)
+		}
+	}
+	return nil
+}
+
+// checkClusterID returns an error if the given server of our datacenter
+// advertises a different cluster ID than ours, which means that it belongs
+// to another cluster, for example of another environment. Servers that
+// don't know their cluster ID yet are accepted.
+func checkClusterID(clusterID func() string, server *metadata.Server) error {
+	if clusterID == nil || server.ClusterID == "" {
+		return nil
+	}
+	if id := clusterID(); id != "" && id != server.ClusterID {
+		return fmt.Errorf("Member '%s' part of another cluster '%s' (expected '%s')",
+			server.Name, server.ClusterID, id)
 	}
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
 )
 
 func makeNode(dc, name, id string, server bool, build string) *serf.Member {
@@ -188,3 +189,81 @@ func TestMerge_WAN(t *testing.T) {
 		}
 	}
 }
+
+func TestMerge_ClusterID(t *testing.T) {
+	t.Parallel()
+	withClusterID := func(m *serf.Member, id string) *serf.Member {
+		m.Tags["cluster_id"] = id
+		return m
+	}
+	clusterID := func() string { return "prod" }
+
+	cases := []struct {
+		name    string
+		members []*serf.Member
+		expect  string
+	}{
+		{
+			"same cluster",
+			[]*serf.Member{
+				withClusterID(makeNode("dc1", "node1", "6185913b-98d7-4441-bd8f-f7f7d854a4af", true, "1.4.0"), "prod"),
+			},
+			"",
+		},
+		{
+			"server without cluster ID",
+			[]*serf.Member{
+				makeNode("dc1", "node1", "6185913b-98d7-4441-bd8f-f7f7d854a4af", true, "1.4.0"),
+			},
+			"",
+		},
+		{
+			"client",
+			[]*serf.Member{
+				withClusterID(makeNode("dc1", "node1", "6185913b-98d7-4441-bd8f-f7f7d854a4af", false, "1.4.0"), "staging"),
+			},
+			"",
+		},
+		{
+			"other cluster",
+			[]*serf.Member{
+				withClusterID(makeNode("dc1", "node1", "6185913b-98d7-4441-bd8f-f7f7d854a4af", true, "1.4.0"), "staging"),
+			},
+			"part of another cluster 'staging' (expected 'prod')",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lan := &lanMergeDelegate{
+				dc:        "dc1",
+				nodeID:    types.NodeID("ee954a2f-80de-4b34-8780-97b942a50a99"),
+				nodeName:  "node0",
+				clusterID: clusterID,
+			}
+			err := lan.NotifyMerge(c.members)
+			if c.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.expect)
+			}
+
+			// The check can be skipped.
+			lan.clusterID = nil
+			require.NoError(t, lan.NotifyMerge(c.members))
+		})
+	}
+
+	// On the WAN only the servers of our datacenter are checked.
+	wan := &wanMergeDelegate{dc: "dc1", clusterID: clusterID}
+	err := wan.NotifyMerge([]*serf.Member{
+		withClusterID(makeNode("dc2", "node1", "6185913b-98d7-4441-bd8f-f7f7d854a4af", true, "1.4.0"), "other"),
+	})
+	require.NoError(t, err)
+	err = wan.NotifyMerge([]*serf.Member{
+		withClusterID(makeNode("dc1", "node1", "6185913b-98d7-4441-bd8f-f7f7d854a4af", true, "1.4.0"), "staging"),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "part of another cluster")
+}
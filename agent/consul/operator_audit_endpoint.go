@@ -0,0 +1,74 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// AuditTrail returns the latest changes of intentions and config entries
+// along with the accessor of the token used for them, so that a change of
+// the traffic policy can be attributed.
+func (op *Operator) AuditTrail(args *structs.AuditTrailRequest, reply *structs.AuditTrailResponse) error {
+	if done, err := op.srv.forward("Operator.AuditTrail", args, args, reply); done {
+		return err
+	}
+
+	switch args.Resource {
+	case "", structs.AuditResourceIntention, structs.AuditResourceConfigEntry:
+	default:
+		return fmt.Errorf("Invalid audit resource %q", args.Resource)
+	}
+
+	// This action requires operator read access.
+	rule, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return op.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, entries, err := state.AuditTrail(ws, args.Resource)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Entries = index, entries
+			if reply.Entries == nil {
+				reply.Entries = make(structs.AuditEntries, 0)
+			}
+			return nil
+		})
+}
+
+// auditInfo returns the audit information of a change made by the leader
+// with the given token. The accessor is left empty if the token can't be
+// resolved, since the change was already authorized at this point.
+func (s *Server) auditInfo(token string) structs.AuditInfo {
+	info := structs.AuditInfo{Time: time.Now().UTC()}
+	if !s.ACLsEnabled() {
+		return info
+	}
+
+	if token == "" {
+		token = anonymousToken
+	}
+	identity, err := s.acls.resolveIdentityFromToken(token)
+	if err != nil {
+		s.logger.Printf("[WARN] consul: Failed to resolve token for audit trail: %v", err)
+		return info
+	}
+	if identity != nil {
+		info.AccessorID = identity.ID()
+	}
+	return info
+}
@@ -0,0 +1,127 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperator_AuditTrail(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	_, root, err := s1.fsm.State().ACLTokenGetBySecret(nil, "root")
+	require.NoError(t, err)
+	require.NotNil(t, root)
+
+	// Create an intention and a config entry.
+	ixn := structs.IntentionRequest{
+		Datacenter: "dc1",
+		Op:         structs.IntentionOpCreate,
+		Intention:  structs.TestIntention(t),
+		WriteRequest: structs.WriteRequest{
+			Token: "root",
+		},
+	}
+	var id string
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.Apply", &ixn, &id))
+	entry := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.TerminatingGatewayConfigEntry{
+			Kind: structs.TerminatingGateway,
+			Name: "gateway",
+		},
+		WriteRequest: structs.WriteRequest{
+			Token: "root",
+		},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &entry, &struct{}{}))
+
+	// Make a request with no token to make sure it gets denied.
+	arg := structs.AuditTrailRequest{
+		Datacenter: "dc1",
+	}
+	var reply structs.AuditTrailResponse
+	err = msgpackrpc.CallWithCodec(codec, "Operator.AuditTrail", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// Now it should go through.
+	arg.Token = "root"
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.AuditTrail", &arg, &reply))
+	require.NotZero(t, reply.Index)
+	require.Len(t, reply.Entries, 2)
+
+	require.Equal(t, structs.AuditResourceIntention, reply.Entries[0].Resource)
+	require.Equal(t, id, reply.Entries[0].Name)
+	require.Equal(t, "create", reply.Entries[0].Op)
+	require.Equal(t, root.AccessorID, reply.Entries[0].AccessorID)
+	require.False(t, reply.Entries[0].Time.IsZero())
+	require.Nil(t, reply.Entries[0].Before)
+	require.NotNil(t, reply.Entries[0].After)
+
+	require.Equal(t, structs.AuditResourceConfigEntry, reply.Entries[1].Resource)
+	require.Equal(t, "terminating-gateway/gateway", reply.Entries[1].Name)
+	require.Equal(t, root.AccessorID, reply.Entries[1].AccessorID)
+
+	// Filter by resource.
+	arg.Resource = structs.AuditResourceConfigEntry
+	var filtered structs.AuditTrailResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.AuditTrail", &arg, &filtered))
+	require.Len(t, filtered.Entries, 1)
+	require.Equal(t, "terminating-gateway/gateway", filtered.Entries[0].Name)
+
+	arg.Resource = "kv"
+	err = msgpackrpc.CallWithCodec(codec, "Operator.AuditTrail", &arg, &reply)
+	require.EqualError(t, err, `Invalid audit resource "kv"`)
+}
+
+func TestOperator_AuditTrail_Txn(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Intentions changed in transactions are recorded. The accessor is
+	// empty without ACLs.
+	ixn := structs.TestIntention(t)
+	txn := structs.TxnRequest{
+		Datacenter: "dc1",
+		Ops: structs.TxnOps{
+			{Intention: &structs.TxnIntentionOp{Op: structs.IntentionOpCreate, Intention: ixn}},
+		},
+	}
+	var txnReply structs.TxnResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Txn.Apply", &txn, &txnReply))
+	require.Empty(t, txnReply.Errors)
+
+	arg := structs.AuditTrailRequest{
+		Datacenter: "dc1",
+		Resource:   structs.AuditResourceIntention,
+	}
+	var reply structs.AuditTrailResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.AuditTrail", &arg, &reply))
+	require.Len(t, reply.Entries, 1)
+	require.Equal(t, "create", reply.Entries[0].Op)
+	require.Empty(t, reply.Entries[0].AccessorID)
+	require.False(t, reply.Entries[0].Time.IsZero())
+}
@@ -14,12 +14,12 @@ func (op *Operator) AutopilotGetConfiguration(args *structs.DCSpecificRequest, r
 		return err
 	}
 
-	// This action requires operator read access.
+	// This action requires operator autopilot read access.
 	rule, err := op.srv.ResolveToken(args.Token)
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.OperatorRead() {
+	if rule != nil && !rule.OperatorAutopilotRead() {
 		return acl.ErrPermissionDenied
 	}
 
@@ -43,12 +43,12 @@ func (op *Operator) AutopilotSetConfiguration(args *structs.AutopilotSetConfigRe
 		return err
 	}
 
-	// This action requires operator write access.
+	// This action requires operator autopilot write access.
 	rule, err := op.srv.ResolveToken(args.Token)
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.OperatorWrite() {
+	if rule != nil && !rule.OperatorAutopilotWrite() {
 		return acl.ErrPermissionDenied
 	}
 
@@ -79,12 +79,12 @@ func (op *Operator) ServerHealth(args *structs.DCSpecificRequest, reply *autopil
 		return err
 	}
 
-	// This action requires operator read access.
+	// This action requires operator autopilot read access.
 	rule, err := op.srv.ResolveToken(args.Token)
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.OperatorRead() {
+	if rule != nil && !rule.OperatorAutopilotRead() {
 		return acl.ErrPermissionDenied
 	}
 
@@ -101,3 +101,27 @@ func (op *Operator) ServerHealth(args *structs.DCSpecificRequest, reply *autopil
 
 	return nil
 }
+
+// AutopilotRemovals is used to get the dead servers Autopilot removed.
+func (op *Operator) AutopilotRemovals(args *structs.DCSpecificRequest, reply *autopilot.OperatorRemovalsReply) error {
+	// This must be sent to the leader, so we fix the args since we are
+	// re-using a structure where we don't support all the options.
+	args.RequireConsistent = true
+	args.AllowStale = false
+	if done, err := op.srv.forward("Operator.AutopilotRemovals", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator autopilot read access.
+	rule, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorAutopilotRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	*reply = op.srv.autopilot.GetRemovals()
+
+	return nil
+}
@@ -17,12 +17,12 @@ func (op *Operator) RaftGetConfiguration(args *structs.DCSpecificRequest, reply
 		return err
 	}
 
-	// This action requires operator read access.
+	// This action requires operator raft read access.
 	rule, err := op.srv.ResolveToken(args.Token)
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.OperatorRead() {
+	if rule != nil && !rule.OperatorRaftRead() {
 		return acl.ErrPermissionDenied
 	}
 
@@ -84,7 +84,7 @@ func (op *Operator) RaftRemovePeerByAddress(args *structs.RaftRemovePeerRequest,
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.OperatorWrite() {
+	if rule != nil && !rule.OperatorRaftWrite() {
 		return acl.ErrPermissionDenied
 	}
 
@@ -151,7 +151,7 @@ func (op *Operator) RaftRemovePeerByID(args *structs.RaftRemovePeerRequest, repl
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.OperatorWrite() {
+	if rule != nil && !rule.OperatorRaftWrite() {
 		return acl.ErrPermissionDenied
 	}
 
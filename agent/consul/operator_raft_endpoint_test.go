@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/autopilot"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib/freeport"
 	"github.com/hashicorp/consul/testrpc"
@@ -135,6 +136,79 @@ func TestOperator_RaftGetConfiguration_ACLDeny(t *testing.T) {
 	verify.Values(t, "", reply, expected)
 }
 
+func TestOperator_RaftGetConfiguration_OperatorRaftPolicy(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Create a token that may only read the Raft configuration.
+	policyReq := structs.ACLPolicySetRequest{
+		Datacenter: "dc1",
+		Policy: structs.ACLPolicy{
+			Name:  "raft-read",
+			Rules: `operator_raft = "read"`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policy structs.ACLPolicy
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &policyReq, &policy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tokenReq := structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			Policies: []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var token structs.ACLToken
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &tokenReq, &token); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reading the Raft configuration is allowed.
+	arg := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: token.SecretID},
+	}
+	var reply structs.RaftConfigurationResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftGetConfiguration", &arg, &reply); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(reply.Servers) != 1 {
+		t.Fatalf("bad: %v", reply.Servers)
+	}
+
+	// Removing a peer and reading the Autopilot configuration are not.
+	removeArg := structs.RaftRemovePeerRequest{
+		Datacenter:   "dc1",
+		Address:      reply.Servers[0].Address,
+		WriteRequest: structs.WriteRequest{Token: token.SecretID},
+	}
+	var removeReply struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftRemovePeerByAddress", &removeArg, &removeReply)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	var config autopilot.Config
+	err = msgpackrpc.CallWithCodec(codec, "Operator.AutopilotGetConfiguration", &arg, &config)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestOperator_RaftRemovePeerByAddress(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -0,0 +1,34 @@
+package consul
+
+import (
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// StateUsage reports the approximate memory used by each table of the state
+// store and its indexes, so operators can see which data dominates the memory
+// usage of the servers.
+func (op *Operator) StateUsage(args *structs.DCSpecificRequest, reply *structs.StateUsageResponse) error {
+	if done, err := op.srv.forward("Operator.StateUsage", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	rule, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	index, tables, err := op.srv.fsm.State().Usage()
+	if err != nil {
+		return err
+	}
+	reply.Node = op.srv.config.NodeName
+	reply.Index = index
+	reply.Tables = tables
+	op.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
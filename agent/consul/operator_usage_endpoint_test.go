@@ -0,0 +1,53 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperator_StateUsage(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Make a request with no token to make sure it gets denied.
+	arg := structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	var reply structs.StateUsageResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.StateUsage", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// Now it should go through.
+	arg.Token = "root"
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.StateUsage", &arg, &reply))
+	require.Equal(t, s1.config.NodeName, reply.Node)
+	require.NotZero(t, reply.Index)
+	require.True(t, reply.KnownLeader)
+
+	var nodes *structs.StateTableUsage
+	for _, table := range reply.Tables {
+		if table.Name == "nodes" {
+			nodes = table
+		}
+	}
+	require.NotNil(t, nodes)
+	require.Equal(t, 1, nodes.Objects)
+	require.NotEmpty(t, nodes.Indexes)
+}
@@ -367,6 +367,9 @@ func (p *PreparedQuery) Execute(args *structs.PreparedQueryExecuteRequest,
 	if err := p.srv.filterACL(token, &reply.Nodes); err != nil {
 		return err
 	}
+	if err := p.srv.filterExported(nil, state, args.SourceDatacenter, &reply.Nodes); err != nil {
+		return err
+	}
 
 	// TODO (slackpad) We could add a special case here that will avoid the
 	// fail over if we filtered everything due to ACLs. This seems like it
@@ -492,6 +495,9 @@ func (p *PreparedQuery) ExecuteRemote(args *structs.PreparedQueryExecuteRemoteRe
 	if err := p.srv.filterACL(token, &reply.Nodes); err != nil {
 		return err
 	}
+	if err := p.srv.filterExported(nil, p.srv.fsm.State(), args.SourceDatacenter, &reply.Nodes); err != nil {
+		return err
+	}
 
 	// We don't bother trying to do an RTT sort here since we are by
 	// definition in another DC. We just shuffle to make sure that we
@@ -529,6 +535,9 @@ func (p *PreparedQuery) execute(query *structs.PreparedQuery,
 	nodes = nodes.FilterIgnore(query.Service.OnlyPassing,
 		query.Service.IgnoreCheckIDs)
 
+	// Filter out the instances drained with a weight override.
+	nodes = nodes.FilterDrained()
+
 	// Apply the node metadata filters, if any.
 	if len(query.Service.NodeMeta) > 0 {
 		nodes = nodeMetaFilter(query.Service.NodeMeta, nodes)
@@ -0,0 +1,83 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib/semaphore"
+	"github.com/hashicorp/go-memdb"
+)
+
+// The classes of expensive queries whose concurrency can be limited with
+// Config.RPCQueryLimits.
+const (
+	// queryClassHealth covers the health queries for services and checks
+	// in a state, which can return large results for services with many
+	// instances.
+	queryClassHealth = "health"
+
+	// queryClassKV covers recursive KV reads and key listings.
+	queryClassKV = "kv"
+
+	// queryClassTxn covers transactions.
+	queryClassTxn = "txn"
+)
+
+// newQueryLimits returns a semaphore for each query class with a limit. The
+// semaphores of prev are resized and reused, so that the queries holding
+// their slots still count against the new limits.
+func newQueryLimits(limits map[string]int, prev map[string]*semaphore.Dynamic) map[string]*semaphore.Dynamic {
+	sems := make(map[string]*semaphore.Dynamic)
+	for class, limit := range limits {
+		if limit <= 0 {
+			continue
+		}
+		if sem, ok := prev[class]; ok {
+			sem.SetSize(int64(limit))
+			sems[class] = sem
+		} else {
+			sems[class] = semaphore.NewDynamic(int64(limit))
+		}
+	}
+	return sems
+}
+
+// acquireQuerySlot waits for one of the slots of the given query class. If no
+// slot frees up within RPCQueryQueueTimeout, ErrQueryLimitExceeded is
+// returned. On success, the returned function must be called to release the
+// slot once the query is done.
+func (s *Server) acquireQuerySlot(class string) (func(), error) {
+	limits := s.rpcLimits.Load().(*rpcLimits)
+	sem, ok := limits.queries[class]
+	if !ok {
+		return func() {}, nil
+	}
+
+	labels := []metrics.Label{{Name: "class", Value: class}}
+	ctx, cancel := context.WithTimeout(context.Background(), limits.queryQueueTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := sem.Acquire(ctx); err != nil {
+		metrics.IncrCounterWithLabels([]string{"rpc", "query_limit", "timeout"}, 1, labels)
+		return nil, structs.ErrQueryLimitExceeded
+	}
+	metrics.MeasureSinceWithLabels([]string{"rpc", "query_limit", "wait"}, start, labels)
+	return sem.Release, nil
+}
+
+// limitQuery wraps a blocking query function so that each run of it holds one
+// of the slots of the given query class. Slots are not held while a blocking
+// query waits for changes.
+func (s *Server) limitQuery(class string, fn queryFn) queryFn {
+	return func(ws memdb.WatchSet, state *state.Store) error {
+		release, err := s.acquireQuerySlot(class)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return fn(ws, state)
+	}
+}
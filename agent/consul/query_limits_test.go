@@ -0,0 +1,86 @@
+package consul
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLimits(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCQueryLimits = map[string]int{queryClassKV: 1}
+		c.RPCQueryQueueTimeout = 10 * time.Millisecond
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Take the only KV slot.
+	release, err := s1.acquireQuerySlot(queryClassKV)
+	require.NoError(t, err)
+
+	// Listings have to wait for a slot and time out.
+	args := structs.KeyRequest{Datacenter: "dc1", Key: "foo"}
+	var out structs.IndexedDirEntries
+	err = msgpackrpc.CallWithCodec(codec, "KVS.List", &args, &out)
+	require.True(t, structs.IsErrQueryLimitExceeded(err), "err: %v", err)
+
+	// Other classes aren't affected.
+	nodes := structs.ServiceSpecificRequest{Datacenter: "dc1", ServiceName: "consul"}
+	var nodesOut structs.IndexedCheckServiceNodes
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &nodes, &nodesOut))
+
+	// Listings go through once the slot is released.
+	release()
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.List", &args, &out))
+}
+
+func TestQueryLimits_Reload(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCQueryLimits = map[string]int{queryClassKV: 1}
+		c.RPCQueryQueueTimeout = 10 * time.Millisecond
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	// Take the only KV slot.
+	release, err := s1.acquireQuerySlot(queryClassKV)
+	require.NoError(t, err)
+	_, err = s1.acquireQuerySlot(queryClassKV)
+	require.True(t, structs.IsErrQueryLimitExceeded(err), "err: %v", err)
+
+	// Raising the limit frees up a slot while the first one is still held.
+	config := *s1.config
+	config.RPCQueryLimits = map[string]int{queryClassKV: 2, queryClassTxn: 1}
+	require.NoError(t, s1.ReloadConfig(&config))
+	release2, err := s1.acquireQuerySlot(queryClassKV)
+	require.NoError(t, err)
+	_, err = s1.acquireQuerySlot(queryClassKV)
+	require.True(t, structs.IsErrQueryLimitExceeded(err), "err: %v", err)
+
+	// Newly limited classes are limited too.
+	release3, err := s1.acquireQuerySlot(queryClassTxn)
+	require.NoError(t, err)
+	_, err = s1.acquireQuerySlot(queryClassTxn)
+	require.True(t, structs.IsErrQueryLimitExceeded(err), "err: %v", err)
+
+	// Removing the limits lifts them, and releasing the slots held under
+	// the old limits is fine.
+	config.RPCQueryLimits = nil
+	require.NoError(t, s1.ReloadConfig(&config))
+	release4, err := s1.acquireQuerySlot(queryClassKV)
+	require.NoError(t, err)
+	for _, r := range []func(){release, release2, release3, release4} {
+		r()
+	}
+}
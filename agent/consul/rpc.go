@@ -1,11 +1,14 @@
 package consul
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -13,10 +16,14 @@ import (
 	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/tracing"
 	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/lib/connlimit"
+	"github.com/hashicorp/consul/lib/semaphore"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/memberlist"
 	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/serf/serf"
 	"github.com/hashicorp/yamux"
 )
 
@@ -58,11 +65,170 @@ func (s *Server) listen(listener net.Listener) {
 			continue
 		}
 
+		if !s.rpcLimiter.Accept(conn) {
+			conn.Close()
+			continue
+		}
+
 		go s.handleConn(conn, false)
 		metrics.IncrCounter([]string{"rpc", "accept_conn"}, 1)
 	}
 }
 
+// rpcLimits are the limits of the RPC server that can be changed on reload.
+type rpcLimits struct {
+	maxConns           int
+	maxStreamsPerConn  int
+	acceptBackpressure bool
+	queryQueueTimeout  time.Duration
+	queries            map[string]*semaphore.Dynamic
+}
+
+// setRPCLimits applies the RPC limits of the given config. Connections and
+// streams already being handled keep their slots, and new ones are admitted
+// once the totals are below the new limits.
+func (s *Server) setRPCLimits(config *Config) {
+	var prevQueries map[string]*semaphore.Dynamic
+	if prev, ok := s.rpcLimits.Load().(*rpcLimits); ok {
+		prevQueries = prev.queries
+	}
+
+	s.rpcConns.SetSize(int64(config.RPCMaxConns))
+	s.rpcLimiter.SetConfig(connlimit.Config{
+		AcceptRate:              config.RPCAcceptRate,
+		MaxConcurrentHandshakes: config.RPCMaxConcurrentHandshakes,
+	})
+	s.rpcLimits.Store(&rpcLimits{
+		maxConns:           config.RPCMaxConns,
+		maxStreamsPerConn:  config.RPCMaxStreamsPerConn,
+		acceptBackpressure: config.RPCAcceptBackpressure,
+		queryQueueTimeout:  config.RPCQueryQueueTimeout,
+		queries:            newQueryLimits(config.RPCQueryLimits, prevQueries),
+	})
+}
+
+// acquireConnSlot takes a slot of the RPC connection limit for conn and
+// returns a connection that holds it until it is closed. If no slot is free
+// conn is closed and false is returned, unless RPCAcceptBackpressure is set
+// which makes it wait for one. Connections from Consul servers aren't
+// limited, since they carry Raft and forwarded RPCs; see isServerConn.
+func (s *Server) acquireConnSlot(conn net.Conn) (net.Conn, bool) {
+	limits := s.rpcLimits.Load().(*rpcLimits)
+	if limits.maxConns <= 0 || s.isServerConn(conn) {
+		return conn, true
+	}
+
+	// Without backpressure the context is done right away, so that a slot
+	// is only taken if one is free.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if limits.acceptBackpressure {
+		go func() {
+			select {
+			case <-s.shutdownCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	} else {
+		cancel()
+	}
+
+	if err := s.rpcConns.Acquire(ctx); err != nil {
+		if !limits.acceptBackpressure {
+			s.logger.Printf("[WARN] consul.rpc: rejecting RPC conn, limit of %d connections reached %s", limits.maxConns, logConn(conn))
+			metrics.IncrCounter([]string{"rpc", "rejected_conn"}, 1)
+		}
+		conn.Close()
+		return nil, false
+	}
+	return &slotConn{Conn: conn, release: s.rpcConns.Release}, true
+}
+
+// isServerConn returns true if conn comes from a Consul server. TLS
+// connections are identified by the server name in their verified client
+// certificate when server hostnames are verified, other connections by their
+// address being one of a server in the LAN or WAN pool.
+func (s *Server) isServerConn(conn net.Conn) bool {
+	if tlsConn, ok := conn.(*tls.Conn); ok && s.config.VerifyServerHostname {
+		chains := tlsConn.ConnectionState().VerifiedChains
+		if len(chains) == 0 || len(chains[0]) == 0 {
+			return false
+		}
+		dcs := append([]string{s.config.Datacenter}, s.router.GetDatacenters()...)
+		return s.tlsConfigurator.IsServerCert(chains[0][0], dcs)
+	}
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	return s.serverAddrs.contains(addr.IP)
+}
+
+// serverAddrs is the set of addresses of the servers in the LAN and WAN pools,
+// kept up to date with Serf member events.
+type serverAddrs struct {
+	lock sync.RWMutex
+
+	// members maps the pool and name of each server to its address.
+	members map[string]string
+
+	// addrs counts the servers at each address.
+	addrs map[string]int
+}
+
+func newServerAddrs() *serverAddrs {
+	return &serverAddrs{
+		members: make(map[string]string),
+		addrs:   make(map[string]int),
+	}
+}
+
+// update applies a member event from the given pool to the set.
+func (a *serverAddrs) update(pool string, event serf.MemberEvent) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, m := range event.Members {
+		key := pool + "/" + m.Name
+		if addr, ok := a.members[key]; ok {
+			delete(a.members, key)
+			if a.addrs[addr]--; a.addrs[addr] <= 0 {
+				delete(a.addrs, addr)
+			}
+		}
+
+		switch event.Type {
+		case serf.EventMemberJoin, serf.EventMemberUpdate:
+			if ok, _ := metadata.IsConsulServer(m); ok {
+				addr := m.Addr.String()
+				a.members[key] = addr
+				a.addrs[addr]++
+			}
+		}
+	}
+}
+
+// contains returns true if a server is known at ip.
+func (a *serverAddrs) contains(ip net.IP) bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.addrs[ip.String()] > 0
+}
+
+// slotConn is a connection that holds a slot of the RPC connection limit,
+// which is released when the connection is closed.
+type slotConn struct {
+	net.Conn
+	release     func()
+	releaseOnce sync.Once
+}
+
+func (c *slotConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
+
 // logConn is a wrapper around memberlist's LogConn so that we format references
 // to "from" addresses in a consistent way. This is just a shorter name.
 func logConn(conn net.Conn) string {
@@ -90,6 +256,15 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 		return
 	}
 
+	// Raft connections aren't limited, and the type of TLS connections is
+	// only known after the handshake.
+	if typ != pool.RPCRaft && typ != pool.RPCTLS {
+		var ok bool
+		if conn, ok = s.acquireConnSlot(conn); !ok {
+			return
+		}
+	}
+
 	// Switch on the byte
 	switch typ {
 	case pool.RPCConsul:
@@ -105,8 +280,27 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 			conn.Close()
 			return
 		}
-		conn = tls.Server(conn, s.rpcTLS)
-		s.handleConn(conn, true)
+		tlsConn := tls.Server(conn, s.rpcTLS)
+
+		// Connections that selected the gRPC transport during the handshake
+		// don't send another RPC byte.
+		if err := s.rpcLimiter.Handshake(tlsConn); err != nil {
+			if err != connlimit.ErrTooManyHandshakes {
+				s.logger.Printf("[ERR] consul.rpc: TLS handshake failed: %v %s", err, logConn(conn))
+			}
+			tlsConn.Close()
+			return
+		}
+		if tlsConn.ConnectionState().NegotiatedProtocol == pool.ALPNGRPC {
+			if conn, ok := s.acquireConnSlot(tlsConn); ok {
+				s.handleGRPCConn(conn)
+			}
+			return
+		}
+		s.handleConn(tlsConn, true)
+
+	case pool.RPCGRPC:
+		s.handleGRPCConn(conn)
 
 	case pool.RPCMultiplexV2:
 		s.handleMultiplexV2(conn)
@@ -129,7 +323,29 @@ func (s *Server) handleMultiplexV2(conn net.Conn) {
 	conf := yamux.DefaultConfig()
 	conf.LogOutput = s.config.LogOutput
 	server, _ := yamux.Server(conn, conf)
+
+	// streams holds a slot for each stream being handled when the number of
+	// streams is limited.
+	limits := s.rpcLimits.Load().(*rpcLimits)
+	var streams chan struct{}
+	if limits.maxStreamsPerConn > 0 {
+		streams = make(chan struct{}, limits.maxStreamsPerConn)
+	}
+	backpressure := streams != nil && limits.acceptBackpressure
+
 	for {
+		// With backpressure we stop accepting until a stream slot is free,
+		// which makes yamux hold new streams in its accept backlog.
+		if backpressure {
+			select {
+			case streams <- struct{}{}:
+			case <-server.CloseChan():
+				return
+			case <-s.shutdownCh:
+				return
+			}
+		}
+
 		sub, err := server.Accept()
 		if err != nil {
 			if err != io.EOF {
@@ -137,7 +353,24 @@ func (s *Server) handleMultiplexV2(conn net.Conn) {
 			}
 			return
 		}
-		go s.handleConsulConn(sub)
+
+		if streams != nil && !backpressure {
+			select {
+			case streams <- struct{}{}:
+			default:
+				s.logger.Printf("[WARN] consul.rpc: rejecting RPC stream, limit of %d streams reached %s", limits.maxStreamsPerConn, logConn(conn))
+				metrics.IncrCounter([]string{"rpc", "rejected_stream"}, 1)
+				sub.Close()
+				continue
+			}
+		}
+
+		go func() {
+			s.handleConsulConn(sub)
+			if streams != nil {
+				<-streams
+			}
+		}()
 	}
 }
 
@@ -200,15 +433,33 @@ func (s *Server) forward(method string, info structs.RPCInfo, args interface{},
 	// Handle DC forwarding
 	dc := info.RequestDatacenter()
 	if dc != s.config.Datacenter {
+		span, endSpan := startRPCSpan("rpc.forward "+method, args)
+		span.SetAttribute("consul.datacenter", dc)
+		start := time.Now()
 		err := s.forwardDC(method, dc, args, reply)
+		s.logForward(method, "DC "+dc, start, args)
+		endSpan(err)
 		return true, err
 	}
 
 	// Check if we can allow a stale read, ensure our local DB is initialized
 	if info.IsRead() && info.AllowStaleRead() && !s.raft.LastContact().IsZero() {
+		s.logLocal(method, "stale read", args)
 		return false, nil
 	}
 
+	// Check if a follower can serve a default read under its leader lease
+	// once it applied the commit index, otherwise forward it to the leader
+	if info.IsRead() && s.canServeFollowerRead(method, args) {
+		if err := s.waitForCommitIndex(); err != nil {
+			s.logger.Printf("[DEBUG] consul.rpc: forwarding follower read for %s to the leader: %v", method, err)
+		} else {
+			metrics.IncrCounter([]string{"rpc", "follower_read"}, 1)
+			s.logLocal(method, "follower read", args)
+			return false, nil
+		}
+	}
+
 CHECK_LEADER:
 	// Fail fast if we are in the process of leaving
 	select {
@@ -222,14 +473,20 @@ CHECK_LEADER:
 
 	// Handle the case we are the leader
 	if isLeader {
+		s.logLocal(method, "leader", args)
 		return false, nil
 	}
 
 	// Handle the case of a known leader
 	rpcErr := structs.ErrNoLeader
 	if leader != nil {
+		span, endSpan := startRPCSpan("rpc.forward "+method, args)
+		span.SetAttribute("consul.server", leader.Addr.String())
+		start := time.Now()
 		rpcErr = s.connPool.RPC(s.config.Datacenter, leader.Addr,
 			leader.Version, method, leader.UseTLS, args, reply)
+		s.logForward(method, "leader "+leader.Addr.String(), start, args)
+		endSpan(rpcErr)
 		if rpcErr != nil && canRetry(info, rpcErr) {
 			goto RETRY
 		}
@@ -255,6 +512,45 @@ RETRY:
 	return true, rpcErr
 }
 
+// logForward records the time it took to forward an RPC request. Requests
+// made for an API request are logged with its ID, so the path of a slow
+// request can be followed across the agents.
+func (s *Server) logForward(method, target string, start time.Time, args interface{}) {
+	metrics.MeasureSinceWithLabels([]string{"rpc", "forward"}, start,
+		[]metrics.Label{{Name: "method", Value: method}})
+	if id := structs.RequestID(args); id != "" {
+		s.logger.Printf("[DEBUG] consul.rpc: Forwarded %q to %s (%v) request_id=%s", method, target, time.Since(start), id)
+	}
+}
+
+// logLocal logs that an RPC request made for an API request is handled by
+// this server.
+func (s *Server) logLocal(method, role string, args interface{}) {
+	if id := structs.RequestID(args); id != "" {
+		s.logger.Printf("[TRACE] consul.rpc: Handling %q as %s request_id=%s", method, role, id)
+	}
+}
+
+// startRPCSpan starts a tracing span for an RPC request sent to a server if
+// the request was made for an API request, and passes the span's ID along
+// with the request so the spans of the server are recorded as its children.
+// The returned function ends the span and restores the request's span ID.
+func startRPCSpan(name string, args interface{}) (*tracing.Span, func(error)) {
+	requestID, parentID := structs.RequestID(args), structs.SpanID(args)
+	if requestID == "" {
+		return nil, func(error) {}
+	}
+	span := tracing.Start(requestID, parentID, name, tracing.KindClient)
+	if span == nil {
+		return nil, func(error) {}
+	}
+	structs.SetSpanID(args, span.ID())
+	return span, func(err error) {
+		structs.SetSpanID(args, parentID)
+		span.End(err)
+	}
+}
+
 // getLeader returns if the current node is the leader, and if not then it
 // returns the leader which is potentially nil if the cluster has not yet
 // elected a leader.
@@ -287,22 +583,64 @@ func (s *Server) forwardDC(method, dc string, args interface{}, reply interface{
 
 	metrics.IncrCounterWithLabels([]string{"rpc", "cross-dc"}, 1,
 		[]metrics.Label{{Name: "datacenter", Value: dc}})
+	labels := []metrics.Label{
+		{Name: "datacenter", Value: dc},
+		{Name: "method", Value: method},
+	}
+	defer metrics.MeasureSinceWithLabels([]string{"rpc", "cross-dc", "forward"}, time.Now(), labels)
+	structs.SetSourceDatacenter(args, s.config.Datacenter)
 	if err := s.connPool.RPC(dc, server.Addr, server.Version, method, server.UseTLS, args, reply); err != nil {
+		metrics.IncrCounterWithLabels([]string{"rpc", "cross-dc", "error"}, 1, labels)
 		manager.NotifyFailedServer(server)
-		s.logger.Printf("[ERR] consul: RPC failed to server %s in DC %q: %v", server.Addr, dc, err)
+		s.logger.Printf("[ERR] consul: RPC failed to server %s in DC %q: %v request_id=%s", server.Addr, dc, err, structs.RequestID(args))
 		return err
 	}
 
 	return nil
 }
 
+// crossDCStats is a long running routine used to capture the number of
+// pooled RPC connections to the servers of other datacenters.
+func (s *Server) crossDCStats() {
+	for {
+		select {
+		case <-time.After(5 * time.Second):
+			s.emitCrossDCConnections()
+
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
+// emitCrossDCConnections sets the gauge with the number of pooled RPC
+// connections for every known remote datacenter, including the ones there
+// are no connections to.
+func (s *Server) emitCrossDCConnections() {
+	counts := s.connPool.ConnCounts()
+	for _, dc := range s.router.GetDatacenters() {
+		if dc == s.config.Datacenter {
+			continue
+		}
+		metrics.SetGaugeWithLabels([]string{"rpc", "cross-dc", "connections"}, float32(counts[dc]),
+			[]metrics.Label{{Name: "datacenter", Value: dc}})
+	}
+}
+
 // globalRPC is used to forward an RPC request to one server in each datacenter.
 // This will only error for RPC-related errors. Otherwise, application-level
 // errors can be sent in the response objects.
 func (s *Server) globalRPC(method string, args interface{},
-	reply structs.CompoundResponse) error {
+	reply structs.CompoundResponse) (err error) {
 
-	// Make a new request into each datacenter
+	// The requests to all the datacenters share the same span since they
+	// share the same arguments.
+	_, endSpan := startRPCSpan("rpc.global "+method, args)
+	defer func() { endSpan(err) }()
+
+	// Make a new request into each datacenter. The source datacenter is
+	// recorded up front since the requests share the arguments.
+	structs.SetSourceDatacenter(args, s.config.Datacenter)
 	dcs := s.router.GetDatacenters()
 
 	replies, total := 0, len(dcs)
@@ -361,7 +699,34 @@ func (s *Server) raftApply(t structs.MessageType, msg interface{}) (interface{},
 type queryFn func(memdb.WatchSet, *state.Store) error
 
 // blockingQuery is used to process a potentially blocking query operation.
+// Blocking queries made for API requests are traced, since they can wait up
+// to MaxQueryTime.
 func (s *Server) blockingQuery(queryOpts *structs.QueryOptions, queryMeta *structs.QueryMeta,
+	fn queryFn) error {
+	var span *tracing.Span
+	if queryOpts.MinQueryIndex > 0 && queryOpts.RequestID != "" {
+		span = tracing.Start(queryOpts.RequestID, queryOpts.SpanID, "blocking query", tracing.KindInternal)
+	}
+	if span == nil {
+		return s.runBlockingQuery(queryOpts, queryMeta, fn)
+	}
+
+	runs := 0
+	err := s.runBlockingQuery(queryOpts, queryMeta, func(ws memdb.WatchSet, state *state.Store) error {
+		runs++
+		return fn(ws, state)
+	})
+	span.SetAttribute("consul.min_query_index", strconv.FormatUint(queryOpts.MinQueryIndex, 10))
+	span.SetAttribute("consul.max_query_time", queryOpts.MaxQueryTime.String())
+	span.SetAttribute("consul.index", strconv.FormatUint(queryMeta.Index, 10))
+	span.SetAttribute("consul.runs", strconv.Itoa(runs))
+	span.End(err)
+	return err
+}
+
+// runBlockingQuery runs a query until its index exceeds the minimum index of
+// the query options or the query times out.
+func (s *Server) runBlockingQuery(queryOpts *structs.QueryOptions, queryMeta *structs.QueryMeta,
 	fn queryFn) error {
 	var timeout *time.Timer
 
@@ -454,6 +819,77 @@ func (s *Server) setQueryMeta(m *structs.QueryMeta) {
 	}
 }
 
+// followerReadClasses maps the RPC endpoints that support follower reads to
+// the endpoint class used to enable them in the configuration.
+var followerReadClasses = map[string]string{
+	"Catalog": "catalog",
+	"Health":  "health",
+	"KVS":     "kv",
+}
+
+// canServeFollowerRead returns true if a follower may serve a read with the
+// default consistency locally instead of forwarding it to the leader. This
+// requires follower reads to be enabled for the endpoint and that the
+// follower heard from the leader within the leader lease timeout. A leader
+// that can't reach a quorum for that long steps down, and no other leader
+// can be elected before the heartbeat timeout, which is at least as long,
+// so the local state is at most a lease behind the leader's once
+// waitForCommitIndex succeeded.
+func (s *Server) canServeFollowerRead(method string, args interface{}) bool {
+	if len(s.config.FollowerReads) == 0 {
+		return false
+	}
+	if r, ok := args.(structs.ConsistentReadRequirer); ok && r.RequireConsistentRead() {
+		return false
+	}
+
+	class := followerReadClasses[strings.SplitN(method, ".", 2)[0]]
+	enabled := false
+	for _, c := range s.config.FollowerReads {
+		if c == class {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return false
+	}
+
+	if s.IsLeader() || s.raft.Leader() == "" {
+		return false
+	}
+	contact := s.raft.LastContact()
+	return !contact.IsZero() && time.Since(contact) < s.config.RaftConfig.LeaderLeaseTimeout
+}
+
+// waitForCommitIndex waits for up to RPCHoldTimeout until the local FSM
+// applied the commit index the follower learned from the leader, so that a
+// follower read observes every write the follower knows to be committed.
+// The commit index is only looked up if the FSM is behind the last log
+// index, since it can't be ahead of it.
+func (s *Server) waitForCommitIndex() error {
+	if s.raft.AppliedIndex() >= s.raft.LastIndex() {
+		return nil
+	}
+	index, err := strconv.ParseUint(s.raft.Stats()["commit_index"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("error parsing server's commit_index value: %s", err)
+	}
+
+	deadline := time.Now().Add(s.config.RPCHoldTimeout)
+	for s.raft.AppliedIndex() < index {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting to apply index %d", index)
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-s.shutdownCh:
+			return fmt.Errorf("shutdown waiting to apply index %d", index)
+		}
+	}
+	return nil
+}
+
 // consistentRead is used to ensure we do not perform a stale
 // read. This is done by verifying leadership before the read.
 func (s *Server) consistentRead() error {
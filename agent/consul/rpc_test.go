@@ -2,18 +2,31 @@ package consul
 
 import (
 	"bytes"
+	"io"
+	"net"
+	"net/rpc"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/consul/grpcpb"
 	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/tracing"
 	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/testutil"
 	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/serf/serf"
+	"github.com/hashicorp/yamux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 )
 
 func TestRPC_NoLeader_Fail(t *testing.T) {
@@ -48,6 +61,57 @@ func TestRPC_NoLeader_Fail(t *testing.T) {
 	}
 }
 
+func TestRPC_CrossDCMetrics(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("consul")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+	metrics.NewGlobal(cfg, sink)
+	defer metrics.NewGlobal(metrics.DefaultConfig(""), &metrics.BlackholeSink{})
+
+	dir1, s1 := testServerDC(t, "dc1")
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	dir2, s2 := testServerDC(t, "dc2")
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	dir3, s3 := testServerDC(t, "dc3")
+	defer os.RemoveAll(dir3)
+	defer s3.Shutdown()
+
+	joinWAN(t, s2, s1)
+	joinWAN(t, s3, s1)
+	testrpc.WaitForLeader(t, s2.RPC, "dc2")
+	retry.Run(t, func(r *retry.R) {
+		if len(s1.router.GetDatacenters()) != 3 {
+			r.Fatal("dc2 and dc3 not known yet")
+		}
+	})
+
+	// Forward a successful and a failing request to dc2 only.
+	require.NoError(t, s1.forwardDC("Status.Ping", "dc2", &struct{}{}, &struct{}{}))
+	require.Error(t, s1.forwardDC("Bad.Method", "dc2", &struct{}{}, &struct{}{}))
+	require.Equal(t, 1, s1.connPool.ConnCounts()["dc2"])
+	s1.emitCrossDCConnections()
+
+	// The test might span more than one interval of the sink.
+	var pings, errors int
+	conns := map[string]float32{}
+	for _, intv := range sink.Data() {
+		pings += intv.Samples["consul.rpc.cross-dc.forward;datacenter=dc2;method=Status.Ping"].Count
+		errors += intv.Counters["consul.rpc.cross-dc.error;datacenter=dc2;method=Bad.Method"].Count
+		for _, dc := range []string{"dc1", "dc2", "dc3"} {
+			if gauge, ok := intv.Gauges["consul.rpc.cross-dc.connections;datacenter="+dc]; ok {
+				conns[dc] = gauge.Value
+			}
+		}
+	}
+	require.Equal(t, 1, pings)
+	require.Equal(t, 1, errors)
+	// There's a gauge for dc3 even though it wasn't contacted.
+	require.Equal(t, map[string]float32{"dc2": 1, "dc3": 0}, conns)
+}
+
 func TestRPC_NoLeader_Fail_on_stale_read(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServerWithConfig(t, func(c *Config) {
@@ -121,6 +185,43 @@ func TestRPC_NoLeader_Retry(t *testing.T) {
 	}
 }
 
+func TestRPC_RequestID(t *testing.T) {
+	t.Parallel()
+	leaderLogs := new(bytes.Buffer)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.LogOutput = io.MultiWriter(leaderLogs, testutil.TestWriter(t))
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	followerLogs := new(bytes.Buffer)
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Bootstrap = false
+		c.LogOutput = io.MultiWriter(followerLogs, testutil.TestWriter(t))
+	})
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	joinLAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s2.RPC, "dc1")
+
+	codec := rpcClient(t, s2)
+	defer codec.Close()
+
+	// The request ID is logged by the follower forwarding the request and by
+	// the leader handling it.
+	args := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{RequestID: "4bf92f3577b34da6a3ce929d0e0e4736"},
+	}
+	var out structs.IndexedNodes
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out))
+
+	require.Contains(t, followerLogs.String(), `Forwarded "Catalog.ListNodes" to leader`)
+	require.Contains(t, followerLogs.String(), "request_id=4bf92f3577b34da6a3ce929d0e0e4736")
+	require.Contains(t, leaderLogs.String(), `Handling "Catalog.ListNodes" as leader request_id=4bf92f3577b34da6a3ce929d0e0e4736`)
+}
+
 type MockSink struct {
 	*bytes.Buffer
 	cancel bool
@@ -139,6 +240,42 @@ func (m *MockSink) Close() error {
 	return nil
 }
 
+func TestRPC_startRPCSpan(t *testing.T) {
+	// Not parallel since the tracer is installed globally.
+	require := require.New(t)
+
+	// Nothing is traced without a tracer.
+	args := &structs.DCSpecificRequest{
+		QueryOptions: structs.QueryOptions{
+			RequestID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:    "00f067aa0ba902b7",
+		},
+	}
+	span, endSpan := startRPCSpan("rpc Catalog.ListNodes", args)
+	require.Nil(span)
+	endSpan(nil)
+
+	tracer, err := tracing.NewTracer(tracing.Config{Endpoint: "http://127.0.0.1:0/v1/traces"})
+	require.NoError(err)
+	defer tracer.Stop()
+	tracing.SetGlobal(tracer)
+	defer tracing.SetGlobal(nil)
+
+	// The span is passed along with the request while it's sent.
+	span, endSpan = startRPCSpan("rpc Catalog.ListNodes", args)
+	require.NotNil(span)
+	require.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID())
+	require.Equal(span.ID(), args.SpanID)
+	endSpan(nil)
+	require.Equal("00f067aa0ba902b7", args.SpanID)
+
+	// Internal requests aren't traced.
+	args.RequestID = ""
+	span, endSpan = startRPCSpan("rpc Catalog.ListNodes", args)
+	require.Nil(span)
+	endSpan(nil)
+}
+
 func TestRPC_blockingQuery(t *testing.T) {
 	t.Parallel()
 	dir, s := testServer(t)
@@ -315,3 +452,355 @@ func TestRPC_ReadyForConsistentReads(t *testing.T) {
 		}
 	})
 }
+
+// dialRPCClient opens an RPC connection of the given type to the server from
+// 127.0.0.2, so that it doesn't come from the address of a Consul server.
+func dialRPCClient(t *testing.T, s *Server, typ pool.RPCType) net.Conn {
+	dialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.2")},
+		Timeout:   time.Second,
+	}
+	conn, err := dialer.Dial("tcp", s.config.RPCAdvertise.String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte{byte(typ)})
+	require.NoError(t, err)
+	return conn
+}
+
+func TestRPC_MaxConns(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxConns = 2
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Open connections until one is over the limit and gets closed.
+	var codecs []rpc.ClientCodec
+	var out struct{}
+	for i := 0; i < 2; i++ {
+		codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+		codecs = append(codecs, codec)
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+	}
+	codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+	require.Error(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+	codec.Close()
+
+	// Closing the connections frees their slots.
+	for _, codec := range codecs {
+		codec.Close()
+	}
+	retry.Run(t, func(r *retry.R) {
+		codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+		defer codec.Close()
+		if err := msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out); err != nil {
+			r.Fatal(err)
+		}
+	})
+}
+
+func TestRPC_MaxConns_Reload(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxConns = 1
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	// Use up the only slot.
+	client, server := net.Pipe()
+	defer client.Close()
+	conn, ok := s1.acquireConnSlot(server)
+	require.True(t, ok)
+	client2, server2 := net.Pipe()
+	defer client2.Close()
+	_, ok = s1.acquireConnSlot(server2)
+	require.False(t, ok)
+
+	// Raising the limit admits another connection while the first one is
+	// still open.
+	config := *s1.config
+	config.RPCMaxConns = 2
+	require.NoError(t, s1.ReloadConfig(&config))
+	client3, server3 := net.Pipe()
+	defer client3.Close()
+	conn3, ok := s1.acquireConnSlot(server3)
+	require.True(t, ok)
+
+	// Removing the limit lifts it.
+	config.RPCMaxConns = 0
+	require.NoError(t, s1.ReloadConfig(&config))
+	client4, server4 := net.Pipe()
+	defer client4.Close()
+	_, ok = s1.acquireConnSlot(server4)
+	require.True(t, ok)
+
+	require.NoError(t, conn.Close())
+	require.NoError(t, conn3.Close())
+}
+
+func TestRPC_MaxConns_ServerTraffic(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxConns = 1
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Forget the address of the server so that its connections are limited,
+	// and use up the only slot.
+	lan := serf.MemberEvent{Type: serf.EventMemberLeave, Members: s1.LANMembers()}
+	wan := serf.MemberEvent{Type: serf.EventMemberLeave, Members: s1.WANMembers()}
+	s1.serverAddrs.update("lan", lan)
+	s1.serverAddrs.update("wan", wan)
+	var out struct{}
+	codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+	defer codec.Close()
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+	codec2 := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+	require.Error(t, msgpackrpc.CallWithCodec(codec2, "Status.Ping", struct{}{}, &out))
+	codec2.Close()
+
+	// Connections from the address of a server aren't limited.
+	lan.Type, wan.Type = serf.EventMemberJoin, serf.EventMemberJoin
+	s1.serverAddrs.update("lan", lan)
+	s1.serverAddrs.update("wan", wan)
+	codec3 := rpcClient(t, s1)
+	defer codec3.Close()
+	require.NoError(t, msgpackrpc.CallWithCodec(codec3, "Status.Ping", struct{}{}, &out))
+
+	// Neither are Raft connections, which are handed off instead of closed.
+	raftConn := dialRPCClient(t, s1, pool.RPCRaft)
+	defer raftConn.Close()
+	raftConn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, err := raftConn.Read(make([]byte, 1))
+	netErr, ok := err.(net.Error)
+	require.True(t, ok && netErr.Timeout(), "unexpected error: %v", err)
+}
+
+func TestServerAddrs(t *testing.T) {
+	t.Parallel()
+	server := func(name, addr string) serf.Member {
+		return serf.Member{
+			Name: name,
+			Addr: net.ParseIP(addr),
+			Tags: map[string]string{"role": "consul", "dc": "dc1", "port": "8300", "build": "1.6.0", "vsn": "2", "id": name},
+		}
+	}
+	client := serf.Member{Name: "client", Addr: net.ParseIP("10.0.0.3"), Tags: map[string]string{"role": "node"}}
+
+	a := newServerAddrs()
+	a.update("lan", serf.MemberEvent{Type: serf.EventMemberJoin, Members: []serf.Member{server("s1", "10.0.0.1"), client}})
+	a.update("wan", serf.MemberEvent{Type: serf.EventMemberJoin, Members: []serf.Member{server("s1.dc1", "10.0.0.1")}})
+	require.True(t, a.contains(net.ParseIP("10.0.0.1")))
+	require.False(t, a.contains(net.ParseIP("10.0.0.3")))
+
+	// The address is kept while a server of either pool is known there.
+	a.update("lan", serf.MemberEvent{Type: serf.EventMemberFailed, Members: []serf.Member{server("s1", "10.0.0.1")}})
+	require.True(t, a.contains(net.ParseIP("10.0.0.1")))
+	a.update("wan", serf.MemberEvent{Type: serf.EventMemberLeave, Members: []serf.Member{server("s1.dc1", "10.0.0.1")}})
+	require.False(t, a.contains(net.ParseIP("10.0.0.1")))
+
+	// Updates move a server to its new address.
+	a.update("lan", serf.MemberEvent{Type: serf.EventMemberJoin, Members: []serf.Member{server("s2", "10.0.0.2")}})
+	a.update("lan", serf.MemberEvent{Type: serf.EventMemberUpdate, Members: []serf.Member{server("s2", "10.0.0.4")}})
+	require.False(t, a.contains(net.ParseIP("10.0.0.2")))
+	require.True(t, a.contains(net.ParseIP("10.0.0.4")))
+}
+
+func TestRPC_MaxConns_HandedOffConn(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxConns = 1
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// A gRPC connection is handed off to the gRPC server but keeps its slot.
+	conn, err := grpc.Dial(s1.config.RPCAdvertise.String(),
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return dialRPCClient(t, s1, pool.RPCGRPC), nil
+		}))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = grpcpb.NewStatusClient(conn).Ping(ctx, &grpcpb.PingRequest{})
+	require.NoError(t, err)
+
+	var out struct{}
+	codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+	require.Error(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+	codec.Close()
+
+	// Closing the gRPC connection frees the slot.
+	conn.Close()
+	retry.Run(t, func(r *retry.R) {
+		codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+		defer codec.Close()
+		if err := msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out); err != nil {
+			r.Fatal(err)
+		}
+	})
+}
+
+func TestRPC_MaxConns_Backpressure(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxConns = 2
+		c.RPCAcceptBackpressure = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	ping := func(codec rpc.ClientCodec) <-chan error {
+		errCh := make(chan error, 1)
+		go func() {
+			var out struct{}
+			errCh <- msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out)
+		}()
+		return errCh
+	}
+
+	// Open connections until one has to wait for a free slot.
+	var codecs []rpc.ClientCodec
+	for i := 0; i < 2; i++ {
+		codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+		defer codec.Close()
+		require.NoError(t, <-ping(codec))
+		codecs = append(codecs, codec)
+	}
+	codec := msgpackrpc.NewClientCodec(dialRPCClient(t, s1, pool.RPCConsul))
+	defer codec.Close()
+	waiting := ping(codec)
+	select {
+	case err := <-waiting:
+		t.Fatalf("call should wait for a free slot: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Closing the other connections lets it through.
+	for _, codec := range codecs {
+		codec.Close()
+	}
+	select {
+	case err := <-waiting:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("call didn't complete")
+	}
+}
+
+func TestRPC_MaxStreamsPerConn(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxStreamsPerConn = 1
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	conn, err := net.DialTimeout("tcp", s1.config.RPCAdvertise.String(), time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte{byte(pool.RPCMultiplexV2)})
+	require.NoError(t, err)
+
+	session, err := yamux.Client(conn, yamux.DefaultConfig())
+	require.NoError(t, err)
+	defer session.Close()
+
+	stream1, err := session.Open()
+	require.NoError(t, err)
+	codec1 := msgpackrpc.NewClientCodec(stream1)
+	defer codec1.Close()
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec1, "Status.Ping", struct{}{}, &out))
+
+	// The second stream is over the limit and gets closed.
+	stream2, err := session.Open()
+	require.NoError(t, err)
+	codec2 := msgpackrpc.NewClientCodec(stream2)
+	defer codec2.Close()
+	require.Error(t, msgpackrpc.CallWithCodec(codec2, "Status.Ping", struct{}{}, &out))
+}
+
+func TestRPC_FollowerReads(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.FollowerReads = []string{"catalog"}
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Bootstrap = false
+		c.FollowerReads = []string{"catalog"}
+	})
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	joinLAN(t, s2, s1)
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+	testrpc.WaitForTestAgent(t, s2.RPC, "dc1")
+
+	follower := s1
+	if s1.IsLeader() {
+		follower = s2
+	}
+	codec := rpcClient(t, follower)
+	defer codec.Close()
+
+	// Inject fake data on the follower so we can tell where reads are
+	// served from.
+	require.NoError(t, follower.fsm.State().EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+
+	hasFoo := func(nodes structs.Nodes) bool {
+		for _, n := range nodes {
+			if n.Node == "foo" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Default reads are served by the follower.
+	retry.Run(t, func(r *retry.R) {
+		args := structs.DCSpecificRequest{Datacenter: "dc1"}
+		var out structs.IndexedNodes
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out); err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		if !hasFoo(out.Nodes) {
+			r.Fatalf("read not served by the follower: %v", out.Nodes)
+		}
+		if !out.KnownLeader {
+			r.Fatalf("should have known leader")
+		}
+	})
+
+	// The follower serves reads without asking the leader once it applied
+	// the commit index.
+	require.NoError(t, follower.waitForCommitIndex())
+	commitIndex, err := strconv.ParseUint(follower.raft.Stats()["commit_index"], 10, 64)
+	require.NoError(t, err)
+	require.True(t, follower.raft.AppliedIndex() >= commitIndex)
+
+	// Consistent reads still go to the leader.
+	args := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{RequireConsistent: true},
+	}
+	var out structs.IndexedNodes
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out))
+	require.False(t, hasFoo(out.Nodes))
+
+	// Endpoint classes that aren't enabled aren't served by the follower.
+	require.False(t, follower.canServeFollowerRead("Health.ChecksInState", &structs.ChecksInStateRequest{}))
+}
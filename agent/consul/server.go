@@ -28,12 +28,15 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/lib/connlimit"
+	"github.com/hashicorp/consul/lib/semaphore"
 	"github.com/hashicorp/consul/sentinel"
 	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb"
 	"github.com/hashicorp/serf/serf"
+	"google.golang.org/grpc"
 )
 
 // These are the protocol versions that Consul can _understand_. These are
@@ -129,12 +132,24 @@ type Server struct {
 	caProviderRoot *structs.CARoot
 	caProviderLock sync.RWMutex
 
+	// caRollout tracks the staged root rotation in progress, if any. It's
+	// only set on the leader while a rotation waits for the agents to
+	// acknowledge the new root.
+	caRollout     *caRootRollout
+	caRolloutLock sync.Mutex
+
 	// caPruningCh is used to shut down the CA root pruning goroutine when we
 	// lose leadership.
 	caPruningCh      chan struct{}
 	caPruningLock    sync.RWMutex
 	caPruningEnabled bool
 
+	// caMetricsCh is used to shut down the goroutine emitting CA metrics when
+	// we lose leadership.
+	caMetricsCh      chan struct{}
+	caMetricsLock    sync.RWMutex
+	caMetricsEnabled bool
+
 	// Consul configuration
 	config *Config
 
@@ -197,9 +212,32 @@ type Server struct {
 	Listener  net.Listener
 	rpcServer *rpc.Server
 
+	// grpcServer serves the gRPC transport of the RPC port. handleConn hands
+	// off its connections through grpcListener.
+	grpcServer   *grpc.Server
+	grpcListener *grpcListener
+
+	// rpcConns holds a slot for each RPC connection being handled while the
+	// number of connections is limited.
+	rpcConns *semaphore.Dynamic
+
+	// rpcLimiter limits the rate at which RPC connections are accepted and
+	// the number of concurrent TLS handshakes.
+	rpcLimiter *connlimit.Limiter
+
+	// rpcLimits holds the *rpcLimits currently enforced by the RPC server.
+	// It is replaced by ReloadConfig.
+	rpcLimits atomic.Value
+
 	// rpcTLS is the TLS config for incoming TLS requests
 	rpcTLS *tls.Config
 
+	// tlsConfigurator is used to tell the certificates of servers apart.
+	tlsConfigurator *tlsutil.Configurator
+
+	// serverAddrs are the addresses of the servers in the LAN and WAN pools.
+	serverAddrs *serverAddrs
+
 	// serfLAN is the Serf cluster maintained inside the DC
 	// which contains all the DC nodes
 	serfLAN *serf.Serf
@@ -302,12 +340,23 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		return nil, err
 	}
 
+	// Create the TLS wrapper for outgoing gRPC connections.
+	alpnWrap, err := tlsConfigurator.OutgoingALPNRPCWrapper()
+	if err != nil {
+		return nil, err
+	}
+
 	// Get the incoming TLS config.
 	incomingTLS, err := tlsConfigurator.IncomingRPCConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	// Let TLS clients select the gRPC transport during the handshake.
+	if incomingTLS != nil {
+		incomingTLS.NextProtos = []string{pool.ALPNGRPC}
+	}
+
 	// Create the tombstone GC.
 	gc, err := state.NewTombstoneGC(config.TombstoneTTL, config.TombstoneTTLGranularity)
 	if err != nil {
@@ -318,12 +367,13 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 	shutdownCh := make(chan struct{})
 
 	connPool := &pool.ConnPool{
-		SrcAddr:    config.RPCSrcAddr,
-		LogOutput:  config.LogOutput,
-		MaxTime:    serverRPCCache,
-		MaxStreams: serverMaxStreams,
-		TLSWrapper: tlsWrap,
-		ForceTLS:   config.VerifyOutgoing,
+		SrcAddr:     config.RPCSrcAddr,
+		LogOutput:   config.LogOutput,
+		MaxTime:     serverRPCCache,
+		MaxStreams:  serverMaxStreams,
+		TLSWrapper:  tlsWrap,
+		ALPNWrapper: alpnWrap,
+		ForceTLS:    config.VerifyOutgoing,
 	}
 
 	// Create server.
@@ -339,6 +389,8 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		router:           router.NewRouter(logger, config.Datacenter),
 		rpcServer:        rpc.NewServer(),
 		rpcTLS:           incomingTLS,
+		tlsConfigurator:  tlsConfigurator,
+		serverAddrs:      newServerAddrs(),
 		reassertLeaderCh: make(chan chan error),
 		segmentLAN:       make(map[string]*serf.Serf, len(config.Segments)),
 		sessionTimers:    NewSessionTimers(),
@@ -347,6 +399,10 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		shutdownCh:       shutdownCh,
 	}
 
+	s.rpcConns = semaphore.NewDynamic(0)
+	s.rpcLimiter = connlimit.New(connlimit.Config{}, []string{"rpc"}, "consul.rpc", logger)
+	s.setRPCLimits(config)
+
 	// Initialize enterprise specific server functionality
 	if err := s.initEnterprise(); err != nil {
 		s.Shutdown()
@@ -443,7 +499,11 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 			s.Shutdown()
 			return nil, fmt.Errorf("Failed to add WAN serf route: %v", err)
 		}
-		go router.HandleSerfEvents(s.logger, s.router, types.AreaWAN, s.serfWAN.ShutdownCh(), s.eventChWAN)
+		wanEvents := make(chan serf.Event, serfEventChSize)
+		go s.wanEventHandler(wanEvents)
+		go router.HandleSerfEvents(s.logger, s.router, types.AreaWAN, s.serfWAN.ShutdownCh(), wanEvents, func(q *serf.Query) {
+			handleKeyringStatusQuery(s.logger, q, s.config.SerfWANConfig.MemberlistConfig.Keyring)
+		})
 
 		// Fire up the LAN <-> WAN join flooder.
 		portFn := func(s *metadata.Server) (int, bool) {
@@ -455,6 +515,9 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		go s.Flood(nil, portFn, s.serfWAN)
 	}
 
+	// Advertise the cluster ID once it is known.
+	go s.advertiseClusterID()
+
 	// Start enterprise specific functionality
 	if err := s.startEnterprise(); err != nil {
 		s.Shutdown()
@@ -479,6 +542,7 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 
 	// Start the metrics handlers.
 	go s.sessionStats()
+	go s.crossDCStats()
 
 	return s, nil
 }
@@ -712,6 +776,7 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 		return server.UseTLS
 	}
 	s.raftLayer = NewRaftLayer(s.config.RPCSrcAddr, s.config.RPCAdvertise, wrapper, tlsFunc)
+	s.setupGRPC()
 	return nil
 }
 
@@ -756,6 +821,10 @@ func (s *Server) Shutdown() error {
 		s.Listener.Close()
 	}
 
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+
 	// Close the connection pool
 	s.connPool.Shutdown()
 
@@ -1003,6 +1072,10 @@ func (i *inmemCodec) Close() error {
 
 // RPC is used to make a local RPC call
 func (s *Server) RPC(method string, args interface{}, reply interface{}) error {
+	// Only servers forwarding a request to another datacenter may set the
+	// datacenter it comes from.
+	structs.SetSourceDatacenter(args, "")
+
 	codec := &inmemCodec{
 		method: method,
 		args:   args,
@@ -1121,6 +1194,7 @@ func (s *Server) GetLANCoordinate() (lib.CoordinateSet, error) {
 // ReloadConfig is used to have the Server do an online reload of
 // relevant configuration information
 func (s *Server) ReloadConfig(config *Config) error {
+	s.setRPCLimits(config)
 	return nil
 }
 
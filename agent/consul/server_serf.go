@@ -2,6 +2,7 @@ package consul
 
 import (
 	"fmt"
+	"log"
 	"net"
 	"path/filepath"
 	"strings"
@@ -50,6 +51,11 @@ func (s *Server) setupSerf(conf *serf.Config, ch chan serf.Event, path string, w
 			conf.Tags["sl_"+s.Name] = net.JoinHostPort(s.Advertise, fmt.Sprintf("%d", s.Port))
 		}
 	}
+	if wan {
+		for dc, addr := range s.config.AdvertiseAddrWANMap {
+			conf.Tags[wanAddrTagPrefix+dc] = addr
+		}
+	}
 	conf.Tags["id"] = string(s.config.NodeID)
 	conf.Tags["vsn"] = fmt.Sprintf("%d", s.config.ProtocolVersion)
 	conf.Tags["vsn_min"] = fmt.Sprintf("%d", ProtocolVersionMin)
@@ -64,12 +70,16 @@ func (s *Server) setupSerf(conf *serf.Config, ch chan serf.Event, path string, w
 	if s.config.BootstrapExpect != 0 {
 		conf.Tags["expect"] = fmt.Sprintf("%d", s.config.BootstrapExpect)
 	}
+	if id := s.clusterID(); id != "" {
+		conf.Tags["cluster_id"] = id
+	}
 	if s.config.NonVoter {
 		conf.Tags["nonvoter"] = "1"
 	}
 	if s.config.UseTLS {
 		conf.Tags["use_tls"] = "1"
 	}
+	conf.Tags["grpc"] = "1"
 
 	if s.acls.ACLsEnabled() {
 		// we start in legacy mode and allow upgrading later
@@ -86,14 +96,34 @@ func (s *Server) setupSerf(conf *serf.Config, ch chan serf.Event, path string, w
 	conf.EventCh = ch
 	conf.ProtocolVersion = protocolVersionMap[s.config.ProtocolVersion]
 	conf.RejoinAfterLeave = s.config.RejoinAfterLeave
+	clusterID := s.clusterID
+	if s.config.SkipClusterIDCheck {
+		clusterID = nil
+	}
 	if wan {
-		conf.Merge = &wanMergeDelegate{}
+		// Wrap the WAN transport so servers advertising a different
+		// address for our datacenter are reached at that address.
+		logger := s.logger
+		if logger == nil {
+			logger = log.New(s.config.LogOutput, "", log.LstdFlags)
+		}
+		transport, err := newWANTransport(conf.MemberlistConfig, logger, s.config.Datacenter)
+		if err != nil {
+			return nil, err
+		}
+		conf.MemberlistConfig.Transport = transport
+		conf.Merge = &wanMergeDelegate{
+			dc:        s.config.Datacenter,
+			clusterID: clusterID,
+			transport: transport,
+		}
 	} else {
 		conf.Merge = &lanMergeDelegate{
-			dc:       s.config.Datacenter,
-			nodeID:   s.config.NodeID,
-			nodeName: s.config.NodeName,
-			segment:  segment,
+			dc:        s.config.Datacenter,
+			nodeID:    s.config.NodeID,
+			nodeName:  s.config.NodeName,
+			segment:   segment,
+			clusterID: clusterID,
 		}
 	}
 
@@ -132,6 +162,9 @@ func (s *Server) lanEventHandler() {
 	for {
 		select {
 		case e := <-s.eventChLAN:
+			if me, ok := e.(serf.MemberEvent); ok {
+				s.serverAddrs.update("lan", me)
+			}
 			switch e.EventType() {
 			case serf.EventMemberJoin:
 				s.lanNodeJoin(e.(serf.MemberEvent))
@@ -145,7 +178,8 @@ func (s *Server) lanEventHandler() {
 				s.localEvent(e.(serf.UserEvent))
 			case serf.EventMemberUpdate:
 				s.localMemberEvent(e.(serf.MemberEvent))
-			case serf.EventQuery: // Ignore
+			case serf.EventQuery:
+				handleKeyringStatusQuery(s.logger, e.(*serf.Query), s.config.SerfLANConfig.MemberlistConfig.Keyring)
 			default:
 				s.logger.Printf("[WARN] consul: Unhandled LAN Serf Event: %#v", e)
 			}
@@ -156,6 +190,27 @@ func (s *Server) lanEventHandler() {
 	}
 }
 
+// wanEventHandler keeps the server addresses up to date with the events from
+// the WAN Serf cluster before passing them on to the router.
+func (s *Server) wanEventHandler(out chan<- serf.Event) {
+	for {
+		select {
+		case e := <-s.eventChWAN:
+			if me, ok := e.(serf.MemberEvent); ok {
+				s.serverAddrs.update("wan", me)
+			}
+			select {
+			case out <- e:
+			case <-s.shutdownCh:
+				return
+			}
+
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
 // localMemberEvent is used to reconcile Serf events with the strongly
 // consistent store if we are the current leader
 func (s *Server) localMemberEvent(me serf.MemberEvent) {
@@ -269,6 +324,10 @@ func (s *Server) maybeBootstrap() {
 			s.logger.Printf("[ERR] consul: Member %v has bootstrap mode. Expect disabled.", member)
 			return
 		}
+		if s.config.BootstrapClusterID != "" && p.ClusterID != s.config.BootstrapClusterID {
+			s.logger.Printf("[ERR] consul: Member %v has a conflicting cluster ID %q, refusing to bootstrap", member, p.ClusterID)
+			return
+		}
 		if !p.NonVoter {
 			voters++
 		}
@@ -285,6 +344,7 @@ func (s *Server) maybeBootstrap() {
 		var peers []string
 
 		// Retry with exponential backoff to get peer status from this server
+		confirmed := false
 		for attempt := uint(0); attempt < maxPeerRetries; attempt++ {
 			if err := s.connPool.RPC(s.config.Datacenter, server.Addr, server.Version,
 				"Status.Peers", server.UseTLS, &struct{}{}, &peers); err != nil {
@@ -293,10 +353,19 @@ func (s *Server) maybeBootstrap() {
 					"%v...", server.Name, err, nextRetry.String())
 				time.Sleep(nextRetry)
 			} else {
+				confirmed = true
 				break
 			}
 		}
 
+		// With a cluster ID a server that might already be part of a
+		// cluster blocks the bootstrap. It is attempted again on the next
+		// member event.
+		if !confirmed && s.config.BootstrapClusterID != "" {
+			s.logger.Printf("[ERR] consul: Could not confirm peer status for %s, refusing to bootstrap", server.Name)
+			return
+		}
+
 		// Found a node with some Raft peers, stop bootstrap since there's
 		// evidence of an existing cluster. We should get folded in by the
 		// existing servers if that's the case, so it's cleaner to sit as a
@@ -789,6 +789,81 @@ func TestServer_BadExpect(t *testing.T) {
 	})
 }
 
+func TestServer_Expect_ClusterID(t *testing.T) {
+	t.Parallel()
+	testServerDCExpectClusterID := func(id string) (string, *Server) {
+		return testServerWithConfig(t, func(c *Config) {
+			c.Datacenter = "dc1"
+			c.Bootstrap = false
+			c.BootstrapExpect = 3
+			c.BootstrapClusterID = id
+
+			// Let the servers join so the bootstrap is what refuses
+			// the other cluster.
+			c.SkipClusterIDCheck = true
+		})
+	}
+
+	dir1, s1 := testServerDCExpectClusterID("prod")
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	dir2, s2 := testServerDCExpectClusterID("prod")
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	// this one was configured for another cluster
+	dir3, s3 := testServerDCExpectClusterID("staging")
+	defer os.RemoveAll(dir3)
+	defer s3.Shutdown()
+
+	dir4, s4 := testServerDCExpectClusterID("prod")
+	defer os.RemoveAll(dir4)
+	defer s4.Shutdown()
+
+	joinLAN(t, s2, s1)
+	joinLAN(t, s3, s1)
+
+	// should have no peers since s3 has a different cluster ID
+	retry.Run(t, func(r *retry.R) {
+		r.Check(wantPeers(s1, 0))
+		r.Check(wantPeers(s2, 0))
+		r.Check(wantPeers(s3, 0))
+	})
+
+	// A fourth server doesn't help while s3 is a member.
+	joinLAN(t, s4, s1)
+	retry.Run(t, func(r *retry.R) {
+		r.Check(wantPeers(s1, 0))
+		r.Check(wantPeers(s4, 0))
+	})
+}
+
+func TestServer_Expect_SameClusterID(t *testing.T) {
+	t.Parallel()
+	var servers []*Server
+	for i := 0; i < 3; i++ {
+		dir, s := testServerWithConfig(t, func(c *Config) {
+			c.Datacenter = "dc1"
+			c.Bootstrap = false
+			c.BootstrapExpect = 3
+			c.BootstrapClusterID = "prod"
+		})
+		defer os.RemoveAll(dir)
+		defer s.Shutdown()
+		servers = append(servers, s)
+	}
+
+	joinLAN(t, servers[1], servers[0])
+	joinLAN(t, servers[2], servers[0])
+
+	retry.Run(t, func(r *retry.R) {
+		for _, s := range servers {
+			r.Check(wantPeers(s, 3))
+		}
+	})
+}
+
 type fakeGlobalResp struct{}
 
 func (r *fakeGlobalResp) Add(interface{}) {
@@ -858,7 +933,7 @@ func testVerifyRPC(s1, s2 *Server, t *testing.T) (bool, error) {
 	if leader == nil {
 		t.Fatal("no leader")
 	}
-	return s2.connPool.Ping(leader.Datacenter, leader.Addr, leader.Version, leader.UseTLS)
+	return s2.connPool.Ping(leader.Datacenter, leader.Addr, leader.Version, leader.UseTLS, false)
 }
 
 func TestServer_TLSToNoTLS(t *testing.T) {
@@ -14,9 +14,11 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"os"
 	"time"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/fsm"
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/snapshot"
@@ -89,9 +91,14 @@ func (s *Server) dispatchSnapshotRequest(args *structs.SnapshotRequest, in io.Re
 		}
 
 		// Restore the snapshot.
-		if err := snapshot.Restore(s.logger, in, s.raft); err != nil {
+		summary, err := s.restoreSnapshot(args, in)
+		if err != nil {
 			return nil, err
 		}
+		if args.DryRun {
+			reply.Summary = summary
+			return ioutil.NopCloser(bytes.NewReader([]byte(""))), nil
+		}
 
 		// Run a barrier so we are sure that our FSM is caught up with
 		// any snapshot restore details (it's also part of Raft's restore
@@ -145,6 +152,50 @@ func (s *Server) dispatchSnapshotRequest(args *structs.SnapshotRequest, in io.Re
 	}
 }
 
+// restoreSnapshot verifies the snapshot from the reader and restores it
+// unless the request is a dry run. The state in the snapshot is decoded
+// before it's handed to Raft, so that a snapshot which can't be restored
+// fails before the state of the servers is replaced.
+func (s *Server) restoreSnapshot(args *structs.SnapshotRequest, in io.Reader) (*structs.SnapshotSummary, error) {
+	snap, metadata, err := snapshot.Read(s.logger, in)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := snap.Close(); err != nil {
+			s.logger.Printf("[ERR] consul: Failed to close temp snapshot: %v", err)
+		}
+		if err := os.Remove(snap.Name()); err != nil {
+			s.logger.Printf("[ERR] consul: Failed to clean up temp snapshot: %v", err)
+		}
+	}()
+
+	summary, err := fsm.VerifySnapshot(snap)
+	if err != nil {
+		return nil, err
+	}
+	summary.Index = metadata.Index
+	summary.Term = metadata.Term
+
+	// A snapshot of another cluster would make the servers reject the
+	// members of this one.
+	if id := s.clusterID(); !args.SkipClusterIDCheck && summary.ClusterID != "" && id != "" && summary.ClusterID != id {
+		return nil, fmt.Errorf("snapshot was taken from cluster %q, but this is cluster %q", summary.ClusterID, id)
+	}
+
+	if args.DryRun {
+		return summary, nil
+	}
+
+	if _, err := snap.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to rewind temp snapshot: %v", err)
+	}
+	if err := s.raft.Restore(metadata, snap, 0); err != nil {
+		return nil, fmt.Errorf("Raft error when restoring snapshot: %v", err)
+	}
+	return summary, nil
+}
+
 // handleSnapshotRequest reads the request from the conn and dispatches it. This
 // will be called from a goroutine after an incoming stream is determined to be
 // a snapshot request.
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
 )
 
 // verifySnapshot is a helper that does a snapshot and restore.
@@ -408,3 +410,69 @@ func TestSnapshot_AllowStale(t *testing.T) {
 		}
 	}
 }
+
+func TestSnapshot_RestoreVerification(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	dir2, s2 := testServer(t)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s2.RPC, "dc1")
+	retry.Run(t, func(r *retry.R) {
+		if s1.clusterID() == "" || s2.clusterID() == "" {
+			r.Fatal("no cluster ID")
+		}
+	})
+
+	// Take a snapshot of the first cluster.
+	args := structs.SnapshotRequest{
+		Datacenter: "dc1",
+		Op:         structs.SnapshotSave,
+	}
+	var reply structs.SnapshotResponse
+	snap, err := SnapshotRPC(s1.connPool, "dc1", s1.config.RPCAddr, false,
+		&args, bytes.NewReader([]byte("")), &reply)
+	require.NoError(t, err)
+	var data bytes.Buffer
+	_, err = io.Copy(&data, snap)
+	require.NoError(t, err)
+	require.NoError(t, snap.Close())
+
+	restore := func(s *Server, args structs.SnapshotRequest, in []byte) (*structs.SnapshotResponse, error) {
+		args.Datacenter = "dc1"
+		args.Op = structs.SnapshotRestore
+		var reply structs.SnapshotResponse
+		out, err := SnapshotRPC(s.connPool, "dc1", s.config.RPCAddr, false,
+			&args, bytes.NewReader(in), &reply)
+		if err != nil {
+			return nil, err
+		}
+		return &reply, out.Close()
+	}
+
+	// A dry run reports the contents of the snapshot.
+	out, err := restore(s1, structs.SnapshotRequest{DryRun: true}, data.Bytes())
+	require.NoError(t, err)
+	require.NotNil(t, out.Summary)
+	require.Equal(t, s1.clusterID(), out.Summary.ClusterID)
+	require.NotZero(t, out.Summary.Index)
+	require.Equal(t, 1, out.Summary.Records["ClusterIdentity"])
+
+	// The snapshot of another cluster is rejected unless asked otherwise.
+	_, err = restore(s2, structs.SnapshotRequest{}, data.Bytes())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "snapshot was taken from cluster")
+	out, err = restore(s2, structs.SnapshotRequest{DryRun: true, SkipClusterIDCheck: true}, data.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, s1.clusterID(), out.Summary.ClusterID)
+
+	// A corrupted snapshot fails before it's restored.
+	corrupted := append([]byte{}, data.Bytes()...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	_, err = restore(s1, structs.SnapshotRequest{}, corrupted)
+	require.Error(t, err)
+}
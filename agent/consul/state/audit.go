@@ -0,0 +1,158 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const auditTrailTableName = "audit-trail"
+
+// auditTrailMaxEntries is the number of entries the audit trail keeps. Older
+// entries are removed when new ones are added. It must be the same on all
+// servers, so it can't be configured.
+const auditTrailMaxEntries = 1000
+
+// auditTrailTableSchema returns a new table schema used to store the audit
+// trail of changes of intentions and config entries.
+func auditTrailTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: auditTrailTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer:      &IndexAuditEntry{},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(auditTrailTableSchema)
+}
+
+// IndexAuditEntry indexes a *structs.AuditEntry by its Raft index and name.
+// The Raft index is encoded in big endian so that iterating the index
+// returns the oldest entries first, which memdb.UintFieldIndex doesn't
+// guarantee.
+type IndexAuditEntry struct{}
+
+func (idx *IndexAuditEntry) FromObject(obj interface{}) (bool, []byte, error) {
+	entry, ok := obj.(*structs.AuditEntry)
+	if !ok {
+		return false, nil, fmt.Errorf("Object must be AuditEntry, got %T", obj)
+	}
+	return true, auditEntryKey(entry.Index, entry.Name), nil
+}
+
+func (idx *IndexAuditEntry) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("must provide the index and the name")
+	}
+	index, ok := args[0].(uint64)
+	if !ok {
+		return nil, fmt.Errorf("index must be a uint64: %#v", args[0])
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("name must be a string: %#v", args[1])
+	}
+	return auditEntryKey(index, name), nil
+}
+
+func auditEntryKey(index uint64, name string) []byte {
+	key := make([]byte, 8, 8+len(name)+1)
+	binary.BigEndian.PutUint64(key, index)
+	key = append(key, name...)
+
+	// Add the null character as a terminator
+	return append(key, '\x00')
+}
+
+// AuditEntries is used to pull the audit trail for use during snapshots.
+func (s *Snapshot) AuditEntries() (structs.AuditEntries, error) {
+	iter, err := s.tx.Get(auditTrailTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret structs.AuditEntries
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		ret = append(ret, wrapped.(*structs.AuditEntry))
+	}
+	return ret, nil
+}
+
+// AuditEntry is used when restoring from a snapshot.
+func (s *Restore) AuditEntry(entry *structs.AuditEntry) error {
+	if err := s.tx.Insert(auditTrailTableName, entry); err != nil {
+		return fmt.Errorf("failed restoring audit entry: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, entry.Index, auditTrailTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// AuditTrail returns the audit trail, oldest entry first. If a resource is
+// given only its entries are returned.
+func (s *Store) AuditTrail(ws memdb.WatchSet, resource structs.AuditResource) (uint64, structs.AuditEntries, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, auditTrailTableName)
+
+	iter, err := tx.Get(auditTrailTableName, "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed audit trail lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var results structs.AuditEntries
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		entry := wrapped.(*structs.AuditEntry)
+		if resource != "" && entry.Resource != resource {
+			continue
+		}
+		results = append(results, entry)
+	}
+	return idx, results, nil
+}
+
+// AuditEntryAppend adds an entry to the audit trail and removes the oldest
+// entries beyond the size of the trail.
+func (s *Store) AuditEntryAppend(idx uint64, entry *structs.AuditEntry) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	entry.Index = idx
+	if err := tx.Insert(auditTrailTableName, entry); err != nil {
+		return fmt.Errorf("failed inserting audit entry: %s", err)
+	}
+
+	iter, err := tx.Get(auditTrailTableName, "id")
+	if err != nil {
+		return fmt.Errorf("failed audit trail lookup: %s", err)
+	}
+	var entries []interface{}
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		entries = append(entries, wrapped)
+	}
+	for i := 0; i < len(entries)-auditTrailMaxEntries; i++ {
+		if err := tx.Delete(auditTrailTableName, entries[i]); err != nil {
+			return fmt.Errorf("failed deleting audit entry: %s", err)
+		}
+	}
+
+	if err := tx.Insert("index", &IndexEntry{auditTrailTableName, idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_AuditTrail(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	idx, entries, err := s.AuditTrail(nil, "")
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Len(entries, 0)
+
+	ws := memdb.NewWatchSet()
+	_, _, err = s.AuditTrail(ws, "")
+	require.NoError(err)
+	require.NoError(s.AuditEntryAppend(1, &structs.AuditEntry{
+		Resource: structs.AuditResourceIntention,
+		Name:     "a",
+		Op:       "create",
+		After:    []byte(`{"ID":"a"}`),
+	}))
+	require.True(watchFired(ws))
+
+	// Entries at the same index are kept apart by their name.
+	require.NoError(s.AuditEntryAppend(2, &structs.AuditEntry{Resource: structs.AuditResourceIntention, Name: "a", Op: "delete"}))
+	require.NoError(s.AuditEntryAppend(2, &structs.AuditEntry{Resource: structs.AuditResourceIntention, Name: "b", Op: "delete"}))
+	require.NoError(s.AuditEntryAppend(3, &structs.AuditEntry{Resource: structs.AuditResourceConfigEntry, Name: "service-defaults/web", Op: "upsert"}))
+
+	idx, entries, err = s.AuditTrail(nil, "")
+	require.NoError(err)
+	require.Equal(uint64(3), idx)
+	require.Len(entries, 4)
+	require.Equal(uint64(1), entries[0].Index)
+	require.Equal(`{"ID":"a"}`, string(entries[0].After))
+
+	_, entries, err = s.AuditTrail(nil, structs.AuditResourceConfigEntry)
+	require.NoError(err)
+	require.Len(entries, 1)
+	require.Equal("service-defaults/web", entries[0].Name)
+
+	// Snapshot the trail and restore it into a new store.
+	snap := s.Snapshot()
+	defer snap.Close()
+	dump, err := snap.AuditEntries()
+	require.NoError(err)
+	require.Len(dump, 4)
+
+	s2 := testStateStore(t)
+	restore := s2.Restore()
+	for _, entry := range dump {
+		require.NoError(restore.AuditEntry(entry))
+	}
+	restore.Commit()
+	idx, entries, err = s2.AuditTrail(nil, "")
+	require.NoError(err)
+	require.Equal(uint64(3), idx)
+	require.Equal(dump, entries)
+}
+
+func TestStateStore_AuditTrail_Bounded(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	for i := 1; i <= auditTrailMaxEntries+10; i++ {
+		require.NoError(s.AuditEntryAppend(uint64(i), &structs.AuditEntry{
+			Resource: structs.AuditResourceIntention,
+			Name:     fmt.Sprintf("ixn-%d", i),
+		}))
+	}
+
+	// The oldest entries were removed.
+	idx, entries, err := s.AuditTrail(nil, "")
+	require.NoError(err)
+	require.Equal(uint64(auditTrailMaxEntries+10), idx)
+	require.Len(entries, auditTrailMaxEntries)
+	require.Equal(uint64(11), entries[0].Index)
+	require.Equal(uint64(auditTrailMaxEntries+10), entries[len(entries)-1].Index)
+}
@@ -832,6 +832,22 @@ func (s *Store) ensureServiceTxn(tx *memdb.Txn, idx uint64, node string, svc *st
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// Services that support Connect get a virtual IP so they can be reached
+	// through DNS.
+	name := connectServiceName(entry)
+	if name != "" {
+		if err := s.ensureServiceVirtualIPTxn(tx, idx, name); err != nil {
+			return err
+		}
+	}
+	if existing != nil {
+		if prev := connectServiceName(existing.(*structs.ServiceNode)); prev != "" && prev != name {
+			if err := s.freeServiceVirtualIPTxn(tx, idx, prev); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1348,6 +1364,11 @@ func (s *Store) deleteServiceTxn(tx *memdb.Txn, idx uint64, nodeName, serviceID
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// Delete the weight override of the instance.
+	if err := s.deleteServiceWeightOverrideTxn(tx, idx, nodeName, serviceID); err != nil {
+		return err
+	}
+
 	// Delete the service and update the index
 	if err := tx.Delete("services", service); err != nil {
 		return fmt.Errorf("failed deleting service: %s", err)
@@ -1381,6 +1402,12 @@ func (s *Store) deleteServiceTxn(tx *memdb.Txn, idx uint64, nodeName, serviceID
 	} else {
 		return fmt.Errorf("Could not find any service %s: %s", svc.ServiceName, err)
 	}
+
+	if name := connectServiceName(svc); name != "" {
+		if err := s.freeServiceVirtualIPTxn(tx, idx, name); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1881,6 +1908,40 @@ func (s *Store) checkServiceNodes(ws memdb.WatchSet, serviceName string, connect
 	// Get the table index.
 	idx := maxIndexForService(tx, serviceName, len(results) > 0, true)
 
+	// Terminating gateways linked to the service accept Connect traffic on
+	// its behalf so they are returned as if they were its proxies.
+	if connect {
+		gateways, err := s.terminatingGatewaysForServiceTxn(tx, ws, serviceName)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(gateways) > 0 {
+			if configIdx := maxIndexTxn(tx, configTableName); configIdx > idx {
+				idx = configIdx
+			}
+		}
+		for _, gateway := range gateways {
+			iter, err := tx.Get("services", "service", gateway)
+			if err != nil {
+				return 0, nil, fmt.Errorf("failed service lookup: %s", err)
+			}
+			ws.Add(iter.WatchCh())
+
+			exists := false
+			for service := iter.Next(); service != nil; service = iter.Next() {
+				sn := service.(*structs.ServiceNode)
+				if sn.ServiceKind != structs.ServiceKindTerminatingGateway {
+					continue
+				}
+				exists = true
+				results = append(results, sn)
+			}
+			if gwIdx := maxIndexForService(tx, gateway, exists, true); gwIdx > idx {
+				idx = gwIdx
+			}
+		}
+	}
+
 	return s.parseCheckServiceNodes(tx, ws, idx, serviceName, results, err)
 }
 
@@ -1913,6 +1974,73 @@ func (s *Store) CheckServiceTagNodes(ws memdb.WatchSet, serviceName string, tags
 	return s.parseCheckServiceNodes(tx, ws, idx, serviceName, results, err)
 }
 
+// GatewayServices returns the services bound to the given ingress or
+// terminating gateway by its config entry, along with their instances and
+// checks.
+func (s *Store) GatewayServices(ws memdb.WatchSet, gateway string) (uint64, structs.GatewayServices, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx := maxIndexTxn(tx, configTableName)
+
+	var services structs.GatewayServices
+	for _, kind := range []string{structs.IngressGateway, structs.TerminatingGateway} {
+		watchCh, entry, err := tx.FirstWatch(configTableName, "id", kind, gateway)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed config entry lookup: %s", err)
+		}
+		ws.Add(watchCh)
+
+		switch entry := entry.(type) {
+		case *structs.IngressGatewayConfigEntry:
+			for _, l := range entry.Listeners {
+				for _, svc := range l.Services {
+					services = append(services, &structs.GatewayService{
+						Gateway:     gateway,
+						GatewayKind: structs.ServiceKindIngressGateway,
+						Service:     svc.Name,
+						Port:        l.Port,
+						Hosts:       svc.Hosts,
+						TLS:         l.TLS,
+					})
+				}
+			}
+		case *structs.TerminatingGatewayConfigEntry:
+			for _, svc := range entry.Services {
+				services = append(services, &structs.GatewayService{
+					Gateway:     gateway,
+					GatewayKind: structs.ServiceKindTerminatingGateway,
+					Service:     svc.Name,
+					TLS:         svc.TLS(),
+				})
+			}
+		}
+	}
+
+	for _, gs := range services {
+		iter, err := tx.Get("services", "service", gs.Service)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed service lookup: %s", err)
+		}
+		ws.Add(iter.WatchCh())
+
+		var results structs.ServiceNodes
+		for service := iter.Next(); service != nil; service = iter.Next() {
+			results = append(results, service.(*structs.ServiceNode))
+		}
+		if svcIdx := maxIndexForService(tx, gs.Service, len(results) > 0, true); svcIdx > idx {
+			idx = svcIdx
+		}
+
+		_, instances, err := s.parseCheckServiceNodes(tx, ws, 0, gs.Service, results, nil)
+		if err != nil {
+			return 0, nil, err
+		}
+		gs.Instances = instances
+	}
+	return idx, services, nil
+}
+
 // parseCheckServiceNodes is used to parse through a given set of services,
 // and query for an associated node and a set of checks. This is the inner
 // method used to return a rich set of results from a more simple query.
@@ -1947,6 +2075,13 @@ func (s *Store) parseCheckServiceNodes(
 	}
 	allChecksCh := allChecks.WatchCh()
 
+	// Weight overrides are rare, so changes to any of them fire the watch.
+	overrides, err := tx.Get(serviceWeightOverridesTableName, "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed weight override lookup: %s", err)
+	}
+	ws.Add(overrides.WatchCh())
+
 	results := make(structs.CheckServiceNodes, 0, len(services))
 	for _, sn := range services {
 		// Retrieve the node.
@@ -1983,10 +2118,15 @@ func (s *Store) parseCheckServiceNodes(
 			checks = append(checks, check.(*structs.HealthCheck))
 		}
 
+		service := sn.ToNodeService()
+		if err := applyWeightOverrideTxn(tx, sn.Node, service); err != nil {
+			return 0, nil, err
+		}
+
 		// Append to the results.
 		results = append(results, structs.CheckServiceNode{
 			Node:    node,
-			Service: sn.ToNodeService(),
+			Service: service,
 			Checks:  checks,
 		})
 	}
@@ -3102,6 +3102,126 @@ func TestStateStore_CheckConnectServiceNodes(t *testing.T) {
 	}
 }
 
+func TestStateStore_CheckConnectServiceNodes_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// Register the external service and a gateway for it.
+	require.NoError(s.EnsureNode(10, &structs.Node{Node: "ext", Address: "10.0.0.1"}))
+	require.NoError(s.EnsureNode(11, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(s.EnsureService(12, "ext", &structs.NodeService{ID: "db", Service: "db", Port: 5432}))
+	require.NoError(s.EnsureService(13, "foo", &structs.NodeService{Kind: structs.ServiceKindTerminatingGateway, ID: "gateway", Service: "gateway", Port: 8443}))
+
+	// The gateway isn't returned until it is linked to the service.
+	ws := memdb.NewWatchSet()
+	_, nodes, err := s.CheckConnectServiceNodes(ws, "db")
+	require.NoError(err)
+	require.Len(nodes, 0)
+
+	require.NoError(s.EnsureConfigEntry(14, &structs.TerminatingGatewayConfigEntry{
+		Kind:     structs.TerminatingGateway,
+		Name:     "gateway",
+		Services: []structs.LinkedService{{Name: "db"}},
+	}))
+	require.True(watchFired(ws))
+
+	ws = memdb.NewWatchSet()
+	idx, nodes, err := s.CheckConnectServiceNodes(ws, "db")
+	require.NoError(err)
+	require.Equal(uint64(14), idx)
+	require.Len(nodes, 1)
+	require.Equal(structs.ServiceKindTerminatingGateway, nodes[0].Service.Kind)
+	require.Equal("gateway", nodes[0].Service.Service)
+	require.Equal("foo", nodes[0].Node.Node)
+
+	// Deregistering the gateway fires the watch.
+	require.NoError(s.DeleteService(15, "foo", "gateway"))
+	require.True(watchFired(ws))
+
+	_, nodes, err = s.CheckConnectServiceNodes(nil, "db")
+	require.NoError(err)
+	require.Len(nodes, 0)
+}
+
+func TestStateStore_GatewayServices(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	require.NoError(s.EnsureNode(10, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(s.EnsureService(11, "foo", &structs.NodeService{ID: "web", Service: "web", Port: 8080}))
+	require.NoError(s.EnsureCheck(12, &structs.HealthCheck{
+		Node:      "foo",
+		CheckID:   "web",
+		Name:      "web",
+		Status:    api.HealthPassing,
+		ServiceID: "web",
+	}))
+
+	// Nothing is bound to a gateway without a config entry.
+	ws := memdb.NewWatchSet()
+	_, services, err := s.GatewayServices(ws, "ingress")
+	require.NoError(err)
+	require.Len(services, 0)
+
+	require.NoError(s.EnsureConfigEntry(13, &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress",
+		Listeners: []structs.IngressListener{
+			{Port: 8080, Services: []structs.IngressService{{Name: "web"}}},
+			{Port: 8443, TLS: true, Services: []structs.IngressService{
+				{Name: "web", Hosts: []string{"web.example.com"}},
+				{Name: "api"},
+			}},
+		},
+	}))
+	require.True(watchFired(ws))
+
+	ws = memdb.NewWatchSet()
+	idx, services, err := s.GatewayServices(ws, "ingress")
+	require.NoError(err)
+	require.Equal(uint64(13), idx)
+	require.Len(services, 3)
+
+	require.Equal("web", services[0].Service)
+	require.Equal(structs.ServiceKindIngressGateway, services[0].GatewayKind)
+	require.Equal(8080, services[0].Port)
+	require.False(services[0].TLS)
+	require.Len(services[0].Instances, 1)
+	require.Equal("foo", services[0].Instances[0].Node.Node)
+	require.Len(services[0].Instances[0].Checks, 1)
+
+	require.Equal("web", services[1].Service)
+	require.Equal(8443, services[1].Port)
+	require.True(services[1].TLS)
+	require.Equal([]string{"web.example.com"}, services[1].Hosts)
+
+	// Services that aren't registered have no instances.
+	require.Equal("api", services[2].Service)
+	require.Len(services[2].Instances, 0)
+
+	// Registering an instance of a bound service fires the watch.
+	require.NoError(s.EnsureService(14, "foo", &structs.NodeService{ID: "api", Service: "api", Port: 9090}))
+	require.True(watchFired(ws))
+
+	idx, services, err = s.GatewayServices(nil, "ingress")
+	require.NoError(err)
+	require.Equal(uint64(14), idx)
+	require.Len(services[2].Instances, 1)
+
+	// Terminating gateways return their linked services.
+	require.NoError(s.EnsureConfigEntry(15, &structs.TerminatingGatewayConfigEntry{
+		Kind:     structs.TerminatingGateway,
+		Name:     "terminating",
+		Services: []structs.LinkedService{{Name: "web", CAFile: "ca.pem"}},
+	}))
+	_, services, err = s.GatewayServices(nil, "terminating")
+	require.NoError(err)
+	require.Len(services, 1)
+	require.Equal(structs.ServiceKindTerminatingGateway, services[0].GatewayKind)
+	require.True(services[0].TLS)
+	require.Len(services[0].Instances, 1)
+}
+
 func BenchmarkCheckServiceNodes(b *testing.B) {
 	s, err := NewStateStore(nil)
 	if err != nil {
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const clusterIdentityTableName = "cluster-identity"
+
+// clusterIdentityTableSchema returns a new table schema used for storing
+// the identity of the cluster.
+func clusterIdentityTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: clusterIdentityTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: true,
+				Unique:       true,
+				Indexer: &memdb.ConditionalIndex{
+					Conditional: func(obj interface{}) (bool, error) { return true, nil },
+				},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(clusterIdentityTableSchema)
+}
+
+// ClusterIdentity is used to pull the cluster identity from the snapshot.
+func (s *Snapshot) ClusterIdentity() (*structs.ClusterIdentity, error) {
+	i, err := s.tx.First(clusterIdentityTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	identity, ok := i.(*structs.ClusterIdentity)
+	if !ok {
+		return nil, nil
+	}
+	return identity, nil
+}
+
+// ClusterIdentity is used when restoring from a snapshot.
+func (s *Restore) ClusterIdentity(identity *structs.ClusterIdentity) error {
+	if err := s.tx.Insert(clusterIdentityTableName, identity); err != nil {
+		return fmt.Errorf("failed restoring cluster identity: %s", err)
+	}
+	return nil
+}
+
+// ClusterIdentity returns the identity of the cluster, or nil if it isn't
+// set yet.
+func (s *Store) ClusterIdentity(ws memdb.WatchSet) (*structs.ClusterIdentity, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	watchCh, i, err := tx.FirstWatch(clusterIdentityTableName, "id")
+	if err != nil {
+		return nil, fmt.Errorf("failed cluster identity lookup: %s", err)
+	}
+	ws.Add(watchCh)
+
+	identity, ok := i.(*structs.ClusterIdentity)
+	if !ok {
+		return nil, nil
+	}
+	return identity, nil
+}
+
+// ClusterIdentitySet sets the identity of the cluster if it isn't set yet.
+// The identity never changes once it is set, and false is returned.
+func (s *Store) ClusterIdentitySet(idx uint64, identity *structs.ClusterIdentity) (bool, error) {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	existing, err := tx.First(clusterIdentityTableName, "id")
+	if err != nil {
+		return false, fmt.Errorf("failed cluster identity lookup: %s", err)
+	}
+	if existing != nil {
+		return false, nil
+	}
+	if identity.ID == "" {
+		return false, fmt.Errorf("missing cluster ID")
+	}
+
+	identity.CreateIndex = idx
+	identity.ModifyIndex = idx
+	if err := tx.Insert(clusterIdentityTableName, identity); err != nil {
+		return false, fmt.Errorf("failed updating cluster identity: %s", err)
+	}
+
+	tx.Commit()
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_ClusterIdentity(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	ws := memdb.NewWatchSet()
+	identity, err := s.ClusterIdentity(ws)
+	require.NoError(err)
+	require.Nil(identity)
+
+	// An empty ID is rejected.
+	_, err = s.ClusterIdentitySet(1, &structs.ClusterIdentity{})
+	require.Error(err)
+	require.False(watchFired(ws))
+
+	set, err := s.ClusterIdentitySet(2, &structs.ClusterIdentity{ID: "a"})
+	require.NoError(err)
+	require.True(set)
+	require.True(watchFired(ws))
+
+	// The identity never changes once it is set.
+	set, err = s.ClusterIdentitySet(3, &structs.ClusterIdentity{ID: "b"})
+	require.NoError(err)
+	require.False(set)
+
+	identity, err = s.ClusterIdentity(nil)
+	require.NoError(err)
+	require.Equal(&structs.ClusterIdentity{
+		ID:        "a",
+		RaftIndex: structs.RaftIndex{CreateIndex: 2, ModifyIndex: 2},
+	}, identity)
+}
+
+func TestStateStore_ClusterIdentity_Snapshot_Restore(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	set, err := s.ClusterIdentitySet(1, &structs.ClusterIdentity{ID: "a"})
+	require.NoError(err)
+	require.True(set)
+
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	s2 := testStateStore(t)
+	dump, err := snap.ClusterIdentity()
+	require.NoError(err)
+	require.Equal("a", dump.ID)
+
+	restore := s2.Restore()
+	require.NoError(restore.ClusterIdentity(dump))
+	restore.Commit()
+
+	identity, err := s2.ClusterIdentity(nil)
+	require.NoError(err)
+	require.Equal(dump, identity)
+}
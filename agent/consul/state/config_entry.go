@@ -0,0 +1,275 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	configTableName = "config-entries"
+)
+
+// configTableSchema returns a new table schema used to store global
+// config entries.
+func configTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: configTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer:      &IndexConfigEntry{},
+			},
+			"kind": &memdb.IndexSchema{
+				Name:         "kind",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer:      &IndexConfigEntry{KindOnly: true},
+			},
+			"link": &memdb.IndexSchema{
+				Name:         "link",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &IndexConfigEntryLinks{},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(configTableSchema)
+}
+
+// IndexConfigEntry indexes a structs.ConfigEntry by its kind and name, or by
+// its kind only if KindOnly is set.
+type IndexConfigEntry struct {
+	KindOnly bool
+}
+
+func (idx *IndexConfigEntry) FromObject(obj interface{}) (bool, []byte, error) {
+	entry, ok := obj.(structs.ConfigEntry)
+	if !ok {
+		return false, nil, fmt.Errorf("Object must be ConfigEntry, got %T", obj)
+	}
+
+	result := strings.ToLower(entry.GetKind()) + "\x00"
+	if !idx.KindOnly {
+		result += strings.ToLower(entry.GetName()) + "\x00"
+	}
+	return true, []byte(result), nil
+}
+
+func (idx *IndexConfigEntry) FromArgs(args ...interface{}) ([]byte, error) {
+	want := 2
+	if idx.KindOnly {
+		want = 1
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("must provide %d arguments", want)
+	}
+
+	var result string
+	for _, a := range args {
+		arg, ok := a.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument must be a string: %#v", a)
+		}
+		result += strings.ToLower(arg) + "\x00"
+	}
+	return []byte(result), nil
+}
+
+// IndexConfigEntryLinks indexes terminating gateway config entries by the
+// names of the services they link to.
+type IndexConfigEntryLinks struct{}
+
+func (idx *IndexConfigEntryLinks) FromObject(obj interface{}) (bool, [][]byte, error) {
+	entry, ok := obj.(*structs.TerminatingGatewayConfigEntry)
+	if !ok {
+		return false, nil, nil
+	}
+
+	var result [][]byte
+	for _, svc := range entry.Services {
+		result = append(result, []byte(strings.ToLower(svc.Name)+"\x00"))
+	}
+	return len(result) > 0, result, nil
+}
+
+func (idx *IndexConfigEntryLinks) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+
+	arg, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+	return []byte(strings.ToLower(arg) + "\x00"), nil
+}
+
+// ConfigEntries is used to pull all the config entries for the snapshot.
+func (s *Snapshot) ConfigEntries() ([]structs.ConfigEntry, error) {
+	entries, err := s.tx.Get(configTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []structs.ConfigEntry
+	for wrapped := entries.Next(); wrapped != nil; wrapped = entries.Next() {
+		ret = append(ret, wrapped.(structs.ConfigEntry))
+	}
+
+	return ret, nil
+}
+
+// ConfigEntry is used when restoring from a snapshot.
+func (s *Restore) ConfigEntry(c structs.ConfigEntry) error {
+	// Insert
+	if err := s.tx.Insert(configTableName, c); err != nil {
+		return fmt.Errorf("failed restoring config entry object: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, c.GetRaftIndex().ModifyIndex, configTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	return nil
+}
+
+// ConfigEntry is called to get a given config entry.
+func (s *Store) ConfigEntry(ws memdb.WatchSet, kind, name string) (uint64, structs.ConfigEntry, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the index
+	idx := maxIndexTxn(tx, configTableName)
+
+	// Get the existing config entry.
+	watchCh, existing, err := tx.FirstWatch(configTableName, "id", kind, name)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed config entry lookup: %s", err)
+	}
+	ws.Add(watchCh)
+	if existing == nil {
+		return idx, nil, nil
+	}
+
+	conf, ok := existing.(structs.ConfigEntry)
+	if !ok {
+		return 0, nil, fmt.Errorf("config entry %q (%s) is an invalid type: %T", name, kind, conf)
+	}
+
+	return idx, conf, nil
+}
+
+// ConfigEntries is called to get all config entry objects.
+func (s *Store) ConfigEntries(ws memdb.WatchSet) (uint64, []structs.ConfigEntry, error) {
+	return s.ConfigEntriesByKind(ws, "")
+}
+
+// ConfigEntriesByKind is called to get all config entry objects with the given kind.
+// If kind is empty, all config entries will be returned.
+func (s *Store) ConfigEntriesByKind(ws memdb.WatchSet, kind string) (uint64, []structs.ConfigEntry, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the index
+	idx := maxIndexTxn(tx, configTableName)
+
+	// Lookup by kind, or all if kind is empty
+	var iter memdb.ResultIterator
+	var err error
+	if kind != "" {
+		iter, err = tx.Get(configTableName, "kind", kind)
+	} else {
+		iter, err = tx.Get(configTableName, "id")
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed config entry lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var results []structs.ConfigEntry
+	for v := iter.Next(); v != nil; v = iter.Next() {
+		results = append(results, v.(structs.ConfigEntry))
+	}
+	return idx, results, nil
+}
+
+// EnsureConfigEntry is called to upsert creation of a given config entry.
+func (s *Store) EnsureConfigEntry(idx uint64, conf structs.ConfigEntry) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	// Check for existing configuration.
+	existing, err := tx.First(configTableName, "id", conf.GetKind(), conf.GetName())
+	if err != nil {
+		return fmt.Errorf("failed configuration lookup: %s", err)
+	}
+
+	raftIndex := conf.GetRaftIndex()
+	if existing != nil {
+		existingIdx := existing.(structs.ConfigEntry).GetRaftIndex()
+		raftIndex.CreateIndex = existingIdx.CreateIndex
+	} else {
+		raftIndex.CreateIndex = idx
+	}
+	raftIndex.ModifyIndex = idx
+
+	// Insert the config entry and update the index
+	if err := tx.Insert(configTableName, conf); err != nil {
+		return fmt.Errorf("failed inserting config entry: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{configTableName, idx}); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// DeleteConfigEntry is called to remove the given config entry.
+func (s *Store) DeleteConfigEntry(idx uint64, kind, name string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	// Try to retrieve the existing config entry.
+	existing, err := tx.First(configTableName, "id", kind, name)
+	if err != nil {
+		return fmt.Errorf("failed config entry lookup: %s", err)
+	}
+	if existing == nil {
+		return nil
+	}
+
+	// Delete the config entry from the DB and update the index.
+	if err := tx.Delete(configTableName, existing); err != nil {
+		return fmt.Errorf("failed removing config entry: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{configTableName, idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// terminatingGatewaysForServiceTxn returns the names of the terminating
+// gateways linked to the given service.
+func (s *Store) terminatingGatewaysForServiceTxn(tx *memdb.Txn, ws memdb.WatchSet, service string) ([]string, error) {
+	iter, err := tx.Get(configTableName, "link", service)
+	if err != nil {
+		return nil, fmt.Errorf("failed config entry lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var names []string
+	for v := iter.Next(); v != nil; v = iter.Next() {
+		names = append(names, v.(structs.ConfigEntry).GetName())
+	}
+	return names, nil
+}
@@ -0,0 +1,113 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ConfigEntry(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	expected := &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gateway",
+		Services: []structs.LinkedService{
+			{Name: "db"},
+		},
+	}
+
+	// Create
+	require.NoError(s.EnsureConfigEntry(0, expected))
+
+	idx, config, err := s.ConfigEntry(nil, structs.TerminatingGateway, "gateway")
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Equal(expected, config)
+
+	// Update
+	updated := &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gateway",
+		Services: []structs.LinkedService{
+			{Name: "cache"},
+		},
+	}
+	require.NoError(s.EnsureConfigEntry(1, updated))
+
+	idx, config, err = s.ConfigEntry(nil, structs.TerminatingGateway, "gateway")
+	require.NoError(err)
+	require.Equal(uint64(1), idx)
+	require.Equal(updated, config)
+	require.Equal(uint64(0), config.GetRaftIndex().CreateIndex)
+
+	// The link index follows the update.
+	tx := s.db.Txn(false)
+	names, err := s.terminatingGatewaysForServiceTxn(tx, nil, "db")
+	require.NoError(err)
+	require.Empty(names)
+	names, err = s.terminatingGatewaysForServiceTxn(tx, nil, "cache")
+	require.NoError(err)
+	require.Equal([]string{"gateway"}, names)
+	tx.Abort()
+
+	// Delete
+	require.NoError(s.DeleteConfigEntry(2, structs.TerminatingGateway, "gateway"))
+
+	idx, config, err = s.ConfigEntry(nil, structs.TerminatingGateway, "gateway")
+	require.NoError(err)
+	require.Equal(uint64(2), idx)
+	require.Nil(config)
+
+	// Set up a watch.
+	require.NoError(s.EnsureConfigEntry(3, expected))
+
+	ws := memdb.NewWatchSet()
+	_, _, err = s.ConfigEntry(ws, structs.TerminatingGateway, "gateway")
+	require.NoError(err)
+
+	// Make an unrelated modification and make sure the watch doesn't fire.
+	require.NoError(s.EnsureConfigEntry(4, &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "other",
+	}))
+	require.False(watchFired(ws))
+
+	// Now make a modification and make sure the watch fires.
+	require.NoError(s.EnsureConfigEntry(5, updated))
+	require.True(watchFired(ws))
+}
+
+func TestStore_ConfigEntriesByKind(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	gw1 := &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gw1",
+	}
+	gw2 := &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "gw2",
+	}
+	require.NoError(s.EnsureConfigEntry(1, gw1))
+	require.NoError(s.EnsureConfigEntry(2, gw2))
+
+	idx, entries, err := s.ConfigEntriesByKind(nil, structs.TerminatingGateway)
+	require.NoError(err)
+	require.Equal(uint64(2), idx)
+	require.Equal([]structs.ConfigEntry{gw1, gw2}, entries)
+
+	idx, entries, err = s.ConfigEntries(nil)
+	require.NoError(err)
+	require.Equal(uint64(2), idx)
+	require.Len(entries, 2)
+
+	// The kind index is exact.
+	_, entries, err = s.ConfigEntriesByKind(nil, "terminating")
+	require.NoError(err)
+	require.Empty(entries)
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -248,6 +249,45 @@ func (s *Store) kvsListTxn(tx *memdb.Txn, ws memdb.WatchSet, prefix string) (uin
 	return idx, ents, nil
 }
 
+// KVSListPrefixes is used to list the entries of several prefixes from a
+// single snapshot of the KV store. Prefixes covered by another prefix are
+// only read once, so the entries are unique and sorted by key. The returned
+// index is the highest index of the prefixes. No prefixes lists the whole
+// store.
+func (s *Store) KVSListPrefixes(ws memdb.WatchSet, prefixes []string) (uint64, structs.DirEntries, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	sorted := make([]string, len(prefixes))
+	copy(sorted, prefixes)
+	sort.Strings(sorted)
+
+	// After sorting, a prefix that covers others precedes them and the
+	// entries of the remaining prefixes follow each other in key order.
+	var idx uint64
+	var ents structs.DirEntries
+	var last string
+	for i, prefix := range sorted {
+		if i > 0 && strings.HasPrefix(prefix, last) {
+			continue
+		}
+		last = prefix
+
+		pidx, pents, err := s.kvsListTxn(tx, ws, prefix)
+		if err != nil {
+			return 0, nil, err
+		}
+		if pidx > idx {
+			idx = pidx
+		}
+		ents = append(ents, pents...)
+	}
+	return idx, ents, nil
+}
+
 // KVSListKeys is used to query the KV store for keys matching the given prefix.
 // An optional separator may be specified, which can be used to slice off a part
 // of the response so that only a subset of the prefix is returned. In this
@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStateStore_GC(t *testing.T) {
@@ -470,6 +471,56 @@ func TestStateStore_KVSList(t *testing.T) {
 	}
 }
 
+func TestStateStore_KVSListPrefixes(t *testing.T) {
+	s := testStateStore(t)
+
+	// Listing an empty KVS returns nothing
+	idx, entries, err := s.KVSListPrefixes(nil, []string{"foo/", "zip/"})
+	if idx != 0 || entries != nil || err != nil {
+		t.Fatalf("expected (0, nil, nil), got: (%d, %#v, %#v)", idx, entries, err)
+	}
+
+	testSetKey(t, s, 1, "foo/a", "a")
+	testSetKey(t, s, 2, "foo/b/c", "c")
+	testSetKey(t, s, 3, "bar/d", "d")
+	testSetKey(t, s, 4, "zip/e", "e")
+	testSetKey(t, s, 5, "zap/f", "f")
+
+	keys := func(entries structs.DirEntries) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Key)
+		}
+		return out
+	}
+
+	// Overlapping prefixes are read once and the entries are sorted.
+	ws := memdb.NewWatchSet()
+	idx, entries, err = s.KVSListPrefixes(ws, []string{"zip/", "foo/b/", "foo/"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), idx)
+	require.Equal(t, []string{"foo/a", "foo/b/c", "zip/e"}, keys(entries))
+
+	// Changes outside of the prefixes don't fire the watch.
+	testSetKey(t, s, 6, "bar/d", "d2")
+	require.False(t, watchFired(ws))
+
+	// Deleting a key in one of the prefixes does, and its tombstone
+	// raises the index.
+	require.NoError(t, s.KVSDelete(7, "foo/a"))
+	require.True(t, watchFired(ws))
+	idx, entries, err = s.KVSListPrefixes(nil, []string{"zip/", "foo/"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), idx)
+	require.Equal(t, []string{"foo/b/c", "zip/e"}, keys(entries))
+
+	// No prefixes lists everything.
+	idx, entries, err = s.KVSListPrefixes(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), idx)
+	require.Equal(t, []string{"bar/d", "foo/b/c", "zap/f", "zip/e"}, keys(entries))
+}
+
 func TestStateStore_KVSListKeys(t *testing.T) {
 	s := testStateStore(t)
 
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+// countingWriter counts the bytes written to it and discards them.
+type countingWriter struct {
+	n uint64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += uint64(len(p))
+	return len(p), nil
+}
+
+// Usage returns the approximate memory used by the tables of the state store
+// and their indexes, sorted by table name. The size of an object is estimated
+// from its msgpack encoding and the size of an index from the length of its
+// keys, so the numbers are meant to compare tables with each other rather than
+// to account for all the memory used by the server.
+func (s *Store) Usage() (uint64, []*structs.StateTableUsage, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	var names []string
+	for name := range s.schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tables []*structs.StateTableUsage
+	for _, name := range names {
+		table, err := tableUsage(tx, s.schema.Tables[name])
+		if err != nil {
+			return 0, nil, err
+		}
+		tables = append(tables, table)
+	}
+	return maxIndexTxn(tx, names...), tables, nil
+}
+
+// tableUsage walks all the objects of the given table to estimate its memory
+// usage.
+func tableUsage(tx *memdb.Txn, schema *memdb.TableSchema) (*structs.StateTableUsage, error) {
+	usage := &structs.StateTableUsage{Name: schema.Name}
+
+	var indexNames []string
+	for name := range schema.Indexes {
+		indexNames = append(indexNames, name)
+	}
+	sort.Strings(indexNames)
+	indexes := make(map[string]*structs.StateIndexUsage)
+	for _, name := range indexNames {
+		indexes[name] = &structs.StateIndexUsage{Name: name}
+		usage.Indexes = append(usage.Indexes, indexes[name])
+	}
+
+	iter, err := tx.Get(schema.Name, "id")
+	if err != nil {
+		return nil, fmt.Errorf("failed walking table %q: %s", schema.Name, err)
+	}
+	counter := &countingWriter{}
+	encoder := codec.NewEncoder(counter, &codec.MsgpackHandle{})
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		usage.Objects++
+		if err := encoder.Encode(raw); err != nil {
+			return nil, fmt.Errorf("failed encoding object in table %q: %s", schema.Name, err)
+		}
+
+		for name, index := range schema.Indexes {
+			entries, bytes, err := indexKeysSize(index, raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed indexing object in table %q: %s", schema.Name, err)
+			}
+			indexes[name].Entries += entries
+			indexes[name].Bytes += bytes
+		}
+	}
+	usage.Bytes = counter.n
+	return usage, nil
+}
+
+// indexKeysSize returns the number of keys the given index has for an object
+// and their total length.
+func indexKeysSize(index *memdb.IndexSchema, raw interface{}) (int, uint64, error) {
+	switch indexer := index.Indexer.(type) {
+	case memdb.SingleIndexer:
+		ok, key, err := indexer.FromObject(raw)
+		if err != nil || !ok {
+			return 0, 0, err
+		}
+		return 1, uint64(len(key)), nil
+
+	case memdb.MultiIndexer:
+		ok, keys, err := indexer.FromObject(raw)
+		if err != nil || !ok {
+			return 0, 0, err
+		}
+		var size uint64
+		for _, key := range keys {
+			size += uint64(len(key))
+		}
+		return len(keys), size, nil
+	}
+	return 0, 0, fmt.Errorf("index %q has an unsupported indexer %T", index.Name, index.Indexer)
+}
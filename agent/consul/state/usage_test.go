@@ -0,0 +1,40 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_Usage(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterNode(t, s, 2, "node2")
+	testSetKey(t, s, 3, "foo", "bar")
+
+	idx, tables, err := s.Usage()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), idx)
+
+	usage := make(map[string]*structs.StateTableUsage)
+	for _, table := range tables {
+		usage[table.Name] = table
+	}
+	require.Len(t, usage, len(s.schema.Tables))
+
+	nodes := usage["nodes"]
+	require.Equal(t, 2, nodes.Objects)
+	require.NotZero(t, nodes.Bytes)
+	for _, index := range nodes.Indexes {
+		if index.Name == "id" {
+			require.Equal(t, 2, index.Entries)
+			require.NotZero(t, index.Bytes)
+		}
+	}
+
+	require.Equal(t, 1, usage["kvs"].Objects)
+	require.Equal(t, 0, usage["sessions"].Objects)
+	require.Zero(t, usage["sessions"].Bytes)
+}
@@ -0,0 +1,294 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	serviceVirtualIPTableName = "services-virtual-ip"
+	freeVirtualIPTableName    = "free-virtual-ip"
+)
+
+var (
+	// virtualIPRange is the range virtual IPs are allocated from. It is
+	// reserved for future use so it can't clash with real addresses.
+	virtualIPRange = net.IPNet{
+		IP:   net.IPv4(240, 0, 0, 0).To4(),
+		Mask: net.CIDRMask(4, 32),
+	}
+
+	// ErrVirtualIPsExhausted is returned when every virtual IP in the range
+	// is assigned to a service.
+	ErrVirtualIPsExhausted = fmt.Errorf("No virtual IPs left to assign")
+)
+
+// FreeVirtualIP is a virtual IP that is available for a new service. The
+// last IP allocated from the range is stored with IsCounter set so that new
+// IPs are taken from the rest of the range once no freed IPs are left.
+type FreeVirtualIP struct {
+	IP        string
+	IsCounter bool
+}
+
+// serviceVirtualIPTableSchema returns a new table schema used to store the
+// virtual IPs assigned to services.
+func serviceVirtualIPTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: serviceVirtualIPTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Service",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+// freeVirtualIPTableSchema returns a new table schema used to track the
+// virtual IPs that can be assigned to new services.
+func freeVirtualIPTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: freeVirtualIPTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.ConditionalIndex{Conditional: isVirtualIPCounter},
+						&memdb.StringFieldIndex{Field: "IP"},
+					},
+				},
+			},
+			"counter": &memdb.IndexSchema{
+				Name:         "counter",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer:      &memdb.ConditionalIndex{Conditional: isVirtualIPCounter},
+			},
+		},
+	}
+}
+
+func isVirtualIPCounter(obj interface{}) (bool, error) {
+	ip, ok := obj.(*FreeVirtualIP)
+	if !ok {
+		return false, fmt.Errorf("Object must be FreeVirtualIP, got %T", obj)
+	}
+	return ip.IsCounter, nil
+}
+
+func init() {
+	registerSchema(serviceVirtualIPTableSchema)
+	registerSchema(freeVirtualIPTableSchema)
+}
+
+// ServiceVirtualIPs is used to pull the assigned virtual IPs for use during
+// snapshots.
+func (s *Snapshot) ServiceVirtualIPs() ([]*structs.ServiceVirtualIP, error) {
+	iter, err := s.tx.Get(serviceVirtualIPTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*structs.ServiceVirtualIP
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		ret = append(ret, wrapped.(*structs.ServiceVirtualIP))
+	}
+	return ret, nil
+}
+
+// FreeVirtualIPs is used to pull the free virtual IPs and the allocation
+// counter for use during snapshots.
+func (s *Snapshot) FreeVirtualIPs() ([]*FreeVirtualIP, error) {
+	iter, err := s.tx.Get(freeVirtualIPTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*FreeVirtualIP
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		ret = append(ret, wrapped.(*FreeVirtualIP))
+	}
+	return ret, nil
+}
+
+// ServiceVirtualIP is used when restoring from a snapshot.
+func (s *Restore) ServiceVirtualIP(vip *structs.ServiceVirtualIP) error {
+	if err := s.tx.Insert(serviceVirtualIPTableName, vip); err != nil {
+		return fmt.Errorf("failed restoring service virtual IP: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, vip.ModifyIndex, serviceVirtualIPTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// FreeVirtualIP is used when restoring from a snapshot.
+func (s *Restore) FreeVirtualIP(ip *FreeVirtualIP) error {
+	if err := s.tx.Insert(freeVirtualIPTableName, ip); err != nil {
+		return fmt.Errorf("failed restoring free virtual IP: %s", err)
+	}
+	return nil
+}
+
+// VirtualIPForService returns the virtual IP assigned to the given service,
+// or nil if it has none.
+func (s *Store) VirtualIPForService(ws memdb.WatchSet, service string) (uint64, *structs.ServiceVirtualIP, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, serviceVirtualIPTableName)
+
+	watchCh, vip, err := tx.FirstWatch(serviceVirtualIPTableName, "id", service)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed virtual IP lookup: %s", err)
+	}
+	ws.Add(watchCh)
+
+	if vip == nil {
+		return idx, nil, nil
+	}
+	return idx, vip.(*structs.ServiceVirtualIP), nil
+}
+
+// connectServiceName returns the name of the service that the given service
+// instance accepts Connect traffic for, or "" if it doesn't support Connect.
+func connectServiceName(sn *structs.ServiceNode) string {
+	switch {
+	case sn.ServiceKind == structs.ServiceKindConnectProxy:
+		return sn.ServiceProxy.DestinationServiceName
+	case sn.ServiceConnect.Native:
+		return sn.ServiceName
+	default:
+		return ""
+	}
+}
+
+// ensureServiceVirtualIPTxn assigns a virtual IP to the given service if it
+// doesn't have one yet. Freed IPs are reused before new ones are taken from
+// the range.
+func (s *Store) ensureServiceVirtualIPTxn(tx *memdb.Txn, idx uint64, service string) error {
+	existing, err := tx.First(serviceVirtualIPTableName, "id", service)
+	if err != nil {
+		return fmt.Errorf("failed virtual IP lookup: %s", err)
+	}
+	if existing != nil {
+		return nil
+	}
+
+	var ip string
+	free, err := tx.First(freeVirtualIPTableName, "counter", false)
+	if err != nil {
+		return fmt.Errorf("failed free virtual IP lookup: %s", err)
+	}
+	if free != nil {
+		if err := tx.Delete(freeVirtualIPTableName, free); err != nil {
+			return fmt.Errorf("failed deleting free virtual IP: %s", err)
+		}
+		ip = free.(*FreeVirtualIP).IP
+	} else {
+		counter, err := tx.First(freeVirtualIPTableName, "counter", true)
+		if err != nil {
+			return fmt.Errorf("failed virtual IP counter lookup: %s", err)
+		}
+
+		last := virtualIPRange.IP
+		if counter != nil {
+			last = net.ParseIP(counter.(*FreeVirtualIP).IP)
+			if err := tx.Delete(freeVirtualIPTableName, counter); err != nil {
+				return fmt.Errorf("failed updating virtual IP counter: %s", err)
+			}
+		}
+		next, err := nextVirtualIP(last)
+		if err != nil {
+			return err
+		}
+		ip = next.String()
+
+		if err := tx.Insert(freeVirtualIPTableName, &FreeVirtualIP{IP: ip, IsCounter: true}); err != nil {
+			return fmt.Errorf("failed updating virtual IP counter: %s", err)
+		}
+	}
+
+	vip := &structs.ServiceVirtualIP{
+		Service: service,
+		IP:      ip,
+		RaftIndex: structs.RaftIndex{
+			CreateIndex: idx,
+			ModifyIndex: idx,
+		},
+	}
+	if err := tx.Insert(serviceVirtualIPTableName, vip); err != nil {
+		return fmt.Errorf("failed inserting virtual IP: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{serviceVirtualIPTableName, idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// freeServiceVirtualIPTxn releases the virtual IP of the given service once
+// no instances are left that accept Connect traffic for it.
+func (s *Store) freeServiceVirtualIPTxn(tx *memdb.Txn, idx uint64, service string) error {
+	remaining, err := tx.First("services", "connect", service)
+	if err != nil {
+		return fmt.Errorf("failed service lookup: %s", err)
+	}
+	if remaining != nil {
+		return nil
+	}
+
+	existing, err := tx.First(serviceVirtualIPTableName, "id", service)
+	if err != nil {
+		return fmt.Errorf("failed virtual IP lookup: %s", err)
+	}
+	if existing == nil {
+		return nil
+	}
+
+	if err := tx.Delete(serviceVirtualIPTableName, existing); err != nil {
+		return fmt.Errorf("failed deleting virtual IP: %s", err)
+	}
+	ip := existing.(*structs.ServiceVirtualIP).IP
+	if err := tx.Insert(freeVirtualIPTableName, &FreeVirtualIP{IP: ip}); err != nil {
+		return fmt.Errorf("failed freeing virtual IP: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{serviceVirtualIPTableName, idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// nextVirtualIP returns the IP after the given one in the virtual IP range.
+func nextVirtualIP(ip net.IP) (net.IP, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, fmt.Errorf("invalid virtual IP %q", ip)
+	}
+
+	next := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(next, binary.BigEndian.Uint32(ip4)+1)
+
+	// Leave out the broadcast address at the end of the range.
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range broadcast {
+		broadcast[i] = virtualIPRange.IP[i] | ^virtualIPRange.Mask[i]
+	}
+	if !virtualIPRange.Contains(next) || next.Equal(broadcast) {
+		return nil, ErrVirtualIPsExhausted
+	}
+	return next, nil
+}
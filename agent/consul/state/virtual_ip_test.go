@@ -0,0 +1,152 @@
+package state
+
+import (
+	"net"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_VirtualIPForService(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// Querying with no results returns nil.
+	ws := memdb.NewWatchSet()
+	idx, vip, err := s.VirtualIPForService(ws, "db")
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Nil(vip)
+
+	// Services without Connect don't get a virtual IP.
+	testRegisterNode(t, s, 1, "foo")
+	testRegisterNode(t, s, 2, "bar")
+	require.NoError(s.EnsureService(3, "foo", &structs.NodeService{ID: "db", Service: "db", Port: 5000}))
+	require.False(watchFired(ws))
+
+	// A proxy assigns one to its destination.
+	require.NoError(s.EnsureService(4, "foo", &structs.NodeService{Kind: structs.ServiceKindConnectProxy, ID: "db-proxy", Service: "db-proxy", Proxy: structs.ConnectProxyConfig{DestinationServiceName: "db"}, Port: 8000}))
+	require.True(watchFired(ws))
+
+	ws = memdb.NewWatchSet()
+	idx, vip, err = s.VirtualIPForService(ws, "db")
+	require.NoError(err)
+	require.Equal(uint64(4), idx)
+	require.Equal("240.0.0.1", vip.IP)
+
+	// More instances keep the same IP.
+	require.NoError(s.EnsureService(5, "bar", &structs.NodeService{Kind: structs.ServiceKindConnectProxy, ID: "db-proxy", Service: "db-proxy", Proxy: structs.ConnectProxyConfig{DestinationServiceName: "db"}, Port: 8000}))
+	require.NoError(s.EnsureService(6, "bar", &structs.NodeService{ID: "native-db", Service: "db", Connect: structs.ServiceConnect{Native: true}}))
+	require.False(watchFired(ws))
+
+	// Native services get their own.
+	require.NoError(s.EnsureService(7, "bar", &structs.NodeService{ID: "api", Service: "api", Connect: structs.ServiceConnect{Native: true}}))
+	_, vip, err = s.VirtualIPForService(nil, "api")
+	require.NoError(err)
+	require.Equal("240.0.0.2", vip.IP)
+
+	// The IP is kept until the last instance supporting Connect is gone.
+	require.NoError(s.DeleteService(8, "foo", "db-proxy"))
+	require.NoError(s.DeleteNode(9, "bar"))
+	require.True(watchFired(ws))
+
+	idx, vip, err = s.VirtualIPForService(nil, "db")
+	require.NoError(err)
+	require.Equal(uint64(9), idx)
+	require.Nil(vip)
+
+	// Freed IPs are reused before the range. The IP of "api" was freed along
+	// with the "bar" node.
+	require.NoError(s.EnsureService(10, "foo", &structs.NodeService{ID: "web", Service: "web", Connect: structs.ServiceConnect{Native: true}}))
+	require.NoError(s.EnsureService(11, "foo", &structs.NodeService{ID: "cache", Service: "cache", Connect: structs.ServiceConnect{Native: true}}))
+	require.NoError(s.EnsureService(12, "foo", &structs.NodeService{ID: "api", Service: "api", Connect: structs.ServiceConnect{Native: true}}))
+
+	_, vip, err = s.VirtualIPForService(nil, "web")
+	require.NoError(err)
+	require.Equal("240.0.0.1", vip.IP)
+	_, vip, err = s.VirtualIPForService(nil, "cache")
+	require.NoError(err)
+	require.Equal("240.0.0.2", vip.IP)
+	_, vip, err = s.VirtualIPForService(nil, "api")
+	require.NoError(err)
+	require.Equal("240.0.0.3", vip.IP)
+
+	// A proxy changing its destination frees the old IP.
+	require.NoError(s.EnsureService(13, "foo", &structs.NodeService{Kind: structs.ServiceKindConnectProxy, ID: "proxy", Service: "proxy", Proxy: structs.ConnectProxyConfig{DestinationServiceName: "db"}, Port: 8000}))
+	require.NoError(s.EnsureService(14, "foo", &structs.NodeService{Kind: structs.ServiceKindConnectProxy, ID: "proxy", Service: "proxy", Proxy: structs.ConnectProxyConfig{DestinationServiceName: "redis"}, Port: 8000}))
+
+	_, vip, err = s.VirtualIPForService(nil, "db")
+	require.NoError(err)
+	require.Nil(vip)
+	_, vip, err = s.VirtualIPForService(nil, "redis")
+	require.NoError(err)
+	require.NotNil(vip)
+}
+
+func TestStateStore_VirtualIP_Snapshot_Restore(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "foo")
+	require.NoError(s.EnsureService(2, "foo", &structs.NodeService{ID: "web", Service: "web", Connect: structs.ServiceConnect{Native: true}}))
+	require.NoError(s.EnsureService(3, "foo", &structs.NodeService{ID: "api", Service: "api", Connect: structs.ServiceConnect{Native: true}}))
+	require.NoError(s.DeleteService(4, "foo", "web"))
+
+	// Snapshot the virtual IPs.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	vips, err := snap.ServiceVirtualIPs()
+	require.NoError(err)
+	require.Len(vips, 1)
+	require.Equal("api", vips[0].Service)
+
+	free, err := snap.FreeVirtualIPs()
+	require.NoError(err)
+	require.Equal([]*FreeVirtualIP{
+		{IP: "240.0.0.1"},
+		{IP: "240.0.0.2", IsCounter: true},
+	}, free)
+
+	// Restore the values into a new state store.
+	s2 := testStateStore(t)
+	restore := s2.Restore()
+	for _, vip := range vips {
+		require.NoError(restore.ServiceVirtualIP(vip))
+	}
+	for _, ip := range free {
+		require.NoError(restore.FreeVirtualIP(ip))
+	}
+	restore.Commit()
+
+	idx, vip, err := s2.VirtualIPForService(nil, "api")
+	require.NoError(err)
+	require.Equal(uint64(3), idx)
+	require.Equal("240.0.0.2", vip.IP)
+
+	// Allocation continues where it left off.
+	testRegisterNode(t, s2, 5, "foo")
+	require.NoError(s2.EnsureService(6, "foo", &structs.NodeService{ID: "db", Service: "db", Connect: structs.ServiceConnect{Native: true}}))
+	require.NoError(s2.EnsureService(7, "foo", &structs.NodeService{ID: "cache", Service: "cache", Connect: structs.ServiceConnect{Native: true}}))
+
+	_, vip, err = s2.VirtualIPForService(nil, "db")
+	require.NoError(err)
+	require.Equal("240.0.0.1", vip.IP)
+	_, vip, err = s2.VirtualIPForService(nil, "cache")
+	require.NoError(err)
+	require.Equal("240.0.0.3", vip.IP)
+}
+
+func TestNextVirtualIP(t *testing.T) {
+	ip, err := nextVirtualIP(net.ParseIP("240.0.0.255"))
+	require.NoError(t, err)
+	require.Equal(t, "240.0.1.0", ip.String())
+
+	_, err = nextVirtualIP(net.ParseIP("255.255.255.253"))
+	require.NoError(t, err)
+
+	_, err = nextVirtualIP(net.ParseIP("255.255.255.254"))
+	require.Equal(t, ErrVirtualIPsExhausted, err)
+}
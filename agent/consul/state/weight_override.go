@@ -0,0 +1,193 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const serviceWeightOverridesTableName = "service-weight-overrides"
+
+// serviceWeightOverridesTableSchema returns a new table schema used to store
+// the weight overrides of service instances.
+func serviceWeightOverridesTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: serviceWeightOverridesTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field:     "Node",
+							Lowercase: true,
+						},
+						&memdb.StringFieldIndex{
+							Field:     "ServiceID",
+							Lowercase: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(serviceWeightOverridesTableSchema)
+}
+
+// ServiceWeightOverrides is used to pull the weight overrides for use during
+// snapshots.
+func (s *Snapshot) ServiceWeightOverrides() (structs.ServiceWeightOverrides, error) {
+	iter, err := s.tx.Get(serviceWeightOverridesTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret structs.ServiceWeightOverrides
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		ret = append(ret, wrapped.(*structs.ServiceWeightOverride))
+	}
+	return ret, nil
+}
+
+// ServiceWeightOverride is used when restoring from a snapshot.
+func (s *Restore) ServiceWeightOverride(override *structs.ServiceWeightOverride) error {
+	if err := s.tx.Insert(serviceWeightOverridesTableName, override); err != nil {
+		return fmt.Errorf("failed restoring service weight override: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, override.ModifyIndex, serviceWeightOverridesTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// ServiceWeightOverrides returns all the weight overrides of service
+// instances.
+func (s *Store) ServiceWeightOverrides(ws memdb.WatchSet) (uint64, structs.ServiceWeightOverrides, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, serviceWeightOverridesTableName)
+
+	iter, err := tx.Get(serviceWeightOverridesTableName, "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed weight override lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var results structs.ServiceWeightOverrides
+	for override := iter.Next(); override != nil; override = iter.Next() {
+		results = append(results, override.(*structs.ServiceWeightOverride))
+	}
+	return idx, results, nil
+}
+
+// ServiceWeightOverrideSet sets the weight override of a registered service
+// instance.
+func (s *Store) ServiceWeightOverrideSet(idx uint64, override *structs.ServiceWeightOverride) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	service, err := tx.First("services", "id", override.Node, override.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed service lookup: %s", err)
+	}
+	if service == nil {
+		return fmt.Errorf("Unknown service %q on node %q", override.ServiceID, override.Node)
+	}
+
+	existing, err := tx.First(serviceWeightOverridesTableName, "id", override.Node, override.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed weight override lookup: %s", err)
+	}
+
+	// Store the names the instance is registered with.
+	sn := service.(*structs.ServiceNode)
+	entry := &structs.ServiceWeightOverride{
+		Node:      sn.Node,
+		ServiceID: sn.ServiceID,
+		Service:   sn.ServiceName,
+		Weights:   override.Weights,
+		RaftIndex: structs.RaftIndex{
+			CreateIndex: idx,
+			ModifyIndex: idx,
+		},
+	}
+	if existing != nil {
+		entry.CreateIndex = existing.(*structs.ServiceWeightOverride).CreateIndex
+	}
+	if err := tx.Insert(serviceWeightOverridesTableName, entry); err != nil {
+		return fmt.Errorf("failed inserting weight override: %s", err)
+	}
+	if err := s.updateWeightOverrideIndexesTxn(tx, idx, sn.ServiceName); err != nil {
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// ServiceWeightOverrideDelete removes the weight override of a service
+// instance, restoring its registered weights.
+func (s *Store) ServiceWeightOverrideDelete(idx uint64, node, serviceID string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	if err := s.deleteServiceWeightOverrideTxn(tx, idx, node, serviceID); err != nil {
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// deleteServiceWeightOverrideTxn removes the weight override of a service
+// instance, if it has one, within an existing transaction.
+func (s *Store) deleteServiceWeightOverrideTxn(tx *memdb.Txn, idx uint64, node, serviceID string) error {
+	existing, err := tx.First(serviceWeightOverridesTableName, "id", node, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed weight override lookup: %s", err)
+	}
+	if existing == nil {
+		return nil
+	}
+	if err := tx.Delete(serviceWeightOverridesTableName, existing); err != nil {
+		return fmt.Errorf("failed deleting weight override: %s", err)
+	}
+
+	return s.updateWeightOverrideIndexesTxn(tx, idx, existing.(*structs.ServiceWeightOverride).Service)
+}
+
+// updateWeightOverrideIndexesTxn bumps the index of the overrides table and,
+// so that blocking health queries return the new weights, the index of the
+// service.
+func (s *Store) updateWeightOverrideIndexesTxn(tx *memdb.Txn, idx uint64, serviceName string) error {
+	if err := tx.Insert("index", &IndexEntry{serviceWeightOverridesTableName, idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{serviceIndexName(serviceName), idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// applyWeightOverrideTxn replaces the weights of the given instance with its
+// override, if it has one. The service must be a copy that can be modified.
+func applyWeightOverrideTxn(tx *memdb.Txn, node string, service *structs.NodeService) error {
+	override, err := tx.First(serviceWeightOverridesTableName, "id", node, service.ID)
+	if err != nil {
+		return fmt.Errorf("failed weight override lookup: %s", err)
+	}
+	if override == nil {
+		return nil
+	}
+	weights := override.(*structs.ServiceWeightOverride).Weights
+	service.Weights = &weights
+	return nil
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_ServiceWeightOverrides(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "foo")
+	require.NoError(s.EnsureService(2, "foo", &structs.NodeService{ID: "web1", Service: "web", Port: 80, Weights: &structs.Weights{Passing: 3, Warning: 1}}))
+	require.NoError(s.EnsureService(3, "foo", &structs.NodeService{ID: "web2", Service: "web", Port: 81}))
+
+	// Overrides can only be set on registered instances.
+	err := s.ServiceWeightOverrideSet(4, &structs.ServiceWeightOverride{Node: "foo", ServiceID: "nope"})
+	require.Error(err)
+
+	// Setting an override fires the watches of health queries and bumps the
+	// index of the service.
+	ws := memdb.NewWatchSet()
+	_, nodes, err := s.CheckServiceNodes(ws, "web")
+	require.NoError(err)
+	require.Len(nodes, 2)
+
+	require.NoError(s.ServiceWeightOverrideSet(5, &structs.ServiceWeightOverride{
+		Node:      "FOO",
+		ServiceID: "web1",
+		Weights:   structs.Weights{Passing: 0, Warning: 0},
+	}))
+	require.True(watchFired(ws))
+
+	idx, nodes, err := s.CheckServiceNodes(nil, "web")
+	require.NoError(err)
+	require.Equal(uint64(5), idx)
+	require.Equal("web1", nodes[0].Service.ID)
+	require.Equal(&structs.Weights{Passing: 0, Warning: 0}, nodes[0].Service.Weights)
+	require.True(nodes[0].Service.Drained())
+	require.False(nodes[1].Service.Drained())
+
+	// The registration itself is unchanged.
+	_, services, err := s.ServiceNodes(nil, "web")
+	require.NoError(err)
+	require.Equal(structs.Weights{Passing: 3, Warning: 1}, services[0].ServiceWeights)
+
+	// The override is stored with the registered names.
+	ws = memdb.NewWatchSet()
+	idx, overrides, err := s.ServiceWeightOverrides(ws)
+	require.NoError(err)
+	require.Equal(uint64(5), idx)
+	require.Equal(structs.ServiceWeightOverrides{{
+		Node:      "foo",
+		ServiceID: "web1",
+		Service:   "web",
+		RaftIndex: structs.RaftIndex{CreateIndex: 5, ModifyIndex: 5},
+	}}, overrides)
+
+	// Updating it keeps the create index.
+	require.NoError(s.ServiceWeightOverrideSet(6, &structs.ServiceWeightOverride{
+		Node:      "foo",
+		ServiceID: "web1",
+		Weights:   structs.Weights{Passing: 1, Warning: 0},
+	}))
+	require.True(watchFired(ws))
+	_, overrides, err = s.ServiceWeightOverrides(nil)
+	require.NoError(err)
+	require.Equal(structs.RaftIndex{CreateIndex: 5, ModifyIndex: 6}, overrides[0].RaftIndex)
+
+	// Deleting it restores the registered weights.
+	require.NoError(s.ServiceWeightOverrideDelete(7, "foo", "web1"))
+	idx, nodes, err = s.CheckServiceNodes(nil, "web")
+	require.NoError(err)
+	require.Equal(uint64(7), idx)
+	require.Equal(&structs.Weights{Passing: 3, Warning: 1}, nodes[0].Service.Weights)
+
+	// Deregistering an instance removes its override.
+	require.NoError(s.ServiceWeightOverrideSet(8, &structs.ServiceWeightOverride{Node: "foo", ServiceID: "web2"}))
+	require.NoError(s.DeleteService(9, "foo", "web2"))
+	idx, overrides, err = s.ServiceWeightOverrides(nil)
+	require.NoError(err)
+	require.Equal(uint64(9), idx)
+	require.Len(overrides, 0)
+}
@@ -41,6 +41,19 @@ func (s *Status) Peers(args struct{}, reply *[]string) error {
 	return nil
 }
 
+// ClusterID is used to get the ID of the cluster. It is empty until the
+// first leader is elected.
+func (s *Status) ClusterID(args struct{}, reply *string) error {
+	identity, err := s.server.fsm.State().ClusterIdentity(nil)
+	if err != nil {
+		return err
+	}
+	if identity != nil {
+		*reply = identity.ID
+	}
+	return nil
+}
+
 // Used by Autopilot to query the raft stats of the local server.
 func (s *Status) RaftStats(args struct{}, reply *autopilot.ServerStats) error {
 	stats := s.server.raft.Stats()
@@ -37,6 +37,23 @@ func (t *Txn) preCheck(authorizer acl.Authorizer, ops structs.TxnOps) structs.Tx
 					What:    err.Error(),
 				})
 			}
+		case op.Intention != nil:
+			// Intentions are replicated from the primary datacenter so
+			// writes anywhere else would be overwritten.
+			if t.srv.intentionReplicationEnabled() {
+				errors = append(errors, &structs.TxnError{
+					OpIndex: i,
+					What:    "intentions can only be modified in the primary datacenter",
+				})
+				break
+			}
+
+			if err := intentionPreApply(t.srv, authorizer, (*structs.IntentionRequest)(op.Intention)); err != nil {
+				errors = append(errors, &structs.TxnError{
+					OpIndex: i,
+					What:    err.Error(),
+				})
+			}
 		case op.Node != nil:
 			// Skip the pre-apply checks if this is a GET.
 			if op.Node.Verb == api.NodeGet {
@@ -109,6 +126,12 @@ func (t *Txn) Apply(args *structs.TxnRequest, reply *structs.TxnResponse) error
 	}
 	defer metrics.MeasureSince([]string{"txn", "apply"}, time.Now())
 
+	release, err := t.srv.acquireQuerySlot(queryClassTxn)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Run the pre-checks before we send the transaction into Raft.
 	authorizer, err := t.srv.ResolveToken(args.Token)
 	if err != nil {
@@ -119,6 +142,19 @@ func (t *Txn) Apply(args *structs.TxnRequest, reply *structs.TxnResponse) error
 		return nil
 	}
 
+	// Record who changed intentions in the audit trail.
+	var audit *structs.AuditInfo
+	for _, op := range args.Ops {
+		if op.Intention == nil {
+			continue
+		}
+		if audit == nil {
+			info := t.srv.auditInfo(args.Token)
+			audit = &info
+		}
+		op.Intention.Audit = *audit
+	}
+
 	// Apply the update.
 	resp, err := t.srv.raftApply(structs.TxnRequestType, args)
 	if err != nil {
@@ -152,6 +188,12 @@ func (t *Txn) Read(args *structs.TxnReadRequest, reply *structs.TxnReadResponse)
 	}
 	defer metrics.MeasureSince([]string{"txn", "read"}, time.Now())
 
+	release, err := t.srv.acquireQuerySlot(queryClassTxn)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// We have to do this ourselves since we are not doing a blocking RPC.
 	t.srv.setQueryMeta(&reply.QueryMeta)
 	if args.RequireConsistent {
@@ -170,6 +212,19 @@ func (t *Txn) Read(args *structs.TxnReadRequest, reply *structs.TxnReadResponse)
 		return nil
 	}
 
+	// Record who changed intentions in the audit trail.
+	var audit *structs.AuditInfo
+	for _, op := range args.Ops {
+		if op.Intention == nil {
+			continue
+		}
+		if audit == nil {
+			info := t.srv.auditInfo(args.Token)
+			audit = &info
+		}
+		op.Intention.Audit = *audit
+	}
+
 	// Run the read transaction.
 	state := t.srv.fsm.State()
 	reply.Results, reply.Errors = state.TxnRO(args.Ops)
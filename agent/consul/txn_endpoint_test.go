@@ -612,6 +612,91 @@ func TestTxn_Apply_ACLDeny(t *testing.T) {
 	verify.Values(t, "", out, expected)
 }
 
+func TestTxn_Apply_Intentions(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create two intentions in one transaction.
+	arg := structs.TxnRequest{
+		Datacenter: "dc1",
+		Ops: structs.TxnOps{
+			&structs.TxnOp{
+				Intention: &structs.TxnIntentionOp{
+					Op: structs.IntentionOpCreate,
+					Intention: &structs.Intention{
+						SourceName:      "web",
+						DestinationName: "db",
+						Action:          structs.IntentionActionAllow,
+					},
+				},
+			},
+			&structs.TxnOp{
+				Intention: &structs.TxnIntentionOp{
+					Op: structs.IntentionOpCreate,
+					Intention: &structs.Intention{
+						SourceName:      "*",
+						DestinationName: "db",
+						Action:          structs.IntentionActionDeny,
+					},
+				},
+			},
+		},
+	}
+	var out structs.TxnResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Txn.Apply", &arg, &out))
+	require.Empty(out.Errors)
+
+	state := s1.fsm.State()
+	_, ixns, err := state.Intentions(nil)
+	require.NoError(err)
+	require.Len(ixns, 2)
+	for _, ixn := range ixns {
+		require.NotEmpty(ixn.ID)
+		require.Equal(structs.IntentionDefaultNamespace, ixn.SourceNS)
+		require.NotZero(ixn.Precedence)
+	}
+
+	// An invalid intention fails the whole transaction.
+	arg.Ops = structs.TxnOps{
+		&structs.TxnOp{
+			Intention: &structs.TxnIntentionOp{
+				Op: structs.IntentionOpDelete,
+				Intention: &structs.Intention{
+					ID: ixns[0].ID,
+				},
+			},
+		},
+		&structs.TxnOp{
+			Intention: &structs.TxnIntentionOp{
+				Op: structs.IntentionOpUpdate,
+				Intention: &structs.Intention{
+					ID:              "2f6aa8a4-1c33-4ba8-8d47-c4ab7a7a0e6f",
+					SourceName:      "web",
+					DestinationName: "api",
+					Action:          structs.IntentionActionAllow,
+				},
+			},
+		},
+	}
+	out = structs.TxnResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Txn.Apply", &arg, &out))
+	require.Len(out.Errors, 1)
+	require.Equal(1, out.Errors[0].OpIndex)
+	require.Contains(out.Errors[0].What, "non-existent intention")
+
+	_, ixns, err = state.Intentions(nil)
+	require.NoError(err)
+	require.Len(ixns, 2)
+}
+
 func TestTxn_Apply_LockDelay(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -0,0 +1,92 @@
+package consul
+
+import (
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/hashicorp/serf/serf"
+)
+
+// wanAddrTagPrefix is the prefix of the Serf tags holding the WAN address a
+// server advertises to the servers of a given datacenter.
+const wanAddrTagPrefix = "wan_addr_"
+
+// wanTransport is the memberlist transport of the WAN pool. It wraps the
+// default network transport, sending the packets and streams meant for
+// servers that advertise a different WAN address for our datacenter, such
+// as the public address of a NAT, to that address instead.
+type wanTransport struct {
+	*memberlist.NetTransport
+
+	// dc is the datacenter of this server.
+	dc string
+
+	// addrs maps the advertised "ip:port" of remote servers to the
+	// "ip:port" they advertise for our datacenter.
+	addrs     map[string]string
+	addrsLock sync.RWMutex
+}
+
+// newWANTransport creates the network transport for the given memberlist
+// configuration, like memberlist does by default, and wraps it.
+func newWANTransport(conf *memberlist.Config, logger *log.Logger, dc string) (*wanTransport, error) {
+	nt, err := memberlist.NewNetTransport(&memberlist.NetTransportConfig{
+		BindAddrs: []string{conf.BindAddr},
+		BindPort:  conf.BindPort,
+		Logger:    logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if conf.BindPort == 0 {
+		port := nt.GetAutoBindPort()
+		conf.BindPort = port
+		conf.AdvertisePort = port
+	}
+
+	return &wanTransport{
+		NetTransport: nt,
+		dc:           dc,
+		addrs:        make(map[string]string),
+	}, nil
+}
+
+// update records the WAN address the given member advertises for our
+// datacenter, if any.
+func (t *wanTransport) update(m *serf.Member) {
+	port := strconv.Itoa(int(m.Port))
+	advertised := net.JoinHostPort(m.Addr.String(), port)
+
+	t.addrsLock.Lock()
+	defer t.addrsLock.Unlock()
+	if ip := net.ParseIP(m.Tags[wanAddrTagPrefix+t.dc]); ip != nil {
+		t.addrs[advertised] = net.JoinHostPort(ip.String(), port)
+	} else {
+		delete(t.addrs, advertised)
+	}
+}
+
+// translate returns the address to use to reach the given advertised
+// address.
+func (t *wanTransport) translate(addr string) string {
+	t.addrsLock.RLock()
+	defer t.addrsLock.RUnlock()
+	if translated, ok := t.addrs[addr]; ok {
+		return translated
+	}
+	return addr
+}
+
+// WriteTo sends a packet to the translated address.
+func (t *wanTransport) WriteTo(b []byte, addr string) (time.Time, error) {
+	return t.NetTransport.WriteTo(b, t.translate(addr))
+}
+
+// DialTimeout opens a stream to the translated address.
+func (t *wanTransport) DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	return t.NetTransport.DialTimeout(t.translate(addr), timeout)
+}
@@ -0,0 +1,82 @@
+package consul
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWANTransport_Translate(t *testing.T) {
+	t.Parallel()
+	tr := &wanTransport{dc: "dc1", addrs: make(map[string]string)}
+
+	m := &serf.Member{
+		Addr: net.ParseIP("10.0.0.1"),
+		Port: 8302,
+		Tags: map[string]string{"wan_addr_dc1": "203.0.113.1", "wan_addr_dc3": "203.0.113.3"},
+	}
+	tr.update(m)
+	require.Equal(t, "203.0.113.1:8302", tr.translate("10.0.0.1:8302"))
+	require.Equal(t, "10.0.0.2:8302", tr.translate("10.0.0.2:8302"))
+
+	// Dropping the tag removes the translation.
+	delete(m.Tags, "wan_addr_dc1")
+	tr.update(m)
+	require.Equal(t, "10.0.0.1:8302", tr.translate("10.0.0.1:8302"))
+}
+
+func TestServer_AdvertiseAddrWANMap(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	// The second server advertises an unreachable WAN address to every
+	// datacenter but dc1, which reaches it on the loopback address.
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Datacenter = "dc2"
+		c.SerfWANConfig.MemberlistConfig.AdvertiseAddr = "192.0.2.1"
+		c.AdvertiseAddrWANMap = map[string]string{"dc1": "127.0.0.1"}
+	})
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s2.RPC, "dc2")
+
+	if _, err := s2.JoinWAN([]string{joinAddrWAN(s1)}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	retry.Run(t, func(r *retry.R) {
+		if got, want := len(s1.WANMembers()), 2; got != want {
+			r.Fatalf("got %d WAN members want %d", got, want)
+		}
+	})
+
+	// RPCs from dc1 are sent to the translated address.
+	retry.Run(t, func(r *retry.R) {
+		args := structs.DCSpecificRequest{Datacenter: "dc2"}
+		var out structs.IndexedNodes
+		if err := s1.RPC("Catalog.ListNodes", &args, &out); err != nil {
+			r.Fatalf("err: %v", err)
+		}
+	})
+
+	// The gossip is sent to the translated address too, while the member
+	// keeps its advertised address.
+	advertised := net.JoinHostPort("192.0.2.1", strconv.Itoa(s2.config.SerfWANConfig.MemberlistConfig.BindPort))
+	transport := s1.config.SerfWANConfig.MemberlistConfig.Transport.(*wanTransport)
+	require.Equal(t, joinAddrWAN(s2), transport.translate(advertised))
+	for _, m := range s1.WANMembers() {
+		require.Equal(t, serf.StatusAlive, m.Status, m.Name)
+		if m.Name == s2.config.NodeName+".dc2" {
+			require.Equal(t, "192.0.2.1", m.Addr.String())
+		}
+	}
+}
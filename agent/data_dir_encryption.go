@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/consul/lib/file"
+)
+
+// dataDirEncryptionPrefix is prepended to the files encrypted with the data
+// dir encryption key.
+var dataDirEncryptionPrefix = []byte("consul-aes-gcm:")
+
+// dataDirEncryptionMarker is the name of the file in the data dir that
+// records that the files written before the encryption was enabled have been
+// encrypted. Once it exists, unencrypted files are rejected.
+const dataDirEncryptionMarker = "data-dir-encrypted"
+
+// dataDirCipher encrypts the sensitive state the agent persists in its data
+// dir, such as the ACL tokens and the definitions of services, checks and
+// proxies. A nil *dataDirCipher doesn't encrypt anything.
+type dataDirCipher struct {
+	aead    cipher.AEAD
+	dataDir string
+}
+
+// newDataDirCipher returns a dataDirCipher for the files in the given data
+// dir that uses the base64 encoded AES key in the given file, or nil if no
+// file is given.
+func newDataDirCipher(keyFile, dataDir string) (*dataDirCipher, error) {
+	if keyFile == "" {
+		return nil, nil
+	}
+
+	raw, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading data dir encryption key file %q: %s", keyFile, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("Failed decoding data dir encryption key file %q: %s", keyFile, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid data dir encryption key in %q: %s", keyFile, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &dataDirCipher{aead: aead, dataDir: dataDir}, nil
+}
+
+// seal encrypts the contents of the file at the given path.
+func (c *dataDirCipher) seal(path string, plaintext []byte) ([]byte, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed generating nonce: %s", err)
+	}
+
+	out := make([]byte, 0, len(dataDirEncryptionPrefix)+len(nonce)+len(plaintext)+c.aead.Overhead())
+	out = append(out, dataDirEncryptionPrefix...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plaintext, c.additionalData(path)), nil
+}
+
+// open decrypts the contents of a file written by seal to the same path.
+// Unencrypted files are only accepted if no key is configured.
+func (c *dataDirCipher) open(path string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, dataDirEncryptionPrefix) {
+		if c != nil {
+			return nil, fmt.Errorf("file is not encrypted, but data_dir_encryption_key_file is configured")
+		}
+		return data, nil
+	}
+	if c == nil {
+		return nil, fmt.Errorf("file is encrypted, but no data_dir_encryption_key_file is configured")
+	}
+
+	data = data[len(dataDirEncryptionPrefix):]
+	if len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted file is truncated")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, c.additionalData(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed decrypting file: %s", err)
+	}
+	return plaintext, nil
+}
+
+// additionalData returns the path of a file relative to the data dir, which is
+// authenticated along with its contents so encrypted files can't be swapped
+// with each other.
+func (c *dataDirCipher) additionalData(path string) []byte {
+	if rel, err := filepath.Rel(c.dataDir, path); err == nil {
+		path = rel
+	}
+	return []byte(filepath.ToSlash(path))
+}
+
+// migrate encrypts the files in the data dir that were written before the
+// encryption was enabled, so that enabling it doesn't lose the persisted
+// state. This is only done once, recorded by the marker file, so unencrypted
+// files that show up later are rejected instead of being trusted.
+func (c *dataDirCipher) migrate(dataDir string, logger *log.Logger) error {
+	marker := filepath.Join(dataDir, dataDirEncryptionMarker)
+	if c == nil {
+		// Files are written unencrypted from now on, so they need to be
+		// migrated again if the encryption is re-enabled.
+		if err := os.Remove(marker); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed removing %q: %s", marker, err)
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed reading %q: %s", marker, err)
+	}
+
+	paths := []string{filepath.Join(dataDir, tokensPath)}
+	for _, name := range []string{servicesDir, proxyDir, checksDir, checkStateDir} {
+		dir := filepath.Join(dataDir, name)
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed reading dir %q: %s", dir, err)
+		}
+		for _, fi := range files {
+			// Skip dirs and partially written temporary files
+			if fi.IsDir() || strings.HasSuffix(fi.Name(), "tmp") {
+				continue
+			}
+			paths = append(paths, filepath.Join(dir, fi.Name()))
+		}
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed reading file %q: %s", path, err)
+		}
+		if bytes.HasPrefix(data, dataDirEncryptionPrefix) {
+			continue
+		}
+
+		sealed, err := c.seal(path, data)
+		if err != nil {
+			return fmt.Errorf("failed encrypting file %q: %s", path, err)
+		}
+		if err := file.WriteAtomic(path, sealed); err != nil {
+			return fmt.Errorf("failed writing file %q: %s", path, err)
+		}
+		logger.Printf("[INFO] agent: Encrypted data dir file %q", path)
+	}
+
+	return file.WriteAtomic(marker, nil)
+}
@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDataDirKey(t *testing.T, dir string) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "data-dir.key")
+	require.NoError(t, ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600))
+	return path
+}
+
+func TestDataDirCipher(t *testing.T) {
+	t.Parallel()
+	dir := testutil.TempDir(t, "agent")
+	defer os.RemoveAll(dir)
+
+	c, err := newDataDirCipher(writeDataDirKey(t, dir), dir)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, servicesDir, "a")
+	plaintext := []byte(`{"Token":"secret"}`)
+	sealed, err := c.seal(path, plaintext)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(sealed, dataDirEncryptionPrefix))
+	require.NotContains(t, string(sealed), "secret")
+
+	opened, err := c.open(path, sealed)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+
+	// Encrypted files can't be moved to another path.
+	_, err = c.open(filepath.Join(dir, servicesDir, "b"), sealed)
+	require.Error(t, err)
+
+	// Unencrypted files are rejected when a key is configured.
+	_, err = c.open(path, plaintext)
+	require.Error(t, err)
+
+	// Encrypted files can't be read without the key or with another one.
+	var none *dataDirCipher
+	_, err = none.open(path, sealed)
+	require.Error(t, err)
+
+	other, err := newDataDirCipher(writeDataDirKey(t, dir), dir)
+	require.NoError(t, err)
+	_, err = other.open(path, sealed)
+	require.Error(t, err)
+
+	// Without a key nothing is encrypted.
+	sealed, err = none.seal(path, plaintext)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, sealed)
+	opened, err = none.open(path, plaintext)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+}
+
+func TestDataDirCipher_Migrate(t *testing.T) {
+	t.Parallel()
+	dir := testutil.TempDir(t, "agent")
+	defer os.RemoveAll(dir)
+	logger := log.New(ioutil.Discard, "", 0)
+
+	// Files written before the encryption was enabled.
+	plaintext := []byte(`{"Token":"secret"}`)
+	svcPath := filepath.Join(dir, servicesDir, "a")
+	statePath := filepath.Join(dir, checkStateDir, "b")
+	tokensFullPath := filepath.Join(dir, tokensPath)
+	for _, path := range []string{svcPath, statePath, tokensFullPath} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, plaintext, 0600))
+	}
+
+	c, err := newDataDirCipher(writeDataDirKey(t, dir), dir)
+	require.NoError(t, err)
+	require.NoError(t, c.migrate(dir, logger))
+
+	for _, path := range []string{svcPath, statePath, tokensFullPath} {
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		opened, err := c.open(path, content)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, opened)
+	}
+
+	// Unencrypted files written after the migration aren't migrated, and
+	// are rejected when read.
+	require.NoError(t, ioutil.WriteFile(svcPath, plaintext, 0600))
+	require.NoError(t, c.migrate(dir, logger))
+	content, err := ioutil.ReadFile(svcPath)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, content)
+	_, err = c.open(svcPath, content)
+	require.Error(t, err)
+
+	// Disabling the encryption allows migrating again later.
+	var none *dataDirCipher
+	require.NoError(t, none.migrate(dir, logger))
+	require.NoError(t, c.migrate(dir, logger))
+	content, err = ioutil.ReadFile(svcPath)
+	require.NoError(t, err)
+	_, err = c.open(svcPath, content)
+	require.NoError(t, err)
+}
+
+func TestDataDirCipher_InvalidKey(t *testing.T) {
+	t.Parallel()
+	dir := testutil.TempDir(t, "agent")
+	defer os.RemoveAll(dir)
+
+	c, err := newDataDirCipher("", dir)
+	require.NoError(t, err)
+	require.Nil(t, c)
+
+	_, err = newDataDirCipher(filepath.Join(dir, "missing"), dir)
+	require.Error(t, err)
+
+	path := filepath.Join(dir, "key")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not base64"), 0600))
+	_, err = newDataDirCipher(path, dir)
+	require.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0600))
+	_, err = newDataDirCipher(path, dir)
+	require.Error(t, err)
+}
+
+func TestAgent_PersistService_Encrypted(t *testing.T) {
+	t.Parallel()
+	dataDir := testutil.TempDir(t, "agent") // we manage the data dir
+	defer os.RemoveAll(dataDir)
+	cfg := `
+		server = false
+		bootstrap = false
+		data_dir = "` + dataDir + `"
+		data_dir_encryption_key_file = "` + writeDataDirKey(t, dataDir) + `"
+	`
+	a := &TestAgent{Name: t.Name(), HCL: cfg, DataDir: dataDir}
+	a.Start(t)
+	defer a.Shutdown()
+
+	svc := &structs.NodeService{
+		ID:      "redis",
+		Service: "redis",
+		Port:    8000,
+	}
+	require.NoError(t, a.AddService(svc, nil, true, "mytoken", ConfigSourceLocal))
+
+	content, err := ioutil.ReadFile(filepath.Join(a.Config.DataDir, servicesDir, stringHash(svc.ID)))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(content, dataDirEncryptionPrefix))
+	require.NotContains(t, string(content), "mytoken")
+	a.Shutdown()
+
+	// Should load it back during later start
+	a2 := &TestAgent{Name: t.Name(), HCL: cfg, DataDir: dataDir}
+	a2.Start(t)
+	defer a2.Shutdown()
+
+	restored := a2.State.ServiceState(svc.ID)
+	require.NotNil(t, restored)
+	require.Equal(t, "mytoken", restored.Token)
+}
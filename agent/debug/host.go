@@ -10,47 +10,54 @@ import (
 )
 
 const (
-	// DiskUsagePath is the path to check usage of the disk.
+	// DiskUsagePath is the path to check usage of the disk if no data
+	// directory is given.
 	// Must be a filesystem path such as "/", not device file path like "/dev/vda1"
 	DiskUsagePath = "/"
 )
 
 // HostInfo includes information about resources on the host as well as
-// collection time and
+// collection time and any errors encountered while collecting them.
 type HostInfo struct {
 	Memory         *mem.VirtualMemoryStat
 	CPU            []cpu.InfoStat
 	Host           *host.InfoStat
 	Disk           *disk.UsageStat
 	CollectionTime int64
-	Errors         []error
+	Errors         []string
 }
 
-// CollectHostInfo queries the host system and returns HostInfo. Any
-// errors encountered will be returned in HostInfo.Errors
-func CollectHostInfo() *HostInfo {
+// CollectHostInfo queries the host system and returns HostInfo. The disk
+// usage is that of the filesystem holding dataDir, or of DiskUsagePath if
+// dataDir is empty. Any errors encountered will be returned in
+// HostInfo.Errors
+func CollectHostInfo(dataDir string) *HostInfo {
 	info := &HostInfo{CollectionTime: time.Now().UTC().UnixNano()}
 
 	if h, err := host.Info(); err != nil {
-		info.Errors = append(info.Errors, err)
+		info.Errors = append(info.Errors, err.Error())
 	} else {
 		info.Host = h
 	}
 
 	if v, err := mem.VirtualMemory(); err != nil {
-		info.Errors = append(info.Errors, err)
+		info.Errors = append(info.Errors, err.Error())
 	} else {
 		info.Memory = v
 	}
 
-	if d, err := disk.Usage(DiskUsagePath); err != nil {
-		info.Errors = append(info.Errors, err)
+	diskPath := dataDir
+	if diskPath == "" {
+		diskPath = DiskUsagePath
+	}
+	if d, err := disk.Usage(diskPath); err != nil {
+		info.Errors = append(info.Errors, err.Error())
 	} else {
 		info.Disk = d
 	}
 
 	if c, err := cpu.Info(); err != nil {
-		info.Errors = append(info.Errors, err)
+		info.Errors = append(info.Errors, err.Error())
 	} else {
 		info.CPU = c
 	}
@@ -1,6 +1,9 @@
 package debug
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,7 +12,7 @@ import (
 func TestCollectHostInfo(t *testing.T) {
 	assert := assert.New(t)
 
-	host := CollectHostInfo()
+	host := CollectHostInfo("")
 
 	assert.Nil(host.Errors)
 
@@ -17,4 +20,23 @@ func TestCollectHostInfo(t *testing.T) {
 	assert.NotNil(host.Host)
 	assert.NotNil(host.Disk)
 	assert.NotNil(host.Memory)
+	assert.Equal(DiskUsagePath, host.Disk.Path)
+}
+
+func TestCollectHostInfo_DataDir(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "consul")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	host := CollectHostInfo(dir)
+	assert.Nil(host.Errors)
+	assert.Equal(dir, host.Disk.Path)
+
+	// A missing data directory is reported as an error.
+	host = CollectHostInfo(filepath.Join(dir, "nope"))
+	assert.Len(host.Errors, 1)
+	assert.Nil(host.Disk)
+	assert.NotNil(host.Host)
 }
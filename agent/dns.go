@@ -4,8 +4,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
 	"net"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -37,6 +41,18 @@ const (
 
 	defaultMaxUDPSize = 512
 
+	// dnsTruncatedCacheSize is the number of truncated UDP responses that
+	// are remembered in order to count the TCP retries of them.
+	dnsTruncatedCacheSize = 1024
+
+	// dnsTCPRetryWindow is how long after a truncated UDP response a TCP
+	// query for the same question from the same client counts as a retry.
+	dnsTCPRetryWindow = 10 * time.Second
+
+	// maxDNSRotations is the number of service lookups whose rotation is
+	// remembered for the round-robin answer order.
+	maxDNSRotations = 4096
+
 	MaxDNSLabelLength = 63
 )
 
@@ -51,9 +67,11 @@ type dnsSOAConfig struct {
 
 type dnsConfig struct {
 	AllowStale      bool
+	AnswerOrder     string
 	Datacenter      string
 	EnableTruncate  bool
 	MaxStale        time.Duration
+	NegativeTTL     time.Duration
 	UseCache        bool
 	CacheMaxAge     time.Duration
 	NodeName        string
@@ -66,75 +84,92 @@ type dnsConfig struct {
 	ARecordLimit    int
 	NodeMetaTXT     bool
 	dnsSOAConfig    dnsSOAConfig
+
+	// Recursors are the addresses of the upstream DNS servers.
+	Recursors []string
+
+	// ttlRadix and ttlStrict handle the prefix and exact lookups of the
+	// service TTLs.
+	ttlRadix  *radix.Tree
+	ttlStrict map[string]time.Duration
 }
 
 // DNSServer is used to wrap an Agent and expose various
 // service discovery endpoints using a DNS interface.
 type DNSServer struct {
 	*dns.Server
-	agent     *Agent
-	config    *dnsConfig
-	domain    string
-	recursors []string
-	logger    *log.Logger
-	// Those are handling prefix lookups
-	ttlRadix  *radix.Tree
-	ttlStrict map[string]time.Duration
+	agent  *Agent
+	domain string
+	logger *log.Logger
+
+	// config is the *dnsConfig used to answer queries. It is swapped
+	// atomically by ReloadConfig. Each query loads it once and passes it
+	// down, so that in-flight queries keep a consistent view.
+	config atomic.Value
 
 	// disableCompression is the config.DisableCompression flag that can
 	// be safely changed at runtime. It always contains a bool and is
 	// initialized with the value from config.DisableCompression.
 	disableCompression atomic.Value
+
+	// rotations holds the number of lookups of each service, keyed by
+	// datacenter, service and tag, to rotate its instances when the
+	// answer order is round-robin.
+	rotationsLock sync.Mutex
+	rotations     map[string]uint64
 }
 
 func NewDNSServer(a *Agent) (*DNSServer, error) {
-	var recursors []string
-	for _, r := range a.config.DNSRecursors {
-		ra, err := recursorAddr(r)
-		if err != nil {
-			return nil, fmt.Errorf("Invalid recursor address: %v", err)
-		}
-		recursors = append(recursors, ra)
-	}
-
 	// Make sure domain is FQDN, make it case insensitive for ServeMux
 	domain := dns.Fqdn(strings.ToLower(a.config.DNSDomain))
 
-	dnscfg := GetDNSConfig(a.config)
 	srv := &DNSServer{
-		agent:     a,
-		config:    dnscfg,
-		domain:    domain,
-		logger:    a.logger,
-		recursors: recursors,
-		ttlRadix:  radix.New(),
-		ttlStrict: make(map[string]time.Duration),
+		agent:  a,
+		domain: domain,
+		logger: a.logger,
 	}
-	if dnscfg.ServiceTTL != nil {
-		for key, ttl := range dnscfg.ServiceTTL {
-			// All suffix with '*' are put in radix
-			// This include '*' that will match anything
-			if strings.HasSuffix(key, "*") {
-				srv.ttlRadix.Insert(key[:len(key)-1], ttl)
-			} else {
-				srv.ttlStrict[key] = ttl
-			}
-		}
+	if err := srv.ReloadConfig(a.config); err != nil {
+		return nil, err
 	}
+	return srv, nil
+}
 
-	srv.disableCompression.Store(a.config.DNSDisableCompression)
+// ReloadConfig swaps the configuration used to answer queries. The domain
+// and the listeners cannot be changed without a restart.
+func (d *DNSServer) ReloadConfig(conf *config.RuntimeConfig) error {
+	cfg, err := GetDNSConfig(conf)
+	if err != nil {
+		return err
+	}
+	d.config.Store(cfg)
+	d.disableCompression.Store(conf.DNSDisableCompression)
+	return nil
+}
 
-	return srv, nil
+// currentConfig returns the DNS configuration currently in effect.
+func (d *DNSServer) currentConfig() *dnsConfig {
+	return d.config.Load().(*dnsConfig)
 }
 
 // GetDNSConfig takes global config and creates the config used by DNS server
-func GetDNSConfig(conf *config.RuntimeConfig) *dnsConfig {
-	return &dnsConfig{
+func GetDNSConfig(conf *config.RuntimeConfig) (*dnsConfig, error) {
+	var recursors []string
+	for _, r := range conf.DNSRecursors {
+		ra, err := recursorAddr(r)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid recursor address: %v", err)
+		}
+		recursors = append(recursors, ra)
+	}
+
+	cfg := &dnsConfig{
 		AllowStale:      conf.DNSAllowStale,
 		ARecordLimit:    conf.DNSARecordLimit,
+		AnswerOrder:     conf.DNSAnswerOrder,
 		Datacenter:      conf.Datacenter,
 		EnableTruncate:  conf.DNSEnableTruncate,
 		MaxStale:        conf.DNSMaxStale,
+		NegativeTTL:     conf.DNSNegativeTTL,
 		NodeName:        conf.NodeName,
 		NodeTTL:         conf.DNSNodeTTL,
 		OnlyPassing:     conf.DNSOnlyPassing,
@@ -151,18 +186,32 @@ func GetDNSConfig(conf *config.RuntimeConfig) *dnsConfig {
 			Refresh: conf.DNSSOA.Refresh,
 			Retry:   conf.DNSSOA.Retry,
 		},
+		Recursors: recursors,
+		ttlRadix:  radix.New(),
+		ttlStrict: make(map[string]time.Duration),
 	}
+	for key, ttl := range cfg.ServiceTTL {
+		// All suffix with '*' are put in radix
+		// This include '*' that will match anything
+		if strings.HasSuffix(key, "*") {
+			cfg.ttlRadix.Insert(key[:len(key)-1], ttl)
+		} else {
+			cfg.ttlStrict[key] = ttl
+		}
+	}
+	return cfg, nil
 }
 
 // GetTTLForService Find the TTL for a given service.
 // return ttl, true if found, 0, false otherwise
 func (d *DNSServer) GetTTLForService(service string) (time.Duration, bool) {
-	if d.config.ServiceTTL != nil {
-		ttl, ok := d.ttlStrict[service]
+	cfg := d.currentConfig()
+	if cfg.ServiceTTL != nil {
+		ttl, ok := cfg.ttlStrict[service]
 		if ok {
 			return ttl, true
 		}
-		_, ttlRaw, ok := d.ttlRadix.LongestPrefix(service)
+		_, ttlRaw, ok := cfg.ttlRadix.LongestPrefix(service)
 		if ok {
 			return ttlRaw.(time.Duration), true
 		}
@@ -171,12 +220,27 @@ func (d *DNSServer) GetTTLForService(service string) (time.Duration, bool) {
 }
 
 func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
+	d.setupServer(network, addr, notif)
+	return d.Server.ListenAndServe()
+}
+
+// ActivateAndServe is like ListenAndServe but serves DNS requests on a socket
+// that is already bound, such as one passed by systemd. For UDP the packet
+// connection is used, otherwise the listener.
+func (d *DNSServer) ActivateAndServe(network, addr string, l net.Listener, pc net.PacketConn, notif func()) error {
+	d.setupServer(network, addr, notif)
+	d.Server.Listener = l
+	d.Server.PacketConn = pc
+	return d.Server.ActivateAndServe()
+}
+
+func (d *DNSServer) setupServer(network, addr string, notif func()) {
 	mux := dns.NewServeMux()
 	mux.HandleFunc("arpa.", d.handlePtr)
 	mux.HandleFunc(d.domain, d.handleQuery)
-	if len(d.recursors) > 0 {
-		mux.HandleFunc(".", d.handleRecurse)
-	}
+	// Always register the recursor handler since the recursors can be
+	// changed by a reload. It fails the query if none are configured.
+	mux.HandleFunc(".", d.handleRecurse)
 
 	d.Server = &dns.Server{
 		Addr:              addr,
@@ -187,7 +251,6 @@ func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
 	if network == "udp" {
 		d.UDPSize = 65535
 	}
-	return d.Server.ListenAndServe()
 }
 
 // setEDNS is used to set the responses EDNS size headers and
@@ -258,16 +321,19 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 			resp.RemoteAddr().Network())
 	}(time.Now())
 
+	// Load the config once so the whole query is answered with the same one
+	cfg := d.currentConfig()
+
 	// Setup the message response
 	m := new(dns.Msg)
 	m.SetReply(req)
 	m.Compress = !d.disableCompression.Load().(bool)
 	m.Authoritative = true
-	m.RecursionAvailable = (len(d.recursors) > 0)
+	m.RecursionAvailable = (len(cfg.Recursors) > 0)
 
 	// Only add the SOA if requested
 	if req.Question[0].Qtype == dns.TypeSOA {
-		d.addSOA(m)
+		m.Ns = append(m.Ns, d.soa(cfg))
 	}
 
 	datacenter := d.agent.config.Datacenter
@@ -278,8 +344,8 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 	args := structs.DCSpecificRequest{
 		Datacenter: datacenter,
 		QueryOptions: structs.QueryOptions{
-			Token:      d.agent.tokens.UserToken(),
-			AllowStale: d.config.AllowStale,
+			Token:      d.agent.tokens.DNSToken(),
+			AllowStale: cfg.AllowStale,
 		},
 	}
 	var out structs.IndexedNodes
@@ -307,8 +373,8 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 		sargs := structs.ServiceSpecificRequest{
 			Datacenter: datacenter,
 			QueryOptions: structs.QueryOptions{
-				Token:      d.agent.tokens.UserToken(),
-				AllowStale: d.config.AllowStale,
+				Token:      d.agent.tokens.DNSToken(),
+				AllowStale: cfg.AllowStale,
 			},
 			ServiceAddress: serviceAddress,
 		}
@@ -360,25 +426,28 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 		network = "tcp"
 	}
 
+	// Load the config once so the whole query is answered with the same one
+	cfg := d.currentConfig()
+
 	// Setup the message response
 	m := new(dns.Msg)
 	m.SetReply(req)
 	m.Compress = !d.disableCompression.Load().(bool)
 	m.Authoritative = true
-	m.RecursionAvailable = (len(d.recursors) > 0)
+	m.RecursionAvailable = (len(cfg.Recursors) > 0)
 
 	ecsGlobal := true
 
 	switch req.Question[0].Qtype {
 	case dns.TypeSOA:
-		ns, glue := d.nameservers(req.IsEdns0() != nil, maxRecursionLevelDefault)
-		m.Answer = append(m.Answer, d.soa())
+		ns, glue := d.nameservers(cfg, req.IsEdns0() != nil, maxRecursionLevelDefault)
+		m.Answer = append(m.Answer, d.soa(cfg))
 		m.Ns = append(m.Ns, ns...)
 		m.Extra = append(m.Extra, glue...)
 		m.SetRcode(req, dns.RcodeSuccess)
 
 	case dns.TypeNS:
-		ns, glue := d.nameservers(req.IsEdns0() != nil, maxRecursionLevelDefault)
+		ns, glue := d.nameservers(cfg, req.IsEdns0() != nil, maxRecursionLevelDefault)
 		m.Answer = ns
 		m.Extra = glue
 		m.SetRcode(req, dns.RcodeSuccess)
@@ -387,10 +456,11 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 		m.SetRcode(req, dns.RcodeNotImplemented)
 
 	default:
-		ecsGlobal = d.dispatch(network, resp.RemoteAddr(), req, m)
+		ecsGlobal = d.dispatch(cfg, network, resp.RemoteAddr(), req, m)
 	}
 
 	setEDNS(req, m, ecsGlobal)
+	d.countTCPRetry(network, resp.RemoteAddr(), q, m)
 
 	// Write out the complete response
 	if err := resp.WriteMsg(m); err != nil {
@@ -398,34 +468,71 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	}
 }
 
-func (d *DNSServer) soa() *dns.SOA {
+// countTCPRetry remembers the UDP queries answered with a truncated response
+// and counts the TCP queries retrying them. The truncated responses are
+// shared by all the DNS servers of the agent since the retry is received by
+// the TCP server.
+func (d *DNSServer) countTCPRetry(network string, remote net.Addr, q dns.Question, resp *dns.Msg) {
+	host, _, err := net.SplitHostPort(remote.String())
+	if err != nil {
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%d", host, strings.ToLower(q.Name), q.Qtype)
+
+	truncated := d.agent.dnsTruncated
+	switch network {
+	case "udp":
+		if resp.Truncated {
+			truncated.Add(key, time.Now())
+		}
+	case "tcp":
+		v, ok := truncated.Get(key)
+		if !ok {
+			return
+		}
+		truncated.Remove(key)
+		if time.Since(v.(time.Time)) <= dnsTCPRetryWindow {
+			metrics.IncrCounter([]string{"dns", "tcp_retries"}, 1)
+		}
+	}
+}
+
+func (d *DNSServer) soa(cfg *dnsConfig) *dns.SOA {
 	return &dns.SOA{
 		Hdr: dns.RR_Header{
 			Name:   d.domain,
 			Rrtype: dns.TypeSOA,
 			Class:  dns.ClassINET,
 			// Has to be consistent with MinTTL to avoid invalidation
-			Ttl: d.config.dnsSOAConfig.Minttl,
+			Ttl: cfg.dnsSOAConfig.Minttl,
 		},
 		Ns:      "ns." + d.domain,
 		Serial:  uint32(time.Now().Unix()),
 		Mbox:    "hostmaster." + d.domain,
-		Refresh: d.config.dnsSOAConfig.Refresh,
-		Retry:   d.config.dnsSOAConfig.Retry,
-		Expire:  d.config.dnsSOAConfig.Expire,
-		Minttl:  d.config.dnsSOAConfig.Minttl,
+		Refresh: cfg.dnsSOAConfig.Refresh,
+		Retry:   cfg.dnsSOAConfig.Retry,
+		Expire:  cfg.dnsSOAConfig.Expire,
+		Minttl:  cfg.dnsSOAConfig.Minttl,
 	}
 }
 
-// addSOA is used to add an SOA record to a message for the given domain
-func (d *DNSServer) addSOA(msg *dns.Msg) {
-	msg.Ns = append(msg.Ns, d.soa())
+// addSOA is used to add an SOA record to a negative response for the given
+// domain. Resolvers cache negative responses for the lower of the TTL and the
+// minimum of the record (RFC 2308), so both are set to the negative TTL if
+// one is configured.
+func (d *DNSServer) addSOA(cfg *dnsConfig, msg *dns.Msg) {
+	soa := d.soa(cfg)
+	if ttl := cfg.NegativeTTL; ttl > 0 {
+		soa.Hdr.Ttl = uint32(ttl / time.Second)
+		soa.Minttl = uint32(ttl / time.Second)
+	}
+	msg.Ns = append(msg.Ns, soa)
 }
 
 // nameservers returns the names and ip addresses of up to three random servers
 // in the current cluster which serve as authoritative name servers for zone.
-func (d *DNSServer) nameservers(edns bool, maxRecursionLevel int) (ns []dns.RR, extra []dns.RR) {
-	out, err := d.lookupServiceNodes(d.agent.config.Datacenter, structs.ConsulServiceName, "", false, maxRecursionLevel)
+func (d *DNSServer) nameservers(cfg *dnsConfig, edns bool, maxRecursionLevel int) (ns []dns.RR, extra []dns.RR) {
+	out, err := d.lookupServiceNodes(cfg, d.agent.config.Datacenter, structs.ConsulServiceName, "", false, maxRecursionLevel)
 	if err != nil {
 		d.logger.Printf("[WARN] dns: Unable to get list of servers: %s", err)
 		return nil, nil
@@ -456,15 +563,15 @@ func (d *DNSServer) nameservers(edns bool, maxRecursionLevel int) (ns []dns.RR,
 				Name:   d.domain,
 				Rrtype: dns.TypeNS,
 				Class:  dns.ClassINET,
-				Ttl:    uint32(d.config.NodeTTL / time.Second),
+				Ttl:    uint32(cfg.NodeTTL / time.Second),
 			},
 			Ns: fqdn,
 		}
 		ns = append(ns, nsrr)
 
-		glue, meta := d.formatNodeRecord(nil, addr, fqdn, dns.TypeANY, d.config.NodeTTL, edns, maxRecursionLevel, d.config.NodeMetaTXT)
+		glue, meta := d.formatNodeRecord(cfg, nil, addr, fqdn, dns.TypeANY, cfg.NodeTTL, edns, maxRecursionLevel, cfg.NodeMetaTXT)
 		extra = append(extra, glue...)
-		if meta != nil && d.config.NodeMetaTXT {
+		if meta != nil && cfg.NodeMetaTXT {
 			extra = append(extra, meta...)
 		}
 
@@ -478,13 +585,13 @@ func (d *DNSServer) nameservers(edns bool, maxRecursionLevel int) (ns []dns.RR,
 }
 
 // dispatch is used to parse a request and invoke the correct handler
-func (d *DNSServer) dispatch(network string, remoteAddr net.Addr, req, resp *dns.Msg) (ecsGlobal bool) {
-	return d.doDispatch(network, remoteAddr, req, resp, maxRecursionLevelDefault)
+func (d *DNSServer) dispatch(cfg *dnsConfig, network string, remoteAddr net.Addr, req, resp *dns.Msg) (ecsGlobal bool) {
+	return d.doDispatch(cfg, network, remoteAddr, req, resp, maxRecursionLevelDefault)
 }
 
 // doDispatch is used to parse a request and invoke the correct handler.
 // parameter maxRecursionLevel will handle whether recursive call can be performed
-func (d *DNSServer) doDispatch(network string, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) (ecsGlobal bool) {
+func (d *DNSServer) doDispatch(cfg *dnsConfig, network string, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) (ecsGlobal bool) {
 	ecsGlobal = true
 	// By default the query is in the default datacenter
 	datacenter := d.agent.config.Datacenter
@@ -499,7 +606,7 @@ func (d *DNSServer) doDispatch(network string, remoteAddr net.Addr, req, resp *d
 	// Provide a flag for remembering whether the datacenter name was parsed already.
 	var dcParsed bool
 
-	// The last label is either "node", "service", "query", "_<protocol>", or a datacenter name
+	// The last label is either "node", "service", "virtual", "query", "_<protocol>", or a datacenter name
 PARSE:
 	n := len(labels)
 	if n == 0 {
@@ -531,7 +638,7 @@ PARSE:
 			}
 
 			// _name._tag.service.consul
-			d.serviceLookup(network, datacenter, labels[n-3][1:], tag, false, req, resp, maxRecursionLevel)
+			d.serviceLookup(cfg, network, datacenter, labels[n-3][1:], tag, false, req, resp, maxRecursionLevel)
 
 			// Consul 0.3 and prior format for SRV queries
 		} else {
@@ -543,7 +650,7 @@ PARSE:
 			}
 
 			// tag[.tag].name.service.consul
-			d.serviceLookup(network, datacenter, labels[n-2], tag, false, req, resp, maxRecursionLevel)
+			d.serviceLookup(cfg, network, datacenter, labels[n-2], tag, false, req, resp, maxRecursionLevel)
 		}
 
 	case "connect":
@@ -552,7 +659,15 @@ PARSE:
 		}
 
 		// name.connect.consul
-		d.serviceLookup(network, datacenter, labels[n-2], "", true, req, resp, maxRecursionLevel)
+		d.serviceLookup(cfg, network, datacenter, labels[n-2], "", true, req, resp, maxRecursionLevel)
+
+	case "virtual":
+		if n != 2 {
+			goto INVALID
+		}
+
+		// name.virtual.consul
+		d.virtualServiceLookup(cfg, datacenter, labels[n-2], req, resp)
 
 	case "node":
 		if n == 1 {
@@ -561,7 +676,7 @@ PARSE:
 
 		// Allow a "." in the node name, just join all the parts
 		node := strings.Join(labels[:n-1], ".")
-		d.nodeLookup(network, datacenter, node, req, resp, maxRecursionLevel)
+		d.nodeLookup(cfg, network, datacenter, node, req, resp, maxRecursionLevel)
 
 	case "query":
 		if n == 1 {
@@ -571,7 +686,7 @@ PARSE:
 		// Allow a "." in the query name, just join all the parts.
 		query := strings.Join(labels[:n-1], ".")
 		ecsGlobal = false
-		d.preparedQueryLookup(network, datacenter, query, remoteAddr, req, resp, maxRecursionLevel)
+		d.preparedQueryLookup(cfg, network, datacenter, query, remoteAddr, req, resp, maxRecursionLevel)
 
 	case "addr":
 		if n != 2 {
@@ -591,7 +706,7 @@ PARSE:
 					Name:   qName + d.domain,
 					Rrtype: dns.TypeA,
 					Class:  dns.ClassINET,
-					Ttl:    uint32(d.config.NodeTTL / time.Second),
+					Ttl:    uint32(cfg.NodeTTL / time.Second),
 				},
 				A: ip,
 			})
@@ -607,7 +722,7 @@ PARSE:
 					Name:   qName + d.domain,
 					Rrtype: dns.TypeAAAA,
 					Class:  dns.ClassINET,
-					Ttl:    uint32(d.config.NodeTTL / time.Second),
+					Ttl:    uint32(cfg.NodeTTL / time.Second),
 				},
 				AAAA: ip,
 			})
@@ -638,16 +753,17 @@ PARSE:
 	return
 INVALID:
 	d.logger.Printf("[WARN] dns: QName invalid: %s", qName)
-	d.addSOA(resp)
+	d.addSOA(cfg, resp)
 	resp.SetRcode(req, dns.RcodeNameError)
 	return
 }
 
 // nodeLookup is used to handle a node query
-func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.Msg, maxRecursionLevel int) {
+func (d *DNSServer) nodeLookup(cfg *dnsConfig, network, datacenter, node string, req, resp *dns.Msg, maxRecursionLevel int) {
 	// Only handle ANY, A, AAAA, and TXT type requests
 	qType := req.Question[0].Qtype
 	if qType != dns.TypeANY && qType != dns.TypeA && qType != dns.TypeAAAA && qType != dns.TypeTXT {
+		d.addSOA(cfg, resp)
 		return
 	}
 
@@ -656,11 +772,11 @@ func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.
 		Datacenter: datacenter,
 		Node:       node,
 		QueryOptions: structs.QueryOptions{
-			Token:      d.agent.tokens.UserToken(),
-			AllowStale: d.config.AllowStale,
+			Token:      d.agent.tokens.DNSToken(),
+			AllowStale: cfg.AllowStale,
 		},
 	}
-	out, err := d.lookupNode(args)
+	out, err := d.lookupNode(cfg, args)
 	if err != nil {
 		d.logger.Printf("[ERR] dns: rpc error: %v", err)
 		resp.SetRcode(req, dns.RcodeServerFailure)
@@ -669,7 +785,7 @@ func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.
 
 	// If we have no address, return not found!
 	if out.NodeServices == nil {
-		d.addSOA(resp)
+		d.addSOA(cfg, resp)
 		resp.SetRcode(req, dns.RcodeNameError)
 		return
 	}
@@ -679,7 +795,7 @@ func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.
 	if qType == dns.TypeANY || qType == dns.TypeTXT {
 		generateMeta = true
 		metaInAnswer = true
-	} else if d.config.NodeMetaTXT {
+	} else if cfg.NodeMetaTXT {
 		generateMeta = true
 	}
 
@@ -687,7 +803,7 @@ func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.
 	n := out.NodeServices.Node
 	edns := req.IsEdns0() != nil
 	addr := d.agent.TranslateAddress(datacenter, n.Address, n.TaggedAddresses)
-	records, meta := d.formatNodeRecord(out.NodeServices.Node, addr, req.Question[0].Name, qType, d.config.NodeTTL, edns, maxRecursionLevel, generateMeta)
+	records, meta := d.formatNodeRecord(cfg, out.NodeServices.Node, addr, req.Question[0].Name, qType, cfg.NodeTTL, edns, maxRecursionLevel, generateMeta)
 	if records != nil {
 		resp.Answer = append(resp.Answer, records...)
 	}
@@ -696,12 +812,18 @@ func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.
 	} else if meta != nil && generateMeta {
 		resp.Extra = append(resp.Extra, meta...)
 	}
+
+	// If the node has no record of the requested type, return an empty
+	// answer which resolvers can cache.
+	if len(resp.Answer) == 0 {
+		d.addSOA(cfg, resp)
+	}
 }
 
-func (d *DNSServer) lookupNode(args *structs.NodeSpecificRequest) (*structs.IndexedNodeServices, error) {
+func (d *DNSServer) lookupNode(cfg *dnsConfig, args *structs.NodeSpecificRequest) (*structs.IndexedNodeServices, error) {
 	var out structs.IndexedNodeServices
 
-	useCache := d.config.UseCache
+	useCache := cfg.UseCache
 RPC:
 	if useCache {
 		raw, _, err := d.agent.cache.Get(cachetype.NodeServicesName, args)
@@ -722,7 +844,7 @@ RPC:
 
 	// Verify that request is not too stale, redo the request
 	if args.AllowStale {
-		if out.LastContact > d.config.MaxStale {
+		if out.LastContact > cfg.MaxStale {
 			args.AllowStale = false
 			useCache = false
 			d.logger.Printf("[WARN] dns: Query results too stale, re-requesting")
@@ -761,7 +883,7 @@ func encodeKVasRFC1464(key, value string) (txt string) {
 // The return value is two slices. The first slice is the main answer slice (containing the A, AAAA, CNAME) RRs for the node
 // and the second slice contains any TXT RRs created from the node metadata. It is up to the caller to determine where the
 // generated RRs should go and if they should be used at all.
-func (d *DNSServer) formatNodeRecord(node *structs.Node, addr, qName string, qType uint16, ttl time.Duration, edns bool, maxRecursionLevel int, generateMeta bool) (records, meta []dns.RR) {
+func (d *DNSServer) formatNodeRecord(cfg *dnsConfig, node *structs.Node, addr, qName string, qType uint16, ttl time.Duration, edns bool, maxRecursionLevel int, generateMeta bool) (records, meta []dns.RR) {
 	// Parse the IP
 	ip := net.ParseIP(addr)
 	var ipv4 net.IP
@@ -807,7 +929,7 @@ func (d *DNSServer) formatNodeRecord(node *structs.Node, addr, qName string, qTy
 		records = append(records, cnRec)
 
 		// Recurse
-		more := d.resolveCNAME(cnRec.Target, maxRecursionLevel)
+		more := d.resolveCNAME(cfg, cnRec.Target, maxRecursionLevel)
 		extra := 0
 	MORE_REC:
 		for _, rr := range more {
@@ -846,14 +968,25 @@ func (d *DNSServer) formatNodeRecord(node *structs.Node, addr, qName string, qTy
 
 // indexRRs populates a map which indexes a given list of RRs by name. NOTE that
 // the names are all squashed to lower case so we can perform case-insensitive
-// lookups; the RRs are not modified.
-func indexRRs(rrs []dns.RR, index map[string]dns.RR) {
+// lookups; the RRs are not modified. A name can have several RRs, such as the
+// A and AAAA records of a node, but duplicate RRs are only indexed once.
+func indexRRs(rrs []dns.RR, index map[string][]dns.RR) {
 	for _, rr := range rrs {
 		name := strings.ToLower(rr.Header().Name)
-		if _, ok := index[name]; !ok {
-			index[name] = rr
+		if !containsRR(index[name], rr) {
+			index[name] = append(index[name], rr)
+		}
+	}
+}
+
+// containsRR returns true if rrs contains an RR equal to rr.
+func containsRR(rrs []dns.RR, rr dns.RR) bool {
+	for _, x := range rrs {
+		if x.String() == rr.String() {
+			return true
 		}
 	}
+	return false
 }
 
 // syncExtra takes a DNS response message and sets the extra data to the most
@@ -861,7 +994,7 @@ func indexRRs(rrs []dns.RR, index map[string]dns.RR) {
 // so that can be re-used between calls. This assumes that the extra data is
 // only used to provide info for SRV records. If that's not the case, then this
 // will wipe out any additional data.
-func syncExtra(index map[string]dns.RR, resp *dns.Msg) {
+func syncExtra(index map[string][]dns.RR, resp *dns.Msg) {
 	extra := make([]dns.RR, 0, len(resp.Answer))
 	resolved := make(map[string]struct{}, len(resp.Answer))
 	for _, ansRR := range resp.Answer {
@@ -881,21 +1014,26 @@ func syncExtra(index map[string]dns.RR, resp *dns.Msg) {
 		}
 		resolved[target] = struct{}{}
 
-		extraRR, ok := index[target]
-		if ok {
+		// Keep all the records of the target so the answer is never
+		// accompanied by only some of them.
+		var cname *dns.CNAME
+		for _, extraRR := range index[target] {
 			extra = append(extra, extraRR)
-			if cname, ok := extraRR.(*dns.CNAME); ok {
-				target = strings.ToLower(cname.Target)
-				goto RESOLVE
+			if x, ok := extraRR.(*dns.CNAME); ok && cname == nil {
+				cname = x
 			}
 		}
+		if cname != nil {
+			target = strings.ToLower(cname.Target)
+			goto RESOLVE
+		}
 	}
 	resp.Extra = extra
 }
 
 // dnsBinaryTruncate find the optimal number of records using a fast binary search and return
 // it in order to return a DNS answer lower than maxSize parameter.
-func dnsBinaryTruncate(resp *dns.Msg, maxSize int, index map[string]dns.RR, hasExtra bool) int {
+func dnsBinaryTruncate(resp *dns.Msg, maxSize int, index map[string][]dns.RR, hasExtra bool) int {
 	originalAnswser := resp.Answer
 	startIndex := 0
 	endIndex := len(resp.Answer) + 1
@@ -929,7 +1067,7 @@ func (d *DNSServer) trimTCPResponse(req, resp *dns.Msg) (trimmed bool) {
 
 	// We avoid some function calls and allocations by only handling the
 	// extra data when necessary.
-	var index map[string]dns.RR
+	var index map[string][]dns.RR
 	originalSize := resp.Len()
 	originalNumRecords := len(resp.Answer)
 
@@ -945,7 +1083,7 @@ func (d *DNSServer) trimTCPResponse(req, resp *dns.Msg) (trimmed bool) {
 		resp.Answer = resp.Answer[:truncateAt]
 	}
 	if hasExtra {
-		index = make(map[string]dns.RR, len(resp.Extra))
+		index = make(map[string][]dns.RR, len(resp.Extra))
 		indexRRs(resp.Extra, index)
 	}
 	truncated := false
@@ -981,18 +1119,20 @@ func trimUDPResponse(req, resp *dns.Msg, udpAnswerLimit int) (trimmed bool) {
 	hasExtra := len(resp.Extra) > 0
 	maxSize := defaultMaxUDPSize
 
-	// Update to the maximum edns size
+	// Update to the maximum edns size and leave room for the OPT record
+	// which is added to the response after trimming it.
 	if edns := req.IsEdns0(); edns != nil {
 		if size := edns.UDPSize(); size > uint16(maxSize) {
 			maxSize = int(size)
 		}
+		maxSize -= dns.Len(edns)
 	}
 
 	// We avoid some function calls and allocations by only handling the
 	// extra data when necessary.
-	var index map[string]dns.RR
+	var index map[string][]dns.RR
 	if hasExtra {
-		index = make(map[string]dns.RR, len(resp.Extra))
+		index = make(map[string][]dns.RR, len(resp.Extra))
 		indexRRs(resp.Extra, index)
 	}
 
@@ -1028,6 +1168,21 @@ func trimUDPResponse(req, resp *dns.Msg, udpAnswerLimit int) (trimmed bool) {
 			syncExtra(index, resp)
 		}
 	}
+
+	// A single answer can still be too large along with its extra records.
+	// Drop the extra records first since clients can look them up
+	// separately, and the answer itself as a last resort. Sending a larger
+	// response would get it dropped or cut off by some resolvers. Without
+	// any answer the response is always flagged as truncated, otherwise it
+	// would look like the name doesn't exist.
+	if resp.Len() > maxSize {
+		resp.Extra = nil
+	}
+	if resp.Len() > maxSize {
+		resp.Answer = nil
+		resp.Truncated = true
+	}
+
 	// For 512 non-eDNS responses, while we compute size non-compressed,
 	// we send result compressed
 	resp.Compress = compress
@@ -1036,21 +1191,27 @@ func trimUDPResponse(req, resp *dns.Msg, udpAnswerLimit int) (trimmed bool) {
 }
 
 // trimDNSResponse will trim the response for UDP and TCP
-func (d *DNSServer) trimDNSResponse(network string, req, resp *dns.Msg) (trimmed bool) {
+func (d *DNSServer) trimDNSResponse(cfg *dnsConfig, network string, req, resp *dns.Msg) (trimmed bool) {
 	if network != "tcp" {
-		trimmed = trimUDPResponse(req, resp, d.config.UDPAnswerLimit)
+		trimmed = trimUDPResponse(req, resp, cfg.UDPAnswerLimit)
 	} else {
 		trimmed = d.trimTCPResponse(req, resp)
 	}
+	if !trimmed {
+		return false
+	}
+	metrics.IncrCounterWithLabels([]string{"dns", "truncated_responses"}, 1,
+		[]metrics.Label{{Name: "network", Value: network}})
+
 	// Flag that there are more records to return in the UDP response
-	if trimmed && d.config.EnableTruncate {
+	if cfg.EnableTruncate {
 		resp.Truncated = true
 	}
-	return trimmed
+	return true
 }
 
 // lookupServiceNodes returns nodes with a given service.
-func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect bool, maxRecursionLevel int) (structs.IndexedCheckServiceNodes, error) {
+func (d *DNSServer) lookupServiceNodes(cfg *dnsConfig, datacenter, service, tag string, connect bool, maxRecursionLevel int) (structs.IndexedCheckServiceNodes, error) {
 	args := structs.ServiceSpecificRequest{
 		Connect:     connect,
 		Datacenter:  datacenter,
@@ -1058,15 +1219,15 @@ func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect
 		ServiceTags: []string{tag},
 		TagFilter:   tag != "",
 		QueryOptions: structs.QueryOptions{
-			Token:      d.agent.tokens.UserToken(),
-			AllowStale: d.config.AllowStale,
-			MaxAge:     d.config.CacheMaxAge,
+			Token:      d.agent.tokens.DNSToken(),
+			AllowStale: cfg.AllowStale,
+			MaxAge:     cfg.CacheMaxAge,
 		},
 	}
 
 	var out structs.IndexedCheckServiceNodes
 
-	if d.config.UseCache {
+	if cfg.UseCache {
 		raw, m, err := d.agent.cache.Get(cachetype.HealthServicesName, &args)
 		if err != nil {
 			return out, err
@@ -1090,7 +1251,7 @@ func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect
 	}
 
 	// redo the request the response was too stale
-	if args.AllowStale && out.LastContact > d.config.MaxStale {
+	if args.AllowStale && out.LastContact > cfg.MaxStale {
 		args.AllowStale = false
 		d.logger.Printf("[WARN] dns: Query results too stale, re-requesting")
 
@@ -1099,17 +1260,18 @@ func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect
 		}
 	}
 
-	// Filter out any service nodes due to health checks
+	// Filter out any service nodes due to health checks, and the instances
+	// drained with a weight override.
 	// We copy the slice to avoid modifying the result if it comes from the cache
 	nodes := make(structs.CheckServiceNodes, len(out.Nodes))
 	copy(nodes, out.Nodes)
-	out.Nodes = nodes.Filter(d.config.OnlyPassing)
+	out.Nodes = nodes.Filter(cfg.OnlyPassing).FilterDrained()
 	return out, nil
 }
 
 // serviceLookup is used to handle a service query
-func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, connect bool, req, resp *dns.Msg, maxRecursionLevel int) {
-	out, err := d.lookupServiceNodes(datacenter, service, tag, connect, maxRecursionLevel)
+func (d *DNSServer) serviceLookup(cfg *dnsConfig, network, datacenter, service, tag string, connect bool, req, resp *dns.Msg, maxRecursionLevel int) {
+	out, err := d.lookupServiceNodes(cfg, datacenter, service, tag, connect, maxRecursionLevel)
 	if err != nil {
 		d.logger.Printf("[ERR] dns: rpc error: %v", err)
 		resp.SetRcode(req, dns.RcodeServerFailure)
@@ -1118,13 +1280,14 @@ func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, conn
 
 	// If we have no nodes, return not found!
 	if len(out.Nodes) == 0 {
-		d.addSOA(resp)
+		d.addSOA(cfg, resp)
 		resp.SetRcode(req, dns.RcodeNameError)
 		return
 	}
 
-	// Perform a random shuffle
-	out.Nodes.Shuffle()
+	// Order the instances as configured
+	key := fmt.Sprintf("%s/%s/%s/%t", datacenter, service, tag, connect)
+	d.orderServiceNodes(cfg, key, out.Nodes)
 
 	// Determine the TTL
 	ttl, _ := d.GetTTLForService(service)
@@ -1132,18 +1295,132 @@ func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, conn
 	// Add various responses depending on the request
 	qType := req.Question[0].Qtype
 	if qType == dns.TypeSRV {
-		d.serviceSRVRecords(datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
+		d.serviceSRVRecords(cfg, datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
 	} else {
-		d.serviceNodeRecords(datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
+		d.serviceNodeRecords(cfg, datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
 	}
 
-	d.trimDNSResponse(network, req, resp)
+	d.trimDNSResponse(cfg, network, req, resp)
 
 	// If the answer is empty and the response isn't truncated, return not found
 	if len(resp.Answer) == 0 && !resp.Truncated {
-		d.addSOA(resp)
+		d.addSOA(cfg, resp)
+		return
+	}
+}
+
+// orderServiceNodes orders the instances of a service lookup according to
+// the configured answer order. The key identifies the lookup for the
+// round-robin order.
+func (d *DNSServer) orderServiceNodes(cfg *dnsConfig, key string, nodes structs.CheckServiceNodes) {
+	switch cfg.AnswerOrder {
+	case config.DNSAnswerOrderRoundRobin:
+		d.rotateServiceNodes(key, nodes)
+	case config.DNSAnswerOrderWeighted:
+		weightedShuffle(nodes)
+	default:
+		nodes.Shuffle()
+	}
+}
+
+// rotateServiceNodes sorts the instances and rotates them by the number of
+// previous lookups with the same key, so that every instance is returned
+// first in turn.
+func (d *DNSServer) rotateServiceNodes(key string, nodes structs.CheckServiceNodes) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Node.Node != nodes[j].Node.Node {
+			return nodes[i].Node.Node < nodes[j].Node.Node
+		}
+		return nodes[i].Service.ID < nodes[j].Service.ID
+	})
+
+	d.rotationsLock.Lock()
+	// Forget the counters once there are too many lookups, the order only
+	// restarts from the first instance.
+	if d.rotations == nil || len(d.rotations) >= maxDNSRotations {
+		d.rotations = make(map[string]uint64)
+	}
+	n := d.rotations[key]
+	d.rotations[key]++
+	d.rotationsLock.Unlock()
+
+	shift := int(n % uint64(len(nodes)))
+	rotated := append(append(make(structs.CheckServiceNodes, 0, len(nodes)), nodes[shift:]...), nodes[:shift]...)
+	copy(nodes, rotated)
+}
+
+// weightedShuffle shuffles the instances so that the chance of an instance
+// to come first is proportional to its weight. Instances without weight come
+// last.
+func weightedShuffle(nodes structs.CheckServiceNodes) {
+	// Every instance gets the key rand^(1/weight) and the instances are
+	// sorted by descending key, see Efraimidis and Spirakis, "Weighted random
+	// sampling with a reservoir".
+	keys := make([]float64, len(nodes))
+	for i, node := range nodes {
+		keys[i] = -1
+		if weight := findWeight(node); weight > 0 {
+			keys[i] = math.Pow(rand.Float64(), 1/float64(weight))
+		}
+	}
+
+	idx := make([]int, len(nodes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return keys[idx[i]] > keys[idx[j]]
+	})
+
+	shuffled := make(structs.CheckServiceNodes, len(nodes))
+	for i, j := range idx {
+		shuffled[i] = nodes[j]
+	}
+	copy(nodes, shuffled)
+}
+
+// virtualServiceLookup is used to handle a query for the virtual IP of a
+// service.
+func (d *DNSServer) virtualServiceLookup(cfg *dnsConfig, datacenter, service string, req, resp *dns.Msg) {
+	args := structs.ServiceSpecificRequest{
+		Datacenter:  datacenter,
+		ServiceName: service,
+		QueryOptions: structs.QueryOptions{
+			Token:      d.agent.tokens.DNSToken(),
+			AllowStale: cfg.AllowStale,
+		},
+	}
+
+	var out structs.IndexedServiceVirtualIP
+	if err := d.agent.RPC("Catalog.VirtualIPForService", &args, &out); err != nil {
+		d.logger.Printf("[ERR] dns: rpc error: %v", err)
+		resp.SetRcode(req, dns.RcodeServerFailure)
 		return
 	}
+
+	// If the service has no virtual IP, return not found!
+	if out.VirtualIP == nil {
+		d.addSOA(cfg, resp)
+		resp.SetRcode(req, dns.RcodeNameError)
+		return
+	}
+
+	qType := req.Question[0].Qtype
+	if qType != dns.TypeA && qType != dns.TypeANY {
+		d.addSOA(cfg, resp)
+		return
+	}
+
+	ttl, _ := d.GetTTLForService(service)
+	resp.Answer = append(resp.Answer, &dns.A{
+		Hdr: dns.RR_Header{
+			Name:   req.Question[0].Name,
+			Rrtype: dns.TypeA,
+			Class:  dns.ClassINET,
+			Ttl:    uint32(ttl / time.Second),
+		},
+		A: net.ParseIP(out.VirtualIP.IP),
+	})
 }
 
 func ednsSubnetForRequest(req *dns.Msg) *dns.EDNS0_SUBNET {
@@ -1164,15 +1441,15 @@ func ednsSubnetForRequest(req *dns.Msg) *dns.EDNS0_SUBNET {
 }
 
 // preparedQueryLookup is used to handle a prepared query.
-func (d *DNSServer) preparedQueryLookup(network, datacenter, query string, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) {
+func (d *DNSServer) preparedQueryLookup(cfg *dnsConfig, network, datacenter, query string, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) {
 	// Execute the prepared query.
 	args := structs.PreparedQueryExecuteRequest{
 		Datacenter:    datacenter,
 		QueryIDOrName: query,
 		QueryOptions: structs.QueryOptions{
-			Token:      d.agent.tokens.UserToken(),
-			AllowStale: d.config.AllowStale,
-			MaxAge:     d.config.CacheMaxAge,
+			Token:      d.agent.tokens.DNSToken(),
+			AllowStale: cfg.AllowStale,
+			MaxAge:     cfg.CacheMaxAge,
 		},
 
 		// Always pass the local agent through. In the DNS interface, there
@@ -1201,13 +1478,13 @@ func (d *DNSServer) preparedQueryLookup(network, datacenter, query string, remot
 		}
 	}
 
-	out, err := d.lookupPreparedQuery(args)
+	out, err := d.lookupPreparedQuery(cfg, args)
 
 	// If they give a bogus query name, treat that as a name error,
 	// not a full on server error. We have to use a string compare
 	// here since the RPC layer loses the type information.
 	if err != nil && err.Error() == consul.ErrQueryNotFound.Error() {
-		d.addSOA(resp)
+		d.addSOA(cfg, resp)
 		resp.SetRcode(req, dns.RcodeNameError)
 		return
 	} else if err != nil {
@@ -1234,13 +1511,13 @@ func (d *DNSServer) preparedQueryLookup(network, datacenter, query string, remot
 		if err != nil {
 			d.logger.Printf("[WARN] dns: Failed to parse TTL '%s' for prepared query '%s', ignoring", out.DNS.TTL, query)
 		}
-	} else if d.config.ServiceTTL != nil {
+	} else if cfg.ServiceTTL != nil {
 		ttl, _ = d.GetTTLForService(out.Service)
 	}
 
 	// If we have no nodes, return not found!
 	if len(out.Nodes) == 0 {
-		d.addSOA(resp)
+		d.addSOA(cfg, resp)
 		resp.SetRcode(req, dns.RcodeNameError)
 		return
 	}
@@ -1248,25 +1525,25 @@ func (d *DNSServer) preparedQueryLookup(network, datacenter, query string, remot
 	// Add various responses depending on the request.
 	qType := req.Question[0].Qtype
 	if qType == dns.TypeSRV {
-		d.serviceSRVRecords(out.Datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
+		d.serviceSRVRecords(cfg, out.Datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
 	} else {
-		d.serviceNodeRecords(out.Datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
+		d.serviceNodeRecords(cfg, out.Datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
 	}
 
-	d.trimDNSResponse(network, req, resp)
+	d.trimDNSResponse(cfg, network, req, resp)
 
 	// If the answer is empty and the response isn't truncated, return not found
 	if len(resp.Answer) == 0 && !resp.Truncated {
-		d.addSOA(resp)
+		d.addSOA(cfg, resp)
 		return
 	}
 }
 
-func (d *DNSServer) lookupPreparedQuery(args structs.PreparedQueryExecuteRequest) (*structs.PreparedQueryExecuteResponse, error) {
+func (d *DNSServer) lookupPreparedQuery(cfg *dnsConfig, args structs.PreparedQueryExecuteRequest) (*structs.PreparedQueryExecuteResponse, error) {
 	var out structs.PreparedQueryExecuteResponse
 
 RPC:
-	if d.config.UseCache {
+	if cfg.UseCache {
 		raw, m, err := d.agent.cache.Get(cachetype.PreparedQueryName, &args)
 		if err != nil {
 			return nil, err
@@ -1288,7 +1565,7 @@ RPC:
 
 	// Verify that request is not too stale, redo the request.
 	if args.AllowStale {
-		if out.LastContact > d.config.MaxStale {
+		if out.LastContact > cfg.MaxStale {
 			args.AllowStale = false
 			d.logger.Printf("[WARN] dns: Query results too stale, re-requesting")
 			goto RPC
@@ -1301,7 +1578,7 @@ RPC:
 }
 
 // serviceNodeRecords is used to add the node records for a service lookup
-func (d *DNSServer) serviceNodeRecords(dc string, nodes structs.CheckServiceNodes, req, resp *dns.Msg, ttl time.Duration, maxRecursionLevel int) {
+func (d *DNSServer) serviceNodeRecords(cfg *dnsConfig, dc string, nodes structs.CheckServiceNodes, req, resp *dns.Msg, ttl time.Duration, maxRecursionLevel int) {
 	qName := req.Question[0].Name
 	qType := req.Question[0].Qtype
 	handled := make(map[string]struct{})
@@ -1335,13 +1612,13 @@ func (d *DNSServer) serviceNodeRecords(dc string, nodes structs.CheckServiceNode
 		if qType == dns.TypeANY || qType == dns.TypeTXT {
 			generateMeta = true
 			metaInAnswer = true
-		} else if d.config.NodeMetaTXT {
+		} else if cfg.NodeMetaTXT {
 			generateMeta = true
 		}
 
 		// Add the node record
 		had_answer := false
-		records, meta := d.formatNodeRecord(node.Node, addr, qName, qType, ttl, edns, maxRecursionLevel, generateMeta)
+		records, meta := d.formatNodeRecord(cfg, node.Node, addr, qName, qType, ttl, edns, maxRecursionLevel, generateMeta)
 		if records != nil {
 			switch records[0].(type) {
 			case *dns.CNAME:
@@ -1365,7 +1642,7 @@ func (d *DNSServer) serviceNodeRecords(dc string, nodes structs.CheckServiceNode
 
 		if had_answer {
 			count++
-			if count == d.config.ARecordLimit {
+			if count == cfg.ARecordLimit {
 				// We stop only if greater than 0 or we reached the limit
 				return
 			}
@@ -1423,7 +1700,7 @@ func findWeight(node structs.CheckServiceNode) int {
 }
 
 // serviceARecords is used to add the SRV records for a service lookup
-func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes, req, resp *dns.Msg, ttl time.Duration, maxRecursionLevel int) {
+func (d *DNSServer) serviceSRVRecords(cfg *dnsConfig, dc string, nodes structs.CheckServiceNodes, req, resp *dns.Msg, ttl time.Duration, maxRecursionLevel int) {
 	handled := make(map[string]struct{})
 	edns := req.IsEdns0() != nil
 
@@ -1460,7 +1737,7 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 		}
 
 		// Add the extra record
-		records, meta := d.formatNodeRecord(node.Node, addr, srvRec.Target, dns.TypeANY, ttl, edns, maxRecursionLevel, d.config.NodeMetaTXT)
+		records, meta := d.formatNodeRecord(cfg, node.Node, addr, srvRec.Target, dns.TypeANY, ttl, edns, maxRecursionLevel, cfg.NodeMetaTXT)
 		if len(records) > 0 {
 			// Use the node address if it doesn't differ from the service address
 			if addr == node.Node.Address {
@@ -1491,7 +1768,7 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 				}
 			}
 
-			if meta != nil && d.config.NodeMetaTXT {
+			if meta != nil && cfg.NodeMetaTXT {
 				resp.Extra = append(resp.Extra, meta...)
 			}
 		}
@@ -1508,17 +1785,25 @@ func (d *DNSServer) handleRecurse(resp dns.ResponseWriter, req *dns.Msg) {
 			resp.RemoteAddr().Network())
 	}(time.Now())
 
+	// Fail the query like the default handler does if no recursors are
+	// configured
+	cfg := d.currentConfig()
+	if len(cfg.Recursors) == 0 {
+		dns.HandleFailed(resp, req)
+		return
+	}
+
 	// Switch to TCP if the client is
 	if _, ok := resp.RemoteAddr().(*net.TCPAddr); ok {
 		network = "tcp"
 	}
 
 	// Recursively resolve
-	c := &dns.Client{Net: network, Timeout: d.config.RecursorTimeout}
+	c := &dns.Client{Net: network, Timeout: cfg.RecursorTimeout}
 	var r *dns.Msg
 	var rtt time.Duration
 	var err error
-	for _, recursor := range d.recursors {
+	for _, recursor := range cfg.Recursors {
 		r, rtt, err = c.Exchange(req, recursor)
 		// Check if the response is valid and has the desired Response code
 		if r != nil && (r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError) {
@@ -1557,7 +1842,7 @@ func (d *DNSServer) handleRecurse(resp dns.ResponseWriter, req *dns.Msg) {
 }
 
 // resolveCNAME is used to recursively resolve CNAME records
-func (d *DNSServer) resolveCNAME(name string, maxRecursionLevel int) []dns.RR {
+func (d *DNSServer) resolveCNAME(cfg *dnsConfig, name string, maxRecursionLevel int) []dns.RR {
 	// If the CNAME record points to a Consul address, resolve it internally
 	// Convert query to lowercase because DNS is case insensitive; d.domain is
 	// already converted
@@ -1571,13 +1856,13 @@ func (d *DNSServer) resolveCNAME(name string, maxRecursionLevel int) []dns.RR {
 		resp := &dns.Msg{}
 
 		req.SetQuestion(name, dns.TypeANY)
-		d.doDispatch("udp", nil, req, resp, maxRecursionLevel-1)
+		d.doDispatch(cfg, "udp", nil, req, resp, maxRecursionLevel-1)
 
 		return resp.Answer
 	}
 
 	// Do nothing if we don't have a recursor
-	if len(d.recursors) == 0 {
+	if len(cfg.Recursors) == 0 {
 		return nil
 	}
 
@@ -1586,11 +1871,11 @@ func (d *DNSServer) resolveCNAME(name string, maxRecursionLevel int) []dns.RR {
 	m.SetQuestion(name, dns.TypeA)
 
 	// Make a DNS lookup request
-	c := &dns.Client{Net: "udp", Timeout: d.config.RecursorTimeout}
+	c := &dns.Client{Net: "udp", Timeout: cfg.RecursorTimeout}
 	var r *dns.Msg
 	var rtt time.Duration
 	var err error
-	for _, recursor := range d.recursors {
+	for _, recursor := range cfg.Recursors {
 		r, rtt, err = c.Exchange(m, recursor)
 		if err == nil {
 			d.logger.Printf("[DEBUG] dns: cname recurse RTT for %v (%v)", name, rtt)
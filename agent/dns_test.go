@@ -140,6 +140,52 @@ func TestEncodeKVasRFC1464(t *testing.T) {
 	}
 }
 
+func TestDNS_ActivateAndServe(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Register node
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "Foo",
+		Address:    "127.0.0.1",
+	}
+
+	var out struct{}
+	if err := a.RPC("Catalog.Register", args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Serve DNS on a socket that is already bound, like one passed by
+	// systemd.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s, err := NewDNSServer(a.Agent)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	started := make(chan struct{})
+	go s.ActivateAndServe("udp", pc.LocalAddr().String(), nil, pc, func() { close(started) })
+	<-started
+	defer s.Shutdown()
+
+	m := new(dns.Msg)
+	m.SetQuestion("foo.node.dc1.consul.", dns.TypeANY)
+
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(in.Answer) != 1 {
+		t.Fatalf("empty lookup: %#v", in)
+	}
+}
+
 func TestDNS_Over_TCP(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -1148,6 +1194,68 @@ func TestDNS_SOA_Settings(t *testing.T) {
 	testSoaWithConfig("dns_config={soa={refresh=1800,retry=300}}", 0, 86400, 1800, 300)
 }
 
+func TestDNS_NegativeTTL(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			negative_ttl = "30s"
+			soa {
+				min_ttl = 5
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Register a node with an IPv4 address only.
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	cases := []struct {
+		name  string
+		qType uint16
+		rcode int
+	}{
+		{"nofoo.node.consul.", dns.TypeA, dns.RcodeNameError},
+		{"nodb.service.consul.", dns.TypeSRV, dns.RcodeNameError},
+		{"foo.node.consul.", dns.TypeAAAA, dns.RcodeSuccess},
+	}
+	for _, tc := range cases {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.name, tc.qType)
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		require.Equal(t, tc.rcode, in.Rcode, tc.name)
+		require.Empty(t, in.Answer, tc.name)
+
+		// Negative answers are cached for the negative TTL.
+		require.Len(t, in.Ns, 1, tc.name)
+		soa, ok := in.Ns[0].(*dns.SOA)
+		require.True(t, ok, "NS RR is not a SOA record")
+		require.Equal(t, uint32(30), soa.Hdr.Ttl, tc.name)
+		require.Equal(t, uint32(30), soa.Minttl, tc.name)
+	}
+
+	// The SOA record itself keeps its configured minimum TTL.
+	m := new(dns.Msg)
+	m.SetQuestion("consul.", dns.TypeSOA)
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Len(t, in.Answer, 1)
+	soa, ok := in.Answer[0].(*dns.SOA)
+	require.True(t, ok, "answer is not a SOA record")
+	require.Equal(t, uint32(5), soa.Hdr.Ttl)
+	require.Equal(t, uint32(5), soa.Minttl)
+}
+
 func TestDNS_ServiceReverseLookupNodeAddress(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -1611,6 +1719,54 @@ func TestDNS_ConnectServiceLookup(t *testing.T) {
 	}
 }
 
+func TestDNS_VirtualServiceLookup(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Register
+	{
+		args := structs.TestRegisterRequestProxy(t)
+		args.Address = "127.0.0.55"
+		args.Service.Proxy.DestinationServiceName = "db"
+		var out struct{}
+		assert.Nil(a.RPC("Catalog.Register", args, &out))
+	}
+
+	// Look up the service
+	questions := []string{
+		"db.virtual.consul.",
+		"db.virtual.dc1.consul.",
+	}
+	for _, question := range questions {
+		m := new(dns.Msg)
+		m.SetQuestion(question, dns.TypeA)
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		assert.Nil(err)
+		assert.Len(in.Answer, 1)
+
+		aRec, ok := in.Answer[0].(*dns.A)
+		assert.True(ok)
+		assert.Equal(question, aRec.Hdr.Name)
+		assert.Equal("240.0.0.1", aRec.A.String())
+	}
+
+	// Services without a virtual IP are not found
+	m := new(dns.Msg)
+	m.SetQuestion("web.virtual.consul.", dns.TypeA)
+
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	assert.Nil(err)
+	assert.Len(in.Answer, 0)
+	assert.Equal(dns.RcodeNameError, in.Rcode)
+}
+
 func TestDNS_ExternalServiceLookup(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -3833,6 +3989,86 @@ func TestDNS_ServiceLookup_Randomize(t *testing.T) {
 	}
 }
 
+func TestDNS_ServiceLookup_AnswerOrderRoundRobin(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			answer_order = "round-robin"
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	for i := 0; i < 3; i++ {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       fmt.Sprintf("foo%d", i),
+			Address:    fmt.Sprintf("127.0.0.%d", i+1),
+			Service: &structs.NodeService{
+				Service: "web",
+				Port:    8000,
+			},
+		}
+
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	// Every instance comes first in turn, the others keep their order.
+	expected := [][]string{
+		{"127.0.0.1", "127.0.0.2", "127.0.0.3"},
+		{"127.0.0.2", "127.0.0.3", "127.0.0.1"},
+		{"127.0.0.3", "127.0.0.1", "127.0.0.2"},
+		{"127.0.0.1", "127.0.0.2", "127.0.0.3"},
+	}
+	for _, want := range expected {
+		m := new(dns.Msg)
+		m.SetQuestion("web.service.consul.", dns.TypeA)
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+
+		var got []string
+		for _, rec := range in.Answer {
+			got = append(got, rec.(*dns.A).A.String())
+		}
+		require.Equal(t, want, got)
+	}
+}
+
+func TestDNS_WeightedShuffle(t *testing.T) {
+	t.Parallel()
+
+	node := func(name string, weight int) structs.CheckServiceNode {
+		return structs.CheckServiceNode{
+			Node: &structs.Node{Node: name},
+			Service: &structs.NodeService{
+				Service: "web",
+				Weights: &structs.Weights{Passing: weight, Warning: 1},
+			},
+		}
+	}
+
+	first := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		nodes := structs.CheckServiceNodes{
+			node("light", 1),
+			node("heavy", 9),
+			node("none", 0),
+		}
+		weightedShuffle(nodes)
+		require.Len(t, nodes, 3)
+		require.Equal(t, "none", nodes[2].Node.Node)
+		first[nodes[0].Node.Node]++
+	}
+
+	// The heavy instance should come first about 90% of the time. Leave
+	// some wiggle room to avoid flaky failures.
+	require.True(t, first["heavy"] > 800, "heavy first %d/1000", first["heavy"])
+	require.True(t, first["light"] > 0, "light never first")
+}
+
 func TestBinarySearch(t *testing.T) {
 	t.Parallel()
 	msgSrc := new(dns.Msg)
@@ -3852,7 +4088,7 @@ func TestBinarySearch(t *testing.T) {
 				msgSrc.SetQuestion("redis.service.consul.", dns.TypeSRV)
 				msg.Answer = msgSrc.Answer
 				msg.Extra = msgSrc.Extra
-				index := make(map[string]dns.RR, len(msg.Extra))
+				index := make(map[string][]dns.RR, len(msg.Extra))
 				indexRRs(msg.Extra, index)
 				blen := dnsBinaryTruncate(msg, maxSize, index, true)
 				msg.Answer = msg.Answer[:blen]
@@ -4038,6 +4274,20 @@ func TestDNS_ServiceLookup_Truncate(t *testing.T) {
 			t.Fatalf("should have truncate bit")
 		}
 	}
+
+	// The truncated responses are remembered until the client retries
+	// over TCP.
+	require.Equal(t, len(questions), a.dnsTruncated.Len())
+	for _, question := range questions {
+		m := new(dns.Msg)
+		m.SetQuestion(question, dns.TypeANY)
+
+		c := &dns.Client{Net: "tcp"}
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		require.False(t, in.Truncated)
+	}
+	require.Equal(t, 0, a.dnsTruncated.Len())
 }
 
 func TestDNS_ServiceLookup_LargeResponses(t *testing.T) {
@@ -5141,20 +5391,28 @@ func TestDNS_ServiceLookup_SRV_RFC_TCP_Default(t *testing.T) {
 func TestDNS_ServiceLookup_FilterACL(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		token   string
-		results int
+		token    string
+		dnsToken string
+		results  int
 	}{
-		{"root", 1},
-		{"anonymous", 0},
+		{"root", "", 1},
+		{"anonymous", "", 0},
+		{"anonymous", "root", 1},
+		{"root", "anonymous", 0},
 	}
 	for _, tt := range tests {
-		t.Run("ACLToken == "+tt.token, func(t *testing.T) {
+		t.Run("ACLToken == "+tt.token+", DNSToken == "+tt.dnsToken, func(t *testing.T) {
 			a := NewTestAgent(t, t.Name(), `
 				acl_token = "`+tt.token+`"
 				acl_master_token = "root"
 				acl_datacenter = "dc1"
 				acl_down_policy = "deny"
 				acl_default_policy = "deny"
+				acl {
+					tokens {
+						dns = "`+tt.dnsToken+`"
+					}
+				}
 			`)
 			defer a.Shutdown()
 			testrpc.WaitForLeader(t, a.RPC, "dc1")
@@ -5878,6 +6136,126 @@ func TestDNS_trimUDPResponse_TrimSizeEDNS(t *testing.T) {
 	}
 }
 
+func TestDNS_syncExtra_MultipleRecords(t *testing.T) {
+	t.Parallel()
+	target := "ip-10-0-1-185.node.dc1.consul."
+	srv := func(port uint16) *dns.SRV {
+		return &dns.SRV{
+			Hdr: dns.RR_Header{
+				Name:   "redis-cache-redis.service.consul.",
+				Rrtype: dns.TypeSRV,
+				Class:  dns.ClassINET,
+			},
+			Target: target,
+			Port:   port,
+		}
+	}
+	a := &dns.A{
+		Hdr: dns.RR_Header{
+			Name:   target,
+			Rrtype: dns.TypeA,
+			Class:  dns.ClassINET,
+		},
+		A: net.ParseIP("10.0.1.185"),
+	}
+	aaaa := &dns.AAAA{
+		Hdr: dns.RR_Header{
+			Name:   target,
+			Rrtype: dns.TypeAAAA,
+			Class:  dns.ClassINET,
+		},
+		AAAA: net.ParseIP("2001:db8::185"),
+	}
+	txt := &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   target,
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+		},
+		Txt: []string{"rack=a"},
+	}
+
+	// All the records of the target are kept, each only once.
+	resp := &dns.Msg{
+		Answer: []dns.RR{srv(8000), srv(8001)},
+		Extra:  []dns.RR{a, aaaa, txt, a},
+	}
+	index := make(map[string][]dns.RR)
+	indexRRs(resp.Extra, index)
+	syncExtra(index, resp)
+	require.Equal(t, []dns.RR{a, aaaa, txt}, resp.Extra)
+}
+
+func TestDNS_trimUDPResponse_EDNSRoomForOPT(t *testing.T) {
+	t.Parallel()
+	cfg := config.DefaultRuntimeConfig(`data_dir = "a" bind_addr = "127.0.0.1"`)
+
+	req, resp := &dns.Msg{}, &dns.Msg{}
+	req.SetEdns0(1024, true)
+	for i := 0; i < 100; i++ {
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{
+				Name:   "redis-cache-redis.service.consul.",
+				Rrtype: dns.TypeA,
+				Class:  dns.ClassINET,
+			},
+			A: net.ParseIP(fmt.Sprintf("10.0.1.%d", i)),
+		})
+	}
+
+	if trimmed := trimUDPResponse(req, resp, cfg.DNSUDPAnswerLimit); !trimmed {
+		t.Fatalf("expected response to be trimmed: %#v", resp)
+	}
+
+	// The response must still fit once the OPT record is added.
+	setEDNS(req, resp, true)
+	if resp.Len() > 1024 {
+		t.Fatalf("response is %d bytes", resp.Len())
+	}
+}
+
+func TestDNS_trimUDPResponse_SingleAnswerTooLarge(t *testing.T) {
+	t.Parallel()
+	cfg := config.DefaultRuntimeConfig(`data_dir = "a" bind_addr = "127.0.0.1"`)
+
+	target := "ip-10-0-1-1.node.dc1.consul."
+	srv := &dns.SRV{
+		Hdr: dns.RR_Header{
+			Name:   "redis-cache-redis.service.consul.",
+			Rrtype: dns.TypeSRV,
+			Class:  dns.ClassINET,
+		},
+		Target: target,
+	}
+	txt := &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   target,
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+		},
+		Txt: []string{strings.Repeat("a", 250), strings.Repeat("b", 250)},
+	}
+
+	// The extra records are dropped if the answer fits without them.
+	req, resp := &dns.Msg{}, &dns.Msg{}
+	resp.Answer = []dns.RR{srv}
+	resp.Extra = []dns.RR{txt}
+	trimUDPResponse(req, resp, cfg.DNSUDPAnswerLimit)
+	require.Equal(t, []dns.RR{srv}, resp.Answer)
+	require.Empty(t, resp.Extra)
+	require.False(t, resp.Truncated)
+
+	// The answer is dropped if it doesn't fit at all, and the response
+	// flagged as truncated so the client retries over TCP.
+	resp = &dns.Msg{}
+	resp.Answer = []dns.RR{txt}
+	if trimmed := trimUDPResponse(req, resp, cfg.DNSUDPAnswerLimit); !trimmed {
+		t.Fatalf("expected response to be trimmed: %#v", resp)
+	}
+	require.Empty(t, resp.Answer)
+	require.True(t, resp.Truncated)
+}
+
 func TestDNS_syncExtra(t *testing.T) {
 	t.Parallel()
 	resp := &dns.Msg{
@@ -6032,7 +6410,7 @@ func TestDNS_syncExtra(t *testing.T) {
 		},
 	}
 
-	index := make(map[string]dns.RR)
+	index := make(map[string][]dns.RR)
 	indexRRs(resp.Extra, index)
 	syncExtra(index, resp)
 
@@ -6341,11 +6719,83 @@ func TestDNS_formatNodeRecord(t *testing.T) {
 		},
 	}
 
-	records, meta := s.formatNodeRecord(node, "198.18.0.1", "test.node.consul", dns.TypeA, 5*time.Minute, false, 3, false)
+	records, meta := s.formatNodeRecord(&dnsConfig{}, node, "198.18.0.1", "test.node.consul", dns.TypeA, 5*time.Minute, false, 3, false)
 	require.Len(t, records, 1)
 	require.Len(t, meta, 0)
 
-	records, meta = s.formatNodeRecord(node, "198.18.0.1", "test.node.consul", dns.TypeA, 5*time.Minute, false, 3, true)
+	records, meta = s.formatNodeRecord(&dnsConfig{}, node, "198.18.0.1", "test.node.consul", dns.TypeA, 5*time.Minute, false, 3, true)
 	require.Len(t, records, 1)
 	require.Len(t, meta, 2)
 }
+
+func TestDNS_ServiceLookup_WeightOverrideDrained(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Register two instances of the service.
+	for i, node := range []string{"foo", "bar"} {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    fmt.Sprintf("127.0.0.%d", i+1),
+			Service: &structs.NodeService{
+				Service: "db",
+				Port:    12345,
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	// Drain the instance on foo.
+	{
+		args := &structs.ServiceWeightOverrideRequest{
+			Datacenter: "dc1",
+			Op:         structs.ServiceWeightOverrideSet,
+			Override: structs.ServiceWeightOverride{
+				Node:      "foo",
+				ServiceID: "db",
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.WeightOverride", args, &out))
+	}
+
+	// Register an equivalent prepared query.
+	var id string
+	{
+		args := &structs.PreparedQueryRequest{
+			Datacenter: "dc1",
+			Op:         structs.PreparedQueryCreate,
+			Query: &structs.PreparedQuery{
+				Name: "test",
+				Service: structs.ServiceQuery{
+					Service: "db",
+				},
+			},
+		}
+		require.NoError(t, a.RPC("PreparedQuery.Apply", args, &id))
+	}
+
+	// Look up the service directly and via prepared query.
+	questions := []string{
+		"db.service.consul.",
+		id + ".query.consul.",
+	}
+	for _, question := range questions {
+		m := new(dns.Msg)
+		m.SetQuestion(question, dns.TypeA)
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+
+		// Only the instance on bar is returned.
+		require.Len(t, in.Answer, 1, question)
+		aRec, ok := in.Answer[0].(*dns.A)
+		require.True(t, ok)
+		require.Equal(t, "127.0.0.2", aRec.A.String())
+	}
+}
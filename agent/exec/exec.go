@@ -3,6 +3,7 @@ package exec
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
 // Subprocess returns a command to execute a subprocess directly.
@@ -12,3 +13,23 @@ func Subprocess(args []string) (*exec.Cmd, error) {
 	}
 	return exec.Command(args[0], args[1:]...), nil
 }
+
+// FilterEnv returns the variables of env whose names are in whitelist. A
+// name ending in "*" matches all variables with that prefix. The result is
+// never nil, so assigning it to exec.Cmd.Env never inherits the environment.
+func FilterEnv(env []string, whitelist []string) []string {
+	filtered := []string{}
+	for _, kv := range env {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		for _, allowed := range whitelist {
+			if name == allowed || strings.HasSuffix(allowed, "*") && strings.HasPrefix(name, strings.TrimSuffix(allowed, "*")) {
+				filtered = append(filtered, kv)
+				break
+			}
+		}
+	}
+	return filtered
+}
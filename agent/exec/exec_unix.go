@@ -3,8 +3,11 @@
 package exec
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"os/user"
+	"strconv"
 	"syscall"
 )
 
@@ -25,3 +28,49 @@ func SetSysProcAttr(cmd *exec.Cmd) {
 func KillCommandSubtree(cmd *exec.Cmd) error {
 	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 }
+
+// TerminateCommandSubtree asks the command process and any child processes
+// to exit.
+func TerminateCommandSubtree(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// SetUser makes the command run as the given user and its groups. The
+// agent must have the privileges to switch users. SetSysProcAttr must be
+// called first.
+func SetUser(cmd *exec.Cmd, name string) error {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q of user %q", u.Uid, name)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid gid %q of user %q", u.Gid, name)
+	}
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		return fmt.Errorf("failed to look up groups of user %q: %v", name, err)
+	}
+	var groups []uint32
+	for _, id := range groupIDs {
+		g, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid group id %q of user %q", id, name)
+		}
+		groups = append(groups, uint32(g))
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    uint32(uid),
+		Gid:    uint32(gid),
+		Groups: groups,
+	}
+	return nil
+}
@@ -3,6 +3,7 @@
 package exec
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
@@ -28,3 +29,11 @@ func SetSysProcAttr(cmd *exec.Cmd) {}
 func KillCommandSubtree(cmd *exec.Cmd) error {
 	return cmd.Process.Kill()
 }
+
+func TerminateCommandSubtree(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func SetUser(cmd *exec.Cmd, name string) error {
+	return fmt.Errorf("running commands as user %q is not supported on Windows", name)
+}
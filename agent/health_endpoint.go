@@ -33,6 +33,9 @@ func (s *HTTPServer) HealthChecksInState(resp http.ResponseWriter, req *http.Req
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
 	if err := s.agent.RPC("Health.ChecksInState", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
 		return nil, err
 	}
 	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
@@ -76,6 +79,9 @@ func (s *HTTPServer) HealthNodeChecks(resp http.ResponseWriter, req *http.Reques
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
 	if err := s.agent.RPC("Health.NodeChecks", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
 		return nil, err
 	}
 	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
@@ -121,6 +127,9 @@ func (s *HTTPServer) HealthServiceChecks(resp http.ResponseWriter, req *http.Req
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
 	if err := s.agent.RPC("Health.ServiceChecks", &args, &out); err != nil {
+		if staleIfError(&args.QueryOptions, err) {
+			goto RETRY_ONCE
+		}
 		return nil, err
 	}
 	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
@@ -201,6 +210,9 @@ func (s *HTTPServer) healthServiceNodes(resp http.ResponseWriter, req *http.Requ
 	} else {
 	RETRY_ONCE:
 		if err := s.agent.RPC("Health.ServiceNodes", &args, &out); err != nil {
+			if staleIfError(&args.QueryOptions, err) {
+				goto RETRY_ONCE
+			}
 			return nil, err
 		}
 		if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"reflect"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/agentpb"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchQueryTime bounds each blocking query of a watch, so that a query
+// left behind by a client that went away doesn't block for long.
+const watchQueryTime = 30 * time.Second
+
+// grpcServiceHealth implements the ServiceHealth gRPC service of the agent.
+type grpcServiceHealth struct {
+	agent *Agent
+}
+
+// Watch runs blocking queries for the instances of the requested service and
+// sends them every time they change until the client cancels the stream.
+// Results that didn't change, for example because the index was bumped by
+// an unrelated write, aren't sent again.
+//
+// A failed query ends the stream with an error status and the client is
+// expected to start a new watch. The ACL token is read from the
+// x-consul-token metadata of the stream like for the Session service. The
+// watch returns as soon as the stream's context is done, without waiting
+// for the blocking query.
+func (s *grpcServiceHealth) Watch(req *agentpb.WatchServiceRequest, stream agentpb.ServiceHealth_WatchServer) error {
+	if req.Service == "" {
+		return status.Error(codes.InvalidArgument, "Missing service name")
+	}
+
+	metrics.IncrCounterWithLabels([]string{"client", "grpc", "service_health_watch"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.agent.config.NodeName}})
+
+	args := structs.ServiceSpecificRequest{
+		Datacenter:  req.Datacenter,
+		ServiceName: req.Service,
+		ServiceTags: req.Tags,
+		TagFilter:   len(req.Tags) > 0,
+		QueryOptions: structs.QueryOptions{
+			Token:        s.agent.grpcToken(stream.Context()),
+			MaxQueryTime: watchQueryTime,
+		},
+	}
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	var last []*agentpb.ServiceInstance
+	for first := true; ; first = false {
+		// The query can't be cancelled, so stop waiting for it when the
+		// client goes away. It ends on its own within watchQueryTime.
+		var out structs.IndexedCheckServiceNodes
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- s.agent.RPC("Health.ServiceNodes", &args, &out)
+		}()
+		var err error
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case err = <-errCh:
+		}
+		if err != nil {
+			metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "service_health_watch"}, 1,
+				[]metrics.Label{{Name: "node", Value: s.agent.config.NodeName}})
+			if acl.IsErrPermissionDenied(err) || acl.IsErrNotFound(err) {
+				return status.Error(codes.PermissionDenied, err.Error())
+			}
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		if req.PassingOnly {
+			out.Nodes = filterNonPassing(out.Nodes)
+		}
+		s.agent.TranslateAddresses(args.Datacenter, out.Nodes)
+
+		instances := serviceInstances(out.Nodes)
+		if first || !reflect.DeepEqual(instances, last) {
+			resp := &agentpb.WatchServiceResponse{Index: out.Index, Instances: instances}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			last = instances
+		}
+
+		// Start over if the index went backwards, for example after a
+		// snapshot was restored, since the query would block until it timed
+		// out otherwise.
+		if out.Index < args.MinQueryIndex {
+			args.MinQueryIndex = 0
+		} else {
+			args.MinQueryIndex = out.Index
+		}
+	}
+}
+
+// serviceInstances converts the result of a health query of a service for
+// the ServiceHealth gRPC service.
+func serviceInstances(nodes structs.CheckServiceNodes) []*agentpb.ServiceInstance {
+	var instances []*agentpb.ServiceInstance
+	for _, n := range nodes {
+		instance := &agentpb.ServiceInstance{
+			Node:           n.Node.Node,
+			NodeAddress:    n.Node.Address,
+			ServiceId:      n.Service.ID,
+			ServiceAddress: n.Service.Address,
+			ServicePort:    int32(n.Service.Port),
+			ServiceTags:    n.Service.Tags,
+			Status:         api.HealthPassing,
+		}
+		for _, check := range n.Checks {
+			switch check.Status {
+			case api.HealthCritical:
+				instance.Status = api.HealthCritical
+			case api.HealthWarning:
+				if instance.Status == api.HealthPassing {
+					instance.Status = api.HealthWarning
+				}
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
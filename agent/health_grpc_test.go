@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/agentpb"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServiceHealth_Watch(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Serve the service health service on its own listener.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	agentpb.RegisterServiceHealthServer(srv, &grpcServiceHealth{agent: a.Agent})
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := agentpb.NewServiceHealthClient(conn)
+
+	register := func(status string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      "web-1",
+				Service: "web",
+				Tags:    []string{"v1"},
+				Port:    8080,
+			},
+			Check: &structs.HealthCheck{
+				Node:      "foo",
+				CheckID:   "web-check",
+				Name:      "web check",
+				Status:    status,
+				ServiceID: "web-1",
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &agentpb.WatchServiceRequest{Service: "web"})
+	require.NoError(t, err)
+	passing, err := client.Watch(ctx, &agentpb.WatchServiceRequest{Service: "web", PassingOnly: true})
+	require.NoError(t, err)
+
+	// The current result is sent right away, even if there are no
+	// instances.
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Empty(t, resp.Instances)
+	resp, err = passing.Recv()
+	require.NoError(t, err)
+	require.Empty(t, resp.Instances)
+
+	register(api.HealthPassing)
+	instance := &agentpb.ServiceInstance{
+		Node:        "foo",
+		NodeAddress: "127.0.0.1",
+		ServiceId:   "web-1",
+		ServicePort: 8080,
+		ServiceTags: []string{"v1"},
+		Status:      api.HealthPassing,
+	}
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.NotZero(t, resp.Index)
+	require.Equal(t, []*agentpb.ServiceInstance{instance}, resp.Instances)
+	resp, err = passing.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []*agentpb.ServiceInstance{instance}, resp.Instances)
+
+	// A failing check is reported in the status of the instance and removes
+	// it from the passing instances.
+	register(api.HealthCritical)
+	instance.Status = api.HealthCritical
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []*agentpb.ServiceInstance{instance}, resp.Instances)
+	resp, err = passing.Recv()
+	require.NoError(t, err)
+	require.Empty(t, resp.Instances)
+
+	// A service name is required.
+	bad, err := client.Watch(ctx, &agentpb.WatchServiceRequest{})
+	require.NoError(t, err)
+	_, err = bad.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// fakeWatchStream is a ServiceHealth_WatchServer that passes the sent
+// responses to a channel.
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *agentpb.WatchServiceResponse
+}
+
+func (s *fakeWatchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeWatchStream) Send(resp *agentpb.WatchServiceResponse) error {
+	s.sent <- resp
+	return nil
+}
+
+func TestGRPCServiceHealth_Watch_ContextDone(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan *agentpb.WatchServiceResponse, 1)}
+	svc := &grpcServiceHealth{agent: a.Agent}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- svc.Watch(&agentpb.WatchServiceRequest{Service: "web"}, stream)
+	}()
+
+	// Once the first result was sent the next query blocks, but the watch
+	// returns right away when the client goes away.
+	select {
+	case <-stream.sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result was sent")
+	}
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("watch didn't return after the context was cancelled")
+	}
+}
@@ -1,9 +1,14 @@
 package agent
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/pprof"
@@ -21,7 +26,9 @@ import (
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/tracing"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/lib/connlimit"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -46,6 +53,16 @@ func (e BadRequestError) Error() string {
 	return fmt.Sprintf("Bad request: %s", e.Reason)
 }
 
+// RequestTooLargeError is returned when the body of a request exceeds the
+// limit of its endpoint class.
+type RequestTooLargeError struct {
+	Limit int
+}
+
+func (e RequestTooLargeError) Error() string {
+	return fmt.Sprintf("Request body exceeds %d byte limit", e.Limit)
+}
+
 // CodeWithPayloadError allow returning non HTTP 200
 // Error codes while not returning PlainText payload
 type CodeWithPayloadError struct {
@@ -72,6 +89,10 @@ type HTTPServer struct {
 	agent     *Agent
 	blacklist *Blacklist
 
+	// limiter enforces the connection limits of HTTPS listeners. It's nil
+	// for other listeners.
+	limiter *connlimit.Limiter
+
 	// proto is filled by the agent to "http" or "https".
 	proto string
 }
@@ -129,7 +150,7 @@ func (w *wrappedMux) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 }
 
 // handler is used to attach our handlers to the mux
-func (s *HTTPServer) handler(enableDebug bool) http.Handler {
+func (s *HTTPServer) handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// handleFuncMetrics takes the given pattern and handler and wraps to produce
@@ -178,9 +199,9 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 				return
 			}
 
-			// If enableDebug is not set, and ACLs are disabled, write
+			// If enable_debug is not set, and ACLs are disabled, write
 			// an unauthorized response
-			if !enableDebug {
+			if !s.agent.debugEnabled() {
 				if s.checkACLDisabled(resp, req) {
 					return
 				}
@@ -217,6 +238,15 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 	handlePProf("/debug/pprof/symbol", pprof.Symbol)
 	handlePProf("/debug/pprof/trace", pprof.Trace)
 
+	// The same handlers are available below the agent API so that they are
+	// reachable wherever the API is, e.g. through proxies which only forward
+	// /v1/ requests.
+	handlePProf("/v1/agent/debug/pprof/", agentPProfIndex)
+	handlePProf("/v1/agent/debug/pprof/cmdline", pprof.Cmdline)
+	handlePProf("/v1/agent/debug/pprof/profile", pprof.Profile)
+	handlePProf("/v1/agent/debug/pprof/symbol", pprof.Symbol)
+	handlePProf("/v1/agent/debug/pprof/trace", pprof.Trace)
+
 	if s.IsUIEnabled() {
 		legacy_ui, err := strconv.ParseBool(os.Getenv("CONSUL_UI_LEGACY"))
 		if err != nil {
@@ -259,6 +289,18 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 	}
 }
 
+// agentPProfIndex serves the pprof index and the named profiles like heap
+// or goroutine below /v1/agent/debug/pprof/ since pprof.Index only
+// handles requests below /debug/pprof/.
+func agentPProfIndex(resp http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/v1/agent/debug/pprof/")
+	if name != "" {
+		pprof.Handler(name).ServeHTTP(resp, req)
+		return
+	}
+	pprof.Index(resp, req)
+}
+
 // nodeName returns the node name of the agent
 func (s *HTTPServer) nodeName() string {
 	return s.agent.config.NodeName
@@ -293,6 +335,23 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 		setHeaders(resp, s.agent.config.HTTPResponseHeaders)
 		setTranslateAddr(resp, s.agent.config.TranslateWANAddrs)
 
+		// Tag the request with an ID that is passed along with its RPCs so
+		// it can be traced in the logs of the servers.
+		requestID := requestIDFromHeaders(req)
+		req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, requestID))
+		resp.Header().Set("X-Consul-Request-ID", requestID)
+
+		// Trace the request as a child of the caller's span, if any. The span
+		// ID is passed along with the RPCs made for the request.
+		_, parentID, _ := parseTraceparent(req.Header.Get("traceparent"))
+		span := tracing.Start(requestID, parentID, "HTTP "+req.Method, tracing.KindServer)
+		defer span.End(nil)
+		if span != nil {
+			span.SetAttribute("http.method", req.Method)
+			span.SetAttribute("http.target", req.URL.Path)
+			req = req.WithContext(context.WithValue(req.Context(), spanIDKey{}, span.ID()))
+		}
+
 		// Obfuscate any tokens from appearing in the logs
 		formVals, err := url.ParseQuery(req.URL.RawQuery)
 		if err != nil {
@@ -314,7 +373,7 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 
 		if s.blacklist.Block(req.URL.Path) {
 			errMsg := "Endpoint is blocked by agent configuration"
-			s.agent.logger.Printf("[ERR] http: Request %s %v, error: %v from=%s", req.Method, logURL, err, req.RemoteAddr)
+			s.agent.logger.Printf("[ERR] http: Request %s %v, error: %v from=%s request_id=%s", req.Method, logURL, err, req.RemoteAddr, requestID)
 			resp.WriteHeader(http.StatusForbidden)
 			fmt.Fprint(resp, errMsg)
 			return
@@ -338,6 +397,11 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 			return ok
 		}
 
+		isRequestTooLarge := func(err error) bool {
+			_, ok := err.(RequestTooLargeError)
+			return ok
+		}
+
 		isTooManyRequests := func(err error) bool {
 			// Sadness net/rpc can't do nice typed errors so this is all we got
 			return err.Error() == consul.ErrRateLimited.Error()
@@ -348,13 +412,17 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 		}
 
 		handleErr := func(err error) {
-			s.agent.logger.Printf("[ERR] http: Request %s %v, error: %v from=%s", req.Method, logURL, err, req.RemoteAddr)
+			s.agent.logger.Printf("[ERR] http: Request %s %v, error: %v from=%s request_id=%s", req.Method, logURL, err, req.RemoteAddr, requestID)
+			span.SetError(err)
 			switch {
 			case isForbidden(err):
 				resp.WriteHeader(http.StatusForbidden)
 				fmt.Fprint(resp, err.Error())
 			case structs.IsErrRPCRateExceeded(err):
 				resp.WriteHeader(http.StatusTooManyRequests)
+			case structs.IsErrQueryLimitExceeded(err):
+				resp.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(resp, err.Error())
 			case isMethodNotAllowed(err):
 				// RFC2616 states that for 405 Method Not Allowed the response
 				// MUST include an Allow header containing the list of valid
@@ -366,6 +434,9 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 			case isBadRequest(err):
 				resp.WriteHeader(http.StatusBadRequest)
 				fmt.Fprint(resp, err.Error())
+			case isRequestTooLarge(err):
+				resp.WriteHeader(http.StatusRequestEntityTooLarge)
+				fmt.Fprint(resp, err.Error())
 			case isTooManyRequests(err):
 				resp.WriteHeader(http.StatusTooManyRequests)
 				fmt.Fprint(resp, err.Error())
@@ -377,9 +448,16 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 
 		start := time.Now()
 		defer func() {
-			s.agent.logger.Printf("[DEBUG] http: Request %s %v (%v) from=%s", req.Method, logURL, time.Since(start), req.RemoteAddr)
+			s.agent.logger.Printf("[DEBUG] http: Request %s %v (%v) from=%s request_id=%s", req.Method, logURL, time.Since(start), req.RemoteAddr, requestID)
 		}()
 
+		// Attribute the request to its token for the request metrics.
+		if s.agent.tokenRequestMetrics != nil {
+			var token string
+			s.parseToken(req, &token)
+			s.agent.recordTokenRequest(token)
+		}
+
 		var obj interface{}
 
 		// if this endpoint has declared methods, respond appropriately to OPTIONS requests. Otherwise let the endpoint handle that.
@@ -402,6 +480,10 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 		} else {
 			err = s.checkWriteAccess(req)
 
+			if err == nil {
+				err = s.limitRequestBody(req)
+			}
+
 			if err == nil {
 				// Invoke the handler
 				obj, err = handler(resp, req)
@@ -744,6 +826,35 @@ func (s *HTTPServer) parseConsistency(resp http.ResponseWriter, req *http.Reques
 			defaults = false
 		}
 	}
+	// max-age and stale-if-error can also be given as query parameters
+	// for clients which can't set a Cache-Control header. The header takes
+	// precedence if both are given.
+	if maxAge := query.Get("max-age"); maxAge != "" {
+		dur, err := time.ParseDuration(maxAge)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid max-age value %q", maxAge)
+			return true
+		}
+		b.MaxAge = dur
+		if dur == 0 {
+			b.MustRevalidate = true
+		}
+		// Uncached stale reads are bounded by max-age as well, unless
+		// max_stale is given. Older results are fetched from the leader.
+		if b.AllowStale && !b.UseCache && b.MaxStaleDuration == 0 {
+			b.MaxStaleDuration = dur
+		}
+	}
+	if staleIfError := query.Get("stale-if-error"); staleIfError != "" {
+		dur, err := time.ParseDuration(staleIfError)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid stale-if-error value %q", staleIfError)
+			return true
+		}
+		b.StaleIfError = dur
+	}
 	// No specific Consistency has been specified by caller
 	if defaults {
 		path := req.URL.Path
@@ -767,6 +878,69 @@ func (s *HTTPServer) parseConsistency(resp http.ResponseWriter, req *http.Reques
 	return false
 }
 
+// staleIfError switches a read that failed because the cluster has no leader
+// to a stale read if the request accepts stale results on errors. The stale
+// result must not be older than the stale-if-error duration, otherwise the
+// read is retried against the leader. It returns true if the read should be
+// retried. The switch is only made once per request.
+func staleIfError(q *structs.QueryOptions, err error) bool {
+	if q.StaleIfError <= 0 || q.AllowStale || q.RequireConsistent || !structs.IsErrNoLeader(err) {
+		return false
+	}
+	q.AllowStale = true
+	q.MaxStaleDuration = q.StaleIfError
+	q.StaleIfError = 0
+	return true
+}
+
+// requestBodyClass returns the endpoint class used to pick the body size
+// limit of a request, or "" if the body of the endpoint isn't limited.
+func requestBodyClass(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/v1/kv/"):
+		return "kv"
+	case strings.HasPrefix(path, "/v1/txn"):
+		return "txn"
+	case strings.HasPrefix(path, "/v1/snapshot"):
+		// Snapshots are streamed and can be of any size.
+		return ""
+	}
+	return "default"
+}
+
+// limitRequestBody reads the body of the request up to the limit of its
+// endpoint class and returns a RequestTooLargeError if it's larger. This
+// makes oversized requests fail up front, rather than with an opaque error
+// once they reach the servers. The body is buffered so handlers see a body
+// with a known length.
+func (s *HTTPServer) limitRequestBody(req *http.Request) error {
+	class := requestBodyClass(req.URL.Path)
+	limit := s.agent.config.HTTPMaxRequestBodyBytes[class]
+	if class == "" || limit <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	tooLarge := func() error {
+		metrics.IncrCounterWithLabels([]string{"http", "request_too_large"}, 1,
+			[]metrics.Label{{Name: "class", Value: class}})
+		return RequestTooLargeError{Limit: limit}
+	}
+	if req.ContentLength > int64(limit) {
+		return tooLarge()
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, int64(limit)+1))
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	if len(body) > limit {
+		return tooLarge()
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
 // parseDC is used to parse the ?dc query param
 func (s *HTTPServer) parseDC(req *http.Request, dc *string) {
 	if other := req.URL.Query().Get("dc"); other != "" {
@@ -887,11 +1061,70 @@ func (s *HTTPServer) parseMetaFilter(req *http.Request) map[string]string {
 	return nil
 }
 
+// requestIDKey is the context key of the request ID set by wrap.
+type requestIDKey struct{}
+
+// traceparentRE matches a W3C traceparent header and captures its version,
+// trace ID and parent ID. See https://www.w3.org/TR/trace-context/.
+var traceparentRE = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}(-|$)`)
+
+// spanIDKey is the context key of the ID of the tracing span set by wrap.
+type spanIDKey struct{}
+
+// parseTraceparent returns the trace ID and parent ID of a W3C traceparent
+// header, and false if the header isn't valid.
+func parseTraceparent(header string) (string, string, bool) {
+	if m := traceparentRE.FindStringSubmatch(header); m != nil {
+		valid := m[1] != "ff" &&
+			(m[1] != "00" || len(header) == 55) &&
+			m[2] != strings.Repeat("0", 32) &&
+			m[3] != strings.Repeat("0", 16)
+		if valid {
+			return m[2], m[3], true
+		}
+	}
+	return "", "", false
+}
+
+// requestIDFromHeaders returns the ID of the given request. The trace ID of a
+// valid traceparent header is used so the request can be correlated with the
+// trace of the caller. Otherwise a random ID in the same format is generated.
+func requestIDFromHeaders(req *http.Request) string {
+	if traceID, _, ok := parseTraceparent(req.Header.Get("traceparent")); ok {
+		return traceID
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// parseRequestID sets the ID of the API request on an RPC request so it's
+// passed along to the servers.
+func parseRequestID(req *http.Request, id *string) {
+	if v, ok := req.Context().Value(requestIDKey{}).(string); ok {
+		*id = v
+	}
+}
+
+// parseSpanID sets the ID of the tracing span of the API request on an RPC
+// request so the spans of the servers handling it are recorded as its
+// children.
+func parseSpanID(req *http.Request, id *string) {
+	if v, ok := req.Context().Value(spanIDKey{}).(string); ok {
+		*id = v
+	}
+}
+
 // parseInternal is a convenience method for endpoints that need
 // to use both parseWait and parseDC.
 func (s *HTTPServer) parseInternal(resp http.ResponseWriter, req *http.Request, dc *string, b *structs.QueryOptions, resolveProxyToken bool) bool {
 	s.parseDC(req, dc)
 	s.parseTokenInternal(req, &b.Token, resolveProxyToken)
+	parseRequestID(req, &b.RequestID)
+	parseSpanID(req, &b.SpanID)
 	if s.parseConsistency(resp, req, b) {
 		return true
 	}
@@ -3,6 +3,7 @@ package agent
 func init() {
 	allowedMethods = make(map[string][]string)
 
+	registerEndpoint("/v1/acl/anonymous", []string{"GET", "PUT"}, (*HTTPServer).ACLAnonymous)
 	registerEndpoint("/v1/acl/bootstrap", []string{"PUT"}, (*HTTPServer).ACLBootstrap)
 	registerEndpoint("/v1/acl/create", []string{"PUT"}, (*HTTPServer).ACLCreate)
 	registerEndpoint("/v1/acl/update", []string{"PUT"}, (*HTTPServer).ACLUpdate)
@@ -46,10 +47,13 @@ func init() {
 	registerEndpoint("/v1/agent/connect/ca/roots", []string{"GET"}, (*HTTPServer).AgentConnectCARoots)
 	registerEndpoint("/v1/agent/connect/ca/leaf/", []string{"GET"}, (*HTTPServer).AgentConnectCALeafCert)
 	registerEndpoint("/v1/agent/connect/proxy/", []string{"GET"}, (*HTTPServer).AgentConnectProxyConfig)
+	registerEndpoint("/v1/agent/connect/proxy-config/", []string{"GET"}, (*HTTPServer).AgentConnectProxySnapshot)
+	registerEndpoint("/v1/agent/connect/redirect-traffic/", []string{"GET"}, (*HTTPServer).AgentConnectRedirectTraffic)
 	registerEndpoint("/v1/agent/service/register", []string{"PUT"}, (*HTTPServer).AgentRegisterService)
 	registerEndpoint("/v1/agent/service/deregister/", []string{"PUT"}, (*HTTPServer).AgentDeregisterService)
 	registerEndpoint("/v1/agent/service/maintenance/", []string{"PUT"}, (*HTTPServer).AgentServiceMaintenance)
 	registerEndpoint("/v1/catalog/register", []string{"PUT"}, (*HTTPServer).CatalogRegister)
+	registerEndpoint("/v1/catalog/bulk-register", []string{"PUT"}, (*HTTPServer).CatalogBulkRegister)
 	registerEndpoint("/v1/catalog/connect/", []string{"GET"}, (*HTTPServer).CatalogConnectServiceNodes)
 	registerEndpoint("/v1/catalog/deregister", []string{"PUT"}, (*HTTPServer).CatalogDeregister)
 	registerEndpoint("/v1/catalog/datacenters", []string{"GET"}, (*HTTPServer).CatalogDatacenters)
@@ -57,6 +61,10 @@ func init() {
 	registerEndpoint("/v1/catalog/services", []string{"GET"}, (*HTTPServer).CatalogServices)
 	registerEndpoint("/v1/catalog/service/", []string{"GET"}, (*HTTPServer).CatalogServiceNodes)
 	registerEndpoint("/v1/catalog/node/", []string{"GET"}, (*HTTPServer).CatalogNodeServices)
+	registerEndpoint("/v1/catalog/weight-override/", []string{"PUT", "DELETE"}, (*HTTPServer).CatalogWeightOverride)
+	registerEndpoint("/v1/catalog/weight-overrides", []string{"GET"}, (*HTTPServer).CatalogWeightOverrides)
+	registerEndpoint("/v1/config/", []string{"GET", "DELETE"}, (*HTTPServer).Config)
+	registerEndpoint("/v1/config", []string{"PUT"}, (*HTTPServer).ConfigApply)
 	registerEndpoint("/v1/connect/ca/configuration", []string{"GET", "PUT"}, (*HTTPServer).ConnectCAConfiguration)
 	registerEndpoint("/v1/connect/ca/roots", []string{"GET"}, (*HTTPServer).ConnectCARoots)
 	registerEndpoint("/v1/connect/intentions", []string{"GET", "POST"}, (*HTTPServer).IntentionEndpoint)
@@ -77,12 +85,19 @@ func init() {
 	registerEndpoint("/v1/internal/ui/nodes", []string{"GET"}, (*HTTPServer).UINodes)
 	registerEndpoint("/v1/internal/ui/node/", []string{"GET"}, (*HTTPServer).UINodeInfo)
 	registerEndpoint("/v1/internal/ui/services", []string{"GET"}, (*HTTPServer).UIServices)
+	registerEndpoint("/v1/internal/ui/gateway-services-nodes/", []string{"GET"}, (*HTTPServer).UIGatewayServicesNodes)
+	registerEndpoint("/v1/internal/ui/service-topology/", []string{"GET"}, (*HTTPServer).UIServiceTopology)
+	registerEndpoint("/v1/internal/ui/metrics-proxy/", []string{"GET"}, (*HTTPServer).UIMetricsProxy)
 	registerEndpoint("/v1/kv/", []string{"GET", "PUT", "DELETE"}, (*HTTPServer).KVSEndpoint)
+	registerEndpoint("/v1/kv-snapshot", []string{"GET"}, (*HTTPServer).KVSSnapshot)
+	registerEndpoint("/v1/operator/audit", []string{"GET"}, (*HTTPServer).OperatorAuditTrail)
 	registerEndpoint("/v1/operator/raft/configuration", []string{"GET"}, (*HTTPServer).OperatorRaftConfiguration)
 	registerEndpoint("/v1/operator/raft/peer", []string{"DELETE"}, (*HTTPServer).OperatorRaftPeer)
 	registerEndpoint("/v1/operator/keyring", []string{"GET", "POST", "PUT", "DELETE"}, (*HTTPServer).OperatorKeyringEndpoint)
 	registerEndpoint("/v1/operator/autopilot/configuration", []string{"GET", "PUT"}, (*HTTPServer).OperatorAutopilotConfiguration)
 	registerEndpoint("/v1/operator/autopilot/health", []string{"GET"}, (*HTTPServer).OperatorServerHealth)
+	registerEndpoint("/v1/operator/autopilot/removals", []string{"GET"}, (*HTTPServer).OperatorAutopilotRemovals)
+	registerEndpoint("/v1/operator/usage", []string{"GET"}, (*HTTPServer).OperatorStateUsage)
 	registerEndpoint("/v1/query", []string{"GET", "POST"}, (*HTTPServer).PreparedQueryGeneral)
 	// specific prepared query endpoints have more complex rules for allowed methods, so
 	// the prefix is registered with no methods.
@@ -95,6 +110,9 @@ func init() {
 	registerEndpoint("/v1/session/list", []string{"GET"}, (*HTTPServer).SessionList)
 	registerEndpoint("/v1/status/leader", []string{"GET"}, (*HTTPServer).StatusLeader)
 	registerEndpoint("/v1/status/peers", []string{"GET"}, (*HTTPServer).StatusPeers)
+	registerEndpoint("/v1/status/cluster-id", []string{"GET"}, (*HTTPServer).StatusClusterID)
+	registerEndpoint("/v1/status/readiness", []string{"GET"}, (*HTTPServer).StatusReadiness)
+	registerEndpoint("/v1/status/liveness", []string{"GET"}, (*HTTPServer).StatusLiveness)
 	registerEndpoint("/v1/snapshot", []string{"GET", "PUT"}, (*HTTPServer).Snapshot)
 	registerEndpoint("/v1/txn", []string{"PUT"}, (*HTTPServer).Txn)
 }
@@ -319,6 +319,52 @@ func TestHTTPAPI_BlockEndpoints(t *testing.T) {
 	}
 }
 
+func TestHTTPAPI_RequestBodyLimits(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), `
+		http_config {
+			max_request_body_bytes {
+				default = 8
+				kv = 16
+			}
+		}
+	`)
+	defer a.Shutdown()
+
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return string(body), nil
+	}
+
+	for _, tt := range []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/v1/agent/service/register", "12345678", http.StatusOK},
+		{"/v1/agent/service/register", "123456789", http.StatusRequestEntityTooLarge},
+		{"/v1/kv/foo", "123456789", http.StatusOK},
+		{"/v1/kv/foo", "12345678901234567", http.StatusRequestEntityTooLarge},
+		{"/v1/snapshot", "12345678901234567", http.StatusOK},
+	} {
+		// Chunked bodies with an unknown length are limited as well.
+		for _, length := range []int64{int64(len(tt.body)), -1} {
+			req, _ := http.NewRequest("PUT", tt.path, strings.NewReader(tt.body))
+			req.ContentLength = length
+			resp := httptest.NewRecorder()
+			a.srv.wrap(handler, []string{"PUT"})(resp, req)
+			require.Equal(t, tt.code, resp.Code, "%s with %d bytes", tt.path, len(tt.body))
+			if tt.code == http.StatusOK {
+				require.Equal(t, `"`+tt.body+`"`, resp.Body.String())
+			}
+		}
+	}
+}
+
 func TestHTTPAPI_Ban_Nonprintable_Characters(t *testing.T) {
 	a := NewTestAgent(t, t.Name(), "")
 	defer a.Shutdown()
@@ -493,6 +539,139 @@ func TestHTTP_wrap_obfuscateLog(t *testing.T) {
 	}
 }
 
+func TestHTTP_wrap_requestID(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	a := &TestAgent{Name: t.Name(), LogOutput: buf}
+	a.Start(t)
+	defer a.Shutdown()
+
+	var args structs.DCSpecificRequest
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		a.srv.parse(resp, req, &args.Datacenter, &args.QueryOptions)
+		return nil, nil
+	}
+
+	// The trace ID of the caller is used.
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/catalog/nodes", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	a.srv.wrap(handler, []string{"GET"})(resp, req)
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", resp.Header().Get("X-Consul-Request-ID"))
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", args.RequestID)
+	require.Contains(t, buf.String(), "request_id=4bf92f3577b34da6a3ce929d0e0e4736")
+
+	// Otherwise a new one is generated for each request.
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/catalog/nodes", nil)
+	a.srv.wrap(handler, []string{"GET"})(resp, req)
+
+	id := resp.Header().Get("X-Consul-Request-ID")
+	require.Len(t, id, 32)
+	require.Equal(t, id, args.RequestID)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/catalog/nodes", nil)
+	a.srv.wrap(handler, []string{"GET"})(resp, req)
+	require.NotEqual(t, id, resp.Header().Get("X-Consul-Request-ID"))
+}
+
+func TestRequestIDFromHeaders(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		traceparent string
+		used        bool
+	}{
+		"valid":             {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		"future version":    {"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		"invalid version":   {"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		"zero trace ID":     {"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		"zero parent ID":    {"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		"trailing data":     {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		"uppercase":         {"00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false},
+		"short trace ID":    {"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false},
+		"no header":         {"", false},
+		"unrelated garbage": {"nope", false},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/catalog/nodes", nil)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+
+			id := requestIDFromHeaders(req)
+			if tc.used {
+				require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", id)
+			} else {
+				require.Len(t, id, 32)
+				require.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", id)
+			}
+		})
+	}
+}
+
+func TestHTTPAPI_Tracing(t *testing.T) {
+	// Not parallel since the tracer is installed globally.
+	spans := make(chan map[string]interface{}, 100)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]interface{}
+				}
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					spans <- span
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	a := NewTestAgent(t, t.Name(), `
+		telemetry {
+			otlp_traces_endpoint = "`+collector.URL+`/v1/traces"
+		}
+	`)
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/catalog/nodes?index=1&wait=1s", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp := httptest.NewRecorder()
+	a.srv.Handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// Shutting down the agent pushes the spans.
+	a.Shutdown()
+	close(spans)
+	byName := make(map[string]map[string]interface{})
+	for span := range spans {
+		if span["traceId"] == "4bf92f3577b34da6a3ce929d0e0e4736" {
+			byName[span["name"].(string)] = span
+		}
+	}
+
+	httpSpan := byName["HTTP GET"]
+	require.NotNil(t, httpSpan)
+	require.Equal(t, "00f067aa0ba902b7", httpSpan["parentSpanId"])
+
+	querySpan := byName["blocking query"]
+	require.NotNil(t, querySpan)
+	require.Equal(t, httpSpan["spanId"], querySpan["parentSpanId"])
+}
+
 func TestPrettyPrint(t *testing.T) {
 	t.Parallel()
 	testPrettyPrint("pretty=1", t)
@@ -754,6 +933,26 @@ func TestPProfHandlers_DisableDebugNoACLs(t *testing.T) {
 	require.Equal(http.StatusUnauthorized, resp.Code)
 }
 
+func TestPProfHandlers_EnableDebugReload(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "enable_debug = false")
+	defer a.Shutdown()
+
+	get := func() int {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/agent/debug/pprof/heap", nil)
+		a.srv.Handler.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	require.Equal(http.StatusUnauthorized, get())
+
+	cfg := *a.config
+	cfg.EnableDebug = true
+	require.NoError(a.ReloadConfig(&cfg))
+	require.Equal(http.StatusOK, get())
+}
+
 func TestPProfHandlers_ACLs(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -811,6 +1010,30 @@ func TestPProfHandlers_ACLs(t *testing.T) {
 			endpoint:    "/debug/pprof/heap",
 			nilResponse: true,
 		},
+		{
+			code:        http.StatusOK,
+			token:       "master",
+			endpoint:    "/v1/agent/debug/pprof/heap",
+			nilResponse: false,
+		},
+		{
+			code:        http.StatusOK,
+			token:       "master",
+			endpoint:    "/v1/agent/debug/pprof/",
+			nilResponse: false,
+		},
+		{
+			code:        http.StatusForbidden,
+			token:       "agent",
+			endpoint:    "/v1/agent/debug/pprof/heap",
+			nilResponse: true,
+		},
+		{
+			code:        http.StatusNotFound,
+			token:       "master",
+			endpoint:    "/v1/agent/debug/pprof/bogus",
+			nilResponse: true,
+		},
 	}
 
 	defer a.Shutdown()
@@ -961,6 +1184,62 @@ func TestParseConsistency_Invalid(t *testing.T) {
 	}
 }
 
+func TestParseConsistency_MaxAgeAndStaleIfError(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	resp := httptest.NewRecorder()
+	var b structs.QueryOptions
+	req, _ := http.NewRequest("GET", "/v1/health/service/foo?cached&max-age=30s&stale-if-error=5m", nil)
+	require.False(t, a.srv.parseConsistency(resp, req, &b))
+	require.Equal(t, structs.QueryOptions{
+		UseCache:     true,
+		MaxAge:       30 * time.Second,
+		StaleIfError: 5 * time.Minute,
+	}, b)
+
+	b = structs.QueryOptions{}
+	req, _ = http.NewRequest("GET", "/v1/health/service/foo?max-age=0s", nil)
+	require.False(t, a.srv.parseConsistency(resp, req, &b))
+	require.True(t, b.MustRevalidate)
+
+	b = structs.QueryOptions{}
+	req, _ = http.NewRequest("GET", "/v1/health/service/foo?stale&max-age=30s", nil)
+	require.False(t, a.srv.parseConsistency(resp, req, &b))
+	require.True(t, b.AllowStale)
+	require.Equal(t, 30*time.Second, b.MaxStaleDuration)
+
+	b = structs.QueryOptions{}
+	req, _ = http.NewRequest("GET", "/v1/health/service/foo?stale-if-error=10", nil)
+	require.True(t, a.srv.parseConsistency(resp, req, &b))
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestStaleIfError(t *testing.T) {
+	t.Parallel()
+
+	// Falls back to a stale read bounded by stale-if-error once.
+	q := structs.QueryOptions{StaleIfError: 5 * time.Second}
+	require.True(t, staleIfError(&q, structs.ErrNoLeader))
+	require.True(t, q.AllowStale)
+	require.Equal(t, 5*time.Second, q.MaxStaleDuration)
+	q.AllowStale = false
+	require.False(t, staleIfError(&q, structs.ErrNoLeader))
+
+	// Only for errors caused by a missing leader.
+	q = structs.QueryOptions{StaleIfError: 5 * time.Second}
+	require.False(t, staleIfError(&q, fmt.Errorf("permission denied")))
+
+	// Not for reads which are already stale or must be consistent.
+	q = structs.QueryOptions{StaleIfError: 5 * time.Second, AllowStale: true}
+	require.False(t, staleIfError(&q, structs.ErrNoLeader))
+	q = structs.QueryOptions{StaleIfError: 5 * time.Second, RequireConsistent: true}
+	require.False(t, staleIfError(&q, structs.ErrNoLeader))
+	q = structs.QueryOptions{}
+	require.False(t, staleIfError(&q, structs.ErrNoLeader))
+}
+
 // Test ACL token is resolved in correct order
 func TestACLResolution(t *testing.T) {
 	t.Parallel()
@@ -50,6 +50,8 @@ func (s *HTTPServer) IntentionCreate(resp http.ResponseWriter, req *http.Request
 	}
 	s.parseDC(req, &args.Datacenter)
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 	if err := decodeBody(req, &args.Intention, nil); err != nil {
 		return nil, fmt.Errorf("Failed to decode request body: %s", err)
 	}
@@ -243,6 +245,8 @@ func (s *HTTPServer) IntentionSpecificUpdate(id string, resp http.ResponseWriter
 	}
 	s.parseDC(req, &args.Datacenter)
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 	if err := decodeBody(req, &args.Intention, nil); err != nil {
 		resp.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(resp, "Request decode failed: %v", err)
@@ -272,6 +276,8 @@ func (s *HTTPServer) IntentionSpecificDelete(id string, resp http.ResponseWriter
 	}
 	s.parseDC(req, &args.Datacenter)
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	var reply string
 	if err := s.agent.RPC("Intention.Apply", &args, &reply); err != nil {
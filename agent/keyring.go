@@ -138,6 +138,15 @@ func (a *Agent) ListKeys(token string, relayFactor uint8) (*structs.KeyringRespo
 	return a.keyringProcess(&args)
 }
 
+// ListKeysDetailed lists out all keys installed on the collective Consul
+// cluster, along with the keys installed on each node and the primary key it
+// is using.
+func (a *Agent) ListKeysDetailed(token string, relayFactor uint8) (*structs.KeyringResponses, error) {
+	args := structs.KeyringRequest{Operation: structs.KeyringList, Detailed: true}
+	parseKeyringRequest(&args, token, relayFactor)
+	return a.keyringProcess(&args)
+}
+
 // InstallKey installs a new gossip encryption key
 func (a *Agent) InstallKey(key, token string, relayFactor uint8) (*structs.KeyringResponses, error) {
 	args := structs.KeyringRequest{Key: key, Operation: structs.KeyringInstall}
@@ -88,6 +88,36 @@ func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *s
 	return out.Entries, nil
 }
 
+// kvSnapshot is the body of a KV snapshot response. The index is returned
+// along with the entries so it can be recorded with rendered output.
+type kvSnapshot struct {
+	Index   uint64
+	Entries structs.DirEntries
+}
+
+// KVSSnapshot returns the entries of the given key prefixes read from a
+// single snapshot of the KV store, with all their metadata.
+func (s *HTTPServer) KVSSnapshot(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.KeyPrefixesRequest{
+		Prefixes: req.URL.Query()["prefix"],
+	}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var out structs.IndexedDirEntries
+	if err := s.agent.RPC("KVS.ListPrefixes", &args, &out); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &out.QueryMeta)
+
+	// Use empty list instead of null, an empty snapshot isn't an error
+	if out.Entries == nil {
+		out.Entries = structs.DirEntries{}
+	}
+	return kvSnapshot{Index: out.Index, Entries: out.Entries}, nil
+}
+
 // KVSGetKeys handles a GET request for keys
 func (s *HTTPServer) KVSGetKeys(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
 	// Check for a separator, due to historic spelling error,
@@ -148,6 +178,7 @@ func (s *HTTPServer) KVSPut(resp http.ResponseWriter, req *http.Request, args *s
 		},
 	}
 	applyReq.Token = args.Token
+	applyReq.RequestID = args.RequestID
 
 	// Check for flags
 	params := req.URL.Query()
@@ -221,6 +252,7 @@ func (s *HTTPServer) KVSDelete(resp http.ResponseWriter, req *http.Request, args
 		},
 	}
 	applyReq.Token = args.Token
+	applyReq.RequestID = args.RequestID
 
 	// Check for recurse
 	params := req.URL.Query()
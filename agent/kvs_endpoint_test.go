@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/hashicorp/consul/testrpc"
@@ -148,6 +149,67 @@ func TestKVSEndpoint_Recurse(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_Snapshot(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	{
+		// An empty snapshot isn't an error
+		req, _ := http.NewRequest("GET", "/v1/kv-snapshot?prefix=web/", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSSnapshot(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertIndex(t, resp)
+
+		res := obj.(kvSnapshot)
+		if res.Entries == nil || len(res.Entries) != 0 {
+			t.Fatalf("bad: %v", res)
+		}
+	}
+
+	keys := []string{
+		"db/port",
+		"other",
+		"web/port",
+		"web/tls/cert",
+	}
+
+	for _, key := range keys {
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/"+key+"?flags=42", buf)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/kv-snapshot?prefix=web/&prefix=db/", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.KVSSnapshot(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertIndex(t, resp)
+
+	res := obj.(kvSnapshot)
+	if got, want := resp.Header().Get("X-Consul-Index"), strconv.FormatUint(res.Index, 10); got != want {
+		t.Fatalf("got index %s want %s", got, want)
+	}
+	want := []string{"db/port", "web/port", "web/tls/cert"}
+	if len(res.Entries) != len(want) {
+		t.Fatalf("bad: %v", res.Entries)
+	}
+	for i, key := range want {
+		e := res.Entries[i]
+		if e.Key != key || e.Flags != 42 || string(e.Value) != "test" || e.ModifyIndex > res.Index {
+			t.Fatalf("bad: %v", e)
+		}
+	}
+}
+
 func TestKVSEndpoint_DELETE_CAS(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -3,6 +3,7 @@ package local
 import (
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"reflect"
 	"strconv"
@@ -22,9 +23,21 @@ import (
 	uuid "github.com/hashicorp/go-uuid"
 )
 
+const (
+	// flapHalfLife is the time after which a status transition of a health
+	// check counts half as much for its flap score.
+	flapHalfLife = 5 * time.Minute
+
+	// flapThreshold is the flap score from which a health check is
+	// considered flapping. It takes four status transitions within a few
+	// minutes to reach it.
+	flapThreshold = 3.5
+)
+
 // Config is the configuration for the State.
 type Config struct {
 	AdvertiseAddr       string
+	CheckFlapHoldDown   time.Duration
 	CheckUpdateInterval time.Duration
 	Datacenter          string
 	DiscardCheckOutput  bool
@@ -86,6 +99,23 @@ type CheckState struct {
 	// do not affect the state of the node and/or service.
 	DeferCheck *time.Timer
 
+	// StatusChangeTime is the last time the status reported by the health
+	// check changed, or when it was added.
+	StatusChangeTime time.Time
+
+	// flapScore counts the recent status transitions of the health check.
+	// It has been decaying since flapTime, when it was last incremented.
+	flapScore float64
+	flapTime  time.Time
+
+	// HoldDown is running while the status changes of a flapping health
+	// check are held down. It is restarted on every status change, so that
+	// the latest status, which is held in PendingStatus and PendingOutput,
+	// is only synced once it has been stable for the hold-down interval.
+	HoldDown      *time.Timer
+	PendingStatus string
+	PendingOutput string
+
 	// InSync contains whether the local state of the health check
 	// record is in sync with the remote state on the server.
 	InSync bool
@@ -115,6 +145,25 @@ func (c *CheckState) CriticalFor() time.Duration {
 	return time.Since(c.CriticalTime)
 }
 
+// FlapScore returns the number of recent status transitions of the health
+// check, where each transition counts half as much after flapHalfLife.
+func (c *CheckState) FlapScore() float64 {
+	return c.flapScoreAt(time.Now())
+}
+
+func (c *CheckState) flapScoreAt(now time.Time) float64 {
+	if c.flapScore == 0 {
+		return 0
+	}
+	return c.flapScore * math.Pow(0.5, float64(now.Sub(c.flapTime))/float64(flapHalfLife))
+}
+
+// Flapping returns true when the status of the health check changed often
+// enough recently to consider it flapping.
+func (c *CheckState) Flapping() bool {
+	return c.FlapScore() >= flapThreshold
+}
+
 type rpc interface {
 	RPC(method string, args interface{}, reply interface{}) error
 }
@@ -492,8 +541,9 @@ func (l *State) addCheckLocked(check *structs.HealthCheck, token string) error {
 	check.Node = l.config.NodeName
 
 	l.setCheckStateLocked(&CheckState{
-		Check: check,
-		Token: token,
+		Check:            check,
+		Token:            token,
+		StatusChangeTime: time.Now(),
 	})
 	return nil
 }
@@ -582,6 +632,53 @@ func (l *State) UpdateCheck(id types.CheckID, status, output string) {
 		c.CriticalTime = time.Time{}
 	}
 
+	// Track the status transitions of the check. While they are held down
+	// the latest status is the pending one.
+	now := time.Now()
+	last := c.Check.Status
+	if c.HoldDown != nil {
+		last = c.PendingStatus
+	}
+	if status != last {
+		c.StatusChangeTime = now
+		c.flapScore = c.flapScoreAt(now) + 1
+		c.flapTime = now
+	}
+
+	// Hold down the status changes of a flapping check until the status
+	// has been stable for the hold-down interval, so that the servers
+	// don't see every transition.
+	hold := l.config.CheckFlapHoldDown
+	if hold > 0 && (c.HoldDown != nil || (status != c.Check.Status && c.flapScoreAt(now) >= flapThreshold)) {
+		if c.HoldDown == nil || status != last {
+			if c.HoldDown != nil {
+				c.HoldDown.Stop()
+			}
+			var t *time.Timer
+			t = time.AfterFunc(hold, func() {
+				l.Lock()
+				defer l.Unlock()
+
+				// The timer may have been replaced while it fired.
+				c := l.checks[id]
+				if c == nil || c.HoldDown != t {
+					return
+				}
+				c.HoldDown = nil
+				if c.Deleted {
+					return
+				}
+				if c.Check.Status != c.PendingStatus || c.Check.Output != c.PendingOutput {
+					l.setCheckStatusLocked(c, c.PendingStatus, c.PendingOutput)
+				}
+			})
+			c.HoldDown = t
+		}
+		c.PendingStatus = status
+		c.PendingOutput = output
+		return
+	}
+
 	// Do nothing if update is idempotent
 	if c.Check.Status == status && c.Check.Output == output {
 		return
@@ -615,6 +712,12 @@ func (l *State) UpdateCheck(id types.CheckID, status, output string) {
 		return
 	}
 
+	l.setCheckStatusLocked(c, status, output)
+}
+
+// setCheckStatusLocked updates the status of the check and marks it out of
+// sync. This must be called with the lock held.
+func (l *State) setCheckStatusLocked(c *CheckState, status, output string) {
 	// If this is a check for an aliased service, then notify the waiters.
 	if aliases, ok := l.checkAliases[c.Check.ServiceID]; ok && len(aliases) > 0 {
 		for _, notifyCh := range aliases {
@@ -1308,6 +1411,9 @@ func (l *State) deleteCheck(id types.CheckID) error {
 		if c != nil && c.DeferCheck != nil {
 			c.DeferCheck.Stop()
 		}
+		if c != nil && c.HoldDown != nil {
+			c.HoldDown.Stop()
+		}
 		delete(l.checks, id)
 		l.logger.Printf("[INFO] agent: Deregistered check %q", id)
 		return nil
@@ -1763,6 +1763,81 @@ func TestAgent_CheckCriticalTime(t *testing.T) {
 	}
 }
 
+func TestAgent_CheckFlapping(t *testing.T) {
+	t.Parallel()
+	cfg := config.DefaultRuntimeConfig(`bind_addr = "127.0.0.1" data_dir = "dummy"`)
+	l := local.NewState(agent.LocalConfig(cfg), nil, new(token.Store))
+	l.TriggerSyncChanges = func() {}
+
+	checkID := types.CheckID("redis:1")
+	l.AddCheck(&structs.HealthCheck{CheckID: checkID, Status: api.HealthPassing}, "")
+	c := l.CheckState(checkID)
+	require.False(t, c.StatusChangeTime.IsZero())
+	require.Zero(t, c.FlapScore())
+	added := c.StatusChangeTime
+
+	// Updates which don't change the status aren't transitions.
+	time.Sleep(10 * time.Millisecond)
+	l.UpdateCheck(checkID, api.HealthPassing, "still fine")
+	c = l.CheckState(checkID)
+	require.Equal(t, added, c.StatusChangeTime)
+	require.Zero(t, c.FlapScore())
+
+	// Every status change is counted.
+	statuses := []string{api.HealthCritical, api.HealthPassing, api.HealthWarning, api.HealthPassing}
+	for i, status := range statuses {
+		l.UpdateCheck(checkID, status, "")
+		c = l.CheckState(checkID)
+		require.True(t, c.StatusChangeTime.After(added))
+		require.InDelta(t, float64(i+1), c.FlapScore(), 0.01)
+	}
+	require.True(t, c.Flapping())
+
+	// Without a hold-down interval the status is synced right away.
+	require.Equal(t, api.HealthPassing, c.Check.Status)
+	require.Nil(t, c.HoldDown)
+}
+
+func TestAgent_CheckFlapHoldDown(t *testing.T) {
+	t.Parallel()
+	cfg := config.DefaultRuntimeConfig(`bind_addr = "127.0.0.1" data_dir = "dummy" check_flap_hold_down = "100ms"`)
+	l := local.NewState(agent.LocalConfig(cfg), nil, new(token.Store))
+	l.TriggerSyncChanges = func() {}
+
+	checkID := types.CheckID("redis:1")
+	l.AddCheck(&structs.HealthCheck{CheckID: checkID, Status: api.HealthPassing}, "")
+
+	// The first status changes aren't held down.
+	for _, status := range []string{api.HealthCritical, api.HealthPassing, api.HealthCritical} {
+		l.UpdateCheck(checkID, status, "")
+		require.Equal(t, status, l.Check(checkID).Status)
+	}
+
+	// Once the check is flapping, its status changes are held down until
+	// the status is stable.
+	l.UpdateCheck(checkID, api.HealthPassing, "ok")
+	c := l.CheckState(checkID)
+	require.True(t, c.Flapping())
+	require.NotNil(t, c.HoldDown)
+	require.Equal(t, api.HealthPassing, c.PendingStatus)
+	require.Equal(t, api.HealthCritical, c.Check.Status)
+
+	// A flap back to the synced status isn't synced either.
+	l.UpdateCheck(checkID, api.HealthCritical, "")
+	l.UpdateCheck(checkID, api.HealthPassing, "ok")
+	require.Equal(t, api.HealthCritical, l.Check(checkID).Status)
+
+	retry.Run(t, func(r *retry.R) {
+		c := l.CheckState(checkID)
+		if c.HoldDown != nil {
+			r.Fatal("still held down")
+		}
+		if c.Check.Status != api.HealthPassing || c.Check.Output != "ok" {
+			r.Fatalf("bad: %#v", c.Check)
+		}
+	})
+}
+
 func TestAgent_AddCheckFailure(t *testing.T) {
 	t.Parallel()
 	cfg := config.DefaultRuntimeConfig(`bind_addr = "127.0.0.1" data_dir = "dummy"`)
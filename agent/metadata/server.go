@@ -31,6 +31,7 @@ type Server struct {
 	Port         int
 	SegmentAddrs map[string]string
 	SegmentPorts map[string]int
+	WANAddrs     map[string]string
 	WanJoinPort  int
 	Bootstrap    bool
 	Expect       int
@@ -42,8 +43,14 @@ type Server struct {
 	NonVoter     bool
 	ACLs         structs.ACLMode
 
+	// ClusterID is the bootstrap cluster ID the server advertises, if any.
+	ClusterID string
+
 	// If true, use TLS when connecting to this server
 	UseTLS bool
+
+	// If true, the server serves the gRPC transport on its RPC port
+	UseGRPC bool
 }
 
 // Key returns the corresponding Key
@@ -77,6 +84,7 @@ func IsConsulServer(m serf.Member) (bool, *Server) {
 	segment := m.Tags["segment"]
 	_, bootstrap := m.Tags["bootstrap"]
 	_, useTLS := m.Tags["use_tls"]
+	_, useGRPC := m.Tags["grpc"]
 
 	expect := 0
 	expectStr, ok := m.Tags["expect"]
@@ -103,7 +111,14 @@ func IsConsulServer(m serf.Member) (bool, *Server) {
 
 	segmentAddrs := make(map[string]string)
 	segmentPorts := make(map[string]int)
+	var wanAddrs map[string]string
 	for name, value := range m.Tags {
+		if strings.HasPrefix(name, "wan_addr_") {
+			if wanAddrs == nil {
+				wanAddrs = make(map[string]string)
+			}
+			wanAddrs[strings.TrimPrefix(name, "wan_addr_")] = value
+		}
 		if strings.HasPrefix(name, "sl_") {
 			addr, port, err := net.SplitHostPort(value)
 			if err != nil {
@@ -162,6 +177,7 @@ func IsConsulServer(m serf.Member) (bool, *Server) {
 		Port:         port,
 		SegmentAddrs: segmentAddrs,
 		SegmentPorts: segmentPorts,
+		WANAddrs:     wanAddrs,
 		WanJoinPort:  wanJoinPort,
 		Bootstrap:    bootstrap,
 		Expect:       expect,
@@ -171,8 +187,10 @@ func IsConsulServer(m serf.Member) (bool, *Server) {
 		RaftVersion:  raftVsn,
 		Status:       m.Status,
 		UseTLS:       useTLS,
+		UseGRPC:      useGRPC,
 		NonVoter:     nonVoter,
 		ACLs:         acls,
+		ClusterID:    m.Tags["cluster_id"],
 	}
 	return true, parts
 }
@@ -65,6 +65,7 @@ func TestIsConsulServer(t *testing.T) {
 			"expect":        "3",
 			"raft_vsn":      "3",
 			"use_tls":       "1",
+			"grpc":          "1",
 			"nonvoter":      "1",
 		},
 		Status: serf.StatusLeft,
@@ -100,6 +101,9 @@ func TestIsConsulServer(t *testing.T) {
 	if !parts.UseTLS {
 		t.Fatalf("bad: %v", parts.UseTLS)
 	}
+	if !parts.UseGRPC {
+		t.Fatalf("bad: %v", parts.UseGRPC)
+	}
 	if !parts.NonVoter {
 		t.Fatalf("unexpected voter")
 	}
@@ -128,12 +132,31 @@ func TestIsConsulServer(t *testing.T) {
 	if parts.Bootstrap {
 		t.Fatalf("unexpected bootstrap")
 	}
+	if parts.ClusterID != "" {
+		t.Fatalf("bad: %v", parts.ClusterID)
+	}
+	m.Tags["cluster_id"] = "prod"
+	ok, parts = metadata.IsConsulServer(m)
+	if !ok || parts.ClusterID != "prod" {
+		t.Fatalf("bad: %v", parts.ClusterID)
+	}
+	delete(m.Tags, "cluster_id")
 
 	delete(m.Tags, "nonvoter")
 	ok, parts = metadata.IsConsulServer(m)
 	if !ok || parts.NonVoter {
 		t.Fatalf("unexpected nonvoter")
 	}
+	if parts.WANAddrs != nil {
+		t.Fatalf("bad: %v", parts.WANAddrs)
+	}
+
+	m.Tags["wan_addr_west-aws"] = "203.0.113.5"
+	ok, parts = metadata.IsConsulServer(m)
+	if !ok || parts.WANAddrs["west-aws"] != "203.0.113.5" || len(parts.WANAddrs) != 1 {
+		t.Fatalf("bad: %v", parts.WANAddrs)
+	}
+	delete(m.Tags, "wan_addr_west-aws")
 
 	delete(m.Tags, "role")
 	ok, parts = metadata.IsConsulServer(m)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/consul/agent/consul/autopilot"
@@ -29,12 +30,55 @@ func (s *HTTPServer) OperatorRaftConfiguration(resp http.ResponseWriter, req *ht
 	return reply, nil
 }
 
+// OperatorStateUsage is used to inspect the approximate memory used by the
+// state store of the servers. This supports the stale query mode to look at a
+// specific server.
+func (s *HTTPServer) OperatorStateUsage(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.StateUsageResponse
+	if err := s.agent.RPC("Operator.StateUsage", &args, &reply); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &reply.QueryMeta)
+
+	return reply, nil
+}
+
+// OperatorAuditTrail returns the latest changes of intentions and config
+// entries, optionally limited to one kind of resource with ?resource.
+func (s *HTTPServer) OperatorAuditTrail(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.AuditTrailRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	args.Resource = structs.AuditResource(req.URL.Query().Get("resource"))
+
+	var reply structs.AuditTrailResponse
+	if err := s.agent.RPC("Operator.AuditTrail", &args, &reply); err != nil {
+		if strings.Contains(err.Error(), "Invalid audit resource") {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, err.Error())
+			return nil, nil
+		}
+		return nil, err
+	}
+	setMeta(resp, &reply.QueryMeta)
+
+	return reply.Entries, nil
+}
+
 // OperatorRaftPeer supports actions on Raft peers. Currently we only support
 // removing peers by address.
 func (s *HTTPServer) OperatorRaftPeer(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var args structs.RaftRemovePeerRequest
 	s.parseDC(req, &args.Datacenter)
 	s.parseToken(req, &args.Token)
+	parseRequestID(req, &args.RequestID)
+	parseSpanID(req, &args.SpanID)
 
 	params := req.URL.Query()
 	_, hasID := params["id"]
@@ -73,8 +117,26 @@ type keyringArgs struct {
 	Key         string
 	Token       string
 	RelayFactor uint8
+
+	// Detailed, WaitForKey and WaitTime are only used when listing keys.
+	Detailed   bool
+	WaitForKey string
+	WaitTime   time.Duration
 }
 
+const (
+	// keyringDefaultWaitTime is how long a list waits for a key to be
+	// installed on all nodes if no wait time is given.
+	keyringDefaultWaitTime = 5 * time.Minute
+
+	// keyringMaxWaitTime bounds how long a list can wait for a key.
+	keyringMaxWaitTime = 10 * time.Minute
+
+	// keyringWaitInterval is how often the keyrings are listed while
+	// waiting for a key.
+	keyringWaitInterval = 2 * time.Second
+)
+
 // OperatorKeyringEndpoint handles keyring operations (install, list, use, remove)
 func (s *HTTPServer) OperatorKeyringEndpoint(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var args keyringArgs
@@ -104,6 +166,26 @@ func (s *HTTPServer) OperatorKeyringEndpoint(resp http.ResponseWriter, req *http
 		}
 	}
 
+	// Parse the list options
+	query := req.URL.Query()
+	if _, ok := query["detailed"]; ok {
+		args.Detailed = true
+	}
+	args.WaitForKey = query.Get("wait-for-key")
+	args.WaitTime = keyringDefaultWaitTime
+	if wait := query.Get("wait"); wait != "" {
+		dur, err := time.ParseDuration(wait)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "Invalid wait time")
+			return nil, nil
+		}
+		args.WaitTime = dur
+	}
+	if args.WaitTime > keyringMaxWaitTime {
+		args.WaitTime = keyringMaxWaitTime
+	}
+
 	// Switch on the method
 	switch req.Method {
 	case "GET":
@@ -129,16 +211,57 @@ func (s *HTTPServer) KeyringInstall(resp http.ResponseWriter, req *http.Request,
 	return nil, keyringErrorsOrNil(responses.Responses)
 }
 
-// KeyringList is used to list the keys installed in the cluster
+// KeyringList is used to list the keys installed in the cluster. If a key to
+// wait for is given, it blocks until that key is installed on all nodes of
+// every pool, or the wait time is up.
 func (s *HTTPServer) KeyringList(resp http.ResponseWriter, req *http.Request, args *keyringArgs) (interface{}, error) {
-	responses, err := s.agent.ListKeys(args.Token, args.RelayFactor)
+	list := s.agent.ListKeys
+	if args.Detailed {
+		list = s.agent.ListKeysDetailed
+	}
+
+	responses, err := list(args.Token, args.RelayFactor)
 	if err != nil {
 		return nil, err
 	}
+	if args.WaitForKey == "" {
+		return responses.Responses, keyringErrorsOrNil(responses.Responses)
+	}
+
+	timeout := time.NewTimer(args.WaitTime)
+	defer timeout.Stop()
+	for !keyringPropagated(responses.Responses, args.WaitForKey) {
+		select {
+		case <-time.After(keyringWaitInterval):
+		case <-timeout.C:
+			return nil, fmt.Errorf("Timed out waiting for key %q to be installed on all nodes", args.WaitForKey)
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		responses, err = list(args.Token, args.RelayFactor)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return responses.Responses, keyringErrorsOrNil(responses.Responses)
 }
 
+// keyringPropagated returns true if the given key is installed on all nodes
+// of every pool in the responses.
+func keyringPropagated(responses []*structs.KeyringResponse, key string) bool {
+	if len(responses) == 0 {
+		return false
+	}
+	for _, response := range responses {
+		if response.Error != "" || response.Keys[key] < response.NumNodes {
+			return false
+		}
+	}
+	return true
+}
+
 // KeyringRemove is used to list the keys installed in the cluster
 func (s *HTTPServer) KeyringRemove(resp http.ResponseWriter, req *http.Request, args *keyringArgs) (interface{}, error) {
 	responses, err := s.agent.RemoveKey(args.Key, args.Token, args.RelayFactor)
@@ -193,15 +316,17 @@ func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, re
 		}
 
 		out := api.AutopilotConfiguration{
-			CleanupDeadServers:      reply.CleanupDeadServers,
-			LastContactThreshold:    api.NewReadableDuration(reply.LastContactThreshold),
-			MaxTrailingLogs:         reply.MaxTrailingLogs,
-			ServerStabilizationTime: api.NewReadableDuration(reply.ServerStabilizationTime),
-			RedundancyZoneTag:       reply.RedundancyZoneTag,
-			DisableUpgradeMigration: reply.DisableUpgradeMigration,
-			UpgradeVersionTag:       reply.UpgradeVersionTag,
-			CreateIndex:             reply.CreateIndex,
-			ModifyIndex:             reply.ModifyIndex,
+			CleanupDeadServers:       reply.CleanupDeadServers,
+			DeadServerQuarantineTime: api.NewReadableDuration(reply.DeadServerQuarantineTime),
+			MaxDeadServerRemovals:    reply.MaxDeadServerRemovals,
+			LastContactThreshold:     api.NewReadableDuration(reply.LastContactThreshold),
+			MaxTrailingLogs:          reply.MaxTrailingLogs,
+			ServerStabilizationTime:  api.NewReadableDuration(reply.ServerStabilizationTime),
+			RedundancyZoneTag:        reply.RedundancyZoneTag,
+			DisableUpgradeMigration:  reply.DisableUpgradeMigration,
+			UpgradeVersionTag:        reply.UpgradeVersionTag,
+			CreateIndex:              reply.CreateIndex,
+			ModifyIndex:              reply.ModifyIndex,
 		}
 
 		return out, nil
@@ -210,9 +335,11 @@ func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, re
 		var args structs.AutopilotSetConfigRequest
 		s.parseDC(req, &args.Datacenter)
 		s.parseToken(req, &args.Token)
+		parseRequestID(req, &args.RequestID)
+		parseSpanID(req, &args.SpanID)
 
 		var conf api.AutopilotConfiguration
-		durations := NewDurationFixer("lastcontactthreshold", "serverstabilizationtime")
+		durations := NewDurationFixer("lastcontactthreshold", "serverstabilizationtime", "deadserverquarantinetime")
 		if err := decodeBody(req, &conf, durations.FixupDurations); err != nil {
 			resp.WriteHeader(http.StatusBadRequest)
 			fmt.Fprintf(resp, "Error parsing autopilot config: %v", err)
@@ -220,13 +347,15 @@ func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, re
 		}
 
 		args.Config = autopilot.Config{
-			CleanupDeadServers:      conf.CleanupDeadServers,
-			LastContactThreshold:    conf.LastContactThreshold.Duration(),
-			MaxTrailingLogs:         conf.MaxTrailingLogs,
-			ServerStabilizationTime: conf.ServerStabilizationTime.Duration(),
-			RedundancyZoneTag:       conf.RedundancyZoneTag,
-			DisableUpgradeMigration: conf.DisableUpgradeMigration,
-			UpgradeVersionTag:       conf.UpgradeVersionTag,
+			CleanupDeadServers:       conf.CleanupDeadServers,
+			DeadServerQuarantineTime: conf.DeadServerQuarantineTime.Duration(),
+			MaxDeadServerRemovals:    conf.MaxDeadServerRemovals,
+			LastContactThreshold:     conf.LastContactThreshold.Duration(),
+			MaxTrailingLogs:          conf.MaxTrailingLogs,
+			ServerStabilizationTime:  conf.ServerStabilizationTime.Duration(),
+			RedundancyZoneTag:        conf.RedundancyZoneTag,
+			DisableUpgradeMigration:  conf.DisableUpgradeMigration,
+			UpgradeVersionTag:        conf.UpgradeVersionTag,
 		}
 
 		// Check for cas value
@@ -298,3 +427,33 @@ func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Re
 
 	return out, nil
 }
+
+// OperatorAutopilotRemovals is used to get the dead servers Autopilot removed
+// in the local DC.
+func (s *HTTPServer) OperatorAutopilotRemovals(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply autopilot.OperatorRemovalsReply
+	if err := s.agent.RPC("Operator.AutopilotRemovals", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	out := &api.OperatorRemovalsReply{
+		Removals: make([]api.ServerRemoval, 0, len(reply.Removals)),
+	}
+	for _, removal := range reply.Removals {
+		out.Removals = append(out.Removals, api.ServerRemoval{
+			ID:        removal.ID,
+			Name:      removal.Name,
+			Address:   removal.Address,
+			Reason:    removal.Reason,
+			DeadSince: removal.DeadSince.Round(time.Second).UTC(),
+			RemovedAt: removal.RemovedAt.Round(time.Second).UTC(),
+		})
+	}
+
+	return out, nil
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/testrpc"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOperator_RaftConfiguration(t *testing.T) {
@@ -42,6 +45,76 @@ func TestOperator_RaftConfiguration(t *testing.T) {
 	}
 }
 
+func TestOperator_StateUsage(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/operator/usage", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorStateUsage(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("bad code: %d", resp.Code)
+	}
+	out, ok := obj.(structs.StateUsageResponse)
+	if !ok {
+		t.Fatalf("unexpected: %T", obj)
+	}
+	if out.Node != a.Config.NodeName || len(out.Tables) == 0 {
+		t.Fatalf("bad: %v", out)
+	}
+	if resp.Header().Get("X-Consul-Index") == "" {
+		t.Fatalf("missing index header")
+	}
+}
+
+func TestOperator_AuditTrail(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	ixn := structs.IntentionRequest{
+		Datacenter: "dc1",
+		Op:         structs.IntentionOpCreate,
+		Intention:  structs.TestIntention(t),
+	}
+	var id string
+	if err := a.RPC("Intention.Apply", &ixn, &id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/operator/audit?resource=intention", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorAuditTrail(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, ok := obj.(structs.AuditEntries)
+	if !ok {
+		t.Fatalf("unexpected: %T", obj)
+	}
+	if len(out) != 1 || out[0].Name != id || out[0].Op != "create" {
+		t.Fatalf("bad: %v", out)
+	}
+	if resp.Header().Get("X-Consul-Index") == "" {
+		t.Fatalf("missing index header")
+	}
+
+	req, _ = http.NewRequest("GET", "/v1/operator/audit?resource=kv", nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.OperatorAuditTrail(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 400 {
+		t.Fatalf("bad code: %d", resp.Code)
+	}
+}
+
 func TestOperator_RaftPeer(t *testing.T) {
 	t.Parallel()
 	t.Run("", func(t *testing.T) {
@@ -161,6 +234,76 @@ func TestOperator_KeyringList(t *testing.T) {
 	}
 }
 
+func TestOperator_KeyringList_Detailed(t *testing.T) {
+	t.Parallel()
+	key := "H3/9gBxcKKRf45CaI2DlRg=="
+	a := NewTestAgent(t, t.Name(), `
+		encrypt = "`+key+`"
+	`)
+	defer a.Shutdown()
+
+	req, _ := http.NewRequest("GET", "/v1/operator/keyring?detailed", nil)
+	resp := httptest.NewRecorder()
+	r, err := a.srv.OperatorKeyringEndpoint(resp, req)
+	require.NoError(t, err)
+	responses := r.([]*structs.KeyringResponse)
+	require.Len(t, responses, 2)
+
+	// Both pools report the single node, using the original key.
+	for _, response := range responses {
+		node := a.Config.NodeName
+		if response.WAN {
+			node += "." + a.Config.Datacenter
+		}
+		require.Equal(t, map[string]int{key: 1}, response.PrimaryKeys)
+		require.Equal(t, []*structs.KeyringNodeStatus{{
+			Node:       node,
+			Keys:       []string{key},
+			PrimaryKey: key,
+		}}, response.Nodes)
+	}
+}
+
+func TestOperator_KeyringList_WaitForKey(t *testing.T) {
+	t.Parallel()
+	key := "H3/9gBxcKKRf45CaI2DlRg=="
+	newKey := "z90lFx3sZZLtTOkutXcwYg=="
+	a := NewTestAgent(t, t.Name(), `
+		encrypt = "`+key+`"
+	`)
+	defer a.Shutdown()
+
+	// An installed key returns right away.
+	req, _ := http.NewRequest("GET", "/v1/operator/keyring?wait-for-key="+url.QueryEscape(key), nil)
+	resp := httptest.NewRecorder()
+	r, err := a.srv.OperatorKeyringEndpoint(resp, req)
+	require.NoError(t, err)
+	require.Len(t, r.([]*structs.KeyringResponse), 2)
+
+	// A missing key times out.
+	req, _ = http.NewRequest("GET", "/v1/operator/keyring?wait=10ms&wait-for-key="+url.QueryEscape(newKey), nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.OperatorKeyringEndpoint(resp, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Timed out")
+
+	// The list completes once the key is installed.
+	errCh := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequest("GET", "/v1/operator/keyring?wait=10s&wait-for-key="+url.QueryEscape(newKey), nil)
+		_, err := a.srv.OperatorKeyringEndpoint(httptest.NewRecorder(), req)
+		errCh <- err
+	}()
+	_, err = a.InstallKey(newKey, "", 0)
+	require.NoError(t, err)
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("list did not complete")
+	}
+}
+
 func TestOperator_KeyringRemove(t *testing.T) {
 	t.Parallel()
 	key := "H3/9gBxcKKRf45CaI2DlRg=="
@@ -441,6 +584,27 @@ func TestOperator_ServerHealth(t *testing.T) {
 	})
 }
 
+func TestOperator_AutopilotRemovals(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/operator/autopilot/removals", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorAutopilotRemovals(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, ok := obj.(*api.OperatorRemovalsReply)
+	if !ok {
+		t.Fatalf("unexpected: %T", obj)
+	}
+	if out.Removals == nil || len(out.Removals) != 0 {
+		t.Fatalf("bad: %v", out)
+	}
+}
+
 func TestOperator_ServerHealth_Unhealthy(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), `
@@ -12,4 +12,10 @@ const (
 	RPCMultiplexV2         = 4
 	RPCSnapshot            = 5
 	RPCGossip              = 6
+	RPCGRPC                = 7
 )
+
+// ALPNGRPC is the application protocol that TLS connections negotiate to
+// use the gRPC transport. Such connections don't send an RPCType byte after
+// the handshake.
+const ALPNGRPC = "consul-grpc"
@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"net"
+	"time"
+
+	"github.com/hashicorp/consul/agent/consul/grpcpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// GRPCConn returns a gRPC client connection to the given server, which uses
+// the gRPC transport of the server's RPC port. Connections are shared by all
+// callers and kept open until the pool is shut down. gRPC takes care of
+// reconnecting if the underlying connection is lost.
+func (p *ConnPool) GRPCConn(dc string, addr net.Addr, useTLS bool) (*grpc.ClientConn, error) {
+	p.once.Do(p.init)
+
+	addrStr := addr.String()
+
+	p.Lock()
+	defer p.Unlock()
+
+	if conn, ok := p.grpcConns[addrStr]; ok {
+		return conn, nil
+	}
+
+	dialer := func(_ string, timeout time.Duration) (net.Conn, error) {
+		return p.dialGRPC(dc, addr, timeout, useTLS)
+	}
+
+	// TLS is handled by the dialer, so gRPC itself must not add it.
+	conn, err := grpc.Dial(addrStr, grpc.WithInsecure(), grpc.WithDialer(dialer))
+	if err != nil {
+		return nil, err
+	}
+	p.grpcConns[addrStr] = conn
+	return conn, nil
+}
+
+// pingGRPC sends a Status.Ping request to the given server over its gRPC
+// transport and returns true if healthy, false if an error occurred.
+func (p *ConnPool) pingGRPC(dc string, addr net.Addr, useTLS bool) (bool, error) {
+	conn, err := p.GRPCConn(dc, addr, useTLS)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+	defer cancel()
+	_, err = grpcpb.NewStatusClient(conn).Ping(ctx, &grpcpb.PingRequest{})
+	return err == nil, err
+}
+
+// dialGRPC establishes a connection to the gRPC transport of the given
+// server. TLS connections select it during the handshake using ALPN, plain
+// connections send the RPCGRPC byte.
+func (p *ConnPool) dialGRPC(dc string, addr net.Addr, timeout time.Duration, useTLS bool) (net.Conn, error) {
+	d := &net.Dialer{LocalAddr: p.SrcAddr, Timeout: timeout}
+	conn, err := d.Dial("tcp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetKeepAlive(true)
+		tcp.SetNoDelay(true)
+	}
+
+	if (useTLS || p.ForceTLS) && p.ALPNWrapper != nil {
+		// Switch the connection into TLS mode
+		if _, err := conn.Write([]byte{byte(RPCTLS)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		// Wrap the connection in a TLS client that negotiates gRPC
+		tlsConn, err := p.ALPNWrapper(dc, ALPNGRPC, conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+
+	// Write the gRPC byte to set the mode
+	if _, err := conn.Write([]byte{byte(RPCGRPC)}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
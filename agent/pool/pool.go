@@ -6,17 +6,39 @@ import (
 	"io"
 	"net"
 	"net/rpc"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/yamux"
+	"google.golang.org/grpc"
 )
 
-const defaultDialTimeout = 10 * time.Second
+const (
+	defaultDialTimeout = 10 * time.Second
+
+	// defaultDialBackoffBase and defaultDialBackoffMax bound the backoff
+	// applied after failed dials to a server.
+	defaultDialBackoffBase = 100 * time.Millisecond
+	defaultDialBackoffMax  = 5 * time.Second
+
+	// defaultBanThreshold is the number of consecutive failed dials after
+	// which a server is banned for defaultBanWindow.
+	defaultBanThreshold = 5
+	defaultBanWindow    = 10 * time.Second
+)
+
+// dialState tracks the consecutive failed dials to a server.
+type dialState struct {
+	failures  int
+	nextDial  time.Time
+	bannedFor time.Duration
+}
 
 // muxSession is used to provide an interface for a stream multiplexer.
 type muxSession interface {
@@ -40,6 +62,7 @@ type Conn struct {
 	refCount    int32
 	shouldClose int32
 
+	dc       string
 	addr     net.Addr
 	session  muxSession
 	lastUsed time.Time
@@ -136,20 +159,43 @@ type ConnPool struct {
 	// TLS wrapper
 	TLSWrapper tlsutil.DCWrapper
 
+	// ALPNWrapper is used for TLS connections of the gRPC transport.
+	ALPNWrapper tlsutil.ALPNWrapper
+
 	// ForceTLS is used to enforce outgoing TLS verification
 	ForceTLS bool
 
+	// DialBackoffBase and DialBackoffMax bound the exponential backoff,
+	// with jitter, applied to a server after a failed dial. Connection
+	// attempts during the backoff fail right away. Defaults are used when
+	// they are zero.
+	DialBackoffBase time.Duration
+	DialBackoffMax  time.Duration
+
+	// BanThreshold is the number of consecutive failed dials after which
+	// a server is banned for BanWindow instead of backing off, so a sick
+	// server isn't hit by reconnect storms. Defaults are used when they
+	// are zero.
+	BanThreshold int
+	BanWindow    time.Duration
+
 	sync.Mutex
 
 	// pool maps an address to a open connection
 	pool map[string]*Conn
 
+	// grpcConns maps an address to a gRPC client connection
+	grpcConns map[string]*grpc.ClientConn
+
 	// limiter is used to throttle the number of connect attempts
 	// to a given address. The first thread will attempt a connection
 	// and put a channel in here, which all other threads will wait
 	// on to close.
 	limiter map[string]chan struct{}
 
+	// dials tracks the servers whose last dials failed.
+	dials map[string]*dialState
+
 	// Used to indicate the pool is shutdown
 	shutdown   bool
 	shutdownCh chan struct{}
@@ -163,7 +209,9 @@ type ConnPool struct {
 // by p.once.Do(p.init) in all public methods.
 func (p *ConnPool) init() {
 	p.pool = make(map[string]*Conn)
+	p.grpcConns = make(map[string]*grpc.ClientConn)
 	p.limiter = make(map[string]chan struct{})
+	p.dials = make(map[string]*dialState)
 	p.shutdownCh = make(chan struct{})
 	if p.MaxTime > 0 {
 		go p.reap()
@@ -182,6 +230,11 @@ func (p *ConnPool) Shutdown() error {
 	}
 	p.pool = make(map[string]*Conn)
 
+	for _, conn := range p.grpcConns {
+		conn.Close()
+	}
+	p.grpcConns = make(map[string]*grpc.ClientConn)
+
 	if p.shutdown {
 		return nil
 	}
@@ -208,6 +261,12 @@ func (p *ConnPool) acquire(dc string, addr net.Addr, version int, useTLS bool) (
 		return c, nil
 	}
 
+	// Don't dial servers that are backing off after failed dials.
+	if err := p.checkDialBackoff(addrStr); err != nil {
+		p.Unlock()
+		return nil, err
+	}
+
 	// If not (while we are still locked), set up the throttling structure
 	// for this address, which will make everyone else wait until our
 	// attempt is done.
@@ -227,6 +286,7 @@ func (p *ConnPool) acquire(dc string, addr net.Addr, version int, useTLS bool) (
 		p.Lock()
 		delete(p.limiter, addrStr)
 		close(wait)
+		p.recordDial(addrStr, err)
 		if err != nil {
 			p.Unlock()
 			return nil, err
@@ -257,6 +317,97 @@ func (p *ConnPool) acquire(dc string, addr net.Addr, version int, useTLS bool) (
 	return nil, fmt.Errorf("rpc error: lead thread didn't get connection")
 }
 
+// checkDialBackoff returns an error if the given server is backing off or
+// banned after failed dials. The lock must be held.
+func (p *ConnPool) checkDialBackoff(addr string) error {
+	s, ok := p.dials[addr]
+	if !ok {
+		return nil
+	}
+	wait := time.Until(s.nextDial)
+	if wait <= 0 {
+		return nil
+	}
+	metrics.IncrCounterWithLabels([]string{"pool", "dial_backoff"}, 1,
+		[]metrics.Label{{Name: "server", Value: addr}})
+	if s.bannedFor > 0 {
+		return fmt.Errorf("rpc error: server %s is banned for %v after %d failed dials", addr, wait.Round(time.Millisecond), s.failures)
+	}
+	return fmt.Errorf("rpc error: server %s is backing off for %v after %d failed dials", addr, wait.Round(time.Millisecond), s.failures)
+}
+
+// recordDial updates the backoff of the given server after a dial. The lock
+// must be held.
+func (p *ConnPool) recordDial(addr string, err error) {
+	labels := []metrics.Label{{Name: "server", Value: addr}}
+	if err == nil {
+		delete(p.dials, addr)
+		metrics.IncrCounterWithLabels([]string{"pool", "dial"}, 1, labels)
+		return
+	}
+	metrics.IncrCounterWithLabels([]string{"pool", "dial_failed"}, 1, labels)
+
+	s, ok := p.dials[addr]
+	if !ok {
+		s = &dialState{}
+		p.dials[addr] = s
+	}
+	s.failures++
+
+	threshold := p.BanThreshold
+	if threshold <= 0 {
+		threshold = defaultBanThreshold
+	}
+	if s.failures >= threshold {
+		s.bannedFor = p.BanWindow
+		if s.bannedFor <= 0 {
+			s.bannedFor = defaultBanWindow
+		}
+		s.nextDial = time.Now().Add(s.bannedFor)
+		if s.failures == threshold {
+			metrics.IncrCounterWithLabels([]string{"pool", "banned"}, 1, labels)
+		}
+		return
+	}
+	s.nextDial = time.Now().Add(p.dialBackoff(s.failures))
+}
+
+// dialBackoff returns the backoff after the given number of consecutive
+// failed dials. It doubles with each failure up to the maximum, and half of
+// it is random to spread out the dials of different agents.
+func (p *ConnPool) dialBackoff(failures int) time.Duration {
+	base, max := p.DialBackoffBase, p.DialBackoffMax
+	if base <= 0 {
+		base = defaultDialBackoffBase
+	}
+	if max <= 0 {
+		max = defaultDialBackoffMax
+	}
+	backoff := max
+	if failures < 32 && base<<uint(failures-1) < max {
+		backoff = base << uint(failures-1)
+	}
+	return backoff/2 + lib.RandomStagger(backoff/2)
+}
+
+// Banned returns the servers that are currently banned after failed dials.
+func (p *ConnPool) Banned() []string {
+	p.once.Do(p.init)
+
+	p.Lock()
+	defer p.Unlock()
+
+	var banned []string
+	now := time.Now()
+	for addr, s := range p.dials {
+		if s.bannedFor > 0 && now.Before(s.nextDial) {
+			banned = append(banned, addr)
+		}
+	}
+	sort.Strings(banned)
+	return banned
+}
+
 // HalfCloser is an interface that exposes a TCP half-close. We need this
 // because we want to expose the raw TCP connection underlying a TLS one in a
 // way that's hard to screw up and use for anything else. There's a change
@@ -266,6 +417,20 @@ type HalfCloser interface {
 	CloseWrite() error
 }
 
+// ConnCounts returns the number of pooled connections per datacenter.
+func (p *ConnPool) ConnCounts() map[string]int {
+	p.once.Do(p.init)
+
+	p.Lock()
+	defer p.Unlock()
+
+	counts := make(map[string]int)
+	for _, conn := range p.pool {
+		counts[conn.dc]++
+	}
+	return counts
+}
+
 // DialTimeout is used to establish a raw connection to the given server, with a
 // given connection timeout.
 func (p *ConnPool) DialTimeout(dc string, addr net.Addr, timeout time.Duration, useTLS bool) (net.Conn, HalfCloser, error) {
@@ -337,6 +502,7 @@ func (p *ConnPool) getNewConn(dc string, addr net.Addr, version int, useTLS bool
 	// Wrap the connection
 	c := &Conn{
 		refCount: 1,
+		dc:       dc,
 		addr:     addr,
 		session:  session,
 		clients:  list.New(),
@@ -434,8 +600,13 @@ func (p *ConnPool) RPC(dc string, addr net.Addr, version int, method string, use
 }
 
 // Ping sends a Status.Ping message to the specified server and
-// returns true if healthy, false if an error occurred
-func (p *ConnPool) Ping(dc string, addr net.Addr, version int, useTLS bool) (bool, error) {
+// returns true if healthy, false if an error occurred. Servers that serve the
+// gRPC transport are pinged over it.
+func (p *ConnPool) Ping(dc string, addr net.Addr, version int, useTLS, useGRPC bool) (bool, error) {
+	if useGRPC {
+		return p.pingGRPC(dc, addr, useTLS)
+	}
+
 	var out struct{}
 	err := p.RPC(dc, addr, version, "Status.Ping", useTLS, struct{}{}, &out)
 	return err == nil, err
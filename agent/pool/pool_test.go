@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/lib/freeport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnPool_DialBackoff(t *testing.T) {
+	t.Parallel()
+	p := &ConnPool{
+		DialBackoffBase: 20 * time.Millisecond,
+		DialBackoffMax:  40 * time.Millisecond,
+		BanThreshold:    3,
+		BanWindow:       time.Hour,
+	}
+	defer p.Shutdown()
+
+	// Nothing listens on this port so dials fail right away.
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: freeport.Get(1)[0]}
+	rpc := func() error {
+		var out struct{}
+		return p.RPC("dc1", addr, 2, "Status.Ping", false, struct{}{}, &out)
+	}
+
+	// The first failure starts a backoff during which there are no dials.
+	err := rpc()
+	require.Error(t, err)
+	require.False(t, strings.Contains(err.Error(), "backing off"), "err: %v", err)
+	err = rpc()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "backing off for")
+
+	// After the backoff the server is dialed again until it gets banned.
+	for i := 0; i < 2; i++ {
+		time.Sleep(50 * time.Millisecond)
+		err = rpc()
+		require.Error(t, err)
+		require.False(t, strings.Contains(err.Error(), "backing off"), "err: %v", err)
+	}
+	require.Equal(t, []string{addr.String()}, p.Banned())
+	err = rpc()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is banned for")
+}
+
+func TestConnPool_dialBackoff(t *testing.T) {
+	t.Parallel()
+	p := &ConnPool{
+		DialBackoffBase: time.Second,
+		DialBackoffMax:  10 * time.Second,
+	}
+	for failures, max := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		5:  10 * time.Second,
+		64: 10 * time.Second,
+	} {
+		backoff := p.dialBackoff(failures)
+		require.True(t, backoff >= max/2 && backoff <= max, "failures %d: %v", failures, backoff)
+	}
+}
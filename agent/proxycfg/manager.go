@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/local"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/tlsutil"
 )
 
 var (
@@ -27,7 +28,8 @@ type CancelFunc func()
 // proxy configuration state. This should not be confused with the deprecated
 // "managed proxy" concept where the agent supervises the actual proxy process.
 // proxycfg.Manager is oblivious to the distinction and manages state for any
-// service registered with Kind == connect-proxy.
+// service registered with Kind == connect-proxy, terminating-gateway or
+// ingress-gateway.
 //
 // The Manager ensures that any Connect proxy registered on the agent has all
 // the state it needs cached locally via the agent cache. State includes
@@ -66,6 +68,10 @@ type ManagerConfig struct {
 	Source *structs.QuerySource
 	// logger is the agent's logger to be used for logging logs.
 	Logger *log.Logger
+	// TLSConfigurator provides the agent's certificate for ingress gateway
+	// listeners with TLS enabled. It is optional, without it such listeners
+	// are not configured.
+	TLSConfigurator *tlsutil.Configurator
 }
 
 // NewManager constructs a manager from the provided agent cache.
@@ -131,7 +137,9 @@ func (m *Manager) syncState() {
 	// Traverse the local state and ensure all proxy services are registered
 	services := m.State.Services()
 	for svcID, svc := range services {
-		if svc.Kind != structs.ServiceKindConnectProxy {
+		if svc.Kind != structs.ServiceKindConnectProxy &&
+			svc.Kind != structs.ServiceKindTerminatingGateway &&
+			svc.Kind != structs.ServiceKindIngressGateway {
 			continue
 		}
 		// TODO(banks): need to work out when to default some stuff. For example
@@ -182,6 +190,7 @@ func (m *Manager) ensureProxyServiceLocked(ns *structs.NodeService, token string
 	state.logger = m.Logger
 	state.cache = m.Cache
 	state.source = m.Source
+	state.tlsConfigurator = m.TLSConfigurator
 
 	ch, err := state.Watch()
 	if err != nil {
@@ -306,6 +315,18 @@ func (m *Manager) Watch(proxyID string) (<-chan *ConfigSnapshot, CancelFunc) {
 	}
 }
 
+// DebugSnapshot returns the current state of the config for a proxy even if
+// it is not complete yet. It returns nil if the proxy is not registered.
+func (m *Manager) DebugSnapshot(proxyID string) *ConfigSnapshot {
+	m.mu.Lock()
+	state, ok := m.proxies[proxyID]
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return state.DebugSnapshot()
+}
+
 // closeWatchLocked cleans up state related to a single watcher. It assumes the
 // lock is held.
 func (m *Manager) closeWatchLocked(proxyID string, watchIdx uint64) {
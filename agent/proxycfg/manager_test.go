@@ -51,6 +51,7 @@ func TestManager_BasicLifecycle(t *testing.T) {
 	types.roots.value.Store(roots)
 	types.leaf.value.Store(leaf)
 	types.intentions.value.Store(TestIntentions(t))
+	types.configs.value.Store(&structs.ConfigEntryResponse{})
 	types.health.value.Store(
 		&structs.IndexedCheckServiceNodes{
 			Nodes: TestUpstreamNodes(t),
@@ -67,7 +68,7 @@ func TestManager_BasicLifecycle(t *testing.T) {
 	state.TriggerSyncChanges = func() {}
 
 	// Create manager
-	m, err := NewManager(ManagerConfig{c, state, source, logger})
+	m, err := NewManager(ManagerConfig{Cache: c, State: state, Source: source, Logger: logger})
 	require.NoError(err)
 
 	// And run it
@@ -106,15 +107,27 @@ func TestManager_BasicLifecycle(t *testing.T) {
 	// We should see the initial config delivered but not until after the
 	// coalesce timeout
 	expectSnap := &ConfigSnapshot{
-		ProxyID: webProxy.ID,
-		Address: webProxy.Address,
-		Port:    webProxy.Port,
-		Proxy:   webProxy.Proxy,
-		Roots:   roots,
-		Leaf:    leaf,
+		Kind:       structs.ServiceKindConnectProxy,
+		Service:    webProxy.Service,
+		Datacenter: source.Datacenter,
+		ProxyID:    webProxy.ID,
+		Address:    webProxy.Address,
+		Port:       webProxy.Port,
+		Proxy:      webProxy.Proxy,
+		Roots:      roots,
+		Leaf:       leaf,
 		UpstreamEndpoints: map[string]structs.CheckServiceNodes{
 			"service:db": TestUpstreamNodes(t),
 		},
+		Intentions: TestIntentions(t).Matches[0],
+		ProxyDefaults: &structs.ProxyConfigEntry{
+			Kind: structs.ProxyDefaults,
+			Name: structs.ProxyConfigGlobal,
+		},
+		ServiceDefaults: &structs.ServiceConfigEntry{
+			Kind: structs.ServiceDefaults,
+			Name: "web",
+		},
 	}
 	start := time.Now()
 	assertWatchChanRecvs(t, wCh, expectSnap)
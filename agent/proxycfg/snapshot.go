@@ -9,6 +9,9 @@ import (
 // It is meant to be point-in-time coherent and is used to deliver the current
 // config state to observers who need it to be pushed in (e.g. XDS server).
 type ConfigSnapshot struct {
+	Kind              structs.ServiceKind
+	Service           string
+	Datacenter        string
 	ProxyID           string
 	Address           string
 	Port              int
@@ -17,14 +20,84 @@ type ConfigSnapshot struct {
 	Leaf              *structs.IssuedCert
 	UpstreamEndpoints map[string]structs.CheckServiceNodes
 
-	// Skip intentions for now as we don't push those down yet, just pre-warm them.
+	// Intentions are the intentions matching the destination service. They
+	// are not pushed down to proxies yet but kept for debugging.
+	Intentions structs.Intentions
+
+	// ProxyDefaults and ServiceDefaults are the proxy-defaults entry and the
+	// service-defaults entry of the destination service, or of the gateway.
+	// They are nil until they were fetched and empty if they don't exist.
+	ProxyDefaults   *structs.ProxyConfigEntry
+	ServiceDefaults *structs.ServiceConfigEntry
+
+	// TerminatingGateway is only set for terminating gateways.
+	TerminatingGateway configSnapshotTerminatingGateway
+
+	// IngressGateway is only set for ingress gateways.
+	IngressGateway configSnapshotIngressGateway
+}
+
+// configSnapshotTerminatingGateway is the state of a terminating gateway. All
+// maps are keyed by the name of the linked service.
+type configSnapshotTerminatingGateway struct {
+	// Config is the terminating-gateway config entry of the gateway. It is
+	// nil until it was fetched or if it doesn't exist.
+	Config *structs.TerminatingGatewayConfigEntry
+
+	// ServiceNodes are the instances of the linked services the gateway
+	// forwards connections to.
+	ServiceNodes map[string]structs.CheckServiceNodes
+
+	// ServiceLeaves are the leaf certificates the gateway presents to the
+	// mesh on behalf of the linked services.
+	ServiceLeaves map[string]*structs.IssuedCert
+
+	// Intentions are the intentions matching the linked services. Like the
+	// intentions of a proxy they are only kept for debugging.
+	Intentions map[string]structs.Intentions
+}
+
+// configSnapshotIngressGateway is the state of an ingress gateway. The gateway
+// dials the exposed services with its own leaf certificate.
+type configSnapshotIngressGateway struct {
+	// Config is the ingress-gateway config entry of the gateway. It is nil
+	// until it was fetched or if it doesn't exist.
+	Config *structs.IngressGatewayConfigEntry
+
+	// ServiceNodes are the Connect instances of the exposed services keyed
+	// by the service name.
+	ServiceNodes map[string]structs.CheckServiceNodes
+
+	// TLSCertFile and TLSKeyFile are the certificate and key of the agent
+	// used by listeners with TLS enabled. They are empty if the agent has no
+	// certificate.
+	TLSCertFile string
+	TLSKeyFile  string
 }
 
 // Valid returns whether or not the snapshot has all required fields filled yet.
 func (s *ConfigSnapshot) Valid() bool {
+	switch s.Kind {
+	case structs.ServiceKindTerminatingGateway:
+		return s.Roots != nil && s.TerminatingGateway.Config != nil
+	case structs.ServiceKindIngressGateway:
+		return s.Roots != nil && s.Leaf != nil && s.IngressGateway.Config != nil
+	}
 	return s.Roots != nil && s.Leaf != nil
 }
 
+// AccessLogs returns the access logs config of the proxy, or nil if access
+// logs are disabled.
+func (s *ConfigSnapshot) AccessLogs() *structs.AccessLogsConfig {
+	return structs.ResolveAccessLogs(s.ProxyDefaults, s.ServiceDefaults)
+}
+
+// Tracing returns the tracing config of the proxy, or nil if tracing is
+// disabled.
+func (s *ConfigSnapshot) Tracing() *structs.TracingConfig {
+	return structs.ResolveTracing(s.ProxyDefaults, s.ServiceDefaults)
+}
+
 // Clone makes a deep copy of the snapshot we can send to other goroutines
 // without worrying that they will racily read or mutate shared maps etc.
 func (s *ConfigSnapshot) Clone() (*ConfigSnapshot, error) {
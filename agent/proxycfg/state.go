@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/consul/agent/cache"
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/tlsutil"
 	"github.com/mitchellh/copystructure"
 )
 
@@ -20,33 +21,60 @@ const (
 	rootsWatchID                     = "roots"
 	leafWatchID                      = "leaf"
 	intentionsWatchID                = "intentions"
+	gatewayConfigWatchID             = "gateway-config"
+	proxyDefaultsWatchID             = "proxy-defaults"
+	serviceDefaultsWatchID           = "service-defaults"
+	gatewayServiceIDPrefix           = "gateway-service:"
+	gatewayLeafIDPrefix              = "gateway-leaf:"
+	gatewayIntentionsIDPrefix        = "gateway-intentions:"
+	ingressServiceIDPrefix           = "ingress-service:"
 	serviceIDPrefix                  = string(structs.UpstreamDestTypeService) + ":"
 	preparedQueryIDPrefix            = string(structs.UpstreamDestTypePreparedQuery) + ":"
 	defaultPreparedQueryPollInterval = 30 * time.Second
 )
 
 // state holds all the state needed to maintain the config for a registered
-// connect-proxy service. When a proxy registration is changed, the entire state
-// is discarded and a new one created.
+// connect-proxy, terminating-gateway or ingress-gateway service. When a proxy
+// registration is changed, the entire state is discarded and a new one
+// created.
 type state struct {
 	// logger, source and cache are required to be set before calling Watch.
 	logger *log.Logger
 	source *structs.QuerySource
 	cache  *cache.Cache
 
+	// tlsConfigurator is optional and provides the certificate for ingress
+	// listeners with TLS enabled.
+	tlsConfigurator *tlsutil.Configurator
+
 	// ctx and cancel store the context created during initWatches call
 	ctx    context.Context
 	cancel func()
 
+	kind     structs.ServiceKind
+	service  string
 	proxyID  string
 	address  string
 	port     int
 	proxyCfg structs.ConnectProxyConfig
 	token    string
 
+	// gatewayWatches holds the cancel funcs of the watches for each service
+	// linked to a terminating gateway or exposed by an ingress gateway. It is
+	// only accessed from run.
+	gatewayWatches map[string]context.CancelFunc
+
 	ch     chan cache.UpdateEvent
 	snapCh chan ConfigSnapshot
-	reqCh  chan chan *ConfigSnapshot
+	reqCh  chan snapshotRequest
+}
+
+// snapshotRequest asks the run loop for a copy of the current snapshot.
+type snapshotRequest struct {
+	replyCh chan *ConfigSnapshot
+
+	// allowInvalid also returns snapshots that are not complete yet.
+	allowInvalid bool
 }
 
 // newState populates the state struct by copying relevant fields from the
@@ -57,8 +85,11 @@ type state struct {
 // The returned state needs it's required dependencies to be set before Watch
 // can be called.
 func newState(ns *structs.NodeService, token string) (*state, error) {
-	if ns.Kind != structs.ServiceKindConnectProxy {
-		return nil, errors.New("not a connect-proxy")
+	switch ns.Kind {
+	case structs.ServiceKindConnectProxy, structs.ServiceKindTerminatingGateway,
+		structs.ServiceKindIngressGateway:
+	default:
+		return nil, errors.New("not a connect-proxy or gateway")
 	}
 
 	// Copy the config map
@@ -72,6 +103,8 @@ func newState(ns *structs.NodeService, token string) (*state, error) {
 	}
 
 	return &state{
+		kind:     ns.Kind,
+		service:  ns.Service,
 		proxyID:  ns.ID,
 		address:  ns.Address,
 		port:     ns.Port,
@@ -86,7 +119,9 @@ func newState(ns *structs.NodeService, token string) (*state, error) {
 		// cases.
 		ch:     make(chan cache.UpdateEvent, 10),
 		snapCh: make(chan ConfigSnapshot, 1),
-		reqCh:  make(chan chan *ConfigSnapshot, 1),
+		reqCh:  make(chan snapshotRequest, 1),
+
+		gatewayWatches: make(map[string]context.CancelFunc),
 	}, nil
 }
 
@@ -128,6 +163,58 @@ func (s *state) initWatches() error {
 		return err
 	}
 
+	// Watch the defaults of all proxies and of the service's proxies.
+	err = s.cache.Notify(s.ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Kind:         structs.ProxyDefaults,
+		Name:         structs.ProxyConfigGlobal,
+	}, proxyDefaultsWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+	err = s.cache.Notify(s.ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Kind:         structs.ServiceDefaults,
+		Name:         s.defaultsService(),
+	}, serviceDefaultsWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	if s.kind == structs.ServiceKindTerminatingGateway {
+		// Watch the config entry of the gateway. The watches for the linked
+		// services are set up once it is known which they are.
+		return s.cache.Notify(s.ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+			Datacenter:   s.source.Datacenter,
+			QueryOptions: structs.QueryOptions{Token: s.token},
+			Kind:         structs.TerminatingGateway,
+			Name:         s.service,
+		}, gatewayConfigWatchID, s.ch)
+	}
+
+	if s.kind == structs.ServiceKindIngressGateway {
+		// The gateway dials the exposed services with its own identity.
+		err = s.cache.Notify(s.ctx, cachetype.ConnectCALeafName, &cachetype.ConnectCALeafRequest{
+			Datacenter: s.source.Datacenter,
+			Token:      s.token,
+			Service:    s.service,
+		}, leafWatchID, s.ch)
+		if err != nil {
+			return err
+		}
+
+		// Like for terminating gateways the watches for the exposed services
+		// depend on the config entry.
+		return s.cache.Notify(s.ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+			Datacenter:   s.source.Datacenter,
+			QueryOptions: structs.QueryOptions{Token: s.token},
+			Kind:         structs.IngressGateway,
+			Name:         s.service,
+		}, gatewayConfigWatchID, s.ch)
+	}
+
 	// Watch the leaf cert
 	err = s.cache.Notify(s.ctx, cachetype.ConnectCALeafName, &cachetype.ConnectCALeafRequest{
 		Datacenter: s.source.Datacenter,
@@ -192,6 +279,15 @@ func (s *state) initWatches() error {
 	return nil
 }
 
+// defaultsService returns the name of the service whose service-defaults
+// entry applies to the proxy. Gateways use their own entry.
+func (s *state) defaultsService() string {
+	if s.kind == structs.ServiceKindConnectProxy {
+		return s.proxyCfg.DestinationServiceName
+	}
+	return s.service
+}
+
 func (s *state) run() {
 	// Close the channel we return from Watch when we stop so consumers can stop
 	// watching and clean up their goroutines. It's important we do this here and
@@ -200,12 +296,27 @@ func (s *state) run() {
 	defer close(s.snapCh)
 
 	snap := ConfigSnapshot{
+		Kind:              s.kind,
+		Service:           s.service,
+		Datacenter:        s.source.Datacenter,
 		ProxyID:           s.proxyID,
 		Address:           s.address,
 		Port:              s.port,
 		Proxy:             s.proxyCfg,
 		UpstreamEndpoints: make(map[string]structs.CheckServiceNodes),
 	}
+	if s.kind == structs.ServiceKindTerminatingGateway {
+		snap.TerminatingGateway = configSnapshotTerminatingGateway{
+			ServiceNodes:  make(map[string]structs.CheckServiceNodes),
+			ServiceLeaves: make(map[string]*structs.IssuedCert),
+			Intentions:    make(map[string]structs.Intentions),
+		}
+	}
+	if s.kind == structs.ServiceKindIngressGateway {
+		snap.IngressGateway = configSnapshotIngressGateway{
+			ServiceNodes: make(map[string]structs.CheckServiceNodes),
+		}
+	}
 	// This turns out to be really fiddly/painful by just using time.Timer.C
 	// directly in the code below since you can't detect when a timer is stopped
 	// vs waiting in order to know to reset it. So just use a chan to send
@@ -240,8 +351,9 @@ func (s *state) run() {
 			// this iteration
 			continue
 
-		case replyCh := <-s.reqCh:
-			if !snap.Valid() {
+		case req := <-s.reqCh:
+			replyCh := req.replyCh
+			if !snap.Valid() && !req.allowInvalid {
 				// Not valid yet just respond with nil and move on to next task.
 				replyCh <- nil
 				continue
@@ -252,6 +364,7 @@ func (s *state) run() {
 			if err != nil {
 				s.logger.Printf("[ERR] Failed to copy config snapshot for proxy %s",
 					s.proxyID)
+				replyCh <- nil
 				continue
 			}
 			replyCh <- snapCopy
@@ -279,6 +392,11 @@ func (s *state) run() {
 }
 
 func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
+	if s.kind == structs.ServiceKindIngressGateway && s.tlsConfigurator != nil {
+		// Pick up a certificate that was reloaded since the last update.
+		snap.IngressGateway.TLSCertFile, snap.IngressGateway.TLSKeyFile = s.tlsConfigurator.CertFiles()
+	}
+
 	switch u.CorrelationID {
 	case rootsWatchID:
 		roots, ok := u.Result.(*structs.IndexedCARoots)
@@ -293,10 +411,103 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 		}
 		snap.Leaf = leaf
 	case intentionsWatchID:
-		// Not in snapshot currently, no op
+		resp, ok := u.Result.(*structs.IndexedIntentionMatches)
+		if !ok {
+			return fmt.Errorf("invalid type for intentions response: %T", u.Result)
+		}
+		// There is a single match entry for the destination service.
+		snap.Intentions = nil
+		if len(resp.Matches) > 0 {
+			snap.Intentions = resp.Matches[0]
+		}
+	case proxyDefaultsWatchID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for config entry response: %T", u.Result)
+		}
+		entry, ok := resp.Entry.(*structs.ProxyConfigEntry)
+		if resp.Entry != nil && !ok {
+			return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+		}
+		if entry == nil {
+			entry = &structs.ProxyConfigEntry{
+				Kind: structs.ProxyDefaults,
+				Name: structs.ProxyConfigGlobal,
+			}
+		}
+		snap.ProxyDefaults = entry
+	case serviceDefaultsWatchID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for config entry response: %T", u.Result)
+		}
+		entry, ok := resp.Entry.(*structs.ServiceConfigEntry)
+		if resp.Entry != nil && !ok {
+			return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+		}
+		if entry == nil {
+			entry = &structs.ServiceConfigEntry{
+				Kind: structs.ServiceDefaults,
+				Name: s.defaultsService(),
+			}
+		}
+		snap.ServiceDefaults = entry
+	case gatewayConfigWatchID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for config entry response: %T", u.Result)
+		}
+		if s.kind == structs.ServiceKindIngressGateway {
+			return s.handleIngressConfig(resp, snap)
+		}
+		return s.handleGatewayConfig(resp, snap)
 	default:
 		// Service discovery result, figure out which type
 		switch {
+		case strings.HasPrefix(u.CorrelationID, gatewayServiceIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for service response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, gatewayServiceIDPrefix)
+			if _, ok := s.gatewayWatches[name]; ok {
+				snap.TerminatingGateway.ServiceNodes[name] = resp.Nodes
+			}
+
+		case strings.HasPrefix(u.CorrelationID, gatewayLeafIDPrefix):
+			leaf, ok := u.Result.(*structs.IssuedCert)
+			if !ok {
+				return fmt.Errorf("invalid type for leaf response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, gatewayLeafIDPrefix)
+			if _, ok := s.gatewayWatches[name]; ok {
+				snap.TerminatingGateway.ServiceLeaves[name] = leaf
+			}
+
+		case strings.HasPrefix(u.CorrelationID, gatewayIntentionsIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedIntentionMatches)
+			if !ok {
+				return fmt.Errorf("invalid type for intentions response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, gatewayIntentionsIDPrefix)
+			if _, ok := s.gatewayWatches[name]; ok {
+				var ixns structs.Intentions
+				if len(resp.Matches) > 0 {
+					ixns = resp.Matches[0]
+				}
+				snap.TerminatingGateway.Intentions[name] = ixns
+			}
+
+		case strings.HasPrefix(u.CorrelationID, ingressServiceIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for service response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, ingressServiceIDPrefix)
+			if _, ok := s.gatewayWatches[name]; ok {
+				snap.IngressGateway.ServiceNodes[name] = resp.Nodes
+			}
+
 		case strings.HasPrefix(u.CorrelationID, serviceIDPrefix):
 			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
 			if !ok {
@@ -318,13 +529,159 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 	return nil
 }
 
+// handleGatewayConfig updates the config entry of a terminating gateway and
+// starts or stops the watches for its linked services accordingly.
+func (s *state) handleGatewayConfig(resp *structs.ConfigEntryResponse, snap *ConfigSnapshot) error {
+	entry, ok := resp.Entry.(*structs.TerminatingGatewayConfigEntry)
+	if resp.Entry != nil && !ok {
+		return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+	}
+	if entry == nil {
+		// A gateway without a config entry doesn't link any services yet.
+		entry = &structs.TerminatingGatewayConfigEntry{
+			Kind: structs.TerminatingGateway,
+			Name: s.service,
+		}
+	}
+	snap.TerminatingGateway.Config = entry
+
+	linked := make(map[string]bool)
+	for _, svc := range entry.Services {
+		linked[svc.Name] = true
+		if _, ok := s.gatewayWatches[svc.Name]; ok {
+			continue
+		}
+		if err := s.watchGatewayService(svc.Name); err != nil {
+			return err
+		}
+	}
+
+	for name, cancel := range s.gatewayWatches {
+		if linked[name] {
+			continue
+		}
+		cancel()
+		delete(s.gatewayWatches, name)
+		delete(snap.TerminatingGateway.ServiceNodes, name)
+		delete(snap.TerminatingGateway.ServiceLeaves, name)
+		delete(snap.TerminatingGateway.Intentions, name)
+	}
+	return nil
+}
+
+// watchGatewayService starts the watches for the instances, the leaf cert and
+// the intentions of a service linked to a terminating gateway.
+func (s *state) watchGatewayService(name string) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	err := s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		ServiceName:  name,
+	}, gatewayServiceIDPrefix+name, s.ch)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	err = s.cache.Notify(ctx, cachetype.ConnectCALeafName, &cachetype.ConnectCALeafRequest{
+		Datacenter: s.source.Datacenter,
+		Token:      s.token,
+		Service:    name,
+	}, gatewayLeafIDPrefix+name, s.ch)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	err = s.cache.Notify(ctx, cachetype.IntentionMatchName, &structs.IntentionQueryRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Match: &structs.IntentionQueryMatch{
+			Type: structs.IntentionMatchDestination,
+			Entries: []structs.IntentionMatchEntry{
+				{
+					Namespace: structs.IntentionDefaultNamespace,
+					Name:      name,
+				},
+			},
+		},
+	}, gatewayIntentionsIDPrefix+name, s.ch)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	s.gatewayWatches[name] = cancel
+	return nil
+}
+
+// handleIngressConfig updates the config entry of an ingress gateway and
+// starts or stops the watches for the exposed services accordingly.
+func (s *state) handleIngressConfig(resp *structs.ConfigEntryResponse, snap *ConfigSnapshot) error {
+	entry, ok := resp.Entry.(*structs.IngressGatewayConfigEntry)
+	if resp.Entry != nil && !ok {
+		return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+	}
+	if entry == nil {
+		// A gateway without a config entry doesn't have any listeners yet.
+		entry = &structs.IngressGatewayConfigEntry{
+			Kind: structs.IngressGateway,
+			Name: s.service,
+		}
+	}
+	snap.IngressGateway.Config = entry
+
+	exposed := make(map[string]bool)
+	for _, name := range entry.ServiceNames() {
+		exposed[name] = true
+		if _, ok := s.gatewayWatches[name]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(s.ctx)
+		err := s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+			Datacenter:   s.source.Datacenter,
+			QueryOptions: structs.QueryOptions{Token: s.token},
+			ServiceName:  name,
+			Connect:      true,
+		}, ingressServiceIDPrefix+name, s.ch)
+		if err != nil {
+			cancel()
+			return err
+		}
+		s.gatewayWatches[name] = cancel
+	}
+
+	for name, cancel := range s.gatewayWatches {
+		if exposed[name] {
+			continue
+		}
+		cancel()
+		delete(s.gatewayWatches, name)
+		delete(snap.IngressGateway.ServiceNodes, name)
+	}
+	return nil
+}
+
 // CurrentSnapshot synchronously returns the current ConfigSnapshot if there is
 // one ready. If we don't have one yet because not all necessary parts have been
 // returned (i.e. both roots and leaf cert), nil is returned.
 func (s *state) CurrentSnapshot() *ConfigSnapshot {
+	return s.snapshot(false)
+}
+
+// DebugSnapshot synchronously returns the current ConfigSnapshot even if it is
+// not complete yet. It is meant for inspecting why a proxy didn't receive its
+// config.
+func (s *state) DebugSnapshot() *ConfigSnapshot {
+	return s.snapshot(true)
+}
+
+func (s *state) snapshot(allowInvalid bool) *ConfigSnapshot {
 	// Make a chan for the response to be sent on
 	ch := make(chan *ConfigSnapshot, 1)
-	s.reqCh <- ch
+	s.reqCh <- snapshotRequest{replyCh: ch, allowInvalid: allowInvalid}
 	// Wait for the response
 	return <-ch
 }
@@ -335,7 +692,8 @@ func (s *state) Changed(ns *structs.NodeService, token string) bool {
 	if ns == nil {
 		return true
 	}
-	return ns.Kind != structs.ServiceKindConnectProxy ||
+	return ns.Kind != s.kind ||
+		s.service != ns.Service ||
 		s.proxyID != ns.ID ||
 		s.address != ns.Address ||
 		s.port != ns.Port ||
@@ -100,6 +100,20 @@ func TestStateChanged(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "different gateway service name",
+			ns: &structs.NodeService{
+				Kind:    structs.ServiceKindTerminatingGateway,
+				ID:      "gateway",
+				Service: "gateway",
+				Port:    8443,
+			},
+			mutate: func(ns structs.NodeService, token string) (*structs.NodeService, string) {
+				ns.Service = "other-gateway"
+				return &ns, token
+			},
+			want: true,
+		},
 	}
 
 	for _, tt := range tests {
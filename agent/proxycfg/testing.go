@@ -21,6 +21,7 @@ type TestCacheTypes struct {
 	intentions *ControllableCacheType
 	health     *ControllableCacheType
 	query      *ControllableCacheType
+	configs    *ControllableCacheType
 }
 
 // NewTestCacheTypes creates a set of ControllableCacheTypes for all types that
@@ -33,6 +34,7 @@ func NewTestCacheTypes(t testing.T) *TestCacheTypes {
 		intentions: NewControllableCacheType(t),
 		health:     NewControllableCacheType(t),
 		query:      NewControllableCacheType(t),
+		configs:    NewControllableCacheType(t),
 	}
 	ct.query.blocking = false
 	return ct
@@ -65,6 +67,11 @@ func TestCacheWithTypes(t testing.T, types *TestCacheTypes) *cache.Cache {
 	c.RegisterType(cachetype.PreparedQueryName, types.query, &cache.RegisterOptions{
 		Refresh: false,
 	})
+	c.RegisterType(cachetype.ConfigEntryName, types.configs, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
 	return c
 }
 
@@ -149,9 +156,12 @@ func TestUpstreamNodes(t testing.T) structs.CheckServiceNodes {
 func TestConfigSnapshot(t testing.T) *ConfigSnapshot {
 	roots, leaf := TestCerts(t)
 	return &ConfigSnapshot{
-		ProxyID: "web-sidecar-proxy",
-		Address: "0.0.0.0",
-		Port:    9999,
+		Kind:       structs.ServiceKindConnectProxy,
+		Service:    "web-sidecar-proxy",
+		Datacenter: "dc1",
+		ProxyID:    "web-sidecar-proxy",
+		Address:    "0.0.0.0",
+		Port:       9999,
 		Proxy: structs.ConnectProxyConfig{
 			DestinationServiceID:   "web",
 			DestinationServiceName: "web",
@@ -170,6 +180,71 @@ func TestConfigSnapshot(t testing.T) *ConfigSnapshot {
 	}
 }
 
+// TestConfigSnapshotTerminatingGateway returns a fully populated snapshot of
+// a terminating gateway linked to the "db" service.
+func TestConfigSnapshotTerminatingGateway(t testing.T) *ConfigSnapshot {
+	roots, _ := TestCerts(t)
+	leafPEM, pkPEM := connect.TestLeaf(t, "db", roots.Roots[0])
+	return &ConfigSnapshot{
+		Kind:       structs.ServiceKindTerminatingGateway,
+		Service:    "gateway",
+		Datacenter: "dc1",
+		ProxyID:    "gateway",
+		Address:    "1.2.3.4",
+		Port:       8443,
+		Roots:      roots,
+		TerminatingGateway: configSnapshotTerminatingGateway{
+			Config: &structs.TerminatingGatewayConfigEntry{
+				Kind: structs.TerminatingGateway,
+				Name: "gateway",
+				Services: []structs.LinkedService{
+					{Name: "db"},
+				},
+			},
+			ServiceNodes: map[string]structs.CheckServiceNodes{
+				"db": TestUpstreamNodes(t),
+			},
+			ServiceLeaves: map[string]*structs.IssuedCert{
+				"db": &structs.IssuedCert{
+					CertPEM:       leafPEM,
+					PrivateKeyPEM: pkPEM,
+					Service:       "db",
+				},
+			},
+		},
+	}
+}
+
+// TestConfigSnapshotIngressGateway returns a fully populated snapshot of an
+// ingress gateway exposing the "db" service on a plain TCP listener.
+func TestConfigSnapshotIngressGateway(t testing.T) *ConfigSnapshot {
+	roots, leaf := TestCerts(t)
+	return &ConfigSnapshot{
+		Kind:       structs.ServiceKindIngressGateway,
+		Service:    "ingress",
+		Datacenter: "dc1",
+		ProxyID:    "ingress",
+		Address:    "1.2.3.4",
+		Roots:      roots,
+		Leaf:       leaf,
+		IngressGateway: configSnapshotIngressGateway{
+			Config: &structs.IngressGatewayConfigEntry{
+				Kind: structs.IngressGateway,
+				Name: "ingress",
+				Listeners: []structs.IngressListener{
+					{
+						Port:     9191,
+						Services: []structs.IngressService{{Name: "db"}},
+					},
+				},
+			},
+			ServiceNodes: map[string]structs.CheckServiceNodes{
+				"db": TestUpstreamNodes(t),
+			},
+		},
+	}
+}
+
 // ControllableCacheType is a cache.Type that simulates a typical blocking RPC
 // but lets us control the responses and when they are delivered easily.
 type ControllableCacheType struct {
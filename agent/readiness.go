@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// grpcHealthUpdateInterval is how often the status reported by the gRPC
+	// health service is refreshed.
+	grpcHealthUpdateInterval = time.Second
+
+	// grpcHealthReadiness and grpcHealthLiveness are the service names the
+	// gRPC health service reports readiness and liveness under. The empty
+	// service name reports readiness as well.
+	grpcHealthReadiness = "readiness"
+	grpcHealthLiveness  = "liveness"
+)
+
+// Readiness describes whether the agent is ready to serve requests, along
+// with the result of each of the individual checks.
+type Readiness struct {
+	// Ready is true if all the checks below passed.
+	Ready bool
+
+	// Gossip is true if the agent has joined the LAN gossip pool.
+	Gossip bool
+
+	// Sync is true if the agent has synced its local state with the
+	// servers at least once.
+	Sync bool
+
+	// Leader is true if the cluster has a known leader. This is only
+	// checked on servers and always true on client agents.
+	Leader bool
+}
+
+// readiness runs the readiness checks of the agent.
+func (a *Agent) readiness() *Readiness {
+	r := &Readiness{
+		Gossip: a.joinedLAN(),
+		Sync:   a.sync.Synced(),
+		Leader: true,
+	}
+	if a.config.ServerMode {
+		var leader string
+		if err := a.RPC("Status.Leader", struct{}{}, &leader); err != nil || leader == "" {
+			r.Leader = false
+		}
+	}
+	r.Ready = r.Gossip && r.Sync && r.Leader
+	return r
+}
+
+// live returns whether the agent is running and not shutting down.
+func (a *Agent) live() bool {
+	a.shutdownLock.Lock()
+	defer a.shutdownLock.Unlock()
+	return !a.shutdown
+}
+
+// joinedLAN returns whether the agent has joined the LAN gossip pool, which is
+// the case once another member is alive. Servers that bootstrap on their own
+// don't need to join anyone.
+func (a *Agent) joinedLAN() bool {
+	if a.config.ServerMode && (a.config.Bootstrap || a.config.BootstrapExpect == 1) {
+		return true
+	}
+	for _, m := range a.LANMembers() {
+		if m.Name != a.config.NodeName && m.Status == serf.StatusAlive {
+			return true
+		}
+	}
+	return false
+}
+
+// updateGRPCHealth is a long-running loop that keeps the status reported by
+// the gRPC health service up to date. Closing the agent's shutdownChannel will
+// cause this to exit.
+func (a *Agent) updateGRPCHealth(srv *health.Server) {
+	srv.SetServingStatus(grpcHealthLiveness, healthpb.HealthCheckResponse_SERVING)
+	for {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if a.readiness().Ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		srv.SetServingStatus("", status)
+		srv.SetServingStatus(grpcHealthReadiness, status)
+
+		select {
+		case <-time.After(grpcHealthUpdateInterval):
+		case <-a.shutdownCh:
+			srv.SetServingStatus(grpcHealthLiveness, healthpb.HealthCheckResponse_NOT_SERVING)
+			return
+		}
+	}
+}
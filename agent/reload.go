@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/lib"
+)
+
+// reloadableFields are the RuntimeConfig fields which are applied by
+// ReloadConfig. The Telemetry struct is compared field by field and listed
+// with a "Telemetry." prefix. A change to any other field only takes effect
+// after a restart of the agent.
+var reloadableFields = map[string]bool{
+	"ACLAgentMasterToken":   true,
+	"ACLAgentToken":         true,
+	"ACLDNSToken":           true,
+	"ACLReplicationToken":   true,
+	"ACLToken":              true,
+	"Checks":                true,
+	"DiscardCheckOutput":    true,
+	"DNSAllowStale":         true,
+	"DNSARecordLimit":       true,
+	"DNSCacheMaxAge":        true,
+	"DNSDisableCompression": true,
+	"DNSEnableTruncate":     true,
+	"DNSMaxStale":           true,
+	"DNSNodeMetaTXT":        true,
+	"DNSNodeTTL":            true,
+	"DNSOnlyPassing":        true,
+	"DNSRecursors":          true,
+	"DNSRecursorTimeout":    true,
+	"DNSServiceTTL":         true,
+	"DNSSOA":                true,
+	"DNSUDPAnswerLimit":     true,
+	"DNSUseCache":           true,
+	"EnableDebug":           true,
+	"LogLevel":              true,
+	"NodeMeta":              true,
+	"RPCMaxBurst":           true,
+	"RPCRateLimit":          true,
+	"Services":              true,
+	"Watches":               true,
+
+	"HTTPSAcceptRate":              true,
+	"HTTPSMaxConcurrentHandshakes": true,
+	"RPCAcceptBackpressure":        true,
+	"RPCAcceptRate":                true,
+	"RPCMaxConcurrentHandshakes":   true,
+	"RPCMaxConns":                  true,
+	"RPCMaxStreamsPerConn":         true,
+	"RPCQueryLimits":               true,
+	"RPCQueryQueueTimeout":         true,
+
+	"Telemetry.AllowedPrefixes":                    true,
+	"Telemetry.BlockedPrefixes":                    true,
+	"Telemetry.CirconusAPIApp":                     true,
+	"Telemetry.CirconusAPIToken":                   true,
+	"Telemetry.CirconusAPIURL":                     true,
+	"Telemetry.CirconusBrokerID":                   true,
+	"Telemetry.CirconusBrokerSelectTag":            true,
+	"Telemetry.CirconusCheckDisplayName":           true,
+	"Telemetry.CirconusCheckForceMetricActivation": true,
+	"Telemetry.CirconusCheckID":                    true,
+	"Telemetry.CirconusCheckInstanceID":            true,
+	"Telemetry.CirconusCheckSearchTag":             true,
+	"Telemetry.CirconusCheckTags":                  true,
+	"Telemetry.CirconusSubmissionInterval":         true,
+	"Telemetry.CirconusSubmissionURL":              true,
+	"Telemetry.DisableHostname":                    true,
+	"Telemetry.DogstatsdAddr":                      true,
+	"Telemetry.DogstatsdTags":                      true,
+	"Telemetry.FilterDefault":                      true,
+	"Telemetry.MetricsPrefix":                      true,
+	"Telemetry.StatsdAddr":                         true,
+	"Telemetry.StatsiteAddr":                       true,
+}
+
+// ReloadReport lists the configuration fields which changed during a
+// reload. Applied fields are in effect once the reload has completed,
+// fields in RequiresRestart keep their previous value until the agent is
+// restarted.
+type ReloadReport struct {
+	Applied         []string
+	RequiresRestart []string
+}
+
+// newReloadReport compares the configuration the agent is currently running
+// with against the new configuration. Reloadable fields are compared against
+// the previously loaded configuration prev and all other fields against the
+// configuration the agent was started with.
+func newReloadReport(started, prev, next *config.RuntimeConfig) *ReloadReport {
+	r := &ReloadReport{}
+	for _, name := range changedFields(prev, next) {
+		if reloadableFields[name] {
+			r.Applied = append(r.Applied, name)
+		}
+	}
+	for _, name := range changedFields(started, next) {
+		if !reloadableFields[name] {
+			r.RequiresRestart = append(r.RequiresRestart, name)
+		}
+	}
+	return r
+}
+
+// changedFields returns the sorted names of the top level fields of the
+// runtime config which differ between a and b. The fields of the Telemetry
+// struct are compared individually.
+func changedFields(a, b *config.RuntimeConfig) []string {
+	var changed []string
+	var diff func(prefix string, va, vb reflect.Value)
+	diff = func(prefix string, va, vb reflect.Value) {
+		t := va.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			switch {
+			case f.PkgPath != "":
+				// unexported
+			case f.Type == reflect.TypeOf(lib.TelemetryConfig{}):
+				diff(prefix+f.Name+".", va.Field(i), vb.Field(i))
+			case !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()):
+				changed = append(changed, prefix+f.Name)
+			}
+		}
+	}
+	diff("", reflect.ValueOf(*a), reflect.ValueOf(*b))
+	sort.Strings(changed)
+	return changed
+}
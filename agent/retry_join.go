@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/lib"
 	discover "github.com/hashicorp/go-discover"
 	discoverk8s "github.com/hashicorp/go-discover/provider/k8s"
@@ -17,6 +18,8 @@ func (a *Agent) retryJoinLAN() {
 		addrs:       a.config.RetryJoinLAN,
 		maxAttempts: a.config.RetryJoinMaxAttemptsLAN,
 		interval:    a.config.RetryJoinIntervalLAN,
+		maxInterval: a.config.RetryJoinMaxIntervalLAN,
+		splay:       a.config.RetryJoinSplayLAN,
 		join:        a.JoinLAN,
 		logger:      a.logger,
 	}
@@ -31,6 +34,8 @@ func (a *Agent) retryJoinWAN() {
 		addrs:       a.config.RetryJoinWAN,
 		maxAttempts: a.config.RetryJoinMaxAttemptsWAN,
 		interval:    a.config.RetryJoinIntervalWAN,
+		maxInterval: a.config.RetryJoinMaxIntervalWAN,
+		splay:       a.config.RetryJoinSplayWAN,
 		join:        a.JoinWAN,
 		logger:      a.logger,
 	}
@@ -55,6 +60,15 @@ type retryJoiner struct {
 	// interval is the time between two join attempts.
 	interval time.Duration
 
+	// maxInterval is the maximum time between two join attempts. If it is
+	// greater than interval the time doubles after every failed attempt up
+	// to maxInterval.
+	maxInterval time.Duration
+
+	// splay is the maximum random delay added before every join attempt
+	// so that agents which start at the same time don't join at once.
+	splay time.Duration
+
 	// join adds the discovered or configured servers to the given
 	// serf cluster.
 	join func([]string) (int, error)
@@ -86,6 +100,8 @@ func (r *retryJoiner) retryJoin() error {
 
 	r.logger.Printf("[INFO] agent: Retry join %s is supported for: %s", r.cluster, strings.Join(disco.Names(), " "))
 	r.logger.Printf("[INFO] agent: Joining %s cluster...", r.cluster)
+	labels := []metrics.Label{{Name: "cluster", Value: strings.ToLower(r.cluster)}}
+	time.Sleep(lib.RandomStagger(r.splay))
 	attempt := 0
 	for {
 		var addrs []string
@@ -107,6 +123,7 @@ func (r *retryJoiner) retryJoin() error {
 			}
 		}
 
+		metrics.IncrCounterWithLabels([]string{"agent", "retry_join", "attempt"}, 1, labels)
 		if len(addrs) > 0 {
 			n, err := r.join(addrs)
 			if err == nil {
@@ -114,6 +131,7 @@ func (r *retryJoiner) retryJoin() error {
 				return nil
 			}
 		}
+		metrics.IncrCounterWithLabels([]string{"agent", "retry_join", "failure"}, 1, labels)
 
 		if len(addrs) == 0 {
 			err = fmt.Errorf("No servers to join")
@@ -124,7 +142,21 @@ func (r *retryJoiner) retryJoin() error {
 			return fmt.Errorf("agent: max join %s retry exhausted, exiting", r.cluster)
 		}
 
-		r.logger.Printf("[WARN] agent: Join %s failed: %v, retrying in %v", r.cluster, err, r.interval)
-		time.Sleep(r.interval)
+		wait := r.wait(attempt)
+		r.logger.Printf("[WARN] agent: Join %s failed: %v, retrying in %v", r.cluster, err, wait)
+		time.Sleep(wait)
+	}
+}
+
+// wait returns the time to wait after the given number of failed join
+// attempts.
+func (r *retryJoiner) wait(attempt int) time.Duration {
+	wait := r.interval
+	for i := 1; i < attempt && wait < r.maxInterval; i++ {
+		wait *= 2
+	}
+	if r.maxInterval > r.interval && wait > r.maxInterval {
+		wait = r.maxInterval
 	}
+	return wait + lib.RandomStagger(r.splay)
 }
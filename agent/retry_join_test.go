@@ -3,8 +3,10 @@ package agent
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	discover "github.com/hashicorp/go-discover"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGoDiscoverRegistration(t *testing.T) {
@@ -18,3 +20,30 @@ func TestGoDiscoverRegistration(t *testing.T) {
 		t.Fatalf("got go-discover providers %v want %v", got, want)
 	}
 }
+
+func TestRetryJoiner_Wait(t *testing.T) {
+	t.Parallel()
+
+	// Without a maximum interval the wait is constant.
+	r := &retryJoiner{interval: time.Second}
+	for attempt := 1; attempt < 5; attempt++ {
+		require.Equal(t, time.Second, r.wait(attempt))
+	}
+
+	// With a maximum interval the wait doubles up to the maximum.
+	r = &retryJoiner{interval: time.Second, maxInterval: 5 * time.Second}
+	var waits []time.Duration
+	for attempt := 1; attempt < 6; attempt++ {
+		waits = append(waits, r.wait(attempt))
+	}
+	require.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}, waits)
+
+	// The splay adds a random delay to every wait.
+	r = &retryJoiner{interval: time.Second, splay: time.Second}
+	for i := 0; i < 10; i++ {
+		wait := r.wait(1)
+		require.True(t, wait >= time.Second && wait < 2*time.Second, "bad wait %v", wait)
+	}
+}
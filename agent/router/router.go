@@ -3,6 +3,7 @@ package router
 import (
 	"fmt"
 	"log"
+	"net"
 	"sort"
 	"sync"
 
@@ -230,6 +231,14 @@ func (r *Router) addServer(area *areaInfo, s *metadata.Server) error {
 		s.UseTLS = true
 	}
 
+	// If the server advertises a different WAN address for our datacenter,
+	// such as the public address of a NAT, connect to it there.
+	if ip := net.ParseIP(s.WANAddrs[r.localDatacenter]); ip != nil && s.Datacenter != r.localDatacenter {
+		if addr, ok := s.Addr.(*net.TCPAddr); ok {
+			s.Addr = &net.TCPAddr{IP: ip, Port: addr.Port}
+		}
+	}
+
 	info.manager.AddServer(s)
 	return nil
 }
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/types"
@@ -349,6 +350,46 @@ func TestRouter_Routing_Offline(t *testing.T) {
 	}
 }
 
+func TestRouter_WANAddrs(t *testing.T) {
+	r := testRouter("dc0")
+
+	self := "node0.dc0"
+	wan := testCluster(self)
+	if err := r.AddArea(types.AreaWAN, wan, &fauxConnPool{}, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A server advertising an address for our datacenter is reached there.
+	nat := &metadata.Server{
+		Name:       "node1.dc3",
+		Datacenter: "dc3",
+		Addr:       &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8300},
+		WANAddrs:   map[string]string{"dc0": "203.0.113.1"},
+	}
+	if err := r.AddServer(types.AreaWAN, nat); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, s, ok := r.FindRoute("dc3")
+	if !ok || s.Addr.String() != "203.0.113.1:8300" {
+		t.Fatalf("bad: %v", s)
+	}
+
+	// Addresses for other datacenters are ignored.
+	other := &metadata.Server{
+		Name:       "node1.dc4",
+		Datacenter: "dc4",
+		Addr:       &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 8300},
+		WANAddrs:   map[string]string{"dc5": "203.0.113.2"},
+	}
+	if err := r.AddServer(types.AreaWAN, other); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, s, ok = r.FindRoute("dc4")
+	if !ok || s.Addr.String() != "10.0.0.2:8300" {
+		t.Fatalf("bad: %v", s)
+	}
+}
+
 func TestRouter_GetDatacenters(t *testing.T) {
 	r := testRouter("dc0")
 
@@ -42,7 +42,7 @@ func handleMemberEvent(logger *log.Logger, fn routerFn, areaID types.AreaID, e s
 // HandleSerfEvents is a long-running goroutine that pushes incoming events from
 // a Serf manager's channel into the given router. This will return when the
 // shutdown channel is closed.
-func HandleSerfEvents(logger *log.Logger, router *Router, areaID types.AreaID, shutdownCh <-chan struct{}, eventCh <-chan serf.Event) {
+func HandleSerfEvents(logger *log.Logger, router *Router, areaID types.AreaID, shutdownCh <-chan struct{}, eventCh <-chan serf.Event, queryFn func(*serf.Query)) {
 	for {
 		select {
 		case <-shutdownCh:
@@ -59,10 +59,14 @@ func HandleSerfEvents(logger *log.Logger, router *Router, areaID types.AreaID, s
 			case serf.EventMemberFailed:
 				handleMemberEvent(logger, router.FailServer, areaID, e)
 
+			case serf.EventQuery:
+				if queryFn != nil {
+					queryFn(e.(*serf.Query))
+				}
+
 			// All of these event types are ignored.
 			case serf.EventMemberUpdate:
 			case serf.EventUser:
-			case serf.EventQuery:
 
 			default:
 				logger.Printf("[WARN] consul: Unhandled Serf Event: %#v", e)
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"io"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/agentpb"
+	"github.com/hashicorp/consul/agent/structs"
+	"google.golang.org/grpc/metadata"
+)
+
+// grpcSession implements the Session gRPC service of the agent.
+type grpcSession struct {
+	agent *Agent
+}
+
+// KeepAlive renews the session named in each request received on the stream
+// and replies to the requests in order. Failed renewals are reported in the
+// replies and don't end the stream, so a single stream can be used for the
+// sessions of a whole application.
+//
+// The ACL token is read from the x-consul-token metadata of the stream and
+// defaults to the user token of the agent, like with the HTTP API.
+func (s *grpcSession) KeepAlive(stream agentpb.Session_KeepAliveServer) error {
+	token := s.agent.grpcToken(stream.Context())
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(s.renew(req, token)); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcToken returns the ACL token of a gRPC call, which is read from its
+// x-consul-token metadata and defaults to the user token of the agent.
+func (a *Agent) grpcToken(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if toks := md["x-consul-token"]; len(toks) > 0 && toks[0] != "" {
+			return toks[0]
+		}
+	}
+	return a.tokens.UserToken()
+}
+
+// renew renews a session for a keepalive request.
+func (s *grpcSession) renew(req *agentpb.KeepAliveRequest, token string) *agentpb.KeepAliveResponse {
+	metrics.IncrCounterWithLabels([]string{"client", "grpc", "session_keepalive"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.agent.config.NodeName}})
+
+	resp := &agentpb.KeepAliveResponse{SessionId: req.SessionId}
+	if req.SessionId == "" {
+		resp.Error = "Missing session"
+		return resp
+	}
+
+	args := structs.SessionSpecificRequest{
+		Datacenter:   req.Datacenter,
+		Session:      req.SessionId,
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	var out structs.IndexedSessions
+	if err := s.agent.RPC("Session.Renew", &args, &out); err != nil {
+		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "session_keepalive"}, 1,
+			[]metrics.Label{{Name: "node", Value: s.agent.config.NodeName}})
+		resp.Error = err.Error()
+		return resp
+	}
+	if len(out.Sessions) == 0 {
+		// The session expired or was destroyed, so let the caller know right
+		// away that its locks are gone.
+		resp.Invalidated = true
+		return resp
+	}
+	resp.Ttl = out.Sessions[0].TTL
+	return resp
+}
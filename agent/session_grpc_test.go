@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/agentpb"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestGRPCSession_KeepAlive(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Serve the session service on its own listener.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	agentpb.RegisterSessionServer(srv, &grpcSession{agent: a.Agent})
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := agentpb.NewSessionClient(conn).KeepAlive(context.Background())
+	require.NoError(t, err)
+
+	id := makeTestSessionTTL(t, a.srv, "15s")
+
+	// The session is renewed for each request on the stream.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, stream.Send(&agentpb.KeepAliveRequest{SessionId: id}))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, &agentpb.KeepAliveResponse{SessionId: id, Ttl: "15s"}, resp)
+	}
+
+	// Failed renewals are reported without ending the stream.
+	require.NoError(t, stream.Send(&agentpb.KeepAliveRequest{SessionId: id, Datacenter: "nope"}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Contains(t, resp.Error, "No path to datacenter")
+	require.False(t, resp.Invalidated)
+
+	// Destroying the session invalidates it.
+	req, _ := http.NewRequest("PUT", "/v1/session/destroy/"+id, nil)
+	_, err = a.srv.SessionDestroy(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Send(&agentpb.KeepAliveRequest{SessionId: id}))
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &agentpb.KeepAliveResponse{SessionId: id, Invalidated: true}, resp)
+
+	require.NoError(t, stream.CloseSend())
+}
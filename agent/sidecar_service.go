@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
@@ -49,19 +52,34 @@ func (a *Agent) sidecarServiceFromNodeService(ns *structs.NodeService, token str
 		}
 	}
 
-	// Copy the service metadata from the original service if no other meta was provided
-	if len(sidecar.Meta) == 0 && len(ns.Meta) > 0 {
+	// Copy the service metadata from the original service if no other meta was
+	// provided or if it should be inherited. Keys set on the sidecar win.
+	inheritMeta := ns.Connect.SidecarService.InheritMeta
+	if (len(sidecar.Meta) == 0 || inheritMeta) && len(ns.Meta) > 0 {
 		if sidecar.Meta == nil {
 			sidecar.Meta = make(map[string]string)
 		}
 		for k, v := range ns.Meta {
-			sidecar.Meta[k] = v
+			if _, ok := sidecar.Meta[k]; !ok {
+				sidecar.Meta[k] = v
+			}
 		}
 	}
 
 	// Copy the tags from the original service if no other tags were specified
+	// or if they should be inherited.
 	if len(sidecar.Tags) == 0 && len(ns.Tags) > 0 {
 		sidecar.Tags = append(sidecar.Tags, ns.Tags...)
+	} else if ns.Connect.SidecarService.InheritTags {
+		seen := make(map[string]bool)
+		for _, t := range sidecar.Tags {
+			seen[t] = true
+		}
+		for _, t := range ns.Tags {
+			if !seen[t] {
+				sidecar.Tags = append(sidecar.Tags, t)
+			}
+		}
 	}
 
 	// Flag this as a sidecar - this is not persisted in catalog but only needed
@@ -163,6 +181,10 @@ func (a *Agent) sidecarServiceFromNodeService(ns *structs.NodeService, token str
 	if err != nil {
 		return nil, nil, "", err
 	}
+	checks, err = renderSidecarChecks(checks, sidecar)
+	if err != nil {
+		return nil, nil, "", err
+	}
 
 	// Setup default check if none given
 	if len(checks) < 1 {
@@ -183,3 +205,62 @@ func (a *Agent) sidecarServiceFromNodeService(ns *structs.NodeService, token str
 
 	return sidecar, checks, token, nil
 }
+
+// sidecarCheckVars are the values available to the templates in the TCP,
+// HTTP and GRPC targets of sidecar checks, e.g. "{{.Address}}:{{.Port}}".
+type sidecarCheckVars struct {
+	// Address and Port are the address and the (possibly auto-assigned)
+	// port of the sidecar. Address defaults to 127.0.0.1.
+	Address string
+	Port    int
+
+	// LocalServiceAddress and LocalServicePort are the address and port of
+	// the service the sidecar proxies to.
+	LocalServiceAddress string
+	LocalServicePort    int
+}
+
+// renderSidecarChecks returns copies of the checks with the templates in the
+// check targets rendered since the sidecar port is usually only known once it
+// has been assigned. The checks are copied since they point into the service
+// definition which must keep the templates for later registrations.
+func renderSidecarChecks(checks []*structs.CheckType, sidecar *structs.NodeService) ([]*structs.CheckType, error) {
+	vars := sidecarCheckVars{
+		Address:             sidecar.Address,
+		Port:                sidecar.Port,
+		LocalServiceAddress: sidecar.Proxy.LocalServiceAddress,
+		LocalServicePort:    sidecar.Proxy.LocalServicePort,
+	}
+	if vars.Address == "" {
+		vars.Address = "127.0.0.1"
+	}
+
+	render := func(s string) (string, error) {
+		if !strings.Contains(s, "{{") {
+			return s, nil
+		}
+		t, err := template.New("check").Option("missingkey=error").Parse(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid sidecar check template %q: %s", s, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, vars); err != nil {
+			return "", fmt.Errorf("invalid sidecar check template %q: %s", s, err)
+		}
+		return buf.String(), nil
+	}
+
+	var out []*structs.CheckType
+	for _, c := range checks {
+		c2 := *c
+		for _, target := range []*string{&c2.TCP, &c2.HTTP, &c2.GRPC} {
+			v, err := render(*target)
+			if err != nil {
+				return nil, err
+			}
+			*target = v
+		}
+		out = append(out, &c2)
+	}
+	return out, nil
+}
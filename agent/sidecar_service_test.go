@@ -208,6 +208,126 @@ func TestAgent_sidecarServiceFromNodeService(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "merge inherited tags and meta",
+			sd: &structs.ServiceDefinition{
+				ID:   "web1",
+				Name: "web",
+				Port: 1111,
+				Tags: []string{"foo", "baz"},
+				Meta: map[string]string{"foo": "web", "env": "prod"},
+				Connect: &structs.ServiceConnect{
+					SidecarService: &structs.ServiceDefinition{
+						Tags:        []string{"proxy", "foo"},
+						Meta:        map[string]string{"foo": "proxy"},
+						InheritTags: true,
+						InheritMeta: true,
+					},
+				},
+			},
+			token: "foo",
+			wantNS: &structs.NodeService{
+				Kind:    structs.ServiceKindConnectProxy,
+				ID:      "web1-sidecar-proxy",
+				Service: "web-sidecar-proxy",
+				Port:    2222,
+				Tags:    []string{"proxy", "foo", "baz"},
+				Meta: map[string]string{
+					"foo": "proxy",
+					"env": "prod",
+				},
+				LocallyRegisteredAsSidecar: true,
+				Proxy: structs.ConnectProxyConfig{
+					DestinationServiceName: "web",
+					DestinationServiceID:   "web1",
+					LocalServiceAddress:    "127.0.0.1",
+					LocalServicePort:       1111,
+				},
+			},
+			wantChecks: []*structs.CheckType{
+				&structs.CheckType{
+					Name:     "Connect Sidecar Listening",
+					TCP:      "127.0.0.1:2222",
+					Interval: 10 * time.Second,
+				},
+				&structs.CheckType{
+					Name:         "Connect Sidecar Aliasing web1",
+					AliasService: "web1",
+				},
+			},
+			wantToken: "foo",
+		},
+		{
+			name: "templated check targets",
+			sd: &structs.ServiceDefinition{
+				ID:   "web1",
+				Name: "web",
+				Port: 1111,
+				Connect: &structs.ServiceConnect{
+					SidecarService: &structs.ServiceDefinition{
+						Address: "10.0.0.1",
+						Checks: structs.CheckTypes{
+							&structs.CheckType{
+								Name:     "listening",
+								TCP:      "{{.Address}}:{{.Port}}",
+								Interval: 5 * time.Second,
+							},
+							&structs.CheckType{
+								Name:     "ready",
+								HTTP:     "http://{{.LocalServiceAddress}}:{{.LocalServicePort}}/ready",
+								Interval: 5 * time.Second,
+							},
+						},
+					},
+				},
+			},
+			token: "foo",
+			wantNS: &structs.NodeService{
+				Kind:                       structs.ServiceKindConnectProxy,
+				ID:                         "web1-sidecar-proxy",
+				Service:                    "web-sidecar-proxy",
+				Port:                       2222,
+				Address:                    "10.0.0.1",
+				LocallyRegisteredAsSidecar: true,
+				Proxy: structs.ConnectProxyConfig{
+					DestinationServiceName: "web",
+					DestinationServiceID:   "web1",
+					LocalServiceAddress:    "127.0.0.1",
+					LocalServicePort:       1111,
+				},
+			},
+			wantChecks: []*structs.CheckType{
+				&structs.CheckType{
+					Name:     "listening",
+					TCP:      "10.0.0.1:2222",
+					Interval: 5 * time.Second,
+				},
+				&structs.CheckType{
+					Name:     "ready",
+					HTTP:     "http://127.0.0.1:1111/ready",
+					Interval: 5 * time.Second,
+				},
+			},
+			wantToken: "foo",
+		},
+		{
+			name: "invalid check template",
+			sd: &structs.ServiceDefinition{
+				ID:   "web1",
+				Name: "web",
+				Port: 1111,
+				Connect: &structs.ServiceConnect{
+					SidecarService: &structs.ServiceDefinition{
+						Check: structs.CheckType{
+							TCP:      "{{.Bogus}}",
+							Interval: 5 * time.Second,
+						},
+					},
+				},
+			},
+			token:   "foo",
+			wantErr: "invalid sidecar check template",
+		},
 		{
 			name: "invalid check type",
 			sd: &structs.ServiceDefinition{
@@ -38,9 +38,25 @@ func (s *HTTPServer) Snapshot(resp http.ResponseWriter, req *http.Request) (inte
 
 	case "PUT":
 		args.Op = structs.SnapshotRestore
-		if err := s.agent.SnapshotRPC(&args, req.Body, resp, nil); err != nil {
+		if _, ok := req.URL.Query()["dry-run"]; ok {
+			args.DryRun = true
+		}
+		if _, ok := req.URL.Query()["skip-cluster-id-check"]; ok {
+			args.SkipClusterIDCheck = true
+		}
+
+		// A dry run replies with a summary of the snapshot.
+		var summary *structs.SnapshotSummary
+		replyFn := func(reply *structs.SnapshotResponse) error {
+			summary = reply.Summary
+			return nil
+		}
+		if err := s.agent.SnapshotRPC(&args, req.Body, resp, replyFn); err != nil {
 			return nil, err
 		}
+		if args.DryRun {
+			return summary, nil
+		}
 		return nil, nil
 
 	default:
@@ -44,7 +44,8 @@ func TestSnapshot(t *testing.T) {
 		a := NewTestAgent(t, t.Name(), "")
 		defer a.Shutdown()
 
-		req, _ := http.NewRequest("PUT", "/v1/snapshot?token=root", snap)
+		// The snapshot was taken from the cluster of another agent.
+		req, _ := http.NewRequest("PUT", "/v1/snapshot?token=root&skip-cluster-id-check", snap)
 		resp := httptest.NewRecorder()
 		if _, err := a.srv.Snapshot(resp, req); err != nil {
 			t.Fatalf("err: %v", err)
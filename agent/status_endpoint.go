@@ -19,3 +19,34 @@ func (s *HTTPServer) StatusPeers(resp http.ResponseWriter, req *http.Request) (i
 	}
 	return out, nil
 }
+
+// StatusClusterID returns the ID of the cluster of the servers, which is
+// empty until the first leader is elected.
+func (s *HTTPServer) StatusClusterID(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var out string
+	if err := s.agent.RPC("Status.ClusterID", struct{}{}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StatusReadiness reports whether the agent has joined the gossip pool,
+// synced its local state and, for servers, knows the leader. It responds
+// with a 503 if any of these checks fail so it can be used as a readiness
+// probe.
+func (s *HTTPServer) StatusReadiness(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	r := s.agent.readiness()
+	if !r.Ready {
+		return r, CodeWithPayloadError{StatusCode: http.StatusServiceUnavailable, Reason: "agent is not ready", ContentType: "application/json"}
+	}
+	return r, nil
+}
+
+// StatusLiveness reports whether the agent is running. It responds with a
+// 503 once the agent is shutting down so it can be used as a liveness probe.
+func (s *HTTPServer) StatusLiveness(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if !s.agent.live() {
+		return false, CodeWithPayloadError{StatusCode: http.StatusServiceUnavailable, Reason: "agent is shutting down", ContentType: "application/json"}
+	}
+	return true, nil
+}
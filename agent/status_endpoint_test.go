@@ -1,10 +1,15 @@
 package agent
 
 import (
+	"context"
 	"net/http"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/testutil/retry"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func TestStatusLeader(t *testing.T) {
@@ -40,3 +45,108 @@ func TestStatusPeers(t *testing.T) {
 		t.Fatalf("bad peers: %v", peers)
 	}
 }
+
+func TestStatusClusterID(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		bootstrap_cluster_id = "prod"
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	retry.Run(t, func(r *retry.R) {
+		req, _ := http.NewRequest("GET", "/v1/status/cluster-id", nil)
+		obj, err := a.srv.StatusClusterID(nil, req)
+		if err != nil {
+			r.Fatalf("Err: %v", err)
+		}
+		if id := obj.(string); id != "prod" {
+			r.Fatalf("bad cluster ID: %v", id)
+		}
+	})
+}
+
+func TestStatusReadiness(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	retry.Run(t, func(r *retry.R) {
+		req, _ := http.NewRequest("GET", "/v1/status/readiness", nil)
+		obj, err := a.srv.StatusReadiness(nil, req)
+		if err != nil {
+			r.Fatalf("Err: %v", err)
+		}
+		want := &Readiness{Ready: true, Gossip: true, Sync: true, Leader: true}
+		if got := obj.(*Readiness); !reflect.DeepEqual(got, want) {
+			r.Fatalf("got %#v want %#v", got, want)
+		}
+	})
+}
+
+func TestStatusReadiness_notJoined(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		server = false
+		bootstrap = false
+	`)
+	defer a.Shutdown()
+
+	req, _ := http.NewRequest("GET", "/v1/status/readiness", nil)
+	obj, err := a.srv.StatusReadiness(nil, req)
+	cerr, ok := err.(CodeWithPayloadError)
+	if !ok || cerr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Err: %v", err)
+	}
+	r := obj.(*Readiness)
+	if r.Ready || r.Gossip || r.Sync {
+		t.Fatalf("bad: %#v", r)
+	}
+	if !r.Leader {
+		t.Fatalf("leader is not checked on clients: %#v", r)
+	}
+}
+
+func TestStatusLiveness(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+
+	req, _ := http.NewRequest("GET", "/v1/status/liveness", nil)
+	obj, err := a.srv.StatusLiveness(nil, req)
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if !obj.(bool) {
+		t.Fatalf("bad: %v", obj)
+	}
+
+	a.Shutdown()
+	_, err = a.srv.StatusLiveness(nil, req)
+	cerr, ok := err.(CodeWithPayloadError)
+	if !ok || cerr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Err: %v", err)
+	}
+}
+
+func TestStatus_GRPCHealth(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	srv := health.NewServer()
+	go a.updateGRPCHealth(srv)
+
+	for _, service := range []string{"", grpcHealthReadiness, grpcHealthLiveness} {
+		retry.Run(t, func(r *retry.R) {
+			resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				r.Fatalf("Err: %v", err)
+			}
+			if resp.Status != healthpb.HealthCheckResponse_SERVING {
+				r.Fatalf("service %q: bad status: %v", service, resp.Status)
+			}
+		})
+	}
+}
@@ -533,6 +533,18 @@ func (r *ACLTokenSetRequest) RequestDatacenter() string {
 	return r.Datacenter
 }
 
+// ACLAnonymousPoliciesSetRequest is used to replace the policies linked to
+// the anonymous token at the RPC layer
+type ACLAnonymousPoliciesSetRequest struct {
+	Policies   []ACLTokenPolicyLink // The new policies of the anonymous token
+	Datacenter string               // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLAnonymousPoliciesSetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
 // ACLTokenGetRequest is used for token read operations at the RPC layer
 type ACLTokenGetRequest struct {
 	TokenID     string         // id used for the token lookup
@@ -0,0 +1,74 @@
+package structs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditResource is the kind of resource whose changes are recorded in the
+// audit trail.
+type AuditResource string
+
+const (
+	AuditResourceIntention   AuditResource = "intention"
+	AuditResourceConfigEntry AuditResource = "config-entry"
+)
+
+// AuditInfo identifies who made a change and when. It is set by the leader
+// before the change is appended to the Raft log so that all servers record
+// the same audit entry.
+type AuditInfo struct {
+	// AccessorID is the accessor of the token used for the change. It is
+	// empty if ACLs are disabled.
+	AccessorID string
+
+	// Time is when the leader handled the change.
+	Time time.Time
+}
+
+// AuditEntry records a change of an intention or a config entry, so that a
+// change of the traffic policy can be attributed. The servers keep a bounded
+// history of the latest entries.
+type AuditEntry struct {
+	// Index is the Raft index of the change.
+	Index uint64
+
+	AuditInfo
+
+	// Resource is the kind of the changed resource and Name identifies it,
+	// which is the ID of an intention and "<kind>/<name>" for a config
+	// entry.
+	Resource AuditResource
+	Name     string
+
+	// Op is the operation of the request, such as "create" or "delete".
+	Op string
+
+	// Before and After are the JSON encoded resource before and after the
+	// change. Before is empty when the resource was created and After is
+	// empty when it was deleted.
+	Before json.RawMessage `json:",omitempty"`
+	After  json.RawMessage `json:",omitempty"`
+}
+
+type AuditEntries []*AuditEntry
+
+// AuditTrailRequest is used to query the audit trail.
+type AuditTrailRequest struct {
+	Datacenter string
+
+	// Resource optionally limits the entries to the given kind of resource.
+	Resource AuditResource
+
+	QueryOptions
+}
+
+func (r *AuditTrailRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// AuditTrailResponse is the audit trail, oldest entry first.
+type AuditTrailResponse struct {
+	Entries AuditEntries
+	QueryMeta
+}
@@ -31,6 +31,8 @@ type CheckDefinition struct {
 	Shell                          string
 	GRPC                           string
 	GRPCUseTLS                     bool
+	Plugin                         string
+	PluginArgs                     map[string]string
 	TLSSkipVerify                  bool
 	AliasNode                      string
 	AliasService                   string
@@ -70,6 +72,8 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		HTTP:                           c.HTTP,
 		GRPC:                           c.GRPC,
 		GRPCUseTLS:                     c.GRPCUseTLS,
+		Plugin:                         c.Plugin,
+		PluginArgs:                     c.PluginArgs,
 		Header:                         c.Header,
 		Method:                         c.Method,
 		TCP:                            c.TCP,
@@ -9,10 +9,10 @@ import (
 )
 
 // CheckType is used to create either the CheckMonitor or the CheckTTL.
-// The following types are supported: Script, HTTP, TCP, Docker, TTL, GRPC, Alias. Script,
-// HTTP, Docker, TCP and GRPC all require Interval. Only one of the types may
+// The following types are supported: Script, HTTP, TCP, Docker, TTL, GRPC, Alias, Plugin. Script,
+// HTTP, Docker, TCP, GRPC and Plugin all require Interval. Only one of the types may
 // to be provided: TTL or Script/Interval or HTTP/Interval or TCP/Interval or
-// Docker/Interval or GRPC/Interval or AliasService.
+// Docker/Interval or GRPC/Interval or Plugin/Interval or AliasService.
 type CheckType struct {
 	// fields already embedded in CheckDefinition
 	// Note: CheckType.CheckID == CheckDefinition.ID
@@ -37,6 +37,8 @@ type CheckType struct {
 	Shell             string
 	GRPC              string
 	GRPCUseTLS        bool
+	Plugin            string
+	PluginArgs        map[string]string
 	TLSSkipVerify     bool
 	Timeout           time.Duration
 	TTL               time.Duration
@@ -50,7 +52,7 @@ type CheckTypes []*CheckType
 
 // Validate returns an error message if the check is invalid
 func (c *CheckType) Validate() error {
-	intervalCheck := c.IsScript() || c.HTTP != "" || c.TCP != "" || c.GRPC != ""
+	intervalCheck := c.IsScript() || c.HTTP != "" || c.TCP != "" || c.GRPC != "" || c.Plugin != ""
 
 	if c.Interval > 0 && c.TTL > 0 {
 		return fmt.Errorf("Interval and TTL cannot both be specified")
@@ -114,3 +116,8 @@ func (c *CheckType) IsDocker() bool {
 func (c *CheckType) IsGRPC() bool {
 	return c.GRPC != "" && c.Interval > 0
 }
+
+// IsPlugin checks if this is a check implemented by a plugin.
+func (c *CheckType) IsPlugin() bool {
+	return c.Plugin != "" && c.Interval > 0
+}
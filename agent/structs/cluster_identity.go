@@ -0,0 +1,12 @@
+package structs
+
+// ClusterIdentity identifies the cluster formed by the servers of a
+// datacenter. The ID is set once by the first leader and never changes, so
+// servers can refuse to merge with the servers of another cluster.
+type ClusterIdentity struct {
+	// ID is a UUID generated by the first leader, or the
+	// bootstrap_cluster_id of the servers if it was configured.
+	ID string
+
+	RaftIndex
+}
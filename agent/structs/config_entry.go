@@ -0,0 +1,302 @@
+package structs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/mitchellh/hashstructure"
+	"github.com/mitchellh/mapstructure"
+)
+
+// The kinds of config entries. The kind and the name together uniquely
+// identify a config entry.
+const (
+	TerminatingGateway string = "terminating-gateway"
+	IngressGateway     string = "ingress-gateway"
+	ExportedServices   string = "exported-services"
+	ProxyDefaults      string = "proxy-defaults"
+	ServiceDefaults    string = "service-defaults"
+)
+
+// ConfigEntry is the interface for centralized configuration stored in Raft.
+// Currently only service-scoped config entries are supported.
+type ConfigEntry interface {
+	GetKind() string
+	GetName() string
+
+	// This is called in the RPC endpoint and can apply defaults or limits.
+	Normalize() error
+	Validate() error
+
+	// CanRead and CanWrite return whether or not the given Authorizer
+	// has permission to read or write to the config entry, respectively.
+	CanRead(acl.Authorizer) bool
+	CanWrite(acl.Authorizer) bool
+
+	GetRaftIndex() *RaftIndex
+}
+
+// MakeConfigEntry returns an empty config entry of the given kind.
+func MakeConfigEntry(kind, name string) (ConfigEntry, error) {
+	switch kind {
+	case TerminatingGateway:
+		return &TerminatingGatewayConfigEntry{Name: name}, nil
+	case IngressGateway:
+		return &IngressGatewayConfigEntry{Name: name}, nil
+	case ExportedServices:
+		return &ExportedServicesConfigEntry{Name: name}, nil
+	case ProxyDefaults:
+		return &ProxyConfigEntry{Name: name}, nil
+	case ServiceDefaults:
+		return &ServiceConfigEntry{Name: name}, nil
+	default:
+		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
+	}
+}
+
+// DecodeConfigEntry decodes a config entry from the map representation used
+// by the HTTP API and config files. The "Kind" key selects the type of the
+// entry. Keys are matched case-insensitively.
+func DecodeConfigEntry(raw map[string]interface{}) (ConfigEntry, error) {
+	var kind string
+	for k, v := range raw {
+		if strings.ToLower(k) == "kind" {
+			kind, _ = v.(string)
+		}
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("Payload does not contain a Kind key at the top level")
+	}
+
+	entry, err := MakeConfigEntry(kind, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var md mapstructure.Metadata
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		Metadata:         &md,
+		Result:           entry,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	for _, k := range md.Unused {
+		switch strings.ToLower(k) {
+		case "createindex", "modifyindex":
+			// Entries read from the API may be written back unmodified.
+		default:
+			return nil, fmt.Errorf("invalid config key %q", k)
+		}
+	}
+	return entry, nil
+}
+
+// ConfigEntryOp is the operation of a ConfigEntryRequest.
+type ConfigEntryOp string
+
+const (
+	ConfigEntryUpsert ConfigEntryOp = "upsert"
+	ConfigEntryDelete ConfigEntryOp = "delete"
+)
+
+// ConfigEntryRequest is used when creating, updating or deleting a config
+// entry.
+type ConfigEntryRequest struct {
+	Op         ConfigEntryOp
+	Datacenter string
+	Entry      ConfigEntry
+
+	// Audit is recorded in the audit trail along with the change.
+	Audit AuditInfo
+
+	WriteRequest
+}
+
+func (c *ConfigEntryRequest) RequestDatacenter() string {
+	return c.Datacenter
+}
+
+// MarshalBinary encodes the kind of the entry ahead of the request so that
+// the interface can be decoded into the right type.
+func (c *ConfigEntryRequest) MarshalBinary() ([]byte, error) {
+	var kind string
+	if c.Entry != nil {
+		kind = c.Entry.GetKind()
+	}
+
+	var bs []byte
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode(kind); err != nil {
+		return nil, err
+	}
+	// Use an alias to avoid calling MarshalBinary recursively.
+	type alias ConfigEntryRequest
+	if err := enc.Encode((*alias)(c)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (c *ConfigEntryRequest) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	var kind string
+	if err := dec.Decode(&kind); err != nil {
+		return err
+	}
+	if kind != "" {
+		entry, err := MakeConfigEntry(kind, "")
+		if err != nil {
+			return err
+		}
+		c.Entry = entry
+	}
+	type alias ConfigEntryRequest
+	return dec.Decode((*alias)(c))
+}
+
+// ConfigEntryQuery is used when requesting a single config entry or the
+// config entries of a kind.
+type ConfigEntryQuery struct {
+	Kind       string
+	Name       string
+	Datacenter string
+
+	QueryOptions
+}
+
+func (c *ConfigEntryQuery) RequestDatacenter() string {
+	return c.Datacenter
+}
+
+func (c *ConfigEntryQuery) CacheInfo() cache.RequestInfo {
+	info := cache.RequestInfo{
+		Token:      c.Token,
+		Datacenter: c.Datacenter,
+		MinIndex:   c.MinQueryIndex,
+		Timeout:    c.MaxQueryTime,
+	}
+
+	v, err := hashstructure.Hash([]interface{}{
+		c.Kind,
+		c.Name,
+	}, nil)
+	if err == nil {
+		// If there is an error, we don't set the key. A blank key forces
+		// no cache for this request so the request is forwarded directly
+		// to the server.
+		info.Key = strconv.FormatUint(v, 10)
+	}
+
+	return info
+}
+
+// ConfigEntryResponse returns a single config entry.
+type ConfigEntryResponse struct {
+	Entry ConfigEntry
+	QueryMeta
+}
+
+func (c *ConfigEntryResponse) MarshalBinary() ([]byte, error) {
+	var kind string
+	if c.Entry != nil {
+		kind = c.Entry.GetKind()
+	}
+
+	var bs []byte
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode(kind); err != nil {
+		return nil, err
+	}
+	type alias ConfigEntryResponse
+	if err := enc.Encode((*alias)(c)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (c *ConfigEntryResponse) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	var kind string
+	if err := dec.Decode(&kind); err != nil {
+		return err
+	}
+	if kind != "" {
+		entry, err := MakeConfigEntry(kind, "")
+		if err != nil {
+			return err
+		}
+		c.Entry = entry
+	}
+	type alias ConfigEntryResponse
+	return dec.Decode((*alias)(c))
+}
+
+// IndexedConfigEntries returns the config entries of a single kind, or of
+// all kinds if Kind is empty.
+type IndexedConfigEntries struct {
+	Kind    string
+	Entries []ConfigEntry
+	QueryMeta
+}
+
+func (c *IndexedConfigEntries) MarshalBinary() ([]byte, error) {
+	var bs []byte
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode(c.Kind); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(len(c.Entries)); err != nil {
+		return nil, err
+	}
+	for _, entry := range c.Entries {
+		// The kind is encoded with each entry since entries of all kinds
+		// are listed together.
+		if err := enc.Encode(entry.GetKind()); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Encode(&c.QueryMeta); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (c *IndexedConfigEntries) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode(&c.Kind); err != nil {
+		return err
+	}
+	var n int
+	if err := dec.Decode(&n); err != nil {
+		return err
+	}
+	c.Entries = nil
+	for i := 0; i < n; i++ {
+		var kind string
+		if err := dec.Decode(&kind); err != nil {
+			return err
+		}
+		entry, err := MakeConfigEntry(kind, "")
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(entry); err != nil {
+			return err
+		}
+		c.Entries = append(c.Entries, entry)
+	}
+	return dec.Decode(&c.QueryMeta)
+}
@@ -0,0 +1,305 @@
+package structs
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+// ProxyConfigGlobal is the name of the only proxy-defaults config entry of a
+// datacenter.
+const ProxyConfigGlobal = "global"
+
+// The sinks Envoy can write access logs to.
+const (
+	AccessLogSinkStdout = "stdout"
+	AccessLogSinkStderr = "stderr"
+	AccessLogSinkFile   = "file"
+)
+
+// The tracers Envoy can send spans to.
+const (
+	TracingProviderZipkin  = "zipkin"
+	TracingProviderDatadog = "datadog"
+)
+
+// ProxyConfigEntry holds the defaults of all proxies and gateways of the
+// datacenter. Settings of a service-defaults entry take precedence for the
+// proxies of that service.
+type ProxyConfigEntry struct {
+	Kind string
+	Name string
+
+	// AccessLogs configures the access logs of the proxies.
+	AccessLogs *AccessLogsConfig `json:",omitempty"`
+
+	// Tracing configures the tracer of the proxies.
+	Tracing *TracingConfig `json:",omitempty"`
+
+	RaftIndex
+}
+
+func (e *ProxyConfigEntry) GetKind() string {
+	return ProxyDefaults
+}
+
+func (e *ProxyConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+	return e.Name
+}
+
+func (e *ProxyConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+	e.Kind = ProxyDefaults
+	return nil
+}
+
+func (e *ProxyConfigEntry) Validate() error {
+	if e.Name != ProxyConfigGlobal {
+		return fmt.Errorf("Name must be %q", ProxyConfigGlobal)
+	}
+	if e.AccessLogs != nil {
+		if err := e.AccessLogs.Validate(); err != nil {
+			return fmt.Errorf("AccessLogs is invalid: %v", err)
+		}
+	}
+	if e.Tracing != nil {
+		if err := e.Tracing.Validate(); err != nil {
+			return fmt.Errorf("Tracing is invalid: %v", err)
+		}
+	}
+	return nil
+}
+
+// CanRead allows any token to read the entry since every proxy needs it.
+func (e *ProxyConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return true
+}
+
+func (e *ProxyConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.OperatorWrite()
+}
+
+func (e *ProxyConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+	return &e.RaftIndex
+}
+
+// ServiceConfigEntry holds the settings of the proxies of a single service,
+// or of a gateway, which take precedence over the proxy-defaults entry. The
+// name of the entry is the name of the service.
+type ServiceConfigEntry struct {
+	Kind string
+	Name string
+
+	// AccessLogs replaces the access logs config of the proxy-defaults
+	// entry when set.
+	AccessLogs *AccessLogsConfig `json:",omitempty"`
+
+	// Tracing replaces the tracing config of the proxy-defaults entry when
+	// set.
+	Tracing *TracingConfig `json:",omitempty"`
+
+	RaftIndex
+}
+
+func (e *ServiceConfigEntry) GetKind() string {
+	return ServiceDefaults
+}
+
+func (e *ServiceConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+	return e.Name
+}
+
+func (e *ServiceConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+	e.Kind = ServiceDefaults
+	return nil
+}
+
+func (e *ServiceConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+	if e.AccessLogs != nil {
+		if err := e.AccessLogs.Validate(); err != nil {
+			return fmt.Errorf("AccessLogs is invalid: %v", err)
+		}
+	}
+	if e.Tracing != nil {
+		if err := e.Tracing.Validate(); err != nil {
+			return fmt.Errorf("Tracing is invalid: %v", err)
+		}
+	}
+	return nil
+}
+
+func (e *ServiceConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.ServiceRead(e.Name)
+}
+
+func (e *ServiceConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.OperatorWrite()
+}
+
+func (e *ServiceConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+	return &e.RaftIndex
+}
+
+// AccessLogsConfig configures the access logs Envoy writes for the
+// connections, or the requests of HTTP listeners, its listeners handle.
+type AccessLogsConfig struct {
+	// Enabled turns the access logs on. A service-defaults entry can
+	// disable the logs enabled by the proxy-defaults entry.
+	Enabled bool
+
+	// Sink is where the logs are written to: "stdout", which is the
+	// default, "stderr" or "file".
+	Sink string `json:",omitempty"`
+
+	// Path is the file the logs are written to with the "file" sink.
+	Path string `json:",omitempty"`
+
+	// Format is the Envoy format string of the log lines. Envoy's default
+	// format is used if it is empty.
+	Format string `json:",omitempty"`
+
+	// SamplingPercent is the percentage of the connections or requests
+	// which are logged. Zero logs all of them.
+	SamplingPercent float64 `json:",omitempty"`
+}
+
+// Validate checks the access logs config.
+func (c *AccessLogsConfig) Validate() error {
+	switch c.Sink {
+	case "", AccessLogSinkStdout, AccessLogSinkStderr:
+		if c.Path != "" {
+			return fmt.Errorf("Path requires the %q sink", AccessLogSinkFile)
+		}
+	case AccessLogSinkFile:
+		if c.Path == "" {
+			return fmt.Errorf("Path is required for the %q sink", AccessLogSinkFile)
+		}
+	default:
+		return fmt.Errorf("Sink %q is invalid, must be one of %q, %q or %q",
+			c.Sink, AccessLogSinkStdout, AccessLogSinkStderr, AccessLogSinkFile)
+	}
+	if c.SamplingPercent < 0 || c.SamplingPercent > 100 {
+		return fmt.Errorf("SamplingPercent must be between 0 and 100")
+	}
+	return nil
+}
+
+// ResolveAccessLogs returns the access logs config of the proxies of a
+// service given the proxy-defaults and the service-defaults entries, either
+// of which may be nil. It returns nil if access logs are disabled.
+func ResolveAccessLogs(proxyDefaults *ProxyConfigEntry, serviceDefaults *ServiceConfigEntry) *AccessLogsConfig {
+	var cfg *AccessLogsConfig
+	if proxyDefaults != nil {
+		cfg = proxyDefaults.AccessLogs
+	}
+	if serviceDefaults != nil && serviceDefaults.AccessLogs != nil {
+		cfg = serviceDefaults.AccessLogs
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	return cfg
+}
+
+// TracingConfig configures the tracer Envoy sends the spans of the HTTP
+// requests its listeners handle to. The tracer is part of the bootstrap
+// config, so changes only apply once Envoy is restarted.
+type TracingConfig struct {
+	// Enabled turns tracing on. A service-defaults entry can disable the
+	// tracing enabled by the proxy-defaults entry.
+	Enabled bool
+
+	// Provider is the tracer: "zipkin" or "datadog".
+	Provider string
+
+	// CollectorAddress is the host:port of the collector the spans are sent
+	// to. The host is resolved through DNS by Envoy.
+	CollectorAddress string
+
+	// CollectorEndpoint is the path spans are posted to by the "zipkin"
+	// tracer. It defaults to "/api/v1/spans".
+	CollectorEndpoint string `json:",omitempty"`
+
+	// ServiceName is the service the spans of the "datadog" tracer are
+	// reported for. It defaults to the name of the service of the proxy.
+	ServiceName string `json:",omitempty"`
+
+	// SamplingPercent is the percentage of the requests without a tracing
+	// decision which are traced. Zero traces all of them.
+	SamplingPercent float64 `json:",omitempty"`
+}
+
+// Validate checks the tracing config.
+func (c *TracingConfig) Validate() error {
+	// A disabled config only overrides the config of the proxy-defaults
+	// entry and needs no collector.
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Provider {
+	case TracingProviderZipkin:
+		if c.ServiceName != "" {
+			return fmt.Errorf("ServiceName requires the %q provider", TracingProviderDatadog)
+		}
+	case TracingProviderDatadog:
+		if c.CollectorEndpoint != "" {
+			return fmt.Errorf("CollectorEndpoint requires the %q provider", TracingProviderZipkin)
+		}
+	default:
+		return fmt.Errorf("Provider %q is invalid, must be one of %q or %q",
+			c.Provider, TracingProviderZipkin, TracingProviderDatadog)
+	}
+	host, port, err := net.SplitHostPort(c.CollectorAddress)
+	if err != nil {
+		return fmt.Errorf("CollectorAddress is invalid: %v", err)
+	}
+	if host == "" {
+		return fmt.Errorf("CollectorAddress must have a host")
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("CollectorAddress has an invalid port %q", port)
+	}
+	if c.SamplingPercent < 0 || c.SamplingPercent > 100 {
+		return fmt.Errorf("SamplingPercent must be between 0 and 100")
+	}
+	return nil
+}
+
+// ResolveTracing returns the tracing config of the proxies of a service given
+// the proxy-defaults and the service-defaults entries, either of which may be
+// nil. It returns nil if tracing is disabled.
+func ResolveTracing(proxyDefaults *ProxyConfigEntry, serviceDefaults *ServiceConfigEntry) *TracingConfig {
+	var cfg *TracingConfig
+	if proxyDefaults != nil {
+		cfg = proxyDefaults.Tracing
+	}
+	if serviceDefaults != nil && serviceDefaults.Tracing != nil {
+		cfg = serviceDefaults.Tracing
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	return cfg
+}
@@ -0,0 +1,214 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyConfigEntry_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name       string
+		Entry      string
+		AccessLogs *AccessLogsConfig
+		Err        string
+	}{
+		{
+			"valid",
+			"global",
+			&AccessLogsConfig{Enabled: true, Sink: "file", Path: "/var/log/envoy/access.log", SamplingPercent: 12.5},
+			"",
+		},
+		{
+			"no access logs",
+			"global",
+			nil,
+			"",
+		},
+		{
+			"wrong name",
+			"web",
+			nil,
+			`Name must be "global"`,
+		},
+		{
+			"invalid sink",
+			"global",
+			&AccessLogsConfig{Enabled: true, Sink: "syslog"},
+			`Sink "syslog" is invalid`,
+		},
+		{
+			"file without path",
+			"global",
+			&AccessLogsConfig{Enabled: true, Sink: "file"},
+			`Path is required for the "file" sink`,
+		},
+		{
+			"path without file",
+			"global",
+			&AccessLogsConfig{Enabled: true, Path: "/var/log/envoy/access.log"},
+			`Path requires the "file" sink`,
+		},
+		{
+			"sampling too high",
+			"global",
+			&AccessLogsConfig{Enabled: true, SamplingPercent: 101},
+			"SamplingPercent must be between 0 and 100",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			entry := &ProxyConfigEntry{
+				Name:       tc.Entry,
+				AccessLogs: tc.AccessLogs,
+			}
+			err := entry.Validate()
+			if tc.Err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.Err)
+		})
+	}
+}
+
+func TestServiceConfigEntry_Validate(t *testing.T) {
+	t.Parallel()
+
+	entry := &ServiceConfigEntry{Name: "web"}
+	require.NoError(t, entry.Validate())
+
+	entry.AccessLogs = &AccessLogsConfig{SamplingPercent: -1}
+	require.EqualError(t, entry.Validate(), "AccessLogs is invalid: SamplingPercent must be between 0 and 100")
+
+	entry = &ServiceConfigEntry{}
+	require.EqualError(t, entry.Validate(), "Name is required")
+}
+
+func TestResolveAccessLogs(t *testing.T) {
+	t.Parallel()
+
+	global := &ProxyConfigEntry{
+		Name:       ProxyConfigGlobal,
+		AccessLogs: &AccessLogsConfig{Enabled: true},
+	}
+	web := &ServiceConfigEntry{
+		Name:       "web",
+		AccessLogs: &AccessLogsConfig{Enabled: true, Sink: "stderr"},
+	}
+	disabled := &ServiceConfigEntry{
+		Name:       "db",
+		AccessLogs: &AccessLogsConfig{Enabled: false},
+	}
+
+	require.Nil(t, ResolveAccessLogs(nil, nil))
+	require.Equal(t, global.AccessLogs, ResolveAccessLogs(global, nil))
+	require.Equal(t, global.AccessLogs, ResolveAccessLogs(global, &ServiceConfigEntry{Name: "api"}))
+	require.Equal(t, web.AccessLogs, ResolveAccessLogs(global, web))
+	require.Equal(t, web.AccessLogs, ResolveAccessLogs(nil, web))
+	require.Nil(t, ResolveAccessLogs(global, disabled))
+}
+
+func TestTracingConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name    string
+		Tracing TracingConfig
+		Err     string
+	}{
+		{
+			"zipkin",
+			TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: "zipkin.service.consul:9411", CollectorEndpoint: "/api/v2/spans"},
+			"",
+		},
+		{
+			"datadog",
+			TracingConfig{Enabled: true, Provider: "datadog", CollectorAddress: "127.0.0.1:8126", ServiceName: "web", SamplingPercent: 5},
+			"",
+		},
+		{
+			"disabled",
+			TracingConfig{Enabled: false},
+			"",
+		},
+		{
+			"invalid provider",
+			TracingConfig{Enabled: true, Provider: "jaeger", CollectorAddress: "127.0.0.1:9411"},
+			`Provider "jaeger" is invalid`,
+		},
+		{
+			"missing port",
+			TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: "zipkin.service.consul"},
+			"CollectorAddress is invalid",
+		},
+		{
+			"invalid port",
+			TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: "zipkin.service.consul:http"},
+			`CollectorAddress has an invalid port "http"`,
+		},
+		{
+			"missing host",
+			TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: ":9411"},
+			"CollectorAddress must have a host",
+		},
+		{
+			"endpoint with datadog",
+			TracingConfig{Enabled: true, Provider: "datadog", CollectorAddress: "127.0.0.1:8126", CollectorEndpoint: "/api/v1/spans"},
+			`CollectorEndpoint requires the "zipkin" provider`,
+		},
+		{
+			"service name with zipkin",
+			TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: "127.0.0.1:9411", ServiceName: "web"},
+			`ServiceName requires the "datadog" provider`,
+		},
+		{
+			"sampling too low",
+			TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: "127.0.0.1:9411", SamplingPercent: -1},
+			"SamplingPercent must be between 0 and 100",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Tracing.Validate()
+			if tc.Err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.Err)
+		})
+	}
+}
+
+func TestResolveTracing(t *testing.T) {
+	t.Parallel()
+
+	global := &ProxyConfigEntry{
+		Name:    ProxyConfigGlobal,
+		Tracing: &TracingConfig{Enabled: true, Provider: "zipkin", CollectorAddress: "127.0.0.1:9411"},
+	}
+	web := &ServiceConfigEntry{
+		Name:    "web",
+		Tracing: &TracingConfig{Enabled: true, Provider: "datadog", CollectorAddress: "127.0.0.1:8126"},
+	}
+	disabled := &ServiceConfigEntry{
+		Name:    "db",
+		Tracing: &TracingConfig{Enabled: false},
+	}
+
+	require.Nil(t, ResolveTracing(nil, nil))
+	require.Equal(t, global.Tracing, ResolveTracing(global, nil))
+	require.Equal(t, global.Tracing, ResolveTracing(global, &ServiceConfigEntry{Name: "api"}))
+	require.Equal(t, web.Tracing, ResolveTracing(global, web))
+	require.Nil(t, ResolveTracing(global, disabled))
+
+	// A disabled tracing config of the service-defaults entry is valid
+	// without a collector.
+	require.NoError(t, disabled.Validate())
+}
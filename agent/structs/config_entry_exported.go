@@ -0,0 +1,122 @@
+package structs
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+// ExportedServicesName is the name of the only exported-services config
+// entry of a datacenter.
+const ExportedServicesName = "default"
+
+// ExportedServicesConfigEntry controls which services of the datacenter are
+// visible to queries forwarded from other datacenters. Without the entry all
+// services are visible. Once it exists only the services it lists can be
+// discovered from the datacenters they are exported to.
+type ExportedServicesConfigEntry struct {
+	Kind string
+	Name string
+
+	// Services are the services visible to other datacenters.
+	Services []ExportedService
+
+	RaftIndex
+}
+
+// ExportedService is a service exported to other datacenters.
+type ExportedService struct {
+	// Name is the name of the service. The wildcard "*" exports all
+	// services.
+	Name string
+
+	// Datacenters are the datacenters the service is visible to. If empty
+	// the service is visible to all datacenters.
+	Datacenters []string `json:",omitempty"`
+}
+
+func (e *ExportedServicesConfigEntry) GetKind() string {
+	return ExportedServices
+}
+
+func (e *ExportedServicesConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+	return e.Name
+}
+
+func (e *ExportedServicesConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+	e.Kind = ExportedServices
+	return nil
+}
+
+func (e *ExportedServicesConfigEntry) Validate() error {
+	if e.Name != ExportedServicesName {
+		return fmt.Errorf("Name must be %q", ExportedServicesName)
+	}
+
+	seen := make(map[string]bool)
+	for _, svc := range e.Services {
+		if svc.Name == "" {
+			return fmt.Errorf("Service name is required")
+		}
+		if seen[svc.Name] {
+			return fmt.Errorf("Service %q was specified more than once", svc.Name)
+		}
+		seen[svc.Name] = true
+
+		for _, dc := range svc.Datacenters {
+			if dc == "" {
+				return fmt.Errorf("Service %q has an empty datacenter", svc.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *ExportedServicesConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.OperatorRead()
+}
+
+func (e *ExportedServicesConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.OperatorWrite()
+}
+
+func (e *ExportedServicesConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+	return &e.RaftIndex
+}
+
+// Exports returns whether the service is visible to the given datacenter.
+// A service listed by name takes precedence over the wildcard.
+func (e *ExportedServicesConfigEntry) Exports(service, dc string) bool {
+	var wildcard *ExportedService
+	for i := range e.Services {
+		svc := &e.Services[i]
+		if svc.Name == service {
+			return svc.exportedTo(dc)
+		}
+		if svc.Name == "*" {
+			wildcard = svc
+		}
+	}
+	return wildcard != nil && wildcard.exportedTo(dc)
+}
+
+func (s *ExportedService) exportedTo(dc string) bool {
+	if len(s.Datacenters) == 0 {
+		return true
+	}
+	for _, d := range s.Datacenters {
+		if d == dc {
+			return true
+		}
+	}
+	return false
+}
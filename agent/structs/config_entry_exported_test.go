@@ -0,0 +1,92 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportedServicesConfigEntry_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name     string
+		Entry    string
+		Services []ExportedService
+		Err      string
+	}{
+		{
+			"valid",
+			"default",
+			[]ExportedService{
+				{Name: "web"},
+				{Name: "db", Datacenters: []string{"dc2"}},
+				{Name: "*", Datacenters: []string{"dc3"}},
+			},
+			"",
+		},
+		{
+			"wrong name",
+			"exports",
+			nil,
+			`Name must be "default"`,
+		},
+		{
+			"no service name",
+			"default",
+			[]ExportedService{{Datacenters: []string{"dc2"}}},
+			"Service name is required",
+		},
+		{
+			"duplicate service",
+			"default",
+			[]ExportedService{{Name: "web"}, {Name: "web"}},
+			`Service "web" was specified more than once`,
+		},
+		{
+			"empty datacenter",
+			"default",
+			[]ExportedService{{Name: "web", Datacenters: []string{""}}},
+			"empty datacenter",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			entry := &ExportedServicesConfigEntry{
+				Name:     tc.Entry,
+				Services: tc.Services,
+			}
+			err := entry.Validate()
+			if tc.Err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.Err)
+		})
+	}
+}
+
+func TestExportedServicesConfigEntry_Exports(t *testing.T) {
+	t.Parallel()
+
+	entry := &ExportedServicesConfigEntry{
+		Name: "default",
+		Services: []ExportedService{
+			{Name: "web"},
+			{Name: "db", Datacenters: []string{"dc2"}},
+			{Name: "*", Datacenters: []string{"dc3"}},
+		},
+	}
+
+	require.True(t, entry.Exports("web", "dc2"))
+	require.True(t, entry.Exports("web", "dc3"))
+	require.True(t, entry.Exports("db", "dc2"))
+	require.False(t, entry.Exports("db", "dc3"), "an explicit entry takes precedence over the wildcard")
+	require.True(t, entry.Exports("api", "dc3"))
+	require.False(t, entry.Exports("api", "dc2"))
+
+	entry.Services = nil
+	require.False(t, entry.Exports("web", "dc2"))
+}
@@ -0,0 +1,324 @@
+package structs
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+// TerminatingGatewayConfigEntry manages the services that a terminating
+// gateway proxies traffic to. The name of the entry is the name of the
+// gateway service. Connect services dial a linked service as an ordinary
+// upstream and the gateway authorizes the connection with intentions before
+// forwarding it to the instances of the linked service in the catalog.
+type TerminatingGatewayConfigEntry struct {
+	Kind string
+	Name string
+
+	// Services are the services outside of the mesh that the gateway
+	// forwards connections to.
+	Services []LinkedService
+
+	RaftIndex
+}
+
+// LinkedService is a service represented by a terminating gateway.
+type LinkedService struct {
+	// Name is the name of the service in the catalog.
+	Name string
+
+	// CAFile is the path to a CA bundle on the gateway's host used to verify
+	// the destination. If set the gateway originates TLS to the destination.
+	CAFile string `json:",omitempty"`
+
+	// CertFile and KeyFile are the paths to a client certificate and key on
+	// the gateway's host that are presented to the destination.
+	CertFile string `json:",omitempty"`
+	KeyFile  string `json:",omitempty"`
+
+	// SNI is the server name sent to the destination when originating TLS.
+	SNI string `json:",omitempty"`
+}
+
+// TLS returns whether the gateway originates TLS to the service.
+func (s *LinkedService) TLS() bool {
+	return s.CAFile != "" || s.CertFile != ""
+}
+
+func (e *TerminatingGatewayConfigEntry) GetKind() string {
+	return TerminatingGateway
+}
+
+func (e *TerminatingGatewayConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+	return e.Name
+}
+
+func (e *TerminatingGatewayConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+	e.Kind = TerminatingGateway
+	return nil
+}
+
+func (e *TerminatingGatewayConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	seen := make(map[string]bool)
+	for _, svc := range e.Services {
+		if svc.Name == "" {
+			return fmt.Errorf("Service name is required")
+		}
+		if seen[svc.Name] {
+			return fmt.Errorf("Service %q was specified more than once", svc.Name)
+		}
+		seen[svc.Name] = true
+
+		if (svc.CertFile == "") != (svc.KeyFile == "") {
+			return fmt.Errorf("Service %q must specify both a CertFile and a KeyFile or neither", svc.Name)
+		}
+	}
+	return nil
+}
+
+func (e *TerminatingGatewayConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.ServiceRead(e.Name)
+}
+
+func (e *TerminatingGatewayConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.OperatorWrite()
+}
+
+func (e *TerminatingGatewayConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+	return &e.RaftIndex
+}
+
+// LinkedService returns the linked service with the given name or nil.
+func (e *TerminatingGatewayConfigEntry) LinkedService(name string) *LinkedService {
+	for i := range e.Services {
+		if e.Services[i].Name == name {
+			return &e.Services[i]
+		}
+	}
+	return nil
+}
+
+// IngressGatewayConfigEntry manages the listeners of an ingress gateway and
+// the Connect services exposed on them. The name of the entry is the name of
+// the gateway service. The gateway dials the services with its own identity
+// so intentions must allow the gateway as a source.
+type IngressGatewayConfigEntry struct {
+	Kind string
+	Name string
+
+	// Listeners are the ports the gateway accepts connections on.
+	Listeners []IngressListener
+
+	RaftIndex
+}
+
+// IngressListener is a port of an ingress gateway and the services it routes
+// to.
+type IngressListener struct {
+	// Port is the port the gateway listens on.
+	Port int
+
+	// TLS terminates TLS on the listener with the certificate and key the
+	// agent is configured with. Connections are routed by the SNI the client
+	// sends in this case.
+	TLS bool `json:",omitempty"`
+
+	// Services are the services reachable through the listener. Without TLS
+	// a listener can only forward to a single service.
+	Services []IngressService
+}
+
+// IngressService is a service exposed by an ingress gateway.
+type IngressService struct {
+	// Name is the name of the Connect service.
+	Name string
+
+	// Hosts are the server names routed to the service on a TLS listener. A
+	// service without hosts receives the connections no other service
+	// matches.
+	Hosts []string `json:",omitempty"`
+
+	// JWT requires requests to the service to carry a valid JWT. The gateway
+	// proxies the service as HTTP in this case and rejects requests without
+	// a valid token before they reach the mesh.
+	JWT *IngressJWT `json:",omitempty"`
+}
+
+// IngressJWT configures the validation of the JWTs presented to a service
+// exposed by an ingress gateway. Tokens are read from the Authorization
+// header as bearer tokens.
+type IngressJWT struct {
+	// Issuer is the required iss claim of the tokens.
+	Issuer string
+
+	// Audiences are the accepted aud claims. If empty the audience isn't
+	// checked.
+	Audiences []string `json:",omitempty"`
+
+	// JWKSURL is the HTTP or HTTPS URL the gateway fetches the keys that
+	// sign the tokens from.
+	JWKSURL string
+
+	// JWKSCAFile is the path to a CA bundle on the gateway's host used to
+	// verify an HTTPS JWKS server. It is required for HTTPS URLs.
+	JWKSCAFile string `json:",omitempty"`
+
+	// Claims are additional claims the tokens must carry.
+	Claims []JWTClaim `json:",omitempty"`
+}
+
+// JWTClaim is a claim a JWT must carry with one of the given values.
+type JWTClaim struct {
+	// Name is the name of the claim. Nested claims are separated by dots.
+	Name string
+
+	// Values are the accepted string values of the claim.
+	Values []string
+}
+
+// Validate checks the JWT requirements of an ingress service.
+func (j *IngressJWT) Validate() error {
+	if j.Issuer == "" {
+		return fmt.Errorf("Issuer is required")
+	}
+	u, err := url.Parse(j.JWKSURL)
+	if err != nil {
+		return fmt.Errorf("JWKSURL is invalid: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("JWKSURL must be an http or https URL")
+	}
+	if j.JWKSCAFile != "" && u.Scheme != "https" {
+		return fmt.Errorf("JWKSCAFile requires an https JWKSURL")
+	}
+	if j.JWKSCAFile == "" && u.Scheme == "https" {
+		return fmt.Errorf("JWKSCAFile is required to verify an https JWKSURL")
+	}
+	for _, c := range j.Claims {
+		if c.Name == "" {
+			return fmt.Errorf("Claim name is required")
+		}
+		if len(c.Values) == 0 {
+			return fmt.Errorf("Claim %q must have at least one value", c.Name)
+		}
+	}
+	return nil
+}
+
+func (e *IngressGatewayConfigEntry) GetKind() string {
+	return IngressGateway
+}
+
+func (e *IngressGatewayConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+	return e.Name
+}
+
+func (e *IngressGatewayConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+	e.Kind = IngressGateway
+	return nil
+}
+
+func (e *IngressGatewayConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	ports := make(map[int]bool)
+	for _, l := range e.Listeners {
+		if l.Port < 1 || l.Port > 65535 {
+			return fmt.Errorf("Listener port %d is invalid", l.Port)
+		}
+		if ports[l.Port] {
+			return fmt.Errorf("Listener port %d was specified more than once", l.Port)
+		}
+		ports[l.Port] = true
+
+		if len(l.Services) == 0 {
+			return fmt.Errorf("Listener on port %d must have at least one service", l.Port)
+		}
+		if !l.TLS && len(l.Services) > 1 {
+			return fmt.Errorf("Listener on port %d must enable TLS to route to more than one service", l.Port)
+		}
+
+		hosts := make(map[string]bool)
+		defaultService := false
+		for _, svc := range l.Services {
+			if svc.Name == "" {
+				return fmt.Errorf("Service name is required for listener on port %d", l.Port)
+			}
+			if len(svc.Hosts) > 0 && !l.TLS {
+				return fmt.Errorf("Listener on port %d must enable TLS to route by host", l.Port)
+			}
+			if len(svc.Hosts) == 0 {
+				if defaultService {
+					return fmt.Errorf("Listener on port %d can only have one service without hosts", l.Port)
+				}
+				defaultService = true
+			}
+			for _, h := range svc.Hosts {
+				if hosts[h] {
+					return fmt.Errorf("Host %q was specified more than once for listener on port %d", h, l.Port)
+				}
+				hosts[h] = true
+			}
+			if svc.JWT != nil {
+				if err := svc.JWT.Validate(); err != nil {
+					return fmt.Errorf("Service %q on listener on port %d has invalid JWT config: %v", svc.Name, l.Port, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e *IngressGatewayConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.ServiceRead(e.Name)
+}
+
+func (e *IngressGatewayConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.OperatorWrite()
+}
+
+func (e *IngressGatewayConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+	return &e.RaftIndex
+}
+
+// ServiceNames returns the names of all services exposed by the gateway
+// without duplicates.
+func (e *IngressGatewayConfigEntry) ServiceNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, l := range e.Listeners {
+		for _, svc := range l.Services {
+			if seen[svc.Name] {
+				continue
+			}
+			seen[svc.Name] = true
+			names = append(names, svc.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,160 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngressGatewayConfigEntry_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name      string
+		Listeners []IngressListener
+		Err       string
+	}{
+		{
+			"single service",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web"}}},
+			},
+			"",
+		},
+		{
+			"routing by host",
+			[]IngressListener{
+				{Port: 8443, TLS: true, Services: []IngressService{
+					{Name: "web", Hosts: []string{"web.example.com"}},
+					{Name: "api", Hosts: []string{"api.example.com"}},
+					{Name: "default"},
+				}},
+			},
+			"",
+		},
+		{
+			"invalid port",
+			[]IngressListener{
+				{Port: 0, Services: []IngressService{{Name: "web"}}},
+			},
+			"port 0 is invalid",
+		},
+		{
+			"duplicate port",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web"}}},
+				{Port: 8080, Services: []IngressService{{Name: "api"}}},
+			},
+			"more than once",
+		},
+		{
+			"no services",
+			[]IngressListener{
+				{Port: 8080},
+			},
+			"at least one service",
+		},
+		{
+			"multiple services without TLS",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web"}, {Name: "api"}}},
+			},
+			"must enable TLS",
+		},
+		{
+			"hosts without TLS",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{
+					{Name: "web", Hosts: []string{"web.example.com"}},
+				}},
+			},
+			"must enable TLS",
+		},
+		{
+			"multiple default services",
+			[]IngressListener{
+				{Port: 8443, TLS: true, Services: []IngressService{{Name: "web"}, {Name: "api"}}},
+			},
+			"only have one service without hosts",
+		},
+		{
+			"duplicate host",
+			[]IngressListener{
+				{Port: 8443, TLS: true, Services: []IngressService{
+					{Name: "web", Hosts: []string{"example.com"}},
+					{Name: "api", Hosts: []string{"example.com"}},
+				}},
+			},
+			`Host "example.com" was specified more than once`,
+		},
+		{
+			"jwt",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web", JWT: &IngressJWT{
+					Issuer:     "https://auth.example.com",
+					Audiences:  []string{"web"},
+					JWKSURL:    "https://auth.example.com/.well-known/jwks.json",
+					JWKSCAFile: "/etc/ssl/auth-ca.pem",
+					Claims:     []JWTClaim{{Name: "scope", Values: []string{"web"}}},
+				}}}},
+			},
+			"",
+		},
+		{
+			"jwt with https JWKS URL and no CA",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web", JWT: &IngressJWT{
+					Issuer:  "https://auth.example.com",
+					JWKSURL: "https://auth.example.com/.well-known/jwks.json",
+				}}}},
+			},
+			"JWKSCAFile is required",
+		},
+		{
+			"jwt without issuer",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web", JWT: &IngressJWT{
+					JWKSURL: "https://auth.example.com/.well-known/jwks.json",
+				}}}},
+			},
+			"Issuer is required",
+		},
+		{
+			"jwt with invalid JWKS URL",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web", JWT: &IngressJWT{
+					Issuer:  "https://auth.example.com",
+					JWKSURL: "/jwks.json",
+				}}}},
+			},
+			"must be an http or https URL",
+		},
+		{
+			"jwt claim without values",
+			[]IngressListener{
+				{Port: 8080, Services: []IngressService{{Name: "web", JWT: &IngressJWT{
+					Issuer:  "https://auth.example.com",
+					JWKSURL: "http://auth.example.com/.well-known/jwks.json",
+					Claims:  []JWTClaim{{Name: "scope"}},
+				}}}},
+			},
+			`Claim "scope" must have at least one value`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			entry := &IngressGatewayConfigEntry{
+				Name:      "ingress",
+				Listeners: tc.Listeners,
+			}
+			err := entry.Validate()
+			if tc.Err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.Err)
+		})
+	}
+}
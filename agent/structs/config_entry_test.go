@@ -0,0 +1,31 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexedConfigEntries_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	// Entries of all kinds are listed together when no kind is given.
+	in := &IndexedConfigEntries{
+		Entries: []ConfigEntry{
+			&ProxyConfigEntry{Kind: ProxyDefaults, Name: ProxyConfigGlobal},
+			&ServiceConfigEntry{Kind: ServiceDefaults, Name: "web", AccessLogs: &AccessLogsConfig{Enabled: true, Sink: "stdout"}},
+			&ExportedServicesConfigEntry{
+				Kind:     ExportedServices,
+				Name:     ExportedServicesName,
+				Services: []ExportedService{{Name: "web", Datacenters: []string{"dc2"}}},
+			},
+		},
+		QueryMeta: QueryMeta{Index: 42},
+	}
+	data, err := in.MarshalBinary()
+	require.NoError(t, err)
+
+	var out IndexedConfigEntries
+	require.NoError(t, out.UnmarshalBinary(data))
+	require.Equal(t, in, &out)
+}
@@ -20,6 +20,12 @@ type ConnectAuthorizeRequest struct {
 	// lists.
 	ClientCertURI    string
 	ClientCertSerial string
+
+	// ClientCertPEM is the PEM-encoded client certificate followed by its
+	// intermediates. It is optional for Consul services but required for the
+	// workloads of federated trust domains, whose certificate must chain to
+	// the roots of the trust domain in ClientCertURI.
+	ClientCertPEM string `json:",omitempty"`
 }
 
 // ProxyExecMode encodes the mode for running a managed connect proxy.
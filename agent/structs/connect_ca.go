@@ -1,6 +1,7 @@
 package structs
 
 import (
+	"encoding/pem"
 	"fmt"
 	"reflect"
 	"time"
@@ -39,14 +40,47 @@ type IndexedCARoots struct {
 	// seamless rotation between trust domains thanks to cross-signing.
 	TrustDomain string
 
+	// FederatedTrustDomains are the SPIFFE trust domains of the federated trust
+	// bundles in the CA config.
+	FederatedTrustDomains []string
+
 	// Roots is a list of root CA certs to trust.
 	Roots []*CARoot
 
+	// FederatedRoots are the roots of the federated trust domains, with
+	// ExternalTrustDomain set to their trust domain. They are kept apart from
+	// Roots since a federated root may only vouch for the workloads of its own
+	// trust domain, never for Consul services.
+	FederatedRoots []*CARoot
+
+	// Rollout is the progress of the staged root rotation in progress. It's
+	// only reported by the leader, and is nil if no rotation is in progress.
+	Rollout *CARootRollout `json:",omitempty"`
+
 	// QueryMeta contains the meta sent via a header. We ignore for JSON
 	// so this whole structure can be returned.
 	QueryMeta `json:"-"`
 }
 
+// CARootRollout is the progress of a staged root rotation.
+type CARootRollout struct {
+	// RootID is the ID of the staged root.
+	RootID string
+
+	// StartedAt is the time at which the root was staged.
+	StartedAt time.Time
+
+	// Deadline is the time at which the root is activated even if some agents
+	// haven't acknowledged it.
+	Deadline time.Time
+
+	// Acknowledged is the number of agents that have acknowledged the root.
+	Acknowledged int
+
+	// PendingAgents are the names of the agents the leader still waits for.
+	PendingAgents []string
+}
+
 // CARoot represents a root CA certificate that is trusted.
 type CARoot struct {
 	// ID is a globally unique ID (UUID) representing this CA root.
@@ -97,6 +131,11 @@ type CARoot struct {
 	// cannot be active.
 	Active bool
 
+	// Staged is true while this root is rolled out by a staged rotation. It
+	// is trusted by the agents but isn't used for signing until the rotation
+	// activates it.
+	Staged bool
+
 	// RotatedOutAt is the time at which this CA was removed from the state.
 	// This will only be set on roots that have been rotated out from being the
 	// active root.
@@ -195,6 +234,28 @@ func (q *CARequest) RequestDatacenter() string {
 	return q.Datacenter
 }
 
+// CARootsAckRequest is used by an agent to acknowledge the roots it received,
+// which is how the leader confirms a staged root has been rolled out.
+type CARootsAckRequest struct {
+	// Datacenter is the target for this request.
+	Datacenter string
+
+	// Node is the name of the agent's node.
+	Node string
+
+	// RootIDs are the IDs of the roots the agent trusts.
+	RootIDs []string
+
+	// WriteRequest is a common struct containing ACL tokens and other
+	// write-related common elements for requests.
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (q *CARootsAckRequest) RequestDatacenter() string {
+	return q.Datacenter
+}
+
 const (
 	ConsulCAProvider = "consul"
 	VaultCAProvider  = "vault"
@@ -225,6 +286,7 @@ func (c *CAConfiguration) GetCommonConfig() (*CommonCAProviderConfig, error) {
 
 	// Set Defaults
 	config.CSRMaxPerSecond = 50 // See doc comment for rationale here.
+	config.RootRolloutTimeout = 30 * time.Second
 
 	decodeConf := &mapstructure.DecoderConfig{
 		DecodeHook:       ParseDurationFunc(),
@@ -268,13 +330,66 @@ type CommonCAProviderConfig struct {
 	// how many CPU cores can be occupied by Connect CA signing activity and
 	// should be a (small) subset of your server's available cores to allow other
 	// tasks to complete when a barrage of CSRs come in (e.g. after a CA root
-	// rotation). Setting to 0 disables the limit, attempting to sign certs
-	// immediately in the RPC goroutine. This is 0 by default and CSRMaxPerSecond
-	// is used. This is ignored if CSRMaxPerSecond is non-zero.
+	// rotation). Waiting requests are queued per service and the services take
+	// turns in getting a free slot. Setting to 0 disables the limit, attempting
+	// to sign certs immediately in the RPC goroutine. This is 0 by default and
+	// CSRMaxPerSecond is used. This is ignored if CSRMaxPerSecond is non-zero.
 	CSRMaxConcurrent int
+
+	// FederatedTrustBundles are the root certificates of other SPIFFE trust
+	// domains, e.g. a mesh managed by SPIRE, that are trusted in addition to
+	// the roots of this cluster.
+	FederatedTrustBundles []FederatedTrustBundle
+
+	// RootRolloutTimeout is how long the leader waits for the agents running
+	// Connect services to acknowledge a new root before it activates it. The
+	// new root is trusted in the meantime while the old one is still used for
+	// signing, so handshakes in flight keep working. Setting it to 0 activates
+	// new roots immediately. Defaults to 30s.
+	RootRolloutTimeout time.Duration
+
+	// RootRotationMinInterval limits how often the root can be rotated. A
+	// configuration change that would rotate the root sooner than this after
+	// the previous rotation is rejected. 0 disables the limit.
+	RootRotationMinInterval time.Duration
+}
+
+// FederatedTrustBundle is the trust bundle of a federated SPIFFE trust domain.
+type FederatedTrustBundle struct {
+	// TrustDomain is the trust domain of the bundle, e.g. "spire.example.org".
+	TrustDomain string
+
+	// RootCerts are the PEM-encoded root certificates of the trust domain.
+	RootCerts []string
 }
 
 func (c CommonCAProviderConfig) Validate() error {
+	seen := make(map[string]bool)
+	for _, bundle := range c.FederatedTrustBundles {
+		if bundle.TrustDomain == "" {
+			return fmt.Errorf("federated trust bundle must have a trust domain")
+		}
+		if seen[bundle.TrustDomain] {
+			return fmt.Errorf("federated trust domain %q is specified more than once", bundle.TrustDomain)
+		}
+		seen[bundle.TrustDomain] = true
+		if len(bundle.RootCerts) == 0 {
+			return fmt.Errorf("federated trust domain %q must have at least one root cert", bundle.TrustDomain)
+		}
+		for _, cert := range bundle.RootCerts {
+			if block, _ := pem.Decode([]byte(cert)); block == nil || block.Type != "CERTIFICATE" {
+				return fmt.Errorf("federated trust domain %q has an invalid root cert", bundle.TrustDomain)
+			}
+		}
+	}
+
+	if c.RootRolloutTimeout < 0 {
+		return fmt.Errorf("root rollout timeout must not be negative")
+	}
+	if c.RootRotationMinInterval < 0 {
+		return fmt.Errorf("root rotation min interval must not be negative")
+	}
+
 	if c.SkipValidate {
 		return nil
 	}
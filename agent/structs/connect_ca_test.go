@@ -24,8 +24,9 @@ func TestCAConfiguration_GetCommonConfig(t *testing.T) {
 				},
 			},
 			want: &CommonCAProviderConfig{
-				LeafCertTTL:     72 * time.Hour,
-				CSRMaxPerSecond: 50,
+				LeafCertTTL:        72 * time.Hour,
+				CSRMaxPerSecond:    50,
+				RootRolloutTimeout: 30 * time.Second,
 			},
 		},
 		{
@@ -43,8 +44,9 @@ func TestCAConfiguration_GetCommonConfig(t *testing.T) {
 				},
 			},
 			want: &CommonCAProviderConfig{
-				LeafCertTTL:     72 * time.Hour,
-				CSRMaxPerSecond: 50, // The default value
+				LeafCertTTL:        72 * time.Hour,
+				CSRMaxPerSecond:    50, // The default value
+				RootRolloutTimeout: 30 * time.Second,
 			},
 		},
 	}
@@ -59,3 +61,59 @@ func TestCAConfiguration_GetCommonConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCommonCAProviderConfig_Validate_federatedTrustBundles(t *testing.T) {
+	const rootPEM = `-----BEGIN CERTIFICATE-----
+MIIBdzCCAR2gAwIBAgIBATAKBggqhkjOPQQDAjARMQ8wDQYDVQQDEwZ0ZXN0Y2Ew
+HhcNMTkwMTAxMDAwMDAwWhcNMjkwMTAxMDAwMDAwWjARMQ8wDQYDVQQDEwZ0ZXN0
+-----END CERTIFICATE-----`
+
+	tests := []struct {
+		name    string
+		bundles []FederatedTrustBundle
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			bundles: []FederatedTrustBundle{{TrustDomain: "spire.example.org", RootCerts: []string{rootPEM}}},
+		},
+		{
+			name:    "missing trust domain",
+			bundles: []FederatedTrustBundle{{RootCerts: []string{rootPEM}}},
+			wantErr: "must have a trust domain",
+		},
+		{
+			name: "duplicate trust domain",
+			bundles: []FederatedTrustBundle{
+				{TrustDomain: "spire.example.org", RootCerts: []string{rootPEM}},
+				{TrustDomain: "spire.example.org", RootCerts: []string{rootPEM}},
+			},
+			wantErr: "more than once",
+		},
+		{
+			name:    "no root certs",
+			bundles: []FederatedTrustBundle{{TrustDomain: "spire.example.org"}},
+			wantErr: "at least one root cert",
+		},
+		{
+			name:    "invalid root cert",
+			bundles: []FederatedTrustBundle{{TrustDomain: "spire.example.org", RootCerts: []string{"nope"}}},
+			wantErr: "invalid root cert",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := CommonCAProviderConfig{
+				LeafCertTTL:           72 * time.Hour,
+				FederatedTrustBundles: tt.bundles,
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -2,8 +2,12 @@ package structs
 
 import (
 	"fmt"
+	"net"
+	"time"
 
 	"github.com/hashicorp/consul/api"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/mitchellh/mapstructure"
 )
 
 // ConnectProxyConfig describes the configuration needed for any proxy managed
@@ -43,6 +47,11 @@ type ConnectProxyConfig struct {
 	// Upstreams describes any upstream dependencies the proxy instance should
 	// setup.
 	Upstreams Upstreams `json:",omitempty"`
+
+	// TransparentProxy configures how the traffic of the local service is
+	// redirected to the proxy. It is only used by tools that set up the
+	// redirection such as "consul connect redirect-traffic".
+	TransparentProxy *TransparentProxyConfig `json:",omitempty"`
 }
 
 // ToAPI returns the api struct with the same fields. We have duplicates to
@@ -57,6 +66,78 @@ func (c *ConnectProxyConfig) ToAPI() *api.AgentServiceConnectProxyConfig {
 		LocalServicePort:       c.LocalServicePort,
 		Config:                 c.Config,
 		Upstreams:              c.Upstreams.ToAPI(),
+		TransparentProxy:       c.TransparentProxy.ToAPI(),
+	}
+}
+
+// TransparentProxyOutboundListenerPortDefault is the port the proxy listens
+// on for redirected outbound traffic if none is configured.
+const TransparentProxyOutboundListenerPortDefault = 15001
+
+// TransparentProxyConfig describes which traffic of a service is redirected to
+// its proxy.
+type TransparentProxyConfig struct {
+	// OutboundListenerPort is the port the proxy listens on for the outbound
+	// traffic of the service. It defaults to 15001.
+	OutboundListenerPort int `json:",omitempty"`
+
+	// ExcludeInboundPorts are the ports of inbound traffic that goes to the
+	// service directly instead of through the proxy.
+	ExcludeInboundPorts []int `json:",omitempty"`
+
+	// ExcludeOutboundPorts are the ports of outbound traffic that isn't
+	// redirected to the proxy.
+	ExcludeOutboundPorts []int `json:",omitempty"`
+
+	// ExcludeOutboundCIDRs are the destinations of outbound traffic that isn't
+	// redirected to the proxy.
+	ExcludeOutboundCIDRs []string `json:",omitempty"`
+
+	// ExcludeUIDs are the users whose outbound traffic isn't redirected to the
+	// proxy.
+	ExcludeUIDs []string `json:",omitempty"`
+}
+
+// Validate checks the ports and addresses of the config.
+func (c *TransparentProxyConfig) Validate() error {
+	var result error
+	if c.OutboundListenerPort < 0 || c.OutboundListenerPort > 65535 {
+		result = multierror.Append(result, fmt.Errorf(
+			"OutboundListenerPort %d is not a valid port", c.OutboundListenerPort))
+	}
+	for _, port := range c.ExcludeInboundPorts {
+		if port < 1 || port > 65535 {
+			result = multierror.Append(result, fmt.Errorf(
+				"ExcludeInboundPorts: %d is not a valid port", port))
+		}
+	}
+	for _, port := range c.ExcludeOutboundPorts {
+		if port < 1 || port > 65535 {
+			result = multierror.Append(result, fmt.Errorf(
+				"ExcludeOutboundPorts: %d is not a valid port", port))
+		}
+	}
+	for _, cidr := range c.ExcludeOutboundCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil && net.ParseIP(cidr) == nil {
+			result = multierror.Append(result, fmt.Errorf(
+				"ExcludeOutboundCIDRs: %q is not a valid IP or CIDR", cidr))
+		}
+	}
+	return result
+}
+
+// ToAPI returns the api struct with the same fields, or nil if the config is
+// nil.
+func (c *TransparentProxyConfig) ToAPI() *api.TransparentProxyConfig {
+	if c == nil {
+		return nil
+	}
+	return &api.TransparentProxyConfig{
+		OutboundListenerPort: c.OutboundListenerPort,
+		ExcludeInboundPorts:  c.ExcludeInboundPorts,
+		ExcludeOutboundPorts: c.ExcludeOutboundPorts,
+		ExcludeOutboundCIDRs: c.ExcludeOutboundCIDRs,
+		ExcludeUIDs:          c.ExcludeUIDs,
 	}
 }
 
@@ -191,3 +272,133 @@ func UpstreamFromAPI(u api.Upstream) Upstream {
 		Config:               u.Config,
 	}
 }
+
+// The load balancing policies that can be configured for an upstream with the
+// "lb_policy" config key.
+const (
+	UpstreamLBPolicyRandom       = "random"
+	UpstreamLBPolicyRoundRobin   = "round_robin"
+	UpstreamLBPolicyLeastRequest = "least_request"
+)
+
+// UpstreamConfig holds the settings of an upstream that are understood by
+// both the built-in proxy and the xDS server. They are read from the opaque
+// Upstream.Config map. Zero values mean the proxy default is used.
+type UpstreamConfig struct {
+	// ConnectTimeoutMs is the timeout for establishing a connection to an
+	// instance of the upstream.
+	ConnectTimeoutMs int `mapstructure:"connect_timeout_ms"`
+
+	// RequestTimeoutMs is the time a connection to the upstream may be idle
+	// before it is closed. Since upstreams are proxied at the TCP level this is
+	// the closest equivalent to a request timeout.
+	RequestTimeoutMs int `mapstructure:"request_timeout_ms"`
+
+	// LBPolicy is the policy used to pick an instance of the upstream for a
+	// new connection. One of the UpstreamLBPolicy constants.
+	LBPolicy string `mapstructure:"lb_policy"`
+
+	// PassiveHealthCheck configures the ejection of instances that repeatedly
+	// fail to accept connections.
+	PassiveHealthCheck *PassiveHealthCheck `mapstructure:"passive_health_check"`
+
+	// TCPKeepaliveIntervalMs is the TCP keepalive period of the connections
+	// of the upstream. Negative values disable keepalives. It is only honored
+	// by the built-in proxy.
+	TCPKeepaliveIntervalMs int `mapstructure:"tcp_keepalive_interval_ms"`
+
+	// MaxConnections limits the number of concurrent connections to the
+	// upstream. It is only honored by the built-in proxy.
+	MaxConnections int `mapstructure:"max_connections"`
+}
+
+// PassiveHealthCheck configures passive health checking of the instances of
+// an upstream. An instance is ejected from load balancing for Interval after
+// MaxFailures consecutive failures.
+type PassiveHealthCheck struct {
+	MaxFailures int           `mapstructure:"max_failures"`
+	Interval    time.Duration `mapstructure:"interval"`
+}
+
+// ConnectTimeout returns the connect timeout or def if none is configured.
+func (c UpstreamConfig) ConnectTimeout(def time.Duration) time.Duration {
+	if c.ConnectTimeoutMs > 0 {
+		return time.Duration(c.ConnectTimeoutMs) * time.Millisecond
+	}
+	return def
+}
+
+// RequestTimeout returns the request timeout or zero if none is configured.
+func (c UpstreamConfig) RequestTimeout() time.Duration {
+	return time.Duration(c.RequestTimeoutMs) * time.Millisecond
+}
+
+// TCPKeepalive returns the TCP keepalive period, which is zero if none is
+// configured and negative if keepalives are disabled.
+func (c UpstreamConfig) TCPKeepalive() time.Duration {
+	return time.Duration(c.TCPKeepaliveIntervalMs) * time.Millisecond
+}
+
+// ParseUpstreamConfig decodes the well-known keys of the opaque config of an
+// upstream. Unknown keys are ignored since the config may also contain
+// settings for a specific proxy. Numbers may be given as JSON numbers or
+// strings and the passive health check interval as a duration string such as
+// "10s".
+func ParseUpstreamConfig(m map[string]interface{}) (UpstreamConfig, error) {
+	var cfg UpstreamConfig
+	if len(m) == 0 {
+		return cfg, nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &cfg,
+	})
+	if err != nil {
+		return cfg, err
+	}
+	// A nested block in HCL decodes to a slice with a single map.
+	if phc, ok := m["passive_health_check"].([]map[string]interface{}); ok && len(phc) == 1 {
+		m = copyMap(m)
+		m["passive_health_check"] = phc[0]
+	}
+	if err := decoder.Decode(m); err != nil {
+		return cfg, err
+	}
+
+	switch {
+	case cfg.ConnectTimeoutMs < 0:
+		return cfg, fmt.Errorf("connect_timeout_ms cannot be negative")
+	case cfg.RequestTimeoutMs < 0:
+		return cfg, fmt.Errorf("request_timeout_ms cannot be negative")
+	case cfg.MaxConnections < 0:
+		return cfg, fmt.Errorf("max_connections cannot be negative")
+	}
+
+	switch cfg.LBPolicy {
+	case "", UpstreamLBPolicyRandom, UpstreamLBPolicyRoundRobin, UpstreamLBPolicyLeastRequest:
+	default:
+		return cfg, fmt.Errorf("unknown lb_policy %q, must be one of %q, %q or %q",
+			cfg.LBPolicy, UpstreamLBPolicyRandom, UpstreamLBPolicyRoundRobin,
+			UpstreamLBPolicyLeastRequest)
+	}
+
+	if phc := cfg.PassiveHealthCheck; phc != nil {
+		if phc.MaxFailures <= 0 {
+			return cfg, fmt.Errorf("passive_health_check.max_failures must be > 0")
+		}
+		if phc.Interval <= 0 {
+			phc.Interval = 10 * time.Second
+		}
+	}
+	return cfg, nil
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
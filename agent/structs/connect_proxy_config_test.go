@@ -3,6 +3,7 @@ package structs
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/require"
@@ -179,3 +180,95 @@ func TestUpstream_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUpstreamConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   map[string]interface{}
+		want    UpstreamConfig
+		wantErr string
+	}{
+		{
+			name:  "empty",
+			input: nil,
+			want:  UpstreamConfig{},
+		},
+		{
+			name: "json",
+			input: map[string]interface{}{
+				"connect_timeout_ms": float64(1000),
+				"request_timeout_ms": float64(30000),
+				"lb_policy":          "round_robin",
+				"passive_health_check": map[string]interface{}{
+					"max_failures": float64(5),
+					"interval":     "30s",
+				},
+				"tcp_keepalive_interval_ms": float64(60000),
+				"max_connections":           float64(100),
+				"envoy_cluster_json":        "{}",
+			},
+			want: UpstreamConfig{
+				ConnectTimeoutMs: 1000,
+				RequestTimeoutMs: 30000,
+				LBPolicy:         UpstreamLBPolicyRoundRobin,
+				PassiveHealthCheck: &PassiveHealthCheck{
+					MaxFailures: 5,
+					Interval:    30 * time.Second,
+				},
+				TCPKeepaliveIntervalMs: 60000,
+				MaxConnections:         100,
+			},
+		},
+		{
+			name: "hcl block and default interval",
+			input: map[string]interface{}{
+				"connect_timeout_ms": "500",
+				"passive_health_check": []map[string]interface{}{
+					{"max_failures": 3},
+				},
+			},
+			want: UpstreamConfig{
+				ConnectTimeoutMs: 500,
+				PassiveHealthCheck: &PassiveHealthCheck{
+					MaxFailures: 3,
+					Interval:    10 * time.Second,
+				},
+			},
+		},
+		{
+			name:    "unknown lb policy",
+			input:   map[string]interface{}{"lb_policy": "fastest"},
+			wantErr: `unknown lb_policy "fastest"`,
+		},
+		{
+			name:    "negative timeout",
+			input:   map[string]interface{}{"connect_timeout_ms": -1},
+			wantErr: "connect_timeout_ms cannot be negative",
+		},
+		{
+			name:    "negative max connections",
+			input:   map[string]interface{}{"max_connections": -1},
+			wantErr: "max_connections cannot be negative",
+		},
+		{
+			name: "no max failures",
+			input: map[string]interface{}{
+				"passive_health_check": map[string]interface{}{"interval": "5s"},
+			},
+			wantErr: "max_failures must be > 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			got, err := ParseUpstreamConfig(tt.input)
+			if tt.wantErr != "" {
+				require.Error(err)
+				require.Contains(err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.want, got)
+		})
+	}
+}
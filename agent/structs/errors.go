@@ -12,6 +12,7 @@ const (
 	errNotReadyForConsistentReads = "Not ready to serve consistent reads"
 	errSegmentsNotSupported       = "Network segments are not supported in this version of Consul"
 	errRPCRateExceeded            = "RPC rate limit exceeded"
+	errQueryLimitExceeded         = "Query concurrency limit exceeded"
 	errServiceNotFound            = "Service not found: "
 )
 
@@ -22,6 +23,7 @@ var (
 	ErrNotReadyForConsistentReads = errors.New(errNotReadyForConsistentReads)
 	ErrSegmentsNotSupported       = errors.New(errSegmentsNotSupported)
 	ErrRPCRateExceeded            = errors.New(errRPCRateExceeded)
+	ErrQueryLimitExceeded         = errors.New(errQueryLimitExceeded)
 )
 
 func IsErrNoLeader(err error) bool {
@@ -32,6 +34,10 @@ func IsErrRPCRateExceeded(err error) bool {
 	return err != nil && strings.Contains(err.Error(), errRPCRateExceeded)
 }
 
+func IsErrQueryLimitExceeded(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errQueryLimitExceeded)
+}
+
 func IsErrServiceNotFound(err error) bool {
 	return err != nil && strings.Contains(err.Error(), errServiceNotFound)
 }
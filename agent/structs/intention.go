@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -157,9 +158,14 @@ func (x *Intention) Validate() error {
 
 	switch x.SourceType {
 	case IntentionSourceConsul:
+	case IntentionSourceSPIFFE:
+		if u, err := url.Parse(x.SourceName); err != nil || u.Scheme != "spiffe" || u.Host == "" {
+			result = multierror.Append(result, fmt.Errorf(
+				"SourceName must be a SPIFFE ID for the 'spiffe' SourceType"))
+		}
 	default:
 		result = multierror.Append(result, fmt.Errorf(
-			"SourceType must be set to 'consul'"))
+			"SourceType must be set to 'consul' or 'spiffe'"))
 	}
 
 	return result
@@ -252,6 +258,10 @@ type IntentionSourceType string
 const (
 	// IntentionSourceConsul is a service within the Consul catalog.
 	IntentionSourceConsul IntentionSourceType = "consul"
+
+	// IntentionSourceSPIFFE is a workload of a federated SPIFFE trust domain.
+	// The source name is its full SPIFFE ID.
+	IntentionSourceSPIFFE IntentionSourceType = "spiffe"
 )
 
 // Intentions is a list of intentions.
@@ -289,6 +299,9 @@ type IntentionRequest struct {
 	// Intention is the intention.
 	Intention *Intention
 
+	// Audit is recorded in the audit trail along with the change.
+	Audit AuditInfo
+
 	// WriteRequest is a common struct containing ACL tokens and other
 	// write-related common elements for requests.
 	WriteRequest
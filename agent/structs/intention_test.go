@@ -142,6 +142,24 @@ func TestIntentionValidate(t *testing.T) {
 			func(x *Intention) { x.SourceType = IntentionSourceType("other") },
 			"SourceType must",
 		},
+
+		{
+			"SourceType is spiffe",
+			func(x *Intention) {
+				x.SourceType = IntentionSourceSPIFFE
+				x.SourceName = "spiffe://spire.example.org/ns/prod/sa/web"
+			},
+			"",
+		},
+
+		{
+			"SourceType is spiffe without SPIFFE ID",
+			func(x *Intention) {
+				x.SourceType = IntentionSourceSPIFFE
+				x.SourceName = "web"
+			},
+			"must be a SPIFFE ID",
+		},
 	}
 
 	for _, tc := range cases {
@@ -101,4 +101,54 @@ type NetworkSegment struct {
 	// RPCListener is whether to bind a separate RPC listener on the bind address
 	// for this segment.
 	RPCListener bool
+
+	// CAFile, CertFile and KeyFile override the TLS configuration of the
+	// segment's RPC listener, for segments in network zones with their own
+	// PKI. They are only used with RPCListener.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// StateIndexUsage has the approximate memory used by an index of a state
+// store table.
+type StateIndexUsage struct {
+	// Name is the name of the index.
+	Name string
+
+	// Entries is the number of keys in the index.
+	Entries int
+
+	// Bytes is the total length of the keys in the index.
+	Bytes uint64
+}
+
+// StateTableUsage has the approximate memory used by a state store table.
+type StateTableUsage struct {
+	// Name is the name of the table.
+	Name string
+
+	// Objects is the number of objects stored in the table.
+	Objects int
+
+	// Bytes is the total size of the objects, estimated from their msgpack
+	// encoding.
+	Bytes uint64
+
+	// Indexes has the usage of each of the table's indexes.
+	Indexes []*StateIndexUsage
+}
+
+// StateUsageResponse is returned when querying the memory usage of the state
+// store of a server.
+type StateUsageResponse struct {
+	// Node is the name of the server that reported the usage. Servers hold
+	// the same data, but the reply comes from the leader unless stale reads
+	// are allowed.
+	Node string
+
+	// Tables has the usage of each table of the state store.
+	Tables []*StateTableUsage
+
+	QueryMeta
 }
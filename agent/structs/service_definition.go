@@ -19,6 +19,7 @@ type ServiceDefinition struct {
 	Name              string
 	Tags              []string
 	Address           string
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	Meta              map[string]string
 	Port              int
 	Check             CheckType
@@ -26,6 +27,13 @@ type ServiceDefinition struct {
 	Weights           *Weights
 	Token             string
 	EnableTagOverride bool
+
+	// InheritTags and InheritMeta are only used for a sidecar_service. When
+	// set the tags and meta of the parent service are merged with the ones
+	// of the sidecar instead of only being copied if the sidecar has none.
+	InheritTags bool `json:",omitempty"`
+	InheritMeta bool `json:",omitempty"`
+
 	// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
 	// ProxyDestination is deprecated in favor of Proxy.DestinationServiceName
 	ProxyDestination string `json:",omitempty"`
@@ -52,6 +60,7 @@ func (s *ServiceDefinition) NodeService() *NodeService {
 		Service:           s.Name,
 		Tags:              s.Tags,
 		Address:           s.Address,
+		TaggedAddresses:   s.TaggedAddresses,
 		Meta:              s.Meta,
 		Port:              s.Port,
 		Weights:           s.Weights,
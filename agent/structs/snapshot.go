@@ -29,6 +29,14 @@ type SnapshotRequest struct {
 
 	// Op is the operation code for the RPC.
 	Op SnapshotOp
+
+	// DryRun verifies the snapshot and returns a summary of its contents
+	// instead of restoring it. Only applies to SnapshotRestore.
+	DryRun bool
+
+	// SkipClusterIDCheck allows restoring a snapshot which was taken from
+	// another cluster. Only applies to SnapshotRestore.
+	SkipClusterIDCheck bool
 }
 
 // SnapshotResponse is used header for a snapshot RPC response. This will
@@ -41,4 +49,23 @@ type SnapshotResponse struct {
 	// QueryMeta has freshness information about the server that handled the
 	// request. It is only filled in for a SnapshotSave.
 	QueryMeta
+
+	// Summary describes the verified snapshot. It is only filled in for a
+	// SnapshotRestore dry run.
+	Summary *SnapshotSummary
+}
+
+// SnapshotSummary describes the contents of a snapshot which was verified
+// before being restored.
+type SnapshotSummary struct {
+	// Index and Term are the Raft index and term of the snapshot.
+	Index uint64
+	Term  uint64
+
+	// ClusterID is the ID of the cluster the snapshot was taken from. It is
+	// empty if the cluster didn't have an ID yet.
+	ClusterID string
+
+	// Records is the number of records of each type in the snapshot.
+	Records map[string]int
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/ipaddr"
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/go-msgpack/codec"
 	multierror "github.com/hashicorp/go-multierror"
@@ -33,28 +34,35 @@ type RaftIndex struct {
 // These are serialized between Consul servers and stored in Consul snapshots,
 // so entries must only ever be added.
 const (
-	RegisterRequestType        MessageType = 0
-	DeregisterRequestType                  = 1
-	KVSRequestType                         = 2
-	SessionRequestType                     = 3
-	ACLRequestType                         = 4 // DEPRECATED (ACL-Legacy-Compat)
-	TombstoneRequestType                   = 5
-	CoordinateBatchUpdateType              = 6
-	PreparedQueryRequestType               = 7
-	TxnRequestType                         = 8
-	AutopilotRequestType                   = 9
-	AreaRequestType                        = 10
-	ACLBootstrapRequestType                = 11
-	IntentionRequestType                   = 12
-	ConnectCARequestType                   = 13
-	ConnectCAProviderStateType             = 14
-	ConnectCAConfigType                    = 15 // FSM snapshots only.
-	IndexRequestType                       = 16 // FSM snapshots only.
-	ACLTokenSetRequestType                 = 17
-	ACLTokenDeleteRequestType              = 18
-	ACLPolicySetRequestType                = 19
-	ACLPolicyDeleteRequestType             = 20
-	ConnectCALeafRequestType               = 21
+	RegisterRequestType              MessageType = 0
+	DeregisterRequestType                        = 1
+	KVSRequestType                               = 2
+	SessionRequestType                           = 3
+	ACLRequestType                               = 4 // DEPRECATED (ACL-Legacy-Compat)
+	TombstoneRequestType                         = 5
+	CoordinateBatchUpdateType                    = 6
+	PreparedQueryRequestType                     = 7
+	TxnRequestType                               = 8
+	AutopilotRequestType                         = 9
+	AreaRequestType                              = 10
+	ACLBootstrapRequestType                      = 11
+	IntentionRequestType                         = 12
+	ConnectCARequestType                         = 13
+	ConnectCAProviderStateType                   = 14
+	ConnectCAConfigType                          = 15 // FSM snapshots only.
+	IndexRequestType                             = 16 // FSM snapshots only.
+	ACLTokenSetRequestType                       = 17
+	ACLTokenDeleteRequestType                    = 18
+	ACLPolicySetRequestType                      = 19
+	ACLPolicyDeleteRequestType                   = 20
+	ConnectCALeafRequestType                     = 21
+	ConfigEntryRequestType                       = 22
+	ServiceVirtualIPRequestType                  = 23 // FSM snapshots only.
+	FreeVirtualIPRequestType                     = 24 // FSM snapshots only.
+	ServiceWeightOverrideRequestType             = 25
+	AuditEntryRequestType                        = 26 // FSM snapshots only.
+	ClusterIdentityRequestType                   = 27
+	BulkRegisterRequestType                      = 28
 )
 
 const (
@@ -106,6 +114,68 @@ type RPCInfo interface {
 	TokenSecret() string
 }
 
+// RequestIDGetter is implemented by requests that carry the ID of the API
+// request they were made for.
+type RequestIDGetter interface {
+	GetRequestID() string
+}
+
+// SpanIDCarrier is implemented by requests that carry the ID of the tracing
+// span they were sent from.
+type SpanIDCarrier interface {
+	GetSpanID() string
+	SetSpanID(id string)
+}
+
+// SourceDatacenterCarrier is implemented by read requests that record the
+// datacenter they were forwarded from.
+type SourceDatacenterCarrier interface {
+	GetSourceDatacenter() string
+	SetSourceDatacenter(dc string)
+}
+
+// ConsistentReadRequirer is implemented by read requests that can ask for a
+// strongly consistent read.
+type ConsistentReadRequirer interface {
+	RequireConsistentRead() bool
+}
+
+// RequestID returns the ID of the API request that the given RPC request
+// was made for, or "" if it doesn't carry one.
+func RequestID(args interface{}) string {
+	if r, ok := args.(RequestIDGetter); ok {
+		return r.GetRequestID()
+	}
+	return ""
+}
+
+// SpanID returns the ID of the tracing span the given RPC request was sent
+// from, or "" if it doesn't carry one.
+func SpanID(args interface{}) string {
+	if r, ok := args.(SpanIDCarrier); ok {
+		return r.GetSpanID()
+	}
+	return ""
+}
+
+// SetSpanID sets the ID of the tracing span the given RPC request is sent
+// from, if it carries one.
+func SetSpanID(args interface{}, id string) {
+	if r, ok := args.(SpanIDCarrier); ok {
+		r.SetSpanID(id)
+	}
+}
+
+// SetSourceDatacenter records the datacenter the given RPC request is
+// forwarded from, replacing any value the caller supplied. An empty dc
+// clears it. The request is only written to if the value changes, so
+// requests shared by concurrent forwards aren't written to concurrently.
+func SetSourceDatacenter(args interface{}, dc string) {
+	if r, ok := args.(SourceDatacenterCarrier); ok && r.GetSourceDatacenter() != dc {
+		r.SetSourceDatacenter(dc)
+	}
+}
+
 // QueryOptions is used to specify various flags for read queries
 type QueryOptions struct {
 	// Token is the ACL token ID. If not provided, the 'anonymous'
@@ -162,7 +232,24 @@ type QueryOptions struct {
 	// UseCache is true and MaxAge is set to a lower, non-zero value. It is
 	// ignored if the endpoint supports background refresh caching. See
 	// https://www.consul.io/api/index.html#agent-caching for more details.
+	//
+	// Uncached catalog and health reads use it to fall back to a stale read
+	// no older than this if the cluster has no leader.
 	StaleIfError time.Duration
+
+	// RequestID identifies the API request this query was made for. It's
+	// included in the logs of the agents that handle the query so the
+	// request can be traced across them.
+	RequestID string
+
+	// SpanID is the ID of the tracing span this query was sent from, under
+	// the trace identified by RequestID.
+	SpanID string
+
+	// SourceDatacenter is the datacenter the query was forwarded from. It's
+	// set by the server forwarding the query and limits the results to the
+	// services exported to that datacenter.
+	SourceDatacenter string
 }
 
 // IsRead is always true for QueryOption.
@@ -185,14 +272,46 @@ func (q QueryOptions) AllowStaleRead() bool {
 	return q.AllowStale
 }
 
+func (q QueryOptions) RequireConsistentRead() bool {
+	return q.RequireConsistent
+}
+
 func (q QueryOptions) TokenSecret() string {
 	return q.Token
 }
 
+func (q QueryOptions) GetRequestID() string {
+	return q.RequestID
+}
+
+func (q QueryOptions) GetSpanID() string {
+	return q.SpanID
+}
+
+func (q *QueryOptions) SetSpanID(id string) {
+	q.SpanID = id
+}
+
+func (q QueryOptions) GetSourceDatacenter() string {
+	return q.SourceDatacenter
+}
+
+func (q *QueryOptions) SetSourceDatacenter(dc string) {
+	q.SourceDatacenter = dc
+}
+
 type WriteRequest struct {
 	// Token is the ACL token ID. If not provided, the 'anonymous'
 	// token is assumed for backwards compatibility.
 	Token string
+
+	// RequestID identifies the API request this write was made for. See
+	// QueryOptions.RequestID.
+	RequestID string
+
+	// SpanID is the ID of the tracing span this write was sent from. See
+	// QueryOptions.SpanID.
+	SpanID string
 }
 
 // WriteRequest only applies to writes, always false
@@ -208,6 +327,18 @@ func (w WriteRequest) TokenSecret() string {
 	return w.Token
 }
 
+func (w WriteRequest) GetRequestID() string {
+	return w.RequestID
+}
+
+func (w WriteRequest) GetSpanID() string {
+	return w.SpanID
+}
+
+func (w *WriteRequest) SetSpanID(id string) {
+	w.SpanID = id
+}
+
 // QueryMeta allows a query response to include potentially
 // useful metadata about a query
 type QueryMeta struct {
@@ -284,6 +415,37 @@ func (r *RegisterRequest) ChangesNode(node *Node) bool {
 	return false
 }
 
+// BulkRegisterRequest is used for the Catalog.BulkRegister endpoint to
+// register many nodes, services and checks at once. The registrations are
+// applied independently, so some of them can fail without affecting the
+// others.
+type BulkRegisterRequest struct {
+	Datacenter    string
+	Registrations []*RegisterRequest
+	WriteRequest
+}
+
+func (r *BulkRegisterRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// BulkRegisterError reports a registration of a bulk request which failed,
+// by its position in the request.
+type BulkRegisterError struct {
+	Index int
+	Node  string
+	Error string
+}
+
+// BulkRegisterResponse is the result of a bulk registration.
+type BulkRegisterResponse struct {
+	// Registered is the number of registrations which were applied.
+	Registered int
+
+	// Errors are the registrations which failed.
+	Errors []BulkRegisterError
+}
+
 // DeregisterRequest is used for the Catalog.Deregister endpoint
 // to deregister a node as providing a service. If no service is
 // provided the entire node is deregistered.
@@ -574,6 +736,7 @@ type ServiceNode struct {
 	ServiceName              string
 	ServiceTags              []string
 	ServiceAddress           string
+	ServiceTaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	ServiceWeights           Weights
 	ServiceMeta              map[string]string
 	ServicePort              int
@@ -595,6 +758,13 @@ func (s *ServiceNode) PartialClone() *ServiceNode {
 	for k, v := range s.ServiceMeta {
 		nsmeta[k] = v
 	}
+	var taggedAddrs map[string]ServiceAddress
+	if len(s.ServiceTaggedAddresses) > 0 {
+		taggedAddrs = make(map[string]ServiceAddress)
+		for k, v := range s.ServiceTaggedAddresses {
+			taggedAddrs[k] = v
+		}
+	}
 
 	return &ServiceNode{
 		// Skip ID, see above.
@@ -606,6 +776,7 @@ func (s *ServiceNode) PartialClone() *ServiceNode {
 		ServiceName:              s.ServiceName,
 		ServiceTags:              tags,
 		ServiceAddress:           s.ServiceAddress,
+		ServiceTaggedAddresses:   taggedAddrs,
 		ServicePort:              s.ServicePort,
 		ServiceMeta:              nsmeta,
 		ServiceWeights:           s.ServiceWeights,
@@ -629,6 +800,7 @@ func (s *ServiceNode) ToNodeService() *NodeService {
 		Service:           s.ServiceName,
 		Tags:              s.ServiceTags,
 		Address:           s.ServiceAddress,
+		TaggedAddresses:   s.ServiceTaggedAddresses,
 		Port:              s.ServicePort,
 		Meta:              s.ServiceMeta,
 		Weights:           &s.ServiceWeights,
@@ -648,6 +820,14 @@ type Weights struct {
 	Warning int
 }
 
+// ServiceAddress is an additional address of a service advertised under a
+// tag, such as the address of the service on another network. A zero port
+// means the port of the service.
+type ServiceAddress struct {
+	Address string
+	Port    int
+}
+
 type ServiceNodes []*ServiceNode
 
 // ServiceKind is the kind of service being registered.
@@ -664,6 +844,18 @@ const (
 	// service proxies another service within Consul and speaks the connect
 	// protocol.
 	ServiceKindConnectProxy ServiceKind = "connect-proxy"
+
+	// ServiceKindTerminatingGateway is a gateway for the Connect feature
+	// that accepts connections from the mesh and forwards them to services
+	// outside of it. The services it represents are configured with a
+	// terminating-gateway config entry of the same name.
+	ServiceKindTerminatingGateway ServiceKind = "terminating-gateway"
+
+	// ServiceKindIngressGateway is a gateway for the Connect feature that
+	// accepts connections from outside of the mesh and forwards them to
+	// Connect services. Its listeners are configured with an ingress-gateway
+	// config entry of the same name.
+	ServiceKindIngressGateway ServiceKind = "ingress-gateway"
 )
 
 // NodeService is a service provided by a node
@@ -677,6 +869,7 @@ type NodeService struct {
 	Service           string
 	Tags              []string
 	Address           string
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	Meta              map[string]string
 	Port              int
 	Weights           *Weights
@@ -764,6 +957,18 @@ type ServiceConnect struct {
 func (s *NodeService) Validate() error {
 	var result error
 
+	// Tagged addresses validation
+	for tag, addr := range s.TaggedAddresses {
+		if addr.Address == "" || ipaddr.IsAny(addr.Address) {
+			result = multierror.Append(result, fmt.Errorf(
+				"Invalid address %q of tagged address %q", addr.Address, tag))
+		}
+		if addr.Port < 0 || addr.Port > 65535 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Invalid port %d of tagged address %q", addr.Port, tag))
+		}
+	}
+
 	// ConnectProxy validation
 	if s.Kind == ServiceKindConnectProxy {
 		// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
@@ -788,6 +993,55 @@ func (s *NodeService) Validate() error {
 			result = multierror.Append(result, fmt.Errorf(
 				"A Proxy cannot also be Connect Native, only typical services"))
 		}
+
+		for _, u := range s.Proxy.Upstreams {
+			if _, err := ParseUpstreamConfig(u.Config); err != nil {
+				result = multierror.Append(result, fmt.Errorf(
+					"Upstream %s has invalid config: %s", u.Identifier(), err))
+			}
+		}
+
+		if tp := s.Proxy.TransparentProxy; tp != nil {
+			if err := tp.Validate(); err != nil {
+				result = multierror.Append(result, fmt.Errorf(
+					"Proxy.TransparentProxy is invalid: %s", err))
+			}
+			if tp.OutboundListenerPort != 0 && tp.OutboundListenerPort == s.Port {
+				result = multierror.Append(result, fmt.Errorf(
+					"Proxy.TransparentProxy.OutboundListenerPort must differ from the proxy Port"))
+			}
+		}
+	}
+
+	// TerminatingGateway validation
+	if s.Kind == ServiceKindTerminatingGateway {
+		if s.Port == 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Port must be set for a terminating gateway"))
+		}
+
+		if s.Connect.Native {
+			result = multierror.Append(result, fmt.Errorf(
+				"A terminating gateway cannot also be Connect Native"))
+		}
+
+		if s.Proxy.DestinationServiceName != "" || len(s.Proxy.Upstreams) > 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"A terminating gateway cannot have a destination service or upstreams"))
+		}
+	}
+
+	// IngressGateway validation
+	if s.Kind == ServiceKindIngressGateway {
+		if s.Connect.Native {
+			result = multierror.Append(result, fmt.Errorf(
+				"An ingress gateway cannot also be Connect Native"))
+		}
+
+		if s.Proxy.DestinationServiceName != "" || len(s.Proxy.Upstreams) > 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"An ingress gateway cannot have a destination service or upstreams"))
+		}
 	}
 
 	// Nested sidecar validation
@@ -821,6 +1075,7 @@ func (s *NodeService) IsSame(other *NodeService) bool {
 		s.Service != other.Service ||
 		!reflect.DeepEqual(s.Tags, other.Tags) ||
 		s.Address != other.Address ||
+		!reflect.DeepEqual(s.TaggedAddresses, other.TaggedAddresses) ||
 		s.Port != other.Port ||
 		!reflect.DeepEqual(s.Weights, other.Weights) ||
 		!reflect.DeepEqual(s.Meta, other.Meta) ||
@@ -854,6 +1109,7 @@ func (s *ServiceNode) IsSameService(other *ServiceNode) bool {
 		s.ServiceName != other.ServiceName ||
 		!reflect.DeepEqual(s.ServiceTags, other.ServiceTags) ||
 		s.ServiceAddress != other.ServiceAddress ||
+		!reflect.DeepEqual(s.ServiceTaggedAddresses, other.ServiceTaggedAddresses) ||
 		s.ServicePort != other.ServicePort ||
 		!reflect.DeepEqual(s.ServiceMeta, other.ServiceMeta) ||
 		!reflect.DeepEqual(s.ServiceWeights, other.ServiceWeights) ||
@@ -893,6 +1149,7 @@ func (s *NodeService) ToServiceNode(node string) *ServiceNode {
 		ServiceName:              s.Service,
 		ServiceTags:              s.Tags,
 		ServiceAddress:           s.Address,
+		ServiceTaggedAddresses:   s.TaggedAddresses,
 		ServicePort:              s.Port,
 		ServiceMeta:              s.Meta,
 		ServiceWeights:           theWeights,
@@ -926,9 +1183,58 @@ type HealthCheck struct {
 
 	Definition HealthCheckDefinition
 
+	// Maintenance holds the metadata of node and service maintenance
+	// mode checks. It is nil for all other checks.
+	Maintenance *CheckMaintenance `json:",omitempty"`
+
 	RaftIndex
 }
 
+// CheckMaintenance is the metadata recorded for a node or service
+// maintenance mode check.
+type CheckMaintenance struct {
+	// Reason is the reason given for enabling maintenance mode.
+	Reason string
+
+	// Actor optionally identifies who enabled maintenance mode.
+	Actor string `json:",omitempty"`
+
+	// Expires is the time at which the agent clears the maintenance
+	// mode automatically. Maintenance mode does not expire if it is nil.
+	Expires *time.Time `json:",omitempty"`
+}
+
+// Clone returns a copy of the maintenance metadata. Calling Clone on a
+// nil value returns an empty CheckMaintenance.
+func (m *CheckMaintenance) Clone() *CheckMaintenance {
+	clone := new(CheckMaintenance)
+	if m == nil {
+		return clone
+	}
+	*clone = *m
+	if m.Expires != nil {
+		expires := *m.Expires
+		clone.Expires = &expires
+	}
+	return clone
+}
+
+// IsSame checks if the maintenance metadata is equal to other. Expiry times
+// are compared by instant since they lose their monotonic clock reading and
+// location when they are encoded.
+func (m *CheckMaintenance) IsSame(other *CheckMaintenance) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+	if m.Reason != other.Reason || m.Actor != other.Actor {
+		return false
+	}
+	if m.Expires == nil || other.Expires == nil {
+		return m.Expires == other.Expires
+	}
+	return m.Expires.Equal(*other.Expires)
+}
+
 type HealthCheckDefinition struct {
 	HTTP                           string              `json:",omitempty"`
 	TLSSkipVerify                  bool                `json:",omitempty"`
@@ -1012,7 +1318,8 @@ func (c *HealthCheck) IsSame(other *HealthCheck) bool {
 		c.ServiceID != other.ServiceID ||
 		c.ServiceName != other.ServiceName ||
 		!reflect.DeepEqual(c.ServiceTags, other.ServiceTags) ||
-		!reflect.DeepEqual(c.Definition, other.Definition) {
+		!reflect.DeepEqual(c.Definition, other.Definition) ||
+		!c.Maintenance.IsSame(other.Maintenance) {
 		return false
 	}
 
@@ -1134,6 +1441,129 @@ type IndexedCheckServiceNodes struct {
 	QueryMeta
 }
 
+// GatewayService is a service bound to a gateway by the config entry of the
+// gateway, along with its instances.
+type GatewayService struct {
+	Gateway     string
+	GatewayKind ServiceKind
+	Service     string
+
+	// Port and Hosts are the listener port of an ingress gateway that the
+	// service is exposed on and the server names routed to it.
+	Port  int      `json:",omitempty"`
+	Hosts []string `json:",omitempty"`
+
+	// TLS is whether the ingress listener terminates TLS, or whether the
+	// terminating gateway originates TLS to the service.
+	TLS bool `json:",omitempty"`
+
+	// Instances are the instances of the service and their checks.
+	Instances CheckServiceNodes
+}
+
+type GatewayServices []*GatewayService
+
+type IndexedGatewayServices struct {
+	Services GatewayServices
+	QueryMeta
+}
+
+// ServiceVirtualIP is the virtual IP assigned to a service that supports
+// Connect. It stays the same for as long as any instance of the service or
+// its proxies is registered.
+type ServiceVirtualIP struct {
+	Service string
+	IP      string
+
+	RaftIndex
+}
+
+// IndexedServiceVirtualIP is the virtual IP of a service, which is nil if
+// the service doesn't have one.
+type IndexedServiceVirtualIP struct {
+	VirtualIP *ServiceVirtualIP
+	QueryMeta
+}
+
+// ServiceWeightOverride replaces the weights of a single service instance
+// without changing its registration, so that operators can drain it from
+// DNS and Connect traffic. A zero passing weight drains the instance
+// entirely. Overrides are removed along with the instance.
+type ServiceWeightOverride struct {
+	Node      string
+	ServiceID string
+	Weights   Weights
+
+	// Service is the name of the service of the instance. It is filled in
+	// when the override is set.
+	Service string
+
+	RaftIndex
+}
+
+type ServiceWeightOverrides []*ServiceWeightOverride
+
+// ServiceWeightOverrideOp is the operation for a request related to service
+// weight overrides.
+type ServiceWeightOverrideOp string
+
+const (
+	ServiceWeightOverrideSet    ServiceWeightOverrideOp = "set"
+	ServiceWeightOverrideDelete ServiceWeightOverrideOp = "delete"
+)
+
+// ServiceWeightOverrideRequest is used to set or delete the weight override
+// of a service instance.
+type ServiceWeightOverrideRequest struct {
+	Datacenter string
+	Op         ServiceWeightOverrideOp
+	Override   ServiceWeightOverride
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *ServiceWeightOverrideRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+type IndexedServiceWeightOverrides struct {
+	Overrides ServiceWeightOverrides
+	QueryMeta
+}
+
+// ValidateWeightOverride checks the weights of an override are valid. Unlike
+// registered weights the passing weight can be zero.
+func ValidateWeightOverride(weights *Weights) error {
+	if weights.Passing < 0 || weights.Warning < 0 {
+		return fmt.Errorf("Weights must be greater or equal than 0")
+	}
+	if weights.Passing > 65535 || weights.Warning > 65535 {
+		return fmt.Errorf("DNS Weight must be between 0 and 65535")
+	}
+	return nil
+}
+
+// Drained returns true if the instance was drained with a weight override of
+// zero. Registered instances always have a positive passing weight.
+func (s *NodeService) Drained() bool {
+	return s.Weights != nil && s.Weights.Passing == 0
+}
+
+// FilterDrained removes the instances drained with a weight override. Note
+// that this returns the filtered results AND modifies the receiver for
+// performance.
+func (nodes CheckServiceNodes) FilterDrained() CheckServiceNodes {
+	n := 0
+	for _, node := range nodes {
+		if node.Service != nil && node.Service.Drained() {
+			continue
+		}
+		nodes[n] = node
+		n++
+	}
+	return nodes[:n]
+}
+
 type IndexedNodeDump struct {
 	Dump NodeDump
 	QueryMeta
@@ -1203,6 +1633,17 @@ func (r *KeyListRequest) RequestDatacenter() string {
 	return r.Datacenter
 }
 
+// KeyPrefixesRequest is used to read several key prefixes at once
+type KeyPrefixesRequest struct {
+	Datacenter string
+	Prefixes   []string
+	QueryOptions
+}
+
+func (r *KeyPrefixesRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
 type IndexedDirEntries struct {
 	Entries DirEntries
 	QueryMeta
@@ -1408,6 +1849,10 @@ type KeyringRequest struct {
 	Datacenter  string
 	Forwarded   bool
 	RelayFactor uint8
+
+	// Detailed asks a list operation to also report the keys installed on
+	// each node and the primary key it is using.
+	Detailed bool
 	QueryOptions
 }
 
@@ -1425,6 +1870,18 @@ type KeyringResponse struct {
 	Keys       map[string]int
 	NumNodes   int
 	Error      string `json:",omitempty"`
+
+	// PrimaryKeys and Nodes are only set by detailed list operations.
+	PrimaryKeys map[string]int       `json:",omitempty"`
+	Nodes       []*KeyringNodeStatus `json:",omitempty"`
+}
+
+// KeyringNodeStatus is the keyring status of a single node of a gossip pool.
+type KeyringNodeStatus struct {
+	Node       string
+	Keys       []string
+	PrimaryKey string
+	Error      string `json:",omitempty"`
 }
 
 // KeyringResponses holds multiple responses to keyring queries. Each
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/api"
@@ -144,7 +145,10 @@ func testServiceNode(t *testing.T) *ServiceNode {
 		ServiceName:    "dogs",
 		ServiceTags:    []string{"prod", "v1"},
 		ServiceAddress: "127.0.0.2",
-		ServicePort:    8080,
+		ServiceTaggedAddresses: map[string]ServiceAddress{
+			"lan": {Address: "10.0.0.2", Port: 8080},
+		},
+		ServicePort: 8080,
 		ServiceMeta: map[string]string{
 			"service": "metadata",
 		},
@@ -228,6 +232,7 @@ func TestStructs_ServiceNode_IsSameService(t *testing.T) {
 	node := "node1"
 	serviceID := sn.ServiceID
 	serviceAddress := sn.ServiceAddress
+	serviceTaggedAddresses := sn.ServiceTaggedAddresses
 	serviceEnableTagOverride := sn.ServiceEnableTagOverride
 	serviceMeta := make(map[string]string)
 	for k, v := range sn.ServiceMeta {
@@ -265,6 +270,7 @@ func TestStructs_ServiceNode_IsSameService(t *testing.T) {
 	check(func() { other.ServiceID = "66fb695a-c782-472f-8d36-4f3edd754b37" }, func() { other.ServiceID = serviceID })
 	check(func() { other.Node = "other" }, func() { other.Node = node })
 	check(func() { other.ServiceAddress = "1.2.3.4" }, func() { other.ServiceAddress = serviceAddress })
+	check(func() { other.ServiceTaggedAddresses = nil }, func() { other.ServiceTaggedAddresses = serviceTaggedAddresses })
 	check(func() { other.ServiceEnableTagOverride = !serviceEnableTagOverride }, func() { other.ServiceEnableTagOverride = serviceEnableTagOverride })
 	check(func() { other.ServiceKind = "newKind" }, func() { other.ServiceKind = "" })
 	check(func() { other.ServiceMeta = map[string]string{"my": "meta"} }, func() { other.ServiceMeta = serviceMeta })
@@ -391,6 +397,48 @@ func TestStructs_NodeService_ValidateConnectProxy(t *testing.T) {
 			func(x *NodeService) { x.Connect.Native = true },
 			"cannot also be",
 		},
+
+		{
+			"connect-proxy: invalid upstream config",
+			func(x *NodeService) { x.Proxy.Upstreams[0].Config["lb_policy"] = "fastest" },
+			"unknown lb_policy",
+		},
+
+		{
+			"connect-proxy: valid transparent proxy",
+			func(x *NodeService) {
+				x.Proxy.TransparentProxy = &TransparentProxyConfig{
+					OutboundListenerPort: 15001,
+					ExcludeInboundPorts:  []int{8080},
+					ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "192.168.1.1"},
+				}
+			},
+			"",
+		},
+
+		{
+			"connect-proxy: transparent proxy invalid port",
+			func(x *NodeService) {
+				x.Proxy.TransparentProxy = &TransparentProxyConfig{ExcludeOutboundPorts: []int{0}}
+			},
+			"not a valid port",
+		},
+
+		{
+			"connect-proxy: transparent proxy invalid CIDR",
+			func(x *NodeService) {
+				x.Proxy.TransparentProxy = &TransparentProxyConfig{ExcludeOutboundCIDRs: []string{"10.0.0.0/99"}}
+			},
+			"not a valid IP or CIDR",
+		},
+
+		{
+			"connect-proxy: transparent proxy outbound port is the proxy port",
+			func(x *NodeService) {
+				x.Proxy.TransparentProxy = &TransparentProxyConfig{OutboundListenerPort: x.Port}
+			},
+			"must differ from the proxy Port",
+		},
 	}
 
 	for _, tc := range cases {
@@ -466,6 +514,37 @@ func TestStructs_NodeService_ValidateSidecarService(t *testing.T) {
 	}
 }
 
+func TestStructs_NodeService_ValidateTaggedAddresses(t *testing.T) {
+	cases := []struct {
+		Name string
+		Addr ServiceAddress
+		Err  string
+	}{
+		{"valid", ServiceAddress{Address: "198.18.0.1", Port: 80}, ""},
+		{"hostname", ServiceAddress{Address: "web.example.com"}, ""},
+		{"empty address", ServiceAddress{Port: 80}, `invalid address "" of tagged address "wan"`},
+		{"any address", ServiceAddress{Address: "0.0.0.0", Port: 80}, `invalid address "0.0.0.0" of tagged address "wan"`},
+		{"port too large", ServiceAddress{Address: "198.18.0.1", Port: 65536}, `invalid port 65536 of tagged address "wan"`},
+		{"negative port", ServiceAddress{Address: "198.18.0.1", Port: -1}, `invalid port -1 of tagged address "wan"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			ns := TestNodeService(t)
+			ns.TaggedAddresses = map[string]ServiceAddress{"wan": tc.Addr}
+
+			err := ns.Validate()
+			assert.Equal(err != nil, tc.Err != "", err)
+			if err == nil {
+				return
+			}
+
+			assert.Contains(strings.ToLower(err.Error()), strings.ToLower(tc.Err))
+		})
+	}
+}
+
 func TestStructs_NodeService_IsSame(t *testing.T) {
 	ns := &NodeService{
 		ID:      "node1",
@@ -476,6 +555,9 @@ func TestStructs_NodeService_IsSame(t *testing.T) {
 			"meta1": "value1",
 			"meta2": "value2",
 		},
+		TaggedAddresses: map[string]ServiceAddress{
+			"wan": {Address: "198.18.0.1", Port: 1234},
+		},
 		Port:              1234,
 		EnableTagOverride: true,
 		Proxy: ConnectProxyConfig{
@@ -491,10 +573,13 @@ func TestStructs_NodeService_IsSame(t *testing.T) {
 	}
 
 	other := &NodeService{
-		ID:                "node1",
-		Service:           "theservice",
-		Tags:              []string{"foo", "bar"},
-		Address:           "127.0.0.1",
+		ID:      "node1",
+		Service: "theservice",
+		Tags:    []string{"foo", "bar"},
+		Address: "127.0.0.1",
+		TaggedAddresses: map[string]ServiceAddress{
+			"wan": {Address: "198.18.0.1", Port: 1234},
+		},
 		Port:              1234,
 		EnableTagOverride: true,
 		Meta: map[string]string{
@@ -540,6 +625,7 @@ func TestStructs_NodeService_IsSame(t *testing.T) {
 	check(func() { other.Tags = nil }, func() { other.Tags = []string{"foo", "bar"} })
 	check(func() { other.Tags = []string{"foo"} }, func() { other.Tags = []string{"foo", "bar"} })
 	check(func() { other.Address = "XXX" }, func() { other.Address = "127.0.0.1" })
+	check(func() { other.TaggedAddresses["wan"] = ServiceAddress{Address: "198.18.0.1"} }, func() { other.TaggedAddresses["wan"] = ServiceAddress{Address: "198.18.0.1", Port: 1234} })
 	check(func() { other.Port = 9999 }, func() { other.Port = 1234 })
 	check(func() { other.Meta["meta2"] = "wrongValue" }, func() { other.Meta["meta2"] = "value2" })
 	check(func() { other.EnableTagOverride = false }, func() { other.EnableTagOverride = true })
@@ -638,6 +724,20 @@ func TestStructs_HealthCheck_IsSame(t *testing.T) {
 	checkStringField(&other.Output)
 	checkStringField(&other.ServiceID)
 	checkStringField(&other.ServiceName)
+
+	// Maintenance metadata is compared by value and expiry instant.
+	expires := time.Now()
+	hc.Maintenance = &CheckMaintenance{Reason: "broken", Actor: "ops", Expires: &expires}
+	if hc.IsSame(other) || other.IsSame(hc) {
+		t.Fatalf("should not be the same")
+	}
+	utc := expires.UTC().Round(0)
+	other.Maintenance = &CheckMaintenance{Reason: "broken", Actor: "ops", Expires: &utc}
+	if !hc.IsSame(other) || !other.IsSame(hc) {
+		t.Fatalf("should be the same")
+	}
+	checkStringField(&other.Maintenance.Reason)
+	checkStringField(&other.Maintenance.Actor)
 }
 
 func TestStructs_HealthCheck_Marshalling(t *testing.T) {
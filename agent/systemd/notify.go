@@ -4,6 +4,8 @@ import (
 	"errors"
 	"net"
 	"os"
+	"strconv"
+	"time"
 )
 
 const (
@@ -13,6 +15,7 @@ const (
 	Ready     = "READY=1"
 	Reloading = "RELOADING=1"
 	Stopping  = "STOPPING=1"
+	Watchdog  = "WATCHDOG=1"
 )
 
 var NotifyNoSocket = errors.New("No socket")
@@ -40,3 +43,20 @@ func (n *Notifier) Notify(state string) error {
 	_, err = conn.Write([]byte(state))
 	return err
 }
+
+// WatchdogInterval returns the interval after which systemd considers the
+// process hung if it didn't receive a Watchdog message. It returns false if
+// the watchdog isn't enabled for this process.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+
+	// The watchdog applies to the main process only. If the PID is not set
+	// it applies to us.
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
@@ -0,0 +1,36 @@
+package systemd
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWatchdogInterval(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		usec, pid string
+		want      time.Duration
+		ok        bool
+	}{
+		{"", "", 0, false},
+		{"foo", "", 0, false},
+		{"0", "", 0, false},
+		{"30000000", "", 30 * time.Second, true},
+		{"30000000", pid, 30 * time.Second, true},
+		{"30000000", "1", 0, false},
+	}
+	for _, tt := range tests {
+		os.Setenv("WATCHDOG_USEC", tt.usec)
+		os.Setenv("WATCHDOG_PID", tt.pid)
+		got, ok := WatchdogInterval()
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("WATCHDOG_USEC=%q WATCHDOG_PID=%q: got %v, %v want %v, %v",
+				tt.usec, tt.pid, got, ok, tt.want, tt.ok)
+		}
+	}
+}
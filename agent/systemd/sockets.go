@@ -0,0 +1,139 @@
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor systemd passes sockets in.
+// See https://www.freedesktop.org/software/systemd/man/sd_listen_fds.html.
+const listenFDsStart = 3
+
+// Sockets holds the sockets passed to the process through systemd socket
+// activation.
+type Sockets struct {
+	// Listeners are the stream sockets, such as TCP and Unix sockets.
+	Listeners []net.Listener
+
+	// PacketConns are the datagram sockets, such as UDP sockets.
+	PacketConns []net.PacketConn
+}
+
+// InheritedSockets returns the sockets systemd passed to the process if it
+// was started through socket activation. The environment variables used to
+// pass them are cleared so they aren't passed on to child processes.
+func InheritedSockets() (*Sockets, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return &Sockets{}, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return &Sockets{}, nil
+	}
+
+	var fds []uintptr
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		fds = append(fds, uintptr(fd))
+	}
+	return socketsFromFDs(fds)
+}
+
+// socketsFromFDs wraps the given file descriptors in listeners or packet
+// connections depending on their type. The file descriptors are closed since
+// the returned sockets use duplicates of them.
+func socketsFromFDs(fds []uintptr) (*Sockets, error) {
+	s := &Sockets{}
+	for _, fd := range fds {
+		f := os.NewFile(fd, "LISTEN_FD_"+strconv.Itoa(int(fd)))
+		if l, err := net.FileListener(f); err == nil {
+			s.Listeners = append(s.Listeners, l)
+		} else if pc, err := net.FilePacketConn(f); err == nil {
+			s.PacketConns = append(s.PacketConns, pc)
+		} else {
+			f.Close()
+			s.Close()
+			return nil, fmt.Errorf("unsupported socket in file descriptor %d: %v", fd, err)
+		}
+		f.Close()
+	}
+	return s, nil
+}
+
+// Listener removes the listener bound to the given address from the
+// inherited sockets and returns it. It returns nil if there is none.
+func (s *Sockets) Listener(addr net.Addr) net.Listener {
+	if s == nil {
+		return nil
+	}
+	for i, l := range s.Listeners {
+		if sameAddr(l.Addr(), addr) {
+			s.Listeners = append(s.Listeners[:i], s.Listeners[i+1:]...)
+			return l
+		}
+	}
+	return nil
+}
+
+// PacketConn removes the packet connection bound to the given address from
+// the inherited sockets and returns it. It returns nil if there is none.
+func (s *Sockets) PacketConn(addr net.Addr) net.PacketConn {
+	if s == nil {
+		return nil
+	}
+	for i, pc := range s.PacketConns {
+		if sameAddr(pc.LocalAddr(), addr) {
+			s.PacketConns = append(s.PacketConns[:i], s.PacketConns[i+1:]...)
+			return pc
+		}
+	}
+	return nil
+}
+
+// Close closes all the remaining sockets and returns their addresses.
+func (s *Sockets) Close() []net.Addr {
+	if s == nil {
+		return nil
+	}
+	var addrs []net.Addr
+	for _, l := range s.Listeners {
+		addrs = append(addrs, l.Addr())
+		l.Close()
+	}
+	for _, pc := range s.PacketConns {
+		addrs = append(addrs, pc.LocalAddr())
+		pc.Close()
+	}
+	s.Listeners, s.PacketConns = nil, nil
+	return addrs
+}
+
+// sameAddr returns whether two socket addresses are the same. Unspecified
+// IPs are considered equal, since a socket systemd binds to all addresses
+// may be IPv4 or IPv6.
+func sameAddr(a, b net.Addr) bool {
+	unspecified := func(ip net.IP) bool {
+		return len(ip) == 0 || ip.IsUnspecified()
+	}
+	sameIP := func(x, y net.IP) bool {
+		return x.Equal(y) || unspecified(x) && unspecified(y)
+	}
+	switch x := a.(type) {
+	case *net.TCPAddr:
+		y, ok := b.(*net.TCPAddr)
+		return ok && x.Port == y.Port && sameIP(x.IP, y.IP)
+	case *net.UDPAddr:
+		y, ok := b.(*net.UDPAddr)
+		return ok && x.Port == y.Port && sameIP(x.IP, y.IP)
+	case *net.UnixAddr:
+		y, ok := b.(*net.UnixAddr)
+		return ok && x.Name == y.Name
+	}
+	return false
+}
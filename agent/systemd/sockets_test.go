@@ -0,0 +1,67 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketsFromFDs(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	lf, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+	pcf, err := pc.(*net.UDPConn).File()
+	require.NoError(t, err)
+
+	s, err := socketsFromFDs([]uintptr{lf.Fd(), pcf.Fd()})
+	require.NoError(t, err)
+	require.Len(t, s.Listeners, 1)
+	require.Len(t, s.PacketConns, 1)
+
+	// Sockets are matched by address and handed out only once.
+	require.Nil(t, s.Listener(pc.LocalAddr()))
+	require.NotNil(t, s.Listener(l.Addr()))
+	require.Nil(t, s.Listener(l.Addr()))
+	require.NotNil(t, s.PacketConn(pc.LocalAddr()))
+
+	require.Empty(t, s.Close())
+}
+
+func TestInheritedSockets_notActivated(t *testing.T) {
+	os.Setenv("LISTEN_PID", "1")
+	os.Setenv("LISTEN_FDS", "2")
+
+	s, err := InheritedSockets()
+	require.NoError(t, err)
+	require.Empty(t, s.Listeners)
+	require.Empty(t, s.PacketConns)
+	require.Empty(t, os.Getenv("LISTEN_FDS"))
+}
+
+func TestSameAddr(t *testing.T) {
+	tcp := func(s string) net.Addr {
+		a, err := net.ResolveTCPAddr("tcp", s)
+		require.NoError(t, err)
+		return a
+	}
+	udp := func(s string) net.Addr {
+		a, err := net.ResolveUDPAddr("udp", s)
+		require.NoError(t, err)
+		return a
+	}
+
+	require.True(t, sameAddr(tcp("127.0.0.1:8500"), tcp("127.0.0.1:8500")))
+	require.True(t, sameAddr(tcp("0.0.0.0:8500"), tcp("[::]:8500")))
+	require.True(t, sameAddr(tcp(":8500"), tcp("0.0.0.0:8500")))
+	require.False(t, sameAddr(tcp("127.0.0.1:8500"), tcp("127.0.0.1:8501")))
+	require.False(t, sameAddr(tcp("127.0.0.1:8600"), udp("127.0.0.1:8600")))
+	require.True(t, sameAddr(&net.UnixAddr{Name: "/tmp/consul.sock", Net: "unix"}, &net.UnixAddr{Name: "/tmp/consul.sock", Net: "unix"}))
+}
@@ -207,7 +207,9 @@ func (a *TestAgent) Start(t *testing.T) *TestAgent {
 				r.Fatal(a.Name, ": Consul index is 0")
 			}
 		} else {
-			req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+			// The agent master token works without servers, which may not
+			// have elected a leader yet.
+			req, _ := http.NewRequest("GET", "/v1/agent/self?token="+a.Config.ACLAgentMasterToken, nil)
 			resp := httptest.NewRecorder()
 			_, err := a.httpServers[0].AgentSelf(resp, req)
 			if err != nil || resp.Code != 200 {
@@ -347,6 +349,9 @@ func TestConfig(sources ...config.Source) *config.RuntimeConfig {
 			performance {
 				raft_multiplier = 1
 			}
+			# Test agents bootstrap their own cluster, so tests that join
+			# them merge different clusters.
+			skip_cluster_id_check = true
 		`,
 	}
 
@@ -0,0 +1,328 @@
+package agent
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/consul"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/consul/tlsutil"
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClusterConfig configures a TestCluster.
+type TestClusterConfig struct {
+	// Servers is the number of server agents. At least one server is
+	// started.
+	Servers int
+
+	// Clients is the number of client agents.
+	Clients int
+
+	// HCL is added to the configuration of every agent.
+	HCL string
+
+	// ACL enables ACLs with the configuration of TestACLConfig so "root"
+	// is the master token.
+	ACL bool
+
+	// TLS makes the agents encrypt and verify their RPC connections with
+	// certificates of a CA generated for the cluster.
+	TLS bool
+
+	// TimeScale scales the Raft and gossip timings of the agents. Zero
+	// means 1, which elects a leader and detects failed agents within a
+	// fraction of a second. Values above 1 make the cluster more tolerant
+	// of slow machines.
+	TimeScale float64
+
+	// LogOutput is the sink for the logs of all agents. If nil, logs are
+	// written to os.Stderr.
+	LogOutput io.Writer
+}
+
+// TestCluster is a cluster of test agents running in the same process, so
+// tests of leader elections, failover and replication between servers don't
+// need to exec Consul binaries. The servers form a single datacenter which
+// the clients join.
+type TestCluster struct {
+	// Name is the name of the cluster. The agents are named after it.
+	Name string
+
+	// Servers and Clients are the agents of the cluster. Stopped agents
+	// remain in the lists.
+	Servers []*TestAgent
+	Clients []*TestAgent
+
+	// certDir holds the generated TLS certificates.
+	certDir string
+
+	// stopped is the set of agents stopped with Stop.
+	stopped map[*TestAgent]bool
+}
+
+// NewTestCluster starts a cluster with the given configuration and waits
+// until it elected a leader and all agents joined. It fails the test if the
+// cluster could not be started. The caller should call Shutdown() to stop
+// the agents.
+func NewTestCluster(t *testing.T, name string, cfg TestClusterConfig) *TestCluster {
+	c := &TestCluster{
+		Name:    name,
+		stopped: make(map[*TestAgent]bool),
+	}
+	if cfg.Servers < 1 {
+		cfg.Servers = 1
+	}
+	if cfg.TimeScale <= 0 {
+		cfg.TimeScale = 1
+	}
+
+	hcl := testClusterTimingHCL(cfg.TimeScale) + cfg.HCL
+	if cfg.ACL {
+		hcl += TestACLConfig()
+	}
+	var serverTLS, clientTLS string
+	if cfg.TLS {
+		var err error
+		c.certDir, serverTLS, clientTLS, err = testClusterTLS(name)
+		if err != nil {
+			c.Shutdown()
+			t.Fatalf("Error generating TLS certificates: %s", err)
+		}
+	}
+
+	start := func(name, hcl string) *TestAgent {
+		a := &TestAgent{Name: name, HCL: hcl, LogOutput: cfg.LogOutput}
+		return a.Start(t)
+	}
+
+	for i := 0; i < cfg.Servers; i++ {
+		bootstrap := `bootstrap = true`
+		if cfg.Servers > 1 {
+			bootstrap = `bootstrap = false
+				bootstrap_expect = ` + strconv.Itoa(cfg.Servers)
+		}
+		a := start(fmt.Sprintf("%s-server-%d", name, i), hcl+serverTLS+`
+			server = true
+			`+bootstrap)
+		c.Servers = append(c.Servers, a)
+		if i > 0 {
+			c.join(t, a)
+		}
+	}
+	for i := 0; i < cfg.Clients; i++ {
+		a := start(fmt.Sprintf("%s-client-%d", name, i), hcl+clientTLS+`
+			server = false
+			bootstrap = false
+		`)
+		c.Clients = append(c.Clients, a)
+		c.join(t, a)
+	}
+
+	c.WaitForLeader(t)
+	c.WaitForMembers(t)
+	return c
+}
+
+// testClusterTimingHCL returns the configuration of the Raft and gossip
+// timings scaled by the given factor.
+func testClusterTimingHCL(scale float64) string {
+	d := func(base time.Duration) string {
+		return strconv.Quote(time.Duration(float64(base) * scale).String())
+	}
+	return `
+		consul = {
+			raft = {
+				election_timeout = ` + d(100*time.Millisecond) + `
+				heartbeat_timeout = ` + d(100*time.Millisecond) + `
+				leader_lease_timeout = ` + d(50*time.Millisecond) + `
+			}
+			server = {
+				health_interval = ` + d(50*time.Millisecond) + `
+			}
+		}
+		gossip_lan = {
+			gossip_interval = ` + d(50*time.Millisecond) + `
+			probe_interval = ` + d(200*time.Millisecond) + `
+			probe_timeout = ` + d(100*time.Millisecond) + `
+			suspicion_mult = 3
+		}
+	`
+}
+
+// testClusterTLS writes a CA along with a certificate for the servers and
+// one for the clients to a temporary directory and returns it with the
+// TLS configuration of the servers and the clients.
+func testClusterTLS(name string) (string, string, string, error) {
+	dir, err := ioutil.TempDir(TempDir, strings.Replace(name, "/", "_", -1)+"-tls")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	signer, _, err := tlsutil.GeneratePrivateKey()
+	if err != nil {
+		return dir, "", "", err
+	}
+	sn, err := tlsutil.GenerateSerialNumber()
+	if err != nil {
+		return dir, "", "", err
+	}
+	ca, err := tlsutil.GenerateCA(signer, sn, 1, nil)
+	if err != nil {
+		return dir, "", "", err
+	}
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(caFile, []byte(ca), 0600); err != nil {
+		return dir, "", "", err
+	}
+
+	tlsHCL := func(name string) (string, error) {
+		sn, err := tlsutil.GenerateSerialNumber()
+		if err != nil {
+			return "", err
+		}
+		cert, key, err := tlsutil.GenerateCert(signer, ca, sn, name, 1,
+			[]string{name, "localhost"}, []net.IP{net.ParseIP("127.0.0.1")},
+			[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
+		if err != nil {
+			return "", err
+		}
+		certFile := filepath.Join(dir, name+".pem")
+		keyFile := filepath.Join(dir, name+"-key.pem")
+		if err := ioutil.WriteFile(certFile, []byte(cert), 0600); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(keyFile, []byte(key), 0600); err != nil {
+			return "", err
+		}
+		return `
+			ca_file = ` + strconv.Quote(caFile) + `
+			cert_file = ` + strconv.Quote(certFile) + `
+			key_file = ` + strconv.Quote(keyFile) + `
+			verify_incoming_rpc = true
+			verify_outgoing = true
+			verify_server_hostname = true
+		`, nil
+	}
+	serverTLS, err := tlsHCL("server.dc1.consul")
+	if err != nil {
+		return dir, "", "", err
+	}
+	clientTLS, err := tlsHCL("client.dc1.consul")
+	if err != nil {
+		return dir, "", "", err
+	}
+	return dir, serverTLS, clientTLS, nil
+}
+
+// join joins the agent to the LAN pool of the first running server.
+func (c *TestCluster) join(t *testing.T, a *TestAgent) {
+	servers := c.RunningServers()
+	require.NotEmpty(t, servers, "no running server to join")
+	addr := fmt.Sprintf("127.0.0.1:%d", servers[0].Config.SerfPortLAN)
+	_, err := a.JoinLAN([]string{addr})
+	require.NoError(t, err, "%s failed to join %s", a.Name, addr)
+}
+
+// Agents returns the running servers followed by the running clients.
+func (c *TestCluster) Agents() []*TestAgent {
+	return append(c.RunningServers(), c.running(c.Clients)...)
+}
+
+// RunningServers returns the servers which weren't stopped.
+func (c *TestCluster) RunningServers() []*TestAgent {
+	return c.running(c.Servers)
+}
+
+func (c *TestCluster) running(agents []*TestAgent) []*TestAgent {
+	var out []*TestAgent
+	for _, a := range agents {
+		if !c.stopped[a] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Leader returns the running server which is the leader or nil if there is
+// none.
+func (c *TestCluster) Leader() *TestAgent {
+	for _, a := range c.RunningServers() {
+		if srv, ok := a.delegate.(*consul.Server); ok && srv.IsLeader() {
+			return a
+		}
+	}
+	return nil
+}
+
+// WaitForLeader waits until one of the running servers is the leader and
+// all running agents know about it, and returns the leader.
+func (c *TestCluster) WaitForLeader(t *testing.T) *TestAgent {
+	var leader *TestAgent
+	retry.Run(t, func(r *retry.R) {
+		leader = c.Leader()
+		if leader == nil {
+			r.Fatal("no leader")
+		}
+		for _, a := range c.Agents() {
+			var addr string
+			if err := a.RPC("Status.Leader", struct{}{}, &addr); err != nil {
+				r.Fatalf("%s: %v", a.Name, err)
+			}
+			if addr == "" {
+				r.Fatalf("%s: no known leader", a.Name)
+			}
+		}
+	})
+	return leader
+}
+
+// WaitForMembers waits until every running agent sees all running agents
+// as alive members of the LAN pool and no others.
+func (c *TestCluster) WaitForMembers(t *testing.T) {
+	agents := c.Agents()
+	retry.Run(t, func(r *retry.R) {
+		for _, a := range agents {
+			alive := 0
+			for _, m := range a.LANMembers() {
+				if m.Status == serf.StatusAlive {
+					alive++
+				}
+			}
+			if alive != len(agents) {
+				r.Fatalf("%s: got %d alive members want %d", a.Name, alive, len(agents))
+			}
+		}
+	})
+}
+
+// Stop shuts down one of the agents of the cluster, for example to test
+// failover from the leader.
+func (c *TestCluster) Stop(a *TestAgent) error {
+	if c.stopped[a] {
+		return nil
+	}
+	c.stopped[a] = true
+	return a.Shutdown()
+}
+
+// Shutdown stops all running agents and removes the generated TLS
+// certificates.
+func (c *TestCluster) Shutdown() {
+	for _, a := range c.Agents() {
+		c.Stop(a)
+	}
+	if c.certDir != "" {
+		os.RemoveAll(c.certDir)
+	}
+}
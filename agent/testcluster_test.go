@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestCluster_Failover(t *testing.T) {
+	t.Parallel()
+	c := NewTestCluster(t, t.Name(), TestClusterConfig{Servers: 3, Clients: 1})
+	defer c.Shutdown()
+
+	require.Len(t, c.Agents(), 4)
+	kv := c.Clients[0].Client().KV()
+	_, err := kv.Put(&api.KVPair{Key: "foo", Value: []byte("bar")}, nil)
+	require.NoError(t, err)
+
+	leader := c.WaitForLeader(t)
+	require.NoError(t, c.Stop(leader))
+	require.Len(t, c.RunningServers(), 2)
+
+	// The remaining servers elect a new leader which serves the data.
+	newLeader := c.WaitForLeader(t)
+	require.True(t, newLeader != leader)
+	retry.Run(t, func(r *retry.R) {
+		pair, _, err := kv.Get("foo", &api.QueryOptions{RequireConsistent: true})
+		if err != nil {
+			r.Fatal(err)
+		}
+		if pair == nil || string(pair.Value) != "bar" {
+			r.Fatalf("bad: %v", pair)
+		}
+	})
+}
+
+func TestTestCluster_TLSACL(t *testing.T) {
+	t.Parallel()
+	c := NewTestCluster(t, t.Name(), TestClusterConfig{
+		Servers: 2,
+		Clients: 1,
+		TLS:     true,
+		ACL:     true,
+	})
+	defer c.Shutdown()
+
+	require.True(t, c.Servers[0].Config.VerifyIncomingRPC)
+	kv := c.Clients[0].Client().KV()
+	_, err := kv.Put(&api.KVPair{Key: "foo", Value: []byte("bar")}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Permission denied")
+
+	_, err = kv.Put(&api.KVPair{Key: "foo", Value: []byte("bar")}, &api.WriteOptions{Token: "root"})
+	require.NoError(t, err)
+}
@@ -49,6 +49,13 @@ type Store struct {
 
 	// replicationTokenSource indicates where this token originated from
 	replicationTokenSource TokenSource
+
+	// dnsToken is used to resolve DNS queries. If it's blank the user token
+	// is used instead.
+	dnsToken string
+
+	// dnsTokenSource indicates where this token originated from
+	dnsTokenSource TokenSource
 }
 
 // UpdateUserToken replaces the current user token in the store.
@@ -83,6 +90,14 @@ func (t *Store) UpdateReplicationToken(token string, source TokenSource) {
 	t.l.Unlock()
 }
 
+// UpdateDNSToken replaces the current DNS token in the store.
+func (t *Store) UpdateDNSToken(token string, source TokenSource) {
+	t.l.Lock()
+	t.dnsToken = token
+	t.dnsTokenSource = source
+	t.l.Unlock()
+}
+
 // UserToken returns the best token to use for user operations.
 func (t *Store) UserToken() string {
 	t.l.RLock()
@@ -117,6 +132,17 @@ func (t *Store) ReplicationToken() string {
 	return t.replicationToken
 }
 
+// DNSToken returns the best token to use for resolving DNS queries.
+func (t *Store) DNSToken() string {
+	t.l.RLock()
+	defer t.l.RUnlock()
+
+	if t.dnsToken != "" {
+		return t.dnsToken
+	}
+	return t.userToken
+}
+
 // UserToken returns the best token to use for user operations.
 func (t *Store) UserTokenAndSource() (string, TokenSource) {
 	t.l.RLock()
@@ -148,6 +174,14 @@ func (t *Store) ReplicationTokenAndSource() (string, TokenSource) {
 	return t.replicationToken, t.replicationTokenSource
 }
 
+// DNSTokenAndSource returns the DNS token and where it originated from.
+func (t *Store) DNSTokenAndSource() (string, TokenSource) {
+	t.l.RLock()
+	defer t.l.RUnlock()
+
+	return t.dnsToken, t.dnsTokenSource
+}
+
 // IsAgentMasterToken checks to see if a given token is the agent master token.
 // This will never match an empty token for safety.
 func (t *Store) IsAgentMasterToken(token string) bool {
@@ -18,6 +18,8 @@ func TestStore_RegularTokens(t *testing.T) {
 		masterSource TokenSource
 		repl         string
 		replSource   TokenSource
+		dns          string
+		dnsSource    TokenSource
 	}
 
 	tests := []struct {
@@ -30,13 +32,13 @@ func TestStore_RegularTokens(t *testing.T) {
 			name:      "set user - config",
 			set:       tokens{user: "U", userSource: TokenSourceConfig},
 			raw:       tokens{user: "U", userSource: TokenSourceConfig},
-			effective: tokens{user: "U", agent: "U"},
+			effective: tokens{user: "U", agent: "U", dns: "U"},
 		},
 		{
 			name:      "set user - api",
 			set:       tokens{user: "U", userSource: TokenSourceAPI},
 			raw:       tokens{user: "U", userSource: TokenSourceAPI},
-			effective: tokens{user: "U", agent: "U"},
+			effective: tokens{user: "U", agent: "U", dns: "U"},
 		},
 		{
 			name:      "set agent - config",
@@ -54,7 +56,7 @@ func TestStore_RegularTokens(t *testing.T) {
 			name:      "set user and agent",
 			set:       tokens{agent: "A", user: "U"},
 			raw:       tokens{agent: "A", user: "U"},
-			effective: tokens{agent: "A", user: "U"},
+			effective: tokens{agent: "A", user: "U", dns: "U"},
 		},
 		{
 			name:      "set repl - config",
@@ -68,6 +70,24 @@ func TestStore_RegularTokens(t *testing.T) {
 			raw:       tokens{repl: "R", replSource: TokenSourceAPI},
 			effective: tokens{repl: "R"},
 		},
+		{
+			name:      "set dns - config",
+			set:       tokens{dns: "D", dnsSource: TokenSourceConfig},
+			raw:       tokens{dns: "D", dnsSource: TokenSourceConfig},
+			effective: tokens{dns: "D"},
+		},
+		{
+			name:      "set dns - api",
+			set:       tokens{dns: "D", dnsSource: TokenSourceAPI},
+			raw:       tokens{dns: "D", dnsSource: TokenSourceAPI},
+			effective: tokens{dns: "D"},
+		},
+		{
+			name:      "set user and dns",
+			set:       tokens{dns: "D", user: "U"},
+			raw:       tokens{dns: "D", user: "U"},
+			effective: tokens{dns: "D", user: "U", agent: "U"},
+		},
 		{
 			name:      "set master - config",
 			set:       tokens{master: "M", masterSource: TokenSourceConfig},
@@ -82,9 +102,9 @@ func TestStore_RegularTokens(t *testing.T) {
 		},
 		{
 			name:      "set all",
-			set:       tokens{user: "U", agent: "A", repl: "R", master: "M"},
-			raw:       tokens{user: "U", agent: "A", repl: "R", master: "M"},
-			effective: tokens{user: "U", agent: "A", repl: "R", master: "M"},
+			set:       tokens{user: "U", agent: "A", repl: "R", master: "M", dns: "D"},
+			raw:       tokens{user: "U", agent: "A", repl: "R", master: "M", dns: "D"},
+			effective: tokens{user: "U", agent: "A", repl: "R", master: "M", dns: "D"},
 		},
 	}
 	for _, tt := range tests {
@@ -96,11 +116,13 @@ func TestStore_RegularTokens(t *testing.T) {
 			s.UpdateAgentToken(tt.set.agent, tt.set.agentSource)
 			s.UpdateReplicationToken(tt.set.repl, tt.set.replSource)
 			s.UpdateAgentMasterToken(tt.set.master, tt.set.masterSource)
+			s.UpdateDNSToken(tt.set.dns, tt.set.dnsSource)
 
 			require.Equal(t, tt.effective.user, s.UserToken())
 			require.Equal(t, tt.effective.agent, s.AgentToken())
 			require.Equal(t, tt.effective.master, s.AgentMasterToken())
 			require.Equal(t, tt.effective.repl, s.ReplicationToken())
+			require.Equal(t, tt.effective.dns, s.DNSToken())
 
 			tok, src := s.UserTokenAndSource()
 			require.Equal(t, tt.raw.user, tok)
@@ -117,6 +139,10 @@ func TestStore_RegularTokens(t *testing.T) {
 			tok, src = s.ReplicationTokenAndSource()
 			require.Equal(t, tt.raw.repl, tok)
 			require.Equal(t, tt.raw.replSource, src)
+
+			tok, src = s.DNSTokenAndSource()
+			require.Equal(t, tt.raw.dns, tok)
+			require.Equal(t, tt.raw.dnsSource, src)
 		})
 	}
 }
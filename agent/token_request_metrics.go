@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+const (
+	// tokenRequestMetricsInterval is how often the request counts of the
+	// tokens are emitted.
+	tokenRequestMetricsInterval = 10 * time.Second
+
+	// tokenRequestMetricsMaxTokens is the number of tokens whose requests
+	// are counted during an interval. It bounds the memory used when a lot
+	// of different tokens make requests.
+	tokenRequestMetricsMaxTokens = 4096
+
+	// tokenRequestMetricsOther is the accessor ID label of the requests of
+	// the tokens which aren't among the busiest ones.
+	tokenRequestMetricsOther = "other"
+)
+
+// tokenRequestMetrics counts the HTTP API requests of each ACL token so the
+// workload responsible for a surge of requests can be identified. To bound
+// the cardinality of the metrics only the requests of the busiest tokens of
+// an interval are labeled with their accessor ID.
+type tokenRequestMetrics struct {
+	// limit is the number of tokens labeled with their accessor ID.
+	limit int
+
+	lock   sync.Mutex
+	counts map[string]int
+
+	// other counts the requests of the tokens which weren't tracked since
+	// counts already held tokenRequestMetricsMaxTokens tokens.
+	other int
+}
+
+func newTokenRequestMetrics(limit int) *tokenRequestMetrics {
+	return &tokenRequestMetrics{
+		limit:  limit,
+		counts: make(map[string]int),
+	}
+}
+
+// record counts a request of the token with the given accessor ID.
+func (m *tokenRequestMetrics) record(accessorID string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.counts[accessorID]; !ok && len(m.counts) >= tokenRequestMetricsMaxTokens {
+		m.other++
+		return
+	}
+	m.counts[accessorID]++
+}
+
+// top returns the request counts of the busiest tokens since the last call
+// and resets the counts. The requests of the other tokens are summed up as
+// tokenRequestMetricsOther.
+func (m *tokenRequestMetrics) top() map[string]int {
+	m.lock.Lock()
+	counts, other := m.counts, m.other
+	m.counts, m.other = make(map[string]int), 0
+	m.lock.Unlock()
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	top := make(map[string]int)
+	for i, id := range ids {
+		if i < m.limit {
+			top[id] = counts[id]
+		} else {
+			other += counts[id]
+		}
+	}
+	if other > 0 {
+		top[tokenRequestMetricsOther] += other
+	}
+	return top
+}
+
+// run emits the request counts every tokenRequestMetricsInterval until the
+// stop channel is closed.
+func (m *tokenRequestMetrics) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(tokenRequestMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			for id, count := range m.top() {
+				metrics.IncrCounterWithLabels([]string{"http", "requests_by_token"}, float32(count),
+					[]metrics.Label{{Name: "accessor_id", Value: id}})
+			}
+		}
+	}
+}
+
+// recordTokenRequest counts a request made with the given token if the
+// request metrics of the tokens are enabled.
+func (a *Agent) recordTokenRequest(token string) {
+	if a.tokenRequestMetrics == nil {
+		return
+	}
+
+	// The agent master token doesn't have an accessor ID and is only known
+	// to this agent.
+	if a.tokens.IsAgentMasterToken(token) {
+		a.tokenRequestMetrics.record("agent-master")
+		return
+	}
+
+	accessorID, err := a.delegate.ResolveTokenToAccessorID(token)
+	switch {
+	case err != nil:
+		// Requests with unknown tokens are counted too since a
+		// misconfigured workload may cause a surge of them.
+		a.tokenRequestMetrics.record("unknown")
+	case accessorID != "":
+		a.tokenRequestMetrics.record(accessorID)
+	}
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRequestMetrics_Top(t *testing.T) {
+	t.Parallel()
+
+	m := newTokenRequestMetrics(2)
+	for id, n := range map[string]int{"a": 5, "b": 3, "c": 3, "d": 1} {
+		for i := 0; i < n; i++ {
+			m.record(id)
+		}
+	}
+
+	// Ties are broken by the accessor ID so the labels are stable.
+	require.Equal(t, map[string]int{"a": 5, "b": 3, "other": 4}, m.top())
+
+	// The counts are reset after every interval.
+	require.Empty(t, m.top())
+}
+
+func TestTokenRequestMetrics_MaxTokens(t *testing.T) {
+	t.Parallel()
+
+	m := newTokenRequestMetrics(1)
+	for i := 0; i < tokenRequestMetricsMaxTokens+10; i++ {
+		m.record(fmt.Sprintf("token-%d", i))
+	}
+	m.record("token-0")
+	require.Len(t, m.counts, tokenRequestMetricsMaxTokens)
+	require.Equal(t, 10, m.other)
+
+	require.Equal(t, map[string]int{
+		"token-0": 2,
+		"other":   tokenRequestMetricsMaxTokens + 9,
+	}, m.top())
+}
+
+func TestTokenRequestMetrics_HTTP(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), TestACLConfig()+`
+		telemetry {
+			token_request_metrics_limit = 5
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("PUT", "/v1/acl/token", jsonReader(&structs.ACLToken{Description: "test"}))
+	req.Header.Set("X-Consul-Token", "root")
+	obj, err := a.srv.ACLTokenCreate(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	token := obj.(*structs.ACLToken)
+
+	// Reset the counts of the requests made while starting the agent.
+	a.tokenRequestMetrics.top()
+
+	get := func(secret string) {
+		req, _ := http.NewRequest("GET", "/v1/catalog/nodes", nil)
+		if secret != "" {
+			req.Header.Set("X-Consul-Token", secret)
+		}
+		a.srv.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	get(token.SecretID)
+	get(token.SecretID)
+	get("")
+	get("towel")
+	get("not-a-token")
+
+	require.Equal(t, map[string]int{
+		token.AccessorID:            2,
+		structs.ACLTokenAnonymousID: 1,
+		"agent-master":              1,
+		"unknown":                   1,
+	}, a.tokenRequestMetrics.top())
+}
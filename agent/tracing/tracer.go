@@ -0,0 +1,295 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/tlsutil"
+)
+
+const (
+	// defaultFlushInterval is how often the queued spans are pushed to the
+	// collector.
+	defaultFlushInterval = 5 * time.Second
+
+	// defaultBatchSize is the number of queued spans that triggers a push
+	// before the flush interval.
+	defaultBatchSize = 512
+
+	// defaultQueueSize is the maximum number of spans waiting to be pushed.
+	// Spans ending while the queue is full are dropped.
+	defaultQueueSize = 4096
+
+	// otlpStatusError is the OTLP status code of failed spans.
+	otlpStatusError = 2
+)
+
+// Config configures a Tracer.
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint of the collector,
+	// such as "https://collector:4318/v1/traces".
+	Endpoint string
+
+	// Headers are the HTTP headers sent with each push.
+	Headers map[string]string
+
+	// CAFile, CertFile and KeyFile configure TLS for an https endpoint. See
+	// tlsutil.Configurator.OutgoingHTTPSConfig.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	// ServiceName and HostName identify the agent in the collector.
+	ServiceName string
+	HostName    string
+
+	// FlushInterval, BatchSize and QueueSize tune the pushes. Defaults are
+	// used for zero values.
+	FlushInterval time.Duration
+	BatchSize     int
+	QueueSize     int
+}
+
+// Tracer queues the spans that ended and pushes them to an OpenTelemetry
+// collector in batches.
+type Tracer struct {
+	endpoint      string
+	headers       map[string]string
+	client        *http.Client
+	resource      otlpResource
+	flushInterval time.Duration
+	batchSize     int
+
+	queue    chan *Span
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewTracer returns a tracer pushing spans to the configured collector. Stop
+// must be called to push the remaining spans and release its resources.
+func NewTracer(c Config) (*Tracer, error) {
+	tlsConfig, err := tlsutil.NewConfigurator(&tlsutil.Config{
+		CAFile:   c.CAFile,
+		CertFile: c.CertFile,
+		KeyFile:  c.KeyFile,
+	}).OutgoingHTTPSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed configuring TLS for tracing: %v", err)
+	}
+
+	t := &Tracer{
+		endpoint:      c.Endpoint,
+		headers:       c.Headers,
+		flushInterval: c.FlushInterval,
+		batchSize:     c.BatchSize,
+		resource: otlpResource{Attributes: []otlpAttribute{
+			stringAttribute("service.name", c.ServiceName),
+			stringAttribute("host.name", c.HostName),
+		}},
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	if t.flushInterval <= 0 {
+		t.flushInterval = defaultFlushInterval
+	}
+	if t.batchSize <= 0 {
+		t.batchSize = defaultBatchSize
+	}
+	queueSize := c.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	t.queue = make(chan *Span, queueSize)
+	t.client = &http.Client{
+		Timeout:   t.flushInterval,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	go t.run()
+	return t, nil
+}
+
+// Stop pushes the queued spans and stops the tracer. Spans ending afterwards
+// are dropped.
+func (t *Tracer) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopCh)
+	})
+	<-t.doneCh
+}
+
+// enqueue queues a span that ended, dropping it if the queue is full or the
+// tracer stopped.
+func (t *Tracer) enqueue(s *Span) {
+	select {
+	case <-t.stopCh:
+		return
+	default:
+	}
+	select {
+	case t.queue <- s:
+	default:
+		metrics.IncrCounter([]string{"tracing", "dropped_spans"}, 1)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.doneCh)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.push(batch); err != nil {
+			metrics.IncrCounter([]string{"tracing", "export_failed"}, 1)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= t.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stopCh:
+			for {
+				select {
+				case s := <-t.queue:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// push sends a batch of spans to the collector.
+func (t *Tracer) push(spans []*Span) error {
+	body, err := json.Marshal(t.encode(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response code from OTLP collector: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encode converts spans into an OTLP export request.
+func (t *Tracer) encode(spans []*Span) *otlpExportRequest {
+	scope := &otlpScopeSpans{Scope: otlpScope{Name: "consul"}}
+	for _, s := range spans {
+		s.mu.Lock()
+		span := &otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              int(s.kind),
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		for _, attr := range s.attrs {
+			span.Attributes = append(span.Attributes, stringAttribute(attr.key, attr.value))
+		}
+		if s.err != nil {
+			span.Status = &otlpStatus{Code: otlpStatusError, Message: s.err.Error()}
+		}
+		s.mu.Unlock()
+		scope.Spans = append(scope.Spans, span)
+	}
+	return &otlpExportRequest{
+		ResourceSpans: []*otlpResourceSpans{{
+			Resource:   t.resource,
+			ScopeSpans: []*otlpScopeSpans{scope},
+		}},
+	}
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// The types below are the subset of the OTLP trace protocol used by the
+// tracer, with the field names of its JSON encoding. IDs are encoded in hex
+// and 64 bit integers as strings.
+
+type otlpExportRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpan_Nil(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("", "", "test", KindInternal)
+	require.Nil(t, span)
+
+	// None of these panic.
+	span.SetAttribute("foo", "bar")
+	span.SetError(errors.New("fail"))
+	span.End(nil)
+	require.Equal(t, "", span.ID())
+	require.Equal(t, "", span.TraceID())
+}
+
+func TestTracer_Start(t *testing.T) {
+	tracer := &Tracer{}
+
+	// A valid trace and parent are continued.
+	span := tracer.Start("0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", "test", KindServer)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.TraceID())
+	require.Equal(t, "b7ad6b7169203331", span.parentID)
+	require.Len(t, span.ID(), 16)
+
+	// An invalid parent makes the span a root.
+	span = tracer.Start("0af7651916cd43dd8448eb211c80319c", "nope", "test", KindServer)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.TraceID())
+	require.Equal(t, "", span.parentID)
+
+	// An invalid trace starts a new one.
+	span = tracer.Start("", "b7ad6b7169203331", "test", KindServer)
+	require.Len(t, span.TraceID(), 32)
+	require.NotEqual(t, "0af7651916cd43dd8448eb211c80319c", span.TraceID())
+	require.Equal(t, "", span.parentID)
+}
+
+func TestTracer_Export(t *testing.T) {
+	requests := make(chan *otlpExportRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req otlpExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- &req
+	}))
+	defer srv.Close()
+
+	tracer, err := NewTracer(Config{
+		Endpoint:      srv.URL + "/v1/traces",
+		Headers:       map[string]string{"Authorization": "Bearer secret"},
+		ServiceName:   "consul",
+		HostName:      "node1",
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	root := tracer.Start("", "", "HTTP GET", KindServer)
+	root.SetAttribute("http.target", "/v1/kv/foo")
+	child := tracer.Start(root.TraceID(), root.ID(), "rpc KVS.Get", KindClient)
+	child.End(errors.New("No cluster leader"))
+	root.End(nil)
+
+	// Stopping the tracer pushes the queued spans.
+	tracer.Stop()
+	var req *otlpExportRequest
+	select {
+	case req = <-requests:
+	default:
+		t.Fatalf("no spans were pushed")
+	}
+
+	require.Len(t, req.ResourceSpans, 1)
+	rs := req.ResourceSpans[0]
+	require.Equal(t, []otlpAttribute{
+		stringAttribute("service.name", "consul"),
+		stringAttribute("host.name", "node1"),
+	}, rs.Resource.Attributes)
+	spans := rs.ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "rpc KVS.Get", spans[0].Name)
+	require.Equal(t, root.TraceID(), spans[0].TraceID)
+	require.Equal(t, root.ID(), spans[0].ParentSpanID)
+	require.Equal(t, int(KindClient), spans[0].Kind)
+	require.Equal(t, &otlpStatus{Code: otlpStatusError, Message: "No cluster leader"}, spans[0].Status)
+
+	require.Equal(t, "HTTP GET", spans[1].Name)
+	require.Equal(t, "", spans[1].ParentSpanID)
+	require.Nil(t, spans[1].Status)
+	require.Equal(t, []otlpAttribute{stringAttribute("http.target", "/v1/kv/foo")}, spans[1].Attributes)
+
+	// Spans ending after the tracer stopped are dropped.
+	tracer.Start("", "", "late", KindInternal).End(nil)
+	require.Len(t, tracer.queue, 0)
+}
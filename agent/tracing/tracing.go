@@ -0,0 +1,171 @@
+// Package tracing records spans for API requests and the RPCs made for them
+// and pushes them to an OpenTelemetry collector using the OTLP/HTTP JSON
+// encoding.
+//
+// The trace ID of a span is the ID of the API request it was made for, which
+// is already passed along with RPC requests, and the ID of the parent span is
+// passed along with it. Spans are only recorded while a tracer is installed
+// with SetGlobal, and all the methods of a nil *Span are no-ops so callers
+// don't need to check whether tracing is enabled.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Kind is the kind of a span. The values are those of the OTLP span kinds.
+type Kind int
+
+const (
+	// KindInternal is a span for an operation within the agent.
+	KindInternal Kind = 1
+
+	// KindServer is a span for handling a request from a remote caller.
+	KindServer Kind = 2
+
+	// KindClient is a span for a request to a remote service.
+	KindClient Kind = 3
+)
+
+var (
+	traceIDRE = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	spanIDRE  = regexp.MustCompile(`^[0-9a-f]{16}$`)
+)
+
+var (
+	globalLock sync.RWMutex
+	global     *Tracer
+)
+
+// SetGlobal installs the tracer used by Start. A nil tracer disables
+// tracing.
+func SetGlobal(t *Tracer) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	global = t
+}
+
+// Global returns the tracer installed with SetGlobal, or nil.
+func Global() *Tracer {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+	return global
+}
+
+// Start starts a span with the global tracer. It returns nil if tracing is
+// disabled.
+func Start(traceID, parentID, name string, kind Kind) *Span {
+	return Global().Start(traceID, parentID, name, kind)
+}
+
+// Span is an operation being traced. It is sent to the collector when it
+// ends.
+type Span struct {
+	tracer *Tracer
+
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	kind     Kind
+	start    time.Time
+	end      time.Time
+
+	mu    sync.Mutex
+	attrs []attribute
+	err   error
+}
+
+type attribute struct {
+	key   string
+	value string
+}
+
+// Start starts a span under the given trace and parent span. If the trace ID
+// isn't valid a new trace is started, and if the parent ID isn't valid the
+// span is the root of its trace.
+func (t *Tracer) Start(traceID, parentID, name string, kind Kind) *Span {
+	if t == nil {
+		return nil
+	}
+	if !traceIDRE.MatchString(traceID) {
+		traceID = randomID(16)
+		parentID = ""
+	}
+	if !spanIDRE.MatchString(parentID) {
+		parentID = ""
+	}
+	return &Span{
+		tracer:   t,
+		traceID:  traceID,
+		spanID:   randomID(8),
+		parentID: parentID,
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+	}
+}
+
+// TraceID returns the ID of the trace of the span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// ID returns the ID of the span, which is passed along with the requests
+// made within it so they are recorded as its children.
+func (s *Span) ID() string {
+	if s == nil {
+		return ""
+	}
+	return s.spanID
+}
+
+// SetAttribute adds an attribute to the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attribute{key, value})
+}
+
+// SetError marks the span as failed with the given error. A nil error is
+// ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End ends the span, marking it as failed if err isn't nil, and queues it to
+// be sent to the collector.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.SetError(err)
+	s.mu.Lock()
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+// randomID returns n random bytes encoded in hex.
+func randomID(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -49,6 +49,32 @@ func decodeValue(rawKV interface{}) error {
 	return nil
 }
 
+// dropIntentionTimestamps removes the timestamps of the intention of the given
+// operation. They are managed by the servers and can't be decoded from their
+// JSON form.
+func dropIntentionTimestamps(rawIntentionOp interface{}) error {
+	rawMap, ok := rawIntentionOp.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected raw intention op type: %T", rawIntentionOp)
+	}
+	for k, v := range rawMap {
+		if strings.ToLower(k) != "intention" {
+			continue
+		}
+		rawIxn, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for field := range rawIxn {
+			switch strings.ToLower(field) {
+			case "createdat", "updatedat":
+				delete(rawIxn, field)
+			}
+		}
+	}
+	return nil
+}
+
 // fixupTxnOp looks for non-nil Txn operations and passes them on for
 // value conversion.
 func fixupTxnOp(rawOp interface{}) error {
@@ -60,9 +86,14 @@ func fixupTxnOp(rawOp interface{}) error {
 		switch strings.ToLower(k) {
 		case "kv":
 			if v == nil {
-				return nil
+				continue
 			}
 			return decodeValue(v)
+		case "intention":
+			if v == nil {
+				continue
+			}
+			return dropIntentionTimestamps(v)
 		}
 	}
 	return nil
@@ -155,6 +186,30 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 			}
 			opsRPC = append(opsRPC, out)
 
+		case in.Intention != nil:
+			writes++
+
+			ixn := in.Intention.Intention
+			out := &structs.TxnOp{
+				Intention: &structs.TxnIntentionOp{
+					Op: structs.IntentionOp(in.Intention.Verb),
+					Intention: &structs.Intention{
+						ID:              ixn.ID,
+						Description:     ixn.Description,
+						SourceNS:        ixn.SourceNS,
+						SourceName:      ixn.SourceName,
+						DestinationNS:   ixn.DestinationNS,
+						DestinationName: ixn.DestinationName,
+						SourceType:      structs.IntentionSourceType(ixn.SourceType),
+						Action:          structs.IntentionAction(ixn.Action),
+						DefaultAddr:     ixn.DefaultAddr,
+						DefaultPort:     ixn.DefaultPort,
+						Meta:            ixn.Meta,
+					},
+				},
+			}
+			opsRPC = append(opsRPC, out)
+
 		case in.Node != nil:
 			if in.Node.Verb != api.NodeGet {
 				writes++
@@ -301,6 +356,8 @@ func (s *HTTPServer) Txn(resp http.ResponseWriter, req *http.Request) (interface
 		args := structs.TxnRequest{Ops: ops}
 		s.parseDC(req, &args.Datacenter)
 		s.parseToken(req, &args.Token)
+		parseRequestID(req, &args.RequestID)
+		parseSpanID(req, &args.SpanID)
 
 		var reply structs.TxnResponse
 		if err := s.agent.RPC("Txn.Apply", &args, &reply); err != nil {
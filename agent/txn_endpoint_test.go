@@ -400,3 +400,49 @@ func TestTxnEndpoint_KV_Actions(t *testing.T) {
 		}
 	})
 }
+
+func TestTxnEndpoint_Intentions(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Timestamps are ignored since the servers manage them.
+	buf := bytes.NewBuffer([]byte(`
+[
+	{
+		"Intention": {
+			"Verb": "create",
+			"Intention": {
+				"SourceName": "web",
+				"DestinationName": "db",
+				"Action": "allow",
+				"CreatedAt": "0001-01-01T00:00:00Z"
+			}
+		}
+	}
+]
+`))
+	req, _ := http.NewRequest("PUT", "/v1/txn", buf)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.Txn(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("expected 200, got %d: %v", resp.Code, obj)
+	}
+
+	var out structs.IndexedIntentions
+	args := structs.DCSpecificRequest{Datacenter: "dc1"}
+	if err := a.RPC("Intention.List", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Intentions) != 1 {
+		t.Fatalf("bad: %v", out.Intentions)
+	}
+	ixn := out.Intentions[0]
+	if ixn.SourceName != "web" || ixn.DestinationName != "db" || ixn.Action != structs.IntentionActionAllow {
+		t.Fatalf("bad: %v", ixn)
+	}
+}
@@ -3,9 +3,13 @@ package agent
 import (
 	"fmt"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 )
@@ -137,6 +141,47 @@ RPC:
 	return summarizeServices(out.Dump), nil
 }
 
+// UIGatewayServicesNodes is used to list the services bound to an ingress or
+// terminating gateway in a given datacenter, along with their instances.
+func (s *HTTPServer) UIGatewayServicesNodes(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Parse arguments
+	args := structs.ServiceSpecificRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	args.ServiceName = strings.TrimPrefix(req.URL.Path, "/v1/internal/ui/gateway-services-nodes/")
+	if args.ServiceName == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing gateway name")
+		return nil, nil
+	}
+
+	// Make the RPC request
+	var out structs.IndexedGatewayServices
+	defer setMeta(resp, &out.QueryMeta)
+RPC:
+	if err := s.agent.RPC("Internal.GatewayServiceDump", &args, &out); err != nil {
+		// Retry the request allowing stale data if no leader
+		if strings.Contains(err.Error(), structs.ErrNoLeader.Error()) && !args.AllowStale {
+			args.AllowStale = true
+			goto RPC
+		}
+		return nil, err
+	}
+
+	// Use empty list instead of nil
+	for _, svc := range out.Services {
+		if svc.Instances == nil {
+			svc.Instances = make(structs.CheckServiceNodes, 0)
+		}
+	}
+	if out.Services == nil {
+		out.Services = make(structs.GatewayServices, 0)
+	}
+	return out.Services, nil
+}
+
 func summarizeServices(dump structs.NodeDump) []*ServiceSummary {
 	// Collect the summary information
 	var services []string
@@ -208,3 +253,282 @@ func summarizeServices(dump structs.NodeDump) []*ServiceSummary {
 	}
 	return output
 }
+
+// ServiceTopology is the set of services that a service is connected to
+// through Connect.
+type ServiceTopology struct {
+	// Upstreams are the services that the service connects to.
+	Upstreams []*ServiceTopologySummary
+
+	// Downstreams are the services that connect to the service.
+	Downstreams []*ServiceTopologySummary
+}
+
+// ServiceTopologySummary describes a service connected to the service of a
+// ServiceTopology.
+type ServiceTopologySummary struct {
+	Name       string
+	Datacenter string
+
+	// Registered is true if the connection is declared as an upstream by a
+	// registered proxy. Otherwise it was only found in an intention.
+	Registered bool
+
+	// Allowed is whether the intentions allow the connection.
+	Allowed bool
+}
+
+// UIServiceTopology is used to get the upstreams and downstreams of a service
+// in a given datacenter. They are gathered from the upstreams of the
+// registered proxies and from the intentions naming the service.
+func (s *HTTPServer) UIServiceTopology(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Parse arguments
+	args := structs.DCSpecificRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	service := strings.TrimPrefix(req.URL.Path, "/v1/internal/ui/service-topology/")
+	if service == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing service name")
+		return nil, nil
+	}
+
+	// Make the RPC request
+	var out structs.IndexedNodeDump
+	defer setMeta(resp, &out.QueryMeta)
+RPC:
+	if err := s.agent.RPC("Internal.NodeDump", &args, &out); err != nil {
+		// Retry the request allowing stale data if no leader
+		if strings.Contains(err.Error(), structs.ErrNoLeader.Error()) && !args.AllowStale {
+			args.AllowStale = true
+			goto RPC
+		}
+		return nil, err
+	}
+	topology := newTopologyBuilder(service, args.Datacenter)
+	topology.addProxies(out.Dump)
+
+	// The remaining queries must not block, only the dump above does.
+	opts := args.QueryOptions
+	opts.MinQueryIndex = 0
+	opts.MaxQueryTime = 0
+
+	// Add the services named by intentions. Tokens that can't read the
+	// intentions of the service only see the registered connections.
+	for _, matchType := range []structs.IntentionMatchType{structs.IntentionMatchSource, structs.IntentionMatchDestination} {
+		matchArgs := structs.IntentionQueryRequest{
+			Datacenter: args.Datacenter,
+			Match: &structs.IntentionQueryMatch{
+				Type: matchType,
+				Entries: []structs.IntentionMatchEntry{
+					{Namespace: structs.IntentionDefaultNamespace, Name: service},
+				},
+			},
+			QueryOptions: opts,
+		}
+		var matches structs.IndexedIntentionMatches
+		if err := s.agent.RPC("Intention.Match", &matchArgs, &matches); err != nil {
+			if acl.IsErrPermissionDenied(err) {
+				continue
+			}
+			return nil, err
+		}
+		if len(matches.Matches) == 1 {
+			topology.addIntentions(matchType, matches.Matches[0])
+		}
+	}
+
+	// Check whether each connection is allowed.
+	result := topology.result()
+	check := func(source, destination string) (bool, error) {
+		checkArgs := structs.IntentionQueryRequest{
+			Datacenter: args.Datacenter,
+			Check: &structs.IntentionQueryCheck{
+				SourceNS:        structs.IntentionDefaultNamespace,
+				SourceName:      source,
+				DestinationNS:   structs.IntentionDefaultNamespace,
+				DestinationName: destination,
+				SourceType:      structs.IntentionSourceConsul,
+			},
+			QueryOptions: opts,
+		}
+		var reply structs.IntentionQueryCheckResponse
+		if err := s.agent.RPC("Intention.Check", &checkArgs, &reply); err != nil {
+			return false, err
+		}
+		return reply.Allowed, nil
+	}
+	for _, up := range result.Upstreams {
+		allowed, err := check(service, up.Name)
+		if err != nil {
+			return nil, err
+		}
+		up.Allowed = allowed
+	}
+	for _, down := range result.Downstreams {
+		allowed, err := check(down.Name, service)
+		if err != nil {
+			return nil, err
+		}
+		down.Allowed = allowed
+	}
+	return result, nil
+}
+
+// topologyBuilder collects the upstreams and downstreams of a service,
+// keeping a single entry for each datacenter and service name.
+type topologyBuilder struct {
+	service    string
+	datacenter string
+
+	upstreams   map[string]*ServiceTopologySummary
+	downstreams map[string]*ServiceTopologySummary
+}
+
+func newTopologyBuilder(service, datacenter string) *topologyBuilder {
+	return &topologyBuilder{
+		service:     service,
+		datacenter:  datacenter,
+		upstreams:   make(map[string]*ServiceTopologySummary),
+		downstreams: make(map[string]*ServiceTopologySummary),
+	}
+}
+
+func (b *topologyBuilder) add(m map[string]*ServiceTopologySummary, name, dc string, registered bool) {
+	if dc == "" {
+		dc = b.datacenter
+	}
+	key := dc + "/" + name
+	sum, ok := m[key]
+	if !ok {
+		sum = &ServiceTopologySummary{Name: name, Datacenter: dc}
+		m[key] = sum
+	}
+	sum.Registered = sum.Registered || registered
+}
+
+// addProxies adds the upstreams declared by the proxies of the service, and
+// the services whose proxies declare the service as an upstream.
+func (b *topologyBuilder) addProxies(dump structs.NodeDump) {
+	for _, node := range dump {
+		for _, svc := range node.Services {
+			if svc.Kind != structs.ServiceKindConnectProxy {
+				continue
+			}
+			for _, up := range svc.Proxy.Upstreams {
+				if up.DestinationType != "" && up.DestinationType != structs.UpstreamDestTypeService {
+					continue
+				}
+				if svc.Proxy.DestinationServiceName == b.service {
+					b.add(b.upstreams, up.DestinationName, up.Datacenter, true)
+				}
+				if up.DestinationName == b.service && (up.Datacenter == "" || up.Datacenter == b.datacenter) {
+					b.add(b.downstreams, svc.Proxy.DestinationServiceName, "", true)
+				}
+			}
+		}
+	}
+}
+
+// addIntentions adds the services named by intentions matching the service
+// as their source or destination. Wildcard intentions don't name a service
+// and are skipped.
+func (b *topologyBuilder) addIntentions(matchType structs.IntentionMatchType, ixns structs.Intentions) {
+	for _, ixn := range ixns {
+		switch {
+		case matchType == structs.IntentionMatchSource && ixn.SourceName == b.service &&
+			ixn.DestinationName != structs.IntentionWildcard:
+			b.add(b.upstreams, ixn.DestinationName, "", false)
+		case matchType == structs.IntentionMatchDestination && ixn.DestinationName == b.service &&
+			ixn.SourceName != structs.IntentionWildcard:
+			b.add(b.downstreams, ixn.SourceName, "", false)
+		}
+	}
+}
+
+// result returns the collected services sorted by datacenter and name.
+func (b *topologyBuilder) result() *ServiceTopology {
+	sorted := func(m map[string]*ServiceTopologySummary) []*ServiceTopologySummary {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		out := make([]*ServiceTopologySummary, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, m[key])
+		}
+		return out
+	}
+	return &ServiceTopology{
+		Upstreams:   sorted(b.upstreams),
+		Downstreams: sorted(b.downstreams),
+	}
+}
+
+// UIMetricsProxy forwards requests to the metrics provider configured in
+// ui_config.metrics_proxy so that the UI can query it without direct access.
+// Only the allowlisted paths of the provider can be requested, and since
+// the metrics describe the whole cluster the token must be able to read all
+// nodes and services.
+func (s *HTTPServer) UIMetricsProxy(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	cfg := s.agent.config
+	if cfg.UIMetricsProxyBaseURL == "" {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(resp, "Metrics proxy is not enabled")
+		return nil, nil
+	}
+
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil && (!rule.NodeRead("") || !rule.ServiceRead("")) {
+		return nil, acl.ErrPermissionDenied
+	}
+
+	subPath := path.Clean("/" + strings.TrimPrefix(req.URL.Path, "/v1/internal/ui/metrics-proxy"))
+	allowed := false
+	for _, p := range cfg.UIMetricsProxyPathAllowlist {
+		if subPath == p {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		resp.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(resp, "Path %q is not allowed by the metrics proxy", subPath)
+		return nil, nil
+	}
+
+	target, err := url.Parse(cfg.UIMetricsProxyBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	target.Path = strings.TrimSuffix(target.Path, "/") + subPath
+
+	// Don't pass the token along to the provider.
+	query := req.URL.Query()
+	query.Del("token")
+	target.RawQuery = query.Encode()
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL = target
+			r.Host = target.Host
+			r.Header.Del("X-Consul-Token")
+			r.Header.Del("Authorization")
+			r.Header.Del("Cookie")
+			for name, value := range cfg.UIMetricsProxyAddHeaders {
+				r.Header.Set(name, value)
+			}
+		},
+		ErrorLog: s.agent.logger,
+	}
+	proxy.ServeHTTP(resp, req)
+	return nil, nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUiIndex(t *testing.T) {
@@ -262,3 +263,152 @@ func TestSummarizeServices(t *testing.T) {
 		t.Fatalf("bad: %v", summary[2])
 	}
 }
+
+func TestUIGatewayServicesNodes(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "ext",
+		Address:    "10.0.0.1",
+		Service:    &structs.NodeService{ID: "db", Service: "db", Port: 5432},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	entry := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.TerminatingGatewayConfigEntry{
+			Name:     "terminating",
+			Services: []structs.LinkedService{{Name: "db"}, {Name: "cache"}},
+		},
+	}
+	require.NoError(t, a.RPC("ConfigEntry.Apply", &entry, &out))
+
+	req, _ := http.NewRequest("GET", "/v1/internal/ui/gateway-services-nodes/terminating", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.UIGatewayServicesNodes(resp, req)
+	require.NoError(t, err)
+	assertIndex(t, resp)
+
+	// Services without instances have an empty list.
+	services := obj.(structs.GatewayServices)
+	require.Len(t, services, 2)
+	require.Equal(t, "db", services[0].Service)
+	require.Equal(t, structs.ServiceKindTerminatingGateway, services[0].GatewayKind)
+	require.Len(t, services[0].Instances, 1)
+	require.Equal(t, "ext", services[0].Instances[0].Node.Node)
+	require.Equal(t, "cache", services[1].Service)
+	require.NotNil(t, services[1].Instances)
+	require.Len(t, services[1].Instances, 0)
+
+	// Unknown gateways have no services.
+	req, _ = http.NewRequest("GET", "/v1/internal/ui/gateway-services-nodes/nope", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.UIGatewayServicesNodes(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, structs.GatewayServices{}, obj)
+}
+
+func TestUIServiceTopology(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// web's proxy declares db and cache in dc2 as upstreams, and api's
+	// proxy declares web.
+	proxies := map[string]structs.Upstreams{
+		"web": {
+			{DestinationType: structs.UpstreamDestTypeService, DestinationName: "db", LocalBindPort: 1234},
+			{DestinationType: structs.UpstreamDestTypeService, DestinationName: "cache", Datacenter: "dc2", LocalBindPort: 1235},
+			{DestinationType: structs.UpstreamDestTypePreparedQuery, DestinationName: "geo", LocalBindPort: 1236},
+		},
+		"api": {
+			{DestinationType: structs.UpstreamDestTypeService, DestinationName: "web", LocalBindPort: 1234},
+		},
+	}
+	for name, upstreams := range proxies {
+		args := structs.TestRegisterRequestProxy(t)
+		args.Service.Service = name + "-proxy"
+		args.Service.ID = name + "-proxy"
+		args.Service.Proxy.DestinationServiceName = name
+		args.Service.Proxy.Upstreams = upstreams
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	// Intentions deny web to db and allow admin to web.
+	for _, v := range [][]string{{"web", "db", "deny"}, {"admin", "web", "allow"}, {"*", "web", "deny"}} {
+		ixn := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpCreate,
+			Intention:  structs.TestIntention(t),
+		}
+		ixn.Intention.SourceNS = structs.IntentionDefaultNamespace
+		ixn.Intention.SourceName = v[0]
+		ixn.Intention.DestinationNS = structs.IntentionDefaultNamespace
+		ixn.Intention.DestinationName = v[1]
+		ixn.Intention.Action = structs.IntentionAction(v[2])
+		var reply string
+		require.NoError(t, a.RPC("Intention.Apply", &ixn, &reply))
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/internal/ui/service-topology/web", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.UIServiceTopology(resp, req)
+	require.NoError(t, err)
+	assertIndex(t, resp)
+
+	require.Equal(t, &ServiceTopology{
+		Upstreams: []*ServiceTopologySummary{
+			{Name: "db", Datacenter: "dc1", Registered: true, Allowed: false},
+			{Name: "cache", Datacenter: "dc2", Registered: true, Allowed: true},
+		},
+		Downstreams: []*ServiceTopologySummary{
+			{Name: "admin", Datacenter: "dc1", Registered: false, Allowed: true},
+			{Name: "api", Datacenter: "dc1", Registered: true, Allowed: false},
+		},
+	}, obj)
+}
+
+func TestUIMetricsProxy(t *testing.T) {
+	t.Parallel()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s %s", r.URL.Path, r.URL.RawQuery,
+			r.Header.Get("Authorization"), r.Header.Get("X-Consul-Token"))
+	}))
+	defer backend.Close()
+
+	a := NewTestAgent(t, t.Name(), `
+		ui_config {
+			metrics_proxy {
+				base_url = "`+backend.URL+`/prom"
+				add_headers = { Authorization = "Bearer secret" }
+			}
+		}
+	`)
+	defer a.Shutdown()
+
+	t.Run("allowed path", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/internal/ui/metrics-proxy/api/v1/query?query=up&token=root", nil)
+		req.Header.Set("X-Consul-Token", "root")
+		resp := httptest.NewRecorder()
+		_, err := a.srv.UIMetricsProxy(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "/prom/api/v1/query query=up Bearer secret ", resp.Body.String())
+	})
+
+	t.Run("path not allowed", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/internal/ui/metrics-proxy/api/v1/../v1/admin/tsdb/delete_series", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.UIMetricsProxy(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
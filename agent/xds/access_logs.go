@@ -0,0 +1,69 @@
+package xds
+
+import (
+	"math"
+
+	envoyaccesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// fileAccessLogName is the name of Envoy's access logger writing to a
+	// file, which is also used for the standard streams.
+	fileAccessLogName = "envoy.file_access_log"
+
+	// accessLogSamplingRuntimeKey is the runtime key which can override the
+	// configured sampling percentage.
+	accessLogSamplingRuntimeKey = "consul.access_logs.sampling"
+)
+
+// makeAccessLogs returns the access logs of the tcp_proxy and HTTP connection
+// manager filters for the given config, which is nil if access logs are
+// disabled.
+func makeAccessLogs(cfg *structs.AccessLogsConfig) ([]*envoyaccesslog.AccessLog, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	path := cfg.Path
+	switch cfg.Sink {
+	case "", structs.AccessLogSinkStdout:
+		path = "/dev/stdout"
+	case structs.AccessLogSinkStderr:
+		path = "/dev/stderr"
+	}
+	fileCfg := map[string]interface{}{
+		"path": path,
+	}
+	if cfg.Format != "" {
+		fileCfg["format"] = cfg.Format
+	}
+	config, err := makeStruct(fileCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &envoyaccesslog.AccessLog{
+		Name:   fileAccessLogName,
+		Config: config,
+	}
+	if cfg.SamplingPercent > 0 && cfg.SamplingPercent < 100 {
+		// The sampling is independent of the x-request-id header since TCP
+		// connections don't have one.
+		log.Filter = &envoyaccesslog.AccessLogFilter{
+			FilterSpecifier: &envoyaccesslog.AccessLogFilter_RuntimeFilter{
+				RuntimeFilter: &envoyaccesslog.RuntimeFilter{
+					RuntimeKey: accessLogSamplingRuntimeKey,
+					PercentSampled: &envoytype.FractionalPercent{
+						Numerator:   uint32(math.Round(cfg.SamplingPercent * 100)),
+						Denominator: envoytype.FractionalPercent_TEN_THOUSAND,
+					},
+					UseIndependentRandomness: true,
+				},
+			},
+		}
+	}
+	return []*envoyaccesslog.AccessLog{log}, nil
+}
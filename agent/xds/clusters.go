@@ -8,21 +8,31 @@ import (
 
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoyauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoycluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
 // clustersFromSnapshot returns the xDS API representation of the "clusters"
 // (upstreams) in the snapshot.
-func clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
+func clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, cInfo connectionInfo) ([]proto.Message, error) {
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+
+	switch cfgSnap.Kind {
+	case structs.ServiceKindTerminatingGateway:
+		return clustersFromSnapshotTerminatingGateway(cfgSnap)
+	case structs.ServiceKindIngressGateway:
+		return clustersFromSnapshotIngressGateway(cfgSnap)
+	}
+
 	// Include the "app" cluster for the public listener
 	clusters := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1)
 
@@ -101,9 +111,14 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 	}
 
 	if c == nil {
+		cfg, err := structs.ParseUpstreamConfig(upstream.Config)
+		if err != nil {
+			return nil, err
+		}
 		c = &envoy.Cluster{
 			Name:           upstream.Identifier(),
-			ConnectTimeout: 5 * time.Second,
+			ConnectTimeout: cfg.ConnectTimeout(5 * time.Second),
+			LbPolicy:       makeLbPolicy(cfg.LBPolicy),
 			Type:           envoy.Cluster_EDS,
 			EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
 				EdsConfig: &envoycore.ConfigSource{
@@ -113,6 +128,14 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 				},
 			},
 		}
+		if phc := cfg.PassiveHealthCheck; phc != nil {
+			// Failed connections count as 5xx errors for TCP upstreams.
+			c.OutlierDetection = &envoycluster.OutlierDetection{
+				Consecutive_5Xx:  &types.UInt32Value{Value: uint32(phc.MaxFailures)},
+				Interval:         types.DurationProto(phc.Interval),
+				BaseEjectionTime: types.DurationProto(phc.Interval),
+			}
+		}
 	}
 
 	// Enable TLS upstream with the configured client certificate.
@@ -120,9 +143,143 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 		CommonTlsContext: makeCommonTLSContext(cfgSnap),
 	}
 
+	// Terminating gateways need the SNI to know which service is dialed.
+	if upstream.DestinationType != structs.UpstreamDestTypePreparedQuery {
+		dc := upstream.Datacenter
+		if dc == "" {
+			dc = cfgSnap.Datacenter
+		}
+		c.TlsContext.Sni = connect.ServiceSNI(upstream.DestinationName,
+			upstream.DestinationNamespace, dc, cfgSnap.Roots.TrustDomain)
+	}
+
 	return c, nil
 }
 
+// clustersFromSnapshotTerminatingGateway returns a cluster for each service
+// linked to a terminating gateway. Connections are forwarded to the instances
+// of the service in the catalog and use TLS if the config entry asks for it.
+func clustersFromSnapshotTerminatingGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	services := cfgSnap.TerminatingGateway.Config.Services
+	clusters := make([]proto.Message, 0, len(services))
+	for _, svc := range services {
+		c := &envoy.Cluster{
+			Name:           gatewayClusterName(cfgSnap, svc.Name),
+			ConnectTimeout: 5 * time.Second,
+			Type:           envoy.Cluster_EDS,
+			EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
+				EdsConfig: &envoycore.ConfigSource{
+					ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+						Ads: &envoycore.AggregatedConfigSource{},
+					},
+				},
+			},
+		}
+		if svc.TLS() {
+			c.TlsContext = makeLinkedServiceTLSContext(svc)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// clustersFromSnapshotIngressGateway returns a cluster for each service
+// exposed by an ingress gateway. The gateway dials the Connect proxies of the
+// service with its own leaf certificate. The JWKS servers of the services
+// requiring a JWT get a cluster too.
+func clustersFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	var clusters []proto.Message
+	for _, name := range cfgSnap.IngressGateway.Config.ServiceNames() {
+		sni := gatewayClusterName(cfgSnap, name)
+		clusters = append(clusters, &envoy.Cluster{
+			Name:           sni,
+			ConnectTimeout: 5 * time.Second,
+			Type:           envoy.Cluster_EDS,
+			EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
+				EdsConfig: &envoycore.ConfigSource{
+					ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+						Ads: &envoycore.AggregatedConfigSource{},
+					},
+				},
+			},
+			TlsContext: &envoyauth.UpstreamTlsContext{
+				CommonTlsContext: makeCommonTLSContext(cfgSnap),
+				Sni:              sni,
+			},
+		})
+	}
+
+	// Add a cluster for each JWKS server the gateway fetches keys from.
+	seen := make(map[string]bool)
+	for _, l := range cfgSnap.IngressGateway.Config.Listeners {
+		for _, svc := range l.Services {
+			if svc.JWT == nil || seen[jwksClusterName(svc.JWT)] {
+				continue
+			}
+			seen[jwksClusterName(svc.JWT)] = true
+			c, err := makeJWKSCluster(svc.JWT)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %v", svc.Name, err)
+			}
+			clusters = append(clusters, c)
+		}
+	}
+	return clusters, nil
+}
+
+// makeLinkedServiceTLSContext returns the TLS context for originating TLS to
+// a service linked to a terminating gateway. The files are read by Envoy on
+// the gateway's host.
+func makeLinkedServiceTLSContext(svc structs.LinkedService) *envoyauth.UpstreamTlsContext {
+	tlsContext := &envoyauth.UpstreamTlsContext{
+		Sni: svc.SNI,
+		CommonTlsContext: &envoyauth.CommonTlsContext{
+			TlsParams: &envoyauth.TlsParameters{},
+		},
+	}
+	if svc.CAFile != "" {
+		tlsContext.CommonTlsContext.ValidationContextType = &envoyauth.CommonTlsContext_ValidationContext{
+			ValidationContext: &envoyauth.CertificateValidationContext{
+				TrustedCa: &envoycore.DataSource{
+					Specifier: &envoycore.DataSource_Filename{
+						Filename: svc.CAFile,
+					},
+				},
+			},
+		}
+	}
+	if svc.CertFile != "" {
+		tlsContext.CommonTlsContext.TlsCertificates = []*envoyauth.TlsCertificate{
+			&envoyauth.TlsCertificate{
+				CertificateChain: &envoycore.DataSource{
+					Specifier: &envoycore.DataSource_Filename{
+						Filename: svc.CertFile,
+					},
+				},
+				PrivateKey: &envoycore.DataSource{
+					Specifier: &envoycore.DataSource_Filename{
+						Filename: svc.KeyFile,
+					},
+				},
+			},
+		}
+	}
+	return tlsContext
+}
+
+// makeLbPolicy returns the Envoy load balancing policy for the lb_policy of an
+// upstream. Envoy defaults to round robin.
+func makeLbPolicy(policy string) envoy.Cluster_LbPolicy {
+	switch policy {
+	case structs.UpstreamLBPolicyRandom:
+		return envoy.Cluster_RANDOM
+	case structs.UpstreamLBPolicyLeastRequest:
+		return envoy.Cluster_LEAST_REQUEST
+	default:
+		return envoy.Cluster_ROUND_ROBIN
+	}
+}
+
 // makeClusterFromUserConfig returns the listener config decoded from an
 // arbitrary proto3 json format string or an error if it's invalid.
 //
@@ -13,10 +13,36 @@ import (
 
 // endpointsFromSnapshot returns the xDS API representation of the "endpoints"
 // (upstream instances) in the snapshot.
-func endpointsFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
+func endpointsFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, cInfo connectionInfo) ([]proto.Message, error) {
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+
+	if cfgSnap.Kind == structs.ServiceKindTerminatingGateway {
+		var resources []proto.Message
+		for _, svc := range cfgSnap.TerminatingGateway.Config.Services {
+			endpoints := cfgSnap.TerminatingGateway.ServiceNodes[svc.Name]
+			if len(endpoints) < 1 {
+				continue
+			}
+			clusterName := gatewayClusterName(cfgSnap, svc.Name)
+			resources = append(resources, makeLoadAssignment(clusterName, endpoints))
+		}
+		return resources, nil
+	}
+
+	if cfgSnap.Kind == structs.ServiceKindIngressGateway {
+		var resources []proto.Message
+		for _, name := range cfgSnap.IngressGateway.Config.ServiceNames() {
+			endpoints := cfgSnap.IngressGateway.ServiceNodes[name]
+			if len(endpoints) < 1 {
+				continue
+			}
+			resources = append(resources, makeLoadAssignment(gatewayClusterName(cfgSnap, name), endpoints))
+		}
+		return resources, nil
+	}
+
 	resources := make([]proto.Message, 0, len(cfgSnap.UpstreamEndpoints))
 	for id, endpoints := range cfgSnap.UpstreamEndpoints {
 		if len(endpoints) < 1 {
@@ -39,6 +65,10 @@ func makeEndpoint(clusterName, host string, port int) envoyendpoint.LbEndpoint {
 func makeLoadAssignment(clusterName string, endpoints structs.CheckServiceNodes) *envoy.ClusterLoadAssignment {
 	es := make([]envoyendpoint.LbEndpoint, 0, len(endpoints))
 	for _, ep := range endpoints {
+		// Instances drained with a weight override receive no traffic.
+		if ep.Service.Drained() {
+			continue
+		}
 		addr := ep.Service.Address
 		if addr == "" {
 			addr = ep.Node.Address
@@ -0,0 +1,132 @@
+package xds
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+)
+
+// envoyVersion is the version of a connecting Envoy.
+type envoyVersion struct {
+	Major, Minor, Patch int
+}
+
+func (v envoyVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// atLeast returns true if v is the same as or newer than o.
+func (v envoyVersion) atLeast(o envoyVersion) bool {
+	if v.Major != o.Major {
+		return v.Major > o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor > o.Minor
+	}
+	return v.Patch >= o.Patch
+}
+
+var (
+	// minEnvoyVersion is the oldest Envoy the generated config works with.
+	// Older versions lack the ext_authz network filter.
+	minEnvoyVersion = envoyVersion{1, 7, 0}
+
+	// ingressJWTEnvoyVersion is the first Envoy which can validate JWTs on
+	// ingress gateways, which needs the JWT payload in the dynamic metadata
+	// and the RBAC HTTP filter.
+	ingressJWTEnvoyVersion = envoyVersion{1, 8, 0}
+)
+
+// envoyVersionMetadataKey is the key of the node metadata which overrides the
+// version Envoy reports in its build version, for custom builds which report
+// it in another format.
+const envoyVersionMetadataKey = "envoy_version"
+
+var (
+	// buildVersionRE matches the build version Envoy reports in its node,
+	// such as "<commit sha>/1.8.0/Clean/RELEASE/BoringSSL".
+	buildVersionRE = regexp.MustCompile(`^[a-f0-9]{40}/([0-9]+)\.([0-9]+)\.([0-9]+)(?:-[a-zA-Z0-9.]+)?/`)
+
+	// versionRE matches a version given in the node metadata.
+	versionRE = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)(?:\.([0-9]+))?$`)
+)
+
+// envoyVersionFromNode returns the version of the Envoy with the given node,
+// and false if it can't be determined.
+func envoyVersionFromNode(node *envoycore.Node) (envoyVersion, bool, error) {
+	if node == nil {
+		return envoyVersion{}, false, nil
+	}
+	if md := node.GetMetadata(); md != nil {
+		if raw, ok := md.Fields[envoyVersionMetadataKey]; ok {
+			s := raw.GetStringValue()
+			m := versionRE.FindStringSubmatch(s)
+			if m == nil {
+				return envoyVersion{}, false, fmt.Errorf("invalid %s %q in node metadata", envoyVersionMetadataKey, s)
+			}
+			return parseVersion(m[1:]), true, nil
+		}
+	}
+	if m := buildVersionRE.FindStringSubmatch(node.GetBuildVersion()); m != nil {
+		return parseVersion(m[1:]), true, nil
+	}
+	return envoyVersion{}, false, nil
+}
+
+// parseVersion returns the version with the major, minor and patch numbers
+// matched by one of the regular expressions.
+func parseVersion(parts []string) envoyVersion {
+	var nums [3]int
+	for i, p := range parts {
+		// The patch number is optional and the others are known to be
+		// numeric.
+		nums[i], _ = strconv.Atoi(p)
+	}
+	return envoyVersion{nums[0], nums[1], nums[2]}
+}
+
+// proxyFeatures are the optional features of the generated config which
+// depend on the version of the connecting Envoy.
+type proxyFeatures struct {
+	// IngressJWT is the validation of JWTs for services exposed by ingress
+	// gateways.
+	IngressJWT bool
+}
+
+// allProxyFeatures is used for proxies whose version is unknown, which are
+// assumed to be recent enough.
+var allProxyFeatures = proxyFeatures{
+	IngressJWT: true,
+}
+
+// determineProxyFeatures returns the features supported by the Envoy with the
+// given node. It fails for versions which can't work with the generated
+// config at all.
+func determineProxyFeatures(node *envoycore.Node) (proxyFeatures, error) {
+	version, ok, err := envoyVersionFromNode(node)
+	if err != nil {
+		return proxyFeatures{}, err
+	}
+	if !ok {
+		return allProxyFeatures, nil
+	}
+	if !version.atLeast(minEnvoyVersion) {
+		return proxyFeatures{}, fmt.Errorf("Envoy %s is not supported, the minimum supported version is %s",
+			version, minEnvoyVersion)
+	}
+	return proxyFeatures{
+		IngressJWT: version.atLeast(ingressJWTEnvoyVersion),
+	}, nil
+}
+
+// connectionInfo holds the details of an xDS stream which are needed to
+// generate the config for the proxy.
+type connectionInfo struct {
+	// Token is the ACL token the proxy authenticated with.
+	Token string
+
+	// ProxyFeatures are the features supported by the proxy.
+	ProxyFeatures proxyFeatures
+}
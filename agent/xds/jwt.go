@@ -0,0 +1,249 @@
+package xds
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoyauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoylistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoyroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoyaccesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	envoyhttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// jwtAuthnFilterName is the name of Envoy's JWT authentication filter.
+	// The payload of validated tokens is stored in its dynamic metadata.
+	jwtAuthnFilterName = "envoy.filters.http.jwt_authn"
+
+	// jwtPayloadMetadataKey is the key of the token payload in the dynamic
+	// metadata of the JWT authentication filter.
+	jwtPayloadMetadataKey = "jwt_payload"
+
+	// jwtProviderName is the name of the single JWT provider of a filter
+	// chain.
+	jwtProviderName = "consul"
+)
+
+// makeIngressJWTFilter returns an HTTP connection manager filter that
+// validates the JWTs of the requests to a service exposed by an ingress
+// gateway and routes the valid ones to the service's cluster. Claim
+// requirements are enforced with an RBAC filter over the token payload.
+func makeIngressJWTFilter(name, cluster string, jwt *structs.IngressJWT, accessLogs []*envoyaccesslog.AccessLog, tracing *envoyhttp.HttpConnectionManager_Tracing) (envoylistener.Filter, error) {
+	authn, err := makeStruct(makeJWTAuthnConfig(jwt))
+	if err != nil {
+		return envoylistener.Filter{}, err
+	}
+	filters := []*envoyhttp.HttpFilter{
+		{Name: jwtAuthnFilterName, Config: authn},
+	}
+	if len(jwt.Claims) > 0 {
+		rbac, err := makeStruct(makeJWTClaimsRBACConfig(jwt.Claims))
+		if err != nil {
+			return envoylistener.Filter{}, err
+		}
+		filters = append(filters, &envoyhttp.HttpFilter{Name: "envoy.filters.http.rbac", Config: rbac})
+	}
+	filters = append(filters, &envoyhttp.HttpFilter{Name: "envoy.router"})
+
+	cfg := &envoyhttp.HttpConnectionManager{
+		StatPrefix: name,
+		RouteSpecifier: &envoyhttp.HttpConnectionManager_RouteConfig{
+			RouteConfig: &envoy.RouteConfiguration{
+				Name: name,
+				VirtualHosts: []envoyroute.VirtualHost{
+					{
+						Name:    name,
+						Domains: []string{"*"},
+						Routes: []envoyroute.Route{
+							{
+								Match: envoyroute.RouteMatch{
+									PathSpecifier: &envoyroute.RouteMatch_Prefix{Prefix: "/"},
+								},
+								Action: &envoyroute.Route_Route{
+									Route: &envoyroute.RouteAction{
+										ClusterSpecifier: &envoyroute.RouteAction_Cluster{Cluster: cluster},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		HttpFilters: filters,
+		AccessLog:   accessLogs,
+		Tracing:     tracing,
+	}
+	return makeFilter("envoy.http_connection_manager", cfg)
+}
+
+// makeJWTAuthnConfig returns the config of the JWT authentication filter
+// requiring a valid token for every request.
+func makeJWTAuthnConfig(jwt *structs.IngressJWT) map[string]interface{} {
+	provider := map[string]interface{}{
+		"issuer": jwt.Issuer,
+		"remote_jwks": map[string]interface{}{
+			"http_uri": map[string]interface{}{
+				"uri":     jwt.JWKSURL,
+				"cluster": jwksClusterName(jwt),
+				"timeout": "5s",
+			},
+		},
+		"forward":             true,
+		"payload_in_metadata": jwtPayloadMetadataKey,
+	}
+	if len(jwt.Audiences) > 0 {
+		provider["audiences"] = jwt.Audiences
+	}
+	return map[string]interface{}{
+		"providers": map[string]interface{}{
+			jwtProviderName: provider,
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"match":    map[string]interface{}{"prefix": "/"},
+				"requires": map[string]interface{}{"provider_name": jwtProviderName},
+			},
+		},
+	}
+}
+
+// makeJWTClaimsRBACConfig returns the config of an RBAC filter that allows
+// the requests whose token payload has all the claims with one of their
+// values.
+func makeJWTClaimsRBACConfig(claims []structs.JWTClaim) map[string]interface{} {
+	var ids []interface{}
+	for _, c := range claims {
+		path := []interface{}{map[string]interface{}{"key": jwtPayloadMetadataKey}}
+		for _, key := range strings.Split(c.Name, ".") {
+			path = append(path, map[string]interface{}{"key": key})
+		}
+
+		var values []interface{}
+		for _, v := range c.Values {
+			values = append(values, map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"filter": jwtAuthnFilterName,
+					"path":   path,
+					"value": map[string]interface{}{
+						"string_match": map[string]interface{}{"exact": v},
+					},
+				},
+			})
+		}
+		ids = append(ids, map[string]interface{}{
+			"or_ids": map[string]interface{}{"ids": values},
+		})
+	}
+
+	return map[string]interface{}{
+		"rules": map[string]interface{}{
+			"action": "ALLOW",
+			"policies": map[string]interface{}{
+				"jwt_claims": map[string]interface{}{
+					"permissions": []interface{}{map[string]interface{}{"any": true}},
+					"principals": []interface{}{
+						map[string]interface{}{
+							"and_ids": map[string]interface{}{"ids": ids},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// jwksClusterName returns the name of the cluster used to fetch the keys
+// from the JWKS URL of a JWT config. HTTPS clusters are named after the CA
+// as well, so that JWKS servers verified with different CAs don't share a
+// cluster.
+func jwksClusterName(jwt *structs.IngressJWT) string {
+	host, port := jwksHostPort(jwt)
+	if strings.HasPrefix(jwt.JWKSURL, "https://") {
+		return fmt.Sprintf("jwks_%s_%d_%08x", host, port, crc32.ChecksumIEEE([]byte(jwt.JWKSCAFile)))
+	}
+	return fmt.Sprintf("jwks_%s_%d", host, port)
+}
+
+// jwksHostPort returns the host and the port of the JWKS URL of a JWT
+// config. The URL has been validated with the config entry.
+func jwksHostPort(jwt *structs.IngressJWT) (string, int) {
+	u, err := url.Parse(jwt.JWKSURL)
+	if err != nil {
+		return "", 0
+	}
+	port := 80
+	if u.Scheme == "https" {
+		port = 443
+	}
+	if p, err := strconv.Atoi(u.Port()); err == nil {
+		port = p
+	}
+	return u.Hostname(), port
+}
+
+// makeJWKSCluster returns the cluster used to fetch the keys from the JWKS
+// URL of a JWT config. The host is resolved with DNS and HTTPS servers are
+// verified with the configured CA, which is required.
+func makeJWKSCluster(jwt *structs.IngressJWT) (*envoy.Cluster, error) {
+	host, port := jwksHostPort(jwt)
+	c := &envoy.Cluster{
+		Name:           jwksClusterName(jwt),
+		ConnectTimeout: 5 * time.Second,
+		Type:           envoy.Cluster_LOGICAL_DNS,
+		Hosts:          []*envoycore.Address{makeAddressPtr(host, port)},
+	}
+	if net.ParseIP(host) != nil {
+		c.Type = envoy.Cluster_STATIC
+	}
+	if strings.HasPrefix(jwt.JWKSURL, "https://") {
+		// Envoy doesn't verify upstream servers without a validation
+		// context, so never fetch keys without one.
+		if jwt.JWKSCAFile == "" {
+			return nil, fmt.Errorf("JWKSURL %q requires a JWKSCAFile", jwt.JWKSURL)
+		}
+		c.TlsContext = &envoyauth.UpstreamTlsContext{
+			Sni: host,
+			CommonTlsContext: &envoyauth.CommonTlsContext{
+				TlsParams: &envoyauth.TlsParameters{},
+				ValidationContextType: &envoyauth.CommonTlsContext_ValidationContext{
+					ValidationContext: &envoyauth.CertificateValidationContext{
+						TrustedCa: &envoycore.DataSource{
+							Specifier: &envoycore.DataSource_Filename{
+								Filename: jwt.JWKSCAFile,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	return c, nil
+}
+
+// makeStruct converts the config of a filter without generated types into
+// the struct Envoy accepts as filter config.
+func makeStruct(cfg map[string]interface{}) (*types.Struct, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var s types.Struct
+	if err := jsonpb.UnmarshalString(string(raw), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoyauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoylistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoyaccesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	extauthz "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/ext_authz/v2"
 	envoytcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
@@ -16,28 +18,40 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
 // listenersFromSnapshot returns the xDS API representation of the "listeners"
 // in the snapshot.
-func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
+func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, cInfo connectionInfo) ([]proto.Message, error) {
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
 
+	switch cfgSnap.Kind {
+	case structs.ServiceKindTerminatingGateway:
+		return listenersFromSnapshotTerminatingGateway(cfgSnap, cInfo.Token)
+	case structs.ServiceKindIngressGateway:
+		return listenersFromSnapshotIngressGateway(cfgSnap, cInfo.ProxyFeatures)
+	}
+
+	accessLogs, err := makeAccessLogs(cfgSnap.AccessLogs())
+	if err != nil {
+		return nil, err
+	}
+
 	// One listener for each upstream plus the public one
 	resources := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1)
 
 	// Configure public listener
-	var err error
-	resources[0], err = makePublicListener(cfgSnap, token)
+	resources[0], err = makePublicListener(cfgSnap, cInfo.Token, accessLogs)
 	if err != nil {
 		return nil, err
 	}
 	for i, u := range cfgSnap.Proxy.Upstreams {
-		resources[i+1], err = makeUpstreamListener(&u)
+		resources[i+1], err = makeUpstreamListener(&u, accessLogs)
 		if err != nil {
 			return nil, err
 		}
@@ -45,6 +59,156 @@ func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 	return resources, nil
 }
 
+// listenersFromSnapshotTerminatingGateway returns the single listener of a
+// terminating gateway. It has a filter chain for each linked service which is
+// selected by the SNI the client sends and presents the leaf certificate of
+// that service so that intentions are enforced for it.
+func listenersFromSnapshotTerminatingGateway(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+	l := makeListener(TerminatingGatewayListenerName, addr, cfgSnap.Port)
+	l.ListenerFilters = []envoylistener.ListenerFilter{
+		{Name: "envoy.listener.tls_inspector"},
+	}
+
+	authFilter, err := makeExtAuthFilter(token)
+	if err != nil {
+		return nil, err
+	}
+	accessLogs, err := makeAccessLogs(cfgSnap.AccessLogs())
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range cfgSnap.TerminatingGateway.Config.Services {
+		leaf, ok := cfgSnap.TerminatingGateway.ServiceLeaves[svc.Name]
+		if !ok {
+			// Wait for the certificate of the service.
+			continue
+		}
+
+		clusterName := gatewayClusterName(cfgSnap, svc.Name)
+		tcpProxy, err := makeTCPProxyFilter(TerminatingGatewayListenerName+"_"+svc.Name, clusterName, 0, accessLogs)
+		if err != nil {
+			return nil, err
+		}
+		l.FilterChains = append(l.FilterChains, envoylistener.FilterChain{
+			FilterChainMatch: &envoylistener.FilterChainMatch{
+				ServerNames: []string{clusterName},
+			},
+			Filters: []envoylistener.Filter{
+				authFilter,
+				tcpProxy,
+			},
+			TlsContext: &envoyauth.DownstreamTlsContext{
+				CommonTlsContext:         makeCommonTLSContextFromLeaf(cfgSnap, leaf),
+				RequireClientCertificate: &types.BoolValue{Value: true},
+			},
+		})
+	}
+
+	// Envoy rejects listeners without filter chains.
+	if len(l.FilterChains) == 0 {
+		return nil, nil
+	}
+	return []proto.Message{l}, nil
+}
+
+// listenersFromSnapshotIngressGateway returns a listener for each listener of
+// an ingress gateway's config entry. Listeners with TLS enabled present the
+// agent's certificate and select the service by the SNI the client sends.
+// Services requiring a JWT get an HTTP filter chain validating it.
+func listenersFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot, features proxyFeatures) ([]proto.Message, error) {
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+
+	accessLogs, err := makeAccessLogs(cfgSnap.AccessLogs())
+	if err != nil {
+		return nil, err
+	}
+	tracing := makeTracing(cfgSnap.Tracing())
+
+	var resources []proto.Message
+	for _, listener := range cfgSnap.IngressGateway.Config.Listeners {
+		l := makeListener(IngressGatewayListenerName, addr, listener.Port)
+
+		var tlsContext *envoyauth.DownstreamTlsContext
+		if listener.TLS {
+			certFile := cfgSnap.IngressGateway.TLSCertFile
+			keyFile := cfgSnap.IngressGateway.TLSKeyFile
+			if certFile == "" || keyFile == "" {
+				return nil, fmt.Errorf("listener on port %d has TLS enabled but the agent has no certificate", listener.Port)
+			}
+			l.ListenerFilters = []envoylistener.ListenerFilter{
+				{Name: "envoy.listener.tls_inspector"},
+			}
+			tlsContext = &envoyauth.DownstreamTlsContext{
+				CommonTlsContext: &envoyauth.CommonTlsContext{
+					TlsParams: &envoyauth.TlsParameters{},
+					TlsCertificates: []*envoyauth.TlsCertificate{
+						&envoyauth.TlsCertificate{
+							CertificateChain: &envoycore.DataSource{
+								Specifier: &envoycore.DataSource_Filename{
+									Filename: certFile,
+								},
+							},
+							PrivateKey: &envoycore.DataSource{
+								Specifier: &envoycore.DataSource_Filename{
+									Filename: keyFile,
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+
+		for _, svc := range listener.Services {
+			filterName := fmt.Sprintf("%s_%d_%s", IngressGatewayListenerName, listener.Port, svc.Name)
+			cluster := gatewayClusterName(cfgSnap, svc.Name)
+
+			// Services requiring a JWT are proxied as HTTP so the tokens of
+			// the requests can be validated.
+			var filter envoylistener.Filter
+			var err error
+			if svc.JWT != nil {
+				if !features.IngressJWT {
+					return nil, fmt.Errorf("service %q requires JWT validation which needs Envoy %s or newer",
+						svc.Name, ingressJWTEnvoyVersion)
+				}
+				filter, err = makeIngressJWTFilter(filterName, cluster, svc.JWT, accessLogs, tracing)
+			} else {
+				filter, err = makeTCPProxyFilter(filterName, cluster, 0, accessLogs)
+			}
+			if err != nil {
+				return nil, err
+			}
+			chain := envoylistener.FilterChain{
+				Filters:    []envoylistener.Filter{filter},
+				TlsContext: tlsContext,
+			}
+			if len(svc.Hosts) > 0 {
+				chain.FilterChainMatch = &envoylistener.FilterChainMatch{
+					ServerNames: svc.Hosts,
+				}
+			}
+			l.FilterChains = append(l.FilterChains, chain)
+		}
+		resources = append(resources, l)
+	}
+	return resources, nil
+}
+
+// gatewayClusterName returns the name of the cluster for a service linked to
+// a terminating gateway or exposed by an ingress gateway. It is the SNI used
+// to reach the service in the mesh.
+func gatewayClusterName(cfgSnap *proxycfg.ConfigSnapshot, service string) string {
+	return connect.ServiceSNI(service, "", cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain)
+}
+
 // makeListener returns a listener with name and bind details set. Filters must
 // be added before it's useful.
 //
@@ -137,7 +301,7 @@ func injectConnectFilters(cfgSnap *proxycfg.ConfigSnapshot, token string, listen
 	return nil
 }
 
-func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.Message, error) {
+func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string, accessLogs []*envoyaccesslog.AccessLog) (proto.Message, error) {
 	var l *envoy.Listener
 	var err error
 
@@ -157,7 +321,7 @@ func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.M
 			addr = "0.0.0.0"
 		}
 		l = makeListener(PublicListenerName, addr, cfgSnap.Port)
-		tcpProxy, err := makeTCPProxyFilter("public_listener", LocalAppClusterName)
+		tcpProxy, err := makeTCPProxyFilter("public_listener", LocalAppClusterName, 0, accessLogs)
 		if err != nil {
 			return l, err
 		}
@@ -176,7 +340,7 @@ func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.M
 	return l, err
 }
 
-func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
+func makeUpstreamListener(u *structs.Upstream, accessLogs []*envoyaccesslog.AccessLog) (proto.Message, error) {
 	if listenerJSONRaw, ok := u.Config["envoy_listener_json"]; ok {
 		if listenerJSON, ok := listenerJSONRaw.(string); ok {
 			return makeListenerFromUserConfig(listenerJSON)
@@ -186,8 +350,12 @@ func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
 	if addr == "" {
 		addr = "127.0.0.1"
 	}
+	cfg, err := structs.ParseUpstreamConfig(u.Config)
+	if err != nil {
+		return nil, err
+	}
 	l := makeListener(u.Identifier(), addr, u.LocalBindPort)
-	tcpProxy, err := makeTCPProxyFilter(u.Identifier(), u.Identifier())
+	tcpProxy, err := makeTCPProxyFilter(u.Identifier(), u.Identifier(), cfg.RequestTimeout(), accessLogs)
 	if err != nil {
 		return l, err
 	}
@@ -201,10 +369,14 @@ func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
 	return l, nil
 }
 
-func makeTCPProxyFilter(name, cluster string) (envoylistener.Filter, error) {
+func makeTCPProxyFilter(name, cluster string, idleTimeout time.Duration, accessLogs []*envoyaccesslog.AccessLog) (envoylistener.Filter, error) {
 	cfg := &envoytcp.TcpProxy{
 		StatPrefix: name,
 		Cluster:    cluster,
+		AccessLog:  accessLogs,
+	}
+	if idleTimeout > 0 {
+		cfg.IdleTimeout = &idleTimeout
 	}
 	return makeFilter("envoy.tcp_proxy", cfg)
 }
@@ -249,6 +421,10 @@ func makeFilter(name string, cfg proto.Message) (envoylistener.Filter, error) {
 }
 
 func makeCommonTLSContext(cfgSnap *proxycfg.ConfigSnapshot) *envoyauth.CommonTlsContext {
+	return makeCommonTLSContextFromLeaf(cfgSnap, cfgSnap.Leaf)
+}
+
+func makeCommonTLSContextFromLeaf(cfgSnap *proxycfg.ConfigSnapshot, leaf *structs.IssuedCert) *envoyauth.CommonTlsContext {
 	// Concatenate all the root PEMs into one.
 	// TODO(banks): verify this actually works with Envoy (docs are not clear).
 	rootPEMS := ""
@@ -262,12 +438,12 @@ func makeCommonTLSContext(cfgSnap *proxycfg.ConfigSnapshot) *envoyauth.CommonTls
 			&envoyauth.TlsCertificate{
 				CertificateChain: &envoycore.DataSource{
 					Specifier: &envoycore.DataSource_InlineString{
-						InlineString: cfgSnap.Leaf.CertPEM,
+						InlineString: leaf.CertPEM,
 					},
 				},
 				PrivateKey: &envoycore.DataSource{
 					Specifier: &envoycore.DataSource_InlineString{
-						InlineString: cfgSnap.Leaf.PrivateKeyPEM,
+						InlineString: leaf.PrivateKeyPEM,
 					},
 				},
 			},
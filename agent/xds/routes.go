@@ -10,7 +10,7 @@ import (
 
 // routesFromSnapshot returns the xDS API representation of the "routes"
 // in the snapshot.
-func routesFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
+func routesFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, cInfo connectionInfo) ([]proto.Message, error) {
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
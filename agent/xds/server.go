@@ -2,6 +2,7 @@ package xds
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
@@ -50,6 +51,14 @@ const (
 	// PublicListenerName is the name we give the public listener in Envoy config.
 	PublicListenerName = "public_listener"
 
+	// TerminatingGatewayListenerName is the name we give the listener of a
+	// terminating gateway in Envoy config.
+	TerminatingGatewayListenerName = "terminating_gateway"
+
+	// IngressGatewayListenerName is the name we give the listeners of an
+	// ingress gateway in Envoy config.
+	IngressGatewayListenerName = "ingress_gateway"
+
 	// LocalAppClusterName is the name we give the local application "cluster" in
 	// Envoy config.
 	LocalAppClusterName = "local_app"
@@ -58,6 +67,10 @@ const (
 	// Envoy config.
 	LocalAgentClusterName = "local_agent"
 
+	// TracingCollectorClusterName is the name we give the cluster of the
+	// tracing collector in the bootstrap config of Envoy.
+	TracingCollectorClusterName = "tracing_collector"
+
 	// DefaultAuthCheckFrequency is the default value for
 	// Server.AuthCheckFrequency to use when the zero value is provided.
 	DefaultAuthCheckFrequency = 5 * time.Minute
@@ -165,6 +178,7 @@ func (s *Server) process(stream ADSStream, reqCh <-chan *envoy.DiscoveryRequest)
 	var stateCh <-chan *proxycfg.ConfigSnapshot
 	var watchCancel func()
 	var proxyID string
+	var features proxyFeatures
 
 	// need to run a small state machine to get through initial authentication.
 	var state = stateInit
@@ -214,7 +228,14 @@ func (s *Server) process(stream ADSStream, reqCh <-chan *envoy.DiscoveryRequest)
 			return err
 		}
 
-		if rule != nil && !rule.ServiceWrite(cfgSnap.Proxy.DestinationServiceName, nil) {
+		// Gateways aren't proxies for a single service so they need write
+		// access to their own service instead.
+		service := cfgSnap.Proxy.DestinationServiceName
+		if cfgSnap.Kind == structs.ServiceKindTerminatingGateway ||
+			cfgSnap.Kind == structs.ServiceKindIngressGateway {
+			service = cfgSnap.Service
+		}
+		if rule != nil && !rule.ServiceWrite(service, nil) {
 			return status.Errorf(codes.PermissionDenied, "permission denied")
 		}
 
@@ -261,6 +282,14 @@ func (s *Server) process(stream ADSStream, reqCh <-chan *envoy.DiscoveryRequest)
 			// Start authentication process, we need the proxyID
 			proxyID = req.Node.Id
 
+			// Refuse Envoy versions which can't work with the generated
+			// config rather than letting them reject it.
+			var err error
+			if features, err = determineProxyFeatures(req.Node); err != nil {
+				s.Logger.Printf("[WARN] xds: rejecting proxy %q: %s", proxyID, err)
+				return status.Errorf(codes.FailedPrecondition, "%s", err)
+			}
+
 			// Start watching config for that proxy
 			stateCh, watchCancel = s.CfgMgr.Watch(proxyID)
 			// Note that in this case we _intend_ the defer to only be triggered when
@@ -302,9 +331,13 @@ func (s *Server) process(stream ADSStream, reqCh <-chan *envoy.DiscoveryRequest)
 			//  2. Non-determinsic order of complex protobuf responses which are
 			//     compared for non-exact JSON equivalence makes the tests uber-messy
 			//     to handle
+			cInfo := connectionInfo{
+				Token:         tokenFromStream(stream),
+				ProxyFeatures: features,
+			}
 			for _, typeURL := range []string{ClusterType, EndpointType, RouteType, ListenerType} {
 				handler := handlers[typeURL]
-				if err := handler.SendIfNew(cfgSnap, configVersion, &nonce); err != nil {
+				if err := handler.SendIfNew(cfgSnap, cInfo, configVersion, &nonce); err != nil {
 					return err
 				}
 			}
@@ -325,7 +358,7 @@ type xDSType struct {
 	// last version we sent with a Nack then req.VersionInfo will be the older
 	// version it's hanging on to.
 	lastVersion uint64
-	resources   func(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error)
+	resources   func(cfgSnap *proxycfg.ConfigSnapshot, cInfo connectionInfo) ([]proto.Message, error)
 }
 
 func (t *xDSType) Recv(req *envoy.DiscoveryRequest) {
@@ -334,7 +367,7 @@ func (t *xDSType) Recv(req *envoy.DiscoveryRequest) {
 	}
 }
 
-func (t *xDSType) SendIfNew(cfgSnap *proxycfg.ConfigSnapshot, version uint64, nonce *uint64) error {
+func (t *xDSType) SendIfNew(cfgSnap *proxycfg.ConfigSnapshot, cInfo connectionInfo, version uint64, nonce *uint64) error {
 	if t.req == nil {
 		return nil
 	}
@@ -342,7 +375,7 @@ func (t *xDSType) SendIfNew(cfgSnap *proxycfg.ConfigSnapshot, version uint64, no
 		// Already sent this version
 		return nil
 	}
-	resources, err := t.resources(cfgSnap, tokenFromStream(t.stream))
+	resources, err := t.resources(cfgSnap, cInfo)
 	if err != nil {
 		return err
 	}
@@ -463,17 +496,13 @@ func (s *Server) Check(ctx context.Context, r *envoyauthz.CheckRequest) (*envoya
 }
 
 // GRPCServer returns a server instance that can handle XDS and ext_authz
-// requests.
-func (s *Server) GRPCServer(certFile, keyFile string) (*grpc.Server, error) {
+// requests. It serves TLS with the given certificate if it is not nil.
+func (s *Server) GRPCServer(cert *tls.Certificate) (*grpc.Server, error) {
 	opts := []grpc.ServerOption{
 		grpc.MaxConcurrentStreams(2048),
 	}
-	if certFile != "" && keyFile != "" {
-		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
-		if err != nil {
-			return nil, err
-		}
-		opts = append(opts, grpc.Creds(creds))
+	if cert != nil {
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(cert)))
 	}
 	srv := grpc.NewServer(opts...)
 	envoydisco.RegisterAggregatedDiscoveryServiceServer(srv, s)
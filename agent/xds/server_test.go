@@ -3,6 +3,7 @@ package xds
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -15,7 +16,13 @@ import (
 	"time"
 
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoyhttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoytcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type"
+	"github.com/envoyproxy/go-control-plane/pkg/util"
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -389,8 +396,8 @@ func expectClustersJSONResources(t *testing.T, snap *proxycfg.ConfigSnapshot, to
 						}
 					}
 				},
-				"connectTimeout": "5s",
-				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap) + `
+				"connectTimeout": "1s",
+				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap, dbSNI(snap)) + `
 			}`,
 		"prepared_query:geo-cache": `
 			{
@@ -405,7 +412,7 @@ func expectClustersJSONResources(t *testing.T, snap *proxycfg.ConfigSnapshot, to
 					}
 				},
 				"connectTimeout": "5s",
-				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap) + `
+				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap, "") + `
 			}`,
 	}
 }
@@ -483,15 +490,15 @@ func expectEndpointsJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, token stri
 	}`
 }
 
-func expectedUpstreamTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot) string {
-	return expectedTLSContextJSON(t, snap, false)
+func expectedUpstreamTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, sni string) string {
+	return expectedTLSContextJSON(t, snap, false, sni)
 }
 
 func expectedPublicTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot) string {
-	return expectedTLSContextJSON(t, snap, true)
+	return expectedTLSContextJSON(t, snap, true, "")
 }
 
-func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, requireClientCert bool) string {
+func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, requireClientCert bool, sni string) string {
 	// Assume just one root for now, can get fancier later if needed.
 	caPEM := snap.Roots.Roots[0].RootCert
 	reqClient := ""
@@ -499,6 +506,10 @@ func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, require
 		reqClient = `,
 		"requireClientCertificate": true`
 	}
+	if sni != "" {
+		reqClient += `,
+		"sni": "` + sni + `"`
+	}
 	return `{
 		"commonTlsContext": {
 			"tlsParams": {},
@@ -522,6 +533,11 @@ func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, require
 	}`
 }
 
+// dbSNI returns the SNI of the db upstream of the test snapshot.
+func dbSNI(snap *proxycfg.ConfigSnapshot) string {
+	return "db.default.dc1.internal." + snap.Roots.TrustDomain
+}
+
 func assertChanBlocked(t *testing.T, ch chan *envoy.DiscoveryResponse) {
 	t.Helper()
 	select {
@@ -675,6 +691,116 @@ func TestServer_StreamAggregatedResources_ACLEnforcement(t *testing.T) {
 	}
 }
 
+func TestServer_StreamAggregatedResources_UnsupportedEnvoy(t *testing.T) {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	mgr := newTestManager(t)
+	aclResolve := func(id string) (acl.Authorizer, error) {
+		// Allow all
+		return acl.RootAuthorizer("manage"), nil
+	}
+	envoy := NewTestEnvoy(t, "web-sidecar-proxy", "")
+	envoy.BuildVersion = "5f7bf108a93e962bf21dce7bbdfd9294d747cc71/1.6.0/Clean/RELEASE/BoringSSL"
+	defer envoy.Close()
+
+	s := Server{
+		Logger:       logger,
+		CfgMgr:       mgr,
+		Authz:        mgr,
+		ResolveToken: aclResolve,
+	}
+	s.Initialize()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.StreamAggregatedResources(envoy.stream)
+	}()
+
+	envoy.SendReq(t, ClusterType, 0, 0)
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+		gerr, ok := status.FromError(err)
+		require.Truef(t, ok, "not a grpc status error: type='%T' value=%v", err, err)
+		require.Equal(t, codes.FailedPrecondition, gerr.Code())
+		require.Equal(t, "Envoy 1.6.0 is not supported, the minimum supported version is 1.7.0", gerr.Message())
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for handler to finish")
+	}
+}
+
+func TestDetermineProxyFeatures(t *testing.T) {
+	const sha = "5f7bf108a93e962bf21dce7bbdfd9294d747cc71"
+	nodeMetadata := func(version string) *types.Struct {
+		return &types.Struct{Fields: map[string]*types.Value{
+			envoyVersionMetadataKey: {Kind: &types.Value_StringValue{StringValue: version}},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		node    *envoycore.Node
+		want    proxyFeatures
+		wantErr string
+	}{
+		{
+			name: "no node",
+			want: allProxyFeatures,
+		},
+		{
+			name: "unknown build version",
+			node: &envoycore.Node{BuildVersion: "custom"},
+			want: allProxyFeatures,
+		},
+		{
+			name: "1.7",
+			node: &envoycore.Node{BuildVersion: sha + "/1.7.1/Clean/RELEASE/BoringSSL"},
+			want: proxyFeatures{},
+		},
+		{
+			name: "1.8",
+			node: &envoycore.Node{BuildVersion: sha + "/1.8.0/Clean/RELEASE/BoringSSL"},
+			want: proxyFeatures{IngressJWT: true},
+		},
+		{
+			name: "dev build",
+			node: &envoycore.Node{BuildVersion: sha + "/1.9.0-dev/Modified/DEBUG/BoringSSL"},
+			want: proxyFeatures{IngressJWT: true},
+		},
+		{
+			name:    "too old",
+			node:    &envoycore.Node{BuildVersion: sha + "/1.6.0/Clean/RELEASE/BoringSSL"},
+			wantErr: "Envoy 1.6.0 is not supported, the minimum supported version is 1.7.0",
+		},
+		{
+			name: "metadata overrides build version",
+			node: &envoycore.Node{
+				BuildVersion: sha + "/1.7.1/Clean/RELEASE/BoringSSL",
+				Metadata:     nodeMetadata("v1.8"),
+			},
+			want: proxyFeatures{IngressJWT: true},
+		},
+		{
+			name:    "invalid metadata",
+			node:    &envoycore.Node{Metadata: nodeMetadata("latest")},
+			wantErr: `invalid envoy_version "latest" in node metadata`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := determineProxyFeatures(tt.node)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestServer_StreamAggregatedResources_ACLTokenDeleted_StreamTerminatedDuringDiscoveryRequest(t *testing.T) {
 	aclRules := `service "web" { policy = "write" }`
 	token := "service-write-on-web"
@@ -1128,6 +1254,38 @@ func TestServer_ConfigOverridesListeners(t *testing.T) {
 				return expectListenerJSONFromResources(t, snap, "my-token", 1, 1, resources)
 			},
 		},
+		{
+			name: "upstream request timeout",
+			setup: func(snap *proxycfg.ConfigSnapshot) string {
+				snap.Proxy.Upstreams[0].Config["request_timeout_ms"] = 30000
+				resources := expectListenerJSONResources(t, snap, "my-token", 1, 1)
+				resources["service:db"] = `{
+					"@type": "type.googleapis.com/envoy.api.v2.Listener",
+					"name": "service:db:127.0.0.1:9191",
+					"address": {
+						"socketAddress": {
+							"address": "127.0.0.1",
+							"portValue": 9191
+						}
+					},
+					"filterChains": [
+						{
+							"filters": [
+								{
+									"name": "envoy.tcp_proxy",
+									"config": {
+										"cluster": "service:db",
+										"idle_timeout": "30s",
+										"stat_prefix": "service:db"
+									}
+								}
+							]
+						}
+					]
+				}`
+				return expectListenerJSONFromResources(t, snap, "my-token", 1, 1, resources)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1138,7 +1296,7 @@ func TestServer_ConfigOverridesListeners(t *testing.T) {
 			snap := proxycfg.TestConfigSnapshot(t)
 			expect := tt.setup(snap)
 
-			listeners, err := listenersFromSnapshot(snap, "my-token")
+			listeners, err := listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
 			require.NoError(err)
 			r, err := createResponse(ListenerType, "00000001", "00000001", listeners)
 			require.NoError(err)
@@ -1217,7 +1375,7 @@ func TestServer_ConfigOverridesClusters(t *testing.T) {
 					customEDSClusterJSON(t, customClusterJSONOptions{
 						Name:        "myservice",
 						IncludeType: true,
-						TLSContext:  expectedUpstreamTLSContextJSON(t, snap),
+						TLSContext:  expectedUpstreamTLSContextJSON(t, snap, dbSNI(snap)),
 					})
 				return expectClustersJSONFromResources(t, snap, "my-token", 1, 1, resources)
 			},
@@ -1238,11 +1396,45 @@ func TestServer_ConfigOverridesClusters(t *testing.T) {
 					customEDSClusterJSON(t, customClusterJSONOptions{
 						Name:        "myservice",
 						IncludeType: true,
-						TLSContext:  expectedUpstreamTLSContextJSON(t, snap),
+						TLSContext:  expectedUpstreamTLSContextJSON(t, snap, dbSNI(snap)),
 					})
 				return expectClustersJSONFromResources(t, snap, "my-token", 1, 1, resources)
 			},
 		},
+		{
+			name: "upstream timeouts, lb policy and passive health check",
+			setup: func(snap *proxycfg.ConfigSnapshot) string {
+				snap.Proxy.Upstreams[0].Config["connect_timeout_ms"] = 1500
+				snap.Proxy.Upstreams[0].Config["lb_policy"] = "least_request"
+				snap.Proxy.Upstreams[0].Config["passive_health_check"] = map[string]interface{}{
+					"max_failures": 3,
+					"interval":     "20s",
+				}
+				resources := expectClustersJSONResources(t, snap, "my-token", 1, 1)
+				resources["service:db"] = `
+					{
+						"@type": "type.googleapis.com/envoy.api.v2.Cluster",
+						"name": "service:db",
+						"type": "EDS",
+						"edsClusterConfig": {
+							"edsConfig": {
+								"ads": {
+
+								}
+							}
+						},
+						"connectTimeout": "1.500s",
+						"lbPolicy": "LEAST_REQUEST",
+						"outlierDetection": {
+							"consecutive5xx": 3,
+							"interval": "20s",
+							"baseEjectionTime": "20s"
+						},
+						"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap, dbSNI(snap)) + `
+					}`
+				return expectClustersJSONFromResources(t, snap, "my-token", 1, 1, resources)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1253,7 +1445,7 @@ func TestServer_ConfigOverridesClusters(t *testing.T) {
 			snap := proxycfg.TestConfigSnapshot(t)
 			expect := tt.setup(snap)
 
-			clusters, err := clustersFromSnapshot(snap, "my-token")
+			clusters, err := clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
 			require.NoError(err)
 			r, err := createResponse(ClusterType, "00000001", "00000001", clusters)
 			require.NoError(err)
@@ -1393,3 +1585,311 @@ func customAppClusterJSON(t *testing.T, opts customClusterJSONOptions) string {
 	require.NoError(t, err)
 	return buf.String()
 }
+
+func TestServer_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotTerminatingGateway(t)
+	sni := "db.default.dc1.internal." + snap.Roots.TrustDomain
+
+	// The listener selects the filter chain of the service by SNI.
+	listeners, err := listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(listeners, 1)
+	l := listeners[0].(*envoy.Listener)
+	require.Equal(TerminatingGatewayListenerName+":1.2.3.4:8443", l.Name)
+	require.Len(l.FilterChains, 1)
+	chain := l.FilterChains[0]
+	require.Equal([]string{sni}, chain.FilterChainMatch.ServerNames)
+	require.Len(chain.Filters, 2)
+	require.Equal("envoy.ext_authz", chain.Filters[0].Name)
+	require.Equal("envoy.tcp_proxy", chain.Filters[1].Name)
+	require.Equal(snap.TerminatingGateway.ServiceLeaves["db"].CertPEM,
+		chain.TlsContext.CommonTlsContext.TlsCertificates[0].CertificateChain.GetInlineString())
+
+	// Without TLS settings connections are forwarded in plain text.
+	clusters, err := clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(clusters, 1)
+	c := clusters[0].(*envoy.Cluster)
+	require.Equal(sni, c.Name)
+	require.Nil(c.TlsContext)
+
+	snap.TerminatingGateway.Config.Services[0].CAFile = "/etc/ssl/ca.pem"
+	snap.TerminatingGateway.Config.Services[0].SNI = "db.example.com"
+	clusters, err = clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	c = clusters[0].(*envoy.Cluster)
+	require.Equal("db.example.com", c.TlsContext.Sni)
+	require.Equal("/etc/ssl/ca.pem",
+		c.TlsContext.CommonTlsContext.GetValidationContext().TrustedCa.GetFilename())
+
+	endpoints, err := endpointsFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(endpoints, 1)
+	la := endpoints[0].(*envoy.ClusterLoadAssignment)
+	require.Equal(sni, la.ClusterName)
+	require.Len(la.Endpoints[0].LbEndpoints, 2)
+
+	// No listener is generated until a leaf certificate is available.
+	delete(snap.TerminatingGateway.ServiceLeaves, "db")
+	listeners, err = listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Empty(listeners)
+}
+
+func TestServer_IngressGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotIngressGateway(t)
+	sni := "db.default.dc1.internal." + snap.Roots.TrustDomain
+
+	// A plain listener forwards everything to its single service.
+	listeners, err := listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(listeners, 1)
+	l := listeners[0].(*envoy.Listener)
+	require.Equal(IngressGatewayListenerName+":1.2.3.4:9191", l.Name)
+	require.Len(l.FilterChains, 1)
+	require.Nil(l.FilterChains[0].FilterChainMatch)
+	require.Nil(l.FilterChains[0].TlsContext)
+
+	// The gateway dials the service with its own certificate.
+	clusters, err := clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(clusters, 1)
+	c := clusters[0].(*envoy.Cluster)
+	require.Equal(sni, c.Name)
+	require.Equal(sni, c.TlsContext.Sni)
+	require.Equal(snap.Leaf.CertPEM,
+		c.TlsContext.CommonTlsContext.TlsCertificates[0].CertificateChain.GetInlineString())
+
+	endpoints, err := endpointsFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(endpoints, 1)
+	require.Equal(sni, endpoints[0].(*envoy.ClusterLoadAssignment).ClusterName)
+
+	// TLS listeners route by SNI and need the agent's certificate.
+	snap.IngressGateway.Config.Listeners[0].TLS = true
+	snap.IngressGateway.Config.Listeners[0].Services = []structs.IngressService{
+		{Name: "db", Hosts: []string{"db.example.com"}},
+		{Name: "web"},
+	}
+	_, err = listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.Error(err)
+
+	snap.IngressGateway.TLSCertFile = "/etc/consul/cert.pem"
+	snap.IngressGateway.TLSKeyFile = "/etc/consul/key.pem"
+	listeners, err = listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	l = listeners[0].(*envoy.Listener)
+	require.Len(l.ListenerFilters, 1)
+	require.Len(l.FilterChains, 2)
+	require.Equal([]string{"db.example.com"}, l.FilterChains[0].FilterChainMatch.ServerNames)
+	require.Nil(l.FilterChains[1].FilterChainMatch)
+	require.Equal("/etc/consul/cert.pem",
+		l.FilterChains[1].TlsContext.CommonTlsContext.TlsCertificates[0].CertificateChain.GetFilename())
+
+	clusters, err = clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(clusters, 2)
+}
+
+func TestServer_IngressGateway_JWT(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotIngressGateway(t)
+	sni := "db.default.dc1.internal." + snap.Roots.TrustDomain
+	snap.IngressGateway.Config.Listeners[0].Services[0].JWT = &structs.IngressJWT{
+		Issuer:     "https://auth.example.com",
+		Audiences:  []string{"db"},
+		JWKSURL:    "https://auth.example.com:8443/jwks.json",
+		JWKSCAFile: "/etc/ssl/auth-ca.pem",
+		Claims:     []structs.JWTClaim{{Name: "scope.db", Values: []string{"read", "write"}}},
+	}
+
+	// The service is proxied as HTTP with the JWT filters in front of the
+	// router.
+	listeners, err := listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	l := listeners[0].(*envoy.Listener)
+	require.Len(l.FilterChains, 1)
+	filter := l.FilterChains[0].Filters[0]
+	require.Equal("envoy.http_connection_manager", filter.Name)
+
+	var hcm envoyhttp.HttpConnectionManager
+	require.NoError(util.StructToMessage(filter.Config, &hcm))
+	require.Len(hcm.HttpFilters, 3)
+	require.Equal(jwtAuthnFilterName, hcm.HttpFilters[0].Name)
+	require.Equal("envoy.filters.http.rbac", hcm.HttpFilters[1].Name)
+	require.Equal("envoy.router", hcm.HttpFilters[2].Name)
+	route := hcm.GetRouteConfig().VirtualHosts[0].Routes[0]
+	require.Equal(sni, route.GetRoute().GetCluster())
+
+	authn := hcm.HttpFilters[0].Config.Fields["providers"].GetStructValue().Fields[jwtProviderName].GetStructValue()
+	require.Equal("https://auth.example.com", authn.Fields["issuer"].GetStringValue())
+	httpURI := authn.Fields["remote_jwks"].GetStructValue().Fields["http_uri"].GetStructValue()
+	require.Equal("jwks_auth.example.com_8443_acaf9939", httpURI.Fields["cluster"].GetStringValue())
+
+	// The claim path is looked up in the token payload.
+	rbac, err := json.Marshal(makeJWTClaimsRBACConfig(snap.IngressGateway.Config.Listeners[0].Services[0].JWT.Claims))
+	require.NoError(err)
+	require.Contains(string(rbac), `"path":[{"key":"jwt_payload"},{"key":"scope"},{"key":"db"}]`)
+	require.Contains(string(rbac), `"string_match":{"exact":"write"}`)
+
+	// The keys are fetched through a DNS cluster verifying the server.
+	clusters, err := clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	require.Len(clusters, 2)
+	c := clusters[1].(*envoy.Cluster)
+	require.Equal("jwks_auth.example.com_8443_acaf9939", c.Name)
+	require.Equal(envoy.Cluster_LOGICAL_DNS, c.Type)
+	require.Equal("auth.example.com", c.TlsContext.Sni)
+	require.Equal("/etc/ssl/auth-ca.pem",
+		c.TlsContext.CommonTlsContext.GetValidationContext().TrustedCa.GetFilename())
+
+	// Envoy versions which can't validate JWTs get an error instead.
+	_, err = listenersFromSnapshot(snap, connectionInfo{Token: "my-token"})
+	require.Error(err)
+	require.Contains(err.Error(), `service "db" requires JWT validation which needs Envoy 1.8.0 or newer`)
+
+	// Requests are only traced if tracing is enabled.
+	require.Nil(hcm.Tracing)
+	snap.ProxyDefaults = &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: structs.ProxyConfigGlobal,
+		Tracing: &structs.TracingConfig{
+			Enabled:          true,
+			Provider:         structs.TracingProviderZipkin,
+			CollectorAddress: "zipkin.service.consul:9411",
+			SamplingPercent:  25,
+		},
+	}
+	listeners, err = listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	filter = listeners[0].(*envoy.Listener).FilterChains[0].Filters[0]
+	hcm = envoyhttp.HttpConnectionManager{}
+	require.NoError(util.StructToMessage(filter.Config, &hcm))
+	require.Equal(envoyhttp.INGRESS, hcm.Tracing.OperationName)
+	require.Equal(25.0, hcm.Tracing.RandomSampling.Value)
+}
+
+func TestServer_IngressGateway_JWKSClusters(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotIngressGateway(t)
+	jwt := func(url, caFile string) *structs.IngressJWT {
+		return &structs.IngressJWT{Issuer: "https://auth.example.com", JWKSURL: url, JWKSCAFile: caFile}
+	}
+	snap.IngressGateway.Config.Listeners = []structs.IngressListener{
+		{Port: 8080, Services: []structs.IngressService{
+			{Name: "db", JWT: jwt("https://auth.example.com/jwks.json", "/etc/ssl/auth-ca.pem")},
+			{Name: "web", JWT: jwt("https://auth.example.com/keys.json", "/etc/ssl/auth-ca.pem")},
+			{Name: "api", JWT: jwt("https://auth.example.com/jwks.json", "/etc/ssl/certs/ca-certificates.crt")},
+			{Name: "cache", JWT: jwt("http://auth.example.com:443/jwks.json", "")},
+		}},
+	}
+
+	// Services with the same server and CA share a cluster, and a different
+	// CA or scheme gets its own.
+	clusters, err := clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.NoError(err)
+	var jwks []*envoy.Cluster
+	for _, c := range clusters {
+		if c := c.(*envoy.Cluster); strings.HasPrefix(c.Name, "jwks_") {
+			jwks = append(jwks, c)
+		}
+	}
+	require.Len(jwks, 3)
+
+	// HTTPS servers are always verified.
+	for _, c := range jwks[:2] {
+		require.NotNil(c.TlsContext, c.Name)
+		vc := c.TlsContext.CommonTlsContext.GetValidationContext()
+		require.NotNil(vc, c.Name)
+		require.NotEmpty(vc.TrustedCa.GetFilename(), c.Name)
+	}
+	require.Equal("/etc/ssl/auth-ca.pem", jwks[0].TlsContext.CommonTlsContext.GetValidationContext().TrustedCa.GetFilename())
+	require.Equal("/etc/ssl/certs/ca-certificates.crt", jwks[1].TlsContext.CommonTlsContext.GetValidationContext().TrustedCa.GetFilename())
+	require.Nil(jwks[2].TlsContext)
+
+	// An HTTPS server without a CA, as stored before one was required, is
+	// an error rather than an unverified cluster.
+	snap.IngressGateway.Config.Listeners[0].Services[0].JWT.JWKSCAFile = ""
+	_, err = clustersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+	require.Error(err)
+	require.Contains(err.Error(), "requires a JWKSCAFile")
+}
+
+func TestServer_AccessLogs(t *testing.T) {
+	require := require.New(t)
+
+	tcpProxies := func(snap *proxycfg.ConfigSnapshot) []*envoytcp.TcpProxy {
+		t.Helper()
+		listeners, err := listenersFromSnapshot(snap, connectionInfo{Token: "my-token", ProxyFeatures: allProxyFeatures})
+		require.NoError(err)
+		var proxies []*envoytcp.TcpProxy
+		for _, l := range listeners {
+			for _, filter := range l.(*envoy.Listener).FilterChains[0].Filters {
+				if filter.Name != "envoy.tcp_proxy" {
+					continue
+				}
+				var proxy envoytcp.TcpProxy
+				require.NoError(util.StructToMessage(filter.Config, &proxy))
+				proxies = append(proxies, &proxy)
+			}
+		}
+		return proxies
+	}
+
+	// Nothing is logged without config entries.
+	snap := proxycfg.TestConfigSnapshot(t)
+	proxies := tcpProxies(snap)
+	require.Len(proxies, 3)
+	for _, proxy := range proxies {
+		require.Empty(proxy.AccessLog)
+	}
+
+	// The proxy-defaults entry enables the logs of the public and the
+	// upstream listeners.
+	snap.ProxyDefaults = &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: structs.ProxyConfigGlobal,
+		AccessLogs: &structs.AccessLogsConfig{
+			Enabled:         true,
+			Format:          "%START_TIME% %UPSTREAM_HOST%\n",
+			SamplingPercent: 12.5,
+		},
+	}
+	for _, proxy := range tcpProxies(snap) {
+		require.Len(proxy.AccessLog, 1)
+		log := proxy.AccessLog[0]
+		require.Equal(fileAccessLogName, log.Name)
+		require.Equal("/dev/stdout", log.Config.Fields["path"].GetStringValue())
+		require.Equal("%START_TIME% %UPSTREAM_HOST%\n", log.Config.Fields["format"].GetStringValue())
+		sampled := log.Filter.GetRuntimeFilter().PercentSampled
+		require.Equal(uint32(1250), sampled.Numerator)
+		require.Equal(envoytype.FractionalPercent_TEN_THOUSAND, sampled.Denominator)
+	}
+
+	// The service-defaults entry takes precedence.
+	snap.ServiceDefaults = &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "web",
+		AccessLogs: &structs.AccessLogsConfig{
+			Enabled: true,
+			Sink:    structs.AccessLogSinkFile,
+			Path:    "/var/log/envoy/web.log",
+		},
+	}
+	for _, proxy := range tcpProxies(snap) {
+		require.Len(proxy.AccessLog, 1)
+		require.Equal("/var/log/envoy/web.log", proxy.AccessLog[0].Config.Fields["path"].GetStringValue())
+		require.Nil(proxy.AccessLog[0].Filter)
+	}
+
+	snap.ServiceDefaults.AccessLogs = &structs.AccessLogsConfig{Enabled: false}
+	for _, proxy := range tcpProxies(snap) {
+		require.Empty(proxy.AccessLog)
+	}
+}
@@ -91,6 +91,9 @@ type TestEnvoy struct {
 	state   map[string]configState
 	ctx     context.Context
 	cancel  func()
+
+	// BuildVersion is reported in the node of requests if set.
+	BuildVersion string
 }
 
 // NewTestEnvoy creates a TestEnvoy instance.
@@ -127,8 +130,9 @@ func (e *TestEnvoy) SendReq(t testing.T, typeURL string, version, nonce uint64)
 	req := &envoy.DiscoveryRequest{
 		VersionInfo: hexString(version),
 		Node: &envoycore.Node{
-			Id:      e.proxyID,
-			Cluster: e.proxyID,
+			Id:           e.proxyID,
+			Cluster:      e.proxyID,
+			BuildVersion: e.BuildVersion,
 		},
 		ResponseNonce: hexString(nonce),
 		TypeUrl:       typeURL,
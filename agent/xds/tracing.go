@@ -0,0 +1,25 @@
+package xds
+
+import (
+	envoyhttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// makeTracing returns the tracing settings of the HTTP connection managers
+// for the given config, which is nil if tracing is disabled. The tracer
+// itself and the cluster of its collector are part of the bootstrap config
+// generated by "consul connect envoy".
+func makeTracing(cfg *structs.TracingConfig) *envoyhttp.HttpConnectionManager_Tracing {
+	if cfg == nil {
+		return nil
+	}
+	tracing := &envoyhttp.HttpConnectionManager_Tracing{
+		OperationName: envoyhttp.INGRESS,
+	}
+	if cfg.SamplingPercent > 0 {
+		tracing.RandomSampling = &envoytype.Percent{Value: cfg.SamplingPercent}
+	}
+	return tracing
+}
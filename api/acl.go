@@ -409,11 +409,75 @@ func (a *ACL) TokenReadSelf(q *QueryOptions) (*ACLToken, *QueryMeta, error) {
 	return &out, qm, nil
 }
 
+// AnonymousTokenRead returns the anonymous token, whose policies apply to
+// every request made without a token.
+func (a *ACL) AnonymousTokenRead(q *QueryOptions) (*ACLToken, *QueryMeta, error) {
+	r := a.c.newRequest("GET", "/v1/acl/anonymous")
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out ACLToken
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+
+	return &out, qm, nil
+}
+
+// AnonymousTokenSetPolicies replaces the policies of the anonymous token.
+// Policies that grant write access are rejected unless the servers are
+// configured with acl.allow_anonymous_write.
+func (a *ACL) AnonymousTokenSetPolicies(policies []*ACLTokenPolicyLink, q *WriteOptions) (*ACLToken, *WriteMeta, error) {
+	r := a.c.newRequest("PUT", "/v1/acl/anonymous")
+	r.setWriteOptions(q)
+	r.obj = struct {
+		Policies []*ACLTokenPolicyLink
+	}{policies}
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	var out ACLToken
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+
+	return &out, wm, nil
+}
+
+// ACLTokenFilterOptions is used to filter the tokens of a token listing.
+type ACLTokenFilterOptions struct {
+	// Policy limits the listing to the tokens linked to the policy with
+	// this ID.
+	Policy string
+}
+
 // TokenList lists all tokens. The listing does not contain any SecretIDs as those
 // may only be retrieved by a call to TokenRead.
 func (a *ACL) TokenList(q *QueryOptions) ([]*ACLTokenListEntry, *QueryMeta, error) {
+	return a.TokenListFiltered(ACLTokenFilterOptions{}, q)
+}
+
+// TokenListFiltered lists the tokens matching the filter, for example to find
+// the tokens affected by a change of a policy without paging through all
+// tokens. Like TokenList it doesn't return SecretIDs.
+func (a *ACL) TokenListFiltered(f ACLTokenFilterOptions, q *QueryOptions) ([]*ACLTokenListEntry, *QueryMeta, error) {
 	r := a.c.newRequest("GET", "/v1/acl/tokens")
 	r.setQueryOptions(q)
+	if f.Policy != "" {
+		r.params.Set("policy", f.Policy)
+	}
 	rtt, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
 		return nil, nil, err
@@ -594,6 +594,12 @@ func TestAPI_ACLToken_List(t *testing.T) {
 	token5, ok := tokenMap[root.AccessorID]
 	require.True(t, ok)
 	require.NotNil(t, token5)
+
+	// only the tokens linked to the policy are listed when filtering
+	filtered, _, err := acl.TokenListFiltered(ACLTokenFilterOptions{Policy: policies[0].ID}, nil)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	require.Equal(t, created1.AccessorID, filtered[0].AccessorID)
 }
 
 func TestAPI_ACLToken_Clone(t *testing.T) {
@@ -621,6 +627,33 @@ func TestAPI_ACLToken_Clone(t *testing.T) {
 	require.Equal(t, cloned, read)
 }
 
+func TestAPI_ACLToken_Anonymous(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	policy, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "anonymous-read",
+		Rules: `node_prefix "" { policy = "read" }`,
+	}, nil)
+	require.NoError(t, err)
+
+	updated, _, err := acl.AnonymousTokenSetPolicies([]*ACLTokenPolicyLink{{ID: policy.ID}}, nil)
+	require.NoError(t, err)
+	require.Len(t, updated.Policies, 1)
+	require.Equal(t, policy.ID, updated.Policies[0].ID)
+
+	read, _, err := acl.AnonymousTokenRead(nil)
+	require.NoError(t, err)
+	require.Equal(t, updated, read)
+
+	// Policies granting write access are rejected.
+	_, _, err = acl.AnonymousTokenSetPolicies([]*ACLTokenPolicyLink{{Name: "global-management"}}, nil)
+	require.Error(t, err)
+}
+
 func TestAPI_RulesTranslate_FromToken(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // ServiceKind is the kind of service being registered.
@@ -21,6 +22,14 @@ const (
 	// service proxies another service within Consul and speaks the connect
 	// protocol.
 	ServiceKindConnectProxy ServiceKind = "connect-proxy"
+
+	// ServiceKindTerminatingGateway is a gateway for the Connect feature
+	// that forwards connections from the mesh to services outside of it.
+	ServiceKindTerminatingGateway ServiceKind = "terminating-gateway"
+
+	// ServiceKindIngressGateway is a gateway for the Connect feature that
+	// forwards connections from outside of the mesh to Connect services.
+	ServiceKindIngressGateway ServiceKind = "ingress-gateway"
 )
 
 // ProxyExecMode is the execution mode for a managed Connect proxy.
@@ -63,6 +72,16 @@ type AgentCheck struct {
 	ServiceID   string
 	ServiceName string
 	Definition  HealthCheckDefinition
+	Maintenance *HealthCheckMaintenance `json:",omitempty"`
+
+	// The fields below are only returned by the agent running the check.
+	// StatusChangeTime is the last time its status changed, and FlapScore
+	// counts its recent status changes. PendingStatus is the latest status
+	// of a flapping check which is held down and not in the catalog yet.
+	StatusChangeTime *time.Time `json:",omitempty"`
+	FlapScore        float64    `json:",omitempty"`
+	Flapping         bool       `json:",omitempty"`
+	PendingStatus    string     `json:",omitempty"`
 }
 
 // AgentWeights represent optional weights for a service
@@ -71,6 +90,13 @@ type AgentWeights struct {
 	Warning int
 }
 
+// ServiceAddress is an address and port of a service, such as one of its
+// tagged addresses.
+type ServiceAddress struct {
+	Address string
+	Port    int
+}
+
 // AgentService represents a service known to the agent
 type AgentService struct {
 	Kind              ServiceKind `json:",omitempty"`
@@ -80,6 +106,7 @@ type AgentService struct {
 	Meta              map[string]string
 	Port              int
 	Address           string
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	Weights           AgentWeights
 	EnableTagOverride bool
 	CreateIndex       uint64 `json:",omitempty"`
@@ -123,6 +150,32 @@ type AgentServiceConnectProxyConfig struct {
 	LocalServicePort       int                    `json:",omitempty"`
 	Config                 map[string]interface{} `json:",omitempty"`
 	Upstreams              []Upstream
+	TransparentProxy       *TransparentProxyConfig `json:",omitempty"`
+}
+
+// TransparentProxyConfig describes which traffic of a service is redirected to
+// its proxy.
+type TransparentProxyConfig struct {
+	OutboundListenerPort int      `json:",omitempty"`
+	ExcludeInboundPorts  []int    `json:",omitempty"`
+	ExcludeOutboundPorts []int    `json:",omitempty"`
+	ExcludeOutboundCIDRs []string `json:",omitempty"`
+	ExcludeUIDs          []string `json:",omitempty"`
+}
+
+// AgentTrafficRedirect is the information needed to redirect the traffic of a
+// service to its transparent proxy.
+type AgentTrafficRedirect struct {
+	ProxyID              string
+	InboundPort          int
+	OutboundPort         int
+	ExcludeInboundPorts  []int
+	ExcludeOutboundPorts []int
+	ExcludeOutboundCIDRs []string
+	ExcludeUIDs          []string
+
+	// VirtualIPs maps the upstream services of the proxy to their virtual IPs.
+	VirtualIPs map[string]string
 }
 
 // AgentMember represents a cluster member known to the agent
@@ -143,6 +196,14 @@ type AgentMember struct {
 // AllSegments is used to select for all segments in MembersOpts.
 const AllSegments = "_all"
 
+// AgentReloadReport lists the configuration fields which changed during a
+// reload. Fields in RequiresRestart only take effect after the agent has
+// been restarted.
+type AgentReloadReport struct {
+	Applied         []string
+	RequiresRestart []string
+}
+
 // MembersOpts is used for querying member information.
 type MembersOpts struct {
 	// WAN is whether to show members from the WAN.
@@ -155,17 +216,24 @@ type MembersOpts struct {
 
 // AgentServiceRegistration is used to register a new service
 type AgentServiceRegistration struct {
-	Kind              ServiceKind       `json:",omitempty"`
-	ID                string            `json:",omitempty"`
-	Name              string            `json:",omitempty"`
-	Tags              []string          `json:",omitempty"`
-	Port              int               `json:",omitempty"`
-	Address           string            `json:",omitempty"`
-	EnableTagOverride bool              `json:",omitempty"`
-	Meta              map[string]string `json:",omitempty"`
-	Weights           *AgentWeights     `json:",omitempty"`
+	Kind              ServiceKind               `json:",omitempty"`
+	ID                string                    `json:",omitempty"`
+	Name              string                    `json:",omitempty"`
+	Tags              []string                  `json:",omitempty"`
+	Port              int                       `json:",omitempty"`
+	Address           string                    `json:",omitempty"`
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
+	EnableTagOverride bool                      `json:",omitempty"`
+	Meta              map[string]string         `json:",omitempty"`
+	Weights           *AgentWeights             `json:",omitempty"`
 	Check             *AgentServiceCheck
 	Checks            AgentServiceChecks
+
+	// InheritTags and InheritMeta merge the tags and meta of the parent
+	// service into a SidecarService registration.
+	InheritTags bool `json:",omitempty"`
+	InheritMeta bool `json:",omitempty"`
+
 	// DEPRECATED (ProxyDestination) - remove this field
 	ProxyDestination string                          `json:",omitempty"`
 	Proxy            *AgentServiceConnectProxyConfig `json:",omitempty"`
@@ -200,6 +268,8 @@ type AgentServiceCheck struct {
 	TLSSkipVerify     bool                `json:",omitempty"`
 	GRPC              string              `json:",omitempty"`
 	GRPCUseTLS        bool                `json:",omitempty"`
+	Plugin            string              `json:",omitempty"`
+	PluginArgs        map[string]string   `json:",omitempty"`
 	AliasNode         string              `json:",omitempty"`
 	AliasService      string              `json:",omitempty"`
 
@@ -259,6 +329,7 @@ type AgentAuthorizeParams struct {
 	Target           string
 	ClientCertURI    string
 	ClientCertSerial string
+	ClientCertPEM    string `json:",omitempty"`
 }
 
 // AgentAuthorize is the response structure for Connect authorization.
@@ -369,6 +440,23 @@ func (a *Agent) Reload() error {
 	return nil
 }
 
+// ReloadWithReport triggers a configuration reload for the agent we are
+// connected to and returns the configuration fields which changed.
+func (a *Agent) ReloadWithReport() (*AgentReloadReport, error) {
+	r := a.c.newRequest("PUT", "/v1/agent/reload")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out *AgentReloadReport
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeName is used to get the node name of the agent
 func (a *Agent) NodeName() (string, error) {
 	if a.nodeName != "" {
@@ -717,7 +805,8 @@ func (a *Agent) Join(addr string, wan bool) error {
 	return nil
 }
 
-// Leave is used to have the agent gracefully leave the cluster and shutdown
+// Leave is used to have the agent gracefully leave the cluster and shutdown.
+// It blocks while the agent drains its services if service_drain_time is set.
 func (a *Agent) Leave() error {
 	r := a.c.newRequest("PUT", "/v1/agent/leave")
 	_, resp, err := requireOK(a.c.doRequest(r))
@@ -799,6 +888,28 @@ func (a *Agent) ConnectCALeaf(serviceID string, q *QueryOptions) (*LeafCert, *Qu
 	return &out, qm, nil
 }
 
+// ConnectRedirectTraffic gets the information needed to redirect the traffic
+// of a service to the local proxy instance with the given service ID.
+func (a *Agent) ConnectRedirectTraffic(proxyServiceID string, q *QueryOptions) (*AgentTrafficRedirect, *QueryMeta, error) {
+	r := a.c.newRequest("GET", "/v1/agent/connect/redirect-traffic/"+proxyServiceID)
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out AgentTrafficRedirect
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
 // ConnectProxyConfig gets the configuration for a local managed proxy instance.
 //
 // Note that this uses an unconventional blocking mechanism since it's
@@ -824,12 +935,42 @@ func (a *Agent) ConnectProxyConfig(proxyServiceID string, q *QueryOptions) (*Con
 	return &out, qm, nil
 }
 
+// MaintenanceOptions are the optional parameters when enabling node or
+// service maintenance mode.
+type MaintenanceOptions struct {
+	// Reason is recorded in the notes of the maintenance check.
+	Reason string
+
+	// Actor identifies who enabled maintenance mode.
+	Actor string
+
+	// Duration after which the agent clears the maintenance mode
+	// automatically. Zero means maintenance mode does not expire.
+	Duration time.Duration
+}
+
+func (o *MaintenanceOptions) setParams(r *request) {
+	r.params.Set("reason", o.Reason)
+	if o.Actor != "" {
+		r.params.Set("actor", o.Actor)
+	}
+	if o.Duration != 0 {
+		r.params.Set("duration", o.Duration.String())
+	}
+}
+
 // EnableServiceMaintenance toggles service maintenance mode on
 // for the given service ID.
 func (a *Agent) EnableServiceMaintenance(serviceID, reason string) error {
+	return a.EnableServiceMaintenanceOpts(serviceID, &MaintenanceOptions{Reason: reason})
+}
+
+// EnableServiceMaintenanceOpts toggles service maintenance mode on for the
+// given service ID using the given options.
+func (a *Agent) EnableServiceMaintenanceOpts(serviceID string, opts *MaintenanceOptions) error {
 	r := a.c.newRequest("PUT", "/v1/agent/service/maintenance/"+serviceID)
 	r.params.Set("enable", "true")
-	r.params.Set("reason", reason)
+	opts.setParams(r)
 	_, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
 		return err
@@ -854,9 +995,15 @@ func (a *Agent) DisableServiceMaintenance(serviceID string) error {
 // EnableNodeMaintenance toggles node maintenance mode on for the
 // agent we are connected to.
 func (a *Agent) EnableNodeMaintenance(reason string) error {
+	return a.EnableNodeMaintenanceOpts(&MaintenanceOptions{Reason: reason})
+}
+
+// EnableNodeMaintenanceOpts toggles node maintenance mode on for the agent
+// we are connected to using the given options.
+func (a *Agent) EnableNodeMaintenanceOpts(opts *MaintenanceOptions) error {
 	r := a.c.newRequest("PUT", "/v1/agent/maintenance")
 	r.params.Set("enable", "true")
-	r.params.Set("reason", reason)
+	opts.setParams(r)
 	_, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
 		return err
@@ -980,6 +1127,12 @@ func (a *Agent) UpdateReplicationACLToken(token string, q *WriteOptions) (*Write
 	return a.updateTokenFallback("replication", "acl_replication_token", token, q)
 }
 
+// UpdateDNSACLToken updates the agent's "dns" token. See updateToken
+// for more details
+func (a *Agent) UpdateDNSACLToken(token string, q *WriteOptions) (*WriteMeta, error) {
+	return a.updateToken("dns", token, q)
+}
+
 // updateToken can be used to update one of an agent's ACL tokens after the agent has
 // started. The tokens are may not be persisted, so will need to be updated again if
 // the agent is restarted unless the agent is configured to persist them.
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -712,7 +713,7 @@ func TestAPI_AgentService(t *testing.T) {
 		ID:          "foo",
 		Service:     "foo",
 		Tags:        []string{"bar", "baz"},
-		ContentHash: "ad8c7a278470d1e8",
+		ContentHash: "325d9e4891696c34",
 		Port:        8000,
 		Weights: AgentWeights{
 			Passing: 1,
@@ -736,6 +737,101 @@ func TestAPI_AgentService(t *testing.T) {
 	require.True(elapsed >= opts.WaitTime)
 }
 
+func TestAPI_AgentService_RoundTrip(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	catalog := c.Catalog()
+
+	require := require.New(t)
+
+	reg := &AgentServiceRegistration{
+		Name:    "web",
+		Port:    8080,
+		Address: "10.0.0.1",
+		TaggedAddresses: map[string]ServiceAddress{
+			"wan": {Address: "198.18.0.1", Port: 80},
+		},
+		Weights: &AgentWeights{Passing: 10, Warning: 2},
+		Connect: &AgentServiceConnect{
+			SidecarService: &AgentServiceRegistration{
+				Port: 21000,
+				Proxy: &AgentServiceConnectProxyConfig{
+					Upstreams: []Upstream{{
+						DestinationName: "db",
+						LocalBindPort:   9191,
+					}},
+				},
+			},
+		},
+	}
+	require.NoError(agent.ServiceRegister(reg))
+
+	gateway := &AgentServiceRegistration{
+		Kind: ServiceKindTerminatingGateway,
+		Name: "gateway",
+		Port: 8443,
+		Proxy: &AgentServiceConnectProxyConfig{
+			Config: map[string]interface{}{"connect_timeout_ms": float64(5000)},
+		},
+	}
+	require.NoError(agent.ServiceRegister(gateway))
+
+	got, _, err := agent.Service("web", nil)
+	require.NoError(err)
+	require.Equal("10.0.0.1", got.Address)
+	require.Equal(reg.TaggedAddresses, got.TaggedAddresses)
+	require.Equal(AgentWeights{Passing: 10, Warning: 2}, got.Weights)
+
+	got, _, err = agent.Service("web-sidecar-proxy", nil)
+	require.NoError(err)
+	require.Equal(ServiceKindConnectProxy, got.Kind)
+	require.Equal(21000, got.Port)
+	require.Equal("web", got.Proxy.DestinationServiceName)
+	require.Len(got.Proxy.Upstreams, 1)
+	require.Equal("db", got.Proxy.Upstreams[0].DestinationName)
+	require.Equal(9191, got.Proxy.Upstreams[0].LocalBindPort)
+
+	services, err := agent.Services()
+	require.NoError(err)
+	require.Equal(ServiceKindTerminatingGateway, services["gateway"].Kind)
+	require.Equal(gateway.Proxy.Config, services["gateway"].Proxy.Config)
+	require.Equal(reg.TaggedAddresses, services["web"].TaggedAddresses)
+
+	retry.Run(t, func(r *retry.R) {
+		nodes, _, err := catalog.Service("web", "", nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(nodes) != 1 {
+			r.Fatalf("bad: %v", nodes)
+		}
+		if want, got := reg.TaggedAddresses, nodes[0].ServiceTaggedAddresses; !reflect.DeepEqual(want, got) {
+			r.Fatalf("got %v want %v", got, want)
+		}
+	})
+
+	// Validation errors of the agent are returned.
+	err = agent.ServiceRegister(&AgentServiceRegistration{
+		Name: "db",
+		TaggedAddresses: map[string]ServiceAddress{
+			"wan": {Address: "0.0.0.0"},
+		},
+	})
+	require.Error(err)
+	require.Contains(err.Error(), "Unexpected response code: 400")
+	require.Contains(err.Error(), `Invalid address "0.0.0.0" of tagged address "wan"`)
+
+	err = agent.ServiceRegister(&AgentServiceRegistration{
+		Kind: ServiceKindTerminatingGateway,
+		Name: "gateway2",
+	})
+	require.Error(err)
+	require.Contains(err.Error(), "Port must be set for a terminating gateway")
+}
+
 func TestAPI_AgentSetTTLStatus(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
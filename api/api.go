@@ -103,6 +103,9 @@ type QueryOptions struct {
 	// UseCache is true and MaxAge is set to a lower, non-zero value. It is
 	// ignored if the endpoint supports background refresh caching. See
 	// https://www.consul.io/api/index.html#agent-caching for more details.
+	//
+	// Uncached catalog and health reads use it to fall back to a stale read
+	// no older than this if the cluster has no leader.
 	StaleIfError time.Duration
 
 	// WaitIndex is used to enable a blocking query. Waits
@@ -638,6 +641,8 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 		if len(cc) > 0 {
 			r.header.Set("Cache-Control", strings.Join(cc, ", "))
 		}
+	} else if q.StaleIfError > 0 && !q.AllowStale && !q.RequireConsistent {
+		r.params.Set("stale-if-error", q.StaleIfError.String())
 	}
 	r.ctx = q.ctx
 }
@@ -431,6 +431,15 @@ func TestAPI_SetQueryOptions(t *testing.T) {
 	_, ok := r.params["cached"]
 	assert.True(ok)
 	assert.Equal("max-age=30, stale-if-error=346", r.header.Get("Cache-Control"))
+
+	r = c.newRequest("GET", "/v1/health/service/foo")
+	q = &QueryOptions{
+		StaleIfError: 5 * time.Second,
+	}
+	r.setQueryOptions(q)
+
+	assert.Equal("5s", r.params.Get("stale-if-error"))
+	assert.Equal("", r.header.Get("Cache-Control"))
 }
 
 func TestAPI_SetWriteOptions(t *testing.T) {
@@ -26,6 +26,7 @@ type CatalogService struct {
 	ServiceID                string
 	ServiceName              string
 	ServiceAddress           string
+	ServiceTaggedAddresses   map[string]ServiceAddress
 	ServiceTags              []string
 	ServiceMeta              map[string]string
 	ServicePort              int
@@ -91,6 +92,43 @@ func (c *Catalog) Register(reg *CatalogRegistration, q *WriteOptions) (*WriteMet
 	return wm, nil
 }
 
+// CatalogBulkRegisterError reports a registration of a bulk registration
+// which failed, by its position in the request.
+type CatalogBulkRegisterError struct {
+	Index int
+	Node  string
+	Error string
+}
+
+// CatalogBulkRegisterResponse is the result of a bulk registration.
+type CatalogBulkRegisterResponse struct {
+	Registered int
+	Errors     []CatalogBulkRegisterError
+}
+
+// BulkRegister registers many nodes, services and checks at once. The
+// registrations are applied independently, and the ones which failed are
+// reported in the response rather than as an error.
+func (c *Catalog) BulkRegister(regs []*CatalogRegistration, q *WriteOptions) (*CatalogBulkRegisterResponse, *WriteMeta, error) {
+	r := c.c.newRequest("PUT", "/v1/catalog/bulk-register")
+	r.setWriteOptions(q)
+	r.obj = regs
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{}
+	wm.RequestTime = rtt
+
+	var out CatalogBulkRegisterResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
 func (c *Catalog) Deregister(dereg *CatalogDeregistration, q *WriteOptions) (*WriteMeta, error) {
 	r := c.c.newRequest("PUT", "/v1/catalog/deregister")
 	r.setWriteOptions(q)
@@ -242,3 +280,69 @@ func (c *Catalog) Node(node string, q *QueryOptions) (*CatalogNode, *QueryMeta,
 	}
 	return out, qm, nil
 }
+
+// CatalogWeightOverride is the weight override of a service instance.
+type CatalogWeightOverride struct {
+	Node        string
+	ServiceID   string
+	Service     string
+	Weights     Weights
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// SetWeightOverride overrides the weights of a service instance until the
+// override is deleted or the instance is deregistered. A zero passing weight
+// drains the instance from DNS and Connect traffic.
+func (c *Catalog) SetWeightOverride(node, serviceID string, weights *Weights, q *WriteOptions) (*WriteMeta, error) {
+	r := c.c.newRequest("PUT", "/v1/catalog/weight-override/"+node+"/"+serviceID)
+	r.setWriteOptions(q)
+	r.obj = weights
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{}
+	wm.RequestTime = rtt
+
+	return wm, nil
+}
+
+// DeleteWeightOverride restores the registered weights of a service instance.
+func (c *Catalog) DeleteWeightOverride(node, serviceID string, q *WriteOptions) (*WriteMeta, error) {
+	r := c.c.newRequest("DELETE", "/v1/catalog/weight-override/"+node+"/"+serviceID)
+	r.setWriteOptions(q)
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{}
+	wm.RequestTime = rtt
+
+	return wm, nil
+}
+
+// WeightOverrides is used to query the weight overrides of service instances
+func (c *Catalog) WeightOverrides(q *QueryOptions) ([]*CatalogWeightOverride, *QueryMeta, error) {
+	r := c.c.newRequest("GET", "/v1/catalog/weight-overrides")
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*CatalogWeightOverride
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
@@ -844,6 +844,46 @@ func TestAPI_CatalogRegistration(t *testing.T) {
 	})
 }
 
+func TestAPI_CatalogBulkRegister(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	catalog := c.Catalog()
+	regs := []*CatalogRegistration{
+		{
+			Node:    "foo",
+			Address: "192.168.10.10",
+			Service: &AgentService{ID: "redis1", Service: "redis", Port: 8000},
+		},
+		{
+			Node:    "bar",
+			Service: &AgentService{ID: "redis2", Service: "redis", Port: 8000},
+		},
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		out, _, err := catalog.BulkRegister(regs, nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if out.Registered != 1 {
+			r.Fatalf("bad: %v", out)
+		}
+		if len(out.Errors) != 1 || out.Errors[0].Index != 1 || out.Errors[0].Node != "bar" {
+			r.Fatalf("bad: %v", out.Errors)
+		}
+
+		services, _, err := catalog.Service("redis", "", nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(services) != 1 || services[0].Node != "foo" {
+			r.Fatalf("bad: %v", services)
+		}
+	})
+}
+
 func TestAPI_CatalogEnableTagOverride(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -928,3 +968,41 @@ func TestAPI_CatalogEnableTagOverride(t *testing.T) {
 		}
 	})
 }
+
+func TestAPI_CatalogWeightOverride(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	catalog := c.Catalog()
+
+	reg := &CatalogRegistration{
+		Datacenter: "dc1",
+		Node:       "foobar",
+		Address:    "192.168.10.10",
+		Service: &AgentService{
+			ID:      "redis1",
+			Service: "redis",
+			Port:    8000,
+		},
+	}
+	_, err := catalog.Register(reg, nil)
+	require.NoError(t, err)
+
+	_, err = catalog.SetWeightOverride("foobar", "redis1", &Weights{Passing: 0, Warning: 0}, nil)
+	require.NoError(t, err)
+
+	overrides, meta, err := catalog.WeightOverrides(nil)
+	require.NoError(t, err)
+	require.NotEqual(t, uint64(0), meta.LastIndex)
+	require.Len(t, overrides, 1)
+	require.Equal(t, "redis", overrides[0].Service)
+	require.Equal(t, Weights{Passing: 0, Warning: 0}, overrides[0].Weights)
+
+	_, err = catalog.DeleteWeightOverride("foobar", "redis1", nil)
+	require.NoError(t, err)
+
+	overrides, _, err = catalog.WeightOverrides(nil)
+	require.NoError(t, err)
+	require.Len(t, overrides, 0)
+}
@@ -0,0 +1,499 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	TerminatingGateway string = "terminating-gateway"
+	IngressGateway     string = "ingress-gateway"
+	ExportedServices   string = "exported-services"
+	ProxyDefaults      string = "proxy-defaults"
+	ServiceDefaults    string = "service-defaults"
+
+	// ProxyConfigGlobal is the name of the only proxy-defaults entry.
+	ProxyConfigGlobal string = "global"
+)
+
+// ConfigEntry is a centralized config entry stored in the servers.
+type ConfigEntry interface {
+	GetKind() string
+	GetName() string
+	GetCreateIndex() uint64
+	GetModifyIndex() uint64
+}
+
+// TerminatingGatewayConfigEntry manages the services outside of the mesh
+// that a terminating gateway forwards connections to.
+type TerminatingGatewayConfigEntry struct {
+	Kind        string
+	Name        string
+	Services    []LinkedService
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// LinkedService is a service represented by a terminating gateway.
+type LinkedService struct {
+	Name     string
+	CAFile   string `json:",omitempty"`
+	CertFile string `json:",omitempty"`
+	KeyFile  string `json:",omitempty"`
+	SNI      string `json:",omitempty"`
+}
+
+func (g *TerminatingGatewayConfigEntry) GetKind() string {
+	return g.Kind
+}
+
+func (g *TerminatingGatewayConfigEntry) GetName() string {
+	return g.Name
+}
+
+func (g *TerminatingGatewayConfigEntry) GetCreateIndex() uint64 {
+	return g.CreateIndex
+}
+
+func (g *TerminatingGatewayConfigEntry) GetModifyIndex() uint64 {
+	return g.ModifyIndex
+}
+
+// IngressGatewayConfigEntry manages the listeners of an ingress gateway and
+// the Connect services exposed on them.
+type IngressGatewayConfigEntry struct {
+	Kind        string
+	Name        string
+	Listeners   []IngressListener
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// IngressListener is a port of an ingress gateway and the services it
+// routes to.
+type IngressListener struct {
+	Port     int
+	TLS      bool `json:",omitempty"`
+	Services []IngressService
+}
+
+// IngressService is a service exposed by an ingress gateway.
+type IngressService struct {
+	Name  string
+	Hosts []string    `json:",omitempty"`
+	JWT   *IngressJWT `json:",omitempty"`
+}
+
+// IngressJWT configures the validation of the JWTs presented to a service
+// exposed by an ingress gateway.
+type IngressJWT struct {
+	Issuer     string
+	Audiences  []string `json:",omitempty"`
+	JWKSURL    string
+	JWKSCAFile string     `json:",omitempty"`
+	Claims     []JWTClaim `json:",omitempty"`
+}
+
+// JWTClaim is a claim a JWT must carry with one of the given values.
+type JWTClaim struct {
+	Name   string
+	Values []string
+}
+
+func (g *IngressGatewayConfigEntry) GetKind() string {
+	return g.Kind
+}
+
+func (g *IngressGatewayConfigEntry) GetName() string {
+	return g.Name
+}
+
+func (g *IngressGatewayConfigEntry) GetCreateIndex() uint64 {
+	return g.CreateIndex
+}
+
+func (g *IngressGatewayConfigEntry) GetModifyIndex() uint64 {
+	return g.ModifyIndex
+}
+
+// ExportedServicesConfigEntry controls which services of a datacenter are
+// visible to other datacenters.
+type ExportedServicesConfigEntry struct {
+	Kind        string
+	Name        string
+	Services    []ExportedService
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ExportedService is a service exported to other datacenters.
+type ExportedService struct {
+	Name        string
+	Datacenters []string `json:",omitempty"`
+}
+
+func (e *ExportedServicesConfigEntry) GetKind() string {
+	return e.Kind
+}
+
+func (e *ExportedServicesConfigEntry) GetName() string {
+	return e.Name
+}
+
+func (e *ExportedServicesConfigEntry) GetCreateIndex() uint64 {
+	return e.CreateIndex
+}
+
+func (e *ExportedServicesConfigEntry) GetModifyIndex() uint64 {
+	return e.ModifyIndex
+}
+
+// ProxyConfigEntry holds the defaults of all proxies and gateways of the
+// datacenter.
+type ProxyConfigEntry struct {
+	Kind        string
+	Name        string
+	AccessLogs  *AccessLogsConfig `json:",omitempty"`
+	Tracing     *TracingConfig    `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (p *ProxyConfigEntry) GetKind() string {
+	return p.Kind
+}
+
+func (p *ProxyConfigEntry) GetName() string {
+	return p.Name
+}
+
+func (p *ProxyConfigEntry) GetCreateIndex() uint64 {
+	return p.CreateIndex
+}
+
+func (p *ProxyConfigEntry) GetModifyIndex() uint64 {
+	return p.ModifyIndex
+}
+
+// ServiceConfigEntry holds the settings of the proxies of a single service,
+// which take precedence over the proxy-defaults entry.
+type ServiceConfigEntry struct {
+	Kind        string
+	Name        string
+	AccessLogs  *AccessLogsConfig `json:",omitempty"`
+	Tracing     *TracingConfig    `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (s *ServiceConfigEntry) GetKind() string {
+	return s.Kind
+}
+
+func (s *ServiceConfigEntry) GetName() string {
+	return s.Name
+}
+
+func (s *ServiceConfigEntry) GetCreateIndex() uint64 {
+	return s.CreateIndex
+}
+
+func (s *ServiceConfigEntry) GetModifyIndex() uint64 {
+	return s.ModifyIndex
+}
+
+// AccessLogsConfig configures the access logs written by Envoy. Sink is one
+// of "stdout", the default, "stderr" or "file", which requires a Path.
+type AccessLogsConfig struct {
+	Enabled         bool
+	Sink            string  `json:",omitempty"`
+	Path            string  `json:",omitempty"`
+	Format          string  `json:",omitempty"`
+	SamplingPercent float64 `json:",omitempty"`
+}
+
+// TracingConfig configures the tracer of Envoy. Provider is one of "zipkin"
+// or "datadog" and CollectorAddress is the host:port of the collector.
+type TracingConfig struct {
+	Enabled           bool
+	Provider          string
+	CollectorAddress  string
+	CollectorEndpoint string  `json:",omitempty"`
+	ServiceName       string  `json:",omitempty"`
+	SamplingPercent   float64 `json:",omitempty"`
+}
+
+// MakeConfigEntry returns an empty config entry of the given kind.
+func MakeConfigEntry(kind, name string) (ConfigEntry, error) {
+	switch kind {
+	case TerminatingGateway:
+		return &TerminatingGatewayConfigEntry{Kind: kind, Name: name}, nil
+	case IngressGateway:
+		return &IngressGatewayConfigEntry{Kind: kind, Name: name}, nil
+	case ExportedServices:
+		return &ExportedServicesConfigEntry{Kind: kind, Name: name}, nil
+	case ProxyDefaults:
+		return &ProxyConfigEntry{Kind: kind, Name: name}, nil
+	case ServiceDefaults:
+		return &ServiceConfigEntry{Kind: kind, Name: name}, nil
+	default:
+		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
+	}
+}
+
+// DecodeConfigEntry decodes a config entry from its map representation. The
+// "Kind" key selects the type of the entry. Keys are matched without case and
+// may be written in snake_case, such as "jwks_ca_file" for JWKSCAFile, and
+// nested objects may be given as the single element lists HCL decodes blocks
+// to. Unknown keys are ignored so that entries read from newer servers can be
+// decoded.
+func DecodeConfigEntry(raw map[string]interface{}) (ConfigEntry, error) {
+	var kind string
+	for k, v := range raw {
+		if strings.ToLower(k) == "kind" {
+			kind, _ = v.(string)
+		}
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("Payload does not contain a kind/Kind key at the top level")
+	}
+
+	entry, err := MakeConfigEntry(kind, "")
+	if err != nil {
+		return nil, err
+	}
+
+	decodeConf := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			normalizeConfigEntryHook,
+			mapstructure.StringToTimeDurationHookFunc(),
+		),
+		Result:           entry,
+		WeaklyTypedInput: true,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConf)
+	if err != nil {
+		return nil, err
+	}
+	return entry, decoder.Decode(raw)
+}
+
+// normalizeConfigEntryHook prepares the maps decoded into the structs of a
+// config entry. It unwraps the single element list HCL decodes a block to
+// and strips the underscores of snake_case keys so they match the field
+// names.
+func normalizeConfigEntryHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to.Kind() != reflect.Struct {
+		return data, nil
+	}
+	switch v := data.(type) {
+	case []map[string]interface{}:
+		if len(v) != 1 {
+			return data, nil
+		}
+		data = v[0]
+	case []interface{}:
+		if len(v) != 1 {
+			return data, nil
+		}
+		data = v[0]
+	}
+
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[strings.Replace(k, "_", "", -1)] = v
+	}
+	return out, nil
+}
+
+// DecodeConfigEntryFromJSON decodes a config entry from its JSON
+// representation, as returned by the HTTP API. The "Kind" key selects the
+// type of the entry.
+func DecodeConfigEntryFromJSON(data []byte) (ConfigEntry, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return DecodeConfigEntry(raw)
+}
+
+// DecodeConfigEntryFromHCL decodes a config entry written in HCL, such as
+//
+//	Kind = "ingress-gateway"
+//	Name = "ingress"
+//	Listeners {
+//	  Port = 8080
+//	  Services = [{ Name = "web" }]
+//	}
+func DecodeConfigEntryFromHCL(data []byte) (ConfigEntry, error) {
+	var raw map[string]interface{}
+	if err := hcl.Decode(&raw, string(data)); err != nil {
+		return nil, err
+	}
+	return DecodeConfigEntry(raw)
+}
+
+// MarshalConfigEntry returns the JSON representation of a config entry that
+// the HTTP API accepts and DecodeConfigEntryFromJSON decodes. The kind of the
+// entry is set from its type if it is empty.
+func MarshalConfigEntry(entry ConfigEntry) ([]byte, error) {
+	switch e := entry.(type) {
+	case *TerminatingGatewayConfigEntry:
+		if e.Kind == "" {
+			c := *e
+			c.Kind = TerminatingGateway
+			entry = &c
+		}
+	case *IngressGatewayConfigEntry:
+		if e.Kind == "" {
+			c := *e
+			c.Kind = IngressGateway
+			entry = &c
+		}
+	case *ExportedServicesConfigEntry:
+		if e.Kind == "" {
+			c := *e
+			c.Kind = ExportedServices
+			entry = &c
+		}
+	case *ProxyConfigEntry:
+		if e.Kind == "" {
+			c := *e
+			c.Kind = ProxyDefaults
+			entry = &c
+		}
+	case *ServiceConfigEntry:
+		if e.Kind == "" {
+			c := *e
+			c.Kind = ServiceDefaults
+			entry = &c
+		}
+	}
+	return json.Marshal(entry)
+}
+
+// ConfigEntries can be used to query the Config endpoints
+type ConfigEntries struct {
+	c *Client
+}
+
+// ConfigEntries returns a handle to the Config endpoints
+func (c *Client) ConfigEntries() *ConfigEntries {
+	return &ConfigEntries{c}
+}
+
+// Get returns a single config entry, or nil if it doesn't exist.
+func (conf *ConfigEntries) Get(kind string, name string, q *QueryOptions) (ConfigEntry, *QueryMeta, error) {
+	if kind == "" || name == "" {
+		return nil, nil, fmt.Errorf("Both kind and name parameters must not be empty")
+	}
+
+	r := conf.c.newRequest("GET", fmt.Sprintf("/v1/config/%s/%s", kind, name))
+	r.setQueryOptions(q)
+	rtt, resp, err := conf.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	if resp.StatusCode == 404 {
+		return nil, qm, nil
+	} else if resp.StatusCode != 200 {
+		var buf bytes.Buffer
+		io.Copy(&buf, resp.Body)
+		return nil, nil, fmt.Errorf(
+			"Unexpected response %d: %s", resp.StatusCode, buf.String())
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.Body); err != nil {
+		return nil, nil, err
+	}
+	entry, err := DecodeConfigEntryFromJSON(buf.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, qm, nil
+}
+
+// List returns all the config entries of the given kind.
+func (conf *ConfigEntries) List(kind string, q *QueryOptions) ([]ConfigEntry, *QueryMeta, error) {
+	if kind == "" {
+		return nil, nil, fmt.Errorf("The kind parameter must not be empty")
+	}
+
+	r := conf.c.newRequest("GET", fmt.Sprintf("/v1/config/%s", kind))
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var raw []map[string]interface{}
+	if err := decodeBody(resp, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	var entries []ConfigEntry
+	for _, rawEntry := range raw {
+		entry, err := DecodeConfigEntry(rawEntry)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, qm, nil
+}
+
+// Set creates or updates the given config entry.
+func (conf *ConfigEntries) Set(entry ConfigEntry, w *WriteOptions) (*WriteMeta, error) {
+	r := conf.c.newRequest("PUT", "/v1/config")
+	r.setWriteOptions(w)
+	r.obj = entry
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	return wm, nil
+}
+
+// Delete deletes the config entry of the given kind and name.
+func (conf *ConfigEntries) Delete(kind string, name string, w *WriteOptions) (*WriteMeta, error) {
+	if kind == "" || name == "" {
+		return nil, fmt.Errorf("Both kind and name parameters must not be empty")
+	}
+
+	r := conf.c.newRequest("DELETE", fmt.Sprintf("/v1/config/%s/%s", kind, name))
+	r.setWriteOptions(w)
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	return wm, nil
+}
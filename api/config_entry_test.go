@@ -0,0 +1,238 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ConfigEntries(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	config := c.ConfigEntries()
+
+	gateway := &TerminatingGatewayConfigEntry{
+		Kind: TerminatingGateway,
+		Name: "gateway",
+		Services: []LinkedService{
+			{Name: "db", CAFile: "/etc/ssl/db-ca.pem", SNI: "db.example.com"},
+		},
+	}
+
+	// Create it
+	_, err := config.Set(gateway, nil)
+	require.NoError(err)
+
+	// Get it
+	entry, qm, err := config.Get(TerminatingGateway, "gateway", nil)
+	require.NoError(err)
+	require.NotNil(qm)
+	require.NotEqual(0, qm.LastIndex)
+
+	actual, ok := entry.(*TerminatingGatewayConfigEntry)
+	require.True(ok)
+	require.Equal(gateway.Services, actual.Services)
+	require.Equal(qm.LastIndex, actual.GetModifyIndex())
+
+	// List it
+	entries, _, err := config.List(TerminatingGateway, nil)
+	require.NoError(err)
+	require.Len(entries, 1)
+	require.Equal("gateway", entries[0].GetName())
+
+	// Delete it
+	_, err = config.Delete(TerminatingGateway, "gateway", nil)
+	require.NoError(err)
+
+	entry, _, err = config.Get(TerminatingGateway, "gateway", nil)
+	require.NoError(err)
+	require.Nil(entry)
+}
+
+func TestAPI_DecodeConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	entry, err := DecodeConfigEntry(map[string]interface{}{
+		"kind": "terminating-gateway",
+		"name": "gateway",
+		"services": []interface{}{
+			map[string]interface{}{"name": "db", "sni": "db.example.com"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &TerminatingGatewayConfigEntry{
+		Kind:     TerminatingGateway,
+		Name:     "gateway",
+		Services: []LinkedService{{Name: "db", SNI: "db.example.com"}},
+	}, entry)
+
+	entry, err = DecodeConfigEntry(map[string]interface{}{
+		"Kind": "ingress-gateway",
+		"Name": "ingress",
+		"Listeners": []interface{}{
+			map[string]interface{}{
+				"Port":     8443,
+				"TLS":      true,
+				"Services": []interface{}{map[string]interface{}{"Name": "web", "Hosts": []interface{}{"web.example.com"}}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &IngressGatewayConfigEntry{
+		Kind: IngressGateway,
+		Name: "ingress",
+		Listeners: []IngressListener{
+			{Port: 8443, TLS: true, Services: []IngressService{{Name: "web", Hosts: []string{"web.example.com"}}}},
+		},
+	}, entry)
+
+	entry, err = DecodeConfigEntry(map[string]interface{}{
+		"Kind": "exported-services",
+		"Name": "default",
+		"Services": []interface{}{
+			map[string]interface{}{"Name": "web", "Datacenters": []interface{}{"dc2"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &ExportedServicesConfigEntry{
+		Kind:     ExportedServices,
+		Name:     "default",
+		Services: []ExportedService{{Name: "web", Datacenters: []string{"dc2"}}},
+	}, entry)
+
+	_, err = DecodeConfigEntry(map[string]interface{}{"Name": "gateway"})
+	require.Error(t, err)
+}
+
+func TestAPI_DecodeConfigEntryFromHCL(t *testing.T) {
+	t.Parallel()
+
+	entry, err := DecodeConfigEntryFromHCL([]byte(`
+		kind = "ingress-gateway"
+		name = "ingress"
+		listeners {
+			port = 8443
+			tls  = true
+			services = [
+				{
+					name  = "web"
+					hosts = ["web.example.com"]
+					jwt {
+						issuer       = "https://auth.example.com"
+						jwks_url     = "https://auth.example.com/keys"
+						jwks_ca_file = "/etc/ssl/auth-ca.pem"
+					}
+				},
+			]
+		}
+		listeners {
+			port     = 8080
+			services = [{ name = "api" }]
+		}
+	`))
+	require.NoError(t, err)
+	require.Equal(t, &IngressGatewayConfigEntry{
+		Kind: IngressGateway,
+		Name: "ingress",
+		Listeners: []IngressListener{
+			{
+				Port: 8443,
+				TLS:  true,
+				Services: []IngressService{{
+					Name:  "web",
+					Hosts: []string{"web.example.com"},
+					JWT: &IngressJWT{
+						Issuer:     "https://auth.example.com",
+						JWKSURL:    "https://auth.example.com/keys",
+						JWKSCAFile: "/etc/ssl/auth-ca.pem",
+					},
+				}},
+			},
+			{Port: 8080, Services: []IngressService{{Name: "api"}}},
+		},
+	}, entry)
+
+	entry, err = DecodeConfigEntryFromHCL([]byte(`
+		kind = "proxy-defaults"
+		name = "global"
+		access_logs {
+			enabled          = true
+			sink             = "file"
+			path             = "/var/log/envoy/access.log"
+			sampling_percent = 12.5
+		}
+		tracing {
+			enabled            = true
+			provider           = "zipkin"
+			collector_address  = "zipkin.service.consul:9411"
+			collector_endpoint = "/api/v2/spans"
+		}
+	`))
+	require.NoError(t, err)
+	require.Equal(t, &ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		AccessLogs: &AccessLogsConfig{
+			Enabled:         true,
+			Sink:            "file",
+			Path:            "/var/log/envoy/access.log",
+			SamplingPercent: 12.5,
+		},
+		Tracing: &TracingConfig{
+			Enabled:           true,
+			Provider:          "zipkin",
+			CollectorAddress:  "zipkin.service.consul:9411",
+			CollectorEndpoint: "/api/v2/spans",
+		},
+	}, entry)
+
+	entry, err = DecodeConfigEntryFromHCL([]byte(`
+		kind = "service-defaults"
+		name = "web"
+		access_logs {
+			enabled = false
+		}
+	`))
+	require.NoError(t, err)
+	require.Equal(t, &ServiceConfigEntry{
+		Kind:       ServiceDefaults,
+		Name:       "web",
+		AccessLogs: &AccessLogsConfig{},
+	}, entry)
+
+	_, err = DecodeConfigEntryFromHCL([]byte(`kind = "service-router"`))
+	require.EqualError(t, err, "invalid config entry kind: service-router")
+}
+
+func TestAPI_MarshalConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	// The kind is filled in so the entry can be decoded again.
+	entry := &TerminatingGatewayConfigEntry{
+		Name: "gateway",
+		Services: []LinkedService{
+			{Name: "db", CAFile: "/etc/ssl/db-ca.pem", SNI: "db.example.com"},
+		},
+	}
+	data, err := MarshalConfigEntry(entry)
+	require.NoError(t, err)
+	require.Empty(t, entry.Kind)
+
+	decoded, err := DecodeConfigEntryFromJSON(data)
+	require.NoError(t, err)
+	entry.Kind = TerminatingGateway
+	require.Equal(t, entry, decoded)
+
+	// Snake case keys are accepted as well.
+	decoded, err = DecodeConfigEntryFromJSON([]byte(`{
+		"kind": "terminating-gateway",
+		"name": "gateway",
+		"services": [{"name": "db", "ca_file": "/etc/ssl/db-ca.pem", "sni": "db.example.com"}]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, entry, decoded)
+}
@@ -62,9 +62,38 @@ func ParseConsulCAConfig(raw map[string]interface{}) (*ConsulCAProviderConfig, e
 
 // CARootList is the structure for the results of listing roots.
 type CARootList struct {
-	ActiveRootID string
-	TrustDomain  string
-	Roots        []*CARoot
+	ActiveRootID          string
+	TrustDomain           string
+	FederatedTrustDomains []string
+	Roots                 []*CARoot
+
+	// FederatedRoots are the roots of the federated trust domains. Each of
+	// them must only be trusted for the workloads of the trust domain in its
+	// ExternalTrustDomain.
+	FederatedRoots []*CARoot
+
+	// Rollout is the progress of the staged root rotation in progress, if
+	// any.
+	Rollout *CARootRollout
+}
+
+// CARootRollout is the progress of a staged root rotation.
+type CARootRollout struct {
+	// RootID is the ID of the staged root.
+	RootID string
+
+	// StartedAt is the time at which the root was staged.
+	StartedAt time.Time
+
+	// Deadline is the time at which the root is activated even if some agents
+	// haven't acknowledged it.
+	Deadline time.Time
+
+	// Acknowledged is the number of agents that have acknowledged the root.
+	Acknowledged int
+
+	// PendingAgents are the names of the agents the leader still waits for.
+	PendingAgents []string
 }
 
 // CARoot represents a root CA certificate that is trusted.
@@ -85,6 +114,14 @@ type CARoot struct {
 	// cannot be active.
 	Active bool
 
+	// Staged is true while this root is rolled out by a staged rotation. It
+	// is trusted but not yet used for signing.
+	Staged bool
+
+	// ExternalTrustDomain is the trust domain the root was generated under.
+	// For federated roots it is the trust domain of the bundle.
+	ExternalTrustDomain string `json:",omitempty"`
+
 	CreateIndex uint64
 	ModifyIndex uint64
 }
@@ -106,6 +106,10 @@ type IntentionSourceType string
 const (
 	// IntentionSourceConsul is a service within the Consul catalog.
 	IntentionSourceConsul IntentionSourceType = "consul"
+
+	// IntentionSourceSPIFFE is a workload of a federated SPIFFE trust domain.
+	// The source name is its full SPIFFE ID.
+	IntentionSourceSPIFFE IntentionSourceType = "spiffe"
 )
 
 // IntentionMatch are the arguments for the intention match API.
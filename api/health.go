@@ -39,10 +39,21 @@ type HealthCheck struct {
 
 	Definition HealthCheckDefinition
 
+	// Maintenance is set for node and service maintenance mode checks.
+	Maintenance *HealthCheckMaintenance `json:",omitempty"`
+
 	CreateIndex uint64
 	ModifyIndex uint64
 }
 
+// HealthCheckMaintenance is the metadata of a node or service maintenance
+// mode check.
+type HealthCheckMaintenance struct {
+	Reason  string
+	Actor   string     `json:",omitempty"`
+	Expires *time.Time `json:",omitempty"`
+}
+
 // HealthCheckDefinition is used to store the details about
 // a health check's execution.
 type HealthCheckDefinition struct {
@@ -45,6 +45,17 @@ type KVPair struct {
 // KVPairs is a list of KVPair objects
 type KVPairs []*KVPair
 
+// KVSnapshot is the content of several key prefixes read from a single
+// snapshot of the K/V store.
+type KVSnapshot struct {
+	// Index is the Raft index of the snapshot, the highest modify index
+	// of the prefixes.
+	Index uint64
+
+	// Entries are the pairs of the prefixes, sorted by key.
+	Entries KVPairs
+}
+
 // KV is used to manipulate the K/V API
 type KV struct {
 	c *Client
@@ -118,6 +129,32 @@ func (k *KV) Keys(prefix, separator string, q *QueryOptions) ([]string, *QueryMe
 	return entries, qm, nil
 }
 
+// Snapshot is used to read the pairs under several prefixes at once. All
+// the pairs are read at the same index, unlike successive calls to List.
+// No prefixes reads the whole K/V store.
+func (k *KV) Snapshot(prefixes []string, q *QueryOptions) (*KVSnapshot, *QueryMeta, error) {
+	r := k.c.newRequest("GET", "/v1/kv-snapshot")
+	r.setQueryOptions(q)
+	for _, prefix := range prefixes {
+		r.params.Add("prefix", prefix)
+	}
+	rtt, resp, err := requireOK(k.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out KVSnapshot
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
 func (k *KV) getInternal(key string, params map[string]string, q *QueryOptions) (*http.Response, *QueryMeta, error) {
 	r := k.c.newRequest("GET", "/v1/kv/"+strings.TrimPrefix(key, "/"))
 	r.setQueryOptions(q)
@@ -128,6 +128,40 @@ func TestAPI_ClientList_DeleteRecurse(t *testing.T) {
 	}
 }
 
+func TestAPI_ClientSnapshot(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	// Generate keys under two prefixes and one outside of them
+	web, db := testKey(), testKey()
+	keys := []string{path.Join(web, "port"), path.Join(db, "port"), testKey()}
+	for _, key := range keys {
+		p := &KVPair{Key: key, Flags: 42, Value: []byte("test")}
+		if _, err := kv.Put(p, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	snap, meta, err := kv.Snapshot([]string{web + "/", db + "/"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(snap.Entries) != 2 {
+		t.Fatalf("got %d keys", len(snap.Entries))
+	}
+	for _, pair := range snap.Entries {
+		if pair.Flags != 42 || !bytes.Equal(pair.Value, []byte("test")) || pair.ModifyIndex == 0 {
+			t.Fatalf("unexpected value: %#v", pair)
+		}
+	}
+	if snap.Index == 0 || meta.LastIndex != snap.Index {
+		t.Fatalf("unexpected index: %d %#v", snap.Index, meta)
+	}
+}
+
 func TestAPI_ClientDeleteCAS(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// AuditResourceIntention and AuditResourceConfigEntry are the kinds of
+	// resources whose changes are recorded in the audit trail.
+	AuditResourceIntention   = "intention"
+	AuditResourceConfigEntry = "config-entry"
+)
+
+// AuditEntry records a change of an intention or a config entry.
+type AuditEntry struct {
+	// Index is the Raft index of the change.
+	Index uint64
+
+	// AccessorID is the accessor of the token used for the change. It is
+	// empty if ACLs are disabled.
+	AccessorID string
+
+	// Time is when the leader handled the change.
+	Time time.Time
+
+	// Resource is the kind of the changed resource and Name identifies it,
+	// which is the ID of an intention and "<kind>/<name>" for a config
+	// entry.
+	Resource string
+	Name     string
+
+	// Op is the operation of the request, such as "create" or "delete".
+	Op string
+
+	// Before and After are the JSON encoded resource before and after the
+	// change. Before is empty when the resource was created and After is
+	// empty when it was deleted.
+	Before json.RawMessage `json:",omitempty"`
+	After  json.RawMessage `json:",omitempty"`
+}
+
+// AuditTrail returns the latest changes of intentions and config entries,
+// oldest first. If resource isn't empty only the changes of that kind of
+// resource are returned.
+func (op *Operator) AuditTrail(resource string, q *QueryOptions) ([]*AuditEntry, *QueryMeta, error) {
+	r := op.c.newRequest("GET", "/v1/operator/audit")
+	r.setQueryOptions(q)
+	if resource != "" {
+		r.params.Set("resource", resource)
+	}
+	rtt, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*AuditEntry
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
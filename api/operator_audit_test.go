@@ -0,0 +1,39 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_OperatorAuditTrail(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	id, _, err := c.Connect().IntentionCreate(testIntention(), nil)
+	require.NoError(t, err)
+	_, err = c.ConfigEntries().Set(&TerminatingGatewayConfigEntry{
+		Kind: TerminatingGateway,
+		Name: "gateway",
+	}, nil)
+	require.NoError(t, err)
+
+	operator := c.Operator()
+	entries, qm, err := operator.AuditTrail("", nil)
+	require.NoError(t, err)
+	require.NotZero(t, qm.LastIndex)
+	require.Len(t, entries, 2)
+	require.Equal(t, AuditResourceIntention, entries[0].Resource)
+	require.Equal(t, id, entries[0].Name)
+	require.Equal(t, "create", entries[0].Op)
+	require.Nil(t, entries[0].Before)
+	require.Contains(t, string(entries[0].After), id)
+	require.False(t, entries[0].Time.IsZero())
+
+	entries, _, err = operator.AuditTrail(AuditResourceConfigEntry, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "terminating-gateway/gateway", entries[0].Name)
+	require.Equal(t, "upsert", entries[0].Op)
+}
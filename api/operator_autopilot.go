@@ -17,6 +17,14 @@ type AutopilotConfiguration struct {
 	// peer list when a new server joins
 	CleanupDeadServers bool
 
+	// DeadServerQuarantineTime is the minimum amount of time a server must
+	// be dead before it's removed. Zero removes dead servers right away.
+	DeadServerQuarantineTime *ReadableDuration
+
+	// MaxDeadServerRemovals is the maximum number of dead servers removed
+	// at a time. Zero means no limit.
+	MaxDeadServerRemovals uint64
+
 	// LastContactThreshold is the limit on the amount of time a server can go
 	// without leader contact before being considered unhealthy.
 	LastContactThreshold *ReadableDuration
@@ -107,6 +115,35 @@ type OperatorHealthReply struct {
 	Servers []ServerHealth
 }
 
+// ServerRemoval records the removal of a dead server by Autopilot.
+type ServerRemoval struct {
+	// ID is the raft ID of the server.
+	ID string
+
+	// Name is the node name of the server. It's empty for servers that were
+	// only known to Raft.
+	Name string
+
+	// Address is the address of the server.
+	Address string
+
+	// Reason is "failed" for servers that Serf reported as failed and
+	// "stale" for servers known to Raft but not to Serf.
+	Reason string
+
+	// DeadSince is the time Autopilot first noticed the server was dead.
+	DeadSince time.Time
+
+	// RemovedAt is the time the server was removed.
+	RemovedAt time.Time
+}
+
+// OperatorRemovalsReply is the list of the dead servers the current leader
+// removed, oldest first.
+type OperatorRemovalsReply struct {
+	Removals []ServerRemoval
+}
+
 // ReadableDuration is a duration type that is serialized to JSON in human readable format.
 type ReadableDuration time.Duration
 
@@ -217,3 +254,21 @@ func (op *Operator) AutopilotServerHealth(q *QueryOptions) (*OperatorHealthReply
 	}
 	return &out, nil
 }
+
+// AutopilotServerRemovals returns the dead servers Autopilot removed since the
+// current leader was elected.
+func (op *Operator) AutopilotServerRemovals(q *QueryOptions) (*OperatorRemovalsReply, error) {
+	r := op.c.newRequest("GET", "/v1/operator/autopilot/removals")
+	r.setQueryOptions(q)
+	_, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out OperatorRemovalsReply
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -24,6 +24,38 @@ type KeyringResponse struct {
 
 	// The total number of nodes in this ring
 	NumNodes int
+
+	// A map of the primary encryption keys to the number of nodes using
+	// them, only set for detailed lists
+	PrimaryKeys map[string]int `json:",omitempty"`
+
+	// The keyring of each node in this ring, only set for detailed lists
+	Nodes []*KeyringNodeStatus `json:",omitempty"`
+}
+
+// KeyringNodeStatus is the keyring of a single node
+type KeyringNodeStatus struct {
+	// The name of the node
+	Node string
+
+	// The encryption keys installed on the node
+	Keys []string
+
+	// The encryption key the node uses to encrypt messages
+	PrimaryKey string
+
+	// Error is set if the node didn't report its keyring
+	Error string `json:",omitempty"`
+}
+
+// KeyringListOptions are the options for listing the gossip encryption keys
+type KeyringListOptions struct {
+	// Detailed lists the keyring of each node as well
+	Detailed bool
+
+	// WaitForKey blocks the list until the given key is installed on all
+	// nodes, or until the WaitTime of the query options is up
+	WaitForKey string
 }
 
 // KeyringInstall is used to install a new gossip encryption key into the cluster
@@ -58,6 +90,33 @@ func (op *Operator) KeyringList(q *QueryOptions) ([]*KeyringResponse, error) {
 	return out, nil
 }
 
+// KeyringListWithOptions is used to list the gossip keys installed in the
+// cluster, optionally with the keyring of each node or waiting for a key to
+// be installed on all nodes
+func (op *Operator) KeyringListWithOptions(opts *KeyringListOptions, q *QueryOptions) ([]*KeyringResponse, error) {
+	r := op.c.newRequest("GET", "/v1/operator/keyring")
+	r.setQueryOptions(q)
+	if opts != nil {
+		if opts.Detailed {
+			r.params.Set("detailed", "")
+		}
+		if opts.WaitForKey != "" {
+			r.params.Set("wait-for-key", opts.WaitForKey)
+		}
+	}
+	_, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out []*KeyringResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeyringRemove is used to remove a gossip encryption key from the cluster
 func (op *Operator) KeyringRemove(key string, q *WriteOptions) error {
 	r := op.c.newRequest("DELETE", "/v1/operator/keyring")
@@ -2,6 +2,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/testutil"
 )
@@ -71,3 +72,49 @@ func TestAPI_OperatorKeyringInstallListPutRemove(t *testing.T) {
 		}
 	}
 }
+
+func TestAPI_OperatorKeyringListWithOptions(t *testing.T) {
+	t.Parallel()
+	oldKey := "d8wu8CSUrqgtjVsvcBPmhQ=="
+	newKey := "qxycTi/SsePj/TZzCBmNXw=="
+	c, s := makeClientWithConfig(t, nil, func(c *testutil.TestServerConfig) {
+		c.Encrypt = oldKey
+	})
+	defer s.Stop()
+
+	operator := c.Operator()
+	if err := operator.KeyringInstall(newKey, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Wait for the new key and list the keyring of each node
+	opts := &KeyringListOptions{Detailed: true, WaitForKey: newKey}
+	listResponses, err := operator.KeyringListWithOptions(opts, &QueryOptions{WaitTime: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(listResponses) != 2 {
+		t.Fatalf("bad: %v", len(listResponses))
+	}
+	for _, response := range listResponses {
+		if response.Keys[newKey] != response.NumNodes {
+			t.Fatalf("bad: %v", response.Keys)
+		}
+		if response.PrimaryKeys[oldKey] != response.NumNodes {
+			t.Fatalf("bad: %v", response.PrimaryKeys)
+		}
+		if len(response.Nodes) != 1 {
+			t.Fatalf("bad: %v", response.Nodes)
+		}
+		node := response.Nodes[0]
+		if node.PrimaryKey != oldKey || len(node.Keys) != 2 || node.Error != "" {
+			t.Fatalf("bad: %#v", node)
+		}
+	}
+
+	// A key that is never installed times out
+	opts = &KeyringListOptions{WaitForKey: "eXeXyPEpfOQ2R4jHQSZsLw=="}
+	if _, err := operator.KeyringListWithOptions(opts, &QueryOptions{WaitTime: 10 * time.Millisecond}); err == nil {
+		t.Fatalf("expected a timeout")
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+// StateIndexUsage has the approximate memory used by an index of a state
+// store table.
+type StateIndexUsage struct {
+	// Name is the name of the index.
+	Name string
+
+	// Entries is the number of keys in the index.
+	Entries int
+
+	// Bytes is the total length of the keys in the index.
+	Bytes uint64
+}
+
+// StateTableUsage has the approximate memory used by a state store table.
+type StateTableUsage struct {
+	// Name is the name of the table.
+	Name string
+
+	// Objects is the number of objects stored in the table.
+	Objects int
+
+	// Bytes is the total size of the objects, estimated from their msgpack
+	// encoding.
+	Bytes uint64
+
+	// Indexes has the usage of each of the table's indexes.
+	Indexes []*StateIndexUsage
+}
+
+// StateUsage is returned when querying the memory usage of the state store of
+// a server.
+type StateUsage struct {
+	// Node is the name of the server that reported the usage.
+	Node string
+
+	// Tables has the usage of each table of the state store.
+	Tables []*StateTableUsage
+}
+
+// StateUsage is used to query the approximate memory used by the state store
+// of the leader, or of the server answering the query if AllowStale is set.
+func (op *Operator) StateUsage(q *QueryOptions) (*StateUsage, *QueryMeta, error) {
+	r := op.c.newRequest("GET", "/v1/operator/usage")
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out StateUsage
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPI_OperatorStateUsage(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	operator := c.Operator()
+	out, qm, err := operator.StateUsage(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Node == "" || len(out.Tables) == 0 {
+		t.Fatalf("bad: %v", out)
+	}
+	if qm.LastIndex == 0 {
+		t.Fatalf("bad: %v", qm)
+	}
+}
@@ -34,14 +34,57 @@ func (s *Snapshot) Save(q *QueryOptions) (io.ReadCloser, *QueryMeta, error) {
 	return resp.Body, qm, nil
 }
 
+// SnapshotRestoreOptions are the options of a snapshot restore.
+type SnapshotRestoreOptions struct {
+	// DryRun verifies the snapshot and returns a summary of its contents
+	// without restoring it.
+	DryRun bool
+
+	// SkipClusterIDCheck allows restoring a snapshot which was taken from
+	// another cluster.
+	SkipClusterIDCheck bool
+}
+
+// SnapshotSummary describes the contents of a snapshot verified by a restore
+// dry run.
+type SnapshotSummary struct {
+	Index     uint64
+	Term      uint64
+	ClusterID string
+	Records   map[string]int
+}
+
 // Restore streams in an existing snapshot and attempts to restore it.
 func (s *Snapshot) Restore(q *WriteOptions, in io.Reader) error {
+	_, err := s.RestoreWithOptions(q, in, nil)
+	return err
+}
+
+// RestoreWithOptions streams in an existing snapshot and attempts to restore
+// it with the given options. The servers verify the snapshot before
+// restoring it. A summary of the snapshot is only returned for a dry run.
+func (s *Snapshot) RestoreWithOptions(q *WriteOptions, in io.Reader, opts *SnapshotRestoreOptions) (*SnapshotSummary, error) {
 	r := s.c.newRequest("PUT", "/v1/snapshot")
 	r.body = in
 	r.setWriteOptions(q)
-	_, _, err := requireOK(s.c.doRequest(r))
+	if opts != nil && opts.DryRun {
+		r.params.Set("dry-run", "")
+	}
+	if opts != nil && opts.SkipClusterIDCheck {
+		r.params.Set("skip-cluster-id-check", "")
+	}
+	_, resp, err := requireOK(s.c.doRequest(r))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if opts == nil || !opts.DryRun {
+		return nil, nil
+	}
+	var summary SnapshotSummary
+	if err := decodeBody(resp, &summary); err != nil {
+		return nil, err
 	}
-	return nil
+	return &summary, nil
 }
@@ -132,3 +132,44 @@ func TestAPI_Snapshot_Options(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestAPI_Snapshot_DryRun(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+	key := &KVPair{Key: testKey(), Value: []byte("hello")}
+	if _, err := kv.Put(key, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	snapshot := c.Snapshot()
+	snap, _, err := snapshot.Save(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer snap.Close()
+
+	key.Value = []byte("goodbye")
+	if _, err := kv.Put(key, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A dry run reports the contents without restoring them.
+	summary, err := snapshot.RestoreWithOptions(nil, snap, &SnapshotRestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if summary == nil || summary.Index == 0 || summary.Records["KV"] != 1 {
+		t.Fatalf("bad: %#v", summary)
+	}
+
+	pair, _, err := kv.Get(key.Key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || !bytes.Equal(pair.Value, []byte("goodbye")) {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+}
@@ -41,3 +41,20 @@ func (s *Status) Peers() ([]string, error) {
 	}
 	return peers, nil
 }
+
+// ClusterID is used to query for the ID of the cluster of the servers. It is
+// empty until the first leader is elected.
+func (s *Status) ClusterID() (string, error) {
+	r := s.c.newRequest("GET", "/v1/status/cluster-id")
+	_, resp, err := requireOK(s.c.doRequest(r))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var id string
+	if err := decodeBody(resp, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -37,3 +37,20 @@ func TestAPI_StatusPeers(t *testing.T) {
 		t.Fatalf("Expected peers ")
 	}
 }
+
+func TestAPI_StatusClusterID(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+	s.WaitForSerfCheck(t)
+
+	status := c.Status()
+
+	id, err := status.ClusterID()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if id == "" {
+		t.Fatalf("Expected cluster ID")
+	}
+}
@@ -17,13 +17,14 @@ func (c *Client) Txn() *Txn {
 	return &Txn{c}
 }
 
-// TxnOp is the internal format we send to Consul. Currently only K/V and
-// check operations are supported.
+// TxnOp is the internal format we send to Consul. Currently only K/V,
+// catalog and intention operations are supported.
 type TxnOp struct {
-	KV      *KVTxnOp
-	Node    *NodeTxnOp
-	Service *ServiceTxnOp
-	Check   *CheckTxnOp
+	KV        *KVTxnOp
+	Node      *NodeTxnOp
+	Service   *ServiceTxnOp
+	Check     *CheckTxnOp
+	Intention *IntentionTxnOp
 }
 
 // TxnOps is a list of transaction operations.
@@ -145,6 +146,23 @@ type CheckTxnOp struct {
 	Check HealthCheck
 }
 
+// IntentionOp constants give possible operations available in a transaction.
+type IntentionOp string
+
+const (
+	IntentionOpCreate IntentionOp = "create"
+	IntentionOpUpdate IntentionOp = "update"
+	IntentionOpDelete IntentionOp = "delete"
+)
+
+// IntentionTxnOp defines a single operation inside a transaction. Updates
+// and deletes refer to the intention by its ID. Intention operations have no
+// results.
+type IntentionTxnOp struct {
+	Verb      IntentionOp
+	Intention Intention
+}
+
 // Txn is used to apply multiple Consul operations in a single, atomic transaction.
 //
 // Note that Go will perform the required base64 encoding on the values
@@ -58,6 +58,8 @@ func (c *cmd) Run(args []string) int {
 		_, err = client.Agent().UpdateAgentMasterACLToken(token, nil)
 	case "replication":
 		_, err = client.Agent().UpdateReplicationACLToken(token, nil)
+	case "dns":
+		_, err = client.Agent().UpdateDNSACLToken(token, nil)
 	default:
 		c.UI.Error(fmt.Sprintf("Unknown token type"))
 		return 1
@@ -78,7 +80,7 @@ func (c *cmd) dataFromArgs(args []string) (string, string, error) {
 		return "", "", fmt.Errorf("Missing TYPE and TOKEN arguments")
 	case 1:
 		switch args[0] {
-		case "default", "agent", "master", "replication":
+		case "default", "agent", "master", "replication", "dns":
 			return "", "", fmt.Errorf("Missing TOKEN argument")
 		default:
 			return "", "", fmt.Errorf("MISSING TYPE argument")
@@ -128,6 +130,9 @@ Usage: consul acl set-agent-token [options] TYPE TOKEN
                   operations. This token will need to be configured with read access
                   to whatever data is being replicated.
 
+    dns           The token that the agent will use to resolve DNS queries.
+                  If not given then the default token is used for these queries.
+
   Example:
 
     $ consul acl set-agent-token default c4d0f8df-3aba-4ab6-a7a0-35b760dc29a1
@@ -3,7 +3,12 @@ package bootstrap
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/acl"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
@@ -20,10 +25,21 @@ type cmd struct {
 	flags *flag.FlagSet
 	http  *flags.HTTPFlags
 	help  string
+
+	resetFile string
 }
 
+// resetIndexRe matches the reset index in the error returned when the ACL
+// system was already bootstrapped.
+var resetIndexRe = regexp.MustCompile(`reset index: (\d+)\)`)
+
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.resetFile, "reset-file", "",
+		"Path to the acl-bootstrap-reset file in the data directory of the leader. "+
+			"If the ACL system was already bootstrapped, the reset index is written "+
+			"to this file after confirmation and the bootstrap is retried. This must "+
+			"be run on the leader.")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -42,6 +58,9 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	token, _, err := client.ACL().Bootstrap()
+	if err != nil && c.resetFile != "" {
+		token, err = c.reset(client, err)
+	}
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed ACL bootstrapping: %v", err))
 		return 1
@@ -51,6 +70,45 @@ func (c *cmd) Run(args []string) int {
 	return 0
 }
 
+// reset writes the reset index from the given bootstrap error to the reset
+// file and bootstraps again.
+func (c *cmd) reset(client *api.Client, bootstrapErr error) (*api.ACLToken, error) {
+	m := resetIndexRe.FindStringSubmatch(bootstrapErr.Error())
+	if m == nil {
+		return nil, bootstrapErr
+	}
+	resetIdx, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return nil, bootstrapErr
+	}
+
+	// The servers only read the reset file from their own data directory,
+	// and only the leader handles the bootstrap.
+	self, err := client.Agent().Self()
+	if err != nil {
+		return nil, fmt.Errorf("Error querying the agent: %v", err)
+	}
+	if stats, ok := self["Stats"]["consul"].(map[string]interface{}); !ok || stats["leader"] != "true" {
+		return nil, fmt.Errorf("The ACL system was already bootstrapped and -reset-file must be used on the leader")
+	}
+
+	c.UI.Output(fmt.Sprintf("The ACL system was already bootstrapped (reset index: %d).", resetIdx))
+	answer, err := c.UI.Ask(fmt.Sprintf("Write the reset index to %q and bootstrap again? Only 'yes' will be accepted:", c.resetFile))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading confirmation: %v", err)
+	}
+	if strings.TrimSpace(answer) != "yes" {
+		return nil, fmt.Errorf("Bootstrap reset cancelled")
+	}
+
+	if err := ioutil.WriteFile(c.resetFile, []byte(strconv.FormatUint(resetIdx, 10)), 0600); err != nil {
+		return nil, fmt.Errorf("Error writing reset file: %v", err)
+	}
+
+	token, _, err := client.ACL().Bootstrap()
+	return token, err
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -61,12 +119,16 @@ func (c *cmd) Help() string {
 
 const synopsis = "Bootstrap Consul's ACL system"
 
-// TODO (ACL-V2) - maybe embed link to bootstrap reset docs
 const help = `
 Usage: consul acl bootstrap [options]
 
   The bootstrap command will request Consul to generate a new token with unlimited privileges to use
   for management purposes and output its details. This can only be done once and afterwards bootstrapping
-  will be disabled. If all tokens are lost and you need to bootstrap again you can follow the bootstrap
-  reset procedure
+  will be disabled.
+
+  If all tokens are lost and you need to bootstrap again, run the command on the leader with the path
+  of the reset file in its data directory. After confirmation the reset index is written to the file
+  and the bootstrap is retried:
+
+      $ consul acl bootstrap -reset-file /opt/consul/data/acl-bootstrap-reset
 `
@@ -2,6 +2,7 @@ package bootstrap
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/consul/testutil"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBootstrapCommand_noTabs(t *testing.T) {
@@ -54,3 +56,47 @@ func TestBootstrapCommand(t *testing.T) {
 	assert.Contains(output, "Bootstrap Token")
 	assert.Contains(output, structs.ACLPolicyGlobalManagementID)
 }
+
+func TestBootstrapCommand_Reset(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	a := agent.NewTestAgent(t, t.Name(), `
+	primary_datacenter = "dc1"
+	acl {
+		enabled = true
+	}`)
+
+	a.Agent.LogWriter = logger.NewLogWriter(512)
+
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	_, _, err := a.Client().ACL().Bootstrap()
+	require.NoError(err)
+
+	resetFile := filepath.Join(a.Config.DataDir, "acl-bootstrap-reset")
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-reset-file=" + resetFile,
+	}
+
+	// Without confirmation the file isn't written.
+	ui := cli.NewMockUi()
+	ui.InputReader = strings.NewReader("no\n")
+	require.Equal(1, New(ui).Run(args))
+	require.Contains(ui.ErrorWriter.String(), "Bootstrap reset cancelled")
+	_, err = os.Stat(resetFile)
+	require.True(os.IsNotExist(err))
+
+	ui = cli.NewMockUi()
+	ui.InputReader = strings.NewReader("yes\n")
+	require.Equal(0, New(ui).Run(args), ui.ErrorWriter.String())
+	output := ui.OutputWriter.String()
+	require.Contains(output, "reset index:")
+	require.Contains(output, "Bootstrap Token")
+
+	// The servers remove the file once it was used.
+	_, err = os.Stat(resetFile)
+	require.True(os.IsNotExist(err))
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/acl"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
@@ -21,13 +22,19 @@ type cmd struct {
 	http  *flags.HTTPFlags
 	help  string
 
-	showMeta bool
+	showMeta   bool
+	policyID   string
+	policyName string
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.BoolVar(&c.showMeta, "meta", false, "Indicates that token metadata such "+
 		"as the content hash and Raft indices should be shown for each entry")
+	c.flags.StringVar(&c.policyID, "policy-id", "", "Only list the tokens linked "+
+		"to the policy with this ID. It may be specified as a unique ID prefix.")
+	c.flags.StringVar(&c.policyName, "policy-name", "", "Only list the tokens "+
+		"linked to the policy with this name.")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -39,13 +46,29 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.policyID != "" && c.policyName != "" {
+		c.UI.Error("Cannot specify both -policy-id and -policy-name")
+		return 1
+	}
+
 	client, err := c.http.APIClient()
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
 		return 1
 	}
 
-	tokens, _, err := client.ACL().TokenList(nil)
+	var filter api.ACLTokenFilterOptions
+	if c.policyID != "" {
+		filter.Policy, err = acl.GetPolicyIDFromPartial(client, c.policyID)
+	} else if c.policyName != "" {
+		filter.Policy, err = acl.GetPolicyIDByName(client, c.policyName)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error determining policy ID: %v", err))
+		return 1
+	}
+
+	tokens, _, err := client.ACL().TokenListFiltered(filter, nil)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed to retrieve the token list: %v", err))
 		return 1
@@ -79,4 +102,8 @@ Usage: consul acl token list [options]
   List all the ALC tokens
 
           $ consul acl token list
+
+  List the tokens linked to a policy:
+
+          $ consul acl token list -policy-name=node-services-read
 `
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/consul/testutil"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTokenListCommand_noTabs(t *testing.T) {
@@ -78,3 +79,66 @@ func TestTokenListCommand(t *testing.T) {
 		assert.Contains(output, v)
 	}
 }
+
+func TestTokenListCommand_Policy(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	a := agent.NewTestAgent(t, t.Name(), `
+	primary_datacenter = "dc1"
+	acl {
+		enabled = true
+		tokens {
+			master = "root"
+		}
+	}`)
+
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	client := a.Client()
+	policy, _, err := client.ACL().PolicyCreate(
+		&api.ACLPolicy{Name: "test-policy"},
+		&api.WriteOptions{Token: "root"},
+	)
+	require.NoError(err)
+
+	linked, _, err := client.ACL().TokenCreate(
+		&api.ACLToken{
+			Description: "linked token",
+			Policies:    []*api.ACLTokenPolicyLink{&api.ACLTokenPolicyLink{ID: policy.ID}},
+		},
+		&api.WriteOptions{Token: "root"},
+	)
+	require.NoError(err)
+	other, _, err := client.ACL().TokenCreate(
+		&api.ACLToken{Description: "other token"},
+		&api.WriteOptions{Token: "root"},
+	)
+	require.NoError(err)
+
+	for _, arg := range []string{"-policy-id=" + policy.ID[:8], "-policy-name=test-policy"} {
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+		code := cmd.Run([]string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-token=root",
+			arg,
+		})
+		require.Equal(0, code, ui.ErrorWriter.String())
+		output := ui.OutputWriter.String()
+		require.Contains(output, linked.AccessorID)
+		require.NotContains(output, other.AccessorID)
+	}
+
+	ui := cli.NewMockUi()
+	cmd := New(ui)
+	code := cmd.Run([]string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-token=root",
+		"-policy-id=" + policy.ID,
+		"-policy-name=test-policy",
+	})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "Cannot specify both -policy-id and -policy-name")
+}
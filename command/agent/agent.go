@@ -80,22 +80,28 @@ func (c *cmd) Run(args []string) int {
 }
 
 // readConfig is responsible for setup of our configuration using
-// the command line and any file configs
-func (c *cmd) readConfig() *config.RuntimeConfig {
+// the command line and any file configs. It also returns the source which
+// set each config key.
+func (c *cmd) readConfig() (*config.RuntimeConfig, map[string]string) {
 	b, err := config.NewBuilder(c.flagArgs)
 	if err != nil {
 		c.UI.Error(err.Error())
-		return nil
+		return nil, nil
 	}
+	// Keep the generated dev mode secrets so that a reload does not
+	// rotate the master token or the TLS material.
+	c.flagArgs.DevMasterToken = b.Flags.DevMasterToken
+	c.flagArgs.DevTLSDir = b.Flags.DevTLSDir
+
 	cfg, err := b.BuildAndValidate()
 	if err != nil {
 		c.UI.Error(err.Error())
-		return nil
+		return nil, nil
 	}
 	for _, w := range b.Warnings {
 		c.UI.Warn(w)
 	}
-	return &cfg
+	return &cfg, b.Origins
 }
 
 // checkpointResults is used to handler periodic results from our update checker
@@ -181,7 +187,10 @@ func (c *cmd) run(args []string) int {
 		return 1
 	}
 	c.flagArgs.Args = c.flags.Args()
-	config := c.readConfig()
+	config, origins := c.readConfig()
+	if c.flagArgs.DevTLSDir != "" {
+		defer os.RemoveAll(c.flagArgs.DevTLSDir)
+	}
 	if config == nil {
 		return 1
 	}
@@ -191,6 +200,7 @@ func (c *cmd) run(args []string) int {
 		LogLevel:          config.LogLevel,
 		EnableSyslog:      config.EnableSyslog,
 		SyslogFacility:    config.SyslogFacility,
+		EnableEventLog:    config.EnableEventLog,
 		LogFilePath:       config.LogFile,
 		LogRotateDuration: config.LogRotateDuration,
 		LogRotateBytes:    config.LogRotateBytes,
@@ -222,6 +232,7 @@ func (c *cmd) run(args []string) int {
 	agent.LogOutput = logOutput
 	agent.LogWriter = logWriter
 	agent.MemSink = memSink
+	agent.SetConfigOrigins(origins)
 
 	if err := agent.Start(); err != nil {
 		c.UI.Error(fmt.Sprintf("Error starting agent: %s", err))
@@ -266,6 +277,12 @@ func (c *cmd) run(args []string) int {
 		config.SerfPortLAN, config.SerfPortWAN))
 	c.UI.Info(fmt.Sprintf("       Encrypt: Gossip: %v, TLS-Outgoing: %v, TLS-Incoming: %v",
 		agent.GossipEncrypted(), config.VerifyOutgoing, config.VerifyIncoming))
+	if config.DevMode && c.flagArgs.DevMasterToken != "" {
+		c.UI.Info(fmt.Sprintf("    Root Token: '%s'", c.flagArgs.DevMasterToken))
+	}
+	if config.DevMode && c.flagArgs.DevTLSDir != "" {
+		c.UI.Info(fmt.Sprintf("       TLS Dir: '%s' (CA: '%s')", c.flagArgs.DevTLSDir, config.CAFile))
+	}
 
 	// Enable log streaming
 	c.UI.Info("")
@@ -335,7 +352,7 @@ func (c *cmd) run(args []string) int {
 				close(gracefulCh)
 			}()
 
-			gracefulTimeout := 15 * time.Second
+			gracefulTimeout := 15*time.Second + config.ServiceDrainTime
 			select {
 			case <-signalCh:
 				c.logger.Printf("[INFO] agent: Caught second signal %v. Exiting\n", sig)
@@ -355,7 +372,7 @@ func (c *cmd) run(args []string) int {
 func (c *cmd) handleReload(agent *agent.Agent, cfg *config.RuntimeConfig) (*config.RuntimeConfig, error) {
 	c.logger.Println("[INFO] agent: Reloading configuration...")
 	var errs error
-	newCfg := c.readConfig()
+	newCfg, origins := c.readConfig()
 	if newCfg == nil {
 		errs = multierror.Append(errs, fmt.Errorf("Failed to reload configs"))
 		return cfg, errs
@@ -377,6 +394,17 @@ func (c *cmd) handleReload(agent *agent.Agent, cfg *config.RuntimeConfig) (*conf
 	if err := agent.ReloadConfig(newCfg); err != nil {
 		errs = multierror.Append(fmt.Errorf(
 			"Failed to reload configs: %v", err))
+		return newCfg, errs
+	}
+	agent.SetConfigOrigins(origins)
+
+	if r := agent.ReloadReport(); r != nil {
+		if len(r.Applied) > 0 {
+			c.logger.Printf("[INFO] agent: Reloaded config fields: %s", strings.Join(r.Applied, ", "))
+		}
+		if len(r.RequiresRestart) > 0 {
+			c.logger.Printf("[WARN] agent: Changed config fields require a restart: %s", strings.Join(r.RequiresRestart, ", "))
+		}
 	}
 
 	return newCfg, errs
@@ -29,6 +29,7 @@ import (
 	caset "github.com/hashicorp/consul/command/connect/ca/set"
 	"github.com/hashicorp/consul/command/connect/envoy"
 	"github.com/hashicorp/consul/command/connect/proxy"
+	"github.com/hashicorp/consul/command/connect/redirecttraffic"
 	"github.com/hashicorp/consul/command/debug"
 	"github.com/hashicorp/consul/command/event"
 	"github.com/hashicorp/consul/command/exec"
@@ -38,7 +39,9 @@ import (
 	ixncheck "github.com/hashicorp/consul/command/intention/check"
 	ixncreate "github.com/hashicorp/consul/command/intention/create"
 	ixndelete "github.com/hashicorp/consul/command/intention/delete"
+	ixnexp "github.com/hashicorp/consul/command/intention/exp"
 	ixnget "github.com/hashicorp/consul/command/intention/get"
+	ixnimp "github.com/hashicorp/consul/command/intention/imp"
 	ixnmatch "github.com/hashicorp/consul/command/intention/match"
 	"github.com/hashicorp/consul/command/join"
 	"github.com/hashicorp/consul/command/keygen"
@@ -78,6 +81,9 @@ import (
 	"github.com/hashicorp/consul/command/validate"
 	"github.com/hashicorp/consul/command/version"
 	"github.com/hashicorp/consul/command/watch"
+	"github.com/hashicorp/consul/command/windowsservice"
+	wsinstall "github.com/hashicorp/consul/command/windowsservice/install"
+	wsuninstall "github.com/hashicorp/consul/command/windowsservice/uninstall"
 	consulversion "github.com/hashicorp/consul/version"
 
 	"github.com/mitchellh/cli"
@@ -119,6 +125,7 @@ func init() {
 	Register("connect ca set-config", func(ui cli.Ui) (cli.Command, error) { return caset.New(ui), nil })
 	Register("connect proxy", func(ui cli.Ui) (cli.Command, error) { return proxy.New(ui, MakeShutdownCh()), nil })
 	Register("connect envoy", func(ui cli.Ui) (cli.Command, error) { return envoy.New(ui), nil })
+	Register("connect redirect-traffic", func(ui cli.Ui) (cli.Command, error) { return redirecttraffic.New(ui), nil })
 	Register("debug", func(ui cli.Ui) (cli.Command, error) { return debug.New(ui, MakeShutdownCh()), nil })
 	Register("event", func(ui cli.Ui) (cli.Command, error) { return event.New(ui), nil })
 	Register("exec", func(ui cli.Ui) (cli.Command, error) { return exec.New(ui, MakeShutdownCh()), nil })
@@ -128,7 +135,9 @@ func init() {
 	Register("intention check", func(ui cli.Ui) (cli.Command, error) { return ixncheck.New(ui), nil })
 	Register("intention create", func(ui cli.Ui) (cli.Command, error) { return ixncreate.New(ui), nil })
 	Register("intention delete", func(ui cli.Ui) (cli.Command, error) { return ixndelete.New(ui), nil })
+	Register("intention export", func(ui cli.Ui) (cli.Command, error) { return ixnexp.New(ui), nil })
 	Register("intention get", func(ui cli.Ui) (cli.Command, error) { return ixnget.New(ui), nil })
+	Register("intention import", func(ui cli.Ui) (cli.Command, error) { return ixnimp.New(ui), nil })
 	Register("intention match", func(ui cli.Ui) (cli.Command, error) { return ixnmatch.New(ui), nil })
 	Register("join", func(ui cli.Ui) (cli.Command, error) { return join.New(ui), nil })
 	Register("keygen", func(ui cli.Ui) (cli.Command, error) { return keygen.New(ui), nil })
@@ -168,4 +177,7 @@ func init() {
 	Register("validate", func(ui cli.Ui) (cli.Command, error) { return validate.New(ui), nil })
 	Register("version", func(ui cli.Ui) (cli.Command, error) { return version.New(ui, verHuman), nil })
 	Register("watch", func(ui cli.Ui) (cli.Command, error) { return watch.New(ui, MakeShutdownCh()), nil })
+	Register("windows-service", func(ui cli.Ui) (cli.Command, error) { return windowsservice.New(), nil })
+	Register("windows-service install", func(ui cli.Ui) (cli.Command, error) { return wsinstall.New(ui), nil })
+	Register("windows-service uninstall", func(ui cli.Ui) (cli.Command, error) { return wsuninstall.New(ui), nil })
 }
@@ -10,6 +10,24 @@ type templateArgs struct {
 	AdminBindPort         string
 	LocalAgentClusterName string
 	Token                 string
+
+	// Tracing is the tracer configured by the proxy-defaults and
+	// service-defaults config entries. It is nil if tracing is disabled.
+	Tracing *tracingArgs
+}
+
+// tracingArgs are the template args of the tracer and the cluster of its
+// collector.
+type tracingArgs struct {
+	Driver           string
+	ClusterName      string
+	CollectorAddress string
+	CollectorPort    string
+
+	// CollectorEndpoint is only set for Zipkin and ServiceName only for
+	// Datadog.
+	CollectorEndpoint string
+	ServiceName       string
 }
 
 const bootstrapTemplate = `{
@@ -53,8 +71,39 @@ const bootstrapTemplate = `{
           }
         ]
       }
+      {{- if .Tracing }},
+      {
+        "name": "{{ .Tracing.ClusterName }}",
+        "connect_timeout": "1s",
+        "type": "STRICT_DNS",
+        "hosts": [
+          {
+            "socket_address": {
+              "address": "{{ .Tracing.CollectorAddress }}",
+              "port_value": {{ .Tracing.CollectorPort }}
+            }
+          }
+        ]
+      }
+      {{- end }}
     ]
   },
+  {{- if .Tracing }}
+  "tracing": {
+    "http": {
+      "name": "{{ .Tracing.Driver }}",
+      "config": {
+        {{- if .Tracing.CollectorEndpoint }}
+        "collector_endpoint": "{{ .Tracing.CollectorEndpoint }}",
+        {{- end }}
+        {{- if .Tracing.ServiceName }}
+        "service_name": "{{ .Tracing.ServiceName }}",
+        {{- end }}
+        "collector_cluster": "{{ .Tracing.ClusterName }}"
+      }
+    }
+  },
+  {{- end }}
   "dynamic_resources": {
     "lds_config": { "ads": {} },
     "cds_config": { "ads": {} },
@@ -235,6 +235,11 @@ func (c *cmd) templateArgs() (*templateArgs, error) {
 		return nil, fmt.Errorf("Failed to resolve admin bind address: %s", err)
 	}
 
+	tracing, err := c.tracingArgs()
+	if err != nil {
+		return nil, err
+	}
+
 	return &templateArgs{
 		ProxyCluster:          c.proxyID,
 		ProxyID:               c.proxyID,
@@ -246,9 +251,70 @@ func (c *cmd) templateArgs() (*templateArgs, error) {
 		AdminBindPort:         adminPort,
 		Token:                 httpCfg.Token,
 		LocalAgentClusterName: xds.LocalAgentClusterName,
+		Tracing:               tracing,
 	}, nil
 }
 
+// tracingArgs returns the tracer of the proxy configured by the
+// proxy-defaults config entry and the service-defaults entry of the proxied
+// service, or nil if tracing is disabled.
+func (c *cmd) tracingArgs() (*tracingArgs, error) {
+	svc, _, err := c.client.Agent().Service(c.proxyID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to look up proxy %q: %s", c.proxyID, err)
+	}
+	service := svc.Service
+	if svc.Kind == api.ServiceKindConnectProxy && svc.Proxy != nil {
+		service = svc.Proxy.DestinationServiceName
+	}
+
+	var cfg *api.TracingConfig
+	entry, _, err := c.client.ConfigEntries().Get(api.ProxyDefaults, api.ProxyConfigGlobal, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the proxy-defaults config entry: %s", err)
+	}
+	if e, ok := entry.(*api.ProxyConfigEntry); ok {
+		cfg = e.Tracing
+	}
+	entry, _, err = c.client.ConfigEntries().Get(api.ServiceDefaults, service, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the service-defaults config entry: %s", err)
+	}
+	if e, ok := entry.(*api.ServiceConfigEntry); ok && e.Tracing != nil {
+		cfg = e.Tracing
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	host, port, err := net.SplitHostPort(cfg.CollectorAddress)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid tracing collector address: %s", err)
+	}
+	args := &tracingArgs{
+		ClusterName:      xds.TracingCollectorClusterName,
+		CollectorAddress: host,
+		CollectorPort:    port,
+	}
+	switch cfg.Provider {
+	case "zipkin":
+		args.Driver = "envoy.zipkin"
+		args.CollectorEndpoint = cfg.CollectorEndpoint
+		if args.CollectorEndpoint == "" {
+			args.CollectorEndpoint = "/api/v1/spans"
+		}
+	case "datadog":
+		args.Driver = "envoy.tracers.datadog"
+		args.ServiceName = cfg.ServiceName
+		if args.ServiceName == "" {
+			args.ServiceName = service
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported tracing provider %q", cfg.Provider)
+	}
+	return args, nil
+}
+
 func (c *cmd) generateConfig() ([]byte, error) {
 	args, err := c.templateArgs()
 	if err != nil {
@@ -289,6 +355,10 @@ Usage: consul connect envoy [options]
   It can instead only generate the bootstrap.json based on the current ENV and
   arguments using -bootstrap.
 
+  The tracer of Envoy is configured from the proxy-defaults config entry and
+  the service-defaults entry of the proxied service, so the proxy has to be
+  restarted for changes to them to apply.
+
   The proxy requires service:write permissions for the service it represents.
   The token may be passed via the CLI or the CONSUL_TOKEN environment
   variable.
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/agent/xds"
+	"github.com/hashicorp/consul/api"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/require"
 )
@@ -55,10 +57,26 @@ func testSetAndResetEnv(t *testing.T, env []string) func() {
 // the logic is. We also allow generating golden files but only for cases that
 // pass the test of having their template args generated as expected.
 func TestGenerateConfig(t *testing.T) {
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	// The proxy of the "web" service the bootstrap config is generated for.
+	require.NoError(t, client.Agent().ServiceRegister(&api.AgentServiceRegistration{
+		Kind: api.ServiceKindConnectProxy,
+		ID:   "test-proxy",
+		Name: "web-sidecar-proxy",
+		Port: 21000,
+		Proxy: &api.AgentServiceConnectProxyConfig{
+			DestinationServiceName: "web",
+		},
+	}))
+
 	cases := []struct {
 		Name     string
 		Flags    []string
 		Env      []string
+		Entries  []api.ConfigEntry
 		WantArgs templateArgs
 		WantErr  string
 	}{
@@ -119,6 +137,81 @@ func TestGenerateConfig(t *testing.T) {
 				LocalAgentClusterName: xds.LocalAgentClusterName,
 			},
 		},
+		{
+			Name:  "tracing-zipkin",
+			Flags: []string{"-proxy-id", "test-proxy"},
+			Env:   []string{},
+			Entries: []api.ConfigEntry{
+				&api.ProxyConfigEntry{
+					Kind: api.ProxyDefaults,
+					Name: api.ProxyConfigGlobal,
+					Tracing: &api.TracingConfig{
+						Enabled:          true,
+						Provider:         "zipkin",
+						CollectorAddress: "zipkin.service.consul:9411",
+					},
+				},
+			},
+			WantArgs: templateArgs{
+				ProxyCluster:          "test-proxy",
+				ProxyID:               "test-proxy",
+				AgentAddress:          "127.0.0.1",
+				AgentPort:             "8502",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+				Tracing: &tracingArgs{
+					Driver:            "envoy.zipkin",
+					ClusterName:       xds.TracingCollectorClusterName,
+					CollectorAddress:  "zipkin.service.consul",
+					CollectorPort:     "9411",
+					CollectorEndpoint: "/api/v1/spans",
+				},
+			},
+		},
+		{
+			// The service-defaults entry of the proxied service takes
+			// precedence.
+			Name:  "tracing-datadog",
+			Flags: []string{"-proxy-id", "test-proxy"},
+			Env:   []string{},
+			Entries: []api.ConfigEntry{
+				&api.ProxyConfigEntry{
+					Kind: api.ProxyDefaults,
+					Name: api.ProxyConfigGlobal,
+					Tracing: &api.TracingConfig{
+						Enabled:          true,
+						Provider:         "zipkin",
+						CollectorAddress: "zipkin.service.consul:9411",
+					},
+				},
+				&api.ServiceConfigEntry{
+					Kind: api.ServiceDefaults,
+					Name: "web",
+					Tracing: &api.TracingConfig{
+						Enabled:          true,
+						Provider:         "datadog",
+						CollectorAddress: "127.0.0.1:8126",
+					},
+				},
+			},
+			WantArgs: templateArgs{
+				ProxyCluster:          "test-proxy",
+				ProxyID:               "test-proxy",
+				AgentAddress:          "127.0.0.1",
+				AgentPort:             "8502",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+				Tracing: &tracingArgs{
+					Driver:           "envoy.tracers.datadog",
+					ClusterName:      xds.TracingCollectorClusterName,
+					CollectorAddress: "127.0.0.1",
+					CollectorPort:    "8126",
+					ServiceName:      "web",
+				},
+			},
+		},
 		// TODO(banks): all the flags/env manipulation cases
 	}
 
@@ -131,8 +224,14 @@ func TestGenerateConfig(t *testing.T) {
 
 			defer testSetAndResetEnv(t, tc.Env)()
 
+			for _, entry := range tc.Entries {
+				_, err := client.ConfigEntries().Set(entry, nil)
+				require.NoError(err)
+				defer client.ConfigEntries().Delete(entry.GetKind(), entry.GetName(), nil)
+			}
+
 			// Run the command
-			args := append([]string{"-bootstrap"}, tc.Flags...)
+			args := append([]string{"-bootstrap", "-http-addr", a.HTTPAddr()}, tc.Flags...)
 			code := c.Run(args)
 			if tc.WantErr == "" {
 				require.Equal(0, code, ui.ErrorWriter.String())
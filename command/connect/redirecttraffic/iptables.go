@@ -0,0 +1,65 @@
+package redirecttraffic
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// inboundChain holds the rules for traffic arriving for the service.
+	inboundChain = "CONSUL_PROXY_INBOUND"
+
+	// inboundRedirectChain redirects inbound traffic to the proxy's public
+	// listener.
+	inboundRedirectChain = "CONSUL_PROXY_IN_REDIRECT"
+
+	// outputChain holds the rules for traffic sent by the service.
+	outputChain = "CONSUL_PROXY_OUTPUT"
+
+	// redirectChain redirects outbound traffic to the proxy's outbound
+	// listener.
+	redirectChain = "CONSUL_PROXY_REDIRECT"
+)
+
+// iptablesRules returns the arguments of the iptables commands that redirect
+// the traffic as described by cfg. Traffic of the proxy itself, which runs as
+// proxyUID, is never redirected so it can reach the upstreams.
+func iptablesRules(cfg *api.AgentTrafficRedirect, proxyUID string) [][]string {
+	var rules [][]string
+	nat := func(args ...string) {
+		rules = append(rules, append([]string{"-t", "nat"}, args...))
+	}
+
+	for _, chain := range []string{inboundChain, inboundRedirectChain, outputChain, redirectChain} {
+		nat("-N", chain)
+	}
+
+	// Outbound traffic goes to the proxy's outbound listener unless it comes
+	// from the proxy, is local or is excluded.
+	nat("-A", redirectChain, "-p", "tcp", "-j", "REDIRECT", "--to-port", strconv.Itoa(cfg.OutboundPort))
+	nat("-A", "OUTPUT", "-p", "tcp", "-j", outputChain)
+	nat("-A", outputChain, "-m", "owner", "--uid-owner", proxyUID, "-j", "RETURN")
+	nat("-A", outputChain, "-d", "127.0.0.1/32", "-j", "RETURN")
+	for _, port := range cfg.ExcludeOutboundPorts {
+		nat("-A", outputChain, "-p", "tcp", "--dport", strconv.Itoa(port), "-j", "RETURN")
+	}
+	for _, cidr := range cfg.ExcludeOutboundCIDRs {
+		nat("-A", outputChain, "-d", cidr, "-j", "RETURN")
+	}
+	for _, uid := range cfg.ExcludeUIDs {
+		nat("-A", outputChain, "-m", "owner", "--uid-owner", uid, "-j", "RETURN")
+	}
+	nat("-A", outputChain, "-j", redirectChain)
+
+	// Inbound traffic goes to the proxy's public listener unless it's for an
+	// excluded port.
+	nat("-A", inboundRedirectChain, "-p", "tcp", "-j", "REDIRECT", "--to-port", strconv.Itoa(cfg.InboundPort))
+	nat("-A", "PREROUTING", "-p", "tcp", "-j", inboundChain)
+	for _, port := range cfg.ExcludeInboundPorts {
+		nat("-A", inboundChain, "-p", "tcp", "--dport", strconv.Itoa(port), "-j", "RETURN")
+	}
+	nat("-A", inboundChain, "-p", "tcp", "-j", inboundRedirectChain)
+
+	return rules
+}
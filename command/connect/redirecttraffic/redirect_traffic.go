@@ -0,0 +1,183 @@
+package redirecttraffic
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	proxyID              string
+	proxyUID             string
+	excludeInboundPorts  flags.AppendSliceValue
+	excludeOutboundPorts flags.AppendSliceValue
+	excludeOutboundCIDRs flags.AppendSliceValue
+	excludeUIDs          flags.AppendSliceValue
+	dryRun               bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+
+	c.flags.StringVar(&c.proxyID, "proxy-id", "",
+		"The service ID of the local proxy that the traffic is redirected to.")
+	c.flags.StringVar(&c.proxyUID, "proxy-uid", "",
+		"The user ID the proxy runs as. Its own traffic is never redirected.")
+	c.flags.Var(&c.excludeInboundPorts, "exclude-inbound-port",
+		"An inbound port to exclude from the redirection. This is added to the "+
+			"ports of the proxy registration and may be specified multiple times.")
+	c.flags.Var(&c.excludeOutboundPorts, "exclude-outbound-port",
+		"An outbound port to exclude from the redirection. This is added to the "+
+			"ports of the proxy registration and may be specified multiple times.")
+	c.flags.Var(&c.excludeOutboundCIDRs, "exclude-outbound-cidr",
+		"An outbound IP or CIDR to exclude from the redirection. This is added to "+
+			"the CIDRs of the proxy registration and may be specified multiple times.")
+	c.flags.Var(&c.excludeUIDs, "exclude-uid",
+		"A user ID whose outbound traffic is excluded from the redirection. This is "+
+			"added to the user IDs of the proxy registration and may be specified "+
+			"multiple times.")
+	c.flags.BoolVar(&c.dryRun, "dry-run", false,
+		"Print the iptables commands instead of running them.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	if c.proxyID == "" {
+		c.UI.Error("-proxy-id is required")
+		return 1
+	}
+	if c.proxyUID == "" {
+		c.UI.Error("-proxy-uid is required")
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	cfg, _, err := client.Agent().ConnectRedirectTraffic(c.proxyID, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching traffic redirection for proxy %q: %s", c.proxyID, err))
+		return 1
+	}
+
+	if err := c.mergeFlags(cfg); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	for _, rule := range iptablesRules(cfg, c.proxyUID) {
+		if c.dryRun {
+			c.UI.Output("iptables " + strings.Join(rule, " "))
+			continue
+		}
+
+		out, err := exec.Command("iptables", rule...).CombinedOutput()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error running iptables %s: %s\n%s",
+				strings.Join(rule, " "), err, out))
+			return 1
+		}
+	}
+
+	if !c.dryRun {
+		c.UI.Output(fmt.Sprintf("Redirected the traffic to proxy %q", c.proxyID))
+	}
+	return 0
+}
+
+// mergeFlags adds the exclusions given as flags to the ones of the proxy
+// registration.
+func (c *cmd) mergeFlags(cfg *api.AgentTrafficRedirect) error {
+	for _, raw := range c.excludeInboundPorts {
+		port, err := parsePort(raw)
+		if err != nil {
+			return fmt.Errorf("Invalid -exclude-inbound-port: %s", err)
+		}
+		cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, port)
+	}
+	for _, raw := range c.excludeOutboundPorts {
+		port, err := parsePort(raw)
+		if err != nil {
+			return fmt.Errorf("Invalid -exclude-outbound-port: %s", err)
+		}
+		cfg.ExcludeOutboundPorts = append(cfg.ExcludeOutboundPorts, port)
+	}
+	for _, cidr := range c.excludeOutboundCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil && net.ParseIP(cidr) == nil {
+			return fmt.Errorf("Invalid -exclude-outbound-cidr: %q is not a valid IP or CIDR", cidr)
+		}
+		cfg.ExcludeOutboundCIDRs = append(cfg.ExcludeOutboundCIDRs, cidr)
+	}
+	cfg.ExcludeUIDs = append(cfg.ExcludeUIDs, c.excludeUIDs...)
+	return nil
+}
+
+func parsePort(raw string) (int, error) {
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a valid port", raw)
+	}
+	return port, nil
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Redirect the traffic of a service to its transparent proxy"
+const help = `
+Usage: consul connect redirect-traffic [options]
+
+  Sets up iptables rules that redirect the inbound and outbound traffic of a
+  service to its local Connect proxy, so the service doesn't need to be
+  configured with the proxy's upstream listeners. The ports and exclusions
+  are read from the proxy registration and may be extended with flags.
+
+  This must run as root in the network namespace of the service, for example
+  in an init container.
+
+    $ consul connect redirect-traffic -proxy-id web-sidecar-proxy -proxy-uid 1234
+
+  To print the iptables commands without running them:
+
+    $ consul connect redirect-traffic -proxy-id web-sidecar-proxy \
+        -proxy-uid 1234 -dry-run
+`
@@ -0,0 +1,135 @@
+package redirecttraffic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectTrafficCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestRedirectTrafficCommand_Validation(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no proxy ID": {
+			[]string{"-proxy-uid=1234"},
+			"-proxy-id is required",
+		},
+		"no proxy UID": {
+			[]string{"-proxy-id=web-proxy"},
+			"-proxy-uid is required",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui)
+
+			require.Equal(t, 1, c.Run(tc.args))
+			require.Contains(t, ui.ErrorWriter.String(), tc.output)
+		})
+	}
+}
+
+func TestRedirectTrafficCommand_DryRun(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	require.NoError(a.Client().Agent().ServiceRegister(&api.AgentServiceRegistration{
+		Kind: api.ServiceKindConnectProxy,
+		ID:   "web-proxy",
+		Name: "web-proxy",
+		Port: 21000,
+		Proxy: &api.AgentServiceConnectProxyConfig{
+			DestinationServiceName: "web",
+			TransparentProxy: &api.TransparentProxyConfig{
+				ExcludeOutboundPorts: []int{53},
+			},
+		},
+	}))
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-proxy-id=web-proxy",
+		"-proxy-uid=1234",
+		"-exclude-inbound-port=9090",
+		"-exclude-outbound-cidr=10.0.0.0/8",
+		"-dry-run",
+	}
+	require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+
+	output := ui.OutputWriter.String()
+	for _, rule := range []string{
+		"iptables -t nat -A CONSUL_PROXY_REDIRECT -p tcp -j REDIRECT --to-port 15001",
+		"iptables -t nat -A CONSUL_PROXY_OUTPUT -m owner --uid-owner 1234 -j RETURN",
+		"iptables -t nat -A CONSUL_PROXY_OUTPUT -p tcp --dport 53 -j RETURN",
+		"iptables -t nat -A CONSUL_PROXY_OUTPUT -d 10.0.0.0/8 -j RETURN",
+		"iptables -t nat -A CONSUL_PROXY_IN_REDIRECT -p tcp -j REDIRECT --to-port 21000",
+		"iptables -t nat -A CONSUL_PROXY_INBOUND -p tcp --dport 9090 -j RETURN",
+	} {
+		require.Contains(output, rule+"\n")
+	}
+
+	// Unknown proxies are an error.
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-proxy-id=nope",
+		"-proxy-uid=1234",
+		"-dry-run",
+	}
+	require.Equal(1, c.Run(args))
+	require.Contains(ui.ErrorWriter.String(), "unknown proxy service ID")
+}
+
+func TestIptablesRules_Order(t *testing.T) {
+	t.Parallel()
+
+	rules := iptablesRules(&api.AgentTrafficRedirect{
+		InboundPort:         21000,
+		OutboundPort:        15001,
+		ExcludeInboundPorts: []int{9090},
+		ExcludeUIDs:         []string{"5678"},
+	}, "1234")
+
+	index := func(rule string) int {
+		for i, r := range rules {
+			if strings.Join(r, " ") == rule {
+				return i
+			}
+		}
+		t.Fatalf("missing rule %q", rule)
+		return -1
+	}
+
+	// Exclusions must come before the jump to the redirect chains.
+	require.True(t, index("-t nat -A CONSUL_PROXY_OUTPUT -m owner --uid-owner 5678 -j RETURN") <
+		index("-t nat -A CONSUL_PROXY_OUTPUT -j CONSUL_PROXY_REDIRECT"))
+	require.True(t, index("-t nat -A CONSUL_PROXY_INBOUND -p tcp --dport 9090 -j RETURN") <
+		index("-t nat -A CONSUL_PROXY_INBOUND -p tcp -j CONSUL_PROXY_IN_REDIRECT"))
+
+	// Chains are created first.
+	require.Equal(t, []string{"-t", "nat", "-N", "CONSUL_PROXY_INBOUND"}, rules[0])
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
@@ -149,10 +150,16 @@ func (c *cmd) ixnsFromArgs(args []string) ([]*api.Intention, error) {
 		return nil, fmt.Errorf("Must specify two arguments: source and destination")
 	}
 
+	// Sources given as a SPIFFE ID are workloads of a federated trust domain.
+	sourceType := api.IntentionSourceConsul
+	if strings.HasPrefix(args[0], "spiffe://") {
+		sourceType = api.IntentionSourceSPIFFE
+	}
+
 	return []*api.Intention{&api.Intention{
 		SourceName:      args[0],
 		DestinationName: args[1],
-		SourceType:      api.IntentionSourceConsul,
+		SourceType:      sourceType,
 		Action:          c.ixnAction(),
 		Meta:            c.flagMeta,
 	}}, nil
@@ -218,6 +225,11 @@ Usage: consul intention create [options] -file FILE...
   An "allow" intention is created by default (whitelist). To create a
   "deny" intention, the "-deny" flag should be specified.
 
+  The source may also be the SPIFFE ID of a workload in a federated trust
+  domain configured in the CA configuration:
+
+      $ consul intention create spiffe://spire.example.org/ns/prod/sa/web db
+
   If a conflicting intention is found, creation will fail. To replace any
   conflicting intentions, specify the "-replace" flag. This will replace any
   conflicting intentions with the intention specified in this command.
@@ -0,0 +1,94 @@
+package exp
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/command/intention/impexp"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if args := c.flags.Args(); len(args) != 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	ixns, _, err := client.Connect().Intentions(&api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+
+	exported := make([]*impexp.Intention, len(ixns))
+	for i, ixn := range ixns {
+		exported[i] = impexp.ToIntention(ixn)
+	}
+
+	marshaled, err := json.MarshalIndent(exported, "", "\t")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error exporting intentions: %s", err))
+		return 1
+	}
+
+	c.UI.Output(string(marshaled))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Export all intentions as JSON."
+const help = `
+Usage: consul intention export [options]
+
+  Retrieves all intentions and writes a JSON representation to stdout. IDs,
+  timestamps and indexes are left out so the output can be used with
+  "consul intention import" to copy intentions to another Consul cluster.
+
+      $ consul intention export > intentions.json
+
+  For a full list of options and examples, please see the Consul documentation.
+`
@@ -0,0 +1,56 @@
+package exp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/intention/impexp"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntentionExportCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestIntentionExportCommand(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	_, _, err := client.Connect().IntentionCreate(&api.Intention{
+		SourceName:      "web",
+		DestinationName: "db",
+		Action:          api.IntentionActionAllow,
+		Meta:            map[string]string{"owner": "team-db"},
+	}, nil)
+	require.NoError(err)
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	code := c.Run([]string{"-http-addr=" + a.HTTPAddr()})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	var exported []*impexp.Intention
+	require.NoError(json.Unmarshal(ui.OutputWriter.Bytes(), &exported))
+	require.Equal([]*impexp.Intention{
+		{
+			SourceNS:        "default",
+			SourceName:      "web",
+			DestinationNS:   "default",
+			DestinationName: "db",
+			SourceType:      api.IntentionSourceConsul,
+			Action:          api.IntentionActionAllow,
+			Meta:            map[string]string{"owner": "team-db"},
+		},
+	}, exported)
+}
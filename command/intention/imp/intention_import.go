@@ -0,0 +1,269 @@
+package imp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/command/intention/impexp"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	flagDryRun bool
+	flagPrune  bool
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.flagDryRun, "dry-run", false,
+		"Only print the changes the import would make.")
+	c.flags.BoolVar(&c.flagPrune, "prune", false,
+		"Delete existing intentions that are not part of the imported data.")
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+// change is a single change the import makes to the existing intentions.
+type change struct {
+	verb api.IntentionOp
+	ixn  api.Intention
+}
+
+func (c *change) String() string {
+	switch c.verb {
+	case api.IntentionOpCreate:
+		return "Create: " + c.ixn.String()
+	case api.IntentionOpUpdate:
+		return "Update: " + c.ixn.String()
+	default:
+		return "Delete: " + c.ixn.String()
+	}
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check for arg validation
+	args = c.flags.Args()
+	data, err := c.dataFromArgs(args)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
+
+	var imported []*impexp.Intention
+	if err := json.Unmarshal([]byte(data), &imported); err != nil {
+		c.UI.Error(fmt.Sprintf("Cannot unmarshal data: %s", err))
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	existing, _, err := client.Connect().Intentions(nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+
+	changes, err := c.diff(existing, imported)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
+	if len(changes) == 0 {
+		c.UI.Output("No changes.")
+		return 0
+	}
+
+	for _, change := range changes {
+		c.UI.Output(change.String())
+	}
+	if c.flagDryRun {
+		c.UI.Output("Dry run, no changes were applied.")
+		return 0
+	}
+
+	// Apply all changes in a single transaction so a failure doesn't leave
+	// a partially imported set of intentions behind.
+	ops := make(api.TxnOps, 0, len(changes))
+	for _, change := range changes {
+		ops = append(ops, &api.TxnOp{
+			Intention: &api.IntentionTxnOp{
+				Verb:      change.verb,
+				Intention: change.ixn,
+			},
+		})
+	}
+	ok, resp, _, err := client.Txn().Txn(ops, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error applying intentions: %s", err))
+		return 1
+	}
+	if !ok {
+		for _, txnErr := range resp.Errors {
+			c.UI.Error(fmt.Sprintf("Error applying %q: %s",
+				changes[txnErr.OpIndex].ixn.String(), txnErr.What))
+		}
+		c.UI.Error("No changes were applied.")
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Applied %d changes.", len(changes)))
+	return 0
+}
+
+// diff returns the changes needed to turn the existing intentions into the
+// imported ones. Intentions are matched by their source and destination.
+// Existing intentions not being imported are only deleted with -prune.
+func (c *cmd) diff(existing []*api.Intention, imported []*impexp.Intention) ([]*change, error) {
+	current := make(map[string]*api.Intention)
+	for _, ixn := range existing {
+		current[impexp.ToIntention(ixn).Key()] = ixn
+	}
+
+	var changes []*change
+	seen := make(map[string]bool)
+	for _, ixn := range imported {
+		ixn.Normalize()
+		key := ixn.Key()
+		if seen[key] {
+			return nil, fmt.Errorf("Intention %s was specified more than once", key)
+		}
+		seen[key] = true
+
+		old, ok := current[key]
+		if !ok {
+			changes = append(changes, &change{api.IntentionOpCreate, ixn.APIIntention("")})
+			continue
+		}
+
+		oldIxn := impexp.ToIntention(old)
+		oldIxn.Normalize()
+		if !oldIxn.Equal(ixn) {
+			changes = append(changes, &change{api.IntentionOpUpdate, ixn.APIIntention(old.ID)})
+		}
+	}
+
+	if c.flagPrune {
+		var keys []string
+		for key := range current {
+			if !seen[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			changes = append(changes, &change{api.IntentionOpDelete, *current[key]})
+		}
+	}
+	return changes, nil
+}
+
+func (c *cmd) dataFromArgs(args []string) (string, error) {
+	var stdin io.Reader = os.Stdin
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	switch len(args) {
+	case 0:
+		return "", errors.New("Missing DATA argument")
+	case 1:
+	default:
+		return "", fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
+	}
+
+	data := args[0]
+
+	if len(data) == 0 {
+		return "", errors.New("Empty DATA argument")
+	}
+
+	switch data[0] {
+	case '@':
+		data, err := ioutil.ReadFile(data[1:])
+		if err != nil {
+			return "", fmt.Errorf("Failed to read file: %s", err)
+		}
+		return string(data), nil
+	case '-':
+		if len(data) > 1 {
+			return data, nil
+		}
+		var b bytes.Buffer
+		if _, err := io.Copy(&b, stdin); err != nil {
+			return "", fmt.Errorf("Failed to read stdin: %s", err)
+		}
+		return b.String(), nil
+	default:
+		return data, nil
+	}
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Import intentions from JSON."
+const help = `
+Usage: consul intention import [options] [DATA]
+
+  Imports intentions from the JSON representation generated by the
+  "consul intention export" command. Intentions are matched with the existing
+  ones by their source and destination. Missing intentions are created and
+  changed ones are updated. All changes are applied in a single transaction,
+  so either all or none of them are made.
+
+  The data can be read from a file by prefixing the filename with the "@"
+  symbol. For example:
+
+      $ consul intention import @intentions.json
+
+  Or it can be read from stdin using the "-" symbol:
+
+      $ consul intention export | consul intention import -http-addr=other:8500 -
+
+  To only print the changes without applying them, use "-dry-run". To also
+  delete existing intentions that are missing from the data, use "-prune":
+
+      $ consul intention import -prune -dry-run @intentions.json
+
+  For a full list of options and examples, please see the Consul documentation.
+`
@@ -0,0 +1,91 @@
+package imp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntentionImportCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestIntentionImportCommand(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	// Set up an intention that is changed and one that is pruned.
+	for _, ixn := range []*api.Intention{
+		{SourceName: "web", DestinationName: "db", Action: api.IntentionActionAllow},
+		{SourceName: "old", DestinationName: "db", Action: api.IntentionActionAllow},
+	} {
+		_, _, err := client.Connect().IntentionCreate(ixn, nil)
+		require.NoError(err)
+	}
+
+	const data = `[
+		{
+			"SourceName": "web",
+			"DestinationName": "db",
+			"Action": "deny"
+		},
+		{
+			"SourceName": "api",
+			"DestinationName": "db",
+			"Action": "allow",
+			"Meta": {"owner": "team-api"}
+		}
+	]`
+
+	run := func(args ...string) (int, *cli.MockUi) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		c.testStdin = strings.NewReader(data)
+		code := c.Run(append([]string{"-http-addr=" + a.HTTPAddr()}, args...))
+		return code, ui
+	}
+
+	// A dry run only prints the changes.
+	code, ui := run("-prune", "-dry-run", "-")
+	require.Equal(0, code, ui.ErrorWriter.String())
+	out := ui.OutputWriter.String()
+	require.Contains(out, "Update: web => db (deny)")
+	require.Contains(out, "Create: api => db (allow)")
+	require.Contains(out, "Delete: old => db (allow)")
+	require.Contains(out, "Dry run")
+
+	ixns, _, err := client.Connect().Intentions(nil)
+	require.NoError(err)
+	require.Len(ixns, 2)
+
+	// Apply the changes.
+	code, ui = run("-prune", "-")
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Applied 3 changes.")
+
+	ixns, _, err = client.Connect().Intentions(nil)
+	require.NoError(err)
+	require.Len(ixns, 2)
+	actual := make(map[string]*api.Intention)
+	for _, ixn := range ixns {
+		actual[ixn.SourceName] = ixn
+	}
+	require.Equal(api.IntentionActionDeny, actual["web"].Action)
+	require.Equal(map[string]string{"owner": "team-api"}, actual["api"].Meta)
+
+	// Importing the same data again changes nothing.
+	code, ui = run("-prune", "-")
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "No changes.")
+}
@@ -0,0 +1,79 @@
+package impexp
+
+import (
+	"reflect"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Intention is the portable form of an intention used by "consul intention
+// export" and "consul intention import". Fields managed by Consul like the ID,
+// the timestamps and the indexes are left out so intentions can be moved
+// between clusters.
+type Intention struct {
+	Description     string `json:",omitempty"`
+	SourceNS        string
+	SourceName      string
+	DestinationNS   string
+	DestinationName string
+	SourceType      api.IntentionSourceType
+	Action          api.IntentionAction
+	Meta            map[string]string `json:",omitempty"`
+}
+
+// ToIntention returns the portable form of the given intention.
+func ToIntention(ixn *api.Intention) *Intention {
+	return &Intention{
+		Description:     ixn.Description,
+		SourceNS:        ixn.SourceNS,
+		SourceName:      ixn.SourceName,
+		DestinationNS:   ixn.DestinationNS,
+		DestinationName: ixn.DestinationName,
+		SourceType:      ixn.SourceType,
+		Action:          ixn.Action,
+		Meta:            ixn.Meta,
+	}
+}
+
+// Normalize sets the defaults Consul applies when an intention is written so
+// imported and existing intentions can be compared.
+func (i *Intention) Normalize() {
+	if i.SourceNS == "" {
+		i.SourceNS = api.IntentionDefaultNamespace
+	}
+	if i.DestinationNS == "" {
+		i.DestinationNS = api.IntentionDefaultNamespace
+	}
+	if i.SourceType == "" {
+		i.SourceType = api.IntentionSourceConsul
+	}
+	if len(i.Meta) == 0 {
+		i.Meta = nil
+	}
+}
+
+// Key identifies the intention by its source and destination. Consul allows
+// only one intention per pair.
+func (i *Intention) Key() string {
+	return i.SourceNS + "/" + i.SourceName + " => " + i.DestinationNS + "/" + i.DestinationName
+}
+
+// Equal returns whether both intentions have the same settings.
+func (i *Intention) Equal(other *Intention) bool {
+	return reflect.DeepEqual(i, other)
+}
+
+// APIIntention returns the intention in the API format with the given ID.
+func (i *Intention) APIIntention(id string) api.Intention {
+	return api.Intention{
+		ID:              id,
+		Description:     i.Description,
+		SourceNS:        i.SourceNS,
+		SourceName:      i.SourceName,
+		DestinationNS:   i.DestinationNS,
+		DestinationName: i.DestinationName,
+		SourceType:      i.SourceType,
+		Action:          i.Action,
+		Meta:            i.Meta,
+	}
+}
@@ -44,5 +44,10 @@ Usage: consul intention <subcommand> [options] [args]
 
       $ consul intention match db
 
+  Copy all intentions to another cluster:
+
+      $ consul intention export > intentions.json
+      $ consul intention import -http-addr=https://other:8501 @intentions.json
+
   For more examples, ask for subcommand help or view the documentation.
 `
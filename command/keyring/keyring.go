@@ -3,6 +3,8 @@ package keyring
 import (
 	"flag"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/agent"
 	consulapi "github.com/hashicorp/consul/api"
@@ -27,6 +29,9 @@ type cmd struct {
 	useKey     string
 	removeKey  string
 	listKeys   bool
+	detailed   bool
+	waitForKey string
+	timeout    time.Duration
 	relay      int
 }
 
@@ -44,6 +49,17 @@ func (c *cmd) init() {
 			"performed on keys which are not currently the primary key.")
 	c.flags.BoolVar(&c.listKeys, "list", false,
 		"List all keys currently in use within the cluster.")
+	c.flags.BoolVar(&c.detailed, "detailed", false,
+		"When listing keys, also list the keys installed on each node and the "+
+			"primary key it is using.")
+	c.flags.StringVar(&c.waitForKey, "wait-for-key", "",
+		"Wait until the given key is installed on all members in the cluster, "+
+			"then list the installed keys. This makes it possible to script a key "+
+			"rotation by only changing the primary key once the new key has "+
+			"propagated.")
+	c.flags.DurationVar(&c.timeout, "wait-timeout", 0,
+		"How long to wait for the key given with -wait-for-key before failing. "+
+			"Defaults to 5 minutes.")
 	c.flags.IntVar(&c.relay, "relay-factor", 0,
 		"Setting this to a non-zero value will cause nodes to relay their response "+
 			"to the operation through this many randomly-chosen other nodes in the "+
@@ -66,6 +82,11 @@ func (c *cmd) Run(args []string) int {
 		Ui:           c.UI,
 	}
 
+	// Waiting for a key lists the keys once it has propagated
+	if c.waitForKey != "" {
+		c.listKeys = true
+	}
+
 	// Only accept a single argument
 	found := c.listKeys
 	for _, arg := range []string{c.installKey, c.useKey, c.removeKey} {
@@ -97,8 +118,14 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	if c.listKeys {
-		c.UI.Info("Gathering installed encryption keys...")
-		responses, err := client.Operator().KeyringList(&consulapi.QueryOptions{RelayFactor: relayFactor})
+		if c.waitForKey != "" {
+			c.UI.Info("Waiting for the encryption key to be installed on all nodes...")
+		} else {
+			c.UI.Info("Gathering installed encryption keys...")
+		}
+		opts := &consulapi.KeyringListOptions{Detailed: c.detailed, WaitForKey: c.waitForKey}
+		q := &consulapi.QueryOptions{RelayFactor: relayFactor, WaitTime: c.timeout}
+		responses, err := client.Operator().KeyringListWithOptions(opts, q)
 		if err != nil {
 			c.UI.Error(fmt.Sprintf("error: %s", err))
 			return 1
@@ -162,6 +189,23 @@ func (c *cmd) handleList(responses []*consulapi.KeyringResponse) {
 		for key, num := range response.Keys {
 			c.UI.Output(fmt.Sprintf("  %s [%d/%d]", key, num, response.NumNodes))
 		}
+
+		if !c.detailed {
+			continue
+		}
+		c.UI.Output("  Primary keys:")
+		for key, num := range response.PrimaryKeys {
+			c.UI.Output(fmt.Sprintf("    %s [%d/%d]", key, num, response.NumNodes))
+		}
+		c.UI.Output("  Nodes:")
+		for _, node := range response.Nodes {
+			if node.Error != "" {
+				c.UI.Output(fmt.Sprintf("    %s: error: %s", node.Node, node.Error))
+				continue
+			}
+			c.UI.Output(fmt.Sprintf("    %s: primary %s, installed %s",
+				node.Node, node.PrimaryKey, strings.Join(node.Keys, ", ")))
+		}
 	}
 }
 
@@ -66,6 +66,43 @@ func TestKeyringCommand(t *testing.T) {
 	}
 }
 
+func TestKeyringCommand_waitForKeyDetailed(t *testing.T) {
+	t.Parallel()
+	key1 := "HS5lJ+XuTlYKWaeGYyG+/A=="
+	key2 := "kZyFABeAmc64UMTrm9XuKA=="
+
+	a1 := agent.NewTestAgent(t, t.Name(), `
+		encrypt = "`+key1+`"
+	`)
+	defer a1.Shutdown()
+
+	// Waiting for a key that isn't installed fails
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{"-wait-for-key=" + key2, "-wait-timeout=10ms", "-http-addr=" + a1.HTTPAddr()}
+	code := c.Run(args)
+	if code != 1 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	// Once installed, the wait succeeds and lists the keyring of each node
+	installKey(t, a1.HTTPAddr(), key2)
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{"-wait-for-key=" + key2, "-detailed", "-http-addr=" + a1.HTTPAddr()}
+	code = c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Primary keys:\n    "+key1+" [1/1]") {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !strings.Contains(out, a1.Config.NodeName+": primary "+key1) {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestKeyringCommand_help(t *testing.T) {
 	t.Parallel()
 	ui := cli.NewMockUi()
@@ -3,6 +3,7 @@ package get
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -26,6 +27,7 @@ type cmd struct {
 	help         string
 	base64encode bool
 	detailed     bool
+	format       string
 	keys         bool
 	recurse      bool
 	separator    string
@@ -39,6 +41,10 @@ func (c *cmd) init() {
 		"Provide additional metadata about the key in addition to the value such "+
 			"as the ModifyIndex and any flags that may have been set on the key. "+
 			"The default value is false.")
+	c.flags.StringVar(&c.format, "format", "pretty",
+		"Output format. Either \"pretty\" or \"json\". The JSON output contains "+
+			"all metadata of the keys, such as the ModifyIndex and the Session, "+
+			"along with their base64 encoded values. The default value is \"pretty\".")
 	c.flags.BoolVar(&c.keys, "keys", false,
 		"List keys which start with the given prefix, but not their values. "+
 			"This is especially useful if you only need the key names themselves. "+
@@ -90,6 +96,11 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.format != "pretty" && c.format != "json" {
+		c.UI.Error(fmt.Sprintf("Error! Invalid format %q, must be \"pretty\" or \"json\"", c.format))
+		return 1
+	}
+
 	// Create and test the HTTP client
 	client, err := c.http.APIClient()
 	if err != nil {
@@ -107,6 +118,13 @@ func (c *cmd) Run(args []string) int {
 			return 1
 		}
 
+		if c.format == "json" {
+			if keys == nil {
+				keys = []string{}
+			}
+			return c.printJSON(keys)
+		}
+
 		for _, k := range keys {
 			c.UI.Info(string(k))
 		}
@@ -121,6 +139,13 @@ func (c *cmd) Run(args []string) int {
 			return 1
 		}
 
+		if c.format == "json" {
+			if pairs == nil {
+				pairs = api.KVPairs{}
+			}
+			return c.printJSON(pairs)
+		}
+
 		for i, pair := range pairs {
 			if c.detailed {
 				var b bytes.Buffer
@@ -158,6 +183,10 @@ func (c *cmd) Run(args []string) int {
 			return 1
 		}
 
+		if c.format == "json" {
+			return c.printJSON(pair)
+		}
+
 		if c.detailed {
 			var b bytes.Buffer
 			if err := prettyKVPair(&b, pair, c.base64encode); err != nil {
@@ -186,6 +215,17 @@ func (c *cmd) Help() string {
 	return c.help
 }
 
+// printJSON prints the keys or pairs as JSON and returns the exit code.
+func (c *cmd) printJSON(v interface{}) int {
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error rendering JSON: %s", err))
+		return 1
+	}
+	c.UI.Info(string(b))
+	return 0
+}
+
 func prettyKVPair(w io.Writer, pair *api.KVPair, base64EncodeValue bool) error {
 	tw := tabwriter.NewWriter(w, 0, 2, 6, ' ', 0)
 	fmt.Fprintf(tw, "CreateIndex\t%d\n", pair.CreateIndex)
@@ -236,5 +276,11 @@ Usage: consul kv get [options] [KEY_OR_PREFIX]
 
       $ consul kv get -keys foo
 
+  Scripts can read the metadata and the base64 encoded value of keys as JSON
+  with "-format=json", for example to pass the ModifyIndex to a Check-And-Set
+  operation:
+
+      $ consul kv get -format=json foo
+
   For a full list of options and examples, please see the Consul documentation.
 `
@@ -2,6 +2,7 @@ package get
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -34,6 +35,10 @@ func TestKVGetCommand_Validation(t *testing.T) {
 			[]string{"foo", "bar", "baz"},
 			"Too many arguments",
 		},
+		"invalid format": {
+			[]string{"-format", "yaml", "foo"},
+			`Invalid format "yaml"`,
+		},
 	}
 
 	for name, tc := range cases {
@@ -218,6 +223,64 @@ func TestKVGetCommand_Detailed(t *testing.T) {
 	}
 }
 
+func TestKVGetCommand_JSON(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, pair := range []*api.KVPair{
+		{Key: "foo/a", Value: []byte{0, 1, 0xff}, Flags: 42},
+		{Key: "foo/b", Value: []byte("b")},
+	} {
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+	expect, _, err := client.KV().Get("foo/a", nil)
+	if err != nil {
+		t.Fatalf("err: %#v", err)
+	}
+
+	run := func(args ...string) string {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run(append([]string{"-http-addr=" + a.HTTPAddr(), "-format=json"}, args...))
+		if code != 0 {
+			t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+		}
+		return ui.OutputWriter.String()
+	}
+
+	var pair api.KVPair
+	if err := json.Unmarshal([]byte(run("foo/a")), &pair); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair.ModifyIndex != expect.ModifyIndex || pair.Flags != 42 || string(pair.Value) != "\x00\x01\xff" {
+		t.Fatalf("bad: %#v", pair)
+	}
+
+	var pairs api.KVPairs
+	if err := json.Unmarshal([]byte(run("-recurse", "foo")), &pairs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(pairs) != 2 || pairs[1].Key != "foo/b" || string(pairs[1].Value) != "b" {
+		t.Fatalf("bad: %#v", pairs)
+	}
+
+	var keys []string
+	if err := json.Unmarshal([]byte(run("-keys", "foo/")), &keys); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "foo/a" {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	if got := run("-recurse", "bar"); strings.TrimSpace(got) != "[]" {
+		t.Fatalf("bad: %q", got)
+	}
+}
+
 func TestKVGetCommand_Keys(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
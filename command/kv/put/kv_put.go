@@ -1,11 +1,17 @@
 package put
 
 import (
+	"bytes"
 	"encoding/base64"
 	"flag"
 	"fmt"
 	"io"
+	"os"
+	osexec "os/exec"
+	"strconv"
+	"strings"
 
+	"github.com/hashicorp/consul/agent/exec"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/hashicorp/consul/command/helpers"
@@ -26,6 +32,8 @@ type cmd struct {
 
 	// flags
 	cas           bool
+	casRetry      int
+	updateCmd     string
 	kvflags       uint64
 	base64encoded bool
 	modifyIndex   uint64
@@ -43,6 +51,16 @@ func (c *cmd) init() {
 		"Perform a Check-And-Set operation. Specifying this value also "+
 			"requires the -modify-index flag to be set. The default value "+
 			"is false.")
+	c.flags.StringVar(&c.updateCmd, "update-cmd", "",
+		"Shell command computing the new value of the key from its current "+
+			"value, which the command reads from stdin. The ModifyIndex of the "+
+			"current value is in the CONSUL_KV_MODIFY_INDEX environment variable. "+
+			"The output of the command is written with a Check-And-Set operation "+
+			"so concurrent updates are not lost. This can't be combined with DATA.")
+	c.flags.IntVar(&c.casRetry, "cas-retry", 0,
+		"Number of times the key is read and the -update-cmd is run again "+
+			"when the Check-And-Set operation fails because the key was modified "+
+			"in the meantime. The default value is 0 (no retries).")
 	c.flags.Uint64Var(&c.kvflags, "flags", 0,
 		"Unsigned integer value to assign to this key-value pair. This "+
 			"value is not read by Consul, so clients can use this value however "+
@@ -104,6 +122,25 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.updateCmd != "" {
+		if len(args) > 1 {
+			c.UI.Error("Error! Cannot specify DATA with -update-cmd")
+			return 1
+		}
+		if c.cas || c.acquire || c.release {
+			c.UI.Error("Error! Cannot use -update-cmd with -cas, -acquire or -release")
+			return 1
+		}
+	}
+	if c.casRetry < 0 {
+		c.UI.Error("Error! -cas-retry must not be negative")
+		return 1
+	}
+	if c.casRetry > 0 && c.updateCmd == "" {
+		c.UI.Error("Error! Must specify -update-cmd with -cas-retry")
+		return 1
+	}
+
 	// Create and test the HTTP client
 	client, err := c.http.APIClient()
 	if err != nil {
@@ -120,6 +157,8 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	switch {
+	case c.updateCmd != "":
+		return c.update(client.KV(), key)
 	case c.cas:
 		ok, _, err := client.KV().CAS(pair, nil)
 		if err != nil {
@@ -170,6 +209,81 @@ func (c *cmd) Run(args []string) int {
 	}
 }
 
+// update reads the key, runs the update command on its value and writes
+// the result if the key wasn't modified since it was read, retrying up to
+// -cas-retry times.
+func (c *cmd) update(kv *api.KV, key string) int {
+	kvflagsSet := false
+	c.flags.Visit(func(f *flag.Flag) {
+		if f.Name == "flags" {
+			kvflagsSet = true
+		}
+	})
+
+	for attempt := 0; ; attempt++ {
+		pair, _, err := kv.Get(key, nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error! Failed reading %s: %s", key, err))
+			return 1
+		}
+		if pair == nil {
+			// A ModifyIndex of 0 only writes the key if it still doesn't
+			// exist.
+			pair = &api.KVPair{Key: key}
+		}
+
+		value, err := c.runUpdateCmd(pair)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error! Did not write to %s: %s", key, err))
+			return 1
+		}
+		if c.base64encoded {
+			value, err = base64.StdEncoding.DecodeString(string(value))
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error! Cannot base 64 decode data: %s", err))
+				return 1
+			}
+		}
+		pair.Value = value
+		if kvflagsSet {
+			pair.Flags = c.kvflags
+		}
+
+		ok, _, err := kv.CAS(pair, nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error! Did not write to %s: %s", key, err))
+			return 1
+		}
+		if ok {
+			c.UI.Info(fmt.Sprintf("Success! Data written to: %s", key))
+			return 0
+		}
+		if attempt >= c.casRetry {
+			c.UI.Error(fmt.Sprintf("Error! Did not write to %s: CAS failed after %d attempts", key, attempt+1))
+			return 1
+		}
+	}
+}
+
+// runUpdateCmd runs the update command with the current value of the pair
+// on stdin and returns its output.
+func (c *cmd) runUpdateCmd(pair *api.KVPair) ([]byte, error) {
+	cmd, err := exec.Script(c.updateCmd)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = bytes.NewReader(pair.Value)
+	cmd.Env = append(os.Environ(), "CONSUL_KV_MODIFY_INDEX="+strconv.FormatUint(pair.ModifyIndex, 10))
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*osexec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("update command failed: %s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("update command failed: %s", err)
+	}
+	return out, nil
+}
+
 func (c *cmd) dataFromArgs(args []string) (string, string, error) {
 	switch len(args) {
 	case 0:
@@ -231,5 +345,12 @@ Usage: consul kv put [options] KEY [DATA]
 
       $ consul kv put -cas -modify-index=844 config/redis/maxconns 5
 
+  To update a key based on its current value, specify a shell command which
+  reads the current value from stdin and prints the new one with the
+  -update-cmd flag. The key is only written if it wasn't modified since it was
+  read, and the -cas-retry flag retries the update that many times otherwise:
+
+      $ consul kv put -cas-retry=5 -update-cmd='printf %d $(($(cat) + 1))' counter
+
   Additional flags and more advanced use cases are detailed below.
 `
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
+	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/mitchellh/cli"
 )
 
@@ -47,6 +49,18 @@ func TestKVPutCommand_Validation(t *testing.T) {
 			[]string{},
 			"Missing KEY argument",
 		},
+		"-update-cmd with data": {
+			[]string{"-update-cmd", "cat", "foo", "bar"},
+			"Cannot specify DATA with -update-cmd",
+		},
+		"-update-cmd with -cas": {
+			[]string{"-update-cmd", "cat", "-cas", "-modify-index", "1", "foo"},
+			"Cannot use -update-cmd with -cas",
+		},
+		"-cas-retry without -update-cmd": {
+			[]string{"-cas-retry", "3", "foo", "bar"},
+			"Must specify -update-cmd with -cas-retry",
+		},
 		"extra args": {
 			[]string{"foo", "bar", "baz"},
 			"Too many arguments",
@@ -381,3 +395,120 @@ func TestKVPutCommand_CAS(t *testing.T) {
 		t.Errorf("bad: %#v", data.Value)
 	}
 }
+
+func TestKVPutCommand_UpdateCmd(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	run := func(args ...string) (int, *cli.MockUi) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		return c.Run(append([]string{"-http-addr=" + a.HTTPAddr()}, args...)), ui
+	}
+
+	// The key is created and then updated from its current value.
+	update := `printf '%s:%s' "$(cat)" "$CONSUL_KV_MODIFY_INDEX"`
+	code, ui := run("-update-cmd", update, "foo")
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	data, _, err := client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data.Value), ":0"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	code, ui = run("-update-cmd", update, "-flags", "12", "foo")
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	prev := data
+	data, _, err = client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data.Value), ":0:"+strconv.FormatUint(prev.ModifyIndex, 10); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	if data.Flags != 12 {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	// A failing command doesn't write anything.
+	code, ui = run("-update-cmd", "echo broken >&2; exit 3", "foo")
+	if code == 0 {
+		t.Fatalf("bad: expected error")
+	}
+	if got, want := ui.ErrorWriter.String(), "update command failed: exit status 3: broken"; !strings.Contains(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestKVPutCommand_CASRetry(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, tc := range []struct {
+		retries string
+		code    int
+		value   string
+		runs    int
+	}{
+		{"0", 1, "other", 1},
+		{"2", 0, "new", 2},
+	} {
+		t.Run(tc.retries, func(t *testing.T) {
+			dir := testutil.TempDir(t, "kv-put")
+			defer os.RemoveAll(dir)
+
+			// The first run of the command waits until the key was modified
+			// by someone else so the Check-And-Set operation fails.
+			update := `cat >/dev/null; echo >> ` + dir + `/runs
+				while [ ! -f ` + dir + `/modified ]; do sleep 0.01; done
+				printf new`
+
+			ui := cli.NewMockUi()
+			c := New(ui)
+			codeCh := make(chan int, 1)
+			go func() {
+				codeCh <- c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-cas-retry", tc.retries, "-update-cmd", update, "foo"})
+			}()
+
+			retry.Run(t, func(r *retry.R) {
+				if _, err := os.Stat(dir + "/runs"); err != nil {
+					r.Fatal(err)
+				}
+			})
+			if _, err := client.KV().Put(&api.KVPair{Key: "foo", Value: []byte("other")}, nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(dir+"/modified", nil, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if code := <-codeCh; code != tc.code {
+				t.Fatalf("got code %d want %d: %s", code, tc.code, ui.ErrorWriter.String())
+			}
+			data, _, err := client.KV().Get("foo", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data.Value); got != tc.value {
+				t.Fatalf("got %q want %q", got, tc.value)
+			}
+			runs, err := ioutil.ReadFile(dir + "/runs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(runs); got != tc.runs {
+				t.Fatalf("got %d runs want %d", got, tc.runs)
+			}
+		})
+	}
+}
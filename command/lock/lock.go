@@ -6,6 +6,7 @@ import (
 	"os"
 	osexec "os/exec"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -57,6 +58,7 @@ type cmd struct {
 	name               string
 	passStdin          bool
 	propagateChildCode bool
+	sessionTTL         string
 	shell              bool
 	timeout            time.Duration
 }
@@ -88,6 +90,11 @@ func (c *cmd) init() {
 			"is generated based on the provided child command.")
 	c.flags.BoolVar(&c.passStdin, "pass-stdin", false,
 		"Pass stdin to the child process.")
+	c.flags.StringVar(&c.sessionTTL, "session-ttl", api.DefaultLockSessionTTL,
+		"TTL of the session holding the lock, specified as a duration like "+
+			"\"30s\". The session is renewed while the lock is held and the child "+
+			"is terminated if it can't be renewed in time. The default value is "+
+			api.DefaultLockSessionTTL+".")
 	c.flags.BoolVar(&c.shell, "shell", true,
 		"Use a shell to run the command (can set a custom shell via the SHELL "+
 			"environment variable).")
@@ -151,6 +158,11 @@ func (c *cmd) run(args []string, lu **LockUnlock) int {
 		return 1
 	}
 
+	if _, err := time.ParseDuration(c.sessionTTL); err != nil {
+		c.UI.Error(fmt.Sprintf("Invalid session TTL: %s", err))
+		return 1
+	}
+
 	// Create and test the HTTP client
 	client, err := c.http.APIClient()
 	if err != nil {
@@ -163,11 +175,38 @@ func (c *cmd) run(args []string, lu **LockUnlock) int {
 		return 1
 	}
 
+	// Create the session rather than leaving it to the lock so its renewal
+	// can be watched and the holder of the lock verified.
+	session, _, err := client.Session().Create(&api.SessionEntry{
+		Name: c.name,
+		TTL:  c.sessionTTL,
+	}, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating lock session: %s", err))
+		return 1
+	}
+	if c.verbose {
+		c.UI.Info(fmt.Sprintf("Created session %s with a TTL of %s", session, c.sessionTTL))
+	}
+	renewStopCh := make(chan struct{})
+	renewErrCh := make(chan error, 1)
+	go func() {
+		renewErrCh <- client.Session().RenewPeriodic(c.sessionTTL, session, nil, renewStopCh)
+	}()
+	renewStopped := false
+	defer func() {
+		// Stopping the renewal destroys the session.
+		close(renewStopCh)
+		if !renewStopped {
+			<-renewErrCh
+		}
+	}()
+
 	// Setup the lock or semaphore
 	if c.limit == 1 {
-		*lu, err = c.setupLock(client, prefix, c.name, oneshot, c.timeout, c.monitorRetry)
+		*lu, err = c.setupLock(client, prefix, session, oneshot, c.timeout, c.monitorRetry)
 	} else {
-		*lu, err = c.setupSemaphore(client, c.limit, prefix, c.name, oneshot, c.timeout, c.monitorRetry)
+		*lu, err = c.setupSemaphore(client, c.limit, prefix, session, oneshot, c.timeout, c.monitorRetry)
 	}
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Lock setup failed: %s", err))
@@ -191,6 +230,8 @@ func (c *cmd) run(args []string, lu **LockUnlock) int {
 	// Check if we were shutdown but managed to still acquire the lock
 	var childCode int
 	var childErr chan error
+	var token uint64
+	env := []string{"CONSUL_LOCK_SESSION=" + session}
 	select {
 	case <-c.ShutdownCh:
 		c.UI.Error("Shutdown triggered during lock acquisition")
@@ -198,13 +239,27 @@ func (c *cmd) run(args []string, lu **LockUnlock) int {
 	default:
 	}
 
+	// Pass the fencing token of a lock to the child
+	if opts, ok := (*lu).rawOpts.(*api.LockOptions); ok {
+		token, err = c.fencingToken(client, opts.Key, session)
+		if err != nil {
+			c.UI.Error(err.Error())
+			goto RELEASE
+		}
+		if c.verbose {
+			c.UI.Info(fmt.Sprintf("Lock acquired with fencing token %d", token))
+		}
+		env = append(env, "CONSUL_LOCK_MODIFY_INDEX="+strconv.FormatUint(token, 10))
+	}
+
 	// Start the child process
 	childErr = make(chan error, 1)
 	go func() {
-		childErr <- c.startChild(c.flags.Args()[1:], c.passStdin, c.shell)
+		childErr <- c.startChild(c.flags.Args()[1:], env, c.passStdin, c.shell)
 	}()
 
-	// Monitor for shutdown, child termination, or lock loss
+	// Monitor for shutdown, child termination, lock loss or failed session
+	// renewals
 	select {
 	case <-c.ShutdownCh:
 		if c.verbose {
@@ -214,6 +269,12 @@ func (c *cmd) run(args []string, lu **LockUnlock) int {
 		if c.verbose {
 			c.UI.Info("Lock lost, killing child")
 		}
+	case err := <-renewErrCh:
+		renewStopped = true
+		if err == nil {
+			err = api.ErrSessionExpired
+		}
+		c.UI.Error(fmt.Sprintf("Lock session renewal failed, killing child: %s", err))
 	case err := <-childErr:
 		if err != nil {
 			childCode = 2
@@ -261,12 +322,27 @@ RELEASE:
 	return 0
 }
 
+// fencingToken returns the ModifyIndex of the lock key written when the
+// session acquired it. Since every acquisition writes the key the index
+// grows with every new holder, so systems acting on behalf of the holder can
+// reject requests with a smaller index than they have seen already.
+func (c *cmd) fencingToken(client *api.Client, key, session string) (uint64, error) {
+	pair, _, err := client.KV().Get(key, &api.QueryOptions{RequireConsistent: true})
+	if err != nil {
+		return 0, fmt.Errorf("Error reading fencing token: %s", err)
+	}
+	if pair == nil || pair.Session != session {
+		return 0, fmt.Errorf("Lock lost before reading fencing token")
+	}
+	return pair.ModifyIndex, nil
+}
+
 // setupLock is used to setup a new Lock given the API client, the key prefix to
-// operate on, and an optional session name. If oneshot is true then we will set
+// operate on, and the session to hold it with. If oneshot is true then we will set
 // up for a single attempt at acquisition, using the given wait time. The retry
 // parameter sets how many 500 errors the lock monitor will tolerate before
 // giving up the lock.
-func (c *cmd) setupLock(client *api.Client, prefix, name string,
+func (c *cmd) setupLock(client *api.Client, prefix, session string,
 	oneshot bool, wait time.Duration, retry int) (*LockUnlock, error) {
 	// Use the DefaultSemaphoreKey extension, this way if a lock and
 	// semaphore are both used at the same prefix, we will get a conflict
@@ -277,7 +353,7 @@ func (c *cmd) setupLock(client *api.Client, prefix, name string,
 	}
 	opts := api.LockOptions{
 		Key:              key,
-		SessionName:      name,
+		Session:          session,
 		MonitorRetries:   retry,
 		MonitorRetryTime: defaultMonitorRetryTime,
 	}
@@ -300,11 +376,11 @@ func (c *cmd) setupLock(client *api.Client, prefix, name string,
 }
 
 // setupSemaphore is used to setup a new Semaphore given the API client, key
-// prefix, session name, and slot holder limit. If oneshot is true then we will
+// prefix, session, and slot holder limit. If oneshot is true then we will
 // set up for a single attempt at acquisition, using the given wait time. The
 // retry parameter sets how many 500 errors the lock monitor will tolerate
 // before giving up the semaphore.
-func (c *cmd) setupSemaphore(client *api.Client, limit int, prefix, name string,
+func (c *cmd) setupSemaphore(client *api.Client, limit int, prefix, session string,
 	oneshot bool, wait time.Duration, retry int) (*LockUnlock, error) {
 	if c.verbose {
 		c.UI.Info(fmt.Sprintf("Setting up semaphore (limit %d) at prefix: %s", limit, prefix))
@@ -312,7 +388,7 @@ func (c *cmd) setupSemaphore(client *api.Client, limit int, prefix, name string,
 	opts := api.SemaphoreOptions{
 		Prefix:           prefix,
 		Limit:            limit,
-		SessionName:      name,
+		Session:          session,
 		MonitorRetries:   retry,
 		MonitorRetryTime: defaultMonitorRetryTime,
 	}
@@ -335,8 +411,9 @@ func (c *cmd) setupSemaphore(client *api.Client, limit int, prefix, name string,
 }
 
 // startChild is a long running routine used to start and
-// wait for the child process to exit.
-func (c *cmd) startChild(args []string, passStdin, shell bool) error {
+// wait for the child process to exit. The env is added to the
+// environment of the child.
+func (c *cmd) startChild(args []string, env []string, passStdin, shell bool) error {
 	if c.verbose {
 		c.UI.Info("Starting handler")
 	}
@@ -358,6 +435,7 @@ func (c *cmd) startChild(args []string, passStdin, shell bool) error {
 	cmd.Env = append(os.Environ(),
 		"CONSUL_LOCK_HELD=true",
 	)
+	cmd.Env = append(cmd.Env, env...)
 	if passStdin {
 		if c.verbose {
 			c.UI.Info("Stdin passed to handler process")
@@ -481,5 +559,12 @@ Usage: consul lock [options] prefix child...
   exclusion. Setting a higher value switches to a semaphore allowing multiple
   holders to coordinate.
 
+  The child process finds the ID of the session holding the lock in the
+  CONSUL_LOCK_SESSION environment variable. For a lock, CONSUL_LOCK_MODIFY_INDEX
+  is a fencing token which grows with every acquisition of the lock. Systems
+  the child talks to can reject requests carrying a smaller token than one
+  they have seen, so a holder which lost the lock can't overwrite the work of
+  the next one.
+
   The prefix provided must have write privileges.
 `
@@ -3,6 +3,7 @@ package lock
 import (
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -38,6 +39,7 @@ func TestLockCommand_BadArgs(t *testing.T) {
 	argFail(t, []string{"-try=blah", "test/prefix", "date"}, "invalid duration")
 	argFail(t, []string{"-try=-10s", "test/prefix", "date"}, "Timeout must be positive")
 	argFail(t, []string{"-monitor-retry=-5", "test/prefix", "date"}, "must be >= 0")
+	argFail(t, []string{"-session-ttl=soon", "test/prefix", "date"}, "Invalid session TTL")
 }
 
 func TestLockCommand(t *testing.T) {
@@ -65,6 +67,55 @@ func TestLockCommand(t *testing.T) {
 	}
 }
 
+func TestLockCommand_FencingToken(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+	client := a.Client()
+
+	// Each acquisition of the lock passes a greater token to the child.
+	var tokens []uint64
+	for i := 0; i < 2; i++ {
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		filePath := filepath.Join(a.Config.DataDir, "test_env")
+		args := []string{"-http-addr=" + a.HTTPAddr(), "-session-ttl=30s", "test/prefix",
+			"echo $CONSUL_LOCK_MODIFY_INDEX $CONSUL_LOCK_SESSION > " + filePath}
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+		}
+
+		buf, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		fields := strings.Fields(string(buf))
+		if len(fields) != 2 {
+			t.Fatalf("bad: %q", buf)
+		}
+		token, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil || token == 0 {
+			t.Fatalf("bad token: %q", fields[0])
+		}
+		if len(tokens) > 0 && token <= tokens[len(tokens)-1] {
+			t.Fatalf("token %d not greater than %d", token, tokens[len(tokens)-1])
+		}
+		tokens = append(tokens, token)
+
+		// The session is destroyed after the lock was released.
+		session, _, err := client.Session().Info(fields[1], nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if session != nil {
+			t.Fatalf("session not destroyed: %#v", session)
+		}
+	}
+}
+
 func TestLockCommand_NoShell(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
@@ -124,6 +175,33 @@ func TestLockCommand_TryLock(t *testing.T) {
 	}
 }
 
+func TestLockCommand_Semaphore_Env(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	// Semaphore slots have no fencing token.
+	filePath := filepath.Join(a.Config.DataDir, "test_env")
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-n=3", "test/prefix",
+		"echo \"$CONSUL_LOCK_MODIFY_INDEX\" $CONSUL_LOCK_SESSION > " + filePath}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fields := strings.Fields(string(buf)); len(fields) != 1 || fields[0] == "" {
+		t.Fatalf("bad: %q", buf)
+	}
+}
+
 func TestLockCommand_TrySemaphore(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
@@ -4,7 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
 )
@@ -21,6 +23,8 @@ type cmd struct {
 	enable    bool
 	disable   bool
 	reason    string
+	actor     string
+	duration  time.Duration
 	serviceID string
 }
 
@@ -38,6 +42,11 @@ func (c *cmd) init() {
 		"Disable maintenance mode.")
 	c.flags.StringVar(&c.reason, "reason", "",
 		"Text describing the maintenance reason.")
+	c.flags.StringVar(&c.actor, "actor", "",
+		"Identifies who enabled maintenance mode.")
+	c.flags.DurationVar(&c.duration, "duration", 0,
+		"Duration after which maintenance mode is disabled automatically. "+
+			"Defaults to no expiry.")
 	c.flags.StringVar(&c.serviceID, "service", "",
 		"Control maintenance mode for a specific service ID.")
 
@@ -60,6 +69,14 @@ func (c *cmd) Run(args []string) int {
 		c.UI.Error("Reason may only be provided with -enable")
 		return 1
 	}
+	if !c.enable && (c.actor != "" || c.duration != 0) {
+		c.UI.Error("Actor and duration may only be provided with -enable")
+		return 1
+	}
+	if c.duration < 0 {
+		c.UI.Error("Duration must be positive")
+		return 1
+	}
 	if !c.enable && !c.disable && c.serviceID != "" {
 		c.UI.Error("Service requires either -enable or -disable")
 		return 1
@@ -89,13 +106,15 @@ func (c *cmd) Run(args []string) int {
 		for _, check := range checks {
 			if check.CheckID == "_node_maintenance" {
 				c.UI.Output("Node:")
-				c.UI.Output("  Name:   " + nodeName)
-				c.UI.Output("  Reason: " + check.Notes)
+				c.UI.Output("  Name:    " + nodeName)
+				c.UI.Output("  Reason:  " + check.Notes)
+				c.outputMaintenance(check.Maintenance)
 				c.UI.Output("")
 			} else if strings.HasPrefix(string(check.CheckID), "_service_maintenance:") {
 				c.UI.Output("Service:")
-				c.UI.Output("  ID:     " + check.ServiceID)
-				c.UI.Output("  Reason: " + check.Notes)
+				c.UI.Output("  ID:      " + check.ServiceID)
+				c.UI.Output("  Reason:  " + check.Notes)
+				c.outputMaintenance(check.Maintenance)
 				c.UI.Output("")
 			}
 		}
@@ -104,9 +123,15 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	if c.enable {
+		opts := &api.MaintenanceOptions{
+			Reason:   c.reason,
+			Actor:    c.actor,
+			Duration: c.duration,
+		}
+
 		// Enable node maintenance
 		if c.serviceID == "" {
-			if err := a.EnableNodeMaintenance(c.reason); err != nil {
+			if err := a.EnableNodeMaintenanceOpts(opts); err != nil {
 				c.UI.Error(fmt.Sprintf("Error enabling node maintenance: %s", err))
 				return 1
 			}
@@ -115,7 +140,7 @@ func (c *cmd) Run(args []string) int {
 		}
 
 		// Enable service maintenance
-		if err := a.EnableServiceMaintenance(c.serviceID, c.reason); err != nil {
+		if err := a.EnableServiceMaintenanceOpts(c.serviceID, opts); err != nil {
 			c.UI.Error(fmt.Sprintf("Error enabling service maintenance: %s", err))
 			return 1
 		}
@@ -146,6 +171,19 @@ func (c *cmd) Run(args []string) int {
 	return 0
 }
 
+// outputMaintenance prints the optional maintenance metadata of a check.
+func (c *cmd) outputMaintenance(maint *api.HealthCheckMaintenance) {
+	if maint == nil {
+		return
+	}
+	if maint.Actor != "" {
+		c.UI.Output("  Actor:   " + maint.Actor)
+	}
+	if maint.Expires != nil {
+		c.UI.Output("  Expires: " + maint.Expires.Format(time.RFC3339))
+	}
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -170,7 +208,10 @@ Usage: consul maint [options]
 
   Maintenance mode is persistent, and will be restored in the event of an
   agent restart. It is therefore required to disable maintenance mode on
-  a given node or service before it will be placed back into the pool.
+  a given node or service before it will be placed back into the pool,
+  unless a "-duration" was given, in which case the agent disables it
+  automatically once the duration has passed. The optional "-actor" is
+  recorded with the reason and shown in the health APIs.
 
   By default, we operate on the node as a whole. By specifying the
   "-service" argument, this behavior can be changed to enable or disable
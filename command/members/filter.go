@@ -0,0 +1,109 @@
+package members
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/serf/serf"
+)
+
+// clauseRe matches a single clause of a filter expression, such as
+// `Tags.dc == "dc1"`, along with the "and" joining it to the next one.
+var clauseRe = regexp.MustCompile(`^\s*([A-Za-z][\w.\-]*)\s*(==|!=|=~|!~)\s*("(?:[^"\\]|\\.)*"|[^\s"]+)\s*(?:and\s+|$)`)
+
+// filterClause compares a single field of a member against a value.
+type filterClause struct {
+	selector string
+	op       string
+	value    string
+	re       *regexp.Regexp
+}
+
+// memberFilter is a parsed filter expression. A member matches if it matches
+// all of the clauses.
+type memberFilter []*filterClause
+
+// parseFilter parses a filter expression made of clauses joined by "and".
+// Each clause compares a selector, which is Name, Addr, Status or Tags.<key>,
+// to a value with one of the ==, !=, =~ and !~ operators. The last two match
+// the value as a regular expression. Values containing spaces or quotes must
+// be double quoted.
+func parseFilter(expr string) (memberFilter, error) {
+	var filter memberFilter
+	rest := strings.TrimSpace(expr)
+	for rest != "" {
+		m := clauseRe.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("invalid filter clause at %q", rest)
+		}
+		rest = rest[len(m[0]):]
+
+		clause := &filterClause{selector: m[1], op: m[2], value: m[3]}
+		if !validSelector(clause.selector) {
+			return nil, fmt.Errorf("unknown filter selector %q", clause.selector)
+		}
+		if strings.HasPrefix(clause.value, `"`) {
+			value, err := strconv.Unquote(clause.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter value %s: %v", clause.value, err)
+			}
+			clause.value = value
+		}
+		if clause.op == "=~" || clause.op == "!~" {
+			re, err := regexp.Compile(clause.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter regexp %q: %v", clause.value, err)
+			}
+			clause.re = re
+		}
+		filter = append(filter, clause)
+	}
+	return filter, nil
+}
+
+// validSelector returns true if the selector names a field of a member.
+func validSelector(selector string) bool {
+	switch selector {
+	case "Name", "Addr", "Status":
+		return true
+	}
+	return strings.HasPrefix(selector, "Tags.") && len(selector) > len("Tags.")
+}
+
+// Match returns true if the member matches all the clauses of the filter.
+func (f memberFilter) Match(member *consulapi.AgentMember) bool {
+	for _, clause := range f {
+		if !clause.match(member) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *filterClause) match(member *consulapi.AgentMember) bool {
+	var field string
+	switch c.selector {
+	case "Name":
+		field = member.Name
+	case "Addr":
+		field = member.Addr
+	case "Status":
+		field = serf.MemberStatus(member.Status).String()
+	default:
+		field = member.Tags[strings.TrimPrefix(c.selector, "Tags.")]
+	}
+
+	switch c.op {
+	case "==":
+		return field == c.value
+	case "!=":
+		return field != c.value
+	case "=~":
+		return c.re.MatchString(field)
+	default:
+		return !c.re.MatchString(field)
+	}
+}
@@ -0,0 +1,53 @@
+package members
+
+import (
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+	member := &consulapi.AgentMember{
+		Name:   "web-1",
+		Addr:   "10.0.0.1",
+		Status: 1,
+		Tags:   map[string]string{"role": "node", "dc": "dc1", "build": "1.4.0:abc"},
+	}
+
+	cases := []struct {
+		expr  string
+		match bool
+		err   string
+	}{
+		{"", true, ""},
+		{"Name == web-1", true, ""},
+		{"Name==web-1", true, ""},
+		{"Name != web-1", false, ""},
+		{`Name =~ "^web-"`, true, ""},
+		{"Name !~ ^web-", false, ""},
+		{"Status == alive", true, ""},
+		{"Addr == 10.0.0.1 and Tags.role == node", true, ""},
+		{"Tags.role == node and Tags.dc == dc2", false, ""},
+		{`Tags.build == "1.4.0:abc"`, true, ""},
+		{"Tags.missing == \"\"", true, ""},
+		{"Tags.role == node and", false, "invalid filter clause"},
+		{"Tags.role == node or Name == web-1", false, "invalid filter clause"},
+		{"Nope == 1", false, "unknown filter selector"},
+		{"Tags. == 1", false, "unknown filter selector"},
+		{"Name =~ (", false, "invalid filter regexp"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			filter, err := parseFilter(tc.expr)
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.match, filter.Match(member))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package members
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net"
@@ -28,6 +29,9 @@ type cmd struct {
 	wan          bool
 	statusFilter string
 	segment      string
+	filter       string
+	sortBy       string
+	format       string
 }
 
 func New(ui cli.Ui) *cmd {
@@ -49,6 +53,19 @@ func (c *cmd) init() {
 	c.flags.StringVar(&c.segment, "segment", consulapi.AllSegments,
 		"(Enterprise-only) If provided, output is filtered to only nodes in"+
 			"the given segment.")
+	c.flags.StringVar(&c.filter, "filter", "",
+		"If provided, output is filtered to only nodes matching the expression. "+
+			"The expression is made of clauses joined by 'and', each comparing "+
+			"Name, Addr, Status or Tags.<key> to a value with ==, != or the "+
+			"=~ and !~ regular expression operators, for example "+
+			"'Tags.role == consul and Name =~ \"^web-\"'.")
+	c.flags.StringVar(&c.sortBy, "sort", "name",
+		"Sort the nodes by 'name' or by 'status'. Nodes are sorted by segment "+
+			"first in both cases.")
+	c.flags.StringVar(&c.format, "format", "standard",
+		"Output format. Must be 'standard', 'wide' or 'json'. The wide format "+
+			"adds the full build and the Serf and delegate protocol versions, and "+
+			"the JSON format lists all the fields of each node.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -67,6 +84,26 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	// Parse the filter expression
+	filter, err := parseFilter(c.filter)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to parse filter: %v", err))
+		return 1
+	}
+
+	if c.sortBy != "name" && c.sortBy != "status" {
+		c.UI.Error("-sort must be either 'name' or 'status'")
+		return 1
+	}
+	if c.format != "standard" && c.format != "wide" && c.format != "json" {
+		c.UI.Error("-format must be one of 'standard', 'wide' or 'json'")
+		return 1
+	}
+	if c.detailed && c.format != "standard" {
+		c.UI.Error("-detailed can't be combined with the wide or JSON formats")
+		return 1
+	}
+
 	client, err := c.http.APIClient()
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
@@ -95,7 +132,7 @@ func (c *cmd) Run(args []string) int {
 			member.Tags["segment"] = "<all>"
 		}
 		statusString := serf.MemberStatus(member.Status).String()
-		if !statusRe.MatchString(statusString) {
+		if !statusRe.MatchString(statusString) || !filter.Match(member) {
 			members[i], members[n-1] = members[n-1], members[i]
 			i--
 			n--
@@ -110,12 +147,20 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	sort.Sort(ByMemberNameAndSegment(members))
+	if c.sortBy == "status" {
+		sort.Stable(ByMemberStatus(members))
+	}
 
 	// Generate the output
 	var result []string
-	if c.detailed {
+	switch {
+	case c.format == "json":
+		return c.jsonOutput(members)
+	case c.format == "wide":
+		result = c.wideOutput(members)
+	case c.detailed:
 		result = c.detailedOutput(members)
-	} else {
+	default:
 		result = c.standardOutput(members)
 	}
 
@@ -142,6 +187,28 @@ func (m ByMemberNameAndSegment) Less(i, j int) bool {
 	}
 }
 
+// so we can sort members by status, keeping them sorted by name within
+// the same status
+type ByMemberStatus []*consulapi.AgentMember
+
+func (m ByMemberStatus) Len() int      { return len(m) }
+func (m ByMemberStatus) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByMemberStatus) Less(i, j int) bool {
+	return serf.MemberStatus(m[i].Status).String() < serf.MemberStatus(m[j].Status).String()
+}
+
+// memberType returns whether the member is a server or a client.
+func memberType(member *consulapi.AgentMember) string {
+	switch member.Tags["role"] {
+	case "node":
+		return "client"
+	case "consul":
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
 // standardOutput is used to dump the most useful information about nodes
 // in a more human-friendly format
 func (c *cmd) standardOutput(members []*consulapi.AgentMember) []string {
@@ -209,6 +276,52 @@ func (c *cmd) detailedOutput(members []*consulapi.AgentMember) []string {
 	return result
 }
 
+// wideOutput is used to dump the standard information about nodes along
+// with the full build and the protocol versions they speak
+func (c *cmd) wideOutput(members []*consulapi.AgentMember) []string {
+	result := make([]string, 0, len(members))
+	header := "Node|Address|Status|Type|Build|Protocol|DC|Segment|Serf Protocol|Delegate Protocol"
+	result = append(result, header)
+	for _, member := range members {
+		addr := net.TCPAddr{IP: net.ParseIP(member.Addr), Port: int(member.Port)}
+		line := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%d (%d-%d)|%d (%d-%d)",
+			member.Name, addr.String(), serf.MemberStatus(member.Status).String(),
+			memberType(member), member.Tags["build"], member.Tags["vsn"],
+			member.Tags["dc"], member.Tags["segment"],
+			member.ProtocolCur, member.ProtocolMin, member.ProtocolMax,
+			member.DelegateCur, member.DelegateMin, member.DelegateMax)
+		result = append(result, line)
+	}
+	return result
+}
+
+// jsonMember is a member as listed by -format=json, with its status
+// spelled out.
+type jsonMember struct {
+	*consulapi.AgentMember
+	Status string
+	Type   string
+}
+
+// jsonOutput is used to dump all known information about nodes as JSON
+func (c *cmd) jsonOutput(members []*consulapi.AgentMember) int {
+	out := make([]jsonMember, 0, len(members))
+	for _, member := range members {
+		out = append(out, jsonMember{
+			AgentMember: member,
+			Status:      serf.MemberStatus(member.Status).String(),
+			Type:        memberType(member),
+		})
+	}
+	b, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encoding members: %s", err))
+		return 1
+	}
+	c.UI.Output(string(b))
+	return 0
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -1,7 +1,9 @@
 package members
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -120,3 +122,89 @@ func TestMembersCommand_statusFilter_failed(t *testing.T) {
 		t.Fatalf("bad: %d", code)
 	}
 }
+
+func TestMembersCommand_filter(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	c.flags.SetOutput(ui.ErrorWriter)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-filter=Tags.role == consul and Name == " + strconv.Quote(a.Config.NodeName),
+	}
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), a.Config.NodeName) {
+		t.Fatalf("bad: %#v", ui.OutputWriter.String())
+	}
+
+	// No clients match
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-filter=Tags.role == node"}
+	if code := c.Run(args); code != 2 {
+		t.Fatalf("bad: %d", code)
+	}
+
+	// Invalid expressions are rejected
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-filter=Role == consul"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "unknown filter selector") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
+
+func TestMembersCommand_formats(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	// The wide format has the protocol versions
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-format=wide", "-sort=status"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Serf Protocol") || !strings.Contains(out, "2 (1-5)") {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The JSON format lists all the fields
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-format=json"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var members []map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &members); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(members) != 1 {
+		t.Fatalf("bad: %#v", members)
+	}
+	if members[0]["Name"] != a.Config.NodeName || members[0]["Status"] != "alive" || members[0]["Type"] != "server" {
+		t.Fatalf("bad: %#v", members[0])
+	}
+
+	// Invalid options are rejected
+	for _, arg := range []string{"-format=yaml", "-sort=age"} {
+		ui = cli.NewMockUi()
+		c = New(ui)
+		if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), arg}); code != 1 {
+			t.Fatalf("bad: %s %d", arg, code)
+		}
+	}
+}
@@ -56,6 +56,8 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 	c.UI.Output(fmt.Sprintf("CleanupDeadServers = %v", config.CleanupDeadServers))
+	c.UI.Output(fmt.Sprintf("DeadServerQuarantineTime = %v", config.DeadServerQuarantineTime.String()))
+	c.UI.Output(fmt.Sprintf("MaxDeadServerRemovals = %v", config.MaxDeadServerRemovals))
 	c.UI.Output(fmt.Sprintf("LastContactThreshold = %v", config.LastContactThreshold.String()))
 	c.UI.Output(fmt.Sprintf("MaxTrailingLogs = %v", config.MaxTrailingLogs))
 	c.UI.Output(fmt.Sprintf("ServerStabilizationTime = %v", config.ServerStabilizationTime.String()))
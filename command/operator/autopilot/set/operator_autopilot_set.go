@@ -23,13 +23,15 @@ type cmd struct {
 	help  string
 
 	// flags
-	cleanupDeadServers      flags.BoolValue
-	maxTrailingLogs         flags.UintValue
-	lastContactThreshold    flags.DurationValue
-	serverStabilizationTime flags.DurationValue
-	redundancyZoneTag       flags.StringValue
-	disableUpgradeMigration flags.BoolValue
-	upgradeVersionTag       flags.StringValue
+	cleanupDeadServers       flags.BoolValue
+	deadServerQuarantineTime flags.DurationValue
+	maxDeadServerRemovals    flags.UintValue
+	maxTrailingLogs          flags.UintValue
+	lastContactThreshold     flags.DurationValue
+	serverStabilizationTime  flags.DurationValue
+	redundancyZoneTag        flags.StringValue
+	disableUpgradeMigration  flags.BoolValue
+	upgradeVersionTag        flags.StringValue
 }
 
 func (c *cmd) init() {
@@ -37,6 +39,12 @@ func (c *cmd) init() {
 	c.flags.Var(&c.cleanupDeadServers, "cleanup-dead-servers",
 		"Controls whether Consul will automatically remove dead servers "+
 			"when new ones are successfully added. Must be one of `true|false`.")
+	c.flags.Var(&c.deadServerQuarantineTime, "dead-server-quarantine-time",
+		"Controls the minimum amount of time a server must be dead before it's "+
+			"removed. Must be a duration value such as `10m`.")
+	c.flags.Var(&c.maxDeadServerRemovals, "max-dead-server-removals",
+		"Controls the maximum number of dead servers removed at a time. 0 means "+
+			"no limit.")
 	c.flags.Var(&c.maxTrailingLogs, "max-trailing-logs",
 		"Controls the maximum number of log entries that a server can trail the "+
 			"leader by before being considered unhealthy.")
@@ -95,6 +103,14 @@ func (c *cmd) Run(args []string) int {
 	c.disableUpgradeMigration.Merge(&conf.DisableUpgradeMigration)
 	c.upgradeVersionTag.Merge(&conf.UpgradeVersionTag)
 
+	quarantine := conf.DeadServerQuarantineTime.Duration()
+	c.deadServerQuarantineTime.Merge(&quarantine)
+	conf.DeadServerQuarantineTime = api.NewReadableDuration(quarantine)
+
+	removals := uint(conf.MaxDeadServerRemovals)
+	c.maxDeadServerRemovals.Merge(&removals)
+	conf.MaxDeadServerRemovals = uint64(removals)
+
 	trailing := uint(conf.MaxTrailingLogs)
 	c.maxTrailingLogs.Merge(&trailing)
 	conf.MaxTrailingLogs = uint64(trailing)
@@ -34,6 +34,8 @@ func TestOperatorAutopilotSetConfigCommand(t *testing.T) {
 		"-max-trailing-logs=99",
 		"-last-contact-threshold=123ms",
 		"-server-stabilization-time=123ms",
+		"-dead-server-quarantine-time=5m",
+		"-max-dead-server-removals=2",
 	}
 
 	code := c.Run(args)
@@ -65,4 +67,10 @@ func TestOperatorAutopilotSetConfigCommand(t *testing.T) {
 	if reply.ServerStabilizationTime != 123*time.Millisecond {
 		t.Fatalf("bad: %#v", reply)
 	}
+	if reply.DeadServerQuarantineTime != 5*time.Minute {
+		t.Fatalf("bad: %#v", reply)
+	}
+	if reply.MaxDeadServerRemovals != 2 {
+		t.Fatalf("bad: %#v", reply)
+	}
 }
@@ -3,6 +3,7 @@ package reload
 import (
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
@@ -39,12 +40,17 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	if err := client.Agent().Reload(); err != nil {
+	report, err := client.Agent().ReloadWithReport()
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error reloading: %s", err))
 		return 1
 	}
 
 	c.UI.Output("Configuration reload triggered")
+	if report != nil && len(report.RequiresRestart) > 0 {
+		c.UI.Warn(fmt.Sprintf("The following changed fields require a restart: %s",
+			strings.Join(report.RequiresRestart, ", ")))
+	}
 	return 0
 }
 
@@ -1,11 +1,16 @@
 package restore
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"text/tabwriter"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/snapshot"
 	"github.com/mitchellh/cli"
 )
 
@@ -20,10 +25,19 @@ type cmd struct {
 	flags *flag.FlagSet
 	http  *flags.HTTPFlags
 	help  string
+
+	// flags
+	dryRun             bool
+	skipClusterIDCheck bool
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.dryRun, "dry-run", false,
+		"Verify the snapshot with the servers and report what would be "+
+			"restored, without restoring it.")
+	c.flags.BoolVar(&c.skipClusterIDCheck, "skip-cluster-id-check", false,
+		"Restore the snapshot even if it was taken from another cluster.")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -64,14 +78,51 @@ func (c *cmd) Run(args []string) int {
 	}
 	defer f.Close()
 
+	// Check the integrity of the file before sending it to the servers.
+	if _, err := snapshot.Verify(f); err != nil {
+		c.UI.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
+		return 1
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		c.UI.Error(fmt.Sprintf("Error rewinding snapshot file: %s", err))
+		return 1
+	}
+
 	// Restore the snapshot.
-	err = client.Snapshot().Restore(nil, f)
+	summary, err := client.Snapshot().RestoreWithOptions(nil, f, &api.SnapshotRestoreOptions{
+		DryRun:             c.dryRun,
+		SkipClusterIDCheck: c.skipClusterIDCheck,
+	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error restoring snapshot: %s", err))
 		return 1
 	}
 
-	c.UI.Info("Restored snapshot")
+	if !c.dryRun {
+		c.UI.Info("Restored snapshot")
+		return 0
+	}
+
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 2, 6, ' ', 0)
+	fmt.Fprintf(tw, "Index\t%d\n", summary.Index)
+	fmt.Fprintf(tw, "Term\t%d\n", summary.Term)
+	fmt.Fprintf(tw, "Cluster ID\t%s\n", summary.ClusterID)
+	names := make([]string, 0, len(summary.Records))
+	for name := range summary.Records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%d\n", name, summary.Records[name])
+	}
+	if err := tw.Flush(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error rendering snapshot summary: %s", err))
+		return 1
+	}
+
+	c.UI.Info("Snapshot is valid and would restore:")
+	c.UI.Info(b.String())
 	return 0
 }
 
@@ -103,5 +154,10 @@ Usage: consul snapshot restore [options] FILE
 
     $ consul snapshot restore backup.snap
 
+  The snapshot is verified before it's restored. To only verify it and see
+  what would be restored:
+
+    $ consul snapshot restore -dry-run backup.snap
+
   For a full list of options and examples, please see the Consul documentation.
 `
@@ -2,6 +2,7 @@ package restore
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"strings"
@@ -100,3 +101,55 @@ func TestSnapshotRestoreCommand(t *testing.T) {
 		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestSnapshotRestoreCommand_DryRun(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	snap, _, err := client.Snapshot().Save(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err := ioutil.ReadAll(snap)
+	snap.Close()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	file := path.Join(dir, "backup.tgz")
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-dry-run", file})
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{"Snapshot is valid", "Index", "Cluster ID", "Registration"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q to contain %q", output, want)
+		}
+	}
+
+	// A corrupted snapshot isn't sent to the servers.
+	data[len(data)/2] ^= 0xff
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ui = cli.NewMockUi()
+	c = New(ui)
+	code = c.Run([]string{"-http-addr=" + a.HTTPAddr(), file})
+	if code == 0 {
+		t.Fatal("expected non-zero exit")
+	}
+	if output := ui.ErrorWriter.String(); !strings.Contains(output, "Error verifying snapshot") {
+		t.Fatalf("bad: %s", output)
+	}
+}
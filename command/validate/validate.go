@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 
@@ -21,14 +22,24 @@ type cmd struct {
 	// configFormat forces all config files to be interpreted as this
 	// format independent of their extension.
 	configFormat string
+	format       string
 	quiet        bool
 	help         string
 }
 
+// result is the output of -format=json.
+type result struct {
+	Valid       bool
+	Diagnostics []config.Diagnostic
+}
+
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.StringVar(&c.configFormat, "config-format", "",
 		"Config files are in this format irrespective of their extension. Must be 'hcl' or 'json'")
+	c.flags.StringVar(&c.format, "format", "pretty",
+		"Output format of the diagnostics. Must be 'pretty' or 'json'. The JSON output "+
+			"lists every problem with its file, position, severity and a suggested fix.")
 	c.flags.BoolVar(&c.quiet, "quiet", false,
 		"When given, a successful run will produce no output.")
 	c.help = flags.Usage(help, c.flags)
@@ -51,21 +62,72 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.format != "pretty" && c.format != "json" {
+		c.UI.Error("-format must be either 'pretty' or 'json'")
+		return 1
+	}
+
+	var diags []config.Diagnostic
 	b, err := config.NewBuilder(config.Flags{ConfigFiles: configFiles, ConfigFormat: &c.configFormat})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Config validation failed: %v", err.Error()))
-		return 1
+		diags = []config.Diagnostic{{Severity: config.SeverityError, Message: err.Error()}}
+	} else {
+		diags = b.Diagnose()
 	}
-	if _, err := b.BuildAndValidate(); err != nil {
-		c.UI.Error(fmt.Sprintf("Config validation failed: %v", err.Error()))
-		return 1
+
+	valid := true
+	for _, d := range diags {
+		if d.Severity == config.SeverityError {
+			valid = false
+		}
 	}
-	if !c.quiet {
-		c.UI.Output("Configuration is valid!")
+
+	if c.format == "json" {
+		out, err := json.MarshalIndent(result{Valid: valid, Diagnostics: diags}, "", "    ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding diagnostics: %s", err))
+			return 1
+		}
+		c.UI.Output(string(out))
+	} else {
+		if !valid {
+			c.UI.Error("Config validation failed:")
+		}
+		for _, d := range diags {
+			if d.Severity == config.SeverityError {
+				c.UI.Error(formatDiagnostic(d))
+			} else if !c.quiet {
+				c.UI.Warn(formatDiagnostic(d))
+			}
+		}
+		if valid && !c.quiet {
+			c.UI.Output("Configuration is valid!")
+		}
+	}
+
+	if !valid {
+		return 1
 	}
 	return 0
 }
 
+// formatDiagnostic formats a diagnostic as file:line:column: severity:
+// message followed by the suggested fix on a separate line.
+func formatDiagnostic(d config.Diagnostic) string {
+	var pos string
+	switch {
+	case d.File != "" && d.Line > 0:
+		pos = fmt.Sprintf("%s:%d:%d: ", d.File, d.Line, d.Column)
+	case d.File != "":
+		pos = d.File + ": "
+	}
+	s := fmt.Sprintf("%s%s: %s", pos, d.Severity, d.Message)
+	if d.Suggestion != "" {
+		s += "\n    " + d.Suggestion
+	}
+	return s
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -88,5 +150,10 @@ Usage: consul validate [options] FILE_OR_DIRECTORY...
   to be loaded by the agent. This command cannot operate on partial
   configuration fragments since those won't pass the full agent validation.
 
+  Service and check definitions are validated per file so that all problems
+  are reported at once. With -format=json the diagnostics are written as JSON
+  including the file, position, severity and a suggested fix, which is
+  suitable for gating changes in CI.
+
   Returns 0 if the configuration is valid, or 1 if there are problems.
 `
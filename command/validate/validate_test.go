@@ -1,12 +1,14 @@
 package validate
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/testutil"
 	"github.com/mitchellh/cli"
 	require "github.com/stretchr/testify/require"
@@ -143,3 +145,33 @@ func TestValidateCommand_Quiet(t *testing.T) {
 	require.Equalf(t, 0, code, "return code - expected: 0, bad: %d, %s", code, ui.ErrorWriter.String())
 	require.Equal(t, "", ui.OutputWriter.String())
 }
+
+func TestValidateCommand_JSONDiagnostics(t *testing.T) {
+	t.Parallel()
+	td := testutil.TempDir(t, "consul")
+	defer os.RemoveAll(td)
+
+	fp := filepath.Join(td, "config.hcl")
+	err := ioutil.WriteFile(fp, []byte("data_dir = \""+td+"\"\nservices = [{\n  name = \"web\"\n  prot = 80\n}]\n"), 0644)
+	require.Nilf(t, err, "err: %s", err)
+
+	ui := cli.NewMockUi()
+	cmd := New(ui)
+	args := []string{"-format", "json", td}
+
+	code := cmd.Run(args)
+	require.Equal(t, 1, code)
+
+	var out struct {
+		Valid       bool
+		Diagnostics []config.Diagnostic
+	}
+	require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+	require.False(t, out.Valid)
+	require.Len(t, out.Diagnostics, 1)
+	d := out.Diagnostics[0]
+	require.Equal(t, fp, d.File)
+	require.Equal(t, 4, d.Line)
+	require.Equal(t, config.SeverityError, d.Severity)
+	require.Equal(t, `Did you mean "port"?`, d.Suggestion)
+}
@@ -9,6 +9,7 @@ import (
 	osexec "os/exec"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/agent/exec"
@@ -41,6 +42,7 @@ type cmd struct {
 	state       string
 	name        string
 	shell       bool
+	template    string
 }
 
 func (c *cmd) init() {
@@ -67,6 +69,10 @@ func (c *cmd) init() {
 		"Specifies the states to watch. Optional for 'checks' type.")
 	c.flags.StringVar(&c.name, "name", "",
 		"Specifies an event name to watch. Only for 'event' type.")
+	c.flags.StringVar(&c.template, "template", "",
+		"Renders the result with the given Go template instead of printing it "+
+			"as JSON. The functions 'json', 'join' and 'string' are available "+
+			"to the template. Can't be used with a child process.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -87,6 +93,21 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	// Parse the output template
+	var tmpl *template.Template
+	if c.template != "" {
+		if len(c.flags.Args()) > 0 {
+			c.UI.Error("Cannot specify -template with a child process")
+			return 1
+		}
+		var err error
+		tmpl, err = template.New("watch").Funcs(templateFuncs).Parse(c.template)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error parsing template: %s", err))
+			return 1
+		}
+	}
+
 	// Compile the watch parameters
 	params := make(map[string]interface{})
 	if c.watchType != "" {
@@ -158,7 +179,18 @@ func (c *cmd) Run(args []string) int {
 	//	0: false
 	//	1: true
 	errExit := 0
-	if len(c.flags.Args()) == 0 {
+	if tmpl != nil {
+		wp.Handler = func(idx uint64, data interface{}) {
+			defer wp.Stop()
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				c.UI.Error(fmt.Sprintf("Error rendering template: %s", err))
+				errExit = 1
+				return
+			}
+			c.UI.Output(strings.TrimSuffix(buf.String(), "\n"))
+		}
+	} else if len(c.flags.Args()) == 0 {
 		wp.Handler = func(idx uint64, data interface{}) {
 			defer wp.Stop()
 			buf, err := json.MarshalIndent(data, "", "    ")
@@ -239,6 +271,26 @@ func (c *cmd) Run(args []string) int {
 	return errExit
 }
 
+// templateFuncs are the functions available to the -template flag.
+var templateFuncs = template.FuncMap{
+	// json encodes the value as JSON, for example to print a nested value.
+	"json": func(v interface{}) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+
+	// join joins the strings with the separator, for example the tags of a
+	// service.
+	"join": func(sep string, a []string) string {
+		return strings.Join(a, sep)
+	},
+
+	// string converts a byte slice such as the value of a key to a string.
+	"string": func(b []byte) string {
+		return string(b)
+	},
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -255,6 +307,13 @@ Usage: consul watch [options] [child...]
   is specified, it will be invoked with the latest results on changes. Otherwise,
   the latest values are dumped to stdout and the watch terminates.
 
+  The -template flag renders the latest values with a Go template instead
+  of dumping them as JSON. For example, to list the addresses of the healthy
+  instances of a service:
+
+      $ consul watch -type=service -service=web -passingonly=true \
+          -template='{{range .}}{{.Service.Address}} {{end}}'
+
   Providing the watch type is required, and other parameters may be required
   or supported depending on the watch type.
 `
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/mitchellh/cli"
 )
@@ -76,3 +77,110 @@ func TestWatchCommandNoAgentService(t *testing.T) {
 		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
 	}
 }
+
+func TestWatchCommand_Template(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ui := cli.NewMockUi()
+	c := New(ui, nil)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-type=nodes",
+		`-template={{range .}}{{.Node}} {{.Address}}{{"\n"}}{{end}}`}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	want := a.Config.NodeName + " 127.0.0.1\n"
+	if got := ui.OutputWriter.String(); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestWatchCommand_TemplateFuncs(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	client := a.Client()
+	if _, err := client.KV().Put(&api.KVPair{Key: "foo", Value: []byte("bar"), Flags: 42}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, err := client.Catalog().Register(&api.CatalogRegistration{
+		Node:    "web1",
+		Address: "10.0.0.1",
+		Service: &api.AgentService{Service: "web", Tags: []string{"a", "b"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := map[string]struct {
+		args []string
+		want string
+	}{
+		"string and json": {
+			[]string{"-type=key", "-key=foo", "-template={{string .Value}} {{json .Flags}}"},
+			"bar 42\n",
+		},
+		"join": {
+			[]string{"-type=services", `-template={{join "," .web}}`},
+			"a,b\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui, nil)
+			code := c.Run(append([]string{"-http-addr=" + a.HTTPAddr()}, tc.args...))
+			if code != 0 {
+				t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+			}
+			if got := ui.OutputWriter.String(); got != tc.want {
+				t.Fatalf("got %q want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWatchCommand_TemplateErrors(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	cases := map[string]struct {
+		args []string
+		err  string
+	}{
+		"parse": {
+			[]string{"-type=nodes", "-template={{range .}"},
+			"Error parsing template",
+		},
+		"child": {
+			[]string{"-type=nodes", "-template={{.}}", "cat"},
+			"Cannot specify -template with a child process",
+		},
+		"render": {
+			[]string{"-type=nodes", "-template={{.Missing}}"},
+			"Error rendering template",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui, nil)
+			code := c.Run(append([]string{"-http-addr=" + a.HTTPAddr()}, tc.args...))
+			if code != 1 {
+				t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), tc.err) {
+				t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package install
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/service_os"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	name        string
+	displayName string
+	description string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.name, "name", "consul",
+		"The name of the service.")
+	c.flags.StringVar(&c.displayName, "display-name", "Consul",
+		"The name of the service shown in the service manager.")
+	c.flags.StringVar(&c.description, "description", "HashiCorp Consul agent",
+		"The description of the service shown in the service manager.")
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.name == "" {
+		c.UI.Error("Missing the service name")
+		return 1
+	}
+
+	// All remaining arguments are passed to the agent.
+	if err := service_os.Install(c.name, c.displayName, c.description, c.flags.Args()); err != nil {
+		c.UI.Error(fmt.Sprintf("Error installing service: %s", err))
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Installed service %q", c.name))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Installs the Consul agent as a Windows service"
+const help = `
+Usage: consul windows-service install [options] [-- agent args]
+
+  Installs the Consul agent as a Windows service that starts automatically
+  when the system boots. Any arguments after the options are passed to
+  "consul agent" when the service starts. It must be run by an administrator.
+
+  The event source used by the agent's -eventlog option is registered as
+  well, so the agent can log to the Windows event log:
+
+      $ consul windows-service install -- -config-dir=C:\consul\config -eventlog
+
+  The service can then be started with the Windows service manager:
+
+      $ sc.exe start consul
+`
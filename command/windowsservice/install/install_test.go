@@ -0,0 +1,32 @@
+package install
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestWindowsServiceInstallCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestWindowsServiceInstallCommand_unsupportedOS(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("only applies to other platforms")
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "only supported on Windows") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
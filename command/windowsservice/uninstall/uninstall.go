@@ -0,0 +1,69 @@
+package uninstall
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/service_os"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	name string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.name, "name", "consul",
+		"The name of the service.")
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	if len(c.flags.Args()) > 0 {
+		c.UI.Error(fmt.Sprintf("Error found unexpected args: %v", c.flags.Args()))
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	if err := service_os.Uninstall(c.name); err != nil {
+		c.UI.Error(fmt.Sprintf("Error uninstalling service: %s", err))
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Uninstalled service %q", c.name))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Uninstalls the Consul agent Windows service"
+const help = `
+Usage: consul windows-service uninstall [options]
+
+  Stops and removes the Consul agent Windows service along with the event
+  source registered when it was installed. It must be run by an
+  administrator.
+
+      $ consul windows-service uninstall
+`
@@ -0,0 +1,43 @@
+package windowsservice
+
+import (
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New() *cmd {
+	return &cmd{}
+}
+
+type cmd struct{}
+
+func (c *cmd) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return flags.Usage(help, nil)
+}
+
+const synopsis = "Manage the Consul agent as a Windows service"
+const help = `
+Usage: consul windows-service <subcommand> [options] [args]
+
+  This command has subcommands for installing the Consul agent as a native
+  Windows service and removing it again. When started by the service manager,
+  the agent runs as a service and stops gracefully when the service is stopped.
+
+  Install the service, passing arguments to "consul agent":
+
+      $ consul windows-service install -- -config-dir=C:\consul\config
+
+  Uninstall the service:
+
+      $ consul windows-service uninstall
+
+  For more examples, ask for subcommand help or view the documentation.
+`
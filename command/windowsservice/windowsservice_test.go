@@ -0,0 +1,13 @@
+package windowsservice
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWindowsServiceCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New().Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	agConnect "github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/connect"
+)
+
+// upstreamBalancer implements connect.Balancer for an upstream listener. It
+// picks instances according to the configured lb_policy and, if a passive
+// health check is configured, skips instances that recently failed to accept
+// connections.
+type upstreamBalancer struct {
+	policy string
+	check  *structs.PassiveHealthCheck
+
+	lock     sync.Mutex
+	next     int
+	active   map[string]int
+	failures map[string]int
+	ejected  map[string]time.Time
+}
+
+func newUpstreamBalancer(cfg structs.UpstreamConfig) *upstreamBalancer {
+	return &upstreamBalancer{
+		policy:   cfg.LBPolicy,
+		check:    cfg.PassiveHealthCheck,
+		active:   make(map[string]int),
+		failures: make(map[string]int),
+		ejected:  make(map[string]time.Time),
+	}
+}
+
+// Pick implements connect.Balancer.
+func (b *upstreamBalancer) Pick(addrs []string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// Only consider instances that are not ejected unless all of them are in
+	// which case ejecting doesn't help.
+	now := time.Now()
+	candidates := make([]int, 0, len(addrs))
+	for i, addr := range addrs {
+		if until, ok := b.ejected[addr]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(b.ejected, addr)
+		}
+		candidates = append(candidates, i)
+	}
+	if len(candidates) == 0 {
+		for i := range addrs {
+			candidates = append(candidates, i)
+		}
+	}
+
+	switch b.policy {
+	case structs.UpstreamLBPolicyRoundRobin:
+		idx := candidates[b.next%len(candidates)]
+		b.next++
+		return idx
+
+	case structs.UpstreamLBPolicyLeastRequest:
+		// Start at a random offset so ties are spread over the instances.
+		offset := rand.Intn(len(candidates))
+		best := candidates[offset]
+		for i := range candidates {
+			idx := candidates[(offset+i)%len(candidates)]
+			if b.active[addrs[idx]] < b.active[addrs[best]] {
+				best = idx
+			}
+		}
+		return best
+
+	default:
+		return candidates[rand.Intn(len(candidates))]
+	}
+}
+
+// connected records a successful connection to addr.
+func (b *upstreamBalancer) connected(addr string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.active[addr]++
+	delete(b.failures, addr)
+}
+
+// closed records that a connection to addr was closed.
+func (b *upstreamBalancer) closed(addr string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.active[addr]--
+	if b.active[addr] <= 0 {
+		delete(b.active, addr)
+	}
+}
+
+// failed records a failed connection attempt to addr and ejects it after too
+// many consecutive failures.
+func (b *upstreamBalancer) failed(addr string) {
+	if b.check == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failures[addr]++
+	if b.failures[addr] >= b.check.MaxFailures {
+		b.ejected[addr] = time.Now().Add(b.check.Interval)
+		delete(b.failures, addr)
+	}
+}
+
+// recordingResolver wraps a resolver to remember the address it resolved so
+// the outcome of the dial can be attributed to the instance.
+type recordingResolver struct {
+	connect.Resolver
+	addr string
+}
+
+func (r *recordingResolver) Resolve(ctx context.Context) (string, agConnect.CertURI, error) {
+	addr, certURI, err := r.Resolver.Resolve(ctx)
+	r.addr = addr
+	return addr, certURI, err
+}
+
+// upstreamConn is a connection to an upstream instance that reports its
+// closing to the balancer.
+type upstreamConn struct {
+	net.Conn
+	closeOnce sync.Once
+	onClose   func()
+}
+
+func (c *upstreamConn) Close() error {
+	c.closeOnce.Do(c.onClose)
+	return c.Conn.Close()
+}
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamBalancer_RoundRobin(t *testing.T) {
+	b := newUpstreamBalancer(structs.UpstreamConfig{LBPolicy: structs.UpstreamLBPolicyRoundRobin})
+	addrs := []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"}
+
+	var got []int
+	for i := 0; i < 6; i++ {
+		got = append(got, b.Pick(addrs))
+	}
+	require.Equal(t, []int{0, 1, 2, 0, 1, 2}, got)
+}
+
+func TestUpstreamBalancer_LeastRequest(t *testing.T) {
+	b := newUpstreamBalancer(structs.UpstreamConfig{LBPolicy: structs.UpstreamLBPolicyLeastRequest})
+	addrs := []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"}
+
+	b.connected(addrs[0])
+	b.connected(addrs[1])
+	require.Equal(t, 2, b.Pick(addrs))
+
+	b.connected(addrs[2])
+	b.connected(addrs[2])
+	b.closed(addrs[1])
+	require.Equal(t, 1, b.Pick(addrs))
+}
+
+func TestUpstreamBalancer_PassiveHealthCheck(t *testing.T) {
+	b := newUpstreamBalancer(structs.UpstreamConfig{
+		LBPolicy: structs.UpstreamLBPolicyRoundRobin,
+		PassiveHealthCheck: &structs.PassiveHealthCheck{
+			MaxFailures: 2,
+			Interval:    50 * time.Millisecond,
+		},
+	})
+	addrs := []string{"10.0.0.1:80", "10.0.0.2:80"}
+
+	// A single failure doesn't eject the instance.
+	b.failed(addrs[0])
+	require.Equal(t, 0, b.Pick(addrs))
+	require.Equal(t, 1, b.Pick(addrs))
+
+	b.failed(addrs[0])
+	for i := 0; i < 4; i++ {
+		require.Equal(t, 1, b.Pick(addrs))
+	}
+
+	// If every instance is ejected they are all considered again.
+	b.failed(addrs[1])
+	b.failed(addrs[1])
+	require.Len(t, b.ejected, 2)
+	b.Pick(addrs)
+
+	// Ejected instances return after the interval.
+	time.Sleep(60 * time.Millisecond)
+	b.Pick(addrs)
+	require.Len(t, b.ejected, 0)
+}
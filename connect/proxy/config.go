@@ -7,6 +7,7 @@ import (
 
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/connect"
 	"github.com/hashicorp/consul/lib"
@@ -69,6 +70,19 @@ type PublicListenerConfig struct {
 	// handshake. Setting this low avoids DOS by malicious clients holding
 	// resources open. Defaults to 10000 (10s).
 	HandshakeTimeoutMs int `json:"handshake_timeout_ms" hcl:"handshake_timeout_ms" mapstructure:"handshake_timeout_ms"`
+
+	// TCPKeepaliveIntervalMs is the TCP keepalive period of incoming
+	// connections and the connections to the local backend. Zero uses the
+	// system default and negative values disable keepalives.
+	TCPKeepaliveIntervalMs int `json:"tcp_keepalive_interval_ms" hcl:"tcp_keepalive_interval_ms" mapstructure:"tcp_keepalive_interval_ms"`
+
+	// MaxConnections limits the number of concurrent incoming connections.
+	// Further connections are closed right away. Zero means no limit.
+	MaxConnections int `json:"max_connections" hcl:"max_connections" mapstructure:"max_connections"`
+
+	// IdleTimeoutMs is the time a connection may be idle, with no data sent
+	// in either direction, before it is closed. Zero means no timeout.
+	IdleTimeoutMs int `json:"idle_timeout_ms" hcl:"idle_timeout_ms" mapstructure:"idle_timeout_ms"`
 }
 
 // applyDefaults sets zero-valued params to a sane default.
@@ -88,13 +102,18 @@ func (plc *PublicListenerConfig) applyDefaults() {
 // way but define custom methods for accessing the opaque config metadata.
 type UpstreamConfig api.Upstream
 
+// defaultUpstreamConnectTimeout is used for upstreams that don't configure
+// connect_timeout_ms.
+const defaultUpstreamConnectTimeout = 10000 * time.Millisecond
+
 // ConnectTimeout returns the connect timeout field of the nested config struct
 // or the default value.
 func (uc *UpstreamConfig) ConnectTimeout() time.Duration {
-	if ms, ok := uc.Config["connect_timeout_ms"].(int); ok {
-		return time.Duration(ms) * time.Millisecond
+	cfg, err := structs.ParseUpstreamConfig(uc.Config)
+	if err != nil {
+		return defaultUpstreamConnectTimeout
 	}
-	return 10000 * time.Millisecond
+	return cfg.ConnectTimeout(defaultUpstreamConnectTimeout)
 }
 
 // applyDefaults sets zero-valued params to a sane default.
@@ -1,9 +1,11 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"io"
 	"net"
 	"sync/atomic"
+	"time"
 )
 
 // Conn represents a single proxied TCP connection.
@@ -99,3 +101,43 @@ func (cw *countWriter) Write(p []byte) (n int, err error) {
 func (cw *countWriter) Written() uint64 {
 	return atomic.LoadUint64(&cw.written)
 }
+
+// idleConn is a net.Conn that is closed after being idle, with no data read
+// or written, for idleTimeout.
+type idleConn struct {
+	net.Conn
+	idleTimeout time.Duration
+}
+
+func (c *idleConn) Read(b []byte) (int, error) {
+	c.Conn.SetDeadline(time.Now().Add(c.idleTimeout))
+	return c.Conn.Read(b)
+}
+
+func (c *idleConn) Write(b []byte) (int, error) {
+	c.Conn.SetDeadline(time.Now().Add(c.idleTimeout))
+	return c.Conn.Write(b)
+}
+
+// setKeepAlive sets the TCP keepalive period of conn, which may be a TLS
+// connection. Zero leaves the current setting and negative values disable
+// keepalives.
+func setKeepAlive(conn net.Conn, period time.Duration) error {
+	if period == 0 {
+		return nil
+	}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	if period < 0 {
+		return tcpConn.SetKeepAlive(false)
+	}
+	if err := tcpConn.SetKeepAlive(true); err != nil {
+		return err
+	}
+	return tcpConn.SetKeepAlivePeriod(period)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/connect"
 )
@@ -33,6 +34,10 @@ type Listener struct {
 	dialFunc   func() (net.Conn, error)
 	bindAddr   string
 
+	// connLimit holds a token for every open connection if the number of
+	// connections is limited, and is nil otherwise.
+	connLimit chan struct{}
+
 	stopFlag int32
 	stopChan chan struct{}
 
@@ -59,16 +64,35 @@ type Listener struct {
 func NewPublicListener(svc *connect.Service, cfg PublicListenerConfig,
 	logger *log.Logger) *Listener {
 	bindAddr := fmt.Sprintf("%s:%d", cfg.BindAddress, cfg.BindPort)
+	keepAlive := time.Duration(cfg.TCPKeepaliveIntervalMs) * time.Millisecond
 	return &Listener{
 		Service: svc,
 		listenFunc: func() (net.Listener, error) {
-			return tls.Listen("tcp", bindAddr, svc.ServerTLSConfig())
+			l, err := listenTCP(bindAddr, keepAlive)
+			if err != nil {
+				return nil, err
+			}
+			return tls.NewListener(l, svc.ServerTLSConfig()), nil
 		},
 		dialFunc: func() (net.Conn, error) {
-			return net.DialTimeout("tcp", cfg.LocalServiceAddress,
-				time.Duration(cfg.LocalConnectTimeoutMs)*time.Millisecond)
+			dialer := net.Dialer{
+				Timeout:   time.Duration(cfg.LocalConnectTimeoutMs) * time.Millisecond,
+				KeepAlive: keepAlive,
+			}
+			conn, err := dialer.Dial("tcp", cfg.LocalServiceAddress)
+			if err != nil {
+				return nil, err
+			}
+			if cfg.IdleTimeoutMs > 0 {
+				conn = &idleConn{
+					Conn:        conn,
+					idleTimeout: time.Duration(cfg.IdleTimeoutMs) * time.Millisecond,
+				}
+			}
+			return conn, nil
 		},
 		bindAddr:      bindAddr,
+		connLimit:     newConnLimit(cfg.MaxConnections),
 		stopChan:      make(chan struct{}),
 		listeningChan: make(chan struct{}),
 		logger:        logger,
@@ -97,22 +121,52 @@ func newUpstreamListenerWithResolver(svc *connect.Service, cfg UpstreamConfig,
 	resolverFunc func(UpstreamConfig) (connect.Resolver, error),
 	logger *log.Logger) *Listener {
 	bindAddr := fmt.Sprintf("%s:%d", cfg.LocalBindAddress, cfg.LocalBindPort)
+	settings, err := structs.ParseUpstreamConfig(cfg.Config)
+	if err != nil {
+		logger.Printf("[WARN] invalid config for upstream %s, using defaults: %s",
+			cfg.String(), err)
+	}
+	balancer := newUpstreamBalancer(settings)
 	return &Listener{
 		Service: svc,
 		listenFunc: func() (net.Listener, error) {
-			return net.Listen("tcp", bindAddr)
+			return listenTCP(bindAddr, settings.TCPKeepalive())
 		},
 		dialFunc: func() (net.Conn, error) {
 			rf, err := resolverFunc(cfg)
 			if err != nil {
 				return nil, err
 			}
+			if cr, ok := rf.(*connect.ConsulResolver); ok {
+				cr.Balancer = balancer
+			}
 			ctx, cancel := context.WithTimeout(context.Background(),
-				cfg.ConnectTimeout())
+				settings.ConnectTimeout(defaultUpstreamConnectTimeout))
 			defer cancel()
-			return svc.Dial(ctx, rf)
+
+			r := &recordingResolver{Resolver: rf}
+			conn, err := svc.Dial(ctx, r)
+			if err != nil {
+				if r.addr != "" {
+					balancer.failed(r.addr)
+				}
+				return nil, err
+			}
+			balancer.connected(r.addr)
+			if err := setKeepAlive(conn, settings.TCPKeepalive()); err != nil {
+				logger.Printf("[WARN] failed to set TCP keepalive for upstream %s: %s",
+					cfg.String(), err)
+			}
+			if timeout := settings.RequestTimeout(); timeout > 0 {
+				conn = &idleConn{Conn: conn, idleTimeout: timeout}
+			}
+			return &upstreamConn{
+				Conn:    conn,
+				onClose: func() { balancer.closed(r.addr) },
+			}, nil
 		},
 		bindAddr:      bindAddr,
+		connLimit:     newConnLimit(settings.MaxConnections),
 		stopChan:      make(chan struct{}),
 		listeningChan: make(chan struct{}),
 		logger:        logger,
@@ -151,12 +205,40 @@ func (l *Listener) Serve() error {
 			return err
 		}
 
+		if !l.acquireConn() {
+			l.logger.Printf("[WARN] rejected connection from %s: limit of %d connections reached",
+				conn.RemoteAddr(), cap(l.connLimit))
+			conn.Close()
+			continue
+		}
 		go l.handleConn(conn)
 	}
 }
 
+// acquireConn reserves a connection slot, returning false if the connection
+// limit is reached.
+func (l *Listener) acquireConn() bool {
+	if l.connLimit == nil {
+		return true
+	}
+	select {
+	case l.connLimit <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseConn frees the slot reserved by acquireConn.
+func (l *Listener) releaseConn() {
+	if l.connLimit != nil {
+		<-l.connLimit
+	}
+}
+
 // handleConn is the internal connection handler goroutine.
 func (l *Listener) handleConn(src net.Conn) {
+	defer l.releaseConn()
 	defer src.Close()
 
 	dst, err := l.dialFunc()
@@ -239,6 +321,23 @@ func (l *Listener) trackConn() func() {
 	}
 }
 
+// newConnLimit returns the connLimit for the given maximum number of
+// connections, where zero means no limit.
+func newConnLimit(max int) chan struct{} {
+	if max <= 0 {
+		return nil
+	}
+	return make(chan struct{}, max)
+}
+
+// listenTCP listens on addr with the given keepalive period for accepted
+// connections. Zero uses the system default and negative values disable
+// keepalives.
+func listenTCP(addr string, keepAlive time.Duration) (net.Listener, error) {
+	lc := net.ListenConfig{KeepAlive: keepAlive}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
 // Close terminates the listener and all active connections.
 func (l *Listener) Close() error {
 	oldFlag := atomic.SwapInt32(&l.stopFlag, 1)
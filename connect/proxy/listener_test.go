@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
@@ -17,6 +18,7 @@ import (
 	agConnect "github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/connect"
 	"github.com/hashicorp/consul/lib/freeport"
+	"github.com/hashicorp/consul/testutil/retry"
 )
 
 func testSetupMetrics(t *testing.T) *metrics.InmemSink {
@@ -219,3 +221,85 @@ func TestUpstreamListener(t *testing.T) {
 	assertAllTimeCounterValue(t, sink, "consul.proxy.test.upstream.tx_bytes;src=web;dst_type=service;dst=db", 11)
 	assertAllTimeCounterValue(t, sink, "consul.proxy.test.upstream.rx_bytes;src=web;dst_type=service;dst=db", 11)
 }
+
+func TestPublicListener_MaxConnections(t *testing.T) {
+	ca := agConnect.TestCA(t, nil)
+	ports := freeport.GetT(t, 1)
+
+	testApp := NewTestTCPServer(t)
+	defer testApp.Close()
+
+	cfg := PublicListenerConfig{
+		BindAddress:           "127.0.0.1",
+		BindPort:              ports[0],
+		LocalServiceAddress:   testApp.Addr().String(),
+		HandshakeTimeoutMs:    100,
+		LocalConnectTimeoutMs: 100,
+		MaxConnections:        1,
+	}
+
+	svc := connect.TestService(t, "db", ca)
+	l := NewPublicListener(svc, cfg, log.New(os.Stderr, "", log.LstdFlags))
+	go l.Serve()
+	defer l.Close()
+	l.Wait()
+
+	resolver := &connect.StaticResolver{
+		Addr:    TestLocalAddr(ports[0]),
+		CertURI: agConnect.TestSpiffeIDService(t, "db"),
+	}
+	conn, err := svc.Dial(context.Background(), resolver)
+	require.NoError(t, err)
+	TestEchoConn(t, conn, "")
+
+	// The second connection is closed before the handshake completes.
+	_, err = svc.Dial(context.Background(), resolver)
+	require.Error(t, err)
+
+	// Closing the first connection frees its slot.
+	conn.Close()
+	retry.Run(t, func(r *retry.R) {
+		conn, err := svc.Dial(context.Background(), resolver)
+		if err != nil {
+			r.Fatal(err)
+		}
+		conn.Close()
+	})
+}
+
+func TestPublicListener_IdleTimeout(t *testing.T) {
+	ca := agConnect.TestCA(t, nil)
+	ports := freeport.GetT(t, 1)
+
+	testApp := NewTestTCPServer(t)
+	defer testApp.Close()
+
+	cfg := PublicListenerConfig{
+		BindAddress:            "127.0.0.1",
+		BindPort:               ports[0],
+		LocalServiceAddress:    testApp.Addr().String(),
+		HandshakeTimeoutMs:     100,
+		LocalConnectTimeoutMs:  100,
+		TCPKeepaliveIntervalMs: 1000,
+		IdleTimeoutMs:          100,
+	}
+
+	svc := connect.TestService(t, "db", ca)
+	l := NewPublicListener(svc, cfg, log.New(os.Stderr, "", log.LstdFlags))
+	go l.Serve()
+	defer l.Close()
+	l.Wait()
+
+	conn, err := svc.Dial(context.Background(), &connect.StaticResolver{
+		Addr:    TestLocalAddr(ports[0]),
+		CertURI: agConnect.TestSpiffeIDService(t, "db"),
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	TestEchoConn(t, conn, "")
+
+	// The proxy closes the connection once it has been idle for long enough.
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+}
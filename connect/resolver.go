@@ -74,6 +74,19 @@ type ConsulResolver struct {
 
 	// Datacenter to resolve in, empty indicates agent's local DC.
 	Datacenter string
+
+	// Balancer picks the instance to connect to. If nil an instance is picked
+	// at (pseudo) random.
+	Balancer Balancer
+}
+
+// Balancer picks one of the healthy instances of a service for a new
+// connection.
+type Balancer interface {
+	// Pick returns the index of the instance to use given the "host:port"
+	// addresses of all healthy instances. It is only called with at least one
+	// address.
+	Pick(addrs []string) int
 }
 
 // Resolve performs service discovery against the local Consul agent and returns
@@ -101,13 +114,7 @@ func (cr *ConsulResolver) resolveService(ctx context.Context) (string, connect.C
 		return "", nil, fmt.Errorf("no healthy instances found")
 	}
 
-	// Services are not shuffled by HTTP API, pick one at (pseudo) random.
-	idx := 0
-	if len(svcs) > 1 {
-		idx = rand.Intn(len(svcs))
-	}
-
-	return cr.resolveServiceEntry(svcs[idx])
+	return cr.resolveServiceEntry(svcs[cr.pick(svcs)])
 }
 
 func (cr *ConsulResolver) resolveQuery(ctx context.Context) (string, connect.CertURI, error) {
@@ -116,27 +123,45 @@ func (cr *ConsulResolver) resolveQuery(ctx context.Context) (string, connect.Cer
 		return "", nil, err
 	}
 
-	svcs := resp.Nodes
-	if len(svcs) < 1 {
+	if len(resp.Nodes) < 1 {
 		return "", nil, fmt.Errorf("no healthy instances found")
 	}
 
+	svcs := make([]*api.ServiceEntry, len(resp.Nodes))
+	for i := range resp.Nodes {
+		svcs[i] = &resp.Nodes[i]
+	}
+	return cr.resolveServiceEntry(svcs[cr.pick(svcs)])
+}
+
+// pick returns the index of the instance to connect to.
+func (cr *ConsulResolver) pick(svcs []*api.ServiceEntry) int {
+	if cr.Balancer != nil {
+		addrs := make([]string, len(svcs))
+		for i, entry := range svcs {
+			addrs[i] = serviceEntryAddr(entry)
+		}
+		return cr.Balancer.Pick(addrs)
+	}
+
 	// Services are not shuffled by HTTP API, pick one at (pseudo) random.
 	idx := 0
 	if len(svcs) > 1 {
 		idx = rand.Intn(len(svcs))
 	}
-
-	return cr.resolveServiceEntry(&svcs[idx])
+	return idx
 }
 
-func (cr *ConsulResolver) resolveServiceEntry(entry *api.ServiceEntry) (string, connect.CertURI, error) {
+// serviceEntryAddr returns the "host:port" address of a service instance.
+func serviceEntryAddr(entry *api.ServiceEntry) string {
 	addr := entry.Service.Address
 	if addr == "" {
 		addr = entry.Node.Address
 	}
-	port := entry.Service.Port
+	return fmt.Sprintf("%s:%d", addr, entry.Service.Port)
+}
 
+func (cr *ConsulResolver) resolveServiceEntry(entry *api.ServiceEntry) (string, connect.CertURI, error) {
 	service := entry.Service.Proxy.DestinationServiceName
 	if entry.Service.Connect != nil && entry.Service.Connect.Native {
 		service = entry.Service.Service
@@ -156,7 +181,7 @@ func (cr *ConsulResolver) resolveServiceEntry(entry *api.ServiceEntry) (string,
 		Service:    service,
 	}
 
-	return fmt.Sprintf("%s:%d", addr, port), certURI, nil
+	return serviceEntryAddr(entry), certURI, nil
 }
 
 func (cr *ConsulResolver) queryOptions(ctx context.Context) *api.QueryOptions {
@@ -152,7 +152,7 @@ func (s *Service) Name() string {
 // handle the case that certificates expire and an error prevents timely
 // renewal.
 func (s *Service) ServerTLSConfig() *tls.Config {
-	return s.tlsCfg.Get(newServerSideVerifier(s.client, s.service))
+	return s.tlsCfg.Get(newServerSideVerifier(s.client, s.service, s.tlsCfg.FederatedRoots))
 }
 
 // Dial connects to a remote Connect-enabled server. The passed Resolver is used
@@ -277,6 +277,22 @@ func (s *Service) rootsWatchHandler(blockParam watch.BlockingParamVal, raw inter
 		roots.AppendCertsFromPEM([]byte(root.RootCertPEM))
 	}
 
+	// The roots of each federated trust domain are kept apart so they are
+	// only trusted for the workloads of that trust domain.
+	var federated map[string]*x509.CertPool
+	for _, root := range v.FederatedRoots {
+		if federated == nil {
+			federated = make(map[string]*x509.CertPool)
+		}
+		pool, ok := federated[root.ExternalTrustDomain]
+		if !ok {
+			pool = x509.NewCertPool()
+			federated[root.ExternalTrustDomain] = pool
+		}
+		pool.AppendCertsFromPEM([]byte(root.RootCertPEM))
+	}
+
+	s.tlsCfg.SetFederatedRoots(federated)
 	s.tlsCfg.SetRoots(roots)
 }
 
@@ -1,10 +1,12 @@
 package connect
 
 import (
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -205,9 +207,19 @@ func verifyServerCertMatchesURI(certs []*x509.Certificate,
 // api.Client to verify the TLS chain and perform AuthZ for the server end of
 // the connection. The service name provided is used as the target service name
 // for the Authorization.
-func newServerSideVerifier(client *api.Client, serviceName string) verifierFunc {
+//
+// federatedRoots returns the roots of the federated trust domains whose
+// workloads are accepted too, keyed by trust domain. It may be nil.
+func newServerSideVerifier(client *api.Client, serviceName string,
+	federatedRoots func() map[string]*x509.CertPool) verifierFunc {
 	return func(tlsCfg *tls.Config, rawCerts [][]byte) error {
 		leaf, err := verifyChain(tlsCfg, rawCerts, false)
+		federated := false
+		if err != nil && federatedRoots != nil {
+			if fLeaf, fErr := verifyFederatedChain(federatedRoots(), rawCerts); fErr == nil {
+				leaf, err, federated = fLeaf, nil, true
+			}
+		}
 		if err != nil {
 			log.Printf("connect: failed TLS verification: %s", err)
 			return err
@@ -219,7 +231,12 @@ func newServerSideVerifier(client *api.Client, serviceName string) verifierFunc
 			return errors.New("connect: invalid leaf certificate")
 		}
 
-		certURI, err := connect.ParseCertURI(leaf.URIs[0])
+		var certURI connect.CertURI
+		if federated {
+			certURI, err = connect.ParseFederatedCertURI(leaf.URIs[0], []string{leaf.URIs[0].Host})
+		} else {
+			certURI, err = connect.ParseCertURI(leaf.URIs[0])
+		}
 		if err != nil {
 			log.Printf("connect: invalid leaf certificate URI")
 			return errors.New("connect: invalid leaf certificate URI")
@@ -237,6 +254,11 @@ func newServerSideVerifier(client *api.Client, serviceName string) verifierFunc
 			ClientCertURI:    certURI.URI().String(),
 			ClientCertSerial: connect.HexString(leaf.SerialNumber.Bytes()),
 		}
+		if federated {
+			// The agent only trusts a federated workload once it has checked
+			// the chain against the root of its own trust domain.
+			req.ClientCertPEM = encodeCertsPEM(rawCerts)
+		}
 		resp, err := client.Agent().ConnectAuthorize(req)
 		if err != nil {
 			log.Printf("connect: authz call failed: %s", err)
@@ -263,18 +285,9 @@ func clientSideVerifier(tlsCfg *tls.Config, rawCerts [][]byte) error {
 // verifyChain performs standard TLS verification without enforcing remote
 // hostname matching.
 func verifyChain(tlsCfg *tls.Config, rawCerts [][]byte, client bool) (*x509.Certificate, error) {
-
-	// Fetch leaf and intermediates. This is based on code form tls handshake.
-	if len(rawCerts) < 1 {
-		return nil, errors.New("tls: no certificates from peer")
-	}
-	certs := make([]*x509.Certificate, len(rawCerts))
-	for i, asn1Data := range rawCerts {
-		cert, err := x509.ParseCertificate(asn1Data)
-		if err != nil {
-			return nil, errors.New("tls: failed to parse certificate from peer: " + err.Error())
-		}
-		certs[i] = cert
+	certs, err := parsePeerCerts(rawCerts)
+	if err != nil {
+		return nil, err
 	}
 
 	cas := tlsCfg.RootCAs
@@ -297,10 +310,66 @@ func verifyChain(tlsCfg *tls.Config, rawCerts [][]byte, client bool) (*x509.Cert
 	for _, cert := range certs[1:] {
 		opts.Intermediates.AddCert(cert)
 	}
-	_, err := certs[0].Verify(opts)
+	_, err = certs[0].Verify(opts)
 	return certs[0], err
 }
 
+// verifyFederatedChain verifies the client certificate of a workload of a
+// federated trust domain. The chain is only verified against the roots of the
+// trust domain in the URI of the leaf, so a federated CA can neither issue
+// certificates for Consul services nor for other trust domains.
+func verifyFederatedChain(federated map[string]*x509.CertPool, rawCerts [][]byte) (*x509.Certificate, error) {
+	certs, err := parsePeerCerts(rawCerts)
+	if err != nil {
+		return nil, err
+	}
+	leaf := certs[0]
+	if len(leaf.URIs) < 1 {
+		return nil, errors.New("connect: invalid leaf certificate")
+	}
+	roots, ok := federated[leaf.URIs[0].Host]
+	if !ok {
+		return nil, errors.New("connect: leaf certificate is not in a federated trust domain")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(opts)
+	return leaf, err
+}
+
+// parsePeerCerts parses the leaf and intermediates presented by the peer. This
+// is based on code form tls handshake.
+func parsePeerCerts(rawCerts [][]byte) ([]*x509.Certificate, error) {
+	if len(rawCerts) < 1 {
+		return nil, errors.New("tls: no certificates from peer")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, asn1Data := range rawCerts {
+		cert, err := x509.ParseCertificate(asn1Data)
+		if err != nil {
+			return nil, errors.New("tls: failed to parse certificate from peer: " + err.Error())
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
+
+// encodeCertsPEM returns the PEM encoding of the DER-encoded certificates.
+func encodeCertsPEM(rawCerts [][]byte) string {
+	var buf bytes.Buffer
+	for _, raw := range rawCerts {
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: raw})
+	}
+	return buf.String()
+}
+
 // dynamicTLSConfig represents the state for returning a tls.Config that can
 // have root and leaf certificates updated dynamically with all existing clients
 // and servers automatically picking up the changes. It requires initializing
@@ -313,6 +382,9 @@ type dynamicTLSConfig struct {
 	sync.RWMutex
 	leaf  *tls.Certificate
 	roots *x509.CertPool
+	// federated are the roots of the federated trust domains keyed by trust
+	// domain. They are only used by verifiers, never by the TLS stack.
+	federated map[string]*x509.CertPool
 	// readyCh is closed when the config first gets both leaf and roots set.
 	// Watchers can wait on this via ReadyWait.
 	readyCh chan struct{}
@@ -360,6 +432,12 @@ func (cfg *dynamicTLSConfig) Get(v verifierFunc) *tls.Config {
 	copy := cfg.base.Clone()
 	copy.RootCAs = cfg.roots
 	copy.ClientCAs = cfg.roots
+	if v != nil && len(cfg.federated) > 0 && copy.ClientAuth == tls.RequireAndVerifyClientCert {
+		// The TLS stack would only accept client certificates signed by the
+		// roots of the cluster. The verifier checks the chain itself and binds
+		// each federated root to its trust domain.
+		copy.ClientAuth = tls.RequireAnyClientCert
+	}
 	if v != nil {
 		copy.VerifyPeerCertificate = func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
 			return v(cfg.Get(nil), rawCerts)
@@ -394,6 +472,21 @@ func (cfg *dynamicTLSConfig) SetRoots(roots *x509.CertPool) error {
 	return nil
 }
 
+// SetFederatedRoots sets the roots of the federated trust domains keyed by
+// trust domain.
+func (cfg *dynamicTLSConfig) SetFederatedRoots(federated map[string]*x509.CertPool) {
+	cfg.Lock()
+	defer cfg.Unlock()
+	cfg.federated = federated
+}
+
+// FederatedRoots returns the current roots of the federated trust domains.
+func (cfg *dynamicTLSConfig) FederatedRoots() map[string]*x509.CertPool {
+	cfg.RLock()
+	defer cfg.RUnlock()
+	return cfg.federated
+}
+
 // SetLeaf sets a new leaf.
 func (cfg *dynamicTLSConfig) SetLeaf(leaf *tls.Certificate) error {
 	cfg.Lock()
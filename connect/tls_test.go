@@ -4,6 +4,8 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"io/ioutil"
+	"net"
 	"testing"
 
 	"github.com/hashicorp/consul/testrpc"
@@ -241,7 +243,7 @@ func TestServerSideVerifier(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			v := newServerSideVerifier(client, tt.service)
+			v := newServerSideVerifier(client, tt.service, nil)
 			err := v(tt.tlsCfg, tt.rawCerts)
 			if tt.wantErr == "" {
 				require.Nil(t, err)
@@ -253,6 +255,105 @@ func TestServerSideVerifier(t *testing.T) {
 	}
 }
 
+func TestServerSideVerifier_federated(t *testing.T) {
+	ca := connect.TestCA(t, nil)
+	federatedCA := connect.TestCA(t, nil)
+	otherCA := connect.TestCA(t, nil)
+
+	apiID := &connect.SpiffeIDExternal{TrustDomain: "spire.example.org", Path: "/ns/prod/sa/api"}
+	apiPEM, _ := connect.TestLeafWithURI(t, apiID, federatedCA)
+	apiOtherPEM, _ := connect.TestLeafWithURI(t, apiID, otherCA)
+	// A certificate for the Consul service "web" issued by the federated CA.
+	webPEM, _ := connect.TestLeaf(t, "web", federatedCA)
+	otherID := &connect.SpiffeIDExternal{TrustDomain: "other.example.org", Path: "/ns/prod/sa/api"}
+	otherPEM, _ := connect.TestLeafWithURI(t, otherID, federatedCA)
+
+	federated := map[string]*x509.CertPool{
+		"spire.example.org": TestCAPool(t, federatedCA),
+		"other.example.org": TestCAPool(t, otherCA),
+	}
+
+	tests := []struct {
+		name     string
+		rawCerts [][]byte
+		wantErr  string
+	}{
+		{
+			name:     "federated workload",
+			rawCerts: [][]byte{testCertPEMBlock(t, apiPEM)},
+		},
+		{
+			name:     "federated workload signed by another trust domain",
+			rawCerts: [][]byte{testCertPEMBlock(t, apiOtherPEM)},
+			wantErr:  "unknown authority",
+		},
+		{
+			name:     "consul service signed by federated CA",
+			rawCerts: [][]byte{testCertPEMBlock(t, webPEM)},
+			wantErr:  "unknown authority",
+		},
+		{
+			name:     "other trust domain signed by federated CA",
+			rawCerts: [][]byte{testCertPEMBlock(t, otherPEM)},
+			wantErr:  "unknown authority",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No AuthZ without a client, only the TLS verification.
+			v := newServerSideVerifier(nil, "db", func() map[string]*x509.CertPool {
+				return federated
+			})
+			err := v(TestTLSConfig(t, "db", ca), tt.rawCerts)
+			if tt.wantErr == "" {
+				require.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+
+	// The handshake accepts the federated workload but rejects a certificate
+	// issued by the federated CA for a Consul service.
+	cfg := newDynamicTLSConfig(TestTLSConfig(t, "db", ca), nil)
+	cfg.SetFederatedRoots(map[string]*x509.CertPool{
+		"spire.example.org": TestCAPool(t, federatedCA),
+	})
+	handshake := func(certPEM, keyPEM string) error {
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		require.NoError(t, err)
+		clientConn, serverConn := net.Pipe()
+		defer clientConn.Close()
+		defer serverConn.Close()
+
+		// The federated CA is not among the advertised client CAs, so present
+		// the certificate unconditionally like SPIFFE workloads do.
+		client := tls.Client(clientConn, &tls.Config{
+			InsecureSkipVerify: true,
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return &cert, nil
+			},
+		})
+		go func() {
+			// Keep reading so the server can send its alert after the client
+			// finished its side of the handshake.
+			if client.Handshake() == nil {
+				ioutil.ReadAll(client)
+			}
+		}()
+
+		server := tls.Server(serverConn, cfg.Get(newServerSideVerifier(nil, "db", cfg.FederatedRoots)))
+		return server.Handshake()
+	}
+
+	apiPEM, apiKey := connect.TestLeafWithURI(t, apiID, federatedCA)
+	require.NoError(t, handshake(apiPEM, apiKey))
+
+	webPEM, webKey := connect.TestLeaf(t, "web", federatedCA)
+	require.Error(t, handshake(webPEM, webKey))
+}
+
 // requireEqualTLSConfig compares tlsConfig fields we care about. Equal and even
 // cmp.Diff fail on tls.Config due to unexported fields in each. expectLeaf
 // allows expecting a leaf cert different from the one in expect
@@ -0,0 +1,164 @@
+// Package connlimit limits the rate at which a listener accepts connections
+// and the number of TLS handshakes it performs concurrently. It protects a
+// listener from clients that open large numbers of connections, since each
+// TLS handshake is expensive for the server.
+package connlimit
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// HandshakeTimeout is the time a client has to complete a TLS
+	// handshake.
+	HandshakeTimeout = 10 * time.Second
+
+	// logInterval is the minimum time between two log lines about rejected
+	// connections of a limiter.
+	logInterval = 10 * time.Second
+)
+
+// ErrTooManyHandshakes is returned when a TLS handshake is rejected because
+// the limit of concurrent handshakes is reached.
+var ErrTooManyHandshakes = errors.New("too many concurrent TLS handshakes")
+
+// Config holds the limits of a listener. Zero values disable a limit.
+type Config struct {
+	// AcceptRate is the number of connections accepted per second. Bursts
+	// of up to a second's worth of connections are allowed.
+	AcceptRate float64
+
+	// MaxConcurrentHandshakes is the maximum number of TLS handshakes in
+	// progress.
+	MaxConcurrentHandshakes int
+}
+
+// Limiter enforces the limits of a listener. Rejections are counted in the
+// "<metric prefix>.accept_rate_limited" and "<metric prefix>.handshake_limited"
+// metrics and logged at most every few seconds. A nil Limiter enforces no
+// limits.
+type Limiter struct {
+	metricPrefix []string
+	logPrefix    string
+	logger       *log.Logger
+
+	// limits holds the *limits currently enforced. It is replaced by
+	// SetConfig.
+	limits atomic.Value
+
+	// l guards the state of the log sampling.
+	l          sync.Mutex
+	lastLog    time.Time
+	suppressed int
+}
+
+// limits are the limits of a Config, ready to be enforced.
+type limits struct {
+	config     Config
+	accept     *rate.Limiter
+	handshakes chan struct{}
+}
+
+func newLimits(config Config) *limits {
+	l := &limits{config: config}
+	if config.AcceptRate > 0 {
+		burst := int(math.Ceil(config.AcceptRate))
+		l.accept = rate.NewLimiter(rate.Limit(config.AcceptRate), burst)
+	}
+	if config.MaxConcurrentHandshakes > 0 {
+		l.handshakes = make(chan struct{}, config.MaxConcurrentHandshakes)
+	}
+	return l
+}
+
+// New returns a limiter enforcing the given limits. Log lines are prefixed
+// with logPrefix, for example "consul.rpc".
+func New(config Config, metricPrefix []string, logPrefix string, logger *log.Logger) *Limiter {
+	l := &Limiter{
+		metricPrefix: metricPrefix,
+		logPrefix:    logPrefix,
+		logger:       logger,
+	}
+	l.SetConfig(config)
+	return l
+}
+
+// SetConfig replaces the limits of the limiter. Handshakes already in
+// progress don't count against the new limit of concurrent handshakes.
+func (l *Limiter) SetConfig(config Config) {
+	l.limits.Store(newLimits(config))
+}
+
+// Accept returns whether a newly accepted connection is within the accept
+// rate. The caller must close the connection otherwise.
+func (l *Limiter) Accept(conn net.Conn) bool {
+	if l == nil {
+		return true
+	}
+	lim := l.limits.Load().(*limits)
+	if lim.accept == nil || lim.accept.Allow() {
+		return true
+	}
+	metrics.IncrCounter(append(l.metricPrefix, "accept_rate_limited"), 1)
+	l.logRejected(conn, "accept rate of %g connections per second exceeded", lim.config.AcceptRate)
+	return false
+}
+
+// Handshake performs the handshake of a TLS connection within the limit of
+// concurrent handshakes and the handshake timeout. It returns
+// ErrTooManyHandshakes without reading from the connection if the limit is
+// reached. The caller must close the connection if an error is returned.
+func (l *Limiter) Handshake(conn *tls.Conn) error {
+	if l != nil {
+		if lim := l.limits.Load().(*limits); lim.handshakes != nil {
+			select {
+			case lim.handshakes <- struct{}{}:
+				defer func() { <-lim.handshakes }()
+			default:
+				metrics.IncrCounter(append(l.metricPrefix, "handshake_limited"), 1)
+				l.logRejected(conn, "limit of %d concurrent TLS handshakes reached", lim.config.MaxConcurrentHandshakes)
+				return ErrTooManyHandshakes
+			}
+		}
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		return err
+	}
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	return conn.SetDeadline(time.Time{})
+}
+
+// logRejected logs a rejected connection unless another one was logged
+// recently, in which case it's counted and reported with the next line.
+func (l *Limiter) logRejected(conn net.Conn, format string, args ...interface{}) {
+	if l.logger == nil {
+		return
+	}
+
+	l.l.Lock()
+	now := time.Now()
+	if now.Sub(l.lastLog) < logInterval {
+		l.suppressed++
+		l.l.Unlock()
+		return
+	}
+	suppressed := l.suppressed
+	l.lastLog, l.suppressed = now, 0
+	l.l.Unlock()
+
+	args = append(args, conn.RemoteAddr(), suppressed)
+	l.logger.Printf("[WARN] "+l.logPrefix+": rejecting connection, "+format+" from=%s (%d similar rejections not logged)", args...)
+}
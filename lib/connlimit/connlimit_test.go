@@ -0,0 +1,148 @@
+package connlimit
+
+import (
+	"bytes"
+	"crypto/tls"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func testTLSConfig(t *testing.T) *tls.Config {
+	cert, err := tls.LoadX509KeyPair("../../test/key/ourdomain.cer", "../../test/key/ourdomain.key")
+	require.NoError(t, err)
+	return &tls.Config{Certificates: []tls.Certificate{cert}}
+}
+
+func TestLimiter_Nil(t *testing.T) {
+	t.Parallel()
+
+	var l *Limiter
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	require.True(t, l.Accept(server))
+}
+
+func TestLimiter_Accept(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := New(Config{AcceptRate: 2}, []string{"test"}, "test", log.New(&buf, "", 0))
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// The burst is a second's worth of connections.
+	require.True(t, l.Accept(server))
+	require.True(t, l.Accept(server))
+	require.False(t, l.Accept(server))
+	require.False(t, l.Accept(server))
+
+	// Only the first rejection is logged, the second one is reported with
+	// the next log line.
+	require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+	require.Contains(t, buf.String(), "[WARN] test: rejecting connection, accept rate of 2 connections per second exceeded")
+	require.Equal(t, 1, l.suppressed)
+
+	// Connections are accepted again once tokens are available.
+	time.Sleep(600 * time.Millisecond)
+	require.True(t, l.Accept(server))
+}
+
+func TestLimiter_Handshake(t *testing.T) {
+	t.Parallel()
+
+	l := New(Config{MaxConcurrentHandshakes: 1}, []string{"test"}, "test", nil)
+	handshakes := l.limits.Load().(*limits).handshakes
+	config := testTLSConfig(t)
+
+	// A client that never sends a hello holds the only handshake slot.
+	stalled, stalledServer := net.Pipe()
+	defer stalled.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- l.Handshake(tls.Server(stalledServer, config))
+	}()
+	retry.Run(t, func(r *retry.R) {
+		if len(handshakes) != 1 {
+			r.Fatal("handshake not started")
+		}
+	})
+
+	client, server := net.Pipe()
+	defer client.Close()
+	require.Equal(t, ErrTooManyHandshakes, l.Handshake(tls.Server(server, config)))
+
+	// Once the stalled handshake fails the slot is released.
+	stalled.Close()
+	require.Error(t, <-errCh)
+	require.Len(t, handshakes, 0)
+
+	go func() {
+		errCh <- l.Handshake(tls.Server(server, config))
+	}()
+	tlsClient := tls.Client(client, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, tlsClient.Handshake())
+	require.NoError(t, <-errCh)
+}
+
+func TestLimiter_SetConfig(t *testing.T) {
+	t.Parallel()
+
+	l := New(Config{AcceptRate: 1}, []string{"test"}, "test", nil)
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	require.True(t, l.Accept(server))
+	require.False(t, l.Accept(server))
+
+	// Raising the accept rate takes effect right away.
+	l.SetConfig(Config{AcceptRate: 2})
+	require.True(t, l.Accept(server))
+	require.True(t, l.Accept(server))
+	require.False(t, l.Accept(server))
+
+	// As does removing it.
+	l.SetConfig(Config{})
+	require.True(t, l.Accept(server))
+}
+
+func TestTLSListener(t *testing.T) {
+	t.Parallel()
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l := NewTLSListener(inner, testTLSConfig(t), New(Config{AcceptRate: 1}, []string{"test"}, "test", nil))
+	defer l.Close()
+
+	dial := func() net.Conn {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return nil
+		}
+		return conn
+	}
+
+	// The first connection is accepted after its handshake.
+	conn := dial()
+	require.NotNil(t, conn)
+	defer conn.Close()
+	accepted, err := l.Accept()
+	require.NoError(t, err)
+	defer accepted.Close()
+	_, ok := accepted.(*tls.Conn)
+	require.True(t, ok)
+
+	// The second one exceeds the accept rate and is closed right away.
+	require.Nil(t, dial())
+
+	require.NoError(t, l.Close())
+	_, err = l.Accept()
+	require.Equal(t, errClosed, err)
+}
@@ -0,0 +1,100 @@
+package connlimit
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+)
+
+// tlsListener is a TLS listener that enforces the limits of a Limiter. The
+// handshakes are performed before the connections are returned by Accept so
+// that their concurrency can be limited and slow clients don't hold up the
+// server.
+type tlsListener struct {
+	net.Listener
+	config  *tls.Config
+	limiter *Limiter
+
+	conns   chan net.Conn
+	errs    chan error
+	closeCh chan struct{}
+	once    sync.Once
+}
+
+// NewTLSListener returns a listener that accepts connections from inner
+// within the limits of the given limiter and returns them once their TLS
+// handshake completed. Like tls.NewListener the config must contain at least
+// one certificate or set GetCertificate.
+func NewTLSListener(inner net.Listener, config *tls.Config, limiter *Limiter) net.Listener {
+	l := &tlsListener{
+		Listener: inner,
+		config:   config,
+		limiter:  limiter,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error),
+		closeCh:  make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// run accepts connections until the inner listener fails permanently.
+func (l *tlsListener) run() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			select {
+			case l.errs <- err:
+			case <-l.closeCh:
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+
+		if !l.limiter.Accept(conn) {
+			conn.Close()
+			continue
+		}
+		go l.handshake(conn)
+	}
+}
+
+// handshake hands the connection to Accept once its handshake succeeded.
+func (l *tlsListener) handshake(conn net.Conn) {
+	tlsConn := tls.Server(conn, l.config)
+	if err := l.limiter.Handshake(tlsConn); err != nil {
+		tlsConn.Close()
+		return
+	}
+	select {
+	case l.conns <- tlsConn:
+	case <-l.closeCh:
+		tlsConn.Close()
+	}
+}
+
+// Accept returns the next connection that completed its TLS handshake.
+func (l *tlsListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.closeCh:
+		return nil, errClosed
+	}
+}
+
+// Close closes the inner listener and the connections waiting to be
+// accepted.
+func (l *tlsListener) Close() error {
+	l.once.Do(func() { close(l.closeCh) })
+	return l.Listener.Close()
+}
+
+// errClosed is returned by Accept once the listener is closed.
+var errClosed = errors.New("listener closed")
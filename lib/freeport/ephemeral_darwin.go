@@ -0,0 +1,18 @@
+// +build darwin
+
+package freeport
+
+import (
+	"os/exec"
+)
+
+// ephemeralPortRange returns the range of ports the kernel assigns to
+// outgoing connections.
+func ephemeralPortRange() (int, int, error) {
+	out, err := exec.Command("/usr/sbin/sysctl", "-n",
+		"net.inet.ip.portrange.first", "net.inet.ip.portrange.last").Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	return parsePortRange(string(out))
+}
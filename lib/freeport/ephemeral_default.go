@@ -0,0 +1,10 @@
+// +build !linux,!darwin
+
+package freeport
+
+// ephemeralPortRange returns the range of ports the kernel assigns to
+// outgoing connections. Windows and the BSDs default to the dynamic port
+// range of the IANA.
+func ephemeralPortRange() (int, int, error) {
+	return 49152, 65535, nil
+}
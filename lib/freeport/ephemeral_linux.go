@@ -0,0 +1,19 @@
+// +build linux
+
+package freeport
+
+import (
+	"io/ioutil"
+)
+
+const ephemeralPortRangeProcFile = "/proc/sys/net/ipv4/ip_local_port_range"
+
+// ephemeralPortRange returns the range of ports the kernel assigns to
+// outgoing connections.
+func ephemeralPortRange() (int, int, error) {
+	data, err := ioutil.ReadFile(ephemeralPortRangeProcFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parsePortRange(string(data))
+}
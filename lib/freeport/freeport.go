@@ -1,13 +1,17 @@
 // Package freeport provides a helper for allocating free ports across multiple
 // processes on the same machine.
+//
+// Every process reserves a block of ports for its lifetime. The blocks are
+// claimed in order, so concurrently running test binaries each get their own
+// block instead of colliding on randomly picked ones, and blocks within the
+// kernel's ephemeral port range are skipped since outgoing connections may
+// take any port in it.
 package freeport
 
 import (
 	"fmt"
-	"math/rand"
 	"net"
 	"sync"
-	"time"
 
 	"github.com/mitchellh/go-testing-interface"
 )
@@ -18,16 +22,11 @@ const (
 	// application/test run.
 	blockSize = 1500
 
-	// maxBlocks is the number of available port blocks.
-	// lowPort + maxBlocks * blockSize must be less than 65535.
-	maxBlocks = 30
-
 	// lowPort is the lowest port number that should be used.
 	lowPort = 10000
 
-	// attempts is how often we try to allocate a port block
-	// before giving up.
-	attempts = 10
+	// maxPort is the highest port number that can be used.
+	maxPort = 65535
 )
 
 var (
@@ -50,30 +49,61 @@ var (
 
 // initialize is used to initialize freeport.
 func initialize() {
-	if lowPort+maxBlocks*blockSize > 65535 {
-		panic("freeport: block size too big or too many blocks requested")
-	}
+	// The range is only used to skip blocks so it is fine to ignore it if
+	// it can't be determined.
+	ephFirst, ephLast, _ := ephemeralPortRange()
+	firstPort, lockLn = alloc(blocks(ephFirst, ephLast))
+}
 
-	rand.Seed(time.Now().UnixNano())
-	firstPort, lockLn = alloc()
+// blocks returns the first ports of the port blocks in ascending order,
+// leaving out the blocks which overlap the ephemeral port range from ephFirst
+// to ephLast. A zero range doesn't exclude any block. All blocks are returned
+// if the range covers them all.
+func blocks(ephFirst, ephLast int) []int {
+	var all, free []int
+	for first := lowPort; first+blockSize-1 <= maxPort; first += blockSize {
+		all = append(all, first)
+		if ephFirst > 0 && first <= ephLast && first+blockSize-1 >= ephFirst {
+			continue
+		}
+		free = append(free, first)
+	}
+	if len(free) == 0 {
+		return all
+	}
+	return free
 }
 
-// alloc reserves a port block for exclusive use for the lifetime of the
-// application. lockLn serves as a system-wide mutex for the port block and is
-// implemented as a TCP listener which is bound to the firstPort and which will
-// be automatically released when the application terminates.
-func alloc() (int, net.Listener) {
-	for i := 0; i < attempts; i++ {
-		block := int(rand.Int31n(int32(maxBlocks)))
-		firstPort := lowPort + block*blockSize
+// alloc reserves the first available port block for exclusive use for the
+// lifetime of the application. lockLn serves as a system-wide mutex for the
+// port block and is implemented as a TCP listener which is bound to the
+// firstPort and which will be automatically released when the application
+// terminates. Trying the blocks in order means that processes started at the
+// same time only contend for a block until one of them wins it, after which
+// the other moves on to the next one.
+func alloc(firsts []int) (int, net.Listener) {
+	for _, firstPort := range firsts {
 		ln, err := net.ListenTCP("tcp", tcpAddr("127.0.0.1", firstPort))
 		if err != nil {
 			continue
 		}
-		// log.Printf("[DEBUG] freeport: allocated port block %d (%d-%d)", block, firstPort, firstPort+blockSize-1)
+		// log.Printf("[DEBUG] freeport: allocated port block %d-%d", firstPort, firstPort+blockSize-1)
 		return firstPort, ln
 	}
-	panic("freeport: cannot allocate port block")
+	panic(fmt.Sprintf("freeport: cannot allocate port block, all %d blocks are in use", len(firsts)))
+}
+
+// parsePortRange parses the two port numbers of a range separated by
+// whitespace, as in "32768	60999".
+func parsePortRange(s string) (int, int, error) {
+	var first, last int
+	if _, err := fmt.Sscan(s, &first, &last); err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %v", s, err)
+	}
+	if first <= 0 || last < first || last > maxPort {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return first, last, nil
 }
 
 func tcpAddr(ip string, port int) *net.TCPAddr {
@@ -0,0 +1,76 @@
+package freeport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocks(t *testing.T) {
+	t.Parallel()
+
+	all := blocks(0, 0)
+	require.Len(t, all, 37)
+	require.Equal(t, lowPort, all[0])
+	require.Equal(t, 64000, all[len(all)-1])
+
+	// The default range of Linux leaves the blocks below and above it.
+	linux := blocks(32768, 60999)
+	require.Len(t, linux, 18)
+	require.Equal(t, 31000, linux[14])
+	require.Equal(t, 61000, linux[15])
+
+	// A range covering every block excludes none.
+	require.Equal(t, all, blocks(1024, 65535))
+}
+
+func TestAlloc(t *testing.T) {
+	// Blocks held by another process are skipped.
+	firsts := blocks(32768, 60999)
+	first1, ln1 := alloc(firsts)
+	defer ln1.Close()
+	first2, ln2 := alloc(firsts)
+	defer ln2.Close()
+	require.True(t, first2 > first1)
+
+	ln1.Close()
+	first3, ln3 := alloc(firsts)
+	defer ln3.Close()
+	require.Equal(t, first1, first3)
+}
+
+func TestParsePortRange(t *testing.T) {
+	t.Parallel()
+
+	first, last, err := parsePortRange("32768\t60999\n")
+	require.NoError(t, err)
+	require.Equal(t, 32768, first)
+	require.Equal(t, 60999, last)
+
+	first, last, err = parsePortRange("49152\n65535\n")
+	require.NoError(t, err)
+	require.Equal(t, 49152, first)
+	require.Equal(t, 65535, last)
+
+	for _, s := range []string{"", "32768", "60999 32768", "0 100", "1024 70000"} {
+		_, _, err := parsePortRange(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestFree(t *testing.T) {
+	t.Parallel()
+
+	ports, err := Free(3)
+	require.NoError(t, err)
+	require.Len(t, ports, 3)
+	for _, port := range ports {
+		require.True(t, port > firstPort && port < firstPort+blockSize, "port %d", port)
+	}
+
+	ephFirst, ephLast, err := ephemeralPortRange()
+	if err == nil {
+		require.True(t, firstPort+blockSize-1 < ephFirst || firstPort > ephLast,
+			"block %d overlaps the ephemeral ports %d-%d", firstPort, ephFirst, ephLast)
+	}
+}
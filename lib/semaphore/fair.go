@@ -0,0 +1,135 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Fair implements a semaphore whose capacity can be changed dynamically at run
+// time like Dynamic. Unlike Dynamic, waiters are queued per key and released
+// slots are handed out to the keys in round-robin order. This way a single key
+// with many waiters can't starve all the others.
+type Fair struct {
+	size int64
+	cur  int64
+
+	// queues holds the waiters of each key in FIFO order. Only keys with
+	// waiters are present.
+	queues map[string]*list.List
+
+	// keys holds the keys with waiters in the order they are served next.
+	keys     list.List
+	keyElems map[string]*list.Element
+
+	mu sync.Mutex
+}
+
+// NewFair returns a fair semaphore with the given initial capacity. It's
+// possible to use a zero-value semaphore provided SetSize is called before use.
+func NewFair(n int64) *Fair {
+	return &Fair{
+		size: n,
+	}
+}
+
+// SetSize dynamically updates the number of available slots. If there are more
+// than n slots currently acquired, no further acquires will succeed until
+// sufficient have been released to take the total outstanding below n again.
+func (s *Fair) SetSize(n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = n
+	return nil
+}
+
+// Acquire attempts to acquire one "slot" in the semaphore for the given key,
+// blocking only until ctx is Done. On success, returns nil. On failure, returns
+// ctx.Err() and leaves the semaphore unchanged.
+//
+// If ctx is already done, Acquire may still succeed without blocking.
+func (s *Fair) Acquire(ctx context.Context, key string) error {
+	s.mu.Lock()
+	if s.cur < s.size {
+		s.cur++
+		s.mu.Unlock()
+		return nil
+	}
+
+	// Need to wait, add to the waiters of the key
+	if s.queues == nil {
+		s.queues = make(map[string]*list.List)
+		s.keyElems = make(map[string]*list.Element)
+	}
+	queue, ok := s.queues[key]
+	if !ok {
+		queue = list.New()
+		s.queues[key] = queue
+		s.keyElems[key] = s.keys.PushBack(key)
+	}
+	ready := make(chan struct{})
+	elem := queue.PushBack(ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		err := ctx.Err()
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Acquired the semaphore after we were canceled.  Rather than trying to
+			// fix up the queue, just pretend we didn't notice the cancellation.
+			err = nil
+		default:
+			queue.Remove(elem)
+			if queue.Len() == 0 {
+				s.removeKey(key)
+			}
+		}
+		s.mu.Unlock()
+		return err
+
+	case <-ready:
+		return nil
+	}
+}
+
+// Release releases the semaphore. It will panic if release is called on an
+// empty semphore.
+func (s *Fair) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cur < 1 {
+		panic("semaphore: bad release")
+	}
+
+	front := s.keys.Front()
+
+	// If there are no waiters, just decrement and we're done
+	if front == nil {
+		s.cur--
+		return
+	}
+
+	// Yield our slot to the next waiter of the next key. The key moves to the
+	// back of the line if it has more waiters.
+	key := front.Value.(string)
+	queue := s.queues[key]
+	next := queue.Front()
+	queue.Remove(next)
+	if queue.Len() == 0 {
+		s.removeKey(key)
+	} else {
+		s.keys.MoveToBack(front)
+	}
+	close(next.Value.(chan struct{}))
+	// Note we _don't_ decrement inflight since the slot was yielded directly.
+}
+
+// removeKey removes a key without waiters. The lock must be held.
+func (s *Fair) removeKey(key string) {
+	s.keys.Remove(s.keyElems[key])
+	delete(s.keyElems, key)
+	delete(s.queues, key)
+}
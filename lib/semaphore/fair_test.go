@@ -0,0 +1,111 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFair hammers the semaphore from all available cores to ensure we don't
+// hit a panic or race detector notice something wonky.
+func TestFair(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 1000 / n
+	sem := NewFair(int64(n))
+	var wg sync.WaitGroup
+	wg.Add(2 * n)
+	for i := 0; i < 2*n; i++ {
+		key := fmt.Sprintf("key-%d", i%3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < loops; j++ {
+				sem.Acquire(context.Background(), key)
+				time.Sleep(time.Microsecond)
+				sem.Release()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFairPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("release of an unacquired fair semaphore did not panic")
+		}
+	}()
+	w := NewFair(1)
+	w.Release()
+}
+
+func TestFairAcquire(t *testing.T) {
+	t.Parallel()
+
+	sem := NewFair(1)
+	require.NoError(t, sem.Acquire(context.Background(), "a"))
+
+	// Should fail to acquire another and leave no waiters behind.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Error(t, sem.Acquire(ctx, "a"))
+	require.Equal(t, 0, sem.keys.Len())
+	require.Empty(t, sem.queues)
+
+	// Growing the semaphore frees a slot.
+	sem.SetSize(2)
+	require.NoError(t, sem.Acquire(context.Background(), "b"))
+}
+
+func TestFairOrder(t *testing.T) {
+	t.Parallel()
+
+	sem := NewFair(1)
+	require.NoError(t, sem.Acquire(context.Background(), "busy"))
+
+	// Queue three waiters for one key and then one for another key. Each
+	// waiter is queued before the next one starts.
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	wait := func(key string, waiters int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, sem.Acquire(context.Background(), key))
+			mu.Lock()
+			order = append(order, key)
+			mu.Unlock()
+			sem.Release()
+		}()
+		for {
+			sem.mu.Lock()
+			n := 0
+			for _, q := range sem.queues {
+				n += q.Len()
+			}
+			sem.mu.Unlock()
+			if n == waiters {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wait("a", 1)
+	wait("a", 2)
+	wait("a", 3)
+	wait("b", 4)
+
+	// Releasing lets the keys take turns, so "b" doesn't wait for all of "a".
+	sem.Release()
+	wg.Wait()
+	require.Equal(t, []string{"a", "b", "a", "a"}, order)
+}
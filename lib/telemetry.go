@@ -2,6 +2,7 @@ package lib
 
 import (
 	"reflect"
+	"sync"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
@@ -145,6 +146,50 @@ type TelemetryConfig struct {
 	// hcl: telemetry { dogstatsd_tags = []string }
 	DogstatsdTags []string `json:"dogstatsd_tags,omitempty" mapstructure:"dogstatsd_tags"`
 
+	// OTLPEndpoint is the URL of the OTLP/HTTP metrics endpoint of an
+	// OpenTelemetry collector, such as "https://collector:4318/v1/metrics".
+	// If provided, metrics will be pushed to it every OTLPInterval.
+	//
+	// hcl: telemetry { otlp_endpoint = string }
+	OTLPEndpoint string `json:"otlp_endpoint,omitempty" mapstructure:"otlp_endpoint"`
+
+	// OTLPHeaders are the HTTP headers sent with each request to
+	// OTLPEndpoint and OTLPTracesEndpoint, for example to authenticate with
+	// the collector.
+	//
+	// hcl: telemetry { otlp_headers = map[string]string }
+	OTLPHeaders map[string]string `json:"otlp_headers,omitempty" mapstructure:"otlp_headers"`
+
+	// OTLPInterval is the interval over which metrics are aggregated before
+	// being pushed to OTLPEndpoint.
+	// Default: 10s
+	//
+	// hcl: telemetry { otlp_interval = "duration" }
+	OTLPInterval time.Duration `json:"otlp_interval,omitempty" mapstructure:"otlp_interval"`
+
+	// OTLPTracesEndpoint is the URL of the OTLP/HTTP traces endpoint of an
+	// OpenTelemetry collector, such as "https://collector:4318/v1/traces".
+	// If provided, the agent records spans for the API requests it handles
+	// and the RPCs made for them, and pushes them to it. It's only read when
+	// the agent starts.
+	//
+	// hcl: telemetry { otlp_traces_endpoint = string }
+	OTLPTracesEndpoint string `json:"otlp_traces_endpoint,omitempty" mapstructure:"otlp_traces_endpoint"`
+
+	// OTLPCAFile is the PEM encoded CA certificate used to verify the
+	// certificate of OTLPEndpoint and OTLPTracesEndpoint. If empty, the
+	// system roots are used.
+	//
+	// hcl: telemetry { otlp_ca_file = string }
+	OTLPCAFile string `json:"otlp_ca_file,omitempty" mapstructure:"otlp_ca_file"`
+
+	// OTLPCertFile and OTLPKeyFile are the PEM encoded client certificate
+	// and key presented to OTLPEndpoint and OTLPTracesEndpoint.
+	//
+	// hcl: telemetry { otlp_cert_file = string otlp_key_file = string }
+	OTLPCertFile string `json:"otlp_cert_file,omitempty" mapstructure:"otlp_cert_file"`
+	OTLPKeyFile  string `json:"otlp_key_file,omitempty" mapstructure:"otlp_key_file"`
+
 	// PrometheusRetentionTime is the retention time for prometheus metrics if greater than 0.
 	// A value of 0 disable Prometheus support. Regarding Prometheus, it is considered a good
 	// practice to put large values here (such as a few days), and at least the interval between
@@ -153,6 +198,21 @@ type TelemetryConfig struct {
 	// hcl: telemetry { prometheus_retention_time = "duration" }
 	PrometheusRetentionTime time.Duration `json:"prometheus_retention_time,omitempty" mapstructure:"prometheus_retention_time"`
 
+	// PrometheusHistogramBuckets are the upper bounds, in milliseconds, of the
+	// buckets of the histograms the timings matching
+	// PrometheusHistogramPrefixes are exported as. If empty, all timings are
+	// exported as summaries.
+	//
+	// hcl: telemetry { prometheus_histogram_buckets = []float64 }
+	PrometheusHistogramBuckets []float64 `json:"prometheus_histogram_buckets,omitempty" mapstructure:"prometheus_histogram_buckets"`
+
+	// PrometheusHistogramPrefixes are the prefixes of the timings exported as
+	// histograms when PrometheusHistogramBuckets is set.
+	// Default: the RPC, raft and HTTP timings
+	//
+	// hcl: telemetry { prometheus_histogram_prefixes = []string }
+	PrometheusHistogramPrefixes []string `json:"prometheus_histogram_prefixes,omitempty" mapstructure:"prometheus_histogram_prefixes"`
+
 	// FilterDefault is the default for whether to allow a metric that's not
 	// covered by the filter.
 	//
@@ -190,6 +250,15 @@ type TelemetryConfig struct {
 	//
 	// hcl: telemetry { statsite_address = string }
 	StatsiteAddr string `json:"statsite_address,omitempty" mapstructure:"statsite_address"`
+
+	// TokenRequestMetricsLimit is the number of ACL tokens whose HTTP API
+	// requests are counted with their accessor ID as a label. The requests
+	// of the other tokens are counted with the accessor ID "other". Zero
+	// disables the metrics.
+	// Default: 0
+	//
+	// hcl: telemetry { token_request_metrics_limit = int }
+	TokenRequestMetricsLimit int `json:"token_request_metrics_limit,omitempty" mapstructure:"token_request_metrics_limit"`
 }
 
 // MergeDefaults copies any non-zero field from defaults into the current
@@ -212,7 +281,7 @@ func (c *TelemetryConfig) MergeDefaults(defaults *TelemetryConfig) {
 		// implementing this for the types we actually have for now. Test failure
 		// should catch the case where we add new types later.
 		switch f.Kind() {
-		case reflect.Slice:
+		case reflect.Slice, reflect.Map:
 			if !f.IsNil() {
 				continue
 			}
@@ -254,23 +323,44 @@ func statsdSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, error
 	return metrics.NewStatsdSink(addr)
 }
 
+// telemetryLock serializes the calls to ReloadTelemetry, including the one
+// made by InitTelemetry, and guards the sinks kept between them.
+var telemetryLock sync.Mutex
+
+// dogSink is the DogStatsD sink created by the last call to dogstatdSink.
+// Its client can't be closed, so it is reused on reload while the address
+// stays the same. Guarded by telemetryLock.
+var dogSink *datadog.DogStatsdSink
+var dogSinkAddr string
+
 func dogstatdSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, error) {
 	addr := cfg.DogstatsdAddr
 	if addr == "" {
 		return nil, nil
 	}
-	sink, err := datadog.NewDogStatsdSink(addr, hostname)
-	if err != nil {
-		return nil, err
+	if dogSink == nil || dogSinkAddr != addr {
+		sink, err := datadog.NewDogStatsdSink(addr, hostname)
+		if err != nil {
+			return nil, err
+		}
+		dogSink, dogSinkAddr = sink, addr
 	}
-	sink.SetTags(cfg.DogstatsdTags)
-	return sink, nil
+	dogSink.SetTags(cfg.DogstatsdTags)
+	return dogSink, nil
 }
 
+// promSink is the Prometheus sink created by the first call to
+// prometheusSink. It is reused on reload since a sink can only be registered
+// with the global Prometheus registry once. Guarded by telemetryLock.
+var promSink metrics.MetricSink
+
 func prometheusSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, error) {
 	if cfg.PrometheusRetentionTime.Nanoseconds() < 1 {
 		return nil, nil
 	}
+	if promSink != nil {
+		return promSink, nil
+	}
 	prometheusOpts := prometheus.PrometheusOpts{
 		Expiration: cfg.PrometheusRetentionTime,
 	}
@@ -278,7 +368,15 @@ func prometheusSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, e
 	if err != nil {
 		return nil, err
 	}
-	return sink, nil
+	promSink = sink
+	if len(cfg.PrometheusHistogramBuckets) > 0 {
+		histogramSink, err := newPrometheusHistogramSink(sink, cfg)
+		if err != nil {
+			return nil, err
+		}
+		promSink = histogramSink
+	}
+	return promSink, nil
 }
 
 func circonusSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, error) {
@@ -331,6 +429,34 @@ func InitTelemetry(cfg TelemetryConfig) (*metrics.InmemSink, error) {
 	// metrics over stderr when there is a SIGUSR1 received.
 	memSink := metrics.NewInmemSink(10*time.Second, time.Minute)
 	metrics.DefaultInmemSignal(memSink)
+	if err := ReloadTelemetry(memSink, cfg); err != nil {
+		return nil, err
+	}
+	return memSink, nil
+}
+
+// activeSinks are the sinks installed by the last call to ReloadTelemetry,
+// without the in-memory sink. Guarded by telemetryLock.
+var activeSinks metrics.FanoutSink
+
+// sinkShutdownDelay is how long the sinks replaced by ReloadTelemetry keep
+// running, so that metrics still in flight to them don't hit a stopped sink.
+// Guarded by telemetryLock.
+var sinkShutdownDelay = 10 * time.Second
+
+// ReloadTelemetry rebuilds the metric sinks from the given config and
+// installs them as the global sink alongside the in-memory sink returned by
+// InitTelemetry. The sinks that were replaced are shut down after
+// sinkShutdownDelay.
+//
+// The Prometheus sink is registered with the process global Prometheus
+// registry and is therefore only created once. Changes to its retention
+// time or histograms require a restart. The OTLP sink pushes the metrics it
+// still holds when it is shut down.
+func ReloadTelemetry(memSink *metrics.InmemSink, cfg TelemetryConfig) error {
+	telemetryLock.Lock()
+	defer telemetryLock.Unlock()
+
 	metricsConf := metrics.DefaultConfig(cfg.MetricsPrefix)
 	metricsConf.EnableHostname = !cfg.DisableHostname
 	metricsConf.FilterDefault = cfg.FilterDefault
@@ -350,27 +476,70 @@ func InitTelemetry(cfg TelemetryConfig) (*metrics.InmemSink, error) {
 	}
 
 	if err := addSink("statsite", statsiteSink); err != nil {
-		return nil, err
+		return err
 	}
 	if err := addSink("statsd", statsdSink); err != nil {
-		return nil, err
+		return err
 	}
 	if err := addSink("dogstatd", dogstatdSink); err != nil {
-		return nil, err
+		return err
 	}
 	if err := addSink("circonus", circonusSink); err != nil {
-		return nil, err
+		return err
 	}
 	if err := addSink("prometheus", prometheusSink); err != nil {
-		return nil, err
+		return err
+	}
+	if err := addSink("otlp", otlpSink); err != nil {
+		return err
 	}
 
+	previous := activeSinks
+	activeSinks = sinks
 	if len(sinks) > 0 {
-		sinks = append(sinks, memSink)
-		metrics.NewGlobal(metricsConf, sinks)
+		metrics.NewGlobal(metricsConf, append(sinks, memSink))
 	} else {
 		metricsConf.EnableHostname = false
 		metrics.NewGlobal(metricsConf, memSink)
 	}
-	return memSink, nil
+
+	var replaced []metrics.MetricSink
+	for _, old := range previous {
+		if !containsSink(sinks, old) {
+			replaced = append(replaced, old)
+		}
+	}
+	if len(replaced) > 0 {
+		time.AfterFunc(sinkShutdownDelay, func() {
+			for _, old := range replaced {
+				shutdownSink(old)
+			}
+		})
+	}
+	return nil
+}
+
+func containsSink(sinks metrics.FanoutSink, sink metrics.MetricSink) bool {
+	for _, s := range sinks {
+		if s == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// shutdownSink stops the background work of a sink that is no longer used.
+// The Circonus sink can't be stopped and is flushed instead.
+func shutdownSink(sink metrics.MetricSink) {
+	switch s := sink.(type) {
+	case interface{ Shutdown() }:
+		// statsite and statsd
+		s.Shutdown()
+	case interface{ Stop() }:
+		// OTLP
+		s.Stop()
+	case interface{ Flush() }:
+		// Circonus
+		s.Flush()
+	}
 }
@@ -0,0 +1,353 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/tlsutil"
+)
+
+// otlpAggregationTemporalityDelta is the OTLP aggregation temporality of sums
+// reset at the start of each interval.
+const otlpAggregationTemporalityDelta = 1
+
+// otlpMetricsSink aggregates metrics in memory over intervals and pushes each
+// completed interval to an OpenTelemetry collector using the OTLP/HTTP JSON
+// encoding. Gauges are exported as gauges, counters as delta sums and samples
+// as summaries with their minimum and maximum as quantiles 0 and 1.
+type otlpMetricsSink struct {
+	*metrics.InmemSink
+
+	endpoint string
+	headers  map[string]string
+	interval time.Duration
+	client   *http.Client
+	resource otlpResource
+
+	// lastExported is the start of the last interval pushed to the
+	// collector. It is only used by the export loop.
+	lastExported time.Time
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+func otlpSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, error) {
+	if cfg.OTLPEndpoint == "" {
+		return nil, nil
+	}
+	sink, err := newOTLPMetricsSink(cfg, hostname)
+	if err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// newOTLPMetricsSink returns a sink pushing metrics to the configured OTLP
+// endpoint every OTLPInterval until it is stopped.
+func newOTLPMetricsSink(cfg TelemetryConfig, hostname string) (*otlpMetricsSink, error) {
+	tlsConfig, err := tlsutil.NewConfigurator(&tlsutil.Config{
+		CAFile:   cfg.OTLPCAFile,
+		CertFile: cfg.OTLPCertFile,
+		KeyFile:  cfg.OTLPKeyFile,
+	}).OutgoingHTTPSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed configuring TLS for OTLP: %v", err)
+	}
+
+	interval := cfg.OTLPInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	serviceName := cfg.MetricsPrefix
+	if serviceName == "" {
+		serviceName = "consul"
+	}
+
+	s := &otlpMetricsSink{
+		// Keep a few intervals so that a slow collector doesn't lose
+		// metrics.
+		InmemSink: metrics.NewInmemSink(interval, 4*interval),
+		endpoint:  cfg.OTLPEndpoint,
+		headers:   cfg.OTLPHeaders,
+		interval:  interval,
+		client: &http.Client{
+			Timeout:   interval,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		resource: otlpResource{Attributes: []otlpAttribute{
+			otlpStringAttribute("service.name", serviceName),
+			otlpStringAttribute("host.name", hostname),
+		}},
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Stop pushes the metrics that haven't been exported yet, including the ones
+// of the current interval, and stops the sink.
+func (s *otlpMetricsSink) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.doneCh
+}
+
+func (s *otlpMetricsSink) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.export(false)
+		case <-s.stopCh:
+			s.export(true)
+			return
+		}
+	}
+}
+
+// export pushes the intervals that completed since the last export. If
+// current is true, the interval in progress is pushed as well. Failures are
+// counted in the telemetry.otlp.export_failed metric and the intervals are
+// retried with the next export while the sink still retains them.
+func (s *otlpMetricsSink) export(current bool) {
+	now := time.Now()
+	var intervals []*metrics.IntervalMetrics
+	for _, intv := range s.Data() {
+		if !intv.Interval.After(s.lastExported) {
+			continue
+		}
+		if !current && intv.Interval.Add(s.interval).After(now) {
+			continue
+		}
+		intervals = append(intervals, intv)
+	}
+	if len(intervals) == 0 {
+		return
+	}
+
+	request := s.encode(intervals)
+	if len(request.ResourceMetrics[0].ScopeMetrics[0].Metrics) > 0 {
+		if err := s.push(request); err != nil {
+			metrics.IncrCounter([]string{"telemetry", "otlp", "export_failed"}, 1)
+			return
+		}
+	}
+	s.lastExported = intervals[len(intervals)-1].Interval
+}
+
+// push sends an export request to the collector.
+func (s *otlpMetricsSink) push(request *otlpExportRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response code from OTLP collector: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encode converts the aggregated intervals into an OTLP export request. The
+// data points of a metric are grouped by metric name.
+func (s *otlpMetricsSink) encode(intervals []*metrics.IntervalMetrics) *otlpExportRequest {
+	byName := make(map[string]*otlpMetric)
+	metric := func(name string) *otlpMetric {
+		m, ok := byName[name]
+		if !ok {
+			m = &otlpMetric{Name: name}
+			byName[name] = m
+		}
+		return m
+	}
+
+	for _, intv := range intervals {
+		start := otlpTime(intv.Interval)
+		end := otlpTime(intv.Interval.Add(s.interval))
+
+		intv.RLock()
+		for _, gauge := range intv.Gauges {
+			m := metric(gauge.Name)
+			if m.Gauge == nil {
+				m.Gauge = &otlpGauge{}
+			}
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, &otlpNumberDataPoint{
+				Attributes:   otlpAttributes(gauge.Labels),
+				TimeUnixNano: end,
+				AsDouble:     float64(gauge.Value),
+			})
+		}
+		for _, counter := range intv.Counters {
+			m := metric(counter.Name)
+			if m.Sum == nil {
+				m.Sum = &otlpSum{
+					AggregationTemporality: otlpAggregationTemporalityDelta,
+					IsMonotonic:            true,
+				}
+			}
+			m.Sum.DataPoints = append(m.Sum.DataPoints, &otlpNumberDataPoint{
+				Attributes:        otlpAttributes(counter.Labels),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				AsDouble:          counter.Sum,
+			})
+		}
+		for _, sample := range intv.Samples {
+			m := metric(sample.Name)
+			if m.Summary == nil {
+				m.Summary = &otlpSummary{}
+			}
+			m.Summary.DataPoints = append(m.Summary.DataPoints, &otlpSummaryDataPoint{
+				Attributes:        otlpAttributes(sample.Labels),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				Count:             strconv.Itoa(sample.Count),
+				Sum:               sample.Sum,
+				QuantileValues: []otlpQuantileValue{
+					{Quantile: 0, Value: sample.Min},
+					{Quantile: 1, Value: sample.Max},
+				},
+			})
+		}
+		intv.RUnlock()
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	scope := &otlpScopeMetrics{Scope: otlpScope{Name: "consul"}}
+	for _, name := range names {
+		scope.Metrics = append(scope.Metrics, byName[name])
+	}
+	return &otlpExportRequest{
+		ResourceMetrics: []*otlpResourceMetrics{{
+			Resource:     s.resource,
+			ScopeMetrics: []*otlpScopeMetrics{scope},
+		}},
+	}
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpStringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+func otlpAttributes(labels []metrics.Label) []otlpAttribute {
+	var attrs []otlpAttribute
+	for _, label := range labels {
+		attrs = append(attrs, otlpStringAttribute(label.Name, label.Value))
+	}
+	return attrs
+}
+
+// The types below are the subset of the OTLP metrics protocol used by the
+// sink, with the field names of its JSON encoding. 64 bit integers are
+// encoded as strings.
+
+type otlpExportRequest struct {
+	ResourceMetrics []*otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource        `json:"resource"`
+	ScopeMetrics []*otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope     `json:"scope"`
+	Metrics []*otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpMetric struct {
+	Name    string       `json:"name"`
+	Gauge   *otlpGauge   `json:"gauge,omitempty"`
+	Sum     *otlpSum     `json:"sum,omitempty"`
+	Summary *otlpSummary `json:"summary,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []*otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []*otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                    `json:"aggregationTemporality"`
+	IsMonotonic            bool                   `json:"isMonotonic"`
+}
+
+type otlpSummary struct {
+	DataPoints []*otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes        []otlpAttribute     `json:"attributes,omitempty"`
+	StartTimeUnixNano string              `json:"startTimeUnixNano"`
+	TimeUnixNano      string              `json:"timeUnixNano"`
+	Count             string              `json:"count"`
+	Sum               float64             `json:"sum"`
+	QuantileValues    []otlpQuantileValue `json:"quantileValues"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
@@ -0,0 +1,117 @@
+package lib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPMetricsSink(t *testing.T) {
+	requests := make(chan *otlpExportRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Authorization") != "Bearer secret" ||
+			r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req otlpExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- &req
+	}))
+	defer srv.Close()
+
+	sink, err := newOTLPMetricsSink(TelemetryConfig{
+		MetricsPrefix: "consul",
+		OTLPEndpoint:  srv.URL + "/v1/metrics",
+		OTLPHeaders:   map[string]string{"Authorization": "Bearer secret"},
+		OTLPInterval:  time.Hour,
+	}, "node1")
+	require.NoError(t, err)
+
+	labels := []metrics.Label{{Name: "method", Value: "GET"}}
+	sink.SetGauge([]string{"consul", "runtime", "num_goroutines"}, 42)
+	sink.IncrCounterWithLabels([]string{"consul", "http", "requests"}, 1, labels)
+	sink.IncrCounterWithLabels([]string{"consul", "http", "requests"}, 2, labels)
+	sink.AddSample([]string{"consul", "raft", "commitTime"}, 5)
+	sink.AddSample([]string{"consul", "raft", "commitTime"}, 15)
+
+	// Stopping the sink pushes the current interval.
+	sink.Stop()
+	var req *otlpExportRequest
+	select {
+	case req = <-requests:
+	default:
+		t.Fatalf("no metrics were pushed")
+	}
+
+	require.Len(t, req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	require.Equal(t, []otlpAttribute{
+		otlpStringAttribute("service.name", "consul"),
+		otlpStringAttribute("host.name", "node1"),
+	}, rm.Resource.Attributes)
+	require.Len(t, rm.ScopeMetrics, 1)
+	ms := rm.ScopeMetrics[0].Metrics
+	require.Len(t, ms, 3)
+
+	require.Equal(t, "consul.http.requests", ms[0].Name)
+	require.NotNil(t, ms[0].Sum)
+	require.Equal(t, otlpAggregationTemporalityDelta, ms[0].Sum.AggregationTemporality)
+	require.Len(t, ms[0].Sum.DataPoints, 1)
+	require.Equal(t, 3.0, ms[0].Sum.DataPoints[0].AsDouble)
+	require.Equal(t, []otlpAttribute{otlpStringAttribute("method", "GET")}, ms[0].Sum.DataPoints[0].Attributes)
+
+	require.Equal(t, "consul.raft.commitTime", ms[1].Name)
+	require.NotNil(t, ms[1].Summary)
+	require.Len(t, ms[1].Summary.DataPoints, 1)
+	dp := ms[1].Summary.DataPoints[0]
+	require.Equal(t, "2", dp.Count)
+	require.Equal(t, 20.0, dp.Sum)
+	require.Equal(t, []otlpQuantileValue{{Quantile: 0, Value: 5}, {Quantile: 1, Value: 15}}, dp.QuantileValues)
+
+	require.Equal(t, "consul.runtime.num_goroutines", ms[2].Name)
+	require.NotNil(t, ms[2].Gauge)
+	require.Equal(t, 42.0, ms[2].Gauge.DataPoints[0].AsDouble)
+
+	// Stopping again doesn't push anything.
+	sink.Stop()
+	require.Len(t, requests, 0)
+}
+
+func TestOTLPMetricsSink_ExportFailure(t *testing.T) {
+	fail := true
+	requests := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		requests <- struct{}{}
+	}))
+	defer srv.Close()
+
+	sink, err := newOTLPMetricsSink(TelemetryConfig{
+		OTLPEndpoint: srv.URL,
+		OTLPInterval: time.Hour,
+	}, "node1")
+	require.NoError(t, err)
+	defer sink.Stop()
+
+	sink.IncrCounter([]string{"consul", "http", "requests"}, 1)
+	sink.export(true)
+	require.True(t, sink.lastExported.IsZero())
+
+	// The interval is pushed again with the next export.
+	fail = false
+	sink.export(true)
+	require.Len(t, requests, 1)
+	require.False(t, sink.lastExported.IsZero())
+}
@@ -0,0 +1,138 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultPrometheusHistogramPrefixes are the metrics, relative to the metrics
+// prefix, that are exported as histograms when histogram buckets are
+// configured but no prefixes are.
+var defaultPrometheusHistogramPrefixes = []string{"rpc", "raft", "http"}
+
+// forbiddenPrometheusChars matches the characters the Prometheus sink
+// replaces in metric names.
+var forbiddenPrometheusChars = regexp.MustCompile("[ .=\\-]")
+
+// histogramDesc is reported to the Prometheus registry, which requires each
+// collector to describe at least one metric. It is never collected.
+var histogramDesc = promclient.NewDesc("consul_prometheus_histograms", "Histograms of Consul timings", nil, nil)
+
+// prometheusHistogramSink wraps the Prometheus sink so that the samples of the
+// metrics matching one of its prefixes are exported as histograms with fixed
+// buckets rather than as summaries. Other metrics are passed through.
+type prometheusHistogramSink struct {
+	*prometheus.PrometheusSink
+
+	buckets    []float64
+	prefixes   []string
+	expiration time.Duration
+
+	mu         sync.Mutex
+	histograms map[string]promclient.Histogram
+	updates    map[string]time.Time
+}
+
+// newPrometheusHistogramSink returns a sink exporting the samples of the
+// metrics matching the configured histogram prefixes as histograms. It is
+// registered with the global Prometheus registry.
+func newPrometheusHistogramSink(sink *prometheus.PrometheusSink, cfg TelemetryConfig) (*prometheusHistogramSink, error) {
+	prefixes := cfg.PrometheusHistogramPrefixes
+	if len(prefixes) == 0 {
+		for _, prefix := range defaultPrometheusHistogramPrefixes {
+			if cfg.MetricsPrefix != "" {
+				prefix = cfg.MetricsPrefix + "." + prefix
+			}
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	h := &prometheusHistogramSink{
+		PrometheusSink: sink,
+		buckets:        cfg.PrometheusHistogramBuckets,
+		prefixes:       prefixes,
+		expiration:     cfg.PrometheusRetentionTime,
+		histograms:     make(map[string]promclient.Histogram),
+		updates:        make(map[string]time.Time),
+	}
+	if err := promclient.Register(h); err != nil {
+		return nil, fmt.Errorf("failed registering Prometheus histograms: %v", err)
+	}
+	return h, nil
+}
+
+// matches returns whether the metric with the given key is exported as a
+// histogram.
+func (h *prometheusHistogramSink) matches(parts []string) bool {
+	name := strings.Join(parts, ".")
+	for _, prefix := range h.prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *prometheusHistogramSink) AddSample(parts []string, val float32) {
+	h.AddSampleWithLabels(parts, val, nil)
+}
+
+func (h *prometheusHistogramSink) AddSampleWithLabels(parts []string, val float32, labels []metrics.Label) {
+	if !h.matches(parts) {
+		h.PrometheusSink.AddSampleWithLabels(parts, val, labels)
+		return
+	}
+
+	name := forbiddenPrometheusChars.ReplaceAllString(strings.Join(parts, "_"), "_")
+	hash := name
+	constLabels := make(promclient.Labels)
+	for _, label := range labels {
+		hash += fmt.Sprintf(";%s=%s", label.Name, label.Value)
+		constLabels[label.Name] = label.Value
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	histogram, ok := h.histograms[hash]
+	if !ok {
+		histogram = promclient.NewHistogram(promclient.HistogramOpts{
+			Name:        name,
+			Help:        name,
+			Buckets:     h.buckets,
+			ConstLabels: constLabels,
+		})
+		h.histograms[hash] = histogram
+	}
+	histogram.Observe(float64(val))
+	h.updates[hash] = time.Now()
+}
+
+// Describe is part of the Prometheus Collector interface.
+func (h *prometheusHistogramSink) Describe(c chan<- *promclient.Desc) {
+	c <- histogramDesc
+}
+
+// Collect is part of the Prometheus Collector interface. Histograms that
+// haven't been updated within the retention time are dropped, the same as
+// the other metrics of the Prometheus sink.
+func (h *prometheusHistogramSink) Collect(c chan<- promclient.Metric) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for hash, histogram := range h.histograms {
+		if h.expiration != 0 && h.updates[hash].Add(h.expiration).Before(now) {
+			delete(h.histograms, hash)
+			delete(h.updates, hash)
+			continue
+		}
+		histogram.Collect(c)
+	}
+}
@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusHistogramSink(t *testing.T) {
+	base, err := prometheus.NewPrometheusSinkFrom(prometheus.PrometheusOpts{Expiration: time.Minute})
+	require.NoError(t, err)
+	sink, err := newPrometheusHistogramSink(base, TelemetryConfig{
+		MetricsPrefix:              "consul",
+		PrometheusRetentionTime:    time.Minute,
+		PrometheusHistogramBuckets: []float64{1, 10, 100},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"consul.rpc", "consul.raft", "consul.http"}, sink.prefixes)
+
+	labels := []metrics.Label{{Name: "method", Value: "GET"}}
+	sink.AddSampleWithLabels([]string{"consul", "http", "GET", "v1", "kv"}, 5, labels)
+	sink.AddSampleWithLabels([]string{"consul", "http", "GET", "v1", "kv"}, 50, labels)
+	sink.AddSample([]string{"consul", "raft", "commitTime"}, 500)
+	sink.AddSample([]string{"consul", "raftish"}, 5)
+	sink.AddSample([]string{"consul", "catalog", "register"}, 5)
+
+	families, err := promclient.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily)
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	family := byName["consul_http_GET_v1_kv"]
+	require.NotNil(t, family)
+	require.Equal(t, dto.MetricType_HISTOGRAM, family.GetType())
+	histogram := family.Metric[0].GetHistogram()
+	require.Equal(t, uint64(2), histogram.GetSampleCount())
+	var counts []uint64
+	for _, bucket := range histogram.Bucket {
+		counts = append(counts, bucket.GetCumulativeCount())
+	}
+	require.Equal(t, []uint64{0, 1, 2}, counts)
+	require.Equal(t, "GET", family.Metric[0].Label[0].GetValue())
+
+	family = byName["consul_raft_commitTime"]
+	require.NotNil(t, family)
+	require.Equal(t, dto.MetricType_HISTOGRAM, family.GetType())
+	require.Equal(t, uint64(1), family.Metric[0].GetHistogram().GetSampleCount())
+
+	for _, name := range []string{"consul_raftish", "consul_catalog_register"} {
+		family = byName[name]
+		require.NotNil(t, family, name)
+		require.Equal(t, dto.MetricType_SUMMARY, family.GetType(), name)
+	}
+}
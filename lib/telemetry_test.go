@@ -1,18 +1,23 @@
 package lib
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/stretchr/testify/require"
 )
 
 func makeFullTelemetryConfig(t *testing.T) TelemetryConfig {
 	var (
-		strSliceVal = []string{"foo"}
-		strVal      = "foo"
-		intVal      = int64(1 * time.Second)
+		strSliceVal   = []string{"foo"}
+		floatSliceVal = []float64{1}
+		strMapVal     = map[string]string{"foo": "bar"}
+		strVal        = "foo"
+		intVal        = int64(1 * time.Second)
 	)
 
 	cfg := TelemetryConfig{}
@@ -28,11 +33,21 @@ func makeFullTelemetryConfig(t *testing.T) TelemetryConfig {
 		// this is likely not implemented in MergeDefaults either.
 		switch f.Kind() {
 		case reflect.Slice:
-			if f.Type() != reflect.TypeOf(strSliceVal) {
+			switch f.Type() {
+			case reflect.TypeOf(strSliceVal):
+				f.Set(reflect.ValueOf(strSliceVal))
+			case reflect.TypeOf(floatSliceVal):
+				f.Set(reflect.ValueOf(floatSliceVal))
+			default:
 				t.Fatalf("unknown slice type in TelemetryConfig." +
 					" You need to update MergeDefaults and this test code.")
 			}
-			f.Set(reflect.ValueOf(strSliceVal))
+		case reflect.Map:
+			if f.Type() != reflect.TypeOf(strMapVal) {
+				t.Fatalf("unknown map type in TelemetryConfig." +
+					" You need to update MergeDefaults and this test code.")
+			}
+			f.Set(reflect.ValueOf(strMapVal))
 		case reflect.Int, reflect.Int64: // time.Duration == int64
 			f.SetInt(intVal)
 		case reflect.String:
@@ -88,3 +103,46 @@ func TestTelemetryConfig_MergeDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadTelemetry_ShutsDownReplacedSinks(t *testing.T) {
+	defer func(d time.Duration) { sinkShutdownDelay = d }(sinkShutdownDelay)
+	sinkShutdownDelay = 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	memSink := metrics.NewInmemSink(10*time.Second, time.Minute)
+	cfg := TelemetryConfig{
+		MetricsPrefix: "consul",
+		DogstatsdAddr: "127.0.0.1:8125",
+		OTLPEndpoint:  srv.URL,
+		OTLPInterval:  time.Hour,
+	}
+	stopped := func(s *otlpMetricsSink) bool {
+		select {
+		case <-s.doneCh:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	require.NoError(t, ReloadTelemetry(memSink, cfg))
+	require.Len(t, activeSinks, 2)
+	dog, first := activeSinks[0], activeSinks[1].(*otlpMetricsSink)
+
+	// The first OTLP sink is replaced and shut down, while the DogStatsD
+	// sink is kept since its address didn't change.
+	require.NoError(t, ReloadTelemetry(memSink, cfg))
+	require.Len(t, activeSinks, 2)
+	require.True(t, dog == activeSinks[0])
+	second := activeSinks[1].(*otlpMetricsSink)
+	require.True(t, first != second)
+	require.True(t, stopped(first))
+	require.False(t, stopped(second))
+
+	// Reloading without sinks shuts down the rest.
+	require.NoError(t, ReloadTelemetry(memSink, TelemetryConfig{MetricsPrefix: "consul"}))
+	require.Empty(t, activeSinks)
+	require.True(t, stopped(second))
+}
@@ -0,0 +1,6 @@
+package logger
+
+// EventLogSource is the name of the event source Consul logs to the Windows
+// event log under. It is registered when Consul is installed as a Windows
+// service.
+const EventLogSource = "consul"
@@ -0,0 +1,14 @@
+// +build !windows
+
+package logger
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/logutils"
+)
+
+func newEventLog(filt *logutils.LevelFilter) (io.Writer, error) {
+	return nil, fmt.Errorf("the event log is only supported on Windows")
+}
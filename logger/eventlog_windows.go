@@ -0,0 +1,70 @@
+// +build windows
+
+package logger
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/hashicorp/logutils"
+	"golang.org/x/sys/windows"
+)
+
+// eventLogID is the event ID used for all log messages. The event source is
+// registered with EventCreate.exe as its message file, which accepts IDs from
+// 1 to 1000 and shows the message as is.
+const eventLogID = 1
+
+// levelEventType is used to map a log level to an event log type.
+var levelEventType = map[string]uint16{
+	"TRACE": windows.EVENTLOG_INFORMATION_TYPE,
+	"DEBUG": windows.EVENTLOG_INFORMATION_TYPE,
+	"INFO":  windows.EVENTLOG_INFORMATION_TYPE,
+	"WARN":  windows.EVENTLOG_WARNING_TYPE,
+	"ERR":   windows.EVENTLOG_ERROR_TYPE,
+	"CRIT":  windows.EVENTLOG_ERROR_TYPE,
+}
+
+// EventLogWrapper is used to cleanup log messages before writing them to the
+// Windows event log. Implements the io.Writer interface.
+type EventLogWrapper struct {
+	handle windows.Handle
+	filt   *logutils.LevelFilter
+}
+
+// newEventLog opens the event log for the Consul event source.
+func newEventLog(filt *logutils.LevelFilter) (io.Writer, error) {
+	source, err := windows.UTF16PtrFromString(EventLogSource)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.RegisterEventSource(nil, source)
+	if err != nil {
+		return nil, err
+	}
+	return &EventLogWrapper{handle: handle, filt: filt}, nil
+}
+
+// Write is used to implement io.Writer
+func (e *EventLogWrapper) Write(p []byte) (int, error) {
+	// Skip the event log if the log level doesn't apply
+	if !e.filt.Check(p) {
+		return 0, nil
+	}
+
+	// Each log level will be handled by a specific event type
+	level, afterLevel := splitLevel(p)
+	etype, ok := levelEventType[level]
+	if !ok {
+		etype = windows.EVENTLOG_INFORMATION_TYPE
+	}
+
+	msg, err := windows.UTF16PtrFromString(string(bytes.TrimSpace(afterLevel)))
+	if err != nil {
+		return 0, err
+	}
+
+	// Attempt the write
+	err = windows.ReportEvent(e.handle, etype, 0, eventLogID, 0, 1, 0, &msg, nil)
+	return len(p), err
+}
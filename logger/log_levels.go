@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"bytes"
 	"io/ioutil"
 
 	"github.com/hashicorp/logutils"
@@ -26,3 +27,17 @@ func ValidateLevelFilter(minLevel logutils.LogLevel, filter *logutils.LevelFilte
 	}
 	return false
 }
+
+// splitLevel extracts the level from a log line. It returns the level along
+// with the rest of the line after it. If there is no level, the full line is
+// returned.
+func splitLevel(p []byte) (string, []byte) {
+	x := bytes.IndexByte(p, '[')
+	if x >= 0 {
+		y := bytes.IndexByte(p[x:], ']')
+		if y >= 0 {
+			return string(p[x+1 : x+y]), p[x+y+2:]
+		}
+	}
+	return "", p
+}
@@ -1,8 +1,6 @@
 package logger
 
 import (
-	"bytes"
-
 	"github.com/hashicorp/go-syslog"
 	"github.com/hashicorp/logutils"
 )
@@ -34,16 +32,7 @@ func (s *SyslogWrapper) Write(p []byte) (int, error) {
 	}
 
 	// Extract log level
-	var level string
-	afterLevel := p
-	x := bytes.IndexByte(p, '[')
-	if x >= 0 {
-		y := bytes.IndexByte(p[x:], ']')
-		if y >= 0 {
-			level = string(p[x+1 : x+y])
-			afterLevel = p[x+y+2:]
-		}
-	}
+	level, afterLevel := splitLevel(p)
 
 	// Each log level will be handled by a specific syslog priority
 	priority, ok := levelPriority[level]
@@ -0,0 +1,17 @@
+// +build !windows
+
+package service_os
+
+import "errors"
+
+// errUnsupportedOS is returned when managing Windows services on other
+// platforms.
+var errUnsupportedOS = errors.New("Windows services are only supported on Windows")
+
+func Install(name, displayName, description string, args []string) error {
+	return errUnsupportedOS
+}
+
+func Uninstall(name string) error {
+	return errUnsupportedOS
+}
@@ -0,0 +1,165 @@
+//+build windows
+
+package service_os
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"github.com/hashicorp/consul/logger"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// eventLogKey is the registry key of the event source Consul logs
+	// under.
+	eventLogKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\` + logger.EventLogSource
+
+	// eventLogMessageFile is the message file of the event source. It comes
+	// with Windows and formats events by showing their message as is.
+	eventLogMessageFile = `%SystemRoot%\System32\EventCreate.exe`
+)
+
+var (
+	modadvapi32         = windows.NewLazySystemDLL("advapi32.dll")
+	procRegCreateKeyExW = modadvapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueExW  = modadvapi32.NewProc("RegSetValueExW")
+	procRegDeleteKeyW   = modadvapi32.NewProc("RegDeleteKeyW")
+)
+
+// Install registers a Windows service that runs "consul agent" with the
+// given arguments. The service starts automatically when the system boots.
+// It also registers the event source used by the agent's -eventlog option.
+func Install(name, displayName, description string, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.Abs(exe)
+	if err != nil {
+		return err
+	}
+	cmdLine := syscall.EscapeArg(exe) + " agent"
+	for _, arg := range args {
+		cmdLine += " " + syscall.EscapeArg(arg)
+	}
+
+	m, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_ALL_ACCESS)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %v", err)
+	}
+	defer windows.CloseServiceHandle(m)
+
+	s, err := windows.CreateService(m, utf16Ptr(name), utf16Ptr(displayName),
+		windows.SERVICE_ALL_ACCESS, windows.SERVICE_WIN32_OWN_PROCESS,
+		windows.SERVICE_AUTO_START, windows.SERVICE_ERROR_NORMAL,
+		utf16Ptr(cmdLine), nil, nil, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create service %q: %v", name, err)
+	}
+	defer windows.CloseServiceHandle(s)
+
+	if description != "" {
+		desc := windows.SERVICE_DESCRIPTION{Description: utf16Ptr(description)}
+		err := windows.ChangeServiceConfig2(s, windows.SERVICE_CONFIG_DESCRIPTION, (*byte)(unsafe.Pointer(&desc)))
+		if err != nil {
+			windows.DeleteService(s)
+			return fmt.Errorf("failed to set service description: %v", err)
+		}
+	}
+
+	if err := installEventSource(); err != nil {
+		windows.DeleteService(s)
+		return err
+	}
+	return nil
+}
+
+// Uninstall stops and removes the given Windows service along with the event
+// source registered by Install.
+func Uninstall(name string) error {
+	m, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_ALL_ACCESS)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %v", err)
+	}
+	defer windows.CloseServiceHandle(m)
+
+	s, err := windows.OpenService(m, utf16Ptr(name), windows.SERVICE_ALL_ACCESS)
+	if err != nil {
+		return fmt.Errorf("failed to open service %q: %v", name, err)
+	}
+	defer windows.CloseServiceHandle(s)
+
+	// Ask the service to stop first, otherwise it's only removed once it
+	// stops by itself.
+	var status windows.SERVICE_STATUS
+	if err := windows.QueryServiceStatus(s, &status); err != nil {
+		return fmt.Errorf("failed to query service %q: %v", name, err)
+	}
+	if status.CurrentState != windows.SERVICE_STOPPED {
+		if err := windows.ControlService(s, windows.SERVICE_CONTROL_STOP, &status); err != nil {
+			return fmt.Errorf("failed to stop service %q: %v", name, err)
+		}
+	}
+
+	if err := windows.DeleteService(s); err != nil {
+		return fmt.Errorf("failed to delete service %q: %v", name, err)
+	}
+	return removeEventSource()
+}
+
+// installEventSource registers the event source Consul logs under.
+func installEventSource() error {
+	var key windows.Handle
+	r, _, _ := procRegCreateKeyExW.Call(windows.HKEY_LOCAL_MACHINE,
+		uintptr(unsafe.Pointer(utf16Ptr(eventLogKey))), 0, 0, 0,
+		windows.KEY_WRITE, 0, uintptr(unsafe.Pointer(&key)), 0)
+	if r != 0 {
+		return fmt.Errorf("failed to register event source: %v", syscall.Errno(r))
+	}
+	defer windows.RegCloseKey(key)
+
+	msgFile, err := windows.UTF16FromString(eventLogMessageFile)
+	if err != nil {
+		return err
+	}
+	err = setRegistryValue(key, "EventMessageFile", windows.REG_EXPAND_SZ,
+		unsafe.Pointer(&msgFile[0]), len(msgFile)*2)
+	if err != nil {
+		return err
+	}
+
+	types := uint32(windows.EVENTLOG_ERROR_TYPE | windows.EVENTLOG_WARNING_TYPE | windows.EVENTLOG_INFORMATION_TYPE)
+	return setRegistryValue(key, "TypesSupported", windows.REG_DWORD,
+		unsafe.Pointer(&types), 4)
+}
+
+// removeEventSource removes the event source registered by
+// installEventSource.
+func removeEventSource() error {
+	r, _, _ := procRegDeleteKeyW.Call(windows.HKEY_LOCAL_MACHINE,
+		uintptr(unsafe.Pointer(utf16Ptr(eventLogKey))))
+	if r != 0 && syscall.Errno(r) != windows.ERROR_FILE_NOT_FOUND {
+		return fmt.Errorf("failed to remove event source: %v", syscall.Errno(r))
+	}
+	return nil
+}
+
+func setRegistryValue(key windows.Handle, name string, valueType uint32, data unsafe.Pointer, size int) error {
+	r, _, _ := procRegSetValueExW.Call(uintptr(key), uintptr(unsafe.Pointer(utf16Ptr(name))),
+		0, uintptr(valueType), uintptr(data), uintptr(size))
+	if r != 0 {
+		return fmt.Errorf("failed to set registry value %q: %v", name, syscall.Errno(r))
+	}
+	return nil
+}
+
+// utf16Ptr converts a string to a UTF-16 pointer for the Windows API. The
+// strings passed here never contain NUL bytes.
+func utf16Ptr(s string) *uint16 {
+	p, _ := windows.UTF16PtrFromString(s)
+	return p
+}
@@ -231,5 +231,11 @@ func read(in io.Reader, metadata *raft.SnapshotMeta, snap io.Writer) error {
 		return fmt.Errorf("failed checking integrity of snapshot: %v", err)
 	}
 
+	// Make sure Raft can restore the snapshot before it's handed over.
+	if metadata.Version < raft.SnapshotVersionMin || metadata.Version > raft.SnapshotVersionMax {
+		return fmt.Errorf("unsupported snapshot version %d, must be between %d and %d",
+			metadata.Version, raft.SnapshotVersionMin, raft.SnapshotVersionMax)
+	}
+
 	return nil
 }
@@ -63,6 +63,24 @@ func TestArchive(t *testing.T) {
 	}
 }
 
+func TestArchive_UnsupportedVersion(t *testing.T) {
+	metadata := raft.SnapshotMeta{
+		Index:   2005,
+		Term:    2011,
+		Version: raft.SnapshotVersionMax + 1,
+	}
+	var archive bytes.Buffer
+	if err := write(&archive, &metadata, bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var newMeta raft.SnapshotMeta
+	err := read(&archive, &newMeta, ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "unsupported snapshot version") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestArchive_GoodData(t *testing.T) {
 	paths := []string{
 		"../test/snapshot/spaces-meta.tar",
@@ -140,13 +140,15 @@ func Verify(in io.Reader) (*raft.SnapshotMeta, error) {
 	return &metadata, nil
 }
 
-// Restore takes the snapshot from the reader and attempts to apply it to the
-// given Raft instance.
-func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
+// Read takes the snapshot from the reader, verifies its integrity and
+// extracts the Raft snapshot data into a temporary file, which is rewound so
+// it's ready to be read. You must arrange to call Close() on the returned
+// file and remove it or else you will leak a temporary file.
+func Read(logger *log.Logger, in io.Reader) (*os.File, *raft.SnapshotMeta, error) {
 	// Wrap the reader in a gzip decompressor.
 	decomp, err := gzip.NewReader(in)
 	if err != nil {
-		return fmt.Errorf("failed to decompress snapshot: %v", err)
+		return nil, nil, fmt.Errorf("failed to decompress snapshot: %v", err)
 	}
 	defer func() {
 		if err := decomp.Close(); err != nil {
@@ -158,9 +160,16 @@ func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
 	// we can avoid buffering in memory.
 	snap, err := ioutil.TempFile("", "snapshot")
 	if err != nil {
-		return fmt.Errorf("failed to create temp snapshot file: %v", err)
+		return nil, nil, fmt.Errorf("failed to create temp snapshot file: %v", err)
 	}
+
+	// If anything goes wrong after this point, we will attempt to clean up
+	// the temp file. The happy path will disarm this.
+	var keep bool
 	defer func() {
+		if keep {
+			return
+		}
 		if err := snap.Close(); err != nil {
 			logger.Printf("[ERR] snapshot: Failed to close temp snapshot: %v", err)
 		}
@@ -172,19 +181,39 @@ func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
 	// Read the archive.
 	var metadata raft.SnapshotMeta
 	if err := read(decomp, &metadata, snap); err != nil {
-		return fmt.Errorf("failed to read snapshot file: %v", err)
+		return nil, nil, fmt.Errorf("failed to read snapshot file: %v", err)
 	}
 
 	// Sync and rewind the file so it's ready to be read again.
 	if err := snap.Sync(); err != nil {
-		return fmt.Errorf("failed to sync temp snapshot: %v", err)
+		return nil, nil, fmt.Errorf("failed to sync temp snapshot: %v", err)
 	}
 	if _, err := snap.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to rewind temp snapshot: %v", err)
+		return nil, nil, fmt.Errorf("failed to rewind temp snapshot: %v", err)
+	}
+
+	keep = true
+	return snap, &metadata, nil
+}
+
+// Restore takes the snapshot from the reader and attempts to apply it to the
+// given Raft instance.
+func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
+	snap, metadata, err := Read(logger, in)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		if err := snap.Close(); err != nil {
+			logger.Printf("[ERR] snapshot: Failed to close temp snapshot: %v", err)
+		}
+		if err := os.Remove(snap.Name()); err != nil {
+			logger.Printf("[ERR] snapshot: Failed to clean up temp snapshot: %v", err)
+		}
+	}()
 
 	// Feed the snapshot into Raft.
-	if err := r.Restore(&metadata, snap, 0); err != nil {
+	if err := r.Restore(metadata, snap, 0); err != nil {
 		return fmt.Errorf("Raft error when restoring snapshot: %v", err)
 	}
 
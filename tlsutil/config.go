@@ -4,7 +4,11 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"net"
+	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -21,11 +25,17 @@ type DCWrapper func(dc string, conn net.Conn) (net.Conn, error)
 // a constant value. This is usually done by currying DCWrapper.
 type Wrapper func(conn net.Conn) (net.Conn, error)
 
-// TLSLookup maps the tls_min_version configuration to the internal value
+// ALPNWrapper is a variant of DCWrapper that negotiates the given
+// application protocol with the server during the TLS handshake.
+type ALPNWrapper func(dc, alpnProto string, conn net.Conn) (net.Conn, error)
+
+// TLSLookup maps the tls_min_version and tls_max_version configuration to
+// the internal value
 var TLSLookup = map[string]uint16{
 	"tls10": tls.VersionTLS10,
 	"tls11": tls.VersionTLS11,
 	"tls12": tls.VersionTLS12,
+	"tls13": tls.VersionTLS13,
 }
 
 // Config used to create tls.Config
@@ -76,6 +86,20 @@ type Config struct {
 	// the TLS connection.
 	CAPath string
 
+	// CAPEM is a PEM encoded certificate authority bundle. It is used
+	// instead of CAFile and CAPath when set, so that the certificates don't
+	// have to be written to disk.
+	CAPEM string
+
+	// CRLFile is a path to a PEM or DER encoded certificate revocation
+	// list. Incoming connections presenting a client certificate which it
+	// revokes are rejected.
+	CRLFile string
+
+	// CRLPath is a path to a directory containing certificate revocation
+	// list files, which are used like CRLFile.
+	CRLPath string
+
 	// CertFile is used to provide a TLS certificate that is used for
 	// serving TLS connections.  Must be provided to serve TLS connections.
 	CertFile string
@@ -84,6 +108,31 @@ type Config struct {
 	// connections.  Must be provided to serve TLS connections.
 	KeyFile string
 
+	// CertPEM is a PEM encoded certificate which is used instead of
+	// CertFile when set.
+	CertPEM string
+
+	// KeyPEM is a PEM encoded key which is used instead of KeyFile when set.
+	KeyPEM string
+
+	// CertBundleFile is a PKCS#12 bundle with the TLS certificate, its key
+	// and the chain of intermediates. It replaces CertFile and KeyFile when
+	// set.
+	CertBundleFile string
+
+	// KeyPassphrase is used to decrypt the key if it is an encrypted PKCS#8
+	// or PKCS#1 PEM, and the CertBundleFile.
+	KeyPassphrase string
+
+	// KeyPassphraseFile is a file containing the KeyPassphrase, which is
+	// used when KeyPassphrase is not set. A trailing newline is ignored.
+	KeyPassphraseFile string
+
+	// SNICertificates are additional certificates served on incoming HTTPS
+	// connections to clients which ask for one of their names with SNI.
+	// All other clients are served the certificate above.
+	SNICertificates []SNICertificate
+
 	// Node name is the name we use to advertise. Defaults to hostname.
 	NodeName string
 
@@ -91,12 +140,22 @@ type Config struct {
 	// provide matches the certificate
 	ServerName string
 
+	// DatacenterServerNames maps datacenters to the name the certificates
+	// of their servers are verified against with VerifyServerHostname, for
+	// datacenters whose servers don't have certificates for
+	// "server.<datacenter>.<domain>".
+	DatacenterServerNames map[string]string
+
 	// Domain is the Consul TLD being used. Defaults to "consul."
 	Domain string
 
 	// TLSMinVersion is the minimum accepted TLS version that can be used.
 	TLSMinVersion string
 
+	// TLSMaxVersion is the maximum accepted TLS version that can be used.
+	// It defaults to the latest version supported by crypto/tls.
+	TLSMaxVersion string
+
 	// CipherSuites is the list of TLS cipher suites to use.
 	CipherSuites []uint16
 
@@ -110,20 +169,104 @@ type Config struct {
 	// the server using the same TLS configuration as the agent (CA, cert,
 	// and key).
 	EnableAgentTLSForChecks bool
+
+	// WatchFiles makes the Configurator watch CertFile, KeyFile,
+	// CertBundleFile, KeyPassphraseFile, SNICertificates, CAFile and CRLFile
+	// and reload them when they change on disk, so that certificates can be
+	// rotated without reloading the agent. It is only honored when the
+	// Configurator is created.
+	WatchFiles bool
+
+	// OCSPStapling makes the *tls.Config for incoming HTTPS connections
+	// staple an OCSP response for the served certificate, which the
+	// Configurator fetches in the background. It is only honored when the
+	// Configurator is created.
+	OCSPStapling bool
+
+	// OCSPResponderURL overrides the OCSP responder named by the served
+	// certificate.
+	OCSPResponderURL string
+
+	// OCSPRefreshInterval is how often the stapled OCSP response is
+	// refreshed. It defaults to an hour. Responses which expire earlier are
+	// refreshed before they do.
+	OCSPRefreshInterval time.Duration
 }
 
-// KeyPair is used to open and parse a certificate and key file
+// KeyPair is used to open and parse a certificate and key. The
+// CertBundleFile is preferred to the inline CertPEM and KeyPEM, which are
+// preferred to CertFile and KeyFile. An encrypted key or bundle is decrypted
+// with the KeyPassphrase.
 func (c *Config) KeyPair() (*tls.Certificate, error) {
-	if c.CertFile == "" || c.KeyFile == "" {
+	if c.CertBundleFile != "" {
+		passphrase, err := c.keyPassphrase()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load cert bundle: %v", err)
+		}
+		cert, err := loadCertBundle(c.CertBundleFile, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load cert bundle: %v", err)
+		}
+		return cert, nil
+	}
+	if (c.CertPEM == "" && c.CertFile == "") || (c.KeyPEM == "" && c.KeyFile == "") {
 		return nil, nil
 	}
-	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	certPEM, err := loadPEM(c.CertPEM, c.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
+	}
+	keyPEM, err := loadPEM(c.KeyPEM, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
+	}
+	passphrase, err := c.keyPassphrase()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
+	}
+	keyPEM, err = decryptKeyPEM(keyPEM, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
 	}
 	return &cert, err
 }
 
+// loadPEM returns the inline PEM data if it is set, or else the content of
+// the file.
+func loadPEM(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	return ioutil.ReadFile(file)
+}
+
+// hasCA returns true if certificate authorities are configured.
+func (c *Config) hasCA() bool {
+	return c.CAPEM != "" || c.CAFile != "" || c.CAPath != ""
+}
+
+// CAPool loads the configured certificate authorities, preferring the
+// inline CAPEM to CAFile and CAPath. It returns nil if there are none.
+func (c *Config) CAPool() (*x509.CertPool, error) {
+	switch {
+	case c.CAPEM != "":
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CAPEM)) {
+			return nil, fmt.Errorf("Error loading CA PEM: Couldn't parse PEM")
+		}
+		return pool, nil
+	case c.CAFile != "":
+		return rootcerts.LoadCAFile(c.CAFile)
+	case c.CAPath != "":
+		return rootcerts.LoadCAPath(c.CAPath)
+	}
+	return nil, nil
+}
+
 // SpecificDC is used to invoke a static datacenter
 // and turns a DCWrapper into a Wrapper type.
 func SpecificDC(dc string, tlsWrap DCWrapper) Wrapper {
@@ -193,12 +336,54 @@ func (c *Config) wrapTLSClient(conn net.Conn, tlsConfig *tls.Config) (net.Conn,
 	return tlsConn, err
 }
 
+// fileWatchInterval is how often the Configurator checks whether the watched
+// certificate files changed.
+const fileWatchInterval = 5 * time.Second
+
 // Configurator holds a Config and is responsible for generating all the
 // *tls.Config necessary for Consul. Except the one in the api package.
 type Configurator struct {
 	sync.Mutex
 	base   *Config
 	checks map[string]bool
+
+	// version is incremented every time the configuration is updated.
+	version uint64
+
+	// cert is the key pair reloaded from disk by the file watcher. The
+	// *tls.Config generated with WatchFiles serve it instead of the key pair
+	// they were generated with, so that existing listeners and clients pick
+	// up rotated certificates. It is reset by Update.
+	cert *tls.Certificate
+
+	// crls are the revocation lists loaded from CRLFile and CRLPath. They
+	// are looked up at every handshake by the *tls.Config for incoming
+	// connections, so that Update applies new lists to existing listeners.
+	crls []*x509.RevocationList
+
+	// sniCerts are the key pairs loaded from SNICertificates. Like the
+	// revocation lists they are looked up at every handshake.
+	sniCerts []*tls.Certificate
+
+	// notifyCh receives a value when the file watcher updated the
+	// configuration.
+	notifyCh chan struct{}
+
+	// ocspLeaf is the DER encoded certificate ocspStaple, the OCSP response
+	// stapled to incoming HTTPS handshakes, was fetched for. The staple is
+	// dropped at ocspNextUpdate.
+	ocspLeaf       []byte
+	ocspStaple     []byte
+	ocspNextUpdate time.Time
+
+	// ocspCh wakes up the OCSP refresher when the configuration changed.
+	ocspCh chan struct{}
+
+	// logger reports the errors of the background refreshers.
+	logger *log.Logger
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 // NewConfigurator creates a new Configurator and sets the provided
@@ -206,15 +391,200 @@ type Configurator struct {
 // Todo (Hans): should config be a value instead a pointer to avoid side
 // effects?
 func NewConfigurator(config *Config) *Configurator {
-	return &Configurator{base: config, checks: map[string]bool{}}
+	return newConfigurator(config, fileWatchInterval)
+}
+
+func newConfigurator(config *Config, interval time.Duration) *Configurator {
+	c := &Configurator{
+		base:     config,
+		checks:   map[string]bool{},
+		notifyCh: make(chan struct{}, 1),
+		ocspCh:   make(chan struct{}, 1),
+		logger:   log.New(os.Stderr, "", log.LstdFlags),
+		stopCh:   make(chan struct{}),
+	}
+	// Invalid revocation lists are reported when generating the *tls.Config
+	// for incoming connections.
+	c.crls, _ = loadCRLs(config)
+	c.sniCerts, _ = loadSNICertificates(config)
+	if config != nil && config.WatchFiles {
+		// The files are compared to their state when the Configurator was
+		// created, so that changes made right after aren't missed.
+		_, files := c.watchedFiles()
+		go c.watchFiles(interval, files)
+	}
+	if config != nil && config.OCSPStapling {
+		go c.refreshOCSP()
+	}
+	return c
+}
+
+// SetLogger sets the logger the errors of the background refreshers are
+// reported to.
+func (c *Configurator) SetLogger(logger *log.Logger) {
+	c.Lock()
+	c.logger = logger
+	c.Unlock()
 }
 
 // Update updates the internal configuration which is used to generate
 // *tls.Config.
 func (c *Configurator) Update(config *Config) {
+	c.update(config, nil)
+}
+
+func (c *Configurator) update(config *Config, cert *tls.Certificate) {
+	crls, crlErr := loadCRLs(config)
+	sniCerts, sniErr := loadSNICertificates(config)
+
 	c.Lock()
 	defer c.Unlock()
 	c.base = config
+	c.cert = cert
+	// The previous revocation lists stay in use if the new ones can't be
+	// loaded, rather than accepting revoked certificates.
+	if crlErr == nil {
+		c.crls = crls
+	}
+	if sniErr == nil {
+		c.sniCerts = sniCerts
+	}
+	c.version++
+
+	select {
+	case c.ocspCh <- struct{}{}:
+	default:
+	}
+}
+
+// Version returns the number of times the configuration was updated, either
+// with Update or by the file watcher.
+func (c *Configurator) Version() uint64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.version
+}
+
+// Notify returns a channel which receives a value after the file watcher
+// reloaded the certificate files. Notifications are coalesced if they aren't
+// received in time, so Version should be used to tell how many updates
+// happened.
+func (c *Configurator) Notify() <-chan struct{} {
+	return c.notifyCh
+}
+
+// Stop stops watching the certificate files and refreshing the OCSP staple.
+func (c *Configurator) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
+
+// CertFiles returns the paths of the certificate and key the agent serves
+// TLS connections with. Both are empty if they aren't configured.
+func (c *Configurator) CertFiles() (string, string) {
+	c.Lock()
+	defer c.Unlock()
+	if c.base == nil {
+		return "", ""
+	}
+	return c.base.CertFile, c.base.KeyFile
+}
+
+// fileState identifies the content of a watched file without reading it.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchedFiles returns the state of the files watched for the current
+// configuration. Files which can't be read have the zero state.
+func (c *Configurator) watchedFiles() (*Config, map[string]fileState) {
+	c.Lock()
+	base := c.base
+	c.Unlock()
+
+	files := make(map[string]fileState)
+	if base == nil || !base.WatchFiles {
+		return base, files
+	}
+	paths := []string{base.CertFile, base.KeyFile, base.CertBundleFile, base.KeyPassphraseFile, base.CAFile, base.CRLFile}
+	for _, sni := range base.SNICertificates {
+		paths = append(paths, sni.CertFile, sni.KeyFile)
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		var state fileState
+		if info, err := os.Stat(path); err == nil {
+			state = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		files[path] = state
+	}
+	return base, files
+}
+
+// watchFiles polls the certificate files every interval and updates the
+// configuration when they changed from last, until Stop is called. A change is only
+// applied once the files can be loaded, which makes the watcher wait for
+// the certificate and the key to be rotated together.
+func (c *Configurator) watchFiles(interval time.Duration, last map[string]fileState) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		base, files := c.watchedFiles()
+		if base == nil || !base.WatchFiles {
+			last = files
+			continue
+		}
+		if reflect.DeepEqual(files, last) {
+			continue
+		}
+
+		cert, err := base.KeyPair()
+		if err != nil {
+			continue
+		}
+		if _, err := base.CAPool(); err != nil {
+			continue
+		}
+		if _, err := loadCRLs(base); err != nil {
+			continue
+		}
+		if _, err := loadSNICertificates(base); err != nil {
+			continue
+		}
+
+		// The configuration is copied so that the watcher doesn't change
+		// the value passed to NewConfigurator or Update.
+		config := *base
+		c.update(&config, cert)
+		last = files
+
+		select {
+		case c.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// currentCert returns the key pair reloaded by the file watcher, or the
+// given one if the files weren't reloaded since the last Update.
+func (c *Configurator) currentCert(cert *tls.Certificate) *tls.Certificate {
+	c.Lock()
+	defer c.Unlock()
+	if c.cert != nil {
+		return c.cert
+	}
+	return cert
 }
 
 // commonTLSConfig generates a *tls.Config from the base configuration the
@@ -245,40 +615,55 @@ func (c *Configurator) commonTLSConfig(additionalVerifyIncomingFlag bool) (*tls.
 		tlsConfig.Certificates = []tls.Certificate{*cert}
 	}
 
+	// Serve the latest certificate reloaded from disk.
+	if c.base.WatchFiles && cert != nil {
+		tlsConfig.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return c.currentCert(cert), nil
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return c.currentCert(cert), nil
+		}
+	}
+
 	// Check if a minimum TLS version was set
 	if c.base.TLSMinVersion != "" {
 		tlsvers, ok := TLSLookup[c.base.TLSMinVersion]
 		if !ok {
-			return nil, fmt.Errorf("TLSMinVersion: value %s not supported, please specify one of [tls10,tls11,tls12]", c.base.TLSMinVersion)
+			return nil, fmt.Errorf("TLSMinVersion: value %s not supported, please specify one of [tls10,tls11,tls12,tls13]", c.base.TLSMinVersion)
 		}
 		tlsConfig.MinVersion = tlsvers
 	}
 
+	// Check if a maximum TLS version was set
+	if c.base.TLSMaxVersion != "" {
+		tlsvers, ok := TLSLookup[c.base.TLSMaxVersion]
+		if !ok {
+			return nil, fmt.Errorf("TLSMaxVersion: value %s not supported, please specify one of [tls10,tls11,tls12,tls13]", c.base.TLSMaxVersion)
+		}
+		if tlsConfig.MinVersion > tlsvers {
+			return nil, fmt.Errorf("TLSMinVersion %s is greater than TLSMaxVersion %s", c.base.TLSMinVersion, c.base.TLSMaxVersion)
+		}
+		tlsConfig.MaxVersion = tlsvers
+	}
+
 	// Ensure we have a CA if VerifyOutgoing is set
-	if c.base.VerifyOutgoing && c.base.CAFile == "" && c.base.CAPath == "" {
+	if c.base.VerifyOutgoing && !c.base.hasCA() {
 		return nil, fmt.Errorf("VerifyOutgoing set, and no CA certificate provided!")
 	}
 
 	// Parse the CA certs if any
-	if c.base.CAFile != "" {
-		pool, err := rootcerts.LoadCAFile(c.base.CAFile)
-		if err != nil {
-			return nil, err
-		}
-		tlsConfig.ClientCAs = pool
-		tlsConfig.RootCAs = pool
-	} else if c.base.CAPath != "" {
-		pool, err := rootcerts.LoadCAPath(c.base.CAPath)
-		if err != nil {
-			return nil, err
-		}
+	pool, err := c.base.CAPool()
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
 		tlsConfig.ClientCAs = pool
 		tlsConfig.RootCAs = pool
 	}
 
 	// Set ClientAuth if necessary
 	if c.base.VerifyIncoming || additionalVerifyIncomingFlag {
-		if c.base.CAFile == "" && c.base.CAPath == "" {
+		if !c.base.hasCA() {
 			return nil, fmt.Errorf("VerifyIncoming set, and no CA certificate provided!")
 		}
 		if len(tlsConfig.Certificates) == 0 {
@@ -288,17 +673,77 @@ func (c *Configurator) commonTLSConfig(additionalVerifyIncomingFlag bool) (*tls.
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
+	// crypto/tls only asks GetCertificate when there are no Certificates
+	// or the client sent SNI, so the key pair loaded above is only used for
+	// the validation.
+	if tlsConfig.GetCertificate != nil {
+		tlsConfig.Certificates = nil
+	}
+
+	return tlsConfig, nil
+}
+
+// withRevocationCheck makes a *tls.Config for incoming connections reject
+// client certificates revoked by the configured revocation lists.
+func (c *Configurator) withRevocationCheck(tlsConfig *tls.Config, err error) (*tls.Config, error) {
+	if err != nil {
+		return nil, err
+	}
+	if c.base.CRLFile == "" && c.base.CRLPath == "" {
+		return tlsConfig, nil
+	}
+
+	// The lists in use were loaded by NewConfigurator or Update, which
+	// can't report errors.
+	if _, err := loadCRLs(c.base); err != nil {
+		return nil, err
+	}
+	tlsConfig.VerifyPeerCertificate = c.verifyNotRevoked
 	return tlsConfig, nil
 }
 
 // IncomingRPCConfig generates a *tls.Config for incoming RPC connections.
 func (c *Configurator) IncomingRPCConfig() (*tls.Config, error) {
-	return c.commonTLSConfig(c.base.VerifyIncomingRPC)
+	return c.withRevocationCheck(c.commonTLSConfig(c.base.VerifyIncomingRPC))
 }
 
 // IncomingHTTPSConfig generates a *tls.Config for incoming HTTPS connections.
 func (c *Configurator) IncomingHTTPSConfig() (*tls.Config, error) {
-	return c.commonTLSConfig(c.base.VerifyIncomingHTTPS)
+	tlsConfig, err := c.withRevocationCheck(c.commonTLSConfig(c.base.VerifyIncomingHTTPS))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig, err = c.withSNICertificates(tlsConfig); err != nil || !c.base.OCSPStapling {
+		return tlsConfig, err
+	}
+	return c.withOCSPStaple(tlsConfig), nil
+}
+
+// IncomingHTTPSListenerConfig generates a *tls.Config for incoming HTTPS
+// connections on a listener which has its own certificate and client
+// verification setting. All other settings are taken from the base config.
+func (c *Configurator) IncomingHTTPSListenerConfig(certFile, keyFile string, verifyIncoming bool) (*tls.Config, error) {
+	c.Lock()
+	if c.base == nil {
+		c.Unlock()
+		return nil, fmt.Errorf("No base config")
+	}
+	base := *c.base
+	c.Unlock()
+
+	base.CertFile = certFile
+	base.KeyFile = keyFile
+	base.CertPEM = ""
+	base.KeyPEM = ""
+	base.CertBundleFile = ""
+	base.KeyPassphrase = ""
+	base.KeyPassphraseFile = ""
+	base.SNICertificates = nil
+	base.VerifyIncoming = verifyIncoming
+	base.VerifyIncomingHTTPS = verifyIncoming
+	// The listener's own certificate isn't watched.
+	base.WatchFiles = false
+	return c.withRevocationCheck(NewConfigurator(&base).commonTLSConfig(false))
 }
 
 // IncomingTLSConfig generates a *tls.Config for outgoing TLS connections for
@@ -329,13 +774,51 @@ func (c *Configurator) OutgoingTLSConfigForCheck(id string) (*tls.Config, error)
 // there is a CA or VerifyOutgoing is set, a *tls.Config will be provided,
 // otherwise we assume that no TLS should be used.
 func (c *Configurator) OutgoingRPCConfig() (*tls.Config, error) {
-	useTLS := c.base.CAFile != "" || c.base.CAPath != "" || c.base.VerifyOutgoing
+	useTLS := c.base.hasCA() || c.base.VerifyOutgoing
 	if !useTLS {
 		return nil, nil
 	}
 	return c.commonTLSConfig(false)
 }
 
+// OutgoingHTTPSConfig generates a *tls.Config for outgoing HTTPS connections
+// to services other than Consul, such as metrics collectors. The certificate
+// of the server is always verified, against the system roots if there is no
+// CA.
+func (c *Configurator) OutgoingHTTPSConfig() (*tls.Config, error) {
+	tlsConfig, err := c.commonTLSConfig(false)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.InsecureSkipVerify = false
+	tlsConfig.ClientCAs = nil
+	return tlsConfig, nil
+}
+
+// serverNameForDC returns the name the certificates of the servers of the
+// datacenter are verified against.
+func (c *Configurator) serverNameForDC(dc string) string {
+	if name, ok := c.base.DatacenterServerNames[dc]; ok {
+		return name
+	}
+	// Strip the trailing '.' from the domain if any
+	domain := strings.TrimSuffix(c.base.Domain, ".")
+	return "server." + dc + "." + domain
+}
+
+// IsServerCert returns whether cert is valid for the name the certificates
+// of the servers of one of the given datacenters are verified against.
+func (c *Configurator) IsServerCert(cert *x509.Certificate, datacenters []string) bool {
+	c.Lock()
+	defer c.Unlock()
+	for _, dc := range datacenters {
+		if cert.VerifyHostname(c.serverNameForDC(dc)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // OutgoingRPCWrapper wraps the result of OutgoingRPCConfig in a DCWrapper. It
 // decides if verify server hostname should be used.
 func (c *Configurator) OutgoingRPCWrapper() (DCWrapper, error) {
@@ -352,18 +835,58 @@ func (c *Configurator) OutgoingRPCWrapper() (DCWrapper, error) {
 
 	// Generate the wrapper based on hostname verification
 	wrapper := func(dc string, conn net.Conn) (net.Conn, error) {
+		config := tlsConfig
 		if c.base.VerifyServerHostname {
-			// Strip the trailing '.' from the domain if any
-			domain := strings.TrimSuffix(c.base.Domain, ".")
-			tlsConfig = tlsConfig.Clone()
-			tlsConfig.ServerName = "server." + dc + "." + domain
+			config = tlsConfig.Clone()
+			config.ServerName = c.serverNameForDC(dc)
 		}
-		return c.base.wrapTLSClient(conn, tlsConfig)
+		return c.base.wrapTLSClient(conn, config)
 	}
 
 	return wrapper, nil
 }
 
+// OutgoingALPNRPCWrapper is like OutgoingRPCWrapper, but the returned
+// wrapper completes the handshake and fails if the server didn't agree to
+// the requested application protocol.
+func (c *Configurator) OutgoingALPNRPCWrapper() (ALPNWrapper, error) {
+	// Get the TLS config
+	tlsConfig, err := c.OutgoingRPCConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if TLS is not enabled
+	if tlsConfig == nil {
+		return nil, nil
+	}
+
+	alpnWrapper := func(dc, alpnProto string, conn net.Conn) (net.Conn, error) {
+		config := tlsConfig.Clone()
+		config.NextProtos = []string{alpnProto}
+		if c.base.VerifyServerHostname {
+			config.ServerName = c.serverNameForDC(dc)
+		}
+
+		wrapped, err := c.base.wrapTLSClient(conn, config)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := wrapped.(*tls.Conn)
+		if err := tlsConn.Handshake(); err != nil {
+			tlsConn.Close()
+			return nil, err
+		}
+		if proto := tlsConn.ConnectionState().NegotiatedProtocol; proto != alpnProto {
+			tlsConn.Close()
+			return nil, fmt.Errorf("server doesn't support protocol %q", alpnProto)
+		}
+		return tlsConn, nil
+	}
+
+	return alpnWrapper, nil
+}
+
 // AddCheck adds a check to the internal check map together with the skipVerify
 // value, which is used when generating a *tls.Config for this check.
 func (c *Configurator) AddCheck(id string, skipVerify bool) {
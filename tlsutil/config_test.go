@@ -3,12 +3,17 @@ package tlsutil
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/yamux"
 	"github.com/stretchr/testify/require"
@@ -39,6 +44,129 @@ func TestConfig_KeyPair_Valid(t *testing.T) {
 	}
 }
 
+func TestConfig_KeyPair_PEM(t *testing.T) {
+	certPEM, err := ioutil.ReadFile("../test/key/ourdomain.cer")
+	require.NoError(t, err)
+	keyPEM, err := ioutil.ReadFile("../test/key/ourdomain.key")
+	require.NoError(t, err)
+
+	// The inline material is preferred to the files.
+	conf := &Config{
+		CertFile: "/something/bogus",
+		KeyFile:  "/something/bogus",
+		CertPEM:  string(certPEM),
+		KeyPEM:   string(keyPEM),
+	}
+	cert, err := conf.KeyPair()
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+
+	// Inline and file material can be mixed.
+	conf = &Config{
+		CertPEM: string(certPEM),
+		KeyFile: "../test/key/ourdomain.key",
+	}
+	cert, err = conf.KeyPair()
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+
+	conf = &Config{
+		CertPEM: string(certPEM),
+		KeyPEM:  "bogus",
+	}
+	_, err = conf.KeyPair()
+	require.Error(t, err)
+}
+
+func TestConfig_KeyPair_Encrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	passFile := filepath.Join(dir, "passphrase")
+	require.NoError(t, ioutil.WriteFile(passFile, []byte("secret\n"), 0600))
+
+	for _, keyFile := range []string{
+		"../test/key/ourdomain-pkcs8-encrypted.key",
+		"../test/key/ourdomain-pkcs1-encrypted.key",
+	} {
+		t.Run(filepath.Base(keyFile), func(t *testing.T) {
+			conf := &Config{
+				CertFile:      "../test/key/ourdomain.cer",
+				KeyFile:       keyFile,
+				KeyPassphrase: "secret",
+			}
+			cert, err := conf.KeyPair()
+			require.NoError(t, err)
+			require.NotNil(t, cert)
+
+			// The passphrase can be read from a file.
+			conf.KeyPassphrase = ""
+			conf.KeyPassphraseFile = passFile
+			cert, err = conf.KeyPair()
+			require.NoError(t, err)
+			require.NotNil(t, cert)
+
+			conf.KeyPassphraseFile = ""
+			_, err = conf.KeyPair()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "no passphrase is configured")
+
+			conf.KeyPassphrase = "wrong"
+			_, err = conf.KeyPair()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "incorrect passphrase")
+		})
+	}
+
+	// An unencrypted key ignores the passphrase.
+	conf := &Config{
+		CertFile:      "../test/key/ourdomain.cer",
+		KeyFile:       "../test/key/ourdomain.key",
+		KeyPassphrase: "secret",
+	}
+	cert, err := conf.KeyPair()
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+}
+
+func TestConfig_KeyPair_CertBundle(t *testing.T) {
+	leaf, err := ioutil.ReadFile("../test/key/ourdomain.cer")
+	require.NoError(t, err)
+	root, err := ioutil.ReadFile("../test/ca/root.cer")
+	require.NoError(t, err)
+	leafBlock, _ := pem.Decode(leaf)
+	rootBlock, _ := pem.Decode(root)
+
+	for _, bundle := range []string{
+		"../test/key/ourdomain.p12",
+		"../test/key/ourdomain-legacy.p12",
+	} {
+		t.Run(filepath.Base(bundle), func(t *testing.T) {
+			// The bundle replaces the cert and key files.
+			conf := &Config{
+				CertFile:       "/something/bogus",
+				KeyFile:        "/something/bogus",
+				CertBundleFile: bundle,
+				KeyPassphrase:  "secret",
+			}
+			cert, err := conf.KeyPair()
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{leafBlock.Bytes, rootBlock.Bytes}, cert.Certificate)
+			require.Equal(t, "testco.internal", cert.Leaf.Subject.CommonName)
+			require.NotNil(t, cert.PrivateKey)
+
+			conf.KeyPassphrase = "wrong"
+			_, err = conf.KeyPair()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "incorrect passphrase")
+		})
+	}
+
+	conf := &Config{CertBundleFile: "../test/key/ourdomain.cer"}
+	_, err = conf.KeyPair()
+	require.Error(t, err)
+}
+
 func TestConfigurator_OutgoingTLS_MissingCA(t *testing.T) {
 	conf := &Config{
 		VerifyOutgoing: true,
@@ -118,7 +246,7 @@ func TestConfigurator_OutgoingTLS_WithKeyPair(t *testing.T) {
 }
 
 func TestConfigurator_OutgoingTLS_TLSMinVersion(t *testing.T) {
-	tlsVersions := []string{"tls10", "tls11", "tls12"}
+	tlsVersions := []string{"tls10", "tls11", "tls12", "tls13"}
 	for _, version := range tlsVersions {
 		conf := &Config{
 			VerifyOutgoing: true,
@@ -231,6 +359,93 @@ func TestConfigurator_outgoingWrapper_BadDC(t *testing.T) {
 	<-errc
 }
 
+func TestConfigurator_outgoingWrapper_DatacenterServerNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The servers of dc2 have certificates for a custom name.
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	ca, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	cert, key, err := GenerateCert(signer, ca, big.NewInt(2), "server", 365, []string{"consul.dc2.example.com"}, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), []byte(ca), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte(cert), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte(key), 0600))
+
+	serverConfig := &Config{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	c := NewConfigurator(&Config{
+		CAFile:               filepath.Join(dir, "ca.pem"),
+		VerifyServerHostname: true,
+		VerifyOutgoing:       true,
+		Domain:               "consul.",
+		DatacenterServerNames: map[string]string{
+			"dc2": "consul.dc2.example.com",
+		},
+	})
+	require.Equal(t, "consul.dc2.example.com", c.serverNameForDC("dc2"))
+	require.Equal(t, "server.dc1.consul", c.serverNameForDC("dc1"))
+
+	wrap, err := c.OutgoingRPCWrapper()
+	require.NoError(t, err)
+
+	client, errc := startTLSServer(serverConfig)
+	if client == nil {
+		t.Fatalf("startTLSServer err: %v", <-errc)
+	}
+	tlsClient, err := wrap("dc2", client)
+	require.NoError(t, err)
+	require.NoError(t, tlsClient.(*tls.Conn).Handshake())
+	tlsClient.Close()
+	require.NoError(t, <-errc)
+
+	// Other datacenters still use the default name.
+	client, errc = startTLSServer(serverConfig)
+	if client == nil {
+		t.Fatalf("startTLSServer err: %v", <-errc)
+	}
+	tlsClient, err = wrap("dc1", client)
+	require.NoError(t, err)
+	err = tlsClient.(*tls.Conn).Handshake()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "server.dc1.consul")
+	tlsClient.Close()
+	<-errc
+}
+
+func TestConfigurator_IsServerCert(t *testing.T) {
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	ca, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	parse := func(name string) *x509.Certificate {
+		cert, _, err := GenerateCert(signer, ca, big.NewInt(2), name, 365, []string{name}, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+		require.NoError(t, err)
+		block, _ := pem.Decode([]byte(cert))
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	c := NewConfigurator(&Config{
+		Domain: "consul.",
+		DatacenterServerNames: map[string]string{
+			"dc2": "consul.dc2.example.com",
+		},
+	})
+	require.True(t, c.IsServerCert(parse("server.dc1.consul"), []string{"dc1", "dc2"}))
+	require.True(t, c.IsServerCert(parse("consul.dc2.example.com"), []string{"dc1", "dc2"}))
+	require.False(t, c.IsServerCert(parse("server.dc2.consul"), []string{"dc1", "dc2"}))
+	require.False(t, c.IsServerCert(parse("server.dc3.consul"), []string{"dc1", "dc2"}))
+	require.False(t, c.IsServerCert(parse("client.dc1.consul"), []string{"dc1", "dc2"}))
+}
+
 func TestConfigurator_outgoingWrapper_BadCert(t *testing.T) {
 	config := &Config{
 		CAFile:               "../test/ca/root.cer",
@@ -435,8 +650,33 @@ func TestConfigurator_IncomingHTTPS_NoVerify(t *testing.T) {
 	require.Empty(t, tlsConf.Certificates)
 }
 
+func TestConfigurator_IncomingHTTPSListenerConfig(t *testing.T) {
+	conf := &Config{
+		VerifyIncoming: true,
+		CAFile:         "../test/ca/root.cer",
+		CertFile:       "../test/key/ourdomain.cer",
+		KeyFile:        "../test/key/ourdomain.key",
+		TLSMinVersion:  "tls12",
+	}
+	c := NewConfigurator(conf)
+
+	tlsConf, err := c.IncomingHTTPSListenerConfig("../test/key/ssl-cert-snakeoil.pem", "../test/key/ssl-cert-snakeoil.key", false)
+	require.NoError(t, err)
+	require.Equal(t, tls.NoClientCert, tlsConf.ClientAuth)
+	require.Equal(t, uint16(tls.VersionTLS12), tlsConf.MinVersion)
+	require.Len(t, tlsConf.Certificates, 1)
+
+	defConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	require.NotEqual(t, defConf.Certificates[0].Certificate, tlsConf.Certificates[0].Certificate)
+
+	// the base config is not modified
+	require.Equal(t, "../test/key/ourdomain.cer", conf.CertFile)
+	require.True(t, conf.VerifyIncoming)
+}
+
 func TestConfigurator_IncomingHTTPS_TLSMinVersion(t *testing.T) {
-	tlsVersions := []string{"tls10", "tls11", "tls12"}
+	tlsVersions := []string{"tls10", "tls11", "tls12", "tls13"}
 	for _, version := range tlsVersions {
 		conf := &Config{
 			VerifyIncoming: true,
@@ -518,7 +758,7 @@ func TestConfigurator_CommonTLSConfigCertKey(t *testing.T) {
 }
 
 func TestConfigurator_CommonTLSConfigTLSMinVersion(t *testing.T) {
-	tlsVersions := []string{"tls10", "tls11", "tls12"}
+	tlsVersions := []string{"tls10", "tls11", "tls12", "tls13"}
 	for _, version := range tlsVersions {
 		c := NewConfigurator(&Config{TLSMinVersion: version})
 		tlsConf, err := c.commonTLSConfig(false)
@@ -531,6 +771,53 @@ func TestConfigurator_CommonTLSConfigTLSMinVersion(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestConfigurator_CommonTLSConfigTLSMaxVersion(t *testing.T) {
+	for _, version := range []string{"tls10", "tls11", "tls12", "tls13"} {
+		c := NewConfigurator(&Config{TLSMaxVersion: version})
+		tlsConf, err := c.commonTLSConfig(false)
+		require.NoError(t, err)
+		require.Equal(t, TLSLookup[version], tlsConf.MaxVersion)
+	}
+
+	c := NewConfigurator(&Config{TLSMaxVersion: "tlsBOGUS"})
+	_, err := c.commonTLSConfig(false)
+	require.Error(t, err)
+
+	// The range can be pinned to a single version.
+	c = NewConfigurator(&Config{TLSMinVersion: "tls13", TLSMaxVersion: "tls13"})
+	tlsConf, err := c.commonTLSConfig(false)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), tlsConf.MinVersion)
+	require.Equal(t, uint16(tls.VersionTLS13), tlsConf.MaxVersion)
+
+	c = NewConfigurator(&Config{TLSMinVersion: "tls12", TLSMaxVersion: "tls11"})
+	_, err = c.commonTLSConfig(false)
+	require.Error(t, err)
+}
+
+func TestConfigurator_TLSMaxVersion_Handshake(t *testing.T) {
+	serverConf, err := NewConfigurator(&Config{
+		CertFile:      "../test/key/ourdomain.cer",
+		KeyFile:       "../test/key/ourdomain.key",
+		TLSMaxVersion: "tls12",
+	}).IncomingHTTPSConfig()
+	require.NoError(t, err)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Server(server, serverConf).Handshake()
+	}()
+
+	tlsClient := tls.Client(client, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, tlsClient.Handshake())
+	require.NoError(t, <-errCh)
+	require.Equal(t, uint16(tls.VersionTLS12), tlsClient.ConnectionState().Version)
+}
+
 func TestConfigurator_CommonTLSConfigValidateVerifyOutgoingCA(t *testing.T) {
 	c := NewConfigurator(&Config{VerifyOutgoing: true})
 	_, err := c.commonTLSConfig(false)
@@ -569,6 +856,51 @@ func TestConfigurator_CommonTLSConfigLoadCA(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, tlsConf.RootCAs.Subjects(), 1)
 	require.Len(t, tlsConf.ClientCAs.Subjects(), 1)
+
+	c.Update(&Config{CAPEM: "bogus"})
+	_, err = c.commonTLSConfig(false)
+	require.Error(t, err)
+
+	// The inline bundle is preferred to the files.
+	caPEM, err := ioutil.ReadFile("../test/ca/root.cer")
+	require.NoError(t, err)
+	c.Update(&Config{CAPEM: string(caPEM), CAPath: "../test/ca_path"})
+	tlsConf, err = c.commonTLSConfig(false)
+	require.NoError(t, err)
+	require.Len(t, tlsConf.RootCAs.Subjects(), 1)
+	require.Len(t, tlsConf.ClientCAs.Subjects(), 1)
+}
+
+func TestConfigurator_PEM(t *testing.T) {
+	caPEM, err := ioutil.ReadFile("../test/ca/root.cer")
+	require.NoError(t, err)
+	certPEM, err := ioutil.ReadFile("../test/key/ourdomain.cer")
+	require.NoError(t, err)
+	keyPEM, err := ioutil.ReadFile("../test/key/ourdomain.key")
+	require.NoError(t, err)
+
+	c := NewConfigurator(&Config{
+		VerifyIncoming: true,
+		VerifyOutgoing: true,
+		CAPEM:          string(caPEM),
+		CertPEM:        string(certPEM),
+		KeyPEM:         string(keyPEM),
+	})
+
+	tlsConf, err := c.IncomingRPCConfig()
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, tlsConf.ClientAuth)
+	require.Len(t, tlsConf.Certificates, 1)
+
+	tlsConf, err = c.OutgoingRPCConfig()
+	require.NoError(t, err)
+	require.NotNil(t, tlsConf)
+	require.Len(t, tlsConf.RootCAs.Subjects(), 1)
+
+	// A listener with its own certificate files doesn't use the inline
+	// key pair.
+	_, err = c.IncomingHTTPSListenerConfig("/something/bogus", "/something/bogus", false)
+	require.Error(t, err)
 }
 
 func TestConfigurator_CommonTLSConfigVerifyIncoming(t *testing.T) {
@@ -673,6 +1005,27 @@ func TestConfigurator_OutgoingRPCConfig(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestConfigurator_OutgoingHTTPSConfig(t *testing.T) {
+	c := NewConfigurator(&Config{})
+	tlsConf, err := c.OutgoingHTTPSConfig()
+	require.NoError(t, err)
+	require.False(t, tlsConf.InsecureSkipVerify)
+	require.Nil(t, tlsConf.RootCAs)
+	require.Empty(t, tlsConf.Certificates)
+
+	c.Update(&Config{
+		CAFile:   "../test/ca/root.cer",
+		CertFile: "../test/key/ourdomain.cer",
+		KeyFile:  "../test/key/ourdomain.key",
+	})
+	tlsConf, err = c.OutgoingHTTPSConfig()
+	require.NoError(t, err)
+	require.False(t, tlsConf.InsecureSkipVerify)
+	require.NotNil(t, tlsConf.RootCAs)
+	require.Nil(t, tlsConf.ClientCAs)
+	require.Len(t, tlsConf.Certificates, 1)
+}
+
 func TestConfigurator_OutgoingTLSConfigForChecks(t *testing.T) {
 	c := NewConfigurator(&Config{})
 	tlsConf, err := c.OutgoingTLSConfigForCheck("")
@@ -717,3 +1070,98 @@ func TestConfigurator_OutgoingTLSConfigForChecks(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "node", tlsConf.ServerName)
 }
+
+func TestConfigurator_CertFiles(t *testing.T) {
+	c := NewConfigurator(&Config{})
+	cert, key := c.CertFiles()
+	require.Empty(t, cert)
+	require.Empty(t, key)
+
+	c.Update(&Config{CertFile: "cert.pem", KeyFile: "key.pem"})
+	cert, key = c.CertFiles()
+	require.Equal(t, "cert.pem", cert)
+	require.Equal(t, "key.pem", key)
+}
+
+func TestConfigurator_WatchFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	copyFile := func(src, dst string) {
+		data, err := ioutil.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, dst), data, 0600))
+
+		// Make sure the change is noticed even if the file system has a
+		// coarse modification time.
+		future := time.Now().Add(time.Duration(len(data)) * time.Second)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, dst), future, future))
+	}
+	copyFile("../test/key/ourdomain.cer", "cert.pem")
+	copyFile("../test/key/ourdomain.key", "key.pem")
+
+	c := newConfigurator(&Config{
+		CertFile:   filepath.Join(dir, "cert.pem"),
+		KeyFile:    filepath.Join(dir, "key.pem"),
+		WatchFiles: true,
+	}, 10*time.Millisecond)
+	defer c.Stop()
+
+	ourdomain, err := tls.LoadX509KeyPair("../test/key/ourdomain.cer", "../test/key/ourdomain.key")
+	require.NoError(t, err)
+
+	// The certificate is only served by GetCertificate, which crypto/tls
+	// doesn't ask when there are Certificates and no SNI.
+	tlsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	require.Empty(t, tlsConf.Certificates)
+	cert, err := tlsConf.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Equal(t, ourdomain.Certificate, cert.Certificate)
+
+	// A certificate which doesn't match the key isn't loaded.
+	copyFile("../test/hostname/Alice.crt", "cert.pem")
+	select {
+	case <-c.Notify():
+		t.Fatal("reloaded mismatched key pair")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.Equal(t, uint64(0), c.Version())
+
+	copyFile("../test/hostname/Alice.key", "key.pem")
+	select {
+	case <-c.Notify():
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificate files weren't reloaded")
+	}
+	require.Equal(t, uint64(1), c.Version())
+
+	alice, err := tls.LoadX509KeyPair("../test/hostname/Alice.crt", "../test/hostname/Alice.key")
+	require.NoError(t, err)
+
+	// The existing config serves the new certificate.
+	cert, err = tlsConf.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, alice.Certificate, cert.Certificate)
+	clientCert, err := tlsConf.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, alice.Certificate, clientCert.Certificate)
+
+	// New configs load the new certificate.
+	tlsConf, err = c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	cert, err = tlsConf.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Equal(t, alice.Certificate, cert.Certificate)
+}
+
+func TestConfigurator_Version(t *testing.T) {
+	c := NewConfigurator(&Config{})
+	defer c.Stop()
+	require.Equal(t, uint64(0), c.Version())
+
+	c.Update(&Config{})
+	c.Update(&Config{})
+	require.Equal(t, uint64(2), c.Version())
+}
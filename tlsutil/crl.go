@@ -0,0 +1,115 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// loadCRLs loads the certificate revocation lists from the CRLFile and the
+// CRLPath of the given configuration.
+func loadCRLs(config *Config) ([]*x509.RevocationList, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var files []string
+	if config.CRLFile != "" {
+		files = append(files, config.CRLFile)
+	}
+	if config.CRLPath != "" {
+		infos, err := ioutil.ReadDir(config.CRLPath)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read CRL path %q: %v", config.CRLPath, err)
+		}
+		for _, info := range infos {
+			if !info.IsDir() {
+				files = append(files, filepath.Join(config.CRLPath, info.Name()))
+			}
+		}
+	}
+
+	var crls []*x509.RevocationList
+	for _, file := range files {
+		loaded, err := loadCRLFile(file)
+		if err != nil {
+			return nil, err
+		}
+		crls = append(crls, loaded...)
+	}
+	return crls, nil
+}
+
+// loadCRLFile parses a file containing either PEM encoded revocation lists or
+// a single DER encoded one.
+func loadCRLFile(file string) ([]*x509.RevocationList, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CRL file %q: %v", file, err)
+	}
+
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse CRL file %q: %v", file, err)
+		}
+		return []*x509.RevocationList{crl}, nil
+	}
+
+	var crls []*x509.RevocationList
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse CRL file %q: %v", file, err)
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) == 0 {
+		return nil, fmt.Errorf("No CRL found in %q", file)
+	}
+	return crls, nil
+}
+
+// verifyNotRevoked is used as the VerifyPeerCertificate callback of the
+// *tls.Config for incoming connections. It rejects the verified chains which
+// contain a certificate revoked by a list its issuer signed.
+func (c *Configurator) verifyNotRevoked(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	c.Lock()
+	crls := c.crls
+	c.Unlock()
+
+	if len(crls) == 0 {
+		return nil
+	}
+
+	for _, chain := range verifiedChains {
+		for i := 0; i+1 < len(chain); i++ {
+			cert, issuer := chain[i], chain[i+1]
+			for _, crl := range crls {
+				if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) {
+					continue
+				}
+				if crl.CheckSignatureFrom(issuer) != nil {
+					continue
+				}
+				for _, revoked := range crl.RevokedCertificateEntries {
+					if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+						return fmt.Errorf("certificate %q with serial number %s is revoked", cert.Subject.CommonName, cert.SerialNumber)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
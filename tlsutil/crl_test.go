@@ -0,0 +1,183 @@
+package tlsutil
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCRLChain generates a CA with a server and a client certificate in dir,
+// and returns the parsed chain of the client certificate together with a
+// function writing a revocation list of the CA for the given serial numbers.
+func testCRLChain(t *testing.T, dir string) ([]*x509.Certificate, func(path string, revoked ...*big.Int)) {
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	caPEM, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	serverPEM, serverKey, err := GenerateCert(signer, caPEM, big.NewInt(2), "server", 365, nil, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+	require.NoError(t, err)
+	clientPEM, _, err := GenerateCert(signer, caPEM, big.NewInt(3), "client", 365, nil, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), []byte(caPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte(serverPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte(serverKey), 0600))
+
+	ca, err := parseCert(caPEM)
+	require.NoError(t, err)
+	client, err := parseCert(clientPEM)
+	require.NoError(t, err)
+
+	writeCRL := func(path string, revoked ...*big.Int) {
+		template := &x509.RevocationList{
+			Number:     big.NewInt(time.Now().UnixNano()),
+			ThisUpdate: time.Now(),
+			NextUpdate: time.Now().Add(time.Hour),
+		}
+		for _, sn := range revoked {
+			template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+				SerialNumber:   sn,
+				RevocationTime: time.Now(),
+			})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, template, ca, signer)
+		require.NoError(t, err)
+		data := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+		require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	}
+
+	return []*x509.Certificate{client, ca}, writeCRL
+}
+
+func TestConfigurator_CRLFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chain, writeCRL := testCRLChain(t, dir)
+	crlFile := filepath.Join(dir, "crl.pem")
+	writeCRL(crlFile, chain[0].SerialNumber)
+
+	config := Config{
+		VerifyIncoming: true,
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CRLFile:        crlFile,
+	}
+	c := NewConfigurator(&config)
+
+	rpcConf, err := c.IncomingRPCConfig()
+	require.NoError(t, err)
+	httpsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	for _, tlsConf := range []*tls.Config{rpcConf, httpsConf} {
+		require.NotNil(t, tlsConf.VerifyPeerCertificate)
+		err = tlsConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "revoked")
+	}
+
+	// Outgoing connections don't check revocations.
+	outConf, err := c.OutgoingRPCConfig()
+	require.NoError(t, err)
+	require.Nil(t, outConf.VerifyPeerCertificate)
+
+	// Update reloads the list for the existing configs.
+	writeCRL(crlFile, big.NewInt(42))
+	updated := config
+	c.Update(&updated)
+	require.NoError(t, rpcConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}))
+
+	// A list which can't be loaded is reported, and the previous one stays
+	// in use.
+	require.NoError(t, ioutil.WriteFile(crlFile, []byte("not a crl"), 0600))
+	broken := config
+	c.Update(&broken)
+	_, err = c.IncomingRPCConfig()
+	require.Error(t, err)
+	require.NoError(t, rpcConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}))
+}
+
+func TestConfigurator_CRLPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chain, writeCRL := testCRLChain(t, dir)
+	crlPath := filepath.Join(dir, "crls")
+	require.NoError(t, os.Mkdir(crlPath, 0700))
+	writeCRL(filepath.Join(crlPath, "a.pem"), big.NewInt(42))
+	writeCRL(filepath.Join(crlPath, "b.pem"), chain[0].SerialNumber)
+
+	c := NewConfigurator(&Config{
+		VerifyIncoming: true,
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CRLPath:        crlPath,
+	})
+	tlsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	require.Error(t, tlsConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}))
+
+	// Lists of another CA are ignored.
+	otherDir := filepath.Join(dir, "other")
+	require.NoError(t, os.Mkdir(otherDir, 0700))
+	_, writeOtherCRL := testCRLChain(t, otherDir)
+	writeOtherCRL(filepath.Join(crlPath, "b.pem"), chain[0].SerialNumber)
+	c.Update(&Config{
+		VerifyIncoming: true,
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CRLPath:        crlPath,
+	})
+	require.NoError(t, tlsConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}))
+}
+
+func TestConfigurator_WatchFiles_CRL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chain, writeCRL := testCRLChain(t, dir)
+	crlFile := filepath.Join(dir, "crl.pem")
+	writeCRL(crlFile)
+
+	c := newConfigurator(&Config{
+		VerifyIncoming: true,
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CRLFile:        crlFile,
+		WatchFiles:     true,
+	}, 10*time.Millisecond)
+	defer c.Stop()
+
+	tlsConf, err := c.IncomingRPCConfig()
+	require.NoError(t, err)
+	require.NoError(t, tlsConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}))
+
+	writeCRL(crlFile, chain[0].SerialNumber)
+	// Make sure the change is noticed even if the file system has a coarse
+	// modification time.
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(crlFile, future, future))
+
+	select {
+	case <-c.Notify():
+	case <-time.After(5 * time.Second):
+		t.Fatal("revocation list wasn't reloaded")
+	}
+	require.Error(t, tlsConf.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}))
+}
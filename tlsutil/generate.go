@@ -1,4 +1,4 @@
-package tls
+package tlsutil
 
 import (
 	"bytes"
@@ -207,7 +207,7 @@ func Verify(caString, certString, dns string) error {
 	}
 
 	opts := x509.VerifyOptions{
-		DNSName: fmt.Sprintf(dns),
+		DNSName: dns,
 		Roots:   roots,
 	}
 
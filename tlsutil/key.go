@@ -0,0 +1,255 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+var (
+	// errMissingPassphrase is returned for an encrypted key without a
+	// configured passphrase.
+	errMissingPassphrase = errors.New("private key is encrypted but no passphrase is configured")
+
+	// errIncorrectPassphrase is returned when an encrypted key can't be
+	// decrypted with the configured passphrase.
+	errIncorrectPassphrase = errors.New("failed to decrypt private key: incorrect passphrase")
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3   = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// encryptedPrivateKeyInfo is the PKCS#8 EncryptedPrivateKeyInfo structure
+// from RFC 5208.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the PBES2 parameters from RFC 8018.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the PBKDF2 parameters from RFC 8018.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// keyPassphrase returns the inline KeyPassphrase if it is set, or else the
+// content of KeyPassphraseFile without the trailing newline.
+func (c *Config) keyPassphrase() (string, error) {
+	if c.KeyPassphrase != "" || c.KeyPassphraseFile == "" {
+		return c.KeyPassphrase, nil
+	}
+	data, err := loadPEM("", c.KeyPassphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key passphrase file: %v", err)
+	}
+	return string(bytes.TrimRight(data, "\r\n")), nil
+}
+
+// decryptKeyPEM returns the given PEM data with an encrypted private key
+// replaced by its decrypted form. Both encrypted PKCS#8 keys and legacy
+// PKCS#1 keys with a "Proc-Type: 4,ENCRYPTED" header are supported.
+// Unencrypted keys are returned unchanged.
+func decryptKeyPEM(data []byte, passphrase string) ([]byte, error) {
+	var out []byte
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		var err error
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			if passphrase == "" {
+				return nil, errMissingPassphrase
+			}
+			var der []byte
+			if der, err = decryptPKCS8(block.Bytes, passphrase); err != nil {
+				return nil, err
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+		case x509.IsEncryptedPEMBlock(block):
+			if passphrase == "" {
+				return nil, errMissingPassphrase
+			}
+			var der []byte
+			if der, err = x509.DecryptPEMBlock(block, []byte(passphrase)); err != nil {
+				if err == x509.IncorrectPasswordError {
+					return nil, errIncorrectPassphrase
+				}
+				return nil, fmt.Errorf("failed to decrypt private key: %v", err)
+			}
+			// The legacy padding check passes for about one in 256 wrong
+			// passphrases, so make sure the result is an actual key.
+			if !isPrivateKey(block.Type, der) {
+				return nil, errIncorrectPassphrase
+			}
+			block = &pem.Block{Type: block.Type, Bytes: der}
+		}
+		out = append(out, pem.EncodeToMemory(block)...)
+	}
+	return out, nil
+}
+
+// decryptPKCS8 decrypts a DER encoded PKCS#8 EncryptedPrivateKeyInfo and
+// returns the DER encoded PrivateKeyInfo.
+func decryptPKCS8(der []byte, passphrase string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %v", err)
+	}
+	plain, err := decryptPBE(info.Algorithm, info.EncryptedData, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	// Valid padding around garbage occasionally results from a wrong
+	// passphrase too.
+	if _, err := x509.ParsePKCS8PrivateKey(plain); err != nil {
+		return nil, errIncorrectPassphrase
+	}
+	return plain, nil
+}
+
+// decryptPBE decrypts data which was encrypted with the given password based
+// encryption scheme, either PBES2 with PBKDF2 or one of the PKCS#12 schemes.
+func decryptPBE(alg pkix.AlgorithmIdentifier, data []byte, passphrase string) ([]byte, error) {
+	var block cipher.Block
+	var iv []byte
+	var err error
+	if alg.Algorithm.Equal(oidPBES2) {
+		block, iv, err = pbes2Cipher(alg.Parameters.FullBytes, passphrase)
+	} else {
+		block, iv, err = pkcs12Cipher(alg, passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("failed to decrypt: invalid encrypted data")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// A wrong passphrase shows up as broken padding.
+	n := int(plain[len(plain)-1])
+	if n == 0 || n > block.BlockSize() {
+		return nil, errIncorrectPassphrase
+	}
+	for _, b := range plain[len(plain)-n:] {
+		if int(b) != n {
+			return nil, errIncorrectPassphrase
+		}
+	}
+	return plain[:len(plain)-n], nil
+}
+
+// pbes2Cipher returns the cipher and IV for the given DER encoded PBES2
+// parameters.
+func pbes2Cipher(der []byte, passphrase string) (cipher.Block, []byte, error) {
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(der, &params); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse PBES2 parameters: %v", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, fmt.Errorf("unsupported key derivation function %s, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse PBKDF2 parameters: %v", err)
+	}
+
+	var prf func() hash.Hash
+	switch alg := kdf.PRF.Algorithm; {
+	case len(alg) == 0, alg.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case alg.Equal(oidHMACWithSHA224):
+		prf = sha256.New224
+	case alg.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case alg.Equal(oidHMACWithSHA384):
+		prf = sha512.New384
+	case alg.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function %s", alg)
+	}
+
+	var newCipher func([]byte) (cipher.Block, error)
+	var keyLen int
+	switch alg := params.EncryptionScheme.Algorithm; {
+	case alg.Equal(oidAES128CBC):
+		newCipher, keyLen = aes.NewCipher, 16
+	case alg.Equal(oidAES192CBC):
+		newCipher, keyLen = aes.NewCipher, 24
+	case alg.Equal(oidAES256CBC):
+		newCipher, keyLen = aes.NewCipher, 32
+	case alg.Equal(oidDESEDE3):
+		newCipher, keyLen = des.NewTripleDESCipher, 24
+	default:
+		return nil, nil, fmt.Errorf("unsupported cipher %s", alg)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse cipher parameters: %v", err)
+	}
+
+	key, err := pbkdf2.Key(prf, passphrase, kdf.Salt, kdf.IterationCount, keyLen)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive encryption key: %v", err)
+	}
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, iv, nil
+}
+
+// isPrivateKey returns true if der is a private key of the given PEM type.
+func isPrivateKey(pemType string, der []byte) bool {
+	var err error
+	switch pemType {
+	case "RSA PRIVATE KEY":
+		_, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		_, err = x509.ParseECPrivateKey(der)
+	default:
+		_, err = x509.ParsePKCS8PrivateKey(der)
+	}
+	return err == nil
+}
@@ -0,0 +1,465 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// defaultOCSPRefreshInterval is how often the stapled OCSP response is
+	// refreshed if the config doesn't say otherwise.
+	defaultOCSPRefreshInterval = time.Hour
+
+	// ocspRetryInterval is how long the OCSP refresher waits after a failed
+	// fetch. It is also the shortest time between two fetches.
+	ocspRetryInterval = time.Minute
+
+	// ocspMaxResponseSize limits the size of the responses read from an
+	// OCSP responder.
+	ocspMaxResponseSize = 1 << 20
+)
+
+// ocspClient is the HTTP client used to talk to OCSP responders.
+var ocspClient = &http.Client{Timeout: 10 * time.Second}
+
+var (
+	oidSHA1          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasic     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	ocspSignatureAlg = map[string]x509.SignatureAlgorithm{
+		"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+		"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+		"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+		"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+		"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+		"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+		"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+		"1.3.101.112":           x509.PureEd25519,
+	}
+)
+
+// The ASN.1 structures of OCSP requests and responses from RFC 6960, limited
+// to the parts needed for stapling.
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		Version     int `asn1:"explicit,tag:0,default:0,optional"`
+		RequestList []struct {
+			Cert ocspCertID
+		}
+	}
+}
+
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	} `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          []ocspSingleResponse
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID           ocspCertID
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// newOCSPCertID returns the identifier of the certificate in OCSP requests
+// and responses.
+func newOCSPCertID(leaf, issuer *x509.Certificate) (ocspCertID, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return ocspCertID{}, fmt.Errorf("failed to parse public key of issuer: %v", err)
+	}
+	nameHash := sha1.Sum(issuer.RawSubject)
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+	return ocspCertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidSHA1,
+			Parameters: asn1.RawValue{Tag: asn1.TagNull},
+		},
+		NameHash:      nameHash[:],
+		IssuerKeyHash: keyHash[:],
+		SerialNumber:  leaf.SerialNumber,
+	}, nil
+}
+
+// matches returns true if both identify the same certificate. The hashes are
+// only comparable if they use the same algorithm.
+func (id ocspCertID) matches(other ocspCertID) bool {
+	return id.HashAlgorithm.Algorithm.Equal(other.HashAlgorithm.Algorithm) &&
+		bytes.Equal(id.NameHash, other.NameHash) &&
+		bytes.Equal(id.IssuerKeyHash, other.IssuerKeyHash) &&
+		id.SerialNumber != nil && other.SerialNumber != nil &&
+		id.SerialNumber.Cmp(other.SerialNumber) == 0
+}
+
+// fetchOCSP asks the responder for the status of the certificate. It returns
+// the DER encoded response if the certificate is good, and the time the
+// response expires, which is zero if the responder didn't say.
+func fetchOCSP(responder string, leaf, issuer *x509.Certificate) ([]byte, time.Time, error) {
+	id, err := newOCSPCertID(leaf, issuer)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var req ocspRequest
+	req.TBSRequest.RequestList = append(req.TBSRequest.RequestList, struct{ Cert ocspCertID }{id})
+	body, err := asn1.Marshal(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	httpReq, err := http.NewRequest("POST", responder, bytes.NewReader(body))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpReq.Header.Set("Accept", "application/ocsp-response")
+	resp, err := ocspClient.Do(httpReq)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("OCSP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("OCSP responder returned status %d", resp.StatusCode)
+	}
+	raw, err := ioutil.ReadAll(io.LimitReader(resp.Body, ocspMaxResponseSize))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	nextUpdate, err := verifyOCSPResponse(raw, leaf, issuer, time.Now())
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid OCSP response: %v", err)
+	}
+	return raw, nextUpdate, nil
+}
+
+// verifyOCSPResponse checks that the response is signed by the issuer of the
+// certificate or a responder it delegated to, and that it reports the
+// certificate as good at the given time. It returns when the response
+// expires.
+func verifyOCSPResponse(raw []byte, leaf, issuer *x509.Certificate, now time.Time) (time.Time, error) {
+	id, err := newOCSPCertID(leaf, issuer)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var resp ocspResponse
+	if rest, err := asn1.Unmarshal(raw, &resp); err != nil {
+		return time.Time{}, err
+	} else if len(rest) > 0 {
+		return time.Time{}, fmt.Errorf("trailing data")
+	}
+	if resp.Status != 0 {
+		return time.Time{}, fmt.Errorf("responder returned status %d", resp.Status)
+	}
+	if !resp.ResponseBytes.ResponseType.Equal(oidOCSPBasic) {
+		return time.Time{}, fmt.Errorf("unsupported response type %v", resp.ResponseBytes.ResponseType)
+	}
+
+	var basic ocspBasicResponse
+	if rest, err := asn1.Unmarshal(resp.ResponseBytes.Response, &basic); err != nil {
+		return time.Time{}, err
+	} else if len(rest) > 0 {
+		return time.Time{}, fmt.Errorf("trailing data")
+	}
+
+	// The response is signed by the issuer, or by a certificate the issuer
+	// issued for signing OCSP responses.
+	signer := issuer
+	for _, rawCert := range basic.Certificates {
+		cert, err := x509.ParseCertificate(rawCert.FullBytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if bytes.Equal(cert.Raw, issuer.Raw) {
+			continue
+		}
+		if err := cert.CheckSignatureFrom(issuer); err != nil {
+			return time.Time{}, fmt.Errorf("responder certificate isn't signed by the issuer: %v", err)
+		}
+		delegated := false
+		for _, usage := range cert.ExtKeyUsage {
+			delegated = delegated || usage == x509.ExtKeyUsageOCSPSigning
+		}
+		if !delegated {
+			return time.Time{}, fmt.Errorf("responder certificate can't sign OCSP responses")
+		}
+		signer = cert
+	}
+	alg, ok := ocspSignatureAlg[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unsupported signature algorithm %v", basic.SignatureAlgorithm.Algorithm)
+	}
+	if err := signer.CheckSignature(alg, basic.TBSResponseData.Raw, basic.Signature.RightAlign()); err != nil {
+		return time.Time{}, fmt.Errorf("bad signature: %v", err)
+	}
+
+	for _, single := range basic.TBSResponseData.Responses {
+		// Serial numbers are only unique per issuer, so the issuer must
+		// match too.
+		if !single.CertID.matches(id) {
+			continue
+		}
+		switch {
+		case !bool(single.Good):
+			return time.Time{}, fmt.Errorf("certificate isn't good")
+		case now.Before(single.ThisUpdate.Add(-5 * time.Minute)):
+			return time.Time{}, fmt.Errorf("response isn't valid yet")
+		case !single.NextUpdate.IsZero() && !now.Before(single.NextUpdate):
+			return time.Time{}, fmt.Errorf("response expired")
+		}
+		return single.NextUpdate, nil
+	}
+	return time.Time{}, fmt.Errorf("no response for certificate %s", leaf.SerialNumber)
+}
+
+// loadCACerts parses the certificates in the CAPEM, the CAFile, or the files
+// of the CAPath.
+func loadCACerts(config *Config) ([]*x509.Certificate, error) {
+	var bundles [][]byte
+	if config.CAPEM != "" {
+		bundles = append(bundles, []byte(config.CAPEM))
+	} else if config.CAFile != "" {
+		data, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, data)
+	} else if config.CAPath != "" {
+		infos, err := ioutil.ReadDir(config.CAPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(config.CAPath, info.Name()))
+			if err != nil {
+				return nil, err
+			}
+			bundles = append(bundles, data)
+		}
+	}
+
+	var certs []*x509.Certificate
+	for _, data := range bundles {
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+	}
+	return certs, nil
+}
+
+// ocspTarget returns the certificate served with the given configuration,
+// its issuer and the OCSP responder to ask for its status.
+func ocspTarget(config *Config, cert *tls.Certificate) (*x509.Certificate, *x509.Certificate, string, error) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, nil, "", fmt.Errorf("no certificate")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var issuer *x509.Certificate
+	if len(cert.Certificate) > 1 {
+		if issuer, err = x509.ParseCertificate(cert.Certificate[1]); err != nil {
+			return nil, nil, "", err
+		}
+	} else {
+		cas, err := loadCACerts(config)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		for _, ca := range cas {
+			if bytes.Equal(ca.RawSubject, leaf.RawIssuer) && leaf.CheckSignatureFrom(ca) == nil {
+				issuer = ca
+				break
+			}
+		}
+	}
+	if issuer == nil {
+		return nil, nil, "", fmt.Errorf("issuer of the certificate not found")
+	}
+
+	responder := config.OCSPResponderURL
+	if responder == "" && len(leaf.OCSPServer) > 0 {
+		responder = leaf.OCSPServer[0]
+	}
+	if responder == "" {
+		return nil, nil, "", fmt.Errorf("certificate has no OCSP responder")
+	}
+	return leaf, issuer, responder, nil
+}
+
+// refreshOCSP keeps the OCSP staple up to date until Stop is called. It
+// fetches a new response when the configuration changed, when the
+// refresh interval elapsed, and before the response expires.
+func (c *Configurator) refreshOCSP() {
+	var wait time.Duration
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-c.ocspCh:
+		case <-time.After(wait):
+		}
+		wait = c.fetchOCSPStaple()
+	}
+}
+
+// fetchOCSPStaple fetches the OCSP response for the certificate served with
+// the current configuration and returns how long to wait before the next
+// fetch. The previous staple is kept until it expires if the fetch fails.
+func (c *Configurator) fetchOCSPStaple() time.Duration {
+	c.Lock()
+	base := c.base
+	cert := c.cert
+	logger := c.logger
+	c.Unlock()
+
+	if base == nil || !base.OCSPStapling {
+		return defaultOCSPRefreshInterval
+	}
+	interval := base.OCSPRefreshInterval
+	if interval <= 0 {
+		interval = defaultOCSPRefreshInterval
+	}
+
+	if cert == nil {
+		var err error
+		if cert, err = base.KeyPair(); err != nil {
+			logger.Printf("[WARN] tlsutil: Failed to load the certificate for OCSP stapling: %v", err)
+			return ocspRetryInterval
+		}
+	}
+	leaf, issuer, responder, err := ocspTarget(base, cert)
+	if err != nil {
+		logger.Printf("[WARN] tlsutil: Failed to find the OCSP responder of the certificate: %v", err)
+		return interval
+	}
+	staple, nextUpdate, err := fetchOCSP(responder, leaf, issuer)
+	if err != nil {
+		logger.Printf("[WARN] tlsutil: Failed to fetch the OCSP response from %s: %v", responder, err)
+		return ocspRetryInterval
+	}
+
+	c.Lock()
+	c.ocspLeaf = leaf.Raw
+	c.ocspStaple = staple
+	c.ocspNextUpdate = nextUpdate
+	c.Unlock()
+
+	wait := interval
+	if !nextUpdate.IsZero() {
+		if half := time.Until(nextUpdate) / 2; half < wait {
+			wait = half
+		}
+	}
+	if wait < ocspRetryInterval {
+		wait = ocspRetryInterval
+	}
+	return wait
+}
+
+// stapled returns the certificate with the OCSP staple, if there is a valid
+// one for it.
+func (c *Configurator) stapled(cert *tls.Certificate) *tls.Certificate {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.ocspStaple) == 0 || len(cert.Certificate) == 0 || !bytes.Equal(cert.Certificate[0], c.ocspLeaf) {
+		return cert
+	}
+	if !c.ocspNextUpdate.IsZero() && !time.Now().Before(c.ocspNextUpdate) {
+		return cert
+	}
+	stapled := *cert
+	stapled.OCSPStaple = c.ocspStaple
+	return &stapled
+}
+
+// withOCSPStaple makes a *tls.Config for incoming connections staple the
+// OCSP response fetched by the refresher to the certificate it serves.
+func (c *Configurator) withOCSPStaple(tlsConfig *tls.Config) *tls.Config {
+	getCert := tlsConfig.GetCertificate
+	if getCert == nil {
+		if len(tlsConfig.Certificates) == 0 {
+			return tlsConfig
+		}
+		cert := tlsConfig.Certificates[0]
+		getCert = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &cert, nil
+		}
+	}
+
+	tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, err := getCert(hello)
+		if err != nil || cert == nil {
+			return cert, err
+		}
+		return c.stapled(cert), nil
+	}
+	// See commonTLSConfig.
+	tlsConfig.Certificates = nil
+	return tlsConfig
+}
@@ -0,0 +1,278 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/stretchr/testify/require"
+)
+
+// testOCSPResponse returns an OCSP response for the certificate with the
+// given serial number, signed by the issuer.
+func testOCSPResponse(t *testing.T, signer crypto.Signer, issuer *x509.Certificate, serial *big.Int, good bool, nextUpdate time.Time) []byte {
+	id, err := newOCSPCertID(&x509.Certificate{SerialNumber: serial}, issuer)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	single := ocspSingleResponse{
+		CertID:     id,
+		ThisUpdate: now.Add(-time.Minute),
+		NextUpdate: nextUpdate.UTC().Truncate(time.Second),
+	}
+	if good {
+		single.Good = true
+	} else {
+		single.Revoked = ocspRevokedInfo{RevocationTime: now.Add(-time.Minute)}
+	}
+	data := ocspResponseData{
+		RawResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: issuer.RawSubject},
+		ProducedAt:     now,
+		Responses:      []ocspSingleResponse{single},
+	}
+	data.Raw, err = asn1.Marshal(data)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(data.Raw)
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	basic, err := asn1.Marshal(ocspBasicResponse{
+		TBSResponseData:    data,
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: len(sig) * 8},
+	})
+	require.NoError(t, err)
+
+	var resp ocspResponse
+	resp.ResponseBytes.ResponseType = oidOCSPBasic
+	resp.ResponseBytes.Response = basic
+	raw, err := asn1.Marshal(resp)
+	require.NoError(t, err)
+	return raw
+}
+
+// lockedBuffer is a bytes.Buffer that can be written by the OCSP refresher
+// while the test reads it.
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+// testOCSPResponder starts an OCSP responder for the certificates issued by
+// the CA, which reports them as good unless they are in revoked.
+func testOCSPResponder(t *testing.T, signer crypto.Signer, ca *x509.Certificate, revoked map[string]bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req ocspRequest
+		if _, err := asn1.Unmarshal(body, &req); err != nil || len(req.TBSRequest.RequestList) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		serial := req.TBSRequest.RequestList[0].Cert.SerialNumber
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(testOCSPResponse(t, signer, ca, serial, !revoked[serial.String()], time.Now().Add(time.Hour)))
+	}))
+}
+
+func TestVerifyOCSPResponse(t *testing.T) {
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	caPEM, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	ca, err := parseCert(caPEM)
+	require.NoError(t, err)
+	leaf := &x509.Certificate{SerialNumber: big.NewInt(2)}
+
+	nextUpdate := time.Now().Add(time.Hour)
+	resp := testOCSPResponse(t, signer, ca, leaf.SerialNumber, true, nextUpdate)
+	got, err := verifyOCSPResponse(resp, leaf, ca, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, nextUpdate.UTC().Truncate(time.Second), got.UTC())
+
+	// Expired responses are rejected.
+	_, err = verifyOCSPResponse(resp, leaf, ca, nextUpdate.Add(time.Second))
+	require.Error(t, err)
+
+	// Revoked certificates are rejected.
+	resp = testOCSPResponse(t, signer, ca, leaf.SerialNumber, false, nextUpdate)
+	_, err = verifyOCSPResponse(resp, leaf, ca, time.Now())
+	require.Error(t, err)
+
+	// Responses for other certificates are rejected.
+	resp = testOCSPResponse(t, signer, ca, big.NewInt(3), true, nextUpdate)
+	_, err = verifyOCSPResponse(resp, leaf, ca, time.Now())
+	require.Error(t, err)
+
+	// Responses for a certificate with the same serial number from another
+	// issuer are rejected.
+	otherSigner, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	otherCAPEM, err := GenerateCA(otherSigner, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	otherCA, err := parseCert(otherCAPEM)
+	require.NoError(t, err)
+	resp = testOCSPResponse(t, signer, otherCA, leaf.SerialNumber, true, nextUpdate)
+	_, err = verifyOCSPResponse(resp, leaf, ca, time.Now())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no response for certificate")
+
+	// Responses signed by someone else are rejected.
+	other, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	resp = testOCSPResponse(t, other, ca, leaf.SerialNumber, true, nextUpdate)
+	_, err = verifyOCSPResponse(resp, leaf, ca, time.Now())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "signature")
+
+	_, err = verifyOCSPResponse([]byte("garbage"), leaf, ca, time.Now())
+	require.Error(t, err)
+}
+
+func TestConfigurator_OCSPStapling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	caPEM, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	ca, err := parseCert(caPEM)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := GenerateCert(signer, caPEM, big.NewInt(2), "server", 365, []string{"server.dc1.consul"}, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), []byte(caPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte(certPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte(keyPEM), 0600))
+
+	responder := testOCSPResponder(t, signer, ca, nil)
+	defer responder.Close()
+
+	c := NewConfigurator(&Config{
+		CAFile:           filepath.Join(dir, "ca.pem"),
+		CertFile:         filepath.Join(dir, "cert.pem"),
+		KeyFile:          filepath.Join(dir, "key.pem"),
+		OCSPStapling:     true,
+		OCSPResponderURL: responder.URL,
+	})
+	defer c.Stop()
+
+	tlsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	retry.Run(t, func(r *retry.R) {
+		cert, err := tlsConf.GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(cert.OCSPStaple) == 0 {
+			r.Fatal("no OCSP staple")
+		}
+	})
+
+	// Clients connecting without SNI get the staple too.
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go func() {
+		tlsConn := tls.Server(serverConn, tlsConf)
+		tlsConn.Handshake()
+		tlsConn.Close()
+	}()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	client := tls.Client(clientConn, &tls.Config{
+		RootCAs:    pool,
+		ServerName: "server.dc1.consul",
+	})
+	require.NoError(t, client.Handshake())
+	staple := client.ConnectionState().OCSPResponse
+	require.NotEmpty(t, staple)
+	leaf, err := parseCert(certPEM)
+	require.NoError(t, err)
+	_, err = verifyOCSPResponse(staple, leaf, ca, time.Now())
+	require.NoError(t, err)
+
+	// The HTTPS config without stapling serves the plain certificate.
+	plain := NewConfigurator(&Config{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	})
+	tlsConf, err = plain.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	require.Nil(t, tlsConf.GetCertificate)
+	require.Len(t, tlsConf.Certificates, 1)
+}
+
+func TestConfigurator_OCSPStapling_Revoked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	caPEM, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	ca, err := parseCert(caPEM)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := GenerateCert(signer, caPEM, big.NewInt(2), "server", 365, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), []byte(caPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte(certPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte(keyPEM), 0600))
+
+	responder := testOCSPResponder(t, signer, ca, map[string]bool{"2": true})
+	defer responder.Close()
+
+	c := NewConfigurator(&Config{
+		CAFile:           filepath.Join(dir, "ca.pem"),
+		CertFile:         filepath.Join(dir, "cert.pem"),
+		KeyFile:          filepath.Join(dir, "key.pem"),
+		OCSPStapling:     true,
+		OCSPResponderURL: responder.URL,
+	})
+	defer c.Stop()
+	logs := &lockedBuffer{}
+	c.SetLogger(log.New(logs, "", 0))
+
+	// A response saying the certificate is revoked isn't stapled, and the
+	// failure is logged with the responder.
+	require.Equal(t, ocspRetryInterval, c.fetchOCSPStaple())
+	require.Contains(t, logs.String(), "[WARN] tlsutil: Failed to fetch the OCSP response from "+responder.URL)
+	tlsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+	cert, err := tlsConf.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Empty(t, cert.OCSPStaple)
+}
@@ -0,0 +1,349 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"math/big"
+	"unicode/utf16"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Certificate     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd128BitRC2CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 5}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSHA224 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+)
+
+// pfxPdu is the top level PFX structure from RFC 7292.
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue `asn1:"tag:0,explicit"`
+	Attributes asn1.RawValue `asn1:"optional"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+// pbeParams are the parameters of the PKCS#12 password based encryption
+// schemes.
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// loadCertBundle reads a PKCS#12 bundle and returns the certificate with the
+// chain of the other certificates in the bundle.
+func loadCertBundle(file, passphrase string) (*tls.Certificate, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseCertBundle(data, passphrase)
+}
+
+// parseCertBundle parses a DER encoded PKCS#12 bundle which must contain a
+// single private key and the certificate for it. The other certificates in
+// the bundle are taken as the chain of intermediates.
+func parseCertBundle(data []byte, passphrase string) (*tls.Certificate, error) {
+	var pfx pfxPdu
+	if rest, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#12 bundle: %v", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("failed to parse PKCS#12 bundle: trailing data")
+	}
+	if pfx.Version != 3 {
+		return nil, fmt.Errorf("unsupported PKCS#12 version %d", pfx.Version)
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, errors.New("unsupported PKCS#12 bundle, only password integrity mode is supported")
+	}
+	var authSafe []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#12 bundle: %v", err)
+	}
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) > 0 {
+		if err := verifyPKCS12MAC(&pfx.MacData, authSafe, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	var contents []contentInfo
+	if _, err := asn1.Unmarshal(authSafe, &contents); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#12 bundle: %v", err)
+	}
+	var certs []*x509.Certificate
+	var keys []crypto.PrivateKey
+	for _, ci := range contents {
+		var safeContents []byte
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &safeContents); err != nil {
+				return nil, fmt.Errorf("failed to parse PKCS#12 bundle: %v", err)
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, fmt.Errorf("failed to parse PKCS#12 bundle: %v", err)
+			}
+			var err error
+			eci := ed.EncryptedContentInfo
+			if safeContents, err = decryptPBE(eci.ContentEncryptionAlgorithm, eci.EncryptedContent, passphrase); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported PKCS#12 content type %s", ci.ContentType)
+		}
+
+		var bags []safeBag
+		if _, err := asn1.Unmarshal(safeContents, &bags); err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#12 bundle: %v", err)
+		}
+		for _, bag := range bags {
+			switch {
+			case bag.ID.Equal(oidCertBag):
+				var cb certBag
+				if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil {
+					return nil, fmt.Errorf("failed to parse PKCS#12 certificate: %v", err)
+				}
+				if !cb.ID.Equal(oidX509Certificate) {
+					continue
+				}
+				cert, err := x509.ParseCertificate(cb.Data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse PKCS#12 certificate: %v", err)
+				}
+				certs = append(certs, cert)
+
+			case bag.ID.Equal(oidKeyBag), bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+				der := bag.Value.Bytes
+				if bag.ID.Equal(oidPKCS8ShroudedKeyBag) {
+					var err error
+					if der, err = decryptPKCS8(der, passphrase); err != nil {
+						return nil, err
+					}
+				}
+				key, err := x509.ParsePKCS8PrivateKey(der)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse PKCS#12 private key: %v", err)
+				}
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("PKCS#12 bundle must contain exactly one private key, found %d", len(keys))
+	}
+	signer, ok := keys[0].(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported PKCS#12 private key type")
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return nil, errors.New("unsupported PKCS#12 private key type")
+	}
+
+	cert := &tls.Certificate{PrivateKey: keys[0]}
+	for _, c := range certs {
+		if cert.Leaf == nil && pub.Equal(c.PublicKey) {
+			cert.Leaf = c
+		}
+	}
+	if cert.Leaf == nil {
+		return nil, errors.New("PKCS#12 bundle does not contain the certificate for its private key")
+	}
+	cert.Certificate = append(cert.Certificate, cert.Leaf.Raw)
+	for _, c := range certs {
+		if c != cert.Leaf {
+			cert.Certificate = append(cert.Certificate, c.Raw)
+		}
+	}
+	return cert, nil
+}
+
+// verifyPKCS12MAC checks the integrity of the authenticated safe, which fails
+// if the passphrase is incorrect.
+func verifyPKCS12MAC(md *macData, message []byte, passphrase string) error {
+	newHash, err := pkcs12Hash(md.Mac.Algorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	key := pkcs12KDF(newHash, bmpString(passphrase), md.MacSalt, md.Iterations, 3, newHash().Size())
+	mac := hmac.New(newHash, key)
+	mac.Write(message)
+	if !hmac.Equal(mac.Sum(nil), md.Mac.Digest) {
+		return errIncorrectPassphrase
+	}
+	return nil
+}
+
+// pkcs12Hash returns the hash function for the given digest algorithm.
+func pkcs12Hash(alg asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case alg.Equal(oidSHA1):
+		return sha1.New, nil
+	case alg.Equal(oidSHA224):
+		return sha256.New224, nil
+	case alg.Equal(oidSHA256):
+		return sha256.New, nil
+	case alg.Equal(oidSHA384):
+		return sha512.New384, nil
+	case alg.Equal(oidSHA512):
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported PKCS#12 MAC algorithm %s", alg)
+	}
+}
+
+// pkcs12Cipher returns the cipher and IV for one of the PKCS#12 password
+// based encryption schemes.
+func pkcs12Cipher(alg pkix.AlgorithmIdentifier, passphrase string) (cipher.Block, []byte, error) {
+	var keyLen int
+	switch {
+	case alg.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC):
+		keyLen = 24
+	case alg.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC):
+		keyLen = 16
+	case alg.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+		keyLen = 5
+	default:
+		return nil, nil, fmt.Errorf("unsupported encryption algorithm %s", alg.Algorithm)
+	}
+	var params pbeParams
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse encryption parameters: %v", err)
+	}
+
+	password := bmpString(passphrase)
+	key := pkcs12KDF(sha1.New, password, params.Salt, params.Iterations, 1, keyLen)
+	iv := pkcs12KDF(sha1.New, password, params.Salt, params.Iterations, 2, 8)
+	if keyLen == 24 {
+		block, err := des.NewTripleDESCipher(key)
+		return block, iv, err
+	}
+	return newRC2Cipher(key, keyLen*8), iv, nil
+}
+
+// pkcs12KDF derives size bytes of key material with the key derivation
+// function from appendix B of RFC 7292. The id selects encryption keys (1),
+// IVs (2) or MAC keys (3).
+func pkcs12KDF(newHash func() hash.Hash, password, salt []byte, iterations int, id byte, size int) []byte {
+	h := newHash()
+	v := h.BlockSize()
+
+	d := bytes.Repeat([]byte{id}, v)
+	fill := func(b []byte) []byte {
+		out := make([]byte, v*((len(b)+v-1)/v))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+	var i []byte
+	if len(salt) > 0 {
+		i = append(i, fill(salt)...)
+	}
+	if len(password) > 0 {
+		i = append(i, fill(password)...)
+	}
+
+	one := big.NewInt(1)
+	mod := new(big.Int).Lsh(one, uint(8*v))
+	var out []byte
+	for len(out) < size {
+		h.Reset()
+		h.Write(d)
+		h.Write(i)
+		a := h.Sum(nil)
+		for n := 1; n < iterations; n++ {
+			h.Reset()
+			h.Write(a)
+			a = h.Sum(a[:0])
+		}
+		out = append(out, a...)
+		if len(out) >= size {
+			break
+		}
+
+		// Add B+1 to every block of I, where B is A repeated to v bytes.
+		b := new(big.Int).SetBytes(fill(a)[:v])
+		b.Add(b, one)
+		for j := 0; j < len(i); j += v {
+			ij := new(big.Int).SetBytes(i[j : j+v])
+			ij.Add(ij, b).Mod(ij, mod)
+			ij.FillBytes(i[j : j+v])
+		}
+	}
+	return out[:size]
+}
+
+// bmpString returns the passphrase as a null terminated big endian UTF-16
+// string, which is the password encoding of the PKCS#12 key derivation.
+func bmpString(s string) []byte {
+	var out []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return append(out, 0, 0)
+}
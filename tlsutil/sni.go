@@ -0,0 +1,95 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// SNICertificate is an additional certificate served on incoming HTTPS
+// connections, to clients which ask for one of its names with SNI.
+type SNICertificate struct {
+	CertFile string
+	KeyFile  string
+}
+
+// loadSNICertificates loads the SNICertificates of the given configuration.
+func loadSNICertificates(config *Config) ([]*tls.Certificate, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var certs []*tls.Certificate
+	for _, sni := range config.SNICertificates {
+		cert, err := tls.LoadX509KeyPair(sni.CertFile, sni.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load SNI cert/key pair %q: %v", sni.CertFile, err)
+		}
+		// The leaf is needed to match the names at every handshake.
+		if cert.Leaf == nil {
+			if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+				return nil, fmt.Errorf("Failed to load SNI cert/key pair %q: %v", sni.CertFile, err)
+			}
+		}
+		certs = append(certs, &cert)
+	}
+	return certs, nil
+}
+
+// sniCertificate returns the first SNI certificate which is valid for the
+// server name the client asked for, or nil if there is none.
+func (c *Configurator) sniCertificate(hello *tls.ClientHelloInfo) *tls.Certificate {
+	if hello.ServerName == "" {
+		return nil
+	}
+	c.Lock()
+	certs := c.sniCerts
+	c.Unlock()
+
+	for _, cert := range certs {
+		// This also checks that the client supports the key type.
+		if hello.SupportsCertificate(cert) == nil {
+			return cert
+		}
+	}
+	return nil
+}
+
+// withSNICertificates makes a *tls.Config for incoming connections serve the
+// SNI certificates to clients asking for one of their names, and the
+// certificate it was generated with to all others.
+func (c *Configurator) withSNICertificates(tlsConfig *tls.Config) (*tls.Config, error) {
+	if len(c.base.SNICertificates) == 0 {
+		return tlsConfig, nil
+	}
+
+	// The certificates in use were loaded by NewConfigurator or Update,
+	// which can't report errors.
+	if _, err := loadSNICertificates(c.base); err != nil {
+		return nil, err
+	}
+
+	getCert := tlsConfig.GetCertificate
+	if getCert == nil {
+		var cert *tls.Certificate
+		if len(tlsConfig.Certificates) > 0 {
+			cert = &tlsConfig.Certificates[0]
+		}
+		getCert = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert == nil {
+				return nil, fmt.Errorf("no certificate for server name %q", hello.ServerName)
+			}
+			return cert, nil
+		}
+	}
+
+	tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if cert := c.sniCertificate(hello); cert != nil {
+			return cert, nil
+		}
+		return getCert(hello)
+	}
+	// See commonTLSConfig.
+	tlsConfig.Certificates = nil
+	return tlsConfig, nil
+}
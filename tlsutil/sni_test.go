@@ -0,0 +1,84 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigurator_SNICertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	signer, _, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	caPEM, err := GenerateCA(signer, big.NewInt(1), 365, nil)
+	require.NoError(t, err)
+	writeCert := func(name string, sn int64, dnsName string) {
+		certPEM, keyPEM, err := GenerateCert(signer, caPEM, big.NewInt(sn), name, 365, []string{dnsName}, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".pem"), []byte(certPEM), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+"-key.pem"), []byte(keyPEM), 0600))
+	}
+	writeCert("internal", 2, "consul.internal")
+	writeCert("public", 3, "consul.example.com")
+
+	c := NewConfigurator(&Config{
+		CertFile: filepath.Join(dir, "internal.pem"),
+		KeyFile:  filepath.Join(dir, "internal-key.pem"),
+		SNICertificates: []SNICertificate{{
+			CertFile: filepath.Join(dir, "public.pem"),
+			KeyFile:  filepath.Join(dir, "public-key.pem"),
+		}},
+	})
+	tlsConf, err := c.IncomingHTTPSConfig()
+	require.NoError(t, err)
+
+	served := func(serverName string) string {
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+		go func() {
+			tlsConn := tls.Server(serverConn, tlsConf)
+			tlsConn.Handshake()
+			tlsConn.Close()
+		}()
+		client := tls.Client(clientConn, &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         serverName,
+		})
+		require.NoError(t, client.Handshake())
+		return client.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	require.Equal(t, "public", served("consul.example.com"))
+	require.Equal(t, "internal", served("consul.internal"))
+	require.Equal(t, "internal", served("other.example.com"))
+	require.Equal(t, "internal", served(""))
+
+	// Only the HTTPS config serves them.
+	rpcConf, err := c.IncomingRPCConfig()
+	require.NoError(t, err)
+	require.Nil(t, rpcConf.GetCertificate)
+	require.Len(t, rpcConf.Certificates, 1)
+}
+
+func TestConfigurator_SNICertificates_Invalid(t *testing.T) {
+	c := NewConfigurator(&Config{
+		CertFile: "../test/key/ourdomain.cer",
+		KeyFile:  "../test/key/ourdomain.key",
+		SNICertificates: []SNICertificate{{
+			CertFile: "../test/hostname/Alice.crt",
+			KeyFile:  "../test/key/ourdomain.key",
+		}},
+	})
+	_, err := c.IncomingHTTPSConfig()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Failed to load SNI cert/key pair "../test/hostname/Alice.crt"`)
+}